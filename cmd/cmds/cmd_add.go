@@ -11,6 +11,7 @@ import (
 func NewAddCommand() *cobra.Command {
 	var branchName string
 	var forceOverwrite bool
+	var useExisting bool
 
 	cmd := &cobra.Command{
 		Use:   "add <workspace-name> <repo-name>",
@@ -32,7 +33,11 @@ Examples:
   workspace-manager add my-feature my-new-repo --branch feature/different-branch
 
   # Force overwrite if the branch already exists
-  workspace-manager add my-feature my-new-repo --force`,
+  workspace-manager add my-feature my-new-repo --force
+
+When the branch already exists in the repository, add asks whether to
+overwrite it or use it as-is; without a terminal it fails unless --force or
+--use-existing decides.`,
 		Args: cobra.ExactArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			workspaceName := args[0]
@@ -43,12 +48,21 @@ Examples:
 				return errors.Wrap(err, "failed to create workspace manager")
 			}
 
-			return wm.AddRepositoryToWorkspace(cmd.Context(), workspaceName, repoName, branchName, forceOverwrite)
+			existingBranch := wsm.ExistingBranchAsk
+			switch {
+			case forceOverwrite:
+				existingBranch = wsm.ExistingBranchOverwrite
+			case useExisting:
+				existingBranch = wsm.ExistingBranchUse
+			}
+			return wm.AddRepositoryToWorkspace(cmd.Context(), workspaceName, repoName, branchName, existingBranch)
 		},
 	}
 
 	cmd.Flags().StringVarP(&branchName, "branch", "b", "", "Branch name to use (defaults to workspace's branch)")
 	cmd.Flags().BoolVarP(&forceOverwrite, "force", "f", false, "Force overwrite if branch already exists")
+	cmd.Flags().BoolVar(&useExisting, "use-existing", false, "Use the branch as-is if it already exists")
+	cmd.MarkFlagsMutuallyExclusive("force", "use-existing")
 
 	carapace.Gen(cmd).PositionalCompletion(
 		WorkspaceNameCompletion(),
@@ -0,0 +1,194 @@
+package cmds
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewAliasCommand creates the alias command
+func NewAliasCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "alias",
+		Short: "Manage short names for repositories",
+		Long: `Aliases are short names stored in the registry that can be used wherever a
+repository name is accepted (create --repos, add, remove, diff --repo, apply
+manifests). A repository whose name equals an alias always takes precedence.
+
+Examples:
+  workspace-manager alias add wm workspace-manager
+  workspace-manager create my-feature --repos wm,gp
+  workspace-manager alias list`,
+	}
+
+	cmd.AddCommand(
+		NewAliasAddCommand(),
+		NewAliasRemoveCommand(),
+		NewAliasListCommand(),
+	)
+
+	return cmd
+}
+
+// NewAliasAddCommand creates the alias add command
+func NewAliasAddCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "add <alias> <repository>",
+		Short: "Add or update a repository alias",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAliasAdd(args[0], args[1])
+		},
+	}
+
+	carapace.Gen(cmd).PositionalCompletion(carapace.ActionValues(), RepositoryNameCompletion())
+
+	return cmd
+}
+
+// NewAliasRemoveCommand creates the alias remove command
+func NewAliasRemoveCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "remove <alias>",
+		Short: "Remove a repository alias",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAliasRemove(args[0])
+		},
+	}
+
+	carapace.Gen(cmd).PositionalCompletion(AliasCompletion())
+
+	return cmd
+}
+
+// NewAliasListCommand creates the alias list command
+func NewAliasListCommand() *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List repository aliases",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAliasList(format)
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "table", "Output format: table, json")
+
+	return cmd
+}
+
+func loadDiscoverer() (*wsm.RepositoryDiscoverer, error) {
+	registryPath, err := getRegistryPath()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get registry path")
+	}
+
+	discoverer := wsm.NewRepositoryDiscoverer(registryPath)
+	if err := discoverer.LoadRegistry(); err != nil {
+		return nil, errors.Wrap(err, "failed to load registry")
+	}
+	return discoverer, nil
+}
+
+// resolveRepositoryAlias maps an alias to its repository name for filters that compare names
+// directly; names are returned unchanged when the registry cannot be loaded
+func resolveRepositoryAlias(name string) string {
+	if name == "" {
+		return name
+	}
+	discoverer, err := loadDiscoverer()
+	if err != nil {
+		return name
+	}
+	return discoverer.ResolveAlias(name)
+}
+
+func runAliasAdd(alias, repoName string) error {
+	discoverer, err := loadDiscoverer()
+	if err != nil {
+		return err
+	}
+
+	previous, existed := discoverer.GetAliases()[alias]
+	if err := discoverer.SetAlias(alias, repoName); err != nil {
+		return err
+	}
+	if err := discoverer.SaveRegistry(); err != nil {
+		return errors.Wrap(err, "failed to save registry")
+	}
+
+	if existed && previous != repoName {
+		output.PrintSuccess("Alias '%s' now points to '%s' (was '%s')", alias, repoName, previous)
+	} else {
+		output.PrintSuccess("Alias '%s' -> '%s'", alias, repoName)
+	}
+	return nil
+}
+
+func runAliasRemove(alias string) error {
+	discoverer, err := loadDiscoverer()
+	if err != nil {
+		return err
+	}
+
+	if err := discoverer.RemoveAlias(alias); err != nil {
+		return err
+	}
+	if err := discoverer.SaveRegistry(); err != nil {
+		return errors.Wrap(err, "failed to save registry")
+	}
+
+	output.PrintSuccess("Removed alias '%s'", alias)
+	return nil
+}
+
+func runAliasList(format string) error {
+	discoverer, err := loadDiscoverer()
+	if err != nil {
+		return err
+	}
+
+	aliases := discoverer.GetAliases()
+	if format == "json" {
+		if aliases == nil {
+			aliases = map[string]string{}
+		}
+		return wsm.PrintJSON(aliases)
+	}
+
+	if len(aliases) == 0 {
+		output.PrintInfo("No aliases defined. Add one with 'workspace-manager alias add <alias> <repository>'")
+		return nil
+	}
+
+	names := make([]string, 0, len(aliases))
+	for alias := range aliases {
+		names = append(names, alias)
+	}
+	sort.Strings(names)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ALIAS\tREPOSITORY")
+	fmt.Fprintln(w, "-----\t----------")
+	for _, alias := range names {
+		fmt.Fprintf(w, "%s\t%s\n", alias, aliases[alias])
+	}
+	if err := w.Flush(); err != nil {
+		return errors.Wrap(err, "failed to flush table writer")
+	}
+
+	for _, conflict := range discoverer.AliasConflicts() {
+		output.PrintWarning("%s", conflict)
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package cmds
+
+import (
+	"context"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewApplyCommand creates the apply command
+func NewApplyCommand() *cobra.Command {
+	var (
+		dryRun      bool
+		useExisting bool
+		bootstrap   bootstrapFlags
+		cloneRoot   string
+		ignoreQuota bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "apply <manifest.yaml>",
+		Short: "Create or converge a workspace from a declarative manifest",
+		Long: `Read a workspace manifest and converge the workspace to match it:
+missing worktrees are created, repositories no longer listed are removed,
+pinned repositories are checked out at their ref, and go.work and agent
+files are updated. Post-apply hooks run in the workspace directory whenever
+apply changed something.
+
+Manifest format:
+
+  name: my-feature
+  branch: feature/new-api        # defaults to task/<name>
+  base_branch: origin/main
+  agent_md: ~/templates/AGENT.md
+  agent_assets:                  # replaces agent_assets from config.yaml
+    - source: ~/templates/CLAUDE.md
+      target: CLAUDE.md
+  repos:
+    - name: app
+      remote: git@github.com:acme/app.git   # matches or clones the repository
+    - name: lib
+      branch: feature/lib-api    # per-repository branch
+    - name: shared
+      pin: v1.4.0                # detached at a tag or commit
+  hooks:
+    post_apply:
+      - make setup
+
+Repositories with uncommitted changes are never removed or re-pinned; apply
+fails instead. Branch differences of existing worktrees are reported but not
+changed. A repository added on a branch that already exists in it is only
+checked out on that branch with --use-existing; otherwise apply asks, or fails
+without a terminal.
+
+Repositories with a remote (as written by 'share') are matched against the
+registry by remote URL first, so they may be named differently locally.
+Repositories missing from the registry are cloned into --clone-root and
+discovered.
+
+Examples:
+  # Show what would change
+  workspace-manager apply workspace.yaml --dry-run
+
+  # Converge the workspace
+  workspace-manager apply workspace.yaml
+
+  # Recreate a workspace shared by a teammate, cloning missing repositories
+  workspace-manager apply shared.yaml --clone-root ~/code`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			existingBranch := wsm.ExistingBranchAsk
+			if useExisting {
+				existingBranch = wsm.ExistingBranchUse
+			}
+			return runApply(cmd.Context(), args[0], cloneRoot, dryRun, existingBranch, bootstrap, ignoreQuota)
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show the changes without applying them")
+	cmd.Flags().BoolVar(&useExisting, "use-existing", false, "Check out branches that already exist as they are when adding repositories")
+	bootstrap.register(cmd)
+	cmd.Flags().BoolVar(&ignoreQuota, "ignore-quota", false, "Create the workspace even when the quota (quota.max_workspaces, quota.disk_budget) is exceeded")
+	cmd.Flags().StringVar(&cloneRoot, "clone-root", "", "Clone repositories that are not in the registry into this directory")
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"clone-root": carapace.ActionDirectories(),
+	})
+
+	return cmd
+}
+
+func runApply(ctx context.Context, manifestPath, cloneRoot string, dryRun bool, existingBranch string, bootstrap bootstrapFlags, ignoreQuota bool) error {
+	manifest, err := wsm.LoadManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	wm, err := wsm.NewWorkspaceManager()
+	if err != nil {
+		return errors.Wrap(err, "failed to create workspace manager")
+	}
+	wm.IgnoreQuota = ignoreQuota
+
+	clones, err := wm.ResolveManifestRemotes(ctx, manifest, cloneRoot, dryRun)
+	if err != nil {
+		return err
+	}
+	if dryRun && len(clones) > 0 {
+		output.PrintHeader("Plan for workspace '%s'", manifest.Name)
+		for _, clone := range clones {
+			output.Printf("  + clone %s into %s\n", clone.Remote, clone.Path)
+		}
+		output.PrintInfo("The rest of the plan is computed once the repositories are cloned")
+		return nil
+	}
+
+	plan, err := wm.PlanManifest(ctx, manifest)
+	if err != nil {
+		return errors.Wrap(err, "failed to plan manifest")
+	}
+
+	printManifestPlan(manifest, plan)
+
+	if plan.IsEmpty() {
+		output.PrintSuccess("Workspace '%s' matches %s", manifest.Name, manifestPath)
+		return nil
+	}
+	if dryRun {
+		return nil
+	}
+
+	output.Printf("\n")
+	workspace, err := wm.ApplyManifest(ctx, manifest, plan, existingBranch)
+	if err != nil {
+		return creationError(err, "apply failed")
+	}
+
+	if plan.Create {
+		bootstrapWorkspace(ctx, wm, workspace, bootstrap)
+	}
+
+	output.Printf("\n")
+	output.PrintSuccess("Workspace '%s' converged to %s", workspace.Name, manifestPath)
+	return nil
+}
+
+func printManifestPlan(manifest *wsm.WorkspaceManifest, plan *wsm.ManifestPlan) {
+	output.PrintHeader("Plan for workspace '%s'", manifest.Name)
+
+	if plan.Create {
+		output.Printf("  + create workspace (branch %s)\n", manifest.Branch)
+	}
+	for _, repo := range plan.Add {
+		switch {
+		case repo.Pin != "":
+			output.Printf("  + add %s (pinned at %s)\n", repo.Name, repo.Pin)
+		case repo.Branch != "":
+			output.Printf("  + add %s (branch %s)\n", repo.Name, repo.Branch)
+		default:
+			output.Printf("  + add %s\n", repo.Name)
+		}
+	}
+	for _, name := range plan.Remove {
+		output.Printf("  - remove %s\n", name)
+	}
+	for _, repo := range plan.Repin {
+		output.Printf("  ~ pin %s at %s\n", repo.Name, repo.Pin)
+	}
+	if plan.UpdateAgentFiles {
+		output.Printf("  ~ update agent files\n")
+	}
+	if plan.IsEmpty() {
+		output.Printf("  (no changes)\n")
+	} else {
+		for _, hook := range manifest.Hooks.PostApply {
+			output.Printf("  ! run hook: %s\n", hook)
+		}
+	}
+
+	for _, drift := range plan.Drift {
+		output.PrintWarning("%s (not changed by apply)", drift)
+	}
+}
@@ -0,0 +1,109 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewApplyPRCommand creates the apply-pr command
+func NewApplyPRCommand() *cobra.Command {
+	var (
+		workspaceName string
+		remote        string
+		cherryPick    bool
+		format        string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "apply-pr <repo> <pr-number>",
+		Short: "Merge or cherry-pick a pull request onto the workspace branch of a repository",
+		Long: `Fetch a pull request from the remote of a workspace repository and merge it
+onto the workspace branch, e.g. to test a colleague's in-flight change together
+with your multi-repository feature. With --cherry-pick its commits are
+cherry-picked instead, noting the original commits in their messages.
+
+The pull request is fetched from pull/<n>/head (GitHub, Gitea) or
+merge-requests/<n>/head (GitLab); its title and author are looked up with the
+GitHub CLI when it is installed. What was applied is recorded in the workspace
+and shown by 'info'. Conflicts are left in the worktree to resolve, e.g. with
+'workspace-manager resolve'.
+
+Examples:
+  workspace-manager apply-pr api 1234
+  workspace-manager apply-pr web 87 --cherry-pick --workspace my-feature
+  workspace-manager apply-pr lib 12 --remote upstream`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			number, err := strconv.Atoi(strings.TrimPrefix(args[1], "#"))
+			if err != nil {
+				return errors.Errorf("invalid pull request number '%s'", args[1])
+			}
+			method := wsm.PRApplyMerge
+			if cherryPick {
+				method = wsm.PRApplyCherryPick
+			}
+			return runApplyPR(cmd.Context(), workspaceName, args[0], number, wsm.ApplyPROptions{Remote: remote, Method: method}, format)
+		},
+	}
+
+	cmd.Flags().StringVar(&workspaceName, "workspace", "", "Workspace name (default: detected from the current directory)")
+	cmd.Flags().StringVar(&remote, "remote", "origin", "Remote to fetch the pull request from")
+	cmd.Flags().BoolVar(&cherryPick, "cherry-pick", false, "Cherry-pick the commits of the pull request instead of merging it")
+	cmd.Flags().StringVar(&format, "format", "text", "Output format: text, json")
+
+	carapace.Gen(cmd).PositionalCompletion(WorkspaceRepositoryCompletion())
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"workspace": WorkspaceNameCompletion(),
+		"format":    carapace.ActionValues("text", "json"),
+	})
+
+	return cmd
+}
+
+func runApplyPR(ctx context.Context, workspaceName, repoName string, number int, opts wsm.ApplyPROptions, format string) error {
+	wm, workspace, err := resolveManagedWorkspace(workspaceName)
+	if err != nil {
+		return err
+	}
+
+	stop := output.Spinner(os.Stderr, fmt.Sprintf("Fetching pull request #%d of %s", number, repoName))
+	applied, err := wm.ApplyPullRequest(ctx, workspace, repoName, number, opts)
+	stop()
+	if err != nil {
+		return err
+	}
+
+	if format == "json" {
+		return wsm.PrintJSON(applied)
+	}
+
+	verb := "Merged"
+	if applied.Method == wsm.PRApplyCherryPick {
+		verb = "Cherry-picked"
+	}
+	if len(applied.Conflicts) > 0 {
+		output.PrintWarning("Applying pull request %s to %s left conflicts:", applied, applied.Repository)
+		for _, file := range applied.Conflicts {
+			fmt.Printf("  %s\n", file)
+		}
+		output.PrintInfo("Resolve them and commit, e.g. with 'workspace-manager resolve --repos %s'", applied.Repository)
+		return nil
+	}
+	output.PrintSuccess("%s pull request %s into %s (%d commits, %s)", verb, applied, applied.Repository, applied.Commits, shortHash(applied.Head))
+	if applied.Author != "" {
+		output.PrintInfo("Author: %s", applied.Author)
+	}
+	if applied.URL != "" {
+		output.PrintInfo("%s", applied.URL)
+	}
+	return nil
+}
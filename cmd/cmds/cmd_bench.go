@@ -0,0 +1,206 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewBenchCommand creates the bench command
+func NewBenchCommand() *cobra.Command {
+	var (
+		phases []string
+		runs   int
+		paths  []string
+		format string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "bench [workspace-name]",
+		Short: "Measure discovery, status, worktree creation and sync on this machine",
+		Long: `Time the main workspace operations, repository by repository, to find
+pathological repositories and check performance changes:
+
+  discovery  scanning each discovery path (or --path) for repositories
+  status     the status of each repository of the workspace
+  worktree   adding a worktree of each source repository
+  sync       fetching origin in each repository
+
+Each phase runs --runs times and the median, fastest and slowest runs are
+reported; repositories far slower than the others of a phase are flagged.
+Nothing is changed: worktrees are created in a temporary directory and removed,
+and sync fetches with --dry-run. Without a workspace only discovery runs.
+
+Examples:
+  # Benchmark the current workspace
+  workspace-manager bench
+
+  # Only status and sync, five runs, as JSON
+  workspace-manager bench my-feature --phases status,sync --runs 5 --format json`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspaceName := ""
+			if len(args) > 0 {
+				workspaceName = args[0]
+			}
+			return runBench(cmd.Context(), workspaceName, phases, runs, paths, format)
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&phases, "phases", wsm.BenchPhases, "Phases to measure: discovery, status, worktree, sync")
+	cmd.Flags().IntVar(&runs, "runs", 3, "Number of runs of each phase")
+	cmd.Flags().StringSliceVar(&paths, "path", nil, "Directories scanned by the discovery phase (default: discovery_paths from config)")
+	cmd.Flags().StringVar(&format, "format", "table", "Output format: table, json")
+
+	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion())
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"phases": carapace.ActionValues(wsm.BenchPhases...).UniqueList(","),
+		"path":   carapace.ActionDirectories(),
+	})
+
+	return cmd
+}
+
+func runBench(ctx context.Context, workspaceName string, phases []string, runs int, paths []string, format string) error {
+	if runs < 1 {
+		return errors.New("--runs must be at least 1")
+	}
+
+	config, err := wsm.LoadConfig()
+	if err != nil {
+		return errors.Wrap(err, "failed to load configuration")
+	}
+
+	// Only discovery runs outside a workspace
+	var workspace *wsm.Workspace
+	if workspaceName != "" {
+		if workspace, err = loadWorkspace(workspaceName); err != nil {
+			return err
+		}
+	} else if cwd, err := os.Getwd(); err == nil {
+		if name, err := detectWorkspace(cwd); err == nil {
+			if workspace, err = loadWorkspace(name); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(paths) == 0 {
+		paths = config.DiscoveryPaths
+	}
+	var roots []string
+	for _, path := range paths {
+		expanded, err := wsm.ExpandPath(path)
+		if err != nil {
+			return err
+		}
+		roots = append(roots, expanded)
+	}
+
+	checker := wsm.NewStatusChecker()
+	checker.Config = config.Status
+	bench := &wsm.Benchmark{
+		Workspace:      workspace,
+		Checker:        checker,
+		Discovery:      config.Discovery,
+		DiscoveryPaths: roots,
+		Runs:           runs,
+	}
+	if output.IsTerminal(os.Stderr) && !output.IsCI() && !output.IsQuiet() && format != "json" {
+		bench.Progress = func(phase, target string, run int) {
+			fmt.Fprintf(os.Stderr, "\r\033[K%s", output.DimStyle.Render(fmt.Sprintf("%s %s (run %d/%d)", phase, target, run, runs)))
+		}
+	}
+
+	report, err := bench.Run(ctx, phases)
+	if bench.Progress != nil {
+		fmt.Fprint(os.Stderr, "\r\033[K")
+	}
+	if err != nil {
+		return errors.Wrap(err, "benchmark failed")
+	}
+
+	if format == "json" {
+		return wsm.PrintJSON(report)
+	}
+
+	target := "no workspace"
+	if report.Workspace != "" {
+		target = "workspace '" + report.Workspace + "'"
+	}
+	output.PrintHeader("Benchmark: %s, %d runs", target, report.Runs)
+	fmt.Printf("%s/%s, %d CPUs, %s\n", report.Machine.OS, report.Machine.Arch, report.Machine.CPUs, orDash(report.Machine.Git))
+
+	slow := 0
+	for _, phase := range report.Phases {
+		fmt.Println()
+		if phase.Skipped != "" {
+			fmt.Printf("%s  %s\n", output.InfoStyle.Render(phase.Name), output.DimStyle.Render("skipped: "+phase.Skipped))
+			continue
+		}
+		fmt.Printf("%s  %s\n", output.InfoStyle.Render(phase.Name), output.DimStyle.Render("median "+formatBenchDuration(phase.Median())))
+		printBenchSamples(phase.Samples)
+		for _, sample := range phase.Samples {
+			if sample.Slow {
+				slow++
+			}
+		}
+	}
+
+	if slow > 0 {
+		fmt.Println()
+		output.PrintWarning("%d measurements are far slower than the rest of their phase (marked ⚠)", slow)
+	}
+	return nil
+}
+
+func printBenchSamples(samples []wsm.BenchSample) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer func() {
+		if err := w.Flush(); err != nil {
+			output.LogWarn(
+				fmt.Sprintf("Failed to flush table writer: %v", err),
+				"Failed to flush table writer",
+				"error", err,
+			)
+		}
+	}()
+
+	fmt.Fprintln(w, "  TARGET\tMEDIAN\tMIN\tMAX\t")
+	for _, sample := range samples {
+		if len(sample.Runs) == 0 {
+			fmt.Fprintf(w, "  %s\t-\t-\t-\t%s\n", sample.Target, output.ErrorStyle.Render(firstLine(sample.Error)))
+			continue
+		}
+		note := ""
+		if sample.Slow {
+			note = output.WarningStyle.Render("⚠ slow")
+		}
+		if sample.Error != "" {
+			note = strings.TrimSpace(note + " " + output.ErrorStyle.Render(firstLine(sample.Error)))
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n", sample.Target,
+			formatBenchDuration(sample.Median()), formatBenchDuration(sample.Min()), formatBenchDuration(sample.Max()), note)
+	}
+}
+
+func formatBenchDuration(d time.Duration) string {
+	if d < time.Millisecond {
+		return d.Round(time.Microsecond).String()
+	}
+	return d.Round(100 * time.Microsecond).String()
+}
+
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(text, "\n")
+	return line
+}
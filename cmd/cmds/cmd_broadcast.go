@@ -0,0 +1,163 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/charmbracelet/huh"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewBroadcastCommand creates the broadcast command
+func NewBroadcastCommand() *cobra.Command {
+	var (
+		opts   wsm.BroadcastOptions
+		stage  bool
+		update bool
+		yes    bool
+		dryRun bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "broadcast <file> [workspace-name]",
+		Short: "Copy a file into every repository of a workspace",
+		Long: `Copy a file, such as a CI configuration, a lint configuration or CODEOWNERS,
+into every repository worktree of the workspace.
+
+The file is written to --dest inside each repository (default: the file name at
+the repository root). With --template the file is rendered as a Go template
+with the same context as AGENT.md, scoped to each repository ({{ .Repository.Name }}).
+
+Files that already exist with different content are skipped unless --update is
+given; --update shows the diff of each file and asks before overwriting it
+(use --yes to overwrite without asking).
+
+Examples:
+  # Add the same golangci config to every repository and stage it
+  workspace-manager broadcast ~/templates/.golangci.yml --stage
+
+  # Update the CI workflow, reviewing each diff
+  workspace-manager broadcast ci.yml --dest .github/workflows/ci.yml --update
+
+  # Render CODEOWNERS per repository
+  workspace-manager broadcast CODEOWNERS.tmpl --dest .github/CODEOWNERS --template`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Source = args[0]
+			workspaceName := ""
+			if len(args) > 1 {
+				workspaceName = args[1]
+			}
+			return runBroadcast(cmd.Context(), workspaceName, opts, stage, update, yes, dryRun)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Dest, "dest", "", "Path of the file inside each repository (default: the file name)")
+	cmd.Flags().BoolVar(&opts.Template, "template", false, "Render the file as a Go template with the workspace context")
+	cmd.Flags().StringSliceVar(&opts.Repositories, "repos", nil, "Only write to these repositories (comma-separated)")
+	cmd.Flags().BoolVar(&stage, "stage", false, "Stage the written files with git add")
+	cmd.Flags().BoolVar(&update, "update", false, "Overwrite existing files that differ, after showing the diff")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Overwrite without asking in --update mode")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be written without writing")
+
+	carapace.Gen(cmd).PositionalCompletion(carapace.ActionFiles(), WorkspaceNameCompletion())
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"repos": WorkspaceRepositoryCompletion().UniqueList(","),
+	})
+
+	return cmd
+}
+
+func runBroadcast(ctx context.Context, workspaceName string, opts wsm.BroadcastOptions, stage, update, yes, dryRun bool) error {
+	workspace, err := resolveWorkspace(workspaceName)
+	if err != nil {
+		return err
+	}
+
+	targets, err := wsm.PlanBroadcast(workspace, opts)
+	if err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		return errors.New("no matching repositories in the workspace")
+	}
+
+	if update && !yes && !dryRun {
+		if err := output.RequireInteractive("confirm overwriting files", "use --yes to overwrite without confirmation"); err != nil {
+			return err
+		}
+	}
+
+	output.PrintHeader("Broadcasting %s to workspace '%s'", opts.Source, workspace.Name)
+	written, skipped := 0, 0
+	for _, target := range targets {
+		switch target.State {
+		case wsm.BroadcastUnchanged:
+			output.PrintInfo("%s: %s is up to date", target.Repository, target.Path)
+			continue
+		case wsm.BroadcastUpdate:
+			if !update {
+				skipped++
+				output.PrintWarning("%s: %s already exists with different content, use --update to overwrite it", target.Repository, target.Path)
+				continue
+			}
+			diff, err := wsm.BroadcastDiff(ctx, target, output.IsTerminal(os.Stdout))
+			if err != nil {
+				return errors.Wrapf(err, "failed to diff %s", target.Path)
+			}
+			fmt.Print(diff)
+			if !yes && !dryRun {
+				confirmed, err := confirmBroadcast(target)
+				if err != nil {
+					return err
+				}
+				if !confirmed {
+					skipped++
+					continue
+				}
+			}
+		}
+
+		if dryRun {
+			output.Printf("  %s %s\n", output.InfoStyle.Render(target.State), target.Path)
+			continue
+		}
+		if err := wsm.WriteBroadcastTarget(ctx, workspace, target, stage); err != nil {
+			return err
+		}
+		written++
+		output.PrintSuccess("%s: %s %s", target.Repository, target.State+"d", target.Path)
+	}
+
+	if dryRun {
+		output.PrintInfo("Dry run mode - no files were written")
+		return nil
+	}
+
+	output.Printf("\n")
+	output.PrintSuccess("Wrote %s to %d repositories (%d skipped)", opts.Source, written, skipped)
+	return nil
+}
+
+func confirmBroadcast(target wsm.BroadcastTarget) (bool, error) {
+	var confirmed bool
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewConfirm().
+				Title(fmt.Sprintf("Overwrite %s in '%s'?", target.Path, target.Repository)).
+				Value(&confirmed),
+		),
+	)
+	if err := form.Run(); err != nil {
+		if isFormAborted(err) {
+			return false, errors.New("broadcast cancelled")
+		}
+		return false, errors.Wrap(err, "confirmation failed")
+	}
+	return confirmed, nil
+}
@@ -0,0 +1,111 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/spf13/cobra"
+)
+
+// NewChangesCommand creates the changes command
+func NewChangesCommand() *cobra.Command {
+	var (
+		summary bool
+		format  string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "changes [workspace-name]",
+		Short: "Show what the workspace changed since its branch points",
+		Long: `List the commits and diffstat of every repository since the commit its worktree
+branched off. Branch points are recorded when a workspace is created or a
+repository is added, so the view does not grow when the base branch moves on;
+the number of commits added upstream since then is shown as well.
+
+For workspaces created before branch points were recorded, the merge-base with
+the base branch is used instead (marked "not recorded").
+
+Examples:
+  # Changes of the current workspace
+  workspace-manager changes
+
+  # Only the per-repository totals
+  workspace-manager changes my-feature --summary`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspaceName := ""
+			if len(args) > 0 {
+				workspaceName = args[0]
+			}
+			return runChanges(cmd.Context(), workspaceName, summary, format)
+		},
+	}
+
+	cmd.Flags().BoolVar(&summary, "summary", false, "Only show per-repository totals, not the commits")
+	cmd.Flags().StringVar(&format, "format", "text", "Output format: text, json")
+
+	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion())
+
+	return cmd
+}
+
+func runChanges(ctx context.Context, workspaceName string, summary bool, format string) error {
+	workspace, err := resolveWorkspace(workspaceName)
+	if err != nil {
+		return err
+	}
+
+	changes := wsm.GetWorkspaceChanges(ctx, workspace)
+
+	if format == "json" {
+		return wsm.PrintJSON(changes)
+	}
+
+	defer output.StartPager()()
+
+	output.PrintHeader("Changes in workspace '%s'", workspace.Name)
+
+	var commits, files, insertions, deletions int
+	for _, repo := range changes {
+		fmt.Println()
+		if repo.Error != "" {
+			output.PrintError("%s: %s", repo.Repository, repo.Error)
+			continue
+		}
+
+		origin := fmt.Sprintf("branched at %s", shortHash(repo.BranchPoint))
+		if !repo.Recorded {
+			origin += " (not recorded)"
+		}
+		if repo.UpstreamCommits > 0 {
+			origin += fmt.Sprintf(", %s moved %d commits since", repo.Base, repo.UpstreamCommits)
+		}
+		fmt.Printf("%s  %s\n", output.InfoStyle.Render(repo.Repository), output.DimStyle.Render(origin))
+		fmt.Printf("  %d commits, %d files changed, +%d -%d\n", len(repo.Commits), repo.FilesChanged, repo.Insertions, repo.Deletions)
+
+		if !summary {
+			for _, commit := range repo.Commits {
+				fmt.Printf("    %s %s\n", output.DimStyle.Render(commit.Hash), commit.Subject)
+			}
+		}
+
+		commits += len(repo.Commits)
+		files += repo.FilesChanged
+		insertions += repo.Insertions
+		deletions += repo.Deletions
+	}
+
+	fmt.Println()
+	fmt.Printf("Total: %d commits, %d files changed, +%d -%d across %d repositories\n", commits, files, insertions, deletions, len(changes))
+	return nil
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
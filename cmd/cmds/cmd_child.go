@@ -0,0 +1,189 @@
+package cmds
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewChildCommand creates the child command
+func NewChildCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "child",
+		Short: "Compose workspaces from other workspaces",
+		Long: `Include workspaces as components (children) of a larger workspace, e.g. an
+integration workspace composed from several feature workspaces.
+
+A child is linked into its parent directory under its own name, so its
+repositories appear as <child>/<repo>. 'status' and 'sync' on the parent
+cover the repositories of all nested workspaces, and the parent's go.work
+includes their Go modules.
+
+A workspace has at most one parent. A child cannot be deleted while it is
+owned by a parent; deleting a parent detaches its children and leaves them
+in place.
+
+Examples:
+  workspace-manager child add integration feature-a
+  workspace-manager child add integration feature-b
+  workspace-manager child list integration
+  workspace-manager child remove integration feature-b`,
+	}
+
+	cmd.AddCommand(
+		NewChildAddCommand(),
+		NewChildRemoveCommand(),
+		NewChildListCommand(),
+	)
+
+	return cmd
+}
+
+// NewChildAddCommand creates the child add command
+func NewChildAddCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "add <parent-workspace> <child-workspace>",
+		Short: "Include a workspace in another one",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wm, err := wsm.NewWorkspaceManager()
+			if err != nil {
+				return errors.Wrap(err, "failed to create workspace manager")
+			}
+			if err := wm.AddChildWorkspace(args[0], args[1]); err != nil {
+				return err
+			}
+			output.PrintSuccess("Workspace '%s' is now part of '%s'", args[1], args[0])
+			return nil
+		},
+	}
+
+	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion(), WorkspaceNameCompletion())
+
+	return cmd
+}
+
+// NewChildRemoveCommand creates the child remove command
+func NewChildRemoveCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "remove <parent-workspace> <child-workspace>",
+		Short: "Detach a child workspace from its parent",
+		Long: `Detach a child workspace from its parent. The child workspace and its
+worktrees are left untouched; only the link in the parent directory is removed.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wm, err := wsm.NewWorkspaceManager()
+			if err != nil {
+				return errors.Wrap(err, "failed to create workspace manager")
+			}
+			if err := wm.RemoveChildWorkspace(args[0], args[1]); err != nil {
+				return err
+			}
+			output.PrintSuccess("Detached workspace '%s' from '%s'", args[1], args[0])
+			return nil
+		},
+	}
+
+	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion(), ChildWorkspaceCompletion())
+
+	return cmd
+}
+
+// NewChildListCommand creates the child list command
+func NewChildListCommand() *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "list [workspace-name]",
+		Short: "Show the workspaces nested in a workspace",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspaceName := ""
+			if len(args) > 0 {
+				workspaceName = args[0]
+			}
+			return runChildList(workspaceName, format)
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "tree", "Output format: tree, json")
+
+	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion())
+	carapace.Gen(cmd).FlagCompletion(
+		carapace.ActionMap{
+			"format": carapace.ActionValues("tree", "json"),
+		},
+	)
+
+	return cmd
+}
+
+// workspaceNode is a workspace and the workspaces nested in it
+type workspaceNode struct {
+	Name         string          `json:"name"`
+	Path         string          `json:"path"`
+	Repositories []string        `json:"repositories"`
+	Children     []workspaceNode `json:"children,omitempty"`
+	Missing      bool            `json:"missing,omitempty"`
+}
+
+func runChildList(workspaceName, format string) error {
+	workspace, err := resolveWorkspace(workspaceName)
+	if err != nil {
+		return err
+	}
+
+	workspaces, err := wsm.LoadWorkspaces()
+	if err != nil {
+		return errors.Wrap(err, "failed to load workspaces")
+	}
+	byName := make(map[string]*wsm.Workspace, len(workspaces))
+	for i := range workspaces {
+		byName[workspaces[i].Name] = &workspaces[i]
+	}
+
+	root := buildWorkspaceNode(workspace, byName, map[string]bool{})
+
+	if format == "json" {
+		return wsm.PrintJSON(root)
+	}
+
+	if workspace.Parent != "" {
+		output.PrintInfo("Workspace '%s' is a child of '%s'", workspace.Name, workspace.Parent)
+	}
+	printWorkspaceNode(root, "")
+	return nil
+}
+
+func buildWorkspaceNode(workspace *wsm.Workspace, workspaces map[string]*wsm.Workspace, visited map[string]bool) workspaceNode {
+	visited[workspace.Name] = true
+	node := workspaceNode{Name: workspace.Name, Path: workspace.Path}
+	for _, repo := range workspace.Repositories {
+		node.Repositories = append(node.Repositories, repo.Name)
+	}
+	for _, childName := range workspace.Children {
+		child, ok := workspaces[childName]
+		if !ok || visited[childName] {
+			node.Children = append(node.Children, workspaceNode{Name: childName, Missing: !ok})
+			continue
+		}
+		node.Children = append(node.Children, buildWorkspaceNode(child, workspaces, visited))
+	}
+	return node
+}
+
+func printWorkspaceNode(node workspaceNode, indent string) {
+	if node.Missing {
+		fmt.Printf("%s%s %s\n", indent, node.Name, output.WarningStyle.Render("(missing)"))
+		return
+	}
+	fmt.Printf("%s%s %s\n", indent, output.InfoStyle.Render(node.Name), output.DimStyle.Render(strings.Join(node.Repositories, ", ")))
+	for _, child := range node.Children {
+		printWorkspaceNode(child, indent+"  ")
+	}
+}
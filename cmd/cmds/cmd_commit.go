@@ -20,15 +20,71 @@ func NewCommitCommand() *cobra.Command {
 		push        bool
 		dryRun      bool
 		template    string
+		summary     bool
+		enforce     bool
+		noTrailers  bool
+		noIgnore    bool
 	)
 
 	cmd := &cobra.Command{
 		Use:   "commit",
 		Short: "Commit changes across workspace repositories",
 		Long: `Commit related changes across multiple repositories in the workspace.
-Supports interactive file selection and consistent commit messaging.`,
+Supports interactive file selection and consistent commit messaging.
+
+Commits are signed according to the signing policy in config.yaml:
+
+  signing:
+    mode: require        # off, auto (sign when a key is available) or require
+    format: ssh          # gpg, ssh or x509 (default: gpg.format)
+    key: ~/.ssh/id_ed25519.pub
+    repositories:
+      scratch:
+        mode: off
+
+With 'require', nothing is committed unless every repository has a usable
+signing key.
+
+Tickets found in the workspace branch (feature/PROJ-123-export) and linked
+issues are appended to the message as trailers, once per ticket:
+
+  issues:
+    trailers:
+      enabled: true                  # default
+      pattern: '(?:ENG|OPS)-[0-9]+'  # default: Jira keys
+      format: 'Refs: {ticket}'       # default
+
+With --template, a predefined template (feature, fix, docs, style, refactor,
+test, chore) gives a generic message such as "fix: resolve issue"; with
+--ticket-summary, it uses the summary of the first linked issue instead, e.g.
+"fix: Export invoices as CSV", once it is known (see 'link refresh'). Other
+templates may use {ticket} and {summary}:
+
+  workspace-manager commit --template fix --ticket-summary
+  workspace-manager commit --template "{ticket}: {summary}"
+
+When the workspace has a .wsm/ownership.yaml, changes to files owned by areas
+outside the workspace scope are reported before committing, and block the
+commit with --enforce (or enforce: true in the file):
+
+  rules:
+    - area: billing
+      owners: ["@payments"]
+      paths: ["api/internal/billing/**", "web/src/billing/**"]
+    - area: ci
+      owners: ["@platform"]
+      paths: ["*/.github/**"]
+      protected: true    # checked even when the workspace declares no scope
+  scope: [billing]       # areas this workspace works on
+  tickets:
+    PROJ-123: [billing]  # areas of linked tickets (see 'wsm link issue')
+
+Untracked files matched by the workspace's .wsm/ignore (gitignore syntax,
+paths relative to the workspace root such as api/internal/gen/) are hidden and
+not committed, unless --no-ignore is given; --add-all still stages everything.
+Changes to tracked files are always shown.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runCommit(cmd.Context(), message, interactive, addAll, push, dryRun, template)
+			return runCommit(cmd.Context(), message, interactive, addAll, push, dryRun, template, summary, enforce, noTrailers, noIgnore)
 		},
 	}
 
@@ -37,12 +93,16 @@ Supports interactive file selection and consistent commit messaging.`,
 	cmd.Flags().BoolVar(&addAll, "add-all", false, "Add all changes")
 	cmd.Flags().BoolVar(&push, "push", false, "Push changes after commit")
 	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be committed")
-	cmd.Flags().StringVar(&template, "template", "", "Use commit message template")
+	cmd.Flags().StringVar(&template, "template", "", "Use commit message template: feature, fix, docs, style, refactor, test, chore, or a message with {ticket} and {summary}")
+	cmd.Flags().BoolVar(&summary, "ticket-summary", false, "Use the summary of the first linked issue in the predefined --template messages")
+	cmd.Flags().BoolVar(&noTrailers, "no-trailers", false, "Do not append ticket trailers (Refs: PROJ-123) to the commit message")
+	cmd.Flags().BoolVar(&enforce, "enforce", false, "Refuse to commit files outside the workspace scope declared in .wsm/ownership.yaml")
+	cmd.Flags().BoolVar(&noIgnore, "no-ignore", false, "Include untracked files matched by .wsm/ignore")
 
 	return cmd
 }
 
-func runCommit(ctx context.Context, message string, interactive, addAll, push, dryRun bool, template string) error {
+func runCommit(ctx context.Context, message string, interactive, addAll, push, dryRun bool, template string, summary, enforce, noTrailers, noIgnore bool) error {
 	// Detect current workspace
 	workspace, err := detectCurrentWorkspace()
 	if err != nil {
@@ -51,12 +111,14 @@ func runCommit(ctx context.Context, message string, interactive, addAll, push, d
 
 	// Initialize git operations
 	gitOps := wsm.NewGitOperations(workspace)
+	gitOps.IncludeIgnored = noIgnore
 
 	// Get all changes in workspace
 	allChanges, err := gitOps.GetWorkspaceChanges(ctx)
 	if err != nil {
 		return errors.Wrap(err, "failed to get workspace changes")
 	}
+	printHiddenChanges(gitOps)
 
 	if len(allChanges) == 0 {
 		output.PrintInfo("No changes found in workspace")
@@ -65,7 +127,7 @@ func runCommit(ctx context.Context, message string, interactive, addAll, push, d
 
 	// Handle commit message
 	if message == "" && template != "" {
-		message = getCommitMessageFromTemplate(template)
+		message = getCommitMessageFromTemplate(template, workspace.Issues, summary)
 	}
 
 	if message == "" && !interactive {
@@ -88,6 +150,24 @@ func runCommit(ctx context.Context, message string, interactive, addAll, push, d
 		return nil
 	}
 
+	if err := checkOwnership(workspace, selectedChanges, enforce); err != nil {
+		return err
+	}
+
+	config, err := wsm.LoadConfig()
+	if err != nil {
+		return errors.Wrap(err, "failed to load configuration")
+	}
+	gitOps.Timeouts = config.Timeouts
+
+	if !noTrailers && config.Issues.Trailers.IsEnabled() {
+		tickets, err := wsm.WorkspaceTickets(workspace, config.Issues.Trailers)
+		if err != nil {
+			return err
+		}
+		message = wsm.AppendTrailers(message, wsm.TicketTrailers(tickets, config.Issues.Trailers))
+	}
+
 	// Create commit operation
 	operation := &wsm.CommitOperation{
 		Message: message,
@@ -95,6 +175,7 @@ func runCommit(ctx context.Context, message string, interactive, addAll, push, d
 		DryRun:  dryRun,
 		AddAll:  addAll,
 		Push:    push,
+		Signing: config.Signing,
 	}
 
 	// Execute commit
@@ -112,6 +193,13 @@ func runCommit(ctx context.Context, message string, interactive, addAll, push, d
 	return nil
 }
 
+// printHiddenChanges tells how many untracked files were left out because they are ignored
+func printHiddenChanges(gitOps *wsm.GitOperations) {
+	if hidden := gitOps.HiddenChanges(); hidden > 0 {
+		output.PrintInfo("%d ignored untracked files hidden (.wsm/ignore); use --no-ignore to include them", hidden)
+	}
+}
+
 // detectCurrentWorkspace detects the current workspace
 func detectCurrentWorkspace() (*wsm.Workspace, error) {
 	cwd, err := os.Getwd()
@@ -119,19 +207,16 @@ func detectCurrentWorkspace() (*wsm.Workspace, error) {
 		return nil, errors.Wrap(err, "failed to get current directory")
 	}
 
-	// Try to find workspace by checking if we're in a workspace directory
 	workspaces, err := wsm.LoadWorkspaces()
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to load workspaces")
 	}
 
-	for _, workspace := range workspaces {
-		if strings.HasPrefix(cwd, workspace.Path) {
-			return &workspace, nil
-		}
+	match, err := wsm.DetectWorkspace(context.Background(), nil, cwd, workspaces)
+	if err != nil {
+		return nil, errors.New("not in a workspace directory. Run command from within a workspace")
 	}
-
-	return nil, errors.New("not in a workspace directory. Run command from within a workspace")
+	return match.Workspace, nil
 }
 
 // selectChangesInteractively allows user to select files interactively
@@ -178,8 +263,10 @@ func selectChangesInteractively(allChanges map[string][]wsm.FileChange, initialM
 	return allChanges, message, nil
 }
 
-// getCommitMessageFromTemplate gets commit message from template
-func getCommitMessageFromTemplate(template string) string {
+// getCommitMessageFromTemplate gets commit message from template. With summary, the predefined
+// templates use the summary of the first linked issue when it is known; others may use {ticket}
+// and {summary}.
+func getCommitMessageFromTemplate(template string, issues []wsm.IssueLink, summary bool) string {
 	templates := map[string]string{
 		"feature":  "feat: add new feature",
 		"fix":      "fix: resolve issue",
@@ -191,8 +278,47 @@ func getCommitMessageFromTemplate(template string) string {
 	}
 
 	if msg, exists := templates[template]; exists {
+		if summary && len(issues) > 0 && issues[0].Summary != "" {
+			kind, _, _ := strings.Cut(msg, ":")
+			return kind + ": " + issues[0].Summary
+		}
 		return msg
 	}
 
-	return template // Use template as-is if not found in predefined templates
+	return wsm.ExpandIssueTemplate(template, issues) // Use template as-is if not found in predefined templates
+}
+
+// checkOwnership reports the selected changes that fall outside the scope of the workspace, and
+// fails when the ownership rules are enforced
+func checkOwnership(workspace *wsm.Workspace, changes map[string][]wsm.FileChange, enforce bool) error {
+	ownership, err := wsm.LoadOwnership(workspace)
+	if err != nil {
+		return err
+	}
+	if ownership == nil {
+		return nil
+	}
+	violations := ownership.CheckChanges(workspace, changes)
+	if len(violations) == 0 {
+		return nil
+	}
+
+	scope := strings.Join(ownership.WorkspaceScope(workspace), ", ")
+	output.PrintWarning("%d files are outside the workspace scope (%s):", len(violations), orDash(scope))
+	for _, violation := range violations {
+		owners := ""
+		if len(violation.Owners) > 0 {
+			owners = ", owned by " + strings.Join(violation.Owners, " ")
+		}
+		protected := ""
+		if violation.Protected {
+			protected = " [protected]"
+		}
+		fmt.Printf("  %s/%s  %s%s%s\n", violation.Repository, violation.File, violation.Area, owners, protected)
+	}
+
+	if enforce || ownership.Enforce {
+		return errors.Errorf("refusing to commit %d files outside the workspace scope; revert or stash them, or add their area to the scope in .wsm/ownership.yaml", len(violations))
+	}
+	return nil
 }
@@ -0,0 +1,225 @@
+package cmds
+
+import (
+	"context"
+	"os"
+	"os/exec"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewContainerCommand creates the container command
+func NewContainerCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "container",
+		Short: "Run a dev container for a workspace",
+		Long: `Start a dev container (docker or podman) with the workspace directory mounted
+and the WSM_ variables of setup scripts in its environment. The container is
+recorded in the workspace metadata: 'status' shows whether it is running, and
+deleting the workspace removes it.
+
+The container is configured in the container section of config.yaml, with the
+workspace .wsm/container.yaml layered on top:
+
+  container:
+    runtime: auto            # docker, podman or auto
+    image: golang:1.24
+    workdir: /workspace      # mount point of the workspace directory
+    shell: /bin/bash
+    args: ["--publish", "8080:8080"]
+    env:
+      GOFLAGS: -mod=mod
+
+Examples:
+  workspace-manager container up my-feature
+  workspace-manager container shell
+  workspace-manager container shell -- go test ./...
+  workspace-manager container down my-feature`,
+	}
+
+	cmd.AddCommand(
+		NewContainerUpCommand(),
+		NewContainerDownCommand(),
+		NewContainerShellCommand(),
+	)
+
+	return cmd
+}
+
+// NewContainerUpCommand creates the container up command
+func NewContainerUpCommand() *cobra.Command {
+	var (
+		image   string
+		runtime string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "up [workspace-name]",
+		Short: "Start the dev container of a workspace",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspaceName := ""
+			if len(args) > 0 {
+				workspaceName = args[0]
+			}
+
+			wm, workspace, err := loadContainerWorkspace(workspaceName)
+			if err != nil {
+				return err
+			}
+			config, err := wm.WorkspaceContainerConfig(workspace)
+			if err != nil {
+				return err
+			}
+			if cmd.Flags().Changed("image") {
+				config.Image = image
+			}
+			if cmd.Flags().Changed("runtime") {
+				config.Runtime = runtime
+			}
+
+			if err := wm.StartContainer(cmd.Context(), workspace, config); err != nil {
+				return err
+			}
+			output.PrintSuccess("Started container %s", wsm.FormatContainer(workspace.Container, wsm.ContainerStateRunning))
+			output.PrintInfo("Open a shell with 'workspace-manager container shell %s'", workspace.Name)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&image, "image", "", "Container image (overrides container.image)")
+	cmd.Flags().StringVar(&runtime, "runtime", wsm.ContainerRuntimeAuto, "Container runtime (auto, docker, podman)")
+
+	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion())
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"runtime": carapace.ActionValues(wsm.ContainerRuntimeAuto, wsm.ContainerRuntimeDocker, wsm.ContainerRuntimePodman),
+	})
+
+	return cmd
+}
+
+// NewContainerDownCommand creates the container down command
+func NewContainerDownCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "down [workspace-name]",
+		Short: "Stop and remove the dev container of a workspace",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspaceName := ""
+			if len(args) > 0 {
+				workspaceName = args[0]
+			}
+
+			wm, workspace, err := loadContainerWorkspace(workspaceName)
+			if err != nil {
+				return err
+			}
+			if workspace.Container == nil {
+				output.PrintInfo("Workspace '%s' has no container", workspace.Name)
+				return nil
+			}
+
+			name := workspace.Container.Name
+			if err := wm.StopContainer(cmd.Context(), workspace); err != nil {
+				return err
+			}
+			output.PrintSuccess("Removed container %s", name)
+			return nil
+		},
+	}
+
+	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion())
+
+	return cmd
+}
+
+// NewContainerShellCommand creates the container shell command
+func NewContainerShellCommand() *cobra.Command {
+	var shell string
+
+	cmd := &cobra.Command{
+		Use:   "shell [workspace-name] [-- command...]",
+		Short: "Open a shell or run a command in the dev container",
+		Long: `Open an interactive shell in the dev container of a workspace, or run the
+command given after '--'. When run from inside the workspace, the shell starts
+in the matching directory of the container.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if dash := cmd.ArgsLenAtDash(); dash > 1 || (dash < 0 && len(args) > 1) {
+				return errors.New("accepts at most one workspace name before '--'")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspaceName := ""
+			var command []string
+			if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+				command = args[dash:]
+				args = args[:dash]
+			}
+			if len(args) > 0 {
+				workspaceName = args[0]
+			}
+
+			return runContainerShell(cmd.Context(), workspaceName, shell, command)
+		},
+	}
+
+	cmd.Flags().StringVar(&shell, "shell", "", "Shell to start (overrides container.shell, default /bin/sh)")
+
+	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion())
+
+	return cmd
+}
+
+func loadContainerWorkspace(workspaceName string) (*wsm.WorkspaceManager, *wsm.Workspace, error) {
+	workspace, err := resolveWorkspace(workspaceName)
+	if err != nil {
+		return nil, nil, err
+	}
+	wm, err := wsm.NewWorkspaceManager()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to create workspace manager")
+	}
+	return wm, workspace, nil
+}
+
+func runContainerShell(ctx context.Context, workspaceName, shell string, command []string) error {
+	wm, workspace, err := loadContainerWorkspace(workspaceName)
+	if err != nil {
+		return err
+	}
+	if wsm.ContainerState(ctx, wm.Runner, workspace) != wsm.ContainerStateRunning {
+		return errors.Errorf("the container of workspace '%s' is not running; start it with 'workspace-manager container up %s'", workspace.Name, workspace.Name)
+	}
+
+	if len(command) == 0 {
+		if shell == "" {
+			config, err := wm.WorkspaceContainerConfig(workspace)
+			if err != nil {
+				return err
+			}
+			shell = config.Shell
+		}
+		if shell == "" {
+			shell = wsm.DefaultContainerShell
+		}
+		command = []string{shell}
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return errors.Wrap(err, "failed to get current directory")
+	}
+
+	tty := output.IsTerminal(os.Stdin) && output.IsTerminal(os.Stdout)
+	args := wsm.ContainerExecArgs(workspace, cwd, tty, command...)
+	execCmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	execCmd.Stdin = os.Stdin
+	execCmd.Stdout = os.Stdout
+	execCmd.Stderr = os.Stderr
+	return execCmd.Run()
+}
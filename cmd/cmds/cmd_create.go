@@ -1,10 +1,16 @@
 package cmds
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"hash/fnv"
+	"os"
+	"slices"
 	"strings"
+	"sync"
 
+	"github.com/carapace-sh/carapace"
 	"github.com/charmbracelet/huh"
 	"github.com/go-go-golems/workspace-manager/pkg/output"
 	"github.com/go-go-golems/workspace-manager/pkg/wsm"
@@ -15,15 +21,11 @@ import (
 
 func NewCreateCommand() *cobra.Command {
 	var (
-		repos        []string
-		branch       string
-		branchPrefix string
-		baseBranch   string
-		agentSource  string
-		interactive  bool
-		dryRun       bool
+		options      createOptions
+		resume       string
+		abandon      string
+		forceAbandon bool
 	)
-
 	cmd := &cobra.Command{
 		Use:   "create [workspace-name]",
 		Short: "Create a new multi-repository workspace",
@@ -32,6 +34,10 @@ The workspace will contain git worktrees for each repository on the specified br
 
 If no branch is specified, a branch will be automatically created using the pattern:
   <branch-prefix>/<workspace-name>
+With --interactive, branch names are suggested instead: the naming patterns of
+your recent workspaces first (e.g. alice/<name>), then <branch-prefix>/<name>
+and a conventional prefix from the name (fix-login suggests fix/login). Any
+suggestion can be edited inline.
 
 Examples:
   # Create workspace with automatic branch (task/my-feature)
@@ -44,33 +50,218 @@ Examples:
   workspace-manager create my-feature --repos app,lib --branch-prefix bug
 
   # Create workspace from specific base branch
-  workspace-manager create my-feature --repos app,lib --base-branch main`,
-		Args: cobra.ExactArgs(1),
+  workspace-manager create my-feature --repos app,lib --base-branch main
+
+  # Create workspace for a GitHub issue (name, branch and repositories derived from the issue)
+  workspace-manager create --from-issue https://github.com/acme/app/issues/142
+
+With --from-issue, the issue title and labels are read with the GitHub CLI (gh).
+The workspace is named <number>-<title>, labels such as bug or enhancement pick
+the branch prefix unless --branch-prefix is given, and the repositories are the
+one the issue belongs to plus those issues.label_repos maps the labels to:
+
+  issues:
+    label_repos:
+      frontend: [web, design-system]
+      api: [backend]
+
+The issue is linked to the workspace and its body is added to AGENT.md.
+
+--issue links tickets to the new workspace like 'link issue'. Linked Jira
+tickets are moved to issues.jira.transitions.create, e.g. In Progress (see
+'link --help'):
+
+  workspace-manager create export-csv --repos app --issue PROJ-123
+
+With --branch-protection warn or prefix (branch_protection.mode and
+branch_protection.prefix in config.yaml set the defaults), the rulesets and
+branch protection rules of GitHub repositories are looked up with gh before the
+worktrees are created. When they would reject pushes of the branch (e.g.
+--branch main or release/1.2), create warns, or in prefix mode uses
+feature/<branch> instead. The lookup is off by default and skipped on dry runs,
+as it can take a few seconds per repository.
+
+With --link, the repositories are included as symbolic links to their existing
+checkouts instead of new worktrees: the checkouts stay on their branch and
+nothing is installed into them. Use it for read-only exploration, or when a
+branch must not change. Commands that write to the repositories or push them
+(switch, branch, commit, push, sync, rebase, apply-pr, broadcast, rename-symbol,
+rewrite-module, drift --rebase/--merge, gitconfig) refuse to run in linked
+workspaces, and delete only removes the links.
+
+  # Explore three services side by side without touching their checkouts
+  workspace-manager create explore-auth --repos api,auth,web --link
+
+With --clone, the repositories are local clones instead of worktrees, for
+experiments that rewrite history (interactive rebases, git filter-repo) without
+touching the worktree bookkeeping of the source repositories. --clone shared
+uses 'git clone --shared', which borrows the objects of the source repository;
+--clone full makes an independent clone. origin points at the remote of the
+source repository, which is also reachable as the remote "source". delete and
+remove keep clones with uncommitted changes or commits that are on no remote,
+unless forced.
+
+  # Try a history rewrite on a throwaway clone
+  workspace-manager create rewrite-test --repos app --clone full
+
+When some repositories cannot be created (authentication, disk space, a branch
+conflict), the others are kept and the plan of the workspace is saved. Fix the
+problem and retry only the failed repositories with --resume, or remove what was
+created with --abandon (--force-worktrees also removes repositories with
+uncommitted changes or unpushed commits).
+
+  workspace-manager create --resume my-feature
+  workspace-manager create --abandon my-feature
+
+A quota in config.yaml limits how many workspaces may exist and how much disk
+space they may take together, counting deleted workspaces in the trash. Over
+quota, creating a workspace (create, fork, respin, apply, resume, import)
+warns, or with enforce: refuse fails, and suggests workspaces to delete:
+missing ones, then those past the max_age of a policy.yaml rule, then the
+other clean ones, oldest first. --ignore-quota creates the workspace
+regardless.
+
+  quota:
+    max_workspaces: 20
+    disk_budget: 50GB
+    enforce: refuse   # default: warn`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if resume != "" || abandon != "" {
+				return cobra.NoArgs(cmd, args)
+			}
+			if options.fromIssue != "" {
+				return cobra.MaximumNArgs(1)(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runCreate(cmd.Context(), args[0], repos, branch, branchPrefix, baseBranch, agentSource, interactive, dryRun)
+			if resume != "" && abandon != "" {
+				return errors.New("--resume and --abandon cannot be combined")
+			}
+			if forceAbandon && abandon == "" {
+				return errors.New("--force-worktrees only applies to --abandon")
+			}
+			if abandon != "" {
+				return runCreateAbandon(cmd.Context(), abandon, forceAbandon)
+			}
+			if resume != "" {
+				return silenceReported(cmd, runCreateResume(cmd.Context(), resume, options.bootstrap, options.ignoreQuota))
+			}
+
+			name := ""
+			if len(args) > 0 {
+				name = args[0]
+			}
+			if options.fromIssue != "" && !cmd.Flags().Changed("branch-prefix") {
+				options.branchPrefix = ""
+			}
+			if options.link && options.clone != "" {
+				return errors.New("--link and --clone cannot be combined")
+			}
+			if options.clone != "" && !slices.Contains(wsm.CloneModes, options.clone) {
+				return errors.Errorf("invalid --clone '%s': expected one of %s", options.clone, strings.Join(wsm.CloneModes, ", "))
+			}
+			if options.link {
+				for _, flag := range []string{"branch", "branch-prefix", "base-branch", "branch-protection"} {
+					if cmd.Flags().Changed(flag) {
+						return errors.Errorf("--%s cannot be used with --link: linked repositories keep the branch of their checkout", flag)
+					}
+				}
+			}
+			if options.protection != "" && !slices.Contains(wsm.BranchProtectionModes, options.protection) {
+				return errors.Errorf("invalid --branch-protection '%s': expected one of %s", options.protection, strings.Join(wsm.BranchProtectionModes, ", "))
+			}
+			return silenceReported(cmd, runCreate(cmd.Context(), name, options))
 		},
 	}
 
-	cmd.Flags().StringSliceVar(&repos, "repos", nil, "Repository names to include (comma-separated)")
-	cmd.Flags().StringVar(&branch, "branch", "", "Branch name for worktrees (if not specified, uses <branch-prefix>/<workspace-name>)")
-	cmd.Flags().StringVar(&branchPrefix, "branch-prefix", "task", "Prefix for auto-generated branch names")
-	cmd.Flags().StringVar(&baseBranch, "base-branch", "", "Base branch to create new branch from (defaults to current branch)")
-	cmd.Flags().StringVar(&agentSource, "agent-source", "", "Path to AGENT.md template file")
-	cmd.Flags().BoolVar(&interactive, "interactive", false, "Interactive repository selection")
-	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be created without actually creating")
+	cmd.Flags().StringSliceVar(&options.repos, "repos", nil, "Repository names to include (comma-separated)")
+	cmd.Flags().StringVar(&options.branch, "branch", "", "Branch name for worktrees (if not specified, uses <branch-prefix>/<workspace-name>)")
+	cmd.Flags().StringVar(&options.branchPrefix, "branch-prefix", "task", "Prefix for auto-generated branch names")
+	cmd.Flags().StringVar(&options.baseBranch, "base-branch", "", "Base branch to create new branch from (defaults to current branch)")
+	cmd.Flags().StringVar(&options.agentSource, "agent-source", "", "Path to AGENT.md template file")
+	cmd.Flags().BoolVar(&options.interactive, "interactive", false, "Interactive repository and branch selection")
+	options.bootstrap.register(cmd)
+	cmd.Flags().BoolVar(&options.dryRun, "dry-run", false, "Show what would be created without actually creating")
+	cmd.Flags().StringVar(&options.fromIssue, "from-issue", "", "Create the workspace for a GitHub issue (URL or owner/repo#N)")
+	cmd.Flags().StringSliceVar(&options.issues, "issue", nil, "Link issues to the workspace (Jira keys, owner/repo#N or URLs); Jira tickets are moved to issues.jira.transitions.create")
+	cmd.Flags().StringVar(&options.protection, "branch-protection", "", "When GitHub protects the branch: warn, prefix, off (default: branch_protection.mode, else off)")
+	cmd.Flags().BoolVar(&options.link, "link", false, "Link the existing checkouts into the workspace instead of creating worktrees")
+	cmd.Flags().StringVar(&options.clone, "clone", "", "Clone the repositories instead of creating worktrees: shared or full")
+	cmd.Flags().StringVar(&resume, "resume", "", "Retry the failed repositories of an incomplete workspace creation")
+	cmd.Flags().StringVar(&abandon, "abandon", "", "Discard an incomplete workspace creation and the repositories it created")
+	cmd.Flags().BoolVar(&forceAbandon, "force-worktrees", false, "With --abandon, also remove repositories with uncommitted changes or unpushed commits")
+	cmd.Flags().BoolVar(&options.ignoreQuota, "ignore-quota", false, "Create the workspace even when the quota (quota.max_workspaces, quota.disk_budget) is exceeded")
+
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"resume":  IncompleteCreationCompletion(),
+		"abandon": IncompleteCreationCompletion(),
+		"clone":   carapace.ActionValues(wsm.CloneModes...),
+	})
 
 	return cmd
 }
 
-func runCreate(ctx context.Context, name string, repos []string, branch, branchPrefix, baseBranch, agentSource string, interactive, dryRun bool) error {
+// createOptions are the command line settings of create
+type createOptions struct {
+	repos        []string
+	branch       string
+	branchPrefix string
+	baseBranch   string
+	agentSource  string
+	interactive  bool
+	dryRun       bool
+	bootstrap    bootstrapFlags
+	fromIssue    string
+	issues       []string
+	protection   string
+	link         bool
+	clone        string
+	ignoreQuota  bool
+}
+
+func runCreate(ctx context.Context, name string, options createOptions) error {
 	wm, err := wsm.NewWorkspaceManager()
 	if err != nil {
 		return errors.Wrap(err, "failed to create workspace manager")
 	}
+	for _, reference := range options.issues {
+		if _, err := wsm.ParseIssueReference(reference, wm.Config().Issues); err != nil {
+			return err
+		}
+	}
+
+	var issue *wsm.GitHubIssue
+	if options.fromIssue != "" {
+		issue, err = wsm.FetchGitHubIssue(ctx, options.fromIssue, wm.Config().Issues)
+		if err != nil {
+			return err
+		}
+		output.PrintInfo("Issue %s: %s", issue.Link.Ref, issue.Title)
+		if name == "" {
+			name = wsm.IssueWorkspaceName(issue)
+		}
+		if options.branchPrefix == "" {
+			options.branchPrefix = wsm.IssueBranchPrefix(issue)
+			if options.branchPrefix == "" {
+				options.branchPrefix = "task"
+			}
+		}
+		if !options.interactive {
+			for _, repo := range wm.IssueRepositories(issue) {
+				if !slices.Contains(options.repos, repo) {
+					options.repos = append(options.repos, repo)
+				}
+			}
+			if len(options.repos) == 0 {
+				return errors.Errorf("no repository matches issue %s; pass them with --repos or map its labels with issues.label_repos", issue.Link.Ref)
+			}
+		}
+	}
 
 	// Handle interactive mode
-	if interactive {
-		selectedRepos, err := selectRepositoriesInteractively(wm)
+	if options.interactive {
+		selectedRepos, err := selectRepositoriesInteractively(ctx, wm)
 		if err != nil {
 			// Check if user cancelled - handle gracefully without error
 			errMsg := strings.ToLower(err.Error())
@@ -82,25 +273,66 @@ func runCreate(ctx context.Context, name string, repos []string, branch, branchP
 			}
 			return errors.Wrap(err, "interactive selection failed")
 		}
-		repos = selectedRepos
+		options.repos = selectedRepos
 	}
 
 	// Validate inputs
-	if len(repos) == 0 {
+	if len(options.repos) == 0 {
 		return errors.New("no repositories specified. Use --repos flag or --interactive mode")
 	}
 
+	// The quota is enforced when the workspace is created; a dry run only shows the warnings
+	wm.IgnoreQuota = options.ignoreQuota
+	if options.dryRun {
+		var exceeded *wsm.QuotaExceededError
+		if err := wm.EnforceQuota(ctx, !options.link); errors.As(err, &exceeded) {
+			output.PrintWarning("The workspace would be refused (quota.enforce: refuse)")
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if options.link {
+		return createLinkedWorkspace(ctx, wm, name, options.repos, options.agentSource, issue, options.issues, options.dryRun)
+	}
+
 	// Generate branch name if not specified
-	finalBranch := branch
+	finalBranch := options.branch
+	if finalBranch == "" && options.interactive {
+		selectedBranch, err := selectBranchInteractively(ctx, name, options.branchPrefix)
+		if err != nil {
+			if strings.Contains(strings.ToLower(err.Error()), "cancelled by user") {
+				output.PrintInfo("Operation cancelled.")
+				return nil
+			}
+			return errors.Wrap(err, "interactive selection failed")
+		}
+		finalBranch = selectedBranch
+	}
 	if finalBranch == "" {
-		finalBranch = fmt.Sprintf("%s/%s", branchPrefix, name)
+		finalBranch = fmt.Sprintf("%s/%s", options.branchPrefix, name)
 		output.PrintInfo("Using auto-generated branch: %s", finalBranch)
-		log.Debug().Str("branch", finalBranch).Str("prefix", branchPrefix).Str("name", name).Msg("Generated branch name")
+		log.Debug().Str("branch", finalBranch).Str("prefix", options.branchPrefix).Str("name", name).Msg("Generated branch name")
 	}
+	finalBranch = guardBranchProtection(ctx, wm, options.repos, finalBranch, options.protection, options.dryRun)
 
 	// Create workspace
-	log.Debug().Str("name", name).Strs("repos", repos).Str("branch", finalBranch).Str("baseBranch", baseBranch).Bool("dryRun", dryRun).Msg("Creating workspace")
-	workspace, err := wm.CreateWorkspace(ctx, name, repos, finalBranch, baseBranch, agentSource, dryRun)
+	log.Debug().Str("name", name).Strs("repos", options.repos).Str("branch", finalBranch).Str("baseBranch", options.baseBranch).Bool("dryRun", options.dryRun).Msg("Creating workspace")
+	var workspace *wsm.Workspace
+	var progress *output.Progress
+	if !options.dryRun {
+		// Bars per repository replace the silent wait for the checkouts
+		if progress = output.StartProgress(os.Stdout, "Creating workspace "+name, options.repos); progress != nil {
+			wm.Progress = progress
+		}
+	}
+	if options.clone != "" {
+		workspace, err = wm.CreateClonedWorkspace(ctx, name, options.repos, finalBranch, options.baseBranch, options.agentSource, options.clone, options.dryRun)
+	} else {
+		workspace, err = wm.CreateWorkspace(ctx, name, options.repos, finalBranch, options.baseBranch, options.agentSource, options.dryRun)
+	}
+	progress.Stop()
+	wm.Progress = nil
 	if err != nil {
 		// Check if user cancelled - handle gracefully without error
 		errMsg := strings.ToLower(err.Error())
@@ -110,62 +342,271 @@ func runCreate(ctx context.Context, name string, repos []string, branch, branchP
 			output.PrintInfo("Operation cancelled.")
 			return nil // Return success to prevent usage help
 		}
-		return errors.Wrap(err, "failed to create workspace")
+		return creationError(err, "failed to create workspace")
 	}
 
 	// Show results
-	if dryRun {
+	if options.dryRun {
+		if issue != nil {
+			output.PrintInfo("Would link %s and add it to AGENT.md", issue.Link.Ref)
+		}
+		if len(options.issues) > 0 {
+			output.PrintInfo("Would link %s", strings.Join(options.issues, ", "))
+		}
 		return showWorkspacePreview(workspace)
 	}
 
-	output.PrintSuccess("Workspace '%s' created successfully!", workspace.Name)
+	if issue != nil {
+		if _, err := wm.LinkIssue(workspace, issue.Link); err != nil {
+			return errors.Wrap(err, "failed to link issue")
+		}
+		if err := wm.SeedAgentMDWithIssue(workspace, issue); err != nil {
+			output.PrintWarning("Failed to add the issue to AGENT.md: %v", err)
+		}
+	}
+	if err := linkCreatedIssues(ctx, wm, workspace, options.issues); err != nil {
+		return err
+	}
+
+	printCreatedWorkspace(ctx, wm, workspace, issue, options.bootstrap)
+	return nil
+}
+
+// runCreateResume retries the repositories of an incomplete creation, see --resume
+func runCreateResume(ctx context.Context, name string, bootstrap bootstrapFlags, ignoreQuota bool) error {
+	wm, err := wsm.NewWorkspaceManager()
+	if err != nil {
+		return errors.Wrap(err, "failed to create workspace manager")
+	}
+	wm.IgnoreQuota = ignoreQuota
+
+	plan, err := wm.LoadIncompleteCreation(name)
+	if err != nil {
+		return err
+	}
+	var retry []string
+	for _, repo := range plan.Failed {
+		retry = append(retry, repo.Name)
+	}
+	output.PrintHeader("Resuming creation of workspace: %s", name)
+	output.PrintInfo("Retrying %s", strings.Join(retry, ", "))
+
+	progress := output.StartProgress(os.Stdout, "Resuming workspace "+name, retry)
+	if progress != nil {
+		wm.Progress = progress
+	}
+	workspace, err := wm.ResumeCreation(ctx, name)
+	progress.Stop()
+	wm.Progress = nil
+	if err != nil {
+		if strings.Contains(strings.ToLower(err.Error()), "cancelled by user") {
+			output.PrintInfo("Operation cancelled.")
+			return nil
+		}
+		return creationError(err, "failed to create workspace")
+	}
+
+	printCreatedWorkspace(ctx, wm, workspace, nil, bootstrap)
+	return nil
+}
+
+// runCreateAbandon discards an incomplete creation, see --abandon
+func runCreateAbandon(ctx context.Context, name string, force bool) error {
+	wm, err := wsm.NewWorkspaceManager()
+	if err != nil {
+		return errors.Wrap(err, "failed to create workspace manager")
+	}
+	if err := wm.AbandonCreation(ctx, name, force); err != nil {
+		return err
+	}
+	output.PrintSuccess("Discarded the incomplete creation of workspace '%s'", name)
+	return nil
+}
+
+// creationError explains how to continue when some repositories could not be created: the
+// others were kept, and the creation can be resumed or abandoned
+func creationError(err error, message string) error {
+	var incomplete *wsm.IncompleteCreationError
+	if !errors.As(err, &incomplete) {
+		return errors.Wrap(err, message)
+	}
+
 	fmt.Println()
+	output.PrintWarning("Workspace '%s' is incomplete: %d of %d repositories could not be created", incomplete.Name, len(incomplete.Failed), incomplete.Total)
+	for _, repo := range incomplete.Failed {
+		fmt.Printf("  %s: %s\n", repo.Name, repo.Error)
+	}
+	output.PrintInfo("The other repositories were kept. Fix the problem, then retry the failed ones with:")
+	fmt.Printf("  wsm create --resume %s\n", incomplete.Name)
+	output.PrintInfo("or remove what was created with:")
+	fmt.Printf("  wsm create --abandon %s\n", incomplete.Name)
+	return &wsm.ExitCodeError{Code: 1}
+}
+
+// linkCreatedIssues links the issues of --issue to a new workspace and moves its Jira tickets to
+// issues.jira.transitions.create
+func linkCreatedIssues(ctx context.Context, wm *wsm.WorkspaceManager, workspace *wsm.Workspace, issues []string) error {
+	if len(issues) == 0 {
+		return nil
+	}
+	if err := linkIssues(wm, workspace, issues); err != nil {
+		return err
+	}
+	updateJiraIssues(ctx, wm, workspace, wm.Config().Issues.Jira.Transitions.Create)
+	return nil
+}
+
+// printCreatedWorkspace shows the details of a new workspace, then bootstraps it
+func printCreatedWorkspace(ctx context.Context, wm *wsm.WorkspaceManager, workspace *wsm.Workspace, issue *wsm.GitHubIssue, bootstrap bootstrapFlags) {
+	output.PrintSuccess("Workspace '%s' created successfully!", workspace.Name)
+	output.Printf("\n")
 
 	output.PrintHeader("Workspace Details")
-	fmt.Printf("  Path: %s\n", workspace.Path)
-	fmt.Printf("  Repositories: %s\n", strings.Join(getRepositoryNames(workspace.Repositories), ", "))
+	output.Printf("  Path: %s\n", workspace.Path)
+	output.Printf("  Repositories: %s\n", strings.Join(getRepositoryNames(workspace.Repositories), ", "))
 	if workspace.Branch != "" {
-		fmt.Printf("  Branch: %s\n", workspace.Branch)
+		output.Printf("  Branch: %s\n", workspace.Branch)
+	}
+	if workspace.Clone != "" {
+		output.Printf("  Clones: %s (origin is the remote of each source repository)\n", workspace.Clone)
 	}
 	if workspace.GoWorkspace {
-		fmt.Printf("  Go workspace: yes (go.work created)\n")
+		output.Printf("  Go workspace: yes (go.work created)\n")
+	}
+	if len(workspace.BuildManifests) > 0 {
+		output.Printf("  Build manifests: %s\n", strings.Join(wsm.BuildManifestFiles(workspace), ", "))
+	}
+	if issue != nil {
+		output.Printf("  Issue: %s (linked, added to AGENT.md)\n", issue.Link.Ref)
 	}
 	if workspace.AgentMD != "" {
-		fmt.Printf("  AGENT.md: copied from %s\n", workspace.AgentMD)
+		output.Printf("  AGENT.md: copied from %s\n", workspace.AgentMD)
+	}
+	for _, asset := range workspace.AgentAssets {
+		output.Printf("  Agent asset: %s (%s)\n", asset.Target, agentAssetScope(asset))
 	}
 
-	fmt.Println()
+	// Linked workspaces are never bootstrapped: it would install dependencies into the checkouts
+	if workspace.Linked {
+		bootstrap.Skip = true
+	}
+	bootstrapWorkspace(ctx, wm, workspace, bootstrap)
+
+	output.Printf("\n")
 	output.PrintInfo("To start working:")
-	fmt.Printf("  cd %s\n", workspace.Path)
+	output.Printf("  cd %s\n", workspace.Path)
+}
+
+// createLinkedWorkspace creates a workspace of links to the existing checkouts, see --link.
+// Bootstrapping is skipped: it would install dependencies into the checkouts.
+func createLinkedWorkspace(ctx context.Context, wm *wsm.WorkspaceManager, name string, repos []string, agentSource string, issue *wsm.GitHubIssue, issues []string, dryRun bool) error {
+	workspace, err := wm.CreateLinkedWorkspace(ctx, name, repos, agentSource, dryRun)
+	if err != nil {
+		return creationError(err, "failed to create workspace")
+	}
+
+	if dryRun {
+		if issue != nil {
+			output.PrintInfo("Would link %s and add it to AGENT.md", issue.Link.Ref)
+		}
+		if len(issues) > 0 {
+			output.PrintInfo("Would link %s", strings.Join(issues, ", "))
+		}
+		return showWorkspacePreview(workspace)
+	}
+
+	if issue != nil {
+		if _, err := wm.LinkIssue(workspace, issue.Link); err != nil {
+			return errors.Wrap(err, "failed to link issue")
+		}
+		if err := wm.SeedAgentMDWithIssue(workspace, issue); err != nil {
+			output.PrintWarning("Failed to add the issue to AGENT.md: %v", err)
+		}
+	}
+	if err := linkCreatedIssues(ctx, wm, workspace, issues); err != nil {
+		return err
+	}
+
+	output.PrintSuccess("Workspace '%s' created successfully!", workspace.Name)
+	output.Printf("\n")
+
+	output.PrintHeader("Workspace Details")
+	output.Printf("  Path: %s\n", workspace.Path)
+	output.Printf("  Linked checkouts:\n")
+	for _, repo := range workspace.Repositories {
+		output.Printf("    %s -> %s\n", repo.Name, repo.Path)
+	}
+	if workspace.GoWorkspace {
+		output.Printf("  Go workspace: yes (go.work created)\n")
+	}
+	if len(workspace.BuildManifests) > 0 {
+		output.Printf("  Build manifests: %s\n", strings.Join(wsm.BuildManifestFiles(workspace), ", "))
+	}
+	if issue != nil {
+		output.Printf("  Issue: %s (linked, added to AGENT.md)\n", issue.Link.Ref)
+	}
+	if workspace.AgentMD != "" {
+		output.Printf("  AGENT.md: copied from %s\n", workspace.AgentMD)
+	}
+
+	output.Printf("\n")
+	output.PrintInfo("To start exploring:")
+	output.Printf("  cd %s\n", workspace.Path)
 
 	return nil
 }
 
-func selectRepositoriesInteractively(wm *wsm.WorkspaceManager) ([]string, error) {
+func selectRepositoriesInteractively(ctx context.Context, wm *wsm.WorkspaceManager) ([]string, error) {
 	repos := wm.Discoverer.GetRepositories()
 
 	if len(repos) == 0 {
 		return nil, errors.New("no repositories found. Run 'workspace-manager discover' first")
 	}
 
+	if err := output.RequireInteractive("select repositories", "pass them with --repos"); err != nil {
+		return nil, err
+	}
+
 	output.PrintHeader("Select Repositories")
 
 	// Create options for multi-select
 	var options []huh.Option[string]
+	reposByName := make(map[string]wsm.Repository, len(repos))
 	for _, repo := range repos {
 		label := fmt.Sprintf("%s (%s)", repo.Name, strings.Join(repo.Categories, ", "))
 		options = append(options, huh.NewOption(label, repo.Name))
+		reposByName[repo.Name] = repo
+	}
+
+	// Previews run git and read the README, so they are computed when a repository is first
+	// hovered. huh evaluates descriptions in commands, hence the lock.
+	var previewsMu sync.Mutex
+	previews := make(map[string]wsm.RepositoryPreview)
+	preview := func(name string) wsm.RepositoryPreview {
+		previewsMu.Lock()
+		defer previewsMu.Unlock()
+		if p, ok := previews[name]; ok {
+			return p
+		}
+		p := wsm.GetRepositoryPreview(ctx, reposByName[name])
+		previews[name] = p
+		return p
 	}
 
 	var selected []string
-	form := huh.NewForm(
-		huh.NewGroup(
-			huh.NewMultiSelect[string]().
-				Title("Choose repositories to include:").
-				Options(options...).
-				Value(&selected),
-		),
-	)
+	multiSelect := huh.NewMultiSelect[string]().
+		Title("Choose repositories to include:").
+		Options(options...).
+		Value(&selected)
+	multiSelect.DescriptionFunc(func() string {
+		hovered, ok := multiSelect.Hovered()
+		if !ok {
+			return ""
+		}
+		return formatRepositoryPreview(preview(hovered))
+	}, hoveredOptionBinding[string]{field: multiSelect})
+
+	form := huh.NewForm(huh.NewGroup(multiSelect))
 
 	log.Debug().Int("repoCount", len(repos)).Msg("Showing interactive repository selection")
 	err := form.Run()
@@ -189,34 +630,172 @@ func selectRepositoriesInteractively(wm *wsm.WorkspaceManager) ([]string, error)
 	return selected, nil
 }
 
+// customBranchOption is the branch selection entry that opens the inline editor
+const customBranchOption = "\x00custom"
+
+// guardBranchProtection warns when GitHub would reject pushes of branch to one of the repositories
+// and, in prefix mode, returns the prefixed branch to use instead. It is opt-in and does nothing on
+// dry runs: the lookups can take up to 15s per repository.
+func guardBranchProtection(ctx context.Context, wm *wsm.WorkspaceManager, repoNames []string, branch, mode string, dryRun bool) string {
+	config := wm.Config().BranchProtection
+	mode = cmp.Or(mode, config.Mode, wsm.BranchProtectionOff)
+	if mode == wsm.BranchProtectionOff || dryRun {
+		return branch
+	}
+	// Unknown repositories are reported by CreateWorkspace
+	repos, err := wm.FindRepositories(repoNames)
+	if err != nil {
+		return branch
+	}
+
+	protected := wm.CheckBranchProtection(ctx, repos, branch)
+	if len(protected) == 0 {
+		return branch
+	}
+	for _, p := range protected {
+		output.PrintWarning("GitHub will reject pushes of %s to %s: %s", branch, p.Repository, strings.Join(p.Rules, ", "))
+	}
+
+	prefixed := wsm.PrefixProtectedBranch(branch, config.Prefix)
+	if mode != wsm.BranchProtectionPrefix {
+		output.PrintInfo("Choose another --branch, or pass --branch-protection prefix to use %s", prefixed)
+		return branch
+	}
+	if prefixed == branch {
+		return branch
+	}
+	if len(wm.CheckBranchProtection(ctx, repos, prefixed)) > 0 {
+		output.PrintWarning("%s is protected as well, keeping %s", prefixed, branch)
+		return branch
+	}
+	output.PrintInfo("Using branch %s instead", prefixed)
+	return prefixed
+}
+
+// selectBranchInteractively offers branch names suggested from the workspace name and the
+// patterns of recent workspaces, and lets the user edit one inline
+func selectBranchInteractively(ctx context.Context, name, branchPrefix string) (string, error) {
+	suggestions := wsm.SuggestBranchNames(ctx, name, branchPrefix)
+	if len(suggestions) == 0 {
+		return "", nil
+	}
+
+	var options []huh.Option[string]
+	for _, suggestion := range suggestions {
+		label := fmt.Sprintf("%s  %s", suggestion.Branch, output.DimStyle.Render("("+suggestion.Reason+")"))
+		options = append(options, huh.NewOption(label, suggestion.Branch))
+	}
+	options = append(options, huh.NewOption("✎ Edit a branch name...", customBranchOption))
+
+	choice := suggestions[0].Branch
+	branch := suggestions[0].Branch
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[string]().
+				Title("Branch for the worktrees:").
+				Options(options...).
+				Value(&choice),
+		),
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Branch name:").
+				Value(&branch).
+				Validate(func(value string) error {
+					return wsm.ValidateBranchName(ctx, strings.TrimSpace(value))
+				}),
+		).WithHideFunc(func() bool {
+			return choice != customBranchOption
+		}),
+	)
+
+	if err := form.Run(); err != nil {
+		errMsg := strings.ToLower(err.Error())
+		if strings.Contains(errMsg, "aborted") || strings.Contains(errMsg, "interrupt") {
+			return "", errors.New("workspace creation cancelled by user")
+		}
+		return "", errors.Wrap(err, "interactive form failed")
+	}
+
+	if choice != customBranchOption {
+		branch = choice
+	}
+	branch = strings.TrimSpace(branch)
+	output.PrintInfo("Using branch: %s", branch)
+	return branch, nil
+}
+
+// hoveredOptionBinding makes huh re-evaluate a DescriptionFunc whenever the hovered option changes
+type hoveredOptionBinding[T comparable] struct {
+	field *huh.MultiSelect[T]
+}
+
+// Hash implements hashstructure.Hashable, which huh uses to detect binding changes
+func (b hoveredOptionBinding[T]) Hash() (uint64, error) {
+	hovered, _ := b.field.Hovered()
+	h := fnv.New64a()
+	_, _ = fmt.Fprintf(h, "%v", hovered)
+	return h.Sum64(), nil
+}
+
+// formatRepositoryPreview renders the detail pane shown for the hovered repository
+func formatRepositoryPreview(preview wsm.RepositoryPreview) string {
+	var lines []string
+	if preview.Description != "" {
+		lines = append(lines, preview.Description)
+	}
+	if preview.LastCommitSubject != "" {
+		lines = append(lines, fmt.Sprintf("Last commit: %s (%s)", preview.LastCommitSubject, preview.LastCommitDate.Format("2006-01-02")))
+	}
+	return strings.Join(lines, "\n")
+}
+
 func showWorkspacePreview(workspace *wsm.Workspace) error {
 	output.PrintHeader("📋 Workspace Preview: %s", workspace.Name)
-	fmt.Println()
+	output.Printf("\n")
 
 	output.PrintInfo("Actions to be performed:")
-	fmt.Printf("  1. Create directory structure at: %s\n", workspace.Path)
-
-	fmt.Printf("  2. Create worktrees:\n")
+	output.Printf("  1. Create directory structure at: %s\n", workspace.Path)
+
+	if workspace.Linked {
+		output.Printf("  2. Link checkouts:\n")
+	} else if workspace.Clone != "" {
+		output.Printf("  2. Clone repositories:\n")
+	} else {
+		output.Printf("  2. Create worktrees:\n")
+	}
 	for _, repo := range workspace.Repositories {
-		if workspace.Branch != "" {
-			fmt.Printf("     git worktree add -B %s %s/%s\n", workspace.Branch, workspace.Path, repo.Name)
+		if workspace.Linked {
+			output.Printf("     ln -s %s %s/%s\n", repo.Path, workspace.Path, repo.Name)
+		} else if workspace.Clone == wsm.CloneShared {
+			output.Printf("     git clone --shared %s %s/%s\n", repo.Path, workspace.Path, repo.Name)
+		} else if workspace.Clone != "" {
+			output.Printf("     git clone %s %s/%s\n", repo.Path, workspace.Path, repo.Name)
+		} else if workspace.Branch != "" {
+			output.Printf("     git worktree add -B %s %s/%s\n", workspace.Branch, workspace.Path, repo.Name)
 		} else {
-			fmt.Printf("     git worktree add %s/%s\n", workspace.Path, repo.Name)
+			output.Printf("     git worktree add %s/%s\n", workspace.Path, repo.Name)
 		}
 	}
 
 	if workspace.GoWorkspace {
-		fmt.Printf("  3. Initialize go.work and add modules\n")
+		output.Printf("  3. Initialize go.work and add modules\n")
 	}
 
 	if workspace.AgentMD != "" {
-		fmt.Printf("  4. Copy AGENT.md from %s\n", workspace.AgentMD)
+		output.Printf("  4. Copy AGENT.md from %s\n", workspace.AgentMD)
 	}
 
-	fmt.Println()
+	if len(workspace.AgentAssets) > 0 {
+		output.Printf("  5. Install agent assets:\n")
+		for _, asset := range workspace.AgentAssets {
+			output.Printf("     %s -> %s (%s)\n", asset.Source, asset.Target, agentAssetScope(asset))
+		}
+	}
+
+	output.Printf("\n")
 	output.PrintInfo("Repositories to include:")
 	for _, repo := range workspace.Repositories {
-		fmt.Printf("  • %s (%s) [%s]\n", repo.Name, repo.Path, strings.Join(repo.Categories, ", "))
+		output.Printf("  • %s (%s) [%s]\n", repo.Name, repo.Path, strings.Join(repo.Categories, ", "))
 	}
 
 	return nil
@@ -229,3 +808,64 @@ func getRepositoryNames(repos []wsm.Repository) []string {
 	}
 	return names
 }
+
+// agentAssetScope returns the display scope of an agent asset
+func agentAssetScope(asset wsm.AgentAsset) string {
+	if asset.Scope == "" {
+		return wsm.AgentAssetScopeWorkspace
+	}
+	return asset.Scope
+}
+
+// bootstrapFlags are the --bootstrap and --no-bootstrap flags of the commands creating workspaces
+type bootstrapFlags struct {
+	// Force installs dependencies even when config.yaml does not enable bootstrap
+	Force bool
+	// Skip skips both the dependency install and the setup scripts
+	Skip bool
+	// Setup runs the setup scripts even when config.yaml does not enable them
+	Setup bool
+}
+
+func (f *bootstrapFlags) register(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&f.Force, "bootstrap", false, "Install dependencies (go mod download, npm install, ...) after creation, even when bootstrap.enabled is not set")
+	cmd.Flags().BoolVar(&f.Skip, "no-bootstrap", false, "Skip installing dependencies and running setup scripts after creation")
+	cmd.Flags().BoolVar(&f.Setup, "setup", false, "Run the .wsm setup scripts of the workspace and its repositories after creation, even when setup.enabled is not set")
+	cmd.MarkFlagsMutuallyExclusive("bootstrap", "no-bootstrap")
+	cmd.MarkFlagsMutuallyExclusive("setup", "no-bootstrap")
+}
+
+// bootstrapWorkspace installs repository dependencies, then runs the setup scripts, each when
+// enabled by flag or configuration. Setup scripts come from the repositories, so they never run
+// without being asked for.
+func bootstrapWorkspace(ctx context.Context, wm *wsm.WorkspaceManager, workspace *wsm.Workspace, bootstrap bootstrapFlags) {
+	if bootstrap.Skip {
+		return
+	}
+
+	if bootstrap.Force || wm.BootstrapEnabled() {
+		output.Printf("\n")
+		results := wm.BootstrapWorkspace(ctx, workspace)
+
+		failed := 0
+		for _, result := range results {
+			if result.Error != "" {
+				failed++
+			}
+		}
+		if failed > 0 {
+			output.PrintWarning("%d of %d bootstrap steps failed; run them manually in the affected repositories", failed, len(results))
+		}
+	}
+
+	if scripts := wsm.DetectSetupScripts(workspace); len(scripts) > 0 {
+		output.Printf("\n")
+		if !bootstrap.Setup && !wm.SetupEnabled() {
+			output.PrintInfo("Found %d setup scripts; review them, then run them with 'workspace-manager setup %s' (or pass --setup)", len(scripts), workspace.Name)
+			return
+		}
+		if err := wm.RunSetupScripts(ctx, workspace, scripts); err != nil {
+			output.PrintWarning("%v; rerun with 'workspace-manager setup %s'", err, workspace.Name)
+		}
+	}
+}
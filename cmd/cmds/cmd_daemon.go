@@ -0,0 +1,804 @@
+package cmds
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/dustin/go-humanize"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewDaemonCommand creates the daemon command
+func NewDaemonCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Discover new repositories and prefetch in the background",
+		Long: `The daemon watches the discovery_paths of config.yaml and registers
+repositories cloned into them, and fetches all registered repositories every
+prefetch.interval like 'prefetch --every'.
+
+'daemon install' runs it as a systemd user service (Linux) or launchd agent
+(macOS), started with your session. Each profile gets its own daemon.`,
+	}
+
+	cmd.AddCommand(
+		NewDaemonRunCommand(),
+		NewDaemonInstallCommand(),
+		NewDaemonStatusCommand(),
+		NewDaemonUninstallCommand(),
+	)
+
+	return cmd
+}
+
+// NewDaemonRunCommand creates the daemon run command
+func NewDaemonRunCommand() *cobra.Command {
+	var (
+		options    daemonOptions
+		noPrefetch bool
+		noDiscover bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "run",
+		Short: "Run the daemon in the foreground",
+		Long: `Run the discovery watcher and prefetch scheduler until interrupted. This is what
+the installed service runs; run it directly to try it out.
+
+A directory created below a discovery path is scanned once it has been quiet
+for 10s, so clones are registered when they are complete.
+
+Every --status-interval (daemon.status_interval in config.yaml, 5s by default)
+the fast status of all workspaces is taken, and the changes since the last one
+are published as events on the daemon socket
+together with discovered repositories and completed fetches; follow them
+with 'workspace-manager events --follow'. They are also sent to the webhooks
+subscribed to them (see 'webhook --help'), except workspace.created and
+workspace.deleted, which the commands send themselves. While nothing changes
+the checks back off, doubling the wait up to a minute, and return to the
+interval with the next change. A workspace whose status cannot be taken keeps
+its last status instead of being reported as deleted.
+
+Changes to config.yaml and the repository registry are picked up without a
+restart: the new configuration is validated first and swapped in, restarting
+discovery and rescheduling prefetches as needed, and a config.reloaded event
+is published. An invalid file, including a config.yaml with a misspelled key, is
+reported with a config.invalid event and the running configuration and
+repositories are kept, so a typo does not take the daemon down.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options.prefetch = !noPrefetch
+			options.discover = !noDiscover
+			options.statusIntervalSet = cmd.Flags().Changed("status-interval")
+			return runDaemon(cmd.Context(), options)
+		},
+	}
+
+	cmd.Flags().DurationVar(&options.interval, "interval", 0, "Time between fetches (default: prefetch.interval, else 15m)")
+	cmd.Flags().DurationVar(&options.statusInterval, "status-interval", 0, "Time between workspace status checks for events (default: daemon.status_interval, else 5s; 0 disables them)")
+	cmd.Flags().BoolVar(&noPrefetch, "no-prefetch", false, "Do not fetch registered repositories")
+	cmd.Flags().BoolVar(&noDiscover, "no-discover", false, "Do not watch the discovery paths")
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"interval":        carapace.ActionValues("5m", "10m", "15m", "30m", "1h"),
+		"status-interval": carapace.ActionValues("2s", "5s", "10s", "30s"),
+	})
+
+	return cmd
+}
+
+// daemonOptions are the command line settings of daemon run
+type daemonOptions struct {
+	interval          time.Duration
+	statusInterval    time.Duration
+	statusIntervalSet bool
+	prefetch          bool
+	discover          bool
+}
+
+func runDaemon(ctx context.Context, options daemonOptions) error {
+	config, interval, statusInterval, err := loadDaemonConfig(options)
+	if err != nil {
+		return err
+	}
+	if !options.prefetch && !options.discover && statusInterval == 0 {
+		return errors.New("--no-prefetch, --no-discover and a status interval of 0 leave nothing to do")
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	socketPath, err := wsm.EventSocketPath()
+	if err != nil {
+		return err
+	}
+	hub, err := wsm.ListenEvents(socketPath)
+	if err != nil {
+		return err
+	}
+	served := make(chan struct{})
+	go func() {
+		hub.Serve(ctx)
+		close(served)
+	}()
+	defer func() {
+		stop()
+		<-served
+	}()
+	output.PrintInfo("Publishing events on %s", socketPath)
+	webhooks := &daemonWebhooks{}
+	webhooks.set(config.Webhooks)
+	hub.Forward = webhooks.forward(ctx)
+
+	configPath, err := wsm.ConfigPath()
+	if err != nil {
+		return err
+	}
+	registry, err := newDaemonRegistry()
+	if err != nil {
+		return err
+	}
+	output.PrintInfo("Loaded %d repositories from %s", len(registry.repositories()), registry.path)
+
+	// The discovery watcher runs in its own goroutine and reads the configuration it was started
+	// with; it is restarted when a reload changes the discovery settings
+	watchErr := make(chan error, 1)
+	stopDiscovery := func() {}
+	if options.discover {
+		stopDiscovery = watchDaemonDiscovery(ctx, config, hub, registry, watchErr)
+	}
+	defer func() { stopDiscovery() }()
+
+	reloads := make(chan string)
+	go func() {
+		err := wsm.WatchFiles(ctx, []string{configPath, registry.path}, wsm.DaemonReloadDebounce, func(file string) {
+			select {
+			case reloads <- file:
+			case <-ctx.Done():
+			}
+		})
+		if err != nil && ctx.Err() == nil {
+			output.PrintWarning("Not reloading changes to %s: %v", configPath, err)
+		}
+	}()
+
+	var fetchTicks <-chan time.Time
+	var fetchTicker *time.Ticker
+	if options.prefetch {
+		output.PrintInfo("Fetching registered repositories every %s", interval)
+		fetchTicker = time.NewTicker(interval)
+		defer fetchTicker.Stop()
+		fetchTicks = fetchTicker.C
+		prefetchDaemon(ctx, config, registry, hub)
+	}
+
+	// The status checks back off while nothing changes, so they run on a timer reset after each
+	// check rather than on a ticker
+	var statusTicks <-chan time.Time
+	var snapshot map[string]*wsm.WorkspaceStatus
+	statusWait := statusInterval
+	statusTimer := time.NewTimer(statusInterval)
+	defer statusTimer.Stop()
+	if statusInterval > 0 {
+		statusTicks = statusTimer.C
+		snapshot = workspaceSnapshot(ctx, nil)
+	} else {
+		statusTimer.Stop()
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-watchErr:
+			if err != nil {
+				return err
+			}
+		case file := <-reloads:
+			if file == registry.path {
+				registry.reload(hub)
+				continue
+			}
+			next, nextInterval, nextStatusInterval, err := loadDaemonConfig(options)
+			if err != nil {
+				output.PrintWarning("Keeping the running configuration, %s is invalid: %v", configPath, err)
+				hub.Publish(wsm.Event{Type: wsm.EventConfigInvalid, Message: fmt.Sprintf("%s: %v", configPath, err)})
+				continue
+			}
+
+			changes := []string{}
+			if options.discover && (!slices.Equal(next.DiscoveryPaths, config.DiscoveryPaths) || !reflect.DeepEqual(next.Discovery, config.Discovery)) {
+				stopDiscovery()
+				stopDiscovery = watchDaemonDiscovery(ctx, next, hub, registry, watchErr)
+				changes = append(changes, "discovery paths")
+			}
+			if fetchTicker != nil && nextInterval != interval {
+				fetchTicker.Reset(nextInterval)
+				interval = nextInterval
+				changes = append(changes, "prefetch every "+interval.String())
+			}
+			if nextStatusInterval != statusInterval {
+				if statusInterval == 0 {
+					snapshot = workspaceSnapshot(ctx, nil)
+				}
+				statusInterval, statusWait = nextStatusInterval, nextStatusInterval
+				if statusInterval > 0 {
+					statusTimer.Reset(statusInterval)
+					statusTicks = statusTimer.C
+					changes = append(changes, "status every "+statusInterval.String())
+				} else {
+					statusTimer.Stop()
+					statusTicks = nil
+					changes = append(changes, "no status checks")
+				}
+			}
+			if !reflect.DeepEqual(next.Webhooks, config.Webhooks) {
+				webhooks.set(next.Webhooks)
+				changes = append(changes, "webhooks")
+			}
+			config = next
+
+			message := fmt.Sprintf("reloaded %s", configPath)
+			if len(changes) > 0 {
+				message += " (" + strings.Join(changes, ", ") + ")"
+			}
+			output.PrintInfo("%s", message)
+			hub.Publish(wsm.Event{Type: wsm.EventConfigReloaded, Message: message})
+		case <-fetchTicks:
+			prefetchDaemon(ctx, config, registry, hub)
+		case <-statusTicks:
+			current := workspaceSnapshot(ctx, snapshot)
+			events := wsm.StatusEvents(snapshot, current)
+			for _, event := range events {
+				hub.Publish(event)
+			}
+			snapshot = current
+			statusWait = wsm.NextStatusInterval(statusWait, statusInterval, len(events) > 0)
+			statusTimer.Reset(statusWait)
+		}
+	}
+}
+
+// daemonInterval returns the time between fetches: --interval, else prefetch.interval
+func daemonInterval(config *wsm.WorkspaceConfig, options daemonOptions) (time.Duration, error) {
+	if options.interval != 0 {
+		if options.interval < time.Minute {
+			return 0, errors.New("--interval must be at least 1m")
+		}
+		return options.interval, nil
+	}
+	interval, err := config.Prefetch.IntervalDuration()
+	if err != nil {
+		return 0, err
+	}
+	if interval < time.Minute {
+		return 0, errors.New("prefetch.interval must be at least 1m")
+	}
+	return interval, nil
+}
+
+// daemonStatusInterval returns the time between status checks: --status-interval, else
+// daemon.status_interval
+func daemonStatusInterval(config *wsm.WorkspaceConfig, options daemonOptions) (time.Duration, error) {
+	if options.statusIntervalSet {
+		if options.statusInterval < 0 {
+			return 0, errors.New("--status-interval must not be negative")
+		}
+		return options.statusInterval, nil
+	}
+	return config.Daemon.StatusIntervalDuration()
+}
+
+// loadDaemonConfig loads and validates config.yaml, on start and on each change: a configuration
+// the daemon could not start with is never swapped in. It returns the fetch and status intervals
+func loadDaemonConfig(options daemonOptions) (*wsm.WorkspaceConfig, time.Duration, time.Duration, error) {
+	config, err := wsm.LoadConfig()
+	if err != nil {
+		return nil, 0, 0, errors.Wrap(err, "failed to load configuration")
+	}
+	interval, err := daemonInterval(config, options)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	statusInterval, err := daemonStatusInterval(config, options)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	for _, path := range config.DiscoveryPaths {
+		if _, err := wsm.ExpandPath(path); err != nil {
+			return nil, 0, 0, err
+		}
+	}
+	return config, interval, statusInterval, nil
+}
+
+// watchDaemonDiscovery starts watching the discovery paths of config and returns the function
+// stopping it
+func watchDaemonDiscovery(ctx context.Context, config *wsm.WorkspaceConfig, hub *wsm.EventHub, registry *daemonRegistry, watchErr chan<- error) func() {
+	roots := map[string]int{}
+	for _, path := range config.DiscoveryPaths {
+		root, err := wsm.ExpandPath(path)
+		if err != nil {
+			output.PrintWarning("Not watching discovery path %s: %v", path, err)
+			continue
+		}
+		if _, err := os.Stat(root); err != nil {
+			output.PrintWarning("Not watching discovery path %s: %v", root, err)
+			continue
+		}
+		roots[root] = config.Discovery.DiscoveryOptionsFor(root, true, 0).MaxDepth
+	}
+	if len(roots) == 0 {
+		output.PrintInfo("No discovery_paths configured; not watching for new repositories")
+		return func() {}
+	}
+
+	output.PrintInfo("Watching %s for new repositories", strings.Join(slices.Sorted(maps.Keys(roots)), ", "))
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		err := wsm.WatchDiscoveryPaths(ctx, roots, wsm.DaemonDiscoveryDebounce, func(root string) {
+			if err := discoverDaemonRoot(ctx, config, root, hub); err != nil && ctx.Err() == nil {
+				output.PrintWarning("Discovery of %s failed: %v", root, err)
+			}
+			registry.remember()
+		})
+		if err != nil && ctx.Err() == nil {
+			watchErr <- err
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// daemonWebhooks sends the events of the daemon to the webhooks of its running configuration.
+// workspace.created and workspace.deleted are left out: the commands creating and deleting
+// workspaces send them as they happen.
+type daemonWebhooks struct {
+	mu       sync.Mutex
+	webhooks []wsm.WebhookConfig
+}
+
+func (d *daemonWebhooks) set(webhooks []wsm.WebhookConfig) {
+	d.mu.Lock()
+	d.webhooks = webhooks
+	d.mu.Unlock()
+}
+
+// forward returns the function the event hub calls with each event; deliveries run in the
+// background so that a slow webhook does not hold up the daemon
+func (d *daemonWebhooks) forward(ctx context.Context) func(wsm.Event) {
+	return func(event wsm.Event) {
+		if event.Type == wsm.EventWorkspaceCreated || event.Type == wsm.EventWorkspaceDeleted {
+			return
+		}
+		d.mu.Lock()
+		webhooks := d.webhooks
+		d.mu.Unlock()
+		if len(webhooks) == 0 {
+			return
+		}
+		go func() {
+			for _, failure := range wsm.NotifyWebhooks(ctx, webhooks, event) {
+				output.PrintWarning("%v", failure)
+			}
+		}()
+	}
+}
+
+// daemonRegistry holds the repositories the daemon prefetches: the last valid content of the
+// registry. The content is remembered, so that the daemon only reports the changes others make to
+// the registry, and an invalid registry leaves the running repositories in place.
+type daemonRegistry struct {
+	path  string
+	mu    sync.Mutex
+	sum   [sha256.Size]byte
+	repos []wsm.Repository
+}
+
+func newDaemonRegistry() (*daemonRegistry, error) {
+	path, err := getRegistryPath()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get registry path")
+	}
+	registry := &daemonRegistry{path: path}
+	if _, err := registry.load(); err != nil {
+		return nil, errors.Wrap(err, "failed to load registry")
+	}
+	return registry, nil
+}
+
+// repositories returns the running repositories
+func (r *daemonRegistry) repositories() []wsm.Repository {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.repos)
+}
+
+// load swaps in the repositories of the registry if it is valid and records its content as known.
+// It reports whether the content was known already, in which case nothing is swapped.
+func (r *daemonRegistry) load() (bool, error) {
+	data, err := os.ReadFile(r.path)
+	if err != nil && !os.IsNotExist(err) {
+		return false, errors.Wrapf(err, "failed to read %s", r.path)
+	}
+	sum := sha256.Sum256(data)
+	r.mu.Lock()
+	known := sum == r.sum && r.repos != nil
+	r.sum = sum
+	r.mu.Unlock()
+	if known {
+		return true, nil
+	}
+
+	discoverer := wsm.NewRepositoryDiscoverer(r.path)
+	if err := discoverer.LoadRegistry(); err != nil {
+		return false, err
+	}
+	repos := discoverer.GetRepositories()
+	if repos == nil {
+		repos = []wsm.Repository{}
+	}
+	r.mu.Lock()
+	r.repos = repos
+	r.mu.Unlock()
+	return false, nil
+}
+
+// remember loads the registry after the daemon wrote it, without reporting it as a reload. The
+// daemon only writes a registry it could load, so there is nothing to report on failure.
+func (r *daemonRegistry) remember() {
+	_, _ = r.load()
+}
+
+// reload swaps in a registry changed by someone else. An invalid registry is reported and the
+// running repositories are kept until it is fixed.
+func (r *daemonRegistry) reload(hub *wsm.EventHub) {
+	known, err := r.load()
+	if err != nil {
+		output.PrintWarning("Keeping the %d running repositories, %s is invalid: %v", len(r.repositories()), r.path, err)
+		hub.Publish(wsm.Event{Type: wsm.EventConfigInvalid, Message: fmt.Sprintf("%s: %v", r.path, err)})
+		return
+	}
+	if known {
+		return
+	}
+	message := fmt.Sprintf("reloaded %s (%d repositories)", r.path, len(r.repositories()))
+	output.PrintInfo("%s", message)
+	hub.Publish(wsm.Event{Type: wsm.EventConfigReloaded, Message: message})
+}
+
+// prefetchDaemon fetches the running repositories of registry and publishes the outcome
+func prefetchDaemon(ctx context.Context, config *wsm.WorkspaceConfig, registry *daemonRegistry, hub *wsm.EventHub) {
+	results, err := prefetchRepositories(ctx, registry.repositories(), config.Prefetch.Concurrency, config.Timeouts)
+	if err != nil {
+		if ctx.Err() == nil {
+			output.PrintWarning("Prefetch failed: %v", err)
+		}
+		return
+	}
+	event := wsm.Event{Type: wsm.EventFetchCompleted}
+	for _, result := range results {
+		if result.Error != "" {
+			event.Failed++
+		} else {
+			event.Fetched++
+		}
+	}
+	event.Message = fmt.Sprintf("fetched %d repositories, %d failed", event.Fetched, event.Failed)
+	hub.Publish(event)
+}
+
+// workspaceSnapshot takes the fast status of every workspace. A failure carries the previous
+// state over, so it is not reported as the workspace being deleted and created again: all of
+// previous when the workspaces cannot be loaded, the last status of a workspace whose status
+// cannot be taken
+func workspaceSnapshot(ctx context.Context, previous map[string]*wsm.WorkspaceStatus) map[string]*wsm.WorkspaceStatus {
+	workspaces, err := wsm.LoadWorkspaces()
+	if err != nil {
+		output.PrintWarning("Failed to load workspaces: %v", err)
+		return previous
+	}
+	checker := wsm.NewStatusChecker()
+	snapshot := map[string]*wsm.WorkspaceStatus{}
+	for i := range workspaces {
+		name := workspaces[i].Name
+		status, err := checker.GetFastWorkspaceStatus(ctx, &workspaces[i])
+		if err != nil {
+			if before, ok := previous[name]; ok {
+				snapshot[name] = before
+			}
+			continue
+		}
+		snapshot[name] = status
+	}
+	return snapshot
+}
+
+// discoverDaemonRoot scans a discovery path again and reports the repositories it registered
+func discoverDaemonRoot(ctx context.Context, config *wsm.WorkspaceConfig, root string, hub *wsm.EventHub) error {
+	discoverer, err := loadDiscoverer()
+	if err != nil {
+		return err
+	}
+	known := map[string]bool{}
+	for _, repo := range discoverer.GetRepositories() {
+		known[repo.Path] = true
+	}
+	err = discoverer.DiscoverRepositoriesWithOptions(ctx, []string{root}, func(root string) wsm.DiscoveryOptions {
+		return config.Discovery.DiscoveryOptionsFor(root, true, 0)
+	})
+	if err != nil {
+		return err
+	}
+	for _, repo := range discoverer.GetRepositories() {
+		if !known[repo.Path] {
+			output.PrintSuccess("Registered %s (%s)", repo.Name, repo.Path)
+			hub.Publish(wsm.Event{
+				Type:       wsm.EventRepositoryDiscovered,
+				Repository: repo.Name,
+				Branch:     repo.CurrentBranch,
+				Message:    fmt.Sprintf("registered %s (%s)", repo.Name, repo.Path),
+			})
+		}
+	}
+	return nil
+}
+
+// daemonService returns the service of the current profile for the service manager of the platform
+func daemonService(executable string) (wsm.DaemonService, error) {
+	if runtime.GOOS == "darwin" {
+		return wsm.LaunchdDaemonService(executable, wsm.CurrentProfile())
+	}
+	return wsm.SystemdDaemonService(executable, wsm.CurrentProfile())
+}
+
+// NewDaemonInstallCommand creates the daemon install command
+func NewDaemonInstallCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "install",
+		Short: "Install and start the daemon as a user service",
+		Long: `Write a systemd user service (Linux) or launchd agent (macOS) running
+'workspace-manager daemon run' and start it. The service runs the current
+executable; reinstall it after moving it. Installing again updates the service
+and restarts the daemon.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDaemonInstall(cmd.Context())
+		},
+	}
+}
+
+func runDaemonInstall(ctx context.Context) error {
+	executable, err := resolvedExecutable()
+	if err != nil {
+		return err
+	}
+	service, err := daemonService(executable)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(service.Path), 0755); err != nil {
+		return errors.Wrapf(err, "failed to create %s", filepath.Dir(service.Path))
+	}
+	if err := os.WriteFile(service.Path, []byte(service.Content), 0644); err != nil {
+		return errors.Wrapf(err, "failed to write %s", service.Path)
+	}
+	output.PrintInfo("Wrote %s", service.Path)
+
+	if service.Launchd {
+		if _, err := exec.LookPath("launchctl"); err != nil {
+			output.PrintWarning("launchctl not found; load the agent with 'launchctl bootstrap gui/%d %s'", os.Getuid(), service.Path)
+			return nil
+		}
+		// Unload a previous version of the agent first
+		_ = launchctl(ctx, "bootout", launchdTarget(service))
+		if err := launchctl(ctx, "bootstrap", launchdDomain(), service.Path); err != nil {
+			return err
+		}
+	} else {
+		if unitDir, err := wsm.SystemdUserUnitDir(); err == nil {
+			if _, err := os.Stat(filepath.Join(unitDir, wsm.PrefetchTimerName())); err == nil {
+				output.PrintWarning("The prefetch timer is installed as well; the daemon prefetches itself, remove the timer with 'workspace-manager prefetch timer uninstall'")
+			}
+		}
+		if _, err := exec.LookPath("systemctl"); err != nil {
+			output.PrintWarning("systemctl not found; start the service with 'systemctl --user enable --now %s'", service.Name)
+			return nil
+		}
+		if err := systemctlUser(ctx, "daemon-reload"); err != nil {
+			return err
+		}
+		if err := systemctlUser(ctx, "enable", service.Name); err != nil {
+			return err
+		}
+		if err := systemctlUser(ctx, "restart", service.Name); err != nil {
+			return err
+		}
+	}
+	output.PrintSuccess("Started %s", service.Name)
+	return nil
+}
+
+// NewDaemonStatusCommand creates the daemon status command
+func NewDaemonStatusCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show whether the daemon is installed and running",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDaemonStatus(cmd.Context())
+		},
+	}
+}
+
+func runDaemonStatus(ctx context.Context) error {
+	service, err := daemonService("")
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(service.Path); os.IsNotExist(err) {
+		output.PrintInfo("The daemon is not installed; install it with 'workspace-manager daemon install'")
+		return nil
+	}
+
+	fmt.Printf("Service:        %s\n", service.Name)
+	fmt.Printf("Unit file:      %s\n", service.Path)
+	fmt.Printf("State:          %s\n", daemonState(ctx, service))
+
+	lastDiscovery := "never"
+	if discoverer, err := loadDiscoverer(); err == nil && !discoverer.LastScan().IsZero() {
+		lastDiscovery = humanize.Time(discoverer.LastScan())
+	}
+	fmt.Printf("Last discovery: %s\n", lastDiscovery)
+	lastPrefetch := "never"
+	if state, err := wsm.LoadPrefetchState(); err == nil && !state.LastRun.IsZero() {
+		lastPrefetch = humanize.Time(state.LastRun)
+	}
+	fmt.Printf("Last prefetch:  %s\n", lastPrefetch)
+
+	fmt.Println()
+	if service.Launchd {
+		configDir, err := wsm.ConfigDir()
+		if err == nil {
+			output.PrintInfo("Logs: %s", filepath.Join(configDir, "daemon.log"))
+		}
+	} else {
+		output.PrintInfo("Logs: journalctl --user -u %s", service.Name)
+	}
+	return nil
+}
+
+// daemonState asks the service manager whether the daemon runs
+func daemonState(ctx context.Context, service wsm.DaemonService) string {
+	if service.Launchd {
+		out, err := exec.CommandContext(ctx, "launchctl", "print", launchdTarget(service)).Output()
+		if err != nil {
+			return "not loaded"
+		}
+		state, pid := "unknown", ""
+		for _, line := range strings.Split(string(out), "\n") {
+			key, value, ok := strings.Cut(strings.TrimSpace(line), " = ")
+			switch {
+			case !ok:
+			case key == "state" && state == "unknown":
+				state = value
+			case key == "pid" && pid == "":
+				pid = value
+			}
+		}
+		if pid != "" {
+			return fmt.Sprintf("%s (pid %s)", state, pid)
+		}
+		return state
+	}
+
+	out, err := exec.CommandContext(ctx, "systemctl", "--user", "show", service.Name,
+		"--property=ActiveState,SubState,MainPID,ActiveEnterTimestamp").Output()
+	if err != nil {
+		return "unknown (systemctl --user is not available)"
+	}
+	properties := map[string]string{}
+	for _, line := range strings.Split(string(out), "\n") {
+		if key, value, ok := strings.Cut(line, "="); ok {
+			properties[key] = value
+		}
+	}
+	state := fmt.Sprintf("%s (%s)", properties["ActiveState"], properties["SubState"])
+	if pid, _ := strconv.Atoi(properties["MainPID"]); pid > 0 {
+		state += fmt.Sprintf(", pid %d", pid)
+	}
+	if since := properties["ActiveEnterTimestamp"]; since != "" && properties["ActiveState"] == "active" {
+		state += ", since " + since
+	}
+	return state
+}
+
+// NewDaemonUninstallCommand creates the daemon uninstall command
+func NewDaemonUninstallCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "uninstall",
+		Short: "Stop the daemon and remove its service",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDaemonUninstall(cmd.Context())
+		},
+	}
+}
+
+func runDaemonUninstall(ctx context.Context) error {
+	service, err := daemonService("")
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(service.Path); os.IsNotExist(err) {
+		output.PrintInfo("The daemon is not installed")
+		return nil
+	}
+
+	if service.Launchd {
+		if _, err := exec.LookPath("launchctl"); err == nil {
+			if err := launchctl(ctx, "bootout", launchdTarget(service)); err != nil {
+				output.PrintWarning("%v", err)
+			}
+		}
+	} else if _, err := exec.LookPath("systemctl"); err == nil {
+		if err := systemctlUser(ctx, "disable", "--now", service.Name); err != nil {
+			output.PrintWarning("%v", err)
+		}
+	}
+
+	if err := os.Remove(service.Path); err != nil {
+		return errors.Wrapf(err, "failed to remove %s", service.Path)
+	}
+	output.PrintInfo("Removed %s", service.Path)
+
+	if !service.Launchd {
+		if _, err := exec.LookPath("systemctl"); err == nil {
+			if err := systemctlUser(ctx, "daemon-reload"); err != nil {
+				output.PrintWarning("%v", err)
+			}
+		}
+	}
+	output.PrintSuccess("Stopped and removed %s", service.Name)
+	return nil
+}
+
+// launchdDomain is the launchd domain of the user's agents
+func launchdDomain() string {
+	return "gui/" + strconv.Itoa(os.Getuid())
+}
+
+// launchdTarget is the service target of an agent in the user's domain
+func launchdTarget(service wsm.DaemonService) string {
+	return launchdDomain() + "/" + service.Name
+}
+
+func launchctl(ctx context.Context, args ...string) error {
+	out, err := exec.CommandContext(ctx, "launchctl", args...).CombinedOutput()
+	if err != nil {
+		return errors.Wrapf(err, "launchctl %s failed: %s", strings.Join(args, " "), strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package cmds
+
+import (
+	"context"
+	"slices"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/spf13/cobra"
+)
+
+// NewDecryptCommand creates the decrypt command
+func NewDecryptCommand() *cobra.Command {
+	var repos []string
+
+	cmd := &cobra.Command{
+		Use:   "decrypt [workspace-name]",
+		Short: "Unlock the git-crypt and blackbox files of a workspace",
+		Long: `Decrypt the repositories of a workspace that keep secrets with git-crypt or
+blackbox. New workspaces are unlocked on creation; this command unlocks the
+repositories that stayed locked, e.g. because no key was available then.
+
+git-crypt repositories are unlocked with, in order:
+  - the key file set for the repository in config.yaml:
+      encryption:
+        keys:
+          backend: ~/.keys/backend.git-crypt   # git-crypt export-key
+  - the keys of the main clone, when it is unlocked
+  - the GPG keys committed to the repository (git-crypt add-gpg-user)
+  - a key file asked for interactively
+
+blackbox repositories are decrypted with blackbox_decrypt_all_files and GPG.
+Set encryption.disabled to leave new workspaces locked until this command runs.
+'wsm status' marks the repositories that are still locked.
+
+Examples:
+  workspace-manager decrypt
+  workspace-manager decrypt my-feature --repos backend`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspaceName := ""
+			if len(args) > 0 {
+				workspaceName = args[0]
+			}
+			return silenceReported(cmd, runDecrypt(cmd.Context(), workspaceName, repos))
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&repos, "repos", nil, "Only unlock these repositories (comma-separated)")
+
+	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion())
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"repos": WorkspaceRepositoryCompletion().UniqueList(","),
+	})
+
+	return cmd
+}
+
+func runDecrypt(ctx context.Context, workspaceName string, repos []string) error {
+	wm, workspace, err := resolveManagedWorkspace(workspaceName)
+	if err != nil {
+		return err
+	}
+
+	output.PrintHeader("Unlocking workspace '%s'", workspace.Name)
+	encrypted, failed := 0, 0
+	for _, repo := range workspace.Repositories {
+		if len(repos) > 0 && !slices.Contains(repos, repo.Name) {
+			continue
+		}
+		state, err := wm.UnlockRepository(ctx, workspace, repo, true)
+		switch {
+		case state.Tool == "":
+			continue
+		case err != nil:
+			failed++
+			output.PrintError("%s: %v", repo.Name, err)
+		case state.Locked:
+			failed++
+			output.PrintError("%s: still locked after running %s", repo.Name, state.Tool)
+		default:
+			output.PrintSuccess("%s: %s files are unlocked", repo.Name, state.Tool)
+		}
+		encrypted++
+	}
+
+	if encrypted == 0 {
+		output.PrintInfo("No repository of workspace '%s' uses git-crypt or blackbox", workspace.Name)
+		return nil
+	}
+	if failed > 0 {
+		return &wsm.ExitCodeError{Code: 1}
+	}
+	return nil
+}
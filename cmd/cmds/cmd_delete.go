@@ -3,10 +3,14 @@ package cmds
 import (
 	"context"
 	"fmt"
+	"os"
+	"slices"
+	"sort"
 	"strings"
 
 	"github.com/carapace-sh/carapace"
 	"github.com/charmbracelet/huh"
+	"github.com/dustin/go-humanize"
 	"github.com/go-go-golems/workspace-manager/pkg/output"
 	"github.com/go-go-golems/workspace-manager/pkg/wsm"
 	"github.com/pkg/errors"
@@ -19,17 +23,24 @@ func NewDeleteCommand() *cobra.Command {
 		force          bool
 		forceWorktrees bool
 		removeFiles    bool
+		interactive    bool
 		outputFormat   string
+		selector       string
 	)
 
 	cmd := &cobra.Command{
-		Use:   "delete <workspace-name>",
+		Use:   "delete [workspace-name]",
 		Short: "Delete a workspace",
 		Long: `Delete a workspace and optionally remove its files.
 
 This command removes the workspace configuration and optionally deletes
 the workspace directory and all its contents. Use with caution.
 
+With --remove-files, the workspace directory and its worktrees are moved to
+the trash (~/.local/share/wsm/trash) and can be restored with 'undelete'
+until the retention configured as trash.retention ends (default 7d; "off"
+deletes permanently).
+
 Examples:
   # Delete workspace configuration only
   workspace-manager delete my-workspace
@@ -41,19 +52,50 @@ Examples:
   workspace-manager delete my-workspace --force --remove-files
 
   # Force worktree removal even with uncommitted changes
-  workspace-manager delete my-workspace --force-worktrees --remove-files`,
-		Args: cobra.ExactArgs(1),
+  workspace-manager delete my-workspace --force-worktrees --remove-files
+
+  # Pick several stale workspaces from a list showing age, dirty state and disk usage
+  workspace-manager delete --interactive --remove-files
+
+  # Delete every workspace labeled for an old release (see 'label'); with
+  # --interactive, the list is narrowed to the matching workspaces instead
+  workspace-manager delete --selector release=2026.09 --remove-files`,
+		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			labelSelector, err := wsm.ParseLabelSelector(selector)
+			if err != nil {
+				return err
+			}
+			if cmd.Flags().Changed("selector") && labelSelector.IsEmpty() {
+				return errors.New("--selector needs at least one requirement; it does not delete every workspace")
+			}
+			if (interactive || selector != "") && len(args) > 0 {
+				return errors.New("--interactive and --selector do not take a workspace name")
+			}
+			if interactive {
+				return runDeleteInteractive(cmd.Context(), labelSelector, forceWorktrees, removeFiles)
+			}
+			if selector != "" {
+				return runDeleteSelected(cmd.Context(), labelSelector, force, forceWorktrees, removeFiles)
+			}
+			if len(args) == 0 {
+				return errors.New("workspace name is required (or use --interactive or --selector)")
+			}
 			return runDelete(cmd.Context(), args[0], force, forceWorktrees, removeFiles, outputFormat)
 		},
 	}
 
 	cmd.Flags().BoolVarP(&force, "force", "f", false, "Force delete without confirmation")
 	cmd.Flags().BoolVar(&forceWorktrees, "force-worktrees", false, "Force worktree removal even with uncommitted changes")
-	cmd.Flags().BoolVar(&removeFiles, "remove-files", false, "Remove workspace files and directories")
+	cmd.Flags().BoolVar(&removeFiles, "remove-files", false, "Remove workspace files and directories (into the trash, see 'undelete')")
+	cmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Select several workspaces to delete from a list")
 	cmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format (table, json)")
+	cmd.Flags().StringVarP(&selector, "selector", "l", "", "Delete the workspaces whose labels match (team=payments,!keep)")
 
 	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion())
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"selector": LabelCompletion().UniqueList(","),
+	})
 
 	return cmd
 }
@@ -70,18 +112,24 @@ func runDelete(ctx context.Context, workspaceName string, force bool, forceWorkt
 		return errors.Wrapf(err, "workspace '%s' not found", workspaceName)
 	}
 
+	if err := manager.CheckDeletable(workspace); err != nil {
+		return err
+	}
+
 	// Show workspace status first
-	output.PrintHeader("Current workspace status")
-	checker := wsm.NewStatusChecker()
-	status, err := checker.GetWorkspaceStatus(ctx, workspace)
-	if err == nil {
-		if err := printStatusDetailed(status, false); err != nil {
-			output.PrintError("Error showing status: %v", err)
+	if !output.IsQuiet() {
+		output.PrintHeader("Current workspace status")
+		checker := wsm.NewStatusChecker()
+		status, err := checker.GetWorkspaceStatus(ctx, workspace)
+		if err == nil {
+			if err := printStatusDetailed(status, false); err != nil {
+				output.PrintError("Error showing status: %v", err)
+			}
+		} else {
+			output.PrintError("Error getting status: %v", err)
 		}
-	} else {
-		output.PrintError("Error getting status: %v", err)
+		output.Printf("\n")
 	}
-	fmt.Printf("\n")
 
 	// Show what will be deleted
 	if outputFormat == "json" {
@@ -89,46 +137,54 @@ func runDelete(ctx context.Context, workspaceName string, force bool, forceWorkt
 	}
 
 	output.PrintHeader("Workspace: %s", workspace.Name)
-	fmt.Printf("  Path: %s\n", workspace.Path)
-	fmt.Printf("  Repositories: %d\n", len(workspace.Repositories))
+	output.Printf("  Path: %s\n", workspace.Path)
+	output.Printf("  Repositories: %d\n", len(workspace.Repositories))
+	if len(workspace.Children) > 0 {
+		output.Printf("  Children: %s (detached, not deleted)\n", strings.Join(workspace.Children, ", "))
+	}
 
+	trash := removeFiles && manager.Config().Trash.Enabled()
 	output.PrintWarning("This will:")
-	if forceWorktrees {
-		fmt.Printf("  1. Remove git worktrees (git worktree remove --force)\n")
+	if trash {
+		output.Printf("  1. Move the workspace directory and its worktrees to the trash\n")
+		output.Printf("     Restore them with 'wsm undelete %s' within %s\n", workspaceName, trashRetention(manager))
+	} else if forceWorktrees {
+		output.Printf("  1. Remove git worktrees (git worktree remove --force)\n")
 	} else {
-		fmt.Printf("  1. Remove git worktrees (git worktree remove)\n")
+		output.Printf("  1. Remove git worktrees (git worktree remove)\n")
 		output.PrintWarning("     Will fail if there are uncommitted changes")
 	}
 
-	if removeFiles {
+	if trash {
+		output.Printf("  2. Remove workspace configuration\n")
+	} else if removeFiles {
 		output.PrintError("  2. DELETE the workspace directory and ALL its contents!")
-		fmt.Printf("     📁 This includes: go.work, AGENT.md, and all repository worktrees\n")
+		output.Printf("     📁 This includes: go.work, AGENT.md, and all repository worktrees\n")
 	} else {
-		fmt.Printf("  2. Remove workspace configuration\n")
-		fmt.Printf("  3. Clean up workspace-specific files (go.work, AGENT.md)\n")
-		fmt.Printf("  4. Repository worktrees will remain at: %s\n", workspace.Path)
+		output.Printf("  2. Remove workspace configuration\n")
+		output.Printf("  3. Clean up workspace-specific files (go.work, AGENT.md)\n")
+		output.Printf("  4. Repository worktrees will remain at: %s\n", workspace.Path)
 	}
 
 	// Confirm deletion unless forced
 	if !force {
+		if err := output.RequireInteractive("confirm deletion", "use --force to delete without confirmation"); err != nil {
+			return err
+		}
+
 		var confirmed bool
 		form := huh.NewForm(
 			huh.NewGroup(
 				huh.NewConfirm().
 					Title(fmt.Sprintf("Are you sure you want to delete workspace '%s'?", workspaceName)).
-					Description("This action cannot be undone.").
+					Description(undoDescription(manager, removeFiles)).
 					Value(&confirmed),
 			),
 		)
 
 		err := form.Run()
 		if err != nil {
-			// Check if user cancelled/aborted the form
-			errMsg := strings.ToLower(err.Error())
-			if strings.Contains(errMsg, "user aborted") ||
-				strings.Contains(errMsg, "cancelled") ||
-				strings.Contains(errMsg, "aborted") ||
-				strings.Contains(errMsg, "interrupt") {
+			if isFormAborted(err) {
 				output.PrintInfo("Operation cancelled.")
 				return nil
 			}
@@ -146,7 +202,10 @@ func runDelete(ctx context.Context, workspaceName string, force bool, forceWorkt
 		return errors.Wrap(err, "failed to delete workspace")
 	}
 
-	if removeFiles {
+	if trash {
+		output.PrintSuccess("Workspace '%s' moved to the trash", workspaceName)
+		output.PrintInfo("Restore it with: wsm undelete %s", workspaceName)
+	} else if removeFiles {
 		output.PrintSuccess("Workspace '%s' and all files deleted successfully", workspaceName)
 	} else {
 		output.PrintSuccess("Workspace configuration '%s' deleted successfully", workspaceName)
@@ -155,3 +214,213 @@ func runDelete(ctx context.Context, workspaceName string, force bool, forceWorkt
 
 	return nil
 }
+
+// runDeleteInteractive lets the user pick workspaces matching the selector from a list, shows a
+// summary of what will be deleted and deletes them after a single confirmation
+func runDeleteInteractive(ctx context.Context, selector wsm.LabelSelector, forceWorktrees bool, removeFiles bool) error {
+	if err := output.RequireInteractive("select workspaces", "pass a workspace name"); err != nil {
+		return err
+	}
+
+	manager, err := wsm.NewWorkspaceManager()
+	if err != nil {
+		return errors.Wrap(err, "failed to create workspace manager")
+	}
+
+	workspaces, err := wsm.LoadWorkspaces()
+	if err != nil {
+		return errors.Wrap(err, "failed to load workspaces")
+	}
+	candidates := selector.Filter(workspaces)
+	if len(candidates) == 0 {
+		output.PrintInfo("No workspaces found.")
+		return nil
+	}
+
+	stop := output.Spinner(os.Stderr, "Inspecting workspaces...")
+	usages := wsm.GetWorkspaceUsages(ctx, candidates)
+	stop()
+
+	// Oldest first: stale workspaces are the usual candidates
+	sort.Slice(usages, func(i, j int) bool {
+		return usages[i].Workspace.Created.Before(usages[j].Workspace.Created)
+	})
+
+	byName := make(map[string]wsm.WorkspaceUsage)
+	var options []huh.Option[string]
+	for _, usage := range usages {
+		byName[usage.Workspace.Name] = usage
+		options = append(options, huh.NewOption(formatWorkspaceUsage(usage), usage.Workspace.Name))
+	}
+
+	var selected []string
+	form := huh.NewForm(huh.NewGroup(
+		huh.NewMultiSelect[string]().
+			Title("Choose workspaces to delete:").
+			Options(options...).
+			Value(&selected),
+	))
+	if err := form.Run(); err != nil {
+		if isFormAborted(err) {
+			output.PrintInfo("Operation cancelled.")
+			return nil
+		}
+		return errors.Wrap(err, "interactive form failed")
+	}
+	if len(selected) == 0 {
+		output.PrintInfo("No workspaces selected.")
+		return nil
+	}
+
+	return deleteWorkspaces(ctx, manager, workspaces, byName, selected, false, forceWorktrees, removeFiles)
+}
+
+// runDeleteSelected deletes every workspace whose labels match the selector, after a single
+// confirmation unless forced
+func runDeleteSelected(ctx context.Context, selector wsm.LabelSelector, force, forceWorktrees, removeFiles bool) error {
+	manager, err := wsm.NewWorkspaceManager()
+	if err != nil {
+		return errors.Wrap(err, "failed to create workspace manager")
+	}
+
+	workspaces, err := wsm.LoadWorkspaces()
+	if err != nil {
+		return errors.Wrap(err, "failed to load workspaces")
+	}
+	matched := selector.Filter(workspaces)
+	if len(matched) == 0 {
+		output.PrintInfo("No workspaces match the selector %s", selector)
+		return nil
+	}
+
+	stop := output.Spinner(os.Stderr, "Inspecting workspaces...")
+	usages := wsm.GetWorkspaceUsages(ctx, matched)
+	stop()
+
+	byName := make(map[string]wsm.WorkspaceUsage)
+	var selected []string
+	for _, usage := range usages {
+		byName[usage.Workspace.Name] = usage
+		selected = append(selected, usage.Workspace.Name)
+	}
+	return deleteWorkspaces(ctx, manager, workspaces, byName, selected, force, forceWorktrees, removeFiles)
+}
+
+// deleteWorkspaces shows a summary of the selected workspaces, asks for confirmation unless forced
+// and deletes them, parents first
+func deleteWorkspaces(ctx context.Context, manager *wsm.WorkspaceManager, workspaces []wsm.Workspace, byName map[string]wsm.WorkspaceUsage, selected []string, force, forceWorktrees, removeFiles bool) error {
+	// Dry-run summary of the batch
+	output.PrintHeader("The following workspaces will be deleted")
+	var freed int64
+	for _, name := range selected {
+		usage := byName[name]
+		output.Printf("  %s\n", formatWorkspaceUsage(usage))
+		if usage.IsDirty() && !forceWorktrees {
+			output.PrintWarning("    uncommitted changes in %s: deletion will fail without --force-worktrees", strings.Join(usage.DirtyRepositories, ", "))
+		}
+		if parent := usage.Workspace.Parent; parent != "" && !slices.Contains(selected, parent) {
+			output.PrintWarning("    owned by '%s': deletion will fail unless it is detached first", parent)
+		}
+		freed += usage.DiskUsage
+	}
+	output.Printf("\n")
+	if removeFiles && manager.Config().Trash.Enabled() {
+		output.Printf("Workspace directories are moved to the trash (%s), restorable with 'wsm undelete' within %s\n", humanize.Bytes(uint64(freed)), trashRetention(manager))
+	} else if removeFiles {
+		output.PrintError("Workspace directories and ALL their contents will be deleted (%s)", humanize.Bytes(uint64(freed)))
+	} else {
+		output.Printf("Workspace configurations and worktrees are removed; files remain on disk (use --remove-files to free %s)\n", humanize.Bytes(uint64(freed)))
+	}
+
+	if !force {
+		if err := output.RequireInteractive("confirm deletion", "use --force to delete without confirmation"); err != nil {
+			return err
+		}
+		var confirmed bool
+		confirm := huh.NewForm(huh.NewGroup(
+			huh.NewConfirm().
+				Title(fmt.Sprintf("Delete %d workspaces?", len(selected))).
+				Description(undoDescription(manager, removeFiles)).
+				Value(&confirmed),
+		))
+		if err := confirm.Run(); err != nil {
+			if isFormAborted(err) {
+				output.PrintInfo("Operation cancelled.")
+				return nil
+			}
+			return errors.Wrap(err, "confirmation failed")
+		}
+		if !confirmed {
+			output.PrintInfo("Operation cancelled.")
+			return nil
+		}
+	}
+
+	// Parents go first: deleting a parent detaches its children, which can then be deleted too
+	depth := make(map[string]int)
+	for _, name := range selected {
+		workspace := byName[name].Workspace
+		depth[name] = wsm.NestingDepth(&workspace, workspaces)
+	}
+	sort.SliceStable(selected, func(i, j int) bool {
+		return depth[selected[i]] < depth[selected[j]]
+	})
+
+	var failed []string
+	for _, name := range selected {
+		if err := manager.DeleteWorkspace(ctx, name, removeFiles, forceWorktrees); err != nil {
+			output.PrintError("Failed to delete workspace '%s': %v", name, err)
+			failed = append(failed, name)
+			continue
+		}
+		output.PrintSuccess("Deleted workspace '%s'", name)
+	}
+
+	if len(failed) > 0 {
+		return errors.Errorf("failed to delete %d of %d workspaces: %s", len(failed), len(selected), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// trashRetention returns how long deleted workspaces stay in the trash, e.g. "7d"
+func trashRetention(manager *wsm.WorkspaceManager) string {
+	if retention := manager.Config().Trash.Retention; retention != "" {
+		return retention
+	}
+	return "7d"
+}
+
+// undoDescription tells whether a deletion can be undone in confirmation prompts
+func undoDescription(manager *wsm.WorkspaceManager, removeFiles bool) string {
+	if removeFiles && manager.Config().Trash.Enabled() {
+		return fmt.Sprintf("It can be undone with 'wsm undelete' within %s.", trashRetention(manager))
+	}
+	return "This action cannot be undone."
+}
+
+// formatWorkspaceUsage renders a workspace as "name  age  state  size  repositories"
+func formatWorkspaceUsage(usage wsm.WorkspaceUsage) string {
+	state := "clean"
+	switch {
+	case usage.Missing:
+		state = "missing"
+	case usage.IsDirty():
+		state = fmt.Sprintf("dirty (%d)", len(usage.DirtyRepositories))
+	}
+
+	return fmt.Sprintf("%-24s %-14s %-10s %8s  %d repos",
+		usage.Workspace.Name,
+		humanize.Time(usage.Workspace.Created),
+		state,
+		humanize.Bytes(uint64(usage.DiskUsage)),
+		len(usage.Workspace.Repositories))
+}
+
+// isFormAborted reports whether a huh form was cancelled by the user
+func isFormAborted(err error) bool {
+	errMsg := strings.ToLower(err.Error())
+	return strings.Contains(errMsg, "user aborted") ||
+		strings.Contains(errMsg, "cancelled") ||
+		strings.Contains(errMsg, "aborted") ||
+		strings.Contains(errMsg, "interrupt")
+}
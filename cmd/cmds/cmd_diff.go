@@ -3,8 +3,16 @@ package cmds
 import (
 	"context"
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/term"
 	"github.com/go-go-golems/workspace-manager/pkg/output"
 	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/muesli/termenv"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
@@ -12,27 +20,72 @@ import (
 
 func NewDiffCommand() *cobra.Command {
 	var (
-		staged bool
-		repo   string
+		staged   bool
+		repo     string
+		stat     bool
+		numstat  bool
+		nameOnly bool
+		split    bool
+		plain    bool
 	)
 
 	cmd := &cobra.Command{
 		Use:   "diff",
 		Short: "Show diff across workspace repositories",
 		Long: `Show unified diff of changes across all repositories in the workspace.
-This provides a consolidated view of all modifications in your multi-repository development.`,
+This provides a consolidated view of all modifications in your multi-repository development.
+
+Instead of the full patch, --stat shows per-file and per-repository summaries,
+--numstat prints machine-readable "<repo> <added> <deleted> <path>" lines and
+--name-only lists the changed files as <repo>/<path>.
+
+On a terminal the patch is rendered with syntax highlighting and word-level
+highlighting of changed lines; --split shows the old and new version side by
+side. When the output is not a terminal (or with --plain or NO_COLOR), the
+plain unified diff is printed.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runDiff(cmd.Context(), staged, repo)
+			repo = resolveRepositoryAlias(repo)
+			// Decided before paging replaces stdout with a pipe
+			render := diffRenderOptions(plain, split)
+			defer output.StartPager()()
+			switch {
+			case stat:
+				return runDiffSummary(cmd.Context(), staged, repo, "stat")
+			case numstat:
+				return runDiffSummary(cmd.Context(), staged, repo, "numstat")
+			case nameOnly:
+				return runDiffSummary(cmd.Context(), staged, repo, "name-only")
+			}
+			return runDiff(cmd.Context(), staged, repo, render)
 		},
 	}
 
 	cmd.Flags().BoolVar(&staged, "staged", false, "Show staged changes only")
 	cmd.Flags().StringVar(&repo, "repo", "", "Show diff for specific repository only")
+	cmd.Flags().BoolVar(&stat, "stat", false, "Show insertions/deletions per file and repository instead of the patch")
+	cmd.Flags().BoolVar(&numstat, "numstat", false, "Show machine-readable insertion/deletion counts per file")
+	cmd.Flags().BoolVar(&nameOnly, "name-only", false, "Only show the names of changed files")
+	cmd.Flags().BoolVar(&split, "split", false, "Show old and new side by side")
+	cmd.Flags().BoolVar(&plain, "plain", false, "Print the plain unified diff without highlighting")
+	cmd.MarkFlagsMutuallyExclusive("stat", "numstat", "name-only")
+	cmd.MarkFlagsMutuallyExclusive("split", "plain")
 
 	return cmd
 }
 
-func runDiff(ctx context.Context, staged bool, repoFilter string) error {
+// diffRenderOptions returns how to render the patch, or nil to print the plain unified diff
+func diffRenderOptions(plain, split bool) *output.DiffOptions {
+	if plain || !output.IsTerminal(os.Stdout) || lipgloss.ColorProfile() == termenv.Ascii {
+		return nil
+	}
+	width, _, err := term.GetSize(os.Stdout.Fd())
+	if err != nil {
+		width = 0
+	}
+	return &output.DiffOptions{Split: split, Width: width, Dark: lipgloss.HasDarkBackground()}
+}
+
+func runDiff(ctx context.Context, staged bool, repoFilter string, render *output.DiffOptions) error {
 	workspace, err := detectCurrentWorkspace()
 	if err != nil {
 		return errors.Wrap(err, "failed to detect current workspace")
@@ -59,67 +112,318 @@ func runDiff(ctx context.Context, staged bool, repoFilter string) error {
 		return nil
 	}
 
-	fmt.Println(diff)
+	if render == nil {
+		fmt.Println(diff)
+		return nil
+	}
+
+	// Render the patch of every repository below its header
+	var patch []string
+	flush := func() {
+		if len(patch) > 0 {
+			fmt.Print(output.RenderDiff(strings.Join(patch, "\n"), *render))
+			patch = nil
+		}
+	}
+	for _, line := range strings.Split(diff, "\n") {
+		if strings.HasPrefix(line, "=== Repository: ") {
+			flush()
+			output.PrintHeader("%s", line)
+			continue
+		}
+		patch = append(patch, line)
+	}
+	flush()
 	return nil
 }
 
+// runDiffSummary prints diff statistics in one of the stat, numstat or name-only modes
+func runDiffSummary(ctx context.Context, staged bool, repoFilter, mode string) error {
+	workspace, err := detectCurrentWorkspace()
+	if err != nil {
+		return errors.Wrap(err, "failed to detect current workspace")
+	}
+
+	gitOps := wsm.NewGitOperations(workspace)
+	stats, err := gitOps.GetDiffStats(ctx, staged, repoFilter)
+	if err != nil {
+		return errors.Wrap(err, "failed to get diff stats")
+	}
+
+	switch mode {
+	case "numstat":
+		for _, repoStat := range stats {
+			for _, file := range repoStat.Files {
+				added, deleted := "-", "-"
+				if !file.Binary {
+					added, deleted = strconv.Itoa(file.Insertions), strconv.Itoa(file.Deletions)
+				}
+				fmt.Printf("%s\t%s\t%s\t%s\n", repoStat.Repository, added, deleted, file.Path)
+			}
+		}
+		return nil
+	case "name-only":
+		for _, repoStat := range stats {
+			for _, file := range repoStat.Files {
+				fmt.Printf("%s/%s\n", repoStat.Repository, file.Path)
+			}
+		}
+		return nil
+	}
+
+	if len(stats) == 0 {
+		output.PrintInfo("No changes found in workspace.")
+		return nil
+	}
+
+	printDiffStat(stats)
+	return nil
+}
+
+// diffStatBarWidth is the maximum width of the +/- histogram of diff --stat
+const diffStatBarWidth = 40
+
+func printDiffStat(stats []wsm.RepositoryDiffStat) {
+	maxChanges, maxPath := 0, 0
+	for _, repoStat := range stats {
+		for _, file := range repoStat.Files {
+			maxChanges = max(maxChanges, file.Insertions+file.Deletions)
+			maxPath = max(maxPath, len(file.Path))
+		}
+	}
+
+	totalFiles, totalInsertions, totalDeletions := 0, 0, 0
+	for _, repoStat := range stats {
+		output.PrintHeader("=== Repository: %s ===", repoStat.Repository)
+		for _, file := range repoStat.Files {
+			if file.Binary {
+				fmt.Printf(" %-*s | Bin\n", maxPath, file.Path)
+				continue
+			}
+
+			plus, minus := file.Insertions, file.Deletions
+			if maxChanges > diffStatBarWidth {
+				plus = scaleDiffStat(file.Insertions, maxChanges)
+				minus = scaleDiffStat(file.Deletions, maxChanges)
+			}
+			fmt.Printf(" %-*s | %5d %s%s\n", maxPath, file.Path, file.Insertions+file.Deletions,
+				output.SuccessStyle.Render(strings.Repeat("+", plus)),
+				output.ErrorStyle.Render(strings.Repeat("-", minus)))
+		}
+		fmt.Printf(" %s\n\n", diffStatSummary(len(repoStat.Files), repoStat.Insertions, repoStat.Deletions))
+
+		totalFiles += len(repoStat.Files)
+		totalInsertions += repoStat.Insertions
+		totalDeletions += repoStat.Deletions
+	}
+
+	output.PrintHeader("Total: %d repositories, %s", len(stats), diffStatSummary(totalFiles, totalInsertions, totalDeletions))
+}
+
+// scaleDiffStat scales a change count to the histogram width, keeping at least one mark for non-zero counts
+func scaleDiffStat(n, maxChanges int) int {
+	if n == 0 {
+		return 0
+	}
+	return max(1, n*diffStatBarWidth/maxChanges)
+}
+
+func diffStatSummary(files, insertions, deletions int) string {
+	return fmt.Sprintf("%d files changed, %d insertions(+), %d deletions(-)", files, insertions, deletions)
+}
+
 func NewLogCommand() *cobra.Command {
 	var (
-		since   string
+		options wsm.TimelineOptions
 		oneline bool
-		limit   int
+		format  string
+		file    string
+		patch   bool
 	)
 
 	cmd := &cobra.Command{
 		Use:   "log",
 		Short: "Show commit history across workspace repositories",
-		Long: `Show commit history spanning multiple repositories in the workspace.
-This provides a unified view of development activity across your projects.`,
+		Long: `Show the commits of all repositories in the workspace as one chronological
+timeline, newest first, each commit tagged with its repository. Long output is
+paged (see --no-pager).
+
+With --file, show the history of one file instead, following renames. The path
+is relative to the current directory or to the workspace root (api/go.mod),
+so there is no need to cd into the worktree of its repository first; --patch
+adds the change of every commit to the file.
+
+Examples:
+  workspace-manager log --since "1 week ago"
+  workspace-manager log --author alice --grep "fix" --limit 20
+  workspace-manager log --since 2026-10-01 --until 2026-10-08 --oneline
+  workspace-manager log --file api/internal/server/server.go --patch`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runLog(cmd.Context(), since, oneline, limit)
+			if patch && file == "" {
+				return errors.New("--patch requires --file")
+			}
+			// Decided before paging replaces stdout with a pipe
+			render := diffRenderOptions(false, false)
+			if format != "json" {
+				defer output.StartPager()()
+			}
+			if file != "" {
+				return runFileLog(cmd.Context(), file, options, patch, oneline, format, render)
+			}
+			return runLog(cmd.Context(), options, oneline, format)
 		},
 	}
 
-	cmd.Flags().StringVar(&since, "since", "", "Show commits since date (e.g., '1 week ago')")
-	cmd.Flags().BoolVar(&oneline, "oneline", false, "Show one line per commit")
-	cmd.Flags().IntVar(&limit, "limit", 10, "Limit number of commits per repository")
+	cmd.Flags().StringVar(&options.Since, "since", "", "Show commits since date (e.g., '1 week ago')")
+	cmd.Flags().StringVar(&options.Until, "until", "", "Show commits until date")
+	cmd.Flags().StringSliceVar(&options.Authors, "author", nil, "Only show commits by matching authors (name or email pattern, repeatable)")
+	cmd.Flags().StringVar(&options.Grep, "grep", "", "Only show commits whose message matches the pattern (case-insensitive)")
+	cmd.Flags().IntVar(&options.Limit, "limit", 50, "Maximum number of commits in the timeline (0 for all)")
+	cmd.Flags().BoolVar(&oneline, "oneline", false, "Show one line per commit without day headers")
+	cmd.Flags().StringVar(&format, "format", "text", "Output format: text, json")
+	cmd.Flags().StringVar(&file, "file", "", "Show the history of this file, following renames")
+	cmd.Flags().BoolVarP(&patch, "patch", "p", false, "Show the change of every commit to --file")
+
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"format": carapace.ActionValues("text", "json"),
+		"file":   carapace.ActionFiles(),
+	})
 
 	return cmd
 }
 
-func runLog(ctx context.Context, since string, oneline bool, limit int) error {
+func runFileLog(ctx context.Context, file string, options wsm.TimelineOptions, patch, oneline bool, format string, render *output.DiffOptions) error {
 	workspace, err := detectCurrentWorkspace()
 	if err != nil {
 		return errors.Wrap(err, "failed to detect current workspace")
 	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return errors.Wrap(err, "failed to get current directory")
+	}
+	repo, path, err := wsm.ResolveWorkspaceFile(workspace, file, cwd)
+	if err != nil {
+		return err
+	}
 
-	syncOps := wsm.NewSyncOperations(workspace)
+	commits, err := wsm.GetFileHistory(ctx, workspace, repo, path, options, patch)
+	if err != nil {
+		return err
+	}
 
-	output.PrintHeader("📜 Commit history for workspace: %s", workspace.Name)
-	if since != "" {
-		output.PrintInfo("   (since: %s)", since)
+	if format == "json" {
+		return wsm.PrintJSON(commits)
 	}
+
+	output.PrintHeader("📜 History of %s/%s", repo.Name, path)
 	fmt.Println()
+	if len(commits) == 0 {
+		output.PrintInfo("No commits found for %s in %s.", path, repo.Name)
+		return nil
+	}
+
+	for i, commit := range commits {
+		hash := output.WarningStyle.UnsetBold().Render(commit.ShortHash)
+		renamed := ""
+		if commit.Path != path {
+			renamed = output.DimStyle.Render(" (as " + commit.Path + ")")
+		}
+		if oneline {
+			fmt.Printf("%s %s%s\n", hash, commit.Subject, renamed)
+		} else {
+			fmt.Printf("%s %s %s%s %s\n", hash, output.DimStyle.Render(commit.Date.Local().Format("2006-01-02 15:04")),
+				commit.Subject, renamed, output.DimStyle.Render("— "+commit.Author))
+		}
+		if !patch || commit.Patch == "" {
+			continue
+		}
+		if render == nil {
+			fmt.Println(commit.Patch)
+		} else {
+			fmt.Print(output.RenderDiff(commit.Patch, *render))
+		}
+		if i < len(commits)-1 {
+			fmt.Println()
+		}
+	}
+	if options.Limit > 0 && len(commits) == options.Limit {
+		fmt.Println()
+		output.PrintInfo("Showing the latest %d commits; use --limit to see more", options.Limit)
+	}
+	return nil
+}
 
-	logs, err := syncOps.GetWorkspaceLog(ctx, since, oneline, limit)
+func runLog(ctx context.Context, options wsm.TimelineOptions, oneline bool, format string) error {
+	workspace, err := detectCurrentWorkspace()
+	if err != nil {
+		return errors.Wrap(err, "failed to detect current workspace")
+	}
+
+	commits, err := wsm.GetWorkspaceTimeline(ctx, workspace, options)
 	if err != nil {
 		return errors.Wrap(err, "failed to get workspace log")
 	}
 
-	if len(logs) == 0 {
+	if format == "json" {
+		return wsm.PrintJSON(commits)
+	}
+
+	output.PrintHeader("📜 Commit history for workspace: %s", workspace.Name)
+	if options.Since != "" || options.Until != "" {
+		output.PrintInfo("   (since: %s, until: %s)", orDash(options.Since), orDash(options.Until))
+	}
+	fmt.Println()
+
+	if len(commits) == 0 {
 		output.PrintInfo("No commits found in workspace.")
 		return nil
 	}
 
-	for repoName, log := range logs {
-		if log == "" {
+	printTimeline(workspace, commits, oneline)
+	if options.Limit > 0 && len(commits) == options.Limit {
+		fmt.Println()
+		output.PrintInfo("Showing the latest %d commits; use --limit to see more", options.Limit)
+	}
+	return nil
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// repoBadgeColors are the colors of repository badges, assigned in workspace order
+var repoBadgeColors = []string{"12", "10", "11", "13", "14", "9", "6", "3", "5", "2"}
+
+// printTimeline prints the commits with a colored badge per repository, grouped by day unless oneline
+func printTimeline(workspace *wsm.Workspace, commits []wsm.TimelineCommit, oneline bool) {
+	badges := map[string]lipgloss.Style{}
+	width := 0
+	for i, repo := range workspace.Repositories {
+		badges[repo.Name] = lipgloss.NewStyle().Foreground(lipgloss.Color(repoBadgeColors[i%len(repoBadgeColors)])).Bold(true)
+		width = max(width, len(repo.Name))
+	}
+
+	day := ""
+	for _, commit := range commits {
+		badge := badges[commit.Repository].Render(fmt.Sprintf("%-*s", width+2, "["+commit.Repository+"]"))
+		hash := output.WarningStyle.UnsetBold().Render(commit.ShortHash)
+		if oneline {
+			fmt.Printf("%s %s %s\n", badge, hash, commit.Subject)
 			continue
 		}
 
-		output.PrintHeader("=== Repository: %s ===", repoName)
-		fmt.Println(log)
-		fmt.Println()
+		local := commit.Date.Local()
+		if current := local.Format("Mon, 02 Jan 2006"); current != day {
+			if day != "" {
+				fmt.Println()
+			}
+			day = current
+			fmt.Println(output.BoldStyle.Render("── " + day + " ──"))
+		}
+		fmt.Printf("%s %s %s %s %s\n", output.DimStyle.Render(local.Format("15:04")), badge, hash, commit.Subject,
+			output.DimStyle.Render("— "+commit.Author))
 	}
-
-	return nil
 }
@@ -0,0 +1,115 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/spf13/cobra"
+)
+
+// NewDiffWorkspacesCommand creates the diff-workspaces command
+func NewDiffWorkspacesCommand() *cobra.Command {
+	var (
+		summary bool
+		format  string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "diff-workspaces <workspace-a> <workspace-b>",
+		Short: "Compare the branches of two workspaces repository by repository",
+		Long: `Compare two workspaces, e.g. parallel attempts at the same multi-repository
+feature. For every repository present in both, list the commits each branch has
+that the other lacks and the diffstat from the first to the second; then list
+the repositories only one of the workspaces has.
+
+Examples:
+  workspace-manager diff-workspaces login-fix login-fix-alt
+  workspace-manager diff-workspaces login-fix login-fix-alt --summary
+  workspace-manager diff-workspaces login-fix login-fix-alt --format json`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDiffWorkspaces(cmd.Context(), args[0], args[1], summary, format)
+		},
+	}
+
+	cmd.Flags().BoolVar(&summary, "summary", false, "Only show per-repository totals, not the commits")
+	cmd.Flags().StringVar(&format, "format", "text", "Output format: text, json")
+
+	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion(), WorkspaceNameCompletion())
+
+	return cmd
+}
+
+func runDiffWorkspaces(ctx context.Context, nameA, nameB string, summary bool, format string) error {
+	a, err := loadWorkspace(nameA)
+	if err != nil {
+		return err
+	}
+	b, err := loadWorkspace(nameB)
+	if err != nil {
+		return err
+	}
+
+	comparison := wsm.CompareWorkspaces(ctx, a, b)
+
+	if format == "json" {
+		return wsm.PrintJSON(comparison)
+	}
+
+	defer output.StartPager()()
+
+	output.PrintHeader("Comparing workspace '%s' with '%s'", a.Name, b.Name)
+
+	identical := 0
+	for _, repo := range comparison.Repositories {
+		fmt.Println()
+		if repo.Error != "" {
+			output.PrintError("%s: %s", repo.Repository, repo.Error)
+			continue
+		}
+
+		branches := fmt.Sprintf("%s ↔ %s", orDash(repo.BranchA), orDash(repo.BranchB))
+		fmt.Printf("%s  %s\n", output.InfoStyle.Render(repo.Repository), output.DimStyle.Render(branches))
+		if repo.Identical() {
+			identical++
+			fmt.Printf("  same commit (%s)\n", shortHash(repo.HeadA))
+			continue
+		}
+		fmt.Printf("  %d commits only in %s, %d only in %s, %d files changed, +%d -%d (from %s)\n",
+			len(repo.OnlyInA), a.Name, len(repo.OnlyInB), b.Name, repo.FilesChanged, repo.Insertions, repo.Deletions, shortHash(repo.MergeBase))
+
+		if !summary {
+			printComparedCommits(a.Name, repo.OnlyInA)
+			printComparedCommits(b.Name, repo.OnlyInB)
+		}
+	}
+
+	if len(comparison.OnlyInA) > 0 || len(comparison.OnlyInB) > 0 {
+		fmt.Println()
+	}
+	if len(comparison.OnlyInA) > 0 {
+		fmt.Printf("Only in %s: %s\n", a.Name, strings.Join(comparison.OnlyInA, ", "))
+	}
+	if len(comparison.OnlyInB) > 0 {
+		fmt.Printf("Only in %s: %s\n", b.Name, strings.Join(comparison.OnlyInB, ", "))
+	}
+
+	fmt.Println()
+	fmt.Printf("Total: %d shared repositories, %d identical, %d only in %s, %d only in %s\n",
+		len(comparison.Repositories), identical, len(comparison.OnlyInA), a.Name, len(comparison.OnlyInB), b.Name)
+	return nil
+}
+
+func printComparedCommits(workspace string, commits []wsm.CommitSummary) {
+	if len(commits) == 0 {
+		return
+	}
+	fmt.Printf("    %s\n", output.DimStyle.Render("only in "+workspace+":"))
+	for _, commit := range commits {
+		fmt.Printf("      %s %s\n", output.DimStyle.Render(commit.Hash), commit.Subject)
+	}
+}
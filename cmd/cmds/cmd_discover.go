@@ -2,40 +2,105 @@ package cmds
 
 import (
 	"context"
-	"github.com/go-go-golems/workspace-manager/pkg/output"
-	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
 func NewDiscoverCommand() *cobra.Command {
 	var (
-		recursive bool
-		maxDepth  int
+		recursive      bool
+		maxDepth       int
+		followSymlinks bool
+		include        []string
+		exclude        []string
+		jobs           int
 	)
 
 	cmd := &cobra.Command{
 		Use:   "discover [paths...]",
 		Short: "Discover git repositories in specified directories",
 		Long: `Discover git repositories in the specified directories and add them to the registry.
-If no paths are specified, defaults to current directory.`,
+If no paths are specified, scans the discovery_paths of config.yaml, or the
+current directory when none are configured.
+
+Directories are read and repositories analyzed in parallel. Hidden directories,
+node_modules, vendor and target are skipped; the discovery section of
+config.yaml sets the default depth, symlink policy, parallelism and exclude
+globs, and include/exclude globs per path:
+
+  discovery:
+    max_depth: 4
+    follow_symlinks: false
+    exclude: ["Library", "**/testdata"]
+    paths:
+      ~/code:
+        max_depth: 2
+        include: ["go-go-golems/*"]
+        exclude: ["archive/**"]
+
+Globs are matched against the path relative to the scanned directory; a glob
+without a slash matches a directory name at any depth. When config.yaml cannot
+be loaded, the paths given are scanned with the default settings.`,
 		Args: cobra.MinimumNArgs(0),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runDiscover(cmd.Context(), args, recursive, maxDepth)
+			if !cmd.Flags().Changed("max-depth") {
+				maxDepth = 0
+			}
+			options := discoverOptions{
+				recursive:      recursive,
+				maxDepth:       maxDepth,
+				followSymlinks: followSymlinks,
+				include:        include,
+				exclude:        exclude,
+				jobs:           jobs,
+			}
+			return runDiscover(cmd.Context(), args, options)
 		},
 	}
 
 	cmd.Flags().BoolVarP(&recursive, "recursive", "r", true, "Recursively scan subdirectories")
-	cmd.Flags().IntVar(&maxDepth, "max-depth", 3, "Maximum depth for recursive scanning")
+	cmd.Flags().IntVar(&maxDepth, "max-depth", 3, "Maximum depth for recursive scanning (default: discovery.max_depth, else 3)")
+	cmd.Flags().BoolVar(&followSymlinks, "follow-symlinks", false, "Descend into symlinked directories")
+	cmd.Flags().StringSliceVar(&include, "include", nil, "Only register repositories matching these globs")
+	cmd.Flags().StringSliceVar(&exclude, "exclude", nil, "Skip directories matching these globs")
+	cmd.Flags().IntVarP(&jobs, "jobs", "j", 0, "Directories scanned in parallel (default: discovery.concurrency, else the number of CPUs)")
 
 	return cmd
 }
 
-func runDiscover(ctx context.Context, paths []string, recursive bool, maxDepth int) error {
-	// Default to current directory if no paths specified
+// discoverOptions are the command line settings of discover, applied over config.yaml
+type discoverOptions struct {
+	recursive      bool
+	maxDepth       int
+	followSymlinks bool
+	include        []string
+	exclude        []string
+	jobs           int
+}
+
+func runDiscover(ctx context.Context, paths []string, options discoverOptions) error {
+	// The paths given on the command line can be scanned with the default settings when config.yaml
+	// is broken; without them, the configured discovery paths are needed
+	config, err := wsm.LoadConfig()
+	if err != nil {
+		if len(paths) == 0 {
+			return errors.Wrap(err, "failed to load configuration")
+		}
+		output.PrintWarning("Ignoring the discovery settings of config.yaml: %v", err)
+		config = &wsm.WorkspaceConfig{}
+	}
+
+	// Default to the configured discovery paths, then to the current directory
+	if len(paths) == 0 {
+		paths = config.DiscoveryPaths
+	}
 	if len(paths) == 0 {
 		cwd, err := os.Getwd()
 		if err != nil {
@@ -84,13 +149,30 @@ func runDiscover(ctx context.Context, paths []string, recursive bool, maxDepth i
 
 	// Discover repositories
 	output.PrintInfo("Discovering repositories in %v", expandedPaths)
-	if err := discoverer.DiscoverRepositories(ctx, expandedPaths, recursive, maxDepth); err != nil {
+	start := time.Now()
+	progress := newDiscoverProgress()
+	err = discoverer.DiscoverRepositoriesWithOptions(ctx, expandedPaths, func(root string) wsm.DiscoveryOptions {
+		opts := config.Discovery.DiscoveryOptionsFor(root, options.recursive, options.maxDepth)
+		opts.FollowSymlinks = opts.FollowSymlinks || options.followSymlinks
+		opts.Include = append(opts.Include, options.include...)
+		opts.Exclude = append(opts.Exclude, options.exclude...)
+		if options.jobs > 0 {
+			opts.Concurrency = options.jobs
+		}
+		opts.Progress = progress.report(root)
+		return opts
+	})
+	if err != nil {
 		return errors.Wrap(err, "discovery failed")
 	}
+	output.PrintInfo("Scanned %d directories in %s", progress.dirs, time.Since(start).Round(time.Millisecond))
 
 	// Show results
 	repos := discoverer.GetRepositories()
 	output.PrintSuccess("Discovery complete! Found %d repositories", len(repos))
+	for _, conflict := range discoverer.AliasConflicts() {
+		output.PrintWarning("%s", conflict)
+	}
 
 	if len(repos) > 0 {
 		output.PrintInfo("Use 'workspace-manager list repos' to see all discovered repositories")
@@ -101,9 +183,32 @@ func runDiscover(ctx context.Context, paths []string, recursive bool, maxDepth i
 
 // getRegistryPath returns the path to the registry file
 func getRegistryPath() (string, error) {
-	configDir, err := os.UserConfigDir()
-	if err != nil {
-		return "", err
+	return wsm.RegistryPath()
+}
+
+// discoverProgress shows the number of directories scanned on one stderr line while discover
+// runs in a terminal
+type discoverProgress struct {
+	enabled bool
+	dirs    int
+}
+
+func newDiscoverProgress() *discoverProgress {
+	return &discoverProgress{enabled: output.IsTerminal(os.Stderr) && !output.IsCI() && !output.IsQuiet()}
+}
+
+// report returns the progress callback of one discovery path
+func (p *discoverProgress) report(root string) func(dirs, repos int, done bool) {
+	base := p.dirs
+	return func(dirs, repos int, done bool) {
+		p.dirs = base + dirs
+		if !p.enabled {
+			return
+		}
+		if done {
+			fmt.Fprint(os.Stderr, "\r\033[K")
+			return
+		}
+		fmt.Fprintf(os.Stderr, "\r\033[K⠿ %s: %d directories, %d repositories", root, dirs, repos)
 	}
-	return filepath.Join(configDir, "workspace-manager", "registry.json"), nil
 }
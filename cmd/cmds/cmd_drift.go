@@ -0,0 +1,244 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewDriftCommand creates the drift command
+func NewDriftCommand() *cobra.Command {
+	var (
+		all       bool
+		selector  string
+		fetch     bool
+		maxBehind int
+		maxAge    string
+		rebase    bool
+		merge     bool
+		autoStash bool
+		format    string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "drift [workspace-name]",
+		Short: "Flag workspace branches that fell far behind their default branch",
+		Long: `Compare the branch of each repository of a workspace with the default branch
+of the repository (origin/HEAD, origin/main or origin/master, then main or
+master) and flag the branches that are too far behind: more than 100 commits,
+or missing commits older than 14 days. Change the thresholds in config.yaml:
+
+  drift:
+    max_behind: 100
+    max_age: 14d
+
+With --rebase or --merge, the flagged branches are brought up to date. A
+rebase or merge that conflicts is aborted and reported, leaving the
+repository as it was. Frozen repositories are not checked, and linked
+workspaces are never caught up, as that would change the registered
+checkouts.
+
+The command exits with status 1 when branches remain flagged, so it can run
+from cron or CI to keep long-lived workspaces healthy.
+
+Examples:
+  # Check the current workspace against the latest default branches
+  workspace-manager drift --fetch
+
+  # Check every workspace of a team
+  workspace-manager drift --all --selector team=payments
+
+  # Rebase the flagged branches, stashing uncommitted changes meanwhile
+  workspace-manager drift my-feature --rebase --autostash
+
+  # Stricter thresholds, as JSON
+  workspace-manager drift --all --max-behind 20 --max-age 3d --format json`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if rebase && merge {
+				return errors.New("--rebase and --merge are mutually exclusive")
+			}
+			if all && len(args) > 0 {
+				return errors.New("--all does not take a workspace name")
+			}
+			mode := ""
+			if rebase {
+				mode = wsm.CatchUpRebase
+			} else if merge {
+				mode = wsm.CatchUpMerge
+			}
+			name := ""
+			if len(args) > 0 {
+				name = args[0]
+			}
+			return runDrift(cmd, name, all, selector, fetch, maxBehind, maxAge, mode, autoStash, format)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&all, "all", "a", false, "Check all workspaces")
+	cmd.Flags().StringVarP(&selector, "selector", "l", "", "With --all, only check workspaces whose labels match (team=payments,!archived)")
+	cmd.Flags().BoolVar(&fetch, "fetch", false, "Fetch origin before comparing")
+	cmd.Flags().IntVar(&maxBehind, "max-behind", 0, "Flag branches more commits behind than this (default: drift.max_behind, 100)")
+	cmd.Flags().StringVar(&maxAge, "max-age", "", "Flag branches missing commits older than this, e.g. 14d (default: drift.max_age, 14d)")
+	cmd.Flags().BoolVar(&rebase, "rebase", false, "Rebase flagged branches onto the default branch")
+	cmd.Flags().BoolVar(&merge, "merge", false, "Merge the default branch into flagged branches")
+	cmd.Flags().BoolVar(&autoStash, "autostash", false, "Stash uncommitted changes before rebasing or merging and restore them afterwards")
+	cmd.Flags().StringVar(&format, "format", "text", "Output format: text, json")
+
+	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion())
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"selector": LabelCompletion().UniqueList(","),
+		"format":   carapace.ActionValues("text", "json"),
+	})
+
+	return cmd
+}
+
+func runDrift(cmd *cobra.Command, name string, all bool, selector string, fetch bool, maxBehind int, maxAge, mode string, autoStash bool, format string) error {
+	ctx := cmd.Context()
+	labelSelector, err := wsm.ParseLabelSelector(selector)
+	if err != nil {
+		return err
+	}
+
+	wm, err := wsm.NewWorkspaceManager()
+	if err != nil {
+		return errors.Wrap(err, "failed to create workspace manager")
+	}
+	config := wm.Config().Drift
+	if maxBehind != 0 {
+		config.MaxBehind = maxBehind
+	}
+	if maxAge != "" {
+		config.MaxAge = maxAge
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	var workspaces []wsm.Workspace
+	if all {
+		if workspaces, err = wsm.LoadWorkspaces(); err != nil {
+			return errors.Wrap(err, "failed to load workspaces")
+		}
+		workspaces = labelSelector.Filter(workspaces)
+	} else {
+		workspace, err := resolveWorkspace(name)
+		if err != nil {
+			return err
+		}
+		workspaces = []wsm.Workspace{*workspace}
+	}
+
+	check := func(fetch bool) ([]*wsm.WorkspaceDrift, int, error) {
+		var results []*wsm.WorkspaceDrift
+		drifted := 0
+		for i := range workspaces {
+			drift, err := wm.CheckDrift(ctx, &workspaces[i], config, fetch)
+			if err != nil {
+				return nil, 0, errors.Wrapf(err, "failed to check drift of workspace '%s'", workspaces[i].Name)
+			}
+			results = append(results, drift)
+			drifted += len(drift.Drifted())
+		}
+		return results, drifted, nil
+	}
+
+	results, remaining, err := check(fetch)
+	if err != nil {
+		return err
+	}
+	if mode != "" && remaining > 0 {
+		catchUpDrift(ctx, wm, workspaces, results, mode, autoStash, format == "json")
+		// Measure again, so the report reflects the branches that were caught up
+		if results, remaining, err = check(false); err != nil {
+			return err
+		}
+	}
+
+	if format == "json" {
+		if results == nil {
+			results = []*wsm.WorkspaceDrift{}
+		}
+		if err := wsm.PrintJSON(results); err != nil {
+			return err
+		}
+	} else {
+		printDrift(results, remaining)
+	}
+
+	if remaining == 0 {
+		return nil
+	}
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	return &wsm.ExitCodeError{Code: 1}
+}
+
+// catchUpDrift rebases or merges the flagged branches; results are those of workspaces, in order
+func catchUpDrift(ctx context.Context, wm *wsm.WorkspaceManager, workspaces []wsm.Workspace, results []*wsm.WorkspaceDrift, mode string, autoStash, quiet bool) {
+	for i, result := range results {
+		if workspaces[i].Linked {
+			// Linked workspaces share the registered checkouts, which catching up would change
+			if !quiet && len(result.Drifted()) > 0 {
+				output.PrintWarning("%s: skipped, the workspace links existing checkouts", result.Workspace)
+			}
+			continue
+		}
+		for _, drift := range result.Drifted() {
+			if err := wm.CatchUp(ctx, &workspaces[i], drift, mode, autoStash); err != nil {
+				if !quiet {
+					output.PrintError("%s/%s: %v", result.Workspace, drift.Repository, err)
+				}
+				continue
+			}
+			if !quiet {
+				output.PrintSuccess("%s/%s: caught up with %s (%s)", result.Workspace, drift.Repository, drift.DefaultBranch, mode)
+			}
+		}
+	}
+	if !quiet {
+		fmt.Println()
+	}
+}
+
+func printDrift(results []*wsm.WorkspaceDrift, drifted int) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "WORKSPACE\tREPOSITORY\tBRANCH\tDEFAULT\tBEHIND\tAHEAD\tSTATUS")
+	for _, result := range results {
+		for _, drift := range result.Repositories {
+			status := "ok"
+			switch {
+			case drift.Error != "":
+				status = "error: " + drift.Error
+			case drift.Drifted():
+				status = "drifted: " + strings.Join(drift.Reasons, ", ")
+			}
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%d\t%s\n",
+				result.Workspace, drift.Repository, drift.Branch, drift.DefaultBranch, drift.Behind, drift.Ahead, status)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		output.LogWarn(
+			fmt.Sprintf("Failed to flush drift table: %v", err),
+			"Failed to flush tabwriter",
+			"error", err,
+		)
+	}
+
+	fmt.Println()
+	if drifted == 0 {
+		output.PrintSuccess("No branch is too far behind its default branch")
+		return
+	}
+	output.PrintWarning("%d branches are too far behind their default branch", drifted)
+	output.PrintInfo("Catch up with --rebase or --merge (add --autostash to keep uncommitted changes)")
+}
@@ -0,0 +1,109 @@
+package cmds
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewEventsCommand creates the events command
+func NewEventsCommand() *cobra.Command {
+	var (
+		follow    bool
+		types     []string
+		workspace string
+		format    string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "events",
+		Short: "Print the events of the daemon as JSON lines",
+		Long: `Print the recent events of the running daemon ('daemon run' or 'daemon
+install'), one JSON object per line, or with --follow the events from now on
+until interrupted - so status bars and editors can react to workspaces without
+polling.
+
+Event types:
+  workspace.created       a workspace appeared
+  workspace.deleted       a workspace was deleted
+  repository.dirty        a workspace repository got uncommitted changes
+  repository.clean        its changes were committed or discarded
+  branch.diverged         a workspace branch is now both ahead of and behind its upstream
+  repository.discovered   a repository was cloned into a discovery path and registered
+  fetch.completed         the daemon fetched the registered repositories
+  config.reloaded         the daemon reloaded a changed config.yaml or registry
+  config.invalid          a changed config.yaml or registry is invalid; the daemon
+                          keeps running with the previous one
+
+Two more event types are only sent to webhooks (see 'webhook --help'), by the
+commands they happen in, as are workspace.created and workspace.deleted:
+
+  pr.opened               'pr' created a pull request
+  sync.failed             'sync' could not pull or push some repositories
+
+Events are read from the daemon socket (daemon.sock next to config.yaml), which
+other tools can also connect to directly: send "follow" or "recent" followed by
+a newline and read JSON lines.
+
+Examples:
+  workspace-manager events --follow
+  workspace-manager events --follow --type repository.dirty,repository.clean --workspace my-feature
+  workspace-manager events --format text`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, eventType := range types {
+				if !slices.Contains(wsm.EventTypes, eventType) {
+					return errors.Errorf("unknown event type '%s'", eventType)
+				}
+			}
+			return runEvents(cmd.Context(), follow, types, workspace, format)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&follow, "follow", "f", false, "Keep printing events as they happen")
+	cmd.Flags().StringSliceVar(&types, "type", nil, "Only print events of these types (comma-separated)")
+	cmd.Flags().StringVar(&workspace, "workspace", "", "Only print events of this workspace")
+	cmd.Flags().StringVar(&format, "format", "json", "Output format: json, text")
+
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"type":      carapace.ActionValues(wsm.EventTypes...).UniqueList(","),
+		"workspace": WorkspaceNameCompletion(),
+		"format":    carapace.ActionValues("json", "text"),
+	})
+
+	return cmd
+}
+
+func runEvents(ctx context.Context, follow bool, types []string, workspace, format string) error {
+	socketPath, err := wsm.EventSocketPath()
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	encoder := json.NewEncoder(os.Stdout)
+	return wsm.ReadEvents(ctx, socketPath, follow, func(event wsm.Event) error {
+		if len(types) > 0 && !slices.Contains(types, event.Type) {
+			return nil
+		}
+		if workspace != "" && event.Workspace != workspace {
+			return nil
+		}
+		if format == "text" {
+			_, err := fmt.Println(event)
+			return err
+		}
+		return encoder.Encode(event)
+	})
+}
@@ -0,0 +1,147 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewExportCommand creates the export command
+func NewExportCommand() *cobra.Command {
+	var bundlePath string
+
+	cmd := &cobra.Command{
+		Use:   "export <workspace-name>",
+		Short: "Export a workspace as a portable bundle",
+		Long: `Export a workspace into a single archive that can be moved to another machine.
+
+The bundle contains:
+- The workspace metadata
+- A git bundle of every branch with commits that are not on any remote
+- A patch of uncommitted changes (staged and unstaged) for each repository
+- Copies of untracked files
+
+Use 'import-bundle' on the other machine to recreate the workspace with the same state.
+The repositories must already be discovered there.
+
+Examples:
+  # Export a workspace
+  workspace-manager export my-feature --bundle my-feature.wsmpack`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runExport(cmd.Context(), args[0], bundlePath)
+		},
+	}
+
+	cmd.Flags().StringVar(&bundlePath, "bundle", "", "Path of the bundle file to write (defaults to <workspace-name>.wsmpack)")
+
+	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion())
+
+	return cmd
+}
+
+// NewImportBundleCommand creates the import-bundle command
+func NewImportBundleCommand() *cobra.Command {
+	var (
+		name        string
+		ignoreQuota bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "import-bundle <bundle-file>",
+		Short: "Recreate a workspace from an exported bundle",
+		Long: `Recreate a workspace from a bundle produced by 'export'.
+
+For each repository in the bundle this command:
+- Fetches unpushed commits from the embedded git bundle into the local repository
+- Creates a worktree on the exported branch
+- Re-applies uncommitted changes and restores untracked files
+
+Examples:
+  # Import a workspace bundle
+  workspace-manager import-bundle my-feature.wsmpack
+
+  # Import under a different workspace name
+  workspace-manager import-bundle my-feature.wsmpack --name my-feature-laptop`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runImportBundle(cmd.Context(), args[0], name, ignoreQuota)
+		},
+	}
+
+	cmd.Flags().StringVar(&name, "name", "", "Workspace name to use (defaults to the exported name)")
+	cmd.Flags().BoolVar(&ignoreQuota, "ignore-quota", false, "Create the workspace even when the quota (quota.max_workspaces, quota.disk_budget) is exceeded")
+
+	return cmd
+}
+
+func runExport(ctx context.Context, workspaceName, bundlePath string) error {
+	wm, err := wsm.NewWorkspaceManager()
+	if err != nil {
+		return errors.Wrap(err, "failed to create workspace manager")
+	}
+
+	if bundlePath == "" {
+		bundlePath = workspaceName + ".wsmpack"
+	}
+
+	manifest, err := wm.ExportWorkspaceBundle(ctx, workspaceName, bundlePath)
+	if err != nil {
+		return errors.Wrap(err, "export failed")
+	}
+
+	output.PrintSuccess("Workspace '%s' exported to %s", workspaceName, bundlePath)
+	output.Printf("\n")
+	for _, repo := range manifest.Repositories {
+		var details []string
+		if repo.HasBundle {
+			details = append(details, fmt.Sprintf("%d unpushed commits", repo.UnpushedCount))
+		}
+		if repo.HasPatch {
+			details = append(details, "uncommitted changes")
+		}
+		if len(repo.UntrackedFiles) > 0 {
+			details = append(details, fmt.Sprintf("%d untracked files", len(repo.UntrackedFiles)))
+		}
+		if len(details) == 0 {
+			details = append(details, "clean")
+		}
+		output.Printf("  %s [%s]: %s\n", repo.Name, repo.Branch, strings.Join(details, ", "))
+	}
+
+	return nil
+}
+
+func runImportBundle(ctx context.Context, bundlePath, name string, ignoreQuota bool) error {
+	wm, err := wsm.NewWorkspaceManager()
+	if err != nil {
+		return errors.Wrap(err, "failed to create workspace manager")
+	}
+	wm.IgnoreQuota = ignoreQuota
+
+	workspace, err := wm.ImportWorkspaceBundle(ctx, bundlePath, name)
+	if err != nil {
+		return errors.Wrap(err, "import failed")
+	}
+
+	output.PrintSuccess("Workspace '%s' imported successfully!", workspace.Name)
+	output.Printf("\n")
+	output.PrintHeader("Workspace Details")
+	output.Printf("  Path: %s\n", workspace.Path)
+	output.Printf("  Repositories: %s\n", strings.Join(getRepositoryNames(workspace.Repositories), ", "))
+	if workspace.Branch != "" {
+		output.Printf("  Branch: %s\n", workspace.Branch)
+	}
+
+	output.Printf("\n")
+	output.PrintInfo("To start working:")
+	output.Printf("  cd %s\n", workspace.Path)
+
+	return nil
+}
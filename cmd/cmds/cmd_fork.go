@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 
 	"github.com/go-go-golems/workspace-manager/pkg/output"
@@ -19,7 +20,10 @@ func NewForkCommand() *cobra.Command {
 		branchPrefix string
 		agentSource  string
 		dryRun       bool
+		bootstrap    bootstrapFlags
 		workspace    string
+		protection   string
+		ignoreQuota  bool
 	)
 
 	cmd := &cobra.Command{
@@ -45,7 +49,10 @@ Examples:
   workspace-manager fork my-feature --branch feature/new-api
 
   # Fork with custom branch prefix (bug/my-feature)
-  workspace-manager fork my-feature --branch-prefix bug`,
+  workspace-manager fork my-feature --branch-prefix bug
+
+As with create, --branch-protection warn reports a branch GitHub would reject
+pushes of, and --branch-protection prefix replaces it by feature/<branch>.`,
 		Args: cobra.RangeArgs(1, 2),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			newWorkspaceName := args[0]
@@ -53,24 +60,31 @@ Examples:
 			if len(args) > 1 {
 				sourceWorkspaceName = args[1]
 			}
-			return runFork(cmd.Context(), newWorkspaceName, sourceWorkspaceName, branch, branchPrefix, agentSource, dryRun)
+			if protection != "" && !slices.Contains(wsm.BranchProtectionModes, protection) {
+				return errors.Errorf("invalid --branch-protection '%s': expected one of %s", protection, strings.Join(wsm.BranchProtectionModes, ", "))
+			}
+			return silenceReported(cmd, runFork(cmd.Context(), newWorkspaceName, sourceWorkspaceName, branch, branchPrefix, agentSource, protection, dryRun, bootstrap, ignoreQuota))
 		},
 	}
 
 	cmd.Flags().StringVar(&branch, "branch", "", "Branch name for the new workspace (if not specified, uses <branch-prefix>/<new-workspace-name>)")
 	cmd.Flags().StringVar(&branchPrefix, "branch-prefix", "task", "Prefix for auto-generated branch names")
 	cmd.Flags().StringVar(&agentSource, "agent-source", "", "Path to AGENT.md template file")
+	bootstrap.register(cmd)
 	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be created without actually creating")
 	cmd.Flags().StringVar(&workspace, "workspace", "", "Source workspace name")
+	cmd.Flags().StringVar(&protection, "branch-protection", "", "When GitHub protects the branch: warn, prefix, off (default: branch_protection.mode, else off)")
+	cmd.Flags().BoolVar(&ignoreQuota, "ignore-quota", false, "Create the workspace even when the quota (quota.max_workspaces, quota.disk_budget) is exceeded")
 
 	return cmd
 }
 
-func runFork(ctx context.Context, newWorkspaceName, sourceWorkspaceName, branch, branchPrefix, agentSource string, dryRun bool) error {
+func runFork(ctx context.Context, newWorkspaceName, sourceWorkspaceName, branch, branchPrefix, agentSource, protection string, dryRun bool, bootstrap bootstrapFlags, ignoreQuota bool) error {
 	wm, err := wsm.NewWorkspaceManager()
 	if err != nil {
 		return errors.Wrap(err, "failed to create workspace manager")
 	}
+	wm.IgnoreQuota = ignoreQuota
 
 	// If no source workspace specified, try to detect current workspace
 	if sourceWorkspaceName == "" {
@@ -130,6 +144,7 @@ func runFork(ctx context.Context, newWorkspaceName, sourceWorkspaceName, branch,
 	for _, repo := range sourceWorkspace.Repositories {
 		repoNames = append(repoNames, repo.Name)
 	}
+	finalBranch = guardBranchProtection(ctx, wm, repoNames, finalBranch, protection, dryRun)
 
 	// Use the source workspace's agent MD if no custom one specified
 	finalAgentSource := agentSource
@@ -148,7 +163,7 @@ func runFork(ctx context.Context, newWorkspaceName, sourceWorkspaceName, branch,
 		Bool("dryRun", dryRun).
 		Msg("Forking workspace")
 
-	workspace, err := wm.CreateWorkspace(ctx, newWorkspaceName, repoNames, finalBranch, baseBranch, finalAgentSource, dryRun)
+	workspace, err := wm.ForkWorkspace(ctx, sourceWorkspace, newWorkspaceName, finalBranch, baseBranch, finalAgentSource, dryRun)
 	if err != nil {
 		// Check if user cancelled - handle gracefully without error
 		errMsg := strings.ToLower(err.Error())
@@ -158,41 +173,43 @@ func runFork(ctx context.Context, newWorkspaceName, sourceWorkspaceName, branch,
 			output.PrintInfo("Operation cancelled.")
 			return nil // Return success to prevent usage help
 		}
-		return errors.Wrap(err, "failed to fork workspace")
+		return creationError(err, "failed to fork workspace")
 	}
 
 	// Show results
 	if dryRun {
 		output.PrintHeader("📋 Fork Preview: %s → %s", sourceWorkspace.Name, workspace.Name)
-		fmt.Println()
+		output.Printf("\n")
 		output.PrintInfo("Source workspace:")
-		fmt.Printf("  Name: %s\n", sourceWorkspace.Name)
-		fmt.Printf("  Path: %s\n", sourceWorkspace.Path)
-		fmt.Printf("  Current branch: %s\n", baseBranch)
-		fmt.Println()
+		output.Printf("  Name: %s\n", sourceWorkspace.Name)
+		output.Printf("  Path: %s\n", sourceWorkspace.Path)
+		output.Printf("  Current branch: %s\n", baseBranch)
+		output.Printf("\n")
 		return showWorkspacePreview(workspace)
 	}
 
 	output.PrintSuccess("Workspace '%s' forked successfully from '%s'!", workspace.Name, sourceWorkspace.Name)
-	fmt.Println()
+	output.Printf("\n")
 
 	output.PrintHeader("Fork Details")
-	fmt.Printf("  Source: %s (branch: %s)\n", sourceWorkspace.Name, baseBranch)
-	fmt.Printf("  New workspace: %s\n", workspace.Name)
-	fmt.Printf("  Path: %s\n", workspace.Path)
-	fmt.Printf("  Repositories: %s\n", strings.Join(getRepositoryNames(workspace.Repositories), ", "))
-	fmt.Printf("  New branch: %s\n", workspace.Branch)
-	fmt.Printf("  Base branch: %s\n", workspace.BaseBranch)
+	output.Printf("  Source: %s (branch: %s)\n", sourceWorkspace.Name, baseBranch)
+	output.Printf("  New workspace: %s\n", workspace.Name)
+	output.Printf("  Path: %s\n", workspace.Path)
+	output.Printf("  Repositories: %s\n", strings.Join(getRepositoryNames(workspace.Repositories), ", "))
+	output.Printf("  New branch: %s\n", workspace.Branch)
+	output.Printf("  Base branch: %s\n", workspace.BaseBranch)
 	if workspace.GoWorkspace {
-		fmt.Printf("  Go workspace: yes (go.work created)\n")
+		output.Printf("  Go workspace: yes (go.work created)\n")
 	}
 	if workspace.AgentMD != "" {
-		fmt.Printf("  AGENT.md: copied from %s\n", workspace.AgentMD)
+		output.Printf("  AGENT.md: copied from %s\n", workspace.AgentMD)
 	}
 
-	fmt.Println()
+	bootstrapWorkspace(ctx, wm, workspace, bootstrap)
+
+	output.Printf("\n")
 	output.PrintInfo("To start working:")
-	fmt.Printf("  cd %s\n", workspace.Path)
+	output.Printf("  cd %s\n", workspace.Path)
 
 	return nil
 }
@@ -0,0 +1,88 @@
+package cmds
+
+import (
+	"strings"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+// NewFreezeCommand creates the freeze command
+func NewFreezeCommand() *cobra.Command {
+	var workspaceName string
+
+	cmd := &cobra.Command{
+		Use:   "freeze <repo>...",
+		Short: "Exclude repositories of a workspace from commit, sync and push",
+		Long: `Mark repositories of a workspace as frozen. A frozen repository keeps its
+worktree for reference, but commit, sync and push skip it. Frozen repositories
+are marked in 'status'; include them again with 'unfreeze'.
+
+Examples:
+  # Keep the shared library around without committing to or pushing it
+  workspace-manager freeze shared-lib
+
+  workspace-manager freeze docs schemas --workspace my-feature`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wm, workspace, err := resolveManagedWorkspace(workspaceName)
+			if err != nil {
+				return err
+			}
+			frozen, err := wm.FreezeRepositories(workspace, args)
+			if err != nil {
+				return err
+			}
+			if len(frozen) == 0 {
+				output.PrintInfo("Already frozen in workspace '%s': %s", workspace.Name, strings.Join(args, ", "))
+				return nil
+			}
+			output.PrintSuccess("Froze %s in workspace '%s'", strings.Join(frozen, ", "), workspace.Name)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&workspaceName, "workspace", "", "Workspace name (default: detected from the current directory)")
+
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"workspace": WorkspaceNameCompletion(),
+	})
+
+	return cmd
+}
+
+// NewUnfreezeCommand creates the unfreeze command
+func NewUnfreezeCommand() *cobra.Command {
+	var workspaceName string
+
+	cmd := &cobra.Command{
+		Use:   "unfreeze <repo>...",
+		Short: "Include frozen repositories in commit, sync and push again",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wm, workspace, err := resolveManagedWorkspace(workspaceName)
+			if err != nil {
+				return err
+			}
+			thawed, err := wm.UnfreezeRepositories(workspace, args)
+			if err != nil {
+				return err
+			}
+			if len(thawed) == 0 {
+				output.PrintInfo("Not frozen in workspace '%s': %s", workspace.Name, strings.Join(args, ", "))
+				return nil
+			}
+			output.PrintSuccess("Unfroze %s in workspace '%s'", strings.Join(thawed, ", "), workspace.Name)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&workspaceName, "workspace", "", "Workspace name (default: detected from the current directory)")
+
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"workspace": WorkspaceNameCompletion(),
+	})
+
+	return cmd
+}
@@ -0,0 +1,227 @@
+package cmds
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewGitConfigCommand creates the gitconfig command
+func NewGitConfigCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "gitconfig",
+		Short: "Manage the git configuration of the worktrees of a workspace",
+		Long: `Manage git configuration that only applies to the worktrees of a workspace,
+such as the identity used for work or open source (user.email),
+commit.gpgsign or core.hooksPath.
+
+Values declared under git_config in config.yaml are copied into every new
+workspace and applied when its worktrees are created:
+
+  git_config:
+    values:
+      user.email: me@company.com
+      commit.gpgsign: "true"
+    repositories:
+      oss-lib:
+        user.email: me@example.org
+
+The values are written with 'git config --worktree', which enables
+extensions.worktreeConfig in the source repositories, so the source
+repositories and other workspaces keep their own configuration.
+
+Examples:
+  workspace-manager gitconfig show
+  workspace-manager gitconfig set user.email me@example.org --repo oss-lib
+  workspace-manager gitconfig unset core.hooksPath
+  workspace-manager gitconfig apply my-feature`,
+	}
+
+	cmd.AddCommand(
+		NewGitConfigShowCommand(),
+		NewGitConfigApplyCommand(),
+		NewGitConfigSetCommand(),
+		NewGitConfigUnsetCommand(),
+	)
+
+	return cmd
+}
+
+// NewGitConfigShowCommand creates the gitconfig show command
+func NewGitConfigShowCommand() *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "show [workspace-name]",
+		Short: "Show the declared git configuration and the values git uses",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspaceName := ""
+			if len(args) > 0 {
+				workspaceName = args[0]
+			}
+			wm, workspace, err := resolveManagedWorkspace(workspaceName)
+			if err != nil {
+				return err
+			}
+			entries := wm.GitConfigStatus(cmd.Context(), workspace)
+
+			if format == "json" {
+				return wsm.PrintJSON(entries)
+			}
+			if len(entries) == 0 {
+				output.PrintInfo("No git config declared for workspace '%s'", workspace.Name)
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "REPOSITORY\tKEY\tDECLARED\tEFFECTIVE\tSTATE")
+			fmt.Fprintln(w, "----------\t---\t--------\t---------\t-----")
+			pending := 0
+			for _, entry := range entries {
+				state := "applied"
+				if !entry.Applied() {
+					state = "not applied"
+					pending++
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", entry.Repository, entry.Key, entry.Declared, entry.Effective, state)
+			}
+			if err := w.Flush(); err != nil {
+				return errors.Wrap(err, "failed to flush table writer")
+			}
+			if pending > 0 {
+				fmt.Println()
+				output.PrintWarning("%d value(s) not applied; run 'workspace-manager gitconfig apply %s'", pending, workspace.Name)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "table", "Output format: table, json")
+
+	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion())
+
+	return cmd
+}
+
+// NewGitConfigApplyCommand creates the gitconfig apply command
+func NewGitConfigApplyCommand() *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "apply [workspace-name]",
+		Short: "Write the declared git configuration into the worktrees",
+		Long: `Write the git configuration declared for the workspace into its worktrees,
+e.g. for workspaces created before git_config was added to config.yaml or
+after editing the workspace configuration by hand.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspaceName := ""
+			if len(args) > 0 {
+				workspaceName = args[0]
+			}
+			wm, workspace, err := resolveManagedWorkspace(workspaceName)
+			if err != nil {
+				return err
+			}
+
+			changed, err := wm.ApplyGitConfig(cmd.Context(), workspace, dryRun)
+			for _, entry := range changed {
+				output.Printf("  %s: %s = %s\n", entry.Repository, entry.Key, entry.Declared)
+			}
+			if err != nil {
+				return err
+			}
+			switch {
+			case len(changed) == 0:
+				output.PrintSuccess("Git config of workspace '%s' is up to date", workspace.Name)
+			case dryRun:
+				output.PrintInfo("Dry run mode - %d value(s) would be set", len(changed))
+			default:
+				output.PrintSuccess("Set %d value(s) in workspace '%s'", len(changed), workspace.Name)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show the values that would be set")
+
+	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion())
+
+	return cmd
+}
+
+// NewGitConfigSetCommand creates the gitconfig set command
+func NewGitConfigSetCommand() *cobra.Command {
+	var workspaceName, repoName string
+
+	cmd := &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Declare a git config value for the workspace and apply it",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wm, workspace, err := resolveManagedWorkspace(workspaceName)
+			if err != nil {
+				return err
+			}
+			if err := wm.SetGitConfig(cmd.Context(), workspace, repoName, args[0], args[1]); err != nil {
+				return err
+			}
+			output.PrintSuccess("Set %s = %s in %s", args[0], args[1], gitConfigScope(workspace, repoName))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&workspaceName, "workspace", "", "Workspace name (default: detected from the current directory)")
+	cmd.Flags().StringVar(&repoName, "repo", "", "Only set the value for this repository")
+
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"workspace": WorkspaceNameCompletion(),
+	})
+
+	return cmd
+}
+
+// NewGitConfigUnsetCommand creates the gitconfig unset command
+func NewGitConfigUnsetCommand() *cobra.Command {
+	var workspaceName, repoName string
+
+	cmd := &cobra.Command{
+		Use:   "unset <key>",
+		Short: "Remove a declared git config value from the workspace and its worktrees",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wm, workspace, err := resolveManagedWorkspace(workspaceName)
+			if err != nil {
+				return err
+			}
+			if err := wm.UnsetGitConfig(cmd.Context(), workspace, repoName, args[0]); err != nil {
+				return err
+			}
+			output.PrintSuccess("Unset %s in %s", args[0], gitConfigScope(workspace, repoName))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&workspaceName, "workspace", "", "Workspace name (default: detected from the current directory)")
+	cmd.Flags().StringVar(&repoName, "repo", "", "Remove the override of this repository")
+
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"workspace": WorkspaceNameCompletion(),
+	})
+
+	return cmd
+}
+
+func gitConfigScope(workspace *wsm.Workspace, repoName string) string {
+	if repoName != "" {
+		return fmt.Sprintf("'%s' of workspace '%s'", repoName, workspace.Name)
+	}
+	return fmt.Sprintf("workspace '%s'", workspace.Name)
+}
@@ -0,0 +1,165 @@
+package cmds
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var historyColumns = []output.Column{
+	{Name: "time", Header: "TIME"},
+	{Name: "operation", Header: "OPERATION"},
+	{Name: "workspace", Header: "WORKSPACE"},
+	{Name: "user", Header: "USER"},
+	{Name: "parameters", Header: "PARAMETERS"},
+	{Name: "host", Header: "HOST", Hidden: true},
+	{Name: "command", Header: "COMMAND", Hidden: true},
+}
+
+// NewHistoryCommand creates the history command
+func NewHistoryCommand() *cobra.Command {
+	var (
+		since      string
+		operations []string
+		limit      int
+		format     string
+		columns    []string
+		sortBy     string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "history [workspace-name]",
+		Short: "Show the history of workspace operations",
+		Long: `List past workspace operations (create, add, remove, sync, delete) with their
+timestamps, user and parameters, oldest first. Without a workspace name the
+history of all workspaces is shown, including deleted ones.
+
+The history is read from the operation journal in
+~/.config/workspace-manager/history.jsonl.
+
+Examples:
+  # Everything that happened to a workspace
+  workspace-manager history my-feature
+
+  # Deletions during the last week
+  workspace-manager history --since 7d --operation delete
+
+  # Show the full command line of each operation
+  workspace-manager history --columns time,user,operation,command`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspaceName := ""
+			if len(args) > 0 {
+				workspaceName = args[0]
+			}
+			return runHistory(workspaceName, since, operations, limit, format, tableOptions{columns: columns, sortBy: sortBy})
+		},
+	}
+
+	cmd.Flags().StringVar(&since, "since", "", "Only show operations after a date (2006-01-02) or within a duration (24h, 7d)")
+	cmd.Flags().StringSliceVar(&operations, "operation", nil, "Only show these operations (create, add, remove, sync, delete)")
+	cmd.Flags().IntVarP(&limit, "limit", "n", 0, "Only show the last N operations")
+	cmd.Flags().StringVar(&format, "format", "table", "Output format: table, json")
+	addTableFlags(cmd, &columns, &sortBy, historyColumns)
+
+	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion())
+	carapace.Gen(cmd).FlagCompletion(
+		carapace.ActionMap{
+			"operation": carapace.ActionValues("create", "add", "remove", "sync", "delete").UniqueList(","),
+			"format":    carapace.ActionValues("table", "json"),
+			"columns":   ColumnCompletion(historyColumns).UniqueList(","),
+		},
+	)
+
+	return cmd
+}
+
+func runHistory(workspaceName, since string, operations []string, limit int, format string, opts tableOptions) error {
+	sinceTime, err := parseSince(since, time.Now())
+	if err != nil {
+		return err
+	}
+
+	entries, err := wsm.ReadHistory(workspaceName, sinceTime)
+	if err != nil {
+		return errors.Wrap(err, "failed to read history")
+	}
+
+	if len(operations) > 0 {
+		var filtered []wsm.HistoryEntry
+		for _, entry := range entries {
+			for _, operation := range operations {
+				if entry.Operation == operation {
+					filtered = append(filtered, entry)
+					break
+				}
+			}
+		}
+		entries = filtered
+	}
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+
+	if format == "json" {
+		return wsm.PrintJSON(entries)
+	}
+
+	if len(entries) == 0 {
+		output.PrintInfo("No operations recorded")
+		return nil
+	}
+
+	table := output.NewTable(historyColumns...)
+	for _, entry := range entries {
+		table.AddRow(output.Row{
+			"time":       output.Time(entry.Time, "2006-01-02 15:04:05"),
+			"operation":  output.Text(entry.Operation),
+			"workspace":  output.Text(entry.Workspace),
+			"user":       output.Text(entry.User),
+			"parameters": output.Text(formatHistoryParameters(entry.Parameters)),
+			"host":       output.Text(entry.Host),
+			"command":    output.Text(entry.Command),
+		})
+	}
+
+	return renderTable(table, opts)
+}
+
+// formatHistoryParameters prints the non-empty parameters as sorted key=value pairs
+func formatHistoryParameters(parameters map[string]string) string {
+	var pairs []string
+	for key, value := range parameters {
+		if value != "" {
+			pairs = append(pairs, fmt.Sprintf("%s=%s", key, value))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, " ")
+}
+
+// parseSince accepts a date, an RFC 3339 timestamp or a duration before now (with a "d" suffix for days)
+func parseSince(since string, now time.Time) (time.Time, error) {
+	if since == "" {
+		return time.Time{}, nil
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, since, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	if d, err := wsm.ParseAge(since); err == nil {
+		return now.Add(-d), nil
+	}
+
+	return time.Time{}, errors.Errorf("invalid --since '%s': expected a date (2006-01-02) or a duration (24h, 7d)", since)
+}
@@ -35,6 +35,7 @@ Available fields:
   - created: creation date and time (YYYY-MM-DD HH:MM:SS)
   - date: creation date only (YYYY-MM-DD)
   - time: creation time only (HH:MM:SS)
+  - labels: labels as key=value pairs separated by commas
 
 Examples:
   # Show all workspace info
@@ -59,7 +60,7 @@ Examples:
 	}
 
 	cmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format (table, json)")
-	cmd.Flags().StringVar(&outputField, "field", "", "Output specific field only (path, name, branch, repositories, created, date, time)")
+	cmd.Flags().StringVar(&outputField, "field", "", "Output specific field only (path, name, branch, repositories, created, date, time, labels)")
 	cmd.Flags().StringVar(&workspace, "workspace", "", "Workspace name")
 
 	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion())
@@ -118,8 +119,10 @@ func printField(workspace *wsm.Workspace, field string) error {
 		fmt.Println(workspace.Created.Format("2006-01-02"))
 	case "time":
 		fmt.Println(workspace.Created.Format("15:04:05"))
+	case "labels":
+		fmt.Println(wsm.FormatLabels(workspace.Labels))
 	default:
-		return errors.Errorf("unknown field: %s. Available fields: path, name, branch, repositories, created, date, time", field)
+		return errors.Errorf("unknown field: %s. Available fields: path, name, branch, repositories, created, date, time, labels", field)
 	}
 	return nil
 }
@@ -132,13 +135,40 @@ func printInfoTable(workspace *wsm.Workspace) error {
 	fmt.Printf("  Repositories: %d\n", len(workspace.Repositories))
 	fmt.Printf("  Created:      %s\n", workspace.Created.Format("2006-01-02 15:04:05"))
 	fmt.Printf("  Go Workspace: %t\n", workspace.GoWorkspace)
+	if workspace.Linked {
+		fmt.Printf("  Mode:         linked checkouts (no worktrees)\n")
+	}
+	if workspace.Clone != "" {
+		fmt.Printf("  Mode:         %s clones (no worktrees)\n", workspace.Clone)
+	}
+	if len(workspace.BuildManifests) > 0 {
+		fmt.Printf("  Build Files:  %s\n", strings.Join(wsm.BuildManifestFiles(workspace), ", "))
+	}
+	if len(workspace.Labels) > 0 {
+		fmt.Printf("  Labels:       %s\n", wsm.FormatLabels(workspace.Labels))
+	}
 
 	if len(workspace.Repositories) > 0 {
 		output.PrintHeader("\nRepositories")
 		for _, repo := range workspace.Repositories {
+			if workspace.Linked {
+				fmt.Printf("  - %s (%s, linked to %s)\n", repo.Name, repo.RemoteURL, repo.Path)
+				continue
+			}
 			fmt.Printf("  - %s (%s)\n", repo.Name, repo.RemoteURL)
 		}
 	}
 
+	if len(workspace.AppliedPRs) > 0 {
+		output.PrintHeader("\nApplied Pull Requests")
+		for _, pr := range workspace.AppliedPRs {
+			fmt.Printf("  - %s %s from %s, %s at %s", pr.Repository, pr.String(), pr.Remote, pr.Method, shortHash(pr.Head))
+			if pr.Author != "" {
+				fmt.Printf(" by %s", pr.Author)
+			}
+			fmt.Printf(" (%s)\n", pr.Applied.Format("2006-01-02 15:04"))
+		}
+	}
+
 	return nil
 }
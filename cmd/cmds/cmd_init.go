@@ -0,0 +1,286 @@
+package cmds
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/charmbracelet/huh"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewInitCommand creates the init command
+func NewInitCommand() *cobra.Command {
+	var (
+		codeDirs      []string
+		workspaceRoot string
+		layout        string
+		multiplexer   string
+		starship      bool
+		direnv        bool
+		discover      bool
+		force         bool
+		yes           bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Configure workspace manager for a new user",
+		Long: `Walk through the first configuration of workspace manager: the directories your
+repositories are checked out in, where workspaces are created, and the terminal
+multiplexer 'tmux' or 'term' opens them in. The answers are written to
+config.yaml of the current profile, and the code directories are scanned with
+'discover' to fill the registry.
+
+Workspaces are created below the workspace root in a directory per day
+(dated, e.g. ~/workspaces/2025-06-01/my-feature) or directly in it (flat).
+
+Optionally a starship prompt module showing the current workspace is added to
+the starship configuration, and an .envrc selecting the profile for direnv is
+written to the workspace root.
+
+Flags preset the answers; with --yes, or without a terminal, they are used
+without asking.
+
+Examples:
+  workspace-manager init
+  workspace-manager init --yes --code-dir ~/code --layout flat --multiplexer none
+  workspace-manager --profile work init --workspace-root ~/work/workspaces`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			answers, err := wsm.DefaultInitConfig()
+			if err != nil {
+				return err
+			}
+			flags := cmd.Flags()
+			if flags.Changed("code-dir") {
+				answers.CodeDirs = codeDirs
+			}
+			if flags.Changed("workspace-root") {
+				answers.WorkspaceRoot = workspaceRoot
+			}
+			if flags.Changed("layout") {
+				answers.Layout = layout
+			}
+			if flags.Changed("multiplexer") {
+				answers.Multiplexer = multiplexer
+			}
+			if flags.Changed("starship") {
+				answers.Starship = starship
+			}
+			if flags.Changed("direnv") {
+				answers.Direnv = direnv
+			}
+			return runInit(cmd.Context(), answers, discover, force, yes || !output.IsInteractive())
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&codeDirs, "code-dir", nil, "Directory your repositories are checked out in (repeatable; default: existing ~/code, ~/src, ...)")
+	cmd.Flags().StringVar(&workspaceRoot, "workspace-root", "", "Directory workspaces are created below (default ~/workspaces)")
+	cmd.Flags().StringVar(&layout, "layout", wsm.WorkspaceLayoutDated, "Workspace layout: dated, flat")
+	cmd.Flags().StringVar(&multiplexer, "multiplexer", "", "Terminal integration: tmux, kitty, wezterm, iterm2, none (default: detected)")
+	cmd.Flags().BoolVar(&starship, "starship", false, "Add a starship module showing the current workspace (default: when starship is installed)")
+	cmd.Flags().BoolVar(&direnv, "direnv", false, "Write an .envrc selecting the profile to the workspace root (default: when direnv is installed)")
+	cmd.Flags().BoolVar(&discover, "discover", true, "Discover the repositories of the code directories")
+	cmd.Flags().BoolVar(&force, "force", false, "Overwrite an existing config.yaml")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Use the flags and defaults without asking")
+
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"code-dir":       carapace.ActionDirectories(),
+		"workspace-root": carapace.ActionDirectories(),
+		"layout":         carapace.ActionValues(wsm.WorkspaceLayoutDated, wsm.WorkspaceLayoutFlat),
+		"multiplexer":    carapace.ActionValues(wsm.MultiplexerTmux, wsm.TermBackendKitty, wsm.TermBackendWezTerm, wsm.TermBackendITerm2, wsm.MultiplexerNone),
+	})
+
+	return cmd
+}
+
+func runInit(ctx context.Context, answers wsm.InitConfig, discover, force, yes bool) error {
+	configDir, err := wsm.ConfigDir()
+	if err != nil {
+		return err
+	}
+	configPath := filepath.Join(configDir, "config.yaml")
+	if _, err := os.Stat(configPath); err == nil && !force {
+		return errors.Errorf("%s already exists; pass --force to replace it", configPath)
+	}
+
+	if !yes {
+		if err := askInitQuestions(&answers, &discover); err != nil {
+			return err
+		}
+	}
+	for i, dir := range answers.CodeDirs {
+		if answers.CodeDirs[i], err = wsm.ExpandPath(dir); err != nil {
+			return err
+		}
+	}
+	if answers.WorkspaceRoot, err = wsm.ExpandPath(answers.WorkspaceRoot); err != nil {
+		return err
+	}
+	if err := answers.Validate(); err != nil {
+		return err
+	}
+
+	data, err := answers.ConfigYAML()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		return errors.Wrapf(err, "failed to create %s", configDir)
+	}
+	if err := os.WriteFile(configPath, data, 0644); err != nil {
+		return errors.Wrapf(err, "failed to write %s", configPath)
+	}
+	if _, err := wsm.LoadConfig(); err != nil {
+		return errors.Wrap(err, "the written configuration does not load")
+	}
+	output.PrintSuccess("Wrote %s", configPath)
+
+	if answers.Starship {
+		if err := setupStarship(); err != nil {
+			output.PrintWarning("Could not configure starship: %v", err)
+		}
+	}
+	if answers.Direnv {
+		if err := setupDirenv(answers.WorkspaceRoot); err != nil {
+			output.PrintWarning("Could not configure direnv: %v", err)
+		}
+	}
+
+	if discover && len(answers.CodeDirs) > 0 {
+		output.Printf("\n")
+		if err := runDiscover(ctx, nil, discoverOptions{recursive: true}); err != nil {
+			return err
+		}
+	}
+
+	output.Printf("\n")
+	output.PrintInfo("Create your first workspace with 'workspace-manager create <name> --repos <repo,...>'")
+	return nil
+}
+
+// askInitQuestions asks for the answers of init, preset with the flags and defaults
+func askInitQuestions(answers *wsm.InitConfig, discover *bool) error {
+	codeDirs := strings.Join(answers.CodeDirs, ", ")
+
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Where are your repositories checked out?").
+				Description("Comma-separated directories, scanned by 'discover'").
+				Value(&codeDirs).
+				Validate(func(value string) error {
+					for _, dir := range splitInitList(value) {
+						path, err := wsm.ExpandPath(dir)
+						if err != nil {
+							return err
+						}
+						if info, err := os.Stat(path); err != nil || !info.IsDir() {
+							return errors.Errorf("%s is not a directory", dir)
+						}
+					}
+					return nil
+				}),
+			huh.NewConfirm().
+				Title("Discover the repositories in these directories now?").
+				Value(discover),
+		),
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Where should workspaces be created?").
+				Value(&answers.WorkspaceRoot).
+				Validate(func(value string) error {
+					if strings.TrimSpace(value) == "" {
+						return errors.New("the workspace root is required")
+					}
+					return nil
+				}),
+			huh.NewSelect[string]().
+				Title("Workspace layout").
+				Options(
+					huh.NewOption("Dated: <root>/2025-06-01/my-feature", wsm.WorkspaceLayoutDated),
+					huh.NewOption("Flat: <root>/my-feature", wsm.WorkspaceLayoutFlat),
+				).
+				Value(&answers.Layout),
+		),
+		huh.NewGroup(
+			huh.NewSelect[string]().
+				Title("Open workspaces with").
+				Options(
+					huh.NewOption("tmux: a session with a window per repository", wsm.MultiplexerTmux),
+					huh.NewOption("kitty: a tab per repository", wsm.TermBackendKitty),
+					huh.NewOption("WezTerm: a tab per repository", wsm.TermBackendWezTerm),
+					huh.NewOption("iTerm2: a tab per repository", wsm.TermBackendITerm2),
+					huh.NewOption("Nothing", wsm.MultiplexerNone),
+				).
+				Value(&answers.Multiplexer),
+			huh.NewConfirm().
+				Title("Show the current workspace in the starship prompt?").
+				Value(&answers.Starship),
+			huh.NewConfirm().
+				Title("Write an .envrc selecting this profile to the workspace root for direnv?").
+				Value(&answers.Direnv),
+		),
+	)
+	if err := form.Run(); err != nil {
+		if isFormAborted(err) {
+			return errors.New("init cancelled")
+		}
+		return errors.Wrap(err, "interactive form failed")
+	}
+	answers.CodeDirs = splitInitList(codeDirs)
+	return nil
+}
+
+func splitInitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func setupStarship() error {
+	path, err := wsm.StarshipConfigPath()
+	if err != nil {
+		return err
+	}
+	executable := "wsm"
+	if exe, err := os.Executable(); err == nil && filepath.Base(exe) != "wsm" {
+		executable = exe
+	}
+	added, err := wsm.AddStarshipModule(path, wsm.StarshipModule(executable))
+	if err != nil {
+		return err
+	}
+	if added {
+		output.PrintSuccess("Added the custom.wsm module to %s", path)
+	} else {
+		output.PrintInfo("%s already has a custom.wsm module", path)
+	}
+	return nil
+}
+
+func setupDirenv(workspaceRoot string) error {
+	path := filepath.Join(workspaceRoot, ".envrc")
+	if _, err := os.Stat(path); err == nil {
+		output.PrintInfo("%s already exists; add 'export %s=%s' to it by hand", path, wsm.ProfileEnvVar, wsm.CurrentProfile())
+		return nil
+	}
+	if err := os.MkdirAll(workspaceRoot, 0755); err != nil {
+		return errors.Wrapf(err, "failed to create %s", workspaceRoot)
+	}
+	if err := os.WriteFile(path, []byte(wsm.DirenvConfig()), 0644); err != nil {
+		return errors.Wrapf(err, "failed to write %s", path)
+	}
+	output.PrintSuccess("Wrote %s; enable it with 'direnv allow %s'", path, workspaceRoot)
+	return nil
+}
@@ -0,0 +1,95 @@
+package cmds
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewLabelCommand creates the label command
+func NewLabelCommand() *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "label <workspace> [key=value | key-]...",
+		Short: "Set, remove or show key=value labels of a workspace",
+		Long: `Attach key=value labels to a workspace, e.g. the team or the release it
+belongs to. key- removes a label; without labels, the labels of the workspace
+are shown.
+
+Labels are shown by 'list workspaces' and 'status', and select workspaces with
+--selector in 'list workspaces', 'delete' and 'unpushed'. A selector is a
+comma-separated list of requirements that must all hold: key=value, key!=value,
+key (label is set) and !key (label is not set).
+
+Examples:
+  workspace-manager label my-feature team=payments release=2026.10
+  workspace-manager label my-feature release-
+  workspace-manager list workspaces --selector team=payments
+  workspace-manager delete --selector 'team=payments,release=2026.09' --remove-files`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runLabel(args[0], args[1:], format)
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "text", "Output format: text, json")
+
+	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion())
+	carapace.Gen(cmd).PositionalAnyCompletion(LabelCompletion())
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"format": carapace.ActionValues("text", "json"),
+	})
+
+	return cmd
+}
+
+func runLabel(workspaceName string, args []string, format string) error {
+	changes, err := wsm.ParseLabelChanges(args)
+	if err != nil {
+		return err
+	}
+
+	wm, err := wsm.NewWorkspaceManager()
+	if err != nil {
+		return errors.Wrap(err, "failed to create workspace manager")
+	}
+	workspace, err := wm.LoadWorkspace(workspaceName)
+	if err != nil {
+		return errors.Wrapf(err, "workspace '%s' not found", workspaceName)
+	}
+
+	if len(args) > 0 {
+		changed, err := wm.LabelWorkspace(workspace, changes)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			output.PrintInfo("Labels of workspace '%s' are unchanged", workspace.Name)
+		} else if format != "json" {
+			output.PrintSuccess("Updated labels of workspace '%s'", workspace.Name)
+		}
+	}
+
+	if format == "json" {
+		labels := workspace.Labels
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		return wsm.PrintJSON(labels)
+	}
+	if len(workspace.Labels) == 0 {
+		output.PrintInfo("Workspace '%s' has no labels", workspace.Name)
+		return nil
+	}
+	for _, key := range slices.Sorted(maps.Keys(workspace.Labels)) {
+		fmt.Printf("%s=%s\n", key, workspace.Labels[key])
+	}
+	return nil
+}
@@ -0,0 +1,363 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewLinkCommand creates the link command
+func NewLinkCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "link",
+		Short: "Link tickets and issues to a workspace",
+		Long: `Link Jira tickets and GitHub issues to a workspace. Linked issues are shown
+by 'list workspaces' and 'status', referenced in the default body of pull
+requests created with 'pr', and appended as a "Refs:" trailer to commit
+messages created with 'commit --template'.
+
+Jira keys are turned into URLs with the issues section of config.yaml:
+
+  issues:
+    jira:
+      url: https://acme.atlassian.net
+
+With an API token in $JIRA_API_TOKEN, the summary and status of Jira tickets
+are read into the workspace metadata when they are linked and by 'link
+refresh'. The summary is used by the default title of pull requests and by
+'commit --ticket-summary'. Tickets are moved along the workflow by 'link
+transition', and by 'create' and 'pr' when transitions are configured:
+
+  issues:
+    jira:
+      url: https://acme.atlassian.net
+      email: alice@acme.com      # Jira Cloud (default: $JIRA_EMAIL); without it,
+                                 # the token is sent as a personal access token
+      token_env: JIRA_API_TOKEN  # default
+      transitions:
+        create: In Progress      # when 'create --issue' links tickets
+        pr: In Review            # once 'pr' created pull requests
+
+Examples:
+  workspace-manager link issue PROJ-123
+  workspace-manager link issue https://github.com/acme/app/issues/42 --workspace my-feature
+  workspace-manager link list
+  workspace-manager link refresh
+  workspace-manager link transition "In Progress"
+  workspace-manager link remove PROJ-123`,
+	}
+
+	cmd.AddCommand(
+		NewLinkIssueCommand(),
+		NewLinkRemoveCommand(),
+		NewLinkListCommand(),
+		NewLinkRefreshCommand(),
+		NewLinkTransitionCommand(),
+	)
+
+	return cmd
+}
+
+// NewLinkIssueCommand creates the link issue command
+func NewLinkIssueCommand() *cobra.Command {
+	var workspaceName string
+
+	cmd := &cobra.Command{
+		Use:   "issue <url|PROJ-123|owner/repo#123>...",
+		Short: "Link issues to a workspace",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wm, workspace, err := resolveManagedWorkspace(workspaceName)
+			if err != nil {
+				return err
+			}
+
+			if err := linkIssues(wm, workspace, args); err != nil {
+				return err
+			}
+			updateJiraIssues(cmd.Context(), wm, workspace, "")
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&workspaceName, "workspace", "", "Workspace name (default: detected from the current directory)")
+
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"workspace": WorkspaceNameCompletion(),
+	})
+
+	return cmd
+}
+
+// NewLinkRemoveCommand creates the link remove command
+func NewLinkRemoveCommand() *cobra.Command {
+	var workspaceName string
+
+	cmd := &cobra.Command{
+		Use:   "remove <ref|url>",
+		Short: "Unlink an issue from a workspace",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wm, workspace, err := resolveManagedWorkspace(workspaceName)
+			if err != nil {
+				return err
+			}
+			if err := wm.UnlinkIssue(workspace, args[0]); err != nil {
+				return err
+			}
+			output.PrintSuccess("Unlinked %s from workspace '%s'", args[0], workspace.Name)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&workspaceName, "workspace", "", "Workspace name (default: detected from the current directory)")
+
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"workspace": WorkspaceNameCompletion(),
+	})
+
+	return cmd
+}
+
+// NewLinkListCommand creates the link list command
+func NewLinkListCommand() *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "list [workspace-name]",
+		Short: "List the issues linked to a workspace",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspaceName := ""
+			if len(args) > 0 {
+				workspaceName = args[0]
+			}
+			return runLinkList(workspaceName, format)
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "table", "Output format: table, json")
+
+	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion())
+
+	return cmd
+}
+
+// NewLinkRefreshCommand creates the link refresh command
+func NewLinkRefreshCommand() *cobra.Command {
+	var workspaceName string
+
+	cmd := &cobra.Command{
+		Use:   "refresh",
+		Short: "Read the summary and status of the linked Jira tickets",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return silenceReported(cmd, runLinkRefresh(cmd.Context(), workspaceName))
+		},
+	}
+
+	cmd.Flags().StringVar(&workspaceName, "workspace", "", "Workspace name (default: detected from the current directory)")
+
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"workspace": WorkspaceNameCompletion(),
+	})
+
+	return cmd
+}
+
+// NewLinkTransitionCommand creates the link transition command
+func NewLinkTransitionCommand() *cobra.Command {
+	var workspaceName string
+
+	cmd := &cobra.Command{
+		Use:   "transition <status>",
+		Short: "Move the linked Jira tickets to a status, e.g. \"In Review\"",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return silenceReported(cmd, runLinkTransition(cmd.Context(), workspaceName, args[0]))
+		},
+	}
+
+	cmd.Flags().StringVar(&workspaceName, "workspace", "", "Workspace name (default: detected from the current directory)")
+
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"workspace": WorkspaceNameCompletion(),
+	})
+
+	return cmd
+}
+
+func runLinkRefresh(ctx context.Context, workspaceName string) error {
+	wm, workspace, err := resolveManagedWorkspace(workspaceName)
+	if err != nil {
+		return err
+	}
+	links := wsm.JiraIssueLinks(workspace)
+	if len(links) == 0 {
+		output.PrintInfo("No Jira tickets linked to workspace '%s'", workspace.Name)
+		return nil
+	}
+	client, err := wsm.NewJiraClient(wm.Config().Issues)
+	if err != nil {
+		return err
+	}
+
+	failures, err := wm.RefreshJiraIssues(ctx, client, workspace)
+	if err != nil {
+		return errors.Wrap(err, "failed to save workspace configuration")
+	}
+	for _, link := range links {
+		if !link.Refreshed.IsZero() {
+			output.Printf("  %s [%s] %s\n", link.Ref, link.Status, link.Summary)
+		}
+	}
+	for _, failure := range failures {
+		output.PrintError("%v", failure)
+	}
+	if len(failures) > 0 {
+		output.PrintError("%d of %d tickets could not be read", len(failures), len(links))
+		return &wsm.ExitCodeError{Code: 1}
+	}
+	return nil
+}
+
+func runLinkTransition(ctx context.Context, workspaceName, status string) error {
+	wm, workspace, err := resolveManagedWorkspace(workspaceName)
+	if err != nil {
+		return err
+	}
+	links := wsm.JiraIssueLinks(workspace)
+	if len(links) == 0 {
+		output.PrintInfo("No Jira tickets linked to workspace '%s'", workspace.Name)
+		return nil
+	}
+	client, err := wsm.NewJiraClient(wm.Config().Issues)
+	if err != nil {
+		return err
+	}
+
+	moved, failures, err := wm.TransitionJiraIssues(ctx, client, workspace, status)
+	if err != nil {
+		return errors.Wrap(err, "failed to save workspace configuration")
+	}
+	if len(moved) > 0 {
+		output.PrintSuccess("Moved %s to '%s'", strings.Join(moved, ", "), status)
+	}
+	if unchanged := len(links) - len(moved) - len(failures); unchanged > 0 {
+		output.PrintInfo("%d ticket(s) already '%s'", unchanged, status)
+	}
+	for _, failure := range failures {
+		output.PrintError("%v", failure)
+	}
+	if len(failures) > 0 {
+		output.PrintError("%d of %d tickets could not be moved", len(failures), len(links))
+		return &wsm.ExitCodeError{Code: 1}
+	}
+	return nil
+}
+
+// linkIssues links issue references to the workspace
+func linkIssues(wm *wsm.WorkspaceManager, workspace *wsm.Workspace, references []string) error {
+	for _, reference := range references {
+		link, err := wsm.ParseIssueReference(reference, wm.Config().Issues)
+		if err != nil {
+			return err
+		}
+		added, err := wm.LinkIssue(workspace, link)
+		if err != nil {
+			return errors.Wrap(err, "failed to save workspace configuration")
+		}
+		if !added {
+			output.PrintInfo("%s is already linked to workspace '%s'", link.Ref, workspace.Name)
+			continue
+		}
+		output.PrintSuccess("Linked %s to workspace '%s'", link.Ref, workspace.Name)
+	}
+	return nil
+}
+
+// updateJiraIssues reads the summary and status of the Jira tickets linked to the workspace and,
+// with status, moves them to it. Failures only warn: the tickets stay linked either way. Without
+// an API token, the metadata is left alone unless a transition was asked for.
+func updateJiraIssues(ctx context.Context, wm *wsm.WorkspaceManager, workspace *wsm.Workspace, status string) {
+	config := wm.Config().Issues
+	if config.Jira.URL == "" || len(wsm.JiraIssueLinks(workspace)) == 0 {
+		return
+	}
+	client, err := wsm.NewJiraClient(config)
+	if err != nil {
+		if status != "" {
+			output.PrintWarning("Jira tickets not moved to '%s': %v", status, err)
+		}
+		return
+	}
+
+	var failures []error
+	if status == "" {
+		failures, err = wm.RefreshJiraIssues(ctx, client, workspace)
+	} else {
+		var moved []string
+		moved, failures, err = wm.TransitionJiraIssues(ctx, client, workspace, status)
+		if len(moved) > 0 {
+			output.PrintSuccess("Moved %s to '%s'", strings.Join(moved, ", "), status)
+		}
+	}
+	if err != nil {
+		output.PrintWarning("Failed to save the Jira ticket metadata: %v", err)
+	}
+	for _, failure := range failures {
+		output.PrintWarning("%v", failure)
+	}
+}
+
+// resolveManagedWorkspace resolves a workspace like resolveWorkspace along with the manager that saves it
+func resolveManagedWorkspace(workspaceName string) (*wsm.WorkspaceManager, *wsm.Workspace, error) {
+	workspace, err := resolveWorkspace(workspaceName)
+	if err != nil {
+		return nil, nil, err
+	}
+	wm, err := wsm.NewWorkspaceManager()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to create workspace manager")
+	}
+	return wm, workspace, nil
+}
+
+func runLinkList(workspaceName, format string) error {
+	workspace, err := resolveWorkspace(workspaceName)
+	if err != nil {
+		return err
+	}
+
+	if format == "json" {
+		issues := workspace.Issues
+		if issues == nil {
+			issues = []wsm.IssueLink{}
+		}
+		return wsm.PrintJSON(issues)
+	}
+
+	if len(workspace.Issues) == 0 {
+		output.PrintInfo("No issues linked to workspace '%s'. Link one with 'workspace-manager link issue <url|PROJ-123>'", workspace.Name)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ISSUE\tTRACKER\tSTATUS\tSUMMARY\tURL")
+	fmt.Fprintln(w, "-----\t-------\t------\t-------\t---")
+	for _, issue := range workspace.Issues {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", issue.Ref, issue.Tracker, orDash(issue.Status), truncateString(issue.Summary, 50), issue.URL)
+	}
+	if err := w.Flush(); err != nil {
+		return errors.Wrap(err, "failed to flush table writer")
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewLintCommand creates the lint command
+func NewLintCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lint",
+		Short: "Check workspace changes against project conventions",
+		Long:  "Run checks across all repositories of a workspace before creating pull requests.",
+	}
+
+	cmd.AddCommand(
+		NewLintCommitsCommand(),
+	)
+
+	return cmd
+}
+
+// NewLintCommitsCommand creates the lint commits command
+func NewLintCommitsCommand() *cobra.Command {
+	var (
+		base      string
+		workspace string
+		format    string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "commits",
+		Short: "Validate commit messages against conventional-commit rules",
+		Long: `Validate the subject of every commit on the workspace branch (<base>..HEAD)
+in each repository against conventional-commit rules.
+
+Rules can be configured in config.yaml:
+
+  commit_lint:
+    types: [feat, fix, docs, refactor, test, chore]
+    pattern: ""              # custom subject regex, replaces the type rule
+    max_subject_length: 72   # -1 disables the length check
+
+Commits that are not signed are flagged in repositories whose signing policy
+(signing.mode in config.yaml) is 'require'.
+
+Examples:
+  # Lint commits of the current workspace against its base branch
+  workspace-manager lint commits
+
+  # Lint against a specific base
+  workspace-manager lint commits --base origin/main`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runLintCommits(cmd.Context(), workspace, base, format)
+		},
+	}
+
+	cmd.Flags().StringVar(&base, "base", "", "Base ref (defaults to workspace base branch or origin/main)")
+	cmd.Flags().StringVar(&workspace, "workspace", "", "Workspace name")
+	cmd.Flags().StringVar(&format, "format", "table", "Output format: table, json")
+
+	return cmd
+}
+
+func runLintCommits(ctx context.Context, workspaceName, base, format string) error {
+	wm, err := wsm.NewWorkspaceManager()
+	if err != nil {
+		return errors.Wrap(err, "failed to create workspace manager")
+	}
+
+	workspace, err := resolveWorkspace(workspaceName)
+	if err != nil {
+		return err
+	}
+
+	linter, err := wsm.NewCommitLinter(wm.Config().CommitLint)
+	if err != nil {
+		return err
+	}
+
+	linter.WithSigning(wm.Config().Signing)
+
+	result, err := wsm.LintWorkspaceCommits(ctx, workspace, base, linter)
+	if err != nil {
+		return errors.Wrap(err, "commit lint failed")
+	}
+
+	if format == "json" {
+		if err := wsm.PrintJSON(result); err != nil {
+			return err
+		}
+	} else {
+		printCommitLintResult(workspace, result)
+	}
+
+	if len(result.Violations) > 0 {
+		return errors.Errorf("%d commit message violations", len(result.Violations))
+	}
+
+	return nil
+}
+
+func printCommitLintResult(workspace *wsm.Workspace, result *wsm.CommitLintResult) {
+	output.PrintHeader("Commit lint: %s (base: %s)", workspace.Name, result.Base)
+
+	byRepo := make(map[string][]wsm.CommitLintViolation)
+	for _, violation := range result.Violations {
+		byRepo[violation.Repository] = append(byRepo[violation.Repository], violation)
+	}
+
+	for _, repo := range workspace.Repositories {
+		checked := result.Checked[repo.Name]
+		violations := byRepo[repo.Name]
+		if len(violations) == 0 {
+			output.PrintSuccess("%s: %d commits OK", repo.Name, checked)
+			continue
+		}
+
+		output.PrintError("%s: %d violations in %d commits", repo.Name, len(violations), checked)
+		for _, violation := range violations {
+			fmt.Printf("    %s %s\n", violation.Commit, violation.Subject)
+			fmt.Printf("      → %s\n", violation.Reason)
+		}
+	}
+}
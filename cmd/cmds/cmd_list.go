@@ -1,6 +1,7 @@
 package cmds
 
 import (
+	"context"
 	"fmt"
 	"github.com/go-go-golems/workspace-manager/pkg/output"
 	"github.com/go-go-golems/workspace-manager/pkg/wsm"
@@ -8,6 +9,7 @@ import (
 	"sort"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/carapace-sh/carapace"
 	"github.com/pkg/errors"
@@ -31,25 +33,46 @@ func NewListCommand() *cobra.Command {
 
 func NewListReposCommand() *cobra.Command {
 	var (
-		format string
-		tags   []string
+		format  string
+		tags    []string
+		details bool
+		columns []string
+		sortBy  string
 	)
 
 	cmd := &cobra.Command{
 		Use:   "repos",
 		Short: "List discovered repositories",
-		Long:  "List all discovered repositories with optional filtering by tags.",
+		Long: `List all discovered repositories with optional filtering by tags.
+
+With --details, each repository's README description, last commit subject and
+commit date are shown as well.
+
+Columns can be chosen and sorted in table output, e.g.:
+  workspace-manager list repos --columns name,branch,tags --sort "name desc"
+
+With --porcelain, the default when stdout is not a terminal and no other
+output flag is given, one tab-separated line is printed per repository:
+  <name> <path> <branch> <tags> <remote>`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runListRepos(format, tags)
+			if porcelainOutput(cmd, "format", "details", "columns", "sort") {
+				output.SetQuiet(true)
+				format = "porcelain"
+			}
+			return runListRepos(cmd.Context(), format, tags, details, tableOptions{columns: columns, sortBy: sortBy})
 		},
 	}
 
 	cmd.Flags().StringVar(&format, "format", "table", "Output format: table, json")
 	cmd.Flags().StringSliceVar(&tags, "tags", nil, "Filter by tags (comma-separated)")
+	cmd.Flags().BoolVar(&details, "details", false, "Show README description and last commit for each repository")
+	addTableFlags(cmd, &columns, &sortBy, repoColumns)
+	cmd.Flags().Bool("porcelain", false, "Print stable, tab-separated output for scripts (default when stdout is not a terminal)")
 
 	carapace.Gen(cmd).FlagCompletion(
 		carapace.ActionMap{
-			"tags": TagCompletion(),
+			"tags":    TagCompletion(),
+			"columns": ColumnCompletion(repoColumns).UniqueList(","),
 		},
 	)
 
@@ -57,23 +80,52 @@ func NewListReposCommand() *cobra.Command {
 }
 
 func NewListWorkspacesCommand() *cobra.Command {
-	var format string
+	var (
+		format   string
+		columns  []string
+		sortBy   string
+		selector string
+	)
 
 	cmd := &cobra.Command{
 		Use:   "workspaces",
 		Short: "List created workspaces",
-		Long:  "List all created workspaces, sorted by creation date (newest first).",
+		Long: `List all created workspaces, sorted by creation date (newest first).
+
+Columns can be chosen and sorted in table output, e.g.:
+  workspace-manager list workspaces --columns name,branch,created --sort name
+
+With --selector, only workspaces whose labels (see 'label') match are listed:
+  workspace-manager list workspaces --selector 'team=payments,!archived'
+
+With --porcelain, the default when stdout is not a terminal and no other
+output flag is given, one tab-separated line is printed per workspace:
+  <name> <path> <branch> <repositories> <created (RFC 3339)>`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runListWorkspaces(format)
+			if porcelainOutput(cmd, "format", "columns", "sort") {
+				output.SetQuiet(true)
+				format = "porcelain"
+			}
+			return runListWorkspaces(format, selector, tableOptions{columns: columns, sortBy: sortBy})
 		},
 	}
 
 	cmd.Flags().StringVar(&format, "format", "table", "Output format: table, json")
+	cmd.Flags().StringVarP(&selector, "selector", "l", "", "Only list workspaces whose labels match (team=payments,env!=prod,!archived)")
+	addTableFlags(cmd, &columns, &sortBy, workspaceColumns)
+	cmd.Flags().Bool("porcelain", false, "Print stable, tab-separated output for scripts (default when stdout is not a terminal)")
+
+	carapace.Gen(cmd).FlagCompletion(
+		carapace.ActionMap{
+			"columns":  ColumnCompletion(workspaceColumns).UniqueList(","),
+			"selector": LabelCompletion().UniqueList(","),
+		},
+	)
 
 	return cmd
 }
 
-func runListRepos(format string, tags []string) error {
+func runListRepos(ctx context.Context, format string, tags []string, details bool, opts tableOptions) error {
 	// Get registry path and load registry
 	registryPath, err := getRegistryPath()
 	if err != nil {
@@ -97,9 +149,32 @@ func runListRepos(format string, tags []string) error {
 		return nil
 	}
 
+	if format == "porcelain" {
+		for _, repo := range repos {
+			output.PrintPorcelain(repo.Name, repo.Path, repo.CurrentBranch, strings.Join(repo.Categories, ","), repo.RemoteURL)
+		}
+		return nil
+	}
+
+	if details {
+		previews := make([]repositoryWithPreview, len(repos))
+		for i, repo := range repos {
+			previews[i] = repositoryWithPreview{Repository: repo, Preview: wsm.GetRepositoryPreview(ctx, repo)}
+		}
+
+		switch format {
+		case "table":
+			return printReposDetailsTable(previews)
+		case "json":
+			return wsm.PrintJSON(previews)
+		default:
+			return errors.Errorf("unsupported format: %s", format)
+		}
+	}
+
 	switch format {
 	case "table":
-		return printReposTable(repos)
+		return printReposTable(repos, opts)
 	case "json":
 		return printReposJSON(repos)
 	default:
@@ -107,7 +182,12 @@ func runListRepos(format string, tags []string) error {
 	}
 }
 
-func runListWorkspaces(format string) error {
+func runListWorkspaces(format, selector string, opts tableOptions) error {
+	labelSelector, err := wsm.ParseLabelSelector(selector)
+	if err != nil {
+		return err
+	}
+
 	workspaces, err := wsm.LoadWorkspaces()
 	if err != nil {
 		return errors.Wrap(err, "failed to load workspaces")
@@ -117,6 +197,14 @@ func runListWorkspaces(format string) error {
 		output.PrintInfo("No workspaces found. Use 'workspace-manager create' to create a workspace")
 		return nil
 	}
+	workspaces = labelSelector.Filter(workspaces)
+	if len(workspaces) == 0 {
+		if format == "json" {
+			return printWorkspacesJSON([]wsm.Workspace{})
+		}
+		output.PrintInfo("No workspaces match the selector %s", labelSelector)
+		return nil
+	}
 
 	// Sort workspaces by creation date descending (newest first)
 	sort.Slice(workspaces, func(i, j int) bool {
@@ -125,28 +213,34 @@ func runListWorkspaces(format string) error {
 
 	switch format {
 	case "table":
-		return printWorkspacesTable(workspaces)
+		return printWorkspacesTable(workspaces, opts)
 	case "json":
 		return printWorkspacesJSON(workspaces)
+	case "porcelain":
+		for _, workspace := range workspaces {
+			repoNames := make([]string, len(workspace.Repositories))
+			for i, repo := range workspace.Repositories {
+				repoNames[i] = repo.Name
+			}
+			output.PrintPorcelain(workspace.Name, workspace.Path, workspace.Branch, strings.Join(repoNames, ","), workspace.Created.Format(time.RFC3339))
+		}
+		return nil
 	default:
 		return errors.Errorf("unsupported format: %s", format)
 	}
 }
 
-func printReposTable(repos []wsm.Repository) error {
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	defer func() {
-		if err := w.Flush(); err != nil {
-			output.LogWarn(
-				fmt.Sprintf("Failed to flush table writer: %v", err),
-				"Failed to flush table writer",
-				"error", err,
-			)
-		}
-	}()
+var repoColumns = []output.Column{
+	{Name: "name"},
+	{Name: "path"},
+	{Name: "branch"},
+	{Name: "tags"},
+	{Name: "remote"},
+	{Name: "modified", Header: "LAST UPDATED", Hidden: true},
+}
 
-	fmt.Fprintln(w, "NAME\tPATH\tBRANCH\tTAGS\tREMOTE")
-	fmt.Fprintln(w, "----\t----\t------\t----\t------")
+func printReposTable(repos []wsm.Repository, opts tableOptions) error {
+	table := output.NewTable(repoColumns...)
 
 	for _, repo := range repos {
 		tags := strings.Join(repo.Categories, ",")
@@ -159,23 +253,26 @@ func printReposTable(repos []wsm.Repository) error {
 			remote = "..." + remote[len(remote)-47:]
 		}
 
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
-			repo.Name,
-			repo.Path,
-			repo.CurrentBranch,
-			tags,
-			remote,
-		)
+		table.AddRow(output.Row{
+			"name":     output.Text(repo.Name),
+			"path":     output.Text(repo.Path),
+			"branch":   output.Text(repo.CurrentBranch),
+			"tags":     output.Text(tags),
+			"remote":   output.Text(remote),
+			"modified": output.Time(repo.LastUpdated, "2006-01-02 15:04"),
+		})
 	}
 
-	return nil
+	return renderTable(table, opts)
 }
 
-func printReposJSON(repos []wsm.Repository) error {
-	return wsm.PrintJSON(repos)
+// repositoryWithPreview is a repository together with its README/commit preview
+type repositoryWithPreview struct {
+	wsm.Repository
+	Preview wsm.RepositoryPreview `json:"preview"`
 }
 
-func printWorkspacesTable(workspaces []wsm.Workspace) error {
+func printReposDetailsTable(repos []repositoryWithPreview) error {
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
 	defer func() {
 		if err := w.Flush(); err != nil {
@@ -187,8 +284,56 @@ func printWorkspacesTable(workspaces []wsm.Workspace) error {
 		}
 	}()
 
-	fmt.Fprintln(w, "NAME\tPATH\tREPOS\tBRANCH\tCREATED")
-	fmt.Fprintln(w, "----\t----\t-----\t------\t-------")
+	fmt.Fprintln(w, "NAME\tDESCRIPTION\tLAST COMMIT\tDATE")
+	fmt.Fprintln(w, "----\t-----------\t-----------\t----")
+
+	for _, repo := range repos {
+		date := "-"
+		if !repo.Preview.LastCommitDate.IsZero() {
+			date = repo.Preview.LastCommitDate.Format("2006-01-02")
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+			repo.Name,
+			truncateString(repo.Preview.Description, 50),
+			truncateString(repo.Preview.LastCommitSubject, 40),
+			date,
+		)
+	}
+
+	return nil
+}
+
+// truncateString shortens s to at most maxLen runes, marking truncation with "..."
+func truncateString(s string, maxLen int) string {
+	if s == "" {
+		return "-"
+	}
+	runes := []rune(s)
+	if len(runes) <= maxLen {
+		return s
+	}
+	return string(runes[:maxLen-3]) + "..."
+}
+
+func printReposJSON(repos []wsm.Repository) error {
+	return wsm.PrintJSON(repos)
+}
+
+var workspaceColumns = []output.Column{
+	{Name: "name"},
+	{Name: "path"},
+	{Name: "repos"},
+	{Name: "branch"},
+	{Name: "created"},
+	{Name: "issues"},
+	{Name: "labels"},
+	{Name: "base", Header: "BASE BRANCH", Hidden: true},
+	{Name: "count", Header: "REPO COUNT", Hidden: true},
+}
+
+func printWorkspacesTable(workspaces []wsm.Workspace, opts tableOptions) error {
+	table := output.NewTable(workspaceColumns...)
 
 	for _, workspace := range workspaces {
 		repoNames := make([]string, len(workspace.Repositories))
@@ -200,16 +345,20 @@ func printWorkspacesTable(workspaces []wsm.Workspace) error {
 			repos = repos[:27] + "..."
 		}
 
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
-			workspace.Name,
-			workspace.Path,
-			repos,
-			workspace.Branch,
-			workspace.Created.Format("2006-01-02 15:04"),
-		)
+		table.AddRow(output.Row{
+			"name":    output.Text(workspace.Name),
+			"path":    output.Text(workspace.Path),
+			"repos":   output.Text(repos),
+			"branch":  output.Text(workspace.Branch),
+			"created": output.Time(workspace.Created, "2006-01-02 15:04"),
+			"issues":  output.Text(strings.Join(wsm.IssueRefs(workspace.Issues), ",")),
+			"labels":  output.Text(wsm.FormatLabels(workspace.Labels)),
+			"base":    output.Text(workspace.BaseBranch),
+			"count":   output.Int(len(workspace.Repositories)),
+		})
 	}
 
-	return nil
+	return renderTable(table, opts)
 }
 
 func printWorkspacesJSON(workspaces []wsm.Workspace) error {
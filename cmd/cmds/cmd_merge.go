@@ -2,7 +2,6 @@ package cmds
 
 import (
 	"context"
-	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -174,14 +173,14 @@ func runMerge(ctx context.Context, workspaceName string, dryRun, force, keepWork
 
 func previewMerge(workspace *wsm.Workspace, candidates []MergeCandidate) error {
 	output.PrintHeader("📋 Merge Preview: %s", workspace.Name)
-	fmt.Println()
+	output.Printf("\n")
 
 	output.PrintInfo("Workspace Details:")
-	fmt.Printf("  Name: %s\n", workspace.Name)
-	fmt.Printf("  Path: %s\n", workspace.Path)
-	fmt.Printf("  Current branch: %s\n", workspace.Branch)
-	fmt.Printf("  Base branch: %s\n", workspace.BaseBranch)
-	fmt.Println()
+	output.Printf("  Name: %s\n", workspace.Name)
+	output.Printf("  Path: %s\n", workspace.Path)
+	output.Printf("  Current branch: %s\n", workspace.Branch)
+	output.Printf("  Base branch: %s\n", workspace.BaseBranch)
+	output.Printf("\n")
 
 	output.PrintInfo("Merge Plan:")
 	for _, candidate := range candidates {
@@ -190,30 +189,30 @@ func previewMerge(workspace *wsm.Workspace, candidates []MergeCandidate) error {
 			status = "⚠️  Has changes"
 		}
 
-		fmt.Printf("  %s (%s)\n", candidate.Repository.Name, status)
-		fmt.Printf("    Merge: %s → %s\n", workspace.Branch, workspace.BaseBranch)
-		fmt.Printf("    Push: %s to origin\n", workspace.BaseBranch)
+		output.Printf("  %s (%s)\n", candidate.Repository.Name, status)
+		output.Printf("    Merge: %s → %s\n", workspace.Branch, workspace.BaseBranch)
+		output.Printf("    Push: %s to origin\n", workspace.BaseBranch)
 	}
 
-	fmt.Println()
+	output.Printf("\n")
 	output.PrintInfo("After successful merge:")
-	fmt.Printf("  - All repositories will have %s branch updated\n", workspace.BaseBranch)
-	fmt.Printf("  - Changes will be pushed to origin\n")
-	fmt.Printf("  - Workspace will be deleted\n")
+	output.Printf("  - All repositories will have %s branch updated\n", workspace.BaseBranch)
+	output.Printf("  - Changes will be pushed to origin\n")
+	output.Printf("  - Workspace will be deleted\n")
 
 	return nil
 }
 
 func confirmMerge(workspace *wsm.Workspace, candidates []MergeCandidate, keepWorkspace bool) (bool, error) {
-	fmt.Printf("\n")
+	output.Printf("\n")
 	output.PrintWarning("You are about to merge workspace '%s'", workspace.Name)
-	fmt.Printf("  Branch: %s → %s\n", workspace.Branch, workspace.BaseBranch)
-	fmt.Printf("  Repositories: %d\n", len(candidates))
+	output.Printf("  Branch: %s → %s\n", workspace.Branch, workspace.BaseBranch)
+	output.Printf("  Repositories: %d\n", len(candidates))
 
 	if !keepWorkspace {
-		fmt.Printf("  The workspace will be DELETED after successful merge\n")
+		output.Printf("  The workspace will be DELETED after successful merge\n")
 	}
-	fmt.Println()
+	output.Printf("\n")
 
 	// Show any repositories with changes
 	hasChanges := false
@@ -223,12 +222,16 @@ func confirmMerge(workspace *wsm.Workspace, candidates []MergeCandidate, keepWor
 				output.PrintWarning("The following repositories have uncommitted changes:")
 				hasChanges = true
 			}
-			fmt.Printf("  - %s\n", candidate.Repository.Name)
+			output.Printf("  - %s\n", candidate.Repository.Name)
 		}
 	}
 
 	if hasChanges {
-		fmt.Printf("\nThese changes will be included in the merge.\n")
+		output.Printf("\nThese changes will be included in the merge.\n")
+	}
+
+	if err := output.RequireInteractive("confirm the merge", "use --force to merge without confirmation"); err != nil {
+		return false, err
 	}
 
 	var confirmed bool
@@ -251,13 +254,18 @@ func confirmMerge(workspace *wsm.Workspace, candidates []MergeCandidate, keepWor
 func executeMerge(ctx context.Context, workspace *wsm.Workspace, candidates []MergeCandidate, keepWorkspace bool) error {
 	output.PrintHeader("🔀 Executing Merge: %s", workspace.Name)
 
+	wm, err := wsm.NewWorkspaceManager()
+	if err != nil {
+		return errors.Wrap(err, "failed to create workspace manager")
+	}
+
 	var successfulMerges []string
 
 	// Execute merge for each repository
 	for _, candidate := range candidates {
 		output.PrintInfo("Processing repository: %s", candidate.Repository.Name)
 
-		if err := mergeRepository(ctx, candidate); err != nil {
+		if err := mergeRepository(ctx, candidate, wm.Config().Timeouts); err != nil {
 			output.PrintError("Failed to merge repository %s: %v", candidate.Repository.Name, err)
 
 			// Rollback successful merges
@@ -279,11 +287,6 @@ func executeMerge(ctx context.Context, workspace *wsm.Workspace, candidates []Me
 	if !keepWorkspace {
 		output.PrintInfo("Deleting workspace '%s'...", workspace.Name)
 
-		wm, err := wsm.NewWorkspaceManager()
-		if err != nil {
-			return errors.Wrap(err, "failed to create workspace manager for deletion")
-		}
-
 		if err := wm.DeleteWorkspace(ctx, workspace.Name, true, true); err != nil {
 			output.PrintWarning("Failed to delete workspace: %v", err)
 			output.PrintInfo("You may need to delete it manually: workspace-manager delete %s", workspace.Name)
@@ -292,20 +295,22 @@ func executeMerge(ctx context.Context, workspace *wsm.Workspace, candidates []Me
 		}
 	}
 
-	fmt.Println()
+	output.Printf("\n")
 	output.PrintSuccess("Merge completed successfully!")
 	output.PrintInfo("Summary:")
-	fmt.Printf("  - Merged %d repositories\n", len(successfulMerges))
-	fmt.Printf("  - Branch %s merged into %s\n", workspace.Branch, workspace.BaseBranch)
-	fmt.Printf("  - Changes pushed to origin\n")
+	output.Printf("  - Merged %d repositories\n", len(successfulMerges))
+	output.Printf("  - Branch %s merged into %s\n", workspace.Branch, workspace.BaseBranch)
+	output.Printf("  - Changes pushed to origin\n")
 	if !keepWorkspace {
-		fmt.Printf("  - Workspace deleted\n")
+		output.Printf("  - Workspace deleted\n")
 	}
 
 	return nil
 }
 
-func mergeRepository(ctx context.Context, candidate MergeCandidate) error {
+// mergeRepository merges the workspace branch into the base branch and pushes it; the fetch and
+// the push are bounded by the timeouts of the repository
+func mergeRepository(ctx context.Context, candidate MergeCandidate, timeouts wsm.TimeoutConfig) error {
 	repoPath := candidate.WorktreePath
 
 	log.Debug().
@@ -317,7 +322,8 @@ func mergeRepository(ctx context.Context, candidate MergeCandidate) error {
 
 	// Step 1: Fetch latest changes
 	output.PrintInfo("  Fetching latest changes...")
-	if err := executeGitCommand(ctx, repoPath, "git", "fetch", "origin"); err != nil {
+	fetchCtx, done := wsm.WithNetworkTimeout(ctx, timeouts, candidate.Repository.Name, wsm.OperationFetch)
+	if err := done(executeGitCommand(fetchCtx, repoPath, "git", "fetch", "origin")); err != nil {
 		return errors.Wrap(err, "failed to fetch latest changes")
 	}
 
@@ -327,9 +333,9 @@ func mergeRepository(ctx context.Context, candidate MergeCandidate) error {
 		return errors.Wrapf(err, "failed to switch to base branch %s", candidate.BaseBranch)
 	}
 
-	// Step 3: Pull latest base branch changes
+	// Step 3: Pull latest base branch changes; origin was fetched in step 1, so only the merge is left
 	output.PrintInfo("  Pulling latest base branch changes...")
-	if err := executeGitCommand(ctx, repoPath, "git", "pull", "origin", candidate.BaseBranch); err != nil {
+	if err := executeGitCommand(ctx, repoPath, "git", "merge", "--no-edit", "origin/"+candidate.BaseBranch); err != nil {
 		return errors.Wrapf(err, "failed to pull latest changes for %s", candidate.BaseBranch)
 	}
 
@@ -345,7 +351,8 @@ func mergeRepository(ctx context.Context, candidate MergeCandidate) error {
 
 	// Step 5: Push merged changes
 	output.PrintInfo("  Pushing merged changes...")
-	if err := executeGitCommand(ctx, repoPath, "git", "push", "origin", candidate.BaseBranch); err != nil {
+	pushCtx, done := wsm.WithNetworkTimeout(ctx, timeouts, candidate.Repository.Name, wsm.OperationPush)
+	if err := done(executeGitCommand(pushCtx, repoPath, "git", "push", "origin", candidate.BaseBranch)); err != nil {
 		return errors.Wrapf(err, "failed to push merged changes for %s", candidate.BaseBranch)
 	}
 
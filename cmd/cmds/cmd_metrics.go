@@ -0,0 +1,143 @@
+package cmds
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewMetricsCommand creates the metrics command
+func NewMetricsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "metrics",
+		Short: "Expose workspace health metrics for Prometheus and OpenTelemetry",
+		Long: `Collect metrics about the workspaces on this machine so platform teams can
+monitor developer tooling health:
+
+- wsm_workspaces, and per workspace the number of repositories, repositories
+  with uncommitted changes and commits ahead of/behind upstream
+- wsm_operations_total and wsm_operation_duration_seconds from the history
+  journal (create, sync, delete, ...)
+- wsm_git_commands_total, wsm_git_command_failures_total and
+  wsm_git_command_duration_seconds_total for the git commands run to collect
+
+Collection only runs local git commands; nothing is fetched.`,
+	}
+
+	cmd.AddCommand(
+		NewMetricsServeCommand(),
+		NewMetricsPrintCommand(),
+	)
+
+	return cmd
+}
+
+// NewMetricsServeCommand creates the metrics serve command
+func NewMetricsServeCommand() *cobra.Command {
+	var (
+		listen       string
+		interval     time.Duration
+		otlpEndpoint string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve metrics on a Prometheus /metrics endpoint",
+		Long: `Collect metrics every --interval and serve them on /metrics in the Prometheus
+text format. With --otlp-endpoint (default: $OTEL_EXPORTER_OTLP_ENDPOINT) every
+collection is also sent as a trace, with one span per git command, to an
+OpenTelemetry collector over OTLP/HTTP.
+
+Examples:
+  workspace-manager metrics serve --listen 127.0.0.1:9464
+  workspace-manager metrics serve --interval 5m --otlp-endpoint http://localhost:4318`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runMetricsServe(cmd.Context(), listen, interval, otlpEndpoint)
+		},
+	}
+
+	cmd.Flags().StringVar(&listen, "listen", "127.0.0.1:9464", "Address to serve /metrics on")
+	cmd.Flags().DurationVar(&interval, "interval", time.Minute, "Time between collections")
+	cmd.Flags().StringVar(&otlpEndpoint, "otlp-endpoint", os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), "OTLP/HTTP collector to send traces of git operations to")
+
+	return cmd
+}
+
+// NewMetricsPrintCommand creates the metrics print command
+func NewMetricsPrintCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "print",
+		Short: "Collect once and print the metrics in the Prometheus text format",
+		Long: `Collect once and print the metrics, e.g. for the node_exporter textfile collector:
+
+  workspace-manager metrics print > /var/lib/node_exporter/wsm.prom`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			collector := wsm.NewMetricsCollector()
+			if err := collector.Collect(cmd.Context()); err != nil {
+				return errors.Wrap(err, "failed to collect metrics")
+			}
+			return collector.WritePrometheus(os.Stdout)
+		},
+	}
+}
+
+func runMetricsServe(ctx context.Context, listen string, interval time.Duration, otlpEndpoint string) error {
+	if interval <= 0 {
+		return errors.New("--interval must be positive")
+	}
+
+	collector := wsm.NewMetricsCollector()
+	if otlpEndpoint != "" {
+		collector.Tracer = wsm.NewOTLPExporter(otlpEndpoint)
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := collector.WritePrometheus(w); err != nil {
+			output.PrintWarning("Failed to write metrics: %v", err)
+		}
+	})
+	server := &http.Server{Addr: listen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := collector.Collect(ctx); err != nil && ctx.Err() == nil {
+				output.PrintWarning("Metrics collection failed: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	output.PrintInfo("Serving metrics on http://%s/metrics (Ctrl+C to stop)", listen)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return errors.Wrapf(err, "failed to serve metrics on %s", listen)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// NewMigrateCommand creates the migrate command
+func NewMigrateCommand() *cobra.Command {
+	var (
+		from       string
+		root       string
+		name       string
+		groups     []string
+		outputPath string
+		clone      bool
+		dryRun     bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "migrate --from meta|gita|repo [config]",
+		Short: "Import the repositories of meta, gita or repo setups",
+		Long: `Read the configuration of another multi-repository tool, add its repositories
+to the registry and write a workspace manifest for 'workspace-manager apply'.
+
+Sources and their default configuration:
+
+  meta   .meta in the current directory ({"projects": {"dir": "url"}})
+  gita   $XDG_CONFIG_HOME/gita/repos.csv, with groups from groups.csv
+  repo   .repo/manifest.xml in the current directory, following <include>
+
+Checkout paths of meta and repo are relative to the directory of .meta or the
+one holding .repo, unless --root is given. Repositories that are not checked out
+are skipped, or cloned from their remote with --clone. Repo projects pinned to a
+tag or commit are pinned in the manifest; branch revisions are not, since the
+workspace branch starts from them.
+
+Examples:
+  # Preview what a meta project would import
+  workspace-manager migrate --from meta --dry-run
+
+  # Register the repositories of a repo checkout and save a manifest of its 'tools' group
+  workspace-manager migrate --from repo ~/aosp/.repo/manifest.xml --group tools -o tools.yaml
+
+  # Register gita's repositories and print the manifest
+  workspace-manager migrate --from gita --name everything`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configPath := ""
+			if len(args) > 0 {
+				configPath = args[0]
+			}
+			return runMigrate(cmd.Context(), from, configPath, root, name, groups, outputPath, clone, dryRun)
+		},
+	}
+
+	cmd.Flags().StringVar(&from, "from", "", "Tool to migrate from: "+strings.Join(wsm.MigrationSources, ", "))
+	cmd.Flags().StringVar(&root, "root", "", "Directory the checkout paths of the configuration are relative to")
+	cmd.Flags().StringVar(&name, "name", "", "Name of the workspace in the manifest (default: the root directory name)")
+	cmd.Flags().StringSliceVar(&groups, "group", nil, "Only import repositories of these groups (gita and repo)")
+	cmd.Flags().StringVarP(&outputPath, "output", "o", "", "Write the manifest to a file instead of stdout")
+	cmd.Flags().BoolVar(&clone, "clone", false, "Clone repositories that are not checked out from their remote")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be imported without changing the registry")
+	_ = cmd.MarkFlagRequired("from")
+
+	carapace.Gen(cmd).PositionalCompletion(carapace.ActionFiles())
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"from":   carapace.ActionValues(wsm.MigrationSources...),
+		"root":   carapace.ActionDirectories(),
+		"output": carapace.ActionFiles(".yaml", ".yml"),
+	})
+
+	return cmd
+}
+
+func runMigrate(ctx context.Context, from, configPath, root, name string, groups []string, outputPath string, clone, dryRun bool) error {
+	migration, err := wsm.ReadMigration(ctx, from, configPath, root)
+	if err != nil {
+		return err
+	}
+	migration.Filter(groups)
+	if len(migration.Repositories) == 0 {
+		output.PrintInfo("No repositories found in %s", migration.Config)
+		return nil
+	}
+
+	output.PrintHeader("Repositories from %s (%s)", migration.Source, migration.Config)
+	printMigration(migration, clone)
+	for _, warning := range migration.Warnings {
+		output.PrintWarning("%s", warning)
+	}
+
+	if !dryRun {
+		wm, err := wsm.NewWorkspaceManager()
+		if err != nil {
+			return errors.Wrap(err, "failed to create workspace manager")
+		}
+		cloned, err := wm.RegisterMigration(ctx, migration, clone)
+		if err != nil {
+			return err
+		}
+		registered := 0
+		for _, repo := range migration.Repositories {
+			if repo.Exists {
+				registered++
+			}
+		}
+		output.PrintSuccess("Added %d repositories to the registry (%d cloned)", registered, len(cloned))
+	}
+
+	if name == "" {
+		name = migration.Source
+		if migration.Root != "" {
+			name = filepath.Base(migration.Root)
+		}
+	}
+	data, err := yaml.Marshal(migration.Manifest(name))
+	if err != nil {
+		return errors.Wrap(err, "failed to encode manifest")
+	}
+
+	if outputPath == "" || dryRun {
+		output.Printf("\n")
+		output.PrintHeader("Workspace manifest")
+		fmt.Print(string(data))
+		return nil
+	}
+	if err := os.WriteFile(outputPath, data, 0644); err != nil {
+		return errors.Wrapf(err, "failed to write %s", outputPath)
+	}
+	output.PrintSuccess("Wrote %s; create the workspace with 'workspace-manager apply %s'", outputPath, outputPath)
+	return nil
+}
+
+func printMigration(migration *wsm.Migration, clone bool) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "NAME\tPATH\tREMOTE\tPIN\tSTATUS")
+	for _, repo := range migration.Repositories {
+		status := "checked out"
+		switch {
+		case repo.Exists:
+		case clone && repo.Remote != "":
+			status = "clone"
+		default:
+			status = "missing, skipped"
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", repo.Name, repo.Path, orDash(repo.Remote), orDash(repo.Pin), status)
+	}
+	if err := w.Flush(); err != nil {
+		output.LogWarn(
+			fmt.Sprintf("Failed to flush migration table: %v", err),
+			"Failed to flush tabwriter",
+			"error", err,
+		)
+	}
+}
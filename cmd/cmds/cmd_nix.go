@@ -0,0 +1,110 @@
+package cmds
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewNixCommand creates the nix command
+func NewNixCommand() *cobra.Command {
+	var (
+		format string
+		stdout bool
+		force  bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "nix [workspace-name]",
+		Short: "Generate a Nix dev shell for a workspace",
+		Long: `Write a flake.nix (or shell.nix with --format shell) to the workspace root
+whose dev shell provides the toolchains of all repositories, so 'nix develop'
+(or 'nix-shell') in the workspace yields a complete environment.
+
+Toolchain versions are read from:
+  go.mod               Go (toolchain directive, or the go directive)
+  .nvmrc/.node-version Node.js
+  .python-version      Python
+
+When repositories require different versions, the highest one is used and the
+conflict is noted in the generated file. Files generated by this command are
+overwritten on the next run; other files require --force.
+
+Examples:
+  workspace-manager nix
+  workspace-manager nix my-feature --format shell
+  workspace-manager nix --stdout`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspaceName := ""
+			if len(args) > 0 {
+				workspaceName = args[0]
+			}
+			return runNix(workspaceName, format, stdout, force)
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", wsm.NixFormatFlake, "File to generate: flake (flake.nix), shell (shell.nix)")
+	cmd.Flags().BoolVar(&stdout, "stdout", false, "Print the file instead of writing it")
+	cmd.Flags().BoolVar(&force, "force", false, "Overwrite an existing file not generated by wsm")
+
+	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion())
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"format": carapace.ActionValues(wsm.NixFormatFlake, wsm.NixFormatShell),
+	})
+
+	return cmd
+}
+
+func runNix(workspaceName, format string, stdout, force bool) error {
+	if format != wsm.NixFormatFlake && format != wsm.NixFormatShell {
+		return errors.Errorf("unknown format '%s' (expected flake or shell)", format)
+	}
+
+	workspace, err := resolveWorkspace(workspaceName)
+	if err != nil {
+		return err
+	}
+	workspace, err = wsm.ExpandWorkspace(workspace)
+	if err != nil {
+		return err
+	}
+
+	packages := wsm.NixPackages(wsm.DetectToolRequirements(workspace))
+	content := wsm.GenerateNix(workspace, packages, format)
+
+	if stdout {
+		fmt.Print(content)
+		return nil
+	}
+
+	path := filepath.Join(workspace.Path, wsm.NixFileName(format))
+	if _, err := os.Stat(path); err == nil && !force && !wsm.IsGeneratedNixFile(path) {
+		return errors.Errorf("%s exists and was not generated by wsm; use --force to overwrite it", path)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return errors.Wrapf(err, "failed to write %s", path)
+	}
+
+	for _, pkg := range packages {
+		if pkg.Conflict {
+			output.PrintWarning("Repositories require different %s versions, using %s", pkg.Tool, pkg.Attribute)
+		}
+	}
+	if len(packages) == 0 {
+		output.PrintWarning("No toolchain requirements found; the dev shell only provides git")
+	}
+	output.PrintSuccess("Wrote %s", path)
+	if format == wsm.NixFormatFlake {
+		output.PrintInfo("Run 'nix develop' in %s", workspace.Path)
+	} else {
+		output.PrintInfo("Run 'nix-shell' in %s", workspace.Path)
+	}
+	return nil
+}
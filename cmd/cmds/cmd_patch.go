@@ -0,0 +1,157 @@
+package cmds
+
+import (
+	"context"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewPatchCommand creates the patch command
+func NewPatchCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "patch",
+		Short: "Export and apply patch series across workspace repositories",
+		Long: `Transfer multi-repository changes without pushing them.
+
+'patch export' writes a git format-patch series per repository, and
+'patch apply' applies such a directory onto another workspace with git am.`,
+	}
+
+	cmd.AddCommand(
+		NewPatchExportCommand(),
+		NewPatchApplyCommand(),
+	)
+
+	return cmd
+}
+
+// NewPatchExportCommand creates the patch export command
+func NewPatchExportCommand() *cobra.Command {
+	var (
+		outputDir string
+		base      string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export [workspace-name]",
+		Short: "Export the workspace branch as per-repository patch series",
+		Long: `Write the commits of the workspace branch as git format-patch series,
+one directory per repository: <output>/<repo>/0001-*.patch
+
+Commits are taken from <base>..HEAD, where base defaults to the workspace
+base branch or origin/main. Rerunning the export replaces the patches of
+each repository directory written by the previous run.
+
+Examples:
+  # Export patches for the current workspace
+  workspace-manager patch export --output ./my-feature-patches
+
+  # Export relative to a specific base
+  workspace-manager patch export my-feature --base origin/develop`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspaceName := ""
+			if len(args) > 0 {
+				workspaceName = args[0]
+			}
+			return runPatchExport(cmd.Context(), workspaceName, outputDir, base)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputDir, "output", "o", "", "Output directory (defaults to <workspace-name>-patches)")
+	cmd.Flags().StringVar(&base, "base", "", "Base ref to export commits from (defaults to workspace base branch or origin/main)")
+
+	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion())
+
+	return cmd
+}
+
+// NewPatchApplyCommand creates the patch apply command
+func NewPatchApplyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply <patch-dir> [workspace-name]",
+		Short: "Apply exported patch series onto a workspace",
+		Long: `Apply a directory produced by 'patch export' onto a workspace.
+Each <patch-dir>/<repo>/ series is applied with 'git am --3way' in the matching
+repository worktree. A series that fails is aborted and reported.
+
+Examples:
+  # Apply patches onto the current workspace
+  workspace-manager patch apply ./my-feature-patches`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspaceName := ""
+			if len(args) > 1 {
+				workspaceName = args[1]
+			}
+			return runPatchApply(cmd.Context(), args[0], workspaceName)
+		},
+	}
+
+	return cmd
+}
+
+func runPatchExport(ctx context.Context, workspaceName, outputDir, base string) error {
+	workspace, err := resolveWorkspace(workspaceName)
+	if err != nil {
+		return err
+	}
+
+	if outputDir == "" {
+		outputDir = workspace.Name + "-patches"
+	}
+
+	series, err := wsm.ExportPatches(ctx, workspace, base, outputDir)
+	if err != nil {
+		return errors.Wrap(err, "patch export failed")
+	}
+
+	if len(series) == 0 {
+		output.PrintInfo("No commits to export on top of %s", wsm.WorkspaceBaseRef(workspace, base))
+		return nil
+	}
+
+	output.PrintSuccess("Exported patches to %s", outputDir)
+	for _, s := range series {
+		output.Printf("  %s: %d patches (base: %s)\n", s.Repository, len(s.Patches), s.Base)
+	}
+
+	return nil
+}
+
+func runPatchApply(ctx context.Context, patchDir, workspaceName string) error {
+	workspace, err := resolveWorkspace(workspaceName)
+	if err != nil {
+		return err
+	}
+
+	results, err := wsm.ApplyPatches(ctx, workspace, patchDir)
+	if err != nil {
+		return errors.Wrap(err, "patch apply failed")
+	}
+
+	if len(results) == 0 {
+		output.PrintInfo("No patches found for repositories of workspace '%s'", workspace.Name)
+		return nil
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Error != "" {
+			failed++
+			output.PrintError("%s: %s", result.Repository, result.Error)
+			continue
+		}
+		output.PrintSuccess("%s: applied %d patches", result.Repository, result.Applied)
+	}
+
+	if failed > 0 {
+		return errors.Errorf("failed to apply patches to %d repositories", failed)
+	}
+
+	return nil
+}
@@ -0,0 +1,175 @@
+package cmds
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewPolicyCommand creates the policy command
+func NewPolicyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "policy",
+		Short: "Check workspaces against compliance rules",
+		Long: `Describe rules workspaces must comply with in policy.yaml, next to config.yaml
+(~/.config/workspace-manager/policy.yaml for the default profile):
+
+  rules:
+    - name: go-tooling
+      description: Go workspaces pin their tools in the tooling repository
+      match:
+        tags: [go]                 # workspaces with a repository tagged go
+      require:
+        repositories: [tooling]
+    - name: branch-naming
+      require:
+        branch: '^(feature|fix|chore)/[a-z0-9._-]+$'
+    - name: stale
+      severity: warning
+      match:
+        selector: '!keep'          # label selector, see 'label'
+      require:
+        max_age: 60d
+    - name: ownership
+      match:
+        name: 'release-*'          # glob on the workspace name
+      require:
+        labels: [team]
+
+A rule applies to the workspaces matching all of its match conditions (name,
+selector, tags, repositories), or to every workspace without any. Each
+requirement a workspace fails is a violation.`,
+	}
+
+	cmd.AddCommand(NewPolicyCheckCommand())
+
+	return cmd
+}
+
+// NewPolicyCheckCommand creates the policy check command
+func NewPolicyCheckCommand() *cobra.Command {
+	var (
+		file     string
+		selector string
+		format   string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "check [workspace...]",
+		Short: "Report workspaces violating the policy",
+		Long: `Evaluate the policy against all workspaces, or the given ones, and list the
+violations. The command exits with status 1 when a rule of severity error is
+violated, so it can run from cron or CI and feed cleanup reports (--format json).
+
+Examples:
+  workspace-manager policy check
+  workspace-manager policy check --selector team=payments --format json
+  workspace-manager policy check --file ./team-policy.yaml my-feature`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPolicyCheck(cmd, args, file, selector, format)
+		},
+	}
+
+	cmd.Flags().StringVar(&file, "file", "", "Policy file (default: policy.yaml in the configuration directory)")
+	cmd.Flags().StringVarP(&selector, "selector", "l", "", "Only check workspaces matching a label selector")
+	cmd.Flags().StringVar(&format, "format", "text", "Output format: text, json")
+
+	carapace.Gen(cmd).PositionalAnyCompletion(WorkspaceNameCompletion())
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"file":     carapace.ActionFiles(".yaml", ".yml"),
+		"selector": LabelCompletion(),
+		"format":   carapace.ActionValues("text", "json"),
+	})
+
+	return cmd
+}
+
+func runPolicyCheck(cmd *cobra.Command, names []string, file, selector, format string) error {
+	if file == "" {
+		path, err := wsm.DefaultPolicyPath()
+		if err != nil {
+			return err
+		}
+		file = path
+	}
+	policy, err := wsm.LoadPolicy(file)
+	if err != nil {
+		return err
+	}
+	labelSelector, err := wsm.ParseLabelSelector(selector)
+	if err != nil {
+		return err
+	}
+
+	workspaces, err := wsm.LoadWorkspaces()
+	if err != nil {
+		return errors.Wrap(err, "failed to load workspaces")
+	}
+	for _, name := range names {
+		if !slices.ContainsFunc(workspaces, func(workspace wsm.Workspace) bool { return workspace.Name == name }) {
+			return errors.Errorf("workspace '%s' not found", name)
+		}
+	}
+	if len(names) > 0 {
+		workspaces = slices.DeleteFunc(workspaces, func(workspace wsm.Workspace) bool { return !slices.Contains(names, workspace.Name) })
+	}
+	workspaces = labelSelector.Filter(workspaces)
+
+	violations := policy.Check(workspaces, time.Now())
+	failed := slices.ContainsFunc(violations, func(violation wsm.PolicyViolation) bool {
+		return violation.Severity == wsm.PolicySeverityError
+	})
+
+	if format == "json" {
+		if violations == nil {
+			violations = []wsm.PolicyViolation{}
+		}
+		if err := wsm.PrintJSON(violations); err != nil {
+			return err
+		}
+	} else if len(violations) == 0 {
+		output.PrintSuccess("%d workspaces comply with the %d rules of %s", len(workspaces), len(policy.Rules), file)
+	} else {
+		printPolicyViolations(violations)
+		fmt.Println()
+		output.PrintWarning("%d violations in %d of %d workspaces", len(violations), countViolatingWorkspaces(violations), len(workspaces))
+	}
+
+	if !failed {
+		return nil
+	}
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	return &wsm.ExitCodeError{Code: 1}
+}
+
+func printPolicyViolations(violations []wsm.PolicyViolation) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "WORKSPACE\tRULE\tSEVERITY\tVIOLATION")
+	for _, violation := range violations {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", violation.Workspace, violation.Rule, violation.Severity, violation.Message)
+	}
+	if err := w.Flush(); err != nil {
+		output.LogWarn(
+			fmt.Sprintf("Failed to flush policy table: %v", err),
+			"Failed to flush tabwriter",
+			"error", err,
+		)
+	}
+}
+
+func countViolatingWorkspaces(violations []wsm.PolicyViolation) int {
+	seen := map[string]bool{}
+	for _, violation := range violations {
+		seen[violation.Workspace] = true
+	}
+	return len(seen)
+}
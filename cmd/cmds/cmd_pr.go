@@ -9,6 +9,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -25,6 +26,10 @@ func NewPRCommand() *cobra.Command {
 		draft     bool
 		title     string
 		body      string
+		skipCheck bool
+
+		reviewers bool
+		balance   bool
 	)
 
 	cmd := &cobra.Command{
@@ -43,10 +48,24 @@ A branch is considered to need a PR if:
 - It has commits ahead of origin/main
 - If the branch doesn't exist on remote, it will be pushed first
 
+With --reviewers, reviews are requested from the code owners: every owner of a
+file the branch changes is asked for a review, following the CODEOWNERS file
+(.github/CODEOWNERS, CODEOWNERS or docs/CODEOWNERS) of the base branch of the
+workspace, like GitHub, and the consolidated plan is shown before creating the
+PRs. With --balance-reviewers, a single owner is picked for each group of
+owners listed together, the one with the fewest reviews in the plan, which
+spreads the reviews of a multi-repository change.
+
 Requirements:
 - GitHub CLI (gh) must be installed and authenticated
 - Repositories must be hosted on GitHub
 
+The default title and body of the PRs name the issues linked to the workspace
+with 'link issue' and their summary. --title and --body may use {ticket} and
+{summary}, the reference and summary of the first linked issue. Once PRs are
+created, linked Jira tickets are moved to issues.jira.transitions.pr, e.g. In
+Review (see 'link --help').
+
 Examples:
   # Check what PRs would be created (dry run)
   workspace-manager pr my-workspace --dry-run
@@ -58,14 +77,23 @@ Examples:
   workspace-manager pr my-workspace --force
 
   # Create draft PRs with custom title
-  workspace-manager pr my-workspace --draft --title "WIP: Feature branch"`,
+  workspace-manager pr my-workspace --draft --title "WIP: Feature branch"
+
+  # Title the PRs after the linked ticket
+  workspace-manager pr my-workspace --title "{ticket}: {summary}"
+
+  # Request reviews from the code owners of the changed files
+  workspace-manager pr my-workspace --reviewers
+
+  # Request one code owner per owned area instead of all of them
+  workspace-manager pr my-workspace --balance-reviewers`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			workspaceName := workspace
 			if len(args) > 0 {
 				workspaceName = args[0]
 			}
-			return runPR(cmd.Context(), workspaceName, dryRun, force, draft, title, body)
+			return runPR(cmd.Context(), workspaceName, dryRun, force, draft, title, body, skipCheck, reviewers || balance, balance)
 		},
 	}
 
@@ -73,13 +101,18 @@ Examples:
 	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what PRs would be created without actually creating them")
 	cmd.Flags().BoolVarP(&force, "force", "f", false, "Create PRs without asking for confirmation")
 	cmd.Flags().BoolVar(&draft, "draft", false, "Create draft pull requests")
-	cmd.Flags().StringVar(&title, "title", "", "Custom title for all PRs (default: use branch name)")
-	cmd.Flags().StringVar(&body, "body", "", "Custom body for all PRs")
+	cmd.Flags().StringVar(&title, "title", "", "Custom title for all PRs, may use {ticket} and {summary} (default: the linked ticket or the branch name)")
+	cmd.Flags().StringVar(&body, "body", "", "Custom body for all PRs, may use {ticket} and {summary}")
+	cmd.Flags().BoolVar(&skipCheck, "skip-preflight", false, "Skip the remote access preflight check")
+	cmd.Flags().BoolVar(&reviewers, "reviewers", false, "Request reviews from the code owners of the changed files")
+	cmd.Flags().BoolVar(&balance, "balance-reviewers", false, "Request one code owner per group of owners, spreading the reviews (implies --reviewers)")
+
+	cmd.AddCommand(NewPRCleanupCommand())
 
 	return cmd
 }
 
-func runPR(ctx context.Context, workspaceName string, dryRun, force, draft bool, customTitle, customBody string) error {
+func runPR(ctx context.Context, workspaceName string, dryRun, force, draft bool, customTitle, customBody string, skipPreflight, reviewers, balance bool) error {
 	// Check if gh CLI is available
 	if err := checkGHCLI(ctx); err != nil {
 		return err
@@ -104,6 +137,13 @@ func runPR(ctx context.Context, workspaceName string, dryRun, force, draft bool,
 	if err != nil {
 		return errors.Wrapf(err, "failed to load workspace '%s'", workspaceName)
 	}
+	if err := workspace.RequireWorktrees("pushing branches for pull requests"); err != nil {
+		return err
+	}
+
+	if err := preflightGate(ctx, workspace, "origin", true, skipPreflight || dryRun); err != nil {
+		return err
+	}
 
 	// Get workspace status to check branch merge status
 	checker := wsm.NewStatusChecker()
@@ -115,6 +155,9 @@ func runPR(ctx context.Context, workspaceName string, dryRun, force, draft bool,
 	// Find branches that need PRs
 	var candidateBranches []PRCandidate
 	for _, repoStatus := range status.Repositories {
+		if repoStatus.Frozen {
+			continue
+		}
 		if candidate, needsPR := checkIfNeedsPR(ctx, repoStatus, workspace.Path); needsPR {
 			candidateBranches = append(candidateBranches, candidate)
 		}
@@ -127,19 +170,29 @@ func runPR(ctx context.Context, workspaceName string, dryRun, force, draft bool,
 
 	// Show what we found
 	output.PrintHeader("Found %d branch(es) that could use pull requests:", len(candidateBranches))
-	fmt.Println()
+	output.Printf("\n")
 
 	for i, candidate := range candidateBranches {
 		output.PrintInfo("%d. %s/%s", i+1, candidate.Repository, candidate.Branch)
-		fmt.Printf("   Commits ahead: %d\n", candidate.CommitsAhead)
+		output.Printf("   Commits ahead: %d\n", candidate.CommitsAhead)
 		if candidate.NeedsPush {
 			output.PrintWarning("   🚀 Needs push: Branch must be pushed to remote first")
 		}
 		if candidate.ExistingPR != "" {
 			output.PrintWarning("   ⚠️  Existing PR: %s", candidate.ExistingPR)
 		}
-		fmt.Printf("   Remote URL: %s\n", candidate.RemoteURL)
-		fmt.Println()
+		output.Printf("   Remote URL: %s\n", candidate.RemoteURL)
+		output.Printf("\n")
+	}
+
+	// Reviewers come from the CODEOWNERS of each repository
+	plannedReviewers := map[string][]string{}
+	if reviewers {
+		plan := planPRReviewers(ctx, candidateBranches, workspace.BaseBranch, balance)
+		printReviewerPlan(plan)
+		for _, repo := range plan.Repositories {
+			plannedReviewers[repo.Repository] = repo.Reviewers
+		}
 	}
 
 	if dryRun {
@@ -147,8 +200,14 @@ func runPR(ctx context.Context, workspaceName string, dryRun, force, draft bool,
 		return nil
 	}
 
+	wm, err := wsm.NewWorkspaceManager()
+	if err != nil {
+		return errors.Wrap(err, "failed to create workspace manager")
+	}
+
 	// Create PRs
 	reader := bufio.NewReader(os.Stdin)
+	created := 0
 	for _, candidate := range candidateBranches {
 		if candidate.ExistingPR != "" {
 			output.PrintWarning("Skipping %s/%s - PR already exists: %s", candidate.Repository, candidate.Branch, candidate.ExistingPR)
@@ -167,23 +226,57 @@ func runPR(ctx context.Context, workspaceName string, dryRun, force, draft bool,
 			// Push branch first if needed
 			if candidate.NeedsPush {
 				output.PrintInfo("🚀 Pushing branch %s/%s to remote...", candidate.Repository, candidate.Branch)
-				if err := pushBranchForPR(ctx, candidate); err != nil {
+				if err := pushBranchForPR(ctx, candidate, wm.Config().Timeouts); err != nil {
 					output.PrintError("Failed to push branch %s/%s: %v", candidate.Repository, candidate.Branch, err)
 					continue
 				}
 				output.PrintSuccess("Pushed branch %s/%s", candidate.Repository, candidate.Branch)
 			}
 
-			if err := createPR(ctx, candidate, draft, customTitle, customBody); err != nil {
+			url, err := createPR(ctx, candidate, workspace.Issues, draft, customTitle, customBody, plannedReviewers[candidate.Repository])
+			if err != nil {
 				output.PrintError("Failed to create PR for %s/%s: %v", candidate.Repository, candidate.Branch, err)
 			} else {
 				output.PrintSuccess("Created PR for %s/%s", candidate.Repository, candidate.Branch)
+				created++
+				wm.Notify(ctx, wsm.Event{
+					Type:       wsm.EventPROpened,
+					Workspace:  workspace.Name,
+					Repository: candidate.Repository,
+					Branch:     candidate.Branch,
+					URL:        url,
+					Message:    fmt.Sprintf("opened a pull request for %s/%s: %s", candidate.Repository, candidate.Branch, orDash(url)),
+				})
 			}
 		} else {
 			output.PrintInfo("Skipped %s/%s", candidate.Repository, candidate.Branch)
 		}
 	}
 
+	if created > 0 {
+		if err := updatePRJiraIssues(ctx, workspace.Name); err != nil {
+			output.PrintWarning("%v", err)
+		}
+	}
+
+	return nil
+}
+
+// updatePRJiraIssues moves the Jira tickets linked to the workspace to issues.jira.transitions.pr
+func updatePRJiraIssues(ctx context.Context, workspaceName string) error {
+	wm, err := wsm.NewWorkspaceManager()
+	if err != nil {
+		return errors.Wrap(err, "failed to create workspace manager")
+	}
+	status := wm.Config().Issues.Jira.Transitions.PR
+	if status == "" {
+		return nil
+	}
+	workspace, err := wm.LoadWorkspace(workspaceName)
+	if err != nil {
+		return errors.Wrap(err, "failed to load workspace")
+	}
+	updateJiraIssues(ctx, wm, workspace, status)
 	return nil
 }
 
@@ -330,32 +423,45 @@ func checkExistingPR(ctx context.Context, repoPath, branch string) string {
 	return strings.TrimSpace(string(output))
 }
 
-func pushBranchForPR(ctx context.Context, candidate PRCandidate) error {
-	cmd := exec.CommandContext(ctx, "git", "push", "-u", "origin", candidate.Branch)
-	cmd.Dir = candidate.RepoPath
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
+func pushBranchForPR(ctx context.Context, candidate PRCandidate, timeouts wsm.TimeoutConfig) error {
+	ctx, done := wsm.WithNetworkTimeout(ctx, timeouts, candidate.Repository, wsm.OperationPush)
+	output, err := wsm.ExecRunner{}.CombinedOutput(ctx, candidate.RepoPath, "git", "push", "-u", "origin", candidate.Branch)
+	if err = done(err); err != nil {
+		if wsm.IsTimeout(err) {
+			return err
+		}
 		return errors.Wrapf(err, "git push failed: %s", string(output))
 	}
 
 	return nil
 }
 
-func createPR(ctx context.Context, candidate PRCandidate, draft bool, customTitle, customBody string) error {
+// createPR runs 'gh pr create' and returns the URL of the new pull request
+func createPR(ctx context.Context, candidate PRCandidate, issues []wsm.IssueLink, draft bool, customTitle, customBody string, reviewers []string) (string, error) {
 	args := []string{"pr", "create"}
 
 	// Add title
-	title := customTitle
+	title := wsm.ExpandIssueTemplate(customTitle, issues)
 	if title == "" {
 		title = fmt.Sprintf("Feature: %s", candidate.Branch)
+		if len(issues) > 0 {
+			subject := candidate.Branch
+			if issues[0].Summary != "" {
+				subject = issues[0].Summary
+				title = subject
+			}
+			// Jira picks up keys mentioned in the title
+			if issues[0].Tracker == wsm.IssueTrackerJira {
+				title = fmt.Sprintf("%s: %s", issues[0].Ref, subject)
+			}
+		}
 	}
 	args = append(args, "--title", title)
 
 	// Add body
-	body := customBody
+	body := wsm.ExpandIssueTemplate(customBody, issues)
 	if body == "" {
-		body = fmt.Sprintf("Pull request for branch: %s\n\nCreated automatically by workspace-manager.", candidate.Branch)
+		body = fmt.Sprintf("Pull request for branch: %s\n\n%sCreated automatically by workspace-manager.", candidate.Branch, formatPRIssues(issues))
 	}
 	args = append(args, "--body", body)
 
@@ -364,13 +470,122 @@ func createPR(ctx context.Context, candidate PRCandidate, draft bool, customTitl
 		args = append(args, "--draft")
 	}
 
+	if handles := reviewerHandles(reviewers); len(handles) > 0 {
+		args = append(args, "--reviewer", strings.Join(handles, ","))
+	}
+
 	cmd := exec.CommandContext(ctx, "gh", args...)
 	cmd.Dir = candidate.RepoPath
 
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return errors.Wrapf(err, "gh pr create failed: %s", string(output))
+		return "", errors.Wrapf(err, "gh pr create failed: %s", string(output))
 	}
 
-	return nil
+	// gh prints the URL of the pull request last
+	fields := strings.Fields(string(output))
+	for i := len(fields) - 1; i >= 0; i-- {
+		if strings.HasPrefix(fields[i], "https://") {
+			return fields[i], nil
+		}
+	}
+	return "", nil
+}
+
+// formatPRIssues lists the issues linked to the workspace for the default PR body
+func formatPRIssues(issues []wsm.IssueLink) string {
+	if len(issues) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("Related issues:\n")
+	for _, issue := range issues {
+		line := issue.Ref
+		if issue.Summary != "" {
+			line += ": " + issue.Summary
+		}
+		if issue.URL != "" && issue.URL != issue.Ref {
+			line += fmt.Sprintf(" (%s)", issue.URL)
+		}
+		fmt.Fprintf(&b, "- %s\n", line)
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// planPRReviewers computes the reviewers of the PRs about to be created from the CODEOWNERS of
+// their repositories at base, by default origin/main, leaving out the authenticated user
+func planPRReviewers(ctx context.Context, candidates []PRCandidate, base string, balance bool) *wsm.ReviewerPlan {
+	if base == "" {
+		base = "origin/main"
+	}
+	var requests []wsm.ReviewRequest
+	for _, candidate := range candidates {
+		if candidate.ExistingPR != "" {
+			continue
+		}
+		requests = append(requests, wsm.ReviewRequest{Repository: candidate.Repository, Path: candidate.RepoPath, Base: base})
+	}
+
+	author := ""
+	if out, err := exec.CommandContext(ctx, "gh", "api", "user", "--jq", ".login").Output(); err == nil {
+		author = strings.TrimSpace(string(out))
+	}
+	return wsm.PlanReviewers(ctx, requests, author, balance)
+}
+
+// printReviewerPlan shows the reviewers of each PR and how many reviews each reviewer gets
+func printReviewerPlan(plan *wsm.ReviewerPlan) {
+	if len(plan.Repositories) == 0 {
+		return
+	}
+	output.PrintHeader("Reviewer plan (from CODEOWNERS):")
+	for _, repo := range plan.Repositories {
+		switch {
+		case repo.Error != "":
+			output.PrintWarning("   %s: %s", repo.Repository, repo.Error)
+		case repo.CodeOwners == "":
+			output.Printf("   %s: no CODEOWNERS\n", repo.Repository)
+		case len(repo.Reviewers) == 0:
+			output.Printf("   %s: no owned files changed\n", repo.Repository)
+		default:
+			var reviewers []string
+			for _, reviewer := range repo.Reviewers {
+				reviewers = append(reviewers, fmt.Sprintf("%s (%d file(s))", reviewer, repo.Files[reviewer]))
+			}
+			output.Printf("   %s: %s\n", repo.Repository, strings.Join(reviewers, ", "))
+		}
+		if len(repo.Unowned) > 0 {
+			output.Printf("      %d changed files have no owner\n", len(repo.Unowned))
+		}
+		for _, owners := range repo.Unassignable {
+			output.PrintWarning("      only you own files of %s; ask someone else to review them", owners)
+		}
+	}
+
+	if len(plan.Load) > 0 {
+		var reviewers []string
+		for reviewer := range plan.Load {
+			reviewers = append(reviewers, reviewer)
+		}
+		sort.Strings(reviewers)
+		output.Printf("\n")
+		output.Printf("   Reviews per reviewer:\n")
+		for _, reviewer := range reviewers {
+			output.Printf("   %-24s %d (%s)\n", reviewer, len(plan.Load[reviewer]), strings.Join(plan.Load[reviewer], ", "))
+		}
+	}
+	output.Printf("\n")
+}
+
+// reviewerHandles turns CODEOWNERS owners into the reviewers 'gh pr create' accepts: users and
+// org/team without the @. Email owners cannot be requested and are left out.
+func reviewerHandles(owners []string) []string {
+	var handles []string
+	for _, owner := range owners {
+		if handle, ok := strings.CutPrefix(owner, "@"); ok {
+			handles = append(handles, handle)
+		}
+	}
+	return handles
 }
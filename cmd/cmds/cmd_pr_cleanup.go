@@ -0,0 +1,224 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/charmbracelet/huh"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewPRCleanupCommand creates the pr cleanup subcommand
+func NewPRCleanupCommand() *cobra.Command {
+	var (
+		dryRun          bool
+		force           bool
+		deleteWorkspace bool
+		keepWorkspace   bool
+		format          string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "cleanup [workspace-name]",
+		Short: "Clean up after the pull requests of a workspace are merged",
+		Long: `Close the loop once the pull requests of a workspace are merged.
+
+For every repository whose branch has a merged pull request, this command:
+1. Deletes the branch from origin (if GitHub did not already)
+2. Fast-forwards the local base branch of the source repository to origin
+
+When every repository is finished (merged or without changes, no open pull
+request and no commits made after the merge), it then offers to delete the
+workspace, its files and its local branches.
+
+Requirements:
+- GitHub CLI (gh) must be installed and authenticated
+
+Examples:
+  # Show what would be cleaned up
+  workspace-manager pr cleanup my-workspace --dry-run
+
+  # Clean up without asking, then delete the workspace if it is finished
+  workspace-manager pr cleanup my-workspace --force --delete-workspace`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspaceName := ""
+			if len(args) > 0 {
+				workspaceName = args[0]
+			}
+			if deleteWorkspace && keepWorkspace {
+				return errors.New("--delete-workspace and --keep-workspace are mutually exclusive")
+			}
+			return runPRCleanup(cmd.Context(), workspaceName, dryRun, force, deleteWorkspace, keepWorkspace, format)
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be cleaned up without changing anything")
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "Delete remote branches and fast-forward without asking")
+	cmd.Flags().BoolVar(&deleteWorkspace, "delete-workspace", false, "Delete the workspace without asking when it is finished")
+	cmd.Flags().BoolVar(&keepWorkspace, "keep-workspace", false, "Never offer to delete the workspace")
+	cmd.Flags().StringVar(&format, "format", "text", "Output format of the plan: text, json")
+
+	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion())
+
+	return cmd
+}
+
+func runPRCleanup(ctx context.Context, workspaceName string, dryRun, force, deleteWorkspace, keepWorkspace bool, format string) error {
+	if err := checkGHCLI(ctx); err != nil {
+		return err
+	}
+
+	workspace, err := resolveWorkspace(workspaceName)
+	if err != nil {
+		return err
+	}
+
+	manager, err := wsm.NewWorkspaceManager()
+	if err != nil {
+		return errors.Wrap(err, "failed to create workspace manager")
+	}
+
+	plan, err := manager.PRCleanupPlan(ctx, workspace)
+	if err != nil {
+		return err
+	}
+
+	if format == "json" {
+		return wsm.PrintJSON(plan)
+	}
+
+	output.PrintHeader("Pull requests of workspace '%s'", workspace.Name)
+	merged, finished := 0, true
+	for _, cleanup := range plan {
+		if !cleanup.Finished() {
+			finished = false
+		}
+		switch {
+		case cleanup.Error != "":
+			output.PrintError("%s: %s", cleanup.Repository, cleanup.Error)
+			continue
+		case cleanup.Merged != nil:
+			merged++
+			output.Printf("  %s  #%d merged into %s  %s\n", output.InfoStyle.Render(cleanup.Repository),
+				cleanup.Merged.Number, cleanup.Merged.Base, output.DimStyle.Render(cleanup.Merged.URL))
+			if cleanup.RemoteBranch {
+				output.Printf("    delete origin/%s, fast-forward %s\n", cleanup.Branch, cleanup.Merged.Base)
+			} else {
+				output.Printf("    fast-forward %s\n", cleanup.Merged.Base)
+			}
+		case cleanup.Open != "":
+			output.Printf("  %s  open pull request  %s\n", output.InfoStyle.Render(cleanup.Repository), output.DimStyle.Render(cleanup.Open))
+		default:
+			output.Printf("  %s  %s\n", output.InfoStyle.Render(cleanup.Repository), output.DimStyle.Render("no pull request"))
+		}
+		if cleanup.LocalCommits > 0 {
+			output.PrintWarning("    %d commits not merged", cleanup.LocalCommits)
+		}
+	}
+	output.Printf("\n")
+
+	if merged == 0 {
+		output.PrintInfo("No merged pull requests found.")
+		return nil
+	}
+	if dryRun {
+		if finished && !keepWorkspace {
+			output.PrintInfo("The workspace is finished and would be offered for deletion.")
+		}
+		output.PrintInfo("Dry run mode - nothing was changed.")
+		return nil
+	}
+
+	if !force {
+		if err := output.RequireInteractive("confirm the cleanup", "use --force to clean up without confirmation"); err != nil {
+			return err
+		}
+		confirmed, err := confirmPRCleanup(fmt.Sprintf("Clean up the %d merged pull request(s)?", merged), "Remote branches are deleted and base branches fast-forwarded.")
+		if err != nil || !confirmed {
+			return err
+		}
+	}
+
+	for _, cleanup := range plan {
+		if cleanup.Merged == nil || cleanup.Error != "" {
+			continue
+		}
+		repo, ok := wsm.FindRepository(workspace, cleanup.Repository)
+		if !ok {
+			continue
+		}
+		if cleanup.RemoteBranch {
+			if err := manager.DeleteRemoteBranch(ctx, repo, cleanup.Branch); err != nil {
+				output.PrintError("%s: failed to delete origin/%s: %v", cleanup.Repository, cleanup.Branch, err)
+			} else {
+				output.PrintSuccess("%s: deleted origin/%s", cleanup.Repository, cleanup.Branch)
+			}
+		}
+		updated, err := manager.FastForwardBranch(ctx, repo, cleanup.Merged.Base)
+		switch {
+		case err != nil:
+			output.PrintError("%s: %v", cleanup.Repository, err)
+		case updated:
+			output.PrintSuccess("%s: fast-forwarded %s", cleanup.Repository, cleanup.Merged.Base)
+		default:
+			output.PrintInfo("%s: %s is up to date", cleanup.Repository, cleanup.Merged.Base)
+		}
+	}
+
+	if !finished {
+		output.PrintInfo("The workspace still has unmerged work; keeping it.")
+		return nil
+	}
+	if keepWorkspace {
+		return nil
+	}
+	if !deleteWorkspace {
+		if !output.IsInteractive() {
+			output.PrintInfo("The workspace is finished; delete it with 'workspace-manager delete %s --remove-files'", workspace.Name)
+			return nil
+		}
+		confirmed, err := confirmPRCleanup(fmt.Sprintf("Delete the finished workspace '%s'?", workspace.Name),
+			"Its worktrees, files and local branches are removed.")
+		if err != nil || !confirmed {
+			return err
+		}
+	}
+
+	output.Printf("\n")
+	if err := manager.DeleteWorkspace(ctx, workspace.Name, true, false); err != nil {
+		return errors.Wrap(err, "failed to delete workspace")
+	}
+	// Every branch is merged or has no commits of its own, so none holds work anymore
+	for _, cleanup := range plan {
+		if repo, ok := wsm.FindRepository(workspace, cleanup.Repository); ok {
+			if err := manager.DeleteLocalBranch(ctx, repo, cleanup.Branch); err != nil {
+				output.PrintWarning("%s: failed to delete branch %s: %v", cleanup.Repository, cleanup.Branch, err)
+			}
+		}
+	}
+	output.PrintSuccess("Workspace '%s' deleted", workspace.Name)
+	return nil
+}
+
+func confirmPRCleanup(title, description string) (bool, error) {
+	var confirmed bool
+	err := huh.NewForm(huh.NewGroup(
+		huh.NewConfirm().Title(title).Description(description).Value(&confirmed),
+	)).Run()
+	if err != nil {
+		if isFormAborted(err) {
+			output.PrintInfo("Operation cancelled.")
+			return false, nil
+		}
+		return false, errors.Wrap(err, "confirmation failed")
+	}
+	if !confirmed {
+		output.PrintInfo("Operation cancelled.")
+	}
+	return confirmed, nil
+}
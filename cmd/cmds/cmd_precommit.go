@@ -0,0 +1,179 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewPrecommitCommand creates the precommit command
+func NewPrecommitCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "precommit",
+		Short: "Install and run the pre-commit hooks of the workspace repositories",
+		Long: `Manage the commit hooks of every repository of a workspace with the framework
+each repository configures: pre-commit (.pre-commit-config.yaml) or lefthook
+(lefthook.yml). Repositories without either are skipped.
+
+Examples:
+  # Install the hooks of every repository
+  workspace-manager precommit install
+
+  # Check all repositories concurrently before 'workspace-manager commit'
+  workspace-manager precommit run --all-repos`,
+	}
+
+	cmd.AddCommand(
+		NewPrecommitInstallCommand(),
+		NewPrecommitRunCommand(),
+	)
+
+	return cmd
+}
+
+// NewPrecommitInstallCommand creates the precommit install command
+func NewPrecommitInstallCommand() *cobra.Command {
+	var repos []string
+
+	cmd := &cobra.Command{
+		Use:   "install [workspace-name]",
+		Short: "Install the hooks of each repository (pre-commit install, lefthook install)",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspaceName := ""
+			if len(args) > 0 {
+				workspaceName = args[0]
+			}
+			workspace, err := resolveWorkspace(workspaceName)
+			if err != nil {
+				return err
+			}
+			return runPrecommit(cmd.Context(), workspace, repos, 4, "Installing", func(suite wsm.HookSuite) []string {
+				return suite.InstallCommand()
+			})
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&repos, "repos", nil, "Only install in these repositories (comma-separated)")
+	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion())
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"repos": WorkspaceRepositoryCompletion().UniqueList(","),
+	})
+
+	return cmd
+}
+
+// NewPrecommitRunCommand creates the precommit run command
+func NewPrecommitRunCommand() *cobra.Command {
+	var (
+		allRepos bool
+		repos    []string
+		allFiles bool
+		jobs     int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "run [workspace-name]",
+		Short: "Run the pre-commit hooks of the current or of all repositories",
+		Long: `Run the pre-commit hooks on the staged files (--all-files for all files) of
+the repository containing the current directory, of the repositories given
+with --repos, or of every repository with --all-repos. Suites of different
+repositories run concurrently; the output of failing suites is shown and the
+command fails if any suite fails.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspaceName := ""
+			if len(args) > 0 {
+				workspaceName = args[0]
+			}
+			workspace, err := resolveWorkspace(workspaceName)
+			if err != nil {
+				return err
+			}
+			if !allRepos && len(repos) == 0 {
+				repo, err := currentWorkspaceRepository(workspace)
+				if err != nil {
+					return err
+				}
+				repos = []string{repo}
+			}
+			return runPrecommit(cmd.Context(), workspace, repos, jobs, "Running", func(suite wsm.HookSuite) []string {
+				return suite.RunCommand(allFiles)
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&allRepos, "all-repos", false, "Run the hooks of every repository of the workspace")
+	cmd.Flags().StringSliceVar(&repos, "repos", nil, "Run the hooks of these repositories (comma-separated)")
+	cmd.Flags().BoolVar(&allFiles, "all-files", false, "Check all files instead of the staged files")
+	cmd.Flags().IntVarP(&jobs, "jobs", "j", 4, "Number of hook suites run at the same time")
+	cmd.MarkFlagsMutuallyExclusive("all-repos", "repos")
+	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion())
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"repos": WorkspaceRepositoryCompletion().UniqueList(","),
+	})
+
+	return cmd
+}
+
+// currentWorkspaceRepository returns the repository of the workspace containing the current directory
+func currentWorkspaceRepository(workspace *wsm.Workspace) (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get current directory")
+	}
+	if rel, err := filepath.Rel(workspace.Path, cwd); err == nil && rel != "." && filepath.IsLocal(rel) {
+		name := strings.Split(rel, string(filepath.Separator))[0]
+		for _, repo := range workspace.Repositories {
+			if repo.Name == name {
+				return name, nil
+			}
+		}
+	}
+	return "", errors.New("not inside a repository of the workspace; use --repos or --all-repos")
+}
+
+func runPrecommit(ctx context.Context, workspace *wsm.Workspace, repos []string, jobs int, verb string, command func(wsm.HookSuite) []string) error {
+	suites := wsm.DetectHookSuites(workspace, repos)
+	if len(suites) == 0 {
+		output.PrintInfo("No repository of workspace '%s' configures pre-commit or lefthook", workspace.Name)
+		return nil
+	}
+
+	output.PrintInfo("%s hooks of %d repositories...", verb, len(suites))
+	results := wsm.RunHookCommands(ctx, suites, command, jobs, func(completed int, result wsm.HookResult) {
+		commandLine := strings.Join(result.Command, " ")
+		if result.Error != "" {
+			output.PrintError("[%d/%d] %s: %s failed after %s: %s", completed, len(suites), result.Suite.Repository, commandLine, result.Duration.Round(time.Millisecond), result.Error)
+			if result.Output != "" {
+				fmt.Println(indentLines(result.Output, "    "))
+			}
+			return
+		}
+		output.PrintSuccess("[%d/%d] %s: %s (%s)", completed, len(suites), result.Suite.Repository, commandLine, result.Duration.Round(time.Millisecond))
+	})
+
+	failed := 0
+	for _, result := range results {
+		if result.Error != "" {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return errors.Errorf("%d of %d hook suites failed", failed, len(results))
+	}
+	return nil
+}
+
+func indentLines(text, prefix string) string {
+	return prefix + strings.ReplaceAll(text, "\n", "\n"+prefix)
+}
@@ -0,0 +1,342 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewPrefetchCommand creates the prefetch command
+func NewPrefetchCommand() *cobra.Command {
+	var (
+		jobs    int
+		every   time.Duration
+		timeout time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "prefetch",
+		Short: "Fetch all registered repositories so status shows current ahead/behind counts",
+		Long: `Run 'git fetch --all --prune' in every repository of the registry. Workspace
+worktrees share the remote-tracking refs of their repository, so afterwards
+'workspace-manager status' shows current ahead/behind counts without fetching
+itself, along with when the repositories were last fetched.
+
+Fetches never prompt for credentials: repositories that need them fail and are
+reported, as are repositories whose fetch takes longer than its timeout; the
+others are fetched regardless. With --every, prefetch keeps running and fetches again after each
+interval; 'prefetch timer install' schedules it with a systemd user timer
+instead.
+
+Configuration (config.yaml):
+
+  prefetch:
+    interval: 10m     # default interval of --every and the timer
+    concurrency: 4    # repositories fetched at the same time
+  timeouts:
+    fetch: 2m         # per repository (default), or off
+    repositories:
+      monorepo:
+        fetch: 10m    # known-slow repositories get more time
+
+Examples:
+  workspace-manager prefetch
+  workspace-manager prefetch --every 10m
+  workspace-manager prefetch timer install`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if cmd.Flags().Changed("every") && every <= 0 {
+				return errors.New("--every must be positive")
+			}
+			if cmd.Flags().Changed("timeout") && timeout <= 0 {
+				return errors.New("--timeout must be positive")
+			}
+			return runPrefetch(cmd.Context(), jobs, every, timeout)
+		},
+	}
+
+	cmd.Flags().IntVarP(&jobs, "jobs", "j", 0, "Repositories fetched at the same time (default: prefetch.concurrency, else 8)")
+	cmd.Flags().DurationVar(&every, "every", 0, "Keep running and fetch again after this interval")
+	cmd.Flags().DurationVar(&timeout, "timeout", 0, "Time each repository may take to fetch (default: timeouts.fetch, else none)")
+
+	cmd.AddCommand(NewPrefetchTimerCommand())
+
+	return cmd
+}
+
+func runPrefetch(ctx context.Context, jobs int, every, timeout time.Duration) error {
+	config, err := wsm.LoadConfig()
+	if err != nil {
+		return errors.Wrap(err, "failed to load configuration")
+	}
+	if jobs <= 0 {
+		jobs = config.Prefetch.Concurrency
+	}
+	timeouts := config.Timeouts.WithTimeout(wsm.OperationFetch, timeout)
+
+	if every == 0 {
+		_, err := prefetchOnce(ctx, jobs, timeouts)
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	output.PrintInfo("Fetching registered repositories every %s (Ctrl+C to stop)", every)
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		if _, err := prefetchOnce(ctx, jobs, timeouts); err != nil && ctx.Err() == nil {
+			output.PrintWarning("Prefetch failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// prefetchOnce fetches the repositories currently in the registry, so repositories discovered
+// while the scheduler runs are picked up, and returns the result of each
+func prefetchOnce(ctx context.Context, jobs int, timeouts wsm.TimeoutConfig) ([]wsm.PrefetchResult, error) {
+	discoverer, err := loadDiscoverer()
+	if err != nil {
+		return nil, err
+	}
+	return prefetchRepositories(ctx, discoverer.GetRepositories(), jobs, timeouts)
+}
+
+// prefetchRepositories fetches repos, jobs at a time, and returns the result of each
+func prefetchRepositories(ctx context.Context, repos []wsm.Repository, jobs int, timeouts wsm.TimeoutConfig) ([]wsm.PrefetchResult, error) {
+	if len(repos) == 0 {
+		output.PrintInfo("No repositories registered; run 'workspace-manager discover' first")
+		return nil, nil
+	}
+
+	start := time.Now()
+	failed := 0
+	var timedOut []string
+	results, err := wsm.Prefetch(ctx, repos, jobs, timeouts, func(result wsm.PrefetchResult) {
+		if result.TimedOut {
+			timedOut = append(timedOut, result.Repository)
+		}
+		if result.Error != "" {
+			failed++
+			output.LogWarn(
+				fmt.Sprintf("Failed to fetch %s: %s", result.Repository, result.Error),
+				"Failed to fetch repository",
+				"repository", result.Repository,
+				"path", result.Path,
+				"error", result.Error,
+			)
+		}
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to record prefetch results")
+	}
+
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if failed > 0 {
+		output.PrintWarning("Fetched %d of %d repositories in %s", len(results)-failed, len(results), elapsed)
+		printTimedOut(timedOut, wsm.OperationFetch)
+		return results, nil
+	}
+	output.PrintSuccess("Fetched %d repositories in %s", len(results), elapsed)
+	return results, nil
+}
+
+// NewPrefetchTimerCommand creates the prefetch timer command
+func NewPrefetchTimerCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "timer",
+		Short: "Schedule prefetch with a systemd user timer",
+		Long: `Install or remove a systemd user service and timer running
+'workspace-manager prefetch' periodically. Each profile gets its own units
+(wsm-prefetch.timer, wsm-prefetch-<profile>.timer).
+
+Inspect the timer with 'systemctl --user list-timers' and the fetches with
+'journalctl --user -u wsm-prefetch'.`,
+	}
+
+	cmd.AddCommand(
+		NewPrefetchTimerInstallCommand(),
+		NewPrefetchTimerUninstallCommand(),
+	)
+
+	return cmd
+}
+
+// NewPrefetchTimerInstallCommand creates the prefetch timer install command
+func NewPrefetchTimerInstallCommand() *cobra.Command {
+	var interval time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "install",
+		Short: "Install and start the prefetch timer",
+		Long: `Write the systemd user units running 'workspace-manager prefetch' every
+--interval (default: prefetch.interval, else 15m) and enable the timer. The
+units run the current executable; reinstall them after moving it.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPrefetchTimerInstall(cmd.Context(), interval)
+		},
+	}
+
+	cmd.Flags().DurationVar(&interval, "interval", 0, "Time between fetches")
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"interval": carapace.ActionValues("5m", "10m", "15m", "30m", "1h"),
+	})
+
+	return cmd
+}
+
+func runPrefetchTimerInstall(ctx context.Context, interval time.Duration) error {
+	if interval == 0 {
+		config, err := wsm.LoadConfig()
+		if err != nil {
+			return errors.Wrap(err, "failed to load configuration")
+		}
+		if interval, err = config.Prefetch.IntervalDuration(); err != nil {
+			return err
+		}
+	}
+	if interval < time.Minute {
+		return errors.New("--interval must be at least 1m")
+	}
+
+	executable, err := resolvedExecutable()
+	if err != nil {
+		return err
+	}
+
+	unitDir, err := wsm.SystemdUserUnitDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(unitDir, 0755); err != nil {
+		return errors.Wrapf(err, "failed to create %s", unitDir)
+	}
+	units := wsm.PrefetchTimerUnits(executable, wsm.CurrentProfile(), interval)
+	for _, name := range slices.Sorted(maps.Keys(units)) {
+		path := filepath.Join(unitDir, name)
+		if err := os.WriteFile(path, []byte(units[name]), 0644); err != nil {
+			return errors.Wrapf(err, "failed to write %s", path)
+		}
+		output.PrintInfo("Wrote %s", path)
+	}
+
+	timer := wsm.PrefetchTimerName()
+	if _, err := exec.LookPath("systemctl"); err != nil {
+		output.PrintWarning("systemctl not found; enable the timer with 'systemctl --user enable --now %s'", timer)
+		return nil
+	}
+	if err := systemctlUser(ctx, "daemon-reload"); err != nil {
+		return err
+	}
+	if err := systemctlUser(ctx, "enable", "--now", timer); err != nil {
+		return err
+	}
+	output.PrintSuccess("Enabled %s: repositories are fetched every %s", timer, interval)
+	return nil
+}
+
+// NewPrefetchTimerUninstallCommand creates the prefetch timer uninstall command
+func NewPrefetchTimerUninstallCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "uninstall",
+		Short: "Stop and remove the prefetch timer",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPrefetchTimerUninstall(cmd.Context())
+		},
+	}
+}
+
+func runPrefetchTimerUninstall(ctx context.Context) error {
+	unitDir, err := wsm.SystemdUserUnitDir()
+	if err != nil {
+		return err
+	}
+
+	timer := wsm.PrefetchTimerName()
+	if _, err := os.Stat(filepath.Join(unitDir, timer)); os.IsNotExist(err) {
+		output.PrintInfo("The prefetch timer is not installed")
+		return nil
+	}
+
+	_, lookErr := exec.LookPath("systemctl")
+	if lookErr == nil {
+		if err := systemctlUser(ctx, "disable", "--now", timer); err != nil {
+			output.PrintWarning("%v", err)
+		}
+	}
+
+	units := wsm.PrefetchTimerUnits("", wsm.CurrentProfile(), wsm.DefaultPrefetchInterval)
+	for _, name := range slices.Sorted(maps.Keys(units)) {
+		path := filepath.Join(unitDir, name)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, "failed to remove %s", path)
+		}
+		output.PrintInfo("Removed %s", path)
+	}
+
+	if lookErr == nil {
+		if err := systemctlUser(ctx, "daemon-reload"); err != nil {
+			output.PrintWarning("%v", err)
+		}
+	}
+	output.PrintSuccess("Removed %s", timer)
+	return nil
+}
+
+func systemctlUser(ctx context.Context, args ...string) error {
+	args = append([]string{"--user"}, args...)
+	out, err := exec.CommandContext(ctx, "systemctl", args...).CombinedOutput()
+	if err != nil {
+		return errors.Wrapf(err, "systemctl %s failed: %s", strings.Join(args, " "), strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// resolvedExecutable returns the path of the running executable for units started by the service
+// manager, with symlinks resolved
+func resolvedExecutable() (string, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to locate the workspace-manager executable")
+	}
+	if resolved, err := filepath.EvalSymlinks(executable); err == nil {
+		executable = resolved
+	}
+	return executable, nil
+}
+
+// printTimedOut lists the repositories whose operations exceeded their timeout and how to give
+// them more time
+func printTimedOut(repos []string, operations ...string) {
+	if len(repos) == 0 {
+		return
+	}
+	slices.Sort(repos)
+	var keys []string
+	for _, operation := range operations {
+		keys = append(keys, "timeouts."+operation)
+	}
+	output.PrintWarning("Timed out: %s", strings.Join(repos, ", "))
+	output.PrintInfo("Give them more time with --timeout, or with %s in config.yaml (timeouts.repositories.<name> for a single repository)", strings.Join(keys, " and "))
+}
@@ -0,0 +1,172 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewPreflightCommand creates the preflight command
+func NewPreflightCommand() *cobra.Command {
+	var (
+		remote string
+		noPush bool
+		format string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "preflight [workspace-name]",
+		Short: "Check remote reachability and credentials for every repository",
+		Long: `Verify that each repository's remote is reachable and that pushing will be
+authorized, without prompting for credentials. Also reports the ssh-agent keys,
+GitHub CLI authentication and git credential helper when relevant, and whether
+the signing key is available in repositories with a signing policy.
+
+Preflight runs automatically before 'sync', 'push' and 'pr' (use --skip-preflight
+on those commands to disable it).
+
+Examples:
+  # Check the current workspace against origin
+  workspace-manager preflight
+
+  # Only check read access to a fork remote
+  workspace-manager preflight my-feature --remote fork --no-push`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspaceName := ""
+			if len(args) > 0 {
+				workspaceName = args[0]
+			}
+			return runPreflight(cmd.Context(), workspaceName, remote, !noPush, format)
+		},
+	}
+
+	cmd.Flags().StringVar(&remote, "remote", "origin", "Remote to check")
+	cmd.Flags().BoolVar(&noPush, "no-push", false, "Only check read access")
+	cmd.Flags().StringVar(&format, "format", "table", "Output format: table, json")
+
+	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion())
+
+	return cmd
+}
+
+func runPreflight(ctx context.Context, workspaceName, remote string, checkPush bool, format string) error {
+	wm, workspace, err := resolveManagedWorkspace(workspaceName)
+	if err != nil {
+		return err
+	}
+
+	report := wsm.RunPreflight(ctx, workspace, remote, checkPush)
+	report.Signing = wsm.CheckSigning(ctx, workspace, wm.Config().Signing)
+
+	if format == "json" {
+		if err := wsm.PrintJSON(report); err != nil {
+			return err
+		}
+	} else {
+		printPreflightReport(report, checkPush)
+	}
+
+	if failed := report.Failed(checkPush); len(failed) > 0 {
+		return errors.Errorf("%d of %d repositories failed preflight", len(failed), len(report.Checks))
+	}
+	if missing := report.MissingSigningKeys(); len(missing) > 0 {
+		return errors.Errorf("%d repositories require signed commits but have no usable signing key", len(missing))
+	}
+
+	return nil
+}
+
+// preflightGate runs preflight before a batch remote operation and fails early with a report
+// of the repositories that would fail
+func preflightGate(ctx context.Context, workspace *wsm.Workspace, remote string, requirePush, skip bool) error {
+	if skip {
+		return nil
+	}
+
+	// Frozen repositories are neither pulled nor pushed, so their remotes do not matter
+	active := *workspace
+	active.Repositories = workspace.ActiveRepositories()
+
+	report := wsm.RunPreflight(ctx, &active, remote, requirePush)
+	failed := report.Failed(requirePush)
+	if len(failed) == 0 {
+		return nil
+	}
+
+	output.PrintError("Preflight failed for %d of %d repositories:", len(failed), len(report.Checks))
+	for _, check := range failed {
+		fmt.Printf("  %s (%s): %s\n", check.Repository, check.Remote, check.Error)
+	}
+	return errors.New("preflight check failed; fix access or rerun with --skip-preflight")
+}
+
+func printPreflightReport(report *wsm.PreflightReport, checkPush bool) {
+	output.PrintHeader("Preflight")
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "REPOSITORY\tREMOTE\tTRANSPORT\tREACHABLE\tPUSH\tERROR")
+	fmt.Fprintln(w, "----------\t------\t---------\t---------\t----\t-----")
+	for _, check := range report.Checks {
+		push := "-"
+		if checkPush && check.Reachable {
+			push = checkMark(check.CanPush)
+		}
+		errStr := check.Error
+		if errStr == "" {
+			errStr = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			check.Repository, check.Remote, check.Transport, checkMark(check.Reachable), push, errStr)
+	}
+	if err := w.Flush(); err != nil {
+		output.LogWarn(
+			fmt.Sprintf("Failed to flush table writer: %v", err),
+			"Failed to flush table writer",
+			"error", err,
+		)
+	}
+
+	fmt.Println()
+	if report.SSHAgentKeys > 0 {
+		output.PrintInfo("ssh-agent: %d keys loaded", report.SSHAgentKeys)
+	} else if report.SSHAgentError != "" {
+		output.PrintWarning("ssh-agent: %s", report.SSHAgentError)
+	}
+	if report.GHAuthenticated {
+		output.PrintInfo("gh: authenticated")
+	} else if report.GHError != "" {
+		output.PrintWarning("gh: %s", report.GHError)
+	}
+	if report.CredentialHelper != "" {
+		output.PrintInfo("git credential helper: %s", report.CredentialHelper)
+	}
+	for _, check := range report.Signing {
+		key := check.Key
+		if key == "" {
+			key = "default key"
+		}
+		switch {
+		case check.Available:
+			output.PrintInfo("signing (%s): %s %s", check.Repository, check.Format, key)
+		case check.Mode == wsm.SigningRequire:
+			output.PrintError("signing (%s): %s", check.Repository, check.Error)
+		default:
+			output.PrintWarning("signing (%s): %s, commits will be unsigned", check.Repository, check.Error)
+		}
+	}
+}
+
+func checkMark(ok bool) string {
+	if ok {
+		return "✓"
+	}
+	return "✗"
+}
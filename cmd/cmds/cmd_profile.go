@@ -0,0 +1,168 @@
+package cmds
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// NewProfileCommand creates the profile command
+func NewProfileCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profile",
+		Short: "Manage profiles with separate config, registry and workspaces",
+		Long: `A profile is an isolated environment with its own config.yaml, repository
+registry, workspace configurations and history, e.g. "work" and "personal",
+or one profile per client. Select it with --profile or WSM_PROFILE; without
+either, the "default" profile in the configuration directory is used.
+
+Named profiles live in <config dir>/workspace-manager/profiles/<name> and
+create workspaces in ~/workspaces/<name>/<date> unless their config.yaml sets
+workspace_dir. Their config.yaml also holds their discovery_paths and
+agent_assets.
+
+Examples:
+  workspace-manager profile create acme --discovery-path ~/clients/acme
+  WSM_PROFILE=acme workspace-manager discover
+  workspace-manager --profile acme create feature-x --repos api
+  workspace-manager profile list`,
+	}
+
+	cmd.AddCommand(
+		NewProfileListCommand(),
+		NewProfileShowCommand(),
+		NewProfileCreateCommand(),
+	)
+
+	return cmd
+}
+
+// NewProfileListCommand creates the profile list command
+func NewProfileListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List profiles, marking the current one",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			profiles, err := wsm.ListProfiles()
+			if err != nil {
+				return err
+			}
+			current := wsm.CurrentProfile()
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "\tPROFILE\tCONFIG DIR")
+			for _, profile := range profiles {
+				marker := ""
+				if profile == current {
+					marker = "*"
+				}
+				dir, err := wsm.ProfileConfigDir(profile)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\n", marker, profile, dir)
+			}
+			return w.Flush()
+		},
+	}
+}
+
+// NewProfileShowCommand creates the profile show command
+func NewProfileShowCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show",
+		Short: "Show the paths used by the current profile",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configDir, err := wsm.ConfigDir()
+			if err != nil {
+				return err
+			}
+			config, err := wsm.LoadConfig()
+			if err != nil {
+				return errors.Wrap(err, "failed to load configuration")
+			}
+			registryPath, err := wsm.RegistryPath()
+			if err != nil {
+				return err
+			}
+
+			output.PrintHeader("Profile %s", wsm.CurrentProfile())
+			fmt.Printf("  Config:          %s\n", filepath.Join(configDir, "config.yaml"))
+			fmt.Printf("  Registry:        %s\n", registryPath)
+			fmt.Printf("  Workspaces:      %s\n", filepath.Join(configDir, "workspaces"))
+			fmt.Printf("  Workspace dir:   %s\n", config.WorkspaceDir)
+			fmt.Printf("  Discovery paths: %s\n", orDash(strings.Join(config.DiscoveryPaths, ", ")))
+			fmt.Printf("  Agent assets:    %d\n", len(config.AgentAssets))
+			return nil
+		},
+	}
+}
+
+// NewProfileCreateCommand creates the profile create command
+func NewProfileCreateCommand() *cobra.Command {
+	var (
+		workspaceDir   string
+		discoveryPaths []string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "create <name>",
+		Short: "Create a profile with its own config.yaml",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runProfileCreate(args[0], workspaceDir, discoveryPaths)
+		},
+	}
+
+	cmd.Flags().StringVar(&workspaceDir, "workspace-dir", "", "Directory for new workspaces (default ~/workspaces/<name>/<date>)")
+	cmd.Flags().StringSliceVar(&discoveryPaths, "discovery-path", nil, "Directory scanned by 'discover' without arguments (repeatable)")
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"workspace-dir":  carapace.ActionDirectories(),
+		"discovery-path": carapace.ActionDirectories(),
+	})
+
+	return cmd
+}
+
+func runProfileCreate(name, workspaceDir string, discoveryPaths []string) error {
+	if name == wsm.DefaultProfile {
+		return errors.Errorf("the '%s' profile always exists", name)
+	}
+	configDir, err := wsm.ProfileConfigDir(name)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(configDir); err == nil {
+		return errors.Errorf("profile '%s' already exists: %s", name, configDir)
+	}
+
+	config := struct {
+		WorkspaceDir   string   `yaml:"workspace_dir,omitempty"`
+		DiscoveryPaths []string `yaml:"discovery_paths,omitempty"`
+	}{workspaceDir, discoveryPaths}
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode profile configuration")
+	}
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		return errors.Wrapf(err, "failed to create profile directory: %s", configDir)
+	}
+	configPath := filepath.Join(configDir, "config.yaml")
+	if err := os.WriteFile(configPath, data, 0644); err != nil {
+		return errors.Wrapf(err, "failed to write %s", configPath)
+	}
+
+	output.PrintSuccess("Created profile '%s' (%s)", name, configPath)
+	output.PrintInfo("Use it with --profile %s or %s=%s", name, wsm.ProfileEnvVar, name)
+	return nil
+}
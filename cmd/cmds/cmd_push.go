@@ -11,6 +11,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
@@ -23,6 +24,8 @@ func NewPushCommand() *cobra.Command {
 		dryRun      bool
 		force       bool
 		setUpstream bool
+		skipCheck   bool
+		timeout     time.Duration
 	)
 
 	cmd := &cobra.Command{
@@ -36,6 +39,11 @@ This command will:
 3. Ask for confirmation before pushing each branch (unless --force is used)
 4. Push branches to the specified remote
 
+Each push can be bounded by timeouts.push in config.yaml (with per-repository
+overrides under timeouts.repositories), or by --timeout; there is none by
+default, so long pre-push hooks are not interrupted. A push that times out is
+reported and the remaining branches are pushed regardless.
+
 A branch is considered to need pushing if:
 - It has local commits that aren't on the remote yet
 - It's not the main/master branch (unless it has unpushed commits)
@@ -65,7 +73,7 @@ Examples:
 			if len(args) > 1 {
 				workspaceName = args[1]
 			}
-			return runPush(cmd.Context(), remoteName, workspaceName, dryRun, force, setUpstream)
+			return runPush(cmd.Context(), remoteName, workspaceName, dryRun, force, setUpstream, skipCheck, timeout)
 		},
 	}
 
@@ -73,11 +81,13 @@ Examples:
 	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be pushed without actually pushing")
 	cmd.Flags().BoolVarP(&force, "force", "f", false, "Push without asking for confirmation")
 	cmd.Flags().BoolVarP(&setUpstream, "set-upstream", "u", false, "Set upstream tracking for pushed branches")
+	cmd.Flags().BoolVar(&skipCheck, "skip-preflight", false, "Skip the remote access preflight check")
+	cmd.Flags().DurationVar(&timeout, "timeout", 0, "Time each push may take (default: timeouts.push, else none)")
 
 	return cmd
 }
 
-func runPush(ctx context.Context, remoteName, workspaceName string, dryRun, force, setUpstream bool) error {
+func runPush(ctx context.Context, remoteName, workspaceName string, dryRun, force, setUpstream, skipPreflight bool, timeout time.Duration) error {
 	// Check if gh CLI is available
 	if err := checkGHCLI(ctx); err != nil {
 		return err
@@ -102,6 +112,13 @@ func runPush(ctx context.Context, remoteName, workspaceName string, dryRun, forc
 	if err != nil {
 		return errors.Wrapf(err, "failed to load workspace '%s'", workspaceName)
 	}
+	if err := workspace.RequireWorktrees("pushing"); err != nil {
+		return err
+	}
+
+	if err := preflightGate(ctx, workspace, remoteName, true, skipPreflight || dryRun); err != nil {
+		return err
+	}
 
 	// Get workspace status
 	checker := wsm.NewStatusChecker()
@@ -113,6 +130,9 @@ func runPush(ctx context.Context, remoteName, workspaceName string, dryRun, forc
 	// Find branches that need pushing
 	var candidateBranches []PushCandidate
 	for _, repoStatus := range status.Repositories {
+		if repoStatus.Frozen {
+			continue
+		}
 		if candidate, needsPush := checkIfNeedsPush(ctx, repoStatus, workspace.Path, remoteName); needsPush {
 			candidateBranches = append(candidateBranches, candidate)
 		}
@@ -125,18 +145,18 @@ func runPush(ctx context.Context, remoteName, workspaceName string, dryRun, forc
 
 	// Show what we found
 	output.PrintHeader("Found %d branch(es) that could be pushed to remote '%s':", len(candidateBranches), remoteName)
-	fmt.Println()
+	output.Printf("\n")
 
 	for i, candidate := range candidateBranches {
-		fmt.Printf("%d. %s/%s\n", i+1, candidate.Repository, candidate.Branch)
-		fmt.Printf("   Local commits: %d\n", candidate.LocalCommits)
-		fmt.Printf("   Target remote: %s/%s\n", remoteName, candidate.RemoteRepo)
+		output.Printf("%d. %s/%s\n", i+1, candidate.Repository, candidate.Branch)
+		output.Printf("   Local commits: %d\n", candidate.LocalCommits)
+		output.Printf("   Target remote: %s/%s\n", remoteName, candidate.RemoteRepo)
 		if candidate.RemoteExists {
-			fmt.Printf("   Remote branch exists: %t\n", candidate.RemoteBranchExists)
+			output.Printf("   Remote branch exists: %t\n", candidate.RemoteBranchExists)
 		} else {
 			output.PrintWarning("   Remote repository not found or not accessible\n")
 		}
-		fmt.Println()
+		output.Printf("\n")
 	}
 
 	if dryRun {
@@ -144,8 +164,15 @@ func runPush(ctx context.Context, remoteName, workspaceName string, dryRun, forc
 		return nil
 	}
 
+	config, err := wsm.LoadConfig()
+	if err != nil {
+		return errors.Wrap(err, "failed to load configuration")
+	}
+	timeouts := config.Timeouts.WithTimeout(wsm.OperationPush, timeout)
+
 	// Push branches
 	reader := bufio.NewReader(os.Stdin)
+	var timedOut []string
 	for _, candidate := range candidateBranches {
 		if !candidate.RemoteExists {
 			output.PrintWarning("Skipping %s/%s - remote repository '%s' not found or not accessible",
@@ -162,8 +189,11 @@ func runPush(ctx context.Context, remoteName, workspaceName string, dryRun, forc
 		}
 
 		if shouldPush {
-			if err := pushBranch(ctx, candidate, remoteName, setUpstream); err != nil {
+			if err := pushBranch(ctx, candidate, remoteName, setUpstream, timeouts); err != nil {
 				output.PrintError("Failed to push %s/%s: %v", candidate.Repository, candidate.Branch, err)
+				if wsm.IsTimeout(err) {
+					timedOut = append(timedOut, candidate.Repository)
+				}
 			} else {
 				output.PrintSuccess("Pushed %s/%s to %s", candidate.Repository, candidate.Branch, remoteName)
 			}
@@ -171,6 +201,7 @@ func runPush(ctx context.Context, remoteName, workspaceName string, dryRun, forc
 			output.PrintInfo("Skipped %s/%s", candidate.Repository, candidate.Branch)
 		}
 	}
+	printTimedOut(timedOut, wsm.OperationPush)
 
 	return nil
 }
@@ -340,7 +371,7 @@ func checkRemoteBranchExists(ctx context.Context, repoPath, remoteName, branch s
 	return err == nil && len(strings.TrimSpace(string(output))) > 0
 }
 
-func pushBranch(ctx context.Context, candidate PushCandidate, remoteName string, setUpstream bool) error {
+func pushBranch(ctx context.Context, candidate PushCandidate, remoteName string, setUpstream bool, timeouts wsm.TimeoutConfig) error {
 	args := []string{"push"}
 
 	if setUpstream {
@@ -349,11 +380,12 @@ func pushBranch(ctx context.Context, candidate PushCandidate, remoteName string,
 
 	args = append(args, remoteName, candidate.Branch)
 
-	cmd := exec.CommandContext(ctx, "git", args...)
-	cmd.Dir = candidate.RepoPath
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
+	ctx, done := wsm.WithNetworkTimeout(ctx, timeouts, candidate.Repository, wsm.OperationPush)
+	output, err := wsm.ExecRunner{}.CombinedOutput(ctx, candidate.RepoPath, "git", args...)
+	if err = done(err); err != nil {
+		if wsm.IsTimeout(err) {
+			return err
+		}
 		return errors.Wrapf(err, "git push failed: %s", string(output))
 	}
 
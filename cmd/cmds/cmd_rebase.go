@@ -22,6 +22,7 @@ func NewRebaseCommand() *cobra.Command {
 		repository   string
 		dryRun       bool
 		interactive  bool
+		autoStash    bool
 	)
 
 	cmd := &cobra.Command{
@@ -48,20 +49,24 @@ Examples:
   # Interactive rebase
   workspace-manager rebase my-repo --interactive
 
+  # Stash uncommitted changes before rebasing and restore them afterwards
+  workspace-manager rebase --autostash
+
   # Dry run to see what would be done
   workspace-manager rebase --dry-run`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) > 0 {
-				repository = args[0]
+				repository = resolveRepositoryAlias(args[0])
 			}
-			return runRebase(cmd.Context(), repository, targetBranch, interactive, dryRun)
+			return runRebase(cmd.Context(), repository, targetBranch, interactive, dryRun, autoStash)
 		},
 	}
 
 	cmd.Flags().StringVar(&targetBranch, "target", "main", "Target branch to rebase onto")
 	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be done without actually rebasing")
 	cmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Interactive rebase")
+	cmd.Flags().BoolVar(&autoStash, "autostash", false, "Stash uncommitted changes before rebasing and restore them afterwards")
 
 	return cmd
 }
@@ -76,13 +81,22 @@ type RebaseResult struct {
 	CommitsBefore int    `json:"commits_before"`
 	CommitsAfter  int    `json:"commits_after"`
 	TargetBranch  string `json:"target_branch"`
+	// Stash is the stash commit holding changes that could not be restored
+	Stash string `json:"stash,omitempty"`
 }
 
-func runRebase(ctx context.Context, repository, targetBranch string, interactive, dryRun bool) error {
+func runRebase(ctx context.Context, repository, targetBranch string, interactive, dryRun, autoStash bool) error {
 	workspace, err := detectCurrentWorkspace()
 	if err != nil {
 		return errors.Wrap(err, "failed to detect current workspace")
 	}
+	if err := workspace.RequireWorktrees("rebasing"); err != nil {
+		return err
+	}
+	config, err := wsm.LoadConfig()
+	if err != nil {
+		return errors.Wrap(err, "failed to load configuration")
+	}
 
 	if repository != "" {
 		output.PrintHeader("🔄 Rebasing repository '%s' onto '%s'", repository, targetBranch)
@@ -98,12 +112,12 @@ func runRebase(ctx context.Context, repository, targetBranch string, interactive
 
 	if repository != "" {
 		// Rebase specific repository
-		result := rebaseRepository(ctx, workspace, repository, targetBranch, interactive, dryRun)
+		result := rebaseRepository(ctx, workspace, repository, targetBranch, interactive, dryRun, autoStash, config.Timeouts)
 		results = append(results, result)
 	} else {
 		// Rebase all repositories
 		for _, repo := range workspace.Repositories {
-			result := rebaseRepository(ctx, workspace, repo.Name, targetBranch, interactive, dryRun)
+			result := rebaseRepository(ctx, workspace, repo.Name, targetBranch, interactive, dryRun, autoStash, config.Timeouts)
 			results = append(results, result)
 		}
 	}
@@ -111,7 +125,7 @@ func runRebase(ctx context.Context, repository, targetBranch string, interactive
 	return printRebaseResults(results, dryRun)
 }
 
-func rebaseRepository(ctx context.Context, workspace *wsm.Workspace, repoName, targetBranch string, interactive, dryRun bool) RebaseResult {
+func rebaseRepository(ctx context.Context, workspace *wsm.Workspace, repoName, targetBranch string, interactive, dryRun, autoStash bool, timeouts wsm.TimeoutConfig) RebaseResult {
 	result := RebaseResult{
 		Repository:   repoName,
 		Success:      true,
@@ -162,9 +176,22 @@ func rebaseRepository(ctx context.Context, workspace *wsm.Workspace, repoName, t
 	// Check if target branch exists
 	if !branchExists(ctx, repoPath, targetBranch) {
 		// Try to fetch it from remote
-		if err := fetchBranch(ctx, repoPath, targetBranch); err != nil {
+		if err := fetchBranch(ctx, repoName, repoPath, targetBranch, timeouts); err != nil {
 			result.Success = false
 			result.Error = fmt.Sprintf("target branch '%s' not found locally or on remote", targetBranch)
+			if wsm.IsTimeout(err) {
+				result.Error = err.Error()
+			}
+			return result
+		}
+	}
+
+	var stash *wsm.Stash
+	if autoStash {
+		stash, err = wsm.AutoStash(ctx, repoName, repoPath, "rebase")
+		if err != nil {
+			result.Success = false
+			result.Error = err.Error()
 			return result
 		}
 	}
@@ -174,11 +201,31 @@ func rebaseRepository(ctx context.Context, workspace *wsm.Workspace, repoName, t
 		result.Success = false
 		result.Error = fmt.Sprintf("rebase failed: %v", err)
 		result.Conflicts = hasRebaseConflicts(ctx, repoPath)
+		if stash != nil {
+			// The stash can only be restored once the rebase is continued or aborted
+			result.Stash = stash.Commit
+		} else if !autoStash && wsm.HasUncommittedChanges(ctx, repoPath) {
+			result.Error += " (uncommitted changes, retry with --autostash)"
+		}
 		return result
 	}
 
 	result.Rebased = true
 
+	if stash != nil {
+		conflicts, err := stash.Restore(ctx)
+		if err != nil || conflicts {
+			result.Success = false
+			result.Stash = stash.Commit
+			result.Conflicts = conflicts
+			if conflicts {
+				result.Error = "restoring stashed changes conflicted"
+			} else {
+				result.Error = err.Error()
+			}
+		}
+	}
+
 	// Get commits count after rebase
 	commitsAfter, err := getCommitsAhead(ctx, repoPath, targetBranch)
 	if err != nil {
@@ -219,11 +266,12 @@ func branchExists(ctx context.Context, repoPath, branch string) bool {
 	return cmd.Run() == nil
 }
 
-func fetchBranch(ctx context.Context, repoPath, branch string) error {
+func fetchBranch(ctx context.Context, repoName, repoPath, branch string, timeouts wsm.TimeoutConfig) error {
 	// Try to fetch the branch from origin
+	ctx, done := wsm.WithNetworkTimeout(ctx, timeouts, repoName, wsm.OperationFetch)
 	cmd := exec.CommandContext(ctx, "git", "fetch", "origin", branch+":"+branch)
 	cmd.Dir = repoPath
-	return cmd.Run()
+	return done(cmd.Run())
 }
 
 func performRebase(ctx context.Context, repoPath, targetBranch string, interactive bool) error {
@@ -298,15 +346,6 @@ func printRebaseResults(results []RebaseResult, dryRun bool) error {
 	}
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	defer func() {
-		if err := w.Flush(); err != nil {
-			output.LogWarn(
-				fmt.Sprintf("Failed to flush table writer: %v", err),
-				"Failed to flush table writer",
-				"error", err,
-			)
-		}
-	}()
 
 	fmt.Fprintln(w, "\nREPOSITORY\tSTATUS\tTARGET\tCOMMITS BEFORE\tCOMMITS AFTER\tERROR")
 	fmt.Fprintln(w, "----------\t------\t------\t--------------\t-------------\t-----")
@@ -324,7 +363,9 @@ func printRebaseResults(results []RebaseResult, dryRun bool) error {
 
 		if result.Conflicts {
 			status = "⚠️"
-			conflictCount++
+			if !result.Rebased {
+				conflictCount++
+			}
 		}
 
 		commitsBefore := "-"
@@ -353,16 +394,24 @@ func printRebaseResults(results []RebaseResult, dryRun bool) error {
 	}
 
 	fmt.Fprintln(w)
+	if err := w.Flush(); err != nil {
+		return errors.Wrap(err, "failed to flush table writer")
+	}
 
 	// Summary
 	output.PrintSuccess("Summary: %d/%d repositories rebased successfully", successCount, len(results))
 	if conflictCount > 0 {
 		output.PrintWarning("%d repositories have conflicts", conflictCount)
-		output.PrintInfo("Resolve conflicts manually with:")
-		fmt.Println("  - Fix conflicts in the affected files")
-		fmt.Println("  - git add <resolved-files>")
-		fmt.Println("  - git rebase --continue")
-		fmt.Println("  Or abort the rebase with: git rebase --abort")
+		output.PrintInfo("Resolve them with 'workspace-manager resolve', or manually with:")
+		output.Printf("  - Fix conflicts in the affected files\n")
+		output.Printf("  - git add <resolved-files>\n")
+		output.Printf("  - git rebase --continue\n")
+		output.Printf("  Or abort the rebase with: git rebase --abort\n")
+	}
+	for _, result := range results {
+		if result.Stash != "" {
+			printKeptStash(result.Repository, result.Stash, result.Rebased && result.Conflicts)
+		}
 	}
 
 	return nil
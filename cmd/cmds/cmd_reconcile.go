@@ -0,0 +1,116 @@
+package cmds
+
+import (
+	"context"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewReconcileCommand creates the reconcile command
+func NewReconcileCommand() *cobra.Command {
+	var (
+		dryRun bool
+		format string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "reconcile [workspace-name]",
+		Short: "Repair a workspace whose directory no longer matches its configuration",
+		Long: `Compare the workspace configuration with the workspace directory and repair
+the differences left by manual changes:
+
+- worktrees of configured repositories that were deleted are recreated on the
+  workspace branch
+- worktrees found in the workspace directory that are not configured (e.g.
+  created with 'git worktree add') are added to the configuration
+- go.work is rewritten when it does not list the Go modules of the workspace
+
+Directories that cannot be repaired automatically, such as full clones inside
+the workspace, are reported and left alone.
+
+Examples:
+  # Show what would be repaired
+  workspace-manager reconcile my-feature --dry-run
+
+  # Repair the current workspace
+  workspace-manager reconcile`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspaceName := ""
+			if len(args) > 0 {
+				workspaceName = args[0]
+			}
+			return runReconcile(cmd.Context(), workspaceName, dryRun, format)
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show the repairs without applying them")
+	cmd.Flags().StringVar(&format, "format", "text", "Output format of the plan: text, json")
+
+	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion())
+
+	return cmd
+}
+
+func runReconcile(ctx context.Context, workspaceName string, dryRun bool, format string) error {
+	workspace, err := resolveWorkspace(workspaceName)
+	if err != nil {
+		return err
+	}
+
+	wm, err := wsm.NewWorkspaceManager()
+	if err != nil {
+		return errors.Wrap(err, "failed to create workspace manager")
+	}
+
+	actions, err := wm.PlanReconcile(ctx, workspace)
+	if err != nil {
+		return errors.Wrap(err, "failed to compare workspace with its directory")
+	}
+
+	if format == "json" && dryRun {
+		if actions == nil {
+			actions = []wsm.ReconcileAction{}
+		}
+		return wsm.PrintJSON(actions)
+	}
+
+	if len(actions) == 0 {
+		output.PrintSuccess("Workspace '%s' matches its configuration", workspace.Name)
+		return nil
+	}
+
+	output.PrintHeader("Reconciling workspace '%s'", workspace.Name)
+	repairs := 0
+	for _, action := range actions {
+		label := action.Kind
+		if action.Repository != "" {
+			label += " " + action.Repository
+		}
+		if action.Kind == wsm.ReconcileSkip {
+			output.PrintWarning("%s: %s", label, action.Detail)
+			continue
+		}
+		repairs++
+		output.Printf("  %s: %s\n", output.InfoStyle.Render(label), action.Detail)
+	}
+	output.Printf("\n")
+
+	if dryRun {
+		output.PrintInfo("Dry run mode - %d repairs would be applied", repairs)
+		return nil
+	}
+	if repairs == 0 {
+		return nil
+	}
+
+	if err := wm.ApplyReconcile(ctx, workspace, actions); err != nil {
+		return err
+	}
+	output.PrintSuccess("Applied %d repairs to workspace '%s'", repairs, workspace.Name)
+	return nil
+}
@@ -0,0 +1,233 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// Choices for a stale registry entry in 'registry gc'
+const (
+	gcActionRelocate   = "relocate"
+	gcActionRediscover = "rediscover"
+	gcActionRemove     = "remove"
+	gcActionKeep       = "keep"
+)
+
+// NewRegistryCommand creates the registry command
+func NewRegistryCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "registry",
+		Short: "Maintain the repository registry",
+	}
+
+	cmd.AddCommand(
+		NewRegistryGCCommand(),
+	)
+
+	return cmd
+}
+
+// NewRegistryGCCommand creates the registry gc command
+func NewRegistryGCCommand() *cobra.Command {
+	var (
+		searchPaths []string
+		maxDepth    int
+		yes         bool
+		dryRun      bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Remove or update registry entries of deleted and moved repositories",
+		Long: `Check that every repository of the registry still exists on disk and is a
+git repository. Stale entries whose remote is found at another path (already in
+the registry, below the discovery paths, --search or the old parent directory)
+are moved repositories: their entry is updated to the new path and keeps its
+name, so aliases and workspace manifests keep working.
+
+For each stale entry you choose to update it, re-discover it at a path you
+enter, remove it or keep it. With --yes, moved entries are updated and the
+others removed without asking.
+
+Examples:
+  # Review stale entries interactively
+  workspace-manager registry gc
+
+  # Look for moved repositories below ~/src as well, and apply without prompts
+  workspace-manager registry gc --search ~/src --yes
+
+  # Only list stale entries
+  workspace-manager registry gc --dry-run`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRegistryGC(cmd.Context(), searchPaths, maxDepth, yes, dryRun)
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&searchPaths, "search", nil, "Directories to search for moved repositories (default: discovery_paths from config)")
+	cmd.Flags().IntVar(&maxDepth, "max-depth", 3, "Maximum depth searched below each directory")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Update moved entries and remove the other stale entries without prompting")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "List stale entries without changing the registry")
+
+	return cmd
+}
+
+func runRegistryGC(ctx context.Context, searchPaths []string, maxDepth int, yes, dryRun bool) error {
+	discoverer, err := loadDiscoverer()
+	if err != nil {
+		return err
+	}
+
+	if len(searchPaths) == 0 {
+		config, err := wsm.LoadConfig()
+		if err != nil {
+			return errors.Wrap(err, "failed to load configuration")
+		}
+		searchPaths = config.DiscoveryPaths
+	}
+	var roots []string
+	for _, path := range searchPaths {
+		expanded, err := wsm.ExpandPath(path)
+		if err != nil {
+			return err
+		}
+		if info, err := os.Stat(expanded); err == nil && info.IsDir() {
+			roots = append(roots, expanded)
+		}
+	}
+
+	stale, err := discoverer.FindStaleRepositories(ctx, roots, maxDepth)
+	if err != nil {
+		return errors.Wrap(err, "failed to check the registry")
+	}
+	if len(stale) == 0 {
+		output.PrintSuccess("All %d registry entries exist", len(discoverer.GetRepositories()))
+		return nil
+	}
+
+	output.PrintHeader("Stale registry entries")
+	for _, entry := range stale {
+		output.Printf("  %s  %s (%s)\n", entry.Repository.Name, entry.Repository.Path, entry.Reason)
+		if entry.MovedTo != nil {
+			output.Printf("    moved to %s\n", entry.MovedTo.Path)
+		}
+	}
+	output.Printf("\n")
+
+	if dryRun {
+		return nil
+	}
+	if !yes {
+		if err := output.RequireInteractive("choose what to do with stale entries", "pass --yes to update moved entries and remove the others, or --dry-run"); err != nil {
+			return err
+		}
+	}
+
+	updated, removed := 0, 0
+	for _, entry := range stale {
+		action, newPath := gcActionRemove, ""
+		if entry.MovedTo != nil {
+			action, newPath = gcActionRelocate, entry.MovedTo.Path
+		}
+		if !yes {
+			action, newPath, err = chooseStaleEntryAction(entry)
+			if err != nil {
+				if strings.Contains(strings.ToLower(err.Error()), "user aborted") {
+					output.PrintInfo("Operation cancelled.")
+					break
+				}
+				return err
+			}
+		}
+
+		switch action {
+		case gcActionRelocate, gcActionRediscover:
+			repo, err := discoverer.RelocateRepository(ctx, entry.Repository.Path, newPath)
+			if err != nil {
+				output.PrintError("%s: %v", entry.Repository.Name, err)
+				continue
+			}
+			output.PrintSuccess("Updated %s: %s → %s", repo.Name, entry.Repository.Path, repo.Path)
+			updated++
+		case gcActionRemove:
+			if err := discoverer.RemoveRepository(entry.Repository.Path); err != nil {
+				output.PrintError("%s: %v", entry.Repository.Name, err)
+				continue
+			}
+			output.PrintSuccess("Removed %s (%s)", entry.Repository.Name, entry.Repository.Path)
+			removed++
+		}
+	}
+
+	if updated+removed == 0 {
+		return nil
+	}
+	if err := discoverer.SaveRegistry(); err != nil {
+		return errors.Wrap(err, "failed to save registry")
+	}
+	output.PrintInfo("Registry: %d updated, %d removed", updated, removed)
+	return nil
+}
+
+// chooseStaleEntryAction asks what to do with a stale entry and, for re-discovery, where the
+// repository is now
+func chooseStaleEntryAction(entry wsm.StaleRepository) (string, string, error) {
+	var options []huh.Option[string]
+	if entry.MovedTo != nil {
+		options = append(options, huh.NewOption(fmt.Sprintf("Update path to %s", entry.MovedTo.Path), gcActionRelocate))
+	}
+	options = append(options,
+		huh.NewOption("Re-discover at another path...", gcActionRediscover),
+		huh.NewOption("Remove from the registry", gcActionRemove),
+		huh.NewOption("Keep", gcActionKeep),
+	)
+
+	var action, newPath string
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[string]().
+				Title(fmt.Sprintf("%s (%s): %s", entry.Repository.Name, entry.Repository.Path, entry.Reason)).
+				Options(options...).
+				Value(&action),
+		),
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Path of the repository").
+				Value(&newPath).
+				Validate(func(path string) error {
+					expanded, err := wsm.ExpandPath(path)
+					if err != nil {
+						return err
+					}
+					if _, err := os.Stat(filepath.Join(expanded, ".git")); err != nil {
+						return errors.Errorf("%s is not a git repository", expanded)
+					}
+					return nil
+				}),
+		).WithHideFunc(func() bool { return action != gcActionRediscover }),
+	)
+	if err := form.Run(); err != nil {
+		return "", "", err
+	}
+
+	switch action {
+	case gcActionRelocate:
+		newPath = entry.MovedTo.Path
+	case gcActionRediscover:
+		expanded, err := wsm.ExpandPath(newPath)
+		if err != nil {
+			return "", "", err
+		}
+		newPath = expanded
+	}
+	return action, newPath, nil
+}
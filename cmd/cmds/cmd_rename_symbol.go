@@ -0,0 +1,315 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+	"go/token"
+	"path/filepath"
+	"sort"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type renameSymbolOptions struct {
+	from     string
+	to       string
+	repos    []string
+	gopls    bool
+	dryRun   bool
+	noVerify bool
+	noStage  bool
+}
+
+// NewRenameSymbolCommand creates the rename-symbol command
+func NewRenameSymbolCommand() *cobra.Command {
+	var opts renameSymbolOptions
+
+	cmd := &cobra.Command{
+		Use:   "rename-symbol --from <pkg>.<Name> --to <Name> [workspace-name]",
+		Short: "Rename a Go symbol across every repository of a workspace",
+		Long: `Rename an exported function, type, variable or constant of a Go package in all
+the Go modules of the workspace: its declaration and the references in its own
+package, and the qualified references (pkg.Name) in every package importing it.
+
+--from is the import path of the package followed by the name of the symbol.
+--to is the new name, or a symbol of another package; the references then point
+to that package and the imports of the files are updated. The declaration is
+not moved: it must already exist in the new package.
+
+The references are resolved with the type checker, one package at a time:
+methods, struct fields, struct literal keys and local variables of the same
+name are left alone. With --gopls the rename of a symbol
+within its package is done by 'gopls rename' instead, which follows the type
+information of the workspace's go.work.
+
+Once written, 'go vet ./...' runs in every changed module, which also compiles
+the tests, and the changed files are staged in each repository. When vet fails
+the files are restored as they were, and files that had uncommitted changes
+before the rename are never staged.
+
+Examples:
+  # Rename a function used across repositories
+  workspace-manager rename-symbol --from github.com/acme/lib/auth.NewClient --to NewAuthClient
+
+  # Point the callers to the symbol in its new package
+  workspace-manager rename-symbol --from github.com/acme/lib/auth.Token \
+      --to github.com/acme/lib/token.Token
+
+  # Show what would change
+  workspace-manager rename-symbol --from github.com/acme/lib/auth.Client --to HTTPClient --dry-run`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspaceName := ""
+			if len(args) > 0 {
+				workspaceName = args[0]
+			}
+			return silenceReported(cmd, runRenameSymbol(cmd.Context(), workspaceName, opts))
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.from, "from", "", "Symbol to rename, as <import path>.<Name>")
+	cmd.Flags().StringVar(&opts.to, "to", "", "New name, or <import path>.<Name> of the symbol in another package")
+	cmd.Flags().StringSliceVar(&opts.repos, "repos", nil, "Only rename in these repositories (comma-separated)")
+	cmd.Flags().BoolVar(&opts.gopls, "gopls", false, "Rename with 'gopls rename' (same package only)")
+	cmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "Show the files that would change without writing them")
+	cmd.Flags().BoolVar(&opts.noVerify, "no-verify", false, "Do not run go vet on the changed modules")
+	cmd.Flags().BoolVar(&opts.noStage, "no-stage", false, "Do not stage the changed files")
+	_ = cmd.MarkFlagRequired("from")
+	_ = cmd.MarkFlagRequired("to")
+
+	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion())
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"repos": WorkspaceRepositoryCompletion().UniqueList(","),
+	})
+
+	return cmd
+}
+
+func runRenameSymbol(ctx context.Context, workspaceName string, opts renameSymbolOptions) error {
+	workspace, err := resolveWorkspace(workspaceName)
+	if err != nil {
+		return err
+	}
+
+	from, err := wsm.ParseSymbolRef(opts.from)
+	if err != nil {
+		return errors.Wrap(err, "invalid --from")
+	}
+	to := wsm.SymbolRef{Package: from.Package, Name: opts.to}
+	if !token.IsIdentifier(opts.to) {
+		if to, err = wsm.ParseSymbolRef(opts.to); err != nil {
+			return errors.Wrap(err, "invalid --to")
+		}
+	}
+	options := wsm.RenameSymbolOptions{From: from, To: to, Repositories: opts.repos}
+
+	if opts.gopls {
+		return runGoplsRename(ctx, workspace, options, opts)
+	}
+
+	plan, err := wsm.PlanRenameSymbol(workspace, options)
+	if err != nil {
+		return err
+	}
+	output.PrintHeader("Renaming %s to %s in workspace '%s'", from, to, workspace.Name)
+	for path, reason := range plan.Skipped {
+		output.PrintWarning("Skipped %s: %s", relativeTo(workspace.Path, path), reason)
+	}
+	if len(plan.Files) == 0 {
+		output.PrintInfo("No references to %s found", from)
+		return nil
+	}
+
+	changed := map[string][]string{}
+	for _, file := range plan.Files {
+		detail := fmt.Sprintf("%d reference(s)", file.References)
+		if file.Imports {
+			detail += ", imports"
+		}
+		output.Printf("  %s (%s)\n", relativeTo(workspace.Path, file.Path), detail)
+		changed[file.Repository] = append(changed[file.Repository], file.Path)
+	}
+	if opts.dryRun {
+		output.Printf("\n")
+		output.PrintInfo("Dry run mode - %d reference(s) in %d file(s) would change", plan.References(), len(plan.Files))
+		return nil
+	}
+
+	modified, err := modifiedGoFiles(ctx, workspace, changed)
+	if err != nil {
+		return err
+	}
+	paths := make([]string, 0, len(plan.Files))
+	for _, file := range plan.Files {
+		paths = append(paths, file.Path)
+	}
+	snapshot, err := wsm.SnapshotFiles(paths)
+	if err != nil {
+		return err
+	}
+	if err := wsm.WriteRename(workspace, plan); err != nil {
+		return err
+	}
+	output.Printf("\n")
+	output.PrintSuccess("Renamed %d reference(s) in %d file(s)", plan.References(), len(plan.Files))
+
+	return verifyAndStageGoChanges(ctx, workspace, plan.Modules, changed, modified, snapshot, !opts.noVerify, !opts.noStage)
+}
+
+func runGoplsRename(ctx context.Context, workspace *wsm.Workspace, options wsm.RenameSymbolOptions, opts renameSymbolOptions) error {
+	if options.Moves() {
+		return errors.New("--gopls only renames a symbol within its package")
+	}
+	modules, err := wsm.WorkspaceGoModules(workspace, opts.repos)
+	if err != nil {
+		return err
+	}
+	dir := wsm.PackageDir(modules, options.From.Package)
+	if dir == "" {
+		return errors.Errorf("package %s is not part of workspace '%s'", options.From.Package, workspace.Name)
+	}
+	file, offset, err := wsm.SymbolDeclaration(dir, options.From.Name)
+	if err != nil {
+		return err
+	}
+
+	output.PrintHeader("Renaming %s to %s in workspace '%s' with gopls", options.From, options.To, workspace.Name)
+	if opts.dryRun {
+		diff, err := wsm.GoplsRenameDiff(ctx, workspace, file, offset, options.To.Name)
+		if err != nil {
+			return err
+		}
+		fmt.Print(diff)
+		output.PrintInfo("Dry run mode - no files were written")
+		return nil
+	}
+
+	// gopls does not tell which files it is going to change: remember every modified file
+	before := map[string][]string{}
+	for _, repo := range workspace.Repositories {
+		before[repo.Name] = nil
+	}
+	modified, err := modifiedGoFiles(ctx, workspace, before)
+	if err != nil {
+		return err
+	}
+	planned, err := wsm.GoplsRenameFiles(ctx, workspace, file, offset, options.To.Name)
+	if err != nil {
+		return err
+	}
+	snapshot, err := wsm.SnapshotFiles(planned)
+	if err != nil {
+		return err
+	}
+	files, err := wsm.GoplsRename(ctx, workspace, file, offset, options.To.Name)
+	if err != nil {
+		return err
+	}
+
+	changed := map[string][]string{}
+	for _, path := range files {
+		module, ok := wsm.ModuleOf(modules, path)
+		if !ok {
+			output.PrintWarning("gopls changed %s, outside the repositories of the workspace", path)
+			continue
+		}
+		output.Printf("  %s\n", relativeTo(workspace.Path, path))
+		changed[module.Repository] = append(changed[module.Repository], path)
+	}
+	output.Printf("\n")
+	output.PrintSuccess("Renamed %s in %d file(s)", options.From, len(files))
+
+	return verifyAndStageGoChanges(ctx, workspace, modules, changed, modified, snapshot, !opts.noVerify, !opts.noStage)
+}
+
+// modifiedGoFiles returns the files of the repositories that have uncommitted changes before they
+// are rewritten; a repository without files is checked as a whole
+func modifiedGoFiles(ctx context.Context, workspace *wsm.Workspace, files map[string][]string) (map[string]bool, error) {
+	modified := map[string]bool{}
+	for repo, paths := range files {
+		repoModified, err := wsm.ModifiedFiles(ctx, filepath.Join(workspace.Path, repo), paths)
+		if err != nil {
+			return nil, err
+		}
+		for path := range repoModified {
+			modified[path] = true
+		}
+	}
+	return modified, nil
+}
+
+// verifyAndStageGoChanges vets the modules with changed files and stages the files in each
+// repository, leaving the files that were modified before the change unstaged. When vet fails,
+// the files are restored from the snapshot taken before the change.
+func verifyAndStageGoChanges(ctx context.Context, workspace *wsm.Workspace, modules []wsm.GoModule, changed map[string][]string, modified map[string]bool, snapshot wsm.FileSnapshot, verify, stage bool) error {
+	if verify {
+		dirs := map[string]bool{}
+		for _, paths := range changed {
+			for _, path := range paths {
+				if module, ok := wsm.ModuleOf(modules, path); ok {
+					dirs[module.Dir] = true
+				}
+			}
+		}
+		var sorted []string
+		for dir := range dirs {
+			sorted = append(sorted, dir)
+		}
+		sort.Strings(sorted)
+
+		failed := false
+		for _, dir := range sorted {
+			vetOutput, err := wsm.GoVet(ctx, dir)
+			if err != nil {
+				failed = true
+				output.PrintError("go vet failed in %s:\n%s", relativeTo(workspace.Path, dir), vetOutput)
+				continue
+			}
+			output.PrintSuccess("%s passes go vet", relativeTo(workspace.Path, dir))
+		}
+		if failed {
+			if err := snapshot.Restore(); err != nil {
+				return errors.Wrap(err, "failed to revert the changes")
+			}
+			output.PrintWarning("The changes were reverted; rerun with --no-verify to keep them and fix the failures by hand")
+			return &wsm.ExitCodeError{Code: 1}
+		}
+	}
+
+	if !stage {
+		return nil
+	}
+	repos := make([]string, 0, len(changed))
+	for repo := range changed {
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+	for _, repo := range repos {
+		var paths []string
+		for _, path := range changed[repo] {
+			if modified[path] {
+				output.PrintWarning("%s had uncommitted changes and was not staged", relativeTo(workspace.Path, path))
+				continue
+			}
+			paths = append(paths, path)
+		}
+		if err := wsm.StageFiles(ctx, filepath.Join(workspace.Path, repo), paths); err != nil {
+			return err
+		}
+		if len(paths) > 0 {
+			output.PrintSuccess("%s: staged %d file(s)", repo, len(paths))
+		}
+	}
+	return nil
+}
+
+func relativeTo(base, path string) string {
+	if rel, err := filepath.Rel(base, path); err == nil {
+		return rel
+	}
+	return path
+}
@@ -0,0 +1,266 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/dustin/go-humanize"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewRepoCommand creates the repo command
+func NewRepoCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "repo",
+		Short: "Query repositories of the registry",
+	}
+
+	cmd.AddCommand(
+		NewRepoFindCommand(),
+		NewRepoStatusCommand(),
+	)
+
+	return cmd
+}
+
+// NewRepoFindCommand creates the repo find command
+func NewRepoFindCommand() *cobra.Command {
+	var (
+		limit  int
+		format string
+		asJSON bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "find <query>...",
+		Short: "Search the registry by name, alias, tag, remote, path and description",
+		Long: `Search the repositories of the registry and list them best match first.
+
+Every term of the query must match. Names score highest (exact, then prefix,
+then substring, then abbreviations like "wsmgr"), followed by aliases, tags,
+remote URL, the last components of the path and the README description.
+Restrict a term to one field with a qualifier: name:, alias:, tag:, remote:,
+path: or description:.
+
+Examples:
+  workspace-manager repo find api
+  workspace-manager repo find tag:go golems
+  workspace-manager repo find remote:github.com/acme --limit 50 --json`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if asJSON {
+				format = "json"
+			}
+			return runRepoFind(strings.Join(args, " "), limit, format)
+		},
+	}
+
+	cmd.Flags().IntVar(&limit, "limit", 20, "Maximum number of matches")
+	cmd.Flags().StringVar(&format, "format", "table", "Output format: table, json")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Shorthand for --format json")
+
+	return cmd
+}
+
+func runRepoFind(query string, limit int, format string) error {
+	discoverer, err := loadDiscoverer()
+	if err != nil {
+		return err
+	}
+
+	matches := discoverer.Search(query, limit)
+	if format == "json" {
+		if matches == nil {
+			matches = []wsm.SearchMatch{}
+		}
+		return wsm.PrintJSON(matches)
+	}
+
+	if len(matches) == 0 {
+		output.PrintInfo("No repositories match '%s'", query)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSCORE\tMATCHED\tTAGS\tPATH\tDESCRIPTION")
+	for _, match := range matches {
+		description := match.Repository.Description
+		if runes := []rune(description); len(runes) > 60 {
+			description = string(runes[:57]) + "..."
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\n",
+			match.Repository.Name,
+			match.Score,
+			strings.Join(match.Fields, ","),
+			strings.Join(match.Repository.Categories, ","),
+			match.Repository.Path,
+			description)
+	}
+	return w.Flush()
+}
+
+// NewRepoStatusCommand creates the repo status command
+func NewRepoStatusCommand() *cobra.Command {
+	var (
+		tags     []string
+		problems bool
+		format   string
+		columns  []string
+		sortBy   string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "status [repo...]",
+		Short: "Show the state of the source checkouts of registered repositories",
+		Long: `Report on the registered repositories themselves rather than the worktrees of
+a workspace: the checked out branch, uncommitted changes, an interrupted rebase
+or merge, the number of attached worktrees and how long ago the remotes were
+fetched. New workspaces branch off these checkouts, so a repository with
+problems is worth fixing before creating one from it.
+
+Problems are a rebase, merge, cherry-pick or revert in progress, a detached
+HEAD, uncommitted changes, being behind the upstream branch, worktrees whose
+directory is gone, and remotes never fetched or last fetched over a week ago.
+Nothing is fetched; see 'workspace-manager prefetch'.
+
+Examples:
+  workspace-manager repo status
+  workspace-manager repo status api web
+  workspace-manager repo status --tag go --problems`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRepoStatus(cmd.Context(), args, tags, problems, format, tableOptions{columns: columns, sortBy: sortBy})
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&tags, "tag", nil, "Only show repositories with these tags")
+	cmd.Flags().BoolVar(&problems, "problems", false, "Only show repositories with problems")
+	cmd.Flags().StringVar(&format, "format", "table", "Output format: table, json")
+	addTableFlags(cmd, &columns, &sortBy, sourceStatusColumns)
+
+	carapace.Gen(cmd).PositionalAnyCompletion(RepositoryNameCompletion())
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"tag":     TagCompletion(),
+		"format":  carapace.ActionValues("table", "json"),
+		"columns": ColumnCompletion(sourceStatusColumns),
+	})
+
+	return cmd
+}
+
+var sourceStatusColumns = []output.Column{
+	{Name: "name"},
+	{Name: "branch"},
+	{Name: "sync"},
+	{Name: "changes"},
+	{Name: "worktrees"},
+	{Name: "fetched"},
+	{Name: "problems"},
+	{Name: "path", Hidden: true},
+	{Name: "upstream", Hidden: true},
+	{Name: "ahead", Hidden: true},
+	{Name: "behind", Hidden: true},
+	{Name: "untracked", Hidden: true},
+}
+
+func runRepoStatus(ctx context.Context, names, tags []string, onlyProblems bool, format string, opts tableOptions) error {
+	discoverer, err := loadDiscoverer()
+	if err != nil {
+		return err
+	}
+
+	repos := discoverer.GetRepositoriesByTags(tags)
+	if len(names) > 0 {
+		wanted := map[string]bool{}
+		for _, name := range names {
+			wanted[discoverer.ResolveAlias(name)] = true
+		}
+		repos = slices.DeleteFunc(repos, func(repo wsm.Repository) bool { return !wanted[repo.Name] })
+		for name := range wanted {
+			if !slices.ContainsFunc(repos, func(repo wsm.Repository) bool { return repo.Name == name }) {
+				return errors.Errorf("repository '%s' not found in registry", name)
+			}
+		}
+	}
+
+	statuses := wsm.GetSourceStatuses(ctx, nil, repos)
+	if onlyProblems {
+		statuses = slices.DeleteFunc(statuses, func(status wsm.SourceStatus) bool { return len(status.Problems()) == 0 })
+	}
+
+	if format == "json" {
+		type sourceStatusJSON struct {
+			wsm.SourceStatus
+			Problems []string `json:"problems"`
+		}
+		result := make([]sourceStatusJSON, 0, len(statuses))
+		for _, status := range statuses {
+			result = append(result, sourceStatusJSON{SourceStatus: status, Problems: status.Problems()})
+		}
+		return wsm.PrintJSON(result)
+	}
+
+	if len(statuses) == 0 {
+		if onlyProblems {
+			output.PrintSuccess("No problems in the checkouts of %d repositories", len(repos))
+		} else {
+			output.PrintInfo("No repositories found; run 'workspace-manager discover' first")
+		}
+		return nil
+	}
+
+	table := output.NewTable(sourceStatusColumns...)
+	withProblems := 0
+	for _, status := range statuses {
+		problems := status.Problems()
+		if len(problems) > 0 {
+			withProblems++
+		}
+		branch := status.Branch
+		if status.Detached {
+			branch = "(detached)"
+		}
+		sync := "-"
+		if status.Upstream != "" {
+			sync = fmt.Sprintf("↑%d ↓%d", status.Ahead, status.Behind)
+		}
+		changes := "clean"
+		if status.Changed > 0 || status.Untracked > 0 {
+			changes = fmt.Sprintf("M:%d U:%d", status.Changed, status.Untracked)
+		}
+		fetched := "never"
+		if !status.FetchedAt.IsZero() {
+			fetched = humanize.Time(status.FetchedAt)
+		}
+		table.AddRow(output.Row{
+			"name":      output.Text(status.Repository),
+			"branch":    output.Text(branch),
+			"sync":      output.Text(sync),
+			"changes":   output.Text(changes),
+			"worktrees": output.Int(status.Worktrees),
+			"fetched":   output.Cell{Text: fetched, Value: status.FetchedAt},
+			"problems":  output.Text(orDash(strings.Join(problems, "; "))),
+			"path":      output.Text(status.Path),
+			"upstream":  output.Text(status.Upstream),
+			"ahead":     output.Int(status.Ahead),
+			"behind":    output.Int(status.Behind),
+			"untracked": output.Int(status.Untracked),
+		})
+	}
+	if err := renderTable(table, opts); err != nil {
+		return err
+	}
+
+	if withProblems > 0 {
+		fmt.Println()
+		output.PrintWarning("%d of %d repositories have problems; fix them before creating workspaces from them", withProblems, len(statuses))
+	}
+	return nil
+}
@@ -0,0 +1,542 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewResolveCommand creates the resolve command
+func NewResolveCommand() *cobra.Command {
+	var (
+		repos      []string
+		list       bool
+		noContinue bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "resolve [workspace-name]",
+		Short: "Resolve merge and rebase conflicts across the workspace hunk by hunk",
+		Long: `Open every conflicted file of the workspace repositories in a three-pane
+view: our side and their side of the current conflict at the top, the
+resulting file below. Pick a side for each conflict; files are written and
+staged once all their conflicts are resolved.
+
+Keys:
+  o          take ours              t        take theirs
+  b          ours, then theirs      B        theirs, then ours
+  O/T        ours/theirs for every conflict of the file
+  u          undo the resolution    n/p      next/previous conflict
+  ]/[        next/previous file     ↑/↓      scroll the result
+  m          open the merge tool    q        save and quit
+
+Files that cannot be resolved hunk by hunk (binary files, deleted on one side)
+are handed to 'git mergetool', using $MERGE_TOOL when set.
+
+When every conflict is resolved, the interrupted rebase, merge, cherry-pick
+or revert is continued in each repository; a rebase that stops on the next
+commit opens the view again.
+
+Examples:
+  workspace-manager resolve
+  workspace-manager resolve my-feature --repos api
+  workspace-manager resolve --list`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspaceName := ""
+			if len(args) > 0 {
+				workspaceName = args[0]
+			}
+			return runResolve(cmd.Context(), workspaceName, repos, list, noContinue)
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&repos, "repos", nil, "Only resolve conflicts in these repositories (comma-separated)")
+	cmd.Flags().BoolVar(&list, "list", false, "List conflicted files without opening the resolver")
+	cmd.Flags().BoolVar(&noContinue, "no-continue", false, "Do not continue the interrupted rebase or merge once conflicts are resolved")
+
+	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion())
+
+	return cmd
+}
+
+func runResolve(ctx context.Context, workspaceName string, repos []string, list, noContinue bool) error {
+	workspace, err := resolveWorkspace(workspaceName)
+	if err != nil {
+		return err
+	}
+
+	for round := 0; ; round++ {
+		conflicts, err := wsm.FindConflicts(ctx, workspace, repos)
+		if err != nil {
+			return err
+		}
+
+		if list {
+			if len(conflicts) == 0 {
+				output.PrintInfo("No conflicts in workspace '%s'", workspace.Name)
+				return nil
+			}
+			for _, conflict := range conflicts {
+				fmt.Printf("%s/%s\t%s\n", conflict.Repository, conflict.File, orDash(conflict.Operation))
+			}
+			return nil
+		}
+
+		if len(conflicts) > 0 {
+			if err := output.RequireInteractive("resolve conflicts", "use --list to see the conflicted files"); err != nil {
+				return err
+			}
+			model := newResolveModel(ctx, conflicts)
+			if _, err := tea.NewProgram(model, tea.WithAltScreen()).Run(); err != nil {
+				return errors.Wrap(err, "resolve failed")
+			}
+			if err := model.save(ctx); err != nil {
+				return err
+			}
+		} else if round == 0 && len(wsm.ResolvedOperations(ctx, workspace, repos)) == 0 {
+			output.PrintInfo("No conflicts in workspace '%s'", workspace.Name)
+			return nil
+		}
+
+		remaining, err := wsm.FindConflicts(ctx, workspace, repos)
+		if err != nil {
+			return err
+		}
+		if len(remaining) > 0 {
+			output.PrintWarning("%d conflicted files left; run 'workspace-manager resolve' again to finish", len(remaining))
+		}
+
+		pending := wsm.ResolvedOperations(ctx, workspace, repos)
+		if len(pending) == 0 {
+			if len(remaining) == 0 {
+				output.PrintSuccess("All conflicts resolved")
+			}
+			return nil
+		}
+		if noContinue {
+			for _, repo := range pending {
+				output.PrintInfo("%s: conflicts resolved; run 'git %s --continue' to finish", repo.Repository, repo.Operation)
+			}
+			return nil
+		}
+
+		// A rebase continued past the conflicting commit can stop again on a later one; those
+		// repositories are resolved in the next round
+		var stopped []string
+		for _, repo := range pending {
+			if _, err := wsm.ContinueOperation(ctx, repo.Dir, repo.Operation); err != nil {
+				output.PrintError("%s: %v", repo.Repository, err)
+				continue
+			}
+			if operation := wsm.ConflictOperation(ctx, repo.Dir); operation != "" {
+				output.PrintWarning("%s: %s stopped on new conflicts", repo.Repository, operation)
+				stopped = append(stopped, repo.Repository)
+				continue
+			}
+			output.PrintSuccess("%s: %s continued", repo.Repository, repo.Operation)
+		}
+		if len(stopped) == 0 {
+			return nil
+		}
+		repos = stopped
+	}
+}
+
+// resolveFile is a conflicted file and its parsed conflicts; doc is nil when the file has to be
+// resolved with the merge tool
+type resolveFile struct {
+	conflict wsm.ConflictedFile
+	doc      *wsm.ConflictDocument
+	problem  string
+	// external is set once the merge tool resolved the file
+	external bool
+	changed  bool
+}
+
+func (f *resolveFile) resolved() bool {
+	return f.external || (f.doc != nil && f.doc.Unresolved() == 0)
+}
+
+// mergetoolDoneMsg is sent when the merge tool started from the resolver exits
+type mergetoolDoneMsg struct {
+	err error
+}
+
+// resolveModel shows the current conflict of the current file: ours and theirs side by side,
+// the resulting file below
+type resolveModel struct {
+	ctx     context.Context
+	files   []*resolveFile
+	file    int
+	hunk    int
+	result  viewport.Model
+	width   int
+	height  int
+	message string
+	ready   bool
+}
+
+func newResolveModel(ctx context.Context, conflicts []wsm.ConflictedFile) *resolveModel {
+	m := &resolveModel{ctx: ctx}
+	for _, conflict := range conflicts {
+		m.files = append(m.files, loadResolveFile(conflict))
+	}
+	return m
+}
+
+func loadResolveFile(conflict wsm.ConflictedFile) *resolveFile {
+	file := &resolveFile{conflict: conflict}
+	content, err := os.ReadFile(conflict.Path())
+	switch {
+	case err != nil:
+		file.problem = "deleted on one side"
+	case strings.IndexByte(string(content), 0) >= 0:
+		file.problem = "binary file"
+	default:
+		doc, err := wsm.ParseConflictDocument(string(content))
+		switch {
+		case err != nil:
+			file.problem = err.Error()
+		case len(doc.Hunks()) == 0:
+			file.problem = "no conflict markers"
+		default:
+			file.doc = doc
+		}
+	}
+	return file
+}
+
+// save writes the files changed in the resolver and stages the fully resolved ones
+func (m *resolveModel) save(ctx context.Context) error {
+	for _, file := range m.files {
+		if file.doc == nil || !file.changed {
+			continue
+		}
+		if _, err := wsm.SaveResolution(ctx, file.conflict, file.doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *resolveModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *resolveModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		if !m.ready {
+			m.result = viewport.New(msg.Width-2, 1)
+			m.ready = true
+		}
+		m.layout()
+		m.showResult()
+		return m, nil
+
+	case mergetoolDoneMsg:
+		file := m.files[m.file]
+		if msg.err != nil {
+			m.message = fmt.Sprintf("merge tool failed: %v", msg.err)
+		}
+		if !wsm.IsUnmerged(m.ctx, file.conflict) {
+			file.external = true
+			m.message = fmt.Sprintf("%s resolved with the merge tool", file.conflict.File)
+			m.nextUnresolved()
+		} else {
+			// The merge tool may have edited the file without resolving everything
+			*file = *loadResolveFile(file.conflict)
+			m.hunk = 0
+		}
+		m.showResult()
+		return m, nil
+
+	case tea.KeyMsg:
+		m.message = ""
+		switch msg.String() {
+		case "q", "ctrl+c", "esc":
+			return m, tea.Quit
+		case "o":
+			m.resolve(wsm.ResolutionOurs)
+		case "t":
+			m.resolve(wsm.ResolutionTheirs)
+		case "b":
+			m.resolve(wsm.ResolutionOursFirst)
+		case "B":
+			m.resolve(wsm.ResolutionTheirsFirst)
+		case "O":
+			m.resolveFile(wsm.ResolutionOurs)
+		case "T":
+			m.resolveFile(wsm.ResolutionTheirs)
+		case "u":
+			if hunk := m.currentHunk(); hunk != nil {
+				hunk.Resolution = wsm.ResolutionNone
+				m.files[m.file].changed = true
+			}
+		case "n", "right", "l", " ":
+			m.moveHunk(1)
+		case "p", "left", "h":
+			m.moveHunk(-1)
+		case "]":
+			m.moveFile(m.file + 1)
+		case "[":
+			m.moveFile(m.file - 1)
+		case "m":
+			return m, m.runMergetool()
+		default:
+			var cmd tea.Cmd
+			m.result, cmd = m.result.Update(msg)
+			return m, cmd
+		}
+		m.showResult()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.result, cmd = m.result.Update(msg)
+	return m, cmd
+}
+
+func (m *resolveModel) currentHunk() *wsm.ConflictHunk {
+	doc := m.files[m.file].doc
+	if doc == nil {
+		return nil
+	}
+	return doc.Hunks()[m.hunk]
+}
+
+// resolve applies a resolution to the current conflict and moves to the next unresolved one
+func (m *resolveModel) resolve(resolution string) {
+	hunk := m.currentHunk()
+	if hunk == nil {
+		m.message = "this file cannot be resolved hunk by hunk; press m to open the merge tool"
+		return
+	}
+	hunk.Resolution = resolution
+	m.files[m.file].changed = true
+	m.nextUnresolved()
+}
+
+func (m *resolveModel) resolveFile(resolution string) {
+	file := m.files[m.file]
+	if file.doc == nil {
+		m.message = "this file cannot be resolved hunk by hunk; press m to open the merge tool"
+		return
+	}
+	for _, hunk := range file.doc.Hunks() {
+		hunk.Resolution = resolution
+	}
+	file.changed = true
+	m.nextUnresolved()
+}
+
+// nextUnresolved moves to the next unresolved conflict of the current file, then of the
+// following files, staying put when everything is resolved
+func (m *resolveModel) nextUnresolved() {
+	for offset := 0; offset < len(m.files); offset++ {
+		index := (m.file + offset) % len(m.files)
+		file := m.files[index]
+		if file.resolved() {
+			continue
+		}
+		m.file = index
+		m.hunk = 0
+		if file.doc != nil {
+			for i, hunk := range file.doc.Hunks() {
+				if _, ok := hunk.Resolved(); !ok {
+					m.hunk = i
+					break
+				}
+			}
+		}
+		return
+	}
+	m.message = "all conflicts resolved; press q to save and continue"
+}
+
+func (m *resolveModel) moveHunk(direction int) {
+	doc := m.files[m.file].doc
+	if doc != nil {
+		if next := m.hunk + direction; next >= 0 && next < len(doc.Hunks()) {
+			m.hunk = next
+			return
+		}
+	}
+	if direction > 0 {
+		m.moveFile(m.file + 1)
+		return
+	}
+	if m.file > 0 {
+		m.moveFile(m.file - 1)
+		if doc := m.files[m.file].doc; doc != nil {
+			m.hunk = len(doc.Hunks()) - 1
+		}
+	}
+}
+
+func (m *resolveModel) moveFile(index int) {
+	if index < 0 || index >= len(m.files) {
+		return
+	}
+	m.file = index
+	m.hunk = 0
+}
+
+// runMergetool hands the current file to git mergetool; hunk resolutions made so far for the
+// file are dropped, as the tool starts again from both sides
+func (m *resolveModel) runMergetool() tea.Cmd {
+	file := m.files[m.file]
+	args := []string{"mergetool"}
+	if tool := os.Getenv("MERGE_TOOL"); tool != "" {
+		args = append(args, "--tool="+tool)
+	}
+	args = append(args, "--", file.conflict.File)
+	cmd := exec.CommandContext(m.ctx, "git", args...)
+	cmd.Dir = file.conflict.Dir
+	file.changed = false
+	return tea.ExecProcess(cmd, func(err error) tea.Msg { return mergetoolDoneMsg{err: err} })
+}
+
+// paneHeight returns the content height of the ours and theirs panes
+func (m *resolveModel) paneHeight() int {
+	// Header, help, message lines and the borders of both pane rows
+	available := max(m.height-7, 2)
+	return max(available*2/5, 1)
+}
+
+func (m *resolveModel) layout() {
+	m.result.Width = max(m.width-2, 1)
+	m.result.Height = max(m.height-7-m.paneHeight(), 1)
+}
+
+// showResult renders the resulting file, scrolled to the current conflict
+func (m *resolveModel) showResult() {
+	if !m.ready {
+		return
+	}
+	file := m.files[m.file]
+	if file.doc == nil {
+		status := fmt.Sprintf("%s: %s; press m to open the merge tool", file.conflict.File, file.problem)
+		if file.external {
+			status = fmt.Sprintf("%s was resolved with the merge tool", file.conflict.File)
+		}
+		m.result.SetContent(output.DimStyle.Render(status))
+		m.result.GotoTop()
+		return
+	}
+
+	lines, ranges := file.doc.RenderLines()
+	hunks := file.doc.Hunks()
+	styled := make([]string, len(lines))
+	for i, line := range lines {
+		styled[i] = truncateLine(line, m.result.Width)
+	}
+	for i, r := range ranges {
+		style := output.WarningStyle
+		if _, ok := hunks[i].Resolved(); ok {
+			style = output.SuccessStyle
+		}
+		if i == m.hunk {
+			style = style.Bold(true).Reverse(true)
+		}
+		for line := r.Start; line < r.End; line++ {
+			styled[line] = style.Render(truncateLine(lines[line], m.result.Width))
+		}
+	}
+	m.result.SetContent(strings.Join(styled, "\n"))
+	m.result.SetYOffset(max(ranges[m.hunk].Start-2, 0))
+}
+
+func truncateLine(line string, width int) string {
+	line = strings.ReplaceAll(line, "\t", "    ")
+	return lipgloss.NewStyle().MaxWidth(width).Render(line)
+}
+
+// renderPane draws a bordered pane with a title and as many lines as fit
+func renderPane(title string, lines []string, width, height int, style lipgloss.Style) string {
+	content := make([]string, 0, height)
+	for i, line := range lines {
+		if len(content) == height-1 && len(lines)-i > 1 {
+			content = append(content, output.DimStyle.Render(fmt.Sprintf("… %d more lines", len(lines)-i)))
+			break
+		}
+		content = append(content, style.Render(truncateLine(line, width)))
+	}
+	if len(lines) == 0 {
+		content = append(content, output.DimStyle.Render("(empty)"))
+	}
+	for len(content) < height {
+		content = append(content, "")
+	}
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("8")).
+		Width(width).
+		Render(output.BoldStyle.Render(title) + "\n" + strings.Join(content, "\n"))
+}
+
+func (m *resolveModel) View() string {
+	if !m.ready {
+		return ""
+	}
+	file := m.files[m.file]
+
+	resolvedFiles := 0
+	for _, f := range m.files {
+		if f.resolved() {
+			resolvedFiles++
+		}
+	}
+	position := fmt.Sprintf("file %d/%d, %d resolved", m.file+1, len(m.files), resolvedFiles)
+	if file.doc != nil {
+		position = fmt.Sprintf("conflict %d/%d, %s", m.hunk+1, len(file.doc.Hunks()), position)
+	}
+	operation := ""
+	if file.conflict.Operation != "" {
+		operation = output.InfoStyle.Render(file.conflict.Operation)
+	}
+	header := fmt.Sprintf("%s %s  %s  %s",
+		output.HeaderStyle.Render(file.conflict.Repository),
+		output.BoldStyle.Render(file.conflict.File),
+		output.DimStyle.Render(position),
+		operation)
+
+	// Each pane has a border and a title line
+	paneWidth := max(m.width/2-2, 1)
+	paneHeight := max(m.paneHeight()-1, 1)
+	var ours, theirs string
+	if hunk := m.currentHunk(); hunk != nil {
+		ours = renderPane("ours "+hunk.OursLabel, hunk.Ours, paneWidth, paneHeight, output.ErrorStyle)
+		theirs = renderPane("theirs "+hunk.TheirsLabel, hunk.Theirs, paneWidth, paneHeight, output.SuccessStyle)
+	} else {
+		ours = renderPane("ours", nil, paneWidth, paneHeight, output.DimStyle)
+		theirs = renderPane("theirs", nil, paneWidth, paneHeight, output.DimStyle)
+	}
+	panes := lipgloss.JoinHorizontal(lipgloss.Top, ours, theirs)
+
+	result := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("8")).
+		Render(m.result.View())
+
+	message := ""
+	if m.message != "" {
+		message = output.InfoStyle.Render(m.message)
+	}
+	help := output.DimStyle.Render("o/t ours/theirs · b/B both · O/T all · u undo · n/p conflict · ]/[ file · m mergetool · q quit")
+
+	return strings.Join([]string{header, panes, result, message, help}, "\n")
+}
@@ -0,0 +1,123 @@
+package cmds
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewRespinCommand creates the respin command
+func NewRespinCommand() *cobra.Command {
+	var (
+		branch      string
+		name        string
+		noFetch     bool
+		bootstrap   bootstrapFlags
+		dryRun      bool
+		ignoreQuota bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "respin [workspace-name] --branch <new-branch>",
+		Short: "Start a new workspace with the same repositories on a fresh branch",
+		Long: `Create a sibling of a workspace with the same repositories, on a new branch
+that starts from the default branch of each repository (origin/HEAD, falling
+back to main or master) rather than from the branch of the source workspace.
+The .wsm configuration (setup scripts, env, container and tmux settings) and
+the git config overrides are copied, so the next feature starts with the same
+setup. Review notes stay with the source workspace.
+
+origin is fetched first; the new workspace is named after the last component
+of the branch unless --name is given.
+
+Examples:
+  # Start the next feature with the repositories of the current workspace
+  workspace-manager respin --branch feature/billing-export
+
+  # Respin a specific workspace under a chosen name
+  workspace-manager respin my-feature --branch fix/login-timeout --name login-timeout`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspaceName := ""
+			if len(args) > 0 {
+				workspaceName = args[0]
+			}
+			return silenceReported(cmd, runRespin(cmd.Context(), workspaceName, name, branch, !noFetch, bootstrap, dryRun, ignoreQuota))
+		},
+	}
+
+	cmd.Flags().StringVar(&branch, "branch", "", "Branch of the new workspace (required)")
+	cmd.Flags().StringVar(&name, "name", "", "Name of the new workspace (default: last component of the branch)")
+	cmd.Flags().BoolVar(&noFetch, "no-fetch", false, "Do not fetch origin before branching off the default branches")
+	bootstrap.register(cmd)
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be created without actually creating")
+	cmd.Flags().BoolVar(&ignoreQuota, "ignore-quota", false, "Create the workspace even when the quota (quota.max_workspaces, quota.disk_budget) is exceeded")
+	_ = cmd.MarkFlagRequired("branch")
+	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion())
+
+	return cmd
+}
+
+func runRespin(ctx context.Context, workspaceName, name, branch string, fetch bool, bootstrap bootstrapFlags, dryRun, ignoreQuota bool) error {
+	source, err := resolveWorkspace(workspaceName)
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		name = path.Base(strings.TrimSuffix(branch, "/"))
+	}
+	if name == source.Name {
+		return errors.Errorf("the new workspace needs a name other than '%s'; use --name", source.Name)
+	}
+
+	wm, err := wsm.NewWorkspaceManager()
+	if err != nil {
+		return errors.Wrap(err, "failed to create workspace manager")
+	}
+	wm.IgnoreQuota = ignoreQuota
+
+	output.PrintInfo("Respinning workspace '%s' as '%s' on branch %s", source.Name, name, branch)
+	workspace, plan, err := wm.RespinWorkspace(ctx, source, name, branch, fetch, dryRun)
+	if err != nil {
+		return creationError(err, "failed to respin workspace")
+	}
+
+	if dryRun {
+		output.PrintHeader("📋 Respin Preview: %s → %s", source.Name, workspace.Name)
+		output.Printf("\n")
+		output.Printf("  Path: %s\n", workspace.Path)
+		output.Printf("  Branch: %s\n", workspace.Branch)
+		for _, repo := range plan {
+			output.Printf("     git worktree add -b %s %s/%s %s\n", workspace.Branch, workspace.Path, repo.Name, repo.Base)
+		}
+		output.Printf("  Copy %s/.wsm to %s/.wsm\n", source.Path, workspace.Path)
+		return nil
+	}
+
+	output.PrintSuccess("Workspace '%s' respun from '%s'!", workspace.Name, source.Name)
+	output.Printf("\n")
+
+	output.PrintHeader("Respin Details")
+	output.Printf("  Path: %s\n", workspace.Path)
+	output.Printf("  Branch: %s\n", workspace.Branch)
+	for _, repo := range plan {
+		output.Printf("  %s: from %s\n", repo.Name, orDash(repo.Base))
+	}
+	if workspace.GoWorkspace {
+		output.Printf("  Go workspace: yes (go.work created)\n")
+	}
+
+	bootstrapWorkspace(ctx, wm, workspace, bootstrap)
+
+	output.Printf("\n")
+	output.PrintInfo("To start working:")
+	output.Printf("  cd %s\n", workspace.Path)
+
+	return nil
+}
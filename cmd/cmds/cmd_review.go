@@ -0,0 +1,333 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewReviewCommand creates the review command
+func NewReviewCommand() *cobra.Command {
+	var (
+		repos      []string
+		committed  bool
+		outputPath string
+		exportOnly bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "review [workspace-name]",
+		Short: "Step through the workspace diff hunk by hunk before opening pull requests",
+		Long: `Walk through the changes of every repository since its branch point,
+repository by repository and hunk by hunk, marking each hunk as approved or
+flagged and adding comments - a self-review before opening pull requests.
+
+Keys:
+  →/n/space  next hunk          ←/p      previous hunk
+  tab        next unreviewed    ]/[      next/previous repository
+  a          approve            x        flag
+  c          comment            u        clear the mark
+  ↑/↓        scroll             q        save and quit
+
+Marks are kept in .wsm/review.json, so a later review only asks about hunks
+that changed. On quit the notes are exported to Markdown (default:
+.wsm/review.md in the workspace).
+
+Examples:
+  workspace-manager review
+  workspace-manager review my-feature --repos api --committed
+  workspace-manager review --export --output review.md`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspaceName := ""
+			if len(args) > 0 {
+				workspaceName = args[0]
+			}
+			return runReview(cmd.Context(), workspaceName, repos, committed, outputPath, exportOnly)
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&repos, "repos", nil, "Only review these repositories (comma-separated)")
+	cmd.Flags().BoolVar(&committed, "committed", false, "Only review committed changes, not the working tree")
+	cmd.Flags().StringVarP(&outputPath, "output", "o", "", "Markdown file for the review notes (default: .wsm/review.md in the workspace)")
+	cmd.Flags().BoolVar(&exportOnly, "export", false, "Export the notes of the saved review without opening the review")
+
+	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion())
+
+	return cmd
+}
+
+func runReview(ctx context.Context, workspaceName string, repos []string, committed bool, outputPath string, exportOnly bool) error {
+	workspace, err := resolveWorkspace(workspaceName)
+	if err != nil {
+		return err
+	}
+
+	hunks, err := wsm.CollectReviewHunks(ctx, workspace, repos, committed)
+	if err != nil {
+		return err
+	}
+	if len(hunks) == 0 {
+		output.PrintInfo("No changes to review in workspace '%s'", workspace.Name)
+		return nil
+	}
+
+	review, err := wsm.LoadReview(workspace)
+	if err != nil {
+		return err
+	}
+
+	if !exportOnly {
+		if err := output.RequireInteractive("review the workspace diff", "use --export to write the notes of a saved review"); err != nil {
+			return err
+		}
+		model := newReviewModel(hunks, review)
+		if _, err := tea.NewProgram(model, tea.WithAltScreen()).Run(); err != nil {
+			return errors.Wrap(err, "review failed")
+		}
+		if err := wsm.SaveReview(workspace, review, hunks); err != nil {
+			return err
+		}
+	}
+
+	if outputPath == "" {
+		outputPath = filepath.Join(workspace.Path, ".wsm", "review.md")
+	}
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return errors.Wrap(err, "failed to create output directory")
+	}
+	if err := os.WriteFile(outputPath, []byte(wsm.RenderReviewMarkdown(workspace, review, hunks)), 0644); err != nil {
+		return errors.Wrap(err, "failed to write review notes")
+	}
+
+	summary := review.Summarize(hunks)
+	output.PrintSuccess("Reviewed %d of %d hunks: %d approved, %d flagged, %d commented",
+		summary.Total-summary.Unreviewed, summary.Total, summary.Approved, summary.Flagged, summary.Commented)
+	output.PrintInfo("Notes written to %s", outputPath)
+	return nil
+}
+
+// reviewModel shows one hunk at a time with its verdict and comment
+type reviewModel struct {
+	hunks    []wsm.ReviewHunk
+	review   *wsm.Review
+	index    int
+	viewport viewport.Model
+	comment  textinput.Model
+	ready    bool
+}
+
+func newReviewModel(hunks []wsm.ReviewHunk, review *wsm.Review) *reviewModel {
+	comment := textinput.New()
+	comment.Prompt = "comment: "
+	comment.CharLimit = 0
+	m := &reviewModel{hunks: hunks, review: review, comment: comment}
+	// Resume at the first hunk without a mark
+	m.index = m.nextUnreviewed(-1)
+	return m
+}
+
+func (m *reviewModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *reviewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		// Header, hunk header, comment and help lines
+		height := max(msg.Height-4, 1)
+		if !m.ready {
+			m.viewport = viewport.New(msg.Width, height)
+			m.ready = true
+		} else {
+			m.viewport.Width = msg.Width
+			m.viewport.Height = height
+		}
+		m.comment.Width = msg.Width - len(m.comment.Prompt) - 1
+		m.showHunk()
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.comment.Focused() {
+			switch msg.Type {
+			case tea.KeyEnter:
+				m.setMark(func(mark *wsm.ReviewMark) { mark.Comment = strings.TrimSpace(m.comment.Value()) })
+				m.comment.Blur()
+				return m, nil
+			case tea.KeyEsc:
+				m.comment.Blur()
+				return m, nil
+			}
+			var cmd tea.Cmd
+			m.comment, cmd = m.comment.Update(msg)
+			return m, cmd
+		}
+
+		switch msg.String() {
+		case "q", "ctrl+c", "esc":
+			return m, tea.Quit
+		case "right", "n", " ", "l":
+			m.move(m.index + 1)
+		case "left", "p", "h":
+			m.move(m.index - 1)
+		case "tab":
+			m.move(m.nextUnreviewed(m.index))
+		case "]":
+			m.move(m.repositoryStart(1))
+		case "[":
+			m.move(m.repositoryStart(-1))
+		case "a":
+			m.setMark(func(mark *wsm.ReviewMark) { mark.Verdict = toggleVerdict(mark.Verdict, wsm.ReviewApproved) })
+			if m.currentMark().Verdict == wsm.ReviewApproved {
+				m.move(m.index + 1)
+			}
+		case "x":
+			m.setMark(func(mark *wsm.ReviewMark) { mark.Verdict = toggleVerdict(mark.Verdict, wsm.ReviewFlagged) })
+		case "u":
+			m.setMark(func(mark *wsm.ReviewMark) { *mark = wsm.ReviewMark{} })
+		case "c":
+			m.comment.SetValue(m.currentMark().Comment)
+			m.comment.CursorEnd()
+			return m, m.comment.Focus()
+		default:
+			var cmd tea.Cmd
+			m.viewport, cmd = m.viewport.Update(msg)
+			return m, cmd
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+func toggleVerdict(current, verdict string) string {
+	if current == verdict {
+		return ""
+	}
+	return verdict
+}
+
+func (m *reviewModel) currentMark() wsm.ReviewMark {
+	return m.review.Marks[m.hunks[m.index].ID()]
+}
+
+func (m *reviewModel) setMark(update func(mark *wsm.ReviewMark)) {
+	id := m.hunks[m.index].ID()
+	mark := m.review.Marks[id]
+	update(&mark)
+	m.review.Marks[id] = mark
+}
+
+func (m *reviewModel) move(index int) {
+	if index < 0 || index >= len(m.hunks) {
+		return
+	}
+	m.index = index
+	m.showHunk()
+}
+
+// nextUnreviewed returns the first hunk without a mark after index, wrapping around, or index
+// itself when every hunk has been reviewed
+func (m *reviewModel) nextUnreviewed(index int) int {
+	for offset := 1; offset <= len(m.hunks); offset++ {
+		i := (index + offset + len(m.hunks)) % len(m.hunks)
+		if m.review.Marks[m.hunks[i].ID()].IsEmpty() {
+			return i
+		}
+	}
+	return max(index, 0)
+}
+
+// repositoryStart returns the first hunk of the next (direction 1) or previous (-1) repository
+func (m *reviewModel) repositoryStart(direction int) int {
+	start := m.index
+	for start > 0 && m.hunks[start-1].Repository == m.hunks[m.index].Repository {
+		start--
+	}
+	if direction < 0 {
+		if start == 0 {
+			return 0
+		}
+		previous := start - 1
+		for previous > 0 && m.hunks[previous-1].Repository == m.hunks[start-1].Repository {
+			previous--
+		}
+		return previous
+	}
+	next := m.index
+	for next < len(m.hunks) && m.hunks[next].Repository == m.hunks[m.index].Repository {
+		next++
+	}
+	if next == len(m.hunks) {
+		return m.index
+	}
+	return next
+}
+
+func (m *reviewModel) showHunk() {
+	if !m.ready {
+		return
+	}
+	hunk := m.hunks[m.index]
+	lines := make([]string, 0, len(hunk.Lines))
+	for _, line := range hunk.Lines {
+		switch {
+		case strings.HasPrefix(line, "+"):
+			lines = append(lines, output.SuccessStyle.Render(line))
+		case strings.HasPrefix(line, "-"):
+			lines = append(lines, output.ErrorStyle.Render(line))
+		default:
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		lines = append(lines, output.DimStyle.Render("(no textual changes)"))
+	}
+	m.viewport.SetContent(strings.Join(lines, "\n"))
+	m.viewport.GotoTop()
+}
+
+func (m *reviewModel) View() string {
+	if !m.ready {
+		return ""
+	}
+	hunk := m.hunks[m.index]
+	mark := m.currentMark()
+
+	verdict := output.DimStyle.Render("not reviewed")
+	switch mark.Verdict {
+	case wsm.ReviewApproved:
+		verdict = output.SuccessStyle.Render("✓ approved")
+	case wsm.ReviewFlagged:
+		verdict = output.WarningStyle.Render("⚑ flagged")
+	}
+	summary := m.review.Summarize(m.hunks)
+	header := fmt.Sprintf("%s %s  %s  %s",
+		output.HeaderStyle.Render(hunk.Repository),
+		output.BoldStyle.Render(hunk.File),
+		output.DimStyle.Render(fmt.Sprintf("hunk %d/%d, %d left", m.index+1, len(m.hunks), summary.Unreviewed)),
+		verdict)
+
+	comment := output.DimStyle.Render("no comment")
+	if m.comment.Focused() {
+		comment = m.comment.View()
+	} else if mark.Comment != "" {
+		comment = output.InfoStyle.Render("💬 " + mark.Comment)
+	}
+	help := output.DimStyle.Render("n/p next/prev · tab unreviewed · ]/[ repo · a approve · x flag · c comment · u clear · q quit")
+
+	return strings.Join([]string{header, output.InfoStyle.Render(hunk.Header), m.viewport.View(), comment, help}, "\n")
+}
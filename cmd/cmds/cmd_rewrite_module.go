@@ -0,0 +1,138 @@
+package cmds
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/spf13/cobra"
+)
+
+// NewRewriteModuleCommand creates the rewrite-module command
+func NewRewriteModuleCommand() *cobra.Command {
+	var (
+		repos    []string
+		dryRun   bool
+		noVerify bool
+		noStage  bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "rewrite-module <old-module> <new-module> [workspace-name]",
+		Short: "Move a Go module to a new module path across the workspace",
+		Long: `Rewrite a Go module path in every repository of the workspace, e.g. after
+forking a module, vendoring it under your organization or moving it to another
+organization:
+
+  - the module line of its go.mod
+  - the require, replace, exclude and tool directives of the other go.mod files,
+    and their go.sum checksums, which 'go mod download' records again
+  - the imports of every Go file
+  - the replace directives of the workspace's go.work
+
+Packages below the module path (<old-module>/pkg) and nested modules move
+along. Vendored and testdata directories are left alone.
+
+Once written, 'go vet ./...' runs in every changed module and the changed files
+are staged in each repository. When vet fails the files are restored as they
+were, and files that had uncommitted changes before are never staged.
+
+Examples:
+  # Move to the fork of the module
+  workspace-manager rewrite-module github.com/upstream/lib github.com/acme/lib
+
+  # Show what would change
+  workspace-manager rewrite-module github.com/old-org/api github.com/new-org/api --dry-run`,
+		Args: cobra.RangeArgs(2, 3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspaceName := ""
+			if len(args) > 2 {
+				workspaceName = args[2]
+			}
+			options := wsm.ModuleRewriteOptions{Old: args[0], New: args[1], Repositories: repos}
+			return silenceReported(cmd, runRewriteModule(cmd.Context(), workspaceName, options, dryRun, !noVerify, !noStage))
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&repos, "repos", nil, "Only rewrite these repositories (comma-separated)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show the files that would change without writing them")
+	cmd.Flags().BoolVar(&noVerify, "no-verify", false, "Do not run go vet on the changed modules")
+	cmd.Flags().BoolVar(&noStage, "no-stage", false, "Do not stage the changed files")
+
+	carapace.Gen(cmd).PositionalCompletion(carapace.ActionValues(), carapace.ActionValues(), WorkspaceNameCompletion())
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"repos": WorkspaceRepositoryCompletion().UniqueList(","),
+	})
+
+	return cmd
+}
+
+func runRewriteModule(ctx context.Context, workspaceName string, options wsm.ModuleRewriteOptions, dryRun, verify, stage bool) error {
+	workspace, err := resolveWorkspace(workspaceName)
+	if err != nil {
+		return err
+	}
+
+	plan, err := wsm.PlanModuleRewrite(workspace, options)
+	if err != nil {
+		return err
+	}
+	output.PrintHeader("Rewriting %s to %s in workspace '%s'", options.Old, options.New, workspace.Name)
+	for path, reason := range plan.Skipped {
+		output.PrintWarning("Skipped %s: %s", relativeTo(workspace.Path, path), reason)
+	}
+	if len(plan.Files) == 0 {
+		output.PrintInfo("No references to %s found", options.Old)
+		return nil
+	}
+
+	changed := map[string][]string{}
+	for _, file := range plan.Files {
+		output.Printf("  %s (%d change(s))\n", relativeTo(workspace.Path, file.Path), file.Changes)
+		// go.work is not part of any repository
+		if file.Repository != "" {
+			changed[file.Repository] = append(changed[file.Repository], file.Path)
+		}
+	}
+	if dryRun {
+		output.Printf("\n")
+		output.PrintInfo("Dry run mode - %d module path(s) in %d file(s) would change", plan.Changes(), len(plan.Files))
+		return nil
+	}
+
+	modified, err := modifiedGoFiles(ctx, workspace, changed)
+	if err != nil {
+		return err
+	}
+	paths := make([]string, 0, len(plan.Files))
+	for _, file := range plan.Files {
+		paths = append(paths, file.Path)
+	}
+	snapshot, err := wsm.SnapshotFiles(paths)
+	if err != nil {
+		return err
+	}
+	if err := wsm.WriteModuleRewrite(workspace, plan); err != nil {
+		if restoreErr := snapshot.Restore(); restoreErr != nil {
+			output.PrintWarning("Failed to revert the rewrite: %v", restoreErr)
+		}
+		return err
+	}
+	output.Printf("\n")
+	output.PrintSuccess("Rewrote %d module path(s) in %d file(s)", plan.Changes(), len(plan.Files))
+
+	// The checksums of the old module were dropped; record those of the new one
+	for _, file := range plan.Files {
+		if filepath.Base(file.Path) != "go.sum" {
+			continue
+		}
+		dir := filepath.Dir(file.Path)
+		if downloadOutput, err := wsm.GoModDownload(ctx, dir); err != nil {
+			output.PrintWarning("Could not update the checksums of %s, run 'go mod tidy' there:\n%s", relativeTo(workspace.Path, dir), downloadOutput)
+		}
+	}
+
+	return verifyAndStageGoChanges(ctx, workspace, plan.Modules, changed, modified, snapshot, verify, stage)
+}
@@ -0,0 +1,136 @@
+package cmds
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewSetupCommand creates the setup command
+func NewSetupCommand() *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "setup [workspace-name]",
+		Short: "Run the workspace and repository setup scripts",
+		Long: `Run the setup scripts of a workspace. Setup scripts only run automatically
+after 'create', 'fork', 'respin' and 'apply' with --setup or setup.enabled in
+config.yaml, since repository scripts come from the cloned repositories.
+
+Scripts are run in this order, stopping at the first failure:
+  <workspace>/.wsm/setup.sh[.tmpl] and <workspace>/.wsm/setup.d/*   (in the workspace directory)
+  <repo>/.wsm/setup.sh[.tmpl] and <repo>/.wsm/setup.d/*             (in each repository)
+
+Scripts whose name ends in .tmpl are rendered as Go templates first, with the
+same context as AGENT.md (e.g. {{ .Name }}, {{ .Port "web" }}, {{ .Repository.Name }}).
+
+Environment:
+  WSM_WORKSPACE, WSM_WORKSPACE_PATH, WSM_BRANCH, WSM_BASE_BRANCH, WSM_REPOS
+  WSM_REPO, WSM_REPO_PATH       for repository scripts
+  variables from <workspace>/.wsm/env and <repo>/.wsm/env (KEY=VALUE lines)
+  WSM_SECRET_<NAME>             secrets from config.yaml and <workspace>/.wsm/secrets.yaml, for
+                                workspace scripts and setup.trusted_repositories only
+
+Secrets configuration:
+
+  setup:
+    secrets:
+      sops_file: ~/secrets/dev.enc.env       # every key, decrypted with sops
+      onepassword:
+        github_token: op://dev/github/token  # read with 'op read'
+      vault:
+        db_password: secret/dev/db#password  # read with 'vault kv get -field'
+
+Examples:
+  # Rerun the setup of the current workspace
+  workspace-manager setup
+
+  # Show the scripts and variables without running anything
+  workspace-manager setup my-feature --dry-run`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspaceName := ""
+			if len(args) > 0 {
+				workspaceName = args[0]
+			}
+			return runSetup(cmd.Context(), workspaceName, dryRun)
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "List the scripts and environment variable names without running them or resolving secrets")
+
+	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion())
+
+	return cmd
+}
+
+func runSetup(ctx context.Context, workspaceName string, dryRun bool) error {
+	workspace, err := resolveWorkspace(workspaceName)
+	if err != nil {
+		return err
+	}
+
+	wm, err := wsm.NewWorkspaceManager()
+	if err != nil {
+		return errors.Wrap(err, "failed to create workspace manager")
+	}
+
+	scripts := wsm.DetectSetupScripts(workspace)
+	if len(scripts) == 0 {
+		output.PrintInfo("No setup scripts in workspace '%s'", workspace.Name)
+		return nil
+	}
+
+	if dryRun {
+		secrets, err := wm.WorkspaceSecrets(workspace)
+		if err != nil {
+			return err
+		}
+		printSetupPlan(workspace, scripts, secrets)
+		return nil
+	}
+
+	if err := wm.RunSetupScripts(ctx, workspace, scripts); err != nil {
+		return err
+	}
+
+	output.PrintSuccess("Setup of workspace '%s' completed", workspace.Name)
+	return nil
+}
+
+func printSetupPlan(workspace *wsm.Workspace, scripts []wsm.SetupScript, secrets wsm.SecretsConfig) {
+	output.PrintHeader("Setup scripts for workspace '%s'", workspace.Name)
+	for _, script := range scripts {
+		name, _ := filepath.Rel(workspace.Path, script.Path)
+		output.Printf("  %s\n", name)
+	}
+
+	env, err := wsm.LoadEnvFile(filepath.Join(workspace.Path, ".wsm", "env"))
+	if err != nil {
+		output.PrintWarning("%v", err)
+		return
+	}
+	if len(env) > 0 {
+		keys := make([]string, 0, len(env))
+		for key := range env {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		output.Printf("\n")
+		output.Printf("Variables from .wsm/env: %s\n", strings.Join(keys, ", "))
+	}
+
+	if variables := secrets.SecretVariables(); len(variables) > 0 {
+		output.Printf("Secrets: %s\n", strings.Join(variables, ", "))
+	}
+	if secrets.SopsFile != "" {
+		output.Printf("Secrets: every key of %s\n", secrets.SopsFile)
+	}
+}
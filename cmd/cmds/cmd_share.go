@@ -0,0 +1,90 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// NewShareCommand creates the share command
+func NewShareCommand() *cobra.Command {
+	var outputPath string
+
+	cmd := &cobra.Command{
+		Use:   "share [workspace-name]",
+		Short: "Print a manifest that recreates the workspace on another machine",
+		Long: `Print the workspace as an apply manifest that lists repositories by remote
+URL and branch instead of local paths. A teammate recreates an equivalent
+workspace from it with:
+
+  workspace-manager apply shared.yaml --clone-root ~/code
+
+Repositories they already have are matched by remote URL, even under another
+name; the others are cloned into the clone root. Detached repositories are
+shared pinned at their commit. Branches or commits that are not pushed are
+reported, since the teammate cannot get them.
+
+Examples:
+  workspace-manager share > shared.yaml
+  workspace-manager share my-feature -o shared.yaml`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := ""
+			if len(args) > 0 {
+				name = args[0]
+			}
+			if outputPath == "" {
+				// stdout carries the manifest; warnings go to stderr
+				output.SetQuiet(true)
+			}
+			return runShare(cmd.Context(), name, outputPath)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputPath, "output", "o", "", "Write the manifest to a file instead of stdout")
+
+	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion())
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"output": carapace.ActionFiles(".yaml", ".yml"),
+	})
+
+	return cmd
+}
+
+func runShare(ctx context.Context, workspaceName, outputPath string) error {
+	workspace, err := resolveWorkspace(workspaceName)
+	if err != nil {
+		return err
+	}
+
+	manifest, warnings, err := wsm.ShareWorkspace(ctx, workspace)
+	if err != nil {
+		return errors.Wrap(err, "failed to describe workspace")
+	}
+
+	data, err := yaml.Marshal(manifest)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode manifest")
+	}
+
+	for _, warning := range warnings {
+		output.PrintWarning("%s", warning)
+	}
+	if outputPath == "" {
+		fmt.Print(string(data))
+		return nil
+	}
+
+	if err := os.WriteFile(outputPath, data, 0644); err != nil {
+		return errors.Wrapf(err, "failed to write %s", outputPath)
+	}
+	output.PrintSuccess("Wrote %s; recreate it with 'workspace-manager apply %s --clone-root <dir>'", outputPath, outputPath)
+	return nil
+}
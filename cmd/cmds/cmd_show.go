@@ -0,0 +1,222 @@
+package cmds
+
+import (
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/spf13/cobra"
+)
+
+// NewShowCommand creates the show command
+func NewShowCommand() *cobra.Command {
+	var asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "show [workspace-name]",
+		Short: "Print the full definition of a workspace",
+		Long: `Print everything wsm knows about a workspace: its repositories with their
+paths, checked out branches and pins, the issues, pull requests and workspaces
+linked to it, its setup scripts and commit hooks, the AGENT.md template and
+agent assets it was created with, and who created it, when and how.
+
+Nothing is changed and git is not run: branches are read from the HEAD files of
+the repositories, the creation from the operation journal. Use 'wsm status' for
+the state of the working trees.
+
+Examples:
+  workspace-manager show my-feature
+  workspace-manager show my-feature --json | jq '.repositories[].branch'`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspaceName := ""
+			if len(args) > 0 {
+				workspaceName = args[0]
+			}
+			return runShow(workspaceName, asJSON)
+		},
+	}
+
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Output the definition as JSON")
+
+	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion())
+
+	return cmd
+}
+
+func runShow(workspaceName string, asJSON bool) error {
+	workspace, err := resolveWorkspace(workspaceName)
+	if err != nil {
+		return err
+	}
+	definition, err := wsm.DescribeWorkspace(workspace)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		return wsm.PrintJSON(definition)
+	}
+
+	printDefinition(definition)
+	return nil
+}
+
+func printDefinition(definition *wsm.WorkspaceDefinition) {
+	workspace := definition.Workspace
+
+	output.PrintHeader("Workspace '%s'", workspace.Name)
+	fmt.Printf("  Path:         %s\n", workspace.Path)
+	if workspace.Branch != "" {
+		fmt.Printf("  Branch:       %s\n", workspace.Branch)
+	}
+	if workspace.BaseBranch != "" {
+		fmt.Printf("  Base Branch:  %s\n", workspace.BaseBranch)
+	}
+	switch definition.Mode {
+	case wsm.WorkspaceModeLinked:
+		fmt.Printf("  Mode:         linked checkouts\n")
+	case wsm.WorkspaceModeClone:
+		fmt.Printf("  Mode:         %s clones\n", workspace.Clone)
+	default:
+		fmt.Printf("  Mode:         worktrees\n")
+	}
+	fmt.Printf("  Go Workspace: %t\n", workspace.GoWorkspace)
+	if len(workspace.BuildManifests) > 0 {
+		fmt.Printf("  Build Files:  %s\n", strings.Join(wsm.BuildManifestFiles(workspace), ", "))
+	}
+	if len(workspace.Labels) > 0 {
+		fmt.Printf("  Labels:       %s\n", wsm.FormatLabels(workspace.Labels))
+	}
+	if workspace.Parent != "" {
+		fmt.Printf("  Parent:       %s\n", workspace.Parent)
+	}
+	if len(workspace.Children) > 0 {
+		fmt.Printf("  Children:     %s\n", strings.Join(workspace.Children, ", "))
+	}
+	if workspace.Container != nil {
+		fmt.Printf("  Container:    %s %s (%s)\n", workspace.Container.Runtime, workspace.Container.Name, workspace.Container.Image)
+	}
+
+	output.PrintHeader("\nProvenance")
+	fmt.Printf("  Created:      %s\n", workspace.Created.Format("2006-01-02 15:04:05"))
+	if entry := definition.Provenance; entry != nil {
+		by := entry.User
+		if entry.Host != "" {
+			by += "@" + entry.Host
+		}
+		if by != "" {
+			fmt.Printf("  By:           %s\n", by)
+		}
+		if entry.Command != "" {
+			fmt.Printf("  Command:      %s\n", entry.Command)
+		}
+		operation := entry.Operation
+		if mode := entry.Parameters["mode"]; mode != "" {
+			operation += " (" + mode + ")"
+		}
+		fmt.Printf("  Operation:    %s\n", operation)
+		if bundle := entry.Parameters["bundle"]; bundle != "" {
+			fmt.Printf("  Bundle:       %s\n", bundle)
+		}
+		if resumed := entry.Parameters["resumed"]; resumed != "" {
+			fmt.Printf("  Resumed:      %s\n", resumed)
+		}
+	} else {
+		fmt.Printf("  By:           unknown (not in the history journal)\n")
+	}
+	if definition.RespunFrom != "" {
+		fmt.Printf("  Respun From:  %s\n", definition.RespunFrom)
+	}
+	if definition.ForkedFrom != "" {
+		fmt.Printf("  Forked From:  %s\n", definition.ForkedFrom)
+	}
+	if workspace.AgentMD != "" {
+		fmt.Printf("  AGENT.md:     %s\n", workspace.AgentMD)
+	}
+	for _, asset := range workspace.AgentAssets {
+		scope := asset.Scope
+		if scope == "" {
+			scope = "workspace"
+		}
+		fmt.Printf("  Agent Asset:  %s -> %s (%s)\n", asset.Source, asset.Target, scope)
+	}
+
+	if len(definition.Repositories) > 0 {
+		output.PrintHeader("\nRepositories")
+		for _, repo := range definition.Repositories {
+			var notes []string
+			if repo.Frozen {
+				notes = append(notes, "frozen")
+			}
+			if repo.Missing {
+				notes = append(notes, "missing")
+			}
+			if len(notes) > 0 {
+				fmt.Printf("  %s (%s)\n", repo.Name, strings.Join(notes, ", "))
+			} else {
+				fmt.Printf("  %s\n", repo.Name)
+			}
+			fmt.Printf("    Path:         %s\n", repo.Path)
+			fmt.Printf("    Source:       %s\n", repo.Source)
+			if repo.RemoteURL != "" {
+				fmt.Printf("    Remote:       %s\n", repo.RemoteURL)
+			}
+			switch {
+			case repo.Pin != "":
+				fmt.Printf("    Pinned At:    %s (detached)\n", shortHash(repo.Pin))
+			case repo.Branch != "":
+				fmt.Printf("    Branch:       %s\n", repo.Branch)
+			}
+			if repo.BranchPoint != "" {
+				fmt.Printf("    Branch Point: %s\n", shortHash(repo.BranchPoint))
+			}
+			if len(repo.Categories) > 0 {
+				fmt.Printf("    Categories:   %s\n", strings.Join(repo.Categories, ", "))
+			}
+		}
+	}
+
+	if len(workspace.Issues) > 0 || len(workspace.AppliedPRs) > 0 {
+		output.PrintHeader("\nLinks")
+		for _, issue := range workspace.Issues {
+			fmt.Printf("  - %s issue %s", issue.Tracker, issue.Ref)
+			if issue.Status != "" {
+				fmt.Printf(" [%s]", issue.Status)
+			}
+			if issue.Summary != "" {
+				fmt.Printf(" %s", issue.Summary)
+			}
+			fmt.Println()
+		}
+		for _, pr := range workspace.AppliedPRs {
+			fmt.Printf("  - %s pull request %s, %s at %s\n", pr.Repository, pr.String(), pr.Method, shortHash(pr.Head))
+		}
+	}
+
+	gitConfig := workspace.GitConfig
+	hasGitConfig := gitConfig != nil && (len(gitConfig.Values) > 0 || len(gitConfig.Repositories) > 0)
+	if len(definition.SetupScripts) > 0 || len(definition.HookSuites) > 0 || hasGitConfig {
+		output.PrintHeader("\nHooks")
+		for _, script := range definition.SetupScripts {
+			fmt.Printf("  - setup script %s\n", relativeTo(workspace.Path, script.Path))
+		}
+		for _, suite := range definition.HookSuites {
+			fmt.Printf("  - %s hooks %s\n", suite.Framework, relativeTo(workspace.Path, filepath.Join(suite.Dir, suite.Config)))
+		}
+		if hasGitConfig {
+			for _, key := range slices.Sorted(maps.Keys(gitConfig.Values)) {
+				fmt.Printf("  - git config %s=%s\n", key, gitConfig.Values[key])
+			}
+			for _, repo := range slices.Sorted(maps.Keys(gitConfig.Repositories)) {
+				for _, key := range slices.Sorted(maps.Keys(gitConfig.Repositories[repo])) {
+					fmt.Printf("  - git config %s=%s in %s\n", key, gitConfig.Repositories[repo][key], repo)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewStatsCommand creates the stats command
+func NewStatsCommand() *cobra.Command {
+	var (
+		since  string
+		base   string
+		format string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "stats [workspace-name]",
+		Short: "Show commit and contribution statistics for the workspace branch",
+		Long: `Aggregate per-repository commit counts, insertions/deletions, files touched
+and authors for the commits of the workspace branch (<base>..HEAD).
+
+Examples:
+  # Stats for the current workspace
+  workspace-manager stats
+
+  # Only the last two weeks, as JSON
+  workspace-manager stats my-feature --since "2 weeks ago" --format json`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspaceName := ""
+			if len(args) > 0 {
+				workspaceName = args[0]
+			}
+			return runStats(cmd.Context(), workspaceName, base, since, format)
+		},
+	}
+
+	cmd.Flags().StringVar(&since, "since", "", "Only count commits more recent than this date (e.g. 2024-01-01, \"2 weeks ago\")")
+	cmd.Flags().StringVar(&base, "base", "", "Base ref (defaults to workspace base branch or origin/main)")
+	cmd.Flags().StringVar(&format, "format", "table", "Output format: table, json")
+
+	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion())
+
+	return cmd
+}
+
+func runStats(ctx context.Context, workspaceName, base, since, format string) error {
+	workspace, err := resolveWorkspace(workspaceName)
+	if err != nil {
+		return err
+	}
+
+	stats, err := wsm.GetWorkspaceStats(ctx, workspace, base, since)
+	if err != nil {
+		return errors.Wrap(err, "failed to collect workspace stats")
+	}
+
+	if format == "json" {
+		return wsm.PrintJSON(stats)
+	}
+
+	header := fmt.Sprintf("Stats: %s (base: %s", stats.Workspace, stats.Base)
+	if stats.Since != "" {
+		header += fmt.Sprintf(", since: %s", stats.Since)
+	}
+	output.PrintHeader("%s)", header)
+
+	printStatsTable(stats)
+	return nil
+}
+
+func printStatsTable(stats *wsm.WorkspaceStats) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer func() {
+		if err := w.Flush(); err != nil {
+			output.LogWarn(
+				fmt.Sprintf("Failed to flush table writer: %v", err),
+				"Failed to flush table writer",
+				"error", err,
+			)
+		}
+	}()
+
+	fmt.Fprintln(w, "REPOSITORY\tCOMMITS\tINSERTIONS\tDELETIONS\tFILES\tAUTHORS")
+	fmt.Fprintln(w, "----------\t-------\t----------\t---------\t-----\t-------")
+
+	rows := make([]wsm.RepositoryStats, 0, len(stats.Repositories)+1)
+	rows = append(rows, stats.Repositories...)
+	rows = append(rows, stats.Total)
+	for _, repo := range rows {
+		authors := "-"
+		if names := repo.AuthorNames(); len(names) > 0 {
+			for i, name := range names {
+				names[i] = fmt.Sprintf("%s (%d)", name, repo.Authors[name])
+			}
+			authors = strings.Join(names, ", ")
+		}
+		fmt.Fprintf(w, "%s\t%d\t+%d\t-%d\t%d\t%s\n",
+			repo.Repository, repo.Commits, repo.Insertions, repo.Deletions, repo.FilesTouched, authors)
+	}
+}
@@ -7,10 +7,12 @@ import (
 	"github.com/go-go-golems/workspace-manager/pkg/wsm"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
-	"text/tabwriter"
+	"time"
 
 	"github.com/carapace-sh/carapace"
+	"github.com/dustin/go-humanize"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
@@ -21,43 +23,138 @@ func NewStatusCommand() *cobra.Command {
 		short     bool
 		untracked bool
 		workspace string
+		watch     bool
+		interval  time.Duration
+		columns   []string
+		sortBy    string
+		fast      bool
+		failOn    []string
+		groupBy   string
 	)
 
 	cmd := &cobra.Command{
 		Use:   "status [workspace-name]",
 		Short: "Show workspace status",
 		Long: `Show the git status of all repositories in a workspace.
-If no workspace name is provided, attempts to detect the current workspace.`,
+If no workspace name is provided, attempts to detect the current workspace.
+
+Columns of the detailed table can be chosen and sorted, e.g.:
+  workspace-manager status --columns repository,branch,ahead,behind --sort "behind desc"
+
+With --group-by tag, the table is split by the tags discovery gave the
+repositories (go, node, docker, ...; see 'list repos --tags'), each group
+headed by its number of dirty repositories and commits ahead and behind. A
+repository with several tags appears in each of their groups.
+
+With --porcelain, the default when stdout is not a terminal and no other
+output flag is given (--porcelain=false keeps the table), one tab-separated
+line is printed per repository:
+  <repository> <branch> <clean|modified|conflict> <ahead> <behind> <staged> <modified> <untracked> <merged> <needs-rebase> <frozen> <encryption-locked>
+
+With --fast, only the branch, ahead/behind counts and whether a repository has
+uncommitted changes are reported, with a single git invocation per repository
+run in parallel - cheap enough for shell prompts and dashboards. With
+--porcelain the fast records are:
+  <repository> <branch> <ahead> <behind> <dirty>
+
+With --fail-on, status exits non-zero when a repository is in one of the
+listed conditions, to gate CI jobs or pre-push hooks:
+  dirty     uncommitted changes (exit 1)
+  behind    behind its upstream (exit 1)
+  conflict  merge conflicts, or diverged from its upstream (exit 2)
+The highest code of the failing conditions is used. Codes are configured
+with status.exit_codes and a default list with status.fail_on in config.yaml.
+Frozen repositories are not checked.
+
+Examples:
+  workspace-manager status --fail-on dirty,conflict
+  workspace-manager status --porcelain --fail-on behind
+  workspace-manager status --fast --porcelain`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			workspaceName := workspace
 			if len(args) > 0 {
 				workspaceName = args[0]
 			}
-			return runStatus(cmd.Context(), workspaceName, short, untracked)
+			if err := wsm.ValidateStatusConditions(failOn); err != nil {
+				return err
+			}
+			opts := tableOptions{columns: columns, sortBy: sortBy, groupBy: groupBy}
+			porcelain := porcelainOutput(cmd, "short", "watch", "columns", "sort", "group-by")
+			if groupBy != "" {
+				if groupBy != "tag" {
+					return errors.Errorf("invalid --group-by '%s': only 'tag' is supported", groupBy)
+				}
+				if short || porcelain || fast {
+					return errors.New("--group-by cannot be combined with --short, --porcelain or --fast")
+				}
+			}
+			if fast {
+				if watch || short || untracked || cmd.Flags().Changed("columns") || cmd.Flags().Changed("sort") {
+					return errors.New("--fast cannot be combined with --watch, --short, --untracked, --columns or --sort")
+				}
+				status, err := runStatusFast(cmd.Context(), workspaceName, porcelain)
+				if err != nil {
+					return err
+				}
+				return gateStatus(cmd, status, failOn)
+			}
+			if watch {
+				return watchStatus(cmd.Context(), workspaceName, short, untracked, opts, interval)
+			}
+
+			var status *wsm.WorkspaceStatus
+			var err error
+			if porcelain {
+				output.SetQuiet(true)
+				status, err = runStatusPorcelain(cmd.Context(), workspaceName)
+			} else {
+				if !short {
+					defer output.StartPager()()
+				}
+				status, err = runStatus(cmd.Context(), workspaceName, short, untracked, opts)
+			}
+			if err != nil {
+				return err
+			}
+			return gateStatus(cmd, status, failOn)
 		},
 	}
 
 	cmd.Flags().BoolVar(&short, "short", false, "Show short status format")
 	cmd.Flags().BoolVar(&untracked, "untracked", false, "Include untracked files")
 	cmd.Flags().StringVar(&workspace, "workspace", "", "Workspace name")
+	cmd.Flags().BoolVar(&watch, "watch", false, "Refresh the status periodically until interrupted")
+	cmd.Flags().DurationVar(&interval, "interval", 5*time.Second, "Refresh interval for --watch")
+	addTableFlags(cmd, &columns, &sortBy, statusColumns)
+	cmd.Flags().Bool("porcelain", false, "Print stable, tab-separated output for scripts (default when stdout is not a terminal)")
+	cmd.Flags().BoolVar(&fast, "fast", false, "Only report branch, ahead/behind and a dirty flag, as fast as possible")
+	cmd.Flags().StringSliceVar(&failOn, "fail-on", nil, "Exit non-zero when a repository is dirty, behind or in conflict (comma-separated)")
+	cmd.Flags().StringVar(&groupBy, "group-by", "", "Split the table into groups with rollup counts: tag")
 
 	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion())
+	carapace.Gen(cmd).FlagCompletion(
+		carapace.ActionMap{
+			"columns":  ColumnCompletion(statusColumns).UniqueList(","),
+			"fail-on":  carapace.ActionValues(wsm.StatusConditions...).UniqueList(","),
+			"group-by": carapace.ActionValues("tag"),
+		},
+	)
 
 	return cmd
 }
 
-func runStatus(ctx context.Context, workspaceName string, short, untracked bool) error {
+func runStatus(ctx context.Context, workspaceName string, short, untracked bool, opts tableOptions) (*wsm.WorkspaceStatus, error) {
 	// If no workspace specified, try to detect current workspace
 	if workspaceName == "" {
 		cwd, err := os.Getwd()
 		if err != nil {
-			return errors.Wrap(err, "failed to get current directory")
+			return nil, errors.Wrap(err, "failed to get current directory")
 		}
 
 		detected, err := detectWorkspace(cwd)
 		if err != nil {
-			return errors.Wrap(err, "failed to detect workspace. Use 'workspace-manager status <workspace-name>' or specify --workspace flag")
+			return nil, errors.Wrap(err, "failed to detect workspace. Use 'workspace-manager status <workspace-name>' or specify --workspace flag")
 		}
 		workspaceName = detected
 	}
@@ -65,144 +162,227 @@ func runStatus(ctx context.Context, workspaceName string, short, untracked bool)
 	// Load workspace
 	workspace, err := loadWorkspace(workspaceName)
 	if err != nil {
-		return errors.Wrapf(err, "failed to load workspace '%s'", workspaceName)
+		return nil, errors.Wrapf(err, "failed to load workspace '%s'", workspaceName)
+	}
+
+	// Child workspaces are reported as part of their parent
+	workspace, err = wsm.ExpandWorkspace(workspace)
+	if err != nil {
+		return nil, err
 	}
 
 	// Get status
+	config, err := wsm.LoadConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load configuration")
+	}
 	checker := wsm.NewStatusChecker()
+	checker.Config = config.Status
 	status, err := checker.GetWorkspaceStatus(ctx, workspace)
 	if err != nil {
-		return errors.Wrap(err, "failed to get workspace status")
+		return nil, errors.Wrap(err, "failed to get workspace status")
 	}
 
 	// Display status
 	if short {
-		return printStatusShort(status, untracked)
+		return status, printStatusShort(status, untracked)
 	}
 
-	return printStatusDetailed(status, untracked)
+	return status, printStatusTable(status, untracked, opts)
 }
 
-func detectWorkspace(cwd string) (string, error) {
-	log.Debug().Str("cwd", cwd).Msg("Starting workspace detection")
+// runStatusPorcelain prints the status of each repository as a stable tab-separated record
+func runStatusPorcelain(ctx context.Context, workspaceName string) (*wsm.WorkspaceStatus, error) {
+	workspace, err := resolveWorkspace(workspaceName)
+	if err != nil {
+		return nil, err
+	}
+	workspace, err = wsm.ExpandWorkspace(workspace)
+	if err != nil {
+		return nil, err
+	}
 
-	// First, try to find a workspace that contains this directory
-	workspaces, err := wsm.LoadWorkspaces()
+	config, err := wsm.LoadConfig()
 	if err != nil {
-		log.Debug().Err(err).Msg("Failed to load workspaces")
-		return "", errors.Wrap(err, "failed to load workspaces")
+		return nil, errors.Wrap(err, "failed to load configuration")
+	}
+	checker := wsm.NewStatusChecker()
+	checker.Config = config.Status
+	status, err := checker.GetWorkspaceStatus(ctx, workspace)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get workspace status")
 	}
 
-	log.Debug().Int("workspaceCount", len(workspaces)).Msg("Loaded workspaces")
+	for _, repoStatus := range status.Repositories {
+		output.PrintPorcelain(
+			repoStatus.Repository.Name,
+			repoStatus.CurrentBranch,
+			getStateString(repoStatus),
+			strconv.Itoa(repoStatus.Ahead),
+			strconv.Itoa(repoStatus.Behind),
+			strconv.Itoa(len(repoStatus.StagedFiles)),
+			strconv.Itoa(len(repoStatus.ModifiedFiles)),
+			strconv.Itoa(len(repoStatus.UntrackedFiles)),
+			strconv.FormatBool(repoStatus.IsMerged),
+			strconv.FormatBool(repoStatus.NeedsRebase),
+			strconv.FormatBool(repoStatus.Frozen),
+			strconv.FormatBool(repoStatus.Encryption.Locked),
+		)
+	}
 
-	// Check if current directory is within any workspace path
-	for _, workspace := range workspaces {
-		log.Debug().
-			Str("workspaceName", workspace.Name).
-			Str("workspacePath", workspace.Path).
-			Msg("Checking workspace")
-
-		// Check if current directory is within or matches workspace path
-		if strings.HasPrefix(cwd, workspace.Path) {
-			output.LogInfo(
-				fmt.Sprintf("Detected workspace: %s", workspace.Name),
-				"Found workspace containing current directory",
-				"workspaceName", workspace.Name,
-				"workspacePath", workspace.Path,
-				"cwd", cwd,
+	return status, nil
+}
+
+// runStatusFast prints one line per repository with its branch, ahead/behind counts and whether it
+// has uncommitted changes
+func runStatusFast(ctx context.Context, workspaceName string, porcelain bool) (*wsm.WorkspaceStatus, error) {
+	// Prompts cannot use the workspace detection notice
+	output.SetQuiet(true)
+	workspace, err := resolveWorkspace(workspaceName)
+	if err != nil {
+		return nil, err
+	}
+	workspace, err = wsm.ExpandWorkspace(workspace)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := wsm.NewStatusChecker().GetFastWorkspaceStatus(ctx, workspace)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get workspace status")
+	}
+
+	for _, repoStatus := range status.Repositories {
+		if porcelain {
+			output.PrintPorcelain(
+				repoStatus.Repository.Name,
+				repoStatus.CurrentBranch,
+				strconv.Itoa(repoStatus.Ahead),
+				strconv.Itoa(repoStatus.Behind),
+				strconv.FormatBool(repoStatus.HasChanges),
 			)
-			return workspace.Name, nil
+			continue
 		}
+		fmt.Printf("%s %s [%s]", getRepositoryStatusSymbol(repoStatus), repoStatus.Repository.Name, orDash(repoStatus.CurrentBranch))
+		if repoStatus.Ahead > 0 || repoStatus.Behind > 0 {
+			fmt.Printf(" ↑%d ↓%d", repoStatus.Ahead, repoStatus.Behind)
+		}
+		if repoStatus.HasChanges {
+			fmt.Print(" *")
+		}
+		fmt.Println()
+	}
 
-		// Also check if any repository in the workspace matches current directory
-		for _, repo := range workspace.Repositories {
-			repoWorktreePath := filepath.Join(workspace.Path, repo.Name)
-			log.Debug().
-				Str("repo", repo.Name).
-				Str("repoWorktreePath", repoWorktreePath).
-				Msg("Checking repository worktree path")
-
-			if strings.HasPrefix(cwd, repoWorktreePath) {
-				output.LogInfo(
-					fmt.Sprintf("Detected workspace: %s (via repo %s)", workspace.Name, repo.Name),
-					"Found workspace via repository worktree path",
-					"workspaceName", workspace.Name,
-					"repo", repo.Name,
-					"repoWorktreePath", repoWorktreePath,
-					"cwd", cwd,
-				)
-				return workspace.Name, nil
-			}
+	return status, nil
+}
+
+// gateStatus fails the command with the configured exit code when a repository is in one of
+// the conditions of --fail-on, or of status.fail_on in config.yaml without the flag
+func gateStatus(cmd *cobra.Command, status *wsm.WorkspaceStatus, failOn []string) error {
+	config, err := wsm.LoadConfig()
+	if err != nil {
+		return errors.Wrap(err, "failed to load configuration")
+	}
+	if !cmd.Flags().Changed("fail-on") {
+		if err := wsm.ValidateStatusConditions(config.Status.FailOn); err != nil {
+			return errors.Wrap(err, "invalid status.fail_on in config.yaml")
 		}
+		failOn = config.Status.FailOn
+	}
+	if len(failOn) == 0 {
+		return nil
 	}
 
-	log.Debug().Msg("No workspace found containing current directory, trying heuristic detection")
+	exitCode, failures := wsm.EvaluateStatusGate(status, failOn, config.Status.ExitCodes)
+	if exitCode == 0 {
+		return nil
+	}
+	for _, failure := range failures {
+		output.PrintWarning("%s: %s (%s)", failure.Repository, failure.Condition, failure.Detail)
+	}
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	return &wsm.ExitCodeError{Code: exitCode}
+}
 
-	// Fallback: Look for workspace configuration file in current directory or parents
-	dir := cwd
+// watchStatus redraws the workspace status every interval until the context is cancelled
+func watchStatus(ctx context.Context, workspaceName string, short, untracked bool, opts tableOptions, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
 	for {
-		log.Debug().Str("dir", dir).Msg("Checking directory for workspace structure")
-
-		// Check if this directory contains repository worktrees
-		entries, err := os.ReadDir(dir)
-		if err != nil {
-			log.Debug().Err(err).Str("dir", dir).Msg("Failed to read directory")
-			return "", err
+		// Clear screen and move cursor home
+		fmt.Print("\033[H\033[2J")
+		if _, err := runStatus(ctx, workspaceName, short, untracked, opts); err != nil {
+			output.PrintError("%v", err)
 		}
+		fmt.Printf("\nUpdated %s (every %s, Ctrl+C to stop)\n", time.Now().Format("15:04:05"), interval)
 
-		// Look for .git files (worktree indicators) and workspace structure
-		gitDirs := 0
-		var gitRepos []string
-		for _, entry := range entries {
-			if entry.IsDir() {
-				gitFile := filepath.Join(dir, entry.Name(), ".git")
-				if stat, err := os.Stat(gitFile); err == nil && stat.Mode().IsRegular() {
-					gitDirs++
-					gitRepos = append(gitRepos, entry.Name())
-				}
-			}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
 		}
+	}
+}
 
-		log.Debug().
-			Str("dir", dir).
-			Int("gitDirs", gitDirs).
-			Strs("gitRepos", gitRepos).
-			Msg("Found git repositories in directory")
-
-		// If we found multiple git worktrees, this might be a workspace
-		if gitDirs >= 2 {
-			// Try to find a workspace that matches this path
-			dirName := filepath.Base(dir)
-			log.Debug().Str("dirName", dirName).Msg("Checking if directory name matches any workspace")
-
-			for _, workspace := range workspaces {
-				if workspace.Name == dirName || strings.Contains(workspace.Path, dirName) {
-					output.LogInfo(
-						fmt.Sprintf("Detected workspace: %s", workspace.Name),
-						"Found workspace by directory name match",
-						"workspaceName", workspace.Name,
-						"dirName", dirName,
-					)
-					return workspace.Name, nil
-				}
-			}
+// detectWorkspace returns the name of the workspace cwd belongs to, see wsm.DetectWorkspace
+func detectWorkspace(cwd string) (string, error) {
+	log.Debug().Str("cwd", cwd).Msg("Starting workspace detection")
+
+	workspaces, err := wsm.LoadWorkspaces()
+	if err != nil {
+		log.Debug().Err(err).Msg("Failed to load workspaces")
+		return "", errors.Wrap(err, "failed to load workspaces")
+	}
+
+	match, err := wsm.DetectWorkspace(context.Background(), nil, cwd, workspaces)
+	if err != nil {
+		log.Debug().Int("workspaceCount", len(workspaces)).Msg("No workspace detected")
+		return "", err
+	}
 
-			// If no exact match, return the directory name as best guess
-			log.Debug().Str("dirName", dirName).Msg("Using directory name as workspace name")
-			return dirName, nil
+	if match.Repository != "" {
+		output.LogInfo(
+			fmt.Sprintf("Detected workspace: %s (via repo %s)", match.Workspace.Name, match.Repository),
+			"Found workspace via repository worktree",
+			"workspaceName", match.Workspace.Name,
+			"repo", match.Repository,
+			"cwd", cwd,
+		)
+	} else {
+		output.LogInfo(
+			fmt.Sprintf("Detected workspace: %s", match.Workspace.Name),
+			"Found workspace containing current directory",
+			"workspaceName", match.Workspace.Name,
+			"workspacePath", match.Workspace.Path,
+			"cwd", cwd,
+		)
+	}
+	return match.Workspace.Name, nil
+}
+
+// resolveWorkspace loads the named workspace, detecting it from the current directory when name is empty
+func resolveWorkspace(name string) (*wsm.Workspace, error) {
+	if name == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get current directory")
 		}
 
-		parent := filepath.Dir(dir)
-		if parent == dir {
-			log.Debug().Msg("Reached filesystem root")
-			break // Reached root
+		detected, err := detectWorkspace(cwd)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to detect workspace. Specify the workspace name explicitly")
 		}
-		dir = parent
+		name = detected
 	}
 
-	log.Debug().Msg("No workspace detected")
-	return "", errors.New("not in a workspace directory")
+	workspace, err := loadWorkspace(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load workspace '%s'", name)
+	}
+
+	return workspace, nil
 }
 
 func loadWorkspace(name string) (*wsm.Workspace, error) {
@@ -222,10 +402,30 @@ func loadWorkspace(name string) (*wsm.Workspace, error) {
 
 func printStatusShort(status *wsm.WorkspaceStatus, includeUntracked bool) error {
 	output.PrintHeader("Workspace: %s (%s)", status.Workspace.Name, status.Overall)
+	if status.Container != "" {
+		output.PrintInfo("Container: %s", wsm.FormatContainer(status.Workspace.Container, status.Container))
+	}
+	if len(status.Workspace.Issues) > 0 {
+		output.PrintInfo("Issues: %s", strings.Join(wsm.IssueRefs(status.Workspace.Issues), ", "))
+	}
+	if len(status.Workspace.Labels) > 0 {
+		output.PrintInfo("Labels: %s", wsm.FormatLabels(status.Workspace.Labels))
+	}
+	if status.Workspace.Linked {
+		output.PrintInfo("Linked: repositories are the checkouts themselves, on their own branches")
+	}
+	printFetchedAt(status)
+	printLocked(status)
 
 	for _, repoStatus := range status.Repositories {
 		symbol := getRepositoryStatusSymbol(repoStatus)
 		fmt.Printf("%s %s", symbol, repoStatus.Repository.Name)
+		if repoStatus.Frozen {
+			fmt.Print(" (frozen)")
+		}
+		if repoStatus.Encryption.Locked {
+			fmt.Printf(" (locked: %s)", repoStatus.Encryption.Tool)
+		}
 
 		if repoStatus.CurrentBranch != "" {
 			fmt.Printf(" [%s]", repoStatus.CurrentBranch)
@@ -256,45 +456,84 @@ func printStatusShort(status *wsm.WorkspaceStatus, includeUntracked bool) error
 	return nil
 }
 
+// printFetchedAt tells how current the ahead/behind counts are, once 'wsm prefetch' has run
+func printFetchedAt(status *wsm.WorkspaceStatus) {
+	if fetchedAt, ok := status.OldestFetch(); ok {
+		output.PrintInfo("Fetched: %s", humanize.Time(fetchedAt))
+	}
+}
+
+// printLocked points at the repositories whose git-crypt or blackbox files are still encrypted,
+// which otherwise show up as binary blobs
+func printLocked(status *wsm.WorkspaceStatus) {
+	var locked []string
+	for _, repoStatus := range status.Repositories {
+		if repoStatus.Encryption.Locked {
+			locked = append(locked, fmt.Sprintf("%s (%s)", repoStatus.Repository.Name, repoStatus.Encryption.Tool))
+		}
+	}
+	if len(locked) > 0 {
+		output.PrintWarning("Encrypted files are still locked in %s; run 'wsm decrypt %s' to unlock them", strings.Join(locked, ", "), status.Workspace.Name)
+	}
+}
+
+var statusColumns = []output.Column{
+	{Name: "repository"},
+	{Name: "branch"},
+	{Name: "status"},
+	{Name: "changes"},
+	{Name: "sync"},
+	{Name: "remotes"},
+	{Name: "merged"},
+	{Name: "rebase"},
+	{Name: "ahead", Hidden: true},
+	{Name: "behind", Hidden: true},
+	{Name: "staged", Hidden: true},
+	{Name: "modified", Hidden: true},
+	{Name: "untracked", Hidden: true},
+	{Name: "path", Hidden: true},
+	{Name: "fetched", Hidden: true},
+}
+
 func printStatusDetailed(status *wsm.WorkspaceStatus, includeUntracked bool) error {
+	return printStatusTable(status, includeUntracked, tableOptions{})
+}
+
+func printStatusTable(status *wsm.WorkspaceStatus, includeUntracked bool, opts tableOptions) error {
 	output.PrintHeader("Workspace: %s", status.Workspace.Name)
 	output.PrintInfo("Path: %s", status.Workspace.Path)
 	output.PrintInfo("Overall Status: %s", status.Overall)
+	if status.Container != "" {
+		output.PrintInfo("Container: %s", wsm.FormatContainer(status.Workspace.Container, status.Container))
+	}
+	if len(status.Workspace.Issues) > 0 {
+		output.PrintInfo("Issues: %s", strings.Join(wsm.IssueRefs(status.Workspace.Issues), ", "))
+	}
+	if len(status.Workspace.Labels) > 0 {
+		output.PrintInfo("Labels: %s", wsm.FormatLabels(status.Workspace.Labels))
+	}
+	if status.Workspace.Linked {
+		output.PrintInfo("Linked: repositories are the checkouts themselves, on their own branches")
+	}
+	printFetchedAt(status)
+	printLocked(status)
 	fmt.Println()
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	defer func() {
-		if err := w.Flush(); err != nil {
-			output.LogWarn(
-				fmt.Sprintf("Failed to flush table writer: %v", err),
-				"Failed to flush table writer",
-				"error", err,
-			)
+	if opts.groupBy != "" {
+		for _, group := range status.GroupByCategory() {
+			output.PrintHeader("%s: %s", group.Category, statusGroupSummary(group))
+			if err := renderTable(statusTable(status, group.Repositories, includeUntracked), opts); err != nil {
+				return err
+			}
+			fmt.Println()
 		}
-	}()
-
-	fmt.Fprintln(w, "REPOSITORY\tBRANCH\tSTATUS\tCHANGES\tSYNC\tMERGED\tREBASE")
-	fmt.Fprintln(w, "----------\t------\t------\t-------\t----\t------\t------")
-
-	for _, repoStatus := range status.Repositories {
-		repoName := repoStatus.Repository.Name
-		branch := repoStatus.CurrentBranch
-		if branch == "" {
-			branch = "-"
+	} else {
+		if err := renderTable(statusTable(status, status.Repositories, includeUntracked), opts); err != nil {
+			return err
 		}
-
-		statusStr := getStatusString(repoStatus)
-		changesStr := getChangesString(repoStatus, includeUntracked)
-		syncStr := getSyncString(repoStatus)
-		mergedStr := getMergedString(repoStatus)
-		rebaseStr := getRebaseString(repoStatus)
-
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-			repoName, branch, statusStr, changesStr, syncStr, mergedStr, rebaseStr)
+		fmt.Println()
 	}
 
-	fmt.Fprintln(w)
-
 	// Show detailed changes if any
 	for _, repoStatus := range status.Repositories {
 		if repoStatus.HasChanges || (includeUntracked && len(repoStatus.UntrackedFiles) > 0) {
@@ -326,6 +565,48 @@ func printStatusDetailed(status *wsm.WorkspaceStatus, includeUntracked bool) err
 	return nil
 }
 
+// statusTable returns the status table rows of the repositories
+func statusTable(status *wsm.WorkspaceStatus, repos []wsm.RepositoryStatus, includeUntracked bool) *output.Table {
+	table := output.NewTable(statusColumns...)
+	for _, repoStatus := range repos {
+		table.AddRow(output.Row{
+			"repository": output.Text(repoStatus.Repository.Name),
+			"branch":     output.Text(repoStatus.CurrentBranch),
+			"status":     output.Text(getStatusString(repoStatus)),
+			"changes":    output.Text(getChangesString(repoStatus, includeUntracked)),
+			"sync":       output.Text(getSyncString(repoStatus)),
+			"remotes":    output.Text(getRemotesString(repoStatus)),
+			"merged":     output.Text(getMergedString(repoStatus)),
+			"rebase":     output.Text(getRebaseString(repoStatus)),
+			"ahead":      output.Int(repoStatus.Ahead),
+			"behind":     output.Int(repoStatus.Behind),
+			"staged":     output.Int(len(repoStatus.StagedFiles)),
+			"modified":   output.Int(len(repoStatus.ModifiedFiles)),
+			"untracked":  output.Int(len(repoStatus.UntrackedFiles)),
+			"path":       output.Text(filepath.Join(status.Workspace.Path, repoStatus.Repository.Name)),
+			"fetched":    output.Time(repoStatus.FetchedAt, time.DateTime),
+		})
+	}
+
+	return table
+}
+
+// statusGroupSummary rolls up the status of a group of repositories
+func statusGroupSummary(group wsm.StatusGroup) string {
+	parts := []string{fmt.Sprintf("%d repositories", len(group.Repositories))}
+	if len(group.Repositories) == 1 {
+		parts[0] = "1 repository"
+	}
+	if group.Dirty > 0 {
+		parts = append(parts, fmt.Sprintf("%d dirty", group.Dirty))
+	}
+	if group.Conflicted > 0 {
+		parts = append(parts, fmt.Sprintf("%d in conflict", group.Conflicted))
+	}
+	parts = append(parts, fmt.Sprintf("↑%d ↓%d", group.Ahead, group.Behind))
+	return strings.Join(parts, ", ")
+}
+
 func getRepositoryStatusSymbol(status wsm.RepositoryStatus) string {
 	if status.HasConflicts {
 		return "⚠️ "
@@ -339,7 +620,8 @@ func getRepositoryStatusSymbol(status wsm.RepositoryStatus) string {
 	return "✅"
 }
 
-func getStatusString(status wsm.RepositoryStatus) string {
+// getStateString returns the state field of porcelain records: clean, modified or conflict
+func getStateString(status wsm.RepositoryStatus) string {
 	if status.HasConflicts {
 		return "conflict"
 	}
@@ -349,6 +631,17 @@ func getStatusString(status wsm.RepositoryStatus) string {
 	return "clean"
 }
 
+func getStatusString(status wsm.RepositoryStatus) string {
+	state := getStateString(status)
+	if status.Frozen {
+		state += " (frozen)"
+	}
+	if status.Encryption.Locked {
+		state += " (locked)"
+	}
+	return state
+}
+
 func getChangesString(status wsm.RepositoryStatus, includeUntracked bool) string {
 	parts := []string{}
 
@@ -376,6 +669,22 @@ func getSyncString(status wsm.RepositoryStatus) string {
 	return fmt.Sprintf("↑%d ↓%d", status.Ahead, status.Behind)
 }
 
+func getRemotesString(status wsm.RepositoryStatus) string {
+	if len(status.Remotes) == 0 {
+		return "-"
+	}
+
+	parts := make([]string, len(status.Remotes))
+	for i, remote := range status.Remotes {
+		if remote.Ahead == 0 && remote.Behind == 0 {
+			parts[i] = fmt.Sprintf("%s ✓", remote.Ref)
+		} else {
+			parts[i] = fmt.Sprintf("%s ↑%d ↓%d", remote.Ref, remote.Ahead, remote.Behind)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
 func getMergedString(status wsm.RepositoryStatus) string {
 	if status.IsMerged {
 		return "✓"
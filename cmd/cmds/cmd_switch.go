@@ -0,0 +1,110 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewSwitchCommand creates the switch command
+func NewSwitchCommand() *cobra.Command {
+	var (
+		workspaceName string
+		base          string
+		force         bool
+		dryRun        bool
+		format        string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "switch <branch>",
+		Short: "Move every repository of a workspace to another branch",
+		Long: `Switch every repository worktree of the workspace to another branch and make
+it the workspace branch, so one workspace can be reused for the next feature
+instead of being recreated.
+
+Branches that do not exist yet are created from origin/<branch> when the remote
+has it, and from the base otherwise: --base, the base branch of the workspace,
+or the branch checked out in the source repository. Repositories with
+uncommitted changes stop the switch unless --force is given.
+
+If a repository cannot be switched, the repositories already switched are moved
+back to their previous branch.
+
+Examples:
+  # Start the next feature in the current workspace
+  workspace-manager switch task/next-feature
+
+  # Create the branches from a release branch
+  workspace-manager switch fix/hotfix --base origin/release-1.2 --workspace my-feature`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSwitch(cmd.Context(), workspaceName, args[0], base, force, dryRun, format)
+		},
+	}
+
+	cmd.Flags().StringVar(&workspaceName, "workspace", "", "Workspace name (default: detected from the current directory)")
+	cmd.Flags().StringVar(&base, "base", "", "Ref to create missing branches from (default: the workspace base branch)")
+	cmd.Flags().BoolVar(&force, "force", false, "Switch even when repositories have uncommitted changes")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be done without switching")
+	cmd.Flags().StringVar(&format, "format", "text", "Output format of the plan: text, json")
+
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"workspace": WorkspaceNameCompletion(),
+	})
+
+	return cmd
+}
+
+func runSwitch(ctx context.Context, workspaceName, branch, base string, force, dryRun bool, format string) error {
+	workspace, err := resolveWorkspace(workspaceName)
+	if err != nil {
+		return err
+	}
+
+	wm, err := wsm.NewWorkspaceManager()
+	if err != nil {
+		return errors.Wrap(err, "failed to create workspace manager")
+	}
+
+	steps, err := wm.PlanSwitch(ctx, workspace, branch, base, force)
+	if err != nil {
+		return err
+	}
+
+	if format == "json" && dryRun {
+		if steps == nil {
+			steps = []wsm.SwitchStep{}
+		}
+		return wsm.PrintJSON(steps)
+	}
+
+	output.PrintHeader("Switching workspace '%s' to branch '%s'", workspace.Name, branch)
+	for _, step := range steps {
+		detail := fmt.Sprintf("%s -> %s", step.From, branch)
+		switch step.Action {
+		case wsm.SwitchCurrent:
+			detail = "already on " + branch
+		case wsm.SwitchTrack, wsm.SwitchCreate:
+			detail += fmt.Sprintf(" (from %s)", step.Base)
+		}
+		output.Printf("  %s %s: %s\n", output.InfoStyle.Render(step.Action), step.Repository, detail)
+	}
+	output.Printf("\n")
+
+	if dryRun {
+		output.PrintInfo("Dry run mode - no branches were switched")
+		return nil
+	}
+
+	if err := wm.ApplySwitch(ctx, workspace, branch, steps); err != nil {
+		return err
+	}
+	output.PrintSuccess("Workspace '%s' is now on branch '%s'", workspace.Name, branch)
+	return nil
+}
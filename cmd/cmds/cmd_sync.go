@@ -6,7 +6,12 @@ import (
 	"github.com/go-go-golems/workspace-manager/pkg/output"
 	"github.com/go-go-golems/workspace-manager/pkg/wsm"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
 	"text/tabwriter"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
@@ -17,7 +22,18 @@ func NewSyncCommand() *cobra.Command {
 		Use:   "sync",
 		Short: "Synchronize workspace repositories",
 		Long: `Synchronize all repositories in the workspace with their remotes.
-Supports pulling latest changes and pushing local commits.`,
+Supports pulling latest changes and pushing local commits.
+
+The fetch of each pull and each push can be bounded by a timeout
+(timeouts.fetch and timeouts.push in config.yaml, with per-repository
+overrides), or by --timeout; there is none by default. The merge or rebase of a
+pull is never interrupted. A repository that times out is reported and the
+others are synced regardless; on Ctrl+C, the repositories not reached yet are
+reported as cancelled.
+
+With --porcelain, the default when stdout is not a terminal (--porcelain=false
+keeps the human output), one tab-separated line is printed per repository:
+  <repository> <ok|failed|conflict|timeout|cancelled> <pulled> <pushed> <ahead> <behind> <error>`,
 	}
 
 	cmd.AddCommand(
@@ -26,23 +42,38 @@ Supports pulling latest changes and pushing local commits.`,
 		NewSyncAllCommand(),
 	)
 
+	cmd.PersistentFlags().Bool("skip-preflight", false, "Skip the remote access preflight check")
+	cmd.PersistentFlags().Bool("porcelain", false, "Print stable, tab-separated output for scripts (default when stdout is not a terminal)")
+	cmd.PersistentFlags().Duration("timeout", 0, "Time the fetch of each pull and each push may take per repository (default: timeouts.fetch and timeouts.push, else none)")
+
 	return cmd
 }
 
 func NewSyncAllCommand() *cobra.Command {
 	var (
-		pull   bool
-		push   bool
-		rebase bool
-		dryRun bool
+		pull      bool
+		push      bool
+		rebase    bool
+		dryRun    bool
+		autoStash bool
 	)
 
 	cmd := &cobra.Command{
 		Use:   "all",
 		Short: "Sync all repositories (pull and push)",
-		Long:  "Synchronize all repositories by pulling latest changes and pushing local commits.",
+		Long: `Synchronize all repositories by pulling latest changes and pushing local commits.
+
+With --autostash, uncommitted changes are stashed before pulling and restored
+afterwards. If restoring conflicts, the conflicts are left in the worktree and
+the stash is kept until they are resolved.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runSyncAll(cmd.Context(), pull, push, rebase, dryRun)
+			skipPreflight, _ := cmd.Flags().GetBool("skip-preflight")
+			porcelain := porcelainOutput(cmd)
+			if porcelain {
+				output.SetQuiet(true)
+			}
+			timeout, _ := cmd.Flags().GetDuration("timeout")
+			return runSyncAll(cmd.Context(), pull, push, rebase, dryRun, autoStash, skipPreflight, porcelain, timeout)
 		},
 	}
 
@@ -50,27 +81,40 @@ func NewSyncAllCommand() *cobra.Command {
 	cmd.Flags().BoolVar(&push, "push", true, "Push local commits")
 	cmd.Flags().BoolVar(&rebase, "rebase", false, "Use rebase when pulling")
 	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be done")
+	cmd.Flags().BoolVar(&autoStash, "autostash", false, "Stash uncommitted changes before pulling and restore them afterwards")
 
 	return cmd
 }
 
 func NewSyncPullCommand() *cobra.Command {
 	var (
-		rebase bool
-		dryRun bool
+		rebase    bool
+		dryRun    bool
+		autoStash bool
 	)
 
 	cmd := &cobra.Command{
 		Use:   "pull",
 		Short: "Pull latest changes from all repositories",
-		Long:  "Pull latest changes from remote repositories in the workspace.",
+		Long: `Pull latest changes from remote repositories in the workspace.
+
+With --autostash, uncommitted changes are stashed before pulling and restored
+afterwards. If restoring conflicts, the conflicts are left in the worktree and
+the stash is kept until they are resolved.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runSyncPull(cmd.Context(), rebase, dryRun)
+			skipPreflight, _ := cmd.Flags().GetBool("skip-preflight")
+			porcelain := porcelainOutput(cmd)
+			if porcelain {
+				output.SetQuiet(true)
+			}
+			timeout, _ := cmd.Flags().GetDuration("timeout")
+			return runSyncPull(cmd.Context(), rebase, dryRun, autoStash, skipPreflight, porcelain, timeout)
 		},
 	}
 
 	cmd.Flags().BoolVar(&rebase, "rebase", false, "Use rebase instead of merge")
 	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be done")
+	cmd.Flags().BoolVar(&autoStash, "autostash", false, "Stash uncommitted changes before pulling and restore them afterwards")
 
 	return cmd
 }
@@ -83,7 +127,13 @@ func NewSyncPushCommand() *cobra.Command {
 		Short: "Push local commits from all repositories",
 		Long:  "Push local commits to remote repositories in the workspace.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runSyncPush(cmd.Context(), dryRun)
+			skipPreflight, _ := cmd.Flags().GetBool("skip-preflight")
+			porcelain := porcelainOutput(cmd)
+			if porcelain {
+				output.SetQuiet(true)
+			}
+			timeout, _ := cmd.Flags().GetDuration("timeout")
+			return runSyncPush(cmd.Context(), dryRun, skipPreflight, porcelain, timeout)
 		},
 	}
 
@@ -92,18 +142,23 @@ func NewSyncPushCommand() *cobra.Command {
 	return cmd
 }
 
-func runSyncAll(ctx context.Context, pull, push, rebase, dryRun bool) error {
-	workspace, err := detectCurrentWorkspace()
+func runSyncAll(ctx context.Context, pull, push, rebase, dryRun, autoStash, skipPreflight, porcelain bool, timeout time.Duration) error {
+	workspace, err := detectSyncWorkspace()
 	if err != nil {
-		return errors.Wrap(err, "failed to detect current workspace")
+		return err
+	}
+
+	if err := preflightGate(ctx, workspace, "origin", push, skipPreflight || dryRun); err != nil {
+		return err
 	}
 
 	syncOps := wsm.NewSyncOperations(workspace)
 	options := &wsm.SyncOptions{
-		Pull:   pull,
-		Push:   push,
-		Rebase: rebase,
-		DryRun: dryRun,
+		Pull:      pull,
+		Push:      push,
+		Rebase:    rebase,
+		DryRun:    dryRun,
+		AutoStash: autoStash,
 	}
 
 	output.PrintHeader("Synchronizing workspace: %s", workspace.Name)
@@ -111,26 +166,31 @@ func runSyncAll(ctx context.Context, pull, push, rebase, dryRun bool) error {
 		output.PrintInfo("Dry run mode - no changes will be made")
 	}
 
-	results, err := syncOps.SyncWorkspace(ctx, options)
+	results, err := syncWithProgress(ctx, workspace, syncOps, options, porcelain, timeout)
 	if err != nil {
 		return errors.Wrap(err, "sync failed")
 	}
 
-	return printSyncResults(results, dryRun)
+	return printSyncResults(results, dryRun, porcelain)
 }
 
-func runSyncPull(ctx context.Context, rebase, dryRun bool) error {
-	workspace, err := detectCurrentWorkspace()
+func runSyncPull(ctx context.Context, rebase, dryRun, autoStash, skipPreflight, porcelain bool, timeout time.Duration) error {
+	workspace, err := detectSyncWorkspace()
 	if err != nil {
-		return errors.Wrap(err, "failed to detect current workspace")
+		return err
+	}
+
+	if err := preflightGate(ctx, workspace, "origin", false, skipPreflight || dryRun); err != nil {
+		return err
 	}
 
 	syncOps := wsm.NewSyncOperations(workspace)
 	options := &wsm.SyncOptions{
-		Pull:   true,
-		Push:   false,
-		Rebase: rebase,
-		DryRun: dryRun,
+		Pull:      true,
+		Push:      false,
+		Rebase:    rebase,
+		DryRun:    dryRun,
+		AutoStash: autoStash,
 	}
 
 	output.PrintHeader("Pulling changes for workspace: %s", workspace.Name)
@@ -138,18 +198,22 @@ func runSyncPull(ctx context.Context, rebase, dryRun bool) error {
 		output.PrintInfo("Dry run mode - no changes will be made")
 	}
 
-	results, err := syncOps.SyncWorkspace(ctx, options)
+	results, err := syncWithProgress(ctx, workspace, syncOps, options, porcelain, timeout)
 	if err != nil {
 		return errors.Wrap(err, "pull failed")
 	}
 
-	return printSyncResults(results, dryRun)
+	return printSyncResults(results, dryRun, porcelain)
 }
 
-func runSyncPush(ctx context.Context, dryRun bool) error {
-	workspace, err := detectCurrentWorkspace()
+func runSyncPush(ctx context.Context, dryRun, skipPreflight, porcelain bool, timeout time.Duration) error {
+	workspace, err := detectSyncWorkspace()
 	if err != nil {
-		return errors.Wrap(err, "failed to detect current workspace")
+		return err
+	}
+
+	if err := preflightGate(ctx, workspace, "origin", true, skipPreflight || dryRun); err != nil {
+		return err
 	}
 
 	syncOps := wsm.NewSyncOperations(workspace)
@@ -165,31 +229,97 @@ func runSyncPush(ctx context.Context, dryRun bool) error {
 		output.PrintInfo("Dry run mode - no changes will be made")
 	}
 
-	results, err := syncOps.SyncWorkspace(ctx, options)
+	results, err := syncWithProgress(ctx, workspace, syncOps, options, porcelain, timeout)
 	if err != nil {
 		return errors.Wrap(err, "push failed")
 	}
 
-	return printSyncResults(results, dryRun)
+	return printSyncResults(results, dryRun, porcelain)
+}
+
+// syncWithProgress runs the sync with a progress bar per repository on terminals; dry runs and
+// porcelain output stay plain. Interrupting it reports the repositories synced so far.
+func syncWithProgress(ctx context.Context, workspace *wsm.Workspace, syncOps *wsm.SyncOperations, options *wsm.SyncOptions, porcelain bool, timeout time.Duration) ([]wsm.SyncResult, error) {
+	wm, err := wsm.NewWorkspaceManager()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create workspace manager")
+	}
+	syncOps.Timeouts = wm.Config().Timeouts.WithTimeout(wsm.OperationFetch, timeout).WithTimeout(wsm.OperationPush, timeout)
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if !options.DryRun && !porcelain {
+		var names []string
+		for _, repo := range workspace.ActiveRepositories() {
+			names = append(names, repo.Name)
+		}
+		if progress := output.StartProgress(os.Stdout, "Syncing "+workspace.Name, names); progress != nil {
+			syncOps.Progress = progress
+			defer progress.Stop()
+		}
+	}
+	results, err := syncOps.SyncWorkspace(ctx, options)
+	if err == nil && !options.DryRun {
+		notifySyncFailures(ctx, wm, workspace, results)
+	}
+	return results, err
+}
+
+// notifySyncFailures sends sync.failed to the webhooks when repositories could not be synced.
+// Interrupted syncs are not failures.
+func notifySyncFailures(ctx context.Context, wm *wsm.WorkspaceManager, workspace *wsm.Workspace, results []wsm.SyncResult) {
+	var failed []string
+	event := wsm.Event{Type: wsm.EventSyncFailed, Workspace: workspace.Name, Branch: workspace.Branch}
+	for _, result := range results {
+		if (result.Success && !result.Conflicts) || result.Cancelled {
+			continue
+		}
+		reason := result.Error
+		switch {
+		case result.Conflicts:
+			reason = "conflicts"
+		case result.TimedOut:
+			reason = "timed out"
+		case reason == "":
+			reason = "failed"
+		}
+		failed = append(failed, fmt.Sprintf("%s (%s)", result.Repository, reason))
+		event.Repository = result.Repository
+		event.Failed++
+	}
+	if len(failed) == 0 {
+		return
+	}
+	if event.Failed > 1 {
+		event.Repository = ""
+	}
+	event.Message = fmt.Sprintf("sync of %s failed for %s", workspace.Name, strings.Join(failed, ", "))
+	// The webhooks are still notified when the sync was interrupted
+	wm.Notify(context.WithoutCancel(ctx), event)
+}
+
+// detectSyncWorkspace detects the current workspace and includes the repositories of its children
+func detectSyncWorkspace() (*wsm.Workspace, error) {
+	workspace, err := detectCurrentWorkspace()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to detect current workspace")
+	}
+	return wsm.ExpandWorkspace(workspace)
 }
 
-func printSyncResults(results []wsm.SyncResult, dryRun bool) error {
+func printSyncResults(results []wsm.SyncResult, dryRun, porcelain bool) error {
+	if porcelain {
+		printSyncPorcelain(results)
+		return nil
+	}
+
 	if len(results) == 0 {
 		output.PrintInfo("No repositories to sync.")
 		return nil
 	}
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	defer func() {
-		if err := w.Flush(); err != nil {
-			output.LogWarn(
-				fmt.Sprintf("Failed to flush table writer: %v", err),
-				"Failed to flush table writer",
-				"error", err,
-			)
-		}
-	}()
-
 	fmt.Fprintln(w, "\nREPOSITORY\tSTATUS\tPULL\tPUSH\tBEFORE\tAFTER\tERROR")
 	fmt.Fprintln(w, "----------\t------\t----\t----\t------\t-----\t-----")
 
@@ -239,6 +369,9 @@ func printSyncResults(results []wsm.SyncResult, dryRun bool) error {
 	}
 
 	fmt.Fprintln(w)
+	if err := w.Flush(); err != nil {
+		return errors.Wrap(err, "failed to flush table writer")
+	}
 
 	// Summary
 	output.PrintSuccess("Summary: %d/%d repositories synced successfully", successCount, len(results))
@@ -246,6 +379,62 @@ func printSyncResults(results []wsm.SyncResult, dryRun bool) error {
 		output.PrintWarning("⚠️  %d repositories have conflicts", conflictCount)
 		output.PrintInfo("Resolve conflicts manually and run sync again.")
 	}
+	var timedOut []string
+	cancelled := 0
+	for _, result := range results {
+		if result.TimedOut {
+			timedOut = append(timedOut, result.Repository)
+		}
+		if result.Cancelled {
+			cancelled++
+		}
+	}
+	printTimedOut(timedOut, wsm.OperationFetch, wsm.OperationPush)
+	if cancelled > 0 {
+		output.PrintWarning("Interrupted: %d repositories were not synced", cancelled)
+	}
+	for _, result := range results {
+		if result.Stash != "" {
+			printKeptStash(result.Repository, result.Stash, result.Pulled && result.Conflicts)
+		}
+	}
 
 	return nil
 }
+
+// printKeptStash tells the user how to get back the changes of an autostash that was not
+// restored. restoreConflicted is set when the stash was applied with conflicts.
+func printKeptStash(repository, stash string, restoreConflicted bool) {
+	short := (&wsm.Stash{Commit: stash}).Short()
+	if restoreConflicted {
+		output.PrintWarning("%s: restoring stashed changes conflicted; resolve the conflicts, then drop stash %s (see 'git stash list')", repository, short)
+		return
+	}
+	output.PrintWarning("%s: local changes are kept in stash %s; once the repository is clean, restore them with 'git stash apply %s' and drop the stash", repository, short, short)
+}
+
+func printSyncPorcelain(results []wsm.SyncResult) {
+	for _, result := range results {
+		state := "ok"
+		switch {
+		case result.Conflicts:
+			state = "conflict"
+		case result.TimedOut:
+			state = "timeout"
+		case result.Cancelled:
+			state = "cancelled"
+		case !result.Success:
+			state = "failed"
+		}
+
+		output.PrintPorcelain(
+			result.Repository,
+			state,
+			strconv.FormatBool(result.Pulled),
+			strconv.FormatBool(result.Pushed),
+			strconv.Itoa(result.AheadAfter),
+			strconv.Itoa(result.BehindAfter),
+			result.Error,
+		)
+	}
+}
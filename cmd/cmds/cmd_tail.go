@@ -0,0 +1,137 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"regexp"
+	"syscall"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewTailCommand creates the tail command
+func NewTailCommand() *cobra.Command {
+	var (
+		globs      []string
+		repos      []string
+		grep       string
+		lines      int
+		noFollow   bool
+		noServices bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "tail [workspace-name]",
+		Short: "Follow log files across all repositories in one stream",
+		Long: `Tail the files matching --glob in every repository worktree, and the logs of
+the services started with 'workspace-manager up', as one stream where each line
+is prefixed with its repository (and file, when a repository has several) in
+its own color.
+
+Globs are relative to each worktree; globs without a slash match file names at
+any depth and ** matches any number of directories. .git and node_modules are
+not searched. Files created later are picked up (the worktrees are searched again
+after 2s, backing off to once a minute while no new file shows up), and
+truncated or rotated files are read again from their start.
+
+Examples:
+  # Follow every *.log file of the current workspace
+  workspace-manager tail
+
+  # Only the logs/ directories of the API and worker, showing errors
+  workspace-manager tail --glob 'logs/*.log' --repos api,worker --grep 'ERROR|panic'`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspaceName := ""
+			if len(args) > 0 {
+				workspaceName = args[0]
+			}
+			opts := wsm.TailOptions{
+				Globs:        globs,
+				Repositories: repos,
+				Services:     !noServices,
+				Lines:        lines,
+				Follow:       !noFollow,
+			}
+			if grep != "" {
+				pattern, err := regexp.Compile(grep)
+				if err != nil {
+					return errors.Wrapf(err, "invalid --grep pattern %q", grep)
+				}
+				opts.Pattern = pattern
+			}
+			return runTail(cmd.Context(), workspaceName, opts)
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&globs, "glob", []string{wsm.DefaultTailGlob}, "Files to tail, relative to each repository (repeatable)")
+	cmd.Flags().StringSliceVar(&repos, "repos", nil, "Only tail files of these repositories (comma-separated)")
+	cmd.Flags().StringVar(&grep, "grep", "", "Only show lines matching this regular expression")
+	cmd.Flags().IntVarP(&lines, "lines", "n", 10, "Number of lines of each file to show first")
+	cmd.Flags().BoolVar(&noFollow, "no-follow", false, "Print the last lines and exit")
+	cmd.Flags().BoolVar(&noServices, "no-services", false, "Do not include the logs of the workspace services")
+
+	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion())
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"repos": WorkspaceRepositoryCompletion().UniqueList(","),
+	})
+
+	return cmd
+}
+
+func runTail(ctx context.Context, workspaceName string, opts wsm.TailOptions) error {
+	workspace, err := resolveWorkspace(workspaceName)
+	if err != nil {
+		return err
+	}
+	for _, repo := range opts.Repositories {
+		if _, ok := wsm.FindRepository(workspace, repo); !ok {
+			return errors.Errorf("repository '%s' is not in workspace '%s'", repo, workspace.Name)
+		}
+	}
+
+	files, err := wsm.FindTailFiles(workspace, opts)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 && !opts.Follow {
+		output.PrintInfo("No files match %v", opts.Globs)
+		return nil
+	}
+	if opts.Follow {
+		output.PrintHeader("Tailing %d files of workspace '%s' (Ctrl+C to stop)", len(files), workspace.Name)
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Labels get their color and width as they appear, files created later included
+	prefixes := map[string]string{}
+	width := 0
+	for _, file := range files {
+		width = max(width, len(file.Label))
+	}
+	return wsm.TailWorkspace(ctx, workspace, opts, func(line wsm.TailLine) {
+		prefix, ok := prefixes[line.File.Label]
+		if !ok {
+			width = max(width, len(line.File.Label))
+			style := lipgloss.NewStyle().Foreground(serviceColors[len(prefixes)%len(serviceColors)])
+			prefix = style.Render(fmt.Sprintf("%-*s |", width, line.File.Label))
+			prefixes[line.File.Label] = prefix
+		}
+		text := line.Line
+		if opts.Pattern != nil {
+			text = opts.Pattern.ReplaceAllStringFunc(text, func(match string) string {
+				return output.WarningStyle.Render(match)
+			})
+		}
+		fmt.Printf("%s %s\n", prefix, text)
+	})
+}
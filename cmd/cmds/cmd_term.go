@@ -0,0 +1,174 @@
+package cmds
+
+import (
+	"context"
+	"os"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewTermCommand creates the term command
+func NewTermCommand() *cobra.Command {
+	var (
+		backend  string
+		overview bool
+		dryRun   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "term [workspace-name]",
+		Short: "Open terminal tabs for each repository of a workspace",
+		Long: `Open one terminal tab per repository (cwd set to its worktree) in the running
+terminal emulator, as an alternative to 'wsm tmux' for users not running tmux.
+
+Supported backends:
+- kitty:   uses kitty remote control (requires allow_remote_control in kitty.conf)
+- wezterm: uses 'wezterm cli spawn'
+- iterm2:  uses AppleScript, optionally with a configured iTerm2 profile
+
+With --host, the workspace is looked up on the remote machine and every tab
+runs 'ssh -t' into its worktree there.
+
+Defaults come from the term section in config.yaml:
+
+  term:
+    backend: auto
+    overview: true
+    iterm2_profile: Workspace
+
+Examples:
+  # Open tabs in the detected terminal
+  workspace-manager term
+
+  # Force the WezTerm backend and add a status overview tab
+  workspace-manager term my-feature --backend wezterm --overview
+
+  # Open local tabs attached to a workspace on a dev server
+  workspace-manager --host dev1 term my-feature`,
+		Args:        cobra.MaximumNArgs(1),
+		Annotations: map[string]string{localAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspaceName := ""
+			if len(args) > 0 {
+				workspaceName = args[0]
+			}
+
+			wm, err := wsm.NewWorkspaceManager()
+			if err != nil {
+				return errors.Wrap(err, "failed to create workspace manager")
+			}
+
+			config := wm.Config().Term
+			if cmd.Flags().Changed("backend") {
+				config.Backend = backend
+			}
+			if cmd.Flags().Changed("overview") {
+				config.Overview = overview
+			}
+
+			hostName, host, err := remoteHost(cmd)
+			if err != nil {
+				return err
+			}
+			if hostName != "" {
+				return runRemoteTerm(cmd.Context(), hostName, host, workspaceName, config, dryRun)
+			}
+
+			return runTerm(cmd.Context(), workspaceName, config, dryRun)
+		},
+	}
+
+	cmd.Flags().StringVar(&backend, "backend", wsm.TermBackendAuto, "Terminal backend (auto, kitty, wezterm, iterm2)")
+	cmd.Flags().BoolVar(&overview, "overview", false, "Add an overview tab running 'wsm status --watch'")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show the tabs that would be opened")
+
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"backend": carapace.ActionValues(wsm.TermBackendAuto, wsm.TermBackendKitty, wsm.TermBackendWezTerm, wsm.TermBackendITerm2),
+	})
+	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion())
+
+	return cmd
+}
+
+func runTerm(ctx context.Context, workspaceName string, config wsm.TermConfig, dryRun bool) error {
+	if workspaceName == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return errors.Wrap(err, "failed to get current directory")
+		}
+
+		detected, err := detectWorkspace(cwd)
+		if err != nil {
+			return errors.Wrap(err, "failed to detect workspace. Use 'workspace-manager term <workspace-name>'")
+		}
+		workspaceName = detected
+	}
+
+	workspace, err := loadWorkspace(workspaceName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load workspace '%s'", workspaceName)
+	}
+
+	return openTermTabs(ctx, workspace, wsm.NewTermSession(workspace, config).Windows, config, dryRun)
+}
+
+// runRemoteTerm opens local tabs that ssh into the worktrees of a workspace on a remote host
+func runRemoteTerm(ctx context.Context, hostName string, host wsm.RemoteHost, workspaceName string, config wsm.TermConfig, dryRun bool) error {
+	if workspaceName == "" {
+		return errors.Errorf("a workspace name is required with --host: 'workspace-manager --host %s term <workspace-name>'", hostName)
+	}
+
+	workspace, err := host.LoadRemoteWorkspace(ctx, workspaceName)
+	if err != nil {
+		return err
+	}
+
+	// The tabs start locally in the home directory and change to the worktree on the remote side
+	localDir, err := os.UserHomeDir()
+	if err != nil {
+		return errors.Wrap(err, "failed to get home directory")
+	}
+
+	var windows []wsm.SessionWindow
+	for _, window := range wsm.NewTermSession(workspace, config).Windows {
+		windows = append(windows, wsm.SessionWindow{
+			Name:    window.Name,
+			Dir:     localDir,
+			Command: host.ShellCommand(window.Dir, window.Command),
+		})
+	}
+
+	return openTermTabs(ctx, workspace, windows, config, dryRun)
+}
+
+func openTermTabs(ctx context.Context, workspace *wsm.Workspace, windows []wsm.SessionWindow, config wsm.TermConfig, dryRun bool) error {
+	if dryRun {
+		output.PrintHeader("Terminal tabs for %s", workspace.Name)
+		for _, window := range windows {
+			if window.Command != "" {
+				output.Printf("  %s (%s): %s\n", window.Name, window.Dir, window.Command)
+			} else {
+				output.Printf("  %s (%s)\n", window.Name, window.Dir)
+			}
+		}
+		return nil
+	}
+
+	backend, err := wsm.NewTerminalBackend(config)
+	if err != nil {
+		return err
+	}
+
+	for _, window := range windows {
+		if err := backend.OpenTab(ctx, window); err != nil {
+			return errors.Wrapf(err, "failed to open %s tab for '%s'", backend.Name(), window.Name)
+		}
+	}
+
+	output.PrintSuccess("Opened %d %s tabs for workspace '%s'", len(windows), backend.Name(), workspace.Name)
+	return nil
+}
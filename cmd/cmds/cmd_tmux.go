@@ -0,0 +1,135 @@
+package cmds
+
+import (
+	"context"
+	"os"
+	"os/exec"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewTmuxCommand creates the tmux command
+func NewTmuxCommand() *cobra.Command {
+	var (
+		perRepo  bool
+		overview bool
+		noAttach bool
+		dryRun   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "tmux [workspace-name]",
+		Short: "Open a tmux session for a workspace",
+		Long: `Create (or attach to) a tmux session rooted at the workspace directory.
+
+With --per-repo, the session gets one window per repository, named after the
+repository and opened in its worktree. With --overview, a first window runs
+'wsm status --watch' in the workspace root.
+
+Defaults for these options come from the tmux profile in config.yaml:
+
+  tmux:
+    per_repo_windows: true
+    overview: true
+    overview_command: "wsm status --watch"
+
+Examples:
+  # Open a single-window session for the current workspace
+  workspace-manager tmux
+
+  # One window per repository plus a status overview
+  workspace-manager tmux my-feature --per-repo --overview`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspaceName := ""
+			if len(args) > 0 {
+				workspaceName = args[0]
+			}
+
+			wm, err := wsm.NewWorkspaceManager()
+			if err != nil {
+				return errors.Wrap(err, "failed to create workspace manager")
+			}
+
+			profile := wm.Config().Tmux
+			if cmd.Flags().Changed("per-repo") {
+				profile.PerRepoWindows = perRepo
+			}
+			if cmd.Flags().Changed("overview") {
+				profile.Overview = overview
+			}
+
+			return runTmux(cmd.Context(), workspaceName, profile, noAttach, dryRun)
+		},
+	}
+
+	cmd.Flags().BoolVar(&perRepo, "per-repo", false, "Create one window per repository")
+	cmd.Flags().BoolVar(&overview, "overview", false, "Add an overview window running 'wsm status --watch' (with --per-repo)")
+	cmd.Flags().BoolVar(&noAttach, "no-attach", false, "Create the session without attaching to it")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show the session layout without creating it")
+
+	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion())
+
+	return cmd
+}
+
+func runTmux(ctx context.Context, workspaceName string, profile wsm.TmuxProfile, noAttach, dryRun bool) error {
+	if workspaceName == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return errors.Wrap(err, "failed to get current directory")
+		}
+
+		detected, err := detectWorkspace(cwd)
+		if err != nil {
+			return errors.Wrap(err, "failed to detect workspace. Use 'workspace-manager tmux <workspace-name>'")
+		}
+		workspaceName = detected
+	}
+
+	workspace, err := loadWorkspace(workspaceName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load workspace '%s'", workspaceName)
+	}
+
+	session := wsm.NewTmuxSession(workspace, profile)
+
+	if dryRun {
+		output.PrintHeader("tmux session: %s", session.Name)
+		for _, window := range session.Windows {
+			if window.Command != "" {
+				output.Printf("  %s (%s): %s\n", window.Name, window.Dir, window.Command)
+			} else {
+				output.Printf("  %s (%s)\n", window.Name, window.Dir)
+			}
+		}
+		if session.Config != "" {
+			output.Printf("  source-file %s\n", session.Config)
+		}
+		return nil
+	}
+
+	if _, err := exec.LookPath("tmux"); err != nil {
+		return errors.New("tmux is not installed or not in PATH")
+	}
+
+	if wsm.TmuxSessionExists(ctx, session.Name) {
+		output.PrintInfo("Session '%s' already exists", session.Name)
+	} else {
+		if err := wsm.CreateTmuxSession(ctx, session); err != nil {
+			return errors.Wrap(err, "failed to create tmux session")
+		}
+		output.PrintSuccess("Created tmux session '%s' with %d windows", session.Name, len(session.Windows))
+	}
+
+	if noAttach {
+		output.Printf("  tmux attach -t %s\n", session.Name)
+		return nil
+	}
+
+	return wsm.AttachTmuxSession(ctx, session.Name)
+}
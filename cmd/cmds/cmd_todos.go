@@ -0,0 +1,120 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewTodosCommand creates the todos command
+func NewTodosCommand() *cobra.Command {
+	var (
+		markers  []string
+		format   string
+		exitCode bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "todos [workspace-name]",
+		Short: "List TODO/FIXME/HACK comments added on the workspace branch",
+		Long: `Scan the worktrees for TODO, FIXME and HACK comments added since each
+repository branched off, grouped by repository and file. Committed, uncommitted
+and untracked changes are included; markers that already existed on the base
+branch are not reported. Only markers in comments (//, /* */, #, --, <!-- -->)
+count, not those in code or string literals.
+
+Examples:
+  # New debt in the current workspace
+  workspace-manager todos
+
+  # Look for custom markers
+  workspace-manager todos --marker TODO,XXX
+
+  # Fail a CI step when the workspace adds markers
+  workspace-manager todos my-feature --exit-code`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspaceName := ""
+			if len(args) > 0 {
+				workspaceName = args[0]
+			}
+			return runTodos(cmd.Context(), workspaceName, markers, format, exitCode)
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&markers, "marker", wsm.DefaultTodoMarkers, "Comment markers to look for")
+	cmd.Flags().StringVar(&format, "format", "text", "Output format: text, json")
+	cmd.Flags().BoolVar(&exitCode, "exit-code", false, "Exit with an error when markers were added")
+
+	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion())
+	carapace.Gen(cmd).FlagCompletion(
+		carapace.ActionMap{
+			"marker": carapace.ActionValues("TODO", "FIXME", "HACK", "XXX", "BUG").UniqueList(","),
+			"format": carapace.ActionValues("text", "json"),
+		},
+	)
+
+	return cmd
+}
+
+func runTodos(ctx context.Context, workspaceName string, markers []string, format string, exitCode bool) error {
+	workspace, err := resolveWorkspace(workspaceName)
+	if err != nil {
+		return err
+	}
+
+	items, err := wsm.ScanTodos(ctx, workspace, markers)
+	if err != nil {
+		return err
+	}
+
+	if format == "json" {
+		if items == nil {
+			items = []wsm.TodoItem{}
+		}
+		if err := wsm.PrintJSON(items); err != nil {
+			return err
+		}
+	} else {
+		printTodos(workspace, items)
+	}
+
+	if exitCode && len(items) > 0 {
+		return errors.Errorf("%d markers added in workspace '%s'", len(items), workspace.Name)
+	}
+	return nil
+}
+
+func printTodos(workspace *wsm.Workspace, items []wsm.TodoItem) {
+	if len(items) == 0 {
+		output.PrintSuccess("No markers added in workspace '%s'", workspace.Name)
+		return
+	}
+
+	defer output.StartPager()()
+
+	output.PrintHeader("Markers added in workspace '%s'", workspace.Name)
+
+	// Items arrive grouped by repository and file
+	repo, file := "", ""
+	for _, item := range items {
+		if item.Repository != repo {
+			repo, file = item.Repository, ""
+			fmt.Println()
+			fmt.Println(output.InfoStyle.Render(repo))
+		}
+		if item.File != file {
+			file = item.File
+			fmt.Printf("  %s\n", file)
+		}
+		fmt.Printf("    %s %s %s\n", output.DimStyle.Render(fmt.Sprintf("%5d", item.Line)), output.WarningStyle.Render(item.Marker), item.Text)
+	}
+
+	fmt.Println()
+	fmt.Printf("Total: %d markers\n", len(items))
+}
@@ -0,0 +1,126 @@
+package cmds
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewToolsCommand creates the tools command
+func NewToolsCommand() *cobra.Command {
+	var (
+		format string
+		stdout bool
+		force  bool
+		strict bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "tools [workspace-name]",
+		Short: "Merge asdf/mise tool versions of the repositories into the workspace root",
+		Long: `Merge the .tool-versions and .mise.toml files of all workspace repositories
+into a .tool-versions (or .mise.toml with --format mise) file in the workspace
+root, so asdf and mise pick the right toolchains when working at the
+workspace level.
+
+When repositories pin different versions of a tool, the highest one is used
+and the conflict is reported and noted in the generated file; --strict fails
+instead. Files generated by this command are overwritten on the next run;
+other files require --force.
+
+Examples:
+  workspace-manager tools
+  workspace-manager tools my-feature --format mise
+  workspace-manager tools --stdout --strict`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspaceName := ""
+			if len(args) > 0 {
+				workspaceName = args[0]
+			}
+			return runTools(workspaceName, format, stdout, force, strict)
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", wsm.ToolVersionsFormatASDF, "File to generate: asdf (.tool-versions), mise (.mise.toml)")
+	cmd.Flags().BoolVar(&stdout, "stdout", false, "Print the file instead of writing it")
+	cmd.Flags().BoolVar(&force, "force", false, "Overwrite an existing file not generated by wsm")
+	cmd.Flags().BoolVar(&strict, "strict", false, "Fail when repositories pin different versions of a tool")
+
+	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion())
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"format": carapace.ActionValues(wsm.ToolVersionsFormatASDF, wsm.ToolVersionsFormatMise),
+	})
+
+	return cmd
+}
+
+func runTools(workspaceName, format string, stdout, force, strict bool) error {
+	if format != wsm.ToolVersionsFormatASDF && format != wsm.ToolVersionsFormatMise {
+		return errors.Errorf("unknown format '%s' (expected asdf or mise)", format)
+	}
+
+	workspace, err := resolveWorkspace(workspaceName)
+	if err != nil {
+		return err
+	}
+	workspace, err = wsm.ExpandWorkspace(workspace)
+	if err != nil {
+		return err
+	}
+
+	pins, err := wsm.ReadToolVersions(workspace)
+	if err != nil {
+		return err
+	}
+	merged := wsm.MergeToolVersions(pins)
+
+	var conflicts []string
+	for _, tool := range merged {
+		if tool.Conflict {
+			var pinned []string
+			for _, pin := range tool.Pins {
+				pinned = append(pinned, fmt.Sprintf("%s %s", pin.Repository, strings.Join(pin.Versions, " ")))
+			}
+			conflicts = append(conflicts, fmt.Sprintf("%s (%s)", tool.Tool, strings.Join(pinned, ", ")))
+		}
+	}
+	if strict && len(conflicts) > 0 {
+		return errors.Errorf("repositories pin different versions of %s", strings.Join(conflicts, "; "))
+	}
+
+	// Conflicts are noted in the generated file, so --stdout output stays a valid file
+	content := wsm.GenerateToolVersions(workspace, merged, format)
+	if stdout {
+		fmt.Print(content)
+		return nil
+	}
+
+	if len(merged) == 0 {
+		output.PrintInfo("No repository of workspace '%s' pins tool versions", workspace.Name)
+		return nil
+	}
+	for _, tool := range merged {
+		if tool.Conflict {
+			output.PrintWarning("Repositories pin different %s versions, using %s", tool.Tool, strings.Join(tool.Versions, " "))
+		}
+	}
+
+	path := filepath.Join(workspace.Path, wsm.ToolVersionsFileName(format))
+	if _, err := os.Stat(path); err == nil && !force && !wsm.IsGeneratedToolVersionsFile(path) {
+		return errors.Errorf("%s exists and was not generated by wsm; use --force to overwrite it", path)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return errors.Wrapf(err, "failed to write %s", path)
+	}
+
+	output.PrintSuccess("Wrote %d tools to %s", len(merged), path)
+	return nil
+}
@@ -0,0 +1,119 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewUndeleteCommand creates the undelete command
+func NewUndeleteCommand() *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "undelete [workspace-name]",
+		Short: "Restore a workspace deleted with --remove-files from the trash",
+		Long: `Restore a workspace that 'delete --remove-files' moved to the trash.
+
+The workspace directory moves back to its original path, its worktrees are
+registered again with their repositories (with their staged changes) and
+its configuration is restored. A branch that was deleted in the meantime is
+recreated at the commit the worktree was on.
+
+Deleted workspaces stay in the trash (~/.local/share/wsm/trash, or
+$XDG_DATA_HOME/wsm/trash) for the retention configured as trash.retention,
+7d by default. Without a name, the workspaces in the trash are listed.
+
+Examples:
+  # List the deleted workspaces that can be restored
+  workspace-manager undelete
+
+  # Restore a workspace
+  workspace-manager undelete my-feature`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return runListTrash(format)
+			}
+			return runUndelete(cmd.Context(), args[0])
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "text", "Output format of the list: text, json")
+
+	carapace.Gen(cmd).PositionalCompletion(TrashCompletion())
+
+	return cmd
+}
+
+func runListTrash(format string) error {
+	if _, err := wsm.PurgeTrash(); err != nil {
+		output.PrintWarning("Failed to empty the trash: %v", err)
+	}
+	entries, err := wsm.ListTrash()
+	if err != nil {
+		return errors.Wrap(err, "failed to read the trash")
+	}
+
+	if format == "json" {
+		if entries == nil {
+			entries = []wsm.TrashEntry{}
+		}
+		return wsm.PrintJSON(entries)
+	}
+
+	if len(entries) == 0 {
+		output.PrintInfo("The trash is empty")
+		return nil
+	}
+	output.PrintHeader("Deleted workspaces")
+	for _, entry := range entries {
+		fmt.Printf("  %-24s deleted %s, %d repositories, expires in %s\n",
+			entry.Workspace.Name,
+			entry.Deleted.Format("2006-01-02 15:04"),
+			len(entry.Workspace.Repositories),
+			formatTrashExpiry(time.Until(entry.Expires)),
+		)
+	}
+	fmt.Println()
+	output.PrintInfo("Restore one with: wsm undelete <name>")
+	return nil
+}
+
+func runUndelete(ctx context.Context, name string) error {
+	wm, err := wsm.NewWorkspaceManager()
+	if err != nil {
+		return errors.Wrap(err, "failed to create workspace manager")
+	}
+
+	workspace, err := wm.UndeleteWorkspace(ctx, name)
+	if err != nil && workspace == nil {
+		return errors.Wrapf(err, "failed to restore workspace '%s'", name)
+	}
+	if err != nil {
+		output.PrintWarning("%v", err)
+	}
+
+	output.PrintSuccess("Workspace '%s' restored", workspace.Name)
+	output.PrintInfo("Path: %s", workspace.Path)
+	return nil
+}
+
+// formatTrashExpiry renders the time left before a deleted workspace is purged, e.g. "6d 23h"
+func formatTrashExpiry(d time.Duration) string {
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(max(d, 0).Minutes()))
+	}
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	if days == 0 {
+		return fmt.Sprintf("%dh", hours)
+	}
+	return fmt.Sprintf("%dd %dh", days, hours)
+}
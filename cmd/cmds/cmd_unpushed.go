@@ -0,0 +1,177 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/charmbracelet/huh"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewUnpushedCommand creates the unpushed command
+func NewUnpushedCommand() *cobra.Command {
+	var (
+		format   string
+		pushAll  bool
+		remote   string
+		force    bool
+		selector string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "unpushed",
+		Short: "List repositories with commits not pushed to any remote",
+		Long: `Scan every workspace and list repositories whose branch has commits
+that are not present on any remote. Run this before reformatting a laptop
+or at the end of a sprint to make sure no work is lost.
+
+Examples:
+  # List unpushed work across all workspaces
+  workspace-manager unpushed
+
+  # Push every unpushed branch to origin (asks for confirmation)
+  workspace-manager unpushed --push-all
+
+  # Only the workspaces of a team (see 'label')
+  workspace-manager unpushed --selector team=payments`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runUnpushed(cmd.Context(), format, pushAll, remote, force, selector)
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "table", "Output format: table, json")
+	cmd.Flags().BoolVar(&pushAll, "push-all", false, "Push all unpushed branches")
+	cmd.Flags().StringVar(&remote, "remote", "origin", "Remote to push to with --push-all")
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "Push without asking for confirmation")
+	cmd.Flags().StringVarP(&selector, "selector", "l", "", "Only check workspaces whose labels match (team=payments,!archived)")
+
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"selector": LabelCompletion().UniqueList(","),
+	})
+
+	return cmd
+}
+
+func runUnpushed(ctx context.Context, format string, pushAll bool, remote string, force bool, selector string) error {
+	labelSelector, err := wsm.ParseLabelSelector(selector)
+	if err != nil {
+		return err
+	}
+
+	workspaces, err := wsm.LoadWorkspaces()
+	if err != nil {
+		return errors.Wrap(err, "failed to load workspaces")
+	}
+	workspaces = labelSelector.Filter(workspaces)
+
+	unpushed := wsm.FindUnpushedCommits(ctx, workspaces)
+
+	if format == "json" {
+		return wsm.PrintJSON(unpushed)
+	}
+
+	if len(unpushed) == 0 {
+		output.PrintSuccess("No unpushed commits in %d workspaces", len(workspaces))
+		return nil
+	}
+
+	total := 0
+	for _, repo := range unpushed {
+		total += repo.Commits
+	}
+	output.PrintWarning("%d unpushed commits in %d repositories", total, len(unpushed))
+	fmt.Println()
+
+	printUnpushedTable(unpushed)
+
+	if !pushAll {
+		return nil
+	}
+
+	pushable, skipped := wsm.SplitPushable(unpushed)
+	if len(skipped) > 0 {
+		fmt.Println()
+	}
+	for _, repo := range skipped {
+		reason := "frozen"
+		if repo.Linked {
+			reason = "linked workspace"
+		}
+		output.PrintInfo("Skipping %s/%s (%s): %s", repo.Workspace, repo.Repository, repo.Branch, reason)
+	}
+	if len(pushable) == 0 {
+		output.PrintInfo("No branches to push.")
+		return nil
+	}
+
+	wm, err := wsm.NewWorkspaceManager()
+	if err != nil {
+		return errors.Wrap(err, "failed to create workspace manager")
+	}
+
+	if !force {
+		if err := output.RequireInteractive("confirm pushing", "use --force to push without confirmation"); err != nil {
+			return err
+		}
+
+		var confirmed bool
+		form := huh.NewForm(
+			huh.NewGroup(
+				huh.NewConfirm().
+					Title(fmt.Sprintf("Push %d branches to '%s'?", len(pushable), remote)).
+					Value(&confirmed),
+			),
+		)
+		if err := form.Run(); err != nil {
+			return errors.Wrap(err, "confirmation failed")
+		}
+		if !confirmed {
+			output.PrintInfo("Push cancelled.")
+			return nil
+		}
+	}
+
+	fmt.Println()
+	failed := 0
+	for _, repo := range pushable {
+		if err := wsm.PushUnpushed(ctx, repo, remote, wm.Config().Timeouts); err != nil {
+			failed++
+			output.PrintError("Failed to push %s/%s (%s): %v", repo.Workspace, repo.Repository, repo.Branch, err)
+			continue
+		}
+		output.PrintSuccess("Pushed %s/%s (%s) to %s", repo.Workspace, repo.Repository, repo.Branch, remote)
+	}
+
+	if failed > 0 {
+		return errors.Errorf("failed to push %d of %d branches", failed, len(pushable))
+	}
+
+	return nil
+}
+
+func printUnpushedTable(unpushed []wsm.UnpushedRepository) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer func() {
+		if err := w.Flush(); err != nil {
+			output.LogWarn(
+				fmt.Sprintf("Failed to flush table writer: %v", err),
+				"Failed to flush table writer",
+				"error", err,
+			)
+		}
+	}()
+
+	fmt.Fprintln(w, "WORKSPACE\tREPOSITORY\tBRANCH\tUNPUSHED")
+	fmt.Fprintln(w, "---------\t----------\t------\t--------")
+
+	for _, repo := range unpushed {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", repo.Workspace, repo.Repository, repo.Branch, repo.Commits)
+	}
+}
@@ -0,0 +1,110 @@
+package cmds
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/spf13/cobra"
+)
+
+// NewValidateCommand creates the validate command
+func NewValidateCommand() *cobra.Command {
+	var (
+		skipPaths bool
+		strict    bool
+		format    string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "validate [path...]",
+		Short: "Check registry.json and the workspace files for errors",
+		Long: `Check the repository registry and the workspace files against their schema:
+
+  - invalid JSON, values of the wrong type and unknown fields
+  - missing, relative and non-existent paths
+  - duplicate repository names and paths, and duplicate workspaces
+  - invalid branch names (the rules of 'git check-ref-format --branch')
+  - aliases, frozen repositories, parents and children naming nothing
+
+Paths are configuration directories, whose registry.json and workspaces/*.json
+are checked, or single files; files named registry.json are registries, other
+files workspaces. Without paths, the configuration directory of the current
+profile is checked. Parents and children are only checked within directories.
+
+Nothing is changed and git is not run, so it is safe to run in CI, e.g. for
+configurations committed to a dotfiles repository. The command exits with
+status 1 when an error is found, or a warning with --strict. Use --skip-paths
+when the repositories are not checked out on the machine running it.
+
+Examples:
+  workspace-manager validate
+  workspace-manager validate ./dotfiles/workspace-manager --skip-paths --format json
+  workspace-manager validate ~/.config/workspace-manager/workspaces/my-feature.json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return silenceReported(cmd, runValidate(args, wsm.ValidateOptions{SkipPaths: skipPaths}, strict, format))
+		},
+	}
+
+	cmd.Flags().BoolVar(&skipPaths, "skip-paths", false, "Do not check that repository and workspace paths exist")
+	cmd.Flags().BoolVar(&strict, "strict", false, "Also fail on warnings")
+	cmd.Flags().StringVar(&format, "format", "text", "Output format: text, json")
+
+	carapace.Gen(cmd).PositionalAnyCompletion(carapace.ActionFiles(".json"))
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"format": carapace.ActionValues("text", "json"),
+	})
+
+	return cmd
+}
+
+func runValidate(paths []string, options wsm.ValidateOptions, strict bool, format string) error {
+	report, err := wsm.ValidateConfigFiles(paths, options)
+	if err != nil {
+		return err
+	}
+	errorCount, warningCount := report.Count(wsm.ValidationError), report.Count(wsm.ValidationWarning)
+
+	if format == "json" {
+		if err := wsm.PrintJSON(report); err != nil {
+			return err
+		}
+	} else if len(report.Issues) == 0 {
+		output.PrintSuccess("%d file(s) valid", len(report.Files))
+	} else {
+		printValidationIssues(report.Issues)
+		fmt.Println()
+		if errorCount > 0 {
+			output.PrintError("%d error(s) and %d warning(s) in %d file(s)", errorCount, warningCount, len(report.Files))
+		} else {
+			output.PrintWarning("%d warning(s) in %d file(s)", warningCount, len(report.Files))
+		}
+	}
+
+	if errorCount > 0 || (strict && warningCount > 0) {
+		return &wsm.ExitCodeError{Code: 1}
+	}
+	return nil
+}
+
+func printValidationIssues(issues []wsm.ValidationIssue) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "FILE\tFIELD\tSEVERITY\tPROBLEM")
+	for _, issue := range issues {
+		field := issue.Field
+		if field == "" {
+			field = "-"
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", issue.File, field, issue.Severity, issue.Message)
+	}
+	if err := w.Flush(); err != nil {
+		output.LogWarn(
+			fmt.Sprintf("Failed to flush validation table: %v", err),
+			"Failed to flush tabwriter",
+			"error", err,
+		)
+	}
+}
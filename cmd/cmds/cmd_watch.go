@@ -0,0 +1,166 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewWatchCommand creates the watch command
+func NewWatchCommand() *cobra.Command {
+	var (
+		run      string
+		repos    []string
+		debounce time.Duration
+		clear    bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "watch [workspace-name]",
+		Short: "Rerun a command in the repositories whose files change",
+		Long: `Watch the worktrees of the workspace and run a command in a repository
+whenever files in it are saved - a multi-repo entr/air. Changes are batched
+until the repository has been quiet for --debounce, files ignored by git and
+directories such as .git, node_modules and vendor are not watched, and
+commands never run concurrently.
+
+The command runs with 'sh -c' in the repository worktree, with the workspace
+environment (WSM_WORKSPACE, WSM_BRANCH, .wsm/env, ...) plus WSM_REPOSITORY and
+WSM_CHANGED_FILES (space-separated, relative to the repository).
+
+Files the command writes itself should be ignored by git (or live in a
+directory listed in watch.ignore), otherwise they trigger the next run.
+
+Commands can be configured per repository in config.yaml; --run overrides them:
+
+  watch:
+    run: go test ./...
+    debounce: 500ms
+    ignore: [testdata]
+    commands:
+      web: npm test -- --watch=false
+
+Examples:
+  workspace-manager watch --run "go test ./..."
+  workspace-manager watch my-feature --repos api,lib --clear`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspaceName := ""
+			if len(args) > 0 {
+				workspaceName = args[0]
+			}
+			var debounceFlag time.Duration
+			if cmd.Flags().Changed("debounce") {
+				debounceFlag = debounce
+			}
+			return runWatch(cmd.Context(), workspaceName, run, repos, debounceFlag, clear)
+		},
+	}
+
+	cmd.Flags().StringVar(&run, "run", "", "Command to run in a changed repository (default: watch.run / watch.commands in config.yaml)")
+	cmd.Flags().StringSliceVar(&repos, "repos", nil, "Only watch these repositories (comma-separated)")
+	cmd.Flags().DurationVar(&debounce, "debounce", wsm.DefaultWatchDebounce, "Quiet period before running the command")
+	cmd.Flags().BoolVar(&clear, "clear", false, "Clear the screen before each run")
+
+	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion())
+
+	return cmd
+}
+
+func runWatch(ctx context.Context, workspaceName, run string, repoFilter []string, debounce time.Duration, clear bool) error {
+	wm, workspace, err := resolveManagedWorkspace(workspaceName)
+	if err != nil {
+		return err
+	}
+	config := wm.Config().Watch
+
+	if debounce == 0 {
+		if debounce, err = config.DebounceDuration(); err != nil {
+			return err
+		}
+	}
+
+	var repos []wsm.Repository
+	for _, repo := range workspace.Repositories {
+		if len(repoFilter) > 0 && !slices.Contains(repoFilter, repo.Name) {
+			continue
+		}
+		if config.CommandFor(repo.Name, run) == "" {
+			output.PrintWarning("No command for '%s', not watching it (use --run or watch.run in config.yaml)", repo.Name)
+			continue
+		}
+		repos = append(repos, repo)
+	}
+	if len(repos) == 0 {
+		return errors.New("nothing to watch: specify a command with --run")
+	}
+
+	env, err := wm.SetupEnvironment(ctx, workspace)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	names := make([]string, len(repos))
+	for i, repo := range repos {
+		names[i] = repo.Name
+	}
+	output.PrintHeader("Watching %s in workspace '%s' (Ctrl+C to stop)", strings.Join(names, ", "), workspace.Name)
+
+	return wsm.WatchWorkspace(ctx, workspace, wsm.WatchOptions{
+		Repositories: repos,
+		Debounce:     debounce,
+		Ignore:       config.Ignore,
+	}, func(change wsm.WatchChange) {
+		if clear {
+			fmt.Print("\033[H\033[2J")
+		}
+		runWatchCommand(ctx, workspace, change, config.CommandFor(change.Repository.Name, run), env)
+	})
+}
+
+func runWatchCommand(ctx context.Context, workspace *wsm.Workspace, change wsm.WatchChange, command string, env map[string]string) {
+	files := strings.Join(change.Files, " ")
+	summary := files
+	if len(change.Files) > 3 {
+		summary = fmt.Sprintf("%s and %d more", strings.Join(change.Files[:3], " "), len(change.Files)-3)
+	}
+	output.Printf("\n")
+	output.PrintInfo("%s: %s (changed: %s)", change.Repository.Name, command, summary)
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = filepath.Join(workspace.Path, change.Repository.Name)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+	for key, value := range env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+	cmd.Env = append(cmd.Env, "WSM_REPOSITORY="+change.Repository.Name, "WSM_CHANGED_FILES="+files)
+
+	start := time.Now()
+	err := cmd.Run()
+	elapsed := time.Since(start).Round(time.Millisecond)
+	switch {
+	case ctx.Err() != nil:
+	case err != nil:
+		output.PrintError("%s: failed after %s: %v", change.Repository.Name, elapsed, err)
+	default:
+		output.PrintSuccess("%s: passed in %s", change.Repository.Name, elapsed)
+	}
+}
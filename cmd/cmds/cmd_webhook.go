@@ -0,0 +1,164 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewWebhookCommand creates the webhook command
+func NewWebhookCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "webhook",
+		Short: "List and test the webhooks workspace events are sent to",
+		Long: `Webhooks post workspace events to Slack or any HTTP endpoint, so teams sharing
+dev servers see the workspaces created, the pull requests opened and the syncs
+that failed on them. They are configured in config.yaml:
+
+  webhooks:
+    - name: team-slack
+      url: ${SLACK_WEBHOOK_URL}          # ${VAR} is read from the environment
+      events: [workspace.created, pr.opened, sync.failed]   # default
+      template: '{{.User}}@{{.Host}}: {{.Message}}'
+    - name: audit
+      url: https://audit.internal/wsm
+      format: json                      # default: slack
+      events: [workspace.created, workspace.deleted]
+      headers:
+        Authorization: Bearer ${AUDIT_TOKEN}
+
+Slack webhooks post {"text": <template>}. JSON webhooks post the template as
+is, or the event as JSON (type, time, workspace, repository, branch, url,
+message, user, host) without a template. Templates are Go templates over the
+same fields: {{.Type}}, {{.Workspace}}, {{.Branch}}, {{.URL}}, {{.Message}},
+{{.User}} and {{.Host}}. In JSON templates the fields are escaped for JSON
+strings, e.g. {"text": "{{.Message}}"}, and the result must be valid JSON.
+
+workspace.created, workspace.deleted, pr.opened and sync.failed are sent by the
+commands they happen in; the other event types (see 'events --help') are sent
+by the daemon. A webhook that cannot be reached only warns.
+
+Examples:
+  workspace-manager webhook list
+  workspace-manager webhook test team-slack`,
+	}
+
+	cmd.AddCommand(
+		NewWebhookListCommand(),
+		NewWebhookTestCommand(),
+	)
+
+	return cmd
+}
+
+// NewWebhookListCommand creates the webhook list command
+func NewWebhookListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the configured webhooks and their events",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := wsm.LoadConfig()
+			if err != nil {
+				return errors.Wrap(err, "failed to load configuration")
+			}
+			if len(config.Webhooks) == 0 {
+				output.PrintInfo("No webhooks configured; add them to the webhooks section of config.yaml (see 'webhook --help')")
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tFORMAT\tEVENTS")
+			fmt.Fprintln(w, "----\t------\t------")
+			for _, webhook := range config.Webhooks {
+				format := webhook.Format
+				if format == "" {
+					format = wsm.WebhookSlack
+				}
+				events := webhook.Events
+				if len(events) == 0 {
+					events = wsm.DefaultWebhookEvents
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\n", webhook.Name, format, strings.Join(events, ","))
+			}
+			if err := w.Flush(); err != nil {
+				return errors.Wrap(err, "failed to flush table writer")
+			}
+			return nil
+		},
+	}
+}
+
+// NewWebhookTestCommand creates the webhook test command
+func NewWebhookTestCommand() *cobra.Command {
+	var eventType string
+
+	cmd := &cobra.Command{
+		Use:   "test [name...]",
+		Short: "Send a test event to webhooks (default: all)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return silenceReported(cmd, runWebhookTest(cmd.Context(), args, eventType))
+		},
+	}
+
+	cmd.Flags().StringVar(&eventType, "type", wsm.EventWorkspaceCreated, "Type of the test event")
+
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"type": carapace.ActionValues(wsm.EventTypes...),
+	})
+	carapace.Gen(cmd).PositionalAnyCompletion(WebhookNameCompletion())
+
+	return cmd
+}
+
+func runWebhookTest(ctx context.Context, names []string, eventType string) error {
+	config, err := wsm.LoadConfig()
+	if err != nil {
+		return errors.Wrap(err, "failed to load configuration")
+	}
+
+	var webhooks []wsm.WebhookConfig
+	for _, webhook := range config.Webhooks {
+		if len(names) == 0 || slices.Contains(names, webhook.Name) {
+			// The test event reaches the webhook whatever its events
+			webhook.Events = []string{eventType}
+			webhooks = append(webhooks, webhook)
+		}
+	}
+	for _, name := range names {
+		if !slices.ContainsFunc(webhooks, func(webhook wsm.WebhookConfig) bool { return webhook.Name == name }) {
+			return errors.Errorf("no webhook named '%s' in config.yaml", name)
+		}
+	}
+	if len(webhooks) == 0 {
+		output.PrintInfo("No webhooks configured; add them to the webhooks section of config.yaml (see 'webhook --help')")
+		return nil
+	}
+
+	event := wsm.Event{
+		Type:      eventType,
+		Workspace: "webhook-test",
+		Branch:    "task/webhook-test",
+		Message:   "test event from 'wsm webhook test'",
+	}
+	failures := wsm.NotifyWebhooks(ctx, webhooks, event)
+	for _, failure := range failures {
+		output.PrintError("%v", failure)
+	}
+	if sent := len(webhooks) - len(failures); sent > 0 {
+		output.PrintSuccess("Sent a %s test event to %d webhook(s)", eventType, sent)
+	}
+	if len(failures) > 0 {
+		return &wsm.ExitCodeError{Code: 1}
+	}
+	return nil
+}
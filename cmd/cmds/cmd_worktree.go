@@ -0,0 +1,155 @@
+package cmds
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewWorktreeCommand creates the worktree command
+func NewWorktreeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "worktree",
+		Short: "Lock and unlock the git worktrees of a workspace",
+		Long: `Manage git's worktree locks for the repositories of a workspace.
+
+A locked worktree is not pruned by git when its directory is missing, which
+protects workspaces on removable drives or network shares that are not always
+mounted. Worktrees created on such filesystems are locked automatically.
+'delete' and 'remove' refuse to remove locked worktrees unless forced, and
+'reconcile' does not recreate them.
+
+Examples:
+  workspace-manager worktree lock app --reason "on the USB drive"
+  workspace-manager worktree unlock app
+  workspace-manager worktree list my-feature`,
+	}
+
+	cmd.AddCommand(
+		NewWorktreeLockCommand(),
+		NewWorktreeUnlockCommand(),
+		NewWorktreeListCommand(),
+	)
+
+	return cmd
+}
+
+// NewWorktreeLockCommand creates the worktree lock command
+func NewWorktreeLockCommand() *cobra.Command {
+	var (
+		workspaceName string
+		reason        string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "lock <repo>",
+		Short: "Lock the worktree of a repository",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wm, workspace, err := resolveManagedWorkspace(workspaceName)
+			if err != nil {
+				return err
+			}
+			if err := wm.LockWorktree(cmd.Context(), workspace, args[0], reason); err != nil {
+				return err
+			}
+			output.PrintSuccess("Locked worktree of '%s' in workspace '%s'", args[0], workspace.Name)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&workspaceName, "workspace", "", "Workspace name (default: detected from the current directory)")
+	cmd.Flags().StringVar(&reason, "reason", "", "Why the worktree is locked")
+
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"workspace": WorkspaceNameCompletion(),
+	})
+
+	return cmd
+}
+
+// NewWorktreeUnlockCommand creates the worktree unlock command
+func NewWorktreeUnlockCommand() *cobra.Command {
+	var workspaceName string
+
+	cmd := &cobra.Command{
+		Use:   "unlock <repo>",
+		Short: "Unlock the worktree of a repository",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wm, workspace, err := resolveManagedWorkspace(workspaceName)
+			if err != nil {
+				return err
+			}
+			if err := wm.UnlockWorktree(cmd.Context(), workspace, args[0]); err != nil {
+				return err
+			}
+			output.PrintSuccess("Unlocked worktree of '%s' in workspace '%s'", args[0], workspace.Name)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&workspaceName, "workspace", "", "Workspace name (default: detected from the current directory)")
+
+	carapace.Gen(cmd).FlagCompletion(carapace.ActionMap{
+		"workspace": WorkspaceNameCompletion(),
+	})
+
+	return cmd
+}
+
+// NewWorktreeListCommand creates the worktree list command
+func NewWorktreeListCommand() *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "list [workspace-name]",
+		Short: "Show the lock state of the worktrees of a workspace",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workspaceName := ""
+			if len(args) > 0 {
+				workspaceName = args[0]
+			}
+			wm, workspace, err := resolveManagedWorkspace(workspaceName)
+			if err != nil {
+				return err
+			}
+			locks, err := wm.WorktreeLocks(cmd.Context(), workspace)
+			if err != nil {
+				return err
+			}
+
+			if format == "json" {
+				return wsm.PrintJSON(locks)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "REPOSITORY\tLOCKED\tREASON\tPATH")
+			fmt.Fprintln(w, "----------\t------\t------\t----")
+			for _, lock := range locks {
+				locked := "no"
+				if lock.Locked {
+					locked = "yes"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", lock.Repository, locked, lock.Reason, lock.Path)
+			}
+			if err := w.Flush(); err != nil {
+				return errors.Wrap(err, "failed to flush table writer")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "table", "Output format: table, json")
+
+	carapace.Gen(cmd).PositionalCompletion(WorkspaceNameCompletion())
+
+	return cmd
+}
@@ -1,7 +1,10 @@
 package cmds
 
 import (
+	"fmt"
+
 	"github.com/carapace-sh/carapace"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
 	"github.com/go-go-golems/workspace-manager/pkg/wsm"
 )
 
@@ -20,6 +23,38 @@ func WorkspaceNameCompletion() carapace.Action {
 	})
 }
 
+// IncompleteCreationCompletion returns a carapace.Action that completes the workspaces whose
+// creation can be resumed.
+func IncompleteCreationCompletion() carapace.Action {
+	return carapace.ActionCallback(func(ctx carapace.Context) carapace.Action {
+		plans, err := wsm.ListIncompleteCreations()
+		if err != nil {
+			return carapace.ActionMessage("failed to load incomplete creations")
+		}
+		var values []string
+		for _, plan := range plans {
+			values = append(values, plan.Workspace.Name, fmt.Sprintf("%d repositories failed", len(plan.Failed)))
+		}
+		return carapace.ActionValuesDescribed(values...)
+	})
+}
+
+// TrashCompletion returns a carapace.Action that completes the deleted workspaces that can be
+// restored.
+func TrashCompletion() carapace.Action {
+	return carapace.ActionCallback(func(ctx carapace.Context) carapace.Action {
+		entries, err := wsm.ListTrash()
+		if err != nil {
+			return carapace.ActionMessage("failed to read the trash")
+		}
+		var values []string
+		for _, entry := range entries {
+			values = append(values, entry.Workspace.Name, "deleted "+entry.Deleted.Format("2006-01-02 15:04"))
+		}
+		return carapace.ActionValuesDescribed(values...)
+	})
+}
+
 // RepositoryNameCompletion returns a carapace.Action that completes repository names
 // from the registry for add commands.
 func RepositoryNameCompletion() carapace.Action {
@@ -32,11 +67,76 @@ func RepositoryNameCompletion() carapace.Action {
 		if err := discoverer.LoadRegistry(); err != nil {
 			return carapace.ActionMessage("failed to load registry")
 		}
-		var names []string
+		// Ranked matches first when something was typed, described by their README line or path
+		var values []string
+		seen := map[string]bool{}
+		if ctx.Value != "" {
+			for _, match := range discoverer.Search(ctx.Value, 0) {
+				values = append(values, match.Repository.Name, repositoryCompletionDescription(match.Repository))
+				seen[match.Repository.Name] = true
+			}
+		}
 		for _, repo := range discoverer.GetRepositories() {
-			names = append(names, repo.Name)
+			if !seen[repo.Name] {
+				values = append(values, repo.Name, repositoryCompletionDescription(repo))
+				seen[repo.Name] = true
+			}
 		}
-		return carapace.ActionValues(names...)
+		for alias, target := range discoverer.GetAliases() {
+			if !seen[alias] {
+				values = append(values, alias, "alias for "+target)
+			}
+		}
+		return carapace.ActionValuesDescribed(values...)
+	})
+}
+
+func repositoryCompletionDescription(repo wsm.Repository) string {
+	if repo.Description != "" {
+		return repo.Description
+	}
+	return repo.Path
+}
+
+// AliasCompletion returns a carapace.Action that completes repository aliases with their target.
+func AliasCompletion() carapace.Action {
+	return carapace.ActionCallback(func(ctx carapace.Context) carapace.Action {
+		discoverer, err := loadDiscoverer()
+		if err != nil {
+			return carapace.ActionMessage("failed to load registry")
+		}
+		var values []string
+		for alias, target := range discoverer.GetAliases() {
+			values = append(values, alias, target)
+		}
+		return carapace.ActionValuesDescribed(values...)
+	})
+}
+
+// ProfileCompletion returns a carapace.Action that completes profile names.
+func ProfileCompletion() carapace.Action {
+	return carapace.ActionCallback(func(ctx carapace.Context) carapace.Action {
+		profiles, err := wsm.ListProfiles()
+		if err != nil {
+			return carapace.ActionMessage("failed to list profiles")
+		}
+		return carapace.ActionValues(profiles...)
+	})
+}
+
+// WebhookNameCompletion returns a carapace.Action that completes the names of the webhooks in
+// config.yaml.
+func WebhookNameCompletion() carapace.Action {
+	return carapace.ActionCallback(func(ctx carapace.Context) carapace.Action {
+		config, err := wsm.LoadConfig()
+		if err != nil {
+			return carapace.ActionMessage("failed to load configuration")
+		}
+		var names []string
+		for _, webhook := range config.Webhooks {
+			names = append(names, webhook.Name)
+		}
+		return carapace.ActionValues(names...).FilterArgs()
 	})
 }
 
@@ -67,6 +167,28 @@ func WorkspaceRepositoryCompletion() carapace.Action {
 	})
 }
 
+// ChildWorkspaceCompletion returns a carapace.Action that completes the children of the workspace
+// given as first argument.
+func ChildWorkspaceCompletion() carapace.Action {
+	return carapace.ActionCallback(func(ctx carapace.Context) carapace.Action {
+		if len(ctx.Args) < 1 {
+			return carapace.ActionMessage("workspace name required")
+		}
+
+		workspaces, err := wsm.LoadWorkspaces()
+		if err != nil {
+			return carapace.ActionMessage("failed to load workspaces")
+		}
+
+		for _, ws := range workspaces {
+			if ws.Name == ctx.Args[0] {
+				return carapace.ActionValues(ws.Children...)
+			}
+		}
+		return carapace.ActionMessage("workspace not found")
+	})
+}
+
 // TagCompletion returns a carapace.Action that completes repository tags.
 func TagCompletion() carapace.Action {
 	return carapace.ActionCallback(func(ctx carapace.Context) carapace.Action {
@@ -91,3 +213,29 @@ func TagCompletion() carapace.Action {
 		return carapace.ActionValues(tags...)
 	})
 }
+
+// LabelCompletion returns a carapace.Action that completes the key=value labels set on workspaces.
+func LabelCompletion() carapace.Action {
+	return carapace.ActionCallback(func(ctx carapace.Context) carapace.Action {
+		workspaces, err := wsm.LoadWorkspaces()
+		if err != nil {
+			return carapace.ActionMessage("failed to load workspaces")
+		}
+		labelsSet := make(map[string]struct{})
+		for _, ws := range workspaces {
+			for key, value := range ws.Labels {
+				labelsSet[key+"="+value] = struct{}{}
+			}
+		}
+		var labels []string
+		for label := range labelsSet {
+			labels = append(labels, label)
+		}
+		return carapace.ActionValues(labels...)
+	})
+}
+
+// ColumnCompletion returns a carapace.Action that completes the given table column names.
+func ColumnCompletion(columns []output.Column) carapace.Action {
+	return carapace.ActionValues(output.ColumnNames(columns)...)
+}
@@ -0,0 +1,781 @@
+package cmds_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-go-golems/workspace-manager/cmd/cmds"
+	"github.com/go-go-golems/workspace-manager/internal/testkit"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/spf13/cobra"
+)
+
+// setupRepos creates the "lib" (a Go module) and "app" repositories and registers them
+func setupRepos(t *testing.T) *testkit.Env {
+	env := testkit.New(t)
+	env.NewRepo("lib", map[string]string{
+		"go.mod": "module example.com/lib\n\ngo 1.23\n",
+		"lib.go": "package lib\n",
+	})
+	env.NewRepo("app", map[string]string{
+		"README.md": "# app\n",
+	})
+	env.Discover()
+	return env
+}
+
+func assertExists(t *testing.T, path string) {
+	t.Helper()
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("expected %s to exist: %v", path, err)
+	}
+}
+
+func assertNotExists(t *testing.T, path string) {
+	t.Helper()
+	if _, err := os.Lstat(path); !os.IsNotExist(err) {
+		t.Errorf("expected %s not to exist", path)
+	}
+}
+
+func worktreeCount(env *testkit.Env, repo string) int {
+	return len(strings.Split(env.Git(filepath.Join(env.CodeDir, repo), "worktree", "list"), "\n"))
+}
+
+func TestCreateAndDelete(t *testing.T) {
+	env := setupRepos(t)
+
+	env.MustRun(cmds.NewCreateCommand(), "feat", "--repos", "lib,app", "--branch", "feature/x", "--no-bootstrap")
+
+	path := env.WorkspacePath("feat")
+	for _, repo := range []string{"lib", "app"} {
+		if branch := env.Git(filepath.Join(path, repo), "rev-parse", "--abbrev-ref", "HEAD"); branch != "feature/x" {
+			t.Errorf("%s: expected branch feature/x, got %s", repo, branch)
+		}
+		if n := worktreeCount(env, repo); n != 2 {
+			t.Errorf("%s: expected 2 worktrees, got %d", repo, n)
+		}
+	}
+
+	workspace := env.LoadWorkspace("feat")
+	if len(workspace.Repositories) != 2 || workspace.Branch != "feature/x" {
+		t.Errorf("unexpected workspace configuration: %+v", workspace)
+	}
+	if !workspace.GoWorkspace {
+		t.Errorf("expected a Go workspace")
+	}
+	goWork, err := os.ReadFile(filepath.Join(path, "go.work"))
+	if err != nil {
+		t.Fatalf("failed to read go.work: %v", err)
+	}
+	if !strings.Contains(string(goWork), "./lib") || strings.Contains(string(goWork), "./app") {
+		t.Errorf("go.work should only use ./lib:\n%s", goWork)
+	}
+
+	env.MustRun(cmds.NewDeleteCommand(), "feat", "--force", "--remove-files")
+
+	assertNotExists(t, path)
+	assertNotExists(t, filepath.Join(env.ConfigDir, "workspaces", "feat.json"))
+	for _, repo := range []string{"lib", "app"} {
+		if n := worktreeCount(env, repo); n != 1 {
+			t.Errorf("%s: expected the worktree to be removed, got %d worktrees", repo, n)
+		}
+	}
+}
+
+func TestQuietCreateAndDeletePrintNothing(t *testing.T) {
+	env := setupRepos(t)
+
+	for _, run := range []struct {
+		cmd  *cobra.Command
+		args []string
+	}{
+		{cmds.NewCreateCommand(), []string{"feat", "--repos", "lib,app", "--branch", "feature/x", "--no-bootstrap"}},
+		{cmds.NewDeleteCommand(), []string{"feat", "--force", "--remove-files"}},
+	} {
+		result := env.RunQuiet(run.cmd, run.args...)
+		if result.Err != nil {
+			t.Fatalf("%s failed: %v\n%s", run.cmd.Name(), result.Err, result.Stderr)
+		}
+		if result.Stdout != "" {
+			t.Errorf("%s printed with --quiet:\n%s", run.cmd.Name(), result.Stdout)
+		}
+	}
+}
+
+func TestCreateRejectsUnknownRepository(t *testing.T) {
+	env := setupRepos(t)
+
+	result := env.Run(cmds.NewCreateCommand(), "feat", "--repos", "lib,missing", "--no-bootstrap")
+	if result.Err == nil {
+		t.Fatalf("expected create to fail for an unknown repository")
+	}
+	assertNotExists(t, env.WorkspacePath("feat"))
+}
+
+func TestAddAndRemove(t *testing.T) {
+	env := setupRepos(t)
+
+	env.MustRun(cmds.NewCreateCommand(), "feat", "--repos", "lib", "--branch", "feature/x", "--no-bootstrap")
+	env.MustRun(cmds.NewAddCommand(), "feat", "app")
+
+	path := env.WorkspacePath("feat")
+	assertExists(t, filepath.Join(path, "app", "README.md"))
+	if branch := env.Git(filepath.Join(path, "app"), "rev-parse", "--abbrev-ref", "HEAD"); branch != "feature/x" {
+		t.Errorf("added repository should use the workspace branch, got %s", branch)
+	}
+	workspace := env.LoadWorkspace("feat")
+	if len(workspace.Repositories) != 2 {
+		t.Errorf("expected 2 repositories after add, got %d", len(workspace.Repositories))
+	}
+	if workspace.BranchPoints["app"] == "" {
+		t.Errorf("expected a branch point to be recorded for app")
+	}
+
+	result := env.Run(cmds.NewAddCommand(), "feat", "app")
+	if result.Err == nil {
+		t.Errorf("adding a repository twice should fail")
+	}
+
+	env.MustRun(cmds.NewRemoveCommand(), "feat", "app", "--remove-files")
+
+	assertNotExists(t, filepath.Join(path, "app"))
+	workspace = env.LoadWorkspace("feat")
+	if len(workspace.Repositories) != 1 || workspace.Repositories[0].Name != "lib" {
+		t.Errorf("expected only lib after remove, got %+v", workspace.Repositories)
+	}
+	if _, ok := workspace.BranchPoints["app"]; ok {
+		t.Errorf("branch point of app should be dropped")
+	}
+	if n := worktreeCount(env, "app"); n != 1 {
+		t.Errorf("expected the app worktree to be removed, got %d worktrees", n)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	env := setupRepos(t)
+
+	env.MustRun(cmds.NewCreateCommand(), "feat", "--repos", "lib,app", "--branch", "feature/x", "--no-bootstrap")
+
+	path := env.WorkspacePath("feat")
+	env.WriteFile(filepath.Join(path, "lib", "lib.go"), "package lib\n\nfunc Hello() {}\n")
+	env.WriteFile(filepath.Join(path, "app", "main.go"), "package main\n")
+	env.Commit(filepath.Join(path, "app"), "Add main")
+
+	t.Run("table", func(t *testing.T) {
+		result := env.MustRun(cmds.NewStatusCommand(), "feat", "--porcelain=false")
+		testkit.AssertGolden(t, "status", result.Stdout)
+	})
+	t.Run("short", func(t *testing.T) {
+		result := env.MustRun(cmds.NewStatusCommand(), "feat", "--short")
+		testkit.AssertGolden(t, "status-short", result.Stdout)
+	})
+	t.Run("porcelain", func(t *testing.T) {
+		result := env.MustRun(cmds.NewStatusCommand(), "feat", "--porcelain")
+		testkit.AssertGolden(t, "status-porcelain", result.Stdout)
+	})
+	t.Run("redirected", func(t *testing.T) {
+		// Stdout is a pipe here, so porcelain is the default
+		result := env.MustRun(cmds.NewStatusCommand(), "feat")
+		testkit.AssertGolden(t, "status-porcelain", result.Stdout)
+	})
+}
+
+func TestReconcile(t *testing.T) {
+	env := setupRepos(t)
+
+	env.MustRun(cmds.NewCreateCommand(), "feat", "--repos", "lib", "--branch", "feature/x", "--no-bootstrap")
+	path := env.WorkspacePath("feat")
+
+	// A deleted worktree and a worktree added by hand
+	if err := os.RemoveAll(filepath.Join(path, "lib")); err != nil {
+		t.Fatal(err)
+	}
+	env.Git(filepath.Join(env.CodeDir, "app"), "worktree", "add", "-b", "feature/x", filepath.Join(path, "app"))
+
+	env.MustRun(cmds.NewReconcileCommand(), "feat")
+
+	assertExists(t, filepath.Join(path, "lib", "lib.go"))
+	if branch := env.Git(filepath.Join(path, "lib"), "rev-parse", "--abbrev-ref", "HEAD"); branch != "feature/x" {
+		t.Errorf("recreated worktree should be on the workspace branch, got %s", branch)
+	}
+	workspace := env.LoadWorkspace("feat")
+	if len(workspace.Repositories) != 2 || workspace.Repositories[1].Name != "app" {
+		t.Errorf("expected app to be registered, got %+v", workspace.Repositories)
+	}
+	if workspace.Repositories[1].Path != filepath.Join(env.CodeDir, "app") {
+		t.Errorf("registered repository should point at its source, got %s", workspace.Repositories[1].Path)
+	}
+
+	result := env.MustRun(cmds.NewReconcileCommand(), "feat")
+	if !strings.Contains(result.Stdout, "matches its configuration") {
+		t.Errorf("expected nothing left to reconcile, got:\n%s", result.Stdout)
+	}
+}
+
+func TestChangesKeepTheRecordedBranchPoint(t *testing.T) {
+	env := setupRepos(t)
+
+	source := filepath.Join(env.CodeDir, "lib")
+	created := env.Git(source, "rev-parse", "main")
+	env.MustRun(cmds.NewCreateCommand(), "feat", "--repos", "lib", "--branch", "feature/x", "--no-bootstrap")
+	worktree := filepath.Join(env.WorkspacePath("feat"), "lib")
+
+	workspace := env.LoadWorkspace("feat")
+	if got := workspace.BranchPoints["lib"]; got != created {
+		t.Fatalf("recorded branch point = %q, want %s", got, created)
+	}
+
+	env.WriteFile(filepath.Join(worktree, "feature.go"), "package lib\n\nfunc Feature() {}\n")
+	env.Commit(worktree, "Add feature")
+
+	// The base branch moves on after the workspace was created
+	env.WriteFile(filepath.Join(source, "upstream.go"), "package lib\n\nfunc Upstream() {}\n")
+	upstream := env.Commit(source, "Add upstream change")
+	env.Git(source, "push", "--quiet", "origin", "main")
+
+	changes := wsm.GetWorkspaceChanges(t.Context(), workspace)
+	if len(changes) != 1 || changes[0].Error != "" {
+		t.Fatalf("unexpected changes: %+v", changes)
+	}
+	lib := changes[0]
+	if lib.BranchPoint != created || !lib.Recorded {
+		t.Errorf("branch point = %s (recorded %v), want the recorded %s", lib.BranchPoint, lib.Recorded, created)
+	}
+	if len(lib.Commits) != 1 || lib.Commits[0].Subject != "Add feature" {
+		t.Errorf("commits = %+v, want only the workspace commit", lib.Commits)
+	}
+	if lib.FilesChanged != 1 || lib.Insertions != 3 || lib.UpstreamCommits != 1 {
+		t.Errorf("diffstat = %d files +%d, %d upstream commits; want 1 file +3, 1 upstream commit",
+			lib.FilesChanged, lib.Insertions, lib.UpstreamCommits)
+	}
+
+	// Without a recorded branch point the merge-base is recomputed, which still predates upstream
+	workspace.BranchPoints = nil
+	lib = wsm.GetWorkspaceChanges(t.Context(), workspace)[0]
+	if lib.BranchPoint != created || lib.Recorded {
+		t.Errorf("recomputed branch point = %s (recorded %v), want %s", lib.BranchPoint, lib.Recorded, created)
+	}
+
+	// Once the workspace is rebased, the branch point follows the new base
+	env.Git(worktree, "rebase", "--quiet", "origin/main")
+	branchPoint, err := wsm.FindBranchPoint(t.Context(), workspace, worktree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if branchPoint != upstream {
+		t.Errorf("branch point after rebase = %s, want %s", branchPoint, upstream)
+	}
+	lib = wsm.GetWorkspaceChanges(t.Context(), workspace)[0]
+	if len(lib.Commits) != 1 || lib.UpstreamCommits != 0 {
+		t.Errorf("after rebase: %d commits, %d upstream commits; want 1 and 0", len(lib.Commits), lib.UpstreamCommits)
+	}
+}
+
+func TestDiffSummaries(t *testing.T) {
+	env := setupRepos(t)
+	env.MustRun(cmds.NewCreateCommand(), "feat", "--repos", "lib,app", "--branch", "feature/x", "--no-bootstrap")
+	lib := filepath.Join(env.WorkspacePath("feat"), "lib")
+	app := filepath.Join(env.WorkspacePath("feat"), "app")
+
+	// lib has an unstaged text and binary change, app a staged one
+	env.WriteFile(filepath.Join(lib, "logo.bin"), "\x00\x01\x02")
+	env.Commit(lib, "Add logo")
+	env.WriteFile(filepath.Join(lib, "logo.bin"), "\x00\x03\x04\x05")
+	env.WriteFile(filepath.Join(lib, "lib.go"), "package lib\n\nfunc Hello() {}\n")
+	env.WriteFile(filepath.Join(app, "README.md"), "# app\n\nUsage\n")
+	env.Git(app, "add", "README.md")
+	t.Chdir(env.WorkspacePath("feat"))
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "numstat", args: []string{"--numstat"}, want: "lib\t2\t0\tlib.go\nlib\t-\t-\tlogo.bin\n"},
+		{name: "numstat staged", args: []string{"--numstat", "--staged"}, want: "app\t2\t0\tREADME.md\n"},
+		{name: "name-only", args: []string{"--name-only"}, want: "lib/lib.go\nlib/logo.bin\n"},
+		{name: "name-only of a repository", args: []string{"--name-only", "--staged", "--repo", "app"}, want: "app/README.md\n"},
+		{name: "repository without changes", args: []string{"--name-only", "--staged", "--repo", "lib"}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := env.MustRun(cmds.NewDiffCommand(), tt.args...); result.Stdout != tt.want {
+				t.Errorf("diff %v =\n%q\nwant\n%q", tt.args, result.Stdout, tt.want)
+			}
+		})
+	}
+
+	result := env.MustRun(cmds.NewDiffCommand(), "--stat")
+	for _, want := range []string{
+		"=== Repository: lib ===",
+		" lib.go   |     2 ++",
+		" logo.bin | Bin",
+		" 2 files changed, 2 insertions(+), 0 deletions(-)",
+		"Total: 1 repositories, 2 files changed, 2 insertions(+), 0 deletions(-)",
+	} {
+		if !strings.Contains(result.Stdout, want) {
+			t.Errorf("diff --stat is missing %q:\n%s", want, result.Stdout)
+		}
+	}
+	if strings.Contains(result.Stdout, "app") {
+		t.Errorf("diff --stat should not list the staged change of app:\n%s", result.Stdout)
+	}
+}
+
+func TestPatchExportAndApplyWithRelativePaths(t *testing.T) {
+	env := setupRepos(t)
+
+	env.MustRun(cmds.NewCreateCommand(), "feat", "--repos", "lib", "--branch", "feature/x", "--no-bootstrap")
+	env.MustRun(cmds.NewCreateCommand(), "other", "--repos", "lib", "--branch", "feature/y", "--no-bootstrap")
+
+	lib := filepath.Join(env.WorkspacePath("feat"), "lib")
+	env.WriteFile(filepath.Join(lib, "hello.go"), "package lib\n\nfunc Hello() {}\n")
+	env.Commit(lib, "Add Hello")
+
+	// Run from a directory outside the workspace with relative paths
+	t.Chdir(env.Root)
+
+	env.MustRun(cmds.NewPatchCommand(), "export", "feat", "--output", "feat-patches")
+
+	assertExists(t, filepath.Join(env.Root, "feat-patches", "lib", "0001-Add-Hello.patch"))
+	assertNotExists(t, filepath.Join(lib, "feat-patches"))
+
+	env.MustRun(cmds.NewPatchCommand(), "apply", "feat-patches", "other")
+
+	other := filepath.Join(env.WorkspacePath("other"), "lib")
+	assertExists(t, filepath.Join(other, "hello.go"))
+	if subject := env.Git(other, "log", "-1", "--format=%s"); subject != "Add Hello" {
+		t.Errorf("expected the patch to be applied as a commit, got %q", subject)
+	}
+}
+
+func TestDeleteToTrashRespectsWorktreeLocks(t *testing.T) {
+	env := setupRepos(t)
+
+	env.MustRun(cmds.NewCreateCommand(), "feat", "--repos", "lib,app", "--branch", "feature/x", "--no-bootstrap")
+	path := env.WorkspacePath("feat")
+	env.Git(filepath.Join(env.CodeDir, "lib"), "worktree", "lock", "--reason", "on a share", filepath.Join(path, "lib"))
+
+	result := env.Run(cmds.NewDeleteCommand(), "feat", "--force", "--remove-files")
+	if result.Err == nil || !strings.Contains(result.Err.Error(), "locked") {
+		t.Fatalf("expected delete to refuse the locked worktree, got %v", result.Err)
+	}
+	assertExists(t, filepath.Join(path, "lib", "lib.go"))
+	assertExists(t, filepath.Join(path, "app", "README.md"))
+	assertExists(t, filepath.Join(env.ConfigDir, "workspaces", "feat.json"))
+
+	env.MustRun(cmds.NewDeleteCommand(), "feat", "--force", "--force-worktrees", "--remove-files")
+
+	assertNotExists(t, path)
+	assertNotExists(t, filepath.Join(env.ConfigDir, "workspaces", "feat.json"))
+}
+
+// writeBundle writes a bundle archive holding manifest and the given files
+func writeBundle(t *testing.T, path string, manifest wsm.BundleManifest, files map[string]string) {
+	t.Helper()
+
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files["manifest.json"] = string(data)
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestImportBundleRejectsPathsOutsideTheWorkspace(t *testing.T) {
+	env := setupRepos(t)
+	head := env.Git(filepath.Join(env.CodeDir, "lib"), "rev-parse", "HEAD")
+
+	for name, file := range map[string]string{
+		"dotdot":   "../../../escaped.txt",
+		"absolute": filepath.Join(env.Root, "escaped.txt"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			bundle := filepath.Join(env.Root, name+".wsmpack")
+			writeBundle(t, bundle, wsm.BundleManifest{
+				Version:   1,
+				Workspace: wsm.Workspace{Name: "evil", Branch: "feature/evil"},
+				Repositories: []wsm.BundleRepository{{
+					Name:           "lib",
+					Branch:         "feature/evil",
+					Head:           head,
+					UntrackedFiles: []string{file},
+				}},
+			}, map[string]string{"repos/lib/untracked/escaped.txt": "pwned\n"})
+
+			result := env.Run(cmds.NewImportBundleCommand(), bundle)
+			if result.Err == nil || !strings.Contains(result.Err.Error(), "invalid") {
+				t.Fatalf("expected the bundle to be rejected, got %v", result.Err)
+			}
+			assertNotExists(t, filepath.Join(env.Root, "escaped.txt"))
+			assertNotExists(t, filepath.Join(env.WorkspaceDir, "escaped.txt"))
+			assertNotExists(t, env.WorkspacePath("evil"))
+		})
+	}
+}
+
+func TestSetupScriptsRequireOptIn(t *testing.T) {
+	env := setupRepos(t)
+	env.NewRepo("tool", map[string]string{
+		".wsm/setup.sh": "env > setup-ran\n",
+	})
+	env.Discover()
+
+	env.MustRun(cmds.NewCreateCommand(), "feat", "--repos", "tool", "--branch", "feature/x")
+	assertNotExists(t, filepath.Join(env.WorkspacePath("feat"), "tool", "setup-ran"))
+
+	env.MustRun(cmds.NewCreateCommand(), "other", "--repos", "tool", "--branch", "feature/y", "--setup")
+	ran, err := os.ReadFile(filepath.Join(env.WorkspacePath("other"), "tool", "setup-ran"))
+	if err != nil {
+		t.Fatalf("expected the setup script to run with --setup: %v", err)
+	}
+	if !strings.Contains(string(ran), "WSM_REPO=tool") {
+		t.Errorf("setup script should receive the workspace environment:\n%s", ran)
+	}
+}
+
+func TestIgnoreRulesOnlyHideUntrackedFiles(t *testing.T) {
+	env := testkit.New(t)
+	env.NewRepo("api", map[string]string{
+		".gitignore":    "*.pb.go\n",
+		"service.go":    "package api\n",
+		"service.pb.go": "package api\n",
+	})
+	env.Discover()
+	env.MustRun(cmds.NewCreateCommand(), "feat", "--repos", "api", "--branch", "feature/x")
+
+	repo := filepath.Join(env.WorkspacePath("feat"), "api")
+	env.WriteFile(filepath.Join(repo, "service.pb.go"), "package api\n\nconst Version = 2\n")
+	env.Git(repo, "add", "--force", "service.pb.go")
+	env.WriteFile(filepath.Join(repo, "new.pb.go"), "package api\n")
+	env.WriteFile(filepath.Join(repo, "notes.txt"), "todo\n")
+	env.WriteFile(filepath.Join(repo, "types.gen.go"), "package api\n")
+	env.WriteFile(filepath.Join(env.WorkspacePath("feat"), ".wsm", "ignore"), "*.gen.go\n")
+
+	gitOps := wsm.NewGitOperations(env.LoadWorkspace("feat"))
+	changes, err := gitOps.GetWorkspaceChanges(t.Context())
+	if err != nil {
+		t.Fatalf("failed to get workspace changes: %v", err)
+	}
+	var paths []string
+	for _, change := range changes["api"] {
+		paths = append(paths, change.FilePath)
+	}
+	if !slices.Contains(paths, "service.pb.go") {
+		t.Errorf("staged changes to a tracked ignored file must be shown, got %v", paths)
+	}
+	if !slices.Contains(paths, "notes.txt") || slices.Contains(paths, "new.pb.go") || slices.Contains(paths, "types.gen.go") {
+		t.Errorf("expected only untracked ignored files to be hidden, got %v", paths)
+	}
+	if hidden := gitOps.HiddenChanges(); hidden != 1 {
+		t.Errorf("expected 1 hidden file, got %d", hidden)
+	}
+
+	diff, err := gitOps.GetDiff(t.Context(), true, "")
+	if err != nil {
+		t.Fatalf("failed to get diff: %v", err)
+	}
+	if !strings.Contains(diff, "Version = 2") {
+		t.Errorf("the diff should include the tracked ignored file:\n%s", diff)
+	}
+}
+
+func TestDeleteRejectsBlankSelectors(t *testing.T) {
+	env := setupRepos(t)
+	env.MustRun(cmds.NewCreateCommand(), "feat", "--repos", "lib", "--branch", "feature/x")
+
+	for _, selector := range []string{",", " ", ""} {
+		result := env.Run(cmds.NewDeleteCommand(), "--selector", selector, "--force", "--remove-files")
+		if result.Err == nil {
+			t.Errorf("delete --selector %q should fail", selector)
+		}
+	}
+	assertExists(t, env.WorkspacePath("feat"))
+	env.LoadWorkspace("feat")
+}
+
+func TestLinkedWorkspacesLeaveCheckoutsUnchanged(t *testing.T) {
+	env := setupRepos(t)
+	env.MustRun(cmds.NewCreateCommand(), "look", "--repos", "lib", "--link")
+
+	checkout := filepath.Join(env.CodeDir, "lib")
+	head := env.Git(checkout, "rev-parse", "HEAD")
+	env.WriteFile(filepath.Join(checkout, "scratch.go"), "package lib\n")
+	t.Chdir(env.WorkspacePath("look"))
+
+	for _, run := range []struct {
+		name string
+		cmd  func() testkit.Result
+	}{
+		{name: "commit", cmd: func() testkit.Result { return env.Run(cmds.NewCommitCommand(), "-m", "scratch", "--add-all") }},
+		{name: "sync pull", cmd: func() testkit.Result { return env.Run(cmds.NewSyncCommand(), "pull", "--skip-preflight") }},
+		{name: "gitconfig set", cmd: func() testkit.Result {
+			return env.Run(cmds.NewGitConfigCommand(), "set", "user.email", "me@example.com", "--workspace", "look")
+		}},
+		{name: "patch apply", cmd: func() testkit.Result {
+			return env.Run(cmds.NewPatchCommand(), "apply", t.TempDir(), "look")
+		}},
+	} {
+		result := run.cmd()
+		if result.Err == nil || !strings.Contains(result.Err.Error()+result.Stdout+result.Stderr, "links existing checkouts") {
+			t.Errorf("%s should be refused in a linked workspace, got %v\n%s%s", run.name, result.Err, result.Stdout, result.Stderr)
+		}
+	}
+
+	if got := env.Git(checkout, "rev-parse", "HEAD"); got != head {
+		t.Errorf("the checkout was committed to: %s -> %s", head, got)
+	}
+	if out, _ := exec.Command("git", "-C", checkout, "config", "--local", "extensions.worktreeConfig").Output(); len(out) > 0 {
+		t.Errorf("worktree config was enabled in the checkout")
+	}
+}
+
+func TestIncompleteCreationCanBeResumed(t *testing.T) {
+	env := setupRepos(t)
+	app := filepath.Join(env.CodeDir, "app")
+	if err := os.Rename(app, app+".moved"); err != nil {
+		t.Fatal(err)
+	}
+
+	result := env.Run(cmds.NewCreateCommand(), "feat", "--repos", "lib,app", "--branch", "feature/x")
+	if result.Err == nil {
+		t.Fatal("create should fail when a repository cannot be created")
+	}
+	if !strings.Contains(result.Stdout, "wsm create --resume feat") || !strings.Contains(result.Stdout, "wsm create --abandon feat") {
+		t.Errorf("expected the resume and abandon hints:\n%s", result.Stdout)
+	}
+	assertExists(t, filepath.Join(env.WorkspacePath("feat"), "lib"))
+
+	if result := env.Run(cmds.NewCreateCommand(), "--abandon", "../feat"); result.Err == nil {
+		t.Error("abandon should reject names that are not a workspace name")
+	}
+
+	if err := os.Rename(app+".moved", app); err != nil {
+		t.Fatal(err)
+	}
+	env.MustRun(cmds.NewCreateCommand(), "--resume", "feat")
+	assertExists(t, filepath.Join(env.WorkspacePath("feat"), "app"))
+	if workspace := env.LoadWorkspace("feat"); len(workspace.Repositories) != 2 {
+		t.Errorf("expected both repositories in the resumed workspace, got %+v", workspace.Repositories)
+	}
+}
+
+func TestRespinBranchesOffTheDefaultBranchWithoutTrackingIt(t *testing.T) {
+	env := setupRepos(t)
+	env.MustRun(cmds.NewCreateCommand(), "feat", "--repos", "lib,app", "--branch", "feature/x")
+	env.WriteFile(filepath.Join(env.WorkspacePath("feat"), "lib", "feature.go"), "package lib\n")
+	env.Commit(filepath.Join(env.WorkspacePath("feat"), "lib"), "Feature")
+
+	env.MustRun(cmds.NewRespinCommand(), "feat", "--branch", "feature/y", "--no-fetch")
+	lib := filepath.Join(env.WorkspacePath("y"), "lib")
+	if head, main := env.Git(lib, "rev-parse", "HEAD"), env.Git(lib, "rev-parse", "origin/main"); head != main {
+		t.Errorf("respun branch starts at %s, want origin/main %s", head, main)
+	}
+	// Pushing and pulling must not target main
+	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "feature/y@{upstream}")
+	cmd.Dir = lib
+	if out, err := cmd.CombinedOutput(); err == nil {
+		t.Errorf("respun branch tracks %s", out)
+	}
+	if workspace := env.LoadWorkspace("y"); workspace.Branch != "feature/y" {
+		t.Errorf("respun workspace is on %q", workspace.Branch)
+	}
+}
+
+func TestEveryCreationPathSendsWorkspaceCreated(t *testing.T) {
+	env := setupRepos(t)
+	var mu sync.Mutex
+	var created []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event wsm.Event
+		_ = json.NewDecoder(r.Body).Decode(&event)
+		mu.Lock()
+		created = append(created, event.Workspace)
+		mu.Unlock()
+	}))
+	defer server.Close()
+	env.WriteFile(filepath.Join(env.ConfigDir, "config.yaml"), "workspace_dir: "+env.WorkspaceDir+"\n"+
+		"webhooks:\n  - name: test\n    url: "+server.URL+"\n    format: json\n")
+
+	env.MustRun(cmds.NewCreateCommand(), "feat", "--repos", "lib", "--branch", "feature/x")
+	env.MustRun(cmds.NewCreateCommand(), "linked", "--repos", "lib", "--link")
+	env.MustRun(cmds.NewRespinCommand(), "feat", "--branch", "feature/y", "--no-fetch")
+
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []string{"feat", "linked", "y"}; !slices.Equal(created, want) {
+		t.Errorf("workspace.created was sent for %v, want %v", created, want)
+	}
+}
+
+func TestEveryCreationPathEnforcesTheQuota(t *testing.T) {
+	env := setupRepos(t)
+	env.WriteFile(filepath.Join(env.ConfigDir, "config.yaml"), "workspace_dir: "+env.WorkspaceDir+"\n"+
+		"quota:\n  max_workspaces: 1\n  enforce: refuse\n")
+
+	env.MustRun(cmds.NewCreateCommand(), "feat", "--repos", "lib", "--branch", "feature/x", "--no-bootstrap")
+	refused := map[string]testkit.Result{
+		"create": env.Run(cmds.NewCreateCommand(), "other", "--repos", "lib", "--branch", "feature/o", "--no-bootstrap"),
+		"fork":   env.Run(cmds.NewForkCommand(), "forked", "feat", "--no-bootstrap"),
+		"respin": env.Run(cmds.NewRespinCommand(), "feat", "--branch", "feature/y", "--no-fetch", "--no-bootstrap"),
+	}
+	for command, result := range refused {
+		if result.Err == nil || !strings.Contains(result.Err.Error(), "quota exceeded") {
+			t.Errorf("%s should be refused over quota, got %v", command, result.Err)
+		}
+	}
+	for _, name := range []string{"other", "forked", "y"} {
+		assertNotExists(t, env.WorkspacePath(name))
+	}
+	if result := refused["fork"]; !strings.Contains(result.Stdout+result.Stderr, "wsm delete feat --remove-files") {
+		t.Errorf("the clean workspace should be suggested for deletion:\n%s%s", result.Stdout, result.Stderr)
+	}
+
+	env.MustRun(cmds.NewRespinCommand(), "feat", "--branch", "feature/y", "--no-fetch", "--no-bootstrap", "--ignore-quota")
+	assertExists(t, filepath.Join(env.WorkspacePath("y"), "lib"))
+}
+
+func TestSyncPullMergesOrRebasesAfterFetching(t *testing.T) {
+	env := setupRepos(t)
+	env.MustRun(cmds.NewCreateCommand(), "feat", "--repos", "lib", "--branch", "feature/x", "--no-bootstrap")
+	worktree := filepath.Join(env.WorkspacePath("feat"), "lib")
+	env.Git(worktree, "push", "--quiet", "-u", "origin", "feature/x")
+
+	other := filepath.Join(t.TempDir(), "lib")
+	env.Git(env.RemoteDir, "clone", "--quiet", "--branch", "feature/x", filepath.Join(env.RemoteDir, "lib.git"), other)
+	pushUpstream := func(file string) string {
+		env.WriteFile(filepath.Join(other, file), "upstream\n")
+		commit := env.Commit(other, "Upstream "+file)
+		env.Git(other, "push", "--quiet", "origin", "feature/x")
+		return commit
+	}
+	t.Chdir(env.WorkspacePath("feat"))
+
+	// pull.rebase is honoured like git pull does
+	env.Git(worktree, "config", "pull.rebase", "true")
+	upstream := pushUpstream("a.txt")
+	env.WriteFile(filepath.Join(worktree, "local-a.txt"), "local\n")
+	env.Commit(worktree, "Local a")
+	env.MustRun(cmds.NewSyncCommand(), "pull", "--skip-preflight")
+	if parent := env.Git(worktree, "rev-parse", "HEAD^"); parent != upstream {
+		t.Errorf("expected the local commit to be rebased onto %s, its parent is %s", upstream, parent)
+	}
+
+	env.Git(worktree, "config", "pull.rebase", "false")
+	upstream = pushUpstream("b.txt")
+	env.WriteFile(filepath.Join(worktree, "local-b.txt"), "local\n")
+	env.Commit(worktree, "Local b")
+	env.MustRun(cmds.NewSyncCommand(), "pull", "--skip-preflight")
+	if parents := strings.Fields(env.Git(worktree, "rev-list", "--parents", "-n", "1", "HEAD")); len(parents) != 3 || parents[2] != upstream {
+		t.Errorf("expected a merge of %s, got %v", upstream, parents)
+	}
+}
+
+func TestAddOnlyReusesAnExistingBranchWhenAsked(t *testing.T) {
+	env := setupRepos(t)
+	app := filepath.Join(env.CodeDir, "app")
+	env.Git(app, "checkout", "--quiet", "-b", "feature/x")
+	env.WriteFile(filepath.Join(app, "wip.txt"), "wip\n")
+	wip := env.Commit(app, "Work in progress")
+	env.Git(app, "checkout", "--quiet", "main")
+	env.MustRun(cmds.NewCreateCommand(), "feat", "--repos", "lib", "--branch", "feature/x", "--no-bootstrap")
+
+	result := env.Run(cmds.NewAddCommand(), "feat", "app")
+	if result.Err == nil || !strings.Contains(result.Err.Error(), "--use-existing") {
+		t.Fatalf("adding a repository on an existing branch should fail without a terminal, got %v", result.Err)
+	}
+	assertNotExists(t, filepath.Join(env.WorkspacePath("feat"), "app"))
+
+	env.MustRun(cmds.NewAddCommand(), "feat", "app", "--use-existing")
+	if head := env.Git(filepath.Join(env.WorkspacePath("feat"), "app"), "rev-parse", "HEAD"); head != wip {
+		t.Errorf("expected the existing branch at %s, got %s", wip, head)
+	}
+}
+
+func TestCreateClonedWorkspaceTakesTheModeAsValue(t *testing.T) {
+	env := setupRepos(t)
+	env.MustRun(cmds.NewCreateCommand(), "rewrite", "--repos", "lib", "--clone", "full", "--branch", "feature/x", "--no-bootstrap")
+
+	clone := filepath.Join(env.WorkspacePath("rewrite"), "lib")
+	if info, err := os.Stat(filepath.Join(clone, ".git")); err != nil || !info.IsDir() {
+		t.Fatalf("expected a clone with its own .git directory: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(clone, ".git", "objects", "info", "alternates")); err == nil {
+		t.Errorf("a full clone should not borrow the objects of the source")
+	}
+	if workspace := env.LoadWorkspace("rewrite"); workspace.Clone != wsm.CloneFull {
+		t.Errorf("clone mode = %q, want %q", workspace.Clone, wsm.CloneFull)
+	}
+	if n := worktreeCount(env, "lib"); n != 1 {
+		t.Errorf("lib: expected no new worktree, got %d worktrees", n)
+	}
+
+	if result := env.Run(cmds.NewCreateCommand(), "other", "--repos", "lib", "--clone"); result.Err == nil {
+		t.Errorf("expected --clone without a mode to be rejected")
+	}
+	assertNotExists(t, env.WorkspacePath("other"))
+}
+
+func TestRebaseResolvesRepositoryAliases(t *testing.T) {
+	env := setupRepos(t)
+	env.MustRun(cmds.NewCreateCommand(), "feat", "--repos", "lib,app", "--branch", "feature/x", "--no-bootstrap")
+	env.MustRun(cmds.NewAliasAddCommand(), "l", "lib")
+	t.Chdir(env.WorkspacePath("feat"))
+
+	result := env.MustRun(cmds.NewRebaseCommand(), "l", "--dry-run")
+	if strings.Contains(result.Stdout, "not found") || !strings.Contains(result.Stdout, "lib") {
+		t.Errorf("rebase of the alias:\n%s", result.Stdout)
+	}
+}
+
+func TestDiscoverScansGivenPathsWithBrokenConfig(t *testing.T) {
+	env := testkit.New(t)
+	env.NewRepo("lib", map[string]string{"README.md": "# lib\n"})
+	env.WriteFile(filepath.Join(env.ConfigDir, "config.yaml"), "discovery: [\n")
+
+	result := env.MustRun(cmds.NewDiscoverCommand(), env.CodeDir)
+	if !strings.Contains(result.Stdout+result.Stderr, "Ignoring the discovery settings") {
+		t.Errorf("expected a warning about config.yaml:\n%s%s", result.Stdout, result.Stderr)
+	}
+	registry, err := os.ReadFile(env.RegistryPath())
+	if err != nil || !strings.Contains(string(registry), `"lib"`) {
+		t.Errorf("lib should be registered (%v):\n%s", err, registry)
+	}
+
+	// Without paths, the configured discovery paths cannot be read
+	if result := env.Run(cmds.NewDiscoverCommand()); result.Err == nil || !strings.Contains(result.Err.Error(), "configuration") {
+		t.Errorf("expected a configuration error, got %v", result.Err)
+	}
+}
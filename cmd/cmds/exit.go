@@ -0,0 +1,18 @@
+package cmds
+
+import (
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// silenceReported keeps cobra from printing the error and the usage of a command that ended with
+// an ExitCodeError: the command, or the remote wsm, has reported the failure itself
+func silenceReported(cmd *cobra.Command, err error) error {
+	var exitErr *wsm.ExitCodeError
+	if errors.As(err, &exitErr) {
+		cmd.SilenceErrors = true
+		cmd.SilenceUsage = true
+	}
+	return err
+}
@@ -0,0 +1,83 @@
+package cmds
+
+import (
+	"os"
+	"strings"
+
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// localAnnotation marks commands that handle --host themselves instead of being proxied
+const localAnnotation = "wsm/local"
+
+// ProxyToHost replaces the command's action with running the same command line through the wsm
+// of a remote host over SSH. Commands annotated as local, help and completion run unchanged.
+func ProxyToHost(cmd *cobra.Command, hostName string) error {
+	if isLocalCommand(cmd) {
+		return nil
+	}
+
+	config, err := wsm.LoadConfig()
+	if err != nil {
+		return errors.Wrap(err, "failed to load configuration")
+	}
+	host := wsm.ResolveRemoteHost(config, hostName)
+	args := stripHostFlag(os.Args[1:])
+	tty := output.IsTerminal(os.Stdin) && output.IsTerminal(os.Stdout)
+
+	cmd.Run = nil
+	cmd.RunE = func(cmd *cobra.Command, _ []string) error {
+		// The remote wsm reports its own errors
+		return silenceReported(cmd, host.RunRemote(cmd.Context(), hostName, tty, args...))
+	}
+	return nil
+}
+
+// remoteHost returns the host selected with --host, if any
+func remoteHost(cmd *cobra.Command) (string, wsm.RemoteHost, error) {
+	hostName, _ := cmd.Flags().GetString("host")
+	if hostName == "" {
+		return "", wsm.RemoteHost{}, nil
+	}
+	config, err := wsm.LoadConfig()
+	if err != nil {
+		return "", wsm.RemoteHost{}, errors.Wrap(err, "failed to load configuration")
+	}
+	return hostName, wsm.ResolveRemoteHost(config, hostName), nil
+}
+
+func isLocalCommand(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		if c.Annotations[localAnnotation] == "true" {
+			return true
+		}
+		switch c.Name() {
+		case "help", "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd, "_carapace":
+			return true
+		}
+	}
+	return false
+}
+
+// stripHostFlag removes --host from the command line forwarded to the remote wsm
+func stripHostFlag(args []string) []string {
+	var stripped []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return append(stripped, args[i:]...)
+		}
+		if arg == "--host" {
+			i++
+			continue
+		}
+		if strings.HasPrefix(arg, "--host=") {
+			continue
+		}
+		stripped = append(stripped, arg)
+	}
+	return stripped
+}
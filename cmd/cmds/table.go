@@ -0,0 +1,52 @@
+package cmds
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+// tableOptions holds the --columns and --sort flags of table-printing commands
+type tableOptions struct {
+	columns []string
+	sortBy  string
+	// groupBy splits the status table into a table per repository category ("tag")
+	groupBy string
+}
+
+// addTableFlags registers --columns and --sort, listing the available columns in the help text
+func addTableFlags(cmd *cobra.Command, columns *[]string, sortBy *string, available []output.Column) {
+	names := strings.Join(output.ColumnNames(available), ",")
+	cmd.Flags().StringSliceVar(columns, "columns", nil, fmt.Sprintf("Columns to show in table output (available: %s)", names))
+	cmd.Flags().StringVar(sortBy, "sort", "", "Sort table rows by a column, e.g. \"name\", \"behind desc\" or \"-behind\"")
+}
+
+// renderTable applies column selection and sorting, then prints the table to stdout
+func renderTable(table *output.Table, opts tableOptions) error {
+	if err := table.SelectColumns(opts.columns); err != nil {
+		return err
+	}
+	if err := table.SortBy(opts.sortBy); err != nil {
+		return err
+	}
+	return table.Render(os.Stdout)
+}
+
+// porcelainOutput returns whether to print porcelain records. Styled output is only the default on
+// a terminal: when stdout is redirected and neither --porcelain nor one of the given output flags
+// was set, porcelain is used. --porcelain=false keeps the styled output.
+func porcelainOutput(cmd *cobra.Command, outputFlags ...string) bool {
+	if cmd.Flags().Changed("porcelain") {
+		porcelain, _ := cmd.Flags().GetBool("porcelain")
+		return porcelain
+	}
+	for _, name := range outputFlags {
+		if cmd.Flags().Changed(name) {
+			return false
+		}
+	}
+	return !output.IsTerminal(os.Stdout)
+}
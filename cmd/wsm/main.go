@@ -5,6 +5,8 @@ import (
 	"os"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/pkg/errors"
 )
 
 var (
@@ -15,6 +17,12 @@ var (
 
 func main() {
 	if err := Execute(); err != nil {
+		// The command or the remote wsm already printed the error, only forward the exit status
+		var exitErr *wsm.ExitCodeError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
+		}
+
 		// Since we handle cancellations at command level, any error reaching here is a real error
 		errorMsg := errorStyle.Render("✗ Error: " + err.Error())
 		fmt.Fprintln(os.Stderr, errorMsg)
@@ -4,8 +4,11 @@ import (
 	"github.com/go-go-golems/glazed/pkg/cmds/logging"
 	"github.com/go-go-golems/workspace-manager/cmd/cmds"
 	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 
 	"github.com/carapace-sh/carapace"
 	clay "github.com/go-go-golems/clay/pkg"
@@ -36,10 +39,42 @@ Examples:
   # Check status across all workspace repositories
   wsm status
 
+  # Use the separate registry, config and workspaces of the "work" profile
+  wsm --profile work list workspaces
+
+  # Run a command against workspaces on a dev server over SSH
+  wsm --host dev1 status my-feature
+
   # Interactive mode
   `,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		return logging.InitLoggerFromViper()
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		output.SetQuiet(quiet)
+		noInput, _ := cmd.Flags().GetBool("no-input")
+		output.SetNoInput(noInput)
+		noPager, _ := cmd.Flags().GetBool("no-pager")
+		output.SetNoPager(noPager)
+		output.ConfigureTerminal()
+		if profile, _ := cmd.Flags().GetString("profile"); profile != "" {
+			if err := wsm.SetProfile(profile); err != nil {
+				return err
+			}
+		}
+		if err := logging.InitLoggerFromViper(); err != nil {
+			return err
+		}
+		// Log lines on stderr are printed above the progress bars instead of through them
+		if viper.GetString("log-file") == "" && !viper.GetBool("logstash-enabled") {
+			if viper.GetString("log-format") == "text" {
+				log.Logger = log.Output(zerolog.ConsoleWriter{Out: output.Stderr})
+			} else {
+				log.Logger = log.Output(output.Stderr)
+			}
+		}
+		if host, _ := cmd.Flags().GetString("host"); host != "" {
+			return cmds.ProxyToHost(cmd, host)
+		}
+		return nil
 	},
 }
 
@@ -54,28 +89,96 @@ func init() {
 		log.Fatal().Err(err).Msg("Failed to initialize Viper")
 	}
 
+	rootCmd.PersistentFlags().BoolP("quiet", "q", false, "Only print errors and requested data")
+	rootCmd.PersistentFlags().Bool("no-pager", false, "Do not pipe long output (diff, log, status) into a pager")
+	rootCmd.PersistentFlags().String("host", "", "Run the command with the wsm of a remote host over SSH (see remote.hosts in config.yaml)")
+	rootCmd.PersistentFlags().String("profile", "", "Profile with its own config, registry and workspaces (default $WSM_PROFILE, else \"default\")")
+	rootCmd.PersistentFlags().Bool("no-input", false, "Never prompt; fail or use defaults instead (also implied without a terminal or in CI)")
+
 	// Add all subcommands
 	rootCmd.AddCommand(
+		cmds.NewInitCommand(),
 		cmds.NewDiscoverCommand(),
+		cmds.NewAliasCommand(),
+		cmds.NewRepoCommand(),
+		cmds.NewRegistryCommand(),
+		cmds.NewMigrateCommand(),
 		cmds.NewListCommand(),
 		cmds.NewCreateCommand(),
+		cmds.NewApplyCommand(),
+		cmds.NewApplyPRCommand(),
+		cmds.NewSetupCommand(),
 		cmds.NewForkCommand(),
+		cmds.NewRespinCommand(),
 		cmds.NewMergeCommand(),
 		cmds.NewAddCommand(),
 		cmds.NewRemoveCommand(),
+		cmds.NewFreezeCommand(),
+		cmds.NewUnfreezeCommand(),
+		cmds.NewLabelCommand(),
+		cmds.NewWorktreeCommand(),
+		cmds.NewGitConfigCommand(),
+		cmds.NewChildCommand(),
+		cmds.NewLinkCommand(),
 		cmds.NewDeleteCommand(),
+		cmds.NewUndeleteCommand(),
+		cmds.NewReconcileCommand(),
+		cmds.NewExportCommand(),
+		cmds.NewImportBundleCommand(),
+		cmds.NewShareCommand(),
 		cmds.NewInfoCommand(),
 		cmds.NewStatusCommand(),
+		cmds.NewTmuxCommand(),
+		cmds.NewTermCommand(),
+		cmds.NewContainerCommand(),
+		cmds.NewUpCommand(),
+		cmds.NewDownCommand(),
+		cmds.NewPsCommand(),
+		cmds.NewLogsCommand(),
+		cmds.NewTailCommand(),
+		cmds.NewNixCommand(),
+		cmds.NewToolsCommand(),
+		cmds.NewBroadcastCommand(),
+		cmds.NewRenameSymbolCommand(),
+		cmds.NewRewriteModuleCommand(),
+		cmds.NewDecryptCommand(),
+		cmds.NewShowCommand(),
+		cmds.NewValidateCommand(),
 		cmds.NewPRCommand(),
+		cmds.NewLintCommand(),
+		cmds.NewPolicyCommand(),
 		cmds.NewPushCommand(),
+		cmds.NewUnpushedCommand(),
 
 		cmds.NewCommitCommand(),
+		cmds.NewPrecommitCommand(),
 		cmds.NewSyncCommand(),
+		cmds.NewPreflightCommand(),
+		cmds.NewPrefetchCommand(),
+		cmds.NewDaemonCommand(),
+		cmds.NewEventsCommand(),
+		cmds.NewWebhookCommand(),
 		cmds.NewBranchCommand(),
+		cmds.NewSwitchCommand(),
 		cmds.NewRebaseCommand(),
+		cmds.NewDriftCommand(),
+		cmds.NewResolveCommand(),
 		cmds.NewDiffCommand(),
+		cmds.NewDiffWorkspacesCommand(),
+		cmds.NewReviewCommand(),
+		cmds.NewPatchCommand(),
 		cmds.NewLogCommand(),
+		cmds.NewChangesCommand(),
+		cmds.NewTodosCommand(),
+		cmds.NewWatchCommand(),
+		cmds.NewStatsCommand(),
+		cmds.NewHistoryCommand(),
+		cmds.NewMetricsCommand(),
+		cmds.NewBenchCommand(),
+		cmds.NewProfileCommand(),
 	)
 
-	carapace.Gen(rootCmd)
+	carapace.Gen(rootCmd).FlagCompletion(carapace.ActionMap{
+		"profile": cmds.ProfileCompletion(),
+	})
 }
@@ -0,0 +1,171 @@
+package testkit
+
+import (
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+)
+
+var _ wsm.FS = (*MemFS)(nil)
+
+// MemFS is an in-memory wsm.FS for unit tests that must not touch the disk
+type MemFS struct {
+	mu    sync.Mutex
+	files map[string][]byte
+	dirs  map[string]bool
+}
+
+// NewMemFS creates an empty in-memory file system containing only the root directory
+func NewMemFS() *MemFS {
+	return &MemFS{files: map[string][]byte{}, dirs: map[string]bool{"/": true}}
+}
+
+func clean(name string) string {
+	return filepath.ToSlash(filepath.Clean(name))
+}
+
+func notExist(op, name string) error {
+	return &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+}
+
+// Stat implements wsm.FS
+func (m *MemFS) Stat(name string) (fs.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name = clean(name)
+	if data, ok := m.files[name]; ok {
+		return memInfo{name: path.Base(name), size: int64(len(data)), mode: 0644}, nil
+	}
+	if m.dirs[name] {
+		return memInfo{name: path.Base(name), mode: fs.ModeDir | 0755}, nil
+	}
+	return nil, notExist("stat", name)
+}
+
+// Lstat implements wsm.FS; MemFS has no symlinks
+func (m *MemFS) Lstat(name string) (fs.FileInfo, error) {
+	return m.Stat(name)
+}
+
+// ReadFile implements wsm.FS
+func (m *MemFS) ReadFile(name string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	data, ok := m.files[clean(name)]
+	if !ok {
+		return nil, notExist("open", name)
+	}
+	return append([]byte(nil), data...), nil
+}
+
+// WriteFile implements wsm.FS. Like os.WriteFile, it fails when the parent directory is missing.
+func (m *MemFS) WriteFile(name string, data []byte, _ fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name = clean(name)
+	if !m.dirs[path.Dir(name)] {
+		return notExist("open", name)
+	}
+	m.files[name] = append([]byte(nil), data...)
+	return nil
+}
+
+// ReadDir implements wsm.FS
+func (m *MemFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name = clean(name)
+	if !m.dirs[name] {
+		return nil, notExist("open", name)
+	}
+
+	var entries []fs.DirEntry
+	for file, data := range m.files {
+		if path.Dir(file) == name {
+			entries = append(entries, fs.FileInfoToDirEntry(memInfo{name: path.Base(file), size: int64(len(data)), mode: 0644}))
+		}
+	}
+	for dir := range m.dirs {
+		if dir != name && path.Dir(dir) == name {
+			entries = append(entries, fs.FileInfoToDirEntry(memInfo{name: path.Base(dir), mode: fs.ModeDir | 0755}))
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+// MkdirAll implements wsm.FS
+func (m *MemFS) MkdirAll(name string, _ fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for dir := clean(name); !m.dirs[dir]; dir = path.Dir(dir) {
+		m.dirs[dir] = true
+	}
+	return nil
+}
+
+// Remove implements wsm.FS
+func (m *MemFS) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name = clean(name)
+	if _, ok := m.files[name]; ok {
+		delete(m.files, name)
+		return nil
+	}
+	if !m.dirs[name] {
+		return notExist("remove", name)
+	}
+	for other := range m.files {
+		if path.Dir(other) == name {
+			return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrExist}
+		}
+	}
+	delete(m.dirs, name)
+	return nil
+}
+
+// RemoveAll implements wsm.FS
+func (m *MemFS) RemoveAll(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name = clean(name)
+	prefix := strings.TrimSuffix(name, "/") + "/"
+	for file := range m.files {
+		if file == name || strings.HasPrefix(file, prefix) {
+			delete(m.files, file)
+		}
+	}
+	for dir := range m.dirs {
+		if dir != "/" && (dir == name || strings.HasPrefix(dir, prefix)) {
+			delete(m.dirs, dir)
+		}
+	}
+	return nil
+}
+
+type memInfo struct {
+	name string
+	size int64
+	mode fs.FileMode
+}
+
+func (i memInfo) Name() string       { return i.name }
+func (i memInfo) Size() int64        { return i.size }
+func (i memInfo) Mode() fs.FileMode  { return i.mode }
+func (i memInfo) ModTime() time.Time { return time.Time{} }
+func (i memInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i memInfo) Sys() any           { return nil }
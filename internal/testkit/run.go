@@ -0,0 +1,97 @@
+package testkit
+
+import (
+	"bytes"
+	"io"
+	"os"
+
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+// Result is the outcome of a command run
+type Result struct {
+	Stdout string
+	Stderr string
+	Err    error
+}
+
+// Run executes a command with args and captures what it writes to os.Stdout and os.Stderr.
+// Output is normalized with Normalize.
+func (e *Env) Run(cmd *cobra.Command, args ...string) Result {
+	e.t.Helper()
+	return e.run(cmd, false, args)
+}
+
+// RunQuiet is Run with the global --quiet flag set
+func (e *Env) RunQuiet(cmd *cobra.Command, args ...string) Result {
+	e.t.Helper()
+	return e.run(cmd, true, args)
+}
+
+func (e *Env) run(cmd *cobra.Command, quiet bool, args []string) Result {
+	e.t.Helper()
+
+	// Commands set process-wide output state (e.g. --porcelain implies quiet); start from scratch
+	output.SetQuiet(quiet)
+
+	cmd.SetArgs(args)
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	var err error
+	stdout, stderr := capture(e, func() {
+		err = cmd.Execute()
+	})
+	return Result{Stdout: e.Normalize(stdout), Stderr: e.Normalize(stderr), Err: err}
+}
+
+// MustRun is Run that fails the test when the command returns an error
+func (e *Env) MustRun(cmd *cobra.Command, args ...string) Result {
+	e.t.Helper()
+
+	result := e.Run(cmd, args...)
+	if result.Err != nil {
+		e.t.Fatalf("%s %v failed: %v\nstdout:\n%s\nstderr:\n%s", cmd.Name(), args, result.Err, result.Stdout, result.Stderr)
+	}
+	return result
+}
+
+// capture swaps os.Stdout and os.Stderr for pipes while fn runs. Stdin is /dev/null so that
+// commands never wait for input.
+func capture(e *Env, fn func()) (string, string) {
+	e.t.Helper()
+
+	stdoutR, stdoutW, err := os.Pipe()
+	if err != nil {
+		e.t.Fatalf("failed to create pipe: %v", err)
+	}
+	stderrR, stderrW, err := os.Pipe()
+	if err != nil {
+		e.t.Fatalf("failed to create pipe: %v", err)
+	}
+	devNull, err := os.Open(os.DevNull)
+	if err != nil {
+		e.t.Fatalf("failed to open %s: %v", os.DevNull, err)
+	}
+	defer devNull.Close()
+
+	origStdout, origStderr, origStdin := os.Stdout, os.Stderr, os.Stdin
+	os.Stdout, os.Stderr, os.Stdin = stdoutW, stderrW, devNull
+
+	var stdout, stderr bytes.Buffer
+	done := make(chan struct{}, 2)
+	go func() { _, _ = io.Copy(&stdout, stdoutR); done <- struct{}{} }()
+	go func() { _, _ = io.Copy(&stderr, stderrR); done <- struct{}{} }()
+
+	defer func() {
+		os.Stdout, os.Stderr, os.Stdin = origStdout, origStderr, origStdin
+	}()
+	fn()
+
+	_ = stdoutW.Close()
+	_ = stderrW.Close()
+	<-done
+	<-done
+	return stdout.String(), stderr.String()
+}
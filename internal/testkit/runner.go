@@ -0,0 +1,81 @@
+package testkit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+)
+
+var _ wsm.CommandRunner = (*FakeRunner)(nil)
+
+// FakeRunner is a wsm.CommandRunner that answers from canned responses instead of spawning
+// processes. Responses are keyed by the command line ("git status --porcelain"), regardless of
+// the directory unless given with OnIn or FailIn; unknown commands fail.
+type FakeRunner struct {
+	mu        sync.Mutex
+	responses map[string]fakeResponse
+	// Calls records every command run, as "<dir>: <command line>"
+	Calls []string
+}
+
+type fakeResponse struct {
+	output string
+	err    error
+}
+
+// NewFakeRunner creates a runner without responses
+func NewFakeRunner() *FakeRunner {
+	return &FakeRunner{responses: map[string]fakeResponse{}}
+}
+
+// On makes the command line succeed with output
+func (r *FakeRunner) On(commandLine, output string) *FakeRunner {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.responses[commandLine] = fakeResponse{output: output}
+	return r
+}
+
+// Fail makes the command line fail with err
+func (r *FakeRunner) Fail(commandLine string, err error) *FakeRunner {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.responses[commandLine] = fakeResponse{err: err}
+	return r
+}
+
+// OnIn makes the command line succeed with output when run in dir, overriding On
+func (r *FakeRunner) OnIn(dir, commandLine, output string) *FakeRunner {
+	return r.On(dir+": "+commandLine, output)
+}
+
+// FailIn makes the command line fail with err when run in dir, overriding On
+func (r *FakeRunner) FailIn(dir, commandLine string, err error) *FakeRunner {
+	return r.Fail(dir+": "+commandLine, err)
+}
+
+// Output implements wsm.CommandRunner
+func (r *FakeRunner) Output(_ context.Context, dir, name string, args ...string) ([]byte, error) {
+	commandLine := strings.Join(append([]string{name}, args...), " ")
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Calls = append(r.Calls, dir+": "+commandLine)
+
+	response, ok := r.responses[dir+": "+commandLine]
+	if !ok {
+		response, ok = r.responses[commandLine]
+	}
+	if !ok {
+		return nil, fmt.Errorf("unexpected command: %s", commandLine)
+	}
+	return []byte(response.output), response.err
+}
+
+// CombinedOutput implements wsm.CommandRunner
+func (r *FakeRunner) CombinedOutput(ctx context.Context, dir, name string, args ...string) ([]byte, error) {
+	return r.Output(ctx, dir, name, args...)
+}
@@ -0,0 +1,205 @@
+// Package testkit provides isolated environments for end-to-end tests: temporary git repositories
+// with bare remotes, a repository registry and a workspace-manager configuration, all below
+// t.TempDir(), plus helpers to run commands and compare their output with golden files.
+package testkit
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+	"github.com/rs/zerolog"
+)
+
+// Env is an isolated workspace-manager environment. HOME and the user config directory point
+// below Root, so configuration, registry, workspaces and history never touch the real ones.
+type Env struct {
+	t *testing.T
+
+	// Root is the temporary directory everything lives in
+	Root string
+	// Home is the fake home directory
+	Home string
+	// ConfigDir is the workspace-manager configuration directory
+	ConfigDir string
+	// CodeDir holds the clones registered by Discover
+	CodeDir string
+	// RemoteDir holds the bare repositories used as origin
+	RemoteDir string
+	// WorkspaceDir is where workspaces are created
+	WorkspaceDir string
+}
+
+// New creates an environment and points HOME, XDG_CONFIG_HOME and the git identity at it for the
+// duration of the test. Commits get fixed dates so hashes are reproducible.
+func New(t *testing.T) *Env {
+	t.Helper()
+
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to resolve temp dir: %v", err)
+	}
+
+	e := &Env{
+		t:            t,
+		Root:         root,
+		Home:         filepath.Join(root, "home"),
+		CodeDir:      filepath.Join(root, "code"),
+		RemoteDir:    filepath.Join(root, "remotes"),
+		WorkspaceDir: filepath.Join(root, "workspaces"),
+	}
+	e.ConfigDir = filepath.Join(e.Home, ".config", "workspace-manager")
+
+	for _, dir := range []string{e.Home, e.ConfigDir, e.CodeDir, e.RemoteDir, e.WorkspaceDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("failed to create %s: %v", dir, err)
+		}
+	}
+
+	env := map[string]string{
+		"HOME":                e.Home,
+		"XDG_CONFIG_HOME":     filepath.Join(e.Home, ".config"),
+		"XDG_DATA_HOME":       filepath.Join(e.Home, ".local", "share"),
+		"GIT_CONFIG_NOSYSTEM": "1",
+		"GIT_AUTHOR_NAME":     "Test User",
+		"GIT_AUTHOR_EMAIL":    "test@example.com",
+		"GIT_AUTHOR_DATE":     "2024-01-01T12:00:00Z",
+		"GIT_COMMITTER_NAME":  "Test User",
+		"GIT_COMMITTER_EMAIL": "test@example.com",
+		"GIT_COMMITTER_DATE":  "2024-01-01T12:00:00Z",
+		"NO_COLOR":            "1",
+		"CI":                  "true",
+		"TMUX":                "",
+	}
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+
+	zerolog.SetGlobalLevel(zerolog.WarnLevel)
+	output.SetNoInput(true)
+	output.SetNoPager(true)
+	output.ConfigureTerminal()
+
+	e.WriteFile(filepath.Join(e.ConfigDir, "config.yaml"), "workspace_dir: "+e.WorkspaceDir+"\n")
+
+	return e
+}
+
+// Git runs git in dir and returns its trimmed output, failing the test on error
+func (e *Env) Git(dir string, args ...string) string {
+	e.t.Helper()
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		e.t.Fatalf("git %s in %s failed: %v\n%s", strings.Join(args, " "), dir, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// WriteFile writes content to path, creating parent directories
+func (e *Env) WriteFile(path, content string) {
+	e.t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		e.t.Fatalf("failed to create directory for %s: %v", path, err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		e.t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+// NewRepo creates a repository with a bare origin: the files are committed on main, pushed, and
+// the clone is left in CodeDir/<name>. It returns the clone path.
+func (e *Env) NewRepo(name string, files map[string]string) string {
+	e.t.Helper()
+
+	remote := filepath.Join(e.RemoteDir, name+".git")
+	e.Git(e.RemoteDir, "init", "--quiet", "--bare", "--initial-branch=main", remote)
+
+	clone := filepath.Join(e.CodeDir, name)
+	e.Git(e.CodeDir, "init", "--quiet", "--initial-branch=main", clone)
+	e.Git(clone, "remote", "add", "origin", remote)
+
+	if len(files) == 0 {
+		files = map[string]string{"README.md": "# " + name + "\n"}
+	}
+	for path, content := range files {
+		e.WriteFile(filepath.Join(clone, path), content)
+	}
+	e.Commit(clone, "Initial commit")
+
+	e.Git(clone, "push", "--quiet", "-u", "origin", "main")
+	e.Git(clone, "remote", "set-head", "origin", "main")
+	return clone
+}
+
+// Commit stages everything in dir and commits it
+func (e *Env) Commit(dir, message string) string {
+	e.t.Helper()
+
+	e.Git(dir, "add", "-A")
+	e.Git(dir, "commit", "--quiet", "-m", message)
+	return e.Git(dir, "rev-parse", "HEAD")
+}
+
+// RegistryPath is the path of the repository registry
+func (e *Env) RegistryPath() string {
+	return filepath.Join(e.ConfigDir, "registry.json")
+}
+
+// Discover registers all repositories in CodeDir
+func (e *Env) Discover() {
+	e.t.Helper()
+
+	output.SetQuiet(true)
+	defer output.SetQuiet(false)
+
+	discoverer := wsm.NewRepositoryDiscoverer(e.RegistryPath())
+	if err := discoverer.LoadRegistry(); err != nil {
+		e.t.Fatalf("failed to load registry: %v", err)
+	}
+	if err := discoverer.DiscoverRepositories(context.Background(), []string{e.CodeDir}, true, 2); err != nil {
+		e.t.Fatalf("failed to discover repositories: %v", err)
+	}
+	if err := discoverer.SaveRegistry(); err != nil {
+		e.t.Fatalf("failed to save registry: %v", err)
+	}
+}
+
+// WorkspacePath is where a workspace named name is created
+func (e *Env) WorkspacePath(name string) string {
+	return filepath.Join(e.WorkspaceDir, name)
+}
+
+// LoadWorkspace loads a workspace configuration, failing the test when it does not exist
+func (e *Env) LoadWorkspace(name string) *wsm.Workspace {
+	e.t.Helper()
+
+	wm, err := wsm.NewWorkspaceManager()
+	if err != nil {
+		e.t.Fatalf("failed to create workspace manager: %v", err)
+	}
+	workspace, err := wm.LoadWorkspace(name)
+	if err != nil {
+		e.t.Fatalf("failed to load workspace %s: %v", name, err)
+	}
+	return workspace
+}
+
+// Normalize replaces the temporary root with $ROOT and strips trailing whitespace, so output
+// can be compared across runs
+func (e *Env) Normalize(s string) string {
+	s = strings.ReplaceAll(s, e.Root, "$ROOT")
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,490 @@
+package output
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+)
+
+// DiffOptions configures RenderDiff
+type DiffOptions struct {
+	// Split shows the old and new version side by side instead of interleaved
+	Split bool
+	// Width is the width of the terminal; long lines wrap in split mode
+	Width int
+	// Dark selects colors for a dark terminal background
+	Dark bool
+}
+
+// diffTheme holds the colors of changed lines and the syntax highlighting style
+type diffTheme struct {
+	removed, removedEmph, added, addedEmph lipgloss.Color
+	syntax                                 *chroma.Style
+}
+
+var (
+	darkDiffTheme = diffTheme{
+		removed: "#3f0001", removedEmph: "#901011",
+		added: "#002800", addedEmph: "#006000",
+		syntax: styles.Get("monokai"),
+	}
+	lightDiffTheme = diffTheme{
+		removed: "#ffe0e0", removedEmph: "#ffb0b0",
+		added: "#dcffdc", addedEmph: "#a0efa0",
+		syntax: styles.Get("github"),
+	}
+)
+
+const (
+	diffTabWidth = 4
+	// diffMaxWordDiff bounds the token LCS of word-level highlighting (old tokens × new tokens)
+	diffMaxWordDiff = 40000
+	// diffMinSimilarity is the share of unchanged text below which a removed and an added line are
+	// considered unrelated, so no words are emphasized
+	diffMinSimilarity = 0.3
+)
+
+var (
+	hunkHeaderRegex = regexp.MustCompile(`^@@ -(\d+)(?:,\d+)? \+(\d+)(?:,\d+)? @@ ?(.*)$`)
+	diffTokenRegex  = regexp.MustCompile(`\w+|\s+|[^\w\s]`)
+)
+
+type diffLineKind int
+
+const (
+	diffContext diffLineKind = iota
+	diffRemoved
+	diffAdded
+)
+
+// diffSegment is a run of text with one syntax color, optionally emphasized as a word-level change
+type diffSegment struct {
+	text string
+	fg   string
+	emph bool
+}
+
+// diffLine is one content line of a hunk with its line numbers in the old and new file (0 for
+// the file it is not part of)
+type diffLine struct {
+	kind     diffLineKind
+	old, new int
+	segments []diffSegment
+}
+
+// RenderDiff renders a unified git diff for the terminal, like delta: a banner per file, syntax
+// highlighting, emphasized word-level changes between paired removed and added lines, and
+// optionally old and new side by side. Lines that are not part of a diff are kept unchanged.
+func RenderDiff(diff string, options DiffOptions) string {
+	r := &diffRenderer{options: options, theme: lightDiffTheme}
+	if options.Dark {
+		r.theme = darkDiffTheme
+	}
+	if r.options.Width <= 0 {
+		r.options.Width = 120
+	}
+
+	for _, line := range strings.Split(strings.TrimRight(diff, "\n"), "\n") {
+		r.feed(line)
+	}
+	r.flushBlock()
+	r.flushBanner()
+	return r.out.String()
+}
+
+type diffRenderer struct {
+	options DiffOptions
+	theme   diffTheme
+	out     strings.Builder
+
+	lexer            chroma.Lexer
+	inHunk           bool
+	banner           string
+	notes            []string
+	oldLine, newLine int
+	removed, added   []diffLine
+}
+
+func (r *diffRenderer) feed(line string) {
+	switch {
+	case strings.HasPrefix(line, "diff --git "):
+		r.flushBlock()
+		r.flushBanner()
+		r.inHunk = false
+		r.banner = GitDiffPath(line)
+		r.lexer = lexers.Match(r.banner)
+		if r.lexer != nil {
+			r.lexer = chroma.Coalesce(r.lexer)
+		}
+	case !r.inHunk && r.banner != "" && !strings.HasPrefix(line, "@@"):
+		r.feedHeader(line)
+	case strings.HasPrefix(line, "@@"):
+		r.flushBlock()
+		r.flushBanner()
+		r.inHunk = true
+		r.hunkHeader(line)
+	case r.inHunk && strings.HasPrefix(line, "-"):
+		r.removed = append(r.removed, diffLine{kind: diffRemoved, old: r.oldLine, segments: r.highlight(line[1:])})
+		r.oldLine++
+	case r.inHunk && strings.HasPrefix(line, "+"):
+		r.added = append(r.added, diffLine{kind: diffAdded, new: r.newLine, segments: r.highlight(line[1:])})
+		r.newLine++
+	case r.inHunk && strings.HasPrefix(line, " "):
+		r.flushBlock()
+		context := diffLine{kind: diffContext, old: r.oldLine, new: r.newLine, segments: r.highlight(strings.TrimPrefix(line, " "))}
+		if r.options.Split {
+			r.splitRow(&context, &context)
+		} else {
+			r.unifiedRow(&context)
+		}
+		r.oldLine++
+		r.newLine++
+	case r.inHunk && strings.HasPrefix(line, `\`):
+		r.flushBlock()
+		r.out.WriteString(DimStyle.Render(line) + "\n")
+	default:
+		r.flushBlock()
+		r.inHunk = false
+		r.out.WriteString(line + "\n")
+	}
+}
+
+// feedHeader collects what the extended header lines of a file say about it for the banner
+func (r *diffRenderer) feedHeader(line string) {
+	switch {
+	case strings.HasPrefix(line, "new file mode"):
+		r.notes = append(r.notes, "new file")
+	case strings.HasPrefix(line, "deleted file mode"):
+		r.notes = append(r.notes, "deleted")
+	case strings.HasPrefix(line, "rename from "):
+		r.notes = append(r.notes, "renamed from "+strings.TrimPrefix(line, "rename from "))
+	case strings.HasPrefix(line, "old mode "):
+		r.notes = append(r.notes, "mode "+strings.TrimPrefix(line, "old mode "))
+	case strings.HasPrefix(line, "new mode "):
+		r.notes = append(r.notes, "→ "+strings.TrimPrefix(line, "new mode "))
+	case strings.HasPrefix(line, "Binary files"):
+		r.notes = append(r.notes, "binary")
+	}
+}
+
+// GitDiffPath extracts the new path from a "diff --git a/<path> b/<path>" line
+func GitDiffPath(line string) string {
+	rest := strings.TrimPrefix(line, "diff --git ")
+	if i := strings.LastIndex(rest, " b/"); i >= 0 {
+		return rest[i+3:]
+	}
+	return rest
+}
+
+func (r *diffRenderer) flushBanner() {
+	if r.banner == "" {
+		return
+	}
+	title := InfoStyle.Bold(true).Render("▌ " + r.banner)
+	if len(r.notes) > 0 {
+		title += " " + DimStyle.Render("("+strings.Join(r.notes, ", ")+")")
+	}
+	r.out.WriteString("\n" + title + "\n" + InfoStyle.Render(strings.Repeat("─", r.options.Width)) + "\n")
+	r.banner = ""
+	r.notes = nil
+}
+
+func (r *diffRenderer) hunkHeader(line string) {
+	match := hunkHeaderRegex.FindStringSubmatch(line)
+	if match == nil {
+		r.out.WriteString(DimStyle.Render(line) + "\n")
+		return
+	}
+	r.oldLine, _ = strconv.Atoi(match[1])
+	r.newLine, _ = strconv.Atoi(match[2])
+	header := DimStyle.Render(fmt.Sprintf("@@ line %d @@", r.newLine))
+	if match[3] != "" {
+		header += " " + BoldStyle.Render(match[3])
+	}
+	r.out.WriteString(header + "\n")
+}
+
+// flushBlock renders the pending run of removed and added lines, pairing the i-th removed with
+// the i-th added line for word-level highlighting
+func (r *diffRenderer) flushBlock() {
+	if len(r.removed) == 0 && len(r.added) == 0 {
+		return
+	}
+	for i := 0; i < min(len(r.removed), len(r.added)); i++ {
+		emphasizeWordChanges(&r.removed[i], &r.added[i])
+	}
+
+	if r.options.Split {
+		for i := 0; i < max(len(r.removed), len(r.added)); i++ {
+			var left, right *diffLine
+			if i < len(r.removed) {
+				left = &r.removed[i]
+			}
+			if i < len(r.added) {
+				right = &r.added[i]
+			}
+			r.splitRow(left, right)
+		}
+	} else {
+		for i := range r.removed {
+			r.unifiedRow(&r.removed[i])
+		}
+		for i := range r.added {
+			r.unifiedRow(&r.added[i])
+		}
+	}
+	r.removed, r.added = nil, nil
+}
+
+// unifiedRow renders a line with the old and new line numbers in the gutter
+func (r *diffRenderer) unifiedRow(line *diffLine) {
+	gutter := DimStyle.Render(fmt.Sprintf("%4s %4s │", lineNumber(line.old), lineNumber(line.new)))
+	width := max(r.options.Width-lipgloss.Width(gutter)-1, 1)
+	rows := wrapSegments(line.segments, width)
+	for i, row := range rows {
+		if i > 0 {
+			gutter = DimStyle.Render(fmt.Sprintf("%4s %4s │", "", ""))
+		}
+		r.out.WriteString(gutter + r.renderRow(line.kind, row, width, i == 0) + "\n")
+	}
+}
+
+// splitRow renders the old line on the left and the new line on the right; either may be nil
+func (r *diffRenderer) splitRow(left, right *diffLine) {
+	half := max((r.options.Width-1)/2, 10)
+	width := half - 7
+
+	leftRows, rightRows := sideRows(left, width), sideRows(right, width)
+	for i := 0; i < max(len(leftRows), len(rightRows)); i++ {
+		r.out.WriteString(r.splitSide(left, leftRows, i, width, true) + " " + r.splitSide(right, rightRows, i, width, false) + "\n")
+	}
+}
+
+func lineNumber(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+func sideRows(line *diffLine, width int) [][]diffSegment {
+	if line == nil {
+		return nil
+	}
+	return wrapSegments(line.segments, width)
+}
+
+func (r *diffRenderer) splitSide(line *diffLine, rows [][]diffSegment, i, width int, old bool) string {
+	if line == nil || i >= len(rows) {
+		return DimStyle.Render("     │") + strings.Repeat(" ", width+1)
+	}
+	number := line.new
+	if old {
+		number = line.old
+	}
+	if i > 0 {
+		number = 0
+	}
+	return DimStyle.Render(fmt.Sprintf("%5s│", lineNumber(number))) + r.renderRow(line.kind, rows[i], width, i == 0)
+}
+
+// renderRow renders the sign and the segments of one visual row, filling it up to width with
+// the background color of changed lines (and with spaces in split mode, to align the right side)
+func (r *diffRenderer) renderRow(kind diffLineKind, row []diffSegment, width int, first bool) string {
+	var background, emphasis lipgloss.Color
+	sign := " "
+	switch kind {
+	case diffRemoved:
+		background, emphasis, sign = r.theme.removed, r.theme.removedEmph, "-"
+	case diffAdded:
+		background, emphasis, sign = r.theme.added, r.theme.addedEmph, "+"
+	}
+	if !first {
+		sign = " "
+	}
+
+	base := lipgloss.NewStyle()
+	if background != "" {
+		base = base.Background(background)
+	}
+
+	var b strings.Builder
+	b.WriteString(base.Render(sign))
+	used := 0
+	for _, segment := range row {
+		style := base
+		if segment.fg != "" {
+			style = style.Foreground(lipgloss.Color(segment.fg))
+		}
+		if segment.emph && emphasis != "" {
+			style = style.Background(emphasis)
+		}
+		b.WriteString(style.Render(segment.text))
+		used += runewidth.StringWidth(segment.text)
+	}
+	if used < width && (background != "" || r.options.Split) {
+		b.WriteString(base.Render(strings.Repeat(" ", width-used)))
+	}
+	return b.String()
+}
+
+// highlight splits a line into syntax-colored segments, expanding tabs
+func (r *diffRenderer) highlight(text string) []diffSegment {
+	text = expandTabs(text)
+	if r.lexer == nil {
+		return []diffSegment{{text: text}}
+	}
+	iterator, err := r.lexer.Tokenise(nil, text+"\n")
+	if err != nil {
+		return []diffSegment{{text: text}}
+	}
+	var segments []diffSegment
+	for _, token := range iterator.Tokens() {
+		value := strings.TrimSuffix(token.Value, "\n")
+		if value == "" {
+			continue
+		}
+		fg := ""
+		if entry := r.theme.syntax.Get(token.Type); entry.Colour.IsSet() {
+			fg = entry.Colour.String()
+		}
+		segments = append(segments, diffSegment{text: value, fg: fg})
+	}
+	return segments
+}
+
+func expandTabs(text string) string {
+	if !strings.Contains(text, "\t") {
+		return text
+	}
+	var b strings.Builder
+	column := 0
+	for _, r := range text {
+		if r == '\t' {
+			spaces := diffTabWidth - column%diffTabWidth
+			b.WriteString(strings.Repeat(" ", spaces))
+			column += spaces
+			continue
+		}
+		b.WriteRune(r)
+		column += runewidth.RuneWidth(r)
+	}
+	return b.String()
+}
+
+// emphasizeWordChanges marks the tokens that differ between a removed and an added line, unless
+// the lines have too little in common for the emphasis to help
+func emphasizeWordChanges(removed, added *diffLine) {
+	oldText, newText := segmentsText(removed.segments), segmentsText(added.segments)
+	oldTokens, newTokens := diffTokenRegex.FindAllString(oldText, -1), diffTokenRegex.FindAllString(newText, -1)
+	if len(oldTokens)*len(newTokens) > diffMaxWordDiff {
+		return
+	}
+
+	// Longest common subsequence of the tokens
+	lcs := make([][]int, len(oldTokens)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(newTokens)+1)
+	}
+	for i := len(oldTokens) - 1; i >= 0; i-- {
+		for j := len(newTokens) - 1; j >= 0; j-- {
+			if oldTokens[i] == newTokens[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	oldChanged, newChanged := make([]bool, len(oldText)), make([]bool, len(newText))
+	common := 0
+	i, j, oldOffset, newOffset := 0, 0, 0, 0
+	for i < len(oldTokens) || j < len(newTokens) {
+		switch {
+		case i < len(oldTokens) && j < len(newTokens) && oldTokens[i] == newTokens[j]:
+			common += len(oldTokens[i])
+			oldOffset += len(oldTokens[i])
+			newOffset += len(newTokens[j])
+			i++
+			j++
+		case j < len(newTokens) && (i == len(oldTokens) || lcs[i][j+1] >= lcs[i+1][j]):
+			markChanged(newChanged, newOffset, len(newTokens[j]))
+			newOffset += len(newTokens[j])
+			j++
+		default:
+			markChanged(oldChanged, oldOffset, len(oldTokens[i]))
+			oldOffset += len(oldTokens[i])
+			i++
+		}
+	}
+
+	if float64(common) < diffMinSimilarity*float64(max(len(oldText), len(newText))) {
+		return
+	}
+	removed.segments = splitEmphasis(removed.segments, oldChanged)
+	added.segments = splitEmphasis(added.segments, newChanged)
+}
+
+func markChanged(changed []bool, offset, length int) {
+	for k := offset; k < offset+length; k++ {
+		changed[k] = true
+	}
+}
+
+func segmentsText(segments []diffSegment) string {
+	var b strings.Builder
+	for _, segment := range segments {
+		b.WriteString(segment.text)
+	}
+	return b.String()
+}
+
+// splitEmphasis splits segments where the per-byte changed flags flip
+func splitEmphasis(segments []diffSegment, changed []bool) []diffSegment {
+	var result []diffSegment
+	offset := 0
+	for _, segment := range segments {
+		start := 0
+		for k := 1; k <= len(segment.text); k++ {
+			if k == len(segment.text) || changed[offset+k] != changed[offset+start] {
+				result = append(result, diffSegment{text: segment.text[start:k], fg: segment.fg, emph: changed[offset+start]})
+				start = k
+			}
+		}
+		offset += len(segment.text)
+	}
+	return result
+}
+
+// wrapSegments breaks segments into rows of at most width cells
+func wrapSegments(segments []diffSegment, width int) [][]diffSegment {
+	rows := [][]diffSegment{nil}
+	used := 0
+	for _, segment := range segments {
+		var current strings.Builder
+		for _, r := range segment.text {
+			w := runewidth.RuneWidth(r)
+			if used+w > width && used > 0 {
+				if current.Len() > 0 {
+					rows[len(rows)-1] = append(rows[len(rows)-1], diffSegment{text: current.String(), fg: segment.fg, emph: segment.emph})
+					current.Reset()
+				}
+				rows = append(rows, nil)
+				used = 0
+			}
+			current.WriteRune(r)
+			used += w
+		}
+		if current.Len() > 0 {
+			rows[len(rows)-1] = append(rows[len(rows)-1], diffSegment{text: current.String(), fg: segment.fg, emph: segment.emph})
+		}
+	}
+	return rows
+}
@@ -0,0 +1,100 @@
+package output
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGitDiffPath(t *testing.T) {
+	tests := map[string]string{
+		"diff --git a/main.go b/main.go":         "main.go",
+		"diff --git a/old name.go b/new name.go": "new name.go",
+		"diff --git a/a/b/file.go b/a/b/file.go": "a/b/file.go",
+		"diff --git a/old.go b/renamed.go":       "renamed.go",
+	}
+	for line, want := range tests {
+		if got := GitDiffPath(line); got != want {
+			t.Errorf("GitDiffPath(%q) = %q, want %q", line, got, want)
+		}
+	}
+}
+
+func emphasized(line diffLine) string {
+	var b strings.Builder
+	for _, segment := range line.segments {
+		if segment.emph {
+			b.WriteString("[" + segment.text + "]")
+		} else {
+			b.WriteString(segment.text)
+		}
+	}
+	return b.String()
+}
+
+func TestEmphasizeWordChanges(t *testing.T) {
+	tests := []struct {
+		old, new         string
+		wantOld, wantNew string
+	}{
+		{"return x + 1", "return x + 2", "return x + [1]", "return x + [2]"},
+		{"foo(a, b)", "foo(a, b, c)", "foo(a, b)", "foo(a, b[, c])"},
+		// Unrelated lines are not emphasized
+		{"import os", "func main() {}", "import os", "func main() {}"},
+	}
+	for _, tt := range tests {
+		removed := diffLine{kind: diffRemoved, segments: []diffSegment{{text: tt.old}}}
+		added := diffLine{kind: diffAdded, segments: []diffSegment{{text: tt.new}}}
+		emphasizeWordChanges(&removed, &added)
+		if got := emphasized(removed); got != tt.wantOld {
+			t.Errorf("removed %q = %q, want %q", tt.old, got, tt.wantOld)
+		}
+		if got := emphasized(added); got != tt.wantNew {
+			t.Errorf("added %q = %q, want %q", tt.new, got, tt.wantNew)
+		}
+	}
+}
+
+const testDiff = `On branch task/feat
+diff --git a/notes.txt b/notes.txt
+index 1111111..2222222 100644
+--- a/notes.txt
++++ b/notes.txt
+@@ -3,3 +3,3 @@ intro
+ first
+-second line
++second line, edited
+ third
+\ No newline at end of file
+diff --git a/logo.png b/logo.png
+new file mode 100644
+Binary files /dev/null and b/logo.png differ
+`
+
+func TestRenderDiff(t *testing.T) {
+	got := RenderDiff(testDiff, DiffOptions{Width: 60})
+	for _, want := range []string{
+		"On branch task/feat\n",
+		"▌ notes.txt\n",
+		"@@ line 3 @@ intro\n",
+		"   3    3 │ first",
+		"   4      │-second line",
+		"        4 │+second line, edited",
+		"   5    5 │ third",
+		"\\ No newline at end of file\n",
+		"▌ logo.png (new file, binary)\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("RenderDiff is missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "index 1111111") || strings.Contains(got, "+++ b/notes.txt") {
+		t.Errorf("RenderDiff kept the file header:\n%s", got)
+	}
+
+	split := RenderDiff(testDiff, DiffOptions{Width: 60, Split: true})
+	for _, line := range strings.Split(split, "\n") {
+		if strings.Contains(line, "second line") && !(strings.Contains(line, "-second line") && strings.Contains(line, "+second line, edited")) {
+			t.Errorf("split mode does not pair the changed lines: %q", line)
+		}
+	}
+}
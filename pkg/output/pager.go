@@ -0,0 +1,249 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/term"
+)
+
+// noPager disables paging, see SetNoPager
+var noPager bool
+
+// SetNoPager disables paging of long outputs
+func SetNoPager(n bool) {
+	noPager = n
+}
+
+// StartPager sends everything written to os.Stdout through a pager until the returned function
+// is called, which flushes the output and waits for the pager to exit.
+//
+// The pager is $WSM_PAGER or $PAGER, then "less -FRX", and finally an embedded pager with search
+// when less is not installed. Paging is skipped when stdout is not a terminal, in quiet mode,
+// with --no-pager, or when the pager is set to "cat".
+func StartPager() func() {
+	if noPager || quiet || !IsTerminal(os.Stdout) {
+		return func() {}
+	}
+
+	pager := os.Getenv("WSM_PAGER")
+	if pager == "" {
+		pager = os.Getenv("PAGER")
+	}
+	if pager == "cat" {
+		return func() {}
+	}
+	if pager == "" {
+		if _, err := exec.LookPath("less"); err == nil {
+			pager = "less"
+		}
+	}
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return func() {}
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+
+	if pager == "" {
+		return startEmbeddedPager(stdout, reader, writer)
+	}
+
+	cmd := exec.Command("sh", "-c", pager)
+	cmd.Stdin = reader
+	cmd.Stdout = stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+	if os.Getenv("LESS") == "" {
+		// Quit if the output fits on one screen, keep colors and don't clear the screen, like git
+		cmd.Env = append(cmd.Env, "LESS=FRX")
+	}
+	if err := cmd.Start(); err != nil {
+		os.Stdout = stdout
+		_ = writer.Close()
+		_ = reader.Close()
+		PrintWarning("Failed to start pager '%s': %v", pager, err)
+		return func() {}
+	}
+
+	return func() {
+		_ = writer.Close()
+		_ = cmd.Wait()
+		_ = reader.Close()
+		os.Stdout = stdout
+	}
+}
+
+// startEmbeddedPager buffers the output and shows it in a scrollable view once complete.
+// Output that fits on the screen is printed directly.
+func startEmbeddedPager(stdout, reader, writer *os.File) func() {
+	var buffer bytes.Buffer
+	done := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(&buffer, reader)
+		close(done)
+	}()
+
+	return func() {
+		_ = writer.Close()
+		<-done
+		_ = reader.Close()
+		os.Stdout = stdout
+
+		content := strings.TrimRight(buffer.String(), "\n")
+		_, height, err := term.GetSize(stdout.Fd())
+		if err != nil || strings.Count(content, "\n")+1 < height {
+			fmt.Fprintln(stdout, content)
+			return
+		}
+
+		program := tea.NewProgram(newPagerModel(content), tea.WithOutput(stdout), tea.WithAltScreen())
+		if _, err := program.Run(); err != nil {
+			fmt.Fprintln(stdout, content)
+		}
+	}
+}
+
+// pagerModel is a minimal less-like pager: arrows/pgup/pgdn to scroll, / to search, n/N for the
+// next/previous match and q to quit
+type pagerModel struct {
+	viewport viewport.Model
+	search   textinput.Model
+	lines    []string
+	matches  []int
+	match    int
+	ready    bool
+	content  string
+}
+
+func newPagerModel(content string) pagerModel {
+	search := textinput.New()
+	search.Prompt = "/"
+	return pagerModel{
+		search:  search,
+		lines:   strings.Split(content, "\n"),
+		content: content,
+	}
+}
+
+func (m pagerModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m pagerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		if !m.ready {
+			m.viewport = viewport.New(msg.Width, msg.Height-1)
+			m.viewport.SetContent(m.content)
+			m.ready = true
+		} else {
+			m.viewport.Width = msg.Width
+			m.viewport.Height = msg.Height - 1
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.search.Focused() {
+			switch msg.Type {
+			case tea.KeyEnter:
+				m.search.Blur()
+				m.findMatches(m.search.Value())
+				return m, nil
+			case tea.KeyEsc, tea.KeyCtrlC:
+				m.search.Blur()
+				m.search.SetValue("")
+				return m, nil
+			default:
+				var cmd tea.Cmd
+				m.search, cmd = m.search.Update(msg)
+				return m, cmd
+			}
+		}
+
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			return m, tea.Quit
+		case "/":
+			m.search.SetValue("")
+			cmd := m.search.Focus()
+			return m, cmd
+		case "n":
+			m.jumpToMatch(m.match + 1)
+			return m, nil
+		case "N":
+			m.jumpToMatch(m.match - 1)
+			return m, nil
+		case "g", "home":
+			m.viewport.GotoTop()
+			return m, nil
+		case "G", "end":
+			m.viewport.GotoBottom()
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+// findMatches collects the lines containing query (case-insensitive) and jumps to the first
+// match at or below the current position
+func (m *pagerModel) findMatches(query string) {
+	m.matches = nil
+	if query == "" {
+		return
+	}
+
+	query = strings.ToLower(query)
+	first := -1
+	for i, line := range m.lines {
+		if strings.Contains(strings.ToLower(line), query) {
+			if first == -1 && i >= m.viewport.YOffset {
+				first = len(m.matches)
+			}
+			m.matches = append(m.matches, i)
+		}
+	}
+	if first == -1 {
+		first = 0
+	}
+	m.jumpToMatch(first)
+}
+
+func (m *pagerModel) jumpToMatch(index int) {
+	if len(m.matches) == 0 {
+		return
+	}
+	m.match = (index + len(m.matches)) % len(m.matches)
+	m.viewport.SetYOffset(m.matches[m.match])
+}
+
+func (m pagerModel) View() string {
+	if !m.ready {
+		return ""
+	}
+
+	status := DimStyle.Render(fmt.Sprintf("lines %d-%d of %d  (/ search, n/N next/prev, q quit)",
+		m.viewport.YOffset+1, min(m.viewport.YOffset+m.viewport.Height, len(m.lines)), len(m.lines)))
+	if m.search.Focused() {
+		status = m.search.View()
+	} else if m.search.Value() != "" {
+		if len(m.matches) == 0 {
+			status = WarningStyle.Render(fmt.Sprintf("pattern not found: %s", m.search.Value())) + "  " + status
+		} else {
+			status = InfoStyle.Render(fmt.Sprintf("match %d/%d for '%s'", m.match+1, len(m.matches), m.search.Value())) + "  " + status
+		}
+	}
+
+	return m.viewport.View() + "\n" + status
+}
@@ -0,0 +1,50 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestPagerSearch(t *testing.T) {
+	lines := make([]string, 100)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i)
+	}
+	for _, i := range []int{5, 50, 90} {
+		lines[i] += " MATCH"
+	}
+	model, _ := newPagerModel(strings.Join(lines, "\n")).Update(tea.WindowSizeMsg{Width: 80, Height: 11})
+	m := model.(pagerModel)
+
+	// Matching ignores case and starts at the first match on screen or below
+	m.findMatches("match")
+	if fmt.Sprint(m.matches) != "[5 50 90]" || m.match != 0 || m.viewport.YOffset != 5 {
+		t.Fatalf("matches = %v, current %d at offset %d", m.matches, m.match, m.viewport.YOffset)
+	}
+	m.jumpToMatch(m.match + 1)
+	if m.match != 1 || m.viewport.YOffset != 50 {
+		t.Errorf("next match = %d at offset %d, want 1 at 50", m.match, m.viewport.YOffset)
+	}
+	m.findMatches("MATCH")
+	if m.match != 1 {
+		t.Errorf("a new search from offset 50 starts at match %d, want 1", m.match)
+	}
+
+	// Moving past either end wraps around
+	m.jumpToMatch(3)
+	if m.match != 0 || m.viewport.YOffset != 5 {
+		t.Errorf("wrapped to match %d at offset %d, want 0 at 5", m.match, m.viewport.YOffset)
+	}
+	m.jumpToMatch(-1)
+	if m.match != 2 || m.viewport.YOffset != 90 {
+		t.Errorf("wrapped back to match %d at offset %d, want 2 at 90", m.match, m.viewport.YOffset)
+	}
+
+	m.findMatches("absent")
+	if len(m.matches) != 0 || m.viewport.YOffset != 90 {
+		t.Errorf("a search without matches moved to offset %d (matches %v)", m.viewport.YOffset, m.matches)
+	}
+}
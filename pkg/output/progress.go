@@ -0,0 +1,306 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/bubbles/progress"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// progressTick is the refresh interval of elapsed times and ETAs between git updates
+const progressTick = 200 * time.Millisecond
+
+// Progress shows a progress bar per repository while git clones, checks out or pulls them. It
+// implements wsm.ProgressReporter. The bars are drawn on the terminal given to StartProgress, and
+// the lines printed through this package or written to Stderr meanwhile are printed above them.
+type Progress struct {
+	program   *tea.Program
+	out       *os.File
+	suspended bool
+	stopped   chan struct{}
+	stopOnce  sync.Once
+}
+
+// activeProgress is the progress display on screen, see SuspendProgress
+var (
+	activeProgress   *Progress
+	activeProgressMu sync.Mutex
+)
+
+// StartProgress shows title above one progress bar per repository on out until Stop is called. It
+// returns nil when out is not a terminal, in CI or in quiet mode, where git runs silently as before.
+func StartProgress(out *os.File, title string, repos []string) *Progress {
+	if quiet || IsCI() || len(repos) == 0 || !IsTerminal(out) {
+		return nil
+	}
+
+	p := &Progress{
+		out:     out,
+		stopped: make(chan struct{}),
+	}
+	p.program = tea.NewProgram(newProgressModel(title, repos),
+		tea.WithOutput(out), tea.WithInput(nil), tea.WithoutSignalHandler())
+
+	go func() {
+		defer close(p.stopped)
+		_, _ = p.program.Run()
+	}()
+
+	activeProgressMu.Lock()
+	activeProgress = p
+	activeProgressMu.Unlock()
+	return p
+}
+
+// Update implements wsm.ProgressReporter
+func (p *Progress) Update(repo, phase string, percent float64) {
+	p.program.Send(progressUpdateMsg{repo: repo, phase: phase, percent: percent})
+}
+
+// Done implements wsm.ProgressReporter
+func (p *Progress) Done(repo string, err error) {
+	p.program.Send(progressDoneMsg{repo: repo, err: err})
+}
+
+// Stop leaves the final state of the bars on screen
+func (p *Progress) Stop() {
+	if p == nil {
+		return
+	}
+	p.stopOnce.Do(func() {
+		activeProgressMu.Lock()
+		if activeProgress == p {
+			activeProgress = nil
+		}
+		activeProgressMu.Unlock()
+
+		p.program.Quit()
+		<-p.stopped
+	})
+}
+
+// SuspendProgress hides the progress bars and gives the terminal back, e.g. while a prompt is
+// shown, until the returned function is called
+func SuspendProgress() func() {
+	activeProgressMu.Lock()
+	defer activeProgressMu.Unlock()
+	p := activeProgress
+	if p == nil || p.suspended {
+		return func() {}
+	}
+
+	_ = p.program.ReleaseTerminal()
+	p.suspended = true
+	return func() {
+		activeProgressMu.Lock()
+		defer activeProgressMu.Unlock()
+		p.suspended = false
+		_ = p.program.RestoreTerminal()
+	}
+}
+
+// shownProgress returns the progress display drawing on the same terminal as w, if any
+func shownProgress(w *os.File) *Progress {
+	activeProgressMu.Lock()
+	p := activeProgress
+	suspended := p != nil && p.suspended
+	activeProgressMu.Unlock()
+	if p == nil || suspended || !IsTerminal(w) {
+		return nil
+	}
+	select {
+	case <-p.stopped:
+		// The program failed to start, e.g. because the terminal went away
+		return nil
+	default:
+		return p
+	}
+}
+
+// printText writes text to w, or prints it above the progress bars while they are shown
+func printText(w *os.File, text string) {
+	if p := shownProgress(w); p != nil {
+		p.program.Println(strings.TrimSuffix(text, "\n"))
+		return
+	}
+	fmt.Fprint(w, text)
+}
+
+// Stderr writes to os.Stderr, or above the progress bars while they are shown; the logger writes
+// to it so that log lines do not break the bars
+var Stderr io.Writer = stderrWriter{}
+
+type stderrWriter struct{}
+
+func (stderrWriter) Write(b []byte) (int, error) {
+	if p := shownProgress(os.Stderr); p != nil {
+		p.program.Println(strings.TrimSuffix(string(b), "\n"))
+		return len(b), nil
+	}
+	return os.Stderr.Write(b)
+}
+
+type progressUpdateMsg struct {
+	repo    string
+	phase   string
+	percent float64
+}
+
+type progressDoneMsg struct {
+	repo string
+	err  error
+}
+
+type progressTickMsg time.Time
+
+type repoProgress struct {
+	name         string
+	phase        string
+	percent      float64
+	started      time.Time
+	phaseStarted time.Time
+	finished     time.Time
+	done         bool
+	err          error
+}
+
+type progressModel struct {
+	title     string
+	repos     []*repoProgress
+	nameWidth int
+	started   time.Time
+	bar       progress.Model
+}
+
+func newProgressModel(title string, repos []string) progressModel {
+	m := progressModel{
+		title:   title,
+		started: time.Now(),
+		bar:     progress.New(progress.WithDefaultGradient(), progress.WithWidth(30), progress.WithoutPercentage()),
+	}
+	for _, name := range repos {
+		m.repos = append(m.repos, &repoProgress{name: name})
+		m.nameWidth = max(m.nameWidth, len(name))
+	}
+	return m
+}
+
+func (m progressModel) Init() tea.Cmd {
+	return tickProgress()
+}
+
+func tickProgress() tea.Cmd {
+	return tea.Tick(progressTick, func(t time.Time) tea.Msg {
+		return progressTickMsg(t)
+	})
+}
+
+func (m progressModel) find(name string) *repoProgress {
+	for _, repo := range m.repos {
+		if repo.name == name {
+			return repo
+		}
+	}
+	return nil
+}
+
+func (m progressModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case progressUpdateMsg:
+		repo := m.find(msg.repo)
+		if repo == nil || repo.done {
+			return m, nil
+		}
+		now := time.Now()
+		if repo.started.IsZero() {
+			repo.started = now
+		}
+		if repo.phase != msg.phase {
+			repo.phase = msg.phase
+			repo.phaseStarted = now
+		}
+		repo.percent = min(max(msg.percent, 0), 1)
+	case progressDoneMsg:
+		if repo := m.find(msg.repo); repo != nil {
+			if repo.started.IsZero() {
+				repo.started = time.Now()
+			}
+			repo.done = true
+			repo.finished = time.Now()
+			repo.err = msg.err
+		}
+	case progressTickMsg:
+		return m, tickProgress()
+	}
+	return m, nil
+}
+
+func (m progressModel) View() string {
+	var b strings.Builder
+
+	done := 0
+	fraction := 0.0
+	for _, repo := range m.repos {
+		if repo.done {
+			done++
+			fraction++
+		} else {
+			fraction += repo.percent
+		}
+	}
+	fraction /= float64(len(m.repos))
+
+	header := fmt.Sprintf("%s · %d/%d done", m.title, done, len(m.repos))
+	if done < len(m.repos) {
+		if eta, ok := estimateRemaining(time.Since(m.started), fraction); ok {
+			header += fmt.Sprintf(" · ~%s left", eta)
+		}
+	}
+	b.WriteString(BoldStyle.Render(header))
+	b.WriteString("\n")
+
+	for _, repo := range m.repos {
+		name := fmt.Sprintf("%-*s", m.nameWidth, repo.name)
+		switch {
+		case repo.done && repo.err != nil:
+			b.WriteString(ErrorStyle.Render("  ✗ "+name) + " failed")
+		case repo.done:
+			b.WriteString(SuccessStyle.Render("  ✓ "+name) + DimStyle.Render(" done in "+formatProgressDuration(repo.finished.Sub(repo.started))))
+		case repo.started.IsZero():
+			b.WriteString("    " + name + DimStyle.Render(" waiting"))
+		default:
+			line := fmt.Sprintf("    %s %s %3.0f%%", name, m.bar.ViewAs(repo.percent), repo.percent*100)
+			if repo.phase != "" {
+				line += " " + repo.phase
+			}
+			if eta, ok := estimateRemaining(time.Since(repo.phaseStarted), repo.percent); ok {
+				line += DimStyle.Render(" ~" + eta)
+			}
+			b.WriteString(line)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// estimateRemaining extrapolates the time left from the time elapsed for the completed fraction
+func estimateRemaining(elapsed time.Duration, fraction float64) (string, bool) {
+	if fraction <= 0.01 || fraction >= 1 || elapsed < time.Second {
+		return "", false
+	}
+	remaining := time.Duration(float64(elapsed) * (1 - fraction) / fraction)
+	return formatProgressDuration(remaining), true
+}
+
+func formatProgressDuration(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%ds", int(d.Round(time.Second).Seconds()))
+	}
+	d = d.Round(time.Second)
+	return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+}
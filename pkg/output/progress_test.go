@@ -0,0 +1,46 @@
+package output
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEstimateRemaining(t *testing.T) {
+	tests := []struct {
+		name     string
+		elapsed  time.Duration
+		fraction float64
+		want     string
+		ok       bool
+	}{
+		{name: "half done", elapsed: 10 * time.Second, fraction: 0.5, want: "10s", ok: true},
+		{name: "a quarter done", elapsed: 30 * time.Second, fraction: 0.25, want: "1m30s", ok: true},
+		{name: "almost done", elapsed: 99 * time.Second, fraction: 0.99, want: "1s", ok: true},
+		{name: "just started", elapsed: 10 * time.Second, fraction: 0.005},
+		{name: "too early", elapsed: 500 * time.Millisecond, fraction: 0.5},
+		{name: "done", elapsed: 10 * time.Second, fraction: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := estimateRemaining(tt.elapsed, tt.fraction)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("estimateRemaining(%s, %v) = %q, %v, want %q, %v", tt.elapsed, tt.fraction, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestFormatProgressDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		0:                                     "0s",
+		1499 * time.Millisecond:               "1s",
+		59 * time.Second:                      "59s",
+		time.Minute + 5*time.Second:           "1m05s",
+		12*time.Minute + 500*time.Millisecond: "12m01s",
+	}
+	for d, want := range tests {
+		if got := formatProgressDuration(d); got != want {
+			t.Errorf("formatProgressDuration(%s) = %q, want %q", d, got, want)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
 	"github.com/charmbracelet/lipgloss"
 )
@@ -36,34 +37,82 @@ var (
 			Foreground(lipgloss.Color("8"))
 )
 
+// quiet suppresses informational output, see SetQuiet
+var quiet bool
+
+// SetQuiet suppresses headers, info and success messages and moves warnings to stderr,
+// so that stdout only carries the data a script asked for. Errors are always printed.
+func SetQuiet(q bool) {
+	quiet = q
+}
+
+// IsQuiet reports whether informational output is suppressed
+func IsQuiet() bool {
+	return quiet
+}
+
 // PrintError prints an error message with styling
 func PrintError(format string, args ...interface{}) {
 	msg := ErrorStyle.Render("✗ " + fmt.Sprintf(format, args...))
-	fmt.Fprintln(os.Stderr, msg)
+	printText(os.Stderr, msg+"\n")
 }
 
 // PrintSuccess prints a success message with styling
 func PrintSuccess(format string, args ...interface{}) {
+	if quiet {
+		return
+	}
 	msg := SuccessStyle.Render("✓ " + fmt.Sprintf(format, args...))
-	fmt.Println(msg)
+	printText(os.Stdout, msg+"\n")
 }
 
 // PrintInfo prints an info message with styling - replaces log.Info for user-facing output
 func PrintInfo(format string, args ...interface{}) {
+	if quiet {
+		return
+	}
 	msg := InfoStyle.Render("ℹ " + fmt.Sprintf(format, args...))
-	fmt.Println(msg)
+	printText(os.Stdout, msg+"\n")
 }
 
 // PrintWarning prints a warning message with styling
 func PrintWarning(format string, args ...interface{}) {
 	msg := WarningStyle.Render("⚠ " + fmt.Sprintf(format, args...))
-	fmt.Println(msg)
+	if quiet {
+		printText(os.Stderr, msg+"\n")
+		return
+	}
+	printText(os.Stdout, msg+"\n")
 }
 
 // PrintHeader prints a header message with styling
 func PrintHeader(format string, args ...interface{}) {
+	if quiet {
+		return
+	}
 	msg := HeaderStyle.Render(fmt.Sprintf(format, args...))
-	fmt.Println(msg)
+	printText(os.Stdout, msg+"\n")
+}
+
+// Printf prints plain informational text, e.g. the commands being run
+func Printf(format string, args ...interface{}) {
+	if quiet {
+		return
+	}
+	printText(os.Stdout, fmt.Sprintf(format, args...))
+}
+
+// PrintPorcelain prints one stable, tab-separated record for scripts. Tabs and newlines inside
+// fields are replaced by spaces and empty fields are printed as "-".
+func PrintPorcelain(fields ...string) {
+	for i, field := range fields {
+		field = strings.NewReplacer("\t", " ", "\n", " ", "\r", " ").Replace(field)
+		if field == "" {
+			field = "-"
+		}
+		fields[i] = field
+	}
+	fmt.Println(strings.Join(fields, "\t"))
 }
 
 // LogInfo logs at info level while also printing pretty output to user
@@ -81,8 +130,23 @@ func LogWarn(userMsg string, logMsg string, fields ...interface{}) {
 	PrintWarning("%s", userMsg)
 }
 
-// Spinner creates a simple text-based spinner for operations
+// Spinner creates a simple text-based spinner for operations. When w is not a terminal (or in CI)
+// the message is printed once instead of being animated, and above the progress bars while they
+// are shown.
 func Spinner(w io.Writer, msg string) func() {
+	if f, ok := w.(*os.File); ok && shownProgress(f) != nil {
+		printText(f, msg+"...\n")
+		return func() {
+			printText(f, SuccessStyle.Render(msg+" completed")+"\n")
+		}
+	}
+	if f, ok := w.(*os.File); !ok || !IsTerminal(f) || IsCI() {
+		fmt.Fprintf(w, "%s...\n", msg)
+		return func() {
+			fmt.Fprintf(w, "%s\n", SuccessStyle.Render(msg+" completed"))
+		}
+	}
+
 	chars := []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
 	i := 0
 	done := make(chan bool)
@@ -0,0 +1,199 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Column describes a table column that can be selected with --columns and sorted with --sort
+type Column struct {
+	// Name is the lowercase key used on the command line
+	Name string
+	// Header is the title printed above the column (defaults to the upper-cased name)
+	Header string
+	// Hidden columns are only shown when explicitly selected
+	Hidden bool
+}
+
+// Cell is a rendered table value together with the value used for sorting
+type Cell struct {
+	Text  string
+	Value interface{}
+}
+
+// Text creates a cell sorted by its text
+func Text(s string) Cell {
+	return Cell{Text: s, Value: s}
+}
+
+// Int creates a numeric cell
+func Int(n int) Cell {
+	return Cell{Text: fmt.Sprintf("%d", n), Value: n}
+}
+
+// Time creates a cell displaying t with the given layout and sorted chronologically
+func Time(t time.Time, layout string) Cell {
+	if t.IsZero() {
+		return Cell{Text: "-", Value: t}
+	}
+	return Cell{Text: t.Format(layout), Value: t}
+}
+
+// Row maps column names to cells
+type Row map[string]Cell
+
+// Table is a column-selectable, sortable table rendered with a tabwriter
+type Table struct {
+	columns  []Column
+	selected []Column
+	rows     []Row
+}
+
+// NewTable creates a table showing all non-hidden columns by default
+func NewTable(columns ...Column) *Table {
+	t := &Table{columns: columns}
+	for _, column := range columns {
+		if !column.Hidden {
+			t.selected = append(t.selected, column)
+		}
+	}
+	return t
+}
+
+// ColumnNames lists the names of all columns, for help texts and completion
+func ColumnNames(columns []Column) []string {
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = column.Name
+	}
+	return names
+}
+
+// AddRow appends a row to the table
+func (t *Table) AddRow(row Row) {
+	t.rows = append(t.rows, row)
+}
+
+// SelectColumns restricts and orders the displayed columns; an empty list keeps the defaults
+func (t *Table) SelectColumns(names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+
+	selected := make([]Column, 0, len(names))
+	for _, name := range names {
+		column, ok := t.column(name)
+		if !ok {
+			return errors.Errorf("unknown column '%s' (available: %s)", name, strings.Join(ColumnNames(t.columns), ", "))
+		}
+		selected = append(selected, column)
+	}
+	t.selected = selected
+	return nil
+}
+
+// SortBy sorts the rows by a column. The spec is "<column>", "<column> desc", "<column>:desc"
+// or "-<column>"; an empty spec keeps the insertion order.
+func (t *Table) SortBy(spec string) error {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil
+	}
+
+	descending := false
+	name := spec
+	switch {
+	case strings.HasPrefix(spec, "-"):
+		descending = true
+		name = spec[1:]
+	case strings.ContainsAny(spec, " :"):
+		fields := strings.FieldsFunc(spec, func(r rune) bool { return r == ' ' || r == ':' })
+		if len(fields) != 2 {
+			return errors.Errorf("invalid sort specification '%s'", spec)
+		}
+		name = fields[0]
+		switch strings.ToLower(fields[1]) {
+		case "asc":
+		case "desc":
+			descending = true
+		default:
+			return errors.Errorf("invalid sort direction '%s' (use asc or desc)", fields[1])
+		}
+	}
+
+	column, ok := t.column(name)
+	if !ok {
+		return errors.Errorf("unknown sort column '%s' (available: %s)", name, strings.Join(ColumnNames(t.columns), ", "))
+	}
+
+	sort.SliceStable(t.rows, func(i, j int) bool {
+		if descending {
+			return lessCell(t.rows[j][column.Name], t.rows[i][column.Name])
+		}
+		return lessCell(t.rows[i][column.Name], t.rows[j][column.Name])
+	})
+	return nil
+}
+
+// Render writes the header, separator and rows of the selected columns
+func (t *Table) Render(out io.Writer) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	headers := make([]string, len(t.selected))
+	separators := make([]string, len(t.selected))
+	for i, column := range t.selected {
+		headers[i] = column.Header
+		if headers[i] == "" {
+			headers[i] = strings.ToUpper(column.Name)
+		}
+		separators[i] = strings.Repeat("-", len([]rune(headers[i])))
+	}
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+	fmt.Fprintln(w, strings.Join(separators, "\t"))
+
+	for _, row := range t.rows {
+		cells := make([]string, len(t.selected))
+		for i, column := range t.selected {
+			cells[i] = row[column.Name].Text
+			if cells[i] == "" {
+				cells[i] = "-"
+			}
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+
+	return w.Flush()
+}
+
+func (t *Table) column(name string) (Column, bool) {
+	for _, column := range t.columns {
+		if column.Name == strings.ToLower(strings.TrimSpace(name)) {
+			return column, true
+		}
+	}
+	return Column{}, false
+}
+
+func lessCell(a, b Cell) bool {
+	switch av := a.Value.(type) {
+	case int:
+		if bv, ok := b.Value.(int); ok {
+			return av < bv
+		}
+	case time.Time:
+		if bv, ok := b.Value.(time.Time); ok {
+			return av.Before(bv)
+		}
+	case bool:
+		if bv, ok := b.Value.(bool); ok {
+			return !av && bv
+		}
+	}
+	return strings.ToLower(a.Text) < strings.ToLower(b.Text)
+}
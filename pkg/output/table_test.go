@@ -0,0 +1,93 @@
+package output
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestTable() *Table {
+	table := NewTable(
+		Column{Name: "name"},
+		Column{Name: "repos", Header: "REPOSITORIES"},
+		Column{Name: "created"},
+		Column{Name: "path", Hidden: true},
+	)
+	day := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	table.AddRow(Row{"name": Text("beta"), "repos": Int(10), "created": Time(day, "2006-01-02"), "path": Text("/ws/beta")})
+	table.AddRow(Row{"name": Text("Alpha"), "repos": Int(2), "created": Time(time.Time{}, "2006-01-02")})
+	table.AddRow(Row{"name": Text("gamma"), "repos": Int(3), "created": Time(day.AddDate(0, 0, -1), "2006-01-02")})
+	return table
+}
+
+func renderTable(t *testing.T, table *Table) string {
+	t.Helper()
+	var b strings.Builder
+	if err := table.Render(&b); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	return b.String()
+}
+
+func TestTableRender(t *testing.T) {
+	want := `NAME   REPOSITORIES  CREATED
+----   ------------  -------
+beta   10            2026-10-16
+Alpha  2             -
+gamma  3             2026-10-15
+`
+	if got := renderTable(t, newTestTable()); got != want {
+		t.Errorf("Render:\n%s\nwant:\n%s", got, want)
+	}
+
+	table := newTestTable()
+	if err := table.SelectColumns([]string{"path", " Name"}); err != nil {
+		t.Fatalf("SelectColumns failed: %v", err)
+	}
+	want = `PATH      NAME
+----      ----
+/ws/beta  beta
+-         Alpha
+-         gamma
+`
+	if got := renderTable(t, table); got != want {
+		t.Errorf("Render with selected columns:\n%s\nwant:\n%s", got, want)
+	}
+	if err := table.SelectColumns([]string{"size"}); err == nil || !strings.Contains(err.Error(), "available: name, repos, created, path") {
+		t.Errorf("expected an error listing the columns, got %v", err)
+	}
+}
+
+func TestTableSortBy(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{spec: "", want: "beta Alpha gamma"},
+		{spec: "name", want: "Alpha beta gamma"},
+		{spec: "repos", want: "Alpha gamma beta"},
+		{spec: "-repos", want: "beta gamma Alpha"},
+		{spec: "repos desc", want: "beta gamma Alpha"},
+		{spec: "created:asc", want: "Alpha gamma beta"},
+		{spec: "created:DESC", want: "beta gamma Alpha"},
+	}
+	for _, tt := range tests {
+		table := newTestTable()
+		if err := table.SortBy(tt.spec); err != nil {
+			t.Fatalf("SortBy(%q) failed: %v", tt.spec, err)
+		}
+		var names []string
+		for _, row := range table.rows {
+			names = append(names, row["name"].Text)
+		}
+		if got := strings.Join(names, " "); got != tt.want {
+			t.Errorf("SortBy(%q) = %s, want %s", tt.spec, got, tt.want)
+		}
+	}
+
+	for _, spec := range []string{"size", "name up", "name desc extra"} {
+		if err := newTestTable().SortBy(spec); err == nil {
+			t.Errorf("expected an error for %q", spec)
+		}
+	}
+}
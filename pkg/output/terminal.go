@@ -0,0 +1,67 @@
+package output
+
+import (
+	"os"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-isatty"
+	"github.com/muesli/termenv"
+	"github.com/pkg/errors"
+)
+
+// noInput disables interactive prompts, see SetNoInput
+var noInput bool
+
+// ciEnvironmentVariables are set by common CI systems
+var ciEnvironmentVariables = []string{
+	"CI", "GITHUB_ACTIONS", "GITLAB_CI", "BUILDKITE", "CIRCLECI", "JENKINS_URL", "TEAMCITY_VERSION", "TF_BUILD",
+}
+
+// SetNoInput disables interactive prompts regardless of the terminal
+func SetNoInput(n bool) {
+	noInput = n
+}
+
+// IsTerminal reports whether f is attached to a terminal
+func IsTerminal(f *os.File) bool {
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
+
+// IsCI reports whether the process runs in a continuous integration environment
+func IsCI() bool {
+	for _, name := range ciEnvironmentVariables {
+		if value, ok := os.LookupEnv(name); ok && value != "" && value != "false" && value != "0" {
+			return true
+		}
+	}
+	return false
+}
+
+// IsInteractive reports whether prompts can be shown: stdin and stdout are terminals,
+// the process is not running in CI and --no-input was not given
+func IsInteractive() bool {
+	return !noInput && !IsCI() && IsTerminal(os.Stdin) && IsTerminal(os.Stdout)
+}
+
+// RequireInteractive returns a descriptive error when a prompt for action cannot be shown,
+// pointing at the flag that makes the prompt unnecessary
+func RequireInteractive(action, alternative string) error {
+	if IsInteractive() {
+		return nil
+	}
+	return errors.Errorf("cannot %s: not running interactively (no terminal, CI or --no-input); %s", action, alternative)
+}
+
+// ConfigureTerminal disables colors when NO_COLOR is set, TERM is dumb, the process runs in CI
+// or stdout or stderr is not a terminal
+func ConfigureTerminal() {
+	if colorsDisabled(IsTerminal(os.Stdout), IsTerminal(os.Stderr)) {
+		lipgloss.SetColorProfile(termenv.Ascii)
+	}
+}
+
+// colorsDisabled reports whether output should be plain, given whether stdout and stderr are
+// terminals. Warnings and progress go to stderr, so both have to be terminals for colors.
+func colorsDisabled(stdoutTerminal, stderrTerminal bool) bool {
+	return os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" || IsCI() || !stdoutTerminal || !stderrTerminal
+}
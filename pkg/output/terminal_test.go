@@ -0,0 +1,35 @@
+package output
+
+import "testing"
+
+func TestColorsDisabled(t *testing.T) {
+	tests := []struct {
+		name           string
+		env            map[string]string
+		stdout, stderr bool
+		want           bool
+	}{
+		{name: "terminal", stdout: true, stderr: true, want: false},
+		{name: "stdout redirected", stdout: false, stderr: true, want: true},
+		{name: "stderr redirected", stdout: true, stderr: false, want: true},
+		{name: "NO_COLOR", env: map[string]string{"NO_COLOR": "1"}, stdout: true, stderr: true, want: true},
+		{name: "dumb terminal", env: map[string]string{"TERM": "dumb"}, stdout: true, stderr: true, want: true},
+		{name: "CI", env: map[string]string{"GITHUB_ACTIONS": "true"}, stdout: true, stderr: true, want: true},
+		{name: "CI disabled", env: map[string]string{"CI": "false"}, stdout: true, stderr: true, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("NO_COLOR", "")
+			t.Setenv("TERM", "xterm-256color")
+			for _, name := range ciEnvironmentVariables {
+				t.Setenv(name, "")
+			}
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			if got := colorsDisabled(tt.stdout, tt.stderr); got != tt.want {
+				t.Errorf("colorsDisabled(%v, %v) = %v, want %v", tt.stdout, tt.stderr, got, tt.want)
+			}
+		})
+	}
+}
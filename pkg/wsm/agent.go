@@ -0,0 +1,354 @@
+package wsm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/pkg/errors"
+)
+
+// AgentTemplateData is the context available when rendering AGENT.md, agent assets, scaffold
+// templates and setup scripts
+type AgentTemplateData struct {
+	Name            string
+	Path            string
+	Branch          string
+	BaseBranch      string
+	GoWorkspace     bool
+	Repositories    []AgentTemplateRepository
+	RepositoryNames []string
+	// Repository is set when rendering an asset inside a single repository worktree
+	Repository *AgentTemplateRepository
+}
+
+// AgentTemplateRepository describes a single repository inside an AGENT.md template
+type AgentTemplateRepository struct {
+	Name          string
+	Path          string
+	SourcePath    string
+	RemoteURL     string
+	Categories    []string
+	ModulePath    string
+	BuildCommands []string
+}
+
+// NewAgentTemplateData builds the template context for a workspace
+func NewAgentTemplateData(workspace *Workspace) *AgentTemplateData {
+	data := &AgentTemplateData{
+		Name:        workspace.Name,
+		Path:        workspace.Path,
+		Branch:      workspace.Branch,
+		BaseBranch:  workspace.BaseBranch,
+		GoWorkspace: workspace.GoWorkspace,
+	}
+
+	for _, repo := range workspace.Repositories {
+		worktreePath := filepath.Join(workspace.Path, repo.Name)
+		data.Repositories = append(data.Repositories, AgentTemplateRepository{
+			Name:          repo.Name,
+			Path:          worktreePath,
+			SourcePath:    repo.Path,
+			RemoteURL:     repo.RemoteURL,
+			Categories:    repo.Categories,
+			ModulePath:    readGoModulePath(worktreePath),
+			BuildCommands: buildCommandsForCategories(repo.Categories),
+		})
+		data.RepositoryNames = append(data.RepositoryNames, repo.Name)
+	}
+
+	return data
+}
+
+// forRepository returns a copy of the context with Repository set to the named repository,
+// or the context itself for an empty name
+func (d *AgentTemplateData) forRepository(name string) *AgentTemplateData {
+	if name == "" {
+		return d
+	}
+	scoped := *d
+	for i := range d.Repositories {
+		if d.Repositories[i].Name == name {
+			scoped.Repository = &d.Repositories[i]
+		}
+	}
+	return &scoped
+}
+
+// Port range handed out by AgentTemplateData.Port
+const (
+	templatePortBase  = 20000
+	templatePortRange = 20000
+)
+
+// Port returns a port for a service that is stable for the workspace and unlikely to collide with
+// other workspaces, e.g. {{ .Port "web" }}. Ports are derived from a hash of the workspace name and
+// the service, between 20000 and 39999.
+func (d *AgentTemplateData) Port(service string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(d.Name + "/" + service))
+	return templatePortBase + int(h.Sum32()%templatePortRange)
+}
+
+// RenderAgentTemplate renders content as a Go template with the workspace context.
+// Content that is not a valid template is returned unchanged so plain markdown keeps working.
+func RenderAgentTemplate(name string, content []byte, data *AgentTemplateData) []byte {
+	tmpl, err := template.New(name).Funcs(agentTemplateFuncs).Option("missingkey=zero").Parse(string(content))
+	if err != nil {
+		output.LogWarn(
+			fmt.Sprintf("%s is not a valid template, copying it verbatim: %v", name, err),
+			"Failed to parse agent template",
+			"file", name,
+			"error", err,
+		)
+		return content
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		output.LogWarn(
+			fmt.Sprintf("Failed to render %s, copying it verbatim: %v", name, err),
+			"Failed to render agent template",
+			"file", name,
+			"error", err,
+		)
+		return content
+	}
+
+	return buf.Bytes()
+}
+
+var agentTemplateFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// Agent asset scopes
+const (
+	AgentAssetScopeWorkspace = "workspace"
+	AgentAssetScopeRepos     = "repos"
+	AgentAssetScopeAll       = "all"
+)
+
+// installAgentAssets renders the configured agent assets into the workspace root and/or repository worktrees
+func (wm *WorkspaceManager) installAgentAssets(workspace *Workspace) error {
+	data := NewAgentTemplateData(workspace)
+
+	for _, asset := range workspace.AgentAssets {
+		if err := validateAgentAsset(asset); err != nil {
+			return err
+		}
+
+		source, err := expandHomePath(asset.Source)
+		if err != nil {
+			return err
+		}
+
+		content, err := os.ReadFile(source)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read agent asset: %s", source)
+		}
+
+		scope := asset.Scope
+		if scope == "" {
+			scope = AgentAssetScopeWorkspace
+		}
+
+		if scope == AgentAssetScopeWorkspace || scope == AgentAssetScopeAll {
+			target := filepath.Join(workspace.Path, asset.Target)
+			if err := writeAgentAsset(source, target, content, data); err != nil {
+				return err
+			}
+		}
+
+		if scope == AgentAssetScopeRepos || scope == AgentAssetScopeAll {
+			for i := range data.Repositories {
+				repo := data.Repositories[i]
+				if len(asset.Repos) > 0 && !slices.Contains(asset.Repos, repo.Name) {
+					continue
+				}
+
+				target := filepath.Join(repo.Path, asset.Target)
+				if _, err := os.Stat(target); err == nil {
+					output.LogWarn(
+						fmt.Sprintf("Skipping %s in '%s': file already exists in repository", asset.Target, repo.Name),
+						"Agent asset target already exists",
+						"repo", repo.Name,
+						"target", target,
+					)
+					continue
+				}
+
+				repoData := *data
+				repoData.Repository = &repo
+				if err := writeAgentAsset(source, target, content, &repoData); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// AgentAssetWorkspaceTargets returns the files installed into the workspace root by agent assets
+func AgentAssetWorkspaceTargets(workspace *Workspace) []string {
+	var targets []string
+	for _, asset := range workspace.AgentAssets {
+		if asset.Scope == "" || asset.Scope == AgentAssetScopeWorkspace || asset.Scope == AgentAssetScopeAll {
+			targets = append(targets, asset.Target)
+		}
+	}
+	return targets
+}
+
+// removeRepoAgentAssets removes repository-scoped agent assets that are still untracked in their worktree
+func (wm *WorkspaceManager) removeRepoAgentAssets(ctx context.Context, workspace *Workspace) {
+	for _, asset := range workspace.AgentAssets {
+		if asset.Scope != AgentAssetScopeRepos && asset.Scope != AgentAssetScopeAll {
+			continue
+		}
+
+		for _, repo := range workspace.Repositories {
+			if len(asset.Repos) > 0 && !slices.Contains(asset.Repos, repo.Name) {
+				continue
+			}
+
+			worktreePath := filepath.Join(workspace.Path, repo.Name)
+			target := filepath.Join(worktreePath, asset.Target)
+			if _, err := os.Stat(target); err != nil {
+				continue
+			}
+
+			// Only remove files we generated, never tracked or modified repository content
+			status, err := runGitOutput(ctx, worktreePath, "status", "--porcelain", "--", asset.Target)
+			if err != nil || !strings.HasPrefix(status, "??") {
+				continue
+			}
+
+			if err := os.Remove(target); err != nil {
+				output.LogWarn(
+					fmt.Sprintf("Failed to remove agent asset: %s", target),
+					"Failed to remove agent asset",
+					"target", target,
+					"error", err,
+				)
+			}
+		}
+	}
+}
+
+func validateAgentAsset(asset AgentAsset) error {
+	if asset.Source == "" || asset.Target == "" {
+		return errors.Errorf("agent asset requires both source and target (source: %q, target: %q)", asset.Source, asset.Target)
+	}
+	if !filepath.IsLocal(asset.Target) {
+		return errors.Errorf("agent asset target must be a relative path inside the workspace: %s", asset.Target)
+	}
+	switch asset.Scope {
+	case "", AgentAssetScopeWorkspace, AgentAssetScopeRepos, AgentAssetScopeAll:
+		return nil
+	default:
+		return errors.Errorf("invalid agent asset scope %q for %s (expected workspace, repos or all)", asset.Scope, asset.Target)
+	}
+}
+
+func writeAgentAsset(source, target string, content []byte, data *AgentTemplateData) error {
+	output.LogInfo(
+		fmt.Sprintf("Installing agent asset %s to %s", source, target),
+		"Installing agent asset",
+		"source", source,
+		"target", target,
+	)
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return errors.Wrapf(err, "failed to create directory for %s", target)
+	}
+
+	rendered := RenderAgentTemplate(filepath.Base(source), content, data)
+	if err := os.WriteFile(target, rendered, 0644); err != nil {
+		return errors.Wrapf(err, "failed to write agent asset: %s", target)
+	}
+
+	return nil
+}
+
+// readGoModulePath returns the module path declared in the repository's go.mod, if any
+func readGoModulePath(repoPath string) string {
+	f, err := os.Open(filepath.Join(repoPath, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "module ") {
+			return strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "module ")), `"`)
+		}
+	}
+
+	return ""
+}
+
+// buildCommandsForCategories returns the conventional build/test commands for repository categories
+func buildCommandsForCategories(categories []string) []string {
+	commandsByCategory := map[string][]string{
+		"go":     {"go build ./...", "go test ./..."},
+		"node":   {"npm install", "npm test"},
+		"rust":   {"cargo build", "cargo test"},
+		"python": {"pip install -r requirements.txt", "pytest"},
+		"ruby":   {"bundle install", "bundle exec rake test"},
+		"java":   {"mvn package"},
+		"gradle": {"./gradlew build"},
+		"make":   {"make"},
+	}
+
+	var commands []string
+	seen := make(map[string]bool)
+	for _, category := range categories {
+		for _, command := range commandsByCategory[category] {
+			if !seen[command] {
+				seen[command] = true
+				commands = append(commands, command)
+			}
+		}
+	}
+
+	return commands
+}
+
+// expandHomePath expands a leading ~ to the user's home directory
+func expandHomePath(path string) (string, error) {
+	if !strings.HasPrefix(path, "~") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get home directory")
+	}
+	return filepath.Join(home, path[1:]), nil
+}
+
+// ExpandPath expands a leading ~ to the user's home directory and makes the path absolute
+func ExpandPath(path string) (string, error) {
+	expanded, err := expandHomePath(path)
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(expanded)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get absolute path for %s", path)
+	}
+	return abs, nil
+}
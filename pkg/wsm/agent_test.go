@@ -0,0 +1,36 @@
+package wsm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateAgentAsset(t *testing.T) {
+	tests := []struct {
+		asset   AgentAsset
+		wantErr string
+	}{
+		{asset: AgentAsset{Source: "rules.md", Target: ".cursor/rules/team.md"}},
+		{asset: AgentAsset{Source: "rules.md", Target: "..rules.md", Scope: AgentAssetScopeRepos}},
+		{asset: AgentAsset{Source: "rules.md", Target: "docs/../AGENTS.md"}},
+		{asset: AgentAsset{Source: "rules.md"}, wantErr: "requires both source and target"},
+		{asset: AgentAsset{Source: "rules.md", Target: "../AGENTS.md"}, wantErr: "inside the workspace"},
+		{asset: AgentAsset{Source: "rules.md", Target: "docs/../../AGENTS.md"}, wantErr: "inside the workspace"},
+		{asset: AgentAsset{Source: "rules.md", Target: "/etc/AGENTS.md"}, wantErr: "inside the workspace"},
+		{asset: AgentAsset{Source: "rules.md", Target: "AGENTS.md", Scope: "everywhere"}, wantErr: "invalid agent asset scope"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.asset.Target, func(t *testing.T) {
+			err := validateAgentAsset(tt.asset)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateAgentAsset failed: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
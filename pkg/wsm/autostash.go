@@ -0,0 +1,83 @@
+package wsm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Stash is an automatic stash of the uncommitted changes of a repository, taken before an
+// operation that needs a clean worktree (pull, rebase) and restored after it
+type Stash struct {
+	Repository string
+	Path       string
+	// Commit is the stash commit, which identifies the entry even when other stashes are pushed
+	Commit string
+}
+
+// AutoStash stashes the uncommitted changes to tracked files of a repository, like git's
+// --autostash. It returns nil when the worktree is clean.
+func AutoStash(ctx context.Context, repoName, repoPath, operation string) (*Stash, error) {
+	status, err := runGitOutput(ctx, repoPath, "status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to check for changes in %s", repoName)
+	}
+	if status == "" {
+		return nil, nil
+	}
+
+	if _, err := runGitOutput(ctx, repoPath, "stash", "push", "-m", fmt.Sprintf("wsm autostash before %s", operation)); err != nil {
+		return nil, errors.Wrapf(err, "failed to stash changes in %s", repoName)
+	}
+	commit, err := runGitOutput(ctx, repoPath, "rev-parse", "stash@{0}")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the stash of %s", repoName)
+	}
+	return &Stash{Repository: repoName, Path: repoPath, Commit: commit}, nil
+}
+
+// Short is the abbreviated stash commit, usable with 'git stash apply'
+func (s *Stash) Short() string {
+	if len(s.Commit) > 10 {
+		return s.Commit[:10]
+	}
+	return s.Commit
+}
+
+// Restore applies the stash and drops it. When applying conflicts, the conflict markers are left
+// in the worktree, the stash is kept and true is returned.
+func (s *Stash) Restore(ctx context.Context) (bool, error) {
+	ref, err := s.ref(ctx)
+	if err != nil {
+		return false, err
+	}
+	if _, err := runGitOutput(ctx, s.Path, "stash", "pop", ref); err != nil {
+		if unmerged, _ := runGitOutput(ctx, s.Path, "diff", "--name-only", "--diff-filter=U"); unmerged != "" {
+			return true, nil
+		}
+		return false, errors.Wrapf(err, "failed to restore stashed changes in %s", s.Repository)
+	}
+	return false, nil
+}
+
+// ref finds the stash@{n} entry of the stash commit
+func (s *Stash) ref(ctx context.Context) (string, error) {
+	list, err := runGitOutput(ctx, s.Path, "stash", "list", "--format=%H")
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to list stashes of %s", s.Repository)
+	}
+	for i, commit := range strings.Split(list, "\n") {
+		if commit == s.Commit {
+			return fmt.Sprintf("stash@{%d}", i), nil
+		}
+	}
+	return "", errors.Errorf("stash %s of %s no longer exists", s.Short(), s.Repository)
+}
+
+// HasUncommittedChanges reports whether tracked files of a repository have uncommitted changes
+func HasUncommittedChanges(ctx context.Context, repoPath string) bool {
+	status, err := runGitOutput(ctx, repoPath, "status", "--porcelain", "--untracked-files=no")
+	return err == nil && status != ""
+}
@@ -0,0 +1,113 @@
+package wsm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newStashRepo(t *testing.T) string {
+	t.Helper()
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	dir := t.TempDir()
+	testGit(t, dir, "init", "--quiet")
+	writeGoFiles(t, dir, map[string]string{"README.md": "base\n"})
+	testGit(t, dir, "add", ".")
+	testGit(t, dir, "commit", "--quiet", "-m", "Initial")
+	return dir
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestAutoStashIgnoresCleanWorktrees(t *testing.T) {
+	dir := newStashRepo(t)
+	// Untracked files are left in place, like git's --autostash
+	writeGoFiles(t, dir, map[string]string{"notes.txt": "untracked\n"})
+
+	stash, err := AutoStash(context.Background(), "lib", dir, "sync")
+	if err != nil {
+		t.Fatalf("AutoStash failed: %v", err)
+	}
+	if stash != nil {
+		t.Errorf("expected no stash for a clean worktree, got %+v", stash)
+	}
+}
+
+func TestAutoStashRestore(t *testing.T) {
+	ctx := context.Background()
+	dir := newStashRepo(t)
+	writeGoFiles(t, dir, map[string]string{"README.md": "local\n"})
+
+	stash, err := AutoStash(ctx, "lib", dir, "sync")
+	if err != nil {
+		t.Fatalf("AutoStash failed: %v", err)
+	}
+	if stash == nil || stash.Repository != "lib" || len(stash.Commit) != 40 || len(stash.Short()) != 10 {
+		t.Fatalf("stash = %+v", stash)
+	}
+	if HasUncommittedChanges(ctx, dir) {
+		t.Fatal("the changes were not stashed")
+	}
+	if message := testGit(t, dir, "stash", "list", "--format=%s"); !strings.Contains(message, "wsm autostash before sync") {
+		t.Errorf("stash message = %q", message)
+	}
+
+	// A stash pushed later moves the autostash to stash@{1}
+	writeGoFiles(t, dir, map[string]string{"other.txt": "other\n"})
+	testGit(t, dir, "add", "other.txt")
+	testGit(t, dir, "stash", "push", "--quiet", "-m", "unrelated")
+
+	conflicts, err := stash.Restore(ctx)
+	if err != nil || conflicts {
+		t.Fatalf("Restore = %v, %v", conflicts, err)
+	}
+	if got := readFile(t, filepath.Join(dir, "README.md")); got != "local\n" {
+		t.Errorf("README.md = %q, want the stashed change", got)
+	}
+	if list := testGit(t, dir, "stash", "list", "--format=%s"); !strings.Contains(list, "unrelated") || strings.Contains(list, "wsm autostash") {
+		t.Errorf("stash list after restore = %q, want only the unrelated stash", list)
+	}
+
+	if _, err := stash.Restore(ctx); err == nil || !strings.Contains(err.Error(), "no longer exists") {
+		t.Errorf("restoring a dropped stash should fail, got %v", err)
+	}
+}
+
+func TestAutoStashRestoreConflicts(t *testing.T) {
+	ctx := context.Background()
+	dir := newStashRepo(t)
+	writeGoFiles(t, dir, map[string]string{"README.md": "local\n"})
+
+	stash, err := AutoStash(ctx, "lib", dir, "rebase")
+	if err != nil || stash == nil {
+		t.Fatalf("AutoStash = %+v, %v", stash, err)
+	}
+	writeGoFiles(t, dir, map[string]string{"README.md": "upstream\n"})
+	testGit(t, dir, "commit", "--quiet", "-am", "Upstream change")
+
+	conflicts, err := stash.Restore(ctx)
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if !conflicts {
+		t.Fatal("expected the restore to conflict")
+	}
+	if got := readFile(t, filepath.Join(dir, "README.md")); !strings.Contains(got, "<<<<<<<") {
+		t.Errorf("README.md has no conflict markers:\n%s", got)
+	}
+	if list := testGit(t, dir, "stash", "list", "--format=%H"); list != stash.Commit {
+		t.Errorf("the stash was not kept: %q", list)
+	}
+}
@@ -0,0 +1,264 @@
+package wsm_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-go-golems/workspace-manager/internal/testkit"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/go-go-golems/workspace-manager/pkg/wsm"
+)
+
+func TestStatusCheckerWithFakeRunner(t *testing.T) {
+	runner := testkit.NewFakeRunner().
+		On("git branch --show-current", "feature/x\n").
+		On("git diff --name-only", "a.go\n").
+		On("git diff --cached --name-only", "").
+		On("git ls-files --others --exclude-standard", "new.txt\n").
+		On("git rev-parse --abbrev-ref @{upstream}", "origin/feature/x\n").
+		On("git rev-list --left-right --count HEAD...@{upstream}", "2\t1\n").
+		On("git status --porcelain", "UU conflict.go\n M a.go\n").
+		On("git fetch origin main", "").
+		Fail("git merge-base --is-ancestor HEAD origin/main", errors.New("exit status 1")).
+		On("git rev-list --count HEAD..origin/main", "3\n")
+
+	checker := wsm.NewStatusChecker()
+	checker.Runner = runner
+
+	workspace := &wsm.Workspace{
+		Name:         "feat",
+		Path:         "/ws/feat",
+		Repositories: []wsm.Repository{{Name: "lib"}},
+	}
+	status, err := checker.GetWorkspaceStatus(context.Background(), workspace)
+	if err != nil {
+		t.Fatalf("GetWorkspaceStatus failed: %v", err)
+	}
+
+	if status.Overall != "conflicts" {
+		t.Errorf("expected overall status conflicts, got %s", status.Overall)
+	}
+	repo := status.Repositories[0]
+	if repo.CurrentBranch != "feature/x" || repo.Ahead != 2 || repo.Behind != 1 {
+		t.Errorf("unexpected branch or divergence: %+v", repo)
+	}
+	if !reflect.DeepEqual(repo.ModifiedFiles, []string{"a.go"}) || !reflect.DeepEqual(repo.UntrackedFiles, []string{"new.txt"}) || len(repo.StagedFiles) != 0 {
+		t.Errorf("unexpected files: %+v", repo)
+	}
+	if !repo.HasChanges || !repo.HasConflicts || repo.IsMerged || !repo.NeedsRebase {
+		t.Errorf("unexpected flags: %+v", repo)
+	}
+
+	for _, call := range runner.Calls {
+		if !strings.HasPrefix(call, "/ws/feat/lib: ") {
+			t.Errorf("command ran outside the worktree: %s", call)
+		}
+	}
+}
+
+func TestFastWorkspaceStatusWithFakeRunner(t *testing.T) {
+	const status = "git --no-optional-locks status --porcelain=v2 --branch --untracked-files=no --ignore-submodules=dirty"
+	runner := testkit.NewFakeRunner().
+		OnIn("/ws/feat/lib", status, "# branch.oid 1234\n# branch.head feature/x\n# branch.upstream origin/feature/x\n# branch.ab +2 -1\n"+
+			"1 .M N... 100644 100644 100644 abc abc a.go\n").
+		OnIn("/ws/feat/app", status, "# branch.oid 5678\n# branch.head (detached)\n").
+		OnIn("/ws/feat/docs", status, "# branch.oid 9abc\n# branch.head main\n# branch.ab +0 -0\n"+
+			"u UU N... 100644 100644 100644 100644 abc def 012 README.md\n")
+
+	checker := wsm.NewStatusChecker()
+	checker.Runner = runner
+	workspace := &wsm.Workspace{
+		Name:         "feat",
+		Path:         "/ws/feat",
+		Repositories: []wsm.Repository{{Name: "lib"}, {Name: "app"}, {Name: "docs"}},
+		Frozen:       []string{"docs"},
+	}
+	result, err := checker.GetFastWorkspaceStatus(context.Background(), workspace)
+	if err != nil {
+		t.Fatalf("GetFastWorkspaceStatus failed: %v", err)
+	}
+
+	if result.Overall != "conflicts" {
+		t.Errorf("expected overall status conflicts, got %s", result.Overall)
+	}
+	lib, app, docs := result.Repositories[0], result.Repositories[1], result.Repositories[2]
+	if lib.Repository.Name != "lib" || lib.CurrentBranch != "feature/x" || lib.Ahead != 2 || lib.Behind != 1 || !lib.HasChanges || lib.Frozen {
+		t.Errorf("unexpected lib status: %+v", lib)
+	}
+	if app.Repository.Name != "app" || app.CurrentBranch != "" || app.HasChanges {
+		t.Errorf("a detached HEAD should have no branch: %+v", app)
+	}
+	if !docs.Frozen || !docs.HasConflicts || !docs.HasChanges {
+		t.Errorf("unexpected docs status: %+v", docs)
+	}
+	if len(runner.Calls) != 3 {
+		t.Errorf("expected one git invocation per repository, got %v", runner.Calls)
+	}
+
+	// One failing repository fails the whole status, naming the repository
+	runner.FailIn("/ws/feat/app", status, errors.New("exit status 128"))
+	if _, err := checker.GetFastWorkspaceStatus(context.Background(), workspace); err == nil || !strings.Contains(err.Error(), "repository app") {
+		t.Errorf("expected an error for app, got %v", err)
+	}
+}
+
+func TestDiscovererWithMemFS(t *testing.T) {
+	output.SetQuiet(true)
+	defer output.SetQuiet(false)
+
+	fsys := testkit.NewMemFS()
+	_ = fsys.MkdirAll("/code/lib/.git", 0755)
+	_ = fsys.WriteFile("/code/lib/go.mod", []byte("module example.com/lib\n"), 0644)
+	_ = fsys.MkdirAll("/code/notes", 0755)
+
+	runner := testkit.NewFakeRunner().
+		On("git remote get-url origin", "git@example.com:org/lib.git\n").
+		On("git branch --show-current", "main\n").
+		On("git branch -a", "* main\n  remotes/origin/main\n").
+		On("git tag -l", "v1.0.0\n").
+		On("git log -1 --pretty=format:%H %s", "abc123 Initial commit")
+
+	discoverer := wsm.NewRepositoryDiscoverer("/cfg/registry.json")
+	discoverer.FS, discoverer.Runner = fsys, runner
+	if err := discoverer.LoadRegistry(); err != nil {
+		t.Fatalf("LoadRegistry failed: %v", err)
+	}
+	if err := discoverer.DiscoverRepositories(context.Background(), []string{"/code"}, true, 2); err != nil {
+		t.Fatalf("DiscoverRepositories failed: %v", err)
+	}
+	if err := discoverer.SetAlias("l", "lib"); err != nil {
+		t.Fatalf("SetAlias failed: %v", err)
+	}
+	if err := discoverer.SaveRegistry(); err != nil {
+		t.Fatalf("SaveRegistry failed: %v", err)
+	}
+
+	reloaded := wsm.NewRepositoryDiscoverer("/cfg/registry.json")
+	reloaded.FS = fsys
+	if err := reloaded.LoadRegistry(); err != nil {
+		t.Fatalf("LoadRegistry failed: %v", err)
+	}
+	repos := reloaded.GetRepositories()
+	if len(repos) != 1 {
+		t.Fatalf("expected one repository, got %+v", repos)
+	}
+	repo := repos[0]
+	if repo.Name != "lib" || repo.RemoteURL != "git@example.com:org/lib.git" || repo.CurrentBranch != "main" {
+		t.Errorf("unexpected repository: %+v", repo)
+	}
+	if !reflect.DeepEqual(repo.Categories, []string{"go"}) || !reflect.DeepEqual(repo.Tags, []string{"v1.0.0"}) {
+		t.Errorf("unexpected categories or tags: %+v", repo)
+	}
+	if reloaded.ResolveAlias("l") != "lib" {
+		t.Errorf("alias was not persisted")
+	}
+}
+
+func TestWorkspaceManagerWithMemFS(t *testing.T) {
+	output.SetQuiet(true)
+	defer output.SetQuiet(false)
+
+	// Workspace configurations live below the user config directory; point it somewhere harmless
+	// (only the journal is written to the real file system)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		t.Fatalf("failed to get config dir: %v", err)
+	}
+	registryPath := filepath.Join(configDir, "workspace-manager", "registry.json")
+
+	fsys := testkit.NewMemFS()
+	config := &wsm.WorkspaceConfig{WorkspaceDir: "/ws", RegistryPath: registryPath}
+	wm, err := wsm.NewWorkspaceManagerWithBackends(config, registryPath, fsys, testkit.NewFakeRunner())
+	if err != nil {
+		t.Fatalf("failed to create workspace manager: %v", err)
+	}
+
+	workspace := &wsm.Workspace{
+		Name:         "feat",
+		Path:         "/ws/feat",
+		Branch:       "feature/x",
+		Repositories: []wsm.Repository{{Name: "lib"}, {Name: "app"}},
+		GoWorkspace:  true,
+	}
+	_ = fsys.MkdirAll("/ws/feat/lib", 0755)
+	_ = fsys.MkdirAll("/ws/feat/app", 0755)
+	_ = fsys.WriteFile("/ws/feat/lib/go.mod", []byte("module example.com/lib\n"), 0644)
+
+	if err := wm.SaveWorkspace(workspace); err != nil {
+		t.Fatalf("SaveWorkspace failed: %v", err)
+	}
+	loaded, err := wm.LoadWorkspace("feat")
+	if err != nil {
+		t.Fatalf("LoadWorkspace failed: %v", err)
+	}
+	if loaded.Branch != "feature/x" || len(loaded.Repositories) != 2 {
+		t.Errorf("unexpected workspace: %+v", loaded)
+	}
+
+	if err := wm.CreateGoWorkspace(workspace); err != nil {
+		t.Fatalf("CreateGoWorkspace failed: %v", err)
+	}
+	goWork, err := fsys.ReadFile("/ws/feat/go.work")
+	if err != nil {
+		t.Fatalf("go.work was not written: %v", err)
+	}
+	if !strings.Contains(string(goWork), "./lib") || strings.Contains(string(goWork), "./app") {
+		t.Errorf("go.work should only use ./lib:\n%s", goWork)
+	}
+
+	if _, err := os.Stat("/ws/feat"); !os.IsNotExist(err) {
+		t.Errorf("the real file system was touched")
+	}
+}
+
+func TestApplySwitchRollbackDeletesCreatedBranches(t *testing.T) {
+	output.SetQuiet(true)
+	defer output.SetQuiet(false)
+
+	runner := testkit.NewFakeRunner().
+		On("git checkout -b feature/y main", "").
+		On("git checkout -b feature/y --track origin/feature/y", "").
+		Fail("git checkout -b feature/y develop", errors.New("exit status 128")).
+		On("git checkout feature/x", "").
+		On("git branch -D feature/y", "")
+
+	config := &wsm.WorkspaceConfig{WorkspaceDir: "/ws", RegistryPath: "/config/registry.json"}
+	wm, err := wsm.NewWorkspaceManagerWithBackends(config, config.RegistryPath, testkit.NewMemFS(), runner)
+	if err != nil {
+		t.Fatalf("failed to create workspace manager: %v", err)
+	}
+
+	workspace := &wsm.Workspace{Name: "feat", Path: "/ws/feat", Branch: "feature/x"}
+	steps := []wsm.SwitchStep{
+		{Repository: "lib", Action: wsm.SwitchCreate, From: "feature/x", Base: "main"},
+		{Repository: "cli", Action: wsm.SwitchTrack, From: "feature/x", Base: "origin/feature/y"},
+		{Repository: "app", Action: wsm.SwitchCreate, From: "feature/x", Base: "develop"},
+	}
+	if err := wm.ApplySwitch(context.Background(), workspace, "feature/y", steps); err == nil {
+		t.Fatalf("expected the switch to fail")
+	}
+
+	for _, want := range []string{
+		"/ws/feat/lib: git branch -D feature/y",
+		"/ws/feat/cli: git branch -D feature/y",
+	} {
+		if !slices.Contains(runner.Calls, want) {
+			t.Errorf("expected %q during rollback, got %v", want, runner.Calls)
+		}
+	}
+	if slices.Contains(runner.Calls, "/ws/feat/app: git branch -D feature/y") {
+		t.Errorf("the branch of the failed repository was never created and must not be deleted")
+	}
+	if workspace.Branch != "feature/x" {
+		t.Errorf("workspace branch should be unchanged, got %s", workspace.Branch)
+	}
+}
@@ -0,0 +1,294 @@
+package wsm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Phases measured by 'wsm bench'
+const (
+	BenchPhaseDiscovery = "discovery"
+	BenchPhaseStatus    = "status"
+	BenchPhaseWorktree  = "worktree"
+	BenchPhaseSync      = "sync"
+)
+
+// BenchPhases lists the benchmark phases in the order they run
+var BenchPhases = []string{BenchPhaseDiscovery, BenchPhaseStatus, BenchPhaseWorktree, BenchPhaseSync}
+
+// slowFactor is how many times slower than the typical repository of a phase a repository has
+// to be to be flagged, and slowMinimum the least time it has to take
+const (
+	slowFactor  = 3
+	slowMinimum = 100 * time.Millisecond
+)
+
+// BenchReport holds the timings of a benchmark run
+type BenchReport struct {
+	Machine   BenchMachine `json:"machine"`
+	Workspace string       `json:"workspace,omitempty"`
+	Runs      int          `json:"runs"`
+	Phases    []BenchPhase `json:"phases"`
+}
+
+// BenchMachine describes where the benchmark ran
+type BenchMachine struct {
+	OS   string `json:"os"`
+	Arch string `json:"arch"`
+	CPUs int    `json:"cpus"`
+	Git  string `json:"git"`
+}
+
+// BenchPhase is the timing of one phase, with a breakdown per repository (or discovery path)
+type BenchPhase struct {
+	Name string `json:"name"`
+	// Runs are the wall times of the whole phase
+	Runs    []time.Duration `json:"runs"`
+	Samples []BenchSample   `json:"samples"`
+	Skipped string          `json:"skipped,omitempty"`
+}
+
+// BenchSample is the timing of one repository or discovery path over all runs
+type BenchSample struct {
+	Target string          `json:"target"`
+	Runs   []time.Duration `json:"runs"`
+	Error  string          `json:"error,omitempty"`
+	// Slow flags a repository much slower than the others of the phase
+	Slow bool `json:"slow,omitempty"`
+}
+
+// Median returns the median of the runs of a phase
+func (p BenchPhase) Median() time.Duration {
+	return medianDuration(p.Runs)
+}
+
+// Median returns the median of the runs of a sample
+func (s BenchSample) Median() time.Duration {
+	return medianDuration(s.Runs)
+}
+
+// Min returns the fastest run of a sample
+func (s BenchSample) Min() time.Duration {
+	if len(s.Runs) == 0 {
+		return 0
+	}
+	return sortedDurations(s.Runs)[0]
+}
+
+// Max returns the slowest run of a sample
+func (s BenchSample) Max() time.Duration {
+	if len(s.Runs) == 0 {
+		return 0
+	}
+	return sortedDurations(s.Runs)[len(s.Runs)-1]
+}
+
+func sortedDurations(durations []time.Duration) []time.Duration {
+	sorted := append([]time.Duration{}, durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted
+}
+
+func medianDuration(durations []time.Duration) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	return sortedDurations(durations)[len(durations)/2]
+}
+
+// Benchmark measures workspace operations on the current machine. Every phase except discovery
+// needs a workspace; worktree creation uses temporary detached worktrees of the source
+// repositories and sync fetches without updating any ref, so nothing is changed.
+type Benchmark struct {
+	Workspace      *Workspace
+	Checker        *StatusChecker
+	Discovery      DiscoveryConfig
+	DiscoveryPaths []string
+	Runs           int
+	// Progress is called before each measurement
+	Progress func(phase, target string, run int)
+}
+
+// Run measures the given phases
+func (b *Benchmark) Run(ctx context.Context, phases []string) (*BenchReport, error) {
+	runs := max(b.Runs, 1)
+	report := &BenchReport{
+		Machine: BenchMachine{OS: runtime.GOOS, Arch: runtime.GOARCH, CPUs: runtime.NumCPU()},
+		Runs:    runs,
+	}
+	if version, err := runGitOutput(ctx, "", "--version"); err == nil {
+		report.Machine.Git = version
+	}
+	if b.Workspace != nil {
+		report.Workspace = b.Workspace.Name
+	}
+	if b.Checker == nil {
+		b.Checker = NewStatusChecker()
+	}
+
+	for _, name := range phases {
+		phase := BenchPhase{Name: name}
+		var measure benchMeasure
+		var targets []string
+
+		switch name {
+		case BenchPhaseDiscovery:
+			targets = b.DiscoveryPaths
+			if len(targets) == 0 {
+				phase.Skipped = "no discovery paths"
+			}
+			measure = b.measureDiscovery
+		case BenchPhaseStatus, BenchPhaseWorktree, BenchPhaseSync:
+			if b.Workspace == nil {
+				phase.Skipped = "no workspace"
+				break
+			}
+			for _, repo := range b.Workspace.Repositories {
+				targets = append(targets, repo.Name)
+			}
+			measure = map[string]benchMeasure{
+				BenchPhaseStatus:   b.measureStatus,
+				BenchPhaseWorktree: b.measureWorktree,
+				BenchPhaseSync:     b.measureSync,
+			}[name]
+		default:
+			return nil, errors.Errorf("unknown benchmark phase '%s' (expected one of discovery, status, worktree, sync)", name)
+		}
+
+		if phase.Skipped == "" {
+			phase.Samples = make([]BenchSample, len(targets))
+			for i, target := range targets {
+				phase.Samples[i].Target = target
+			}
+			for run := 0; run < runs; run++ {
+				var total time.Duration
+				for i, target := range targets {
+					if err := ctx.Err(); err != nil {
+						return nil, err
+					}
+					if b.Progress != nil {
+						b.Progress(name, target, run+1)
+					}
+					elapsed, err := timeOperation(ctx, target, measure)
+					if err != nil {
+						phase.Samples[i].Error = err.Error()
+						continue
+					}
+					phase.Samples[i].Runs = append(phase.Samples[i].Runs, elapsed)
+					total += elapsed
+				}
+				phase.Runs = append(phase.Runs, total)
+			}
+			flagSlowSamples(phase.Samples)
+			sort.SliceStable(phase.Samples, func(i, j int) bool {
+				return phase.Samples[i].Median() > phase.Samples[j].Median()
+			})
+		}
+		report.Phases = append(report.Phases, phase)
+	}
+	return report, nil
+}
+
+// benchMeasure prepares the operation measured on a target and returns it with the cleanup to
+// run once it has been timed; cleanup may be nil
+type benchMeasure func(ctx context.Context, target string) (operation func() error, cleanup func(), err error)
+
+// timeOperation prepares an operation, then times it; preparation and cleanup are not timed
+func timeOperation(ctx context.Context, target string, measure benchMeasure) (time.Duration, error) {
+	operation, cleanup, err := measure(ctx, target)
+	if err != nil {
+		return 0, err
+	}
+	if cleanup != nil {
+		defer cleanup()
+	}
+	start := time.Now()
+	err = operation()
+	return time.Since(start), err
+}
+
+// flagSlowSamples marks the samples far slower than the median sample of the phase
+func flagSlowSamples(samples []BenchSample) {
+	var medians []time.Duration
+	for _, sample := range samples {
+		if len(sample.Runs) > 0 {
+			medians = append(medians, sample.Median())
+		}
+	}
+	if len(medians) < 2 {
+		return
+	}
+	typical := medianDuration(medians)
+	for i, sample := range samples {
+		median := sample.Median()
+		samples[i].Slow = median >= slowMinimum && median > typical*slowFactor
+	}
+}
+
+func (b *Benchmark) measureDiscovery(ctx context.Context, path string) (func() error, func(), error) {
+	discoverer := NewRepositoryDiscoverer("")
+	options := b.Discovery.DiscoveryOptionsFor(path, true, 0)
+	return func() error {
+		if _, err := os.Stat(path); err != nil {
+			return errors.Wrapf(err, "failed to scan %s", path)
+		}
+		discoverer.walkRepositories(ctx, path, options)
+		return nil
+	}, nil, nil
+}
+
+func (b *Benchmark) measureStatus(ctx context.Context, name string) (func() error, func(), error) {
+	repo, ok := FindRepository(b.Workspace, name)
+	if !ok {
+		return nil, nil, errors.Errorf("repository '%s' is not in the workspace", name)
+	}
+	return func() error {
+		_, err := b.Checker.getRepositoryStatus(ctx, repo, filepath.Join(b.Workspace.Path, name))
+		return err
+	}, nil, nil
+}
+
+// measureWorktree times adding a detached worktree of the source repository; the worktree is
+// removed right after, outside of the measurement
+func (b *Benchmark) measureWorktree(ctx context.Context, name string) (func() error, func(), error) {
+	repo, ok := FindRepository(b.Workspace, name)
+	if !ok {
+		return nil, nil, errors.Errorf("repository '%s' is not in the workspace", name)
+	}
+	dir, err := os.MkdirTemp("", "wsm-bench-")
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to create temporary directory")
+	}
+	target := filepath.Join(dir, name)
+	operation := func() error {
+		if _, err := runGitOutput(ctx, repo.Path, "worktree", "add", "--detach", target, "HEAD"); err != nil {
+			return errors.Wrap(err, "failed to add worktree")
+		}
+		return nil
+	}
+	// Removing the worktree drops its administrative files as well, so the other worktrees of the
+	// repository are left alone (no prune)
+	cleanup := func() {
+		_, _ = runGitOutput(context.WithoutCancel(ctx), repo.Path, "worktree", "remove", "--force", target)
+		_ = os.RemoveAll(dir)
+	}
+	return operation, cleanup, nil
+}
+
+// measureSync times fetching origin with --dry-run, which contacts the remote and transfers
+// objects but updates no ref
+func (b *Benchmark) measureSync(ctx context.Context, name string) (func() error, func(), error) {
+	dir := filepath.Join(b.Workspace.Path, name)
+	return func() error {
+		if _, err := runGitOutput(ctx, dir, "fetch", "--dry-run", "--quiet", "origin"); err != nil {
+			return errors.Wrap(err, "failed to fetch origin")
+		}
+		return nil
+	}, nil, nil
+}
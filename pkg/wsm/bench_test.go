@@ -0,0 +1,54 @@
+package wsm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTimeOperationExcludesCleanup(t *testing.T) {
+	cleaned := false
+	measure := func(ctx context.Context, target string) (func() error, func(), error) {
+		return func() error { return nil }, func() {
+			time.Sleep(50 * time.Millisecond)
+			cleaned = true
+		}, nil
+	}
+	elapsed, err := timeOperation(context.Background(), "lib", measure)
+	if err != nil {
+		t.Fatalf("timeOperation failed: %v", err)
+	}
+	if !cleaned {
+		t.Error("the cleanup did not run")
+	}
+	if elapsed >= 50*time.Millisecond {
+		t.Errorf("elapsed = %s, the cleanup was timed", elapsed)
+	}
+}
+
+func TestBenchmarkWorktreeLeavesTheRepositoryUnchanged(t *testing.T) {
+	repo := filepath.Join(t.TempDir(), "lib")
+	testGit(t, filepath.Dir(repo), "init", "--quiet", repo)
+	testGit(t, repo, "commit", "--quiet", "--allow-empty", "-m", "Initial commit")
+	// A worktree whose directory is gone: pruning would drop it, the benchmark must not
+	stale := filepath.Join(t.TempDir(), "stale")
+	testGit(t, repo, "worktree", "add", "--quiet", "--detach", stale)
+	if err := os.RemoveAll(stale); err != nil {
+		t.Fatal(err)
+	}
+	before := testGit(t, repo, "worktree", "list", "--porcelain")
+
+	bench := &Benchmark{Workspace: &Workspace{Name: "ws", Repositories: []Repository{{Name: "lib", Path: repo}}}, Runs: 2}
+	report, err := bench.Run(context.Background(), []string{BenchPhaseWorktree})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if sample := report.Phases[0].Samples[0]; sample.Error != "" || len(sample.Runs) != 2 {
+		t.Errorf("sample = %+v", sample)
+	}
+	if after := testGit(t, repo, "worktree", "list", "--porcelain"); after != before {
+		t.Errorf("worktrees changed:\n%s\nwant:\n%s", after, before)
+	}
+}
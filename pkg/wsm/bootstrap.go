@@ -0,0 +1,162 @@
+package wsm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+)
+
+// BootstrapConfig controls the dependency bootstrap phase run after workspace creation
+type BootstrapConfig struct {
+	// Enabled defaults to false when unset; create, fork, respin and apply take --bootstrap
+	Enabled     *bool `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	Concurrency int   `json:"concurrency,omitempty" yaml:"concurrency,omitempty"`
+}
+
+// IsEnabled reports whether bootstrap should run by default
+func (c BootstrapConfig) IsEnabled() bool {
+	return c.Enabled != nil && *c.Enabled
+}
+
+// BootstrapEnabled reports whether the configuration enables bootstrap after workspace creation
+func (wm *WorkspaceManager) BootstrapEnabled() bool {
+	return wm.config.Bootstrap.IsEnabled()
+}
+
+// BootstrapStep is a single dependency install command for a repository
+type BootstrapStep struct {
+	Repository string   `json:"repository"`
+	Dir        string   `json:"dir"`
+	Marker     string   `json:"marker"`
+	Command    []string `json:"command"`
+}
+
+// BootstrapResult is the outcome of a bootstrap step
+type BootstrapResult struct {
+	Step     BootstrapStep `json:"step"`
+	Duration time.Duration `json:"duration"`
+	Output   string        `json:"output,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// DetectBootstrapSteps returns the dependency install commands for each repository in the workspace
+func DetectBootstrapSteps(workspace *Workspace) []BootstrapStep {
+	var steps []BootstrapStep
+
+	for _, repo := range workspace.Repositories {
+		dir := filepath.Join(workspace.Path, repo.Name)
+
+		if fileExists(filepath.Join(dir, "go.mod")) {
+			steps = append(steps, BootstrapStep{Repository: repo.Name, Dir: dir, Marker: "go.mod", Command: []string{"go", "mod", "download"}})
+		}
+
+		if fileExists(filepath.Join(dir, "package.json")) {
+			command := []string{"npm", "install"}
+			switch {
+			case fileExists(filepath.Join(dir, "pnpm-lock.yaml")):
+				command = []string{"pnpm", "install"}
+			case fileExists(filepath.Join(dir, "yarn.lock")):
+				command = []string{"yarn", "install"}
+			case fileExists(filepath.Join(dir, "package-lock.json")):
+				command = []string{"npm", "ci"}
+			}
+			steps = append(steps, BootstrapStep{Repository: repo.Name, Dir: dir, Marker: "package.json", Command: command})
+		}
+
+		if fileExists(filepath.Join(dir, "requirements.txt")) {
+			// pip outside a virtualenv installs into the system or user site-packages
+			if !virtualenvActive() {
+				output.PrintInfo("Skipping pip install in %s: no virtualenv is active", repo.Name)
+				continue
+			}
+			steps = append(steps, BootstrapStep{Repository: repo.Name, Dir: dir, Marker: "requirements.txt", Command: []string{"pip", "install", "-r", "requirements.txt"}})
+		}
+	}
+
+	return steps
+}
+
+// virtualenvActive reports whether a Python virtualenv or conda environment is activated
+func virtualenvActive() bool {
+	return os.Getenv("VIRTUAL_ENV") != "" || os.Getenv("CONDA_PREFIX") != ""
+}
+
+// BootstrapWorkspace installs dependencies for all repositories concurrently, reporting progress as steps finish.
+// Failures are reported in the results and never abort the other steps.
+func (wm *WorkspaceManager) BootstrapWorkspace(ctx context.Context, workspace *Workspace) []BootstrapResult {
+	steps := DetectBootstrapSteps(workspace)
+	if len(steps) == 0 {
+		return nil
+	}
+
+	concurrency := wm.config.Bootstrap.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+
+	output.PrintInfo("Bootstrapping dependencies (%d steps)...", len(steps))
+
+	results := make([]BootstrapResult, len(steps))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	completed := 0
+
+	for i, step := range steps {
+		wg.Add(1)
+		go func(i int, step BootstrapStep) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			result := runBootstrapStep(ctx, step)
+			results[i] = result
+
+			mu.Lock()
+			defer mu.Unlock()
+			completed++
+			commandLine := strings.Join(step.Command, " ")
+			if result.Error != "" {
+				output.PrintWarning("[%d/%d] %s: %s failed after %s: %s", completed, len(steps), step.Repository, commandLine, result.Duration.Round(time.Millisecond), result.Error)
+			} else {
+				output.PrintSuccess("[%d/%d] %s: %s (%s)", completed, len(steps), step.Repository, commandLine, result.Duration.Round(time.Millisecond))
+			}
+		}(i, step)
+	}
+
+	wg.Wait()
+	return results
+}
+
+func runBootstrapStep(ctx context.Context, step BootstrapStep) BootstrapResult {
+	result := BootstrapResult{Step: step}
+	start := time.Now()
+
+	if _, err := exec.LookPath(step.Command[0]); err != nil {
+		result.Error = fmt.Sprintf("%s not found in PATH", step.Command[0])
+		return result
+	}
+
+	cmd := exec.CommandContext(ctx, step.Command[0], step.Command[1:]...)
+	cmd.Dir = step.Dir
+	out, err := cmd.CombinedOutput()
+	result.Duration = time.Since(start)
+	result.Output = string(out)
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
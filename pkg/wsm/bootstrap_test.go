@@ -0,0 +1,56 @@
+package wsm
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDetectBootstrapSteps(t *testing.T) {
+	root := t.TempDir()
+	writeGoFiles(t, root, map[string]string{
+		"api/go.mod":            "module example.com/api\n",
+		"web/package.json":      "{}",
+		"web/pnpm-lock.yaml":    "",
+		"site/package.json":     "{}",
+		"site/yarn.lock":        "",
+		"app/package.json":      "{}",
+		"app/package-lock.json": "{}",
+		"tool/package.json":     "{}",
+		"ml/requirements.txt":   "numpy\n",
+		"docs/README.md":        "",
+	})
+	workspace := &Workspace{Path: root}
+	for _, name := range []string{"api", "web", "site", "app", "tool", "ml", "docs"} {
+		workspace.Repositories = append(workspace.Repositories, Repository{Name: name})
+	}
+
+	describe := func(steps []BootstrapStep) map[string]string {
+		commands := map[string]string{}
+		for _, step := range steps {
+			commands[step.Repository] = fmt.Sprint(step.Command)
+		}
+		return commands
+	}
+	want := map[string]string{
+		"api":  "[go mod download]",
+		"web":  "[pnpm install]",
+		"site": "[yarn install]",
+		"app":  "[npm ci]",
+		"tool": "[npm install]",
+	}
+
+	// pip only runs inside a virtualenv
+	t.Setenv("VIRTUAL_ENV", "")
+	t.Setenv("CONDA_PREFIX", "")
+	got := describe(DetectBootstrapSteps(workspace))
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("steps = %v, want %v", got, want)
+	}
+
+	t.Setenv("VIRTUAL_ENV", "/tmp/venv")
+	want["ml"] = "[pip install -r requirements.txt]"
+	got = describe(DetectBootstrapSteps(workspace))
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("steps in a virtualenv = %v, want %v", got, want)
+	}
+}
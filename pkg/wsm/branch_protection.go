@@ -0,0 +1,157 @@
+package wsm
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"os/exec"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Branch protection modes, see BranchProtectionConfig
+const (
+	BranchProtectionWarn   = "warn"
+	BranchProtectionPrefix = "prefix"
+	BranchProtectionOff    = "off"
+
+	// DefaultProtectedBranchPrefix is put in front of protected branch names in prefix mode
+	DefaultProtectedBranchPrefix = "feature/"
+)
+
+// BranchProtectionModes are the valid values of BranchProtectionConfig.Mode
+var BranchProtectionModes = []string{BranchProtectionWarn, BranchProtectionPrefix, BranchProtectionOff}
+
+// branchProtectionTimeout bounds the GitHub lookups of one repository
+const branchProtectionTimeout = 15 * time.Second
+
+// BranchProtectionConfig decides what create and fork do when GitHub would reject pushes of the
+// branch of the new workspace
+type BranchProtectionConfig struct {
+	// Mode is "warn" to only warn, "prefix" to put Prefix in front of the branch name, or "off"
+	// (default) to not look up the rules at all
+	Mode string `json:"mode,omitempty" yaml:"mode,omitempty"`
+	// Prefix is used in prefix mode, default "feature/"
+	Prefix string `json:"prefix,omitempty" yaml:"prefix,omitempty"`
+}
+
+// Validate checks the mode
+func (c BranchProtectionConfig) Validate() error {
+	if c.Mode != "" && !slices.Contains(BranchProtectionModes, c.Mode) {
+		return errors.Errorf("invalid branch_protection.mode '%s': expected one of %s", c.Mode, strings.Join(BranchProtectionModes, ", "))
+	}
+	return nil
+}
+
+// ProtectedBranch is a repository whose GitHub rules would reject pushes of a branch
+type ProtectedBranch struct {
+	Repository string `json:"repository"`
+	Branch     string `json:"branch"`
+	// Rules are the types of the ruleset rules and the patterns of the classic protection rules
+	// that apply to the branch
+	Rules []string `json:"rules"`
+}
+
+// blockingRuleTypes are the ruleset rules that reject pushing a new branch or updating it directly
+var blockingRuleTypes = []string{
+	"creation", "update", "pull_request", "required_status_checks", "required_deployments",
+	"required_signatures", "branch_name_pattern",
+}
+
+// PrefixProtectedBranch puts prefix in front of branch, e.g. main becomes feature/main
+func PrefixProtectedBranch(branch, prefix string) string {
+	if prefix == "" {
+		prefix = DefaultProtectedBranchPrefix
+	}
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	if strings.HasPrefix(branch, prefix) {
+		return branch
+	}
+	return prefix + branch
+}
+
+// CheckBranchProtection looks up with the GitHub CLI whether the rulesets or classic branch
+// protection rules of the GitHub repositories would reject pushes of branch. Repositories hosted
+// elsewhere and failed lookups, e.g. when offline, are skipped: the check must never get in the way
+// of creating a workspace.
+func (wm *WorkspaceManager) CheckBranchProtection(ctx context.Context, repos []Repository, branch string) []ProtectedBranch {
+	if _, err := exec.LookPath("gh"); err != nil {
+		return nil
+	}
+
+	results := make([]*ProtectedBranch, len(repos))
+	var wg sync.WaitGroup
+	for i, repo := range repos {
+		host, slug, _ := strings.Cut(NormalizeRemoteURL(repo.RemoteURL), "/")
+		if repo.RemoteURL == "" || host != "github.com" || strings.Count(slug, "/") != 1 {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, branchProtectionTimeout)
+			defer cancel()
+			rules := wm.branchProtectionRules(ctx, repo.Path, slug, branch)
+			if len(rules) > 0 {
+				results[i] = &ProtectedBranch{Repository: repo.Name, Branch: branch, Rules: rules}
+			}
+		}()
+	}
+	wg.Wait()
+
+	var protected []ProtectedBranch
+	for _, result := range results {
+		if result != nil {
+			protected = append(protected, *result)
+		}
+	}
+	return protected
+}
+
+// branchProtectionRules returns the blocking rules GitHub applies to branch in the repository slug
+// (owner/name): the rules of all active rulesets, which GitHub resolves for branches that do not
+// exist yet, and the classic protection rules whose pattern matches the name
+func (wm *WorkspaceManager) branchProtectionRules(ctx context.Context, dir, slug, branch string) []string {
+	var rules []string
+
+	segments := strings.Split(branch, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	if out, err := wm.runner().Output(ctx, dir, "gh", "api", "repos/"+slug+"/rules/branches/"+strings.Join(segments, "/")); err == nil {
+		var applied []struct {
+			Type string `json:"type"`
+		}
+		if json.Unmarshal(out, &applied) == nil {
+			for _, rule := range applied {
+				if slices.Contains(blockingRuleTypes, rule.Type) && !slices.Contains(rules, rule.Type) {
+					rules = append(rules, rule.Type)
+				}
+			}
+		}
+	}
+
+	// Reading classic protection rules needs admin access to the repository; without it GitHub
+	// answers with an error or no rules, and only the rulesets are known
+	owner, name, _ := strings.Cut(slug, "/")
+	query := `query($owner: String!, $name: String!) { repository(owner: $owner, name: $name) { branchProtectionRules(first: 100) { nodes { pattern } } } }`
+	out, err := wm.runner().Output(ctx, dir, "gh", "api", "graphql", "-f", "query="+query, "-F", "owner="+owner, "-F", "name="+name,
+		"--jq", ".data.repository.branchProtectionRules.nodes[].pattern")
+	if err == nil {
+		for _, pattern := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+			// GitHub matches patterns like fnmatch with FNM_PATHNAME: * does not match /
+			if matched, _ := path.Match(pattern, branch); pattern != "" && matched {
+				rules = append(rules, "protected by "+pattern)
+			}
+		}
+	}
+
+	return rules
+}
@@ -0,0 +1,32 @@
+package wsm
+
+import "testing"
+
+func TestPrefixProtectedBranch(t *testing.T) {
+	tests := []struct {
+		branch string
+		prefix string
+		want   string
+	}{
+		{branch: "main", want: "feature/main"},
+		{branch: "release/1.2", prefix: "wip", want: "wip/release/1.2"},
+		{branch: "release/1.2", prefix: "wip/", want: "wip/release/1.2"},
+		{branch: "feature/main", want: "feature/main"},
+	}
+	for _, tt := range tests {
+		if got := PrefixProtectedBranch(tt.branch, tt.prefix); got != tt.want {
+			t.Errorf("PrefixProtectedBranch(%q, %q) = %q, want %q", tt.branch, tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestBranchProtectionConfigValidate(t *testing.T) {
+	for _, mode := range append([]string{""}, BranchProtectionModes...) {
+		if err := (BranchProtectionConfig{Mode: mode}).Validate(); err != nil {
+			t.Errorf("mode %q: %v", mode, err)
+		}
+	}
+	if err := (BranchProtectionConfig{Mode: "strict"}).Validate(); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
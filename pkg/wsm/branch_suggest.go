@@ -0,0 +1,180 @@
+package wsm
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// BranchSuggestion is a proposed branch name for a new workspace, with why it was proposed
+type BranchSuggestion struct {
+	Branch string
+	Reason string
+}
+
+// branchNamePlaceholder stands for the workspace name in learned branch patterns
+const branchNamePlaceholder = "{name}"
+
+// maxLearnedPatterns is the number of patterns learned from recent workspaces that are suggested
+const maxLearnedPatterns = 3
+
+// branchTypePrefixes map leading words of workspace names to conventional branch prefixes
+var branchTypePrefixes = map[string]string{
+	"fix":         "fix",
+	"bug":         "fix",
+	"bugfix":      "fix",
+	"hotfix":      "hotfix",
+	"feat":        "feature",
+	"feature":     "feature",
+	"enhancement": "feature",
+	"chore":       "chore",
+	"refactor":    "refactor",
+	"docs":        "docs",
+	"test":        "test",
+	"spike":       "spike",
+}
+
+var branchNameInvalid = regexp.MustCompile(`[^A-Za-z0-9._/-]+`)
+
+// SuggestBranchNames proposes branch names for a workspace: the patterns used most by recent
+// workspaces first, then <prefix>/<name>, then a conventional prefix taken from the first word
+// of the name ("fix-login" suggests fix/login)
+func SuggestBranchNames(ctx context.Context, name, prefix string) []BranchSuggestion {
+	slug := branchSlug(name)
+	var suggestions []BranchSuggestion
+	seen := map[string]bool{}
+	add := func(branch, reason string) {
+		if branch == "" || seen[branch] || ValidateBranchName(ctx, branch) != nil {
+			return
+		}
+		seen[branch] = true
+		suggestions = append(suggestions, BranchSuggestion{Branch: branch, Reason: reason})
+	}
+
+	for _, pattern := range learnBranchPatterns() {
+		add(strings.ReplaceAll(pattern.pattern, branchNamePlaceholder, slug),
+			fmt.Sprintf("like %d recent workspace(s), e.g. %s", pattern.count, pattern.example))
+	}
+	add(prefix+"/"+slug, "default pattern <prefix>/<name>")
+	if word, rest, ok := strings.Cut(slug, "-"); ok && rest != "" {
+		if typ, known := branchTypePrefixes[strings.ToLower(word)]; known {
+			add(typ+"/"+rest, fmt.Sprintf("'%s' names a %s branch", word, typ))
+		}
+	}
+	add(slug, "workspace name")
+
+	return suggestions
+}
+
+// branchPattern is a branch naming pattern learned from previous workspaces
+type branchPattern struct {
+	pattern string
+	count   int
+	last    time.Time
+	example string
+}
+
+// learnBranchPatterns derives naming patterns from the branches of existing workspaces and of
+// workspaces recorded in the history, most used first and most recent on ties
+func learnBranchPatterns() []branchPattern {
+	type sample struct {
+		workspace, branch string
+		when              time.Time
+	}
+	var samples []sample
+	seen := map[string]bool{}
+	if workspaces, err := LoadWorkspaces(); err == nil {
+		for _, workspace := range workspaces {
+			seen[workspace.Name] = true
+			samples = append(samples, sample{workspace.Name, workspace.Branch, workspace.Created})
+		}
+	}
+	if entries, err := ReadHistory("", time.Time{}); err == nil {
+		for i := len(entries) - 1; i >= 0; i-- {
+			entry := entries[i]
+			if entry.Operation != "create" || seen[entry.Workspace] {
+				continue
+			}
+			seen[entry.Workspace] = true
+			samples = append(samples, sample{entry.Workspace, entry.Parameters["branch"], entry.Time})
+		}
+	}
+
+	patterns := map[string]*branchPattern{}
+	for _, s := range samples {
+		pattern := branchPatternOf(s.workspace, s.branch)
+		if pattern == "" {
+			continue
+		}
+		p := patterns[pattern]
+		if p == nil {
+			p = &branchPattern{pattern: pattern}
+			patterns[pattern] = p
+		}
+		p.count++
+		if s.when.After(p.last) {
+			p.last = s.when
+			p.example = s.branch
+		}
+	}
+
+	var learned []branchPattern
+	for _, p := range patterns {
+		learned = append(learned, *p)
+	}
+	sort.Slice(learned, func(i, j int) bool {
+		if learned[i].count != learned[j].count {
+			return learned[i].count > learned[j].count
+		}
+		return learned[i].last.After(learned[j].last)
+	})
+	if len(learned) > maxLearnedPatterns {
+		learned = learned[:maxLearnedPatterns]
+	}
+	return learned
+}
+
+// branchPatternOf turns a branch into a pattern by replacing the workspace name with a
+// placeholder. Ticket numbers and dates next to the name differ between workspaces, so a last
+// path component with digits is reduced to the name: "alice/JIRA-12-login" for workspace "login"
+// gives "alice/{name}". Branches that do not contain the name keep only their prefix.
+func branchPatternOf(workspace, branch string) string {
+	if branch == "" {
+		return ""
+	}
+	slug := branchSlug(workspace)
+	if slug == "" || !strings.Contains(branch, slug) {
+		if slash := strings.LastIndex(branch, "/"); slash > 0 {
+			return branch[:slash+1] + branchNamePlaceholder
+		}
+		return ""
+	}
+
+	pattern := strings.Replace(branch, slug, branchNamePlaceholder, 1)
+	placeholder := strings.Index(pattern, branchNamePlaceholder)
+	dir := pattern[:strings.LastIndex(pattern[:placeholder], "/")+1]
+	leaf := pattern[len(dir):]
+	if strings.ContainsAny(leaf, "0123456789") {
+		leaf = branchNamePlaceholder
+	}
+	return dir + leaf
+}
+
+// branchSlug makes a workspace name usable in a branch name
+func branchSlug(name string) string {
+	slug := branchNameInvalid.ReplaceAllString(strings.TrimSpace(name), "-")
+	return strings.Trim(slug, "-./")
+}
+
+// ValidateBranchName checks a branch name with git check-ref-format
+func ValidateBranchName(ctx context.Context, branch string) error {
+	if _, err := runGitOutput(ctx, "", "check-ref-format", "--branch", branch); err != nil {
+		return errors.Errorf("'%s' is not a valid branch name", branch)
+	}
+	return nil
+}
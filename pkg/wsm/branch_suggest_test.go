@@ -0,0 +1,95 @@
+package wsm
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestBranchSlug(t *testing.T) {
+	for name, want := range map[string]string{
+		"login":             "login",
+		" Fix the login! ":  "Fix-the-login",
+		"api/v2 migration.": "api/v2-migration",
+		"--":                "",
+	} {
+		if got := branchSlug(name); got != want {
+			t.Errorf("branchSlug(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestBranchPatternOf(t *testing.T) {
+	tests := []struct{ workspace, branch, want string }{
+		{"login", "task/login", "task/{name}"},
+		{"login", "alice/JIRA-12-login", "alice/{name}"},
+		{"login", "alice/login-2026-10-16", "alice/{name}"},
+		{"login", "feature/login-v", "feature/{name}-v"},
+		{"login", "login", "{name}"},
+		{"login", "team/auth/something-else", "team/auth/{name}"},
+		{"login", "main", ""},
+		{"login", "", ""},
+	}
+	for _, tt := range tests {
+		if got := branchPatternOf(tt.workspace, tt.branch); got != tt.want {
+			t.Errorf("branchPatternOf(%s, %s) = %q, want %q", tt.workspace, tt.branch, got, tt.want)
+		}
+	}
+}
+
+func TestValidateBranchName(t *testing.T) {
+	ctx := context.Background()
+	for branch, valid := range map[string]bool{
+		"feature/login": true,
+		"fix-2":         true,
+		"a..b":          false,
+		"bad name":      false,
+		"ends.lock":     false,
+	} {
+		if err := ValidateBranchName(ctx, branch); (err == nil) != valid {
+			t.Errorf("ValidateBranchName(%q) = %v, want valid %v", branch, err, valid)
+		}
+	}
+}
+
+func TestSuggestBranchNamesLearnsFromWorkspaces(t *testing.T) {
+	useTestConfigDir(t)
+	configDir, err := ConfigDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	files := map[string]string{}
+	for _, workspace := range []Workspace{
+		{Name: "login", Branch: "alice/JIRA-12-login", Created: now.Add(-2 * time.Hour)},
+		{Name: "signup", Branch: "alice/signup", Created: now.Add(-time.Hour)},
+	} {
+		data, err := json.Marshal(workspace)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[filepath.Join("workspaces", workspace.Name+".json")] = string(data)
+	}
+	writeGoFiles(t, configDir, files)
+	// Deleted workspaces are still known from the history
+	RecordOperation("create", "search", now, map[string]string{"branch": "task/search"})
+	RecordOperation("create", "login", now, map[string]string{"branch": "old/login"})
+
+	learned := learnBranchPatterns()
+	if len(learned) != 2 || learned[0].pattern != "alice/{name}" || learned[0].count != 2 || learned[0].example != "alice/signup" ||
+		learned[1].pattern != "task/{name}" {
+		t.Errorf("learnBranchPatterns() = %+v", learned)
+	}
+
+	var branches []string
+	for _, suggestion := range SuggestBranchNames(context.Background(), "fix-payment", "task") {
+		branches = append(branches, suggestion.Branch)
+	}
+	want := []string{"alice/fix-payment", "task/fix-payment", "fix/payment", "fix-payment"}
+	if !slices.Equal(branches, want) {
+		t.Errorf("SuggestBranchNames = %q, want %q", branches, want)
+	}
+}
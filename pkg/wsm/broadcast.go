@@ -0,0 +1,140 @@
+package wsm
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Broadcast target states
+const (
+	BroadcastCreate    = "create"
+	BroadcastUpdate    = "update"
+	BroadcastUnchanged = "unchanged"
+)
+
+// BroadcastOptions describes a file distributed to every repository of a workspace
+type BroadcastOptions struct {
+	Source string
+	// Dest is the path inside each repository; defaults to the base name of Source
+	Dest string
+	// Template renders the file with the workspace context, scoped to each repository
+	Template bool
+	// Repositories restricts the broadcast to these repositories
+	Repositories []string
+}
+
+// BroadcastTarget is the file a broadcast writes into one repository
+type BroadcastTarget struct {
+	Repository string      `json:"repository"`
+	Path       string      `json:"path"`
+	State      string      `json:"state"`
+	Content    []byte      `json:"-"`
+	Mode       os.FileMode `json:"-"`
+}
+
+// PlanBroadcast renders the file for every repository of the workspace and compares it with the
+// file already there
+func PlanBroadcast(workspace *Workspace, opts BroadcastOptions) ([]BroadcastTarget, error) {
+	source, err := os.ReadFile(opts.Source)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", opts.Source)
+	}
+	info, err := os.Stat(opts.Source)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to stat %s", opts.Source)
+	}
+
+	dest := opts.Dest
+	if dest == "" {
+		dest = filepath.Base(opts.Source)
+	}
+	if !filepath.IsLocal(dest) {
+		return nil, errors.Errorf("destination '%s' must be a path inside the repositories", dest)
+	}
+
+	var data *AgentTemplateData
+	if opts.Template {
+		data = NewAgentTemplateData(workspace)
+	}
+
+	var targets []BroadcastTarget
+	for _, repo := range workspace.Repositories {
+		if len(opts.Repositories) > 0 && !slices.Contains(opts.Repositories, repo.Name) {
+			continue
+		}
+
+		content := source
+		if opts.Template {
+			content = RenderAgentTemplate(filepath.Base(opts.Source), source, data.forRepository(repo.Name))
+		}
+
+		target := BroadcastTarget{
+			Repository: repo.Name,
+			Path:       filepath.Join(workspace.Path, repo.Name, dest),
+			State:      BroadcastCreate,
+			Content:    content,
+			Mode:       info.Mode().Perm(),
+		}
+		if existing, err := os.ReadFile(target.Path); err == nil {
+			target.State = BroadcastUpdate
+			if bytes.Equal(existing, content) {
+				target.State = BroadcastUnchanged
+			}
+		}
+		targets = append(targets, target)
+	}
+
+	return targets, nil
+}
+
+// BroadcastDiff returns the diff between the file in the repository and the broadcast content
+func BroadcastDiff(ctx context.Context, target BroadcastTarget, color bool) (string, error) {
+	tmp, err := os.CreateTemp("", "wsm-broadcast-*")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create temporary file")
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(target.Content); err != nil {
+		tmp.Close()
+		return "", errors.Wrap(err, "failed to write temporary file")
+	}
+	tmp.Close()
+
+	colorFlag := "--color=never"
+	if color {
+		colorFlag = "--color=always"
+	}
+	// git diff --no-index exits with 1 when the files differ
+	out, err := exec.CommandContext(ctx, "git", "diff", "--no-index", colorFlag, "--", target.Path, tmp.Name()).Output()
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+		return "", errors.Wrap(err, "git diff failed")
+	}
+	return strings.ReplaceAll(string(out), tmp.Name(), target.Path), nil
+}
+
+// WriteBroadcastTarget writes the broadcast file into its repository and optionally stages it
+func WriteBroadcastTarget(ctx context.Context, workspace *Workspace, target BroadcastTarget, stage bool) error {
+	if err := workspace.RequireWorktrees("broadcasting files"); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target.Path), 0755); err != nil {
+		return errors.Wrapf(err, "failed to create directory for %s", target.Path)
+	}
+	if err := os.WriteFile(target.Path, target.Content, target.Mode); err != nil {
+		return errors.Wrapf(err, "failed to write %s", target.Path)
+	}
+	if stage {
+		if _, err := runGitOutput(ctx, filepath.Join(workspace.Path, target.Repository), "add", "--", target.Path); err != nil {
+			return errors.Wrapf(err, "failed to stage %s", target.Path)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package wsm
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPlanBroadcast(t *testing.T) {
+	root := t.TempDir()
+	writeGoFiles(t, root, map[string]string{
+		"src/AGENTS.md":      "# Agents\n",
+		"lib/AGENTS.md":      "# Agents\n",
+		"app/docs/AGENTS.md": "# Old\n",
+	})
+	workspace := &Workspace{Name: "ws", Path: root, Repositories: []Repository{{Name: "lib"}, {Name: "app"}, {Name: "tools"}}}
+	source := filepath.Join(root, "src", "AGENTS.md")
+
+	targets, err := PlanBroadcast(workspace, BroadcastOptions{Source: source})
+	if err != nil {
+		t.Fatalf("PlanBroadcast failed: %v", err)
+	}
+	states := map[string]string{}
+	for _, target := range targets {
+		states[target.Repository] = target.State
+	}
+	if states["lib"] != BroadcastUnchanged || states["app"] != BroadcastCreate || states["tools"] != BroadcastCreate {
+		t.Errorf("states = %v", states)
+	}
+
+	targets, err = PlanBroadcast(workspace, BroadcastOptions{Source: source, Dest: "docs/AGENTS.md", Repositories: []string{"app"}})
+	if err != nil {
+		t.Fatalf("PlanBroadcast failed: %v", err)
+	}
+	if len(targets) != 1 || targets[0].State != BroadcastUpdate || targets[0].Path != filepath.Join(root, "app", "docs", "AGENTS.md") {
+		t.Errorf("targets = %+v", targets)
+	}
+
+	for _, dest := range []string{"..", "../x", "foo/../..", "foo/../../x", filepath.Join(root, "x")} {
+		_, err := PlanBroadcast(workspace, BroadcastOptions{Source: source, Dest: dest})
+		if err == nil || !strings.Contains(err.Error(), "inside the repositories") {
+			t.Errorf("destination %q: expected an error, got %v", dest, err)
+		}
+	}
+}
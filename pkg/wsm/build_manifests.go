@@ -0,0 +1,191 @@
+package wsm
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/pkg/errors"
+)
+
+// buildManifestHeader marks the build manifests written by workspace manager, so they are
+// regenerated and removed without touching files of the same name created by hand
+const buildManifestHeader = "Generated by workspace-manager"
+
+// buildManifest is a file at the workspace root that makes the build tool of an ecosystem treat the
+// repositories of the workspace as one project, like go.work does for Go
+type buildManifest struct {
+	// Name identifies the manifest in Workspace.BuildManifests
+	Name string
+	// Category selects the repositories of the ecosystem, see categorizeRepository
+	Category string
+	// File is written to the workspace root
+	File string
+	// Marker is the file a repository needs to be a member
+	Marker string
+	// Content renders the manifest for the member directories
+	Content func(workspace *Workspace, members []string) string
+}
+
+// buildManifests are the manifests generated next to go.work, which is handled by UpdateGoWorkspace
+var buildManifests = []buildManifest{
+	{Name: "pnpm", Category: "node", File: "pnpm-workspace.yaml", Marker: "package.json", Content: pnpmWorkspaceContent},
+	{Name: "cargo", Category: "rust", File: "Cargo.toml", Marker: "Cargo.toml", Content: cargoWorkspaceContent},
+	{Name: "maven", Category: "java", File: "pom.xml", Marker: "pom.xml", Content: mavenAggregatorContent},
+}
+
+// BuildManifestFiles returns the files of the build manifests generated for the workspace,
+// including go.work
+func BuildManifestFiles(workspace *Workspace) []string {
+	var files []string
+	if workspace.GoWorkspace {
+		files = append(files, "go.work")
+	}
+	for _, manifest := range buildManifests {
+		if slices.Contains(workspace.BuildManifests, manifest.Name) {
+			files = append(files, manifest.File)
+		}
+	}
+	return files
+}
+
+// UpdateBuildManifests brings go.work and the other build manifests in line with the repositories
+// of the workspace after they changed. GoWorkspace and BuildManifests are updated in memory only.
+func (wm *WorkspaceManager) UpdateBuildManifests(ctx context.Context, workspace *Workspace) error {
+	if err := wm.UpdateGoWorkspace(ctx, workspace); err != nil {
+		return err
+	}
+	return wm.updateBuildManifests(workspace)
+}
+
+// updateBuildManifests writes the manifest of every ecosystem the workspace (including its child
+// workspaces) has member repositories of, and removes the manifests it no longer has members for.
+// A file of the same name that was not generated is left alone.
+func (wm *WorkspaceManager) updateBuildManifests(workspace *Workspace) error {
+	expanded, err := ExpandWorkspace(workspace)
+	if err != nil {
+		return err
+	}
+
+	for _, manifest := range buildManifests {
+		path := filepath.Join(workspace.Path, manifest.File)
+		generated := slices.Contains(workspace.BuildManifests, manifest.Name)
+		members := wm.buildManifestMembers(expanded, manifest)
+
+		if len(members) == 0 {
+			if !generated {
+				continue
+			}
+			if wm.isGeneratedBuildManifest(path) {
+				if err := wm.fs().Remove(path); err != nil && !os.IsNotExist(err) {
+					return errors.Wrapf(err, "failed to remove %s", path)
+				}
+				output.PrintInfo("Removed %s: the workspace has no %s repositories left", manifest.File, manifest.Category)
+			}
+			workspace.BuildManifests = slices.DeleteFunc(workspace.BuildManifests, func(name string) bool { return name == manifest.Name })
+			continue
+		}
+
+		if _, err := wm.fs().Stat(path); err == nil && !wm.isGeneratedBuildManifest(path) {
+			output.PrintWarning("Not writing %s: %s was not generated by workspace-manager", manifest.File, path)
+			continue
+		}
+
+		output.LogInfo(
+			fmt.Sprintf("Writing %s at %s", manifest.File, path),
+			"Writing build manifest",
+			"manifest", manifest.Name,
+			"path", path,
+		)
+		if err := wm.fs().WriteFile(path, []byte(manifest.Content(workspace, members)), 0644); err != nil {
+			return errors.Wrapf(err, "failed to write %s", path)
+		}
+		if !generated {
+			workspace.BuildManifests = append(workspace.BuildManifests, manifest.Name)
+		}
+	}
+
+	return nil
+}
+
+// buildManifestMembers returns the repositories of the manifest's ecosystem that have its marker
+// file. Cargo repositories that are workspaces themselves cannot be nested and are skipped.
+func (wm *WorkspaceManager) buildManifestMembers(workspace *Workspace, manifest buildManifest) []string {
+	var members []string
+	for _, repo := range workspace.Repositories {
+		if !slices.Contains(repo.Categories, manifest.Category) {
+			continue
+		}
+		data, err := wm.fs().ReadFile(filepath.Join(workspace.Path, repo.Name, manifest.Marker))
+		if err != nil {
+			continue
+		}
+		if manifest.Name == "cargo" && cargoWorkspaceTable.Match(data) {
+			output.PrintWarning("Not adding %s to Cargo.toml: it is a Cargo workspace itself", repo.Name)
+			continue
+		}
+		members = append(members, repo.Name)
+	}
+	return members
+}
+
+var cargoWorkspaceTable = regexp.MustCompile(`(?m)^\s*\[workspace\]`)
+
+// isGeneratedBuildManifest reports whether the header of the file marks it as generated
+func (wm *WorkspaceManager) isGeneratedBuildManifest(path string) bool {
+	data, err := wm.fs().ReadFile(path)
+	if err != nil {
+		return false
+	}
+	// The header follows the XML declaration of pom.xml
+	head, _, _ := strings.Cut(string(data), "\n<project")
+	return strings.Contains(head, buildManifestHeader)
+}
+
+func pnpmWorkspaceContent(_ *Workspace, members []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\npackages:\n", buildManifestHeader)
+	for _, member := range members {
+		fmt.Fprintf(&b, "  - %q\n", member)
+	}
+	return b.String()
+}
+
+func cargoWorkspaceContent(_ *Workspace, members []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n[workspace]\nresolver = \"2\"\nmembers = [\n", buildManifestHeader)
+	for _, member := range members {
+		fmt.Fprintf(&b, "    %q,\n", member)
+	}
+	b.WriteString("]\n")
+	return b.String()
+}
+
+// mavenArtifactChars are the characters not allowed in a Maven artifact id
+var mavenArtifactChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+func mavenAggregatorContent(workspace *Workspace, members []string) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	fmt.Fprintf(&b, "<!-- %s -->\n", buildManifestHeader)
+	b.WriteString(`<project xmlns="http://maven.apache.org/POM/4.0.0" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"` + "\n")
+	b.WriteString(`         xsi:schemaLocation="http://maven.apache.org/POM/4.0.0 https://maven.apache.org/xsd/maven-4.0.0.xsd">` + "\n")
+	b.WriteString("  <modelVersion>4.0.0</modelVersion>\n")
+	b.WriteString("  <groupId>workspace</groupId>\n")
+	fmt.Fprintf(&b, "  <artifactId>%s</artifactId>\n", mavenArtifactChars.ReplaceAllString(workspace.Name, "-"))
+	b.WriteString("  <version>0.0.0-SNAPSHOT</version>\n")
+	b.WriteString("  <packaging>pom</packaging>\n")
+	b.WriteString("  <modules>\n")
+	for _, member := range members {
+		fmt.Fprintf(&b, "    <module>%s</module>\n", html.EscapeString(member))
+	}
+	b.WriteString("  </modules>\n")
+	b.WriteString("</project>\n")
+	return b.String()
+}
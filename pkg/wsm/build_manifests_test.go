@@ -0,0 +1,93 @@
+package wsm
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBuildManifestContent(t *testing.T) {
+	members := []string{"web", "admin"}
+	if got, want := pnpmWorkspaceContent(nil, members), "# Generated by workspace-manager\npackages:\n  - \"web\"\n  - \"admin\"\n"; got != want {
+		t.Errorf("pnpmWorkspaceContent:\n%s\nwant:\n%s", got, want)
+	}
+	if got, want := cargoWorkspaceContent(nil, members), "# Generated by workspace-manager\n[workspace]\nresolver = \"2\"\nmembers = [\n    \"web\",\n    \"admin\",\n]\n"; got != want {
+		t.Errorf("cargoWorkspaceContent:\n%s\nwant:\n%s", got, want)
+	}
+
+	pom := mavenAggregatorContent(&Workspace{Name: "feat/login v2"}, []string{"core", "a&b"})
+	for _, want := range []string{
+		"<!-- Generated by workspace-manager -->\n<project ",
+		"<artifactId>feat-login-v2</artifactId>",
+		"<module>core</module>",
+		"<module>a&amp;b</module>",
+	} {
+		if !strings.Contains(pom, want) {
+			t.Errorf("pom.xml is missing %q:\n%s", want, pom)
+		}
+	}
+}
+
+func TestBuildManifestFiles(t *testing.T) {
+	workspace := &Workspace{GoWorkspace: true, BuildManifests: []string{"maven", "pnpm"}}
+	if got := BuildManifestFiles(workspace); !slices.Equal(got, []string{"go.work", "pnpm-workspace.yaml", "pom.xml"}) {
+		t.Errorf("BuildManifestFiles = %q", got)
+	}
+}
+
+func TestUpdateBuildManifests(t *testing.T) {
+	wm := newTestWorkspaceManager(t)
+	root := t.TempDir()
+	writeGoFiles(t, root, map[string]string{
+		"web/package.json":   "{}",
+		"admin/package.json": "{}",
+		"tools/README.md":    "",
+		"engine/Cargo.toml":  "[package]\nname = \"engine\"\n",
+		"nested/Cargo.toml":  "[workspace]\nmembers = [\"a\"]\n",
+		"pom.xml":            "<project><!-- mine --></project>\n",
+		"service/pom.xml":    "<project/>\n",
+	})
+	workspace := &Workspace{Name: "feat", Path: root, Repositories: []Repository{
+		{Name: "web", Categories: []string{"node"}},
+		{Name: "admin", Categories: []string{"node"}},
+		// Categorized as node but without package.json
+		{Name: "tools", Categories: []string{"node"}},
+		{Name: "engine", Categories: []string{"rust"}},
+		{Name: "nested", Categories: []string{"rust"}},
+		{Name: "service", Categories: []string{"java"}},
+	}}
+
+	if err := wm.updateBuildManifests(workspace); err != nil {
+		t.Fatalf("updateBuildManifests failed: %v", err)
+	}
+	if !slices.Equal(workspace.BuildManifests, []string{"pnpm", "cargo"}) {
+		t.Errorf("BuildManifests = %q, want pnpm and cargo", workspace.BuildManifests)
+	}
+	if content, _ := os.ReadFile(filepath.Join(root, "pnpm-workspace.yaml")); string(content) != pnpmWorkspaceContent(workspace, []string{"web", "admin"}) {
+		t.Errorf("pnpm-workspace.yaml:\n%s", content)
+	}
+	if content, _ := os.ReadFile(filepath.Join(root, "Cargo.toml")); string(content) != cargoWorkspaceContent(workspace, []string{"engine"}) {
+		t.Errorf("Cargo.toml:\n%s", content)
+	}
+	// A pom.xml written by hand is kept
+	if content, _ := os.ReadFile(filepath.Join(root, "pom.xml")); !strings.Contains(string(content), "mine") {
+		t.Errorf("pom.xml was overwritten:\n%s", content)
+	}
+	if !wm.isGeneratedBuildManifest(filepath.Join(root, "Cargo.toml")) || wm.isGeneratedBuildManifest(filepath.Join(root, "pom.xml")) {
+		t.Error("isGeneratedBuildManifest does not recognize the generated manifests")
+	}
+
+	// Manifests without members left are removed
+	workspace.Repositories = workspace.Repositories[3:]
+	if err := wm.updateBuildManifests(workspace); err != nil {
+		t.Fatalf("updateBuildManifests failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "pnpm-workspace.yaml")); !os.IsNotExist(err) {
+		t.Errorf("pnpm-workspace.yaml should be removed, got %v", err)
+	}
+	if !slices.Equal(workspace.BuildManifests, []string{"cargo"}) {
+		t.Errorf("BuildManifests = %q, want cargo", workspace.BuildManifests)
+	}
+}
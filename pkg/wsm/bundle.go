@@ -0,0 +1,582 @@
+package wsm
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/pkg/errors"
+)
+
+const bundleManifestName = "manifest.json"
+
+// BundleManifest describes the contents of a portable workspace bundle
+type BundleManifest struct {
+	Version      int                `json:"version"`
+	Exported     time.Time          `json:"exported"`
+	Workspace    Workspace          `json:"workspace"`
+	Repositories []BundleRepository `json:"repositories"`
+}
+
+// BundleRepository captures the exported state of a single workspace repository
+type BundleRepository struct {
+	Name           string   `json:"name"`
+	RemoteURL      string   `json:"remote_url"`
+	Branch         string   `json:"branch"`
+	Head           string   `json:"head"`
+	UnpushedCount  int      `json:"unpushed_count"`
+	HasBundle      bool     `json:"has_bundle"`
+	HasPatch       bool     `json:"has_patch"`
+	UntrackedFiles []string `json:"untracked_files"`
+}
+
+// ExportWorkspaceBundle writes a workspace, its unpushed commits and its dirty files into a single archive
+func (wm *WorkspaceManager) ExportWorkspaceBundle(ctx context.Context, name string, bundlePath string) (*BundleManifest, error) {
+	workspace, err := wm.LoadWorkspace(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load workspace '%s'", name)
+	}
+
+	stagingDir, err := os.MkdirTemp("", "wsm-export-")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create staging directory")
+	}
+	defer func() {
+		_ = os.RemoveAll(stagingDir)
+	}()
+
+	manifest := &BundleManifest{
+		Version:   1,
+		Exported:  time.Now(),
+		Workspace: *workspace,
+	}
+
+	for _, repo := range workspace.Repositories {
+		worktreePath := filepath.Join(workspace.Path, repo.Name)
+		repoStaging := filepath.Join(stagingDir, "repos", repo.Name)
+		if err := os.MkdirAll(repoStaging, 0755); err != nil {
+			return nil, errors.Wrapf(err, "failed to create staging directory for %s", repo.Name)
+		}
+
+		bundleRepo, err := exportRepository(ctx, repo, worktreePath, workspace.Branch, repoStaging)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to export repository %s", repo.Name)
+		}
+		manifest.Repositories = append(manifest.Repositories, *bundleRepo)
+
+		output.LogInfo(
+			fmt.Sprintf("Exported %s (branch: %s, unpushed: %d, dirty: %v)", repo.Name, bundleRepo.Branch, bundleRepo.UnpushedCount, bundleRepo.HasPatch),
+			"Exported repository",
+			"repo", repo.Name,
+			"branch", bundleRepo.Branch,
+			"unpushed", bundleRepo.UnpushedCount,
+		)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal bundle manifest")
+	}
+	if err := os.WriteFile(filepath.Join(stagingDir, bundleManifestName), data, 0644); err != nil {
+		return nil, errors.Wrap(err, "failed to write bundle manifest")
+	}
+
+	if err := writeTarGz(stagingDir, bundlePath); err != nil {
+		return nil, errors.Wrapf(err, "failed to write bundle archive: %s", bundlePath)
+	}
+
+	return manifest, nil
+}
+
+// exportRepository stages the git bundle, dirty patch and untracked files of a worktree
+func exportRepository(ctx context.Context, repo Repository, worktreePath, defaultBranch, stagingDir string) (*BundleRepository, error) {
+	bundleRepo := &BundleRepository{
+		Name:      repo.Name,
+		RemoteURL: repo.RemoteURL,
+		Branch:    defaultBranch,
+	}
+
+	if branch, err := runGitOutput(ctx, worktreePath, "branch", "--show-current"); err == nil && branch != "" {
+		bundleRepo.Branch = branch
+	}
+
+	head, err := runGitOutput(ctx, worktreePath, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to resolve HEAD")
+	}
+	bundleRepo.Head = head
+
+	// Commits that are not reachable from any remote ref need to travel in a git bundle
+	count, err := runGitOutput(ctx, worktreePath, "rev-list", "--count", "HEAD", "--not", "--remotes")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to count unpushed commits")
+	}
+	bundleRepo.UnpushedCount, _ = strconv.Atoi(count)
+
+	if bundleRepo.UnpushedCount > 0 && bundleRepo.Branch != "" {
+		bundleFile := filepath.Join(stagingDir, "branch.bundle")
+		if _, err := runGitOutput(ctx, worktreePath, "bundle", "create", bundleFile, bundleRepo.Branch, "--not", "--remotes"); err != nil {
+			return nil, errors.Wrap(err, "failed to create git bundle")
+		}
+		bundleRepo.HasBundle = true
+	}
+
+	// Staged and unstaged changes to tracked files
+	cmd := exec.CommandContext(ctx, "git", "diff", "HEAD", "--binary")
+	cmd.Dir = worktreePath
+	patch, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to compute working tree diff")
+	}
+	if len(patch) > 0 {
+		if err := os.WriteFile(filepath.Join(stagingDir, "changes.patch"), patch, 0644); err != nil {
+			return nil, errors.Wrap(err, "failed to write patch file")
+		}
+		bundleRepo.HasPatch = true
+	}
+
+	// Untracked files are copied verbatim
+	untracked, err := runGitOutput(ctx, worktreePath, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list untracked files")
+	}
+	for _, file := range strings.Split(untracked, "\n") {
+		if file == "" {
+			continue
+		}
+		if err := copyFile(filepath.Join(worktreePath, file), filepath.Join(stagingDir, "untracked", file)); err != nil {
+			return nil, errors.Wrapf(err, "failed to copy untracked file %s", file)
+		}
+		bundleRepo.UntrackedFiles = append(bundleRepo.UntrackedFiles, file)
+	}
+
+	return bundleRepo, nil
+}
+
+// ImportWorkspaceBundle recreates a workspace from an archive produced by ExportWorkspaceBundle
+func (wm *WorkspaceManager) ImportWorkspaceBundle(ctx context.Context, bundlePath string, newName string) (*Workspace, error) {
+	start := time.Now()
+	stagingDir, err := os.MkdirTemp("", "wsm-import-")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create staging directory")
+	}
+	defer func() {
+		_ = os.RemoveAll(stagingDir)
+	}()
+
+	if err := extractTarGz(bundlePath, stagingDir); err != nil {
+		return nil, errors.Wrapf(err, "failed to extract bundle: %s", bundlePath)
+	}
+
+	data, err := os.ReadFile(filepath.Join(stagingDir, bundleManifestName))
+	if err != nil {
+		return nil, errors.Wrap(err, "bundle does not contain a manifest")
+	}
+
+	var manifest BundleManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, errors.Wrap(err, "failed to parse bundle manifest")
+	}
+
+	name := manifest.Workspace.Name
+	if newName != "" {
+		name = newName
+	}
+	if err := validateBundleManifest(&manifest, name); err != nil {
+		return nil, err
+	}
+
+	if _, err := wm.LoadWorkspace(name); err == nil {
+		return nil, errors.Errorf("workspace '%s' already exists", name)
+	}
+
+	// Resolve all repositories against the local registry before touching anything
+	var repoNames []string
+	for _, bundleRepo := range manifest.Repositories {
+		repoNames = append(repoNames, bundleRepo.Name)
+	}
+	repos, err := wm.FindRepositories(repoNames)
+	if err != nil {
+		return nil, errors.Wrap(err, "bundle references repositories missing from the local registry")
+	}
+
+	workspace := manifest.Workspace
+	workspace.Name = name
+	// The repositories are always imported as worktrees, even from a linked or cloned workspace
+	workspace.Linked = false
+	workspace.Clone = ""
+	// Child workspaces are not part of the bundle and may not exist here; they are included again
+	// with 'child add'
+	if len(workspace.Children) > 0 || workspace.Parent != "" {
+		output.LogWarn(
+			fmt.Sprintf("Not importing the child workspaces (%s) or parent ('%s') of '%s'; include them again with 'workspace-manager child add'",
+				strings.Join(workspace.Children, ", "), workspace.Parent, manifest.Workspace.Name),
+			"Dropping child workspaces from imported bundle",
+			"children", workspace.Children,
+			"parent", workspace.Parent,
+		)
+		workspace.Children = nil
+		workspace.Parent = ""
+	}
+	workspace.Path = filepath.Join(wm.workspaceDir, name)
+	workspace.Repositories = repos
+	workspace.Created = time.Now()
+
+	if err := wm.EnforceQuota(ctx, true); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(workspace.Path, 0755); err != nil {
+		return nil, errors.Wrapf(err, "failed to create workspace directory: %s", workspace.Path)
+	}
+
+	var createdWorktrees []WorktreeInfo
+	for i, bundleRepo := range manifest.Repositories {
+		repo := repos[i]
+		repoStaging, err := containedPath(filepath.Join(stagingDir, "repos"), bundleRepo.Name)
+		if err != nil {
+			return nil, err
+		}
+		targetPath := filepath.Join(workspace.Path, repo.Name)
+
+		if err := wm.importRepository(ctx, repo, bundleRepo, repoStaging, targetPath); err != nil {
+			wm.rollbackWorktrees(ctx, createdWorktrees)
+			wm.cleanupWorkspaceDirectory(workspace.Path)
+			return nil, errors.Wrapf(err, "failed to import repository %s", repo.Name)
+		}
+
+		createdWorktrees = append(createdWorktrees, WorktreeInfo{
+			Repository: repo,
+			TargetPath: targetPath,
+			Branch:     bundleRepo.Branch,
+		})
+
+		// Keep the exported branch point when its commit is available locally
+		if branchPoint := workspace.BranchPoints[repo.Name]; branchPoint != "" {
+			if _, err := runGitOutput(ctx, targetPath, "cat-file", "-e", branchPoint+"^{commit}"); err == nil {
+				continue
+			}
+		}
+		recordBranchPoint(ctx, &workspace, repo.Name)
+	}
+
+	if workspace.GoWorkspace {
+		if err := wm.CreateGoWorkspace(&workspace); err != nil {
+			output.LogWarn(
+				fmt.Sprintf("Failed to create go.work file: %v", err),
+				"Failed to create go.work file, but continuing",
+				"error", err,
+			)
+		}
+	}
+
+	if err := wm.SaveWorkspace(&workspace); err != nil {
+		return nil, errors.Wrap(err, "failed to save workspace configuration")
+	}
+	wm.workspaceCreated(ctx, &workspace, "import-bundle", start, map[string]string{
+		"bundle": bundlePath,
+		"repos":  strings.Join(repoNames, ","),
+		"branch": workspace.Branch,
+	})
+
+	return &workspace, nil
+}
+
+// importRepository creates the worktree for a bundled repository and replays its local state
+func (wm *WorkspaceManager) importRepository(ctx context.Context, repo Repository, bundleRepo BundleRepository, stagingDir, targetPath string) error {
+	branch := bundleRepo.Branch
+
+	if bundleRepo.HasBundle {
+		bundleFile := filepath.Join(stagingDir, "branch.bundle")
+		if _, err := runGitOutput(ctx, repo.Path, "bundle", "verify", bundleFile); err != nil {
+			return errors.Wrap(err, "git bundle verification failed (missing prerequisite commits? try fetching first)")
+		}
+		refspec := fmt.Sprintf("refs/heads/%s:refs/heads/%s", branch, branch)
+		if err := wm.ExecuteWorktreeCommand(ctx, repo.Path, "git", "fetch", bundleFile, refspec); err != nil {
+			return errors.Wrap(err, "failed to fetch commits from bundle")
+		}
+	}
+
+	branchExists, _ := wm.CheckBranchExists(ctx, repo.Path, branch)
+	remoteBranchExists, _ := wm.CheckRemoteBranchExists(ctx, repo.Path, branch)
+
+	switch {
+	case branchExists:
+		if err := wm.ExecuteWorktreeCommand(ctx, repo.Path, "git", "worktree", "add", targetPath, branch); err != nil {
+			return err
+		}
+	case remoteBranchExists:
+		if err := wm.ExecuteWorktreeCommand(ctx, repo.Path, "git", "worktree", "add", "-b", branch, targetPath, "origin/"+branch); err != nil {
+			return err
+		}
+	default:
+		if err := wm.ExecuteWorktreeCommand(ctx, repo.Path, "git", "worktree", "add", "-b", branch, targetPath, bundleRepo.Head); err != nil {
+			return err
+		}
+	}
+
+	if head, err := runGitOutput(ctx, targetPath, "rev-parse", "HEAD"); err == nil && head != bundleRepo.Head {
+		output.PrintWarning("%s: HEAD is %s but the bundle was exported at %s", repo.Name, head, bundleRepo.Head)
+	}
+
+	if bundleRepo.HasPatch {
+		patchFile := filepath.Join(stagingDir, "changes.patch")
+		if _, err := runGitOutput(ctx, targetPath, "apply", "--binary", patchFile); err != nil {
+			return errors.Wrap(err, "failed to apply uncommitted changes")
+		}
+	}
+
+	for _, file := range bundleRepo.UntrackedFiles {
+		src, err := containedPath(filepath.Join(stagingDir, "untracked"), file)
+		if err != nil {
+			return err
+		}
+		dst, err := containedPath(targetPath, file)
+		if err != nil {
+			return err
+		}
+		if err := throughSymlink(targetPath, dst); err != nil {
+			return err
+		}
+		if err := copyFile(src, dst); err != nil {
+			return errors.Wrapf(err, "failed to restore untracked file %s", file)
+		}
+	}
+
+	return nil
+}
+
+// validateBundleManifest rejects manifests whose workspace name, repository names or untracked
+// files would resolve outside of the directories they are written to
+func validateBundleManifest(manifest *BundleManifest, name string) error {
+	if _, err := containedPath(string(os.PathSeparator), name); err != nil {
+		return errors.Wrap(err, "invalid workspace name in bundle")
+	}
+	for _, bundleRepo := range manifest.Repositories {
+		if _, err := containedPath(string(os.PathSeparator), bundleRepo.Name); err != nil {
+			return errors.Wrap(err, "invalid repository name in bundle")
+		}
+		for _, file := range bundleRepo.UntrackedFiles {
+			if _, err := containedPath(string(os.PathSeparator), file); err != nil {
+				return errors.Wrapf(err, "invalid untracked file of %s in bundle", bundleRepo.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// containedPath joins a slash-separated path from a bundle onto dir, rejecting absolute paths,
+// '..' elements and anything else that would resolve outside of dir
+func containedPath(dir, name string) (string, error) {
+	if name == "" || filepath.IsAbs(name) || filepath.VolumeName(name) != "" || strings.HasPrefix(name, "/") {
+		return "", errors.Errorf("invalid path in bundle: %q", name)
+	}
+	for _, element := range strings.Split(filepath.ToSlash(name), "/") {
+		if element == ".." {
+			return "", errors.Errorf("invalid path in bundle: %q", name)
+		}
+	}
+
+	target := filepath.Join(dir, filepath.FromSlash(name))
+	root := filepath.Clean(dir)
+	if target == root {
+		return "", errors.Errorf("invalid path in bundle: %q", name)
+	}
+	if root != string(os.PathSeparator) {
+		root += string(os.PathSeparator)
+	}
+	if !strings.HasPrefix(target, root) {
+		return "", errors.Errorf("invalid path in bundle: %q", name)
+	}
+
+	return target, nil
+}
+
+// throughSymlink reports an error when a directory between dir and target is a symlink, through
+// which writing target would end up outside of dir
+func throughSymlink(dir, target string) error {
+	rel, err := filepath.Rel(dir, filepath.Dir(target))
+	if err != nil || rel == "." {
+		return err
+	}
+	parent := filepath.Clean(dir)
+	for _, element := range strings.Split(rel, string(filepath.Separator)) {
+		parent = filepath.Join(parent, element)
+		info, err := os.Lstat(parent)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return errors.Errorf("refusing to write %s through the symlink %s", target, parent)
+		}
+	}
+	return nil
+}
+
+// runGitOutput runs a git command in dir and returns its trimmed stdout
+func runGitOutput(ctx context.Context, dir string, args ...string) (string, error) {
+	return gitOutput(ctx, defaultRunner, dir, args...)
+}
+
+// copyFile copies a single file, creating parent directories as needed. Symlinks are recreated
+// with the same target instead of being followed, and an existing file or link at dst is replaced
+// rather than written through
+func copyFile(src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		link, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(link, dst)
+	}
+	if !info.Mode().IsRegular() {
+		return errors.Errorf("%s is not a regular file", src)
+	}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, info.Mode().Perm())
+}
+
+// writeTarGz archives the contents of dir into a gzipped tarball
+func writeTarGz(dir, target string) error {
+	f, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		src, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		_, err = io.Copy(tw, src)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// extractTarGz unpacks a gzipped tarball into dir, rejecting entries that escape it
+func extractTarGz(source, dir string) error {
+	f, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target, err := containedPath(dir, header.Name)
+		if err != nil {
+			return err
+		}
+		if err := throughSymlink(dir, target); err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode).Perm())
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(out, tr); err != nil {
+				_ = out.Close()
+				return err
+			}
+			if err := out.Close(); err != nil {
+				return err
+			}
+		}
+	}
+}
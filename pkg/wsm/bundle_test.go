@@ -0,0 +1,241 @@
+package wsm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+)
+
+func TestCopyFileRecreatesSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "secret")
+	writeGoFiles(t, dir, map[string]string{"secret": "do not copy", "src/file": "content"})
+	if err := os.Symlink(secret, filepath.Join(dir, "src", "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(dir, "dst", "link")
+	if err := copyFile(filepath.Join(dir, "src", "link"), dst); err != nil {
+		t.Fatalf("copyFile failed: %v", err)
+	}
+	if link, err := os.Readlink(dst); err != nil || link != secret {
+		t.Errorf("copied link = %q (%v), want a symlink to %s", link, err, secret)
+	}
+
+	// An existing link at the destination is replaced, not written through
+	if err := copyFile(filepath.Join(dir, "src", "file"), dst); err != nil {
+		t.Fatalf("copyFile failed: %v", err)
+	}
+	if content, _ := os.ReadFile(secret); string(content) != "do not copy" {
+		t.Errorf("copyFile wrote through the link: %q", content)
+	}
+	if content, _ := os.ReadFile(dst); string(content) != "content" {
+		t.Errorf("copied file = %q", content)
+	}
+}
+
+func TestTarGzKeepsSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	writeGoFiles(t, dir, map[string]string{"staging/file": "content"})
+	if err := os.Symlink("/etc/passwd", filepath.Join(dir, "staging", "link")); err != nil {
+		t.Fatal(err)
+	}
+	archive := filepath.Join(dir, "bundle.tar.gz")
+	if err := writeTarGz(filepath.Join(dir, "staging"), archive); err != nil {
+		t.Fatalf("writeTarGz failed: %v", err)
+	}
+
+	out := filepath.Join(dir, "out")
+	if err := extractTarGz(archive, out); err != nil {
+		t.Fatalf("extractTarGz failed: %v", err)
+	}
+	if link, err := os.Readlink(filepath.Join(out, "link")); err != nil || link != "/etc/passwd" {
+		t.Errorf("extracted link = %q (%v)", link, err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(out, "file")); string(content) != "content" {
+		t.Errorf("extracted file = %q", content)
+	}
+}
+
+func TestThroughSymlink(t *testing.T) {
+	dir := t.TempDir()
+	outside := t.TempDir()
+	writeGoFiles(t, dir, map[string]string{"sub/file": ""})
+	if err := os.Symlink(outside, filepath.Join(dir, "sub", "escape")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"file", "sub/file", "sub/new/file", "sub/escape"} {
+		if err := throughSymlink(dir, filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			t.Errorf("throughSymlink(%s) = %v, want nil", name, err)
+		}
+	}
+	err := throughSymlink(dir, filepath.Join(dir, "sub", "escape", "file"))
+	if err == nil || !strings.Contains(err.Error(), "symlink") {
+		t.Errorf("expected an error for a path through the symlink, got %v", err)
+	}
+}
+
+// newBundleWorkspaceManager returns a workspace manager whose registry holds "lib", a clone of a
+// bare remote, with workspaces saved where LoadWorkspace finds them
+func newBundleWorkspaceManager(t *testing.T) *WorkspaceManager {
+	t.Helper()
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	useTestConfigDir(t)
+	configDir, err := ConfigDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	output.SetQuiet(true)
+	t.Cleanup(func() { output.SetQuiet(false) })
+
+	code := t.TempDir()
+	newUnpushedRepo(t, code, "lib")
+	source := filepath.Join(code, "lib")
+	testGit(t, source, "push", "--quiet", "origin", "HEAD:main")
+
+	config := &WorkspaceConfig{
+		WorkspaceDir: filepath.Join(t.TempDir(), "workspaces"),
+		RegistryPath: filepath.Join(configDir, "registry.json"),
+	}
+	wm, err := NewWorkspaceManagerWithBackends(config, config.RegistryPath, OSFS{}, ExecRunner{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wm.Discoverer.registry.Repositories = []Repository{{
+		Name:      "lib",
+		Path:      source,
+		RemoteURL: testGit(t, source, "remote", "get-url", "origin"),
+	}}
+	return wm
+}
+
+// reimportBundle exports a workspace and imports it again under newName
+func reimportBundle(t *testing.T, wm *WorkspaceManager, name, newName string) *Workspace {
+	t.Helper()
+	ctx := context.Background()
+	bundlePath := filepath.Join(t.TempDir(), name+".tar.gz")
+	if _, err := wm.ExportWorkspaceBundle(ctx, name, bundlePath); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	workspace, err := wm.ImportWorkspaceBundle(ctx, bundlePath, newName)
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	return workspace
+}
+
+// assertImportedWorktree checks that the imported repository is a worktree of the source and that
+// deleting the workspace prunes it
+func assertImportedWorktree(t *testing.T, wm *WorkspaceManager, workspace *Workspace) {
+	t.Helper()
+	source := workspace.Repositories[0].Path
+	worktree := filepath.Join(workspace.Path, "lib")
+	if info, err := os.Lstat(filepath.Join(worktree, ".git")); err != nil || info.IsDir() {
+		t.Fatalf("%s should be a worktree of %s", worktree, source)
+	}
+
+	if err := wm.DeleteWorkspace(context.Background(), workspace.Name, true, false); err != nil {
+		t.Fatalf("failed to delete the imported workspace: %v", err)
+	}
+	if list := testGit(t, source, "worktree", "list", "--porcelain"); strings.Contains(list, worktree) {
+		t.Errorf("the worktree of the imported workspace was not pruned:\n%s", list)
+	}
+}
+
+func TestImportLinkedWorkspaceBundleCreatesWorktrees(t *testing.T) {
+	wm := newBundleWorkspaceManager(t)
+	source := wm.Discoverer.registry.Repositories[0].Path
+	testGit(t, source, "checkout", "--quiet", "-b", "explore")
+	look, err := wm.CreateLinkedWorkspace(context.Background(), "look", []string{"lib"}, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundlePath := filepath.Join(t.TempDir(), "look.tar.gz")
+	if _, err := wm.ExportWorkspaceBundle(context.Background(), "look", bundlePath); err != nil {
+		t.Fatal(err)
+	}
+	// The checkout moves on, as it would on another machine, so the branch can be checked out
+	testGit(t, source, "checkout", "--quiet", "main")
+	if err := wm.DeleteWorkspace(context.Background(), look.Name, true, false); err != nil {
+		t.Fatal(err)
+	}
+
+	imported, err := wm.ImportWorkspaceBundle(context.Background(), bundlePath, "copy")
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if imported.Linked {
+		t.Error("imported workspace should not be linked")
+	}
+	saved, err := wm.LoadWorkspace("copy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Linked {
+		t.Error("imported workspace was saved as linked")
+	}
+	assertImportedWorktree(t, wm, saved)
+}
+
+func TestImportClonedWorkspaceBundleCreatesWorktrees(t *testing.T) {
+	wm := newBundleWorkspaceManager(t)
+	if _, err := wm.CreateClonedWorkspace(context.Background(), "solo", []string{"lib"}, "feature/x", "", "", CloneShared, false); err != nil {
+		t.Fatal(err)
+	}
+
+	imported := reimportBundle(t, wm, "solo", "copy")
+	if imported.Clone != "" {
+		t.Errorf("imported workspace clone mode = %q, want worktrees", imported.Clone)
+	}
+	saved, err := wm.LoadWorkspace("copy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Clone != "" {
+		t.Errorf("imported workspace was saved with clone mode %q", saved.Clone)
+	}
+	assertImportedWorktree(t, wm, saved)
+}
+
+func TestImportWorkspaceBundleDropsChildWorkspaces(t *testing.T) {
+	wm := newBundleWorkspaceManager(t)
+	parent, err := wm.CreateWorkspace(context.Background(), "feat", []string{"lib"}, "feature/x", "", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent.Children = []string{"elsewhere"}
+	parent.Parent = "umbrella"
+	if err := wm.SaveWorkspace(parent); err != nil {
+		t.Fatal(err)
+	}
+	bundlePath := filepath.Join(t.TempDir(), "feat.tar.gz")
+	if _, err := wm.ExportWorkspaceBundle(context.Background(), "feat", bundlePath); err != nil {
+		t.Fatal(err)
+	}
+	if err := wm.DeleteWorkspace(context.Background(), "feat", true, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := wm.ImportWorkspaceBundle(context.Background(), bundlePath, "copy"); err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	saved, err := wm.LoadWorkspace("copy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved.Children) != 0 || saved.Parent != "" {
+		t.Errorf("imported workspace kept children %v and parent %q", saved.Children, saved.Parent)
+	}
+	if _, err := os.Lstat(filepath.Join(saved.Path, "elsewhere")); !os.IsNotExist(err) {
+		t.Errorf("no child workspace should be linked into the import")
+	}
+	assertImportedWorktree(t, wm, saved)
+}
@@ -0,0 +1,148 @@
+package wsm
+
+import (
+	"context"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// RepositoryChanges is what a workspace changed in one repository since its branch point
+type RepositoryChanges struct {
+	Repository  string `json:"repository"`
+	BranchPoint string `json:"branch_point"`
+	// Recorded is false when the branch point was not stored at creation and had to be recomputed
+	Recorded     bool            `json:"recorded"`
+	Commits      []CommitSummary `json:"commits"`
+	FilesChanged int             `json:"files_changed"`
+	Insertions   int             `json:"insertions"`
+	Deletions    int             `json:"deletions"`
+	// UpstreamCommits counts the commits added to the base branch since the branch point
+	UpstreamCommits int    `json:"upstream_commits"`
+	Base            string `json:"base"`
+	Error           string `json:"error,omitempty"`
+}
+
+// CommitSummary is a commit of the workspace branch
+type CommitSummary struct {
+	Hash    string `json:"hash"`
+	Subject string `json:"subject"`
+}
+
+// FindBranchPoint returns the commit a worktree branched off: the merge-base of HEAD with the
+// workspace base branch, falling back to HEAD itself when no base can be resolved
+func FindBranchPoint(ctx context.Context, workspace *Workspace, worktreePath string) (string, error) {
+	for _, base := range []string{WorkspaceBaseRef(workspace, ""), "origin/HEAD"} {
+		if mergeBase, err := runGitOutput(ctx, worktreePath, "merge-base", "HEAD", base); err == nil && mergeBase != "" {
+			return mergeBase, nil
+		}
+	}
+	return runGitOutput(ctx, worktreePath, "rev-parse", "HEAD")
+}
+
+// recordBranchPoint stores the branch point of a repository in the workspace configuration.
+// Failures are ignored: 'changes' recomputes missing branch points.
+func recordBranchPoint(ctx context.Context, workspace *Workspace, repoName string) {
+	branchPoint, err := FindBranchPoint(ctx, workspace, filepath.Join(workspace.Path, repoName))
+	if err != nil || branchPoint == "" {
+		return
+	}
+	if workspace.BranchPoints == nil {
+		workspace.BranchPoints = make(map[string]string)
+	}
+	workspace.BranchPoints[repoName] = branchPoint
+}
+
+// repositoryBranchPoint returns the recorded branch point of a repository, recomputing it when
+// none was stored
+func repositoryBranchPoint(ctx context.Context, workspace *Workspace, repoName string) (string, bool, error) {
+	if branchPoint := workspace.BranchPoints[repoName]; branchPoint != "" {
+		return branchPoint, true, nil
+	}
+	branchPoint, err := FindBranchPoint(ctx, workspace, filepath.Join(workspace.Path, repoName))
+	if err != nil {
+		return "", false, errors.Wrap(err, "failed to determine branch point")
+	}
+	return branchPoint, false, nil
+}
+
+// GetWorkspaceChanges returns the commits and diffstat of every repository between its branch
+// point and HEAD, so the view stays the same when the base branch moves on
+func GetWorkspaceChanges(ctx context.Context, workspace *Workspace) []RepositoryChanges {
+	var changes []RepositoryChanges
+	for _, repo := range workspace.Repositories {
+		changes = append(changes, getRepositoryChanges(ctx, workspace, repo.Name))
+	}
+	return changes
+}
+
+func getRepositoryChanges(ctx context.Context, workspace *Workspace, repoName string) RepositoryChanges {
+	worktreePath := filepath.Join(workspace.Path, repoName)
+	changes := RepositoryChanges{
+		Repository: repoName,
+		Base:       WorkspaceBaseRef(workspace, ""),
+	}
+
+	branchPoint, recorded, err := repositoryBranchPoint(ctx, workspace, repoName)
+	changes.BranchPoint, changes.Recorded = branchPoint, recorded
+	if err != nil {
+		changes.Error = err.Error()
+		return changes
+	}
+
+	changes.Commits, err = listCommits(ctx, worktreePath, changes.BranchPoint+"..HEAD")
+	if err != nil {
+		changes.Error = err.Error()
+		return changes
+	}
+
+	numstat, err := runGitOutput(ctx, worktreePath, "diff", "--numstat", changes.BranchPoint, "HEAD")
+	if err != nil {
+		changes.Error = errors.Wrap(err, "failed to compute diffstat").Error()
+		return changes
+	}
+	changes.FilesChanged, changes.Insertions, changes.Deletions = sumNumstat(numstat)
+
+	if count, err := runGitOutput(ctx, worktreePath, "rev-list", "--count", changes.BranchPoint+".."+changes.Base); err == nil {
+		changes.UpstreamCommits, _ = strconv.Atoi(count)
+	}
+
+	return changes
+}
+
+// listCommits returns the non-merge commits of a revision range
+func listCommits(ctx context.Context, dir, revisionRange string) ([]CommitSummary, error) {
+	log, err := runGitOutput(ctx, dir, "log", "--no-merges", "--format=%h%x00%s", revisionRange)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list commits")
+	}
+	var commits []CommitSummary
+	for _, line := range strings.Split(log, "\n") {
+		if hash, subject, ok := strings.Cut(line, "\x00"); ok {
+			commits = append(commits, CommitSummary{Hash: hash, Subject: subject})
+		}
+	}
+	return commits, nil
+}
+
+// sumNumstat totals the output of git diff --numstat: files changed, insertions and deletions
+func sumNumstat(numstat string) (int, int, int) {
+	files, insertions, deletions := 0, 0, 0
+	for _, line := range strings.Split(numstat, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		files++
+		// Binary files report "-" for both counts
+		if n, err := strconv.Atoi(fields[0]); err == nil {
+			insertions += n
+		}
+		if n, err := strconv.Atoi(fields[1]); err == nil {
+			deletions += n
+		}
+	}
+	return files, insertions, deletions
+}
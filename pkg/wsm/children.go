@@ -0,0 +1,266 @@
+package wsm
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/pkg/errors"
+)
+
+// AddChildWorkspace includes the child workspace as a component of the parent. The child directory
+// is linked into the parent under the child name, so its repositories appear as <child>/<repo>
+// in the parent's status, sync and go.work.
+func (wm *WorkspaceManager) AddChildWorkspace(parentName, childName string) error {
+	start := time.Now()
+	if parentName == childName {
+		return errors.New("a workspace cannot include itself")
+	}
+
+	parent, err := wm.LoadWorkspace(parentName)
+	if err != nil {
+		return err
+	}
+	child, err := wm.LoadWorkspace(childName)
+	if err != nil {
+		return err
+	}
+
+	if child.Parent != "" {
+		if child.Parent == parentName {
+			return errors.Errorf("workspace '%s' is already a child of '%s'", childName, parentName)
+		}
+		return errors.Errorf("workspace '%s' is already owned by '%s'", childName, child.Parent)
+	}
+
+	workspaces, err := loadWorkspaceMap()
+	if err != nil {
+		return err
+	}
+	if slices.Contains(descendants(child, workspaces), parentName) {
+		return errors.Errorf("cannot include '%s' in '%s': '%s' is already nested inside '%s'", childName, parentName, parentName, childName)
+	}
+
+	for _, repo := range parent.Repositories {
+		if repo.Name == childName {
+			return errors.Errorf("workspace '%s' already has a repository named '%s'", parentName, childName)
+		}
+	}
+
+	linkPath := filepath.Join(parent.Path, childName)
+	if _, err := wm.fs().Lstat(linkPath); err == nil {
+		return errors.Errorf("path already exists: %s", linkPath)
+	}
+	if err := os.Symlink(child.Path, linkPath); err != nil {
+		return errors.Wrapf(err, "failed to link child workspace into %s", linkPath)
+	}
+
+	parent.Children = append(parent.Children, childName)
+	parent.GoWorkspace = parent.GoWorkspace || child.GoWorkspace
+	child.Parent = parentName
+	if err := wm.updateBuildManifests(parent); err != nil {
+		output.PrintWarning("Could not update the build manifests of '%s': %v", parentName, err)
+	}
+
+	if err := wm.SaveWorkspace(child); err != nil {
+		return errors.Wrap(err, "failed to save child workspace")
+	}
+	if err := wm.SaveWorkspace(parent); err != nil {
+		return errors.Wrap(err, "failed to save parent workspace")
+	}
+
+	output.LogInfo(
+		fmt.Sprintf("Included workspace '%s' in '%s'", childName, parentName),
+		"Included child workspace",
+		"parent", parentName,
+		"child", childName,
+	)
+	RecordOperation("add", parentName, start, map[string]string{"child": childName})
+
+	return wm.refreshGoWorkspace(parent)
+}
+
+// RemoveChildWorkspace detaches a child workspace from its parent. The child itself is left untouched.
+func (wm *WorkspaceManager) RemoveChildWorkspace(parentName, childName string) error {
+	start := time.Now()
+	parent, err := wm.LoadWorkspace(parentName)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(parent.Children, childName) {
+		return errors.Errorf("workspace '%s' is not a child of '%s'", childName, parentName)
+	}
+
+	if err := wm.detachChild(parent, childName); err != nil {
+		return err
+	}
+	if err := wm.updateBuildManifests(parent); err != nil {
+		output.PrintWarning("Could not update the build manifests of '%s': %v", parentName, err)
+	}
+	if err := wm.SaveWorkspace(parent); err != nil {
+		return errors.Wrap(err, "failed to save parent workspace")
+	}
+
+	RecordOperation("remove", parentName, start, map[string]string{"child": childName})
+
+	return wm.refreshGoWorkspace(parent)
+}
+
+// detachChild removes the link to a child from the parent directory and clears the child's owner.
+// The parent configuration is updated in memory only.
+func (wm *WorkspaceManager) detachChild(parent *Workspace, childName string) error {
+	linkPath := filepath.Join(parent.Path, childName)
+	if info, err := wm.fs().Lstat(linkPath); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		if err := wm.fs().Remove(linkPath); err != nil {
+			return errors.Wrapf(err, "failed to remove child link: %s", linkPath)
+		}
+	}
+
+	parent.Children = slices.DeleteFunc(parent.Children, func(name string) bool { return name == childName })
+
+	child, err := wm.LoadWorkspace(childName)
+	if err != nil {
+		// The child was deleted behind our back; dropping the reference is all that is left to do
+		return nil
+	}
+	if child.Parent == parent.Name {
+		child.Parent = ""
+		if err := wm.SaveWorkspace(child); err != nil {
+			return errors.Wrapf(err, "failed to save workspace '%s'", childName)
+		}
+	}
+
+	output.LogInfo(
+		fmt.Sprintf("Detached workspace '%s' from '%s'", childName, parent.Name),
+		"Detached child workspace",
+		"parent", parent.Name,
+		"child", childName,
+	)
+	return nil
+}
+
+// CheckDeletable fails for a workspace that is still owned by a parent: a child has to be detached
+// before it can be deleted. Owners that no longer exist are ignored.
+func (wm *WorkspaceManager) CheckDeletable(workspace *Workspace) error {
+	if workspace.Parent == "" {
+		return nil
+	}
+	if _, err := wm.LoadWorkspace(workspace.Parent); err != nil {
+		return nil
+	}
+	return errors.Errorf("workspace '%s' is a child of '%s'; detach it first with 'workspace-manager child remove %s %s'",
+		workspace.Name, workspace.Parent, workspace.Parent, workspace.Name)
+}
+
+// refreshGoWorkspace regenerates go.work so it covers the modules of the child workspaces
+func (wm *WorkspaceManager) refreshGoWorkspace(workspace *Workspace) error {
+	if !workspace.GoWorkspace {
+		return nil
+	}
+	expanded, err := ExpandWorkspace(workspace)
+	if err != nil {
+		return err
+	}
+	return wm.CreateGoWorkspace(expanded)
+}
+
+// ExpandWorkspace returns a copy of the workspace whose repositories include those of its child
+// workspaces, recursively, named <child>/<repo>. Commands that aggregate across the nesting
+// (status, sync) operate on the expanded workspace. Repositories frozen in a child stay frozen,
+// and the repositories of linked and cloned children are frozen as well: they are not worktrees
+// of the parent's kind, so batch writes on the parent leave them alone.
+func ExpandWorkspace(workspace *Workspace) (*Workspace, error) {
+	if len(workspace.Children) == 0 {
+		return workspace, nil
+	}
+
+	workspaces, err := loadWorkspaceMap()
+	if err != nil {
+		return nil, err
+	}
+	return expandWorkspace(workspace, workspaces), nil
+}
+
+func expandWorkspace(workspace *Workspace, workspaces map[string]*Workspace) *Workspace {
+	expanded := *workspace
+	expanded.Repositories = slices.Clone(workspace.Repositories)
+	expanded.Frozen = slices.Clone(workspace.Frozen)
+	visited := map[string]bool{workspace.Name: true}
+	addChildRepositories(&expanded, workspace, "", workspaces, visited)
+	return &expanded
+}
+
+func addChildRepositories(expanded *Workspace, workspace *Workspace, prefix string, workspaces map[string]*Workspace, visited map[string]bool) {
+	for _, childName := range workspace.Children {
+		child, ok := workspaces[childName]
+		if !ok {
+			output.PrintWarning("Child workspace '%s' of '%s' no longer exists", childName, workspace.Name)
+			continue
+		}
+		if visited[childName] {
+			continue
+		}
+		visited[childName] = true
+
+		childPrefix := prefix + childName + "/"
+		for _, repo := range child.Repositories {
+			frozen := child.IsFrozen(repo.Name) || child.Linked || child.Clone != ""
+			repo.Name = childPrefix + repo.Name
+			expanded.Repositories = append(expanded.Repositories, repo)
+			if frozen {
+				expanded.Frozen = append(expanded.Frozen, repo.Name)
+			}
+		}
+		addChildRepositories(expanded, child, childPrefix, workspaces, visited)
+	}
+}
+
+// descendants returns the names of all workspaces nested below the workspace
+func descendants(workspace *Workspace, workspaces map[string]*Workspace) []string {
+	var names []string
+	for _, childName := range workspace.Children {
+		if slices.Contains(names, childName) {
+			continue
+		}
+		names = append(names, childName)
+		if child, ok := workspaces[childName]; ok {
+			names = append(names, descendants(child, workspaces)...)
+		}
+	}
+	return names
+}
+
+// NestingDepth returns how many ancestors a workspace has
+func NestingDepth(workspace *Workspace, workspaces []Workspace) int {
+	byName := make(map[string]*Workspace, len(workspaces))
+	for i := range workspaces {
+		byName[workspaces[i].Name] = &workspaces[i]
+	}
+
+	depth := 0
+	seen := map[string]bool{workspace.Name: true}
+	for parentName := workspace.Parent; parentName != "" && !seen[parentName]; depth++ {
+		seen[parentName] = true
+		parent, ok := byName[parentName]
+		if !ok {
+			break
+		}
+		parentName = parent.Parent
+	}
+	return depth
+}
+
+func loadWorkspaceMap() (map[string]*Workspace, error) {
+	workspaces, err := LoadWorkspaces()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load workspaces")
+	}
+	byName := make(map[string]*Workspace, len(workspaces))
+	for i := range workspaces {
+		byName[workspaces[i].Name] = &workspaces[i]
+	}
+	return byName, nil
+}
@@ -0,0 +1,63 @@
+package wsm
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestExpandWorkspaceFreezesChildRepositories(t *testing.T) {
+	parent := &Workspace{
+		Name:         "parent",
+		Repositories: []Repository{{Name: "app"}},
+		Frozen:       []string{"app"},
+		Children:     []string{"child", "linked", "cloned"},
+	}
+	workspaces := map[string]*Workspace{
+		"parent": parent,
+		"child": {
+			Name:         "child",
+			Repositories: []Repository{{Name: "lib"}, {Name: "docs"}},
+			Frozen:       []string{"docs"},
+			Children:     []string{"grandchild"},
+		},
+		"grandchild": {
+			Name:         "grandchild",
+			Repositories: []Repository{{Name: "tools"}},
+			Frozen:       []string{"tools"},
+		},
+		"linked": {
+			Name:         "linked",
+			Repositories: []Repository{{Name: "api"}},
+			Linked:       true,
+		},
+		"cloned": {
+			Name:         "cloned",
+			Repositories: []Repository{{Name: "web"}},
+			Clone:        CloneShared,
+		},
+	}
+
+	expanded := expandWorkspace(parent, workspaces)
+
+	var names []string
+	for _, repo := range expanded.Repositories {
+		names = append(names, repo.Name)
+	}
+	wantNames := []string{"app", "child/lib", "child/docs", "child/grandchild/tools", "linked/api", "cloned/web"}
+	if !slices.Equal(names, wantNames) {
+		t.Errorf("repositories = %v, want %v", names, wantNames)
+	}
+
+	var active []string
+	for _, repo := range expanded.ActiveRepositories() {
+		active = append(active, repo.Name)
+	}
+	if !slices.Equal(active, []string{"child/lib"}) {
+		t.Errorf("active repositories = %v, want [child/lib]", active)
+	}
+
+	// The parent itself is left untouched
+	if !slices.Equal(parent.Frozen, []string{"app"}) || len(parent.Repositories) != 1 {
+		t.Errorf("parent was modified: %+v", parent)
+	}
+}
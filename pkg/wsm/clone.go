@@ -0,0 +1,166 @@
+package wsm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/pkg/errors"
+)
+
+// Clone modes of workspaces whose repositories are local clones instead of worktrees
+const (
+	// CloneShared clones with 'git clone --shared': the clone borrows the objects of the source
+	// repository, which is fast and takes no space, but breaks if the source prunes them
+	CloneShared = "shared"
+	// CloneFull clones with 'git clone': the objects are hard-linked or copied, the clone is
+	// independent of the source
+	CloneFull = "full"
+)
+
+// CloneModes are the valid values of Workspace.Clone
+var CloneModes = []string{CloneShared, CloneFull}
+
+// CreateClonedWorkspace creates a workspace whose repositories are local clones of the registered
+// checkouts instead of worktrees. Clones have their own refs, reflog and configuration: rebases,
+// filter-repo runs and other history rewrites stay in the clone and never touch the worktree
+// bookkeeping of the source repositories.
+func (wm *WorkspaceManager) CreateClonedWorkspace(ctx context.Context, name string, repoNames []string, branch, baseBranch, agentSource, mode string, dryRun bool) (*Workspace, error) {
+	if !slices.Contains(CloneModes, mode) {
+		return nil, errors.Errorf("invalid clone mode '%s': expected one of %s", mode, strings.Join(CloneModes, ", "))
+	}
+
+	workspace, err := wm.planWorkspace(name, repoNames, branch, baseBranch, agentSource)
+	if err != nil {
+		return nil, err
+	}
+	workspace.Clone = mode
+	if dryRun {
+		return workspace, nil
+	}
+
+	if err := wm.establishWorkspace(ctx, workspace, "create", map[string]string{
+		"repos":       strings.Join(repoNames, ","),
+		"branch":      branch,
+		"base_branch": baseBranch,
+		"mode":        "clone-" + mode,
+	}); err != nil {
+		return nil, err
+	}
+
+	return workspace, nil
+}
+
+// cloneRepository clones the checkout of the repository into the workspace and checks out the
+// workspace branch. origin is pointed at the remote of the source repository, so pushing and
+// pulling work as in a worktree; until the first fetch, its branches are those of the source
+// checkout. The source itself stays reachable as the remote "source".
+func (wm *WorkspaceManager) cloneRepository(ctx context.Context, workspace *Workspace, repo Repository) error {
+	targetPath := filepath.Join(workspace.Path, repo.Name)
+
+	args := []string{"clone", "--quiet"}
+	if wm.Progress != nil {
+		args = []string{"clone", "--progress"}
+	}
+	if workspace.Clone == CloneShared {
+		args = append(args, "--shared")
+	}
+	args = append(args, "--", repo.Path, targetPath)
+
+	output.LogInfo(
+		fmt.Sprintf("Cloning '%s' (%s) on branch '%s'", repo.Name, workspace.Clone, workspace.Branch),
+		"Cloning repository",
+		"repo", repo.Name,
+		"mode", workspace.Clone,
+		"branch", workspace.Branch,
+		"target", targetPath,
+	)
+	cloneCtx, done := withCloneTimeout(ctx, wm.Config().Timeouts, repo.Name, repo.Path)
+	out, err := runGitProgress(cloneCtx, wm.runner(), wm.Progress, repo.Name, workspace.Path, args...)
+	if err = done(err); err != nil {
+		if IsTimeout(err) {
+			return err
+		}
+		return errors.Wrapf(err, "git clone failed: %s", strings.TrimSpace(string(out)))
+	}
+
+	if repo.RemoteURL != "" {
+		if _, err := gitOutput(ctx, wm.runner(), targetPath, "remote", "set-url", "origin", repo.RemoteURL); err != nil {
+			return errors.Wrapf(err, "failed to point origin of '%s' at %s", repo.Name, repo.RemoteURL)
+		}
+		if _, err := gitOutput(ctx, wm.runner(), targetPath, "remote", "add", "source", repo.Path); err != nil {
+			return errors.Wrapf(err, "failed to add the source remote to '%s'", repo.Name)
+		}
+	}
+
+	// Without a branch, the clone stays on the branch checked out in the source
+	if workspace.Branch == "" {
+		return nil
+	}
+
+	// The clone has its own refs, so the branch can be reset freely: the source keeps its own
+	checkout := []string{"checkout", "--quiet", "-B", workspace.Branch}
+	if base := workspace.repositoryBase(repo.Name); base != "" {
+		if wm.cloneHasRef(ctx, targetPath, "refs/remotes/origin/"+base) {
+			base = "origin/" + base
+		}
+		checkout = append(checkout, "--no-track", base)
+	} else if wm.cloneHasRef(ctx, targetPath, "refs/remotes/origin/"+workspace.Branch) {
+		checkout = append(checkout, "--track", "origin/"+workspace.Branch)
+	}
+	if _, err := gitOutput(ctx, wm.runner(), targetPath, checkout...); err != nil {
+		return errors.Wrapf(err, "failed to check out '%s' in the clone of '%s'", workspace.Branch, repo.Name)
+	}
+	return nil
+}
+
+func (wm *WorkspaceManager) cloneHasRef(ctx context.Context, clonePath, ref string) bool {
+	_, err := gitOutput(ctx, wm.runner(), clonePath, "rev-parse", "--verify", "--quiet", ref)
+	return err == nil
+}
+
+// removeClone deletes the clone of the repository from the workspace. Unlike a worktree, a clone
+// holds history of its own: without force, clones with uncommitted changes or commits that are
+// on no remote are kept.
+func (wm *WorkspaceManager) removeClone(ctx context.Context, workspace *Workspace, repo Repository, force bool) error {
+	clonePath := filepath.Join(workspace.Path, repo.Name)
+	if _, err := wm.fs().Stat(clonePath); os.IsNotExist(err) {
+		return nil
+	}
+
+	if !force {
+		status, err := gitOutput(ctx, wm.runner(), clonePath, "status", "--porcelain")
+		if err != nil {
+			return errors.Wrapf(err, "failed to get status of the clone of '%s'", repo.Name)
+		}
+		unpushed, err := gitOutput(ctx, wm.runner(), clonePath, "log", "--oneline", "--branches", "--not", "--remotes")
+		if err != nil {
+			return errors.Wrapf(err, "failed to list unpushed commits of the clone of '%s'", repo.Name)
+		}
+		if status != "" {
+			return errors.Errorf("the clone of '%s' has uncommitted changes; commit or discard them, or force the removal", repo.Name)
+		}
+		if unpushed != "" {
+			commits := "1 commit that is"
+			if n := len(strings.Split(unpushed, "\n")); n > 1 {
+				commits = fmt.Sprintf("%d commits that are", n)
+			}
+			return errors.Errorf("the clone of '%s' has %s on no remote; push them, or force the removal", repo.Name, commits)
+		}
+	}
+
+	output.LogInfo(
+		fmt.Sprintf("Removing clone of '%s'", repo.Name),
+		"Removing clone",
+		"repo", repo.Name,
+		"path", clonePath,
+	)
+	if err := wm.fs().RemoveAll(clonePath); err != nil {
+		return errors.Wrapf(err, "failed to remove clone %s", clonePath)
+	}
+	return nil
+}
@@ -0,0 +1,262 @@
+package wsm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// codeOwnersLocations are where GitHub looks for CODEOWNERS, in order
+var codeOwnersLocations = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// CodeOwners are the rules of the CODEOWNERS file of a repository
+type CodeOwners struct {
+	// Path is the CODEOWNERS file, relative to the repository
+	Path  string
+	rules []codeOwnersRule
+}
+
+type codeOwnersRule struct {
+	pattern string
+	match   *regexp.Regexp
+	owners  []string
+}
+
+// LoadCodeOwners reads the CODEOWNERS file of a repository at ref, from .github/, the root or
+// docs/ like GitHub, which applies the file of the branch a pull request goes into. It returns nil
+// when the repository has none at ref.
+func LoadCodeOwners(ctx context.Context, repoPath, ref string) (*CodeOwners, error) {
+	if _, err := runGitOutput(ctx, repoPath, "rev-parse", "--verify", "--quiet", ref+"^{commit}"); err != nil {
+		return nil, errors.Errorf("unknown base '%s'", ref)
+	}
+	for _, location := range codeOwnersLocations {
+		// The file is missing when its path does not resolve in the tree of ref
+		if _, err := runGitOutput(ctx, repoPath, "rev-parse", "--verify", "--quiet", ref+":"+location); err != nil {
+			continue
+		}
+		data, err := runGitOutput(ctx, repoPath, "show", ref+":"+location)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s", location)
+		}
+		owners, err := ParseCodeOwners([]byte(data))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse %s", location)
+		}
+		owners.Path = location
+		return owners, nil
+	}
+	return nil, nil
+}
+
+// ParseCodeOwners parses the content of a CODEOWNERS file: one path pattern per line, followed by
+// its owners (@user, @org/team or an email address). A pattern without owners removes the owners
+// of the paths it matches.
+func ParseCodeOwners(data []byte) (*CodeOwners, error) {
+	owners := &CodeOwners{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(text, " #"); i >= 0 {
+			text = strings.TrimSpace(text[:i])
+		}
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		match, err := codeOwnersPattern(fields[0])
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d", line)
+		}
+		owners.rules = append(owners.rules, codeOwnersRule{pattern: fields[0], match: match, owners: fields[1:]})
+	}
+	return owners, scanner.Err()
+}
+
+// codeOwnersPattern compiles a CODEOWNERS pattern, which follows the rules of .gitignore: a pattern
+// with a slash before its end is relative to the root, one without matches at any depth, and a
+// pattern matching a directory matches everything below it. "dir/*" only matches the files
+// directly in dir.
+func codeOwnersPattern(pattern string) (*regexp.Regexp, error) {
+	anchored := strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	p := strings.TrimPrefix(pattern, "/")
+	dirOnly := strings.HasSuffix(p, "/")
+	p = strings.TrimSuffix(p, "/")
+
+	var b strings.Builder
+	if anchored {
+		b.WriteString("^")
+	} else {
+		b.WriteString("^(?:.*/)?")
+	}
+	for i := 0; i < len(p); i++ {
+		switch {
+		case strings.HasPrefix(p[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(p[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case p[i] == '*':
+			b.WriteString("[^/]*")
+		case p[i] == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(p[i])))
+		}
+	}
+	switch {
+	case strings.HasSuffix(p, "/*"):
+		b.WriteString("$")
+	case dirOnly:
+		b.WriteString("/.*$")
+	default:
+		b.WriteString("(?:/.*)?$")
+	}
+	return regexp.Compile(b.String())
+}
+
+// Owners returns the owners of a file, relative to the repository: those of the last rule
+// matching it
+func (c *CodeOwners) Owners(file string) []string {
+	for i := len(c.rules) - 1; i >= 0; i-- {
+		if c.rules[i].match.MatchString(file) {
+			return c.rules[i].owners
+		}
+	}
+	return nil
+}
+
+// ReviewRequest is a branch about to be proposed as a pull request
+type ReviewRequest struct {
+	Repository string
+	Path       string
+	// Base is the branch the pull request goes into: the changes are those since it, and its
+	// CODEOWNERS file applies
+	Base string
+}
+
+// RepositoryReviewers are the reviewers of the pull request of a repository
+type RepositoryReviewers struct {
+	Repository string `json:"repository"`
+	// CodeOwners is the CODEOWNERS file the reviewers come from, empty when there is none
+	CodeOwners string   `json:"codeowners,omitempty"`
+	Reviewers  []string `json:"reviewers,omitempty"`
+	// Files counts the changed files each reviewer owns
+	Files map[string]int `json:"files,omitempty"`
+	// Unowned are changed files no rule assigns an owner
+	Unowned []string `json:"unowned,omitempty"`
+	// Unassignable are owner lists that only name the author of the pull request
+	Unassignable []string `json:"unassignable,omitempty"`
+	Error        string   `json:"error,omitempty"`
+}
+
+// ReviewerPlan are the reviewers of the pull requests of a workspace
+type ReviewerPlan struct {
+	Repositories []RepositoryReviewers `json:"repositories"`
+	// Load maps each reviewer to the repositories they are asked to review
+	Load map[string][]string `json:"load"`
+}
+
+// PlanReviewers computes the reviewers of the pull requests of a workspace from the CODEOWNERS
+// file of the base of each repository and the files the branch changes. Every owner of a changed file is
+// asked for a review, except author, who cannot review their own pull request. With balance, a
+// single owner is picked for each group of owners (any one of them satisfies GitHub), the one with
+// the fewest reviews in the plan so far, so that the work spreads across the owners.
+func PlanReviewers(ctx context.Context, requests []ReviewRequest, author string, balance bool) *ReviewerPlan {
+	plan := &ReviewerPlan{Load: map[string][]string{}}
+	for _, request := range requests {
+		reviewers := RepositoryReviewers{Repository: request.Repository, Files: map[string]int{}}
+		groups, err := reviewGroups(ctx, request, &reviewers)
+		if err != nil {
+			reviewers.Error = err.Error()
+		}
+
+		chosen := map[string]bool{}
+		for _, group := range groups {
+			candidates := slices.DeleteFunc(slices.Clone(group), func(owner string) bool {
+				return author != "" && strings.EqualFold(strings.TrimPrefix(owner, "@"), author)
+			})
+			if len(candidates) == 0 {
+				reviewers.Unassignable = append(reviewers.Unassignable, strings.Join(group, " "))
+				continue
+			}
+			if !balance {
+				for _, owner := range candidates {
+					chosen[owner] = true
+				}
+				continue
+			}
+			if slices.ContainsFunc(candidates, func(owner string) bool { return chosen[owner] }) {
+				continue
+			}
+			pick := candidates[0]
+			for _, owner := range candidates[1:] {
+				if len(plan.Load[owner]) < len(plan.Load[pick]) {
+					pick = owner
+				}
+			}
+			chosen[pick] = true
+			plan.Load[pick] = append(plan.Load[pick], request.Repository)
+		}
+
+		for owner := range chosen {
+			reviewers.Reviewers = append(reviewers.Reviewers, owner)
+			if !balance {
+				plan.Load[owner] = append(plan.Load[owner], request.Repository)
+			}
+		}
+		sort.Strings(reviewers.Reviewers)
+		for owner := range reviewers.Files {
+			if !chosen[owner] {
+				delete(reviewers.Files, owner)
+			}
+		}
+		plan.Repositories = append(plan.Repositories, reviewers)
+	}
+	return plan
+}
+
+// reviewGroups returns the owner lists of the files changed on the branch, smallest first so that
+// balancing satisfies the most constrained groups first
+func reviewGroups(ctx context.Context, request ReviewRequest, reviewers *RepositoryReviewers) ([][]string, error) {
+	codeOwners, err := LoadCodeOwners(ctx, request.Path, request.Base)
+	if err != nil || codeOwners == nil {
+		return nil, err
+	}
+	reviewers.CodeOwners = codeOwners.Path
+
+	changed, err := runGitOutput(ctx, request.Path, "diff", "--name-only", request.Base+"...HEAD")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the files changed since %s", request.Base)
+	}
+
+	seen := map[string]bool{}
+	var groups [][]string
+	for _, file := range strings.Split(changed, "\n") {
+		if file == "" {
+			continue
+		}
+		owners := codeOwners.Owners(file)
+		if len(owners) == 0 {
+			reviewers.Unowned = append(reviewers.Unowned, file)
+			continue
+		}
+		for _, owner := range owners {
+			reviewers.Files[owner]++
+		}
+		if key := strings.Join(owners, " "); !seen[key] {
+			seen[key] = true
+			groups = append(groups, owners)
+		}
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return len(groups[i]) < len(groups[j])
+	})
+	return groups, nil
+}
@@ -0,0 +1,141 @@
+package wsm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCodeOwnersPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		file    string
+		want    bool
+	}{
+		{pattern: "*", file: "main.go", want: true},
+		{pattern: "*", file: "cmd/main.go", want: true},
+		{pattern: "*.go", file: "cmd/main.go", want: true},
+		{pattern: "*.go", file: "main.golden", want: false},
+		{pattern: "docs", file: "docs/index.md", want: true},
+		{pattern: "docs", file: "pkg/docs/index.md", want: true},
+		{pattern: "docs/", file: "pkg/docs/index.md", want: true},
+		{pattern: "docs/", file: "docs", want: false},
+		{pattern: "/docs", file: "pkg/docs/index.md", want: false},
+		{pattern: "/docs", file: "docs/index.md", want: true},
+		{pattern: "pkg/api", file: "pkg/api/v1/types.go", want: true},
+		{pattern: "pkg/api", file: "internal/pkg/api/types.go", want: false},
+		{pattern: "docs/*", file: "docs/index.md", want: true},
+		{pattern: "docs/*", file: "docs/guide/index.md", want: false},
+		{pattern: "**/logs", file: "build/logs/out.txt", want: true},
+		{pattern: "**/logs", file: "logs/out.txt", want: true},
+		{pattern: "apps/**/test", file: "apps/web/unit/test/a_test.go", want: true},
+		{pattern: "apps/**/test", file: "apps/test/a_test.go", want: true},
+		{pattern: "file?.txt", file: "file1.txt", want: true},
+		{pattern: "file?.txt", file: "file/.txt", want: false},
+		{pattern: "a+b.txt", file: "a+b.txt", want: true},
+		{pattern: "a+b.txt", file: "aab.txt", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.file, func(t *testing.T) {
+			match, err := codeOwnersPattern(tt.pattern)
+			if err != nil {
+				t.Fatalf("codeOwnersPattern(%q) failed: %v", tt.pattern, err)
+			}
+			if got := match.MatchString(tt.file); got != tt.want {
+				t.Errorf("%q matches %q = %v, want %v (regexp %s)", tt.pattern, tt.file, got, tt.want, match)
+			}
+		})
+	}
+}
+
+func TestParseCodeOwners(t *testing.T) {
+	owners, err := ParseCodeOwners([]byte(`# Default owners
+*           @acme/core
+
+*.md        @docs-team docs@example.com # the writers
+/pkg/api/   @alice @bob
+/pkg/api/generated/
+`))
+	if err != nil {
+		t.Fatalf("ParseCodeOwners failed: %v", err)
+	}
+	tests := map[string][]string{
+		"main.go":                    {"@acme/core"},
+		"README.md":                  {"@docs-team", "docs@example.com"},
+		"pkg/api/server.go":          {"@alice", "@bob"},
+		"pkg/api/README.md":          {"@alice", "@bob"},
+		"pkg/api/generated/types.go": nil,
+	}
+	for file, want := range tests {
+		if got := owners.Owners(file); !reflect.DeepEqual(got, want) && len(got)+len(want) > 0 {
+			t.Errorf("Owners(%q) = %v, want %v", file, got, want)
+		}
+	}
+}
+
+func TestPlanReviewers(t *testing.T) {
+	ctx := context.Background()
+	newRepo := func(codeOwners string, changed ...string) string {
+		repo := t.TempDir()
+		testGit(t, repo, "init")
+		writeGoFiles(t, repo, map[string]string{".github/CODEOWNERS": codeOwners})
+		testGit(t, repo, "add", ".")
+		testGit(t, repo, "commit", "-m", "codeowners")
+		testGit(t, repo, "checkout", "-b", "feat")
+		// The branch's own CODEOWNERS does not apply before it is merged
+		files := map[string]string{".github/CODEOWNERS": "* @me\n"}
+		for _, file := range changed {
+			files[file] = "change\n"
+		}
+		writeGoFiles(t, repo, files)
+		testGit(t, repo, "add", ".")
+		testGit(t, repo, "commit", "-m", "change")
+		return repo
+	}
+	api := newRepo("/api/ @alice @bob\n/web/ @carol\n.github/ @alice\n", "api/server.go", "web/app.js", "README.md")
+	web := newRepo("* @alice @bob\n", "index.html")
+	requests := []ReviewRequest{
+		{Repository: "api", Path: api, Base: "main"},
+		{Repository: "web", Path: web, Base: "main"},
+	}
+
+	plan := PlanReviewers(ctx, requests, "carol", false)
+	if got := plan.Repositories[0].Reviewers; !reflect.DeepEqual(got, []string{"@alice", "@bob"}) {
+		t.Errorf("api reviewers = %v", got)
+	}
+	if got := plan.Repositories[0].Unassignable; !reflect.DeepEqual(got, []string{"@carol"}) {
+		t.Errorf("api unassignable = %v, want the author's own group", got)
+	}
+	if got := plan.Repositories[0].Unowned; !reflect.DeepEqual(got, []string{"README.md"}) {
+		t.Errorf("api unowned = %v", got)
+	}
+	if got := plan.Repositories[0].Files["@alice"]; got != 2 {
+		t.Errorf("@alice owns %d changed file(s) in api, want 2 (CODEOWNERS and server.go)", got)
+	}
+	if got := plan.Load["@alice"]; !reflect.DeepEqual(got, []string{"api", "web"}) {
+		t.Errorf("@alice load = %v", got)
+	}
+
+	// Balancing picks one owner per group and spreads the groups over the owners
+	balanced := PlanReviewers(ctx, requests, "carol", true)
+	if len(balanced.Load["@alice"]) != 1 || len(balanced.Load["@bob"]) != 1 {
+		t.Errorf("balanced load = %v, want one review each", balanced.Load)
+	}
+
+	missing := PlanReviewers(ctx, []ReviewRequest{{Repository: "api", Path: api, Base: "origin/main"}}, "", false)
+	if missing.Repositories[0].Error == "" {
+		t.Errorf("expected an error for a missing base")
+	}
+
+	if err := os.Remove(filepath.Join(web, ".github", "CODEOWNERS")); err != nil {
+		t.Fatal(err)
+	}
+	if codeOwners, err := LoadCodeOwners(ctx, web, "main"); err != nil || codeOwners == nil || codeOwners.Path != ".github/CODEOWNERS" {
+		t.Errorf("LoadCodeOwners should read the file of the base, not the worktree: %+v, %v", codeOwners, err)
+	}
+	if codeOwners, err := LoadCodeOwners(ctx, t.TempDir(), "main"); err == nil {
+		t.Errorf("expected an error outside a repository, got %+v", codeOwners)
+	}
+}
@@ -0,0 +1,143 @@
+package wsm
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// CommitLintConfig configures commit message linting
+type CommitLintConfig struct {
+	// Types are the allowed conventional-commit types
+	Types []string `json:"types,omitempty" yaml:"types,omitempty"`
+	// Pattern is a custom regular expression for the subject line, replacing the conventional-commit rule
+	Pattern string `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	// MaxSubjectLength limits the subject length (0 uses the default of 72, -1 disables the check)
+	MaxSubjectLength int `json:"max_subject_length,omitempty" yaml:"max_subject_length,omitempty"`
+}
+
+var defaultCommitTypes = []string{"feat", "fix", "docs", "style", "refactor", "perf", "test", "build", "ci", "chore", "revert"}
+
+// CommitLintViolation is a commit whose message does not follow the configured rules
+type CommitLintViolation struct {
+	Repository string `json:"repository"`
+	Commit     string `json:"commit"`
+	Subject    string `json:"subject"`
+	Reason     string `json:"reason"`
+}
+
+// CommitLintResult is the outcome of linting all commits of a workspace branch
+type CommitLintResult struct {
+	Base       string                `json:"base"`
+	Checked    map[string]int        `json:"checked"`
+	Violations []CommitLintViolation `json:"violations"`
+}
+
+// CommitLinter validates commit subjects
+type CommitLinter struct {
+	pattern   *regexp.Regexp
+	maxLength int
+	rule      string
+	signing   SigningConfig
+}
+
+// NewCommitLinter builds a linter from configuration
+func NewCommitLinter(config CommitLintConfig) (*CommitLinter, error) {
+	linter := &CommitLinter{maxLength: config.MaxSubjectLength}
+	if linter.maxLength == 0 {
+		linter.maxLength = 72
+	}
+
+	if config.Pattern != "" {
+		pattern, err := regexp.Compile(config.Pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid commit lint pattern: %s", config.Pattern)
+		}
+		linter.pattern = pattern
+		linter.rule = fmt.Sprintf("subject does not match %s", config.Pattern)
+		return linter, nil
+	}
+
+	types := config.Types
+	if len(types) == 0 {
+		types = defaultCommitTypes
+	}
+	quoted := make([]string, len(types))
+	for i, t := range types {
+		quoted[i] = regexp.QuoteMeta(t)
+	}
+	linter.pattern = regexp.MustCompile(`^(` + strings.Join(quoted, "|") + `)(\([\w\-./]+\))?!?: \S`)
+	linter.rule = fmt.Sprintf("subject is not '<type>(<scope>): <description>' with type in [%s]", strings.Join(types, ", "))
+
+	return linter, nil
+}
+
+// WithSigning makes the linter flag unsigned commits in repositories that require signing
+func (l *CommitLinter) WithSigning(config SigningConfig) *CommitLinter {
+	l.signing = config
+	return l
+}
+
+// Lint returns the reasons a subject violates the rules, if any
+func (l *CommitLinter) Lint(subject string) []string {
+	var reasons []string
+	if strings.HasPrefix(subject, "fixup! ") || strings.HasPrefix(subject, "squash! ") {
+		reasons = append(reasons, "fixup/squash commit must be squashed before merging")
+	} else if !l.pattern.MatchString(subject) {
+		reasons = append(reasons, l.rule)
+	}
+	if l.maxLength > 0 && len([]rune(subject)) > l.maxLength {
+		reasons = append(reasons, fmt.Sprintf("subject is longer than %d characters", l.maxLength))
+	}
+	return reasons
+}
+
+// LintWorkspaceCommits checks the non-merge commits in <base>..HEAD of every repository in the workspace
+func LintWorkspaceCommits(ctx context.Context, workspace *Workspace, base string, linter *CommitLinter) (*CommitLintResult, error) {
+	result := &CommitLintResult{
+		Base:    WorkspaceBaseRef(workspace, base),
+		Checked: make(map[string]int),
+	}
+
+	for _, repo := range workspace.Repositories {
+		worktreePath := filepath.Join(workspace.Path, repo.Name)
+		checkSignatures := linter.signing.For(repo.Name).Mode == SigningRequire
+		out, err := runGitOutput(ctx, worktreePath, "log", "--no-merges", "--format=%h%x00%s", result.Base+"..HEAD")
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list commits for %s", repo.Name)
+		}
+
+		for _, line := range strings.Split(out, "\n") {
+			parts := strings.SplitN(line, "\x00", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			result.Checked[repo.Name]++
+
+			reasons := linter.Lint(parts[1])
+			if checkSignatures {
+				signed, err := commitIsSigned(ctx, worktreePath, parts[0])
+				if err != nil {
+					return nil, errors.Wrapf(err, "failed to read commit %s in %s", parts[0], repo.Name)
+				}
+				if !signed {
+					reasons = append(reasons, "commit is not signed")
+				}
+			}
+			for _, reason := range reasons {
+				result.Violations = append(result.Violations, CommitLintViolation{
+					Repository: repo.Name,
+					Commit:     parts[0],
+					Subject:    parts[1],
+					Reason:     reason,
+				})
+			}
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,47 @@
+package wsm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCommitLinter(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  CommitLintConfig
+		subject string
+		want    []string
+	}{
+		{name: "conventional", subject: "feat(api/v2): add export"},
+		{name: "breaking change", subject: "refactor!: drop the old flag"},
+		{name: "unknown type", subject: "feature: add export", want: []string{"subject is not"}},
+		{name: "missing description", subject: "fix: ", want: []string{"subject is not"}},
+		{name: "fixup", subject: "fixup! feat: add export", want: []string{"must be squashed"}},
+		{name: "configured types", config: CommitLintConfig{Types: []string{"change"}}, subject: "feat: add export", want: []string{"[change]"}},
+		{name: "too long", subject: "fix: " + strings.Repeat("x", 70), want: []string{"longer than 72"}},
+		{name: "length check disabled", config: CommitLintConfig{MaxSubjectLength: -1}, subject: "fix: " + strings.Repeat("x", 100)},
+		{name: "pattern", config: CommitLintConfig{Pattern: `^[A-Z]+-\d+ `}, subject: "PROJ-12 Add export"},
+		{name: "pattern mismatch", config: CommitLintConfig{Pattern: `^[A-Z]+-\d+ `, MaxSubjectLength: 10}, subject: "Add export now", want: []string{"does not match", "longer than 10"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			linter, err := NewCommitLinter(tt.config)
+			if err != nil {
+				t.Fatalf("NewCommitLinter failed: %v", err)
+			}
+			reasons := linter.Lint(tt.subject)
+			if len(reasons) != len(tt.want) {
+				t.Fatalf("Lint(%q) = %q, want reasons containing %q", tt.subject, reasons, tt.want)
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(reasons[i], want) {
+					t.Errorf("reason %q does not contain %q", reasons[i], want)
+				}
+			}
+		})
+	}
+
+	if _, err := NewCommitLinter(CommitLintConfig{Pattern: "("}); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
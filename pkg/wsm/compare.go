@@ -0,0 +1,116 @@
+package wsm
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// WorkspaceComparison compares the branches of two workspaces, repository by repository
+type WorkspaceComparison struct {
+	A string `json:"a"`
+	B string `json:"b"`
+	// Repositories are those present in both workspaces
+	Repositories []RepositoryComparison `json:"repositories"`
+	OnlyInA      []string               `json:"only_in_a"`
+	OnlyInB      []string               `json:"only_in_b"`
+}
+
+// RepositoryComparison is how the branches of a repository differ between two workspaces
+type RepositoryComparison struct {
+	Repository string `json:"repository"`
+	BranchA    string `json:"branch_a"`
+	BranchB    string `json:"branch_b"`
+	HeadA      string `json:"head_a"`
+	HeadB      string `json:"head_b"`
+	MergeBase  string `json:"merge_base,omitempty"`
+	// OnlyInA are the commits of A that B does not have, and OnlyInB the other way round
+	OnlyInA []CommitSummary `json:"only_in_a"`
+	OnlyInB []CommitSummary `json:"only_in_b"`
+	// FilesChanged, Insertions and Deletions summarize the diff from A to B
+	FilesChanged int    `json:"files_changed"`
+	Insertions   int    `json:"insertions"`
+	Deletions    int    `json:"deletions"`
+	Error        string `json:"error,omitempty"`
+}
+
+// Identical reports whether both workspaces are on the same commit
+func (c RepositoryComparison) Identical() bool {
+	return c.Error == "" && c.HeadA == c.HeadB
+}
+
+// CompareWorkspaces compares two workspaces: the commits each has that the other lacks and the
+// diffstat between their branches for the repositories they share, and the repositories only one
+// of them has
+func CompareWorkspaces(ctx context.Context, a, b *Workspace) *WorkspaceComparison {
+	comparison := &WorkspaceComparison{A: a.Name, B: b.Name}
+
+	inB := map[string]bool{}
+	for _, repo := range b.Repositories {
+		inB[repo.Name] = true
+	}
+	inA := map[string]bool{}
+	for _, repo := range a.Repositories {
+		inA[repo.Name] = true
+		if !inB[repo.Name] {
+			comparison.OnlyInA = append(comparison.OnlyInA, repo.Name)
+			continue
+		}
+		comparison.Repositories = append(comparison.Repositories,
+			compareRepository(ctx, repo.Name, filepath.Join(a.Path, repo.Name), filepath.Join(b.Path, repo.Name)))
+	}
+	for _, repo := range b.Repositories {
+		if !inA[repo.Name] {
+			comparison.OnlyInB = append(comparison.OnlyInB, repo.Name)
+		}
+	}
+	return comparison
+}
+
+func compareRepository(ctx context.Context, name, pathA, pathB string) RepositoryComparison {
+	comparison := RepositoryComparison{Repository: name}
+	fail := func(err error) RepositoryComparison {
+		comparison.Error = err.Error()
+		return comparison
+	}
+
+	var err error
+	if comparison.HeadA, err = runGitOutput(ctx, pathA, "rev-parse", "HEAD"); err != nil {
+		return fail(errors.Wrap(err, "failed to resolve HEAD of the first workspace"))
+	}
+	if comparison.HeadB, err = runGitOutput(ctx, pathB, "rev-parse", "HEAD"); err != nil {
+		return fail(errors.Wrap(err, "failed to resolve HEAD of the second workspace"))
+	}
+	comparison.BranchA, _ = runGitOutput(ctx, pathA, "branch", "--show-current")
+	comparison.BranchB, _ = runGitOutput(ctx, pathB, "branch", "--show-current")
+	if comparison.HeadA == comparison.HeadB {
+		comparison.MergeBase = comparison.HeadA
+		return comparison
+	}
+
+	// Worktrees of the same source repository share their objects; when the workspaces use
+	// different clones, the commits of B are fetched into the repository of A
+	if _, err := runGitOutput(ctx, pathA, "cat-file", "-e", comparison.HeadB+"^{commit}"); err != nil {
+		if _, err := runGitOutput(ctx, pathA, "fetch", "--quiet", "--no-tags", pathB, "HEAD"); err != nil {
+			return fail(errors.Wrap(err, "failed to fetch the commits of the second workspace"))
+		}
+	}
+
+	if comparison.MergeBase, err = runGitOutput(ctx, pathA, "merge-base", comparison.HeadA, comparison.HeadB); err != nil {
+		return fail(errors.New("the branches have no common history"))
+	}
+	if comparison.OnlyInA, err = listCommits(ctx, pathA, comparison.HeadB+".."+comparison.HeadA); err != nil {
+		return fail(err)
+	}
+	if comparison.OnlyInB, err = listCommits(ctx, pathA, comparison.HeadA+".."+comparison.HeadB); err != nil {
+		return fail(err)
+	}
+
+	numstat, err := runGitOutput(ctx, pathA, "diff", "--numstat", comparison.HeadA, comparison.HeadB)
+	if err != nil {
+		return fail(errors.Wrap(err, "failed to compute diffstat"))
+	}
+	comparison.FilesChanged, comparison.Insertions, comparison.Deletions = sumNumstat(numstat)
+	return comparison
+}
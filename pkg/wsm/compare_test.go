@@ -0,0 +1,105 @@
+package wsm
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestSumNumstat(t *testing.T) {
+	numstat := "3\t1\tmain.go\n-\t-\tlogo.png\n10\t0\tdocs/README.md\n"
+	files, insertions, deletions := sumNumstat(numstat)
+	if files != 3 || insertions != 13 || deletions != 1 {
+		t.Errorf("sumNumstat = %d, %d, %d, want 3, 13, 1", files, insertions, deletions)
+	}
+	if files, insertions, deletions := sumNumstat(""); files != 0 || insertions != 0 || deletions != 0 {
+		t.Errorf("sumNumstat of an empty diff = %d, %d, %d", files, insertions, deletions)
+	}
+}
+
+func TestCompareWorkspaces(t *testing.T) {
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	ctx := context.Background()
+
+	source := t.TempDir()
+	testGit(t, source, "init", "--quiet")
+	commitFile(t, source, "README.md", "base\n", "Initial")
+
+	rootA, rootB := t.TempDir(), t.TempDir()
+	// lib is a worktree of the same source in both workspaces; app a separate clone in each
+	testGit(t, source, "worktree", "add", "--quiet", "-b", "feature/a", filepath.Join(rootA, "lib"))
+	testGit(t, source, "worktree", "add", "--quiet", "-b", "feature/b", filepath.Join(rootB, "lib"))
+	testGit(t, rootA, "clone", "--quiet", source, "app")
+	testGit(t, rootB, "clone", "--quiet", source, "app")
+	testGit(t, rootA, "clone", "--quiet", source, "docs")
+	testGit(t, rootB, "clone", "--quiet", source, "web")
+
+	commitFile(t, filepath.Join(rootA, "lib"), "a.txt", "a\n", "Add a")
+	commitFile(t, filepath.Join(rootB, "lib"), "b.txt", "one\ntwo\nthree\n", "Add b")
+	commitFile(t, filepath.Join(rootB, "lib"), "README.md", "changed\n", "Change README")
+	commitFile(t, filepath.Join(rootB, "app"), "app.go", "package app\n", "Add app")
+
+	a := &Workspace{Name: "a", Path: rootA, Repositories: []Repository{{Name: "lib"}, {Name: "app"}, {Name: "docs"}}}
+	b := &Workspace{Name: "b", Path: rootB, Repositories: []Repository{{Name: "lib"}, {Name: "app"}, {Name: "web"}}}
+	comparison := CompareWorkspaces(ctx, a, b)
+
+	if len(comparison.OnlyInA) != 1 || comparison.OnlyInA[0] != "docs" || len(comparison.OnlyInB) != 1 || comparison.OnlyInB[0] != "web" {
+		t.Errorf("only in a = %v, only in b = %v", comparison.OnlyInA, comparison.OnlyInB)
+	}
+	if len(comparison.Repositories) != 2 {
+		t.Fatalf("repositories = %+v, want lib and app", comparison.Repositories)
+	}
+
+	lib := comparison.Repositories[0]
+	if lib.Error != "" {
+		t.Fatalf("lib: %s", lib.Error)
+	}
+	if lib.BranchA != "feature/a" || lib.BranchB != "feature/b" || lib.Identical() {
+		t.Errorf("lib = %+v", lib)
+	}
+	if len(lib.OnlyInA) != 1 || lib.OnlyInA[0].Subject != "Add a" || len(lib.OnlyInB) != 2 {
+		t.Errorf("lib commits: only in a %+v, only in b %+v", lib.OnlyInA, lib.OnlyInB)
+	}
+	// From A to B: a.txt is removed, b.txt added and README.md changed
+	if lib.FilesChanged != 3 || lib.Insertions != 4 || lib.Deletions != 2 {
+		t.Errorf("lib diffstat = %d files, +%d -%d, want 3 files, +4 -2", lib.FilesChanged, lib.Insertions, lib.Deletions)
+	}
+
+	// The commits of the other clone are fetched to compare them
+	app := comparison.Repositories[1]
+	if app.Error != "" {
+		t.Fatalf("app: %s", app.Error)
+	}
+	if len(app.OnlyInA) != 0 || len(app.OnlyInB) != 1 || app.FilesChanged != 1 || app.MergeBase != testGit(t, source, "rev-parse", "HEAD") {
+		t.Errorf("app = %+v", app)
+	}
+}
+
+func TestCompareWorkspacesIdenticalAndUnrelated(t *testing.T) {
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	ctx := context.Background()
+
+	rootA, rootB := t.TempDir(), t.TempDir()
+	for _, dir := range []string{filepath.Join(rootA, "lib"), filepath.Join(rootA, "app"), filepath.Join(rootB, "app")} {
+		testGit(t, t.TempDir(), "init", "--quiet", dir)
+		commitFile(t, dir, "README.md", dir+"\n", "Initial")
+	}
+	testGit(t, rootB, "clone", "--quiet", filepath.Join(rootA, "lib"), "lib")
+
+	a := &Workspace{Name: "a", Path: rootA, Repositories: []Repository{{Name: "lib"}, {Name: "app"}}}
+	b := &Workspace{Name: "b", Path: rootB, Repositories: []Repository{{Name: "lib"}, {Name: "app"}}}
+	comparison := CompareWorkspaces(ctx, a, b)
+
+	if lib := comparison.Repositories[0]; !lib.Identical() || lib.MergeBase != lib.HeadA {
+		t.Errorf("lib should be identical: %+v", lib)
+	}
+	if app := comparison.Repositories[1]; app.Error != "the branches have no common history" {
+		t.Errorf("app error = %q", app.Error)
+	}
+}
@@ -0,0 +1,78 @@
+package wsm
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigRejectsUnknownKeys(t *testing.T) {
+	useTestConfigDir(t)
+	configPath, err := ConfigPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	workspaceDir := t.TempDir()
+
+	if err := os.WriteFile(configPath, []byte("workspace_dir: "+workspaceDir+"\nprefetch:\n  interval: 1h\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if config.WorkspaceDir != workspaceDir || config.Prefetch.Interval != "1h" {
+		t.Errorf("config = %+v", config)
+	}
+
+	if err := os.WriteFile(configPath, []byte("workspace_dir: "+workspaceDir+"\nprefetch:\n  intervall: 1h\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), "intervall") {
+		t.Errorf("expected an error naming the misspelled key, got %v", err)
+	}
+
+	// An empty file is not an error
+	if err := os.WriteFile(configPath, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(); err != nil {
+		t.Errorf("LoadConfig failed on an empty file: %v", err)
+	}
+}
+
+func TestLoadConfigAcceptsLoggingKeys(t *testing.T) {
+	useTestConfigDir(t)
+	configPath, err := ConfigPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	workspaceDir := t.TempDir()
+
+	content := "log-level: info\nwith-caller: true\nlogstash-port: 5044\nworkspace_dir: " + workspaceDir + "\n"
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed with logging keys: %v", err)
+	}
+	if config.WorkspaceDir != workspaceDir {
+		t.Errorf("WorkspaceDir = %q, want %q", config.WorkspaceDir, workspaceDir)
+	}
+
+	// Other unknown keys are still rejected next to the logging keys
+	if err := os.WriteFile(configPath, []byte(content+"workspce_dir: x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), "workspce_dir") {
+		t.Errorf("expected an error naming the misspelled key, got %v", err)
+	}
+}
@@ -0,0 +1,264 @@
+package wsm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	ContainerRuntimeAuto   = "auto"
+	ContainerRuntimeDocker = "docker"
+	ContainerRuntimePodman = "podman"
+
+	ContainerStateRunning = "running"
+	ContainerStateStopped = "stopped"
+	ContainerStateMissing = "missing"
+
+	defaultContainerWorkdir = "/workspace"
+	DefaultContainerShell   = "/bin/sh"
+)
+
+var containerNameUnsafe = regexp.MustCompile(`[^a-zA-Z0-9_.-]`)
+
+// ContainerConfig describes the dev container started for a workspace by 'wsm container up'.
+// The container section of config.yaml is overlaid with the workspace .wsm/container.yaml.
+type ContainerConfig struct {
+	// Runtime is docker, podman or auto (docker when installed, podman otherwise)
+	Runtime string `json:"runtime,omitempty" yaml:"runtime,omitempty"`
+	Image   string `json:"image,omitempty" yaml:"image,omitempty"`
+	// Workdir is where the workspace directory is mounted; defaults to /workspace
+	Workdir string `json:"workdir,omitempty" yaml:"workdir,omitempty"`
+	// Shell is run by 'wsm container shell'; defaults to /bin/sh
+	Shell string `json:"shell,omitempty" yaml:"shell,omitempty"`
+	// Command keeps the container alive; defaults to "sleep infinity"
+	Command []string `json:"command,omitempty" yaml:"command,omitempty"`
+	// Args are passed to '<runtime> run' before the image (ports, extra volumes, ...)
+	Args []string          `json:"args,omitempty" yaml:"args,omitempty"`
+	Env  map[string]string `json:"env,omitempty" yaml:"env,omitempty"`
+}
+
+// WorkspaceContainer records the dev container of a workspace in its metadata
+type WorkspaceContainer struct {
+	Runtime string    `json:"runtime"`
+	Name    string    `json:"name"`
+	ID      string    `json:"id"`
+	Image   string    `json:"image"`
+	Workdir string    `json:"workdir"`
+	Started time.Time `json:"started"`
+}
+
+// WorkspaceContainerConfig returns the container section of config.yaml with the workspace
+// .wsm/container.yaml layered on top
+func (wm *WorkspaceManager) WorkspaceContainerConfig(workspace *Workspace) (ContainerConfig, error) {
+	config := wm.config.Container
+	configPath := filepath.Join(workspace.Path, ".wsm", "container.yaml")
+	data, err := wm.fs().ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return config, nil
+		}
+		return config, errors.Wrapf(err, "failed to read %s", configPath)
+	}
+
+	// Maps are merged by yaml, so copy the global one before overlaying
+	if config.Env != nil {
+		env := make(map[string]string, len(config.Env))
+		for key, value := range config.Env {
+			env[key] = value
+		}
+		config.Env = env
+	}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return config, errors.Wrapf(err, "failed to parse %s", configPath)
+	}
+	return config, nil
+}
+
+// ContainerName returns the name of the dev container of a workspace
+func ContainerName(workspace *Workspace) string {
+	return "wsm-" + containerNameUnsafe.ReplaceAllString(workspace.Name, "_")
+}
+
+// ResolveContainerRuntime returns the container CLI to use for a configured runtime
+func ResolveContainerRuntime(runtime string) (string, error) {
+	switch runtime {
+	case "", ContainerRuntimeAuto:
+		for _, candidate := range []string{ContainerRuntimeDocker, ContainerRuntimePodman} {
+			if _, err := exec.LookPath(candidate); err == nil {
+				return candidate, nil
+			}
+		}
+		return "", errors.New("neither docker nor podman is installed or in PATH")
+	case ContainerRuntimeDocker, ContainerRuntimePodman:
+		if _, err := exec.LookPath(runtime); err != nil {
+			return "", errors.Errorf("%s is not installed or not in PATH", runtime)
+		}
+		return runtime, nil
+	default:
+		return "", errors.Errorf("unknown container runtime '%s' (expected docker, podman or auto)", runtime)
+	}
+}
+
+// ContainerState reports whether the recorded container of a workspace is running, stopped or
+// missing; it is empty when the workspace has no container
+func ContainerState(ctx context.Context, runner CommandRunner, workspace *Workspace) string {
+	if workspace.Container == nil {
+		return ""
+	}
+	out, err := runnerOrDefault(runner).Output(ctx, "", workspace.Container.Runtime,
+		"inspect", "--format", "{{.State.Running}}", workspace.Container.Name)
+	if err != nil {
+		return ContainerStateMissing
+	}
+	if strings.TrimSpace(string(out)) == "true" {
+		return ContainerStateRunning
+	}
+	return ContainerStateStopped
+}
+
+// StartContainer starts the dev container of a workspace with the workspace directory mounted and
+// the WSM_ variables of setup scripts in its environment, and records it in the workspace
+// metadata. A stopped container of the workspace is replaced.
+func (wm *WorkspaceManager) StartContainer(ctx context.Context, workspace *Workspace, config ContainerConfig) error {
+	if config.Image == "" {
+		return errors.New("no container image configured: set container.image in config.yaml or .wsm/container.yaml, or pass --image")
+	}
+	runtime, err := ResolveContainerRuntime(config.Runtime)
+	if err != nil {
+		return err
+	}
+	if config.Workdir == "" {
+		config.Workdir = defaultContainerWorkdir
+	}
+	command := config.Command
+	if len(command) == 0 {
+		command = []string{"sleep", "infinity"}
+	}
+
+	name := ContainerName(workspace)
+	if workspace.Container != nil {
+		switch ContainerState(ctx, wm.runner(), workspace) {
+		case ContainerStateRunning:
+			return errors.Errorf("container '%s' is already running; use 'wsm container down' first", workspace.Container.Name)
+		case ContainerStateStopped:
+			if _, err := wm.runner().CombinedOutput(ctx, "", workspace.Container.Runtime, "rm", workspace.Container.Name); err != nil {
+				return errors.Wrapf(err, "failed to remove stopped container '%s'", workspace.Container.Name)
+			}
+		}
+	}
+
+	env, err := wm.ContainerEnvironment(ctx, workspace, config)
+	if err != nil {
+		return err
+	}
+
+	args := []string{
+		"run", "--detach",
+		"--name", name,
+		"--label", "wsm.workspace=" + workspace.Name,
+		"--volume", workspace.Path + ":" + config.Workdir,
+		"--workdir", config.Workdir,
+	}
+	// Values are passed through the environment of the runtime CLI so secrets don't show up in
+	// the process list
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	processEnv := os.Environ()
+	for _, key := range keys {
+		args = append(args, "--env", key)
+		processEnv = append(processEnv, key+"="+env[key])
+	}
+	args = append(args, config.Args...)
+	args = append(args, config.Image)
+	args = append(args, command...)
+
+	cmd := exec.CommandContext(ctx, runtime, args...)
+	cmd.Env = processEnv
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return errors.Wrapf(err, "%s run failed: %s", runtime, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return errors.Wrapf(err, "%s run failed", runtime)
+	}
+
+	workspace.Container = &WorkspaceContainer{
+		Runtime: runtime,
+		Name:    name,
+		ID:      strings.TrimSpace(string(out)),
+		Image:   config.Image,
+		Workdir: config.Workdir,
+		Started: time.Now(),
+	}
+	return wm.SaveWorkspace(workspace)
+}
+
+// ContainerEnvironment returns the variables injected into the dev container: the setup
+// environment with paths translated to the mount point, and the configured env
+func (wm *WorkspaceManager) ContainerEnvironment(ctx context.Context, workspace *Workspace, config ContainerConfig) (map[string]string, error) {
+	env, err := wm.SetupEnvironment(ctx, workspace)
+	if err != nil {
+		return nil, err
+	}
+	workdir := config.Workdir
+	if workdir == "" {
+		workdir = defaultContainerWorkdir
+	}
+	env["WSM_WORKSPACE_PATH"] = workdir
+	env["WSM_HOST_WORKSPACE_PATH"] = workspace.Path
+	for key, value := range config.Env {
+		env[key] = value
+	}
+	return env, nil
+}
+
+// StopContainer removes the dev container of a workspace and clears it from the metadata
+func (wm *WorkspaceManager) StopContainer(ctx context.Context, workspace *Workspace) error {
+	if workspace.Container == nil {
+		return errors.Errorf("workspace '%s' has no container", workspace.Name)
+	}
+	if ContainerState(ctx, wm.runner(), workspace) != ContainerStateMissing {
+		out, err := wm.runner().CombinedOutput(ctx, "", workspace.Container.Runtime, "rm", "--force", workspace.Container.Name)
+		if err != nil {
+			return errors.Wrapf(err, "failed to remove container '%s': %s", workspace.Container.Name, strings.TrimSpace(string(out)))
+		}
+	}
+	workspace.Container = nil
+	return wm.SaveWorkspace(workspace)
+}
+
+// ContainerExecArgs returns the runtime command running command (the configured shell when empty)
+// in the container, in the directory matching dir when it is inside the workspace
+func ContainerExecArgs(workspace *Workspace, dir string, tty bool, command ...string) []string {
+	workdir := workspace.Container.Workdir
+	if rel, err := filepath.Rel(workspace.Path, dir); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		workdir = path.Join(workdir, filepath.ToSlash(rel))
+	}
+
+	args := []string{workspace.Container.Runtime, "exec", "--interactive"}
+	if tty {
+		args = append(args, "--tty")
+	}
+	args = append(args, "--workdir", workdir, workspace.Container.Name)
+	return append(args, command...)
+}
+
+// FormatContainer describes the container of a workspace and its state for display
+func FormatContainer(container *WorkspaceContainer, state string) string {
+	return fmt.Sprintf("%s (%s, %s via %s)", container.Name, state, container.Image, container.Runtime)
+}
@@ -0,0 +1,59 @@
+package wsm
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestContainerExecArgs(t *testing.T) {
+	root := t.TempDir()
+	workspace := &Workspace{
+		Name:      "feat/x y",
+		Path:      root,
+		Container: &WorkspaceContainer{Runtime: "podman", Name: "wsm-feat_x_y", Workdir: "/workspace"},
+	}
+	if name := ContainerName(workspace); name != "wsm-feat_x_y" {
+		t.Errorf("ContainerName = %s", name)
+	}
+
+	tests := []struct {
+		dir     string
+		tty     bool
+		command []string
+		want    string
+	}{
+		{dir: filepath.Join(root, "api", "cmd"), tty: true, want: "podman exec --interactive --tty --workdir /workspace/api/cmd wsm-feat_x_y"},
+		{dir: root, command: []string{"go", "test", "./..."}, want: "podman exec --interactive --workdir /workspace wsm-feat_x_y go test ./..."},
+		{dir: filepath.Dir(root), want: "podman exec --interactive --workdir /workspace wsm-feat_x_y"},
+		{dir: root + "..sibling", want: "podman exec --interactive --workdir /workspace wsm-feat_x_y"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(ContainerExecArgs(workspace, tt.dir, tt.tty, tt.command...), " "); got != tt.want {
+			t.Errorf("ContainerExecArgs(%s) = %s, want %s", tt.dir, got, tt.want)
+		}
+	}
+}
+
+func TestWorkspaceContainerConfigOverlaysTheWorkspaceFile(t *testing.T) {
+	wm := newTestWorkspaceManager(t)
+	wm.config.Container = ContainerConfig{Image: "golang:1.23", Env: map[string]string{"A": "1", "B": "2"}}
+	workspace := &Workspace{Path: t.TempDir()}
+
+	config, err := wm.WorkspaceContainerConfig(workspace)
+	if err != nil || config.Image != "golang:1.23" {
+		t.Fatalf("config without a workspace file = %+v (%v)", config, err)
+	}
+
+	writeGoFiles(t, workspace.Path, map[string]string{".wsm/container.yaml": "image: node:22\nenv:\n  B: override\n  C: \"3\"\n"})
+	config, err = wm.WorkspaceContainerConfig(workspace)
+	if err != nil {
+		t.Fatalf("WorkspaceContainerConfig failed: %v", err)
+	}
+	if config.Image != "node:22" || config.Env["A"] != "1" || config.Env["B"] != "override" || config.Env["C"] != "3" {
+		t.Errorf("config = %+v", config)
+	}
+	if wm.config.Container.Env["B"] != "2" || len(wm.config.Container.Env) != 2 {
+		t.Errorf("the global environment was modified: %v", wm.config.Container.Env)
+	}
+}
@@ -0,0 +1,336 @@
+package wsm
+
+import (
+	"context"
+	"html"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+)
+
+// DaemonDiscoveryDebounce is how long a discovery path has to be quiet before it is scanned again,
+// so a clone in progress is scanned once it is complete
+const DaemonDiscoveryDebounce = 10 * time.Second
+
+// DaemonReloadDebounce is how long config.yaml and the registry have to be quiet before the
+// daemon reloads them, so an editor saving in several steps causes a single reload
+const DaemonReloadDebounce = 500 * time.Millisecond
+
+// DefaultStatusInterval is the time between two workspace status checks of the daemon while the
+// workspaces change
+const DefaultStatusInterval = 5 * time.Second
+
+// maxStatusInterval bounds the backoff of the status checks while nothing changes
+const maxStatusInterval = time.Minute
+
+// DaemonConfig configures 'wsm daemon run' in config.yaml
+type DaemonConfig struct {
+	// StatusInterval is the time between workspace status checks for events, e.g. 10s; 0 disables them
+	StatusInterval string `json:"status_interval,omitempty" yaml:"status_interval,omitempty"`
+}
+
+// StatusIntervalDuration parses StatusInterval, falling back to DefaultStatusInterval
+func (c DaemonConfig) StatusIntervalDuration() (time.Duration, error) {
+	if c.StatusInterval == "" {
+		return DefaultStatusInterval, nil
+	}
+	d, err := time.ParseDuration(c.StatusInterval)
+	if err != nil || d < 0 {
+		return 0, errors.Errorf("invalid daemon.status_interval '%s'", c.StatusInterval)
+	}
+	return d, nil
+}
+
+// NextStatusInterval returns the time until the next status check: interval after a check that
+// found changes, otherwise twice the last wait, up to a minute or interval if that is longer.
+// Every check runs git status in all worktrees, so idle workspaces are checked less and less often
+func NextStatusInterval(last, interval time.Duration, changed bool) time.Duration {
+	if changed || last <= 0 {
+		return interval
+	}
+	return min(2*last, max(interval, maxStatusInterval))
+}
+
+// DaemonService is the unit that keeps 'wsm daemon run' running in the background
+type DaemonService struct {
+	// Name is the systemd unit or launchd label
+	Name string
+	// Path is the unit file or property list
+	Path string
+	// Content is the file written to Path
+	Content string
+	// Launchd is set for a launchd agent (macOS), otherwise it is a systemd user service
+	Launchd bool
+}
+
+// daemonName is the name of the daemon of a profile
+func daemonName(profile string) string {
+	if profile == DefaultProfile {
+		return "wsm-daemon"
+	}
+	return "wsm-daemon-" + profile
+}
+
+// daemonArguments is the command line the service runs
+func daemonArguments(executable, profile string) []string {
+	args := []string{executable, "daemon", "run"}
+	if profile != DefaultProfile {
+		args = append(args, "--profile", profile)
+	}
+	return args
+}
+
+// SystemdDaemonService returns the systemd user service running the daemon of a profile
+func SystemdDaemonService(executable, profile string) (DaemonService, error) {
+	unitDir, err := SystemdUserUnitDir()
+	if err != nil {
+		return DaemonService{}, err
+	}
+	name := daemonName(profile) + ".service"
+	content := strings.Join([]string{
+		"[Unit]",
+		"Description=Discover new repositories and prefetch registered ones for workspace-manager",
+		"",
+		"[Service]",
+		"Type=simple",
+		"ExecStart=" + systemdCommandLine(daemonArguments(executable, profile)),
+		"Restart=on-failure",
+		"RestartSec=30s",
+		"",
+		"[Install]",
+		"WantedBy=default.target",
+		"",
+	}, "\n")
+	return DaemonService{Name: name, Path: filepath.Join(unitDir, name), Content: content}, nil
+}
+
+// LaunchdDaemonService returns the launchd agent running the daemon of a profile, logging to
+// daemon.log in the configuration directory
+func LaunchdDaemonService(executable, profile string) (DaemonService, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return DaemonService{}, errors.Wrap(err, "failed to get home directory")
+	}
+	configDir, err := ProfileConfigDir(profile)
+	if err != nil {
+		return DaemonService{}, err
+	}
+	label := "com.go-go-golems." + daemonName(profile)
+	logPath := filepath.Join(configDir, "daemon.log")
+
+	var args []string
+	for _, arg := range daemonArguments(executable, profile) {
+		args = append(args, "    <string>"+html.EscapeString(arg)+"</string>")
+	}
+	content := strings.Join([]string{
+		`<?xml version="1.0" encoding="UTF-8"?>`,
+		`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">`,
+		`<plist version="1.0">`,
+		`<dict>`,
+		`  <key>Label</key>`,
+		`  <string>` + label + `</string>`,
+		`  <key>ProgramArguments</key>`,
+		`  <array>`,
+		strings.Join(args, "\n"),
+		`  </array>`,
+		`  <key>RunAtLoad</key>`,
+		`  <true/>`,
+		`  <key>KeepAlive</key>`,
+		`  <true/>`,
+		`  <key>ThrottleInterval</key>`,
+		`  <integer>30</integer>`,
+		`  <key>StandardOutPath</key>`,
+		`  <string>` + html.EscapeString(logPath) + `</string>`,
+		`  <key>StandardErrorPath</key>`,
+		`  <string>` + html.EscapeString(logPath) + `</string>`,
+		`</dict>`,
+		`</plist>`,
+		``,
+	}, "\n")
+	path := filepath.Join(home, "Library", "LaunchAgents", label+".plist")
+	return DaemonService{Name: label, Path: path, Content: content, Launchd: true}, nil
+}
+
+// WatchDiscoveryPaths watches the directories below the discovery roots a repository can be
+// created in, down to the maximum discovery depth of each root, until ctx is done. When
+// directories were created below a root, e.g. by a clone, changed is called with the root once
+// it has been quiet for the debounce period.
+func WatchDiscoveryPaths(ctx context.Context, roots map[string]int, debounce time.Duration, changed func(root string)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errors.Wrap(err, "failed to create file watcher")
+	}
+	defer watcher.Close()
+
+	for root, depth := range roots {
+		if err := watchDiscoveryTree(watcher, root, depth); err != nil {
+			return err
+		}
+	}
+
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	pending := map[string]bool{}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			return errors.Wrap(err, "file watcher failed")
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if !event.Has(fsnotify.Create) {
+				continue
+			}
+			info, err := os.Stat(event.Name)
+			if err != nil || !info.IsDir() {
+				continue
+			}
+			root, depth := discoveryRoot(roots, event.Name)
+			if root == "" || strings.HasPrefix(filepath.Base(event.Name), ".") || insideRepository(root, event.Name) {
+				continue
+			}
+			_ = watchDiscoveryTree(watcher, event.Name, depth)
+			pending[root] = true
+			timer.Reset(debounce)
+
+		case <-timer.C:
+			for _, root := range slices.Sorted(maps.Keys(pending)) {
+				if ctx.Err() != nil {
+					return nil
+				}
+				changed(root)
+			}
+			pending = map[string]bool{}
+		}
+	}
+}
+
+// WatchFiles watches files until ctx is done and calls changed with each file that was written,
+// created or replaced once it has been quiet for the debounce period. The directories holding the
+// files are watched rather than the files, so that editors replacing a file on save are noticed.
+func WatchFiles(ctx context.Context, files []string, debounce time.Duration, changed func(file string)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errors.Wrap(err, "failed to create file watcher")
+	}
+	defer watcher.Close()
+
+	watched := map[string]bool{}
+	for _, file := range files {
+		dir := filepath.Dir(file)
+		if watched[dir] {
+			continue
+		}
+		if err := watcher.Add(dir); err != nil {
+			return errors.Wrapf(err, "failed to watch %s", dir)
+		}
+		watched[dir] = true
+	}
+
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	pending := map[string]bool{}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			return errors.Wrap(err, "file watcher failed")
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if !slices.Contains(files, event.Name) || !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) {
+				continue
+			}
+			pending[event.Name] = true
+			timer.Reset(debounce)
+
+		case <-timer.C:
+			for _, file := range slices.Sorted(maps.Keys(pending)) {
+				if ctx.Err() != nil {
+					return nil
+				}
+				changed(file)
+			}
+			pending = map[string]bool{}
+		}
+	}
+}
+
+// watchDiscoveryTree adds dir and the directories below it to the watcher, down to depth levels.
+// Repositories are not descended into, nor hidden directories and those holding dependencies.
+func watchDiscoveryTree(watcher *fsnotify.Watcher, dir string, depth int) error {
+	if depth <= 0 {
+		return nil
+	}
+	if err := watcher.Add(dir); err != nil {
+		return errors.Wrapf(err, "failed to watch %s", dir)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		// Directories can disappear while walking
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to read %s", dir)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() || strings.HasPrefix(name, ".") || slices.Contains(defaultWatchIgnore, name) {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(filepath.Join(path, ".git")); err == nil {
+			continue
+		}
+		if err := watchDiscoveryTree(watcher, path, depth-1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// discoveryRoot returns the root containing path and the depth left below path
+func discoveryRoot(roots map[string]int, path string) (string, int) {
+	for root, depth := range roots {
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == "." || !filepath.IsLocal(rel) {
+			continue
+		}
+		return root, depth - len(strings.Split(filepath.ToSlash(rel), "/"))
+	}
+	return "", 0
+}
+
+// insideRepository reports whether path is below a repository in root; a clone is watched until
+// its .git directory appears
+func insideRepository(root, path string) bool {
+	for dir := filepath.Dir(path); dir != root && strings.HasPrefix(dir, root); dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package wsm
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDaemonStatusIntervalDuration(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "", want: DefaultStatusInterval},
+		{value: "30s", want: 30 * time.Second},
+		{value: "0", want: 0},
+		{value: "-1s", wantErr: true},
+		{value: "often", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := DaemonConfig{StatusInterval: tt.value}.StatusIntervalDuration()
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%q: expected an error, got %s", tt.value, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%q: got %s, %v, want %s", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestNextStatusInterval(t *testing.T) {
+	tests := []struct {
+		name     string
+		last     time.Duration
+		interval time.Duration
+		changed  bool
+		want     time.Duration
+	}{
+		{name: "doubles while nothing changes", last: 5 * time.Second, interval: 5 * time.Second, want: 10 * time.Second},
+		{name: "capped at a minute", last: 40 * time.Second, interval: 5 * time.Second, want: time.Minute},
+		{name: "back to the interval on a change", last: time.Minute, interval: 5 * time.Second, changed: true, want: 5 * time.Second},
+		{name: "a longer interval is the cap", last: 2 * time.Minute, interval: 2 * time.Minute, want: 2 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NextStatusInterval(tt.last, tt.interval, tt.changed); got != tt.want {
+				t.Errorf("NextStatusInterval(%s, %s, %v) = %s, want %s", tt.last, tt.interval, tt.changed, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiscoveryRoot(t *testing.T) {
+	roots := map[string]int{"/src": 3}
+	tests := []struct {
+		path      string
+		wantRoot  string
+		wantDepth int
+	}{
+		{path: "/src/app", wantRoot: "/src", wantDepth: 2},
+		{path: "/src/team/lib", wantRoot: "/src", wantDepth: 1},
+		{path: "/src/..hidden", wantRoot: "/src", wantDepth: 2},
+		{path: "/src"},
+		{path: "/other/app"},
+		{path: "/srcs/app"},
+	}
+	for _, tt := range tests {
+		root, depth := discoveryRoot(roots, tt.path)
+		if root != tt.wantRoot || depth != tt.wantDepth {
+			t.Errorf("discoveryRoot(%s) = %q, %d, want %q, %d", tt.path, root, depth, tt.wantRoot, tt.wantDepth)
+		}
+	}
+}
+
+func TestSystemdDaemonServiceQuotesTheExecutable(t *testing.T) {
+	useTestConfigDir(t)
+	service, err := SystemdDaemonService("/home/me/My Tools/wsm", "work")
+	if err != nil {
+		t.Fatalf("SystemdDaemonService failed: %v", err)
+	}
+	if !strings.Contains(service.Content, "\nExecStart=\"/home/me/My Tools/wsm\" daemon run --profile work\n") {
+		t.Errorf("service:\n%s", service.Content)
+	}
+}
@@ -0,0 +1,83 @@
+package wsm
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// WorkspaceMatch is the workspace a directory belongs to
+type WorkspaceMatch struct {
+	Workspace *Workspace
+	// Repository is the repository whose worktree contains the directory, empty when the directory
+	// is elsewhere in the workspace, e.g. its root
+	Repository string
+}
+
+// DetectWorkspace finds the workspace dir belongs to. Inside a worktree, git reports the repository
+// the worktree was added to (--git-common-dir) and the root of the worktree, which identify the
+// workspace repository regardless of the path dir was reached by. Elsewhere, the workspace whose
+// directory contains dir is used. Symbolic links are resolved on both sides, so a workspace entered
+// through a link is detected as well. The repositories of linked workspaces are the checkouts
+// themselves, so those workspaces are only detected from paths inside their directory.
+func DetectWorkspace(ctx context.Context, runner CommandRunner, dir string, workspaces []Workspace) (*WorkspaceMatch, error) {
+	linkedDir := dir
+	if abs, err := filepath.Abs(dir); err == nil {
+		linkedDir = abs
+	}
+	dir = resolvePath(dir)
+
+	out, err := gitOutput(ctx, runner, dir, "rev-parse", "--path-format=absolute", "--git-common-dir", "--show-toplevel")
+	if commonDir, toplevel, ok := strings.Cut(out, "\n"); err == nil && ok {
+		commonDir, toplevel = resolvePath(commonDir), resolvePath(toplevel)
+		for i := range workspaces {
+			workspace := &workspaces[i]
+			if workspace.Linked {
+				continue
+			}
+			for _, repo := range workspace.Repositories {
+				repoPath := resolvePath(repo.Path)
+				if repoPath != filepath.Dir(commonDir) && repoPath != commonDir {
+					continue
+				}
+				if resolvePath(filepath.Join(workspace.Path, repo.Name)) == toplevel {
+					return &WorkspaceMatch{Workspace: workspace, Repository: repo.Name}, nil
+				}
+			}
+		}
+	}
+
+	// The innermost workspace wins, for workspaces created inside another one
+	var match *Workspace
+	matchPath := ""
+	for i := range workspaces {
+		path := resolvePath(workspaces[i].Path)
+		within := isWithin(path, dir)
+		if workspaces[i].Linked {
+			// The links must not be followed: they lead to the checkouts
+			path = filepath.Clean(workspaces[i].Path)
+			within = isWithin(path, linkedDir)
+		}
+		if within && len(path) > len(matchPath) {
+			match, matchPath = &workspaces[i], path
+		}
+	}
+	if match == nil {
+		return nil, errors.New("not in a workspace directory")
+	}
+	return &WorkspaceMatch{Workspace: match}, nil
+}
+
+// resolvePath returns the absolute path with symbolic links resolved, or the cleaned path when it
+// cannot be resolved, e.g. because it no longer exists
+func resolvePath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return filepath.Clean(path)
+}
@@ -0,0 +1,74 @@
+package wsm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectWorkspace(t *testing.T) {
+	root := t.TempDir()
+	lib := filepath.Join(root, "code", "lib")
+	if err := os.MkdirAll(lib, 0755); err != nil {
+		t.Fatal(err)
+	}
+	testGit(t, lib, "init", "-q")
+	writeGoFiles(t, lib, map[string]string{"pkg/lib.go": "package pkg\n"})
+	testGit(t, lib, "add", ".")
+	testGit(t, lib, "commit", "-qm", "init")
+
+	feat := filepath.Join(root, "ws", "feat")
+	testGit(t, lib, "worktree", "add", "-q", "-b", "feat", filepath.Join(feat, "lib"))
+	nested := filepath.Join(feat, "nested")
+	linked := filepath.Join(root, "ws", "linked")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(linked, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for link, target := range map[string]string{filepath.Join(linked, "lib"): lib, filepath.Join(root, "shortcut"): feat} {
+		if err := os.Symlink(target, link); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repos := []Repository{{Name: "lib", Path: lib}}
+	workspaces := []Workspace{
+		{Name: "feat", Path: feat, Repositories: repos},
+		{Name: "nested", Path: nested},
+		{Name: "linked", Path: linked, Linked: true, Repositories: repos},
+	}
+
+	tests := []struct {
+		dir           string
+		wantWorkspace string
+		wantRepo      string
+	}{
+		{dir: filepath.Join(feat, "lib", "pkg"), wantWorkspace: "feat", wantRepo: "lib"},
+		{dir: filepath.Join(root, "shortcut", "lib"), wantWorkspace: "feat", wantRepo: "lib"},
+		{dir: feat, wantWorkspace: "feat"},
+		{dir: nested, wantWorkspace: "nested"},
+		{dir: filepath.Join(linked, "lib", "pkg"), wantWorkspace: "linked"},
+		{dir: lib},
+		{dir: root},
+	}
+	for _, tt := range tests {
+		rel, _ := filepath.Rel(root, tt.dir)
+		t.Run(rel, func(t *testing.T) {
+			match, err := DetectWorkspace(context.Background(), ExecRunner{}, tt.dir, workspaces)
+			if tt.wantWorkspace == "" {
+				if err == nil {
+					t.Errorf("expected no workspace, got %s", match.Workspace.Name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DetectWorkspace failed: %v", err)
+			}
+			if match.Workspace.Name != tt.wantWorkspace || match.Repository != tt.wantRepo {
+				t.Errorf("DetectWorkspace = %s/%s, want %s/%s", match.Workspace.Name, match.Repository, tt.wantWorkspace, tt.wantRepo)
+			}
+		})
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
@@ -18,6 +17,13 @@ import (
 type RepositoryDiscoverer struct {
 	registry     *RepositoryRegistry
 	registryPath string
+	// index answers Search, built on first use and dropped when the registry changes
+	index *RegistryIndex
+
+	// FS is used to read the registry and scan directories
+	FS FS
+	// Runner runs git to analyze repositories
+	Runner CommandRunner
 }
 
 // NewRepositoryDiscoverer creates a new repository discoverer
@@ -25,12 +31,15 @@ func NewRepositoryDiscoverer(registryPath string) *RepositoryDiscoverer {
 	return &RepositoryDiscoverer{
 		registry:     &RepositoryRegistry{},
 		registryPath: registryPath,
+		FS:           OSFS{},
+		Runner:       ExecRunner{},
 	}
 }
 
 // LoadRegistry loads the repository registry from disk
 func (rd *RepositoryDiscoverer) LoadRegistry() error {
-	if _, err := os.Stat(rd.registryPath); os.IsNotExist(err) {
+	rd.index = nil
+	if _, err := fsOrDefault(rd.FS).Stat(rd.registryPath); os.IsNotExist(err) {
 		// Registry doesn't exist, create empty one
 		rd.registry = &RepositoryRegistry{
 			Repositories: []Repository{},
@@ -39,7 +48,7 @@ func (rd *RepositoryDiscoverer) LoadRegistry() error {
 		return nil
 	}
 
-	data, err := os.ReadFile(rd.registryPath)
+	data, err := fsOrDefault(rd.FS).ReadFile(rd.registryPath)
 	if err != nil {
 		return errors.Wrap(err, "failed to read registry file")
 	}
@@ -55,7 +64,7 @@ func (rd *RepositoryDiscoverer) LoadRegistry() error {
 func (rd *RepositoryDiscoverer) SaveRegistry() error {
 	// Ensure directory exists
 	dir := filepath.Dir(rd.registryPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
+	if err := fsOrDefault(rd.FS).MkdirAll(dir, 0755); err != nil {
 		return errors.Wrap(err, "failed to create registry directory")
 	}
 
@@ -64,7 +73,7 @@ func (rd *RepositoryDiscoverer) SaveRegistry() error {
 		return errors.Wrap(err, "failed to marshal registry")
 	}
 
-	if err := os.WriteFile(rd.registryPath, data, 0644); err != nil {
+	if err := fsOrDefault(rd.FS).WriteFile(rd.registryPath, data, 0644); err != nil {
 		return errors.Wrap(err, "failed to write registry file")
 	}
 
@@ -73,21 +82,32 @@ func (rd *RepositoryDiscoverer) SaveRegistry() error {
 
 // DiscoverRepositories discovers git repositories in the given paths
 func (rd *RepositoryDiscoverer) DiscoverRepositories(ctx context.Context, paths []string, recursive bool, maxDepth int) error {
+	return rd.DiscoverRepositoriesWithOptions(ctx, paths, func(string) DiscoveryOptions {
+		return DiscoveryOptions{Recursive: recursive, MaxDepth: maxDepth}
+	})
+}
+
+// DiscoverRepositoriesWithOptions discovers git repositories in the given paths, scanning each
+// with the options returned for it
+func (rd *RepositoryDiscoverer) DiscoverRepositoriesWithOptions(ctx context.Context, paths []string, options func(path string) DiscoveryOptions) error {
 	output.LogInfo("Starting repository discovery", "Starting repository discovery")
 
 	var allRepos []Repository
 
 	for _, path := range paths {
-		repos, err := rd.scanDirectory(ctx, path, recursive, maxDepth, 0)
-		if err != nil {
+		if _, err := fsOrDefault(rd.FS).Stat(path); err != nil {
 			return errors.Wrapf(err, "failed to scan directory %s", path)
 		}
-		allRepos = append(allRepos, repos...)
+		allRepos = append(allRepos, rd.walkRepositories(ctx, path, options(path))...)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
 	// Update registry
 	rd.registry.Repositories = rd.mergeRepositories(rd.registry.Repositories, allRepos)
 	rd.registry.LastScan = time.Now()
+	rd.index = nil
 
 	output.LogInfo(
 		fmt.Sprintf("Discovery completed: found %d repositories", len(allRepos)),
@@ -98,74 +118,10 @@ func (rd *RepositoryDiscoverer) DiscoverRepositories(ctx context.Context, paths
 	return rd.SaveRegistry()
 }
 
-// scanDirectory recursively scans a directory for git repositories
-func (rd *RepositoryDiscoverer) scanDirectory(ctx context.Context, path string, recursive bool, maxDepth, currentDepth int) ([]Repository, error) {
-	if currentDepth > maxDepth {
-		return nil, nil
-	}
-
-	var repos []Repository
-
-	// Check if current directory is a git repository
-	if rd.isGitRepository(path) {
-		repo, err := rd.analyzeRepository(ctx, path)
-		if err != nil {
-			output.LogWarn(
-				fmt.Sprintf("Failed to analyze repository at %s: %v", path, err),
-				"Failed to analyze repository",
-				"error", err,
-				"path", path,
-			)
-		} else {
-			repos = append(repos, *repo)
-		}
-	}
-
-	if !recursive {
-		return repos, nil
-	}
-
-	// Scan subdirectories
-	entries, err := os.ReadDir(path)
-	if err != nil {
-		return repos, errors.Wrapf(err, "failed to read directory %s", path)
-	}
-
-	for _, entry := range entries {
-		if !entry.IsDir() {
-			continue
-		}
-
-		// Skip hidden directories and common non-code directories
-		name := entry.Name()
-		if strings.HasPrefix(name, ".") && name != ".git" {
-			continue
-		}
-		if name == "node_modules" || name == "vendor" || name == "target" {
-			continue
-		}
-
-		subPath := filepath.Join(path, name)
-		subRepos, err := rd.scanDirectory(ctx, subPath, recursive, maxDepth, currentDepth+1)
-		if err != nil {
-			output.LogWarn(
-				fmt.Sprintf("Failed to scan subdirectory %s: %v", subPath, err),
-				"Failed to scan subdirectory",
-				"error", err,
-				"path", subPath,
-			)
-			continue
-		}
-		repos = append(repos, subRepos...)
-	}
-
-	return repos, nil
-}
-
 // isGitRepository checks if a directory is a git repository
 func (rd *RepositoryDiscoverer) isGitRepository(path string) bool {
 	gitDir := filepath.Join(path, ".git")
-	if stat, err := os.Stat(gitDir); err == nil {
+	if stat, err := fsOrDefault(rd.FS).Stat(gitDir); err == nil {
 		return stat.IsDir() || stat.Mode().IsRegular() // .git can be a file in worktrees
 	}
 	return false
@@ -207,6 +163,8 @@ func (rd *RepositoryDiscoverer) analyzeRepository(ctx context.Context, path stri
 		repo.LastCommit = lastCommit
 	}
 
+	repo.Description = readRepositoryDescription(path)
+
 	return repo, nil
 }
 
@@ -230,7 +188,7 @@ func (rd *RepositoryDiscoverer) categorizeRepository(path string) []string {
 	}
 
 	for file, category := range files {
-		if _, err := os.Stat(filepath.Join(path, file)); err == nil {
+		if _, err := fsOrDefault(rd.FS).Stat(filepath.Join(path, file)); err == nil {
 			categories = append(categories, category)
 		}
 	}
@@ -247,7 +205,7 @@ func (rd *RepositoryDiscoverer) categorizeRepository(path string) []string {
 	}
 
 	for dir, category := range dirs {
-		if stat, err := os.Stat(filepath.Join(path, dir)); err == nil && stat.IsDir() {
+		if stat, err := fsOrDefault(rd.FS).Stat(filepath.Join(path, dir)); err == nil && stat.IsDir() {
 			categories = append(categories, category)
 		}
 	}
@@ -261,35 +219,21 @@ func (rd *RepositoryDiscoverer) categorizeRepository(path string) []string {
 
 // Git command helpers
 func (rd *RepositoryDiscoverer) getGitRemoteURL(ctx context.Context, path string) (string, error) {
-	cmd := exec.CommandContext(ctx, "git", "remote", "get-url", "origin")
-	cmd.Dir = path
-	output, err := cmd.Output()
-	if err != nil {
-		return "", err
-	}
-	return strings.TrimSpace(string(output)), nil
+	return gitOutput(ctx, rd.Runner, path, "remote", "get-url", "origin")
 }
 
 func (rd *RepositoryDiscoverer) getGitCurrentBranch(ctx context.Context, path string) (string, error) {
-	cmd := exec.CommandContext(ctx, "git", "branch", "--show-current")
-	cmd.Dir = path
-	output, err := cmd.Output()
-	if err != nil {
-		return "", err
-	}
-	return strings.TrimSpace(string(output)), nil
+	return gitOutput(ctx, rd.Runner, path, "branch", "--show-current")
 }
 
 func (rd *RepositoryDiscoverer) getGitBranches(ctx context.Context, path string) ([]string, error) {
-	cmd := exec.CommandContext(ctx, "git", "branch", "-a")
-	cmd.Dir = path
-	output, err := cmd.Output()
+	output, err := gitOutput(ctx, rd.Runner, path, "branch", "-a")
 	if err != nil {
 		return nil, err
 	}
 
 	var branches []string
-	lines := strings.Split(string(output), "\n")
+	lines := strings.Split(output, "\n")
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
 		if line == "" {
@@ -307,15 +251,13 @@ func (rd *RepositoryDiscoverer) getGitBranches(ctx context.Context, path string)
 }
 
 func (rd *RepositoryDiscoverer) getGitTags(ctx context.Context, path string) ([]string, error) {
-	cmd := exec.CommandContext(ctx, "git", "tag", "-l")
-	cmd.Dir = path
-	output, err := cmd.Output()
+	output, err := gitOutput(ctx, rd.Runner, path, "tag", "-l")
 	if err != nil {
 		return nil, err
 	}
 
 	var tags []string
-	lines := strings.Split(string(output), "\n")
+	lines := strings.Split(output, "\n")
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
 		if line != "" {
@@ -327,13 +269,7 @@ func (rd *RepositoryDiscoverer) getGitTags(ctx context.Context, path string) ([]
 }
 
 func (rd *RepositoryDiscoverer) getGitLastCommit(ctx context.Context, path string) (string, error) {
-	cmd := exec.CommandContext(ctx, "git", "log", "-1", "--pretty=format:%H %s")
-	cmd.Dir = path
-	output, err := cmd.Output()
-	if err != nil {
-		return "", err
-	}
-	return strings.TrimSpace(string(output)), nil
+	return gitOutput(ctx, rd.Runner, path, "log", "-1", "--pretty=format:%H %s")
 }
 
 // mergeRepositories merges existing repositories with newly discovered ones
@@ -345,8 +281,27 @@ func (rd *RepositoryDiscoverer) mergeRepositories(existing, discovered []Reposit
 		repoMap[repo.Path] = repo
 	}
 
+	// Entries whose path is gone are replaced by a discovered repository with the same remote,
+	// so a moved repository is updated rather than registered twice
+	moved := make(map[string]Repository)
+	for _, repo := range existing {
+		if repo.RemoteURL == "" {
+			continue
+		}
+		if _, err := fsOrDefault(rd.FS).Stat(repo.Path); os.IsNotExist(err) {
+			moved[NormalizeRemoteURL(repo.RemoteURL)] = repo
+		}
+	}
+
 	// Update with discovered repositories
 	for _, repo := range discovered {
+		if old, ok := moved[NormalizeRemoteURL(repo.RemoteURL)]; ok && repo.RemoteURL != "" {
+			if _, registered := repoMap[repo.Path]; !registered {
+				delete(repoMap, old.Path)
+				delete(moved, NormalizeRemoteURL(repo.RemoteURL))
+				repo.Name = old.Name
+			}
+		}
 		repoMap[repo.Path] = repo
 	}
 
@@ -359,6 +314,88 @@ func (rd *RepositoryDiscoverer) mergeRepositories(existing, discovered []Reposit
 	return result
 }
 
+// GetAliases returns the repository aliases of the registry
+func (rd *RepositoryDiscoverer) GetAliases() map[string]string {
+	return rd.registry.Aliases
+}
+
+// ResolveAlias returns the repository name an alias stands for. Real repository names take
+// precedence over aliases, and unknown names are returned unchanged.
+func (rd *RepositoryDiscoverer) ResolveAlias(name string) string {
+	for _, repo := range rd.registry.Repositories {
+		if repo.Name == name {
+			return name
+		}
+	}
+	if target, ok := rd.registry.Aliases[name]; ok {
+		return target
+	}
+	return name
+}
+
+// SetAlias adds or updates an alias for a repository. Aliases cannot shadow a repository name
+// and must point at a repository of the registry.
+func (rd *RepositoryDiscoverer) SetAlias(alias, repoName string) error {
+	if alias == "" || strings.ContainsAny(alias, ", \t/") {
+		return errors.Errorf("invalid alias '%s'", alias)
+	}
+
+	found := false
+	for _, repo := range rd.registry.Repositories {
+		if repo.Name == alias {
+			return errors.Errorf("alias '%s' conflicts with the repository of the same name at %s", alias, repo.Path)
+		}
+		if repo.Name == repoName {
+			found = true
+		}
+	}
+	if !found {
+		return errors.Errorf("repository '%s' not found in registry", repoName)
+	}
+
+	if rd.registry.Aliases == nil {
+		rd.registry.Aliases = make(map[string]string)
+	}
+	rd.registry.Aliases[alias] = repoName
+	rd.index = nil
+	return nil
+}
+
+// RemoveAlias deletes an alias
+func (rd *RepositoryDiscoverer) RemoveAlias(alias string) error {
+	if _, ok := rd.registry.Aliases[alias]; !ok {
+		return errors.Errorf("alias '%s' not found", alias)
+	}
+	delete(rd.registry.Aliases, alias)
+	rd.index = nil
+	return nil
+}
+
+// AliasConflicts returns the aliases shadowed by a repository of the same name, which makes
+// them unusable, and aliases whose target is no longer in the registry
+func (rd *RepositoryDiscoverer) AliasConflicts() []string {
+	names := make(map[string]bool)
+	for _, repo := range rd.registry.Repositories {
+		names[repo.Name] = true
+	}
+
+	var conflicts []string
+	for _, alias := range sortedKeys(rd.registry.Aliases) {
+		target := rd.registry.Aliases[alias]
+		if names[alias] {
+			conflicts = append(conflicts, fmt.Sprintf("alias '%s' (-> %s) is shadowed by a repository of the same name", alias, target))
+		} else if !names[target] {
+			conflicts = append(conflicts, fmt.Sprintf("alias '%s' points to unknown repository '%s'", alias, target))
+		}
+	}
+	return conflicts
+}
+
+// LastScan returns when repositories were last discovered
+func (rd *RepositoryDiscoverer) LastScan() time.Time {
+	return rd.registry.LastScan
+}
+
 // GetRepositories returns all discovered repositories
 func (rd *RepositoryDiscoverer) GetRepositories() []Repository {
 	return rd.registry.Repositories
@@ -0,0 +1,308 @@
+package wsm
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+)
+
+// defaultDiscoveryExcludes are directory names never scanned: dependency and build directories
+// that hold many files and no repositories of their own
+var defaultDiscoveryExcludes = []string{"node_modules", "vendor", "target"}
+
+// progressInterval is the minimum time between two progress reports of a discovery walk
+const progressInterval = 100 * time.Millisecond
+
+// DiscoveryConfig tunes how 'wsm discover' walks directories
+type DiscoveryConfig struct {
+	// MaxDepth is the default maximum depth below each path (3 when unset)
+	MaxDepth int `json:"max_depth,omitempty" yaml:"max_depth,omitempty"`
+	// FollowSymlinks descends into symlinked directories; each directory is still scanned once
+	FollowSymlinks bool `json:"follow_symlinks,omitempty" yaml:"follow_symlinks,omitempty"`
+	// Concurrency is the number of directories read and repositories analyzed at the same time
+	// (the number of CPUs, at least 4, when unset)
+	Concurrency int `json:"concurrency,omitempty" yaml:"concurrency,omitempty"`
+	// Exclude are globs of directories skipped below every path
+	Exclude []string `json:"exclude,omitempty" yaml:"exclude,omitempty"`
+	// Paths holds rules for single discovery paths, keyed by path (~ is expanded)
+	Paths map[string]DiscoveryPathRules `json:"paths,omitempty" yaml:"paths,omitempty"`
+}
+
+// DiscoveryPathRules are the discovery settings of one path
+type DiscoveryPathRules struct {
+	MaxDepth int `json:"max_depth,omitempty" yaml:"max_depth,omitempty"`
+	// Include restricts registration to repositories matching one of these globs
+	Include []string `json:"include,omitempty" yaml:"include,omitempty"`
+	// Exclude are globs of directories skipped below this path, in addition to the global ones
+	Exclude []string `json:"exclude,omitempty" yaml:"exclude,omitempty"`
+}
+
+// DiscoveryOptions control a single discovery run
+type DiscoveryOptions struct {
+	Recursive      bool
+	MaxDepth       int
+	FollowSymlinks bool
+	Concurrency    int
+	Include        []string
+	Exclude        []string
+	// Progress is called at most every 100ms with the directories scanned and repositories found,
+	// and once more with done set when the walk is over
+	Progress func(dirs, repos int, done bool)
+}
+
+// DiscoveryOptionsFor returns the options for scanning root: the per-path rules of the
+// configuration applied over its global settings. maxDepth overrides both when positive.
+func (c DiscoveryConfig) DiscoveryOptionsFor(root string, recursive bool, maxDepth int) DiscoveryOptions {
+	opts := DiscoveryOptions{
+		Recursive:      recursive,
+		MaxDepth:       c.MaxDepth,
+		FollowSymlinks: c.FollowSymlinks,
+		Concurrency:    c.Concurrency,
+		Exclude:        append([]string{}, c.Exclude...),
+	}
+	for configured, rules := range c.Paths {
+		expanded, err := expandHomePath(configured)
+		if err != nil || filepath.Clean(expanded) != filepath.Clean(root) {
+			continue
+		}
+		if rules.MaxDepth > 0 {
+			opts.MaxDepth = rules.MaxDepth
+		}
+		opts.Include = append(opts.Include, rules.Include...)
+		opts.Exclude = append(opts.Exclude, rules.Exclude...)
+	}
+	if maxDepth > 0 {
+		opts.MaxDepth = maxDepth
+	}
+	if opts.MaxDepth <= 0 {
+		opts.MaxDepth = 3
+	}
+	return opts
+}
+
+// discoveryWalk scans one root directory with a bounded number of concurrent directory reads
+// and repository analyses
+type discoveryWalk struct {
+	rd   *RepositoryDiscoverer
+	ctx  context.Context
+	root string
+	opts DiscoveryOptions
+	sem  chan struct{}
+	wg   sync.WaitGroup
+
+	mu           sync.Mutex
+	repos        []Repository
+	visited      map[string]bool
+	dirs         int
+	lastProgress time.Time
+}
+
+// walkRepositories scans root for git repositories according to opts
+func (rd *RepositoryDiscoverer) walkRepositories(ctx context.Context, root string, opts DiscoveryOptions) []Repository {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = max(runtime.NumCPU(), 4)
+	}
+	walk := &discoveryWalk{
+		rd:      rd,
+		ctx:     ctx,
+		root:    root,
+		opts:    opts,
+		sem:     make(chan struct{}, concurrency),
+		visited: map[string]bool{},
+	}
+
+	walk.wg.Add(1)
+	go walk.scan(root, 0)
+	walk.wg.Wait()
+
+	if opts.Progress != nil {
+		opts.Progress(walk.dirs, len(walk.repos), true)
+	}
+	sort.Slice(walk.repos, func(i, j int) bool { return walk.repos[i].Path < walk.repos[j].Path })
+	return walk.repos
+}
+
+func (w *discoveryWalk) scan(dir string, depth int) {
+	defer w.wg.Done()
+	if w.ctx.Err() != nil {
+		return
+	}
+
+	// With symlinks followed, a directory can be reached by several paths; it is scanned once and
+	// its repository registered under its real path
+	repoPath := dir
+	if w.opts.FollowSymlinks {
+		real, err := filepath.EvalSymlinks(dir)
+		if err != nil || !w.visit(real) {
+			return
+		}
+		repoPath = real
+	}
+
+	w.sem <- struct{}{}
+	isRepo := w.rd.isGitRepository(dir)
+	var repo *Repository
+	if isRepo && w.included(dir) {
+		var err error
+		repo, err = w.rd.analyzeRepository(w.ctx, repoPath)
+		if err != nil {
+			output.LogWarn(
+				fmt.Sprintf("Failed to analyze repository at %s: %v", dir, err),
+				"Failed to analyze repository",
+				"error", err,
+				"path", dir,
+			)
+		}
+	}
+	var entries []fs.DirEntry
+	var readErr error
+	if w.opts.Recursive && depth < w.opts.MaxDepth {
+		entries, readErr = fsOrDefault(w.rd.FS).ReadDir(dir)
+	}
+	<-w.sem
+
+	w.record(repo)
+	if readErr != nil {
+		output.LogWarn(
+			fmt.Sprintf("Failed to scan subdirectory %s: %v", dir, readErr),
+			"Failed to scan subdirectory",
+			"error", readErr,
+			"path", dir,
+		)
+		return
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		// Skip hidden directories and common non-code directories
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		subPath := filepath.Join(dir, name)
+		if !w.isDirectory(entry, subPath) || w.excluded(subPath) {
+			continue
+		}
+		w.wg.Add(1)
+		go w.scan(subPath, depth+1)
+	}
+}
+
+// isDirectory reports whether an entry is a directory to descend into, following symlinks to
+// directories when configured
+func (w *discoveryWalk) isDirectory(entry fs.DirEntry, path string) bool {
+	if entry.Type()&fs.ModeSymlink == 0 {
+		return entry.IsDir()
+	}
+	if !w.opts.FollowSymlinks {
+		return false
+	}
+	info, err := fsOrDefault(w.rd.FS).Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// visit marks a real directory path as scanned and reports whether it was new
+func (w *discoveryWalk) visit(real string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.visited[real] {
+		return false
+	}
+	w.visited[real] = true
+	return true
+}
+
+func (w *discoveryWalk) record(repo *Repository) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.dirs++
+	if repo != nil {
+		w.repos = append(w.repos, *repo)
+	}
+	if w.opts.Progress != nil && time.Since(w.lastProgress) >= progressInterval {
+		w.lastProgress = time.Now()
+		w.opts.Progress(w.dirs, len(w.repos), false)
+	}
+}
+
+// excluded reports whether a directory matches one of the exclude globs
+func (w *discoveryWalk) excluded(dir string) bool {
+	rel := w.relative(dir)
+	for _, pattern := range defaultDiscoveryExcludes {
+		if matchPathGlob(pattern, rel) {
+			return true
+		}
+	}
+	for _, pattern := range w.opts.Exclude {
+		if matchPathGlob(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// included reports whether a repository may be registered: always without include globs,
+// otherwise when it matches one of them
+func (w *discoveryWalk) included(dir string) bool {
+	if len(w.opts.Include) == 0 {
+		return true
+	}
+	rel := w.relative(dir)
+	for _, pattern := range w.opts.Include {
+		if matchPathGlob(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *discoveryWalk) relative(dir string) string {
+	rel, err := filepath.Rel(w.root, dir)
+	if err != nil {
+		return filepath.ToSlash(dir)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// matchPathGlob matches a slash-separated relative path, e.g. below a discovery root. Patterns
+// without a slash match the last element at any depth (node_modules, *.bak); other
+// patterns match the whole path, with ** standing for any number of directories
+// (archive/**, **/testdata, go-go-golems/*).
+func matchPathGlob(pattern, rel string) bool {
+	pattern = strings.Trim(filepath.ToSlash(pattern), "/")
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(rel))
+		return ok
+	}
+	return matchGlobSegments(strings.Split(pattern, "/"), strings.Split(rel, "/"))
+}
+
+func matchGlobSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchGlobSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+		return false
+	}
+	return matchGlobSegments(pattern[1:], segments[1:])
+}
@@ -0,0 +1,113 @@
+package wsm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestMatchPathGlob(t *testing.T) {
+	tests := []struct {
+		pattern, rel string
+		want         bool
+	}{
+		{"node_modules", "node_modules", true},
+		{"node_modules", "web/node_modules", true},
+		{"node_modules", "node_modules_old", false},
+		{"*.bak", "old/site.bak", true},
+		{"archive/**", "archive", true},
+		{"archive/**", "archive/2019/tool", true},
+		{"archive/**", "src/archive/tool", false},
+		{"**/testdata", "testdata", true},
+		{"**/testdata", "a/b/testdata", true},
+		{"**/testdata", "a/testdata/x", false},
+		{"go-go-golems/*", "go-go-golems/glazed", true},
+		{"go-go-golems/*", "go-go-golems/glazed/cmd", false},
+		{"/go-go-golems/*/", "go-go-golems/glazed", true},
+		{"a/**/z", "a/z", true},
+		{"a/**/z", "a/b/c/z", true},
+	}
+	for _, tt := range tests {
+		if got := matchPathGlob(tt.pattern, tt.rel); got != tt.want {
+			t.Errorf("matchPathGlob(%s, %s) = %v, want %v", tt.pattern, tt.rel, got, tt.want)
+		}
+	}
+}
+
+func TestDiscoveryOptionsFor(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	config := DiscoveryConfig{
+		MaxDepth:       2,
+		FollowSymlinks: true,
+		Exclude:        []string{"*.bak"},
+		Paths: map[string]DiscoveryPathRules{
+			"~/code":     {MaxDepth: 5, Include: []string{"go-go-golems/*"}, Exclude: []string{"archive/**"}},
+			"/srv/repos": {Exclude: []string{"mirrors"}},
+		},
+	}
+
+	opts := config.DiscoveryOptionsFor(filepath.Join(home, "code")+"/", true, 0)
+	if opts.MaxDepth != 5 || !opts.FollowSymlinks || !opts.Recursive ||
+		!slices.Equal(opts.Include, []string{"go-go-golems/*"}) || !slices.Equal(opts.Exclude, []string{"*.bak", "archive/**"}) {
+		t.Errorf("options for ~/code = %+v", opts)
+	}
+	if opts := config.DiscoveryOptionsFor("/srv/repos", false, 0); opts.MaxDepth != 2 || !slices.Equal(opts.Exclude, []string{"*.bak", "mirrors"}) {
+		t.Errorf("options for /srv/repos = %+v", opts)
+	}
+	if opts := config.DiscoveryOptionsFor(filepath.Join(home, "code"), true, 1); opts.MaxDepth != 1 {
+		t.Errorf("--max-depth should override the path rules, got %d", opts.MaxDepth)
+	}
+	if opts := (DiscoveryConfig{}).DiscoveryOptionsFor("/tmp", true, 0); opts.MaxDepth != 3 || len(opts.Include)+len(opts.Exclude) != 0 {
+		t.Errorf("default options = %+v", opts)
+	}
+
+	// The configured globs are not shared between calls
+	opts = config.DiscoveryOptionsFor("/srv/repos", true, 0)
+	opts.Exclude[0] = "changed"
+	if config.Exclude[0] != "*.bak" {
+		t.Error("DiscoveryOptionsFor shares the exclude globs of the configuration")
+	}
+}
+
+func TestWalkRepositories(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{"go-go-golems/glazed", "go-go-golems/archive/old", "other/tool", "web/node_modules/dep", "deep/a/b/c/repo"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+		testGit(t, filepath.Join(root, dir), "init", "-q")
+	}
+	if err := os.Symlink(filepath.Join(root, "other"), filepath.Join(root, "linked")); err != nil {
+		t.Fatal(err)
+	}
+
+	walk := func(opts DiscoveryOptions) []string {
+		var paths []string
+		for _, repo := range NewRepositoryDiscoverer(filepath.Join(root, "registry.json")).walkRepositories(context.Background(), root, opts) {
+			rel, _ := filepath.Rel(root, repo.Path)
+			paths = append(paths, filepath.ToSlash(rel))
+		}
+		return paths
+	}
+
+	got := walk(DiscoveryOptions{Recursive: true, MaxDepth: 3, Exclude: []string{"**/archive"}})
+	if want := []string{"go-go-golems/glazed", "other/tool"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("walk = %q, want %q", got, want)
+	}
+	got = walk(DiscoveryOptions{Recursive: true, MaxDepth: 5, Include: []string{"go-go-golems/**"}})
+	if want := []string{"go-go-golems/archive/old", "go-go-golems/glazed"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("walk with include = %q, want %q", got, want)
+	}
+	// A symlinked directory is followed once and registered under its real path
+	got = walk(DiscoveryOptions{Recursive: true, MaxDepth: 2, FollowSymlinks: true, Include: []string{"*/tool"}})
+	if want := []string{"other/tool"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("walk following symlinks = %q, want %q", got, want)
+	}
+}
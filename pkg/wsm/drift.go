@@ -0,0 +1,257 @@
+package wsm
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/pkg/errors"
+)
+
+// Default thresholds of CheckDrift
+const (
+	DefaultDriftMaxBehind = 100
+	DefaultDriftMaxAge    = 14 * 24 * time.Hour
+)
+
+// Ways CatchUp brings a drifted branch up to date
+const (
+	CatchUpRebase = "rebase"
+	CatchUpMerge  = "merge"
+)
+
+// DriftConfig sets how far the branch of a workspace repository may fall behind the default branch
+// of the repository before 'wsm drift' flags it
+type DriftConfig struct {
+	// MaxBehind is the number of commits of the default branch a branch may lack (default 100)
+	MaxBehind int `json:"max_behind,omitempty" yaml:"max_behind,omitempty"`
+	// MaxAge is how old the oldest commit of the default branch a branch lacks may be, e.g. 14d
+	// (default) or 72h
+	MaxAge string `json:"max_age,omitempty" yaml:"max_age,omitempty"`
+}
+
+// Thresholds returns the limits, with the defaults for those not set
+func (c DriftConfig) Thresholds() (int, time.Duration, error) {
+	maxBehind := c.MaxBehind
+	if maxBehind == 0 {
+		maxBehind = DefaultDriftMaxBehind
+	}
+	if maxBehind < 0 {
+		return 0, 0, errors.Errorf("invalid drift.max_behind %d: expected a positive number of commits", c.MaxBehind)
+	}
+	maxAge := DefaultDriftMaxAge
+	if c.MaxAge != "" {
+		d, err := ParseAge(c.MaxAge)
+		if err != nil {
+			return 0, 0, errors.Errorf("invalid drift.max_age '%s': expected a duration such as 14d or 72h", c.MaxAge)
+		}
+		maxAge = d
+	}
+	return maxBehind, maxAge, nil
+}
+
+// Validate checks the thresholds
+func (c DriftConfig) Validate() error {
+	_, _, err := c.Thresholds()
+	return err
+}
+
+// RepositoryDrift is how far the branch of a workspace repository is behind the default branch
+type RepositoryDrift struct {
+	Repository    string `json:"repository"`
+	Path          string `json:"path"`
+	Branch        string `json:"branch"`
+	DefaultBranch string `json:"default_branch"`
+	// Behind are the commits of the default branch the branch lacks, Ahead those of the branch the
+	// default branch lacks
+	Behind int `json:"behind"`
+	Ahead  int `json:"ahead"`
+	// Since is the date of the oldest commit of the default branch the branch lacks
+	Since *time.Time `json:"since,omitempty"`
+	// Reasons are the thresholds the branch exceeds; a branch with reasons has drifted
+	Reasons []string `json:"reasons,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// Drifted reports whether the branch exceeds a threshold
+func (d RepositoryDrift) Drifted() bool {
+	return len(d.Reasons) > 0
+}
+
+// WorkspaceDrift is the drift of the repositories of a workspace
+type WorkspaceDrift struct {
+	Workspace    string            `json:"workspace"`
+	Repositories []RepositoryDrift `json:"repositories"`
+}
+
+// Drifted returns the repositories whose branch exceeds a threshold
+func (d *WorkspaceDrift) Drifted() []RepositoryDrift {
+	var drifted []RepositoryDrift
+	for _, repo := range d.Repositories {
+		if repo.Drifted() {
+			drifted = append(drifted, repo)
+		}
+	}
+	return drifted
+}
+
+// CheckDrift compares the branch of each active repository of the workspace with the default
+// branch of the repository (origin/HEAD, origin/main or origin/master, then main or master) and flags the
+// branches that lack more commits, or older ones, than the thresholds allow. With fetch, origin is
+// fetched first so the comparison is with the latest default branch.
+func (wm *WorkspaceManager) CheckDrift(ctx context.Context, workspace *Workspace, config DriftConfig, fetch bool) (*WorkspaceDrift, error) {
+	maxBehind, maxAge, err := config.Thresholds()
+	if err != nil {
+		return nil, err
+	}
+
+	ageLimit := config.MaxAge
+	if ageLimit == "" {
+		ageLimit = "14d"
+	}
+
+	result := &WorkspaceDrift{Workspace: workspace.Name}
+	now := time.Now()
+	for _, repo := range workspace.ActiveRepositories() {
+		drift := RepositoryDrift{Repository: repo.Name, Path: filepath.Join(workspace.Path, repo.Name)}
+		if err := wm.measureDrift(ctx, &drift, fetch); err != nil {
+			drift.Error = err.Error()
+			result.Repositories = append(result.Repositories, drift)
+			continue
+		}
+
+		if drift.Behind > maxBehind {
+			drift.Reasons = append(drift.Reasons, fmt.Sprintf("%d commits behind (limit %d)", drift.Behind, maxBehind))
+		}
+		if drift.Since != nil {
+			if age := now.Sub(*drift.Since); age > maxAge {
+				drift.Reasons = append(drift.Reasons, fmt.Sprintf("missing commits since %s (limit %s)", drift.Since.Format("2006-01-02"), ageLimit))
+			}
+		}
+		result.Repositories = append(result.Repositories, drift)
+	}
+	return result, nil
+}
+
+func (wm *WorkspaceManager) measureDrift(ctx context.Context, drift *RepositoryDrift, fetch bool) error {
+	if fetch {
+		if _, err := wm.networkGit(ctx, drift.Repository, OperationFetch, drift.Path, "fetch", "--quiet", "origin"); err != nil {
+			output.PrintWarning("Failed to fetch origin in %s: %v", drift.Repository, err)
+		}
+	}
+
+	branch, err := gitOutput(ctx, wm.runner(), drift.Path, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return errors.Wrap(err, "failed to get the current branch")
+	}
+	drift.Branch = branch
+	drift.DefaultBranch = wm.RepositoryDefaultBranch(ctx, drift.Path)
+	if drift.DefaultBranch == "" {
+		return errors.New("no default branch found")
+	}
+
+	counts, err := gitOutput(ctx, wm.runner(), drift.Path, "rev-list", "--left-right", "--count", "HEAD..."+drift.DefaultBranch)
+	if err != nil {
+		return errors.Wrapf(err, "failed to compare with %s", drift.DefaultBranch)
+	}
+	fields := strings.Fields(counts)
+	if len(fields) != 2 {
+		return errors.Errorf("unexpected output of git rev-list: %s", counts)
+	}
+	drift.Ahead, _ = strconv.Atoi(fields[0])
+	drift.Behind, _ = strconv.Atoi(fields[1])
+	if drift.Behind == 0 {
+		return nil
+	}
+
+	dates, err := gitOutput(ctx, wm.runner(), drift.Path, "log", "--format=%ct", "HEAD.."+drift.DefaultBranch)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list the commits of %s", drift.DefaultBranch)
+	}
+	lines := strings.Split(dates, "\n")
+	if seconds, err := strconv.ParseInt(lines[len(lines)-1], 10, 64); err == nil {
+		since := time.Unix(seconds, 0)
+		drift.Since = &since
+	}
+	return nil
+}
+
+// CatchUpConflictError is returned when catching up conflicts; the rebase or merge was aborted
+type CatchUpConflictError struct {
+	Repository    string
+	Mode          string
+	DefaultBranch string
+}
+
+func (e *CatchUpConflictError) Error() string {
+	return fmt.Sprintf("the %s with %s conflicts and was aborted; resolve it by hand", e.Mode, e.DefaultBranch)
+}
+
+// CatchUp brings a drifted branch up to date by rebasing it onto the default branch or merging the
+// default branch into it. A rebase or merge that conflicts is aborted, leaving the repository as it
+// was, and returns a *CatchUpConflictError; other failures are returned with the output of git.
+// Uncommitted changes are stashed first and restored afterwards with autoStash, and make CatchUp
+// fail without it. Frozen repositories and linked checkouts are never changed.
+func (wm *WorkspaceManager) CatchUp(ctx context.Context, workspace *Workspace, drift RepositoryDrift, mode string, autoStash bool) error {
+	if err := workspace.RequireWorktrees("catching up"); err != nil {
+		return err
+	}
+	if workspace.IsFrozen(drift.Repository) {
+		return errors.Errorf("repository '%s' is frozen; unfreeze it to catch up", drift.Repository)
+	}
+	var args, abort []string
+	switch mode {
+	case CatchUpRebase:
+		args = []string{"rebase", drift.DefaultBranch}
+		abort = []string{"rebase", "--abort"}
+	case CatchUpMerge:
+		args = []string{"merge", "--no-edit", drift.DefaultBranch}
+		abort = []string{"merge", "--abort"}
+	default:
+		return errors.Errorf("invalid catch-up mode '%s': expected %s or %s", mode, CatchUpRebase, CatchUpMerge)
+	}
+
+	var stash *Stash
+	if HasUncommittedChanges(ctx, drift.Path) {
+		if !autoStash {
+			return errors.New("uncommitted changes; commit or stash them, or use --autostash")
+		}
+		var err error
+		if stash, err = AutoStash(ctx, drift.Repository, drift.Path, mode); err != nil {
+			return err
+		}
+	}
+
+	output.LogInfo(
+		fmt.Sprintf("Catching up '%s' with %s (%s)", drift.Repository, drift.DefaultBranch, mode),
+		"Catching up with the default branch",
+		"repo", drift.Repository,
+		"default_branch", drift.DefaultBranch,
+		"mode", mode,
+	)
+	out, runErr := wm.runner().CombinedOutput(ctx, drift.Path, "git", args...)
+	if runErr != nil {
+		// Only a rebase or merge that stopped on conflicts is left in progress; git refused the
+		// others, e.g. for an unknown default branch, before changing anything
+		if ConflictOperation(ctx, drift.Path) != "" {
+			if _, err := wm.runner().CombinedOutput(ctx, drift.Path, "git", abort...); err != nil {
+				output.PrintWarning("Failed to abort the %s in %s: %v", mode, drift.Repository, err)
+			}
+			runErr = &CatchUpConflictError{Repository: drift.Repository, Mode: mode, DefaultBranch: drift.DefaultBranch}
+		} else {
+			runErr = errors.Wrapf(runErr, "git %s: %s", strings.Join(args, " "), strings.TrimSpace(string(out)))
+		}
+	}
+
+	if stash != nil {
+		conflicts, err := stash.Restore(ctx)
+		if err != nil || conflicts {
+			return errors.Errorf("restoring stashed changes failed, they are kept in stash %s", stash.Short())
+		}
+	}
+	return runErr
+}
@@ -0,0 +1,139 @@
+package wsm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// newDriftRepo creates a workspace whose repository lib is on feature, one commit behind main.
+// With conflicting, both branches change the same line of README.md.
+func newDriftRepo(t *testing.T, conflicting bool) (*Workspace, RepositoryDrift) {
+	t.Helper()
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	root := t.TempDir()
+	dir := filepath.Join(root, "lib")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	testGit(t, dir, "init", "--quiet")
+	write("README.md", "base\n")
+	testGit(t, dir, "add", ".")
+	testGit(t, dir, "commit", "--quiet", "-m", "Initial")
+	testGit(t, dir, "checkout", "--quiet", "-b", "feature")
+	if conflicting {
+		write("README.md", "feature\n")
+	} else {
+		write("feature.txt", "feature\n")
+	}
+	testGit(t, dir, "add", ".")
+	testGit(t, dir, "commit", "--quiet", "-m", "Feature")
+	testGit(t, dir, "checkout", "--quiet", "main")
+	write("README.md", "main\n")
+	testGit(t, dir, "commit", "--quiet", "-am", "Main")
+	testGit(t, dir, "checkout", "--quiet", "feature")
+
+	workspace := &Workspace{Name: "ws", Path: root, Repositories: []Repository{{Name: "lib", Path: dir}}}
+	return workspace, RepositoryDrift{Repository: "lib", Path: dir, Branch: "feature", DefaultBranch: "main", Behind: 1}
+}
+
+func TestCatchUp(t *testing.T) {
+	ctx := context.Background()
+	wm := newTestWorkspaceManager(t)
+
+	for _, mode := range []string{CatchUpRebase, CatchUpMerge} {
+		t.Run(mode, func(t *testing.T) {
+			workspace, drift := newDriftRepo(t, false)
+			if err := wm.CatchUp(ctx, workspace, drift, mode, false); err != nil {
+				t.Fatalf("CatchUp failed: %v", err)
+			}
+			if behind := testGit(t, drift.Path, "rev-list", "--count", "HEAD..main"); behind != "0" {
+				t.Errorf("%s commits behind main after catching up", behind)
+			}
+		})
+	}
+
+	t.Run("conflict is aborted", func(t *testing.T) {
+		workspace, drift := newDriftRepo(t, true)
+		head := testGit(t, drift.Path, "rev-parse", "HEAD")
+		err := wm.CatchUp(ctx, workspace, drift, CatchUpRebase, false)
+		var conflict *CatchUpConflictError
+		if !errors.As(err, &conflict) || conflict.Repository != "lib" {
+			t.Fatalf("expected a CatchUpConflictError, got %v", err)
+		}
+		if operation := ConflictOperation(ctx, drift.Path); operation != "" {
+			t.Errorf("the %s was left in progress", operation)
+		}
+		if got := testGit(t, drift.Path, "rev-parse", "HEAD"); got != head {
+			t.Errorf("HEAD moved from %s to %s", head, got)
+		}
+	})
+
+	t.Run("other failures are not conflicts", func(t *testing.T) {
+		workspace, drift := newDriftRepo(t, false)
+		drift.DefaultBranch = "origin/missing"
+		err := wm.CatchUp(ctx, workspace, drift, CatchUpMerge, false)
+		var conflict *CatchUpConflictError
+		if err == nil || errors.As(err, &conflict) || !strings.Contains(err.Error(), "origin/missing") {
+			t.Errorf("expected the git error, got %v", err)
+		}
+	})
+
+	t.Run("frozen repository", func(t *testing.T) {
+		workspace, drift := newDriftRepo(t, false)
+		workspace.Frozen = []string{"lib"}
+		head := testGit(t, drift.Path, "rev-parse", "HEAD")
+		if err := wm.CatchUp(ctx, workspace, drift, CatchUpRebase, false); err == nil || !strings.Contains(err.Error(), "frozen") {
+			t.Errorf("expected frozen repositories to be refused, got %v", err)
+		}
+		if got := testGit(t, drift.Path, "rev-parse", "HEAD"); got != head {
+			t.Errorf("the frozen repository was changed")
+		}
+	})
+
+	t.Run("uncommitted changes", func(t *testing.T) {
+		workspace, drift := newDriftRepo(t, false)
+		if err := os.WriteFile(filepath.Join(drift.Path, "feature.txt"), []byte("changed\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := wm.CatchUp(ctx, workspace, drift, CatchUpRebase, false); err == nil || !strings.Contains(err.Error(), "uncommitted") {
+			t.Fatalf("expected uncommitted changes to be refused, got %v", err)
+		}
+		if err := wm.CatchUp(ctx, workspace, drift, CatchUpRebase, true); err != nil {
+			t.Fatalf("CatchUp with autostash failed: %v", err)
+		}
+		if content, _ := os.ReadFile(filepath.Join(drift.Path, "feature.txt")); string(content) != "changed\n" {
+			t.Errorf("the stashed change was not restored: %q", content)
+		}
+	})
+}
+
+func TestCheckDriftSkipsFrozenRepositories(t *testing.T) {
+	workspace, _ := newDriftRepo(t, false)
+	wm := newTestWorkspaceManager(t)
+
+	drift, err := wm.CheckDrift(context.Background(), workspace, DriftConfig{MaxBehind: 1}, false)
+	if err != nil {
+		t.Fatalf("CheckDrift failed: %v", err)
+	}
+	if len(drift.Repositories) != 1 || drift.Repositories[0].Behind != 1 || drift.Repositories[0].DefaultBranch != "main" {
+		t.Errorf("drift = %+v", drift.Repositories)
+	}
+
+	workspace.Frozen = []string{"lib"}
+	if drift, err = wm.CheckDrift(context.Background(), workspace, DriftConfig{}, false); err != nil || len(drift.Repositories) != 0 {
+		t.Errorf("frozen repositories should not be checked, got %+v, %v", drift, err)
+	}
+}
@@ -0,0 +1,297 @@
+package wsm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/pkg/errors"
+)
+
+// Tools encrypting files in repositories
+const (
+	EncryptionGitCrypt = "git-crypt"
+	EncryptionBlackbox = "blackbox"
+)
+
+// EncryptionConfig configures the unlocking of git-crypt and blackbox repositories in new
+// workspaces
+type EncryptionConfig struct {
+	// Keys maps repository names to git-crypt key files (see 'git-crypt export-key'). Without one,
+	// the keys of the unlocked main clone and the GPG keys of the repository are tried.
+	Keys map[string]string `json:"keys,omitempty" yaml:"keys,omitempty"`
+	// Disabled leaves the repositories of new workspaces locked; 'wsm decrypt' unlocks them later
+	Disabled bool `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+}
+
+// EncryptionState tells whether a worktree has encrypted files and whether they are readable
+type EncryptionState struct {
+	// Tool is git-crypt or blackbox, empty when the worktree has no encrypted files
+	Tool string `json:"tool,omitempty"`
+	// Locked is set while the files are still encrypted
+	Locked bool `json:"locked,omitempty"`
+	// Files are the blackbox files that are not decrypted yet
+	Files []string `json:"files,omitempty"`
+}
+
+// DetectEncryption reads how the worktree at path is encrypted. A git-crypt worktree has a
+// .gitattributes file, at the top or in any directory, assigning the git-crypt filter; it is
+// unlocked once git-crypt stored its keys in the git directory of the worktree. A blackbox worktree
+// is unlocked once every registered file is decrypted. No command is run, so it is cheap enough
+// for status.
+func DetectEncryption(path string) EncryptionState {
+	if usesGitCrypt(path) {
+		state := EncryptionState{Tool: EncryptionGitCrypt, Locked: true}
+		if gitDir := worktreeGitDir(path); gitDir != "" {
+			if keys, err := os.ReadDir(filepath.Join(gitDir, "git-crypt", "keys")); err == nil && len(keys) > 0 {
+				state.Locked = false
+			}
+		}
+		return state
+	}
+
+	for _, dir := range []string{".blackbox", filepath.Join("keyrings", "live")} {
+		list, err := os.Open(filepath.Join(path, dir, "blackbox-files.txt"))
+		if err != nil {
+			continue
+		}
+		state := EncryptionState{Tool: EncryptionBlackbox}
+		scanner := bufio.NewScanner(list)
+		for scanner.Scan() {
+			file := strings.TrimSpace(scanner.Text())
+			if file == "" {
+				continue
+			}
+			if _, err := os.Stat(filepath.Join(path, file)); os.IsNotExist(err) {
+				state.Files = append(state.Files, file)
+			}
+		}
+		_ = list.Close()
+		state.Locked = len(state.Files) > 0
+		return state
+	}
+	return EncryptionState{}
+}
+
+// usesGitCrypt looks for a .gitattributes file assigning the git-crypt filter, skipping the git
+// directory and dependency directories, which never hold the attributes of the repository
+func usesGitCrypt(path string) bool {
+	found := false
+	_ = filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if entry.IsDir() {
+			if file != path && (entry.Name() == ".git" || entry.Name() == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.Name() != ".gitattributes" {
+			return nil
+		}
+		if attributes, err := os.ReadFile(file); err == nil && hasGitCryptFilter(attributes) {
+			found = true
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return found
+}
+
+// hasGitCryptFilter reports whether gitattributes assign the git-crypt filter, or the filter of
+// a named git-crypt key (filter=git-crypt-<key>), to a pattern
+func hasGitCryptFilter(attributes []byte) bool {
+	for _, line := range bytes.Split(attributes, []byte("\n")) {
+		fields := strings.Fields(string(line))
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		for _, attribute := range fields[1:] {
+			if attribute == "filter=git-crypt" || strings.HasPrefix(attribute, "filter=git-crypt-") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// worktreeGitDir returns the git directory of a clone or worktree, following the .git file of
+// linked worktrees
+func worktreeGitDir(path string) string {
+	dotGit := filepath.Join(path, ".git")
+	info, err := os.Stat(dotGit)
+	if err != nil {
+		return ""
+	}
+	if info.IsDir() {
+		return dotGit
+	}
+	data, err := os.ReadFile(dotGit)
+	if err != nil {
+		return ""
+	}
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:")
+	if !ok {
+		return ""
+	}
+	gitDir = strings.TrimSpace(gitDir)
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(path, gitDir)
+	}
+	return gitDir
+}
+
+// UnlockRepository decrypts the files of a repository of the workspace encrypted with git-crypt
+// or blackbox. git-crypt is unlocked with the key file of encryption.keys, else with the keys of
+// the main clone when it is unlocked, else with the GPG keys committed to the repository; when
+// none works and prompt is set, the user is asked for a key file. blackbox decrypts with GPG. It
+// returns the state of the worktree afterwards. The checkouts of linked workspaces are never
+// unlocked: git-crypt would store its keys in the registered clone.
+func (wm *WorkspaceManager) UnlockRepository(ctx context.Context, workspace *Workspace, repo Repository, prompt bool) (EncryptionState, error) {
+	start := time.Now()
+	worktree := filepath.Join(workspace.Path, repo.Name)
+	state := DetectEncryption(worktree)
+	if !state.Locked {
+		return state, nil
+	}
+	if err := workspace.RequireWorktrees("unlocking"); err != nil {
+		return state, err
+	}
+
+	var err error
+	switch state.Tool {
+	case EncryptionGitCrypt:
+		err = wm.unlockGitCrypt(ctx, worktree, repo, prompt)
+	case EncryptionBlackbox:
+		err = runUnlockCommand(ctx, worktree, "blackbox_decrypt_all_files")
+	}
+	if err != nil {
+		return state, err
+	}
+
+	RecordOperation("decrypt", workspace.Name, start, map[string]string{
+		"repo": repo.Name,
+		"tool": state.Tool,
+	})
+	return DetectEncryption(worktree), nil
+}
+
+func (wm *WorkspaceManager) unlockGitCrypt(ctx context.Context, worktree string, repo Repository, prompt bool) error {
+	if key := wm.config.Encryption.Keys[repo.Name]; key != "" {
+		path, err := expandHomePath(key)
+		if err != nil {
+			return err
+		}
+		return runUnlockCommand(ctx, worktree, "git-crypt", "unlock", path)
+	}
+
+	// The main clone of an unlocked repository holds its keys in the same format as export-key
+	if gitDir := worktreeGitDir(repo.Path); gitDir != "" {
+		keysDir := filepath.Join(gitDir, "git-crypt", "keys")
+		if entries, err := os.ReadDir(keysDir); err == nil && len(entries) > 0 {
+			var keys []string
+			for _, entry := range entries {
+				if !entry.IsDir() {
+					keys = append(keys, filepath.Join(keysDir, entry.Name()))
+				}
+			}
+			if len(keys) > 0 {
+				return runUnlockCommand(ctx, worktree, "git-crypt", append([]string{"unlock"}, keys...)...)
+			}
+		}
+	}
+
+	// Repositories shared with GPG users carry the key encrypted for each of them
+	var gpgErr error
+	if _, err := os.Stat(filepath.Join(worktree, ".git-crypt", "keys")); err == nil {
+		if gpgErr = runUnlockCommand(ctx, worktree, "git-crypt", "unlock"); gpgErr == nil {
+			return nil
+		}
+	}
+
+	if !prompt || !output.IsInteractive() {
+		if gpgErr != nil {
+			return gpgErr
+		}
+		return errors.Errorf("no git-crypt key: set encryption.keys.%s in config.yaml, or run 'git-crypt unlock <key>' in %s", repo.Name, worktree)
+	}
+
+	defer output.SuspendProgress()()
+	var key string
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Title(fmt.Sprintf("'%s' is encrypted with git-crypt", repo.Name)).
+				Description("Path of the key file (git-crypt export-key); empty leaves it locked").
+				Value(&key),
+		),
+	)
+	if err := form.Run(); err != nil {
+		return errors.Wrap(err, "failed to read the key file")
+	}
+	if key = strings.TrimSpace(key); key == "" {
+		return errors.New("no key file given, the repository stays locked")
+	}
+	path, err := expandHomePath(key)
+	if err != nil {
+		return err
+	}
+	return runUnlockCommand(ctx, worktree, "git-crypt", "unlock", path)
+}
+
+// runUnlockCommand runs git-crypt or blackbox in a worktree. In a terminal, GPG can ask for the
+// passphrase of the key.
+func runUnlockCommand(ctx context.Context, worktree, name string, args ...string) error {
+	if _, err := exec.LookPath(name); err != nil {
+		return errors.Errorf("%s is not installed", name)
+	}
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = worktree
+	if output.IsInteractive() {
+		defer output.SuspendProgress()()
+		cmd.Stdin = os.Stdin
+	}
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return errors.Errorf("%s %s failed: %s", name, strings.Join(args, " "), strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// unlockCreatedRepository unlocks a repository just added to a workspace. Failures only warn:
+// the worktree is usable apart from its encrypted files, and 'wsm decrypt' can unlock it later.
+func (wm *WorkspaceManager) unlockCreatedRepository(ctx context.Context, workspace *Workspace, repo Repository) {
+	if wm.config == nil {
+		return
+	}
+	worktree := filepath.Join(workspace.Path, repo.Name)
+	state := DetectEncryption(worktree)
+	if !state.Locked {
+		return
+	}
+	if wm.config.Encryption.Disabled {
+		output.PrintInfo("'%s' is encrypted with %s and stays locked; run 'wsm decrypt %s' to unlock it", repo.Name, state.Tool, workspace.Name)
+		return
+	}
+
+	state, err := wm.UnlockRepository(ctx, workspace, repo, true)
+	if err != nil {
+		output.PrintWarning("'%s' is encrypted with %s and still locked: %v", repo.Name, state.Tool, err)
+		return
+	}
+	if state.Locked {
+		output.PrintWarning("'%s' is still locked after running %s", repo.Name, state.Tool)
+		return
+	}
+	output.PrintSuccess("Unlocked the %s files of '%s'", state.Tool, repo.Name)
+}
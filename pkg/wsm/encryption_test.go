@@ -0,0 +1,71 @@
+package wsm
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestDetectEncryption(t *testing.T) {
+	const attributes = "secrets/** filter=git-crypt diff=git-crypt\n"
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  EncryptionState
+	}{
+		{name: "plain repository", files: map[string]string{".git/HEAD": "", "README.md": ""}},
+		{
+			name:  "git-crypt locked",
+			files: map[string]string{".git/HEAD": "", ".gitattributes": attributes},
+			want:  EncryptionState{Tool: EncryptionGitCrypt, Locked: true},
+		},
+		{
+			name:  "git-crypt unlocked",
+			files: map[string]string{".git/git-crypt/keys/default": "key", ".gitattributes": attributes},
+			want:  EncryptionState{Tool: EncryptionGitCrypt},
+		},
+		{
+			name: "git-crypt unlocked worktree",
+			files: map[string]string{
+				".git":                            "gitdir: ../main/.git/worktrees/lib\n",
+				"../main/.git/worktrees/lib/HEAD": "",
+				"../main/.git/worktrees/lib/git-crypt/keys/default": "key",
+				".gitattributes": attributes,
+			},
+			want: EncryptionState{Tool: EncryptionGitCrypt},
+		},
+		{
+			name:  "git-crypt in a subdirectory",
+			files: map[string]string{".git/HEAD": "", "config/prod/.gitattributes": "*.env filter=git-crypt-prod diff=git-crypt-prod\n"},
+			want:  EncryptionState{Tool: EncryptionGitCrypt, Locked: true},
+		},
+		{
+			name: "commented out and dependency attributes",
+			files: map[string]string{
+				".gitattributes":                "# secrets/** filter=git-crypt\n*.go text\n",
+				"node_modules/x/.gitattributes": attributes,
+				".git/info/.gitattributes":      attributes,
+			},
+		},
+		{
+			name:  "blackbox locked",
+			files: map[string]string{".blackbox/blackbox-files.txt": "secret.txt\n\nother.txt\n", "secret.txt.gpg": "", "other.txt": "decrypted"},
+			want:  EncryptionState{Tool: EncryptionBlackbox, Locked: true, Files: []string{"secret.txt"}},
+		},
+		{
+			name:  "blackbox unlocked in the legacy keyring",
+			files: map[string]string{"keyrings/live/blackbox-files.txt": "secret.txt\n", "secret.txt": "decrypted"},
+			want:  EncryptionState{Tool: EncryptionBlackbox},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "lib")
+			writeGoFiles(t, path, tt.files)
+			got := DetectEncryption(path)
+			if got.Tool != tt.want.Tool || got.Locked != tt.want.Locked || !slices.Equal(got.Files, tt.want.Files) {
+				t.Errorf("DetectEncryption = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,333 @@
+package wsm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Event types published by the daemon, and sent to webhooks by the daemon and by commands
+const (
+	EventWorkspaceCreated     = "workspace.created"
+	EventWorkspaceDeleted     = "workspace.deleted"
+	EventRepositoryDirty      = "repository.dirty"
+	EventRepositoryClean      = "repository.clean"
+	EventBranchDiverged       = "branch.diverged"
+	EventRepositoryDiscovered = "repository.discovered"
+	EventFetchCompleted       = "fetch.completed"
+	EventConfigReloaded       = "config.reloaded"
+	EventConfigInvalid        = "config.invalid"
+	EventPROpened             = "pr.opened"
+	EventSyncFailed           = "sync.failed"
+)
+
+// EventTypes are all event types, in documentation order
+var EventTypes = []string{
+	EventWorkspaceCreated, EventWorkspaceDeleted, EventRepositoryDirty, EventRepositoryClean,
+	EventBranchDiverged, EventRepositoryDiscovered, EventFetchCompleted, EventConfigReloaded,
+	EventConfigInvalid, EventPROpened, EventSyncFailed,
+}
+
+const (
+	// eventSocketFile is the daemon socket, next to config.yaml
+	eventSocketFile = "daemon.sock"
+	// recentEvents is the number of events kept for clients asking for the recent ones
+	recentEvents = 100
+	// eventClientBuffer is the number of events queued for a client before it is dropped as too slow
+	eventClientBuffer = 64
+)
+
+// Requests a client sends when it connects to the event socket
+const (
+	eventRequestRecent = "recent"
+	eventRequestFollow = "follow"
+)
+
+// Event is a change noticed by the daemon, sent to clients as one line of JSON
+type Event struct {
+	Type       string    `json:"type"`
+	Time       time.Time `json:"time"`
+	Workspace  string    `json:"workspace,omitempty"`
+	Repository string    `json:"repository,omitempty"`
+	Branch     string    `json:"branch,omitempty"`
+	Ahead      int       `json:"ahead,omitempty"`
+	Behind     int       `json:"behind,omitempty"`
+	// Fetched and Failed count the repositories of a prefetch
+	Fetched int `json:"fetched,omitempty"`
+	Failed  int `json:"failed,omitempty"`
+	// URL is the pull request of pr.opened
+	URL     string `json:"url,omitempty"`
+	Message string `json:"message"`
+}
+
+// String formats the event as a line for humans
+func (e Event) String() string {
+	return fmt.Sprintf("%s  %-21s  %s", e.Time.Format("15:04:05"), e.Type, e.Message)
+}
+
+// EventSocketPath returns the socket the daemon of the current profile publishes events on
+func EventSocketPath() (string, error) {
+	configDir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, eventSocketFile), nil
+}
+
+// EventHub publishes events to the clients connected to the daemon socket
+type EventHub struct {
+	path     string
+	listener net.Listener
+
+	mu      sync.Mutex
+	clients map[chan Event]bool
+	recent  []Event
+
+	// Forward, when set, is called with each published event, e.g. to send it to webhooks. It
+	// must not block.
+	Forward func(Event)
+}
+
+// ListenEvents creates the daemon socket at path. A socket left behind by a daemon that is gone is
+// replaced; a socket another daemon still listens on is an error.
+func ListenEvents(path string) (*EventHub, error) {
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			_ = conn.Close()
+			return nil, errors.Errorf("a daemon is already running (%s)", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, errors.Wrapf(err, "failed to remove stale socket %s", path)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, errors.Wrapf(err, "failed to create %s", filepath.Dir(path))
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to listen on %s", path)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		_ = listener.Close()
+		return nil, errors.Wrapf(err, "failed to restrict %s", path)
+	}
+	return &EventHub{path: path, listener: listener, clients: map[chan Event]bool{}}, nil
+}
+
+// Serve accepts clients until ctx is done, then closes the socket and removes it
+func (h *EventHub) Serve(ctx context.Context) {
+	go func() {
+		<-ctx.Done()
+		_ = h.listener.Close()
+	}()
+	defer func() { _ = os.Remove(h.path) }()
+
+	var wg sync.WaitGroup
+	for {
+		conn, err := h.listener.Accept()
+		if err != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.serveClient(ctx, conn)
+		}()
+	}
+	wg.Wait()
+}
+
+// serveClient answers the request of a client: the recent events, or the events published from
+// now on until the client disconnects
+func (h *EventHub) serveClient(ctx context.Context, conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	reader := bufio.NewReader(conn)
+	request, err := reader.ReadString('\n')
+	if err != nil {
+		return
+	}
+	_ = conn.SetReadDeadline(time.Time{})
+	encoder := json.NewEncoder(conn)
+
+	switch strings.TrimSpace(request) {
+	case eventRequestRecent:
+		h.mu.Lock()
+		recent := slices.Clone(h.recent)
+		h.mu.Unlock()
+		for _, event := range recent {
+			if encoder.Encode(event) != nil {
+				return
+			}
+		}
+
+	case eventRequestFollow:
+		events := make(chan Event, eventClientBuffer)
+		h.mu.Lock()
+		h.clients[events] = true
+		h.mu.Unlock()
+		defer h.removeClient(events)
+
+		// A client that hangs up is noticed when its connection reads EOF
+		closed := make(chan struct{})
+		go func() {
+			_, _ = reader.ReadString('\n')
+			close(closed)
+		}()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-closed:
+				return
+			case event, ok := <-events:
+				if !ok || encoder.Encode(event) != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (h *EventHub) removeClient(events chan Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.clients[events] {
+		delete(h.clients, events)
+		close(events)
+	}
+}
+
+// Publish sends the event to all following clients. Clients that fall behind are disconnected
+// rather than slowing down the daemon.
+func (h *EventHub) Publish(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	if h.Forward != nil {
+		h.Forward(event)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.recent = append(h.recent, event)
+	if len(h.recent) > recentEvents {
+		h.recent = slices.Delete(h.recent, 0, len(h.recent)-recentEvents)
+	}
+	for events := range h.clients {
+		select {
+		case events <- event:
+		default:
+			delete(h.clients, events)
+			close(events)
+		}
+	}
+}
+
+// ReadEvents connects to the daemon socket and calls handle with each event: the recent events,
+// or with follow the events published from now on until ctx is done or the daemon stops
+func ReadEvents(ctx context.Context, path string, follow bool, handle func(Event) error) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "unix", path)
+	if err != nil {
+		return errors.Errorf("the daemon is not running (no socket at %s); start it with 'wsm daemon run' or 'wsm daemon install'", path)
+	}
+	defer func() { _ = conn.Close() }()
+	go func() {
+		<-ctx.Done()
+		_ = conn.Close()
+	}()
+
+	request := eventRequestRecent
+	if follow {
+		request = eventRequestFollow
+	}
+	if _, err := fmt.Fprintln(conn, request); err != nil {
+		return errors.Wrap(err, "failed to send request to the daemon")
+	}
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return errors.Wrap(err, "failed to decode event")
+		}
+		if err := handle(event); err != nil {
+			return err
+		}
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return scanner.Err()
+}
+
+// StatusEvents compares two snapshots of the fast status of all workspaces, keyed by workspace
+// name, and returns the events of the changes between them in workspace order
+func StatusEvents(previous, current map[string]*WorkspaceStatus) []Event {
+	var events []Event
+	now := time.Now()
+
+	for _, name := range slices.Sorted(maps.Keys(previous)) {
+		if _, ok := current[name]; !ok {
+			events = append(events, Event{Type: EventWorkspaceDeleted, Time: now, Workspace: name,
+				Message: fmt.Sprintf("workspace %s was deleted", name)})
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(current)) {
+		status := current[name]
+		before, existed := previous[name]
+		if !existed {
+			events = append(events, Event{Type: EventWorkspaceCreated, Time: now, Workspace: name, Branch: status.Workspace.Branch,
+				Message: fmt.Sprintf("workspace %s was created", name)})
+			continue
+		}
+		for _, repo := range status.Repositories {
+			old, ok := findRepositoryStatus(before, repo.Repository.Name)
+			if !ok {
+				continue
+			}
+			event := Event{Time: now, Workspace: name, Repository: repo.Repository.Name, Branch: repo.CurrentBranch}
+			switch {
+			case repo.HasChanges && !old.HasChanges:
+				event.Type = EventRepositoryDirty
+				event.Message = fmt.Sprintf("%s/%s has uncommitted changes", name, repo.Repository.Name)
+				events = append(events, event)
+			case !repo.HasChanges && old.HasChanges:
+				event.Type = EventRepositoryClean
+				event.Message = fmt.Sprintf("%s/%s is clean", name, repo.Repository.Name)
+				events = append(events, event)
+			}
+			diverged := repo.Ahead > 0 && repo.Behind > 0
+			if diverged && !(old.Ahead > 0 && old.Behind > 0) {
+				event.Type = EventBranchDiverged
+				event.Ahead = repo.Ahead
+				event.Behind = repo.Behind
+				event.Message = fmt.Sprintf("%s/%s diverged from its upstream, %d ahead and %d behind", name, repo.Repository.Name, repo.Ahead, repo.Behind)
+				events = append(events, event)
+			}
+		}
+	}
+	return events
+}
+
+func findRepositoryStatus(status *WorkspaceStatus, name string) (RepositoryStatus, bool) {
+	for _, repo := range status.Repositories {
+		if repo.Repository.Name == name {
+			return repo, true
+		}
+	}
+	return RepositoryStatus{}, false
+}
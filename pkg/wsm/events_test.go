@@ -0,0 +1,62 @@
+package wsm
+
+import (
+	"slices"
+	"testing"
+)
+
+func testWorkspaceStatus(branch string, repos ...RepositoryStatus) *WorkspaceStatus {
+	return &WorkspaceStatus{Workspace: Workspace{Branch: branch}, Repositories: repos}
+}
+
+func TestStatusEvents(t *testing.T) {
+	previous := map[string]*WorkspaceStatus{
+		"feat": testWorkspaceStatus("task/feat",
+			RepositoryStatus{Repository: Repository{Name: "api"}},
+			RepositoryStatus{Repository: Repository{Name: "web"}, HasChanges: true},
+			RepositoryStatus{Repository: Repository{Name: "lib"}, Ahead: 1},
+			RepositoryStatus{Repository: Repository{Name: "cli"}, Ahead: 1, Behind: 1},
+		),
+		"old": testWorkspaceStatus("task/old"),
+	}
+	current := map[string]*WorkspaceStatus{
+		"feat": testWorkspaceStatus("task/feat",
+			RepositoryStatus{Repository: Repository{Name: "api"}, HasChanges: true, CurrentBranch: "task/feat"},
+			RepositoryStatus{Repository: Repository{Name: "web"}},
+			RepositoryStatus{Repository: Repository{Name: "lib"}, Ahead: 1, Behind: 3, CurrentBranch: "task/feat"},
+			// Still diverged, which was already reported
+			RepositoryStatus{Repository: Repository{Name: "cli"}, Ahead: 2, Behind: 1},
+			// Added to the workspace since the last snapshot
+			RepositoryStatus{Repository: Repository{Name: "docs"}, HasChanges: true},
+		),
+		"new": testWorkspaceStatus("task/new"),
+	}
+
+	var got []string
+	for _, event := range StatusEvents(previous, current) {
+		got = append(got, event.Type+" "+event.Workspace+"/"+event.Repository)
+	}
+	want := []string{
+		"workspace.deleted old/",
+		"repository.dirty feat/api",
+		"repository.clean feat/web",
+		"branch.diverged feat/lib",
+		"workspace.created new/",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("StatusEvents = %q, want %q", got, want)
+	}
+
+	events := StatusEvents(previous, current)
+	if diverged := events[3]; diverged.Ahead != 1 || diverged.Behind != 3 || diverged.Branch != "task/feat" ||
+		diverged.Message != "feat/lib diverged from its upstream, 1 ahead and 3 behind" {
+		t.Errorf("diverged event = %+v", diverged)
+	}
+	if created := events[4]; created.Branch != "task/new" || created.Message != "workspace new was created" {
+		t.Errorf("created event = %+v", created)
+	}
+
+	if events := StatusEvents(current, current); len(events) != 0 {
+		t.Errorf("StatusEvents of unchanged snapshots = %+v", events)
+	}
+}
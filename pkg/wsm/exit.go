@@ -0,0 +1,18 @@
+package wsm
+
+import "fmt"
+
+// ExitCodeError ends wsm with a specific exit status. The failure was already reported, by the
+// command itself or by the remote wsm on Host, so main only forwards the status.
+type ExitCodeError struct {
+	// Host is the remote host whose wsm exited, empty for local commands
+	Host string
+	Code int
+}
+
+func (e *ExitCodeError) Error() string {
+	if e.Host != "" {
+		return fmt.Sprintf("wsm on %s exited with status %d", e.Host, e.Code)
+	}
+	return fmt.Sprintf("exit status %d", e.Code)
+}
@@ -0,0 +1,75 @@
+package wsm
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// IsFrozen reports whether the repository is excluded from batch operations
+func (w *Workspace) IsFrozen(repoName string) bool {
+	return slices.Contains(w.Frozen, repoName)
+}
+
+// ActiveRepositories returns the repositories that are not frozen, which commit, sync and push
+// operate on
+func (w *Workspace) ActiveRepositories() []Repository {
+	var repos []Repository
+	for _, repo := range w.Repositories {
+		if !w.IsFrozen(repo.Name) {
+			repos = append(repos, repo)
+		}
+	}
+	return repos
+}
+
+// FreezeRepositories marks repositories of the workspace as frozen. Their worktrees stay in place
+// but commit, sync and push skip them. It returns the repositories that were not frozen yet.
+func (wm *WorkspaceManager) FreezeRepositories(workspace *Workspace, names []string) ([]string, error) {
+	start := time.Now()
+	var frozen []string
+	for _, name := range names {
+		if _, ok := FindRepository(workspace, name); !ok {
+			return nil, errors.Errorf("repository '%s' is not in workspace '%s'", name, workspace.Name)
+		}
+		if workspace.IsFrozen(name) {
+			continue
+		}
+		workspace.Frozen = append(workspace.Frozen, name)
+		frozen = append(frozen, name)
+	}
+	if len(frozen) == 0 {
+		return nil, nil
+	}
+	if err := wm.SaveWorkspace(workspace); err != nil {
+		return nil, errors.Wrap(err, "failed to save workspace configuration")
+	}
+	RecordOperation("freeze", workspace.Name, start, map[string]string{"repos": strings.Join(frozen, ",")})
+	return frozen, nil
+}
+
+// UnfreezeRepositories includes frozen repositories in batch operations again. It returns the
+// repositories that were frozen.
+func (wm *WorkspaceManager) UnfreezeRepositories(workspace *Workspace, names []string) ([]string, error) {
+	start := time.Now()
+	var thawed []string
+	for _, name := range names {
+		if _, ok := FindRepository(workspace, name); !ok {
+			return nil, errors.Errorf("repository '%s' is not in workspace '%s'", name, workspace.Name)
+		}
+		if i := slices.Index(workspace.Frozen, name); i >= 0 {
+			workspace.Frozen = slices.Delete(workspace.Frozen, i, i+1)
+			thawed = append(thawed, name)
+		}
+	}
+	if len(thawed) == 0 {
+		return nil, nil
+	}
+	if err := wm.SaveWorkspace(workspace); err != nil {
+		return nil, errors.Wrap(err, "failed to save workspace configuration")
+	}
+	RecordOperation("unfreeze", workspace.Name, start, map[string]string{"repos": strings.Join(thawed, ",")})
+	return thawed, nil
+}
@@ -0,0 +1,57 @@
+package wsm
+
+import (
+	"io/fs"
+	"os"
+)
+
+// FS is the file system access used by WorkspaceManager and RepositoryDiscoverer for
+// configuration, registry and workspace files. OSFS is the real file system; tests can inject
+// an in-memory implementation.
+type FS interface {
+	Stat(name string) (fs.FileInfo, error)
+	Lstat(name string) (fs.FileInfo, error)
+	ReadFile(name string) ([]byte, error)
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+	ReadDir(name string) ([]fs.DirEntry, error)
+	MkdirAll(path string, perm fs.FileMode) error
+	Remove(name string) error
+	RemoveAll(path string) error
+}
+
+// OSFS is the local file system
+type OSFS struct{}
+
+// Stat implements FS
+func (OSFS) Stat(name string) (fs.FileInfo, error) { return os.Stat(name) }
+
+// Lstat implements FS
+func (OSFS) Lstat(name string) (fs.FileInfo, error) { return os.Lstat(name) }
+
+// ReadFile implements FS
+func (OSFS) ReadFile(name string) ([]byte, error) { return os.ReadFile(name) }
+
+// WriteFile implements FS
+func (OSFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+// ReadDir implements FS
+func (OSFS) ReadDir(name string) ([]fs.DirEntry, error) { return os.ReadDir(name) }
+
+// MkdirAll implements FS
+func (OSFS) MkdirAll(path string, perm fs.FileMode) error { return os.MkdirAll(path, perm) }
+
+// Remove implements FS
+func (OSFS) Remove(name string) error { return os.Remove(name) }
+
+// RemoveAll implements FS
+func (OSFS) RemoveAll(path string) error { return os.RemoveAll(path) }
+
+// fsOrDefault returns fsys, or the local file system when it is nil
+func fsOrDefault(fsys FS) FS {
+	if fsys == nil {
+		return OSFS{}
+	}
+	return fsys
+}
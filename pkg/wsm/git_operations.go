@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/go-go-golems/workspace-manager/pkg/output"
@@ -14,6 +15,15 @@ import (
 // GitOperations handles git operations across workspace repositories
 type GitOperations struct {
 	workspace *Workspace
+	// IncludeIgnored keeps the untracked files excluded by the .wsm/ignore file of the workspace,
+	// which are hidden by default
+	IncludeIgnored bool
+	// Timeouts bound the push of each repository after committing
+	Timeouts TimeoutConfig
+
+	ignoreRules  *IgnoreRules
+	ignoreLoaded bool
+	hidden       int
 }
 
 // NewGitOperations creates a new git operations handler
@@ -38,13 +48,15 @@ type CommitOperation struct {
 	DryRun  bool                    `json:"dry_run"`
 	AddAll  bool                    `json:"add_all"`
 	Push    bool                    `json:"push"`
+	// Signing is the signing policy the commits follow
+	Signing SigningConfig `json:"-"`
 }
 
-// GetWorkspaceChanges gets all changes across workspace repositories
+// GetWorkspaceChanges gets all changes across the repositories of the workspace that are not frozen
 func (gops *GitOperations) GetWorkspaceChanges(ctx context.Context) (map[string][]FileChange, error) {
 	changes := make(map[string][]FileChange)
 
-	for _, repo := range gops.workspace.Repositories {
+	for _, repo := range gops.workspace.ActiveRepositories() {
 		repoPath := filepath.Join(gops.workspace.Path, repo.Name)
 		repoChanges, err := gops.getRepositoryChanges(ctx, repo.Name, repoPath)
 		if err != nil {
@@ -69,6 +81,7 @@ func (gops *GitOperations) getRepositoryChanges(ctx context.Context, repoName, r
 	}
 
 	var changes []FileChange
+	var untracked []string
 	lines := strings.Split(string(output), "\n")
 
 	for _, line := range lines {
@@ -106,6 +119,7 @@ func (gops *GitOperations) getRepositoryChanges(ctx context.Context, repoName, r
 
 		// Handle untracked files
 		if indexStatus == '?' && workTreeStatus == '?' {
+			untracked = append(untracked, filePath)
 			changes = append(changes, FileChange{
 				Repository: repoName,
 				FilePath:   filePath,
@@ -115,7 +129,40 @@ func (gops *GitOperations) getRepositoryChanges(ctx context.Context, repoName, r
 		}
 	}
 
-	return changes, nil
+	ignored, err := gops.ignoredPaths(repoName, untracked)
+	if err != nil || len(ignored) == 0 {
+		return changes, err
+	}
+	kept := changes[:0]
+	for _, change := range changes {
+		if change.Status != "?" || !ignored[change.FilePath] {
+			kept = append(kept, change)
+		}
+	}
+	return kept, nil
+}
+
+// HiddenChanges returns how many untracked files were left out because they are ignored
+func (gops *GitOperations) HiddenChanges() int {
+	return gops.hidden
+}
+
+// ignoredPaths returns which of the untracked paths of a repository are ignored, counting them as
+// hidden; none are unless ignored changes are excluded
+func (gops *GitOperations) ignoredPaths(repoName string, paths []string) (map[string]bool, error) {
+	if gops.IncludeIgnored || len(paths) == 0 {
+		return nil, nil
+	}
+	if !gops.ignoreLoaded {
+		rules, err := LoadWorkspaceIgnore(gops.workspace)
+		if err != nil {
+			return nil, err
+		}
+		gops.ignoreRules, gops.ignoreLoaded = rules, true
+	}
+	ignored := ignoredUntracked(repoName, paths, gops.ignoreRules)
+	gops.hidden += len(ignored)
+	return ignored, nil
 }
 
 // StageFile stages a specific file in a repository
@@ -162,10 +209,18 @@ func (gops *GitOperations) UnstageFile(ctx context.Context, repoName, filePath s
 
 // CommitChanges commits changes across repositories
 func (gops *GitOperations) CommitChanges(ctx context.Context, operation *CommitOperation) error {
+	if err := gops.workspace.RequireWorktrees("committing"); err != nil {
+		return err
+	}
 	if operation.DryRun {
 		return gops.previewCommit(ctx, operation)
 	}
 
+	policies, err := gops.resolveSigning(ctx, operation)
+	if err != nil {
+		return err
+	}
+
 	var errors []string
 	var successfulRepos []string
 
@@ -204,7 +259,7 @@ func (gops *GitOperations) CommitChanges(ctx context.Context, operation *CommitO
 		}
 
 		// Commit changes
-		if err := gops.commitRepository(ctx, repoName, repoPath, operation.Message); err != nil {
+		if err := gops.commitRepository(ctx, repoName, repoPath, operation.Message, policies[repoName]); err != nil {
 			errors = append(errors, fmt.Sprintf("%s: %v", repoName, err))
 			continue
 		}
@@ -291,9 +346,41 @@ func (gops *GitOperations) hasStagedChanges(ctx context.Context, repoPath string
 	return false, nil
 }
 
-// commitRepository commits changes in a single repository
-func (gops *GitOperations) commitRepository(ctx context.Context, repoName, repoPath, message string) error {
-	cmd := exec.CommandContext(ctx, "git", "commit", "-m", message)
+// resolveSigning returns the signing policy of every repository of the commit. Repositories that
+// require signing without a usable key fail the whole commit before anything is committed; with
+// the auto mode they commit unsigned.
+func (gops *GitOperations) resolveSigning(ctx context.Context, operation *CommitOperation) (map[string]SigningPolicy, error) {
+	policies := make(map[string]SigningPolicy, len(operation.Files))
+	var missing []string
+	for repoName := range operation.Files {
+		policy := operation.Signing.For(repoName)
+		if policy.Mode != SigningOff {
+			check := CheckSigningKey(ctx, repoName, filepath.Join(gops.workspace.Path, repoName), policy)
+			switch {
+			case check.Available:
+			case policy.Mode == SigningRequire:
+				missing = append(missing, fmt.Sprintf("%s: %s", repoName, check.Error))
+			default:
+				output.PrintWarning("No signing key for %s (%s), committing unsigned", repoName, check.Error)
+				policy.Mode = SigningOff
+			}
+		}
+		policies[repoName] = policy
+	}
+	if len(missing) > 0 {
+		return nil, errors.Errorf("signed commits are required but no signing key is available:\n%s", strings.Join(missing, "\n"))
+	}
+	return policies, nil
+}
+
+// commitRepository commits changes in a single repository, signing the commit unless the policy is off
+func (gops *GitOperations) commitRepository(ctx context.Context, repoName, repoPath, message string, policy SigningPolicy) error {
+	args := []string{"commit", "-m", message}
+	if policy.Mode != SigningOff && policy.Mode != "" {
+		gitArgs, commitArgs := signingArgs(policy)
+		args = append(append(gitArgs, "commit"), append(commitArgs, "-m", message)...)
+	}
+	cmd := exec.CommandContext(ctx, "git", args...)
 	cmd.Dir = repoPath
 
 	cmdOutput, err := cmd.CombinedOutput()
@@ -313,11 +400,14 @@ func (gops *GitOperations) commitRepository(ctx context.Context, repoName, repoP
 
 // pushRepository pushes changes in a single repository
 func (gops *GitOperations) pushRepository(ctx context.Context, repoName, repoPath string) error {
-	cmd := exec.CommandContext(ctx, "git", "push")
-	cmd.Dir = repoPath
+	ctx, done := WithNetworkTimeout(ctx, gops.Timeouts, repoName, OperationPush)
+	cmd := gitCommand(ctx, repoPath, "git", "push")
 
 	cmdOutput, err := cmd.CombinedOutput()
-	if err != nil {
+	if err = done(err); err != nil {
+		if IsTimeout(err) {
+			return err
+		}
 		return errors.Wrapf(err, "failed to push %s: %s", repoName, string(cmdOutput))
 	}
 
@@ -360,12 +450,12 @@ func (gops *GitOperations) GetDiff(ctx context.Context, staged bool, repoFilter
 
 // getRepositoryDiff gets diff for a single repository
 func (gops *GitOperations) getRepositoryDiff(ctx context.Context, repoName, repoPath string, staged bool) (string, error) {
-	var cmd *exec.Cmd
+	args := []string{"diff"}
 	if staged {
-		cmd = exec.CommandContext(ctx, "git", "diff", "--cached")
-	} else {
-		cmd = exec.CommandContext(ctx, "git", "diff")
+		args = append(args, "--cached")
 	}
+
+	cmd := exec.CommandContext(ctx, "git", args...)
 	cmd.Dir = repoPath
 
 	output, err := cmd.Output()
@@ -375,3 +465,64 @@ func (gops *GitOperations) getRepositoryDiff(ctx context.Context, repoName, repo
 
 	return string(output), nil
 }
+
+// DiffFileStat is the change summary of one file, as reported by git diff --numstat
+type DiffFileStat struct {
+	Path       string `json:"path"`
+	Insertions int    `json:"insertions"`
+	Deletions  int    `json:"deletions"`
+	Binary     bool   `json:"binary"`
+}
+
+// RepositoryDiffStat summarizes the diff of one repository
+type RepositoryDiffStat struct {
+	Repository string         `json:"repository"`
+	Files      []DiffFileStat `json:"files"`
+	Insertions int            `json:"insertions"`
+	Deletions  int            `json:"deletions"`
+}
+
+// GetDiffStats returns per-file insertion/deletion counts for every repository with changes
+func (gops *GitOperations) GetDiffStats(ctx context.Context, staged bool, repoFilter string) ([]RepositoryDiffStat, error) {
+	var stats []RepositoryDiffStat
+
+	for _, repo := range gops.workspace.Repositories {
+		if repoFilter != "" && repo.Name != repoFilter {
+			continue
+		}
+
+		args := []string{"diff", "--numstat"}
+		if staged {
+			args = append(args, "--cached")
+		}
+		out, err := runGitOutput(ctx, filepath.Join(gops.workspace.Path, repo.Name), args...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get diff stats for %s", repo.Name)
+		}
+
+		repoStat := RepositoryDiffStat{Repository: repo.Name}
+		for _, line := range strings.Split(out, "\n") {
+			fields := strings.SplitN(line, "\t", 3)
+			if len(fields) != 3 {
+				continue
+			}
+
+			file := DiffFileStat{Path: fields[2]}
+			if fields[0] == "-" && fields[1] == "-" {
+				file.Binary = true
+			} else {
+				file.Insertions, _ = strconv.Atoi(fields[0])
+				file.Deletions, _ = strconv.Atoi(fields[1])
+			}
+			repoStat.Files = append(repoStat.Files, file)
+			repoStat.Insertions += file.Insertions
+			repoStat.Deletions += file.Deletions
+		}
+
+		if len(repoStat.Files) > 0 {
+			stats = append(stats, repoStat)
+		}
+	}
+
+	return stats, nil
+}
@@ -2,27 +2,35 @@ package wsm
 
 import (
 	"context"
-	"os/exec"
-	"strings"
 
 	"github.com/rs/zerolog/log"
 )
 
 // getGitCurrentBranch returns the current branch name
-func getGitCurrentBranch(ctx context.Context, path string) (string, error) {
-	cmd := exec.CommandContext(ctx, "git", "branch", "--show-current")
-	cmd.Dir = path
-	output, err := cmd.Output()
-	if err != nil {
-		return "", err
+func getGitCurrentBranch(ctx context.Context, runner CommandRunner, path string) (string, error) {
+	return gitOutput(ctx, runner, path, "branch", "--show-current")
+}
+
+// WorkspaceBaseRef returns the ref workspace branch commits are compared against:
+// the explicit base, the workspace base branch, or origin/main
+func WorkspaceBaseRef(workspace *Workspace, base string) string {
+	if base != "" {
+		return base
+	}
+	if workspace.BaseBranch != "" {
+		return workspace.BaseBranch
 	}
-	return strings.TrimSpace(string(output)), nil
+	return "origin/main"
 }
 
 // CheckBranchMerged checks if the current branch has been merged to origin/main
 func CheckBranchMerged(ctx context.Context, path string) (bool, error) {
+	return checkBranchMerged(ctx, defaultRunner, path)
+}
+
+func checkBranchMerged(ctx context.Context, runner CommandRunner, path string) (bool, error) {
 	// Get current branch for logging
-	currentBranch, branchErr := getGitCurrentBranch(ctx, path)
+	currentBranch, branchErr := getGitCurrentBranch(ctx, runner, path)
 	if branchErr != nil {
 		log.Debug().Err(branchErr).Str("path", path).Msg("Failed to get current branch for merge check")
 		currentBranch = "unknown"
@@ -31,9 +39,7 @@ func CheckBranchMerged(ctx context.Context, path string) (bool, error) {
 	log.Debug().Str("path", path).Str("branch", currentBranch).Msg("Checking if branch is merged to origin/main")
 
 	// First, fetch to ensure we have latest remote refs
-	fetchCmd := exec.CommandContext(ctx, "git", "fetch", "origin", "main")
-	fetchCmd.Dir = path
-	fetchErr := fetchCmd.Run()
+	_, fetchErr := gitOutput(ctx, runner, path, "fetch", "origin", "main")
 	if fetchErr != nil {
 		log.Debug().Err(fetchErr).Str("path", path).Msg("Failed to fetch origin/main - might be offline")
 	} else {
@@ -42,9 +48,7 @@ func CheckBranchMerged(ctx context.Context, path string) (bool, error) {
 
 	// Check if HEAD has been merged into origin/main
 	// This command returns 0 if the current HEAD is merged, non-zero otherwise
-	cmd := exec.CommandContext(ctx, "git", "merge-base", "--is-ancestor", "HEAD", "origin/main")
-	cmd.Dir = path
-	err := cmd.Run()
+	_, err := gitOutput(ctx, runner, path, "merge-base", "--is-ancestor", "HEAD", "origin/main")
 
 	merged := err == nil
 	log.Debug().Str("path", path).Str("branch", currentBranch).Bool("merged", merged).Msg("Branch merge check result")
@@ -54,8 +58,12 @@ func CheckBranchMerged(ctx context.Context, path string) (bool, error) {
 
 // CheckBranchNeedsRebase checks if the current branch needs to be rebased on origin/main
 func CheckBranchNeedsRebase(ctx context.Context, path string) (bool, error) {
+	return checkBranchNeedsRebase(ctx, defaultRunner, path)
+}
+
+func checkBranchNeedsRebase(ctx context.Context, runner CommandRunner, path string) (bool, error) {
 	// Get current branch for logging
-	currentBranch, branchErr := getGitCurrentBranch(ctx, path)
+	currentBranch, branchErr := getGitCurrentBranch(ctx, runner, path)
 	if branchErr != nil {
 		log.Debug().Err(branchErr).Str("path", path).Msg("Failed to get current branch for rebase check")
 		currentBranch = "unknown"
@@ -70,9 +78,7 @@ func CheckBranchNeedsRebase(ctx context.Context, path string) (bool, error) {
 	log.Debug().Str("path", path).Str("branch", currentBranch).Msg("Checking if branch needs rebase on origin/main")
 
 	// First, fetch to ensure we have latest remote refs
-	fetchCmd := exec.CommandContext(ctx, "git", "fetch", "origin", "main")
-	fetchCmd.Dir = path
-	fetchErr := fetchCmd.Run()
+	_, fetchErr := gitOutput(ctx, runner, path, "fetch", "origin", "main")
 	if fetchErr != nil {
 		log.Debug().Err(fetchErr).Str("path", path).Msg("Failed to fetch origin/main - might be offline")
 	} else {
@@ -81,15 +87,12 @@ func CheckBranchNeedsRebase(ctx context.Context, path string) (bool, error) {
 
 	// Check if origin/main has new commits compared to the merge-base
 	// This tells us if origin/main has moved forward since we branched
-	cmd := exec.CommandContext(ctx, "git", "rev-list", "--count", "HEAD..origin/main")
-	cmd.Dir = path
-	output, err := cmd.Output()
+	commitCount, err := gitOutput(ctx, runner, path, "rev-list", "--count", "HEAD..origin/main")
 	if err != nil {
 		log.Debug().Err(err).Str("path", path).Msg("Failed to check for commits ahead on origin/main")
 		return false, err
 	}
 
-	commitCount := strings.TrimSpace(string(output))
 	needsRebase := commitCount != "0"
 	log.Debug().Str("path", path).Str("branch", currentBranch).Str("commits_behind", commitCount).Bool("needs_rebase", needsRebase).Msg("Branch rebase check result")
 
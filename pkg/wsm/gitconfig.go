@@ -0,0 +1,297 @@
+package wsm
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/pkg/errors"
+)
+
+// GitConfigOverrides is git configuration set in the worktrees of a workspace, such as the work
+// or open source identity (user.email), commit.gpgsign or core.hooksPath:
+//
+//	git_config:
+//	  values:
+//	    user.email: me@company.com
+//	  repositories:
+//	    oss-lib:
+//	      user.email: me@example.org
+//
+// Values are written with 'git config --worktree', so they only apply to the workspace worktrees
+// and not to the source repositories or other workspaces.
+type GitConfigOverrides struct {
+	// Values apply to every repository
+	Values map[string]string `json:"values,omitempty" yaml:"values"`
+	// Repositories override Values for single repositories
+	Repositories map[string]map[string]string `json:"repositories,omitempty" yaml:"repositories"`
+}
+
+// For returns the configuration of a repository
+func (g *GitConfigOverrides) For(repoName string) map[string]string {
+	values := map[string]string{}
+	if g == nil {
+		return values
+	}
+	maps.Copy(values, g.Values)
+	maps.Copy(values, g.Repositories[repoName])
+	return values
+}
+
+// IsEmpty reports whether no configuration is declared
+func (g *GitConfigOverrides) IsEmpty() bool {
+	return g == nil || (len(g.Values) == 0 && len(g.Repositories) == 0)
+}
+
+// clone returns a deep copy, so workspaces do not share maps with the global configuration
+func (g GitConfigOverrides) clone() *GitConfigOverrides {
+	c := &GitConfigOverrides{Values: maps.Clone(g.Values)}
+	if len(g.Repositories) > 0 {
+		c.Repositories = map[string]map[string]string{}
+		for repo, values := range g.Repositories {
+			c.Repositories[repo] = maps.Clone(values)
+		}
+	}
+	return c
+}
+
+// GitConfigEntry is a declared git configuration value in a worktree
+type GitConfigEntry struct {
+	Repository string `json:"repository"`
+	Key        string `json:"key"`
+	// Declared is the value from the workspace configuration
+	Declared string `json:"declared"`
+	// Worktree is the value currently set with 'git config --worktree'
+	Worktree string `json:"worktree"`
+	// Effective is what git uses in the worktree, which differs from Declared while the
+	// configuration has not been applied
+	Effective string `json:"effective"`
+}
+
+// Applied reports whether the worktree uses the declared value
+func (e GitConfigEntry) Applied() bool {
+	return e.Worktree == e.Declared && e.Effective == e.Declared
+}
+
+// GitConfigStatus returns the declared configuration of every worktree of the workspace with the
+// values git currently uses
+func (wm *WorkspaceManager) GitConfigStatus(ctx context.Context, workspace *Workspace) []GitConfigEntry {
+	var entries []GitConfigEntry
+	for _, repo := range workspace.Repositories {
+		worktreePath := filepath.Join(workspace.Path, repo.Name)
+		values := workspace.GitConfig.For(repo.Name)
+		for _, key := range sortedKeys(values) {
+			entries = append(entries, GitConfigEntry{
+				Repository: repo.Name,
+				Key:        key,
+				Declared:   values[key],
+				Worktree:   wm.gitConfigValue(ctx, worktreePath, "--worktree", key),
+				Effective:  wm.gitConfigValue(ctx, worktreePath, "", key),
+			})
+		}
+	}
+	return entries
+}
+
+// gitConfigValue reads a configuration value in the given scope ("" for the effective value); unset
+// keys, and the worktree scope of repositories without worktree configuration, read as ""
+func (wm *WorkspaceManager) gitConfigValue(ctx context.Context, dir, scope, key string) string {
+	args := []string{"config"}
+	if scope != "" {
+		args = append(args, scope)
+	}
+	value, err := gitOutput(ctx, wm.runner(), dir, append(args, "--get", key)...)
+	if err != nil {
+		return ""
+	}
+	return value
+}
+
+// ApplyGitConfig writes the declared configuration into the worktrees of the workspace and returns
+// the values that changed. Worktree configuration is enabled in source repositories that lack it.
+func (wm *WorkspaceManager) ApplyGitConfig(ctx context.Context, workspace *Workspace, dryRun bool) ([]GitConfigEntry, error) {
+	start := time.Now()
+	if err := workspace.RequireWorktrees("applying git config"); err != nil {
+		return nil, err
+	}
+	var changed []GitConfigEntry
+	var errs []string
+	for _, entry := range wm.GitConfigStatus(ctx, workspace) {
+		if entry.Worktree == entry.Declared {
+			continue
+		}
+		changed = append(changed, entry)
+		if dryRun {
+			continue
+		}
+		repo, _ := FindRepository(workspace, entry.Repository)
+		if err := wm.setWorktreeConfig(ctx, workspace, repo, entry.Key, entry.Declared); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return changed, errors.Errorf("failed to apply git config: %s", strings.Join(errs, "; "))
+	}
+	if len(changed) > 0 && !dryRun {
+		RecordOperation("gitconfig-apply", workspace.Name, start, map[string]string{"changes": strings.Join(gitConfigEntryNames(changed), ",")})
+	}
+	return changed, nil
+}
+
+func gitConfigEntryNames(entries []GitConfigEntry) []string {
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Repository + ":" + entry.Key
+	}
+	return names
+}
+
+// SetGitConfig declares a configuration value for the workspace, or for one repository when
+// repoName is set, saves the workspace and applies the value to the affected worktrees
+func (wm *WorkspaceManager) SetGitConfig(ctx context.Context, workspace *Workspace, repoName, key, value string) error {
+	if err := workspace.RequireWorktrees("setting git config"); err != nil {
+		return err
+	}
+	if !strings.Contains(key, ".") {
+		return errors.Errorf("invalid git config key '%s': expected section.name, e.g. user.email", key)
+	}
+	if repoName != "" {
+		if _, ok := FindRepository(workspace, repoName); !ok {
+			return errors.Errorf("repository '%s' is not in workspace '%s'", repoName, workspace.Name)
+		}
+	}
+
+	if workspace.GitConfig == nil {
+		workspace.GitConfig = &GitConfigOverrides{}
+	}
+	if repoName == "" {
+		if workspace.GitConfig.Values == nil {
+			workspace.GitConfig.Values = map[string]string{}
+		}
+		workspace.GitConfig.Values[key] = value
+	} else {
+		if workspace.GitConfig.Repositories == nil {
+			workspace.GitConfig.Repositories = map[string]map[string]string{}
+		}
+		if workspace.GitConfig.Repositories[repoName] == nil {
+			workspace.GitConfig.Repositories[repoName] = map[string]string{}
+		}
+		workspace.GitConfig.Repositories[repoName][key] = value
+	}
+	if err := wm.SaveWorkspace(workspace); err != nil {
+		return errors.Wrap(err, "failed to save workspace configuration")
+	}
+
+	_, err := wm.ApplyGitConfig(ctx, workspace, false)
+	return err
+}
+
+// UnsetGitConfig removes a declared configuration value, from the workspace or from one repository
+// when repoName is set, and unsets it in the worktrees that no longer declare it
+func (wm *WorkspaceManager) UnsetGitConfig(ctx context.Context, workspace *Workspace, repoName, key string) error {
+	start := time.Now()
+	if err := workspace.RequireWorktrees("unsetting git config"); err != nil {
+		return err
+	}
+	found := false
+	if workspace.GitConfig != nil {
+		if repoName == "" {
+			if _, found = workspace.GitConfig.Values[key]; found {
+				delete(workspace.GitConfig.Values, key)
+			}
+		} else if _, found = workspace.GitConfig.Repositories[repoName][key]; found {
+			delete(workspace.GitConfig.Repositories[repoName], key)
+			if len(workspace.GitConfig.Repositories[repoName]) == 0 {
+				delete(workspace.GitConfig.Repositories, repoName)
+			}
+		}
+	}
+	if !found {
+		return errors.Errorf("git config '%s' is not declared in workspace '%s'", key, workspace.Name)
+	}
+	if workspace.GitConfig.IsEmpty() {
+		workspace.GitConfig = nil
+	}
+	if err := wm.SaveWorkspace(workspace); err != nil {
+		return errors.Wrap(err, "failed to save workspace configuration")
+	}
+
+	var errs []string
+	for _, repo := range workspace.Repositories {
+		if repoName != "" && repo.Name != repoName {
+			continue
+		}
+		worktreePath := filepath.Join(workspace.Path, repo.Name)
+		if value, declared := workspace.GitConfig.For(repo.Name)[key]; declared {
+			// Still declared for the whole workspace after removing a repository override
+			if err := wm.setWorktreeConfig(ctx, workspace, repo, key, value); err != nil {
+				errs = append(errs, err.Error())
+			}
+			continue
+		}
+		if wm.gitConfigValue(ctx, worktreePath, "--worktree", key) == "" {
+			continue
+		}
+		if _, err := gitOutput(ctx, wm.runner(), worktreePath, "config", "--worktree", "--unset-all", key); err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to unset %s in '%s'", key, repo.Name).Error())
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Errorf("failed to unset git config: %s", strings.Join(errs, "; "))
+	}
+	RecordOperation("gitconfig-unset", workspace.Name, start, map[string]string{"repo": repoName, "key": key})
+	return nil
+}
+
+// setWorktreeConfig sets a value in the worktree-specific configuration of a repository
+func (wm *WorkspaceManager) setWorktreeConfig(ctx context.Context, workspace *Workspace, repo Repository, key, value string) error {
+	// The configuration of a clone is its own; without worktree configuration enabled,
+	// --worktree writes to it
+	if workspace.Clone == "" {
+		if err := wm.enableWorktreeConfig(ctx, repo); err != nil {
+			return err
+		}
+	}
+	worktreePath := filepath.Join(workspace.Path, repo.Name)
+	if _, err := gitOutput(ctx, wm.runner(), worktreePath, "config", "--worktree", key, value); err != nil {
+		return errors.Wrapf(err, "failed to set %s in '%s'", key, repo.Name)
+	}
+	return nil
+}
+
+// enableWorktreeConfig turns on extensions.worktreeConfig in a source repository. Without it,
+// 'git config --worktree' would write to the configuration shared by all worktrees.
+func (wm *WorkspaceManager) enableWorktreeConfig(ctx context.Context, repo Repository) error {
+	if wm.gitConfigValue(ctx, repo.Path, "--local", "extensions.worktreeConfig") == "true" {
+		return nil
+	}
+	// With worktree configuration enabled, core.worktree in the shared configuration would apply
+	// to every worktree; git requires moving it to the main worktree first
+	if wm.gitConfigValue(ctx, repo.Path, "--local", "core.worktree") != "" {
+		return errors.Errorf("repository '%s' sets core.worktree; move it to .git/config.worktree before using workspace git config", repo.Name)
+	}
+	output.LogInfo(
+		fmt.Sprintf("Enabling extensions.worktreeConfig in %s for per-worktree git config", repo.Path),
+		"Enabling worktree config",
+		"repo", repo.Name,
+	)
+	if _, err := gitOutput(ctx, wm.runner(), repo.Path, "config", "--local", "extensions.worktreeConfig", "true"); err != nil {
+		return errors.Wrapf(err, "failed to enable worktree config in '%s'", repo.Name)
+	}
+	return nil
+}
+
+// applyRepositoryGitConfig applies the declared configuration to a new worktree; failures are
+// reported but do not fail workspace creation
+func (wm *WorkspaceManager) applyRepositoryGitConfig(ctx context.Context, workspace *Workspace, repo Repository) {
+	values := workspace.GitConfig.For(repo.Name)
+	for _, key := range sortedKeys(values) {
+		if err := wm.setWorktreeConfig(ctx, workspace, repo, key, values[key]); err != nil {
+			output.PrintWarning("Failed to apply git config: %v", err)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package wsm
+
+import "testing"
+
+func TestGitConfigOverrides(t *testing.T) {
+	overrides := GitConfigOverrides{
+		Values:       map[string]string{"user.email": "me@company.com", "commit.gpgsign": "true"},
+		Repositories: map[string]map[string]string{"oss-lib": {"user.email": "me@example.org"}},
+	}
+	if got := overrides.For("api"); got["user.email"] != "me@company.com" || got["commit.gpgsign"] != "true" {
+		t.Errorf("For(api) = %v", got)
+	}
+	if got := overrides.For("oss-lib"); got["user.email"] != "me@example.org" || got["commit.gpgsign"] != "true" {
+		t.Errorf("For(oss-lib) = %v", got)
+	}
+
+	var missing *GitConfigOverrides
+	if got := missing.For("api"); got == nil || len(got) != 0 {
+		t.Errorf("For on nil overrides = %v, want an empty map", got)
+	}
+	if !missing.IsEmpty() || !(&GitConfigOverrides{}).IsEmpty() || overrides.IsEmpty() {
+		t.Error("IsEmpty is wrong")
+	}
+
+	// The copy does not share maps with the original
+	c := overrides.clone()
+	c.Values["user.email"] = "changed"
+	c.Repositories["oss-lib"]["user.email"] = "changed"
+	if overrides.Values["user.email"] != "me@company.com" || overrides.Repositories["oss-lib"]["user.email"] != "me@example.org" {
+		t.Errorf("clone shares maps with the original: %v", overrides)
+	}
+}
+
+func TestGitConfigEntryApplied(t *testing.T) {
+	tests := []struct {
+		entry GitConfigEntry
+		want  bool
+	}{
+		{GitConfigEntry{Declared: "a", Worktree: "a", Effective: "a"}, true},
+		{GitConfigEntry{Declared: "a", Worktree: "", Effective: "a"}, false},
+		{GitConfigEntry{Declared: "a", Worktree: "a", Effective: "b"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.entry.Applied(); got != tt.want {
+			t.Errorf("%+v.Applied() = %v, want %v", tt.entry, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package wsm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/pkg/errors"
+)
+
+// defaultGoWorkVersion is the go directive of go.work when no module declares a go version
+const defaultGoWorkVersion = "1.23"
+
+// GoWorkConfig configures the go.work file of Go workspaces
+type GoWorkConfig struct {
+	// Sync runs 'go work sync' whenever go.work is written, so the modules agree on the versions of
+	// their shared dependencies and go.work.sum is up to date
+	Sync bool `json:"sync,omitempty" yaml:"sync,omitempty"`
+}
+
+// goWorkVersions returns the go and toolchain directives of go.work for go.mod files: the highest
+// go version of the modules, so none of them is downgraded, and the highest toolchain when it is
+// newer than that. The toolchain is empty when no directive is needed.
+func goWorkVersions(goMods [][]byte) (string, string) {
+	goVersion, toolchain := "", ""
+	for _, data := range goMods {
+		if match := goDirective.FindSubmatch(data); match != nil && compareVersions(string(match[1]), goVersion) > 0 {
+			goVersion = string(match[1])
+		}
+		if match := toolchainDirective.FindSubmatch(data); match != nil && compareVersions(string(match[1]), toolchain) > 0 {
+			toolchain = string(match[1])
+		}
+	}
+	if goVersion == "" {
+		goVersion = defaultGoWorkVersion
+	}
+	if compareVersions(toolchain, goVersion) <= 0 {
+		toolchain = ""
+	}
+	return goVersion, toolchain
+}
+
+// UpdateGoWorkspace brings go.work in line with the Go modules of the workspace after its
+// repositories changed: it is written when the workspace (including its child workspaces) has Go
+// repositories and removed, together with go.work.sum, when the last of them is gone.
+// GoWorkspace is updated in memory only.
+func (wm *WorkspaceManager) UpdateGoWorkspace(ctx context.Context, workspace *Workspace) error {
+	expanded, err := ExpandWorkspace(workspace)
+	if err != nil {
+		return err
+	}
+
+	if !wm.shouldCreateGoWorkspace(expanded.Repositories) {
+		if !workspace.GoWorkspace {
+			return nil
+		}
+		workspace.GoWorkspace = false
+		for _, name := range []string{"go.work", "go.work.sum"} {
+			path := filepath.Join(workspace.Path, name)
+			if err := wm.fs().Remove(path); err != nil && !os.IsNotExist(err) {
+				return errors.Wrapf(err, "failed to remove %s", path)
+			}
+		}
+		output.PrintInfo("Removed go.work: the workspace has no Go repositories left")
+		return nil
+	}
+
+	workspace.GoWorkspace = true
+	if err := wm.CreateGoWorkspace(expanded); err != nil {
+		return err
+	}
+	return wm.SyncGoWorkspace(ctx, workspace)
+}
+
+// SyncGoWorkspace runs 'go work sync' in the workspace when go_work.sync is enabled
+func (wm *WorkspaceManager) SyncGoWorkspace(ctx context.Context, workspace *Workspace) error {
+	if !workspace.GoWorkspace || !wm.config.GoWork.Sync {
+		return nil
+	}
+
+	output.LogInfo(
+		fmt.Sprintf("Running go work sync in %s", workspace.Path),
+		"Running go work sync",
+		"path", workspace.Path,
+	)
+
+	out, err := wm.runner().CombinedOutput(ctx, workspace.Path, "go", "work", "sync")
+	if err != nil {
+		return errors.Wrapf(err, "go work sync failed: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package wsm
+
+import "testing"
+
+func TestGoWorkVersions(t *testing.T) {
+	tests := []struct {
+		name          string
+		goMods        []string
+		wantGo        string
+		wantToolchain string
+	}{
+		{name: "no modules", wantGo: defaultGoWorkVersion},
+		{name: "no go directive", goMods: []string{"module example.com/a\n"}, wantGo: defaultGoWorkVersion},
+		{
+			name:   "highest go version",
+			goMods: []string{"module example.com/a\n\ngo 1.21\n", "module example.com/b\n\ngo 1.22.3\n", "module example.com/c\n\ngo 1.9\n"},
+			wantGo: "1.22.3",
+		},
+		{
+			name:          "toolchain newer than the go version",
+			goMods:        []string{"module example.com/a\n\ngo 1.22\n\ntoolchain go1.23.4\n", "module example.com/b\n\ngo 1.22.1\n"},
+			wantGo:        "1.22.1",
+			wantToolchain: "1.23.4",
+		},
+		{
+			name:   "toolchain not newer than the go version",
+			goMods: []string{"module example.com/a\n\ngo 1.21\n\ntoolchain go1.21.5\n", "module example.com/b\n\ngo 1.23.0\n"},
+			wantGo: "1.23.0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var goMods [][]byte
+			for _, goMod := range tt.goMods {
+				goMods = append(goMods, []byte(goMod))
+			}
+			goVersion, toolchain := goWorkVersions(goMods)
+			if goVersion != tt.wantGo || toolchain != tt.wantToolchain {
+				t.Errorf("goWorkVersions = %q, %q, want %q, %q", goVersion, toolchain, tt.wantGo, tt.wantToolchain)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package wsm
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/pkg/errors"
+)
+
+// HistoryEntry is a workspace operation recorded in the operation journal
+type HistoryEntry struct {
+	Time       time.Time         `json:"time"`
+	Operation  string            `json:"operation"`
+	Workspace  string            `json:"workspace"`
+	User       string            `json:"user,omitempty"`
+	Host       string            `json:"host,omitempty"`
+	Command    string            `json:"command,omitempty"`
+	Parameters map[string]string `json:"parameters,omitempty"`
+	// DurationMs is how long the operation took
+	DurationMs int64 `json:"duration_ms,omitempty"`
+}
+
+// HistoryPath returns the location of the operation journal
+func HistoryPath() (string, error) {
+	configDir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "history.jsonl"), nil
+}
+
+// RecordOperation appends an operation that started at start to the journal. The journal is best
+// effort: failures to write it are logged and never fail the operation itself.
+func RecordOperation(operation, workspace string, start time.Time, parameters map[string]string) {
+	entry := HistoryEntry{
+		Time:       time.Now(),
+		Operation:  operation,
+		Workspace:  workspace,
+		Command:    strings.Join(os.Args, " "),
+		Parameters: parameters,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if current, err := user.Current(); err == nil {
+		entry.User = current.Username
+	}
+	if host, err := os.Hostname(); err == nil {
+		entry.Host = host
+	}
+
+	if err := appendHistory(entry); err != nil {
+		output.LogWarn(
+			"Failed to record operation in history",
+			"Failed to record operation in history",
+			"operation", operation,
+			"workspace", workspace,
+			"error", err,
+		)
+	}
+}
+
+func appendHistory(entry HistoryEntry) error {
+	path, err := HistoryPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrap(err, "failed to create config directory")
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal history entry")
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %s", path)
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		_ = file.Close()
+		return errors.Wrapf(err, "failed to write %s", path)
+	}
+	return file.Close()
+}
+
+// ReadHistory returns the journal entries, oldest first, optionally restricted to a workspace
+// and to entries after since
+func ReadHistory(workspace string, since time.Time) ([]HistoryEntry, error) {
+	path, err := HistoryPath()
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to open %s", path)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	var entries []HistoryEntry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// A partially written line must not hide the rest of the journal
+			continue
+		}
+		if workspace != "" && entry.Workspace != workspace {
+			continue
+		}
+		if !since.IsZero() && entry.Time.Before(since) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", path)
+	}
+
+	return entries, nil
+}
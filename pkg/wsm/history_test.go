@@ -0,0 +1,26 @@
+package wsm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecordOperationMeasuresTheOperation(t *testing.T) {
+	useTestConfigDir(t)
+	RecordOperation("sync", "feat", time.Now().Add(-1500*time.Millisecond), nil)
+	RecordOperation("label", "feat", time.Now(), nil)
+
+	entries, err := ReadHistory("feat", time.Time{})
+	if err != nil {
+		t.Fatalf("ReadHistory failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("entries = %+v, want 2", entries)
+	}
+	if d := entries[0].DurationMs; d < 1500 || d > 10000 {
+		t.Errorf("sync took %dms, want about 1500ms", d)
+	}
+	if d := entries[1].DurationMs; d > 1000 {
+		t.Errorf("label took %dms, want the duration of the operation, not of the process", d)
+	}
+}
@@ -0,0 +1,111 @@
+package wsm
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// IgnoreRules are gitignore-style patterns. Patterns without a slash match a file or directory
+// name at any depth, other patterns the path from the root (a leading slash is optional), with **
+// standing for any number of directories; a trailing slash only matches directories, ! re-includes
+// what an earlier pattern excluded and # starts a comment. A path inside an excluded directory
+// is excluded.
+type IgnoreRules struct {
+	patterns []ignorePattern
+}
+
+type ignorePattern struct {
+	glob     string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// ParseIgnoreRules parses the content of a gitignore-style file
+func ParseIgnoreRules(content string) *IgnoreRules {
+	rules := &IgnoreRules{}
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, " \r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		pattern := ignorePattern{}
+		if strings.HasPrefix(line, "!") {
+			pattern.negate = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			pattern.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		pattern.anchored = strings.Contains(line, "/")
+		pattern.glob = strings.TrimPrefix(line, "/")
+		if pattern.glob != "" {
+			rules.patterns = append(rules.patterns, pattern)
+		}
+	}
+	return rules
+}
+
+// LoadWorkspaceIgnore reads the .wsm/ignore file of a workspace, whose patterns are relative to
+// the workspace root (api/internal/gen/, *.pb.go); it returns nil when there is none
+func LoadWorkspaceIgnore(workspace *Workspace) (*IgnoreRules, error) {
+	path := filepath.Join(workspace.Path, ".wsm", "ignore")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to read %s", path)
+	}
+	return ParseIgnoreRules(string(data)), nil
+}
+
+// Ignored reports whether a slash-separated relative path, or a directory above it, is excluded
+func (r *IgnoreRules) Ignored(rel string, isDir bool) bool {
+	if r == nil {
+		return false
+	}
+	segments := strings.Split(strings.Trim(rel, "/"), "/")
+	for i := 1; i <= len(segments); i++ {
+		if r.match(strings.Join(segments[:i], "/"), isDir || i < len(segments)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *IgnoreRules) match(rel string, isDir bool) bool {
+	ignored := false
+	for _, pattern := range r.patterns {
+		if pattern.dirOnly && !isDir {
+			continue
+		}
+		matched := matchPathGlob(pattern.glob, rel)
+		if pattern.anchored {
+			matched = matchGlobSegments(strings.Split(pattern.glob, "/"), strings.Split(rel, "/"))
+		}
+		if matched {
+			ignored = !pattern.negate
+		}
+	}
+	return ignored
+}
+
+// ignoredUntracked returns which of the untracked paths of a repository the workspace rules
+// exclude, matched against the path prefixed with the repository name; untracked directories
+// end with a slash. git status already leaves out the untracked files its own ignore files
+// match. Tracked files are never passed in: hiding their changes would keep them out of the
+// ownership check while 'git commit' still records them.
+func ignoredUntracked(repoName string, paths []string, workspaceRules *IgnoreRules) map[string]bool {
+	ignored := map[string]bool{}
+	for _, path := range paths {
+		if workspaceRules.Ignored(repoName+"/"+path, strings.HasSuffix(path, "/")) {
+			ignored[path] = true
+		}
+	}
+	return ignored
+}
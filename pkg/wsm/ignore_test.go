@@ -0,0 +1,42 @@
+package wsm
+
+import "testing"
+
+func TestIgnoreRules(t *testing.T) {
+	rules := ParseIgnoreRules("# generated code\n*.pb.go\n!keep.pb.go\napi/internal/gen/\n/vendor\napi/**/mocks\r\n")
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{path: "api/service.pb.go", want: true},
+		{path: "api/keep.pb.go", want: false},
+		{path: "api/internal/gen/types.go", want: true},
+		{path: "api/internal/gen", isDir: true, want: true},
+		{path: "api/internal/gen", want: false},
+		{path: "vendor/lib/lib.go", want: true},
+		{path: "app/vendor/lib.go", want: false},
+		{path: "api/a/b/mocks/mock.go", want: true},
+		{path: "api/mocks", isDir: true, want: true},
+		{path: "# generated code", want: false},
+		{path: "api/service.go", want: false},
+	}
+	for _, tt := range tests {
+		if got := rules.Ignored(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("Ignored(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+
+	var none *IgnoreRules
+	if none.Ignored("api/service.pb.go", false) {
+		t.Error("nil rules should ignore nothing")
+	}
+}
+
+func TestIgnoredUntracked(t *testing.T) {
+	rules := ParseIgnoreRules("api/internal/gen/\n*.gen.go\n")
+	ignored := ignoredUntracked("api", []string{"internal/gen/", "types.gen.go", "main.go", "internal/other/"}, rules)
+	if len(ignored) != 2 || !ignored["internal/gen/"] || !ignored["types.gen.go"] {
+		t.Errorf("unexpected ignored paths %v", ignored)
+	}
+}
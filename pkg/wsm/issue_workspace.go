@@ -0,0 +1,153 @@
+package wsm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// maxIssueWorkspaceName bounds the length of workspace names derived from issue titles
+const maxIssueWorkspaceName = 40
+
+// GitHubIssue is a GitHub issue a workspace is created from
+type GitHubIssue struct {
+	Link   IssueLink `json:"-"`
+	Number int       `json:"number"`
+	Title  string    `json:"title"`
+	Body   string    `json:"body"`
+	URL    string    `json:"url"`
+	Labels []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+}
+
+// LabelNames returns the names of the issue labels
+func (i *GitHubIssue) LabelNames() []string {
+	names := make([]string, len(i.Labels))
+	for j, label := range i.Labels {
+		names[j] = label.Name
+	}
+	return names
+}
+
+// FetchGitHubIssue reads a GitHub issue, given as URL or owner/repo#N, with the gh CLI
+func FetchGitHubIssue(ctx context.Context, reference string, config IssuesConfig) (*GitHubIssue, error) {
+	link, err := ParseIssueReference(reference, config)
+	if err != nil {
+		return nil, err
+	}
+	if link.Tracker != IssueTrackerGitHub {
+		return nil, errors.Errorf("'%s' is not a GitHub issue (expected https://github.com/owner/repo/issues/N or owner/repo#N)", reference)
+	}
+	if _, err := exec.LookPath("gh"); err != nil {
+		return nil, errors.New("the GitHub CLI (gh) is required to read issues")
+	}
+
+	out, err := exec.CommandContext(ctx, "gh", "issue", "view", link.URL, "--json", "number,title,body,url,labels").Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return nil, errors.Errorf("failed to read issue %s: %s", link.Ref, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, errors.Wrapf(err, "failed to read issue %s", link.Ref)
+	}
+
+	issue := &GitHubIssue{Link: link}
+	if err := json.Unmarshal(out, issue); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse issue %s", link.Ref)
+	}
+	issue.Link.Summary = issue.Title
+	return issue, nil
+}
+
+// IssueWorkspaceName derives a workspace name from the issue number and title, e.g.
+// "142-fix-login-redirect-loop"
+func IssueWorkspaceName(issue *GitHubIssue) string {
+	name := fmt.Sprintf("%d", issue.Number)
+	for _, word := range searchTokens(issue.Title) {
+		if len(name)+1+len(word) > maxIssueWorkspaceName {
+			break
+		}
+		name += "-" + word
+	}
+	return name
+}
+
+// IssueBranchPrefix returns the conventional branch prefix of the first issue label that names
+// one (bug gives fix, enhancement gives feature), or "" when no label does
+func IssueBranchPrefix(issue *GitHubIssue) string {
+	for _, label := range issue.LabelNames() {
+		if prefix, ok := branchTypePrefixes[strings.ToLower(label)]; ok {
+			return prefix
+		}
+	}
+	return ""
+}
+
+// IssueRepositories selects the repositories of a workspace for an issue: the repositories that
+// issues.label_repos maps the issue labels to, and the repository the issue belongs to when it is
+// in the registry
+func (wm *WorkspaceManager) IssueRepositories(issue *GitHubIssue) []string {
+	var repos []string
+	add := func(name string) {
+		if !slices.Contains(repos, name) {
+			repos = append(repos, name)
+		}
+	}
+
+	if owner, rest, ok := strings.Cut(issue.Link.Ref, "/"); ok {
+		issueRepo := "github.com/" + strings.ToLower(owner+"/"+strings.Split(rest, "#")[0])
+		for _, repo := range wm.Discoverer.GetRepositories() {
+			if repo.RemoteURL != "" && strings.EqualFold(NormalizeRemoteURL(repo.RemoteURL), issueRepo) {
+				add(repo.Name)
+			}
+		}
+	}
+
+	for _, label := range issue.LabelNames() {
+		for rule, names := range wm.config.Issues.LabelRepos {
+			if strings.EqualFold(rule, label) {
+				for _, name := range names {
+					add(name)
+				}
+			}
+		}
+	}
+	return repos
+}
+
+// SeedAgentMDWithIssue appends the issue to the AGENT.md of the workspace, creating it if needed,
+// so that coding agents start from the issue description
+func (wm *WorkspaceManager) SeedAgentMDWithIssue(workspace *Workspace, issue *GitHubIssue) error {
+	target := filepath.Join(workspace.Path, "AGENT.md")
+	existing, err := wm.fs().ReadFile(target)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "failed to read %s", target)
+	}
+
+	var b strings.Builder
+	b.Write(existing)
+	if len(existing) > 0 {
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "## Issue %s: %s\n\n", issue.Link.Ref, issue.Title)
+	fmt.Fprintf(&b, "%s\n", issue.URL)
+	if labels := issue.LabelNames(); len(labels) > 0 {
+		fmt.Fprintf(&b, "Labels: %s\n", strings.Join(labels, ", "))
+	}
+	if body := strings.TrimSpace(issue.Body); body != "" {
+		fmt.Fprintf(&b, "\n%s\n", body)
+	}
+
+	if err := wm.fs().WriteFile(target, []byte(b.String()), 0644); err != nil {
+		return errors.Wrapf(err, "failed to write %s", target)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package wsm
+
+import (
+	"slices"
+	"testing"
+)
+
+func testIssue(number int, title string, labels ...string) *GitHubIssue {
+	issue := &GitHubIssue{Number: number, Title: title, Link: IssueLink{Ref: "Acme/API#142", Tracker: IssueTrackerGitHub}}
+	for _, label := range labels {
+		issue.Labels = append(issue.Labels, struct {
+			Name string `json:"name"`
+		}{label})
+	}
+	return issue
+}
+
+func TestIssueWorkspaceName(t *testing.T) {
+	tests := map[string]string{
+		"Fix login redirect loop":  "142-fix-login-redirect-loop",
+		"[UI] Crash on start-up!!": "142-ui-crash-on-start-up",
+		"":                         "142",
+		// Words that do not fit in 40 characters are dropped
+		"Support configuring the retention period of archived workspaces": "142-support-configuring-the-retention",
+	}
+	for title, want := range tests {
+		if got := IssueWorkspaceName(testIssue(142, title)); got != want {
+			t.Errorf("IssueWorkspaceName(%q) = %q, want %q", title, got, want)
+		}
+	}
+}
+
+func TestIssueBranchPrefix(t *testing.T) {
+	tests := []struct {
+		labels []string
+		want   string
+	}{
+		{[]string{"Bug"}, "fix"},
+		{[]string{"priority", "enhancement", "bug"}, "feature"},
+		{[]string{"question"}, ""},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		if got := IssueBranchPrefix(testIssue(1, "", tt.labels...)); got != tt.want {
+			t.Errorf("IssueBranchPrefix(%q) = %q, want %q", tt.labels, got, tt.want)
+		}
+	}
+}
+
+func TestIssueRepositories(t *testing.T) {
+	wm := newTestWorkspaceManager(t)
+	wm.Discoverer.registry.Repositories = []Repository{
+		{Name: "api", RemoteURL: "git@github.com:acme/api.git"},
+		{Name: "api-fork", RemoteURL: "https://github.com/me/api"},
+		{Name: "web", RemoteURL: "https://github.com/acme/web.git"},
+		{Name: "local"},
+	}
+	wm.config.Issues.LabelRepos = map[string][]string{"frontend": {"web", "api"}, "docs": {"docs"}}
+
+	if got := wm.IssueRepositories(testIssue(142, "", "Frontend")); !slices.Equal(got, []string{"api", "web"}) {
+		t.Errorf("IssueRepositories = %q, want the issue repository and the frontend repositories", got)
+	}
+	issue := testIssue(7, "", "question")
+	issue.Link.Ref = "other/project#7"
+	if got := wm.IssueRepositories(issue); len(got) != 0 {
+		t.Errorf("IssueRepositories of an unknown repository = %q", got)
+	}
+}
@@ -0,0 +1,132 @@
+package wsm
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	IssueTrackerGitHub = "github"
+	IssueTrackerJira   = "jira"
+	IssueTrackerURL    = "url"
+)
+
+var (
+	jiraKeyPattern     = regexp.MustCompile(`^[A-Z][A-Z0-9_]+-[0-9]+$`)
+	githubRefPattern   = regexp.MustCompile(`^([\w.-]+)/([\w.-]+)#([0-9]+)$`)
+	githubIssuePattern = regexp.MustCompile(`^/([\w.-]+)/([\w.-]+)/(?:issues|pull)/([0-9]+)/?$`)
+	jiraBrowsePattern  = regexp.MustCompile(`/browse/([A-Z][A-Z0-9_]+-[0-9]+)/?$`)
+)
+
+// IssuesConfig configures how issue references are resolved to URLs
+type IssuesConfig struct {
+	// LabelRepos maps issue labels to the repositories 'create --from-issue' adds for them
+	LabelRepos map[string][]string `json:"label_repos,omitempty" yaml:"label_repos,omitempty"`
+	// Trailers are the ticket references 'wsm commit' appends to commit messages
+	Trailers CommitTrailerConfig `json:"trailers" yaml:"trailers"`
+	// Jira turns Jira keys into links, and reads and transitions linked tickets
+	Jira JiraConfig `json:"jira" yaml:"jira"`
+}
+
+// IssueLink is a ticket or issue linked to a workspace
+type IssueLink struct {
+	// Ref is the short reference: PROJ-123, owner/repo#12, or the URL for other trackers
+	Ref     string    `json:"ref"`
+	URL     string    `json:"url,omitempty"`
+	Tracker string    `json:"tracker"`
+	Linked  time.Time `json:"linked"`
+	// Summary and Status are read from the tracker; Refreshed is when they were last read
+	Summary   string    `json:"summary,omitempty"`
+	Status    string    `json:"status,omitempty"`
+	Refreshed time.Time `json:"refreshed,omitzero"`
+}
+
+// ParseIssueReference turns a Jira key, a GitHub owner/repo#N reference or an issue URL into a link
+func ParseIssueReference(reference string, config IssuesConfig) (IssueLink, error) {
+	reference = strings.TrimSpace(reference)
+
+	if jiraKeyPattern.MatchString(reference) {
+		link := IssueLink{Ref: reference, Tracker: IssueTrackerJira}
+		if config.Jira.URL != "" {
+			link.URL = strings.TrimSuffix(config.Jira.URL, "/") + "/browse/" + reference
+		}
+		return link, nil
+	}
+
+	if match := githubRefPattern.FindStringSubmatch(reference); match != nil {
+		return IssueLink{
+			Ref:     reference,
+			URL:     fmt.Sprintf("https://github.com/%s/%s/issues/%s", match[1], match[2], match[3]),
+			Tracker: IssueTrackerGitHub,
+		}, nil
+	}
+
+	parsed, err := url.Parse(reference)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return IssueLink{}, errors.Errorf("invalid issue reference '%s': expected an issue URL, a Jira key (PROJ-123) or owner/repo#123", reference)
+	}
+
+	if parsed.Host == "github.com" {
+		if match := githubIssuePattern.FindStringSubmatch(parsed.Path); match != nil {
+			return IssueLink{
+				Ref:     fmt.Sprintf("%s/%s#%s", match[1], match[2], match[3]),
+				URL:     reference,
+				Tracker: IssueTrackerGitHub,
+			}, nil
+		}
+	}
+	if match := jiraBrowsePattern.FindStringSubmatch(parsed.Path); match != nil {
+		return IssueLink{Ref: match[1], URL: reference, Tracker: IssueTrackerJira}, nil
+	}
+
+	return IssueLink{Ref: reference, URL: reference, Tracker: IssueTrackerURL}, nil
+}
+
+// LinkIssue adds an issue to the workspace metadata; it returns false when it was already linked
+func (wm *WorkspaceManager) LinkIssue(workspace *Workspace, link IssueLink) (bool, error) {
+	for _, existing := range workspace.Issues {
+		if existing.Ref == link.Ref {
+			return false, nil
+		}
+	}
+	if link.Linked.IsZero() {
+		link.Linked = time.Now()
+	}
+	workspace.Issues = append(workspace.Issues, link)
+	return true, wm.SaveWorkspace(workspace)
+}
+
+// UnlinkIssue removes an issue, given by reference or URL, from the workspace metadata
+func (wm *WorkspaceManager) UnlinkIssue(workspace *Workspace, reference string) error {
+	for i, existing := range workspace.Issues {
+		if existing.Ref == reference || (existing.URL != "" && existing.URL == reference) {
+			workspace.Issues = append(workspace.Issues[:i], workspace.Issues[i+1:]...)
+			return wm.SaveWorkspace(workspace)
+		}
+	}
+	return errors.Errorf("issue '%s' is not linked to workspace '%s'", reference, workspace.Name)
+}
+
+// IssueRefs returns the short references of the linked issues
+func IssueRefs(issues []IssueLink) []string {
+	refs := make([]string, len(issues))
+	for i, issue := range issues {
+		refs[i] = issue.Ref
+	}
+	return refs
+}
+
+// ExpandIssueTemplate replaces {ticket} and {summary} in a commit message or pull request
+// template with the reference and summary of the first issue linked to the workspace
+func ExpandIssueTemplate(template string, issues []IssueLink) string {
+	ticket, summary := "", ""
+	if len(issues) > 0 {
+		ticket, summary = issues[0].Ref, issues[0].Summary
+	}
+	return strings.NewReplacer("{ticket}", ticket, "{summary}", summary).Replace(template)
+}
@@ -0,0 +1,40 @@
+package wsm
+
+import "testing"
+
+func TestExpandIssueTemplate(t *testing.T) {
+	issues := []IssueLink{
+		{Ref: "PROJ-1", Summary: "Export invoices as CSV"},
+		{Ref: "PROJ-2", Summary: "Other"},
+	}
+	tests := []struct {
+		template string
+		issues   []IssueLink
+		want     string
+	}{
+		{template: "{ticket}: {summary}", issues: issues, want: "PROJ-1: Export invoices as CSV"},
+		{template: "fix: {summary} ({ticket}, {ticket})", issues: issues, want: "fix: Export invoices as CSV (PROJ-1, PROJ-1)"},
+		{template: "no placeholders", issues: issues, want: "no placeholders"},
+		{template: "{ticket}: {summary}", want: ": "},
+		{template: "{summary}", issues: []IssueLink{{Ref: "acme/app#1"}}, want: ""},
+	}
+	for _, tt := range tests {
+		if got := ExpandIssueTemplate(tt.template, tt.issues); got != tt.want {
+			t.Errorf("ExpandIssueTemplate(%q) = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+}
+
+func TestParseIssueReferenceLinksJiraKeys(t *testing.T) {
+	config := IssuesConfig{Jira: JiraConfig{URL: "https://acme.atlassian.net/"}}
+	link, err := ParseIssueReference("PROJ-123", config)
+	if err != nil {
+		t.Fatalf("ParseIssueReference failed: %v", err)
+	}
+	if link.Tracker != IssueTrackerJira || link.URL != "https://acme.atlassian.net/browse/PROJ-123" {
+		t.Errorf("link = %+v", link)
+	}
+	if link, _ := ParseIssueReference("PROJ-123", IssuesConfig{}); link.URL != "" {
+		t.Errorf("a Jira key should have no URL without issues.jira.url, got %q", link.URL)
+	}
+}
@@ -0,0 +1,274 @@
+package wsm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// defaultJiraTokenEnv holds the Jira API token unless jira.token_env names another variable
+const defaultJiraTokenEnv = "JIRA_API_TOKEN"
+
+// JiraConfig configures the Jira instance Jira keys link to, and its REST API, used to read the
+// summary and status of linked tickets and to move them along the workflow
+type JiraConfig struct {
+	// URL is the base URL of the Jira instance, e.g. https://acme.atlassian.net
+	URL string `json:"url,omitempty" yaml:"url,omitempty"`
+	// Email is the Jira Cloud account the API token belongs to (default: $JIRA_EMAIL). Without
+	// it, the token is sent as a personal access token (Jira Server and Data Center).
+	Email string `json:"email,omitempty" yaml:"email,omitempty"`
+	// TokenEnv is the environment variable holding the API token (default: JIRA_API_TOKEN)
+	TokenEnv string `json:"token_env,omitempty" yaml:"token_env,omitempty"`
+	// Transitions are the statuses tickets are moved to by workspace-manager commands
+	Transitions JiraTransitions `json:"transitions" yaml:"transitions"`
+}
+
+// JiraTransitions name the statuses linked tickets are moved to, e.g. "In Progress"; empty
+// leaves the ticket alone
+type JiraTransitions struct {
+	// Create applies to the tickets of a new workspace
+	Create string `json:"create,omitempty" yaml:"create,omitempty"`
+	// PR applies once 'wsm pr' created pull requests
+	PR string `json:"pr,omitempty" yaml:"pr,omitempty"`
+}
+
+// Validate checks the Jira URL, which the Jira transitions require
+func (c IssuesConfig) Validate() error {
+	if c.Jira.URL != "" {
+		parsed, err := url.Parse(c.Jira.URL)
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			return errors.Errorf("invalid issues.jira.url '%s': expected a URL such as https://acme.atlassian.net", c.Jira.URL)
+		}
+		return nil
+	}
+	if c.Jira.Transitions.Create != "" || c.Jira.Transitions.PR != "" {
+		return errors.New("issues.jira.transitions requires issues.jira.url")
+	}
+	return nil
+}
+
+// JiraIssue is the metadata of a Jira ticket
+type JiraIssue struct {
+	Key     string
+	Summary string
+	Status  string
+}
+
+// JiraClient talks to the REST API (v2) of a Jira instance
+type JiraClient struct {
+	BaseURL string
+	Client  *http.Client
+
+	email string
+	token string
+}
+
+// NewJiraClient creates a client from the issues section of config.yaml and the API token in the
+// environment
+func NewJiraClient(config IssuesConfig) (*JiraClient, error) {
+	if config.Jira.URL == "" {
+		return nil, errors.New("issues.jira.url is not set in config.yaml")
+	}
+	tokenEnv := config.Jira.TokenEnv
+	if tokenEnv == "" {
+		tokenEnv = defaultJiraTokenEnv
+	}
+	token := os.Getenv(tokenEnv)
+	if token == "" {
+		return nil, errors.Errorf("no Jira API token: set $%s", tokenEnv)
+	}
+	email := config.Jira.Email
+	if email == "" {
+		email = os.Getenv("JIRA_EMAIL")
+	}
+	return &JiraClient{
+		BaseURL: strings.TrimSuffix(config.Jira.URL, "/"),
+		Client:  &http.Client{Timeout: 15 * time.Second},
+		email:   email,
+		token:   token,
+	}, nil
+}
+
+// Issue reads the summary and status of a ticket
+func (c *JiraClient) Issue(ctx context.Context, key string) (*JiraIssue, error) {
+	var response struct {
+		Key    string `json:"key"`
+		Fields struct {
+			Summary string `json:"summary"`
+			Status  struct {
+				Name string `json:"name"`
+			} `json:"status"`
+		} `json:"fields"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/rest/api/2/issue/"+url.PathEscape(key)+"?fields=summary,status", nil, &response); err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", key)
+	}
+	return &JiraIssue{Key: response.Key, Summary: response.Fields.Summary, Status: response.Fields.Status.Name}, nil
+}
+
+// Transition moves a ticket to status, given as the name of the transition or of the status it
+// leads to (case-insensitive). It returns false when the ticket already has that status.
+func (c *JiraClient) Transition(ctx context.Context, key, status string) (bool, error) {
+	issue, err := c.Issue(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	moved, err := c.transition(ctx, issue, status)
+	return moved != "", err
+}
+
+// transition moves the ticket and returns the status it now has, as named by Jira, or "" when it
+// already had status
+func (c *JiraClient) transition(ctx context.Context, issue *JiraIssue, status string) (string, error) {
+	if strings.EqualFold(issue.Status, status) {
+		return "", nil
+	}
+
+	var response struct {
+		Transitions []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+			To   struct {
+				Name string `json:"name"`
+			} `json:"to"`
+		} `json:"transitions"`
+	}
+	path := "/rest/api/2/issue/" + url.PathEscape(issue.Key) + "/transitions"
+	if err := c.do(ctx, http.MethodGet, path, nil, &response); err != nil {
+		return "", errors.Wrapf(err, "failed to list the transitions of %s", issue.Key)
+	}
+
+	var available []string
+	for _, transition := range response.Transitions {
+		if strings.EqualFold(transition.To.Name, status) || strings.EqualFold(transition.Name, status) {
+			body := map[string]any{"transition": map[string]string{"id": transition.ID}}
+			if err := c.do(ctx, http.MethodPost, path, body, nil); err != nil {
+				return "", errors.Wrapf(err, "failed to move %s to '%s'", issue.Key, status)
+			}
+			return transition.To.Name, nil
+		}
+		available = append(available, transition.To.Name)
+	}
+	return "", errors.Errorf("%s cannot move from '%s' to '%s' (available: %s)", issue.Key, issue.Status, status, strings.Join(available, ", "))
+}
+
+func (c *JiraClient) do(ctx context.Context, method, path string, body, result any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return errors.Wrap(err, "failed to encode request")
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reader)
+	if err != nil {
+		return errors.Wrap(err, "failed to create Jira request")
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.email != "" {
+		req.SetBasicAuth(c.email, c.token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to reach %s", c.BaseURL)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return errors.Errorf("Jira returned %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+	if result == nil {
+		return nil
+	}
+	return errors.Wrap(json.NewDecoder(resp.Body).Decode(result), "failed to parse the Jira response")
+}
+
+// JiraIssueLinks returns the links of the workspace to Jira tickets
+func JiraIssueLinks(workspace *Workspace) []*IssueLink {
+	var links []*IssueLink
+	for i := range workspace.Issues {
+		if workspace.Issues[i].Tracker == IssueTrackerJira {
+			links = append(links, &workspace.Issues[i])
+		}
+	}
+	return links
+}
+
+// RefreshJiraIssues stores the current summary and status of the Jira tickets linked to the
+// workspace in its metadata. Tickets that cannot be read are reported in the returned errors and
+// keep their previous metadata.
+func (wm *WorkspaceManager) RefreshJiraIssues(ctx context.Context, client *JiraClient, workspace *Workspace) ([]error, error) {
+	var failures []error
+	changed := false
+	for _, link := range JiraIssueLinks(workspace) {
+		issue, err := client.Issue(ctx, link.Ref)
+		if err != nil {
+			failures = append(failures, err)
+			continue
+		}
+		link.Summary = issue.Summary
+		link.Status = issue.Status
+		link.Refreshed = time.Now()
+		changed = true
+	}
+	if !changed {
+		return failures, nil
+	}
+	return failures, wm.SaveWorkspace(workspace)
+}
+
+// TransitionJiraIssues moves the Jira tickets linked to the workspace to status, storing their
+// summary and new status in its metadata. It returns the tickets that were moved; tickets that
+// could not be read or moved are reported in the returned errors.
+func (wm *WorkspaceManager) TransitionJiraIssues(ctx context.Context, client *JiraClient, workspace *Workspace, status string) ([]string, []error, error) {
+	start := time.Now()
+	var moved []string
+	var failures []error
+	changed := false
+	for _, link := range JiraIssueLinks(workspace) {
+		issue, err := client.Issue(ctx, link.Ref)
+		if err != nil {
+			failures = append(failures, err)
+			continue
+		}
+		link.Summary = issue.Summary
+		link.Status = issue.Status
+		link.Refreshed = time.Now()
+		changed = true
+
+		newStatus, err := client.transition(ctx, issue, status)
+		if err != nil {
+			failures = append(failures, err)
+			continue
+		}
+		if newStatus != "" {
+			moved = append(moved, link.Ref)
+			link.Status = newStatus
+		}
+	}
+	if !changed {
+		return nil, failures, nil
+	}
+	if len(moved) > 0 {
+		RecordOperation("jira-transition", workspace.Name, start, map[string]string{
+			"tickets": strings.Join(moved, ","),
+			"status":  status,
+		})
+	}
+	return moved, failures, wm.SaveWorkspace(workspace)
+}
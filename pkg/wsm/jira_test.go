@@ -0,0 +1,190 @@
+package wsm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeJira serves the issues and transitions of the Jira REST API from memory
+type fakeJira struct {
+	mu          sync.Mutex
+	status      map[string]string
+	transitions map[string]string // transition ID to the status it leads to
+	moves       []string
+	auth        []string
+}
+
+func newFakeJira(t *testing.T) (*fakeJira, *httptest.Server) {
+	t.Helper()
+	jira := &fakeJira{
+		status:      map[string]string{"PROJ-1": "To Do"},
+		transitions: map[string]string{"11": "In Progress", "21": "In Review"},
+	}
+	server := httptest.NewServer(http.HandlerFunc(jira.serve))
+	t.Cleanup(server.Close)
+	return jira, server
+}
+
+func (j *fakeJira) serve(w http.ResponseWriter, r *http.Request) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.auth = append(j.auth, r.Header.Get("Authorization"))
+
+	key, transitions := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/rest/api/2/issue/"), "/transitions")
+	status, ok := j.status[key]
+	if !ok {
+		http.Error(w, `{"errorMessages":["Issue does not exist"]}`, http.StatusNotFound)
+		return
+	}
+	switch {
+	case !transitions:
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"key":    key,
+			"fields": map[string]any{"summary": "Export invoices as CSV", "status": map[string]string{"name": status}},
+		})
+	case r.Method == http.MethodGet:
+		var list []map[string]any
+		for _, id := range []string{"11", "21"} {
+			list = append(list, map[string]any{"id": id, "name": "Move to " + j.transitions[id], "to": map[string]string{"name": j.transitions[id]}})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"transitions": list})
+	default:
+		var body struct {
+			Transition struct {
+				ID string `json:"id"`
+			} `json:"transition"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || r.Header.Get("Content-Type") != "application/json" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		j.status[key] = j.transitions[body.Transition.ID]
+		j.moves = append(j.moves, key+" -> "+j.status[key])
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestNewJiraClient(t *testing.T) {
+	t.Setenv("JIRA_API_TOKEN", "")
+	t.Setenv("JIRA_EMAIL", "")
+	if _, err := NewJiraClient(IssuesConfig{}); err == nil || !strings.Contains(err.Error(), "issues.jira.url") {
+		t.Errorf("expected an error without URL, got %v", err)
+	}
+	config := IssuesConfig{Jira: JiraConfig{URL: "https://acme.atlassian.net/", TokenEnv: "ACME_JIRA_TOKEN"}}
+	if _, err := NewJiraClient(config); err == nil || !strings.Contains(err.Error(), "$ACME_JIRA_TOKEN") {
+		t.Errorf("expected an error naming the token variable, got %v", err)
+	}
+	t.Setenv("ACME_JIRA_TOKEN", "secret")
+	client, err := NewJiraClient(config)
+	if err != nil {
+		t.Fatalf("NewJiraClient failed: %v", err)
+	}
+	if client.BaseURL != "https://acme.atlassian.net" || client.email != "" || client.token != "secret" {
+		t.Errorf("client = %+v", client)
+	}
+}
+
+func TestJiraClient(t *testing.T) {
+	jira, server := newFakeJira(t)
+	ctx := context.Background()
+	client := &JiraClient{BaseURL: server.URL, Client: server.Client(), email: "alice@acme.com", token: "secret"}
+
+	issue, err := client.Issue(ctx, "PROJ-1")
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+	if *issue != (JiraIssue{Key: "PROJ-1", Summary: "Export invoices as CSV", Status: "To Do"}) {
+		t.Errorf("issue = %+v", issue)
+	}
+	if user, password, ok := (&http.Request{Header: http.Header{"Authorization": {jira.auth[0]}}}).BasicAuth(); !ok || user != "alice@acme.com" || password != "secret" {
+		t.Errorf("Authorization = %q, want basic auth with the email", jira.auth[0])
+	}
+
+	// Transitions are matched by the status they lead to or by their name, ignoring case
+	if moved, err := client.Transition(ctx, "PROJ-1", "in progress"); err != nil || !moved {
+		t.Fatalf("Transition = %v, %v", moved, err)
+	}
+	if moved, err := client.Transition(ctx, "PROJ-1", "In Progress"); err != nil || moved {
+		t.Errorf("a ticket already in the status should not move: %v, %v", moved, err)
+	}
+	if moved, err := client.Transition(ctx, "PROJ-1", "Move to In Review"); err != nil || !moved {
+		t.Errorf("Transition by name = %v, %v", moved, err)
+	}
+	if _, err := client.Transition(ctx, "PROJ-1", "Done"); err == nil || !strings.Contains(err.Error(), "available: In Progress, In Review") {
+		t.Errorf("expected the available statuses in the error, got %v", err)
+	}
+	if want := []string{"PROJ-1 -> In Progress", "PROJ-1 -> In Review"}; strings.Join(jira.moves, ",") != strings.Join(want, ",") {
+		t.Errorf("moves = %v, want %v", jira.moves, want)
+	}
+
+	if _, err := client.Issue(ctx, "PROJ-404"); err == nil || !strings.Contains(err.Error(), "404") || !strings.Contains(err.Error(), "Issue does not exist") {
+		t.Errorf("expected the Jira error, got %v", err)
+	}
+
+	// Without an email, the token is a personal access token
+	pat := &JiraClient{BaseURL: server.URL, Client: server.Client(), token: "secret"}
+	if _, err := pat.Issue(ctx, "PROJ-1"); err != nil {
+		t.Fatal(err)
+	}
+	if got := jira.auth[len(jira.auth)-1]; got != "Bearer secret" {
+		t.Errorf("Authorization = %q, want a bearer token", got)
+	}
+}
+
+func TestTransitionJiraIssues(t *testing.T) {
+	useTestConfigDir(t)
+	jira, server := newFakeJira(t)
+	wm := newTestWorkspaceManager(t)
+	client := &JiraClient{BaseURL: server.URL, Client: server.Client(), token: "secret"}
+	workspace := &Workspace{Name: "feat", Issues: []IssueLink{
+		{Ref: "PROJ-1", Tracker: IssueTrackerJira},
+		{Ref: "PROJ-404", Tracker: IssueTrackerJira},
+		{Ref: "acme/app#1", Tracker: IssueTrackerGitHub},
+	}}
+
+	moved, failures, err := wm.TransitionJiraIssues(context.Background(), client, workspace, "In Progress")
+	if err != nil {
+		t.Fatalf("TransitionJiraIssues failed: %v", err)
+	}
+	if len(moved) != 1 || moved[0] != "PROJ-1" || len(failures) != 1 {
+		t.Errorf("moved = %v, failures = %v", moved, failures)
+	}
+	if link := workspace.Issues[0]; link.Status != "In Progress" || link.Summary != "Export invoices as CSV" || link.Refreshed.IsZero() {
+		t.Errorf("link = %+v", link)
+	}
+	if len(jira.moves) != 1 {
+		t.Errorf("moves = %v", jira.moves)
+	}
+}
+
+func TestIssuesConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  IssuesConfig
+		wantErr string
+	}{
+		{name: "empty"},
+		{name: "url", config: IssuesConfig{Jira: JiraConfig{URL: "https://acme.atlassian.net"}}},
+		{name: "invalid url", config: IssuesConfig{Jira: JiraConfig{URL: "acme.atlassian.net"}}, wantErr: "invalid issues.jira.url"},
+		{name: "transitions without url", config: IssuesConfig{Jira: JiraConfig{Transitions: JiraTransitions{PR: "In Review"}}}, wantErr: "requires issues.jira.url"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package wsm
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// labelKeyPattern matches label keys: letters, digits and . _ / - inside
+	labelKeyPattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._/-]*[A-Za-z0-9])?$`)
+	// labelValuePattern matches label values, which may be empty
+	labelValuePattern = regexp.MustCompile(`^[A-Za-z0-9._:/@-]*$`)
+)
+
+// LabelChanges are the labels to set and remove, as given to 'wsm label'
+type LabelChanges struct {
+	Set    map[string]string
+	Remove []string
+}
+
+// ParseLabelChanges parses key=value arguments, which set a label, and key- arguments, which
+// remove it
+func ParseLabelChanges(args []string) (LabelChanges, error) {
+	changes := LabelChanges{Set: map[string]string{}}
+	for _, arg := range args {
+		if key, value, ok := strings.Cut(arg, "="); ok {
+			if err := validateLabel(key, value); err != nil {
+				return LabelChanges{}, err
+			}
+			changes.Set[key] = value
+			continue
+		}
+		key, ok := strings.CutSuffix(arg, "-")
+		if !ok || !labelKeyPattern.MatchString(key) {
+			return LabelChanges{}, errors.Errorf("invalid label '%s': expected key=value or key- to remove it", arg)
+		}
+		if _, ok := changes.Set[key]; ok {
+			return LabelChanges{}, errors.Errorf("label '%s' is both set and removed", key)
+		}
+		changes.Remove = append(changes.Remove, key)
+	}
+	return changes, nil
+}
+
+func validateLabel(key, value string) error {
+	if !labelKeyPattern.MatchString(key) {
+		return errors.Errorf("invalid label key '%s': use letters, digits and . _ / -", key)
+	}
+	if !labelValuePattern.MatchString(value) {
+		return errors.Errorf("invalid value '%s' for label '%s': use letters, digits and . _ : / @ -", value, key)
+	}
+	return nil
+}
+
+// LabelWorkspace sets and removes labels of the workspace. It returns whether anything changed.
+func (wm *WorkspaceManager) LabelWorkspace(workspace *Workspace, changes LabelChanges) (bool, error) {
+	start := time.Now()
+	before := maps.Clone(workspace.Labels)
+	if workspace.Labels == nil {
+		workspace.Labels = map[string]string{}
+	}
+	maps.Copy(workspace.Labels, changes.Set)
+	for _, key := range changes.Remove {
+		delete(workspace.Labels, key)
+	}
+	if len(workspace.Labels) == 0 {
+		workspace.Labels = nil
+	}
+	if maps.Equal(before, workspace.Labels) {
+		return false, nil
+	}
+
+	if err := wm.SaveWorkspace(workspace); err != nil {
+		return false, errors.Wrap(err, "failed to save workspace configuration")
+	}
+	RecordOperation("label", workspace.Name, start, map[string]string{"labels": FormatLabels(workspace.Labels)})
+	return true, nil
+}
+
+// FormatLabels renders labels as key=value pairs sorted by key, separated by commas
+func FormatLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for _, key := range slices.Sorted(maps.Keys(labels)) {
+		pairs = append(pairs, key+"="+labels[key])
+	}
+	return strings.Join(pairs, ",")
+}
+
+// LabelSelector matches workspaces by label. It is a comma-separated list of requirements that
+// must all hold: key=value (or key==value), key!=value, key (the label is set) and !key (it is
+// not). The empty selector matches every workspace.
+type LabelSelector struct {
+	requirements []labelRequirement
+}
+
+type labelRequirement struct {
+	key   string
+	value string
+	// op is one of =, !=, exists and !exists
+	op string
+}
+
+// ParseLabelSelector parses a label selector such as "team=payments,env!=prod,!archived"; only
+// the empty string parses to the empty selector
+func ParseLabelSelector(selector string) (LabelSelector, error) {
+	var parsed LabelSelector
+	for _, term := range strings.Split(selector, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+
+		requirement := labelRequirement{}
+		switch {
+		case strings.Contains(term, "!="):
+			requirement.key, requirement.value, _ = strings.Cut(term, "!=")
+			requirement.op = "!="
+		case strings.Contains(term, "="):
+			requirement.key, requirement.value, _ = strings.Cut(term, "=")
+			requirement.value = strings.TrimPrefix(requirement.value, "=")
+			requirement.op = "="
+		case strings.HasPrefix(term, "!"):
+			requirement.key, requirement.op = strings.TrimSpace(term[1:]), "!exists"
+		default:
+			requirement.key, requirement.op = term, "exists"
+		}
+		requirement.key = strings.TrimSpace(requirement.key)
+		requirement.value = strings.TrimSpace(requirement.value)
+		if err := validateLabel(requirement.key, requirement.value); err != nil {
+			return LabelSelector{}, errors.Wrapf(err, "invalid selector '%s'", selector)
+		}
+		parsed.requirements = append(parsed.requirements, requirement)
+	}
+	// A selector that is given but holds no requirement, such as "," or " ", would match every
+	// workspace
+	if selector != "" && parsed.IsEmpty() {
+		return LabelSelector{}, errors.Errorf("invalid selector '%s': it has no requirements", selector)
+	}
+	return parsed, nil
+}
+
+// IsEmpty reports whether the selector matches every workspace
+func (s LabelSelector) IsEmpty() bool {
+	return len(s.requirements) == 0
+}
+
+// Matches reports whether labels satisfy every requirement of the selector
+func (s LabelSelector) Matches(labels map[string]string) bool {
+	for _, requirement := range s.requirements {
+		value, ok := labels[requirement.key]
+		var matched bool
+		switch requirement.op {
+		case "=":
+			matched = ok && value == requirement.value
+		case "!=":
+			matched = !ok || value != requirement.value
+		case "exists":
+			matched = ok
+		case "!exists":
+			matched = !ok
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// Filter returns the workspaces whose labels match the selector
+func (s LabelSelector) Filter(workspaces []Workspace) []Workspace {
+	if s.IsEmpty() {
+		return workspaces
+	}
+	var matched []Workspace
+	for _, workspace := range workspaces {
+		if s.Matches(workspace.Labels) {
+			matched = append(matched, workspace)
+		}
+	}
+	return matched
+}
+
+func (s LabelSelector) String() string {
+	terms := make([]string, len(s.requirements))
+	for i, requirement := range s.requirements {
+		switch requirement.op {
+		case "exists":
+			terms[i] = requirement.key
+		case "!exists":
+			terms[i] = "!" + requirement.key
+		default:
+			terms[i] = fmt.Sprintf("%s%s%s", requirement.key, requirement.op, requirement.value)
+		}
+	}
+	return strings.Join(terms, ",")
+}
@@ -0,0 +1,76 @@
+package wsm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseLabelSelector(t *testing.T) {
+	tests := []struct {
+		selector string
+		want     string
+		wantErr  string
+	}{
+		{selector: "", want: ""},
+		{selector: "team=payments", want: "team=payments"},
+		{selector: "team==payments", want: "team=payments"},
+		{selector: " team = payments , env!=prod ,!archived, keep", want: "team=payments,env!=prod,!archived,keep"},
+		{selector: "team=payments,", want: "team=payments"},
+		{selector: ",", wantErr: "no requirements"},
+		{selector: " ", wantErr: "no requirements"},
+		{selector: ", ,", wantErr: "no requirements"},
+		{selector: "team=pay ments", wantErr: "invalid value"},
+		{selector: "=payments", wantErr: "invalid label key"},
+		{selector: "!", wantErr: "invalid label key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			selector, err := ParseLabelSelector(tt.selector)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseLabelSelector failed: %v", err)
+			}
+			if got := selector.String(); got != tt.want {
+				t.Errorf("ParseLabelSelector(%q) = %q, want %q", tt.selector, got, tt.want)
+			}
+			if selector.IsEmpty() != (tt.selector == "") {
+				t.Errorf("IsEmpty() = %v for %q", selector.IsEmpty(), tt.selector)
+			}
+		})
+	}
+}
+
+func TestLabelSelectorMatches(t *testing.T) {
+	labels := map[string]string{"team": "payments", "env": "staging"}
+	tests := []struct {
+		selector string
+		want     bool
+	}{
+		{selector: "", want: true},
+		{selector: "team=payments", want: true},
+		{selector: "team=search", want: false},
+		{selector: "env!=prod", want: true},
+		{selector: "release!=2026.09", want: true},
+		{selector: "env!=staging", want: false},
+		{selector: "team", want: true},
+		{selector: "archived", want: false},
+		{selector: "!archived", want: true},
+		{selector: "!team", want: false},
+		{selector: "team=payments,!archived,env!=prod", want: true},
+		{selector: "team=payments,archived", want: false},
+	}
+	for _, tt := range tests {
+		selector, err := ParseLabelSelector(tt.selector)
+		if err != nil {
+			t.Fatalf("ParseLabelSelector(%q) failed: %v", tt.selector, err)
+		}
+		if got := selector.Matches(labels); got != tt.want {
+			t.Errorf("%q matches %v = %v, want %v", tt.selector, labels, got, tt.want)
+		}
+	}
+}
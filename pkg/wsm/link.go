@@ -0,0 +1,122 @@
+package wsm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/pkg/errors"
+)
+
+// CreateLinkedWorkspace creates a workspace whose repositories are symbolic links to their
+// registered checkouts instead of new worktrees. The checkouts stay on their branch and nothing
+// is written into them: there are no branch, git config overrides or repository-scoped agent
+// assets, which makes linked workspaces suited for read-only exploration.
+func (wm *WorkspaceManager) CreateLinkedWorkspace(ctx context.Context, name string, repoNames []string, agentSource string, dryRun bool) (*Workspace, error) {
+	workspace, err := wm.planWorkspace(name, repoNames, "", "", agentSource)
+	if err != nil {
+		return nil, err
+	}
+	workspace.Linked = true
+	workspace.GitConfig = nil
+	workspace.AgentAssets = workspaceAgentAssets(workspace.AgentAssets)
+	if dryRun {
+		return workspace, nil
+	}
+
+	if err := wm.establishWorkspace(ctx, workspace, "create", map[string]string{
+		"repos": strings.Join(repoNames, ","),
+		"mode":  "link",
+	}); err != nil {
+		return nil, err
+	}
+
+	return workspace, nil
+}
+
+// RequireWorktrees fails for linked workspaces, whose repositories are the checkouts themselves.
+// Every operation that writes to the repositories of a workspace (branches, commits, files,
+// stashes, git config) or pushes them calls it first, so linked workspaces stay read-only.
+func (w *Workspace) RequireWorktrees(operation string) error {
+	if w.Linked {
+		return errors.Errorf("workspace '%s' links existing checkouts instead of worktrees; %s would change them", w.Name, operation)
+	}
+	return nil
+}
+
+// workspaceAgentAssets keeps the agent assets installed into the workspace root, which is the only
+// place a linked workspace writes to
+func workspaceAgentAssets(assets []AgentAsset) []AgentAsset {
+	var result []AgentAsset
+	for _, asset := range assets {
+		switch asset.Scope {
+		case "", AgentAssetScopeWorkspace:
+			result = append(result, asset)
+		case AgentAssetScopeAll:
+			asset.Scope = AgentAssetScopeWorkspace
+			asset.Repos = nil
+			result = append(result, asset)
+		}
+	}
+	return result
+}
+
+// linkRepository links the checkout of the repository into the workspace under its name. A link
+// left dangling by a checkout that moved is replaced.
+func (wm *WorkspaceManager) linkRepository(workspace *Workspace, repo Repository) error {
+	linkPath := filepath.Join(workspace.Path, repo.Name)
+
+	output.LogInfo(
+		fmt.Sprintf("Linking '%s' to %s", repo.Name, repo.Path),
+		"Linking repository",
+		"repo", repo.Name,
+		"target", repo.Path,
+		"link", linkPath,
+	)
+
+	if _, err := wm.fs().Stat(repo.Path); err != nil {
+		return errors.Wrapf(err, "checkout of '%s' not found", repo.Name)
+	}
+	if info, err := wm.fs().Lstat(linkPath); err == nil {
+		if info.Mode()&os.ModeSymlink == 0 {
+			return errors.Errorf("path already exists: %s", linkPath)
+		}
+		if err := wm.fs().Remove(linkPath); err != nil {
+			return errors.Wrapf(err, "failed to replace link %s", linkPath)
+		}
+	}
+	if err := os.Symlink(repo.Path, linkPath); err != nil {
+		return errors.Wrapf(err, "failed to link %s into the workspace", repo.Path)
+	}
+	return nil
+}
+
+// unlinkRepository removes the link of the repository from the workspace. The checkout it points
+// to is left untouched.
+func (wm *WorkspaceManager) unlinkRepository(workspace *Workspace, repo Repository) error {
+	linkPath := filepath.Join(workspace.Path, repo.Name)
+	info, err := wm.fs().Lstat(linkPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to check %s", linkPath)
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		return errors.Errorf("%s is not a link to the checkout of '%s'", linkPath, repo.Name)
+	}
+
+	output.LogInfo(
+		fmt.Sprintf("Unlinking '%s'", repo.Name),
+		"Unlinking repository",
+		"repo", repo.Name,
+		"link", linkPath,
+	)
+	if err := wm.fs().Remove(linkPath); err != nil {
+		return errors.Wrapf(err, "failed to remove link %s", linkPath)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package wsm
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestLinkedWorkspacesRefuseWrites(t *testing.T) {
+	wm := newTestWorkspaceManager(t)
+	workspace := &Workspace{Name: "look", Path: t.TempDir(), Linked: true}
+	ctx := context.Background()
+	writeGoFiles(t, workspace.Path, map[string]string{"lib/.gitattributes": "secrets/** filter=git-crypt\n"})
+
+	checks := map[string]error{
+		"catch up":         wm.CatchUp(ctx, workspace, RepositoryDrift{Repository: "lib"}, CatchUpRebase, false),
+		"set git config":   wm.SetGitConfig(ctx, workspace, "", "user.email", "me@example.com"),
+		"unset git config": wm.UnsetGitConfig(ctx, workspace, "", "user.email"),
+		"rename":           WriteRename(workspace, &RenamePlan{}),
+		"rewrite module":   WriteModuleRewrite(workspace, &ModuleRewritePlan{}),
+		"broadcast":        WriteBroadcastTarget(ctx, workspace, BroadcastTarget{}, false),
+		"commit":           NewGitOperations(workspace).CommitChanges(ctx, &CommitOperation{Message: "x"}),
+	}
+	_, checks["apply git config"] = wm.ApplyGitConfig(ctx, workspace, false)
+	_, checks["apply pull request"] = wm.ApplyPullRequest(ctx, workspace, "lib", 1, ApplyPROptions{})
+	_, checks["unlock"] = wm.UnlockRepository(ctx, workspace, Repository{Name: "lib"}, false)
+	_, checks["sync"] = NewSyncOperations(workspace).SyncWorkspace(ctx, &SyncOptions{Pull: true})
+	for name, err := range checks {
+		if err == nil || !strings.Contains(err.Error(), "links existing checkouts") {
+			t.Errorf("%s: expected the linked workspace to be refused, got %v", name, err)
+		}
+	}
+
+	workspace.Linked = false
+	if err := workspace.RequireWorktrees("committing"); err != nil {
+		t.Errorf("worktree workspaces should be accepted: %v", err)
+	}
+}
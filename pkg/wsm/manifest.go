@@ -0,0 +1,337 @@
+package wsm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// WorkspaceManifest is a declarative workspace definition converged by 'wsm apply'
+type WorkspaceManifest struct {
+	Name       string `yaml:"name"`
+	Branch     string `yaml:"branch,omitempty"`
+	BaseBranch string `yaml:"base_branch,omitempty"`
+	AgentMD    string `yaml:"agent_md,omitempty"`
+	// AgentAssets replace the agent_assets of config.yaml for this workspace when set
+	AgentAssets  []AgentAsset         `yaml:"agent_assets,omitempty"`
+	Repositories []ManifestRepository `yaml:"repos"`
+	Hooks        ManifestHooks        `yaml:"hooks,omitempty"`
+}
+
+// ManifestRepository is a repository entry of a workspace manifest
+type ManifestRepository struct {
+	Name string `yaml:"name"`
+	// Remote is the clone URL; shared manifests match repositories by it and clone missing ones
+	Remote string `yaml:"remote,omitempty"`
+	// Branch overrides the workspace branch for this repository
+	Branch string `yaml:"branch,omitempty"`
+	// Pin checks out a fixed ref (tag or commit) detached instead of a branch
+	Pin string `yaml:"pin,omitempty"`
+}
+
+// ManifestHooks are shell commands run in the workspace directory
+type ManifestHooks struct {
+	PostApply []string `yaml:"post_apply,omitempty"`
+}
+
+// ManifestPlan lists the changes needed to converge a workspace to its manifest
+type ManifestPlan struct {
+	Create bool
+	Add    []ManifestRepository
+	Remove []string
+	// Repin are existing repositories whose pinned ref differs from the manifest
+	Repin []ManifestRepository
+	// Drift describes differences that apply does not change automatically
+	Drift []string
+	// UpdateAgentFiles is set when AGENT.md or agent assets must be (re)rendered
+	UpdateAgentFiles bool
+}
+
+// IsEmpty reports whether the workspace already matches the manifest
+func (p *ManifestPlan) IsEmpty() bool {
+	return !p.Create && len(p.Add) == 0 && len(p.Remove) == 0 && len(p.Repin) == 0 && !p.UpdateAgentFiles
+}
+
+// LoadManifest reads and validates a workspace manifest
+func LoadManifest(path string) (*WorkspaceManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read manifest: %s", path)
+	}
+
+	var manifest WorkspaceManifest
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse manifest: %s", path)
+	}
+
+	if manifest.Name == "" {
+		return nil, errors.Errorf("manifest %s: name is required", path)
+	}
+	if len(manifest.Repositories) == 0 {
+		return nil, errors.Errorf("manifest %s: at least one repository is required", path)
+	}
+	if manifest.Branch == "" {
+		manifest.Branch = "task/" + manifest.Name
+	}
+
+	seen := make(map[string]bool)
+	for _, repo := range manifest.Repositories {
+		if repo.Name == "" {
+			return nil, errors.Errorf("manifest %s: repository entry without name", path)
+		}
+		if seen[repo.Name] {
+			return nil, errors.Errorf("manifest %s: repository '%s' is listed twice", path, repo.Name)
+		}
+		if repo.Branch != "" && repo.Pin != "" {
+			return nil, errors.Errorf("manifest %s: repository '%s' sets both branch and pin", path, repo.Name)
+		}
+		seen[repo.Name] = true
+	}
+	for _, asset := range manifest.AgentAssets {
+		if err := validateAgentAsset(asset); err != nil {
+			return nil, errors.Wrapf(err, "manifest %s", path)
+		}
+	}
+
+	return &manifest, nil
+}
+
+// PlanManifest compares the manifest with the current workspace state
+func (wm *WorkspaceManager) PlanManifest(ctx context.Context, manifest *WorkspaceManifest) (*ManifestPlan, error) {
+	plan := &ManifestPlan{}
+
+	names := make([]string, len(manifest.Repositories))
+	for i, repo := range manifest.Repositories {
+		names[i] = repo.Name
+	}
+	repos, err := wm.FindRepositories(names)
+	if err != nil {
+		return nil, err
+	}
+	if len(repos) != len(names) {
+		return nil, errors.New("manifest lists a repository twice (by name and alias)")
+	}
+	// Aliases are replaced by the repository names used in the workspace
+	for i := range manifest.Repositories {
+		manifest.Repositories[i].Name = repos[i].Name
+		names[i] = repos[i].Name
+	}
+	for i, repo := range manifest.Repositories {
+		if repo.Pin == "" {
+			continue
+		}
+		if _, err := runGitOutput(ctx, repos[i].Path, "rev-parse", "--verify", repo.Pin+"^{commit}"); err != nil {
+			return nil, errors.Errorf("%s: cannot resolve pin '%s'", repo.Name, repo.Pin)
+		}
+	}
+
+	workspace, err := wm.LoadWorkspace(manifest.Name)
+	if err != nil {
+		plan.Create = true
+		plan.Add = manifest.Repositories
+		plan.UpdateAgentFiles = manifest.AgentMD != "" || len(manifest.AgentAssets) > 0
+		return plan, nil
+	}
+
+	if manifest.Branch != workspace.Branch {
+		plan.Drift = append(plan.Drift, fmt.Sprintf("workspace branch is '%s', manifest wants '%s'", workspace.Branch, manifest.Branch))
+	}
+
+	current := make(map[string]bool)
+	for _, repo := range workspace.Repositories {
+		current[repo.Name] = true
+		if !slices.Contains(names, repo.Name) {
+			plan.Remove = append(plan.Remove, repo.Name)
+		}
+	}
+
+	for _, repo := range manifest.Repositories {
+		if !current[repo.Name] {
+			plan.Add = append(plan.Add, repo)
+			continue
+		}
+
+		worktreePath := filepath.Join(workspace.Path, repo.Name)
+		branch, _ := runGitOutput(ctx, worktreePath, "branch", "--show-current")
+		switch {
+		case repo.Pin != "":
+			head, _ := runGitOutput(ctx, worktreePath, "rev-parse", "HEAD")
+			pinned, err := runGitOutput(ctx, worktreePath, "rev-parse", repo.Pin+"^{commit}")
+			if err != nil {
+				plan.Drift = append(plan.Drift, fmt.Sprintf("%s: cannot resolve pin '%s'", repo.Name, repo.Pin))
+			} else if head != pinned || branch != "" {
+				plan.Repin = append(plan.Repin, repo)
+			}
+		case repo.Branch != "" && branch != repo.Branch:
+			plan.Drift = append(plan.Drift, fmt.Sprintf("%s: on branch '%s', manifest wants '%s'", repo.Name, branch, repo.Branch))
+		}
+	}
+
+	agentAssets := manifest.AgentAssets
+	if len(agentAssets) == 0 {
+		agentAssets = workspace.AgentAssets
+	}
+	plan.UpdateAgentFiles = (manifest.AgentMD != "" && manifest.AgentMD != workspace.AgentMD) ||
+		!reflect.DeepEqual(agentAssets, workspace.AgentAssets) ||
+		((workspace.AgentMD != "" || len(agentAssets) > 0) && (len(plan.Add) > 0 || len(plan.Remove) > 0))
+
+	return plan, nil
+}
+
+// ApplyManifest converges the workspace to the manifest following plan: it creates the workspace
+// or missing worktrees, removes extra worktrees, re-pins repositories, updates go.work and agent
+// files and finally runs the post-apply hooks. existingBranch is how repositories whose branch
+// already exists are added (see CreateWorktreeForAdd).
+func (wm *WorkspaceManager) ApplyManifest(ctx context.Context, manifest *WorkspaceManifest, plan *ManifestPlan, existingBranch string) (*Workspace, error) {
+	var workspace *Workspace
+	add := plan.Add
+	if plan.Create {
+		// Repositories following the workspace branch are created together; overrides are added below
+		var names []string
+		add = nil
+		for _, repo := range plan.Add {
+			if repo.Branch == "" && repo.Pin == "" {
+				names = append(names, repo.Name)
+			} else {
+				add = append(add, repo)
+			}
+		}
+
+		// The agent assets of the manifest replace those of config.yaml for this workspace only, so
+		// it is created by a copy of the manager with its own configuration
+		creator := wm
+		if len(manifest.AgentAssets) > 0 {
+			config := *wm.config
+			config.AgentAssets = slices.Clone(manifest.AgentAssets)
+			scoped := *wm
+			scoped.config = &config
+			creator = &scoped
+		}
+		created, err := creator.CreateWorkspace(ctx, manifest.Name, names, manifest.Branch, manifest.BaseBranch, manifest.AgentMD, false)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create workspace")
+		}
+		workspace = created
+	} else {
+		existing, err := wm.LoadWorkspace(manifest.Name)
+		if err != nil {
+			return nil, err
+		}
+		workspace = existing
+	}
+
+	for _, name := range plan.Remove {
+		if err := wm.RemoveRepositoryFromWorkspace(ctx, workspace.Name, name, false, false); err != nil {
+			return nil, errors.Wrapf(err, "failed to remove repository '%s'", name)
+		}
+	}
+
+	for _, repo := range add {
+		if repo.Pin == "" {
+			if err := wm.AddRepositoryToWorkspace(ctx, workspace.Name, repo.Name, repo.Branch, existingBranch); err != nil {
+				return nil, errors.Wrapf(err, "failed to add repository '%s'", repo.Name)
+			}
+			continue
+		}
+		if err := wm.addPinnedRepository(ctx, workspace.Name, repo); err != nil {
+			return nil, err
+		}
+	}
+
+	reloaded, err := wm.LoadWorkspace(workspace.Name)
+	if err != nil {
+		return nil, err
+	}
+	workspace = reloaded
+
+	for _, repo := range plan.Repin {
+		worktreePath := filepath.Join(workspace.Path, repo.Name)
+		if status, err := runGitOutput(ctx, worktreePath, "status", "--porcelain"); err != nil || status != "" {
+			return nil, errors.Errorf("cannot pin %s to %s: worktree has uncommitted changes", repo.Name, repo.Pin)
+		}
+		if _, err := runGitOutput(ctx, worktreePath, "checkout", "--detach", repo.Pin); err != nil {
+			return nil, errors.Wrapf(err, "failed to pin %s to %s", repo.Name, repo.Pin)
+		}
+		output.PrintInfo("Pinned %s to %s", repo.Name, repo.Pin)
+	}
+
+	if err := wm.UpdateBuildManifests(ctx, workspace); err != nil {
+		return nil, err
+	}
+
+	if plan.UpdateAgentFiles && !plan.Create {
+		if manifest.AgentMD != "" {
+			workspace.AgentMD = manifest.AgentMD
+		}
+		if len(manifest.AgentAssets) > 0 {
+			workspace.AgentAssets = slices.Clone(manifest.AgentAssets)
+		}
+		if workspace.AgentMD != "" {
+			if err := wm.copyAgentMD(workspace); err != nil {
+				return nil, err
+			}
+		}
+		if err := wm.installAgentAssets(workspace); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := wm.SaveWorkspace(workspace); err != nil {
+		return nil, errors.Wrap(err, "failed to save workspace configuration")
+	}
+
+	for _, hook := range manifest.Hooks.PostApply {
+		output.PrintInfo("Running hook: %s", hook)
+		cmd := exec.CommandContext(ctx, "sh", "-c", hook)
+		cmd.Dir = workspace.Path
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return workspace, errors.Wrapf(err, "post_apply hook failed: %s", hook)
+		}
+	}
+
+	return workspace, nil
+}
+
+// addPinnedRepository adds a repository worktree detached at the pinned ref
+func (wm *WorkspaceManager) addPinnedRepository(ctx context.Context, workspaceName string, pinned ManifestRepository) error {
+	start := time.Now()
+	workspace, err := wm.LoadWorkspace(workspaceName)
+	if err != nil {
+		return err
+	}
+
+	repos, err := wm.FindRepositories([]string{pinned.Name})
+	if err != nil {
+		return err
+	}
+	repo := repos[0]
+
+	targetPath := filepath.Join(workspace.Path, repo.Name)
+	output.PrintInfo("Adding repository '%s' pinned at %s", repo.Name, pinned.Pin)
+	if err := wm.ExecuteWorktreeCommand(ctx, repo.Path, "git", "worktree", "add", "--detach", targetPath, pinned.Pin); err != nil {
+		return errors.Wrapf(err, "failed to create pinned worktree for %s", repo.Name)
+	}
+
+	workspace.Repositories = append(workspace.Repositories, repo)
+	recordBranchPoint(ctx, workspace, repo.Name)
+	if err := wm.SaveWorkspace(workspace); err != nil {
+		return err
+	}
+
+	RecordOperation("add", workspace.Name, start, map[string]string{
+		"repo": repo.Name,
+		"pin":  pinned.Pin,
+	})
+	return nil
+}
@@ -0,0 +1,287 @@
+package wsm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// GitCall is one git invocation observed by an InstrumentedRunner
+type GitCall struct {
+	Subcommand string
+	Dir        string
+	Start      time.Time
+	Duration   time.Duration
+	Err        error
+}
+
+// InstrumentedRunner wraps a CommandRunner and reports every git invocation to Observe
+type InstrumentedRunner struct {
+	Runner  CommandRunner
+	Observe func(GitCall)
+}
+
+// Output implements CommandRunner
+func (r InstrumentedRunner) Output(ctx context.Context, dir, name string, args ...string) ([]byte, error) {
+	start := time.Now()
+	out, err := runnerOrDefault(r.Runner).Output(ctx, dir, name, args...)
+	r.observe(dir, name, args, start, err)
+	return out, err
+}
+
+// CombinedOutput implements CommandRunner
+func (r InstrumentedRunner) CombinedOutput(ctx context.Context, dir, name string, args ...string) ([]byte, error) {
+	start := time.Now()
+	out, err := runnerOrDefault(r.Runner).CombinedOutput(ctx, dir, name, args...)
+	r.observe(dir, name, args, start, err)
+	return out, err
+}
+
+func (r InstrumentedRunner) observe(dir, name string, args []string, start time.Time, err error) {
+	if r.Observe == nil || name != "git" {
+		return
+	}
+	subcommand := ""
+	if len(args) > 0 {
+		subcommand = args[0]
+	}
+	r.Observe(GitCall{Subcommand: subcommand, Dir: dir, Start: start, Duration: time.Since(start), Err: err})
+}
+
+// WorkspaceMetrics is the state of one workspace at collection time
+type WorkspaceMetrics struct {
+	Workspace    string
+	Repositories int
+	Dirty        int
+	Ahead        int
+	Behind       int
+}
+
+// operationMetrics aggregates the journal entries of one operation
+type operationMetrics struct {
+	Count         int
+	DurationCount int
+	DurationSum   time.Duration
+}
+
+// gitMetrics aggregates the git invocations of one subcommand
+type gitMetrics struct {
+	Calls       int
+	Failures    int
+	DurationSum time.Duration
+}
+
+// MetricsCollector gathers workspace health metrics for 'wsm metrics'. Git counters accumulate
+// over the lifetime of the collector, covering the git commands it ran itself.
+type MetricsCollector struct {
+	Runner CommandRunner
+	// Tracer receives the git invocations of each collection, if set
+	Tracer *OTLPExporter
+
+	mu          sync.Mutex
+	workspaces  []WorkspaceMetrics
+	operations  map[string]*operationMetrics
+	git         map[string]*gitMetrics
+	collections int
+	lastError   string
+	lastRun     time.Time
+	lastTook    time.Duration
+}
+
+// NewMetricsCollector creates a collector running git as local processes
+func NewMetricsCollector() *MetricsCollector {
+	return &MetricsCollector{Runner: ExecRunner{}, git: map[string]*gitMetrics{}}
+}
+
+// Collect refreshes the metrics: the workspaces with their dirty repositories and divergence from
+// upstream, and the operations recorded in the journal. It only runs local git commands.
+func (c *MetricsCollector) Collect(ctx context.Context) error {
+	start := time.Now()
+	var calls []GitCall
+	var callsMu sync.Mutex
+	runner := InstrumentedRunner{Runner: c.Runner, Observe: func(call GitCall) {
+		callsMu.Lock()
+		calls = append(calls, call)
+		callsMu.Unlock()
+	}}
+
+	workspaces, err := LoadWorkspaces()
+	var collected []WorkspaceMetrics
+	if err == nil {
+		for _, workspace := range workspaces {
+			collected = append(collected, collectWorkspaceMetrics(ctx, runner, workspace))
+		}
+	}
+
+	var operations map[string]*operationMetrics
+	if err == nil {
+		operations, err = collectOperationMetrics()
+	}
+
+	c.mu.Lock()
+	c.collections++
+	c.lastRun = start
+	c.lastTook = time.Since(start)
+	c.lastError = ""
+	if err != nil {
+		c.lastError = err.Error()
+	} else {
+		c.workspaces = collected
+		c.operations = operations
+	}
+	for _, call := range calls {
+		m := c.git[call.Subcommand]
+		if m == nil {
+			m = &gitMetrics{}
+			c.git[call.Subcommand] = m
+		}
+		m.Calls++
+		m.DurationSum += call.Duration
+		if call.Err != nil {
+			m.Failures++
+		}
+	}
+	c.mu.Unlock()
+
+	if c.Tracer != nil {
+		if traceErr := c.Tracer.ExportCollection(ctx, start, time.Since(start), calls); traceErr != nil && err == nil {
+			err = errors.Wrap(traceErr, "failed to export traces")
+		}
+	}
+	return err
+}
+
+func collectWorkspaceMetrics(ctx context.Context, runner CommandRunner, workspace Workspace) WorkspaceMetrics {
+	m := WorkspaceMetrics{Workspace: workspace.Name, Repositories: len(workspace.Repositories)}
+	for _, repo := range workspace.Repositories {
+		repoPath := filepath.Join(workspace.Path, repo.Name)
+		// One command covers both: changed files and "# branch.ab +<ahead> -<behind>" when an upstream is set
+		status, err := gitOutput(ctx, runner, repoPath, "status", "--porcelain=v2", "--branch")
+		if err != nil {
+			continue
+		}
+		dirty := false
+		for _, line := range strings.Split(status, "\n") {
+			if fields := strings.Fields(line); len(fields) == 4 && fields[1] == "branch.ab" {
+				ahead, _ := strconv.Atoi(strings.TrimPrefix(fields[2], "+"))
+				behind, _ := strconv.Atoi(strings.TrimPrefix(fields[3], "-"))
+				m.Ahead += ahead
+				m.Behind += behind
+			} else if line != "" && !strings.HasPrefix(line, "#") {
+				dirty = true
+			}
+		}
+		if dirty {
+			m.Dirty++
+		}
+	}
+	return m
+}
+
+func collectOperationMetrics() (map[string]*operationMetrics, error) {
+	entries, err := ReadHistory("", time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	operations := map[string]*operationMetrics{}
+	for _, entry := range entries {
+		m := operations[entry.Operation]
+		if m == nil {
+			m = &operationMetrics{}
+			operations[entry.Operation] = m
+		}
+		m.Count++
+		if entry.DurationMs > 0 {
+			m.DurationCount++
+			m.DurationSum += time.Duration(entry.DurationMs) * time.Millisecond
+		}
+	}
+	return operations, nil
+}
+
+// WritePrometheus writes the metrics in the Prometheus text exposition format
+func (c *MetricsCollector) WritePrometheus(w io.Writer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var b strings.Builder
+	metric := func(name, kind, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+
+	metric("wsm_workspaces", "gauge", "Number of workspaces.")
+	fmt.Fprintf(&b, "wsm_workspaces %d\n", len(c.workspaces))
+
+	metric("wsm_workspace_repositories", "gauge", "Number of repositories in a workspace.")
+	for _, ws := range c.workspaces {
+		fmt.Fprintf(&b, "wsm_workspace_repositories{workspace=%s} %d\n", promLabel(ws.Workspace), ws.Repositories)
+	}
+	metric("wsm_workspace_dirty_repositories", "gauge", "Number of repositories with uncommitted changes in a workspace.")
+	for _, ws := range c.workspaces {
+		fmt.Fprintf(&b, "wsm_workspace_dirty_repositories{workspace=%s} %d\n", promLabel(ws.Workspace), ws.Dirty)
+	}
+	metric("wsm_workspace_commits_ahead", "gauge", "Commits not pushed to the upstream branches of a workspace.")
+	for _, ws := range c.workspaces {
+		fmt.Fprintf(&b, "wsm_workspace_commits_ahead{workspace=%s} %d\n", promLabel(ws.Workspace), ws.Ahead)
+	}
+	metric("wsm_workspace_commits_behind", "gauge", "Commits on the upstream branches of a workspace not merged locally.")
+	for _, ws := range c.workspaces {
+		fmt.Fprintf(&b, "wsm_workspace_commits_behind{workspace=%s} %d\n", promLabel(ws.Workspace), ws.Behind)
+	}
+
+	operations := sortedKeys(c.operations)
+	metric("wsm_operations_total", "counter", "Operations recorded in the history journal.")
+	for _, op := range operations {
+		fmt.Fprintf(&b, "wsm_operations_total{operation=%s} %d\n", promLabel(op), c.operations[op].Count)
+	}
+	metric("wsm_operation_duration_seconds", "summary", "Duration of the commands that recorded an operation.")
+	for _, op := range operations {
+		m := c.operations[op]
+		fmt.Fprintf(&b, "wsm_operation_duration_seconds_sum{operation=%s} %g\n", promLabel(op), m.DurationSum.Seconds())
+		fmt.Fprintf(&b, "wsm_operation_duration_seconds_count{operation=%s} %d\n", promLabel(op), m.DurationCount)
+	}
+
+	subcommands := sortedKeys(c.git)
+	metric("wsm_git_commands_total", "counter", "Git commands run by the collector.")
+	for _, sub := range subcommands {
+		fmt.Fprintf(&b, "wsm_git_commands_total{subcommand=%s} %d\n", promLabel(sub), c.git[sub].Calls)
+	}
+	metric("wsm_git_command_failures_total", "counter", "Git commands run by the collector that failed.")
+	for _, sub := range subcommands {
+		fmt.Fprintf(&b, "wsm_git_command_failures_total{subcommand=%s} %d\n", promLabel(sub), c.git[sub].Failures)
+	}
+	metric("wsm_git_command_duration_seconds_total", "counter", "Time spent in git commands run by the collector.")
+	for _, sub := range subcommands {
+		fmt.Fprintf(&b, "wsm_git_command_duration_seconds_total{subcommand=%s} %g\n", promLabel(sub), c.git[sub].DurationSum.Seconds())
+	}
+
+	metric("wsm_collections_total", "counter", "Metric collections run.")
+	fmt.Fprintf(&b, "wsm_collections_total %d\n", c.collections)
+	metric("wsm_collection_duration_seconds", "gauge", "Duration of the last metric collection.")
+	fmt.Fprintf(&b, "wsm_collection_duration_seconds %g\n", c.lastTook.Seconds())
+	metric("wsm_collection_last_timestamp_seconds", "gauge", "Unix time of the last metric collection.")
+	fmt.Fprintf(&b, "wsm_collection_last_timestamp_seconds %d\n", c.lastRun.Unix())
+	metric("wsm_collection_success", "gauge", "Whether the last metric collection succeeded.")
+	success := 1
+	if c.lastError != "" {
+		success = 0
+	}
+	fmt.Fprintf(&b, "wsm_collection_success %d\n", success)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var promLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// promLabel quotes a label value for the Prometheus text format
+func promLabel(value string) string {
+	return `"` + promLabelEscaper.Replace(value) + `"`
+}
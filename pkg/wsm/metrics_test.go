@@ -0,0 +1,66 @@
+package wsm
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPromLabel(t *testing.T) {
+	tests := map[string]string{
+		"feat":       `"feat"`,
+		`say "hi"`:   `"say \"hi\""`,
+		`C:\ws`:      `"C:\\ws"`,
+		"two\nlines": `"two\nlines"`,
+		"":           `""`,
+	}
+	for value, want := range tests {
+		if got := promLabel(value); got != want {
+			t.Errorf("promLabel(%q) = %s, want %s", value, got, want)
+		}
+	}
+}
+
+func TestWritePrometheus(t *testing.T) {
+	c := NewMetricsCollector()
+	c.workspaces = []WorkspaceMetrics{{Workspace: `my "ws"`, Repositories: 3, Dirty: 1, Ahead: 2, Behind: 4}}
+	c.operations = map[string]*operationMetrics{
+		"sync":   {Count: 2, DurationCount: 1, DurationSum: 1500 * time.Millisecond},
+		"create": {Count: 1},
+	}
+	c.git["status"] = &gitMetrics{Calls: 3, Failures: 1, DurationSum: 250 * time.Millisecond}
+	c.collections = 1
+	c.lastRun = time.Unix(1700000000, 0)
+	c.lastError = "no workspaces"
+
+	var b strings.Builder
+	if err := c.WritePrometheus(&b); err != nil {
+		t.Fatalf("WritePrometheus failed: %v", err)
+	}
+	out := b.String()
+	for _, line := range []string{
+		"# TYPE wsm_workspaces gauge",
+		"wsm_workspaces 1",
+		`wsm_workspace_repositories{workspace="my \"ws\""} 3`,
+		`wsm_workspace_dirty_repositories{workspace="my \"ws\""} 1`,
+		`wsm_workspace_commits_ahead{workspace="my \"ws\""} 2`,
+		`wsm_workspace_commits_behind{workspace="my \"ws\""} 4`,
+		`wsm_operations_total{operation="sync"} 2`,
+		`wsm_operation_duration_seconds_sum{operation="sync"} 1.5`,
+		`wsm_operation_duration_seconds_count{operation="create"} 0`,
+		`wsm_git_commands_total{subcommand="status"} 3`,
+		`wsm_git_command_failures_total{subcommand="status"} 1`,
+		`wsm_git_command_duration_seconds_total{subcommand="status"} 0.25`,
+		"wsm_collections_total 1",
+		"wsm_collection_last_timestamp_seconds 1700000000",
+		"wsm_collection_success 0",
+	} {
+		if !strings.Contains(out, "\n"+line+"\n") && !strings.HasPrefix(out, line+"\n") {
+			t.Errorf("missing line %q in:\n%s", line, out)
+		}
+	}
+	// Operations are written in a stable order
+	if strings.Index(out, `operation="create"`) > strings.Index(out, `operation="sync"`) {
+		t.Errorf("operations are not sorted:\n%s", out)
+	}
+}
@@ -0,0 +1,509 @@
+package wsm
+
+import (
+	"cmp"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/pkg/errors"
+)
+
+// MigrationSources are the multi-repository tools 'wsm migrate' reads the configuration of
+var MigrationSources = []string{"meta", "gita", "repo"}
+
+// commitPattern matches full commit hashes used as revisions
+var commitPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// MigratedRepository is a repository found in the configuration of another tool
+type MigratedRepository struct {
+	// Name is the registry name of the repository, the base name of its checkout
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	Remote string `json:"remote,omitempty"`
+	// Pin is the tag or commit the repository is fixed at, if any
+	Pin    string   `json:"pin,omitempty"`
+	Groups []string `json:"groups,omitempty"`
+	// Exists is set when the checkout is present on disk
+	Exists bool `json:"exists"`
+}
+
+// Migration is the content of the configuration of another multi-repository tool
+type Migration struct {
+	Source string `json:"source"`
+	Config string `json:"config"`
+	// Root is the directory relative checkout paths are resolved against
+	Root         string               `json:"root,omitempty"`
+	Repositories []MigratedRepository `json:"repositories"`
+	Warnings     []string             `json:"warnings,omitempty"`
+}
+
+// DefaultMigrationConfig returns where a tool keeps its configuration by default, relative to
+// the current directory for meta and repo
+func DefaultMigrationConfig(source string) string {
+	switch source {
+	case "meta":
+		return ".meta"
+	case "repo":
+		return filepath.Join(".repo", "manifest.xml")
+	case "gita":
+		configDir := os.Getenv("XDG_CONFIG_HOME")
+		if configDir == "" {
+			home, _ := os.UserHomeDir()
+			configDir = filepath.Join(home, ".config")
+		}
+		return filepath.Join(configDir, "gita", "repos.csv")
+	}
+	return ""
+}
+
+// ReadMigration reads the configuration of a meta (.meta), gita (repos.csv) or repo
+// (manifest.xml) setup. Relative checkout paths are resolved against root, by default the
+// directory of the .meta file or the directory holding .repo.
+func ReadMigration(ctx context.Context, source, configPath, root string) (*Migration, error) {
+	if configPath == "" {
+		configPath = DefaultMigrationConfig(source)
+	}
+	configPath, err := expandHomePath(configPath)
+	if err != nil {
+		return nil, err
+	}
+	if configPath, err = filepath.Abs(configPath); err != nil {
+		return nil, errors.Wrapf(err, "invalid path: %s", configPath)
+	}
+	if root != "" {
+		if root, err = expandHomePath(root); err != nil {
+			return nil, err
+		}
+		if root, err = filepath.Abs(root); err != nil {
+			return nil, errors.Wrapf(err, "invalid root: %s", root)
+		}
+	}
+
+	migration := &Migration{Source: source, Config: configPath}
+	switch source {
+	case "meta":
+		err = migration.readMeta(configPath, root)
+	case "gita":
+		err = migration.readGita(ctx, configPath)
+	case "repo":
+		err = migration.readRepoManifest(ctx, configPath, root)
+	default:
+		return nil, errors.Errorf("unknown migration source '%s' (expected one of %s)", source, strings.Join(MigrationSources, ", "))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]string{}
+	for i, repo := range migration.Repositories {
+		if _, err := os.Stat(filepath.Join(repo.Path, ".git")); err == nil {
+			migration.Repositories[i].Exists = true
+		}
+		if other, ok := seen[repo.Name]; ok {
+			migration.Warnings = append(migration.Warnings, fmt.Sprintf("%s and %s share the name '%s'; only one of them can be in the registry", other, repo.Path, repo.Name))
+		}
+		seen[repo.Name] = repo.Path
+	}
+	return migration, nil
+}
+
+// metaConfig is the .meta file of meta: checkout directories mapped to clone URLs
+type metaConfig struct {
+	Projects map[string]string `json:"projects"`
+}
+
+func (m *Migration) readMeta(configPath, root string) error {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read %s", configPath)
+	}
+	var config metaConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return errors.Wrapf(err, "failed to parse %s", configPath)
+	}
+	if root == "" {
+		root = filepath.Dir(configPath)
+	}
+	m.Root = root
+
+	dirs := make([]string, 0, len(config.Projects))
+	for dir := range config.Projects {
+		dirs = append(dirs, dir)
+	}
+	slices.Sort(dirs)
+	for _, dir := range dirs {
+		checkout, err := migrationCheckout(root, dir)
+		if err != nil {
+			return errors.Wrapf(err, "invalid project in %s", configPath)
+		}
+		m.Repositories = append(m.Repositories, MigratedRepository{
+			Name:   filepath.Base(checkout),
+			Path:   checkout,
+			Remote: config.Projects[dir],
+		})
+	}
+	return nil
+}
+
+// migrationCheckout resolves the checkout directory of a project against root, rejecting paths
+// that leave it: .meta and manifest.xml files come with the repositories they describe
+func migrationCheckout(root, dir string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(dir)) {
+		return "", errors.Errorf("checkout path '%s' is not inside %s", dir, root)
+	}
+	return filepath.Join(root, filepath.FromSlash(dir)), nil
+}
+
+// readGita reads gita's repos.csv (path,name,type,flags per line; older versions only list
+// paths) and the groups.csv next to it (group:repo repo...:path)
+func (m *Migration) readGita(ctx context.Context, configPath string) error {
+	records, err := readCSV(configPath)
+	if err != nil {
+		return err
+	}
+
+	byName := map[string]int{}
+	for _, record := range records {
+		if len(record) == 0 || strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+		checkout := filepath.Clean(strings.TrimSpace(record[0]))
+		gitaName := filepath.Base(checkout)
+		if len(record) > 1 && strings.TrimSpace(record[1]) != "" {
+			gitaName = strings.TrimSpace(record[1])
+		}
+		byName[gitaName] = len(m.Repositories)
+
+		repo := MigratedRepository{Name: filepath.Base(checkout), Path: checkout}
+		if remote, err := runGitOutput(ctx, checkout, "remote", "get-url", "origin"); err == nil {
+			repo.Remote = remote
+		}
+		m.Repositories = append(m.Repositories, repo)
+	}
+
+	groupsPath := filepath.Join(filepath.Dir(configPath), "groups.csv")
+	data, err := os.ReadFile(groupsPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to read %s", groupsPath)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Split(line, ":")
+		if len(fields) < 2 || strings.TrimSpace(fields[0]) == "" {
+			continue
+		}
+		group := strings.TrimSpace(fields[0])
+		for _, name := range strings.Fields(fields[1]) {
+			i, ok := byName[name]
+			if !ok {
+				m.Warnings = append(m.Warnings, fmt.Sprintf("group %s lists unknown repository '%s'", group, name))
+				continue
+			}
+			m.Repositories[i].Groups = append(m.Repositories[i].Groups, group)
+		}
+	}
+	return nil
+}
+
+func readCSV(configPath string) ([][]string, error) {
+	file, err := os.Open(configPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", configPath)
+	}
+	defer func() { _ = file.Close() }()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", configPath)
+	}
+	return records, nil
+}
+
+// repoManifest is the subset of the repo manifest format that describes projects
+type repoManifest struct {
+	Remotes  []repoRemote  `xml:"remote"`
+	Default  *repoDefault  `xml:"default"`
+	Projects []repoProject `xml:"project"`
+	Removed  []repoProject `xml:"remove-project"`
+	Includes []struct {
+		Name string `xml:"name,attr"`
+	} `xml:"include"`
+}
+
+type repoRemote struct {
+	Name     string `xml:"name,attr"`
+	Fetch    string `xml:"fetch,attr"`
+	Revision string `xml:"revision,attr"`
+}
+
+type repoDefault struct {
+	Remote   string `xml:"remote,attr"`
+	Revision string `xml:"revision,attr"`
+}
+
+type repoProject struct {
+	Name     string `xml:"name,attr"`
+	Path     string `xml:"path,attr"`
+	Remote   string `xml:"remote,attr"`
+	Revision string `xml:"revision,attr"`
+	Groups   string `xml:"groups,attr"`
+}
+
+// readRepoManifest reads a repo manifest and the manifests it includes. Projects are checked
+// out below the directory holding .repo; clone URLs with a relative fetch (..) are resolved
+// against the URL of the manifest repository.
+func (m *Migration) readRepoManifest(ctx context.Context, configPath, root string) error {
+	repoDir := ""
+	for dir := filepath.Dir(configPath); dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if filepath.Base(dir) == ".repo" {
+			repoDir = dir
+			break
+		}
+	}
+	if root == "" {
+		root = filepath.Dir(configPath)
+		if repoDir != "" {
+			root = filepath.Dir(repoDir)
+		}
+	}
+	m.Root = root
+	manifestURL := ""
+	if repoDir != "" {
+		manifestURL, _ = runGitOutput(ctx, filepath.Join(repoDir, "manifests"), "remote", "get-url", "origin")
+	}
+
+	merged := &repoManifest{}
+	if err := loadRepoManifest(configPath, repoDir, merged, map[string]bool{}); err != nil {
+		return err
+	}
+
+	remotes := map[string]repoRemote{}
+	for _, remote := range merged.Remotes {
+		remotes[remote.Name] = remote
+	}
+	defaults := repoDefault{}
+	if merged.Default != nil {
+		defaults = *merged.Default
+	}
+	removed := map[string]bool{}
+	for _, project := range merged.Removed {
+		removed[project.Name] = true
+	}
+
+	for _, project := range merged.Projects {
+		if removed[project.Name] {
+			continue
+		}
+		checkoutPath := project.Path
+		if checkoutPath == "" {
+			checkoutPath = project.Name
+		}
+		checkout, err := migrationCheckout(root, checkoutPath)
+		if err != nil {
+			return errors.Wrapf(err, "invalid project '%s' in %s", project.Name, configPath)
+		}
+		repo := MigratedRepository{Name: filepath.Base(checkout), Path: checkout}
+
+		remoteName := cmp.Or(project.Remote, defaults.Remote)
+		remote, ok := remotes[remoteName]
+		switch {
+		case !ok:
+			m.Warnings = append(m.Warnings, fmt.Sprintf("%s: unknown remote '%s'", project.Name, remoteName))
+		default:
+			fetch, err := resolveFetchURL(manifestURL, remote.Fetch)
+			if err != nil {
+				m.Warnings = append(m.Warnings, fmt.Sprintf("%s: %v", project.Name, err))
+			} else {
+				fetch = strings.TrimSuffix(fetch, "/")
+				if !strings.HasSuffix(fetch, ":") {
+					fetch += "/"
+				}
+				repo.Remote = fetch + project.Name
+			}
+		}
+
+		// Branch revisions are where worktrees start from; tags and commits are kept as pins
+		revision := cmp.Or(project.Revision, remote.Revision, defaults.Revision)
+		switch {
+		case strings.HasPrefix(revision, "refs/tags/"):
+			repo.Pin = strings.TrimPrefix(revision, "refs/tags/")
+		case commitPattern.MatchString(revision):
+			repo.Pin = revision
+		}
+
+		for _, group := range strings.FieldsFunc(project.Groups, func(r rune) bool { return r == ',' || r == ' ' }) {
+			if !strings.HasPrefix(group, "notdefault") {
+				repo.Groups = append(repo.Groups, group)
+			}
+		}
+		m.Repositories = append(m.Repositories, repo)
+	}
+	return nil
+}
+
+// loadRepoManifest merges a manifest and, depth first, the manifests it includes into merged.
+// Includes are looked up next to the manifest, then in .repo/manifests.
+func loadRepoManifest(manifestPath, repoDir string, merged *repoManifest, loading map[string]bool) error {
+	if loading[manifestPath] {
+		return errors.Errorf("manifest %s includes itself", manifestPath)
+	}
+	loading[manifestPath] = true
+	defer delete(loading, manifestPath)
+
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read %s", manifestPath)
+	}
+	var manifest repoManifest
+	if err := xml.Unmarshal(data, &manifest); err != nil {
+		return errors.Wrapf(err, "failed to parse %s", manifestPath)
+	}
+
+	merged.Remotes = append(merged.Remotes, manifest.Remotes...)
+	if manifest.Default != nil {
+		merged.Default = manifest.Default
+	}
+	merged.Projects = append(merged.Projects, manifest.Projects...)
+	merged.Removed = append(merged.Removed, manifest.Removed...)
+
+	for _, include := range manifest.Includes {
+		candidates := []string{filepath.Join(filepath.Dir(manifestPath), include.Name)}
+		if repoDir != "" {
+			candidates = append(candidates, filepath.Join(repoDir, "manifests", include.Name))
+		}
+		found := false
+		for _, candidate := range candidates {
+			if _, err := os.Stat(candidate); err == nil {
+				if err := loadRepoManifest(candidate, repoDir, merged, loading); err != nil {
+					return err
+				}
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.Errorf("%s includes %s, which was not found", manifestPath, include.Name)
+		}
+	}
+	return nil
+}
+
+// resolveFetchURL resolves the fetch URL of a repo remote, which may be relative (.., ../..) to
+// the URL of the manifest repository
+func resolveFetchURL(manifestURL, fetch string) (string, error) {
+	if !strings.HasPrefix(fetch, ".") {
+		return fetch, nil
+	}
+	if manifestURL == "" {
+		return "", errors.Errorf("fetch URL '%s' is relative and the manifest repository URL is unknown", fetch)
+	}
+
+	// The host part (https://host, git@host:) is kept; like a URL reference, the fetch is relative
+	// to the directory of the manifest repository
+	prefix, repoPath := "", manifestURL
+	if i := strings.Index(manifestURL, "://"); i >= 0 {
+		rest := manifestURL[i+3:]
+		slash := strings.Index(rest, "/")
+		if slash < 0 {
+			slash = len(rest)
+		}
+		prefix, repoPath = manifestURL[:i+3+slash], rest[slash:]
+	} else if i := strings.Index(manifestURL, ":"); i >= 0 {
+		prefix, repoPath = manifestURL[:i+1], manifestURL[i+1:]
+	}
+	resolved := path.Join(path.Dir(strings.TrimSuffix(repoPath, "/")), fetch)
+	if resolved == "." {
+		resolved = ""
+	}
+	if strings.HasPrefix(repoPath, "/") && !strings.HasPrefix(resolved, "/") {
+		resolved = "/" + resolved
+	}
+	return prefix + resolved, nil
+}
+
+// Filter keeps the repositories of any of the groups; all of them when no group is given
+func (m *Migration) Filter(groups []string) {
+	if len(groups) == 0 {
+		return
+	}
+	kept := m.Repositories[:0]
+	for _, repo := range m.Repositories {
+		for _, group := range groups {
+			if slices.Contains(repo.Groups, group) {
+				kept = append(kept, repo)
+				break
+			}
+		}
+	}
+	m.Repositories = kept
+}
+
+// Manifest returns a workspace manifest with the migrated repositories, for 'wsm apply'
+func (m *Migration) Manifest(name string) *WorkspaceManifest {
+	manifest := &WorkspaceManifest{Name: name}
+	seen := map[string]bool{}
+	for _, repo := range m.Repositories {
+		if seen[repo.Name] {
+			continue
+		}
+		seen[repo.Name] = true
+		manifest.Repositories = append(manifest.Repositories, ManifestRepository{
+			Name:   repo.Name,
+			Remote: repo.Remote,
+			Pin:    repo.Pin,
+		})
+	}
+	return manifest
+}
+
+// RegisterMigration adds the migrated repositories to the registry, cloning the missing ones
+// from their remote into their checkout path when clone is set. It returns the paths that were
+// cloned; repositories that are missing and not cloned are skipped with a warning.
+func (wm *WorkspaceManager) RegisterMigration(ctx context.Context, migration *Migration, clone bool) ([]string, error) {
+	var paths, cloned []string
+	for i, repo := range migration.Repositories {
+		if !repo.Exists {
+			if !clone || repo.Remote == "" {
+				output.PrintWarning("Skipping %s: %s is not a git checkout", repo.Name, repo.Path)
+				continue
+			}
+			if strings.HasPrefix(repo.Remote, "-") {
+				return cloned, errors.Errorf("invalid remote '%s' of %s", repo.Remote, repo.Name)
+			}
+			output.PrintInfo("Cloning %s into %s", repo.Remote, repo.Path)
+			if err := os.MkdirAll(filepath.Dir(repo.Path), 0755); err != nil {
+				return cloned, errors.Wrapf(err, "failed to create %s", filepath.Dir(repo.Path))
+			}
+			cloneCtx, done := withCloneTimeout(ctx, wm.Config().Timeouts, repo.Name, repo.Remote)
+			_, err := runGitOutput(cloneCtx, "", "clone", "--", repo.Remote, repo.Path)
+			if err = done(err); err != nil {
+				return cloned, errors.Wrapf(err, "failed to clone %s", repo.Remote)
+			}
+			migration.Repositories[i].Exists = true
+			cloned = append(cloned, repo.Path)
+		}
+		paths = append(paths, repo.Path)
+	}
+	if len(paths) == 0 {
+		return cloned, nil
+	}
+
+	if err := wm.Discoverer.DiscoverRepositories(ctx, paths, false, 0); err != nil {
+		return cloned, errors.Wrap(err, "failed to add repositories to the registry")
+	}
+	return cloned, nil
+}
@@ -0,0 +1,153 @@
+package wsm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeMigrationConfig(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadMigrationRejectsCheckoutsOutsideTheRoot(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		config  string
+		content string
+	}{
+		{name: "meta parent directory", source: "meta", config: ".meta", content: `{"projects": {"../escape": "https://example.com/x.git"}}`},
+		{name: "meta nested escape", source: "meta", config: ".meta", content: `{"projects": {"lib/../../escape": "https://example.com/x.git"}}`},
+		{name: "meta absolute", source: "meta", config: ".meta", content: `{"projects": {"/tmp/escape": "https://example.com/x.git"}}`},
+		{name: "repo path", source: "repo", config: "manifest.xml", content: `<manifest><remote name="origin" fetch="https://example.com"/><default remote="origin"/><project name="x" path="../../escape"/></manifest>`},
+		{name: "repo name", source: "repo", config: "manifest.xml", content: `<manifest><remote name="origin" fetch="https://example.com"/><default remote="origin"/><project name="../escape"/></manifest>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			configPath := filepath.Join(root, tt.config)
+			writeMigrationConfig(t, configPath, tt.content)
+
+			_, err := ReadMigration(context.Background(), tt.source, configPath, "")
+			if err == nil || !strings.Contains(err.Error(), "is not inside") {
+				t.Fatalf("expected the checkout to be rejected, got %v", err)
+			}
+		})
+	}
+}
+
+func TestReadMigrationMeta(t *testing.T) {
+	root := t.TempDir()
+	configPath := filepath.Join(root, ".meta")
+	writeMigrationConfig(t, configPath, `{"projects": {"services/api": "git@example.com:org/api.git", "lib": "https://example.com/lib.git"}}`)
+
+	migration, err := ReadMigration(context.Background(), "meta", configPath, "")
+	if err != nil {
+		t.Fatalf("ReadMigration failed: %v", err)
+	}
+	if len(migration.Repositories) != 2 {
+		t.Fatalf("expected 2 repositories, got %+v", migration.Repositories)
+	}
+	lib, api := migration.Repositories[0], migration.Repositories[1]
+	if lib.Name != "lib" || lib.Path != filepath.Join(root, "lib") || lib.Remote != "https://example.com/lib.git" {
+		t.Errorf("unexpected repository %+v", lib)
+	}
+	if api.Name != "api" || api.Path != filepath.Join(root, "services", "api") || api.Remote != "git@example.com:org/api.git" {
+		t.Errorf("unexpected repository %+v", api)
+	}
+}
+
+func TestReadMigrationRepoManifest(t *testing.T) {
+	root := t.TempDir()
+	configPath := filepath.Join(root, "manifest.xml")
+	writeMigrationConfig(t, configPath, `<manifest>
+  <remote name="origin" fetch="https://example.com/org"/>
+  <default remote="origin" revision="main"/>
+  <project name="lib" groups="core"/>
+  <project name="app" path="apps/app" revision="v1.2.0" groups="apps,core"/>
+</manifest>`)
+
+	migration, err := ReadMigration(context.Background(), "repo", configPath, "")
+	if err != nil {
+		t.Fatalf("ReadMigration failed: %v", err)
+	}
+	if len(migration.Repositories) != 2 {
+		t.Fatalf("expected 2 repositories, got %+v", migration.Repositories)
+	}
+	for _, repo := range migration.Repositories {
+		if !strings.HasPrefix(repo.Path, root+string(filepath.Separator)) {
+			t.Errorf("%s is checked out outside the root: %s", repo.Name, repo.Path)
+		}
+	}
+
+	migration.Filter([]string{"apps"})
+	if len(migration.Repositories) != 1 || migration.Repositories[0].Name != "app" {
+		t.Fatalf("expected only app in the apps group, got %+v", migration.Repositories)
+	}
+	app := migration.Repositories[0]
+	if app.Path != filepath.Join(root, "apps", "app") || app.Remote != "https://example.com/org/app" {
+		t.Errorf("unexpected repository %+v", app)
+	}
+}
+
+func TestResolveFetchURL(t *testing.T) {
+	tests := []struct {
+		manifestURL string
+		fetch       string
+		want        string
+		wantErr     bool
+	}{
+		{manifestURL: "https://example.com/org/manifest.git", fetch: "https://other.com", want: "https://other.com"},
+		{manifestURL: "https://example.com/org/manifest.git", fetch: "..", want: "https://example.com/"},
+		{manifestURL: "https://example.com/org/manifests/default.git", fetch: "..", want: "https://example.com/org"},
+		{manifestURL: "git@example.com:org/manifests/default.git", fetch: "..", want: "git@example.com:org"},
+		{manifestURL: "", fetch: "..", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.manifestURL+" "+tt.fetch, func(t *testing.T) {
+			got, err := resolveFetchURL(tt.manifestURL, tt.fetch)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveFetchURL failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("resolveFetchURL(%q, %q) = %q, want %q", tt.manifestURL, tt.fetch, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterMigrationRejectsOptionRemotes(t *testing.T) {
+	wm := newTestWorkspaceManager(t)
+	root := t.TempDir()
+	marker := filepath.Join(root, "pwned")
+	migration := &Migration{Repositories: []MigratedRepository{{
+		Name:   "x",
+		Path:   filepath.Join(root, "x"),
+		Remote: "--upload-pack=touch " + marker,
+	}}}
+
+	_, err := wm.RegisterMigration(context.Background(), migration, true)
+	if err == nil || !strings.Contains(err.Error(), "invalid remote") {
+		t.Fatalf("expected the remote to be rejected, got %v", err)
+	}
+	for _, path := range []string{marker, filepath.Join(root, "x")} {
+		if _, err := os.Stat(path); err == nil {
+			t.Errorf("%s should not exist", path)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package wsm
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	NixFormatFlake = "flake"
+	NixFormatShell = "shell"
+
+	// nixGeneratedHeader marks files written by 'wsm nix' so they can be regenerated safely
+	nixGeneratedHeader = "# Generated by workspace-manager (wsm nix)"
+)
+
+var (
+	goDirective        = regexp.MustCompile(`(?m)^go\s+(\d+\.\d+(?:\.\d+)?)\s*$`)
+	toolchainDirective = regexp.MustCompile(`(?m)^toolchain\s+go(\d+\.\d+(?:\.\d+)?)\s*$`)
+)
+
+// ToolRequirement is a toolchain version required by a repository
+type ToolRequirement struct {
+	Tool       string `json:"tool"`
+	Version    string `json:"version"`
+	Repository string `json:"repository"`
+	// Source is the file the requirement was read from
+	Source string `json:"source"`
+}
+
+// DetectToolRequirements reads the toolchain versions required by the repositories of a
+// workspace: Go from go.mod (toolchain or go directive), Node from .nvmrc or .node-version and
+// Python from .python-version
+func DetectToolRequirements(workspace *Workspace) []ToolRequirement {
+	var requirements []ToolRequirement
+
+	for _, repo := range workspace.Repositories {
+		dir := filepath.Join(workspace.Path, repo.Name)
+
+		if data, err := os.ReadFile(filepath.Join(dir, "go.mod")); err == nil {
+			version := ""
+			if match := toolchainDirective.FindSubmatch(data); match != nil {
+				version = string(match[1])
+			} else if match := goDirective.FindSubmatch(data); match != nil {
+				version = string(match[1])
+			}
+			if version != "" {
+				requirements = append(requirements, ToolRequirement{Tool: "go", Version: version, Repository: repo.Name, Source: "go.mod"})
+			}
+		}
+
+		for _, file := range []string{".nvmrc", ".node-version"} {
+			if version := readVersionFile(filepath.Join(dir, file)); version != "" {
+				requirements = append(requirements, ToolRequirement{Tool: "node", Version: strings.TrimPrefix(version, "v"), Repository: repo.Name, Source: file})
+				break
+			}
+		}
+
+		if version := readVersionFile(filepath.Join(dir, ".python-version")); version != "" {
+			requirements = append(requirements, ToolRequirement{Tool: "python", Version: version, Repository: repo.Name, Source: ".python-version"})
+		}
+	}
+
+	return requirements
+}
+
+// readVersionFile returns the first non-comment line of a version file
+func readVersionFile(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return line
+		}
+	}
+	return ""
+}
+
+// NixPackage is a nixpkgs attribute chosen for a tool, with the requirements it satisfies
+type NixPackage struct {
+	Attribute    string            `json:"attribute"`
+	Tool         string            `json:"tool"`
+	Version      string            `json:"version"`
+	Requirements []ToolRequirement `json:"requirements"`
+	// Conflict is set when repositories require different versions; the highest one is used
+	Conflict bool `json:"conflict,omitempty"`
+}
+
+// NixPackages picks one nixpkgs attribute per tool, using the highest required version
+func NixPackages(requirements []ToolRequirement) []NixPackage {
+	byTool := map[string][]ToolRequirement{}
+	var tools []string
+	for _, requirement := range requirements {
+		if _, ok := byTool[requirement.Tool]; !ok {
+			tools = append(tools, requirement.Tool)
+		}
+		byTool[requirement.Tool] = append(byTool[requirement.Tool], requirement)
+	}
+	sort.Strings(tools)
+
+	var packages []NixPackage
+	for _, tool := range tools {
+		reqs := byTool[tool]
+		pkg := NixPackage{Tool: tool, Version: reqs[0].Version, Requirements: reqs}
+		for _, req := range reqs[1:] {
+			if nixAttribute(tool, req.Version) != nixAttribute(tool, pkg.Version) {
+				pkg.Conflict = true
+			}
+			if compareVersions(req.Version, pkg.Version) > 0 {
+				pkg.Version = req.Version
+			}
+		}
+		pkg.Attribute = nixAttribute(tool, pkg.Version)
+		packages = append(packages, pkg)
+	}
+	return packages
+}
+
+// nixAttribute maps a tool version to the closest versioned nixpkgs attribute, e.g. go 1.24.2 to
+// go_1_24, node 20.11 to nodejs_20 and python 3.11 to python311
+func nixAttribute(tool, version string) string {
+	parts := versionParts(version)
+	switch tool {
+	case "go":
+		if len(parts) >= 2 {
+			return fmt.Sprintf("go_%d_%d", parts[0], parts[1])
+		}
+		return "go"
+	case "node":
+		// Aliases like lts/iron or "node" select the default package
+		if len(parts) >= 1 {
+			return fmt.Sprintf("nodejs_%d", parts[0])
+		}
+		return "nodejs"
+	case "python":
+		if len(parts) >= 2 {
+			return fmt.Sprintf("python%d%d", parts[0], parts[1])
+		}
+		return "python3"
+	default:
+		return tool
+	}
+}
+
+// versionParts returns the leading numeric components of a version
+func versionParts(version string) []int {
+	var parts []int
+	for _, field := range strings.Split(version, ".") {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			break
+		}
+		parts = append(parts, n)
+	}
+	return parts
+}
+
+// compareVersions compares the numeric components of two versions
+func compareVersions(a, b string) int {
+	pa, pb := versionParts(a), versionParts(b)
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// NixFileName returns the file generated for a format
+func NixFileName(format string) string {
+	if format == NixFormatShell {
+		return "shell.nix"
+	}
+	return "flake.nix"
+}
+
+// IsGeneratedNixFile reports whether a file was written by 'wsm nix' and can be overwritten
+func IsGeneratedNixFile(path string) bool {
+	return hasGeneratedHeader(path, nixGeneratedHeader)
+}
+
+// GenerateNix renders a flake.nix or shell.nix whose dev shell provides the toolchains of all
+// repositories of the workspace
+func GenerateNix(workspace *Workspace, packages []NixPackage, format string) string {
+	var b strings.Builder
+	b.WriteString(nixGeneratedHeader + "\n")
+	fmt.Fprintf(&b, "# Workspace: %s\n", workspace.Name)
+	for _, pkg := range packages {
+		for _, req := range pkg.Requirements {
+			fmt.Fprintf(&b, "#   %s: %s %s (%s)\n", req.Repository, req.Tool, req.Version, req.Source)
+		}
+		if pkg.Conflict {
+			fmt.Fprintf(&b, "#   conflicting %s versions, using %s\n", pkg.Tool, pkg.Attribute)
+		}
+	}
+
+	attributes := []string{"git"}
+	for _, pkg := range packages {
+		attributes = append(attributes, pkg.Attribute)
+	}
+
+	indent := ""
+	if format == NixFormatFlake {
+		indent = "        "
+	}
+	var shell strings.Builder
+	fmt.Fprintf(&shell, "pkgs.mkShell {\n")
+	fmt.Fprintf(&shell, "%s  packages = with pkgs; [ %s ];\n", indent, strings.Join(attributes, " "))
+	fmt.Fprintf(&shell, "%s  WSM_WORKSPACE = %s;\n", indent, nixString(workspace.Name))
+	fmt.Fprintf(&shell, "%s}", indent)
+
+	if format == NixFormatShell {
+		b.WriteString("{ pkgs ? import <nixpkgs> { } }:\n\n")
+		b.WriteString(shell.String())
+		b.WriteString("\n")
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, `{
+  description = %s;
+
+  inputs = {
+    nixpkgs.url = "github:NixOS/nixpkgs/nixos-unstable";
+    flake-utils.url = "github:numtide/flake-utils";
+  };
+
+  outputs = { nixpkgs, flake-utils, ... }:
+    flake-utils.lib.eachDefaultSystem (system:
+      let
+        pkgs = nixpkgs.legacyPackages.${system};
+      in
+      {
+        devShells.default = %s;
+      });
+}
+`, nixString("Development shell for workspace "+workspace.Name), shell.String())
+	return b.String()
+}
+
+func nixString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "${", "\\${").Replace(s) + `"`
+}
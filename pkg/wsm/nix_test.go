@@ -0,0 +1,98 @@
+package wsm
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDetectToolRequirements(t *testing.T) {
+	root := t.TempDir()
+	writeGoFiles(t, root, map[string]string{
+		"api/go.mod":           "module example.com/api\n\ngo 1.22\n\ntoolchain go1.23.4\n",
+		"lib/go.mod":           "module example.com/lib\n\ngo 1.21\n",
+		"web/.nvmrc":           "# pinned\nv20.11.0\n",
+		"web/.node-version":    "18\n",
+		"ml/.python-version":   "3.11.4\n",
+		"docs/.python-version": "\n",
+	})
+	workspace := &Workspace{Path: root, Repositories: []Repository{{Name: "api"}, {Name: "lib"}, {Name: "web"}, {Name: "ml"}, {Name: "docs"}}}
+
+	var got []string
+	for _, req := range DetectToolRequirements(workspace) {
+		got = append(got, fmt.Sprintf("%s:%s %s (%s)", req.Repository, req.Tool, req.Version, req.Source))
+	}
+	want := []string{"api:go 1.23.4 (go.mod)", "lib:go 1.21 (go.mod)", "web:node 20.11.0 (.nvmrc)", "ml:python 3.11.4 (.python-version)"}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("requirements = %v, want %v", got, want)
+	}
+}
+
+func TestNixPackages(t *testing.T) {
+	packages := NixPackages([]ToolRequirement{
+		{Tool: "node", Version: "20.11.0", Repository: "web"},
+		{Tool: "go", Version: "1.22.1", Repository: "api"},
+		{Tool: "go", Version: "1.22.5", Repository: "lib"},
+		{Tool: "python", Version: "3.11", Repository: "ml"},
+		{Tool: "python", Version: "3.12.1", Repository: "etl"},
+	})
+	var got []string
+	for _, pkg := range packages {
+		got = append(got, fmt.Sprintf("%s=%s conflict=%v", pkg.Attribute, pkg.Version, pkg.Conflict))
+	}
+	want := []string{"go_1_22=1.22.5 conflict=false", "nodejs_20=20.11.0 conflict=false", "python312=3.12.1 conflict=true"}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("packages = %v, want %v", got, want)
+	}
+}
+
+func TestNixAttribute(t *testing.T) {
+	tests := []struct{ tool, version, want string }{
+		{"go", "1.24.2", "go_1_24"},
+		{"go", "1", "go"},
+		{"node", "lts/iron", "nodejs"},
+		{"node", "22", "nodejs_22"},
+		{"python", "3.11.4", "python311"},
+		{"python", "pypy", "python3"},
+		{"ruby", "3.3", "ruby"},
+	}
+	for _, tt := range tests {
+		if got := nixAttribute(tt.tool, tt.version); got != tt.want {
+			t.Errorf("nixAttribute(%s, %s) = %s, want %s", tt.tool, tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.22", "1.22.0", 0},
+		{"1.22.1", "1.22", 1},
+		{"1.9", "1.10", -1},
+		{"2", "", 1},
+		{"v1", "1", -1},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateNix(t *testing.T) {
+	workspace := &Workspace{Name: `feat "${x}"`}
+	packages := []NixPackage{{Attribute: "go_1_23", Tool: "go", Requirements: []ToolRequirement{{Tool: "go", Version: "1.23", Repository: "api", Source: "go.mod"}}}}
+
+	shell := GenerateNix(workspace, packages, NixFormatShell)
+	for _, want := range []string{nixGeneratedHeader, "#   api: go 1.23 (go.mod)", "packages = with pkgs; [ git go_1_23 ];", `WSM_WORKSPACE = "feat \"\${x}\"";`, "{ pkgs ? import <nixpkgs> { } }:"} {
+		if !strings.Contains(shell, want) {
+			t.Errorf("shell.nix does not contain %q:\n%s", want, shell)
+		}
+	}
+	flake := GenerateNix(workspace, packages, NixFormatFlake)
+	if !strings.Contains(flake, "devShells.default = pkgs.mkShell {\n          packages = with pkgs; [ git go_1_23 ];") {
+		t.Errorf("flake.nix:\n%s", flake)
+	}
+}
@@ -0,0 +1,219 @@
+package wsm
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Workspace layouts below the workspace root
+const (
+	WorkspaceLayoutDated = "dated"
+	WorkspaceLayoutFlat  = "flat"
+)
+
+// Multiplexer integrations configured by 'wsm init', besides the terminal backends
+const (
+	MultiplexerTmux = "tmux"
+	MultiplexerNone = "none"
+)
+
+// starshipModule is the table 'wsm init' adds to the starship configuration
+const starshipModule = "[custom.wsm]"
+
+// InitConfig holds the answers of 'wsm init'
+type InitConfig struct {
+	// CodeDirs are the directories scanned by 'wsm discover'
+	CodeDirs []string
+	// WorkspaceRoot is the directory new workspaces are created below
+	WorkspaceRoot string
+	// Layout is WorkspaceLayoutDated or WorkspaceLayoutFlat
+	Layout string
+	// Multiplexer is MultiplexerTmux, a terminal backend (kitty, wezterm, iterm2) or MultiplexerNone
+	Multiplexer string
+	Starship    bool
+	Direnv      bool
+}
+
+// DefaultInitConfig suggests answers for 'wsm init' from the home directory, the terminal and
+// the tools on PATH
+func DefaultInitConfig() (InitConfig, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return InitConfig{}, errors.Wrap(err, "failed to get home directory")
+	}
+
+	config := InitConfig{
+		WorkspaceRoot: defaultWorkspaceRoot(home, CurrentProfile()),
+		Layout:        WorkspaceLayoutDated,
+		Multiplexer:   detectMultiplexer(),
+	}
+	for _, dir := range []string{"code", "src", "projects", "dev", "git"} {
+		if info, err := os.Stat(filepath.Join(home, dir)); err == nil && info.IsDir() {
+			config.CodeDirs = append(config.CodeDirs, filepath.Join(home, dir))
+		}
+	}
+	_, err = exec.LookPath("starship")
+	config.Starship = err == nil
+	_, err = exec.LookPath("direnv")
+	config.Direnv = err == nil
+	return config, nil
+}
+
+// detectMultiplexer picks the integration matching the terminal wsm runs in
+func detectMultiplexer() string {
+	switch {
+	case os.Getenv("TMUX") != "":
+		return MultiplexerTmux
+	case os.Getenv("KITTY_WINDOW_ID") != "":
+		return TermBackendKitty
+	case os.Getenv("WEZTERM_PANE") != "":
+		return TermBackendWezTerm
+	case os.Getenv("TERM_PROGRAM") == "iTerm.app":
+		return TermBackendITerm2
+	}
+	if _, err := exec.LookPath("tmux"); err == nil {
+		return MultiplexerTmux
+	}
+	return MultiplexerNone
+}
+
+// Validate checks the answers before a configuration is written from them
+func (c InitConfig) Validate() error {
+	for _, dir := range c.CodeDirs {
+		path, err := expandHomePath(dir)
+		if err != nil {
+			return err
+		}
+		if info, err := os.Stat(path); err != nil || !info.IsDir() {
+			return errors.Errorf("code directory %s does not exist", dir)
+		}
+	}
+	if c.WorkspaceRoot == "" {
+		return errors.New("the workspace root is empty")
+	}
+	switch c.Layout {
+	case WorkspaceLayoutDated, WorkspaceLayoutFlat:
+	default:
+		return errors.Errorf("unknown layout '%s' (expected %s or %s)", c.Layout, WorkspaceLayoutDated, WorkspaceLayoutFlat)
+	}
+	switch c.Multiplexer {
+	case MultiplexerTmux, MultiplexerNone, TermBackendKitty, TermBackendWezTerm, TermBackendITerm2:
+	default:
+		return errors.Errorf("unknown multiplexer '%s' (expected tmux, kitty, wezterm, iterm2 or none)", c.Multiplexer)
+	}
+	return nil
+}
+
+// ConfigYAML renders the answers as config.yaml, with paths below the home directory written as ~
+func (c InitConfig) ConfigYAML() ([]byte, error) {
+	config := struct {
+		WorkspaceDir    string       `yaml:"workspace_dir"`
+		WorkspaceLayout string       `yaml:"workspace_layout"`
+		DiscoveryPaths  []string     `yaml:"discovery_paths,omitempty"`
+		Tmux            *TmuxProfile `yaml:"tmux,omitempty"`
+		Term            *TermConfig  `yaml:"term,omitempty"`
+	}{
+		WorkspaceDir:    homeRelative(c.WorkspaceRoot),
+		WorkspaceLayout: c.Layout,
+	}
+	for _, dir := range c.CodeDirs {
+		config.DiscoveryPaths = append(config.DiscoveryPaths, homeRelative(dir))
+	}
+	switch c.Multiplexer {
+	case MultiplexerTmux:
+		config.Tmux = &TmuxProfile{PerRepoWindows: true, Overview: true}
+	case TermBackendKitty, TermBackendWezTerm, TermBackendITerm2:
+		config.Term = &TermConfig{Backend: c.Multiplexer, Overview: true}
+	}
+
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode configuration")
+	}
+	return append([]byte("# Written by 'wsm init'\n"), data...), nil
+}
+
+// homeRelative abbreviates a path below the home directory with ~
+func homeRelative(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	if rel, err := filepath.Rel(home, path); err == nil && rel != ".." && !strings.HasPrefix(rel, "../") {
+		return filepath.Join("~", rel)
+	}
+	return path
+}
+
+// StarshipConfigPath returns the starship configuration file, $STARSHIP_CONFIG or
+// ~/.config/starship.toml
+func StarshipConfigPath() (string, error) {
+	if path := os.Getenv("STARSHIP_CONFIG"); path != "" {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get home directory")
+	}
+	return filepath.Join(home, ".config", "starship.toml"), nil
+}
+
+// StarshipModule returns a starship custom module showing the workspace of the current directory
+func StarshipModule(executable string) string {
+	command := executable
+	if profile := CurrentProfile(); profile != DefaultProfile {
+		command += " --profile " + profile
+	}
+	command += " info --field name"
+	return starshipModule + `
+description = "wsm workspace of the current directory"
+command = "` + command + `"
+when = "` + command + `"
+symbol = "⧉ "
+style = "bold purple"
+format = "[$symbol$output]($style) "
+`
+}
+
+// AddStarshipModule appends the module to the starship configuration, unless it has one already.
+// It reports whether the configuration was changed.
+func AddStarshipModule(path, module string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return false, errors.Wrapf(err, "failed to read %s", path)
+	}
+	if strings.Contains(string(data), starshipModule) {
+		return false, nil
+	}
+	if len(data) > 0 {
+		module = "\n" + module
+		if data[len(data)-1] != '\n' {
+			module = "\n" + module
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return false, errors.Wrapf(err, "failed to create %s", filepath.Dir(path))
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to open %s", path)
+	}
+	defer func() { _ = f.Close() }()
+	if _, err := f.WriteString(module); err != nil {
+		return false, errors.Wrapf(err, "failed to write %s", path)
+	}
+	return true, nil
+}
+
+// DirenvConfig returns an .envrc for the workspace root selecting the current profile, so wsm
+// works on the right workspaces from any directory below it
+func DirenvConfig() string {
+	return `# Written by 'wsm init'
+export ` + ProfileEnvVar + `=` + CurrentProfile() + `
+`
+}
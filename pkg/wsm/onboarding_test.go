@@ -0,0 +1,136 @@
+package wsm
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInitConfigYAMLLoads(t *testing.T) {
+	tests := []struct {
+		name        string
+		layout      string
+		multiplexer string
+	}{
+		{name: "tmux", layout: WorkspaceLayoutFlat, multiplexer: MultiplexerTmux},
+		{name: "kitty", layout: WorkspaceLayoutDated, multiplexer: TermBackendKitty},
+		{name: "none", layout: WorkspaceLayoutFlat, multiplexer: MultiplexerNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTestConfigDir(t)
+			home, err := os.UserHomeDir()
+			if err != nil {
+				t.Fatal(err)
+			}
+			answers := InitConfig{
+				CodeDirs:      []string{filepath.Join(home, "code"), "/srv/repos"},
+				WorkspaceRoot: filepath.Join(home, "workspaces"),
+				Layout:        tt.layout,
+				Multiplexer:   tt.multiplexer,
+			}
+			data, err := answers.ConfigYAML()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(data), "workspace_dir: ~/workspaces\n") {
+				t.Errorf("the workspace root should be written relative to the home directory:\n%s", data)
+			}
+
+			configPath, err := ConfigPath()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(configPath, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			config, err := LoadConfig()
+			if err != nil {
+				t.Fatalf("LoadConfig rejected the generated configuration: %v\n%s", err, data)
+			}
+
+			wantDir := filepath.Join(home, "workspaces")
+			if tt.layout == WorkspaceLayoutDated {
+				wantDir = filepath.Join(wantDir, time.Now().Format("2006-01-02"))
+			}
+			if config.WorkspaceDir != wantDir || config.WorkspaceLayout != tt.layout {
+				t.Errorf("workspace dir = %s (%s), want %s (%s)", config.WorkspaceDir, config.WorkspaceLayout, wantDir, tt.layout)
+			}
+			if !slices.Equal(config.DiscoveryPaths, []string{"~/code", "/srv/repos"}) {
+				t.Errorf("discovery paths = %v", config.DiscoveryPaths)
+			}
+			if got := config.Tmux.PerRepoWindows; got != (tt.multiplexer == MultiplexerTmux) {
+				t.Errorf("tmux per-repo windows = %v for %s", got, tt.multiplexer)
+			}
+			if tt.multiplexer == TermBackendKitty && (config.Term.Backend != TermBackendKitty || !config.Term.Overview) {
+				t.Errorf("term = %+v, want kitty with an overview", config.Term)
+			}
+		})
+	}
+}
+
+func TestAddStarshipModule(t *testing.T) {
+	module := StarshipModule("wsm")
+	read := func(path string) string {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	// A missing configuration is created, and adding the module again changes nothing
+	path := filepath.Join(t.TempDir(), ".config", "starship.toml")
+	for i, want := range []bool{true, false} {
+		changed, err := AddStarshipModule(path, module)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if changed != want {
+			t.Errorf("call %d: changed = %v, want %v", i+1, changed, want)
+		}
+	}
+	if got := read(path); got != module {
+		t.Errorf("starship.toml =\n%s\nwant\n%s", got, module)
+	}
+
+	// The module is separated from existing settings by a blank line, even without a final newline
+	for _, existing := range []string{"add_newline = false\n", "add_newline = false"} {
+		path := filepath.Join(t.TempDir(), "starship.toml")
+		if err := os.WriteFile(path, []byte(existing), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if changed, err := AddStarshipModule(path, module); err != nil || !changed {
+			t.Fatalf("AddStarshipModule = %v, %v", changed, err)
+		}
+		if got, want := read(path), "add_newline = false\n\n"+module; got != want {
+			t.Errorf("starship.toml =\n%q\nwant\n%q", got, want)
+		}
+	}
+}
+
+func TestHomeRelative(t *testing.T) {
+	t.Setenv("HOME", "/home/me")
+	tests := []struct {
+		path, want string
+	}{
+		{"/home/me/code", "~/code"},
+		{"/home/me/src/go-go-golems", "~/src/go-go-golems"},
+		{"/home/me", "~"},
+		{"/home/meadow/code", "/home/meadow/code"},
+		{"/srv/repos", "/srv/repos"},
+		{"/home", "/home"},
+	}
+	for _, tt := range tests {
+		if got := homeRelative(tt.path); got != tt.want {
+			t.Errorf("homeRelative(%s) = %s, want %s", tt.path, got, tt.want)
+		}
+	}
+}
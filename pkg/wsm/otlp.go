@@ -0,0 +1,170 @@
+package wsm
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// OTLP span kinds and status codes, see opentelemetry-proto trace.proto
+const (
+	otlpSpanKindInternal = 1
+	otlpSpanKindClient   = 3
+	otlpStatusOK         = 1
+	otlpStatusError      = 2
+)
+
+// OTLPExporter sends traces of metric collections to an OpenTelemetry collector using OTLP/HTTP
+// with JSON encoding. Each collection is a trace with one span per git command.
+type OTLPExporter struct {
+	// Endpoint is the base URL of the collector, e.g. http://localhost:4318; /v1/traces is appended
+	Endpoint string
+	// ServiceName is reported as the service.name resource attribute
+	ServiceName string
+	Client      *http.Client
+}
+
+// NewOTLPExporter creates an exporter for the given collector endpoint
+func NewOTLPExporter(endpoint string) *OTLPExporter {
+	return &OTLPExporter{
+		Endpoint:    endpoint,
+		ServiceName: "workspace-manager",
+		Client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type otlpValue struct {
+	StringValue string `json:"stringValue,omitempty"`
+	IntValue    string `json:"intValue,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+// ExportCollection sends the trace of one collection: a root span covering it and a child span
+// for every git command it ran
+func (e *OTLPExporter) ExportCollection(ctx context.Context, start time.Time, duration time.Duration, calls []GitCall) error {
+	traceID, err := randomHex(16)
+	if err != nil {
+		return err
+	}
+	rootID, err := randomHex(8)
+	if err != nil {
+		return err
+	}
+
+	failures := 0
+	spans := []otlpSpan{}
+	for _, call := range calls {
+		spanID, err := randomHex(8)
+		if err != nil {
+			return err
+		}
+		span := otlpSpan{
+			TraceID:           traceID,
+			SpanID:            spanID,
+			ParentSpanID:      rootID,
+			Name:              strings.TrimSpace("git " + call.Subcommand),
+			Kind:              otlpSpanKindClient,
+			StartTimeUnixNano: unixNano(call.Start),
+			EndTimeUnixNano:   unixNano(call.Start.Add(call.Duration)),
+			Attributes: []otlpAttribute{
+				{Key: "git.subcommand", Value: otlpValue{StringValue: call.Subcommand}},
+				{Key: "git.dir", Value: otlpValue{StringValue: call.Dir}},
+			},
+			Status: otlpStatus{Code: otlpStatusOK},
+		}
+		if call.Err != nil {
+			failures++
+			span.Status = otlpStatus{Code: otlpStatusError, Message: call.Err.Error()}
+		}
+		spans = append(spans, span)
+	}
+	spans = append(spans, otlpSpan{
+		TraceID:           traceID,
+		SpanID:            rootID,
+		Name:              "wsm metrics collect",
+		Kind:              otlpSpanKindInternal,
+		StartTimeUnixNano: unixNano(start),
+		EndTimeUnixNano:   unixNano(start.Add(duration)),
+		Attributes: []otlpAttribute{
+			{Key: "wsm.git_commands", Value: otlpValue{IntValue: strconv.Itoa(len(calls))}},
+			{Key: "wsm.git_failures", Value: otlpValue{IntValue: strconv.Itoa(failures)}},
+		},
+		Status: otlpStatus{Code: otlpStatusOK},
+	})
+
+	payload := map[string]any{
+		"resourceSpans": []any{map[string]any{
+			"resource": map[string]any{
+				"attributes": []otlpAttribute{{Key: "service.name", Value: otlpValue{StringValue: e.ServiceName}}},
+			},
+			"scopeSpans": []any{map[string]any{
+				"scope": map[string]string{"name": "github.com/go-go-golems/workspace-manager"},
+				"spans": spans,
+			}},
+		}},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode traces")
+	}
+
+	url := strings.TrimSuffix(e.Endpoint, "/") + "/v1/traces"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "failed to create OTLP request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.Client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to send traces to %s", url)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return errors.Errorf("collector at %s returned %s: %s", url, resp.Status, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "failed to generate trace id")
+	}
+	return hex.EncodeToString(b), nil
+}
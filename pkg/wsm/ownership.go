@@ -0,0 +1,141 @@
+package wsm
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// OwnershipConfig is the .wsm/ownership.yaml of a workspace: which areas and owners the paths of
+// its repositories belong to, and which areas the workspace is meant to change
+type OwnershipConfig struct {
+	// Rules map path globs, relative to the workspace root (api/internal/billing/**), to an area;
+	// the last matching rule wins
+	Rules []OwnershipRule `json:"rules" yaml:"rules"`
+	// Scope lists the areas the workspace works on
+	Scope []string `json:"scope,omitempty" yaml:"scope,omitempty"`
+	// Tickets maps linked tickets, or Jira project keys, to the areas they cover
+	Tickets map[string][]string `json:"tickets,omitempty" yaml:"tickets,omitempty"`
+	// Enforce blocks commits touching files out of scope, as 'wsm commit --enforce' does
+	Enforce bool `json:"enforce,omitempty" yaml:"enforce,omitempty"`
+}
+
+// OwnershipRule assigns the paths matching one of its globs to an area
+type OwnershipRule struct {
+	Paths  []string `json:"paths" yaml:"paths"`
+	Area   string   `json:"area" yaml:"area"`
+	Owners []string `json:"owners,omitempty" yaml:"owners,omitempty"`
+	// Protected paths may only be changed when their area is in scope; other areas are only
+	// checked once the workspace declares a scope
+	Protected bool `json:"protected,omitempty" yaml:"protected,omitempty"`
+}
+
+// OwnershipViolation is a changed file outside the scope of the workspace
+type OwnershipViolation struct {
+	Repository string   `json:"repository"`
+	File       string   `json:"file"`
+	Area       string   `json:"area"`
+	Owners     []string `json:"owners,omitempty"`
+	Protected  bool     `json:"protected,omitempty"`
+}
+
+// LoadOwnership reads the ownership rules of a workspace; it returns nil when the workspace has
+// no .wsm/ownership.yaml
+func LoadOwnership(workspace *Workspace) (*OwnershipConfig, error) {
+	path := filepath.Join(workspace.Path, ".wsm", "ownership.yaml")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to read %s", path)
+	}
+	var config OwnershipConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", path)
+	}
+	for i, rule := range config.Rules {
+		if rule.Area == "" || len(rule.Paths) == 0 {
+			return nil, errors.Errorf("%s: rule %d needs an area and paths", path, i+1)
+		}
+	}
+	return &config, nil
+}
+
+// WorkspaceScope returns the areas a workspace may change: the declared scope and the areas of
+// its linked tickets
+func (c *OwnershipConfig) WorkspaceScope(workspace *Workspace) []string {
+	areas := map[string]bool{}
+	for _, area := range c.Scope {
+		areas[area] = true
+	}
+	for _, issue := range workspace.Issues {
+		for _, area := range c.Tickets[issue.Ref] {
+			areas[area] = true
+		}
+		if issue.Tracker == IssueTrackerJira {
+			project, _, _ := strings.Cut(issue.Ref, "-")
+			for _, area := range c.Tickets[project] {
+				areas[area] = true
+			}
+		}
+	}
+	scope := make([]string, 0, len(areas))
+	for area := range areas {
+		scope = append(scope, area)
+	}
+	sort.Strings(scope)
+	return scope
+}
+
+// Owner returns the last rule matching a path relative to the workspace root
+func (c *OwnershipConfig) Owner(path string) *OwnershipRule {
+	var owner *OwnershipRule
+	for i, rule := range c.Rules {
+		for _, pattern := range rule.Paths {
+			if matchPathGlob(pattern, path) {
+				owner = &c.Rules[i]
+				break
+			}
+		}
+	}
+	return owner
+}
+
+// CheckChanges returns the changed files owned by an area outside the scope of the workspace.
+// Files matching no rule are not checked.
+func (c *OwnershipConfig) CheckChanges(workspace *Workspace, changes map[string][]FileChange) []OwnershipViolation {
+	scope := map[string]bool{}
+	for _, area := range c.WorkspaceScope(workspace) {
+		scope[area] = true
+	}
+
+	var violations []OwnershipViolation
+	for repository, files := range changes {
+		for _, change := range files {
+			// Untracked directories are listed with a trailing slash
+			rule := c.Owner(repository + "/" + strings.TrimSuffix(filepath.ToSlash(change.FilePath), "/"))
+			if rule == nil || scope[rule.Area] || (len(scope) == 0 && !rule.Protected) {
+				continue
+			}
+			violations = append(violations, OwnershipViolation{
+				Repository: repository,
+				File:       change.FilePath,
+				Area:       rule.Area,
+				Owners:     rule.Owners,
+				Protected:  rule.Protected,
+			})
+		}
+	}
+	sort.Slice(violations, func(i, j int) bool {
+		if violations[i].Repository != violations[j].Repository {
+			return violations[i].Repository < violations[j].Repository
+		}
+		return violations[i].File < violations[j].File
+	})
+	return violations
+}
@@ -0,0 +1,107 @@
+package wsm
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func testOwnership() *OwnershipConfig {
+	return &OwnershipConfig{
+		Rules: []OwnershipRule{
+			{Paths: []string{"api/**"}, Area: "backend", Owners: []string{"@backend"}},
+			{Paths: []string{"api/internal/billing/**"}, Area: "billing", Owners: []string{"@payments"}, Protected: true},
+			{Paths: []string{"web/**"}, Area: "frontend"},
+		},
+		Tickets: map[string][]string{"PAY": {"billing"}, "acme/web#12": {"frontend"}},
+	}
+}
+
+func TestOwnershipOwner(t *testing.T) {
+	config := testOwnership()
+	tests := map[string]string{
+		"api/cmd/main.go":                "backend",
+		"api/internal/billing/charge.go": "billing",
+		"api/internal/billing":           "billing",
+		"web/src/app.ts":                 "frontend",
+		"docs/readme.md":                 "",
+	}
+	for path, want := range tests {
+		got := ""
+		if rule := config.Owner(path); rule != nil {
+			got = rule.Area
+		}
+		if got != want {
+			t.Errorf("Owner(%s) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestOwnershipWorkspaceScope(t *testing.T) {
+	config := testOwnership()
+	config.Scope = []string{"docs"}
+	workspace := &Workspace{Issues: []IssueLink{
+		{Ref: "PAY-42", Tracker: IssueTrackerJira},
+		{Ref: "acme/web#12", Tracker: IssueTrackerGitHub},
+		{Ref: "acme/api#3", Tracker: IssueTrackerGitHub},
+	}}
+	if got := config.WorkspaceScope(workspace); !slices.Equal(got, []string{"billing", "docs", "frontend"}) {
+		t.Errorf("WorkspaceScope = %q", got)
+	}
+}
+
+func TestOwnershipCheckChanges(t *testing.T) {
+	changes := map[string][]FileChange{
+		"web": {{FilePath: "src/app.ts"}},
+		"api": {
+			{FilePath: "internal/billing/charge.go"},
+			{FilePath: "cmd/main.go"},
+			{FilePath: "internal/billing/new/"},
+		},
+		"docs": {{FilePath: "readme.md"}},
+	}
+
+	// Without a scope, only protected areas are checked
+	config := testOwnership()
+	violations := config.CheckChanges(&Workspace{}, changes)
+	var files []string
+	for _, v := range violations {
+		files = append(files, v.Repository+"/"+v.File)
+	}
+	if want := []string{"api/internal/billing/charge.go", "api/internal/billing/new/"}; !slices.Equal(files, want) {
+		t.Errorf("violations without a scope = %q, want %q", files, want)
+	}
+	if want := (OwnershipViolation{Repository: "api", File: "internal/billing/charge.go", Area: "billing", Owners: []string{"@payments"}, Protected: true}); !reflect.DeepEqual(violations[0], want) {
+		t.Errorf("violation = %+v, want %+v", violations[0], want)
+	}
+
+	// With a scope, every area outside of it is checked
+	config.Scope = []string{"billing"}
+	files = nil
+	for _, v := range config.CheckChanges(&Workspace{}, changes) {
+		files = append(files, v.Repository+"/"+v.File+" ("+v.Area+")")
+	}
+	if want := []string{"api/cmd/main.go (backend)", "web/src/app.ts (frontend)"}; !slices.Equal(files, want) {
+		t.Errorf("violations with a scope = %q, want %q", files, want)
+	}
+}
+
+func TestLoadOwnership(t *testing.T) {
+	root := t.TempDir()
+	workspace := &Workspace{Path: root}
+	if config, err := LoadOwnership(workspace); config != nil || err != nil {
+		t.Errorf("LoadOwnership without a file = %v, %v", config, err)
+	}
+
+	writeGoFiles(t, root, map[string]string{".wsm/ownership.yaml": "rules:\n  - paths: [\"api/**\"]\n    area: backend\nscope: [backend]\nenforce: true\n"})
+	config, err := LoadOwnership(workspace)
+	if err != nil || len(config.Rules) != 1 || config.Rules[0].Area != "backend" || !config.Enforce {
+		t.Errorf("LoadOwnership = %+v, %v", config, err)
+	}
+
+	writeGoFiles(t, root, map[string]string{".wsm/ownership.yaml": "rules:\n  - paths: [\"api/**\"]\n"})
+	if _, err := LoadOwnership(workspace); err == nil || !strings.Contains(err.Error(), "rule 1 needs an area") {
+		t.Errorf("expected an error for a rule without an area, got %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+package wsm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/pkg/errors"
+)
+
+// PatchSeries is the set of patches exported for one repository
+type PatchSeries struct {
+	Repository string   `json:"repository"`
+	Base       string   `json:"base"`
+	Patches    []string `json:"patches"`
+}
+
+// PatchApplyResult is the outcome of applying a patch series to a repository
+type PatchApplyResult struct {
+	Repository string `json:"repository"`
+	Applied    int    `json:"applied"`
+	Error      string `json:"error,omitempty"`
+}
+
+// ExportPatches writes a git format-patch series per repository into outputDir/<repo>/.
+// Repositories without commits on top of the base are skipped. Patches left in outputDir/<repo>/
+// by an earlier export are removed first so they cannot be applied along with the new series.
+func ExportPatches(ctx context.Context, workspace *Workspace, base, outputDir string) ([]PatchSeries, error) {
+	base = WorkspaceBaseRef(workspace, base)
+
+	// git runs inside each worktree, so a relative output directory must be anchored to the caller's cwd
+	outputDir, err := filepath.Abs(outputDir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to resolve output directory: %s", outputDir)
+	}
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, errors.Wrapf(err, "failed to create output directory: %s", outputDir)
+	}
+
+	// Only the per-repository directories are cleared, the output directory may hold unrelated files
+	for _, repo := range workspace.Repositories {
+		if err := os.RemoveAll(filepath.Join(outputDir, repo.Name)); err != nil {
+			return nil, errors.Wrapf(err, "failed to clear previous patches for %s", repo.Name)
+		}
+	}
+
+	var series []PatchSeries
+	for _, repo := range workspace.Repositories {
+		worktreePath := filepath.Join(workspace.Path, repo.Name)
+
+		count, err := runGitOutput(ctx, worktreePath, "rev-list", "--count", base+"..HEAD")
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to compare %s against %s", repo.Name, base)
+		}
+		if count == "0" {
+			output.LogInfo(
+				fmt.Sprintf("No commits in %s on top of %s, skipping", repo.Name, base),
+				"No commits to export",
+				"repo", repo.Name,
+				"base", base,
+			)
+			continue
+		}
+
+		repoDir := filepath.Join(outputDir, repo.Name)
+		files, err := runGitOutput(ctx, worktreePath, "format-patch", "-o", repoDir, base+"..HEAD")
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to export patches for %s", repo.Name)
+		}
+
+		var patches []string
+		for _, file := range strings.Split(files, "\n") {
+			if file != "" {
+				patches = append(patches, filepath.Base(file))
+			}
+		}
+
+		series = append(series, PatchSeries{Repository: repo.Name, Base: base, Patches: patches})
+	}
+
+	return series, nil
+}
+
+// ApplyPatches applies the per-repository patch series in patchDir onto the matching worktrees of a workspace
+// using git am --3way. A failing series is aborted so the repository is left untouched. Frozen repositories
+// are skipped.
+func ApplyPatches(ctx context.Context, workspace *Workspace, patchDir string) ([]PatchApplyResult, error) {
+	if err := workspace.RequireWorktrees("applying patches"); err != nil {
+		return nil, err
+	}
+
+	// git am runs inside each worktree, so the patch paths handed to it must be absolute
+	patchDir, err := filepath.Abs(patchDir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to resolve patch directory: %s", patchDir)
+	}
+
+	entries, err := os.ReadDir(patchDir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read patch directory: %s", patchDir)
+	}
+
+	repos := make(map[string]bool)
+	for _, repo := range workspace.Repositories {
+		repos[repo.Name] = true
+	}
+
+	var results []PatchApplyResult
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		repoName := entry.Name()
+		if !repos[repoName] {
+			output.PrintWarning("Skipping patches for '%s': repository is not part of workspace '%s'", repoName, workspace.Name)
+			continue
+		}
+		if workspace.IsFrozen(repoName) {
+			output.PrintWarning("Skipping patches for '%s': repository is frozen", repoName)
+			continue
+		}
+
+		patches, err := filepath.Glob(filepath.Join(patchDir, repoName, "*.patch"))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list patches for %s", repoName)
+		}
+		if len(patches) == 0 {
+			continue
+		}
+		sort.Strings(patches)
+
+		result := PatchApplyResult{Repository: repoName}
+		worktreePath := filepath.Join(workspace.Path, repoName)
+		args := append([]string{"am", "--3way"}, patches...)
+		if _, err := runGitOutput(ctx, worktreePath, args...); err != nil {
+			result.Error = err.Error()
+			if _, abortErr := runGitOutput(ctx, worktreePath, "am", "--abort"); abortErr != nil {
+				output.LogWarn(
+					fmt.Sprintf("Failed to abort git am in %s: %v", repoName, abortErr),
+					"Failed to abort git am",
+					"repo", repoName,
+					"error", abortErr,
+				)
+			}
+		} else {
+			result.Applied = len(patches)
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,87 @@
+package wsm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExportPatchesClearsPreviousSeries(t *testing.T) {
+	root := t.TempDir()
+	repo := filepath.Join(root, "app")
+	if err := os.MkdirAll(repo, 0755); err != nil {
+		t.Fatal(err)
+	}
+	testGit(t, repo, "init", "-q")
+	for _, name := range []string{"a", "b", "c"} {
+		writeGoFiles(t, repo, map[string]string{name: name})
+		testGit(t, repo, "add", name)
+		testGit(t, repo, "commit", "-qm", "add "+name)
+	}
+	workspace := &Workspace{Name: "ws", Path: root, Repositories: []Repository{{Name: "app"}}}
+
+	out := filepath.Join(t.TempDir(), "patches")
+	writeGoFiles(t, out, map[string]string{"NOTES": "keep me"})
+	if _, err := ExportPatches(context.Background(), workspace, "HEAD~2", out); err != nil {
+		t.Fatalf("ExportPatches failed: %v", err)
+	}
+	series, err := ExportPatches(context.Background(), workspace, "HEAD~1", out)
+	if err != nil {
+		t.Fatalf("ExportPatches failed: %v", err)
+	}
+	if len(series) != 1 || len(series[0].Patches) != 1 {
+		t.Fatalf("series = %+v, want a single patch", series)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(out, "app"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != series[0].Patches[0] {
+		t.Errorf("app patches = %v, want only %s", entries, series[0].Patches[0])
+	}
+	if _, err := os.Stat(filepath.Join(out, "NOTES")); err != nil {
+		t.Errorf("unrelated file was removed: %v", err)
+	}
+}
+
+func TestApplyPatchesSkipsFrozenRepositories(t *testing.T) {
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	root := t.TempDir()
+	for _, name := range []string{"app", "lib"} {
+		repo := filepath.Join(root, name)
+		if err := os.MkdirAll(repo, 0755); err != nil {
+			t.Fatal(err)
+		}
+		testGit(t, repo, "init", "-q")
+		writeGoFiles(t, repo, map[string]string{"a": "a"})
+		testGit(t, repo, "add", "a")
+		testGit(t, repo, "commit", "-qm", "add a")
+		writeGoFiles(t, repo, map[string]string{"b": "b"})
+		testGit(t, repo, "add", "b")
+		testGit(t, repo, "commit", "-qm", "add b")
+	}
+	workspace := &Workspace{Name: "ws", Path: root, Repositories: []Repository{{Name: "app"}, {Name: "lib"}}}
+
+	out := filepath.Join(t.TempDir(), "patches")
+	if _, err := ExportPatches(context.Background(), workspace, "HEAD~1", out); err != nil {
+		t.Fatalf("ExportPatches failed: %v", err)
+	}
+	for _, name := range []string{"app", "lib"} {
+		testGit(t, filepath.Join(root, name), "reset", "-q", "--hard", "HEAD~1")
+	}
+
+	workspace.Frozen = []string{"lib"}
+	results, err := ApplyPatches(context.Background(), workspace, out)
+	if err != nil {
+		t.Fatalf("ApplyPatches failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Repository != "app" || results[0].Applied != 1 {
+		t.Errorf("results = %+v, want only app", results)
+	}
+	if count := testGit(t, filepath.Join(root, "lib"), "rev-list", "--count", "HEAD"); count != "1" {
+		t.Errorf("the frozen repository was committed to: %s commits", count)
+	}
+}
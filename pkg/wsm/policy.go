@@ -0,0 +1,261 @@
+package wsm
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Severities of policy rules
+const (
+	PolicySeverityError   = "error"
+	PolicySeverityWarning = "warning"
+)
+
+// policyFile is the default policy, next to config.yaml
+const policyFile = "policy.yaml"
+
+// Policy is a set of rules workspaces must comply with, read from policy.yaml
+type Policy struct {
+	Rules []PolicyRule `json:"rules" yaml:"rules"`
+}
+
+// PolicyRule requires something of the workspaces it matches
+type PolicyRule struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Severity is error (default) or warning; only errors fail 'wsm policy check'
+	Severity string            `json:"severity,omitempty" yaml:"severity,omitempty"`
+	Match    PolicyMatch       `json:"match,omitempty" yaml:"match,omitempty"`
+	Require  PolicyRequirement `json:"require" yaml:"require"`
+
+	branch   *regexp.Regexp
+	maxAge   time.Duration
+	selector LabelSelector
+}
+
+// PolicyMatch selects the workspaces a rule applies to; all given conditions must hold, and a
+// rule without conditions applies to every workspace
+type PolicyMatch struct {
+	// Name is a glob on the workspace name, e.g. release-*
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// Selector is a label selector, e.g. team=payments
+	Selector string `json:"selector,omitempty" yaml:"selector,omitempty"`
+	// Tags match workspaces with a repository carrying one of the tags, e.g. go
+	Tags []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	// Repositories match workspaces containing one of the repositories
+	Repositories []string `json:"repositories,omitempty" yaml:"repositories,omitempty"`
+}
+
+// PolicyRequirement is what a matched workspace must satisfy
+type PolicyRequirement struct {
+	// Repositories must all be part of the workspace
+	Repositories []string `json:"repositories,omitempty" yaml:"repositories,omitempty"`
+	// Branch is a regular expression the workspace branch must match
+	Branch string `json:"branch,omitempty" yaml:"branch,omitempty"`
+	// MaxAge is the age a workspace may reach, e.g. 60d or 72h
+	MaxAge string `json:"max_age,omitempty" yaml:"max_age,omitempty"`
+	// Labels must all be set on the workspace
+	Labels []string `json:"labels,omitempty" yaml:"labels,omitempty"`
+}
+
+// PolicyViolation is a workspace breaking a rule
+type PolicyViolation struct {
+	Workspace string `json:"workspace"`
+	Rule      string `json:"rule"`
+	Severity  string `json:"severity"`
+	Message   string `json:"message"`
+}
+
+// DefaultPolicyPath returns policy.yaml in the configuration directory of the current profile
+func DefaultPolicyPath() (string, error) {
+	configDir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, policyFile), nil
+}
+
+// LoadPolicy reads and validates a policy file
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.Errorf("no policy at %s; describe the rules there or pass --file", path)
+		}
+		return nil, errors.Wrapf(err, "failed to read %s", path)
+	}
+	// Unknown keys are errors: a misspelled condition would otherwise be dropped and the rule
+	// applied to every workspace
+	var policy Policy
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&policy); err != nil && err != io.EOF {
+		return nil, errors.Wrapf(err, "failed to parse %s", path)
+	}
+
+	seen := map[string]bool{}
+	for i := range policy.Rules {
+		rule := &policy.Rules[i]
+		if rule.Name == "" {
+			return nil, errors.Errorf("rule %d of %s has no name", i+1, path)
+		}
+		if seen[rule.Name] {
+			return nil, errors.Errorf("rule '%s' is defined twice in %s", rule.Name, path)
+		}
+		seen[rule.Name] = true
+		if err := rule.compile(); err != nil {
+			return nil, errors.Wrapf(err, "invalid rule '%s' in %s", rule.Name, path)
+		}
+	}
+	return &policy, nil
+}
+
+func (r *PolicyRule) compile() error {
+	switch r.Severity {
+	case "":
+		r.Severity = PolicySeverityError
+	case PolicySeverityError, PolicySeverityWarning:
+	default:
+		return errors.Errorf("unknown severity '%s' (expected error or warning)", r.Severity)
+	}
+
+	req := r.Require
+	if len(req.Repositories) == 0 && req.Branch == "" && req.MaxAge == "" && len(req.Labels) == 0 {
+		return errors.New("require is empty")
+	}
+	if req.Branch != "" {
+		branch, err := regexp.Compile(req.Branch)
+		if err != nil {
+			return errors.Wrapf(err, "invalid branch pattern '%s'", req.Branch)
+		}
+		r.branch = branch
+	}
+	if req.MaxAge != "" {
+		maxAge, err := ParseAge(req.MaxAge)
+		if err != nil {
+			return errors.Errorf("invalid max_age '%s': expected a duration such as 60d or 72h", req.MaxAge)
+		}
+		r.maxAge = maxAge
+	}
+	if r.Match.Name != "" {
+		if _, err := filepath.Match(r.Match.Name, ""); err != nil {
+			return errors.Wrapf(err, "invalid name pattern '%s'", r.Match.Name)
+		}
+	}
+	selector, err := ParseLabelSelector(r.Match.Selector)
+	if err != nil {
+		return err
+	}
+	r.selector = selector
+	return nil
+}
+
+// ParseAge parses a positive duration with an additional "d" suffix for days, e.g. 60d
+func ParseAge(age string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(age, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	}
+	if d, err := time.ParseDuration(age); err == nil && d > 0 {
+		return d, nil
+	}
+	return 0, errors.Errorf("invalid duration '%s': expected a duration such as 60d or 72h", age)
+}
+
+// Matches reports whether the rule applies to the workspace
+func (r *PolicyRule) Matches(workspace Workspace) bool {
+	if r.Match.Name != "" {
+		if ok, _ := filepath.Match(r.Match.Name, workspace.Name); !ok {
+			return false
+		}
+	}
+	if !r.selector.Matches(workspace.Labels) {
+		return false
+	}
+	if len(r.Match.Tags) > 0 && !slices.ContainsFunc(workspace.Repositories, func(repo Repository) bool {
+		return slices.ContainsFunc(repo.Categories, func(tag string) bool { return slices.Contains(r.Match.Tags, tag) })
+	}) {
+		return false
+	}
+	if len(r.Match.Repositories) > 0 && !slices.ContainsFunc(r.Match.Repositories, func(name string) bool {
+		_, ok := FindRepository(&workspace, name)
+		return ok
+	}) {
+		return false
+	}
+	return true
+}
+
+// Check returns the violations of the rule by a workspace it matches
+func (r *PolicyRule) Check(workspace Workspace, now time.Time) []PolicyViolation {
+	var violations []PolicyViolation
+	violate := func(format string, args ...any) {
+		violations = append(violations, PolicyViolation{
+			Workspace: workspace.Name,
+			Rule:      r.Name,
+			Severity:  r.Severity,
+			Message:   fmt.Sprintf(format, args...),
+		})
+	}
+
+	for _, name := range r.Require.Repositories {
+		if _, ok := FindRepository(&workspace, name); !ok {
+			violate("missing repository %s", name)
+		}
+	}
+	if r.branch != nil && !r.branch.MatchString(workspace.Branch) {
+		violate("branch %s does not match %s", workspace.Branch, r.Require.Branch)
+	}
+	if r.maxAge > 0 && !workspace.Created.IsZero() && now.Sub(workspace.Created) > r.maxAge {
+		violate("created %s, older than %s", humanize.RelTime(workspace.Created, now, "ago", "from now"), r.Require.MaxAge)
+	}
+	for _, label := range r.Require.Labels {
+		if _, ok := workspace.Labels[label]; !ok {
+			violate("missing label %s", label)
+		}
+	}
+	return violations
+}
+
+// Expired returns the first rule matching the workspace whose max_age it outlived. A nil policy
+// has no rules.
+func (p *Policy) Expired(workspace Workspace, now time.Time) (string, bool) {
+	if p == nil || workspace.Created.IsZero() {
+		return "", false
+	}
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+		if rule.maxAge > 0 && rule.Matches(workspace) && now.Sub(workspace.Created) > rule.maxAge {
+			return rule.Name, true
+		}
+	}
+	return "", false
+}
+
+// Check evaluates every rule against the workspaces it matches; the violations are grouped by
+// workspace, in the order of the workspaces and then of the rules
+func (p *Policy) Check(workspaces []Workspace, now time.Time) []PolicyViolation {
+	var violations []PolicyViolation
+	for _, workspace := range workspaces {
+		for i := range p.Rules {
+			rule := &p.Rules[i]
+			if rule.Matches(workspace) {
+				violations = append(violations, rule.Check(workspace, now)...)
+			}
+		}
+	}
+	return violations
+}
@@ -0,0 +1,121 @@
+package wsm
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		age     string
+		want    time.Duration
+		wantErr bool
+	}{
+		{age: "60d", want: 60 * 24 * time.Hour},
+		{age: "72h", want: 72 * time.Hour},
+		{age: "1h30m", want: 90 * time.Minute},
+		{age: "0d", wantErr: true},
+		{age: "-2h", wantErr: true},
+		{age: "d", wantErr: true},
+		{age: "two weeks", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseAge(tt.age)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseAge(%q) = %s, expected an error", tt.age, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseAge(%q) = %s, %v, want %s", tt.age, got, err, tt.want)
+		}
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		rules   int
+		wantErr string
+	}{
+		{
+			name: "valid",
+			content: `rules:
+  - name: go-branches
+    match: {tags: [go]}
+    require: {branch: "^(feat|fix)/", max_age: 60d}
+`,
+			rules: 1,
+		},
+		{name: "empty", content: "", rules: 0},
+		{
+			name: "misspelled condition",
+			content: `rules:
+  - name: go-branches
+    match: {tag: [go]}
+    require: {max_age: 60d}
+`,
+			wantErr: "field tag not found",
+		},
+		{
+			name:    "duplicate rule",
+			content: "rules:\n  - {name: a, require: {max_age: 1d}}\n  - {name: a, require: {max_age: 2d}}\n",
+			wantErr: "defined twice",
+		},
+		{
+			name:    "invalid age",
+			content: "rules:\n  - {name: a, require: {max_age: soon}}\n",
+			wantErr: "invalid max_age 'soon'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "policy.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			policy, err := LoadPolicy(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadPolicy failed: %v", err)
+			}
+			if len(policy.Rules) != tt.rules {
+				t.Errorf("got %d rule(s), want %d", len(policy.Rules), tt.rules)
+			}
+		})
+	}
+}
+
+func TestPolicyCheck(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	policy := &Policy{Rules: []PolicyRule{
+		{Name: "fresh", Require: PolicyRequirement{MaxAge: "30d"}},
+		{Name: "feature-branches", Match: PolicyMatch{Name: "feat-*"}, Require: PolicyRequirement{Branch: "^feature/"}},
+	}}
+	for i := range policy.Rules {
+		if err := policy.Rules[i].compile(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	workspaces := []Workspace{
+		{Name: "feat-old", Branch: "main", Created: now.AddDate(0, -2, 0)},
+		{Name: "other", Branch: "main", Created: now.AddDate(0, 0, -1)},
+	}
+	var got []string
+	for _, violation := range policy.Check(workspaces, now) {
+		got = append(got, violation.Workspace+":"+violation.Rule)
+	}
+	if want := []string{"feat-old:fresh", "feat-old:feature-branches"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("violations = %v, want %v", got, want)
+	}
+}
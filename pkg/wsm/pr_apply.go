@@ -0,0 +1,226 @@
+package wsm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/pkg/errors"
+)
+
+const (
+	// PRApplyMerge merges the head of the pull request with a merge commit
+	PRApplyMerge = "merge"
+	// PRApplyCherryPick cherry-picks the commits of the pull request
+	PRApplyCherryPick = "cherry-pick"
+)
+
+// pullRequestRefs are the refs forges publish pull requests under: GitHub and Gitea, then GitLab
+var pullRequestRefs = []string{"pull/%d/head", "merge-requests/%d/head"}
+
+// AppliedPR records a pull request applied onto the workspace branch with 'wsm apply-pr'
+type AppliedPR struct {
+	Repository string `json:"repository"`
+	Number     int    `json:"number"`
+	Remote     string `json:"remote"`
+	// Head is the commit of the pull request that was applied
+	Head   string `json:"head"`
+	Method string `json:"method"`
+	// Commits is the number of commits of the pull request
+	Commits    int    `json:"commits"`
+	URL        string `json:"url,omitempty"`
+	Title      string `json:"title,omitempty"`
+	Author     string `json:"author,omitempty"`
+	HeadBranch string `json:"head_branch,omitempty"`
+	// Conflicts are the files left conflicted when the pull request was applied
+	Conflicts []string  `json:"conflicts,omitempty"`
+	Applied   time.Time `json:"applied"`
+}
+
+// String describes the pull request as #N, with its title when known
+func (p AppliedPR) String() string {
+	if p.Title == "" {
+		return fmt.Sprintf("#%d", p.Number)
+	}
+	return fmt.Sprintf("#%d (%s)", p.Number, p.Title)
+}
+
+// ApplyPROptions configures ApplyPullRequest
+type ApplyPROptions struct {
+	// Remote the pull request is fetched from, origin by default
+	Remote string
+	// Method is PRApplyMerge (default) or PRApplyCherryPick
+	Method string
+}
+
+// ApplyPullRequest fetches a pull request of a workspace repository from its remote and merges or
+// cherry-picks it onto the workspace branch, e.g. to test a colleague's change together with the
+// workspace. The pull request is recorded in the workspace configuration, also when applying it
+// left conflicts to resolve; those are returned in AppliedPR.Conflicts.
+func (wm *WorkspaceManager) ApplyPullRequest(ctx context.Context, workspace *Workspace, repoName string, number int, opts ApplyPROptions) (*AppliedPR, error) {
+	start := time.Now()
+	if err := workspace.RequireWorktrees("applying a pull request"); err != nil {
+		return nil, err
+	}
+	repo, ok := FindRepository(workspace, repoName)
+	if !ok {
+		return nil, errors.Errorf("repository '%s' is not in workspace '%s'", repoName, workspace.Name)
+	}
+	if number <= 0 {
+		return nil, errors.Errorf("invalid pull request number %d", number)
+	}
+	if opts.Remote == "" {
+		opts.Remote = "origin"
+	}
+	switch opts.Method {
+	case "":
+		opts.Method = PRApplyMerge
+	case PRApplyMerge, PRApplyCherryPick:
+	default:
+		return nil, errors.Errorf("unknown method '%s': use %s or %s", opts.Method, PRApplyMerge, PRApplyCherryPick)
+	}
+
+	dir := filepath.Join(workspace.Path, repo.Name)
+	if HasUncommittedChanges(ctx, dir) {
+		return nil, errors.Errorf("%s has uncommitted changes; commit or stash them first", repo.Name)
+	}
+
+	head, err := wm.fetchPullRequest(ctx, repo.Name, dir, opts.Remote, number)
+	if err != nil {
+		return nil, err
+	}
+	applied := &AppliedPR{
+		Repository: repo.Name,
+		Number:     number,
+		Remote:     opts.Remote,
+		Head:       head,
+		Method:     opts.Method,
+		Applied:    time.Now(),
+	}
+	wm.describePullRequest(ctx, dir, applied)
+
+	if _, err := gitOutput(ctx, wm.runner(), dir, "merge-base", "--is-ancestor", head, "HEAD"); err == nil {
+		return nil, errors.Errorf("%s already contains pull request %s", repo.Name, applied)
+	}
+	base, err := gitOutput(ctx, wm.runner(), dir, "merge-base", "HEAD", head)
+	if err != nil {
+		return nil, errors.Wrapf(err, "pull request %s shares no history with %s", applied, repo.Name)
+	}
+	count, err := gitOutput(ctx, wm.runner(), dir, "rev-list", "--count", base+".."+head)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to count the commits of the pull request")
+	}
+	applied.Commits, _ = strconv.Atoi(count)
+	if opts.Method == PRApplyCherryPick {
+		// Merge commits cannot be cherry-picked without choosing a mainline
+		merges, err := gitOutput(ctx, wm.runner(), dir, "rev-list", "--count", "--merges", base+".."+head)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to count the merge commits of the pull request")
+		}
+		if merges != "0" {
+			return nil, errors.Errorf("pull request %s contains merge commits and cannot be cherry-picked; merge it instead", applied)
+		}
+	}
+
+	var applyErr error
+	if opts.Method == PRApplyCherryPick {
+		// -x notes the original commit in each message
+		_, applyErr = gitOutput(ctx, wm.runner(), dir, "cherry-pick", "-x", base+".."+head)
+	} else {
+		message := fmt.Sprintf("Merge pull request %s from %s", applied, opts.Remote)
+		_, applyErr = gitOutput(ctx, wm.runner(), dir, "merge", "--no-ff", "-m", message, head)
+	}
+	if applyErr != nil {
+		conflicts, _ := gitOutput(ctx, wm.runner(), dir, "diff", "--name-only", "--diff-filter=U")
+		if conflicts == "" {
+			// Nothing is left to resolve, so a merge or cherry-pick still in progress is aborted to
+			// take the repository back to where it was
+			inProgress := "MERGE_HEAD"
+			if opts.Method == PRApplyCherryPick {
+				inProgress = "CHERRY_PICK_HEAD"
+			}
+			if _, err := gitOutput(ctx, wm.runner(), dir, "rev-parse", "--quiet", "--verify", inProgress); err != nil {
+				return nil, errors.Wrapf(applyErr, "failed to %s pull request %s", opts.Method, applied)
+			}
+			if _, err := gitOutput(ctx, wm.runner(), dir, opts.Method, "--abort"); err != nil {
+				output.LogWarn(
+					fmt.Sprintf("Failed to abort git %s in %s: %v", opts.Method, repo.Name, err),
+					"Failed to abort applying the pull request",
+					"repo", repo.Name,
+					"method", opts.Method,
+					"error", err,
+				)
+			}
+			return nil, errors.Wrapf(applyErr, "failed to %s pull request %s", opts.Method, applied)
+		}
+		applied.Conflicts = strings.Split(conflicts, "\n")
+	}
+
+	workspace.AppliedPRs = append(workspace.AppliedPRs, *applied)
+	if err := wm.SaveWorkspace(workspace); err != nil {
+		return nil, errors.Wrap(err, "failed to save workspace configuration")
+	}
+	RecordOperation("apply-pr", workspace.Name, start, map[string]string{
+		"repo":   repo.Name,
+		"pr":     strconv.Itoa(number),
+		"head":   head,
+		"method": opts.Method,
+	})
+	return applied, nil
+}
+
+// fetchPullRequest fetches the head of a pull request from remote and returns its commit
+func (wm *WorkspaceManager) fetchPullRequest(ctx context.Context, repoName, dir, remote string, number int) (string, error) {
+	var fetchErr error
+	for _, ref := range pullRequestRefs {
+		ref = fmt.Sprintf(ref, number)
+		if _, fetchErr = wm.networkGit(ctx, repoName, OperationFetch, dir, "fetch", "--quiet", "--no-tags", remote, ref); fetchErr != nil {
+			if IsTimeout(fetchErr) {
+				return "", fetchErr
+			}
+			continue
+		}
+		return gitOutput(ctx, wm.runner(), dir, "rev-parse", "FETCH_HEAD^{commit}")
+	}
+	if strings.Contains(fetchErr.Error(), "couldn't find remote ref") {
+		return "", errors.Errorf("pull request #%d not found on %s", number, remote)
+	}
+	return "", errors.Wrapf(fetchErr, "failed to fetch pull request #%d from %s", number, remote)
+}
+
+// describePullRequest fills in title, author, URL and head branch of a pull request with the GitHub
+// CLI, when it is installed and knows the repository
+func (wm *WorkspaceManager) describePullRequest(ctx context.Context, dir string, applied *AppliedPR) {
+	if _, err := exec.LookPath("gh"); err != nil {
+		return
+	}
+	out, err := wm.runner().Output(ctx, dir, "gh", "pr", "view", strconv.Itoa(applied.Number),
+		"--json", "url,title,author,headRefName,headRefOid")
+	if err != nil {
+		return
+	}
+	var pr struct {
+		URL    string `json:"url"`
+		Title  string `json:"title"`
+		Author struct {
+			Login string `json:"login"`
+		} `json:"author"`
+		HeadRefName string `json:"headRefName"`
+		HeadRefOid  string `json:"headRefOid"`
+	}
+	// gh resolves the pull request against its default repository, which need not be the remote
+	// it was fetched from; only a matching head is trusted
+	if json.Unmarshal(out, &pr) != nil || pr.HeadRefOid != applied.Head {
+		return
+	}
+	applied.URL = pr.URL
+	applied.Title = pr.Title
+	applied.Author = pr.Author.Login
+	applied.HeadBranch = pr.HeadRefName
+}
@@ -0,0 +1,178 @@
+package wsm
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// newPRWorkspace creates a workspace manager and a workspace with a clone of a bare remote on main.
+// The returned function publishes the commits made by change on a branch off main as pull request n
+// and returns its head.
+func newPRWorkspace(t *testing.T) (*WorkspaceManager, *Workspace, string, func(n int, change func()) string) {
+	t.Helper()
+	useTestConfigDir(t)
+	configDir, err := ConfigDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &WorkspaceConfig{WorkspaceDir: t.TempDir(), RegistryPath: filepath.Join(configDir, "registry.json")}
+	wm, err := NewWorkspaceManagerWithBackends(config, config.RegistryPath, OSFS{}, ExecRunner{})
+	if err != nil {
+		t.Fatalf("failed to create workspace manager: %v", err)
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	root := t.TempDir()
+	remote := filepath.Join(t.TempDir(), "lib.git")
+	testGit(t, root, "init", "--quiet", "--bare", remote)
+	testGit(t, root, "clone", "--quiet", remote, "lib")
+	dir := filepath.Join(root, "lib")
+	commitFile(t, dir, "README.md", "base\n", "Initial")
+	testGit(t, dir, "branch", "-M", "main")
+	testGit(t, dir, "push", "--quiet", "origin", "main")
+
+	publishPR := func(n int, change func()) string {
+		testGit(t, dir, "checkout", "--quiet", "-b", "pr", "main")
+		change()
+		head := testGit(t, dir, "rev-parse", "HEAD")
+		testGit(t, dir, "push", "--quiet", "origin", "HEAD:refs/pull/"+strconv.Itoa(n)+"/head")
+		testGit(t, dir, "checkout", "--quiet", "main")
+		testGit(t, dir, "branch", "--quiet", "-D", "pr")
+		return head
+	}
+	workspace := &Workspace{Name: "ws", Path: root, Branch: "main", Repositories: []Repository{{Name: "lib", Path: dir}}}
+	return wm, workspace, dir, publishPR
+}
+
+func commitFile(t *testing.T, dir, name, content, message string) {
+	t.Helper()
+	writeGoFiles(t, dir, map[string]string{name: content})
+	testGit(t, dir, "add", name)
+	testGit(t, dir, "commit", "--quiet", "-m", message)
+}
+
+func TestApplyPullRequestMergesAndRecordsIt(t *testing.T) {
+	ctx := context.Background()
+	wm, workspace, dir, publishPR := newPRWorkspace(t)
+	head := publishPR(1, func() {
+		commitFile(t, dir, "a.txt", "a\n", "Add a")
+		commitFile(t, dir, "b.txt", "b\n", "Add b")
+	})
+
+	applied, err := wm.ApplyPullRequest(ctx, workspace, "lib", 1, ApplyPROptions{})
+	if err != nil {
+		t.Fatalf("ApplyPullRequest failed: %v", err)
+	}
+	if applied.Head != head || applied.Commits != 2 || applied.Method != PRApplyMerge || applied.Remote != "origin" || len(applied.Conflicts) != 0 {
+		t.Errorf("applied = %+v", applied)
+	}
+	if err := exec.Command("git", "-C", dir, "merge-base", "--is-ancestor", head, "HEAD").Run(); err != nil {
+		t.Errorf("the pull request was not merged: %v", err)
+	}
+
+	saved, err := wm.LoadWorkspace("ws")
+	if err != nil {
+		t.Fatalf("LoadWorkspace failed: %v", err)
+	}
+	if len(saved.AppliedPRs) != 1 || saved.AppliedPRs[0].Number != 1 || saved.AppliedPRs[0].Head != head {
+		t.Errorf("recorded pull requests = %+v", saved.AppliedPRs)
+	}
+
+	if _, err := wm.ApplyPullRequest(ctx, workspace, "lib", 1, ApplyPROptions{}); err == nil || !strings.Contains(err.Error(), "already contains") {
+		t.Errorf("applying the pull request twice should fail, got %v", err)
+	}
+	if _, err := wm.ApplyPullRequest(ctx, workspace, "lib", 2, ApplyPROptions{}); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected a missing pull request to be reported, got %v", err)
+	}
+}
+
+func TestApplyPullRequestCherryPick(t *testing.T) {
+	ctx := context.Background()
+	wm, workspace, dir, publishPR := newPRWorkspace(t)
+	head := publishPR(1, func() {
+		commitFile(t, dir, "a.txt", "a\n", "Add a")
+	})
+	commitFile(t, dir, "main.txt", "main\n", "Work on main")
+
+	applied, err := wm.ApplyPullRequest(ctx, workspace, "lib", 1, ApplyPROptions{Method: PRApplyCherryPick})
+	if err != nil {
+		t.Fatalf("ApplyPullRequest failed: %v", err)
+	}
+	if applied.Commits != 1 || len(applied.Conflicts) != 0 {
+		t.Errorf("applied = %+v", applied)
+	}
+	if message := testGit(t, dir, "log", "-1", "--format=%B"); !strings.Contains(message, "cherry picked from commit "+head) {
+		t.Errorf("the cherry-picked commit does not note its origin:\n%s", message)
+	}
+}
+
+func TestApplyPullRequestRejectsCherryPickingMerges(t *testing.T) {
+	ctx := context.Background()
+	wm, workspace, dir, publishPR := newPRWorkspace(t)
+	publishPR(1, func() {
+		testGit(t, dir, "checkout", "--quiet", "-b", "side")
+		commitFile(t, dir, "side.txt", "side\n", "Side")
+		testGit(t, dir, "checkout", "--quiet", "pr")
+		commitFile(t, dir, "a.txt", "a\n", "Add a")
+		testGit(t, dir, "merge", "--quiet", "--no-ff", "-m", "Merge side", "side")
+	})
+	before := testGit(t, dir, "rev-parse", "HEAD")
+
+	if _, err := wm.ApplyPullRequest(ctx, workspace, "lib", 1, ApplyPROptions{Method: PRApplyCherryPick}); err == nil || !strings.Contains(err.Error(), "merge commits") {
+		t.Errorf("expected cherry-picking a merge to be rejected, got %v", err)
+	}
+	if after := testGit(t, dir, "rev-parse", "HEAD"); after != before {
+		t.Errorf("HEAD moved from %s to %s", before, after)
+	}
+	if len(workspace.AppliedPRs) != 0 {
+		t.Errorf("a rejected pull request was recorded: %+v", workspace.AppliedPRs)
+	}
+}
+
+func TestApplyPullRequestAbortsFailedCherryPick(t *testing.T) {
+	ctx := context.Background()
+	wm, workspace, dir, publishPR := newPRWorkspace(t)
+	publishPR(1, func() {
+		commitFile(t, dir, "a.txt", "a\n", "Add a")
+	})
+	// The same change already on main makes the cherry-pick empty, which fails without conflicts
+	commitFile(t, dir, "a.txt", "a\n", "Add a on main")
+	before := testGit(t, dir, "rev-parse", "HEAD")
+
+	if _, err := wm.ApplyPullRequest(ctx, workspace, "lib", 1, ApplyPROptions{Method: PRApplyCherryPick}); err == nil {
+		t.Fatal("expected the empty cherry-pick to fail")
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".git", "CHERRY_PICK_HEAD")); !os.IsNotExist(err) {
+		t.Errorf("the repository was left in the middle of a cherry-pick")
+	}
+	if after := testGit(t, dir, "rev-parse", "HEAD"); after != before {
+		t.Errorf("HEAD moved from %s to %s", before, after)
+	}
+}
+
+func TestApplyPullRequestRecordsConflicts(t *testing.T) {
+	ctx := context.Background()
+	wm, workspace, dir, publishPR := newPRWorkspace(t)
+	publishPR(1, func() {
+		commitFile(t, dir, "README.md", "pull request\n", "Change README")
+	})
+	commitFile(t, dir, "README.md", "main\n", "Change README on main")
+
+	applied, err := wm.ApplyPullRequest(ctx, workspace, "lib", 1, ApplyPROptions{})
+	if err != nil {
+		t.Fatalf("ApplyPullRequest failed: %v", err)
+	}
+	if len(applied.Conflicts) != 1 || applied.Conflicts[0] != "README.md" {
+		t.Errorf("conflicts = %v, want [README.md]", applied.Conflicts)
+	}
+	if len(workspace.AppliedPRs) != 1 {
+		t.Errorf("the conflicted pull request was not recorded: %+v", workspace.AppliedPRs)
+	}
+}
@@ -0,0 +1,191 @@
+package wsm
+
+import (
+	"context"
+	"encoding/json"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// MergedPullRequest is a merged pull request of a workspace branch
+type MergedPullRequest struct {
+	Number   int       `json:"number"`
+	URL      string    `json:"url"`
+	Base     string    `json:"base"`
+	Head     string    `json:"head"`
+	MergedAt time.Time `json:"merged_at"`
+}
+
+// PRCleanup is what is left to clean up in one repository of a workspace once its pull request
+// is merged
+type PRCleanup struct {
+	Repository string `json:"repository"`
+	Branch     string `json:"branch"`
+	// Merged is the merged pull request of the branch, nil when there is none
+	Merged *MergedPullRequest `json:"merged,omitempty"`
+	// Open is the URL of a pull request of the branch that is still open
+	Open string `json:"open,omitempty"`
+	// RemoteBranch reports whether the branch still exists on origin
+	RemoteBranch bool `json:"remote_branch"`
+	// LocalCommits counts the commits of the worktree that are not part of the merged pull request,
+	// or of the default branch when the branch has no pull request
+	LocalCommits int    `json:"local_commits"`
+	Error        string `json:"error,omitempty"`
+}
+
+// Finished reports whether nothing of the repository remains to be merged
+func (c PRCleanup) Finished() bool {
+	return c.Error == "" && c.Open == "" && c.LocalCommits == 0
+}
+
+// PRCleanupPlan looks up the pull requests of the branch of every workspace repository with the
+// GitHub CLI and what remains of them: the remote branch and commits made after the merge
+func (wm *WorkspaceManager) PRCleanupPlan(ctx context.Context, workspace *Workspace) ([]PRCleanup, error) {
+	if _, err := exec.LookPath("gh"); err != nil {
+		return nil, errors.New("the GitHub CLI (gh) is required to look up pull requests")
+	}
+
+	var plan []PRCleanup
+	for _, repo := range workspace.Repositories {
+		dir := filepath.Join(workspace.Path, repo.Name)
+		cleanup := PRCleanup{Repository: repo.Name, Branch: workspace.Branch}
+		if branch, err := gitOutput(ctx, wm.runner(), dir, "branch", "--show-current"); err == nil && branch != "" {
+			cleanup.Branch = branch
+		}
+		if err := wm.inspectPullRequests(ctx, dir, &cleanup); err != nil {
+			cleanup.Error = err.Error()
+		}
+		plan = append(plan, cleanup)
+	}
+	return plan, nil
+}
+
+func (wm *WorkspaceManager) inspectPullRequests(ctx context.Context, dir string, cleanup *PRCleanup) error {
+	out, err := wm.runner().Output(ctx, dir, "gh", "pr", "list", "--head", cleanup.Branch, "--state", "all",
+		"--json", "number,url,state,baseRefName,headRefOid,mergedAt")
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return errors.Errorf("failed to list pull requests: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return errors.Wrap(err, "failed to list pull requests")
+	}
+	var prs []struct {
+		Number      int       `json:"number"`
+		URL         string    `json:"url"`
+		State       string    `json:"state"`
+		BaseRefName string    `json:"baseRefName"`
+		HeadRefOid  string    `json:"headRefOid"`
+		MergedAt    time.Time `json:"mergedAt"`
+	}
+	if err := json.Unmarshal(out, &prs); err != nil {
+		return errors.Wrap(err, "failed to parse pull requests")
+	}
+	for _, pr := range prs {
+		switch pr.State {
+		case "OPEN":
+			cleanup.Open = pr.URL
+		case "MERGED":
+			if cleanup.Merged == nil || pr.MergedAt.After(cleanup.Merged.MergedAt) {
+				cleanup.Merged = &MergedPullRequest{Number: pr.Number, URL: pr.URL, Base: pr.BaseRefName, Head: pr.HeadRefOid, MergedAt: pr.MergedAt}
+			}
+		}
+	}
+
+	remote, err := gitOutput(ctx, wm.runner(), dir, "ls-remote", "--heads", "origin", cleanup.Branch)
+	if err != nil {
+		return errors.Wrap(err, "failed to check the remote branch")
+	}
+	cleanup.RemoteBranch = remote != ""
+
+	// Commits made after the merge would be lost with the workspace; squash merges leave the
+	// commits of the branch out of the base, so they are compared with the merged head instead
+	since := ""
+	if cleanup.Merged != nil {
+		if _, err := gitOutput(ctx, wm.runner(), dir, "cat-file", "-e", cleanup.Merged.Head+"^{commit}"); err == nil {
+			since = cleanup.Merged.Head
+		}
+	} else {
+		since = wm.RepositoryDefaultBranch(ctx, dir)
+	}
+	if since == "" {
+		return nil
+	}
+	count, err := gitOutput(ctx, wm.runner(), dir, "rev-list", "--count", since+"..HEAD")
+	if err != nil {
+		return errors.Wrap(err, "failed to count local commits")
+	}
+	cleanup.LocalCommits, _ = strconv.Atoi(count)
+	return nil
+}
+
+// DeleteRemoteBranch deletes a branch from origin; a branch that is already gone, e.g. deleted by
+// GitHub on merge, is not an error
+func (wm *WorkspaceManager) DeleteRemoteBranch(ctx context.Context, repo Repository, branch string) error {
+	if _, err := wm.networkGit(ctx, repo.Name, OperationPush, repo.Path, "push", "--quiet", "origin", "--delete", branch); err != nil {
+		if strings.Contains(err.Error(), "remote ref does not exist") {
+			_, _ = wm.networkGit(ctx, repo.Name, OperationFetch, repo.Path, "fetch", "--quiet", "--prune", "origin")
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// FastForwardBranch fetches a branch from origin and fast-forwards the local branch of the source
+// repository to it, in the worktree that has it checked out if any. It returns false when there is
+// no such local branch or it already was up to date; a branch that diverged is an error.
+func (wm *WorkspaceManager) FastForwardBranch(ctx context.Context, repo Repository, branch string) (bool, error) {
+	before, err := gitOutput(ctx, wm.runner(), repo.Path, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch)
+	if err != nil {
+		return false, nil
+	}
+	if _, err := wm.networkGit(ctx, repo.Name, OperationFetch, repo.Path, "fetch", "--quiet", "origin", branch); err != nil {
+		return false, errors.Wrapf(err, "failed to fetch %s", branch)
+	}
+
+	checkedOut, err := wm.branchWorktree(ctx, repo, branch)
+	if err != nil {
+		return false, err
+	}
+	if checkedOut != "" {
+		_, err = gitOutput(ctx, wm.runner(), checkedOut, "merge", "--ff-only", "--quiet", "origin/"+branch)
+	} else {
+		_, err = wm.networkGit(ctx, repo.Name, OperationFetch, repo.Path, "fetch", "--quiet", "origin", branch+":"+branch)
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to fast-forward %s", branch)
+	}
+
+	after, _ := gitOutput(ctx, wm.runner(), repo.Path, "rev-parse", "refs/heads/"+branch)
+	return after != before, nil
+}
+
+// branchWorktree returns the worktree of the source repository that has a branch checked out, or ""
+func (wm *WorkspaceManager) branchWorktree(ctx context.Context, repo Repository, branch string) (string, error) {
+	out, err := gitOutput(ctx, wm.runner(), repo.Path, "worktree", "list", "--porcelain")
+	if err != nil {
+		return "", err
+	}
+	current := ""
+	for _, line := range strings.Split(out, "\n") {
+		switch {
+		case strings.HasPrefix(line, "worktree "):
+			current = strings.TrimPrefix(line, "worktree ")
+		case line == "branch refs/heads/"+branch:
+			return current, nil
+		}
+	}
+	return "", nil
+}
+
+// DeleteLocalBranch deletes a branch of the source repository once its workspace worktree is gone
+func (wm *WorkspaceManager) DeleteLocalBranch(ctx context.Context, repo Repository, branch string) error {
+	_, err := gitOutput(ctx, wm.runner(), repo.Path, "branch", "--quiet", "-D", branch)
+	return err
+}
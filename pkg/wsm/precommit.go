@@ -0,0 +1,149 @@
+package wsm
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Hook frameworks supported by 'wsm precommit'
+const (
+	HookFrameworkPreCommit = "pre-commit"
+	HookFrameworkLefthook  = "lefthook"
+)
+
+// hookFrameworkConfigs are the configuration files that select a hook framework, in order of precedence
+var hookFrameworkConfigs = []struct {
+	framework string
+	file      string
+}{
+	{HookFrameworkPreCommit, ".pre-commit-config.yaml"},
+	{HookFrameworkLefthook, "lefthook.yml"},
+	{HookFrameworkLefthook, "lefthook.yaml"},
+	{HookFrameworkLefthook, ".lefthook.yml"},
+	{HookFrameworkLefthook, ".lefthook.yaml"},
+}
+
+// HookSuite is the pre-commit hook configuration of one repository of a workspace
+type HookSuite struct {
+	Repository string `json:"repository"`
+	Dir        string `json:"dir"`
+	Framework  string `json:"framework"`
+	Config     string `json:"config"`
+}
+
+// HookResult is the outcome of installing or running a hook suite
+type HookResult struct {
+	Suite    HookSuite     `json:"suite"`
+	Command  []string      `json:"command"`
+	Duration time.Duration `json:"duration"`
+	Output   string        `json:"output,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// DetectHookSuites returns the hook suite of each repository that configures pre-commit or
+// lefthook, optionally restricted to some repositories
+func DetectHookSuites(workspace *Workspace, repositories []string) []HookSuite {
+	var suites []HookSuite
+	for _, repo := range workspace.Repositories {
+		if len(repositories) > 0 && !slices.Contains(repositories, repo.Name) {
+			continue
+		}
+		dir := filepath.Join(workspace.Path, repo.Name)
+		for _, candidate := range hookFrameworkConfigs {
+			if fileExists(filepath.Join(dir, candidate.file)) {
+				suites = append(suites, HookSuite{
+					Repository: repo.Name,
+					Dir:        dir,
+					Framework:  candidate.framework,
+					Config:     candidate.file,
+				})
+				break
+			}
+		}
+	}
+	return suites
+}
+
+// InstallCommand returns the command installing the git hooks of the suite
+func (s HookSuite) InstallCommand() []string {
+	return []string{s.Framework, "install"}
+}
+
+// RunCommand returns the command running the pre-commit hooks of the suite, on the staged files
+// or on all files
+func (s HookSuite) RunCommand(allFiles bool) []string {
+	if s.Framework == HookFrameworkLefthook {
+		command := []string{"lefthook", "run", "pre-commit"}
+		if allFiles {
+			command = append(command, "--all-files")
+		}
+		return command
+	}
+	command := []string{"pre-commit", "run"}
+	if allFiles {
+		command = append(command, "--all-files")
+	}
+	return command
+}
+
+// RunHookCommands runs one command per suite with at most concurrency commands at a time and calls
+// done as each finishes. Failures are reported in the results and never stop the other suites.
+func RunHookCommands(ctx context.Context, suites []HookSuite, command func(HookSuite) []string, concurrency int, done func(completed int, result HookResult)) []HookResult {
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+
+	results := make([]HookResult, len(suites))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	completed := 0
+
+	for i, suite := range suites {
+		wg.Add(1)
+		go func(i int, suite HookSuite) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			result := runHookCommand(ctx, suite, command(suite))
+			results[i] = result
+
+			mu.Lock()
+			defer mu.Unlock()
+			completed++
+			if done != nil {
+				done(completed, result)
+			}
+		}(i, suite)
+	}
+
+	wg.Wait()
+	return results
+}
+
+func runHookCommand(ctx context.Context, suite HookSuite, command []string) HookResult {
+	result := HookResult{Suite: suite, Command: command}
+	start := time.Now()
+
+	if _, err := exec.LookPath(command[0]); err != nil {
+		result.Error = fmt.Sprintf("%s not found in PATH", command[0])
+		return result
+	}
+
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Dir = suite.Dir
+	out, err := cmd.CombinedOutput()
+	result.Duration = time.Since(start)
+	result.Output = strings.TrimRight(string(out), "\n")
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
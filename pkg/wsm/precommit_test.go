@@ -0,0 +1,87 @@
+package wsm
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDetectHookSuites(t *testing.T) {
+	root := t.TempDir()
+	writeGoFiles(t, root, map[string]string{
+		"api/.pre-commit-config.yaml": "repos: []\n",
+		"api/lefthook.yml":            "pre-commit: {}\n",
+		"web/.lefthook.yaml":          "pre-commit: {}\n",
+		"docs/README.md":              "",
+	})
+	workspace := &Workspace{Path: root, Repositories: []Repository{{Name: "api"}, {Name: "web"}, {Name: "docs"}}}
+
+	want := []HookSuite{
+		// pre-commit takes precedence over lefthook
+		{Repository: "api", Dir: filepath.Join(root, "api"), Framework: HookFrameworkPreCommit, Config: ".pre-commit-config.yaml"},
+		{Repository: "web", Dir: filepath.Join(root, "web"), Framework: HookFrameworkLefthook, Config: ".lefthook.yaml"},
+	}
+	if got := DetectHookSuites(workspace, nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("DetectHookSuites = %+v, want %+v", got, want)
+	}
+	if got := DetectHookSuites(workspace, []string{"web", "docs"}); !reflect.DeepEqual(got, want[1:]) {
+		t.Errorf("DetectHookSuites(web, docs) = %+v, want %+v", got, want[1:])
+	}
+}
+
+func TestHookSuiteCommands(t *testing.T) {
+	preCommit := HookSuite{Framework: HookFrameworkPreCommit}
+	lefthook := HookSuite{Framework: HookFrameworkLefthook}
+	tests := []struct {
+		got, want []string
+	}{
+		{preCommit.InstallCommand(), []string{"pre-commit", "install"}},
+		{lefthook.InstallCommand(), []string{"lefthook", "install"}},
+		{preCommit.RunCommand(false), []string{"pre-commit", "run"}},
+		{preCommit.RunCommand(true), []string{"pre-commit", "run", "--all-files"}},
+		{lefthook.RunCommand(false), []string{"lefthook", "run", "pre-commit"}},
+		{lefthook.RunCommand(true), []string{"lefthook", "run", "pre-commit", "--all-files"}},
+	}
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("command = %q, want %q", tt.got, tt.want)
+		}
+	}
+}
+
+func TestRunHookCommands(t *testing.T) {
+	dir := t.TempDir()
+	suites := []HookSuite{
+		{Repository: "ok", Dir: dir},
+		{Repository: "fails", Dir: dir},
+		{Repository: "missing", Dir: dir},
+	}
+	command := func(suite HookSuite) []string {
+		switch suite.Repository {
+		case "ok":
+			return []string{"sh", "-c", "pwd"}
+		case "fails":
+			return []string{"sh", "-c", "echo broken; exit 3"}
+		}
+		return []string{"wsm-no-such-hook-tool"}
+	}
+
+	completed := 0
+	results := RunHookCommands(context.Background(), suites, command, 1, func(n int, result HookResult) {
+		completed = n
+	})
+	if completed != 3 || len(results) != 3 {
+		t.Fatalf("completed %d suites with %d results, want 3", completed, len(results))
+	}
+	if results[0].Error != "" || !strings.HasSuffix(results[0].Output, filepath.Base(dir)) {
+		t.Errorf("ok = %+v, want the output of a run in the repository", results[0])
+	}
+	if results[1].Error == "" || results[1].Output != "broken" {
+		t.Errorf("fails = %+v, want the error and the output", results[1])
+	}
+	if results[2].Error != "wsm-no-such-hook-tool not found in PATH" {
+		t.Errorf("missing = %+v", results[2])
+	}
+}
@@ -0,0 +1,282 @@
+package wsm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultPrefetchInterval is the time between two fetches of the scheduler
+	DefaultPrefetchInterval = 15 * time.Minute
+	// defaultPrefetchConcurrency is the number of repositories fetched at the same time
+	defaultPrefetchConcurrency = 8
+	// prefetchStateFile records the last fetch of every repository, next to the registry
+	prefetchStateFile = "prefetch.json"
+)
+
+// PrefetchConfig configures 'wsm prefetch' in config.yaml
+type PrefetchConfig struct {
+	// Interval is the time between fetches when scheduled, e.g. 10m
+	Interval string `json:"interval,omitempty" yaml:"interval,omitempty"`
+	// Concurrency is the number of repositories fetched at the same time
+	Concurrency int `json:"concurrency,omitempty" yaml:"concurrency,omitempty"`
+}
+
+// IntervalDuration parses Interval, falling back to DefaultPrefetchInterval
+func (c PrefetchConfig) IntervalDuration() (time.Duration, error) {
+	if c.Interval == "" {
+		return DefaultPrefetchInterval, nil
+	}
+	d, err := time.ParseDuration(c.Interval)
+	if err != nil || d <= 0 {
+		return 0, errors.Errorf("invalid prefetch.interval '%s'", c.Interval)
+	}
+	return d, nil
+}
+
+// PrefetchResult is the outcome of the last fetch of a repository
+type PrefetchResult struct {
+	Repository string        `json:"repository"`
+	Path       string        `json:"path"`
+	FetchedAt  time.Time     `json:"fetched_at,omitzero"`
+	Duration   time.Duration `json:"duration"`
+	Error      string        `json:"error,omitempty"`
+	// TimedOut is set when the fetch did not complete within its timeout
+	TimedOut bool `json:"timed_out,omitempty"`
+}
+
+// PrefetchState is the result of the last fetch of every repository, keyed by repository path
+type PrefetchState struct {
+	LastRun      time.Time                 `json:"last_run"`
+	Repositories map[string]PrefetchResult `json:"repositories"`
+}
+
+// FetchedAt returns when the repository at path was last fetched successfully
+func (s *PrefetchState) FetchedAt(path string) time.Time {
+	if s == nil {
+		return time.Time{}
+	}
+	return s.Repositories[path].FetchedAt
+}
+
+func prefetchStatePath() (string, error) {
+	configDir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, prefetchStateFile), nil
+}
+
+// LoadPrefetchState reads the results of the previous fetches; it returns an empty state when
+// nothing was fetched yet
+func LoadPrefetchState() (*PrefetchState, error) {
+	state := &PrefetchState{Repositories: map[string]PrefetchResult{}}
+	path, err := prefetchStatePath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, errors.Wrapf(err, "failed to read %s", path)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", path)
+	}
+	if state.Repositories == nil {
+		state.Repositories = map[string]PrefetchResult{}
+	}
+	return state, nil
+}
+
+func savePrefetchState(state *PrefetchState) error {
+	path, err := prefetchStatePath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to encode prefetch state")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrapf(err, "failed to create %s", filepath.Dir(path))
+	}
+	// Written atomically: status reads the file while the scheduler updates it
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return errors.Wrapf(err, "failed to write %s", tmp)
+	}
+	return errors.Wrapf(os.Rename(tmp, path), "failed to write %s", path)
+}
+
+// Prefetch fetches all remotes of the repositories, concurrency at a time, without prompting for
+// credentials, and records the results for 'wsm status'. Worktrees share the refs of their
+// repository, so every workspace sees the new ahead/behind counts. Each fetch is bounded by the
+// fetch timeout of its repository. progress, if set, is called after each repository.
+func Prefetch(ctx context.Context, repos []Repository, concurrency int, timeouts TimeoutConfig, progress func(PrefetchResult)) ([]PrefetchResult, error) {
+	if concurrency <= 0 {
+		concurrency = defaultPrefetchConcurrency
+	}
+
+	results := make([]PrefetchResult, len(repos))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, concurrency)
+	for i, repo := range repos {
+		wg.Add(1)
+		go func(i int, repo Repository) {
+			defer wg.Done()
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				results[i] = PrefetchResult{Repository: repo.Name, Path: repo.Path, Error: ctx.Err().Error()}
+				return
+			}
+			defer func() { <-slots }()
+
+			result := fetchRepository(ctx, repo, timeouts)
+			results[i] = result
+			if progress != nil {
+				mu.Lock()
+				progress(result)
+				mu.Unlock()
+			}
+		}(i, repo)
+	}
+	wg.Wait()
+
+	state, err := LoadPrefetchState()
+	if err != nil {
+		state = &PrefetchState{Repositories: map[string]PrefetchResult{}}
+	}
+	state.LastRun = time.Now()
+	for _, result := range results {
+		previous := state.Repositories[result.Path]
+		if result.Error != "" && !previous.FetchedAt.IsZero() {
+			// A failed fetch keeps the time of the last successful one
+			result.FetchedAt = previous.FetchedAt
+		}
+		state.Repositories[result.Path] = result
+	}
+	// Repositories no longer in the registry are forgotten
+	known := map[string]bool{}
+	for _, repo := range repos {
+		known[repo.Path] = true
+	}
+	for path := range state.Repositories {
+		if !known[path] {
+			delete(state.Repositories, path)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Repository < results[j].Repository })
+	return results, savePrefetchState(state)
+}
+
+func fetchRepository(ctx context.Context, repo Repository, timeouts TimeoutConfig) PrefetchResult {
+	result := PrefetchResult{Repository: repo.Name, Path: repo.Path}
+	ctx, done := WithNetworkTimeout(ctx, timeouts, repo.Name, OperationFetch)
+
+	start := time.Now()
+	out, err := runNonInteractiveGit(ctx, repo.Path, "fetch", "--all", "--prune", "--quiet")
+	err = done(err)
+	result.Duration = time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		if IsTimeout(err) {
+			result.TimedOut = true
+			result.Error = err.Error()
+		} else {
+			result.Error = errorLine(out, err)
+		}
+		return result
+	}
+	result.FetchedAt = time.Now()
+	return result
+}
+
+// PrefetchTimerUnits returns the systemd user service and timer that run 'wsm prefetch' every
+// interval with the given executable and profile, keyed by unit file name
+func PrefetchTimerUnits(executable, profile string, interval time.Duration) map[string]string {
+	name := prefetchUnitName(profile)
+	args := []string{executable, "prefetch", "--quiet"}
+	if profile != DefaultProfile {
+		args = append(args, "--profile", profile)
+	}
+
+	service := strings.Join([]string{
+		"[Unit]",
+		"Description=Fetch the repositories registered in workspace-manager",
+		"",
+		"[Service]",
+		"Type=oneshot",
+		"ExecStart=" + systemdCommandLine(args),
+		"",
+	}, "\n")
+	timer := strings.Join([]string{
+		"[Unit]",
+		"Description=Run " + name + " every " + interval.String(),
+		"",
+		"[Timer]",
+		"OnBootSec=2min",
+		fmt.Sprintf("OnUnitActiveSec=%ds", int(interval.Seconds())),
+		"Persistent=true",
+		"",
+		"[Install]",
+		"WantedBy=timers.target",
+		"",
+	}, "\n")
+
+	return map[string]string{
+		name + ".service": service,
+		name + ".timer":   timer,
+	}
+}
+
+// systemdCommandLine joins arguments for ExecStart=, quoting the ones systemd would split or
+// unescape and doubling the % and $ it would expand
+func systemdCommandLine(args []string) string {
+	expansions := strings.NewReplacer("%", "%%", "$", "$$")
+	escapes := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`)
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		arg = expansions.Replace(arg)
+		if arg == "" || arg == ";" || strings.ContainsAny(arg, " \t\n\"'\\") {
+			arg = `"` + escapes.Replace(arg) + `"`
+		}
+		quoted[i] = arg
+	}
+	return strings.Join(quoted, " ")
+}
+
+// prefetchUnitName is the name of the systemd units of a profile
+func prefetchUnitName(profile string) string {
+	if profile == DefaultProfile {
+		return "wsm-prefetch"
+	}
+	return "wsm-prefetch-" + profile
+}
+
+// PrefetchTimerName returns the systemd timer unit of the current profile
+func PrefetchTimerName() string {
+	return prefetchUnitName(CurrentProfile()) + ".timer"
+}
+
+// SystemdUserUnitDir is where systemd user units are installed
+func SystemdUserUnitDir() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get config directory")
+	}
+	return filepath.Join(configDir, "systemd", "user"), nil
+}
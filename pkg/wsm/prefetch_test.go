@@ -0,0 +1,32 @@
+package wsm
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSystemdCommandLine(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"/usr/bin/wsm", "prefetch", "--quiet"}, want: `/usr/bin/wsm prefetch --quiet`},
+		{args: []string{"/home/me/My Tools/wsm", "daemon", "run"}, want: `"/home/me/My Tools/wsm" daemon run`},
+		{args: []string{`/opt/"q"\wsm`, ""}, want: `"/opt/\"q\"\\wsm" ""`},
+		{args: []string{"/opt/100%/$HOME/wsm", ";"}, want: `/opt/100%%/$$HOME/wsm ";"`},
+	}
+	for _, tt := range tests {
+		if got := systemdCommandLine(tt.args); got != tt.want {
+			t.Errorf("systemdCommandLine(%q) = %s, want %s", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestPrefetchTimerUnitsQuoteTheExecutable(t *testing.T) {
+	units := PrefetchTimerUnits("/home/me/My Tools/wsm", "work", time.Hour)
+	service := units["wsm-prefetch-work.service"]
+	if !strings.Contains(service, "\nExecStart=\"/home/me/My Tools/wsm\" prefetch --quiet --profile work\n") {
+		t.Errorf("service:\n%s", service)
+	}
+}
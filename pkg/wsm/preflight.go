@@ -0,0 +1,199 @@
+package wsm
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PreflightCheck is the remote access check result for one repository
+type PreflightCheck struct {
+	Repository string `json:"repository"`
+	Remote     string `json:"remote"`
+	URL        string `json:"url"`
+	Transport  string `json:"transport"`
+	Reachable  bool   `json:"reachable"`
+	CanPush    bool   `json:"can_push"`
+	Error      string `json:"error,omitempty"`
+}
+
+// PreflightReport collects per-repository checks and the state of the local credential tooling
+type PreflightReport struct {
+	Checks           []PreflightCheck `json:"checks"`
+	SSHAgentKeys     int              `json:"ssh_agent_keys"`
+	SSHAgentError    string           `json:"ssh_agent_error,omitempty"`
+	GHAuthenticated  bool             `json:"gh_authenticated"`
+	GHError          string           `json:"gh_error,omitempty"`
+	CredentialHelper string           `json:"credential_helper,omitempty"`
+	// Signing are the signing key checks of repositories with a signing policy
+	Signing []SigningCheck `json:"signing,omitempty"`
+}
+
+// Failed returns the checks that will fail for the requested operation
+func (r *PreflightReport) Failed(requirePush bool) []PreflightCheck {
+	var failed []PreflightCheck
+	for _, check := range r.Checks {
+		if !check.Reachable || (requirePush && !check.CanPush) {
+			failed = append(failed, check)
+		}
+	}
+	return failed
+}
+
+// MissingSigningKeys returns the signing checks of repositories that require signed commits but
+// have no usable key
+func (r *PreflightReport) MissingSigningKeys() []SigningCheck {
+	var missing []SigningCheck
+	for _, check := range r.Signing {
+		if check.Mode == SigningRequire && !check.Available {
+			missing = append(missing, check)
+		}
+	}
+	return missing
+}
+
+const preflightTimeout = 20 * time.Second
+
+// RunPreflight verifies reachability (and optionally push access) of the given remote for every
+// repository of the workspace, running the checks concurrently and never prompting for credentials.
+func RunPreflight(ctx context.Context, workspace *Workspace, remote string, checkPush bool) *PreflightReport {
+	report := &PreflightReport{Checks: make([]PreflightCheck, len(workspace.Repositories))}
+
+	var wg sync.WaitGroup
+	for i, repo := range workspace.Repositories {
+		wg.Add(1)
+		go func(i int, repo Repository) {
+			defer wg.Done()
+			report.Checks[i] = checkRemoteAccess(ctx, repo.Name, filepath.Join(workspace.Path, repo.Name), remote, checkPush)
+		}(i, repo)
+	}
+	wg.Wait()
+
+	needsSSH, needsGitHub, needsHTTPS := false, false, false
+	for _, check := range report.Checks {
+		switch check.Transport {
+		case "ssh":
+			needsSSH = true
+		case "https", "http":
+			needsHTTPS = true
+		}
+		if strings.Contains(check.URL, "github.com") {
+			needsGitHub = true
+		}
+	}
+
+	if needsSSH {
+		out, err := exec.CommandContext(ctx, "ssh-add", "-l").CombinedOutput()
+		if err != nil {
+			report.SSHAgentError = strings.TrimSpace(string(out))
+			if report.SSHAgentError == "" {
+				report.SSHAgentError = err.Error()
+			}
+		} else {
+			report.SSHAgentKeys = len(strings.Split(strings.TrimSpace(string(out)), "\n"))
+		}
+	}
+
+	if needsGitHub {
+		out, err := exec.CommandContext(ctx, "gh", "auth", "status").CombinedOutput()
+		report.GHAuthenticated = err == nil
+		if err != nil {
+			report.GHError = errorLine(string(out), err)
+		}
+	}
+
+	if needsHTTPS && len(workspace.Repositories) > 0 {
+		helper, _ := runGitOutput(ctx, filepath.Join(workspace.Path, workspace.Repositories[0].Name), "config", "--get", "credential.helper")
+		report.CredentialHelper = helper
+	}
+
+	return report
+}
+
+func checkRemoteAccess(ctx context.Context, repoName, repoPath, remote string, checkPush bool) PreflightCheck {
+	check := PreflightCheck{Repository: repoName, Remote: remote}
+
+	url, err := runGitOutput(ctx, repoPath, "remote", "get-url", remote)
+	if err != nil {
+		check.Error = "remote '" + remote + "' is not configured"
+		return check
+	}
+	check.URL = url
+	check.Transport = remoteTransport(url)
+
+	ctx, cancel := context.WithTimeout(ctx, preflightTimeout)
+	defer cancel()
+
+	if out, err := runNonInteractiveGit(ctx, repoPath, "ls-remote", "--heads", remote); err != nil {
+		check.Error = errorLine(out, err)
+		return check
+	}
+	check.Reachable = true
+
+	if !checkPush {
+		return check
+	}
+
+	branch, err := runGitOutput(ctx, repoPath, "branch", "--show-current")
+	if err != nil || branch == "" {
+		check.Error = "cannot determine current branch to test push access"
+		return check
+	}
+
+	out, err := runNonInteractiveGit(ctx, repoPath, "push", "--dry-run", "--porcelain", remote, "HEAD:refs/heads/"+branch)
+	// A rejected (non-fast-forward) dry-run push still proves that authentication succeeded
+	if err == nil || strings.Contains(out, "[rejected]") || strings.Contains(out, "non-fast-forward") {
+		check.CanPush = true
+	} else {
+		check.Error = errorLine(out, err)
+	}
+
+	return check
+}
+
+// runNonInteractiveGit runs git with prompts disabled so missing credentials fail fast instead of hanging
+func runNonInteractiveGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := gitCommand(ctx, dir, "git", args...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if os.Getenv("GIT_SSH_COMMAND") == "" {
+		cmd.Env = append(cmd.Env, "GIT_SSH_COMMAND=ssh -o BatchMode=yes -o ConnectTimeout=10")
+	}
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
+// remoteTransport classifies a remote URL as ssh, https, http, file or local
+func remoteTransport(url string) string {
+	switch {
+	case strings.HasPrefix(url, "https://"):
+		return "https"
+	case strings.HasPrefix(url, "http://"):
+		return "http"
+	case strings.HasPrefix(url, "ssh://"), strings.Contains(url, "@") && strings.Contains(url, ":"):
+		return "ssh"
+	case strings.HasPrefix(url, "file://"):
+		return "file"
+	default:
+		return "local"
+	}
+}
+
+// errorLine picks the most useful line of a failed command's output: the first fatal/error line,
+// otherwise the last non-empty line, falling back to the error itself
+func errorLine(out string, err error) string {
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "fatal:") || strings.HasPrefix(line, "error:") || strings.HasPrefix(line, "ERROR:") {
+			return line
+		}
+	}
+	if last := strings.TrimSpace(lines[len(lines)-1]); last != "" {
+		return last
+	}
+	return err.Error()
+}
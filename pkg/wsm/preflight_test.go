@@ -0,0 +1,62 @@
+package wsm
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestRemoteTransport(t *testing.T) {
+	tests := map[string]string{
+		"https://github.com/go-go-golems/wsm.git": "https",
+		"http://git.internal/wsm.git":             "http",
+		"ssh://git@github.com/go-go-golems/wsm":   "ssh",
+		"git@github.com:go-go-golems/wsm.git":     "ssh",
+		"file:///srv/git/wsm.git":                 "file",
+		"/srv/git/wsm.git":                        "local",
+	}
+	for url, want := range tests {
+		if got := remoteTransport(url); got != want {
+			t.Errorf("remoteTransport(%s) = %s, want %s", url, got, want)
+		}
+	}
+}
+
+func TestErrorLine(t *testing.T) {
+	err := errors.New("exit status 128")
+	tests := []struct {
+		out  string
+		want string
+	}{
+		{out: "warning: redirecting\nfatal: could not read Username\nmore\n", want: "fatal: could not read Username"},
+		{out: "Permission denied (publickey).\nERROR: Repository not found.\n", want: "ERROR: Repository not found."},
+		{out: "  connecting\n  timed out  \n", want: "timed out"},
+		{out: "", want: "exit status 128"},
+	}
+	for _, tt := range tests {
+		if got := errorLine(tt.out, err); got != tt.want {
+			t.Errorf("errorLine(%q) = %q, want %q", tt.out, got, tt.want)
+		}
+	}
+}
+
+func TestPreflightReportFailed(t *testing.T) {
+	report := &PreflightReport{Checks: []PreflightCheck{
+		{Repository: "ok", Reachable: true, CanPush: true},
+		{Repository: "read-only", Reachable: true},
+		{Repository: "down"},
+	}}
+	names := func(checks []PreflightCheck) []string {
+		var names []string
+		for _, check := range checks {
+			names = append(names, check.Repository)
+		}
+		return names
+	}
+	if got := names(report.Failed(false)); len(got) != 1 || got[0] != "down" {
+		t.Errorf("Failed(false) = %v, want [down]", got)
+	}
+	if got := names(report.Failed(true)); len(got) != 2 || got[0] != "read-only" || got[1] != "down" {
+		t.Errorf("Failed(true) = %v, want [read-only down]", got)
+	}
+}
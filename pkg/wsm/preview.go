@@ -0,0 +1,82 @@
+package wsm
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// RepositoryPreview holds short descriptive metadata used when choosing repositories
+type RepositoryPreview struct {
+	Description       string    `json:"description"`
+	LastCommitSubject string    `json:"last_commit_subject"`
+	LastCommitDate    time.Time `json:"last_commit_date"`
+}
+
+var readmeNames = []string{"README.md", "README", "README.txt", "README.rst", "readme.md"}
+
+// GetRepositoryPreview reads the README description and last commit of a repository.
+// Missing information is left empty.
+func GetRepositoryPreview(ctx context.Context, repo Repository) RepositoryPreview {
+	preview := RepositoryPreview{
+		Description: readRepositoryDescription(repo.Path),
+	}
+
+	logLine, err := runGitOutput(ctx, repo.Path, "log", "-1", "--format=%cI%x00%s")
+	if err == nil {
+		if parts := strings.SplitN(logLine, "\x00", 2); len(parts) == 2 {
+			preview.LastCommitSubject = parts[1]
+			if date, err := time.Parse(time.RFC3339, parts[0]); err == nil {
+				preview.LastCommitDate = date
+			}
+		}
+	}
+
+	return preview
+}
+
+// readRepositoryDescription returns the first prose line of the README, falling back to its title
+func readRepositoryDescription(repoPath string) string {
+	for _, name := range readmeNames {
+		f, err := os.Open(filepath.Join(repoPath, name))
+		if err != nil {
+			continue
+		}
+		defer f.Close()
+
+		title := ""
+		inCodeBlock := false
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if strings.HasPrefix(line, "```") {
+				inCodeBlock = !inCodeBlock
+				continue
+			}
+			if inCodeBlock || line == "" || isMarkupLine(line) {
+				continue
+			}
+			if strings.HasPrefix(line, "#") {
+				if title == "" {
+					title = strings.TrimSpace(strings.TrimLeft(line, "#"))
+				}
+				continue
+			}
+			return strings.Trim(line, "*_")
+		}
+		return title
+	}
+
+	return ""
+}
+
+// isMarkupLine reports lines that carry no description: badges, images, HTML, rules and underlines
+func isMarkupLine(line string) bool {
+	return strings.HasPrefix(line, "[![") ||
+		strings.HasPrefix(line, "![") ||
+		strings.HasPrefix(line, "<") ||
+		strings.Trim(line, "=-*_") == ""
+}
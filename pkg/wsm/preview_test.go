@@ -0,0 +1,29 @@
+package wsm
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestReadRepositoryDescription(t *testing.T) {
+	root := t.TempDir()
+	writeGoFiles(t, root, map[string]string{
+		"prose/README.md": "# Tool\n\n[![CI](https://ci/badge.svg)](https://ci)\n<p align=\"center\">\n\n```sh\nmake install\n```\n\n**Manages workspaces.**\n",
+		"title/README.md": "Title\n=====\n",
+		"heading/README":  "# Only a heading\n\n---\n",
+		"empty/README.md": "",
+		"none/main.go":    "package main\n",
+	})
+	tests := map[string]string{
+		"prose":   "Manages workspaces.",
+		"title":   "Title",
+		"heading": "Only a heading",
+		"empty":   "",
+		"none":    "",
+	}
+	for repo, want := range tests {
+		if got := readRepositoryDescription(filepath.Join(root, repo)); got != want {
+			t.Errorf("%s: description = %q, want %q", repo, got, want)
+		}
+	}
+}
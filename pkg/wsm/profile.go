@@ -0,0 +1,113 @@
+package wsm
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// ProfileEnvVar selects the profile when --profile is not given
+const ProfileEnvVar = "WSM_PROFILE"
+
+// DefaultProfile is the profile whose files live directly in the configuration directory
+const DefaultProfile = "default"
+
+var profileNameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// profile is the profile selected with SetProfile, taking precedence over WSM_PROFILE
+var profile string
+
+// SetProfile selects the profile for the rest of the process
+func SetProfile(name string) error {
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+	profile = name
+	return nil
+}
+
+// ValidateProfileName rejects names that cannot be used as a directory name
+func ValidateProfileName(name string) error {
+	if !profileNameRegex.MatchString(name) {
+		return errors.Errorf("invalid profile name '%s' (letters, digits, '.', '_' and '-')", name)
+	}
+	return nil
+}
+
+// CurrentProfile returns the selected profile: --profile, then WSM_PROFILE, then "default"
+func CurrentProfile() string {
+	if profile != "" {
+		return profile
+	}
+	if name := os.Getenv(ProfileEnvVar); name != "" {
+		return name
+	}
+	return DefaultProfile
+}
+
+// baseConfigDir is the configuration directory of the default profile
+func baseConfigDir() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get config directory")
+	}
+	return filepath.Join(configDir, "workspace-manager"), nil
+}
+
+// ConfigDir returns the configuration directory of the current profile, holding its
+// config.yaml, registry, workspace configurations and history. Named profiles live in
+// profiles/<name> below the directory of the default profile.
+func ConfigDir() (string, error) {
+	return ProfileConfigDir(CurrentProfile())
+}
+
+// ProfileConfigDir returns the configuration directory of a profile
+func ProfileConfigDir(name string) (string, error) {
+	if err := ValidateProfileName(name); err != nil {
+		return "", err
+	}
+	base, err := baseConfigDir()
+	if err != nil {
+		return "", err
+	}
+	if name == DefaultProfile {
+		return base, nil
+	}
+	return filepath.Join(base, "profiles", name), nil
+}
+
+// ListProfiles returns the default profile and every profile with a configuration directory
+func ListProfiles() ([]string, error) {
+	base, err := baseConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	profiles := []string{DefaultProfile}
+	entries, err := os.ReadDir(filepath.Join(base, "profiles"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return profiles, nil
+		}
+		return nil, errors.Wrap(err, "failed to read profiles directory")
+	}
+	var named []string
+	for _, entry := range entries {
+		if entry.IsDir() && ValidateProfileName(entry.Name()) == nil && entry.Name() != DefaultProfile {
+			named = append(named, entry.Name())
+		}
+	}
+	sort.Strings(named)
+	return append(profiles, named...), nil
+}
+
+// defaultWorkspaceRoot is where workspaces of a profile are created unless workspace_dir is
+// configured: ~/workspaces for the default profile, ~/workspaces/<profile> for the others
+func defaultWorkspaceRoot(home, name string) string {
+	if name == DefaultProfile {
+		return filepath.Join(home, "workspaces")
+	}
+	return filepath.Join(home, "workspaces", name)
+}
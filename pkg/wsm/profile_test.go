@@ -0,0 +1,74 @@
+package wsm
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestValidateProfileName(t *testing.T) {
+	for name, valid := range map[string]bool{
+		"work":        true,
+		"client-a.v2": true,
+		"oss_2":       true,
+		"":            false,
+		".hidden":     false,
+		"..":          false,
+		"a/b":         false,
+		"my profile":  false,
+	} {
+		if err := ValidateProfileName(name); (err == nil) != valid {
+			t.Errorf("ValidateProfileName(%q) = %v, want valid %v", name, err, valid)
+		}
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	useTestConfigDir(t)
+	t.Cleanup(func() { profile = "" })
+	base, err := baseConfigDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := CurrentProfile(); got != DefaultProfile {
+		t.Errorf("CurrentProfile() = %s, want the default", got)
+	}
+	t.Setenv(ProfileEnvVar, "work")
+	if dir, _ := ConfigDir(); dir != filepath.Join(base, "profiles", "work") {
+		t.Errorf("ConfigDir() = %s", dir)
+	}
+	if err := SetProfile("oss"); err != nil {
+		t.Fatal(err)
+	}
+	if got := CurrentProfile(); got != "oss" {
+		t.Errorf("CurrentProfile() = %s, want the profile set over %s", got, ProfileEnvVar)
+	}
+	if err := SetProfile("../oss"); err == nil || CurrentProfile() != "oss" {
+		t.Error("SetProfile accepted an invalid name")
+	}
+	if dir, _ := ProfileConfigDir(DefaultProfile); dir != base {
+		t.Errorf("ProfileConfigDir(default) = %s, want %s", dir, base)
+	}
+
+	if got, err := ListProfiles(); err != nil || !slices.Equal(got, []string{DefaultProfile}) {
+		t.Errorf("ListProfiles() = %v (%v)", got, err)
+	}
+	for _, name := range []string{"work", "client", "default", ".trash"} {
+		if err := os.MkdirAll(filepath.Join(base, "profiles", name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeGoFiles(t, base, map[string]string{"profiles/notes.txt": ""})
+	if got, err := ListProfiles(); err != nil || !slices.Equal(got, []string{DefaultProfile, "client", "work"}) {
+		t.Errorf("ListProfiles() = %v (%v)", got, err)
+	}
+
+	if got := defaultWorkspaceRoot("/home/me", DefaultProfile); got != filepath.Join("/home/me", "workspaces") {
+		t.Errorf("defaultWorkspaceRoot(default) = %s", got)
+	}
+	if got := defaultWorkspaceRoot("/home/me", "work"); got != filepath.Join("/home/me", "workspaces", "work") {
+		t.Errorf("defaultWorkspaceRoot(work) = %s", got)
+	}
+}
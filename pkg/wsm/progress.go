@@ -0,0 +1,128 @@
+package wsm
+
+import (
+	"context"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// ProgressReporter receives the progress git reports while cloning, checking out, fetching and
+// pulling repositories, see output.Progress
+type ProgressReporter interface {
+	// Update reports that repo is in phase (e.g. "Receiving objects"), which is percent (0-1) done
+	Update(repo, phase string, percent float64)
+	// Done reports that all git operations on repo finished, with err when one of them failed
+	Done(repo string, err error)
+}
+
+// ProgressRunner is implemented by runners that can stream the standard error of a command while
+// it runs, which is where git writes its progress
+type ProgressRunner interface {
+	// RunWithProgress runs name in dir and calls onLine with each line of its standard error, split
+	// at carriage returns as well as newlines. Lines for which onLine returns true are dropped; the
+	// others are returned after the standard output, like CombinedOutput.
+	RunWithProgress(ctx context.Context, dir string, onLine func(line string) bool, name string, args ...string) ([]byte, error)
+}
+
+// gitProgressLine matches the progress lines of git and of the remote side, e.g.
+// "Receiving objects:  42% (420/1000)" or "remote: Counting objects: 100% (12/12), done."
+var gitProgressLine = regexp.MustCompile(`^(?:remote: )?([A-Z][a-z]+(?: [a-z]+)*):\s+(\d{1,3})%`)
+
+// runGitProgress runs git in dir and forwards its progress lines for repo to reporter. Without a
+// reporter, or with a runner that cannot stream, git runs as with CombinedOutput.
+func runGitProgress(ctx context.Context, runner CommandRunner, reporter ProgressReporter, repo, dir string, args ...string) ([]byte, error) {
+	runner = runnerOrDefault(runner)
+	progressRunner, ok := runner.(ProgressRunner)
+	if reporter == nil || !ok {
+		return runner.CombinedOutput(ctx, dir, "git", args...)
+	}
+
+	return progressRunner.RunWithProgress(ctx, dir, func(line string) bool {
+		match := gitProgressLine.FindStringSubmatch(line)
+		if match == nil {
+			return false
+		}
+		percent, err := strconv.Atoi(match[2])
+		if err != nil {
+			return false
+		}
+		reporter.Update(repo, match[1], float64(percent)/100)
+		return true
+	}, "git", args...)
+}
+
+// reportStart shows repo as started before git reports its first progress
+func reportStart(reporter ProgressReporter, repo, phase string) {
+	if reporter != nil {
+		reporter.Update(repo, phase, 0)
+	}
+}
+
+// reportDone marks repo as finished
+func reportDone(reporter ProgressReporter, repo string, err error) {
+	if reporter != nil {
+		reporter.Done(repo, err)
+	}
+}
+
+// worktreeAddTarget returns the path argument of a 'git worktree add' command line
+func worktreeAddTarget(args []string) (string, bool) {
+	if len(args) < 4 || args[0] != "git" || args[1] != "worktree" || args[2] != "add" {
+		return "", false
+	}
+	for i := 3; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "-b" || arg == "-B" || arg == "--reason":
+			i++
+		case strings.HasPrefix(arg, "-"):
+		default:
+			return arg, true
+		}
+	}
+	return "", false
+}
+
+// addWorktreeWithProgress runs a 'git worktree add' command line without checking out the files,
+// then checks them out with --progress: worktree add reports no progress itself, and the checkout
+// is what takes long in large repositories
+func (wm *WorkspaceManager) addWorktreeWithProgress(ctx context.Context, repoPath, target string, args []string) ([]byte, error) {
+	add := append([]string{"worktree", "add", "--no-checkout"}, args[3:]...)
+	out, err := wm.runner().CombinedOutput(ctx, repoPath, "git", add...)
+	if err != nil {
+		return out, err
+	}
+
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(repoPath, target)
+	}
+	checkout, err := runGitProgress(ctx, wm.runner(), wm.Progress, filepath.Base(target), target, "checkout", "--progress", "--force")
+	if err != nil {
+		wm.removeUncheckedWorktree(ctx, repoPath, target, args)
+		return append(out, checkout...), errors.Wrapf(err, "failed to check out the files of %s", target)
+	}
+	return append(out, checkout...), nil
+}
+
+// removeUncheckedWorktree rolls back a worktree added by addWorktreeWithProgress whose checkout
+// failed, with the branch it created, so that the repository looks as if the add had failed
+func (wm *WorkspaceManager) removeUncheckedWorktree(ctx context.Context, repoPath, target string, args []string) {
+	// The checkout may have failed because ctx was cancelled
+	ctx = context.WithoutCancel(ctx)
+	if out, err := wm.runner().CombinedOutput(ctx, repoPath, "git", "worktree", "remove", "--force", target); err != nil {
+		log.Warn().Err(err).Str("worktree", target).Str("output", string(out)).Msg("Failed to remove the worktree after its checkout failed")
+		return
+	}
+	for i := 3; i < len(args)-1; i++ {
+		if args[i] == "-b" {
+			if out, err := wm.runner().CombinedOutput(ctx, repoPath, "git", "branch", "-D", args[i+1]); err != nil {
+				log.Warn().Err(err).Str("branch", args[i+1]).Str("output", string(out)).Msg("Failed to delete the branch after its checkout failed")
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package wsm
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestScanProgressLines(t *testing.T) {
+	input := "Cloning into 'repo'...\nReceiving objects:  10% (1/10)\rReceiving objects: 100% (10/10), done.\r\nfatal: last line"
+	scanner := bufio.NewScanner(strings.NewReader(input))
+	scanner.Split(scanProgressLines)
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	want := []string{
+		"Cloning into 'repo'...",
+		"Receiving objects:  10% (1/10)",
+		"Receiving objects: 100% (10/10), done.",
+		"",
+		"fatal: last line",
+	}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("lines = %q, want %q", lines, want)
+	}
+}
+
+func TestGitProgressLine(t *testing.T) {
+	tests := []struct {
+		line    string
+		phase   string
+		percent string
+	}{
+		{line: "Receiving objects:  42% (420/1000)", phase: "Receiving objects", percent: "42"},
+		{line: "remote: Counting objects: 100% (12/12), done.", phase: "Counting objects", percent: "100"},
+		{line: "Updating files:   7% (7/100)", phase: "Updating files", percent: "7"},
+		{line: "Cloning into 'repo'..."},
+		{line: "error: 50% of the files are missing"},
+		{line: "fatal: could not read Username"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			match := gitProgressLine.FindStringSubmatch(tt.line)
+			if tt.phase == "" {
+				if match != nil {
+					t.Errorf("unexpected match %q", match)
+				}
+				return
+			}
+			if match == nil || match[1] != tt.phase || match[2] != tt.percent {
+				t.Errorf("match = %q, want phase %q at %s%%", match, tt.phase, tt.percent)
+			}
+		})
+	}
+}
+
+func TestWorktreeAddTarget(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		target string
+		ok     bool
+	}{
+		{name: "existing branch", args: []string{"git", "worktree", "add", "/ws/repo", "feat"}, target: "/ws/repo", ok: true},
+		{name: "new branch", args: []string{"git", "worktree", "add", "-b", "feat", "/ws/repo", "main"}, target: "/ws/repo", ok: true},
+		{name: "reset branch with flags", args: []string{"git", "worktree", "add", "--no-track", "-B", "feat", "/ws/repo", "main"}, target: "/ws/repo", ok: true},
+		{name: "locked with reason", args: []string{"git", "worktree", "add", "--lock", "--reason", "usb", "/ws/repo"}, target: "/ws/repo", ok: true},
+		{name: "other worktree command", args: []string{"git", "worktree", "remove", "/ws/repo"}},
+		{name: "no path", args: []string{"git", "worktree", "add", "-b", "feat"}},
+		{name: "not git", args: []string{"jj", "worktree", "add", "/ws/repo"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, ok := worktreeAddTarget(tt.args)
+			if target != tt.target || ok != tt.ok {
+				t.Errorf("worktreeAddTarget = %q, %v, want %q, %v", target, ok, tt.target, tt.ok)
+			}
+		})
+	}
+}
+
+// failingCheckoutRunner runs commands locally, except for git checkout which fails
+type failingCheckoutRunner struct {
+	ExecRunner
+}
+
+func (r failingCheckoutRunner) CombinedOutput(ctx context.Context, dir, name string, args ...string) ([]byte, error) {
+	if name == "git" && len(args) > 0 && args[0] == "checkout" {
+		return []byte("error: checkout failed"), errors.New("exit status 1")
+	}
+	return r.ExecRunner.CombinedOutput(ctx, dir, name, args...)
+}
+
+func TestAddWorktreeWithProgressRollsBackFailedCheckout(t *testing.T) {
+	repo := t.TempDir()
+	testGit(t, repo, "init")
+	testGit(t, repo, "commit", "--allow-empty", "-m", "initial")
+	target := filepath.Join(t.TempDir(), "repo")
+
+	wm := &WorkspaceManager{Runner: failingCheckoutRunner{}}
+	args := []string{"git", "worktree", "add", "-b", "feat", target, "main"}
+	if _, err := wm.addWorktreeWithProgress(context.Background(), repo, target, args); err == nil {
+		t.Fatal("expected the failed checkout to be reported")
+	}
+
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Errorf("the worktree directory was left behind: %v", err)
+	}
+	if worktrees := testGit(t, repo, "worktree", "list", "--porcelain"); strings.Contains(worktrees, target) {
+		t.Errorf("the worktree is still registered:\n%s", worktrees)
+	}
+	if branches := testGit(t, repo, "branch", "--list", "feat"); branches != "" {
+		t.Errorf("the branch created for the worktree was left behind: %q", branches)
+	}
+}
@@ -0,0 +1,263 @@
+package wsm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// What creating a workspace does when the quota is exceeded
+const (
+	QuotaWarn   = "warn"
+	QuotaRefuse = "refuse"
+)
+
+// QuotaModes are the valid values of quota.enforce
+var QuotaModes = []string{QuotaWarn, QuotaRefuse}
+
+// quotaCandidates is the number of workspaces suggested for deletion
+const quotaCandidates = 5
+
+// QuotaConfig limits the workspaces of a profile, so that build machines creating workspaces
+// unattended do not fill up their disks
+type QuotaConfig struct {
+	// MaxWorkspaces is the number of workspaces that may exist at the same time; 0 means no limit
+	MaxWorkspaces int `json:"max_workspaces,omitempty" yaml:"max_workspaces,omitempty"`
+	// DiskBudget is the space all workspaces may take together, e.g. 50GB or 200GiB
+	DiskBudget string `json:"disk_budget,omitempty" yaml:"disk_budget,omitempty"`
+	// Enforce is warn (default) or refuse
+	Enforce string `json:"enforce,omitempty" yaml:"enforce,omitempty"`
+}
+
+// Enabled reports whether any limit is set
+func (c QuotaConfig) Enabled() bool {
+	return c.MaxWorkspaces > 0 || c.DiskBudget != ""
+}
+
+// Refuses reports whether creating a workspace over quota fails
+func (c QuotaConfig) Refuses() bool {
+	return c.Enforce == QuotaRefuse
+}
+
+// DiskBudgetBytes parses DiskBudget; 0 means no budget
+func (c QuotaConfig) DiskBudgetBytes() (uint64, error) {
+	if c.DiskBudget == "" {
+		return 0, nil
+	}
+	budget, err := humanize.ParseBytes(c.DiskBudget)
+	if err != nil || budget == 0 {
+		return 0, errors.Errorf("invalid quota.disk_budget '%s': expected a size such as 50GB or 200GiB", c.DiskBudget)
+	}
+	return budget, nil
+}
+
+// Validate checks the limits and the enforcement mode
+func (c QuotaConfig) Validate() error {
+	if c.MaxWorkspaces < 0 {
+		return errors.Errorf("invalid quota.max_workspaces %d: expected a positive number", c.MaxWorkspaces)
+	}
+	if c.Enforce != "" && c.Enforce != QuotaWarn && c.Enforce != QuotaRefuse {
+		return errors.Errorf("invalid quota.enforce '%s': expected %s or %s", c.Enforce, QuotaWarn, QuotaRefuse)
+	}
+	_, err := c.DiskBudgetBytes()
+	return err
+}
+
+// QuotaReport is the use of the quota before a workspace is created
+type QuotaReport struct {
+	Workspaces    int    `json:"workspaces"`
+	MaxWorkspaces int    `json:"max_workspaces,omitempty"`
+	DiskUsage     uint64 `json:"disk_usage,omitempty"`
+	// TrashUsage is the part of DiskUsage taken by deleted workspaces waiting in the trash
+	TrashUsage uint64 `json:"trash_usage,omitempty"`
+	DiskBudget uint64 `json:"disk_budget,omitempty"`
+	// Exceeded describes each limit another workspace would exceed
+	Exceeded []string `json:"exceeded,omitempty"`
+	// Candidates are the workspaces to delete first: missing ones, then those past the max_age of
+	// a policy.yaml rule, then the other clean ones, oldest first. Workspaces with uncommitted
+	// changes are never suggested.
+	Candidates []QuotaCandidate `json:"candidates,omitempty"`
+	// Refuse is set when the quota forbids creating the workspace
+	Refuse bool `json:"refuse,omitempty"`
+}
+
+// QuotaCandidate is a workspace suggested for deletion, with why it comes first
+type QuotaCandidate struct {
+	WorkspaceUsage
+	Reason string `json:"reason"`
+}
+
+// QuotaExceededError is returned when a workspace is not created because of quota.enforce: refuse
+type QuotaExceededError struct {
+	Report *QuotaReport
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("workspace quota exceeded (%s); delete workspaces or pass --ignore-quota", strings.Join(e.Report.Exceeded, ", "))
+}
+
+// CheckQuota tells whether another workspace fits the quota of config.yaml. The disk usage of the
+// workspaces, and of the deleted ones in the trash, is only measured with disk, as walking them
+// takes a while; linked workspaces, which take no space, skip it. It returns nil when no quota is
+// configured.
+func (wm *WorkspaceManager) CheckQuota(ctx context.Context, disk bool) (*QuotaReport, error) {
+	config := wm.Config().Quota
+	if !config.Enabled() {
+		return nil, nil
+	}
+	budget, err := config.DiskBudgetBytes()
+	if err != nil {
+		return nil, err
+	}
+	workspaces, err := LoadWorkspaces()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load workspaces")
+	}
+
+	report := &QuotaReport{Workspaces: len(workspaces), MaxWorkspaces: config.MaxWorkspaces, DiskBudget: budget}
+	if config.MaxWorkspaces > 0 && len(workspaces) >= config.MaxWorkspaces {
+		report.Exceeded = append(report.Exceeded, fmt.Sprintf("%d workspaces exist, the limit is %d", len(workspaces), config.MaxWorkspaces))
+	}
+
+	var usages []WorkspaceUsage
+	if budget > 0 && disk {
+		usages = GetWorkspaceUsages(ctx, workspaces)
+		for _, usage := range usages {
+			report.DiskUsage += uint64(usage.DiskUsage)
+		}
+		// Deleted workspaces keep their space until trash.retention ends
+		trash, err := ListTrash()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list the trash")
+		}
+		for _, entry := range trash {
+			report.TrashUsage += uint64(DirectorySize(entry.Dir))
+		}
+		report.DiskUsage += report.TrashUsage
+		if report.DiskUsage >= budget {
+			used := humanize.Bytes(report.DiskUsage)
+			if report.TrashUsage > 0 {
+				used += fmt.Sprintf(" (%s of it in the trash)", humanize.Bytes(report.TrashUsage))
+			}
+			report.Exceeded = append(report.Exceeded, fmt.Sprintf("workspaces take %s, the disk budget is %s", used, humanize.Bytes(budget)))
+		}
+	}
+	if len(report.Exceeded) == 0 {
+		return report, nil
+	}
+
+	report.Refuse = config.Refuses()
+	if usages == nil {
+		usages = GetWorkspaceUsages(ctx, workspaces)
+	}
+	report.Candidates = deletionCandidates(usages, loadQuotaPolicy(), time.Now())
+	return report, nil
+}
+
+// EnforceQuota checks the quota before a workspace is created. Over quota, it warns with the
+// limits exceeded and the workspaces that can be deleted, and returns a *QuotaExceededError when
+// quota.enforce is refuse. Every way of creating a workspace calls it, unless IgnoreQuota is set.
+func (wm *WorkspaceManager) EnforceQuota(ctx context.Context, disk bool) error {
+	if wm.IgnoreQuota {
+		return nil
+	}
+	stop := func() {}
+	if disk && wm.Config().Quota.DiskBudget != "" {
+		stop = output.Spinner(os.Stderr, "Measuring workspace disk usage")
+	}
+	report, err := wm.CheckQuota(ctx, disk)
+	stop()
+	if err != nil {
+		return errors.Wrap(err, "failed to check the workspace quota")
+	}
+	if report == nil || len(report.Exceeded) == 0 {
+		return nil
+	}
+
+	for _, exceeded := range report.Exceeded {
+		output.PrintWarning("Workspace quota exceeded: %s", exceeded)
+	}
+	if len(report.Candidates) > 0 {
+		output.PrintInfo("Workspaces that can be deleted without losing changes:")
+		for _, candidate := range report.Candidates {
+			output.Printf("  %-24s %-14s %8s  %s\n",
+				candidate.Workspace.Name,
+				humanize.Time(candidate.Workspace.Created),
+				humanize.Bytes(uint64(candidate.DiskUsage)),
+				candidate.Reason)
+		}
+		output.Printf("  wsm delete %s --remove-files\n", report.Candidates[0].Workspace.Name)
+	} else {
+		output.PrintInfo("Every workspace has uncommitted changes; commit or stash them before deleting workspaces")
+	}
+	if report.TrashUsage > 0 || wm.Config().Trash.Enabled() {
+		output.PrintInfo("Deleted files stay in the trash until trash.retention ends (set it to off to free the space at once)")
+	}
+
+	if !report.Refuse {
+		return nil
+	}
+	return &QuotaExceededError{Report: report}
+}
+
+// loadQuotaPolicy reads policy.yaml for the max_age of its rules; the quota works without it
+func loadQuotaPolicy() *Policy {
+	path, err := DefaultPolicyPath()
+	if err != nil {
+		return nil
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil
+	}
+	policy, err := LoadPolicy(path)
+	if err != nil {
+		log.Warn().Err(err).Msg("Ignoring the policy for the quota candidates")
+		return nil
+	}
+	return policy
+}
+
+// deletionCandidates picks the workspaces whose deletion loses nothing: missing ones first, then
+// those older than the max_age of a policy rule, then the other clean ones, oldest first
+func deletionCandidates(usages []WorkspaceUsage, policy *Policy, now time.Time) []QuotaCandidate {
+	const (
+		rankMissing = iota
+		rankExpired
+		rankClean
+	)
+	var candidates []QuotaCandidate
+	ranks := map[string]int{}
+	for _, usage := range usages {
+		if usage.IsDirty() || usage.Workspace.Parent != "" {
+			continue
+		}
+		candidate := QuotaCandidate{WorkspaceUsage: usage, Reason: "clean"}
+		ranks[usage.Workspace.Name] = rankClean
+		if usage.Missing {
+			candidate.Reason = "missing"
+			ranks[usage.Workspace.Name] = rankMissing
+		} else if rule, ok := policy.Expired(usage.Workspace, now); ok {
+			candidate.Reason = fmt.Sprintf("older than the max_age of policy rule %s", rule)
+			ranks[usage.Workspace.Name] = rankExpired
+		}
+		candidates = append(candidates, candidate)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if ri, rj := ranks[candidates[i].Workspace.Name], ranks[candidates[j].Workspace.Name]; ri != rj {
+			return ri < rj
+		}
+		return candidates[i].Workspace.Created.Before(candidates[j].Workspace.Created)
+	})
+	if len(candidates) > quotaCandidates {
+		candidates = candidates[:quotaCandidates]
+	}
+	return candidates
+}
@@ -0,0 +1,97 @@
+package wsm
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeletionCandidates(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	policy := &Policy{Rules: []PolicyRule{
+		{Name: "short-lived", Match: PolicyMatch{Name: "tmp-*"}, Require: PolicyRequirement{MaxAge: "7d"}},
+	}}
+	if err := policy.Rules[0].compile(); err != nil {
+		t.Fatal(err)
+	}
+	usage := func(name string, age time.Duration) WorkspaceUsage {
+		return WorkspaceUsage{Workspace: Workspace{Name: name, Created: now.Add(-age)}}
+	}
+	day := 24 * time.Hour
+	missing := usage("gone", day)
+	missing.Missing = true
+	dirty := usage("dirty", 100*day)
+	dirty.DirtyRepositories = []string{"lib"}
+	child := usage("child", 100*day)
+	child.Workspace.Parent = "oldest"
+	usages := []WorkspaceUsage{
+		usage("recent", day),
+		usage("oldest", 90*day),
+		usage("tmp-fresh", 2*day),
+		usage("tmp-expired", 10*day),
+		missing,
+		dirty,
+		child,
+	}
+
+	tests := []struct {
+		name   string
+		policy *Policy
+		want   []string
+	}{
+		{name: "without policy", want: []string{"gone", "oldest", "tmp-expired", "tmp-fresh", "recent"}},
+		{name: "expired first", policy: policy, want: []string{"gone", "tmp-expired", "oldest", "tmp-fresh", "recent"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, candidate := range deletionCandidates(usages, tt.policy, now) {
+				got = append(got, candidate.Workspace.Name)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("candidates = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	candidates := deletionCandidates(usages, policy, now)
+	if reason := candidates[1].Reason; reason != "older than the max_age of policy rule short-lived" {
+		t.Errorf("reason = %q", reason)
+	}
+}
+
+func TestCheckQuotaCountsTheTrash(t *testing.T) {
+	useTestConfigDir(t)
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	wm := newTestWorkspaceManager(t)
+	wm.config.Quota = QuotaConfig{DiskBudget: "1KB"}
+
+	trashDir, err := TrashDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := TrashEntry{Workspace: Workspace{Name: "deleted"}, Profile: CurrentProfile(), Deleted: time.Now(), Expires: time.Now().Add(time.Hour)}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeGoFiles(t, filepath.Join(trashDir, "deleted"), map[string]string{
+		trashEntryFile:    string(data),
+		"feat/lib/big.go": strings.Repeat("x", 2000),
+	})
+
+	report, err := wm.CheckQuota(t.Context(), true)
+	if err != nil {
+		t.Fatalf("CheckQuota failed: %v", err)
+	}
+	if report.TrashUsage < 2000 || report.DiskUsage < report.TrashUsage || len(report.Exceeded) != 1 {
+		t.Errorf("report = %+v, want the trash counted against the budget", report)
+	}
+	// Linked workspaces take no space and skip the disk budget
+	if report, err := wm.CheckQuota(t.Context(), false); err != nil || len(report.Exceeded) != 0 {
+		t.Errorf("CheckQuota without disk = %+v, %v", report, err)
+	}
+}
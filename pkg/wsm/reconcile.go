@@ -0,0 +1,269 @@
+package wsm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ReconcileRecreateWorktree recreates the missing worktree of a configured repository
+	ReconcileRecreateWorktree = "recreate-worktree"
+	// ReconcileRegisterRepository adds a worktree found in the workspace directory to the configuration
+	ReconcileRegisterRepository = "register-repository"
+	// ReconcileUpdateGoWork rewrites a go.work file that does not match the repositories
+	ReconcileUpdateGoWork = "update-go-work"
+	// ReconcileSkip reports a problem that has to be fixed by hand
+	ReconcileSkip = "skip"
+)
+
+// ReconcileAction is a repair of a workspace whose directory no longer matches its configuration
+type ReconcileAction struct {
+	Kind       string `json:"kind"`
+	Repository string `json:"repository,omitempty"`
+	Detail     string `json:"detail"`
+	// repo is the repository registered by a register-repository action
+	repo *Repository
+}
+
+// PlanReconcile compares the workspace configuration with its directory: configured repositories
+// whose worktree is missing, worktrees in the directory that are not configured, and a go.work
+// file that does not list the Go modules of the workspace
+func (wm *WorkspaceManager) PlanReconcile(ctx context.Context, workspace *Workspace) ([]ReconcileAction, error) {
+	var actions []ReconcileAction
+
+	configured := map[string]bool{}
+	for _, repo := range workspace.Repositories {
+		configured[repo.Name] = true
+		worktreePath := filepath.Join(workspace.Path, repo.Name)
+
+		info, err := wm.fs().Stat(worktreePath)
+		switch {
+		case os.IsNotExist(err):
+			// A locked worktree is usually on a drive or share that is not mounted right now
+			if locked, reason, _ := wm.worktreeLockState(ctx, repo, worktreePath); locked {
+				actions = append(actions, ReconcileAction{
+					Kind:       ReconcileSkip,
+					Repository: repo.Name,
+					Detail:     fmt.Sprintf("worktree %s is missing but locked%s; mount it or unlock it to recreate it", worktreePath, lockReasonSuffix(reason)),
+				})
+				continue
+			}
+			detail := fmt.Sprintf("worktree %s is missing, recreate it from %s", worktreePath, repo.Path)
+			if workspace.Linked {
+				detail = fmt.Sprintf("link %s is missing or dangling, link it to %s", worktreePath, repo.Path)
+			} else if workspace.Clone != "" {
+				detail = fmt.Sprintf("clone %s is missing, clone %s again", worktreePath, repo.Path)
+			}
+			actions = append(actions, ReconcileAction{Kind: ReconcileRecreateWorktree, Repository: repo.Name, Detail: detail})
+		case err != nil:
+			return nil, errors.Wrapf(err, "failed to stat %s", worktreePath)
+		case !info.IsDir():
+			actions = append(actions, ReconcileAction{Kind: ReconcileSkip, Repository: repo.Name, Detail: fmt.Sprintf("%s is not a directory", worktreePath)})
+		default:
+			if _, err := wm.fs().Stat(filepath.Join(worktreePath, ".git")); err != nil {
+				actions = append(actions, ReconcileAction{Kind: ReconcileSkip, Repository: repo.Name, Detail: fmt.Sprintf("%s exists but is not a git worktree", worktreePath)})
+			}
+		}
+	}
+
+	entries, err := wm.fs().ReadDir(workspace.Path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read workspace directory %s", workspace.Path)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		// Children are linked into the workspace and hidden directories hold workspace metadata
+		if !entry.IsDir() || configured[name] || strings.HasPrefix(name, ".") || slices.Contains(workspace.Children, name) {
+			continue
+		}
+		dir := filepath.Join(workspace.Path, name)
+		if _, err := wm.fs().Stat(filepath.Join(dir, ".git")); err != nil {
+			continue
+		}
+
+		action, err := wm.planRegisterRepository(ctx, dir)
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, action)
+	}
+
+	if workspace.GoWorkspace {
+		planned := *workspace
+		for _, action := range actions {
+			if action.Kind == ReconcileRegisterRepository {
+				planned.Repositories = append(slices.Clone(planned.Repositories), *action.repo)
+			}
+		}
+		expanded, err := ExpandWorkspace(&planned)
+		if err != nil {
+			return nil, err
+		}
+		goWorkPath := filepath.Join(workspace.Path, "go.work")
+		current, err := wm.fs().ReadFile(goWorkPath)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, errors.Wrapf(err, "failed to read %s", goWorkPath)
+		}
+		// Recreated worktrees only exist after applying, so compare the file they will produce
+		if hasAction(actions, ReconcileRecreateWorktree) || string(current) != wm.goWorkContent(expanded) {
+			detail := "go.work does not match the Go modules of the workspace"
+			if os.IsNotExist(err) {
+				detail = "go.work is missing"
+			}
+			actions = append(actions, ReconcileAction{Kind: ReconcileUpdateGoWork, Detail: detail})
+		}
+	}
+
+	return actions, nil
+}
+
+// planRegisterRepository resolves the repository a worktree found in the workspace belongs to
+func (wm *WorkspaceManager) planRegisterRepository(ctx context.Context, dir string) (ReconcileAction, error) {
+	name := filepath.Base(dir)
+	commonDir, err := gitOutput(ctx, wm.runner(), dir, "rev-parse", "--path-format=absolute", "--git-common-dir")
+	if err != nil {
+		return ReconcileAction{Kind: ReconcileSkip, Repository: name, Detail: fmt.Sprintf("%s is not a readable git repository: %v", dir, err)}, nil
+	}
+	repoPath := filepath.Dir(commonDir)
+	if repoPath == dir {
+		return ReconcileAction{Kind: ReconcileSkip, Repository: name, Detail: fmt.Sprintf("%s is a clone, not a worktree; move it out or replace it with 'wsm add'", dir)}, nil
+	}
+
+	var repo *Repository
+	for _, candidate := range wm.Discoverer.GetRepositories() {
+		if candidate.Path == repoPath {
+			repo = &candidate
+			break
+		}
+	}
+	if repo == nil {
+		if repo, err = wm.Discoverer.analyzeRepository(ctx, repoPath); err != nil {
+			return ReconcileAction{}, errors.Wrapf(err, "failed to analyze repository %s", repoPath)
+		}
+	}
+	// The worktree directory name is what identifies the repository in the workspace
+	repo.Name = name
+
+	return ReconcileAction{
+		Kind:       ReconcileRegisterRepository,
+		Repository: name,
+		Detail:     fmt.Sprintf("worktree of %s is not in the workspace configuration", repoPath),
+		repo:       repo,
+	}, nil
+}
+
+func hasAction(actions []ReconcileAction, kind string) bool {
+	for _, action := range actions {
+		if action.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyReconcile performs the planned repairs and saves the workspace configuration. Skipped
+// actions are left for the user.
+func (wm *WorkspaceManager) ApplyReconcile(ctx context.Context, workspace *Workspace, actions []ReconcileAction) error {
+	start := time.Now()
+	for _, action := range actions {
+		switch action.Kind {
+		case ReconcileRecreateWorktree:
+			repo, ok := FindRepository(workspace, action.Repository)
+			if !ok {
+				continue
+			}
+			if workspace.Linked {
+				if err := wm.linkRepository(workspace, repo); err != nil {
+					return errors.Wrapf(err, "failed to relink '%s'", repo.Name)
+				}
+				continue
+			}
+			restore := wm.restoreWorktree
+			if workspace.Clone != "" {
+				restore = wm.cloneRepository
+			}
+			if err := restore(ctx, workspace, repo); err != nil {
+				return errors.Wrapf(err, "failed to recreate worktree for '%s'", repo.Name)
+			}
+			recordBranchPoint(ctx, workspace, repo.Name)
+			wm.applyRepositoryGitConfig(ctx, workspace, repo)
+		case ReconcileRegisterRepository:
+			workspace.Repositories = append(workspace.Repositories, *action.repo)
+			recordBranchPoint(ctx, workspace, action.repo.Name)
+		}
+	}
+
+	if hasAction(actions, ReconcileUpdateGoWork) {
+		if err := wm.refreshGoWorkspace(workspace); err != nil {
+			return errors.Wrap(err, "failed to update go.work")
+		}
+		if err := wm.SyncGoWorkspace(ctx, workspace); err != nil {
+			output.PrintWarning("Could not sync go.work: %v", err)
+		}
+	}
+
+	if err := wm.SaveWorkspace(workspace); err != nil {
+		return errors.Wrap(err, "failed to save workspace configuration")
+	}
+
+	var kinds []string
+	for _, action := range actions {
+		if action.Kind != ReconcileSkip {
+			kinds = append(kinds, action.Kind+":"+action.Repository)
+		}
+	}
+	RecordOperation("reconcile", workspace.Name, start, map[string]string{"actions": strings.Join(kinds, ",")})
+	return nil
+}
+
+// FindRepository returns the repository of the workspace with the given name
+func FindRepository(workspace *Workspace, name string) (Repository, bool) {
+	for _, repo := range workspace.Repositories {
+		if repo.Name == name {
+			return repo, true
+		}
+	}
+	return Repository{}, false
+}
+
+// restoreWorktree recreates a deleted worktree without prompting: the workspace branch is checked
+// out when it still exists, and created from the remote or base branch otherwise
+func (wm *WorkspaceManager) restoreWorktree(ctx context.Context, workspace *Workspace, repo Repository) error {
+	targetPath := filepath.Join(workspace.Path, repo.Name)
+
+	// The old worktree is still registered in the repository until pruned
+	if err := wm.ExecuteWorktreeCommand(ctx, repo.Path, "git", "worktree", "prune"); err != nil {
+		return err
+	}
+
+	if workspace.Branch == "" {
+		return wm.ExecuteWorktreeCommand(ctx, repo.Path, "git", "worktree", "add", targetPath)
+	}
+
+	branchExists, err := wm.CheckBranchExists(ctx, repo.Path, workspace.Branch)
+	if err != nil {
+		return err
+	}
+	if branchExists {
+		return wm.ExecuteWorktreeCommand(ctx, repo.Path, "git", "worktree", "add", targetPath, workspace.Branch)
+	}
+
+	if remoteExists, _ := wm.CheckRemoteBranchExists(ctx, repo.Path, workspace.Branch); remoteExists {
+		return wm.ExecuteWorktreeCommand(ctx, repo.Path, "git", "worktree", "add", "-b", workspace.Branch, targetPath, "origin/"+workspace.Branch)
+	}
+
+	output.PrintWarning("Branch '%s' no longer exists in '%s', creating it again", workspace.Branch, repo.Name)
+	args := []string{"git", "worktree", "add", "-b", workspace.Branch, targetPath}
+	if workspace.BaseBranch != "" {
+		args = append(args, workspace.BaseBranch)
+	}
+	return wm.ExecuteWorktreeCommand(ctx, repo.Path, args...)
+}
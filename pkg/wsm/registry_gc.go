@@ -0,0 +1,175 @@
+package wsm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Reasons a registry entry is stale
+const (
+	StaleReasonMissing = "path does not exist"
+	StaleReasonNotGit  = "not a git repository"
+)
+
+// StaleRepository is a registry entry whose path no longer holds the repository
+type StaleRepository struct {
+	Repository Repository `json:"repository"`
+	Reason     string     `json:"reason"`
+	// MovedTo is the repository with the same remote found at another path, if any
+	MovedTo *Repository `json:"moved_to,omitempty"`
+}
+
+// FindStaleRepositories checks that every registry entry still exists on disk and is a git
+// repository. For stale entries with a remote, it looks for a repository with the same remote
+// elsewhere: among the other registry entries, below searchPaths and below the closest existing
+// parent of the old path, scanning up to maxDepth levels.
+func (rd *RepositoryDiscoverer) FindStaleRepositories(ctx context.Context, searchPaths []string, maxDepth int) ([]StaleRepository, error) {
+	var stale []StaleRepository
+	wanted := map[string]bool{}
+	for _, repo := range rd.registry.Repositories {
+		reason := ""
+		if _, err := fsOrDefault(rd.FS).Stat(repo.Path); err != nil {
+			reason = StaleReasonMissing
+		} else if !rd.isGitRepository(repo.Path) {
+			reason = StaleReasonNotGit
+		}
+		if reason == "" {
+			continue
+		}
+		stale = append(stale, StaleRepository{Repository: repo, Reason: reason})
+		if repo.RemoteURL != "" {
+			wanted[NormalizeRemoteURL(repo.RemoteURL)] = true
+		}
+	}
+	if len(wanted) == 0 {
+		return stale, nil
+	}
+
+	// Candidates are live repositories with the remote of a stale entry, first those already
+	// in the registry (a moved repository discovered again), then those found on disk
+	candidates := map[string]Repository{}
+	stalePaths := map[string]bool{}
+	for _, s := range stale {
+		stalePaths[s.Repository.Path] = true
+	}
+	for _, repo := range rd.registry.Repositories {
+		remote := NormalizeRemoteURL(repo.RemoteURL)
+		if !stalePaths[repo.Path] && wanted[remote] {
+			if _, ok := candidates[remote]; !ok {
+				candidates[remote] = repo
+			}
+		}
+	}
+
+	roots := append([]string{}, searchPaths...)
+	for _, s := range stale {
+		if parent := existingParent(s.Repository.Path); parent != "" {
+			roots = append(roots, parent)
+		}
+	}
+	scanned := map[string]bool{}
+	for _, root := range roots {
+		if len(candidates) == len(wanted) {
+			break
+		}
+		if scanned[root] {
+			continue
+		}
+		scanned[root] = true
+		for _, repo := range rd.walkRepositories(ctx, root, DiscoveryOptions{Recursive: true, MaxDepth: maxDepth}) {
+			remote := NormalizeRemoteURL(repo.RemoteURL)
+			if _, ok := candidates[remote]; !ok && wanted[remote] {
+				candidates[remote] = repo
+			}
+		}
+	}
+
+	for i := range stale {
+		if stale[i].Repository.RemoteURL == "" {
+			continue
+		}
+		if repo, ok := candidates[NormalizeRemoteURL(stale[i].Repository.RemoteURL)]; ok {
+			stale[i].MovedTo = &repo
+		}
+	}
+	return stale, nil
+}
+
+// existingParent returns the closest existing parent directory of path, or "" when that is the
+// file system root or the home directory, which are too large to scan
+func existingParent(path string) string {
+	home, _ := os.UserHomeDir()
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		if dir == filepath.Dir(dir) || dir == home {
+			return ""
+		}
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+	}
+}
+
+// RelocateRepository points the registry entry at oldPath to the repository at newPath. The entry
+// keeps its name, so aliases and workspace manifests keep resolving, and an entry already
+// registered for newPath is replaced rather than kept as a duplicate.
+func (rd *RepositoryDiscoverer) RelocateRepository(ctx context.Context, oldPath, newPath string) (*Repository, error) {
+	if !rd.isGitRepository(newPath) {
+		return nil, errors.Errorf("%s is not a git repository", newPath)
+	}
+	index := -1
+	for i, repo := range rd.registry.Repositories {
+		if repo.Path == oldPath {
+			index = i
+		}
+	}
+	if index < 0 {
+		return nil, errors.Errorf("no registry entry for %s", oldPath)
+	}
+
+	repo, err := rd.analyzeRepository(ctx, newPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to analyze %s", newPath)
+	}
+	repo.Name = rd.registry.Repositories[index].Name
+	rd.registry.Repositories[index] = *repo
+	rd.registry.Repositories = removeRepositoryAt(rd.registry.Repositories, newPath, index)
+	rd.registry.LastScan = time.Now()
+	rd.index = nil
+	return repo, nil
+}
+
+// RemoveRepository drops the registry entry at path, along with aliases left without a repository
+func (rd *RepositoryDiscoverer) RemoveRepository(path string) error {
+	before := len(rd.registry.Repositories)
+	rd.registry.Repositories = removeRepositoryAt(rd.registry.Repositories, path, -1)
+	if len(rd.registry.Repositories) == before {
+		return errors.Errorf("no registry entry for %s", path)
+	}
+
+	names := map[string]bool{}
+	for _, repo := range rd.registry.Repositories {
+		names[repo.Name] = true
+	}
+	for alias, name := range rd.registry.Aliases {
+		if !names[name] {
+			delete(rd.registry.Aliases, alias)
+		}
+	}
+	rd.index = nil
+	return nil
+}
+
+// removeRepositoryAt removes the entries registered for path, except the one at index keep
+func removeRepositoryAt(repos []Repository, path string, keep int) []Repository {
+	result := repos[:0]
+	for i, repo := range repos {
+		if repo.Path != path || i == keep {
+			result = append(result, repo)
+		}
+	}
+	return result
+}
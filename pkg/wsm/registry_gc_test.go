@@ -0,0 +1,128 @@
+package wsm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+)
+
+// newRegistryRepo creates a git repository at path with origin pointing at remote, if given
+func newRegistryRepo(t *testing.T, path, remote string) {
+	t.Helper()
+	if err := os.MkdirAll(path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	testGit(t, path, "init", "--quiet")
+	if remote != "" {
+		testGit(t, path, "remote", "add", "origin", remote)
+	}
+}
+
+func TestFindStaleRepositoriesDetectsMovedRepositories(t *testing.T) {
+	ctx := context.Background()
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := filepath.Join(root, "code")
+	oldPath := filepath.Join(code, "lib")
+	newPath := filepath.Join(code, "moved", "lib-renamed")
+	notes := filepath.Join(code, "notes")
+	newRegistryRepo(t, oldPath, "git@github.com:acme/lib.git")
+	newRegistryRepo(t, filepath.Join(code, "app"), "https://github.com/acme/app")
+	newRegistryRepo(t, notes, "")
+
+	output.SetQuiet(true)
+	defer output.SetQuiet(false)
+
+	rd := NewRepositoryDiscoverer(filepath.Join(root, "registry.json"))
+	if err := rd.LoadRegistry(); err != nil {
+		t.Fatal(err)
+	}
+	if err := rd.DiscoverRepositories(ctx, []string{code}, true, 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := rd.SetAlias("l", "lib"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(newPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(oldPath, newPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(filepath.Join(notes, ".git")); err != nil {
+		t.Fatal(err)
+	}
+
+	// The moved repository is found by its remote below the parent of its old path
+	stale, err := rd.FindStaleRepositories(ctx, nil, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byPath := map[string]StaleRepository{}
+	for _, s := range stale {
+		byPath[s.Repository.Path] = s
+	}
+	if len(byPath) != 2 {
+		t.Fatalf("stale = %+v, want lib and notes", stale)
+	}
+	if lib := byPath[oldPath]; lib.Reason != StaleReasonMissing || lib.MovedTo == nil || lib.MovedTo.Path != newPath {
+		t.Errorf("lib = %+v, want missing and moved to %s", lib, newPath)
+	}
+	if n := byPath[notes]; n.Reason != StaleReasonNotGit || n.MovedTo != nil {
+		t.Errorf("notes = %+v, want not a git repository without a new location", n)
+	}
+
+	// The new location is registered as well, e.g. by a discovery run with another root
+	moved, err := rd.analyzeRepository(ctx, newPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rd.registry.Repositories = append(rd.registry.Repositories, *moved)
+
+	stale, err = rd.FindStaleRepositories(ctx, nil, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range stale {
+		if s.Repository.Path == oldPath && (s.MovedTo == nil || s.MovedTo.Name != "lib-renamed") {
+			t.Errorf("lib should point at the registered entry, got %+v", s.MovedTo)
+		}
+	}
+
+	// Relocating keeps the name and replaces the duplicate entry
+	repo, err := rd.RelocateRepository(ctx, oldPath, newPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.Name != "lib" || repo.Path != newPath {
+		t.Errorf("relocated = %s at %s, want lib at %s", repo.Name, repo.Path, newPath)
+	}
+	var entries []Repository
+	for _, r := range rd.GetRepositories() {
+		if r.Path == oldPath || r.Path == newPath {
+			entries = append(entries, r)
+		}
+	}
+	if len(entries) != 1 || entries[0].Name != "lib" || entries[0].Path != newPath {
+		t.Errorf("registry entries for lib = %+v, want a single entry at %s", entries, newPath)
+	}
+	if len(rd.GetRepositories()) != 3 {
+		t.Errorf("registry has %d entries, want 3", len(rd.GetRepositories()))
+	}
+	if got := rd.ResolveAlias("l"); got != "lib" {
+		t.Errorf("alias l resolves to %q, want lib", got)
+	}
+
+	if _, err := rd.RelocateRepository(ctx, oldPath, newPath); err == nil {
+		t.Error("expected an error relocating an entry that is no longer registered")
+	}
+	if _, err := rd.RelocateRepository(ctx, notes, notes); err == nil {
+		t.Error("expected an error relocating to a directory that is not a git repository")
+	}
+}
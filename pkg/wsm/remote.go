@@ -0,0 +1,129 @@
+package wsm
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var plainShellWord = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./~-]+$`)
+
+// RemoteConfig lists the dev servers that 'wsm --host' can proxy to
+type RemoteConfig struct {
+	Hosts map[string]RemoteHost `json:"hosts" yaml:"hosts"`
+}
+
+// RemoteHost describes how to reach wsm on another machine over SSH
+type RemoteHost struct {
+	// Address is the ssh destination (user@host or an ssh_config alias); defaults to the host name
+	Address string `json:"address,omitempty" yaml:"address,omitempty"`
+	// Binary is the wsm executable on the remote machine; defaults to "wsm"
+	Binary string `json:"binary,omitempty" yaml:"binary,omitempty"`
+	// SSHArgs are passed to ssh before the destination (-p 2222, -i key, ...)
+	SSHArgs []string `json:"ssh_args,omitempty" yaml:"ssh_args,omitempty"`
+}
+
+// ResolveRemoteHost returns the configured host, or a host using the name as ssh destination
+func ResolveRemoteHost(config *WorkspaceConfig, name string) RemoteHost {
+	host := RemoteHost{}
+	if config != nil {
+		host = config.Remote.Hosts[name]
+	}
+	if host.Address == "" {
+		host.Address = name
+	}
+	if host.Binary == "" {
+		host.Binary = "wsm"
+	}
+	return host
+}
+
+// CommandArgs builds the ssh arguments running a remote command line. ssh joins its arguments
+// with spaces and hands them to the remote shell, so every word is quoted.
+func (h RemoteHost) CommandArgs(tty bool, command ...string) []string {
+	args := append([]string{}, h.SSHArgs...)
+	if tty {
+		args = append(args, "-t")
+	} else {
+		args = append(args, "-T")
+	}
+	args = append(args, h.Address, "--")
+
+	quoted := make([]string, len(command))
+	for i, word := range command {
+		quoted[i] = shellWord(word)
+	}
+	return append(args, strings.Join(quoted, " "))
+}
+
+// WSMArgs builds the ssh arguments running the remote wsm with the given arguments
+func (h RemoteHost) WSMArgs(tty bool, args ...string) []string {
+	return h.CommandArgs(tty, append([]string{h.Binary}, args...)...)
+}
+
+// ShellCommand returns the local command line opening an interactive shell in a remote directory,
+// optionally running a command first
+func (h RemoteHost) ShellCommand(dir, command string) string {
+	script := "cd " + shellWord(dir)
+	if command != "" {
+		script += " && " + command
+	}
+	script += "; exec ${SHELL:-/bin/sh} -l"
+
+	args := []string{"ssh"}
+	args = append(args, h.SSHArgs...)
+	args = append(args, "-t", h.Address, "--", shellQuote(script))
+	for i, arg := range args[:len(args)-1] {
+		args[i] = shellWord(arg)
+	}
+	return strings.Join(args, " ")
+}
+
+// RunRemote runs the remote wsm with the terminal attached and returns an ExitCodeError when it
+// fails
+func (h RemoteHost) RunRemote(ctx context.Context, name string, tty bool, args ...string) error {
+	cmd := exec.CommandContext(ctx, "ssh", h.WSMArgs(tty, args...)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			// ssh itself exits with 255 when the connection fails, after printing why
+			return &ExitCodeError{Host: name, Code: exitErr.ExitCode()}
+		}
+		return errors.Wrapf(err, "failed to run ssh to %s", h.Address)
+	}
+	return nil
+}
+
+// LoadRemoteWorkspace fetches the configuration of a workspace on a remote host
+func (h RemoteHost) LoadRemoteWorkspace(ctx context.Context, name string) (*Workspace, error) {
+	cmd := exec.CommandContext(ctx, "ssh", h.WSMArgs(false, "info", name, "--output", "json")...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load workspace '%s' from %s: %s", name, h.Address, strings.TrimSpace(stderr.String()))
+	}
+
+	var workspace Workspace
+	if err := json.Unmarshal(out, &workspace); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse workspace '%s' from %s", name, h.Address)
+	}
+	return &workspace, nil
+}
+
+// shellWord quotes a word for the shell unless it only contains safe characters
+func shellWord(s string) string {
+	if plainShellWord.MatchString(s) {
+		return s
+	}
+	return shellQuote(s)
+}
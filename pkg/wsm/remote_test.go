@@ -0,0 +1,37 @@
+package wsm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolveRemoteHost(t *testing.T) {
+	config := &WorkspaceConfig{Remote: RemoteConfig{Hosts: map[string]RemoteHost{
+		"dev": {Address: "me@dev.internal", Binary: "/opt/wsm", SSHArgs: []string{"-p", "2222"}},
+	}}}
+	if host := ResolveRemoteHost(config, "dev"); host.Address != "me@dev.internal" || host.Binary != "/opt/wsm" {
+		t.Errorf("configured host = %+v", host)
+	}
+	if host := ResolveRemoteHost(nil, "box"); host.Address != "box" || host.Binary != "wsm" {
+		t.Errorf("unconfigured host = %+v, want the name as address and wsm as binary", host)
+	}
+}
+
+func TestRemoteHostArgs(t *testing.T) {
+	host := RemoteHost{Address: "dev", Binary: "wsm", SSHArgs: []string{"-p", "2222"}}
+
+	got := host.WSMArgs(false, "status", "my ws", "--output=json")
+	want := []string{"-p", "2222", "-T", "dev", "--", "wsm status 'my ws' --output=json"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("WSMArgs = %q, want %q", got, want)
+	}
+	if got := host.CommandArgs(true, "echo", "it's"); got[2] != "-t" || got[len(got)-1] != `echo 'it'\''s'` {
+		t.Errorf("CommandArgs = %q", got)
+	}
+
+	shell := host.ShellCommand("/work/my ws", "make test")
+	wantShell := `ssh -p 2222 -t dev -- 'cd '\''/work/my ws'\'' && make test; exec ${SHELL:-/bin/sh} -l'`
+	if shell != wantShell {
+		t.Errorf("ShellCommand = %s, want %s", shell, wantShell)
+	}
+}
@@ -0,0 +1,99 @@
+package wsm
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// StatusConfig configures status computation
+type StatusConfig struct {
+	// CompareRemotes are the remotes divergence is reported against (default: origin, upstream)
+	CompareRemotes []string `json:"compare_remotes,omitempty" yaml:"compare_remotes,omitempty"`
+	// RepositoryRemotes overrides CompareRemotes per repository name
+	RepositoryRemotes map[string][]string `json:"repository_remotes,omitempty" yaml:"repository_remotes,omitempty"`
+	// FailOn are the conditions that make 'wsm status' fail when --fail-on is not given
+	FailOn []string `json:"fail_on,omitempty" yaml:"fail_on,omitempty"`
+	// ExitCodes are the exit statuses of 'wsm status' per failing condition
+	ExitCodes StatusExitCodes `json:"exit_codes,omitempty" yaml:"exit_codes,omitempty"`
+}
+
+// RemotesFor returns the remotes to compare a repository against
+func (c StatusConfig) RemotesFor(repoName string) []string {
+	if remotes, ok := c.RepositoryRemotes[repoName]; ok {
+		return remotes
+	}
+	if len(c.CompareRemotes) > 0 {
+		return c.CompareRemotes
+	}
+	return []string{"origin", "upstream"}
+}
+
+// RemoteDivergence is the ahead/behind count of HEAD against a remote ref
+type RemoteDivergence struct {
+	Remote string `json:"remote"`
+	Ref    string `json:"ref"`
+	Ahead  int    `json:"ahead"`
+	Behind int    `json:"behind"`
+}
+
+// getRemoteDivergence computes divergence against each configured remote that exists in the repository.
+// The compared ref is <remote>/<branch> when it exists, otherwise the remote's default branch.
+func getRemoteDivergence(ctx context.Context, runner CommandRunner, repoPath, branch string, remotes []string) ([]RemoteDivergence, error) {
+	existing, err := gitOutput(ctx, runner, repoPath, "remote")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list remotes")
+	}
+	available := make(map[string]bool)
+	for _, remote := range strings.Fields(existing) {
+		available[remote] = true
+	}
+
+	var result []RemoteDivergence
+	for _, remote := range remotes {
+		if !available[remote] {
+			continue
+		}
+
+		ref := resolveRemoteCompareRef(ctx, runner, repoPath, remote, branch)
+		if ref == "" {
+			continue
+		}
+
+		counts, err := gitOutput(ctx, runner, repoPath, "rev-list", "--left-right", "--count", "HEAD..."+ref)
+		if err != nil {
+			continue
+		}
+		parts := strings.Fields(counts)
+		if len(parts) != 2 {
+			continue
+		}
+		ahead, _ := strconv.Atoi(parts[0])
+		behind, _ := strconv.Atoi(parts[1])
+
+		result = append(result, RemoteDivergence{Remote: remote, Ref: ref, Ahead: ahead, Behind: behind})
+	}
+
+	return result, nil
+}
+
+func resolveRemoteCompareRef(ctx context.Context, runner CommandRunner, repoPath, remote, branch string) string {
+	candidates := []string{}
+	if branch != "" {
+		candidates = append(candidates, remote+"/"+branch)
+	}
+	if head, err := gitOutput(ctx, runner, repoPath, "symbolic-ref", "--short", "refs/remotes/"+remote+"/HEAD"); err == nil {
+		candidates = append(candidates, head)
+	}
+	candidates = append(candidates, remote+"/main", remote+"/master")
+
+	for _, ref := range candidates {
+		if _, err := gitOutput(ctx, runner, repoPath, "rev-parse", "--verify", "--quiet", "refs/remotes/"+ref); err == nil {
+			return ref
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,833 @@
+package wsm
+
+import (
+	"bytes"
+	"context"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// SymbolRef names a package-level Go identifier by the import path of its package, e.g.
+// github.com/acme/lib/auth.NewClient
+type SymbolRef struct {
+	Package string
+	Name    string
+}
+
+func (s SymbolRef) String() string {
+	return s.Package + "." + s.Name
+}
+
+// ParseSymbolRef parses <import path>.<Name>; the name is what follows the last dot after the last
+// slash, so gopkg.in/yaml.v3.Marshal is Marshal of gopkg.in/yaml.v3
+func ParseSymbolRef(ref string) (SymbolRef, error) {
+	slash := strings.LastIndex(ref, "/")
+	dot := strings.LastIndex(ref[slash+1:], ".")
+	if dot < 0 {
+		return SymbolRef{}, errors.Errorf("invalid symbol '%s': expected <import path>.<Name>, e.g. github.com/acme/lib/auth.NewClient", ref)
+	}
+	symbol := SymbolRef{Package: ref[:slash+1+dot], Name: ref[slash+1+dot+1:]}
+	if symbol.Package == "" || !token.IsIdentifier(symbol.Name) {
+		return SymbolRef{}, errors.Errorf("invalid symbol '%s': expected <import path>.<Name>, e.g. github.com/acme/lib/auth.NewClient", ref)
+	}
+	return symbol, nil
+}
+
+// RenameSymbolOptions describes a rename of a Go symbol across the repositories of a workspace
+type RenameSymbolOptions struct {
+	From SymbolRef
+	// To is the new name; a different package rewrites the references and imports to that
+	// package, where the symbol must be declared
+	To SymbolRef
+	// Repositories restricts the rename to these repositories
+	Repositories []string
+}
+
+// Moves reports whether the symbol changes package
+func (o RenameSymbolOptions) Moves() bool {
+	return o.From.Package != o.To.Package
+}
+
+// GoModule is a Go module inside a repository of a workspace
+type GoModule struct {
+	Repository string
+	Dir        string
+	Path       string
+}
+
+// RenameFile is a Go file a rename changes
+type RenameFile struct {
+	Repository string `json:"repository"`
+	Path       string `json:"path"`
+	// References is the number of identifiers renamed in the file
+	References int `json:"references"`
+	// Imports is set when the imports of the file change
+	Imports bool   `json:"imports,omitempty"`
+	Content []byte `json:"-"`
+}
+
+// RenamePlan is the outcome of a rename before it is written
+type RenamePlan struct {
+	Options RenameSymbolOptions
+	Modules []GoModule
+	// PackageDir is the directory of the package declaring the symbol; empty when the package is
+	// not part of the workspace
+	PackageDir string
+	Files      []RenameFile
+	// Skipped are files that could not be parsed, with the reason
+	Skipped map[string]string
+}
+
+// References returns the number of identifiers the rename changes
+func (p *RenamePlan) References() int {
+	total := 0
+	for _, file := range p.Files {
+		total += file.References
+	}
+	return total
+}
+
+// WorkspaceGoModules returns the Go modules of the workspace repositories, including nested ones
+func WorkspaceGoModules(workspace *Workspace, repositories []string) ([]GoModule, error) {
+	var modules []GoModule
+	for _, repo := range workspace.Repositories {
+		if len(repositories) > 0 && !slices.Contains(repositories, repo.Name) {
+			continue
+		}
+		root := filepath.Join(workspace.Path, repo.Name)
+		err := walkGoTree(root, func(path string, entry fs.DirEntry) {
+			if entry.Name() != "go.mod" {
+				return
+			}
+			dir := filepath.Dir(path)
+			if module := readGoModulePath(dir); module != "" {
+				modules = append(modules, GoModule{Repository: repo.Name, Dir: dir, Path: module})
+			}
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to scan %s", root)
+		}
+	}
+	return modules, nil
+}
+
+// walkGoTree visits the files below root, skipping vendored, test data, dependency and hidden
+// directories
+func walkGoTree(root string, visit func(path string, entry fs.DirEntry)) error {
+	return filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return nil
+		}
+		if entry.IsDir() {
+			name := entry.Name()
+			if path != root && (name == "vendor" || name == "testdata" || name == "node_modules" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		visit(path, entry)
+		return nil
+	})
+}
+
+// PackageDir returns the directory of the package with the given import path in modules
+func PackageDir(modules []GoModule, importPath string) string {
+	best := -1
+	for i, module := range modules {
+		if importPath != module.Path && !strings.HasPrefix(importPath, module.Path+"/") {
+			continue
+		}
+		if best < 0 || len(module.Path) > len(modules[best].Path) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return ""
+	}
+	dir := filepath.Join(modules[best].Dir, filepath.FromSlash(strings.TrimPrefix(importPath, modules[best].Path)))
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return ""
+	}
+	return dir
+}
+
+// packageName returns the name of the package declared in dir, or the last element of its import
+// path (without a major version suffix) when dir is unknown
+func packageName(dir, importPath string) string {
+	if dir != "" {
+		entries, _ := os.ReadDir(dir)
+		for _, entry := range entries {
+			name := entry.Name()
+			if !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+				continue
+			}
+			file, err := parser.ParseFile(token.NewFileSet(), filepath.Join(dir, name), nil, parser.PackageClauseOnly)
+			if err == nil {
+				return file.Name.Name
+			}
+		}
+	}
+	elements := strings.Split(importPath, "/")
+	name := elements[len(elements)-1]
+	if len(elements) > 1 && len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		name = elements[len(elements)-2]
+	}
+	name, _, _ = strings.Cut(name, ".")
+	return strings.ReplaceAll(name, "-", "_")
+}
+
+// PlanRenameSymbol computes the changes of a rename in every Go file of the workspace: the
+// declaration and the references inside its package, the qualified references (pkg.Name) in the
+// packages importing it, and with a new package, their imports. Nothing is written.
+func PlanRenameSymbol(workspace *Workspace, options RenameSymbolOptions) (*RenamePlan, error) {
+	if !token.IsIdentifier(options.To.Name) {
+		return nil, errors.Errorf("invalid name '%s'", options.To.Name)
+	}
+	if options.From == options.To {
+		return nil, errors.New("the new name is the same as the old one")
+	}
+	modules, err := WorkspaceGoModules(workspace, options.Repositories)
+	if err != nil {
+		return nil, err
+	}
+	if len(modules) == 0 {
+		return nil, errors.Errorf("workspace '%s' has no Go modules", workspace.Name)
+	}
+
+	plan := &RenamePlan{Options: options, Modules: modules, Skipped: map[string]string{}}
+	plan.PackageDir = PackageDir(modules, options.From.Package)
+	if plan.PackageDir == "" && !options.Moves() {
+		return nil, errors.Errorf("package %s is not part of workspace '%s'", options.From.Package, workspace.Name)
+	}
+	renamer := &symbolRenamer{
+		options:    options,
+		packageDir: plan.PackageDir,
+		oldName:    packageName(plan.PackageDir, options.From.Package),
+		newName:    packageName(PackageDir(modules, options.To.Package), options.To.Package),
+	}
+
+	importer := stubImporter{modules: modules, packages: map[string]*types.Package{}}
+	for _, repo := range workspace.Repositories {
+		if len(options.Repositories) > 0 && !slices.Contains(options.Repositories, repo.Name) {
+			continue
+		}
+		root := filepath.Join(workspace.Path, repo.Name)
+		var dirs []string
+		paths := map[string][]string{}
+		if err := walkGoTree(root, func(path string, entry fs.DirEntry) {
+			if strings.HasSuffix(path, ".go") {
+				dir := filepath.Dir(path)
+				if paths[dir] == nil {
+					dirs = append(dirs, dir)
+				}
+				paths[dir] = append(paths[dir], path)
+			}
+		}); err != nil {
+			return nil, errors.Wrapf(err, "failed to scan %s", root)
+		}
+
+		for _, dir := range dirs {
+			files, err := checkGoPackages(paths[dir], options.From.Name, importer)
+			if err != nil {
+				return nil, err
+			}
+			for _, file := range files {
+				if file.err != nil {
+					plan.Skipped[file.path] = file.err.Error()
+					continue
+				}
+				renamed := renamer.rename(file)
+				if renamed != nil {
+					renamed.Repository = repo.Name
+					plan.Files = append(plan.Files, *renamed)
+				}
+			}
+		}
+	}
+
+	if !token.IsExported(options.To.Name) {
+		for _, file := range plan.Files {
+			if filepath.Dir(file.Path) != plan.PackageDir {
+				return nil, errors.Errorf("cannot rename to unexported '%s': %s uses it from another package", options.To.Name, file.Path)
+			}
+		}
+	}
+	return plan, nil
+}
+
+// symbolRenamer rewrites the references to a symbol in single files
+type symbolRenamer struct {
+	options    RenameSymbolOptions
+	packageDir string
+	// oldName and newName are the package names of the old and new package
+	oldName string
+	newName string
+}
+
+type textEdit struct {
+	start, end int
+	text       string
+}
+
+// checkedFile is a Go file that mentions the symbol, type-checked with the other files of its
+// package
+type checkedFile struct {
+	path    string
+	content []byte
+	fset    *token.FileSet
+	file    *ast.File
+	info    *types.Info
+	pkg     *types.Package
+	// err is set when the file cannot be parsed
+	err error
+}
+
+// checkGoPackages parses the Go files of a directory and type-checks each package they declare,
+// returning the files mentioning name. Only the identifiers declared by the packages themselves
+// are resolved: imports come from importer, so packages outside the directory are not checked.
+func checkGoPackages(paths []string, name string, importer types.Importer) ([]*checkedFile, error) {
+	var mentions []*checkedFile
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s", path)
+		}
+		// Files not mentioning the name cannot refer to the symbol
+		if bytes.Contains(content, []byte(name)) {
+			mentions = append(mentions, &checkedFile{path: path, content: content})
+		}
+	}
+	if len(mentions) == 0 {
+		return nil, nil
+	}
+
+	fset := token.NewFileSet()
+	packages := map[string][]*ast.File{}
+	var names []string
+	parsed := map[string]*ast.File{}
+	for _, path := range paths {
+		file, err := parser.ParseFile(fset, path, nil, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			for _, mention := range mentions {
+				if mention.path == path {
+					mention.err = errors.Wrap(err, "failed to parse")
+				}
+			}
+			continue
+		}
+		parsed[path] = file
+		if packages[file.Name.Name] == nil {
+			names = append(names, file.Name.Name)
+		}
+		packages[file.Name.Name] = append(packages[file.Name.Name], file)
+	}
+
+	for _, packageName := range names {
+		info := &types.Info{Defs: map[*ast.Ident]types.Object{}, Uses: map[*ast.Ident]types.Object{}}
+		// The imports are stubs, so errors are expected; the identifiers of the package are
+		// resolved all the same
+		config := types.Config{Importer: importer, Error: func(error) {}, FakeImportC: true}
+		pkg, _ := config.Check(packageName, fset, packages[packageName], info)
+		for _, mention := range mentions {
+			if file := parsed[mention.path]; file != nil && slices.Contains(packages[packageName], file) {
+				mention.fset, mention.file, mention.info, mention.pkg = fset, file, info, pkg
+			}
+		}
+	}
+	return mentions, nil
+}
+
+// stubImporter imports every package as an empty package with the name it most likely declares:
+// enough to tell the package qualifiers of a file from its other identifiers
+type stubImporter struct {
+	modules  []GoModule
+	packages map[string]*types.Package
+}
+
+func (i stubImporter) Import(importPath string) (*types.Package, error) {
+	if pkg, ok := i.packages[importPath]; ok {
+		return pkg, nil
+	}
+	pkg := types.NewPackage(importPath, packageName(PackageDir(i.modules, importPath), importPath))
+	pkg.MarkComplete()
+	i.packages[importPath] = pkg
+	return pkg, nil
+}
+
+// rename returns the file with the symbol renamed, or nil when the file does not refer to it
+func (r *symbolRenamer) rename(checked *checkedFile) *RenameFile {
+	path, content, file := checked.path, checked.content, checked.file
+	offset := func(pos token.Pos) int { return checked.fset.Position(pos).Offset }
+
+	var edits []textEdit
+	inPackage := filepath.Dir(path) == r.packageDir && file.Name.Name == r.oldName
+
+	// The name under which the file imports the old and the new package
+	oldLocal, newLocal := "", ""
+	var oldImport *ast.ImportSpec
+	for _, spec := range file.Imports {
+		importPath, _ := strconv.Unquote(spec.Path.Value)
+		local := ""
+		switch {
+		case spec.Name != nil:
+			local = spec.Name.Name
+		case importPath == r.options.From.Package:
+			local = r.oldName
+		case importPath == r.options.To.Package:
+			local = r.newName
+		}
+		if importPath == r.options.From.Package && local != "_" && local != "." {
+			oldLocal, oldImport = local, spec
+		}
+		if importPath == r.options.To.Package && local != "_" && local != "." {
+			newLocal = local
+		}
+	}
+	if !inPackage && oldLocal == "" {
+		return nil
+	}
+
+	// The spans of the references, replaced once the qualifier of the new package is known
+	var spans []textEdit
+	oldUses := 0
+	if inPackage {
+		declarations := topLevelDeclarations(file, r.options.From.Name)
+		for _, ident := range packageLevelIdents(file, checked.info, checked.pkg, r.options.From.Name) {
+			// The declaration stays where it is when the symbol moves to another package
+			if r.options.Moves() && declarations[ident] {
+				continue
+			}
+			spans = append(spans, textEdit{start: offset(ident.Pos()), end: offset(ident.End())})
+		}
+		// Doc comments start with the name of what they document
+		if doc := declarationDoc(file, r.options.From.Name); doc != nil && !r.options.Moves() {
+			text := doc.List[0].Text
+			prefix := "// " + r.options.From.Name
+			if rest := strings.TrimPrefix(text, prefix); rest != text && (rest == "" || rest[0] == ' ') {
+				start := offset(doc.List[0].Pos()) + len("// ")
+				edits = append(edits, textEdit{start, start + len(r.options.From.Name), r.options.To.Name})
+			}
+		}
+	} else {
+		ast.Inspect(file, func(node ast.Node) bool {
+			selector, ok := node.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			qualifier, ok := selector.X.(*ast.Ident)
+			if !ok {
+				return true
+			}
+			// Locals of the same name as the import are not qualifiers
+			pkg, ok := checked.info.Uses[qualifier].(*types.PkgName)
+			if !ok || pkg.Imported().Path() != r.options.From.Package {
+				return true
+			}
+			if selector.Sel.Name != r.options.From.Name {
+				oldUses++
+				return true
+			}
+			if r.options.Moves() {
+				spans = append(spans, textEdit{start: offset(selector.Pos()), end: offset(selector.End())})
+			} else {
+				spans = append(spans, textEdit{start: offset(selector.Sel.Pos()), end: offset(selector.Sel.End())})
+			}
+			return false
+		})
+	}
+	if len(spans) == 0 {
+		return nil
+	}
+
+	replacement := r.options.To.Name
+	imports := false
+	if r.options.Moves() {
+		// The old import is replaced by the new one, keeping its alias, when nothing else uses it
+		replaceImport := oldImport != nil && oldUses == 0 && newLocal == ""
+		qualifier := newLocal
+		switch {
+		case qualifier != "":
+		case replaceImport && oldImport.Name != nil:
+			qualifier = oldImport.Name.Name
+		default:
+			qualifier = r.newName
+		}
+		replacement = qualifier + "." + r.options.To.Name
+
+		switch {
+		case replaceImport:
+			imports = true
+			edits = append(edits, textEdit{offset(oldImport.Path.Pos()), offset(oldImport.Path.End()), strconv.Quote(r.options.To.Package)})
+		case newLocal == "":
+			imports = true
+			edits = append(edits, importEdit(file, offset, oldImport, r.options.To.Package))
+		case oldImport != nil && oldUses == 0:
+			imports = true
+			edits = append(edits, textEdit{offset(oldImport.Pos()), offset(oldImport.End()), ""})
+		}
+	}
+	for _, span := range spans {
+		span.text = replacement
+		edits = append(edits, span)
+	}
+	references := len(spans)
+
+	renamed := applyEdits(content, edits)
+	if imports {
+		// Sorts the imports and removes the blank import lines left behind
+		if formatted, err := format.Source(renamed); err == nil {
+			renamed = formatted
+		}
+	}
+	return &RenameFile{Path: path, References: references, Imports: imports, Content: renamed}
+}
+
+// packageLevelIdents returns the identifiers of a file of the declaring package that refer to the
+// package-level symbol name: its declarations and its uses, as resolved by the type checker.
+// Methods, struct fields, struct literal keys and local variables of the same name are left out,
+// while map and array literal keys naming the symbol are kept.
+func packageLevelIdents(file *ast.File, info *types.Info, pkg *types.Package, name string) []*ast.Ident {
+	var symbol types.Object
+	if pkg != nil {
+		symbol = pkg.Scope().Lookup(name)
+	}
+	// A declaration per build constraint (foo_linux.go, foo_windows.go) is declared once in
+	// the package scope; the others are reported as redeclared and must be renamed too
+	declarations := topLevelDeclarations(file, name)
+	var idents []*ast.Ident
+	ast.Inspect(file, func(node ast.Node) bool {
+		ident, ok := node.(*ast.Ident)
+		if !ok || ident.Name != name {
+			return true
+		}
+		if declarations[ident] || (symbol != nil && info.ObjectOf(ident) == symbol) {
+			idents = append(idents, ident)
+		}
+		return true
+	})
+	return idents
+}
+
+// topLevelDeclarations returns the identifiers declaring name at the top level of the file
+func topLevelDeclarations(file *ast.File, name string) map[*ast.Ident]bool {
+	declarations := map[*ast.Ident]bool{}
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv == nil && decl.Name.Name == name {
+				declarations[decl.Name] = true
+			}
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					if spec.Name.Name == name {
+						declarations[spec.Name] = true
+					}
+				case *ast.ValueSpec:
+					for _, ident := range spec.Names {
+						if ident.Name == name {
+							declarations[ident] = true
+						}
+					}
+				}
+			}
+		}
+	}
+	return declarations
+}
+
+// declarationDoc returns the doc comment of the package-level declaration of name in the file
+func declarationDoc(file *ast.File, name string) *ast.CommentGroup {
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv == nil && decl.Name.Name == name {
+				return decl.Doc
+			}
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				var doc *ast.CommentGroup
+				var names []*ast.Ident
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					doc, names = spec.Doc, []*ast.Ident{spec.Name}
+				case *ast.ValueSpec:
+					doc, names = spec.Doc, spec.Names
+				}
+				if !slices.ContainsFunc(names, func(ident *ast.Ident) bool { return ident.Name == name }) {
+					continue
+				}
+				if doc == nil && len(decl.Specs) == 1 {
+					doc = decl.Doc
+				}
+				return doc
+			}
+		}
+	}
+	return nil
+}
+
+// importEdit adds an import of importPath to the file, next to the import after when given, so
+// that it lands in the same group
+func importEdit(file *ast.File, offset func(token.Pos) int, after *ast.ImportSpec, importPath string) textEdit {
+	quoted := strconv.Quote(importPath)
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+		if !gen.Lparen.IsValid() {
+			if after != nil && gen.Specs[0] != after {
+				continue
+			}
+			at := offset(gen.End())
+			return textEdit{at, at, "\nimport " + quoted}
+		}
+		if len(gen.Specs) == 0 {
+			at := offset(gen.Lparen) + 1
+			return textEdit{at, at, "\n\t" + quoted}
+		}
+		anchor := gen.Specs[len(gen.Specs)-1]
+		if after != nil {
+			if !slices.Contains(gen.Specs, ast.Spec(after)) {
+				continue
+			}
+			anchor = after
+		}
+		at := offset(anchor.End())
+		return textEdit{at, at, "\n\t" + quoted}
+	}
+	at := offset(file.Name.End())
+	return textEdit{at, at, "\n\nimport " + quoted}
+}
+
+func applyEdits(content []byte, edits []textEdit) []byte {
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+	var b bytes.Buffer
+	last := 0
+	for _, edit := range edits {
+		if edit.start < last {
+			continue
+		}
+		b.Write(content[last:edit.start])
+		b.WriteString(edit.text)
+		last = edit.end
+	}
+	b.Write(content[last:])
+	return b.Bytes()
+}
+
+// WriteRename writes the renamed files
+func WriteRename(workspace *Workspace, plan *RenamePlan) error {
+	start := time.Now()
+	if err := workspace.RequireWorktrees("renaming symbols"); err != nil {
+		return err
+	}
+	for _, file := range plan.Files {
+		info, err := os.Stat(file.Path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to stat %s", file.Path)
+		}
+		if err := os.WriteFile(file.Path, file.Content, info.Mode().Perm()); err != nil {
+			return errors.Wrapf(err, "failed to write %s", file.Path)
+		}
+	}
+	RecordOperation("rename-symbol", workspace.Name, start, map[string]string{
+		"from":  plan.Options.From.String(),
+		"to":    plan.Options.To.String(),
+		"files": strconv.Itoa(len(plan.Files)),
+	})
+	return nil
+}
+
+// SymbolDeclaration returns the file and byte offset of the declaration of a package-level symbol
+// in dir
+func SymbolDeclaration(dir, name string) (string, int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", 0, errors.Wrapf(err, "failed to read %s", dir)
+	}
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".go") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		for ident := range topLevelDeclarations(file, name) {
+			return path, fset.Position(ident.Pos()).Offset, nil
+		}
+	}
+	return "", 0, errors.Errorf("no package-level declaration of %s in %s", name, dir)
+}
+
+// GoplsRename renames the symbol declared at offset of file with 'gopls rename', which follows
+// the type information of the go.work of the workspace, and returns the files it changed
+func GoplsRename(ctx context.Context, workspace *Workspace, file string, offset int, name string) ([]string, error) {
+	start := time.Now()
+	if err := workspace.RequireWorktrees("renaming symbols"); err != nil {
+		return nil, err
+	}
+	files, err := goplsRenameList(ctx, workspace, "-w", file+":#"+strconv.Itoa(offset), name)
+	if err != nil {
+		return nil, err
+	}
+	RecordOperation("rename-symbol", workspace.Name, start, map[string]string{
+		"declaration": file + ":#" + strconv.Itoa(offset),
+		"to":          name,
+		"files":       strconv.Itoa(len(files)),
+		"gopls":       "true",
+	})
+	return files, nil
+}
+
+// GoplsRenameFiles returns the files GoplsRename would change, without writing them
+func GoplsRenameFiles(ctx context.Context, workspace *Workspace, file string, offset int, name string) ([]string, error) {
+	return goplsRenameList(ctx, workspace, file+":#"+strconv.Itoa(offset), name)
+}
+
+func goplsRenameList(ctx context.Context, workspace *Workspace, args ...string) ([]string, error) {
+	out, err := gopls(ctx, workspace, append([]string{"rename", "-l"}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			files = append(files, line)
+		}
+	}
+	return files, nil
+}
+
+// GoplsRenameDiff returns the diff of the rename GoplsRename would make, without writing it
+func GoplsRenameDiff(ctx context.Context, workspace *Workspace, file string, offset int, name string) (string, error) {
+	return gopls(ctx, workspace, "rename", "-d", file+":#"+strconv.Itoa(offset), name)
+}
+
+func gopls(ctx context.Context, workspace *Workspace, args ...string) (string, error) {
+	if _, err := exec.LookPath("gopls"); err != nil {
+		return "", errors.New("gopls is not installed (go install golang.org/x/tools/gopls@latest)")
+	}
+	cmd := exec.CommandContext(ctx, "gopls", args...)
+	cmd.Dir = workspace.Path
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", errors.Errorf("gopls %s failed: %s", args[0], strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+// ModuleOf returns the innermost module containing path
+func ModuleOf(modules []GoModule, path string) (GoModule, bool) {
+	best := -1
+	for i, module := range modules {
+		if path != module.Dir && !strings.HasPrefix(path, module.Dir+string(filepath.Separator)) {
+			continue
+		}
+		if best < 0 || len(module.Dir) > len(modules[best].Dir) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return GoModule{}, false
+	}
+	return modules[best], true
+}
+
+// GoVet runs 'go vet ./...' in a module directory, which compiles the packages with their tests,
+// and returns its output when it fails
+func GoVet(ctx context.Context, dir string) (string, error) {
+	cmd := exec.CommandContext(ctx, "go", "vet", "./...")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return strings.TrimSpace(string(out)), errors.Wrapf(err, "go vet failed in %s", dir)
+	}
+	return "", nil
+}
+
+// FileSnapshot holds the content of files before they are rewritten
+type FileSnapshot map[string][]byte
+
+// SnapshotFiles reads the content of paths so that a rewrite can be undone
+func SnapshotFiles(paths []string) (FileSnapshot, error) {
+	snapshot := FileSnapshot{}
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s", path)
+		}
+		snapshot[path] = content
+	}
+	return snapshot, nil
+}
+
+// Restore writes the files back as they were
+func (s FileSnapshot) Restore() error {
+	for path, content := range s {
+		info, err := os.Stat(path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to stat %s", path)
+		}
+		if err := os.WriteFile(path, content, info.Mode().Perm()); err != nil {
+			return errors.Wrapf(err, "failed to restore %s", path)
+		}
+	}
+	return nil
+}
+
+// ModifiedFiles returns which of paths (all files without paths) in the repository at repoPath
+// have uncommitted changes or are untracked
+func ModifiedFiles(ctx context.Context, repoPath string, paths []string) (map[string]bool, error) {
+	modified := map[string]bool{}
+	for _, args := range [][]string{
+		{"diff", "HEAD", "--name-only", "--"},
+		{"ls-files", "--others", "--exclude-standard", "--"},
+	} {
+		out, err := runGitOutput(ctx, repoPath, append(args, paths...)...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get the status of %s", repoPath)
+		}
+		for _, line := range strings.Split(out, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				modified[filepath.Join(repoPath, filepath.FromSlash(line))] = true
+			}
+		}
+	}
+	return modified, nil
+}
+
+// StageFiles stages paths in the repository at repoPath
+func StageFiles(ctx context.Context, repoPath string, paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	if _, err := runGitOutput(ctx, repoPath, append([]string{"add", "--"}, paths...)...); err != nil {
+		return errors.Wrapf(err, "failed to stage changes in %s", repoPath)
+	}
+	return nil
+}
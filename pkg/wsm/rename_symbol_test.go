@@ -0,0 +1,346 @@
+package wsm
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeGoFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestParseSymbolRef(t *testing.T) {
+	tests := []struct {
+		ref     string
+		want    SymbolRef
+		wantErr bool
+	}{
+		{ref: "github.com/acme/lib/auth.NewClient", want: SymbolRef{Package: "github.com/acme/lib/auth", Name: "NewClient"}},
+		{ref: "gopkg.in/yaml.v3.Marshal", want: SymbolRef{Package: "gopkg.in/yaml.v3", Name: "Marshal"}},
+		{ref: "fmt.Println", want: SymbolRef{Package: "fmt", Name: "Println"}},
+		{ref: "github.com/acme/lib/auth", wantErr: true},
+		{ref: "github.com/acme/lib/", wantErr: true},
+		{ref: ".Name", wantErr: true},
+		{ref: "github.com/acme/lib.1st", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := ParseSymbolRef(tt.ref)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSymbolRef failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseSymbolRef(%q) = %+v, want %+v", tt.ref, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanRenameSymbol(t *testing.T) {
+	const libMod = "module example.com/lib\n\ngo 1.22\n"
+	const appMod = "module example.com/app\n\ngo 1.22\n"
+	tests := []struct {
+		name  string
+		from  string
+		to    string
+		files map[string]string
+		// want maps the changed files to their content; the other files must be left alone
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name: "declaration, doc comment and uses",
+			from: "example.com/lib/auth.NewClient",
+			to:   "NewAuthClient",
+			files: map[string]string{
+				"lib/go.mod": libMod,
+				"lib/auth/client.go": `package auth
+
+// NewClient creates a client
+func NewClient() *Client { return &Client{} }
+
+type Client struct{ NewClient bool }
+
+func (c *Client) Reset() { c.NewClient = false }
+`,
+				"lib/auth/other.go": "package auth\n\nvar defaultClient = NewClient()\n",
+				"app/go.mod":        appMod,
+				"app/main.go":       "package main\n\nimport \"example.com/lib/auth\"\n\nfunc main() { _ = auth.NewClient() }\n",
+			},
+			want: map[string]string{
+				"lib/auth/client.go": `package auth
+
+// NewAuthClient creates a client
+func NewAuthClient() *Client { return &Client{} }
+
+type Client struct{ NewClient bool }
+
+func (c *Client) Reset() { c.NewClient = false }
+`,
+				"lib/auth/other.go": "package auth\n\nvar defaultClient = NewAuthClient()\n",
+				"app/main.go":       "package main\n\nimport \"example.com/lib/auth\"\n\nfunc main() { _ = auth.NewAuthClient() }\n",
+			},
+		},
+		{
+			name: "locals, methods and struct literal keys",
+			from: "example.com/lib/auth.Token",
+			to:   "AccessToken",
+			files: map[string]string{
+				"lib/go.mod": libMod,
+				"lib/auth/token.go": `package auth
+
+const Token = "token"
+
+type Options struct{ Token string }
+
+type Session struct{}
+
+func (Session) Token() string { return "" }
+
+func options() Options { return Options{Token: Token} }
+
+func local() string {
+	Token := "shadowed"
+	return Token
+}
+
+var names = map[string]int{Token: 1}
+`,
+			},
+			want: map[string]string{
+				"lib/auth/token.go": `package auth
+
+const AccessToken = "token"
+
+type Options struct{ Token string }
+
+type Session struct{}
+
+func (Session) Token() string { return "" }
+
+func options() Options { return Options{Token: AccessToken} }
+
+func local() string {
+	Token := "shadowed"
+	return Token
+}
+
+var names = map[string]int{AccessToken: 1}
+`,
+			},
+		},
+		{
+			name: "local shadowing the import",
+			from: "example.com/lib/auth.NewClient",
+			to:   "NewAuthClient",
+			files: map[string]string{
+				"lib/go.mod":         libMod,
+				"lib/auth/client.go": "package auth\n\nfunc NewClient() {}\n",
+				"app/go.mod":         appMod,
+				"app/main.go": `package main
+
+import "example.com/lib/auth"
+
+type helper struct{ NewClient func() }
+
+func main() {
+	auth.NewClient()
+	{
+		auth := helper{}
+		auth.NewClient()
+	}
+}
+`,
+			},
+			want: map[string]string{
+				"lib/auth/client.go": "package auth\n\nfunc NewAuthClient() {}\n",
+				"app/main.go": `package main
+
+import "example.com/lib/auth"
+
+type helper struct{ NewClient func() }
+
+func main() {
+	auth.NewAuthClient()
+	{
+		auth := helper{}
+		auth.NewClient()
+	}
+}
+`,
+			},
+		},
+		{
+			name: "declaration per build constraint",
+			from: "example.com/lib/auth.Dial",
+			to:   "Connect",
+			files: map[string]string{
+				"lib/go.mod":             libMod,
+				"lib/auth/dial_unix.go":  "//go:build unix\n\npackage auth\n\nfunc Dial() {}\n",
+				"lib/auth/dial_other.go": "//go:build !unix\n\npackage auth\n\nfunc Dial() {}\n",
+				"lib/auth/use.go":        "package auth\n\nfunc init() { Dial() }\n",
+			},
+			want: map[string]string{
+				"lib/auth/dial_unix.go":  "//go:build unix\n\npackage auth\n\nfunc Connect() {}\n",
+				"lib/auth/dial_other.go": "//go:build !unix\n\npackage auth\n\nfunc Connect() {}\n",
+				"lib/auth/use.go":        "package auth\n\nfunc init() { Connect() }\n",
+			},
+		},
+		{
+			name: "aliased and versioned imports",
+			from: "example.com/lib/v2/auth.NewClient",
+			to:   "NewAuthClient",
+			files: map[string]string{
+				"lib/go.mod":         "module example.com/lib/v2\n\ngo 1.22\n",
+				"lib/auth/client.go": "package auth\n\nfunc NewClient() {}\n",
+				"app/go.mod":         appMod,
+				"app/main.go":        "package main\n\nimport a \"example.com/lib/v2/auth\"\n\nfunc main() { a.NewClient() }\n",
+			},
+			want: map[string]string{
+				"lib/auth/client.go": "package auth\n\nfunc NewAuthClient() {}\n",
+				"app/main.go":        "package main\n\nimport a \"example.com/lib/v2/auth\"\n\nfunc main() { a.NewAuthClient() }\n",
+			},
+		},
+		{
+			name: "move to another package",
+			from: "example.com/lib/auth.Token",
+			to:   "example.com/lib/token.Token",
+			files: map[string]string{
+				"lib/go.mod":         libMod,
+				"lib/auth/token.go":  "package auth\n\ntype Token string\n",
+				"lib/token/token.go": "package token\n\ntype Token string\n",
+				"app/go.mod":         appMod,
+				"app/main.go":        "package main\n\nimport \"example.com/lib/auth\"\n\nvar t auth.Token\n",
+			},
+			want: map[string]string{
+				"app/main.go": "package main\n\nimport \"example.com/lib/token\"\n\nvar t token.Token\n",
+			},
+		},
+		{
+			name: "unexported name used elsewhere",
+			from: "example.com/lib/auth.NewClient",
+			to:   "newClient",
+			files: map[string]string{
+				"lib/go.mod":         libMod,
+				"lib/auth/client.go": "package auth\n\nfunc NewClient() {}\n",
+				"app/go.mod":         appMod,
+				"app/main.go":        "package main\n\nimport \"example.com/lib/auth\"\n\nfunc main() { auth.NewClient() }\n",
+			},
+			wantErr: "cannot rename to unexported",
+		},
+		{
+			name:    "package outside the workspace",
+			from:    "example.com/other.Name",
+			to:      "Other",
+			files:   map[string]string{"lib/go.mod": libMod, "lib/lib.go": "package lib\n"},
+			wantErr: "not part of workspace",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeGoFiles(t, root, tt.files)
+			workspace := &Workspace{Name: "ws", Path: root}
+			for _, repo := range []string{"lib", "app"} {
+				if _, ok := tt.files[repo+"/go.mod"]; ok {
+					workspace.Repositories = append(workspace.Repositories, Repository{Name: repo})
+				}
+			}
+			from, err := ParseSymbolRef(tt.from)
+			if err != nil {
+				t.Fatal(err)
+			}
+			to := SymbolRef{Package: from.Package, Name: tt.to}
+			if strings.Contains(tt.to, "/") {
+				if to, err = ParseSymbolRef(tt.to); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			plan, err := PlanRenameSymbol(workspace, RenameSymbolOptions{From: from, To: to})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PlanRenameSymbol failed: %v", err)
+			}
+			if len(plan.Skipped) > 0 {
+				t.Errorf("unexpected skipped files: %v", plan.Skipped)
+			}
+			got := map[string]string{}
+			for _, file := range plan.Files {
+				rel, _ := filepath.Rel(root, file.Path)
+				got[filepath.ToSlash(rel)] = string(file.Content)
+			}
+			for name, want := range tt.want {
+				if got[name] != want {
+					t.Errorf("%s:\n%s\nwant:\n%s", name, got[name], want)
+				}
+			}
+			for name := range got {
+				if _, ok := tt.want[name]; !ok {
+					t.Errorf("%s changed unexpectedly:\n%s", name, got[name])
+				}
+			}
+		})
+	}
+}
+
+func TestSymbolDeclaration(t *testing.T) {
+	dir := t.TempDir()
+	writeGoFiles(t, dir, map[string]string{
+		"a.go": "package auth\n\nfunc (c *Client) NewClient() {}\n",
+		"b.go": "package auth\n\ntype Client struct{}\n\nfunc NewClient() *Client { return nil }\n",
+	})
+	path, offset, err := SymbolDeclaration(dir, "NewClient")
+	if err != nil {
+		t.Fatalf("SymbolDeclaration failed: %v", err)
+	}
+	content, _ := os.ReadFile(path)
+	if filepath.Base(path) != "b.go" || !strings.HasPrefix(string(content[offset:]), "NewClient() *Client") {
+		t.Errorf("SymbolDeclaration = %s:#%d, want the function in b.go", path, offset)
+	}
+	if _, _, err := SymbolDeclaration(dir, "Missing"); err == nil {
+		t.Error("expected an error for a missing symbol")
+	}
+}
+
+func TestFileSnapshotRestore(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	writeGoFiles(t, dir, map[string]string{"main.go": "package main\n"})
+
+	snapshot, err := SnapshotFiles([]string{path})
+	if err != nil {
+		t.Fatalf("SnapshotFiles failed: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("package broken"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := snapshot.Restore(); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if content, _ := os.ReadFile(path); string(content) != "package main\n" {
+		t.Errorf("content after Restore = %q", content)
+	}
+}
@@ -0,0 +1,348 @@
+package wsm
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Operations a repository can be interrupted in by conflicts
+const (
+	ConflictOperationRebase     = "rebase"
+	ConflictOperationMerge      = "merge"
+	ConflictOperationCherryPick = "cherry-pick"
+	ConflictOperationRevert     = "revert"
+)
+
+// Resolutions of a conflict hunk
+const (
+	ResolutionNone        = ""
+	ResolutionOurs        = "ours"
+	ResolutionTheirs      = "theirs"
+	ResolutionOursFirst   = "both"
+	ResolutionTheirsFirst = "both-reversed"
+)
+
+// ConflictedFile is a file with unmerged changes in a repository of a workspace
+type ConflictedFile struct {
+	Repository string `json:"repository"`
+	// Dir is the worktree of the repository
+	Dir  string `json:"dir"`
+	File string `json:"file"`
+	// Operation is the rebase, merge, cherry-pick or revert that stopped on the conflict
+	Operation string `json:"operation,omitempty"`
+}
+
+// Path returns the absolute path of the file
+func (f ConflictedFile) Path() string {
+	return filepath.Join(f.Dir, f.File)
+}
+
+// FindConflicts lists the unmerged files of every repository of the workspace, optionally
+// restricted to some repositories
+func FindConflicts(ctx context.Context, workspace *Workspace, repositories []string) ([]ConflictedFile, error) {
+	var conflicts []ConflictedFile
+	for _, repo := range workspace.Repositories {
+		if len(repositories) > 0 && !slices.Contains(repositories, repo.Name) {
+			continue
+		}
+		dir := filepath.Join(workspace.Path, repo.Name)
+		out, err := runGitOutput(ctx, dir, "diff", "--name-only", "--diff-filter=U")
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list conflicts in %s", repo.Name)
+		}
+		if out == "" {
+			continue
+		}
+		operation := ConflictOperation(ctx, dir)
+		for _, file := range strings.Split(out, "\n") {
+			conflicts = append(conflicts, ConflictedFile{Repository: repo.Name, Dir: dir, File: file, Operation: operation})
+		}
+	}
+	return conflicts, nil
+}
+
+// ConflictOperation returns the operation in progress in a worktree, or "" when there is none
+func ConflictOperation(ctx context.Context, dir string) string {
+	exists := func(name string) bool {
+		path, err := runGitOutput(ctx, dir, "rev-parse", "--git-path", name)
+		if err != nil {
+			return false
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		_, err = os.Stat(path)
+		return err == nil
+	}
+	switch {
+	case exists("rebase-merge") || exists("rebase-apply"):
+		return ConflictOperationRebase
+	case exists("MERGE_HEAD"):
+		return ConflictOperationMerge
+	case exists("CHERRY_PICK_HEAD"):
+		return ConflictOperationCherryPick
+	case exists("REVERT_HEAD"):
+		return ConflictOperationRevert
+	}
+	return ""
+}
+
+// ContinueOperation continues the interrupted operation of a worktree, keeping the default
+// commit message
+func ContinueOperation(ctx context.Context, dir, operation string) (string, error) {
+	if operation == "" {
+		return "", nil
+	}
+	cmd := exec.CommandContext(ctx, "git", operation, "--continue")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_EDITOR=true")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return strings.TrimSpace(string(out)), errors.Errorf("git %s --continue failed: %s", operation, strings.TrimSpace(string(out)))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// PendingOperation is an interrupted operation of a repository whose conflicts are all resolved
+type PendingOperation struct {
+	Repository string
+	Dir        string
+	Operation  string
+}
+
+// ResolvedOperations returns the repositories of the workspace with an interrupted operation and
+// no unmerged files left, ready to be continued
+func ResolvedOperations(ctx context.Context, workspace *Workspace, repositories []string) []PendingOperation {
+	var pending []PendingOperation
+	for _, repo := range workspace.Repositories {
+		if len(repositories) > 0 && !slices.Contains(repositories, repo.Name) {
+			continue
+		}
+		dir := filepath.Join(workspace.Path, repo.Name)
+		operation := ConflictOperation(ctx, dir)
+		if operation == "" {
+			continue
+		}
+		if out, err := runGitOutput(ctx, dir, "diff", "--name-only", "--diff-filter=U"); err != nil || out != "" {
+			continue
+		}
+		pending = append(pending, PendingOperation{Repository: repo.Name, Dir: dir, Operation: operation})
+	}
+	return pending
+}
+
+// IsUnmerged reports whether a file still has unmerged changes, e.g. after a merge tool ran
+func IsUnmerged(ctx context.Context, file ConflictedFile) bool {
+	out, err := runGitOutput(ctx, file.Dir, "diff", "--name-only", "--diff-filter=U", "--", file.File)
+	return err == nil && out != ""
+}
+
+// SaveResolution writes the resolved hunks of a conflicted file, keeping the markers of the
+// others, and stages the file once no conflict is left. It reports whether the file was staged.
+func SaveResolution(ctx context.Context, file ConflictedFile, doc *ConflictDocument) (bool, error) {
+	info, err := os.Stat(file.Path())
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to stat %s", file.File)
+	}
+	if err := os.WriteFile(file.Path(), []byte(doc.Render()), info.Mode().Perm()); err != nil {
+		return false, errors.Wrapf(err, "failed to write %s", file.File)
+	}
+	if doc.Unresolved() > 0 {
+		return false, nil
+	}
+	if _, err := runGitOutput(ctx, file.Dir, "add", "--", file.File); err != nil {
+		return false, errors.Wrapf(err, "failed to stage %s", file.File)
+	}
+	return true, nil
+}
+
+// ConflictHunk is one conflict of a file: the lines of both sides, and of the merge base when
+// the file uses diff3 markers
+type ConflictHunk struct {
+	OursLabel   string
+	BaseLabel   string
+	TheirsLabel string
+	Ours        []string
+	// Base is nil without diff3 markers, and empty when the base section is
+	Base       []string
+	Theirs     []string
+	Resolution string
+}
+
+// Resolved returns the lines replacing the hunk, or nil and false while it is unresolved
+func (h *ConflictHunk) Resolved() ([]string, bool) {
+	switch h.Resolution {
+	case ResolutionOurs:
+		return h.Ours, true
+	case ResolutionTheirs:
+		return h.Theirs, true
+	case ResolutionOursFirst:
+		return append(append([]string{}, h.Ours...), h.Theirs...), true
+	case ResolutionTheirsFirst:
+		return append(append([]string{}, h.Theirs...), h.Ours...), true
+	}
+	return nil, false
+}
+
+// conflictSegment is either a run of merged lines or a conflict hunk
+type conflictSegment struct {
+	lines []string
+	hunk  *ConflictHunk
+}
+
+// ConflictDocument is a file with conflict markers split into merged text and conflict hunks
+type ConflictDocument struct {
+	segments []conflictSegment
+	// trailingNewline records whether the file ended with a newline
+	trailingNewline bool
+	// lineEnding is "\r\n" for files with Windows line endings, which are kept when rendering
+	lineEnding string
+}
+
+// ParseConflictDocument splits file content at its conflict markers. It fails when the markers
+// are unbalanced.
+func ParseConflictDocument(content string) (*ConflictDocument, error) {
+	doc := &ConflictDocument{trailingNewline: strings.HasSuffix(content, "\n"), lineEnding: "\n"}
+	if strings.Contains(content, "\r\n") {
+		doc.lineEnding = "\r\n"
+	}
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	if doc.lineEnding == "\r\n" {
+		for i := range lines {
+			lines[i] = strings.TrimSuffix(lines[i], "\r")
+		}
+	}
+
+	var text []string
+	var hunk *ConflictHunk
+	section := ""
+	for i, line := range lines {
+		switch {
+		case isConflictMarker(line, "<<<<<<<"):
+			if hunk != nil {
+				return nil, errors.Errorf("line %d: nested conflict marker", i+1)
+			}
+			if len(text) > 0 {
+				doc.segments = append(doc.segments, conflictSegment{lines: text})
+				text = nil
+			}
+			hunk = &ConflictHunk{OursLabel: markerLabel(line)}
+			section = "ours"
+		case hunk != nil && section == "ours" && isConflictMarker(line, "|||||||"):
+			hunk.BaseLabel = markerLabel(line)
+			hunk.Base = []string{}
+			section = "base"
+		case hunk != nil && section != "theirs" && isConflictMarker(line, "======="):
+			section = "theirs"
+		case hunk != nil && section == "theirs" && isConflictMarker(line, ">>>>>>>"):
+			hunk.TheirsLabel = markerLabel(line)
+			doc.segments = append(doc.segments, conflictSegment{hunk: hunk})
+			hunk = nil
+			section = ""
+		case hunk != nil:
+			switch section {
+			case "ours":
+				hunk.Ours = append(hunk.Ours, line)
+			case "base":
+				hunk.Base = append(hunk.Base, line)
+			case "theirs":
+				hunk.Theirs = append(hunk.Theirs, line)
+			}
+		default:
+			text = append(text, line)
+		}
+	}
+	if hunk != nil {
+		return nil, errors.New("unterminated conflict marker")
+	}
+	if len(text) > 0 {
+		doc.segments = append(doc.segments, conflictSegment{lines: text})
+	}
+	return doc, nil
+}
+
+func isConflictMarker(line, marker string) bool {
+	return line == marker || strings.HasPrefix(line, marker+" ")
+}
+
+func markerLabel(line string) string {
+	return strings.TrimSpace(line[7:])
+}
+
+// Hunks returns the conflict hunks in file order
+func (d *ConflictDocument) Hunks() []*ConflictHunk {
+	var hunks []*ConflictHunk
+	for _, segment := range d.segments {
+		if segment.hunk != nil {
+			hunks = append(hunks, segment.hunk)
+		}
+	}
+	return hunks
+}
+
+// Unresolved returns the number of hunks without a resolution
+func (d *ConflictDocument) Unresolved() int {
+	count := 0
+	for _, hunk := range d.Hunks() {
+		if _, ok := hunk.Resolved(); !ok {
+			count++
+		}
+	}
+	return count
+}
+
+// LineRange is a half-open range of line indexes
+type LineRange struct {
+	Start int
+	End   int
+}
+
+// Render returns the file content with resolved hunks replaced by their resolution and the
+// others kept with their markers
+func (d *ConflictDocument) Render() string {
+	lines, _ := d.RenderLines()
+	ending := d.lineEnding
+	if ending == "" {
+		ending = "\n"
+	}
+	content := strings.Join(lines, ending)
+	if d.trailingNewline {
+		content += ending
+	}
+	return content
+}
+
+// RenderLines returns the lines of Render along with the range each hunk occupies in them
+func (d *ConflictDocument) RenderLines() ([]string, []LineRange) {
+	var lines []string
+	var ranges []LineRange
+	for _, segment := range d.segments {
+		if segment.hunk == nil {
+			lines = append(lines, segment.lines...)
+			continue
+		}
+		start := len(lines)
+		if resolved, ok := segment.hunk.Resolved(); ok {
+			lines = append(lines, resolved...)
+		} else {
+			hunk := segment.hunk
+			lines = append(lines, strings.TrimSpace("<<<<<<< "+hunk.OursLabel))
+			lines = append(lines, hunk.Ours...)
+			if hunk.Base != nil {
+				lines = append(lines, strings.TrimSpace("||||||| "+hunk.BaseLabel))
+				lines = append(lines, hunk.Base...)
+			}
+			lines = append(lines, "=======")
+			lines = append(lines, hunk.Theirs...)
+			lines = append(lines, strings.TrimSpace(">>>>>>> "+hunk.TheirsLabel))
+		}
+		ranges = append(ranges, LineRange{Start: start, End: len(lines)})
+	}
+	return lines, ranges
+}
@@ -0,0 +1,99 @@
+package wsm
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+const diff3Conflict = `package main
+
+<<<<<<< HEAD
+const name = "ours"
+||||||| base-commit
+const name = "base"
+=======
+const name = "theirs"
+>>>>>>> feature
+`
+
+func TestParseConflictDocument(t *testing.T) {
+	doc, err := ParseConflictDocument(diff3Conflict)
+	if err != nil {
+		t.Fatalf("ParseConflictDocument failed: %v", err)
+	}
+	hunks := doc.Hunks()
+	if len(hunks) != 1 {
+		t.Fatalf("expected one hunk, got %d", len(hunks))
+	}
+	hunk := hunks[0]
+	if hunk.OursLabel != "HEAD" || hunk.BaseLabel != "base-commit" || hunk.TheirsLabel != "feature" {
+		t.Errorf("labels = %q, %q, %q", hunk.OursLabel, hunk.BaseLabel, hunk.TheirsLabel)
+	}
+	if !slices.Equal(hunk.Ours, []string{`const name = "ours"`}) || !slices.Equal(hunk.Base, []string{`const name = "base"`}) || !slices.Equal(hunk.Theirs, []string{`const name = "theirs"`}) {
+		t.Errorf("hunk = %+v", hunk)
+	}
+
+	// Unresolved hunks are rendered back as they were
+	if got := doc.Render(); got != diff3Conflict {
+		t.Errorf("Render:\n%s\nwant:\n%s", got, diff3Conflict)
+	}
+
+	hunk.Resolution = ResolutionTheirsFirst
+	want := "package main\n\nconst name = \"theirs\"\nconst name = \"ours\"\n"
+	if got := doc.Render(); got != want {
+		t.Errorf("Render:\n%s\nwant:\n%s", got, want)
+	}
+	if doc.Unresolved() != 0 {
+		t.Errorf("Unresolved = %d", doc.Unresolved())
+	}
+}
+
+func TestParseConflictDocumentKeepsLineEndings(t *testing.T) {
+	content := strings.ReplaceAll(diff3Conflict, "\n", "\r\n")
+	doc, err := ParseConflictDocument(content)
+	if err != nil {
+		t.Fatalf("ParseConflictDocument failed: %v", err)
+	}
+	hunks := doc.Hunks()
+	if len(hunks) != 1 || hunks[0].TheirsLabel != "feature" || !slices.Equal(hunks[0].Ours, []string{`const name = "ours"`}) {
+		t.Fatalf("hunks = %+v", hunks)
+	}
+	if got := doc.Render(); got != content {
+		t.Errorf("Render = %q, want %q", got, content)
+	}
+	hunks[0].Resolution = ResolutionOurs
+	if want := "package main\r\n\r\nconst name = \"ours\"\r\n"; doc.Render() != want {
+		t.Errorf("Render = %q, want %q", doc.Render(), want)
+	}
+}
+
+func TestParseConflictDocumentEmptyBase(t *testing.T) {
+	content := "<<<<<<< HEAD\nours\n|||||||\n=======\ntheirs\n>>>>>>>\n"
+	doc, err := ParseConflictDocument(content)
+	if err != nil {
+		t.Fatalf("ParseConflictDocument failed: %v", err)
+	}
+	if hunk := doc.Hunks()[0]; hunk.Base == nil || len(hunk.Base) != 0 {
+		t.Errorf("Base = %#v, want an empty base section", hunk.Base)
+	}
+	if got := doc.Render(); got != content {
+		t.Errorf("Render:\n%s\nwant:\n%s", got, content)
+	}
+}
+
+func TestParseConflictDocumentRejectsUnbalancedMarkers(t *testing.T) {
+	for _, content := range []string{
+		"<<<<<<< HEAD\nours\n=======\ntheirs\n",
+		"<<<<<<< HEAD\n<<<<<<< HEAD\n=======\n>>>>>>> x\n",
+	} {
+		if _, err := ParseConflictDocument(content); err == nil {
+			t.Errorf("expected an error for %q", content)
+		}
+	}
+	// Marker-like lines outside of a conflict are text
+	doc, err := ParseConflictDocument("=======\n>>>>>>> x\n<<<<<<<<< not a marker\n")
+	if err != nil || len(doc.Hunks()) != 0 {
+		t.Errorf("got %d hunk(s), %v", len(doc.Hunks()), err)
+	}
+}
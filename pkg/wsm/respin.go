@@ -0,0 +1,152 @@
+package wsm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/pkg/errors"
+)
+
+// respinSkippedFiles are .wsm files holding state of the source branch rather than configuration
+var respinSkippedFiles = []string{"review.json", "review.md"}
+
+// RespinRepository is a repository of a respun workspace with the ref its new branch starts from
+type RespinRepository struct {
+	Name string
+	// Base is the default branch of the repository, empty when none was found and the current
+	// HEAD of the repository is used
+	Base string
+}
+
+// repositoryBase returns the ref the worktree branch of a repository is created from
+func (w *Workspace) repositoryBase(repoName string) string {
+	if base, ok := w.repositoryBases[repoName]; ok {
+		return base
+	}
+	return w.BaseBranch
+}
+
+// RepositoryDefaultBranch returns the default branch of a repository: origin/HEAD, origin/main or
+// origin/master, then the local main or master branch, or "" when there is none
+func (wm *WorkspaceManager) RepositoryDefaultBranch(ctx context.Context, repoPath string) string {
+	if ref := resolveRemoteCompareRef(ctx, wm.Runner, repoPath, "origin", ""); ref != "" {
+		return ref
+	}
+	for _, branch := range []string{"main", "master"} {
+		if _, err := gitOutput(ctx, wm.Runner, repoPath, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch); err == nil {
+			return branch
+		}
+	}
+	return ""
+}
+
+// RespinWorkspace creates a sibling of a workspace with the same repositories on a new branch that
+// starts from the default branch of each repository instead of from the branch of the source. The
+// .wsm configuration and the git config overrides of the source are copied. With fetch, origin is
+// fetched first so the new branch starts from the latest default branch.
+func (wm *WorkspaceManager) RespinWorkspace(ctx context.Context, source *Workspace, name, branch string, fetch, dryRun bool) (*Workspace, []RespinRepository, error) {
+	if branch == "" {
+		return nil, nil, errors.New("a branch is required")
+	}
+	if err := ValidateBranchName(ctx, branch); err != nil {
+		return nil, nil, err
+	}
+
+	var repoNames []string
+	var plan []RespinRepository
+	bases := map[string]string{}
+	for _, repo := range source.Repositories {
+		if fetch && !dryRun {
+			if _, err := wm.networkGit(ctx, repo.Name, OperationFetch, repo.Path, "fetch", "--quiet", "origin"); err != nil {
+				output.PrintWarning("Failed to fetch origin in %s: %v", repo.Name, err)
+			}
+		}
+		base := wm.RepositoryDefaultBranch(ctx, repo.Path)
+		repoNames = append(repoNames, repo.Name)
+		plan = append(plan, RespinRepository{Name: repo.Name, Base: base})
+		bases[repo.Name] = base
+	}
+
+	workspace, err := wm.planWorkspace(name, repoNames, branch, commonBase(plan), source.AgentMD)
+	if err != nil {
+		return nil, nil, err
+	}
+	workspace.repositoryBases = bases
+	if source.GitConfig != nil && !source.GitConfig.IsEmpty() {
+		workspace.GitConfig = source.GitConfig.clone()
+	}
+	if dryRun {
+		return workspace, plan, nil
+	}
+
+	if _, err := os.Stat(workspace.Path); err == nil {
+		return nil, nil, errors.Errorf("directory %s already exists", workspace.Path)
+	}
+	if err := wm.establishWorkspace(ctx, workspace, "respin", map[string]string{
+		"source": source.Name,
+		"repos":  strings.Join(repoNames, ","),
+		"branch": branch,
+	}); err != nil {
+		return nil, nil, err
+	}
+	if err := copyWsmConfig(source.Path, workspace.Path); err != nil {
+		output.PrintWarning("Failed to copy .wsm configuration: %v", err)
+	}
+
+	return workspace, plan, nil
+}
+
+// commonBase returns the base shared by every repository, or "" when they differ
+func commonBase(plan []RespinRepository) string {
+	if len(plan) == 0 {
+		return ""
+	}
+	for _, repo := range plan[1:] {
+		if repo.Base != plan[0].Base {
+			return ""
+		}
+	}
+	return plan[0].Base
+}
+
+// copyWsmConfig copies the .wsm directory of a workspace into another one, skipping state files.
+// Files rendered from the templates into the new workspace are replaced by those of the source.
+func copyWsmConfig(sourcePath, targetPath string) error {
+	sourceDir := filepath.Join(sourcePath, ".wsm")
+	if _, err := os.Stat(sourceDir); os.IsNotExist(err) {
+		return nil
+	}
+
+	copied := 0
+	err := filepath.WalkDir(sourceDir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(sourceDir, path)
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() || slices.Contains(respinSkippedFiles, rel) {
+			return nil
+		}
+		copied++
+		return copyFile(path, filepath.Join(targetPath, ".wsm", rel))
+	})
+	if err != nil {
+		return err
+	}
+
+	output.LogInfo(
+		fmt.Sprintf("Copied %d .wsm files from %s", copied, sourcePath),
+		"Copied .wsm configuration",
+		"source", sourcePath,
+		"target", targetPath,
+		"files", copied,
+	)
+	return nil
+}
@@ -0,0 +1,341 @@
+package wsm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/pkg/errors"
+)
+
+// IncompleteCreation is the plan of a workspace whose creation failed for some repositories. The
+// repositories that were created are kept in the workspace directory, so that 'create --resume'
+// only retries the failed ones once the problem (authentication, disk space, a branch conflict)
+// is fixed.
+type IncompleteCreation struct {
+	Workspace Workspace `json:"workspace"`
+	// Bases are the base branches of individual repositories, e.g. of respun workspaces
+	Bases   map[string]string  `json:"bases,omitempty"`
+	Failed  []FailedRepository `json:"failed"`
+	Updated time.Time          `json:"updated"`
+}
+
+// FailedRepository is a repository that could not be added to a new workspace
+type FailedRepository struct {
+	Name  string `json:"name"`
+	Error string `json:"error"`
+}
+
+// IncompleteCreationError is returned when some repositories of a new workspace could not be
+// created. The workspace is not saved, its plan is: see ResumeCreation and AbandonCreation.
+type IncompleteCreationError struct {
+	Name   string
+	Total  int
+	Failed []FailedRepository
+}
+
+func (e *IncompleteCreationError) Error() string {
+	var details []string
+	for _, repo := range e.Failed {
+		details = append(details, fmt.Sprintf("%s: %s", repo.Name, repo.Error))
+	}
+	return fmt.Sprintf("%d of %d repositories could not be created (%s)", len(e.Failed), e.Total, strings.Join(details, "; "))
+}
+
+// errCreationCancelled is returned when the user cancels a prompt during creation; cancelling
+// rolls back instead of keeping a plan to resume
+var errCreationCancelled = errors.New("workspace creation cancelled by user")
+
+func isCancellation(err error) bool {
+	return errors.Is(err, errCreationCancelled)
+}
+
+// incompleteCreationsDir holds the plans of incomplete workspace creations
+func incompleteCreationsDir() (string, error) {
+	configDir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "incomplete"), nil
+}
+
+// incompleteCreationPath is the plan of workspace name; names that are not a single path element
+// are rejected, so a plan is never read, written or removed outside the directory
+func incompleteCreationPath(name string) (string, error) {
+	if name == "." || !filepath.IsLocal(name) || filepath.Base(name) != name {
+		return "", errors.Errorf("invalid workspace name '%s'", name)
+	}
+	dir, err := incompleteCreationsDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name+".json"), nil
+}
+
+// ListIncompleteCreations returns the workspaces whose creation can be resumed, oldest first
+func ListIncompleteCreations() ([]IncompleteCreation, error) {
+	dir, err := incompleteCreationsDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", dir)
+	}
+
+	var plans []IncompleteCreation
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		var plan IncompleteCreation
+		if json.Unmarshal(data, &plan) == nil {
+			plans = append(plans, plan)
+		}
+	}
+	sort.Slice(plans, func(i, j int) bool {
+		return plans[i].Updated.Before(plans[j].Updated)
+	})
+	return plans, nil
+}
+
+// LoadIncompleteCreation loads the plan of the incomplete creation of workspace name
+func (wm *WorkspaceManager) LoadIncompleteCreation(name string) (*IncompleteCreation, error) {
+	path, err := incompleteCreationPath(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := wm.fs().ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, errors.Errorf("no incomplete creation of workspace '%s' to resume", name)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", path)
+	}
+
+	var plan IncompleteCreation
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", path)
+	}
+	plan.Workspace.repositoryBases = plan.Bases
+	return &plan, nil
+}
+
+func (wm *WorkspaceManager) saveIncompleteCreation(plan *IncompleteCreation) error {
+	path, err := incompleteCreationPath(plan.Workspace.Name)
+	if err != nil {
+		return err
+	}
+	if err := wm.fs().MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrap(err, "failed to create the directory of incomplete creations")
+	}
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the creation plan")
+	}
+	if err := wm.fs().WriteFile(path, data, 0644); err != nil {
+		return errors.Wrap(err, "failed to write the creation plan")
+	}
+	return nil
+}
+
+func (wm *WorkspaceManager) removeIncompleteCreation(name string) {
+	if path, err := incompleteCreationPath(name); err == nil {
+		if err := wm.fs().Remove(path); err != nil && !os.IsNotExist(err) {
+			output.PrintWarning("Failed to remove the creation plan %s: %v", path, err)
+		}
+	}
+}
+
+// checkNoIncompleteCreation fails when the creation of a workspace with the same name is pending:
+// its repositories are still in the workspace directory
+func (wm *WorkspaceManager) checkNoIncompleteCreation(name string) error {
+	path, err := incompleteCreationPath(name)
+	if err != nil {
+		return err
+	}
+	if _, err := wm.fs().Stat(path); err == nil {
+		return errors.Errorf("the creation of workspace '%s' is incomplete; finish it with 'create --resume %s' or discard it with 'create --abandon %s'", name, name, name)
+	}
+	return nil
+}
+
+// suspendCreation keeps the repositories that were created and saves the plan of the workspace
+// for ResumeCreation. When the plan cannot be saved, everything is rolled back. start is when the
+// attempt to create the workspace started.
+func (wm *WorkspaceManager) suspendCreation(ctx context.Context, workspace *Workspace, start time.Time, created []WorktreeInfo, failed []FailedRepository) error {
+	incomplete := &IncompleteCreationError{Name: workspace.Name, Total: len(workspace.Repositories), Failed: failed}
+
+	plan := &IncompleteCreation{
+		Workspace: *workspace,
+		Bases:     workspace.repositoryBases,
+		Failed:    failed,
+		Updated:   time.Now(),
+	}
+	if err := wm.saveIncompleteCreation(plan); err != nil {
+		wm.rollbackWorktrees(ctx, created)
+		wm.cleanupWorkspaceDirectory(workspace.Path)
+		wm.removeIncompleteCreation(workspace.Name)
+		return errors.Errorf("%s; rolled back because the plan to resume could not be saved: %v", incomplete.Error(), err)
+	}
+
+	RecordOperation("create-incomplete", workspace.Name, start, map[string]string{
+		"created": fmt.Sprintf("%d", len(created)),
+		"failed":  fmt.Sprintf("%d", len(failed)),
+		"path":    workspace.Path,
+	})
+	return incomplete
+}
+
+// ResumeCreation retries the repositories that failed when workspace name was created. When they
+// all succeed, the workspace files are written and the workspace is saved; otherwise the plan is
+// updated with the repositories that still fail.
+func (wm *WorkspaceManager) ResumeCreation(ctx context.Context, name string) (*Workspace, error) {
+	start := time.Now()
+	plan, err := wm.LoadIncompleteCreation(name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := wm.LoadWorkspace(name); err == nil {
+		return nil, errors.Errorf("workspace '%s' already exists; discard the incomplete creation with 'create --abandon %s'", name, name)
+	}
+	workspace := &plan.Workspace
+	if err := wm.EnforceQuota(ctx, !workspace.Linked); err != nil {
+		return nil, err
+	}
+
+	var created []WorktreeInfo
+	var failed []FailedRepository
+	var retried []string
+	for i, repo := range workspace.Repositories {
+		if !slices.ContainsFunc(plan.Failed, func(f FailedRepository) bool { return f.Name == repo.Name }) {
+			created = append(created, WorktreeInfo{
+				Repository: repo,
+				TargetPath: filepath.Join(workspace.Path, repo.Name),
+				Branch:     workspace.Branch,
+			})
+			continue
+		}
+
+		// The registry entry may have been fixed in the meantime, e.g. after moving the checkout
+		if repos, err := wm.FindRepositories([]string{repo.Name}); err == nil {
+			repo = repos[0]
+			workspace.Repositories[i] = repo
+		}
+		retried = append(retried, repo.Name)
+		worktreeInfo, err := wm.createRepository(ctx, workspace, repo)
+		if err != nil {
+			if isCancellation(err) {
+				return nil, err
+			}
+			output.LogError(
+				fmt.Sprintf("Failed again to create worktree for repository '%s': %v", repo.Name, err),
+				"Failed to create worktree on resume",
+				"repo", repo.Name,
+				"error", err,
+			)
+			failed = append(failed, FailedRepository{Name: repo.Name, Error: err.Error()})
+			continue
+		}
+		created = append(created, worktreeInfo)
+	}
+
+	if len(failed) > 0 {
+		return nil, wm.suspendCreation(ctx, workspace, start, created, failed)
+	}
+
+	if err := wm.finishWorkspaceStructure(ctx, workspace, created); err != nil {
+		wm.removeIncompleteCreation(name)
+		return nil, errors.Wrap(err, "failed to create workspace structure")
+	}
+	if err := wm.SaveWorkspace(workspace); err != nil {
+		return nil, errors.Wrap(err, "failed to save workspace configuration")
+	}
+	wm.removeIncompleteCreation(name)
+
+	var repoNames []string
+	for _, repo := range workspace.Repositories {
+		repoNames = append(repoNames, repo.Name)
+	}
+	wm.workspaceCreated(ctx, workspace, "create", start, map[string]string{
+		"repos":   strings.Join(repoNames, ","),
+		"branch":  workspace.Branch,
+		"resumed": strings.Join(retried, ","),
+	})
+
+	return workspace, nil
+}
+
+// AbandonCreation discards an incomplete creation: the repositories that were created are
+// removed along with the workspace directory, and the plan is deleted. Repositories with
+// uncommitted changes, or clones with commits that were not pushed, are only removed with force.
+func (wm *WorkspaceManager) AbandonCreation(ctx context.Context, name string, force bool) error {
+	start := time.Now()
+	plan, err := wm.LoadIncompleteCreation(name)
+	if err != nil {
+		return err
+	}
+	workspace := &plan.Workspace
+
+	var created []WorktreeInfo
+	for _, repo := range workspace.Repositories {
+		if slices.ContainsFunc(plan.Failed, func(f FailedRepository) bool { return f.Name == repo.Name }) {
+			continue
+		}
+		created = append(created, WorktreeInfo{
+			Repository: repo,
+			TargetPath: filepath.Join(workspace.Path, repo.Name),
+			Branch:     workspace.Branch,
+		})
+	}
+
+	if !force {
+		if unsaved := wm.unsavedWork(ctx, created); len(unsaved) > 0 {
+			return errors.Errorf("not abandoning workspace '%s': %s would be lost; keep the work or use --force-worktrees", name, strings.Join(unsaved, ", "))
+		}
+	}
+
+	wm.rollbackWorktrees(ctx, created)
+	wm.cleanupWorkspaceDirectory(workspace.Path)
+	wm.removeIncompleteCreation(name)
+
+	RecordOperation("create-abandoned", name, start, map[string]string{
+		"path": workspace.Path,
+	})
+	return nil
+}
+
+// unsavedWork describes the work that removing the created repositories would lose: uncommitted
+// changes, including untracked files, and for clones, which take their objects with them, local
+// commits that are on no remote
+func (wm *WorkspaceManager) unsavedWork(ctx context.Context, created []WorktreeInfo) []string {
+	var unsaved []string
+	for _, worktree := range created {
+		if _, err := wm.fs().Lstat(worktree.TargetPath); err != nil {
+			continue
+		}
+		if status, err := gitOutput(ctx, wm.runner(), worktree.TargetPath, "status", "--porcelain"); err == nil && status != "" {
+			unsaved = append(unsaved, fmt.Sprintf("uncommitted changes in '%s'", worktree.Repository.Name))
+		}
+		if info, err := wm.fs().Stat(filepath.Join(worktree.TargetPath, ".git")); err == nil && info.IsDir() {
+			if commits, err := gitOutput(ctx, wm.runner(), worktree.TargetPath, "log", "--oneline", "--branches", "--not", "--remotes"); err == nil && commits != "" {
+				unsaved = append(unsaved, fmt.Sprintf("unpushed commits in '%s'", worktree.Repository.Name))
+			}
+		}
+	}
+	return unsaved
+}
@@ -0,0 +1,103 @@
+package wsm
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testGit runs git in dir with a fixed identity and fails the test when it fails
+func testGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@example.com", "-c", "init.defaultBranch=main"}, args...)...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s failed: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// useTestConfigDir points the configuration directory, which holds the creation plans, to a
+// temporary directory
+func useTestConfigDir(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv(ProfileEnvVar, "")
+}
+
+func TestIncompleteCreationPathRejectsNames(t *testing.T) {
+	useTestConfigDir(t)
+	for _, name := range []string{"", ".", "..", "../feat", "a/b", "/tmp/feat"} {
+		if _, err := incompleteCreationPath(name); err == nil {
+			t.Errorf("expected %q to be rejected", name)
+		}
+	}
+	path, err := incompleteCreationPath("feat")
+	if err != nil {
+		t.Fatalf("incompleteCreationPath failed: %v", err)
+	}
+	if filepath.Base(path) != "feat.json" {
+		t.Errorf("unexpected plan path %s", path)
+	}
+}
+
+func TestAbandonCreationKeepsUnsavedWork(t *testing.T) {
+	useTestConfigDir(t)
+	wm := newTestWorkspaceManager(t)
+	ctx := context.Background()
+
+	root := t.TempDir()
+	source := filepath.Join(root, "lib")
+	testGit(t, root, "init", "--quiet", source)
+	testGit(t, source, "commit", "--quiet", "--allow-empty", "-m", "initial")
+	workspacePath := filepath.Join(root, "workspaces", "feat")
+	clone := filepath.Join(workspacePath, "lib")
+	testGit(t, root, "clone", "--quiet", source, clone)
+
+	plan := &IncompleteCreation{
+		Workspace: Workspace{
+			Name:         "feat",
+			Path:         workspacePath,
+			Clone:        CloneFull,
+			Repositories: []Repository{{Name: "lib", Path: source}, {Name: "app", Path: filepath.Join(root, "app")}},
+		},
+		Failed: []FailedRepository{{Name: "app", Error: "authentication failed"}},
+	}
+	if err := wm.saveIncompleteCreation(plan); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(clone, "notes.txt"), []byte("draft\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := wm.AbandonCreation(ctx, "feat", false)
+	if err == nil || !strings.Contains(err.Error(), "uncommitted changes in 'lib'") {
+		t.Fatalf("expected uncommitted changes to block abandoning, got %v", err)
+	}
+
+	testGit(t, clone, "add", "notes.txt")
+	testGit(t, clone, "commit", "--quiet", "-m", "notes")
+	err = wm.AbandonCreation(ctx, "feat", false)
+	if err == nil || !strings.Contains(err.Error(), "unpushed commits in 'lib'") {
+		t.Fatalf("expected unpushed commits to block abandoning, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(clone, "notes.txt")); err != nil {
+		t.Fatalf("the clone should be kept: %v", err)
+	}
+
+	if err := wm.AbandonCreation(ctx, "feat", true); err != nil {
+		t.Fatalf("AbandonCreation with force failed: %v", err)
+	}
+	if _, err := os.Stat(clone); !os.IsNotExist(err) {
+		t.Errorf("expected the clone to be removed, got %v", err)
+	}
+	if _, err := wm.LoadIncompleteCreation("feat"); err == nil {
+		t.Error("expected the plan to be removed")
+	}
+}
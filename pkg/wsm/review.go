@@ -0,0 +1,256 @@
+package wsm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/pkg/errors"
+)
+
+// Review verdicts of a hunk
+const (
+	ReviewApproved = "approved"
+	ReviewFlagged  = "flagged"
+)
+
+// ReviewHunk is one hunk of the workspace diff
+type ReviewHunk struct {
+	Repository string `json:"repository"`
+	File       string `json:"file"`
+	// Header is the @@ line of the hunk, or a summary for binary and mode-only changes
+	Header string   `json:"header"`
+	Lines  []string `json:"lines"`
+}
+
+// ID identifies a hunk by its content, so review marks survive rebases and new hunks elsewhere,
+// but not changes to the hunk itself
+func (h ReviewHunk) ID() string {
+	sum := sha256.New()
+	fmt.Fprintf(sum, "%s\x00%s\x00", h.Repository, h.File)
+	if len(h.Lines) == 0 {
+		fmt.Fprintf(sum, "%s\n", h.Header)
+	}
+	for _, line := range h.Lines {
+		fmt.Fprintf(sum, "%s\n", line)
+	}
+	return hex.EncodeToString(sum.Sum(nil))[:16]
+}
+
+// ReviewMark is the verdict and comment given to a hunk
+type ReviewMark struct {
+	Verdict string `json:"verdict,omitempty"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// IsEmpty reports whether the hunk has neither a verdict nor a comment
+func (m ReviewMark) IsEmpty() bool {
+	return m.Verdict == "" && m.Comment == ""
+}
+
+// Review is the state of a self-review of a workspace, stored in .wsm/review.json
+type Review struct {
+	Workspace string                `json:"workspace"`
+	Updated   time.Time             `json:"updated"`
+	Marks     map[string]ReviewMark `json:"marks"`
+}
+
+func reviewPath(workspace *Workspace) string {
+	return filepath.Join(workspace.Path, ".wsm", "review.json")
+}
+
+// LoadReview reads the review state of the workspace, returning an empty review when none exists
+func LoadReview(workspace *Workspace) (*Review, error) {
+	review := &Review{Workspace: workspace.Name, Marks: map[string]ReviewMark{}}
+	data, err := os.ReadFile(reviewPath(workspace))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return review, nil
+		}
+		return nil, errors.Wrap(err, "failed to read review")
+	}
+	if err := json.Unmarshal(data, review); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", reviewPath(workspace))
+	}
+	if review.Marks == nil {
+		review.Marks = map[string]ReviewMark{}
+	}
+	return review, nil
+}
+
+// SaveReview writes the review state, dropping marks of hunks that no longer exist
+func SaveReview(workspace *Workspace, review *Review, hunks []ReviewHunk) error {
+	current := map[string]bool{}
+	for _, hunk := range hunks {
+		current[hunk.ID()] = true
+	}
+	for id, mark := range review.Marks {
+		if !current[id] || mark.IsEmpty() {
+			delete(review.Marks, id)
+		}
+	}
+	review.Updated = time.Now()
+
+	data, err := json.MarshalIndent(review, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to encode review")
+	}
+	if err := os.MkdirAll(filepath.Dir(reviewPath(workspace)), 0755); err != nil {
+		return errors.Wrap(err, "failed to create .wsm directory")
+	}
+	return errors.Wrap(os.WriteFile(reviewPath(workspace), data, 0644), "failed to write review")
+}
+
+// CollectReviewHunks splits the changes of every repository since its branch point into hunks.
+// Uncommitted changes of tracked files are included unless committedOnly is set.
+func CollectReviewHunks(ctx context.Context, workspace *Workspace, repoFilter []string, committedOnly bool) ([]ReviewHunk, error) {
+	var hunks []ReviewHunk
+	for _, repo := range workspace.Repositories {
+		if len(repoFilter) > 0 && !slices.Contains(repoFilter, repo.Name) {
+			continue
+		}
+		branchPoint, _, err := repositoryBranchPoint(ctx, workspace, repo.Name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to determine branch point of '%s'", repo.Name)
+		}
+		args := []string{"diff", "--no-color", "--no-ext-diff", branchPoint}
+		if committedOnly {
+			args = append(args, "HEAD")
+		}
+		diff, err := runGitOutput(ctx, filepath.Join(workspace.Path, repo.Name), args...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to diff '%s'", repo.Name)
+		}
+		hunks = append(hunks, ParseDiffHunks(repo.Name, diff)...)
+	}
+	return hunks, nil
+}
+
+// ParseDiffHunks splits a unified git diff into hunks. Files without textual hunks (binary
+// files, renames and mode changes) become one hunk whose header describes the change.
+func ParseDiffHunks(repository, diff string) []ReviewHunk {
+	var hunks []ReviewHunk
+	var file string
+	var current *ReviewHunk
+	var fileSummary []string
+
+	flushFile := func() {
+		if file != "" && len(fileSummary) > 0 {
+			hunks = append(hunks, ReviewHunk{Repository: repository, File: file, Header: strings.Join(fileSummary, ", ")})
+		}
+		fileSummary = nil
+	}
+	flushHunk := func() {
+		if current != nil {
+			hunks = append(hunks, *current)
+			current = nil
+		}
+	}
+
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flushHunk()
+			flushFile()
+			file = output.GitDiffPath(line)
+		case current == nil && strings.HasPrefix(line, "+++ "):
+			if path := strings.TrimPrefix(line, "+++ "); path != "/dev/null" {
+				file = strings.TrimPrefix(path, "b/")
+			}
+		case current == nil && strings.HasPrefix(line, "--- "):
+		case strings.HasPrefix(line, "@@"):
+			flushHunk()
+			fileSummary = nil
+			current = &ReviewHunk{Repository: repository, File: file, Header: line}
+		case current != nil && line != "" && strings.ContainsRune(" +-\\", rune(line[0])):
+			current.Lines = append(current.Lines, line)
+		case current == nil && (strings.HasPrefix(line, "Binary files") || strings.HasPrefix(line, "rename ") ||
+			strings.HasPrefix(line, "new file mode") || strings.HasPrefix(line, "deleted file mode") ||
+			strings.HasPrefix(line, "old mode") || strings.HasPrefix(line, "new mode")):
+			fileSummary = append(fileSummary, line)
+		}
+	}
+	flushHunk()
+	flushFile()
+	return hunks
+}
+
+// ReviewSummary counts the hunks per verdict
+type ReviewSummary struct {
+	Total, Approved, Flagged, Commented, Unreviewed int
+}
+
+// Summarize counts the verdicts and comments of the given hunks
+func (r *Review) Summarize(hunks []ReviewHunk) ReviewSummary {
+	summary := ReviewSummary{Total: len(hunks)}
+	for _, hunk := range hunks {
+		mark := r.Marks[hunk.ID()]
+		switch mark.Verdict {
+		case ReviewApproved:
+			summary.Approved++
+		case ReviewFlagged:
+			summary.Flagged++
+		}
+		if mark.Comment != "" {
+			summary.Commented++
+		}
+		if mark.IsEmpty() {
+			summary.Unreviewed++
+		}
+	}
+	return summary
+}
+
+// RenderReviewMarkdown exports the review notes: every hunk per repository with its verdict, and
+// the comments and diff of flagged and commented hunks
+func RenderReviewMarkdown(workspace *Workspace, review *Review, hunks []ReviewHunk) string {
+	var b strings.Builder
+	summary := review.Summarize(hunks)
+	fmt.Fprintf(&b, "# Review: %s\n\n", workspace.Name)
+	if workspace.Branch != "" {
+		fmt.Fprintf(&b, "Branch `%s`, ", workspace.Branch)
+	}
+	fmt.Fprintf(&b, "%d hunks: %d approved, %d flagged, %d commented, %d not reviewed.\n",
+		summary.Total, summary.Approved, summary.Flagged, summary.Commented, summary.Unreviewed)
+
+	repository := ""
+	for _, hunk := range hunks {
+		if hunk.Repository != repository {
+			repository = hunk.Repository
+			fmt.Fprintf(&b, "\n## %s\n\n", repository)
+		}
+		mark := review.Marks[hunk.ID()]
+		marker := "⬜"
+		switch mark.Verdict {
+		case ReviewApproved:
+			marker = "✅"
+		case ReviewFlagged:
+			marker = "🚩"
+		}
+		fmt.Fprintf(&b, "- %s `%s` `%s`\n", marker, hunk.File, hunk.Header)
+		if mark.Comment == "" && mark.Verdict != ReviewFlagged {
+			continue
+		}
+		for _, line := range strings.Split(mark.Comment, "\n") {
+			if line != "" {
+				fmt.Fprintf(&b, "  > %s\n", line)
+			}
+		}
+		if len(hunk.Lines) > 0 {
+			fmt.Fprintf(&b, "\n  ```diff\n")
+			for _, line := range hunk.Lines {
+				fmt.Fprintf(&b, "  %s\n", line)
+			}
+			fmt.Fprintf(&b, "  ```\n")
+		}
+	}
+	return b.String()
+}
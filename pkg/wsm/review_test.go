@@ -0,0 +1,97 @@
+package wsm
+
+import (
+	"strings"
+	"testing"
+)
+
+const reviewDiff = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,3 @@ package main
+ package main
+-var x = 1
++var x = 2
+@@ -10,2 +10,3 @@ func main() {
+ 	run()
++	stop()
+ }
+diff --git a/logo.png b/logo.png
+new file mode 100644
+index 0000000..3333333
+Binary files /dev/null and b/logo.png differ
+diff --git a/old.txt b/new.txt
+similarity index 100%
+rename from old.txt
+rename to new.txt
+diff --git a/gone.go b/gone.go
+deleted file mode 100644
+index 4444444..0000000
+--- a/gone.go
++++ /dev/null
+@@ -1 +0,0 @@
+-package gone
+`
+
+func TestParseDiffHunks(t *testing.T) {
+	hunks := ParseDiffHunks("api", reviewDiff)
+	want := []struct{ file, header string }{
+		{"main.go", "@@ -1,3 +1,3 @@ package main"},
+		{"main.go", "@@ -10,2 +10,3 @@ func main() {"},
+		{"logo.png", "new file mode 100644, Binary files /dev/null and b/logo.png differ"},
+		{"new.txt", "rename from old.txt, rename to new.txt"},
+		{"gone.go", "@@ -1 +0,0 @@"},
+	}
+	if len(hunks) != len(want) {
+		t.Fatalf("got %d hunks, want %d: %+v", len(hunks), len(want), hunks)
+	}
+	for i, w := range want {
+		if hunks[i].Repository != "api" || hunks[i].File != w.file || hunks[i].Header != w.header {
+			t.Errorf("hunk %d = %s %q, want %s %q", i, hunks[i].File, hunks[i].Header, w.file, w.header)
+		}
+	}
+	if got := strings.Join(hunks[0].Lines, "|"); got != " package main|-var x = 1|+var x = 2" {
+		t.Errorf("lines of the first hunk = %q", got)
+	}
+	if len(hunks[2].Lines) != 0 {
+		t.Errorf("binary hunk has lines: %q", hunks[2].Lines)
+	}
+
+	// IDs depend on the content, not on the position in the diff
+	moved := ParseDiffHunks("api", strings.Replace(reviewDiff, "@@ -10,2 +10,3 @@", "@@ -12,2 +12,3 @@", 1))
+	if moved[1].ID() != hunks[1].ID() || hunks[0].ID() == hunks[1].ID() {
+		t.Error("hunk IDs should only depend on the repository, file and lines")
+	}
+}
+
+func TestReviewSummaryAndMarkdown(t *testing.T) {
+	hunks := ParseDiffHunks("api", reviewDiff)
+	review := &Review{Marks: map[string]ReviewMark{
+		hunks[0].ID(): {Verdict: ReviewApproved},
+		hunks[1].ID(): {Verdict: ReviewFlagged, Comment: "stop() is\nnever defined"},
+		hunks[3].ID(): {Comment: "fine"},
+	}}
+	summary := review.Summarize(hunks)
+	if summary != (ReviewSummary{Total: 5, Approved: 1, Flagged: 1, Commented: 2, Unreviewed: 2}) {
+		t.Errorf("summary = %+v", summary)
+	}
+
+	markdown := RenderReviewMarkdown(&Workspace{Name: "feat", Branch: "task/feat"}, review, hunks)
+	for _, want := range []string{
+		"# Review: feat\n",
+		"Branch `task/feat`, 5 hunks: 1 approved, 1 flagged, 2 commented, 2 not reviewed.\n",
+		"\n## api\n\n",
+		"- ✅ `main.go` `@@ -1,3 +1,3 @@ package main`\n",
+		"- 🚩 `main.go` `@@ -10,2 +10,3 @@ func main() {`\n  > stop() is\n  > never defined\n\n  ```diff\n   \trun()\n  +\tstop()\n   }\n  ```\n",
+		"- ⬜ `new.txt` `rename from old.txt, rename to new.txt`\n  > fine\n",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("markdown is missing %q:\n%s", want, markdown)
+		}
+	}
+	// Approved hunks without a comment are listed without their diff
+	if strings.Contains(markdown, "+var x = 2") {
+		t.Errorf("markdown includes the diff of an approved hunk:\n%s", markdown)
+	}
+}
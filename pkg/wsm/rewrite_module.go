@@ -0,0 +1,354 @@
+package wsm
+
+import (
+	"bytes"
+	"context"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/mod/modfile"
+)
+
+// ModuleRewriteOptions describes the move of a Go module to a new module path, e.g. to a fork or
+// another organization
+type ModuleRewriteOptions struct {
+	Old string
+	New string
+	// Repositories restricts the rewrite to these repositories
+	Repositories []string
+}
+
+// Validate checks that both module paths are usable
+func (o ModuleRewriteOptions) Validate() error {
+	for _, path := range []string{o.Old, o.New} {
+		if path == "" || strings.ContainsAny(path, " \t\"'`\\") || strings.HasPrefix(path, "/") || strings.HasSuffix(path, "/") {
+			return errors.Errorf("invalid module path '%s'", path)
+		}
+	}
+	if o.Old == o.New {
+		return errors.New("the new module path is the same as the old one")
+	}
+	return nil
+}
+
+// ModuleRewriteFile is a file a module rewrite changes
+type ModuleRewriteFile struct {
+	// Repository is empty for the go.work of the workspace
+	Repository string `json:"repository,omitempty"`
+	Path       string `json:"path"`
+	// Changes is the number of module paths rewritten in the file
+	Changes int    `json:"changes"`
+	Content []byte `json:"-"`
+}
+
+// ModuleRewritePlan is the outcome of a module rewrite before it is written
+type ModuleRewritePlan struct {
+	Options ModuleRewriteOptions
+	Modules []GoModule
+	Files   []ModuleRewriteFile
+	// Skipped are Go, go.mod and go.work files that could not be parsed, with the reason
+	Skipped map[string]string
+}
+
+// Changes returns the number of module paths the rewrite changes
+func (p *ModuleRewritePlan) Changes() int {
+	total := 0
+	for _, file := range p.Files {
+		total += file.Changes
+	}
+	return total
+}
+
+// rewriteModulePath returns path moved from the old to the new module, and whether it belongs to
+// the old module at all
+func rewriteModulePath(path, oldModule, newModule string) (string, bool) {
+	if path == oldModule {
+		return newModule, true
+	}
+	if rest, ok := strings.CutPrefix(path, oldModule+"/"); ok {
+		return newModule + "/" + rest, true
+	}
+	return path, false
+}
+
+// rewriteModFile rewrites the module paths of a go.mod file: the module line and the require,
+// replace, exclude and tool directives. Comments are kept by the modfile editor.
+func rewriteModFile(path string, content []byte, oldModule, newModule string) ([]byte, int, error) {
+	file, err := modfile.Parse(path, content, nil)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "failed to parse")
+	}
+	changes := 0
+	if file.Module != nil {
+		if rewritten, ok := rewriteModulePath(file.Module.Mod.Path, oldModule, newModule); ok {
+			if err := file.AddModuleStmt(rewritten); err != nil {
+				return nil, 0, err
+			}
+			changes++
+		}
+	}
+	// Dropping a directive clears it, so each one is copied first; the comments of a directive
+	// move to the one replacing it
+	for _, require := range slices.Clone(file.Require) {
+		require := *require
+		if rewritten, ok := rewriteModulePath(require.Mod.Path, oldModule, newModule); ok {
+			comments := require.Syntax.Comments
+			if err := file.DropRequire(require.Mod.Path); err != nil {
+				return nil, 0, err
+			}
+			file.AddNewRequire(rewritten, require.Mod.Version, require.Indirect)
+			file.Require[len(file.Require)-1].Syntax.Comments = comments
+			changes++
+		}
+	}
+	for _, exclude := range slices.Clone(file.Exclude) {
+		exclude := *exclude
+		if rewritten, ok := rewriteModulePath(exclude.Mod.Path, oldModule, newModule); ok {
+			comments := exclude.Syntax.Comments
+			if err := file.DropExclude(exclude.Mod.Path, exclude.Mod.Version); err != nil {
+				return nil, 0, err
+			}
+			if err := file.AddExclude(rewritten, exclude.Mod.Version); err != nil {
+				return nil, 0, err
+			}
+			file.Exclude[len(file.Exclude)-1].Syntax.Comments = comments
+			changes++
+		}
+	}
+	for _, tool := range slices.Clone(file.Tool) {
+		tool := *tool
+		if rewritten, ok := rewriteModulePath(tool.Path, oldModule, newModule); ok {
+			if err := file.DropTool(tool.Path); err != nil {
+				return nil, 0, err
+			}
+			if err := file.AddTool(rewritten); err != nil {
+				return nil, 0, err
+			}
+			changes++
+		}
+	}
+	replaced, err := rewriteReplaces(file.Replace, func() *modfile.Replace { return file.Replace[len(file.Replace)-1] }, file.DropReplace, file.AddReplace, oldModule, newModule)
+	if err != nil {
+		return nil, 0, err
+	}
+	changes += replaced
+	if changes == 0 {
+		return nil, 0, nil
+	}
+	file.Cleanup()
+	formatted, err := file.Format()
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "failed to format")
+	}
+	return formatted, changes, nil
+}
+
+// rewriteWorkFile rewrites the module paths of the replace directives of a go.work file
+func rewriteWorkFile(path string, content []byte, oldModule, newModule string) ([]byte, int, error) {
+	file, err := modfile.ParseWork(path, content, nil)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "failed to parse")
+	}
+	changes, err := rewriteReplaces(file.Replace, func() *modfile.Replace { return file.Replace[len(file.Replace)-1] }, file.DropReplace, file.AddReplace, oldModule, newModule)
+	if err != nil || changes == 0 {
+		return nil, 0, err
+	}
+	file.Cleanup()
+	return modfile.Format(file.Syntax), changes, nil
+}
+
+// rewriteReplaces rewrites both sides of replace directives with the drop and add functions of a
+// go.mod or go.work file, and last returning the directive added last; local directories on the
+// right side are left alone
+func rewriteReplaces(replaces []*modfile.Replace, last func() *modfile.Replace, drop func(path, version string) error, add func(oldPath, oldVersion, newPath, newVersion string) error, oldModule, newModule string) (int, error) {
+	changes := 0
+	for _, replace := range slices.Clone(replaces) {
+		replace := *replace
+		from, fromChanged := rewriteModulePath(replace.Old.Path, oldModule, newModule)
+		to, toChanged := replace.New.Path, false
+		if replace.New.Version != "" {
+			to, toChanged = rewriteModulePath(replace.New.Path, oldModule, newModule)
+		}
+		if !fromChanged && !toChanged {
+			continue
+		}
+		if err := drop(replace.Old.Path, replace.Old.Version); err != nil {
+			return 0, err
+		}
+		if err := add(from, replace.Old.Version, to, replace.New.Version); err != nil {
+			return 0, err
+		}
+		last().Syntax.Comments = replace.Syntax.Comments
+		if fromChanged {
+			changes++
+		}
+		if toChanged {
+			changes++
+		}
+	}
+	return changes, nil
+}
+
+// rewriteGoSum drops the checksums of the old module from a go.sum file: they do not match the
+// module under its new path, whose checksums 'go mod download' adds
+func rewriteGoSum(content []byte, oldModule string) ([]byte, int) {
+	lines := strings.SplitAfter(string(content), "\n")
+	kept := lines[:0]
+	changes := 0
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) > 0 && (fields[0] == oldModule || strings.HasPrefix(fields[0], oldModule+"/")) {
+			changes++
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return []byte(strings.Join(kept, "")), changes
+}
+
+// rewriteImports rewrites the imports of the old module in a Go file and gofmts it, so that the
+// import blocks are sorted again
+func rewriteImports(path string, content []byte, oldModule, newModule string) ([]byte, int, error) {
+	if !bytes.Contains(content, []byte(oldModule)) {
+		return nil, 0, nil
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, content, parser.ImportsOnly|parser.ParseComments)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "failed to parse")
+	}
+	var edits []textEdit
+	for _, spec := range file.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		if rewritten, ok := rewriteModulePath(importPath, oldModule, newModule); ok {
+			edits = append(edits, textEdit{fset.Position(spec.Path.Pos()).Offset, fset.Position(spec.Path.End()).Offset, strconv.Quote(rewritten)})
+		}
+	}
+	if len(edits) == 0 {
+		return nil, 0, nil
+	}
+
+	rewritten, err := format.Source(applyEdits(content, edits))
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "failed to format")
+	}
+	return rewritten, len(edits), nil
+}
+
+// PlanModuleRewrite computes the changes that move the workspace from the old to the new module
+// path: the module line of its go.mod, the require and replace directives of the other modules,
+// their go.sum checksums, the imports of every Go file, and the replace directives of go.work. Packages of nested modules
+// (old/sub) move along. Nothing is written.
+func PlanModuleRewrite(workspace *Workspace, options ModuleRewriteOptions) (*ModuleRewritePlan, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+	modules, err := WorkspaceGoModules(workspace, options.Repositories)
+	if err != nil {
+		return nil, err
+	}
+	if len(modules) == 0 {
+		return nil, errors.Errorf("workspace '%s' has no Go modules", workspace.Name)
+	}
+
+	plan := &ModuleRewritePlan{Options: options, Modules: modules, Skipped: map[string]string{}}
+	for _, repo := range workspace.Repositories {
+		if len(options.Repositories) > 0 && !slices.Contains(options.Repositories, repo.Name) {
+			continue
+		}
+		root := filepath.Join(workspace.Path, repo.Name)
+		var paths []string
+		if err := walkGoTree(root, func(path string, entry fs.DirEntry) {
+			if entry.Name() == "go.mod" || entry.Name() == "go.sum" || strings.HasSuffix(path, ".go") {
+				paths = append(paths, path)
+			}
+		}); err != nil {
+			return nil, errors.Wrapf(err, "failed to scan %s", root)
+		}
+
+		for _, path := range paths {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to read %s", path)
+			}
+			var rewritten []byte
+			var changes int
+			switch filepath.Base(path) {
+			case "go.mod":
+				rewritten, changes, err = rewriteModFile(path, content, options.Old, options.New)
+			case "go.sum":
+				rewritten, changes = rewriteGoSum(content, options.Old)
+			default:
+				rewritten, changes, err = rewriteImports(path, content, options.Old, options.New)
+			}
+			if err != nil {
+				plan.Skipped[path] = err.Error()
+				continue
+			}
+			if changes > 0 {
+				plan.Files = append(plan.Files, ModuleRewriteFile{Repository: repo.Name, Path: path, Changes: changes, Content: rewritten})
+			}
+		}
+	}
+
+	goWork := filepath.Join(workspace.Path, "go.work")
+	if content, err := os.ReadFile(goWork); err == nil {
+		rewritten, changes, err := rewriteWorkFile(goWork, content, options.Old, options.New)
+		if err != nil {
+			plan.Skipped[goWork] = err.Error()
+		} else if changes > 0 {
+			plan.Files = append(plan.Files, ModuleRewriteFile{Path: goWork, Changes: changes, Content: rewritten})
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, errors.Wrapf(err, "failed to read %s", goWork)
+	}
+	return plan, nil
+}
+
+// WriteModuleRewrite writes the rewritten files
+func WriteModuleRewrite(workspace *Workspace, plan *ModuleRewritePlan) error {
+	start := time.Now()
+	if err := workspace.RequireWorktrees("rewriting module paths"); err != nil {
+		return err
+	}
+	for _, file := range plan.Files {
+		info, err := os.Stat(file.Path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to stat %s", file.Path)
+		}
+		if err := os.WriteFile(file.Path, file.Content, info.Mode().Perm()); err != nil {
+			return errors.Wrapf(err, "failed to write %s", file.Path)
+		}
+	}
+	RecordOperation("rewrite-module", workspace.Name, start, map[string]string{
+		"old":   plan.Options.Old,
+		"new":   plan.Options.New,
+		"files": strconv.Itoa(len(plan.Files)),
+	})
+	return nil
+}
+
+// GoModDownload runs 'go mod download' in a module directory, which records the checksums of the
+// modules it requires in go.sum, and returns its output when it fails
+func GoModDownload(ctx context.Context, dir string) (string, error) {
+	cmd := exec.CommandContext(ctx, "go", "mod", "download")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return strings.TrimSpace(string(out)), errors.Wrapf(err, "go mod download failed in %s", dir)
+	}
+	return "", nil
+}
@@ -0,0 +1,190 @@
+package wsm
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRewriteModFile(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		want        string
+		wantChanges int
+	}{
+		{
+			name:        "module line",
+			content:     "module example.com/old\n\ngo 1.22\n",
+			want:        "module example.com/new\n\ngo 1.22\n",
+			wantChanges: 1,
+		},
+		{
+			name: "require block keeps indirect and comments",
+			content: `module example.com/app
+
+go 1.22
+
+require (
+	example.com/old v1.2.0 // pinned for the fix
+	example.com/old/sub v0.1.0 // indirect
+	example.com/other v1.0.0
+)
+`,
+			want: `module example.com/app
+
+go 1.22
+
+require (
+	example.com/other v1.0.0
+	example.com/new v1.2.0 // pinned for the fix
+	example.com/new/sub v0.1.0 // indirect
+)
+`,
+			wantChanges: 2,
+		},
+		{
+			name:        "similar prefix is left alone",
+			content:     "module example.com/app\n\nrequire example.com/older v1.0.0\n",
+			wantChanges: 0,
+		},
+		{
+			name: "replace and exclude",
+			content: `module example.com/app
+
+require example.com/lib v1.0.0
+
+replace example.com/lib => example.com/old v1.1.0
+
+replace example.com/old => ../old
+
+exclude example.com/old v1.0.1
+`,
+			want: `module example.com/app
+
+require example.com/lib v1.0.0
+
+exclude example.com/new v1.0.1
+
+replace example.com/lib => example.com/new v1.1.0
+
+replace example.com/new => ../old
+`,
+			wantChanges: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changes, err := rewriteModFile("go.mod", []byte(tt.content), "example.com/old", "example.com/new")
+			if err != nil {
+				t.Fatalf("rewriteModFile failed: %v", err)
+			}
+			if changes != tt.wantChanges {
+				t.Errorf("changes = %d, want %d", changes, tt.wantChanges)
+			}
+			if tt.wantChanges > 0 && string(got) != tt.want {
+				t.Errorf("rewriteModFile:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+
+	if _, _, err := rewriteModFile("go.mod", []byte("module\nrequire (\n"), "example.com/old", "example.com/new"); err == nil {
+		t.Error("expected an error for an invalid go.mod")
+	}
+}
+
+func TestRewriteWorkFile(t *testing.T) {
+	content := "go 1.22\n\nuse ./old\n\nreplace example.com/old v1.0.0 => ./old\n"
+	got, changes, err := rewriteWorkFile("go.work", []byte(content), "example.com/old", "example.com/new")
+	if err != nil {
+		t.Fatalf("rewriteWorkFile failed: %v", err)
+	}
+	want := "go 1.22\n\nuse ./old\n\nreplace example.com/new v1.0.0 => ./old\n"
+	if changes != 1 || string(got) != want {
+		t.Errorf("rewriteWorkFile = %d change(s):\n%s\nwant 1:\n%s", changes, got, want)
+	}
+}
+
+func TestRewriteGoSum(t *testing.T) {
+	content := `example.com/old v1.0.0 h1:aaa=
+example.com/old v1.0.0/go.mod h1:bbb=
+example.com/old/sub v0.1.0 h1:ccc=
+example.com/older v1.0.0 h1:ddd=
+`
+	got, changes := rewriteGoSum([]byte(content), "example.com/old")
+	if want := "example.com/older v1.0.0 h1:ddd=\n"; changes != 3 || string(got) != want {
+		t.Errorf("rewriteGoSum = %d change(s): %q, want 3: %q", changes, got, want)
+	}
+}
+
+func TestRewriteImports(t *testing.T) {
+	content := `package main
+
+import (
+	"fmt"
+	"example.com/old/pkg"
+	other "example.com/older"
+)
+
+func main() { fmt.Println(pkg.X, other.Y) }
+`
+	got, changes, err := rewriteImports("main.go", []byte(content), "example.com/old", "example.com/new")
+	if err != nil {
+		t.Fatalf("rewriteImports failed: %v", err)
+	}
+	// The file was not gofmt'ed before and is now, with the imports sorted
+	want := `package main
+
+import (
+	"example.com/new/pkg"
+	other "example.com/older"
+	"fmt"
+)
+
+func main() { fmt.Println(pkg.X, other.Y) }
+`
+	if changes != 1 || string(got) != want {
+		t.Errorf("rewriteImports = %d change(s):\n%s\nwant 1:\n%s", changes, got, want)
+	}
+}
+
+func TestPlanModuleRewrite(t *testing.T) {
+	root := t.TempDir()
+	writeGoFiles(t, root, map[string]string{
+		"old/go.mod":       "module example.com/old\n\ngo 1.22\n",
+		"old/lib.go":       "package old\n",
+		"app/go.mod":       "module example.com/app\n\ngo 1.22\n\nrequire example.com/old v1.0.0\n",
+		"app/go.sum":       "example.com/old v1.0.0 h1:aaa=\nexample.com/other v1.0.0 h1:bbb=\n",
+		"app/main.go":      "package main\n\nimport _ \"example.com/old\"\n",
+		"app/broken.go":    "package main\n\nimport \"example.com/old\n",
+		"app/untouched.go": "package main\n",
+		"go.work":          "go 1.22\n\nuse (\n\t./app\n\t./old\n)\n\nreplace example.com/old => ./old\n",
+	})
+	workspace := &Workspace{Name: "ws", Path: root, Repositories: []Repository{{Name: "old"}, {Name: "app"}}}
+
+	plan, err := PlanModuleRewrite(workspace, ModuleRewriteOptions{Old: "example.com/old", New: "example.com/new"})
+	if err != nil {
+		t.Fatalf("PlanModuleRewrite failed: %v", err)
+	}
+	changed := map[string]int{}
+	for _, file := range plan.Files {
+		rel, _ := filepath.Rel(root, file.Path)
+		changed[filepath.ToSlash(rel)] = file.Changes
+	}
+	want := map[string]int{"old/go.mod": 1, "app/go.mod": 1, "app/go.sum": 1, "app/main.go": 1, "go.work": 1}
+	if len(changed) != len(want) {
+		t.Errorf("changed files = %v, want %v", changed, want)
+	}
+	for name, changes := range want {
+		if changed[name] != changes {
+			t.Errorf("%s: %d change(s), want %d", name, changed[name], changes)
+		}
+	}
+	if reason := plan.Skipped[filepath.Join(root, "app", "broken.go")]; !strings.Contains(reason, "failed to parse") {
+		t.Errorf("broken.go should be skipped, got %v", plan.Skipped)
+	}
+
+	if _, err := PlanModuleRewrite(workspace, ModuleRewriteOptions{Old: "example.com/old", New: "example.com/old"}); err == nil {
+		t.Error("expected an error for the same module path")
+	}
+}
@@ -0,0 +1,110 @@
+package wsm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// CommandRunner runs external programs such as git. WorkspaceManager, RepositoryDiscoverer and
+// StatusChecker run every command through their Runner, so tests can replace git with a fake and
+// other backends (e.g. remote execution) can be plugged in.
+type CommandRunner interface {
+	// Output runs name in dir and returns its standard output. When the command fails, the
+	// returned error carries its standard error (see *exec.ExitError).
+	Output(ctx context.Context, dir, name string, args ...string) ([]byte, error)
+	// CombinedOutput runs name in dir and returns its standard output and error interleaved
+	CombinedOutput(ctx context.Context, dir, name string, args ...string) ([]byte, error)
+}
+
+// ExecRunner runs commands as local processes. A git command whose context is done is
+// interrupted first, and killed if it does not exit shortly after; other programs are killed.
+type ExecRunner struct{}
+
+// command creates the process running name, with the graceful cancellation of gitCommand for git
+func (ExecRunner) command(ctx context.Context, dir, name string, args ...string) *exec.Cmd {
+	if name == "git" {
+		return gitCommand(ctx, dir, name, args...)
+	}
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	return cmd
+}
+
+// Output implements CommandRunner
+func (r ExecRunner) Output(ctx context.Context, dir, name string, args ...string) ([]byte, error) {
+	return r.command(ctx, dir, name, args...).Output()
+}
+
+// CombinedOutput implements CommandRunner
+func (r ExecRunner) CombinedOutput(ctx context.Context, dir, name string, args ...string) ([]byte, error) {
+	return r.command(ctx, dir, name, args...).CombinedOutput()
+}
+
+// RunWithProgress implements ProgressRunner
+func (r ExecRunner) RunWithProgress(ctx context.Context, dir string, onLine func(line string) bool, name string, args ...string) ([]byte, error) {
+	cmd := r.command(ctx, dir, name, args...)
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	var kept bytes.Buffer
+	scanner := bufio.NewScanner(stderr)
+	scanner.Split(scanProgressLines)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || onLine(line) {
+			continue
+		}
+		kept.WriteString(line)
+		kept.WriteByte('\n')
+	}
+	err = cmd.Wait()
+
+	return append(stdout.Bytes(), kept.Bytes()...), err
+}
+
+// scanProgressLines splits at carriage returns as well as newlines: progress is redrawn in place
+func scanProgressLines(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// defaultRunner is used where no runner was injected
+var defaultRunner CommandRunner = ExecRunner{}
+
+// runnerOrDefault returns runner, or the local process runner when it is nil
+func runnerOrDefault(runner CommandRunner) CommandRunner {
+	if runner == nil {
+		return defaultRunner
+	}
+	return runner
+}
+
+// gitOutput runs git through runner and returns its trimmed output; errors include git's stderr
+func gitOutput(ctx context.Context, runner CommandRunner, dir string, args ...string) (string, error) {
+	out, err := runnerOrDefault(runner).Output(ctx, dir, "git", args...)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", errors.Wrapf(err, "git %s: %s", strings.Join(args, " "), strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", errors.Wrapf(err, "git %s", strings.Join(args, " "))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,178 @@
+package wsm
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/pkg/errors"
+)
+
+// ScaffoldConfig controls the files written to the workspace root during creation
+type ScaffoldConfig struct {
+	// Gitignore defaults to true when unset
+	Gitignore    *bool `json:"gitignore,omitempty" yaml:"gitignore,omitempty"`
+	EditorConfig bool  `json:"editorconfig" yaml:"editorconfig"`
+}
+
+const defaultWorkspaceGitignore = `# Workspace files generated by workspace-manager
+go.work.sum
+.wsm/
+
+# Editors
+.idea/
+.vscode/
+*.swp
+*~
+.DS_Store
+`
+
+const defaultWorkspaceEditorConfig = `root = true
+
+[*]
+charset = utf-8
+end_of_line = lf
+insert_final_newline = true
+trim_trailing_whitespace = true
+
+[*.go]
+indent_style = tab
+
+[*.{js,ts,json,yml,yaml}]
+indent_style = space
+indent_size = 2
+
+[Makefile]
+indent_style = tab
+`
+
+// scaffoldFile is a workspace-root file with its template name and built-in default
+type scaffoldFile struct {
+	target   string
+	template string
+	content  string
+}
+
+// scaffoldWorkspaceFiles writes the workspace-root .gitignore and .editorconfig and renders the
+// .wsm templates. Templates named "gitignore" and "editorconfig" in the template directory override
+// the built-in defaults.
+func (wm *WorkspaceManager) scaffoldWorkspaceFiles(workspace *Workspace) error {
+	data := NewAgentTemplateData(workspace)
+	for _, file := range wm.scaffoldFiles() {
+		content, err := wm.renderScaffoldFile(file, data)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(workspace.Path, file.target)
+		output.LogInfo(
+			fmt.Sprintf("Writing %s", target),
+			"Writing workspace scaffold file",
+			"target", target,
+		)
+		if err := os.WriteFile(target, content, 0644); err != nil {
+			return errors.Wrapf(err, "failed to write %s", target)
+		}
+	}
+
+	return renderWsmTemplates(filepath.Join(wm.templatesDir(), "wsm"), workspace, data)
+}
+
+// scaffoldFiles returns the workspace-root files enabled by the scaffold configuration
+func (wm *WorkspaceManager) scaffoldFiles() []scaffoldFile {
+	var files []scaffoldFile
+	if wm.config.Scaffold.Gitignore == nil || *wm.config.Scaffold.Gitignore {
+		files = append(files, scaffoldFile{target: ".gitignore", template: "gitignore", content: defaultWorkspaceGitignore})
+	}
+	if wm.config.Scaffold.EditorConfig {
+		files = append(files, scaffoldFile{target: ".editorconfig", template: "editorconfig", content: defaultWorkspaceEditorConfig})
+	}
+	return files
+}
+
+// renderScaffoldFile renders the template overriding the file, or returns its built-in default
+func (wm *WorkspaceManager) renderScaffoldFile(file scaffoldFile, data *AgentTemplateData) ([]byte, error) {
+	templatePath := filepath.Join(wm.templatesDir(), file.template)
+	custom, err := os.ReadFile(templatePath)
+	if os.IsNotExist(err) {
+		return []byte(file.content), nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read template: %s", templatePath)
+	}
+	return RenderAgentTemplate(file.template, custom, data), nil
+}
+
+// unchangedScaffoldFiles returns the scaffold files of the workspace root that still have the
+// content they were written with, so that files the user edited are kept on cleanup
+func (wm *WorkspaceManager) unchangedScaffoldFiles(workspace *Workspace) []string {
+	var unchanged []string
+	data := NewAgentTemplateData(workspace)
+	for _, file := range wm.scaffoldFiles() {
+		content, err := wm.fs().ReadFile(filepath.Join(workspace.Path, file.target))
+		if err != nil {
+			continue
+		}
+		if want, err := wm.renderScaffoldFile(file, data); err == nil && bytes.Equal(content, want) {
+			unchanged = append(unchanged, file.target)
+		} else {
+			output.LogInfo(
+				fmt.Sprintf("Keeping %s: it was changed after the workspace was created", file.target),
+				"Keeping modified workspace scaffold file",
+				"file", file.target,
+			)
+		}
+	}
+	return unchanged
+}
+
+// templatesDir returns the configured template_dir, or <config-dir>/templates when it is unset
+func (wm *WorkspaceManager) templatesDir() string {
+	if wm.config.TemplateDir != "" {
+		return wm.config.TemplateDir
+	}
+	return filepath.Join(filepath.Dir(wm.config.RegistryPath), "templates")
+}
+
+// renderWsmTemplates renders every file below <template-dir>/wsm into the workspace .wsm
+// directory (setup.sh, setup.d/*, env, tmux.conf, ...), keeping file modes so scripts stay executable
+func renderWsmTemplates(templatesDir string, workspace *Workspace, data *AgentTemplateData) error {
+	if _, err := os.Stat(templatesDir); os.IsNotExist(err) {
+		return nil
+	}
+
+	return filepath.WalkDir(templatesDir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(templatesDir, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(workspace.Path, ".wsm", rel)
+		if entry.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read template: %s", path)
+		}
+
+		output.LogInfo(
+			fmt.Sprintf("Writing %s", target),
+			"Writing workspace template file",
+			"target", target,
+		)
+		if err := os.WriteFile(target, RenderAgentTemplate(rel, content, data), info.Mode().Perm()); err != nil {
+			return errors.Wrapf(err, "failed to write %s", target)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,39 @@
+package wsm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCleanupKeepsEditedFiles(t *testing.T) {
+	wm := newTestWorkspaceManager(t)
+	wm.config.Scaffold.EditorConfig = true
+	root := t.TempDir()
+	workspace := &Workspace{Name: "ws", Path: root, GoWorkspace: true, BuildManifests: []string{"pnpm", "cargo"}}
+	if err := wm.scaffoldWorkspaceFiles(workspace); err != nil {
+		t.Fatalf("scaffoldWorkspaceFiles failed: %v", err)
+	}
+	writeGoFiles(t, root, map[string]string{
+		".gitignore":          defaultWorkspaceGitignore + "secrets/\n",
+		"go.work":             "go 1.22\n",
+		"pnpm-workspace.yaml": pnpmWorkspaceContent(workspace, []string{"web"}),
+		"Cargo.toml":          "[workspace]\nmembers = [\"mine\"]\n",
+	})
+
+	if err := wm.cleanupWorkspaceSpecificFiles(workspace); err != nil {
+		t.Fatalf("cleanupWorkspaceSpecificFiles failed: %v", err)
+	}
+	for name, kept := range map[string]bool{
+		".gitignore":          true,
+		".editorconfig":       false,
+		"go.work":             false,
+		"pnpm-workspace.yaml": false,
+		"Cargo.toml":          true,
+	} {
+		_, err := os.Stat(filepath.Join(root, name))
+		if exists := err == nil; exists != kept {
+			t.Errorf("%s exists = %v, want %v", name, exists, kept)
+		}
+	}
+}
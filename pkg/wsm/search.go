@@ -0,0 +1,262 @@
+package wsm
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Searchable fields of a registry repository, usable as query qualifiers (e.g. "tag:go")
+const (
+	SearchFieldName        = "name"
+	SearchFieldAlias       = "alias"
+	SearchFieldTag         = "tag"
+	SearchFieldRemote      = "remote"
+	SearchFieldPath        = "path"
+	SearchFieldDescription = "description"
+)
+
+// searchWeights scores a token match per field: exact token match, then prefix match
+var searchWeights = map[string][2]int{
+	SearchFieldName:        {60, 45},
+	SearchFieldAlias:       {60, 40},
+	SearchFieldTag:         {40, 30},
+	SearchFieldRemote:      {25, 20},
+	SearchFieldPath:        {15, 10},
+	SearchFieldDescription: {10, 8},
+}
+
+// Scores of matches on the whole repository name
+const (
+	searchScoreExactName  = 100
+	searchScoreExactAlias = 90
+	searchScoreNamePrefix = 70
+	searchScoreNameInfix  = 50
+	searchScoreNameSubseq = 5
+)
+
+const (
+	// searchPathComponents is the number of trailing path components that are indexed
+	searchPathComponents = 3
+	searchDefaultLimit   = 20
+)
+
+var searchTokenSplit = regexp.MustCompile(`[^\pL\pN]+`)
+
+// SearchMatch is a repository matching a registry search, with the fields the query matched
+type SearchMatch struct {
+	Repository Repository `json:"repository"`
+	Score      int        `json:"score"`
+	Fields     []string   `json:"fields"`
+}
+
+type searchPosting struct {
+	repo  int
+	field string
+}
+
+// RegistryIndex is an inverted index over the name, aliases, tags (categories), remote, path and
+// description of the registry repositories, answering ranked searches without rescanning them
+type RegistryIndex struct {
+	repositories []Repository
+	names        []string
+	aliases      [][]string
+	// tokens are the sorted distinct tokens of all fields, for prefix lookups
+	tokens   []string
+	postings map[string][]searchPosting
+}
+
+// NewRegistryIndex indexes the repositories and the aliases pointing at them
+func NewRegistryIndex(repositories []Repository, aliases map[string]string) *RegistryIndex {
+	index := &RegistryIndex{
+		repositories: repositories,
+		names:        make([]string, len(repositories)),
+		aliases:      make([][]string, len(repositories)),
+		postings:     map[string][]searchPosting{},
+	}
+
+	byName := map[string]int{}
+	for i, repo := range repositories {
+		index.names[i] = strings.ToLower(repo.Name)
+		byName[repo.Name] = i
+	}
+	for _, alias := range sortedKeys(aliases) {
+		if i, ok := byName[aliases[alias]]; ok {
+			index.aliases[i] = append(index.aliases[i], strings.ToLower(alias))
+		}
+	}
+
+	for i, repo := range repositories {
+		index.add(i, SearchFieldName, repo.Name)
+		for _, alias := range index.aliases[i] {
+			index.add(i, SearchFieldAlias, alias)
+		}
+		for _, category := range repo.Categories {
+			index.add(i, SearchFieldTag, category)
+		}
+		index.add(i, SearchFieldRemote, repo.RemoteURL)
+		// Leading path components such as /home/user would match every repository
+		components := strings.Split(strings.Trim(repo.Path, "/"), "/")
+		index.add(i, SearchFieldPath, strings.Join(components[max(len(components)-searchPathComponents, 0):], "/"))
+		index.add(i, SearchFieldDescription, repo.Description)
+	}
+
+	for token := range index.postings {
+		index.tokens = append(index.tokens, token)
+	}
+	sort.Strings(index.tokens)
+	return index
+}
+
+func (index *RegistryIndex) add(repo int, field, text string) {
+	for _, token := range searchTokens(text) {
+		postings := index.postings[token]
+		if n := len(postings); n > 0 && postings[n-1] == (searchPosting{repo, field}) {
+			continue
+		}
+		index.postings[token] = append(postings, searchPosting{repo, field})
+	}
+}
+
+func searchTokens(text string) []string {
+	var tokens []string
+	for _, token := range searchTokenSplit.Split(strings.ToLower(text), -1) {
+		if token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// Search returns the repositories matching every term of the query, best match first. A term
+// can be restricted to one field with a qualifier: name:, alias:, tag:, remote:, path: or
+// description:. limit <= 0 returns up to 20 matches.
+func (index *RegistryIndex) Search(query string, limit int) []SearchMatch {
+	if limit <= 0 {
+		limit = searchDefaultLimit
+	}
+
+	var totals map[int]int
+	fields := map[int]map[string]bool{}
+	for _, term := range strings.Fields(strings.ToLower(query)) {
+		field := ""
+		if qualifier, value, ok := strings.Cut(term, ":"); ok {
+			if _, known := searchWeights[qualifier]; known {
+				field, term = qualifier, value
+			}
+		}
+		if term == "" {
+			continue
+		}
+
+		scores := index.searchTerm(term, field, fields)
+		if totals == nil {
+			totals = scores
+			continue
+		}
+		for repo := range totals {
+			if score, ok := scores[repo]; ok {
+				totals[repo] += score
+			} else {
+				delete(totals, repo)
+			}
+		}
+	}
+
+	matches := make([]SearchMatch, 0, len(totals))
+	for repo, score := range totals {
+		matches = append(matches, SearchMatch{
+			Repository: index.repositories[repo],
+			Score:      score,
+			Fields:     sortedKeys(fields[repo]),
+		})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Repository.Name < matches[j].Repository.Name
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// searchTerm scores every repository matching one term, keeping the best score per repository
+func (index *RegistryIndex) searchTerm(term, field string, fields map[int]map[string]bool) map[int]int {
+	scores := map[int]int{}
+	record := func(repo, score int, matched string) {
+		if score > scores[repo] {
+			scores[repo] = score
+		}
+		if fields[repo] == nil {
+			fields[repo] = map[string]bool{}
+		}
+		fields[repo][matched] = true
+	}
+
+	// Token matches through the index: all tokens starting with the term
+	for i := sort.SearchStrings(index.tokens, term); i < len(index.tokens) && strings.HasPrefix(index.tokens[i], term); i++ {
+		token := index.tokens[i]
+		for _, posting := range index.postings[token] {
+			if field != "" && posting.field != field {
+				continue
+			}
+			weight := searchWeights[posting.field]
+			score := weight[1]
+			if token == term {
+				score = weight[0]
+			}
+			record(posting.repo, score, posting.field)
+		}
+	}
+
+	// Whole-name matches, which also catch terms spanning separators like "api-ser"
+	if field == "" || field == SearchFieldName || field == SearchFieldAlias {
+		for repo, name := range index.names {
+			if field != SearchFieldAlias {
+				switch {
+				case name == term:
+					record(repo, searchScoreExactName, SearchFieldName)
+				case strings.HasPrefix(name, term):
+					record(repo, searchScoreNamePrefix, SearchFieldName)
+				case strings.Contains(name, term):
+					record(repo, searchScoreNameInfix, SearchFieldName)
+				case field == "" && isSubsequence(term, name):
+					record(repo, searchScoreNameSubseq, SearchFieldName)
+				}
+			}
+			if field != SearchFieldName {
+				for _, alias := range index.aliases[repo] {
+					if alias == term {
+						record(repo, searchScoreExactAlias, SearchFieldAlias)
+					}
+				}
+			}
+		}
+	}
+
+	return scores
+}
+
+// isSubsequence reports whether the characters of term appear in order in text, so that
+// abbreviations like "wsmgr" find "workspace-manager"
+func isSubsequence(term, text string) bool {
+	runes := []rune(term)
+	i := 0
+	for _, r := range text {
+		if i < len(runes) && r == runes[i] {
+			i++
+		}
+	}
+	return i == len(runes)
+}
+
+// Search runs a ranked search over the registry, indexing it on first use
+func (rd *RepositoryDiscoverer) Search(query string, limit int) []SearchMatch {
+	if rd.index == nil {
+		rd.index = NewRegistryIndex(rd.registry.Repositories, rd.registry.Aliases)
+	}
+	return rd.index.Search(query, limit)
+}
@@ -0,0 +1,75 @@
+package wsm
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSearchTokens(t *testing.T) {
+	got := searchTokens("git@github.com:Go-Go-Golems/workspace_manager.git")
+	want := []string{"git", "github", "com", "go", "go", "golems", "workspace", "manager", "git"}
+	if !slices.Equal(got, want) {
+		t.Errorf("searchTokens = %q, want %q", got, want)
+	}
+	if got := searchTokens("  --  "); len(got) != 0 {
+		t.Errorf("searchTokens of separators = %q", got)
+	}
+}
+
+func TestIsSubsequence(t *testing.T) {
+	for term, want := range map[string]bool{"wsmgr": true, "workspace-manager": true, "": true, "mw": false, "wsmgrx": false} {
+		if got := isSubsequence(term, "workspace-manager"); got != want {
+			t.Errorf("isSubsequence(%q) = %v, want %v", term, got, want)
+		}
+	}
+}
+
+func searchNames(matches []SearchMatch) []string {
+	var names []string
+	for _, match := range matches {
+		names = append(names, match.Repository.Name)
+	}
+	return names
+}
+
+func TestRegistryIndexSearch(t *testing.T) {
+	repositories := []Repository{
+		{Name: "api", Path: "/home/me/code/api", RemoteURL: "git@github.com:acme/api.git", Categories: []string{"go"}, Description: "The public REST service"},
+		{Name: "api-server", Path: "/home/me/code/api-server", Categories: []string{"go", "service"}},
+		{Name: "web", Path: "/home/me/code/web", RemoteURL: "git@github.com:acme/frontend.git", Categories: []string{"node"}},
+		{Name: "workspace-manager", Path: "/home/me/code/workspace-manager", Categories: []string{"go"}},
+	}
+	index := NewRegistryIndex(repositories, map[string]string{"fe": "web", "gone": "missing"})
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		// The exact name ranks before the prefix match
+		{"api", []string{"api", "api-server"}},
+		{"api-ser", []string{"api-server"}},
+		{"fe", []string{"web"}},
+		{"frontend", []string{"web"}},
+		{"wsmgr", []string{"workspace-manager"}},
+		// Every term must match
+		{"tag:go service", []string{"api-server", "api"}},
+		{"tag:node", []string{"web"}},
+		// Qualified terms only look at their field; "me" is a path component of every repository
+		{"name:me", nil},
+		{"description:rest", []string{"api"}},
+		{"nothing", nil},
+	}
+	for _, tt := range tests {
+		if got := searchNames(index.Search(tt.query, 0)); !slices.Equal(got, tt.want) {
+			t.Errorf("Search(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+
+	matches := index.Search("fe", 0)
+	if len(matches) != 1 || !slices.Equal(matches[0].Fields, []string{SearchFieldAlias}) {
+		t.Errorf("Search(fe) = %+v, want a match on the alias", matches)
+	}
+	if got := index.Search("go", 2); len(got) != 2 {
+		t.Errorf("Search with a limit of 2 returned %d matches", len(got))
+	}
+}
@@ -0,0 +1,866 @@
+package wsm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Restart policies of a service
+const (
+	ServiceRestartOnFailure = "on-failure"
+	ServiceRestartAlways    = "always"
+	ServiceRestartNo        = "no"
+)
+
+// Service states recorded in the services state file
+const (
+	ServiceStarting   = "starting"
+	ServiceRunning    = "running"
+	ServiceRestarting = "restarting"
+	ServiceExited     = "exited"
+	ServiceFailed     = "failed"
+	ServiceStopped    = "stopped"
+)
+
+const (
+	defaultServiceBasePort = 4000
+	// serviceReadyTimeout is how long dependents wait for the port of a service to accept connections
+	serviceReadyTimeout = 60 * time.Second
+	// serviceStopTimeout is how long a service has to exit after SIGTERM before it is killed
+	serviceStopTimeout = 10 * time.Second
+	// serviceMaxFailures is how many times in a row a service may fail quickly before it is given up
+	serviceMaxFailures = 5
+	// serviceStableAfter is how long a service has to run for its failure count to be reset
+	serviceStableAfter   = 10 * time.Second
+	serviceLogTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+)
+
+// ServicesConfig is the .wsm/services.yaml of a workspace: the long-running processes started by
+// 'wsm up', such as API servers, web dev servers or databases in docker
+type ServicesConfig struct {
+	Services map[string]ServiceConfig `json:"services" yaml:"services"`
+	// BasePort is the first port assigned to services without a fixed port (default 4000)
+	BasePort int `json:"base_port,omitempty" yaml:"base_port,omitempty"`
+}
+
+// ServiceConfig describes one service
+type ServiceConfig struct {
+	// Repo is the repository the command runs in; the workspace root when empty
+	Repo string `json:"repo,omitempty" yaml:"repo,omitempty"`
+	// Command is run with sh -c; $PORT is the port of the service and $<NAME>_PORT those of all
+	// services (API_PORT for a service named api)
+	Command string `json:"command" yaml:"command"`
+	// Port is a fixed port; a free port is assigned when it is 0
+	Port int `json:"port,omitempty" yaml:"port,omitempty"`
+	// Env is added to the environment of the command; values may reference the port variables
+	Env map[string]string `json:"env,omitempty" yaml:"env,omitempty"`
+	// DependsOn lists services started, and listening on their port, before this one
+	DependsOn []string `json:"depends_on,omitempty" yaml:"depends_on,omitempty"`
+	// Restart is on-failure (default), always or no
+	Restart string `json:"restart,omitempty" yaml:"restart,omitempty"`
+	// Stop is run to stop the service instead of sending SIGTERM, e.g. 'docker stop wsm-db'
+	Stop string `json:"stop,omitempty" yaml:"stop,omitempty"`
+}
+
+// ServicesState is the state of the services of a workspace, written by the supervisor
+type ServicesState struct {
+	// PID is the process ID of the supervising wsm
+	PID      int            `json:"pid"`
+	Started  time.Time      `json:"started"`
+	Services []ServiceState `json:"services"`
+}
+
+// ServiceState is the state of one supervised service
+type ServiceState struct {
+	Name     string    `json:"name"`
+	Repo     string    `json:"repo,omitempty"`
+	Port     int       `json:"port,omitempty"`
+	PID      int       `json:"pid,omitempty"`
+	Status   string    `json:"status"`
+	Restarts int       `json:"restarts"`
+	Started  time.Time `json:"started,omitzero"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// ServiceLogLine is a line logged by a service
+type ServiceLogLine struct {
+	Service string    `json:"service"`
+	Time    time.Time `json:"time"`
+	Line    string    `json:"line"`
+}
+
+// ServicesDir holds the state and logs of the services of a workspace
+func ServicesDir(workspace *Workspace) string {
+	return filepath.Join(workspace.Path, ".wsm", "services")
+}
+
+func servicesStatePath(workspace *Workspace) string {
+	return filepath.Join(ServicesDir(workspace), "state.json")
+}
+
+// supervisorLockPath is locked by the supervisor while it runs. The lock, unlike the PID in the
+// state file, cannot outlive the supervisor, so a PID reused by another process is never signaled.
+func supervisorLockPath(workspace *Workspace) string {
+	return filepath.Join(ServicesDir(workspace), "supervisor.lock")
+}
+
+// lockSupervisor takes the supervisor lock of a workspace; the returned file holds it until closed
+func lockSupervisor(workspace *Workspace) (*os.File, error) {
+	f, err := os.OpenFile(supervisorLockPath(workspace), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open the supervisor lock")
+	}
+	locked, err := tryLockFile(f)
+	if err != nil || !locked {
+		_ = f.Close()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to lock the supervisor lock")
+		}
+		return nil, nil
+	}
+	return f, nil
+}
+
+// supervisorRunning reports whether a supervisor holds the lock of the workspace services
+func supervisorRunning(workspace *Workspace) bool {
+	f, err := os.OpenFile(supervisorLockPath(workspace), os.O_RDWR, 0)
+	if err != nil {
+		return false
+	}
+	defer func() { _ = f.Close() }()
+	locked, err := tryLockFile(f)
+	return err == nil && !locked
+}
+
+// ServiceLogPath returns the log file of a service
+func ServiceLogPath(workspace *Workspace, service string) string {
+	return filepath.Join(ServicesDir(workspace), "logs", service+".log")
+}
+
+// LoadServices reads the services of a workspace; it returns nil when the workspace has no
+// .wsm/services.yaml
+func LoadServices(workspace *Workspace) (*ServicesConfig, error) {
+	path := filepath.Join(workspace.Path, ".wsm", "services.yaml")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to read %s", path)
+	}
+	var config ServicesConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", path)
+	}
+
+	for name, service := range config.Services {
+		if service.Command == "" {
+			return nil, errors.Errorf("%s: service '%s' needs a command", path, name)
+		}
+		if _, ok := FindRepository(workspace, service.Repo); service.Repo != "" && !ok {
+			return nil, errors.Errorf("%s: service '%s' runs in '%s', which is not a repository of the workspace", path, name, service.Repo)
+		}
+		switch service.Restart {
+		case "", ServiceRestartOnFailure, ServiceRestartAlways, ServiceRestartNo:
+		default:
+			return nil, errors.Errorf("%s: service '%s' has an unknown restart policy '%s' (expected on-failure, always or no)", path, name, service.Restart)
+		}
+		for _, dependency := range service.DependsOn {
+			if _, ok := config.Services[dependency]; !ok {
+				return nil, errors.Errorf("%s: service '%s' depends on unknown service '%s'", path, name, dependency)
+			}
+		}
+	}
+	if _, err := config.StartOrder(nil); err != nil {
+		return nil, errors.Wrapf(err, "%s", path)
+	}
+	return &config, nil
+}
+
+// Names returns the names of the services, sorted
+func (c *ServicesConfig) Names() []string {
+	names := make([]string, 0, len(c.Services))
+	for name := range c.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// StartOrder returns the given services, all of them when names is empty, and the services they
+// depend on, dependencies first
+func (c *ServicesConfig) StartOrder(names []string) ([]string, error) {
+	if len(names) == 0 {
+		names = c.Names()
+	}
+	var order []string
+	visiting := map[string]bool{}
+	visited := map[string]bool{}
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		if visited[name] {
+			return nil
+		}
+		if visiting[name] {
+			return errors.Errorf("services depend on each other: %s", strings.Join(append(path, name), " -> "))
+		}
+		service, ok := c.Services[name]
+		if !ok {
+			return errors.Errorf("unknown service '%s' (available: %s)", name, strings.Join(c.Names(), ", "))
+		}
+		visiting[name] = true
+		for _, dependency := range service.DependsOn {
+			if err := visit(dependency, append(path, name)); err != nil {
+				return err
+			}
+		}
+		visiting[name] = false
+		visited[name] = true
+		order = append(order, name)
+		return nil
+	}
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// AssignPorts returns the port of each service: its fixed port, or the next port from BasePort
+// that nothing listens on
+func (c *ServicesConfig) AssignPorts(names []string) map[string]int {
+	ports := map[string]int{}
+	used := map[int]bool{}
+	for _, name := range names {
+		if port := c.Services[name].Port; port > 0 {
+			ports[name] = port
+			used[port] = true
+		}
+	}
+	next := c.BasePort
+	if next == 0 {
+		next = defaultServiceBasePort
+	}
+	for _, name := range names {
+		if _, ok := ports[name]; ok {
+			continue
+		}
+		for used[next] || !portFree(next) {
+			next++
+		}
+		ports[name] = next
+		used[next] = true
+		next++
+	}
+	return ports
+}
+
+func portFree(port int) bool {
+	listener, err := net.Listen("tcp", "127.0.0.1:"+strconv.Itoa(port))
+	if err != nil {
+		return false
+	}
+	_ = listener.Close()
+	return true
+}
+
+// ServicePortVariable returns the environment variable holding the port of a service
+func ServicePortVariable(service string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(service)) + "_PORT"
+}
+
+// LoadServicesState reads the state written by the supervisor of the workspace services; it
+// returns nil when no supervisor is running
+func LoadServicesState(workspace *Workspace) (*ServicesState, error) {
+	data, err := os.ReadFile(servicesStatePath(workspace))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "failed to read services state")
+	}
+	var state ServicesState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, errors.Wrap(err, "failed to parse services state")
+	}
+	if !supervisorRunning(workspace) {
+		return nil, nil
+	}
+	return &state, nil
+}
+
+// StopServices asks the supervisor of the workspace services to stop them and waits until it
+// exits. Services left running by a supervisor that did not exit in time are killed. Nothing is
+// signaled unless the supervisor still holds its lock.
+func StopServices(ctx context.Context, workspace *Workspace) error {
+	state, err := LoadServicesState(workspace)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return nil
+	}
+	if err := signalProcess(state.PID, false); err != nil {
+		return errors.Wrapf(err, "failed to stop the services supervisor (pid %d)", state.PID)
+	}
+
+	deadline := time.Now().Add(serviceStopTimeout + 5*time.Second)
+	for supervisorRunning(workspace) {
+		if time.Now().After(deadline) {
+			for _, service := range state.Services {
+				if service.PID > 0 {
+					_ = signalProcessGroup(service.PID, true)
+				}
+			}
+			_ = signalProcess(state.PID, true)
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	_ = os.Remove(servicesStatePath(workspace))
+	return nil
+}
+
+// StartServicesDetached runs the supervisor in the background: the wsm executable is started with
+// args in its own session, its output going to .wsm/services/supervisor.log. It returns once the
+// supervisor has written its state.
+func StartServicesDetached(ctx context.Context, workspace *Workspace, executable string, args []string) (*ServicesState, error) {
+	if err := os.MkdirAll(ServicesDir(workspace), 0755); err != nil {
+		return nil, errors.Wrap(err, "failed to create services directory")
+	}
+	logPath := filepath.Join(ServicesDir(workspace), "supervisor.log")
+	logFile, err := os.Create(logPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create supervisor log")
+	}
+	defer func() { _ = logFile.Close() }()
+
+	cmd := exec.Command(executable, args...)
+	cmd.Dir = workspace.Path
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	detachProcess(cmd)
+	if err := cmd.Start(); err != nil {
+		return nil, errors.Wrap(err, "failed to start the services supervisor")
+	}
+	pid := cmd.Process.Pid
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+
+	for {
+		if state, err := LoadServicesState(workspace); err == nil && state != nil && state.PID == pid {
+			return state, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-exited:
+			data, _ := os.ReadFile(logPath)
+			return nil, errors.Errorf("the services supervisor exited: %s", strings.TrimSpace(string(data)))
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// ServiceSupervisor starts the services of a workspace and keeps them running until its context
+// is done. The output of each service goes to its log file and to Output.
+type ServiceSupervisor struct {
+	Workspace *Workspace
+	Config    *ServicesConfig
+	// Env is added to the environment of every service, e.g. from SetupEnvironment
+	Env map[string]string
+	// Output receives every line a service writes
+	Output func(service, line string)
+	// Event receives supervision events such as starts, exits and restarts
+	Event func(service, message string)
+
+	mu        sync.Mutex
+	state     ServicesState
+	processes map[string]*serviceProcess
+}
+
+type serviceProcess struct {
+	name    string
+	config  ServiceConfig
+	dir     string
+	env     []string
+	port    int
+	log     *os.File
+	logMu   sync.Mutex
+	mu      sync.Mutex
+	cmd     *exec.Cmd
+	exited  chan struct{}
+	stopped bool
+	// output tracks the readers of the process output, which must be done before cmd.Wait
+	output sync.WaitGroup
+}
+
+// errServiceStopped is returned when a service is started after it was asked to stop
+var errServiceStopped = errors.New("service stopped")
+
+// Run starts the given services, all of them when names is empty, with the services they depend
+// on, and supervises them until ctx is done or none is left running. Services are then stopped
+// in reverse start order.
+func (s *ServiceSupervisor) Run(ctx context.Context, names []string) error {
+	order, err := s.Config.StartOrder(names)
+	if err != nil {
+		return err
+	}
+	if current, err := LoadServicesState(s.Workspace); err == nil && current != nil && current.PID != os.Getpid() {
+		return errors.Errorf("the services of workspace '%s' are already running (pid %d); stop them with 'wsm down'", s.Workspace.Name, current.PID)
+	}
+	if err := os.MkdirAll(filepath.Dir(ServiceLogPath(s.Workspace, "x")), 0755); err != nil {
+		return errors.Wrap(err, "failed to create services directory")
+	}
+	lock, err := lockSupervisor(s.Workspace)
+	if err != nil {
+		return err
+	}
+	if lock == nil {
+		return errors.Errorf("the services of workspace '%s' are already running; stop them with 'wsm down'", s.Workspace.Name)
+	}
+	defer func() { _ = lock.Close() }()
+
+	ports := s.Config.AssignPorts(order)
+	portEnv := map[string]string{}
+	for name, port := range ports {
+		portEnv[ServicePortVariable(name)] = strconv.Itoa(port)
+	}
+	needed := map[string]bool{}
+	for _, name := range order {
+		for _, dependency := range s.Config.Services[name].DependsOn {
+			needed[dependency] = true
+		}
+	}
+
+	s.state = ServicesState{PID: os.Getpid(), Started: time.Now()}
+	s.processes = map[string]*serviceProcess{}
+	for _, name := range order {
+		process, err := s.newProcess(name, ports[name], portEnv)
+		if err != nil {
+			s.closeLogs()
+			return err
+		}
+		s.processes[name] = process
+		s.state.Services = append(s.state.Services, ServiceState{Name: name, Repo: process.config.Repo, Port: process.port, Status: ServiceStarting})
+	}
+	defer s.closeLogs()
+	defer func() { _ = os.Remove(servicesStatePath(s.Workspace)) }()
+	s.saveState()
+
+	var wg sync.WaitGroup
+	for _, name := range order {
+		if ctx.Err() != nil {
+			break
+		}
+		process := s.processes[name]
+		started := make(chan struct{})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.supervise(ctx, process, started)
+		}()
+		<-started
+		if needed[name] {
+			s.waitReady(ctx, process)
+		}
+	}
+
+	allExited := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(allExited)
+	}()
+	select {
+	case <-ctx.Done():
+	case <-allExited:
+	}
+
+	for i := len(order) - 1; i >= 0; i-- {
+		s.stop(s.processes[order[i]])
+	}
+	wg.Wait()
+	return nil
+}
+
+func (s *ServiceSupervisor) newProcess(name string, port int, portEnv map[string]string) (*serviceProcess, error) {
+	config := s.Config.Services[name]
+	dir := s.Workspace.Path
+	if config.Repo != "" {
+		dir = filepath.Join(s.Workspace.Path, config.Repo)
+	}
+
+	vars := map[string]string{}
+	for key, value := range s.Env {
+		vars[key] = value
+	}
+	for key, value := range portEnv {
+		vars[key] = value
+	}
+	vars["PORT"] = strconv.Itoa(port)
+	vars["WSM_SERVICE"] = name
+	lookup := func(key string) string {
+		if value, ok := vars[key]; ok {
+			return value
+		}
+		return os.Getenv(key)
+	}
+	env := os.Environ()
+	for key, value := range vars {
+		env = append(env, key+"="+value)
+	}
+	for key, value := range config.Env {
+		env = append(env, key+"="+os.Expand(value, lookup))
+	}
+
+	logFile, err := os.Create(ServiceLogPath(s.Workspace, name))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create log of service '%s'", name)
+	}
+	return &serviceProcess{name: name, config: config, dir: dir, env: env, port: port, log: logFile}, nil
+}
+
+// supervise runs a service and restarts it according to its policy until it is stopped
+func (s *ServiceSupervisor) supervise(ctx context.Context, process *serviceProcess, started chan<- struct{}) {
+	failures := 0
+	backoff := time.Second
+	for {
+		start := time.Now()
+		err := s.start(process)
+		if started != nil {
+			close(started)
+			started = nil
+		}
+		if errors.Is(err, errServiceStopped) {
+			s.update(process.name, func(state *ServiceState) { state.Status = ServiceStopped })
+			return
+		}
+		if err != nil {
+			s.event(process, fmt.Sprintf("failed to start: %v", err))
+			s.update(process.name, func(state *ServiceState) {
+				state.Status = ServiceFailed
+				state.Error = err.Error()
+			})
+			return
+		}
+
+		process.output.Wait()
+		err = process.cmd.Wait()
+		close(process.exited)
+		process.mu.Lock()
+		stopped := process.stopped
+		process.mu.Unlock()
+		if stopped {
+			s.update(process.name, func(state *ServiceState) {
+				state.Status = ServiceStopped
+				state.PID = 0
+			})
+			return
+		}
+
+		failed := err != nil
+		message := "exited"
+		if failed {
+			message = fmt.Sprintf("exited: %v", err)
+		}
+		restart := process.config.Restart == ServiceRestartAlways ||
+			(failed && process.config.Restart != ServiceRestartNo)
+		if time.Since(start) >= serviceStableAfter {
+			failures = 0
+			backoff = time.Second
+		}
+		if failed {
+			failures++
+		}
+		if restart && failures >= serviceMaxFailures {
+			message += fmt.Sprintf("; giving up after %d failures in a row", failures)
+			restart = false
+		}
+
+		status := ServiceExited
+		if failed {
+			status = ServiceFailed
+		}
+		if restart {
+			status = ServiceRestarting
+			message += fmt.Sprintf("; restarting in %s", backoff)
+		}
+		s.event(process, message)
+		s.update(process.name, func(state *ServiceState) {
+			state.Status = status
+			state.PID = 0
+			if err != nil {
+				state.Error = err.Error()
+			}
+		})
+		if !restart {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			s.update(process.name, func(state *ServiceState) { state.Status = ServiceStopped })
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, 30*time.Second)
+		s.update(process.name, func(state *ServiceState) { state.Restarts++ })
+	}
+}
+
+func (s *ServiceSupervisor) start(process *serviceProcess) error {
+	process.mu.Lock()
+	defer process.mu.Unlock()
+	if process.stopped {
+		return errServiceStopped
+	}
+
+	cmd := exec.Command("sh", "-c", process.config.Command)
+	cmd.Dir = process.dir
+	cmd.Env = process.env
+	setProcessGroup(cmd)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	process.cmd = cmd
+	process.exited = make(chan struct{})
+
+	process.output.Add(2)
+	for _, pipe := range []io.Reader{stdout, stderr} {
+		go func() {
+			defer process.output.Done()
+			scanner := bufio.NewScanner(pipe)
+			scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+			for scanner.Scan() {
+				s.logLine(process, scanner.Text())
+			}
+		}()
+	}
+
+	s.event(process, fmt.Sprintf("started (pid %d, port %d)", cmd.Process.Pid, process.port))
+	s.update(process.name, func(state *ServiceState) {
+		state.Status = ServiceRunning
+		state.PID = cmd.Process.Pid
+		state.Started = time.Now()
+		state.Error = ""
+	})
+	return nil
+}
+
+// waitReady waits until the port of a service accepts connections, or the service exits
+func (s *ServiceSupervisor) waitReady(ctx context.Context, process *serviceProcess) {
+	if process.port == 0 {
+		return
+	}
+	process.mu.Lock()
+	exited := process.exited
+	process.mu.Unlock()
+	deadline := time.After(serviceReadyTimeout)
+	address := "127.0.0.1:" + strconv.Itoa(process.port)
+	for {
+		if conn, err := net.DialTimeout("tcp", address, time.Second); err == nil {
+			_ = conn.Close()
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-exited:
+			return
+		case <-deadline:
+			s.event(process, fmt.Sprintf("not listening on port %d after %s; starting dependents anyway", process.port, serviceReadyTimeout))
+			return
+		case <-time.After(250 * time.Millisecond):
+		}
+	}
+}
+
+// stop stops a service with its stop command or SIGTERM, and kills it if it does not exit in time
+func (s *ServiceSupervisor) stop(process *serviceProcess) {
+	process.mu.Lock()
+	process.stopped = true
+	cmd, exited := process.cmd, process.exited
+	process.mu.Unlock()
+	if cmd == nil {
+		return
+	}
+	select {
+	case <-exited:
+		return
+	default:
+	}
+
+	s.event(process, "stopping")
+	if process.config.Stop != "" {
+		stop := exec.Command("sh", "-c", process.config.Stop)
+		stop.Dir = process.dir
+		stop.Env = process.env
+		if out, err := stop.CombinedOutput(); err != nil {
+			s.event(process, fmt.Sprintf("stop command failed: %v: %s", err, strings.TrimSpace(string(out))))
+		}
+	}
+	_ = signalProcessGroup(cmd.Process.Pid, false)
+	select {
+	case <-exited:
+	case <-time.After(serviceStopTimeout):
+		s.event(process, fmt.Sprintf("did not stop within %s; killing it", serviceStopTimeout))
+		_ = signalProcessGroup(cmd.Process.Pid, true)
+		<-exited
+	}
+}
+
+func (s *ServiceSupervisor) logLine(process *serviceProcess, line string) {
+	process.logMu.Lock()
+	_, _ = fmt.Fprintf(process.log, "%s %s\n", time.Now().Format(serviceLogTimeFormat), line)
+	process.logMu.Unlock()
+	if s.Output != nil {
+		s.Output(process.name, line)
+	}
+}
+
+// event logs a supervision event to the service log, marked with "wsm:", and reports it
+func (s *ServiceSupervisor) event(process *serviceProcess, message string) {
+	process.logMu.Lock()
+	_, _ = fmt.Fprintf(process.log, "%s wsm: %s\n", time.Now().Format(serviceLogTimeFormat), message)
+	process.logMu.Unlock()
+	if s.Event != nil {
+		s.Event(process.name, message)
+	}
+}
+
+func (s *ServiceSupervisor) update(name string, change func(*ServiceState)) {
+	s.mu.Lock()
+	for i := range s.state.Services {
+		if s.state.Services[i].Name == name {
+			change(&s.state.Services[i])
+		}
+	}
+	s.mu.Unlock()
+	s.saveState()
+}
+
+// saveState writes the state file through a temporary file so readers never see it half written
+func (s *ServiceSupervisor) saveState() {
+	s.mu.Lock()
+	data, err := json.MarshalIndent(s.state, "", "  ")
+	s.mu.Unlock()
+	if err != nil {
+		return
+	}
+	path := servicesStatePath(s.Workspace)
+	if err := os.WriteFile(path+".tmp", data, 0644); err == nil {
+		_ = os.Rename(path+".tmp", path)
+	}
+}
+
+func (s *ServiceSupervisor) closeLogs() {
+	for _, process := range s.processes {
+		_ = process.log.Close()
+	}
+}
+
+// ReadServiceLogs returns the last lines of the logs of the given services, all of them when
+// names is empty, merged in time order; tail <= 0 returns every line
+func ReadServiceLogs(workspace *Workspace, names []string, tail int) ([]ServiceLogLine, map[string]int64, error) {
+	var lines []ServiceLogLine
+	offsets := map[string]int64{}
+	for _, name := range names {
+		data, err := os.ReadFile(ServiceLogPath(workspace, name))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, nil, errors.Wrapf(err, "failed to read log of service '%s'", name)
+		}
+		offsets[name] = int64(len(data))
+		serviceLines := parseServiceLog(name, string(data))
+		if tail > 0 && len(serviceLines) > tail {
+			serviceLines = serviceLines[len(serviceLines)-tail:]
+		}
+		lines = append(lines, serviceLines...)
+	}
+	sortServiceLogLines(lines)
+	if tail > 0 && len(lines) > tail {
+		lines = lines[len(lines)-tail:]
+	}
+	return lines, offsets, nil
+}
+
+// FollowServiceLogs calls emit with the lines appended to the logs of the given services after
+// offsets until ctx is done; logs truncated by a new 'wsm up' are read from the start again
+func FollowServiceLogs(ctx context.Context, workspace *Workspace, names []string, offsets map[string]int64, emit func(ServiceLogLine)) error {
+	followers := make([]*fileFollower, len(names))
+	for i, name := range names {
+		followers[i] = &fileFollower{path: ServiceLogPath(workspace, name), offset: offsets[name]}
+	}
+	for {
+		var lines []ServiceLogLine
+		for i, name := range names {
+			for _, text := range followers[i].read() {
+				lines = append(lines, parseServiceLog(name, text)...)
+			}
+		}
+		sortServiceLogLines(lines)
+		for _, line := range lines {
+			emit(line)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(250 * time.Millisecond):
+		}
+	}
+}
+
+func parseServiceLog(service, data string) []ServiceLogLine {
+	var lines []ServiceLogLine
+	for _, text := range strings.Split(strings.TrimRight(data, "\n"), "\n") {
+		if text == "" {
+			continue
+		}
+		stamp, line, _ := strings.Cut(text, " ")
+		at, err := time.Parse(serviceLogTimeFormat, stamp)
+		if err != nil {
+			line = text
+		}
+		lines = append(lines, ServiceLogLine{Service: service, Time: at, Line: line})
+	}
+	return lines
+}
+
+func sortServiceLogLines(lines []ServiceLogLine) {
+	slices.SortStableFunc(lines, func(a, b ServiceLogLine) int {
+		return a.Time.Compare(b.Time)
+	})
+}
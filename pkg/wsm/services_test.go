@@ -0,0 +1,86 @@
+package wsm
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func writeServicesState(t *testing.T, workspace *Workspace, state ServicesState) {
+	t.Helper()
+	if err := os.MkdirAll(ServicesDir(workspace), 0755); err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(servicesStatePath(workspace), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServicesStateRequiresTheSupervisorLock(t *testing.T) {
+	workspace := &Workspace{Name: "ws", Path: t.TempDir()}
+	// The PID of the state is alive, as it would be once reused by an unrelated process
+	writeServicesState(t, workspace, ServicesState{PID: os.Getpid()})
+
+	state, err := LoadServicesState(workspace)
+	if err != nil {
+		t.Fatalf("LoadServicesState failed: %v", err)
+	}
+	if state != nil {
+		t.Fatalf("expected no running supervisor without the lock, got %+v", state)
+	}
+
+	lock, err := lockSupervisor(workspace)
+	if err != nil || lock == nil {
+		t.Fatalf("failed to take the supervisor lock: %v", err)
+	}
+	if again, err := lockSupervisor(workspace); err != nil || again != nil {
+		t.Fatalf("expected the supervisor lock to be held, got %v, %v", again, err)
+	}
+	if state, err := LoadServicesState(workspace); err != nil || state == nil || state.PID != os.Getpid() {
+		t.Fatalf("expected the state of the locked supervisor, got %+v, %v", state, err)
+	}
+
+	_ = lock.Close()
+	if state, err := LoadServicesState(workspace); err != nil || state != nil {
+		t.Fatalf("expected no running supervisor once the lock is released, got %+v, %v", state, err)
+	}
+}
+
+func TestStopServicesDoesNotSignalStalePIDs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sleep")
+	}
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = cmd.Process.Kill() })
+	exited := make(chan struct{})
+	go func() {
+		_ = cmd.Wait()
+		close(exited)
+	}()
+
+	workspace := &Workspace{Name: "ws", Path: t.TempDir()}
+	writeServicesState(t, workspace, ServicesState{
+		PID:      cmd.Process.Pid,
+		Services: []ServiceState{{Name: "api", PID: cmd.Process.Pid}},
+	})
+
+	if err := StopServices(context.Background(), workspace); err != nil {
+		t.Fatalf("StopServices failed: %v", err)
+	}
+	select {
+	case <-exited:
+		t.Fatal("StopServices signaled a process that is not the supervisor")
+	case <-time.After(200 * time.Millisecond):
+	}
+}
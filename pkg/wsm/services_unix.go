@@ -0,0 +1,49 @@
+//go:build !windows
+
+package wsm
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// setProcessGroup starts a service in its own process group, so stopping it also stops the
+// processes it spawned (e.g. the server started by 'npm run dev')
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// detachProcess starts a process in its own session, so it outlives the terminal that started it
+func detachProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
+
+// signalProcessGroup sends SIGTERM, or SIGKILL when kill is set, to the process group led by pid
+func signalProcessGroup(pid int, kill bool) error {
+	return syscall.Kill(-pid, terminationSignal(kill))
+}
+
+// signalProcess sends SIGTERM, or SIGKILL when kill is set, to a process
+func signalProcess(pid int, kill bool) error {
+	return syscall.Kill(pid, terminationSignal(kill))
+}
+
+func terminationSignal(kill bool) syscall.Signal {
+	if kill {
+		return syscall.SIGKILL
+	}
+	return syscall.SIGTERM
+}
+
+// tryLockFile takes an exclusive lock on f without waiting; it is released when f is closed,
+// including when the process dies
+func tryLockFile(f *os.File) (bool, error) {
+	err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return false, nil
+	}
+	return err == nil, err
+}
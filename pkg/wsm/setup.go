@@ -0,0 +1,471 @@
+package wsm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// SetupConfig controls the setup scripts run after workspace creation and their environment
+type SetupConfig struct {
+	// Enabled runs the setup scripts after create, fork, respin and apply; off by default since
+	// repository scripts come from the cloned repositories. 'wsm setup' and --setup run them anyway.
+	Enabled bool `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	// TrustedRepositories lists the repositories whose setup scripts receive the secrets. Scripts of
+	// other repositories run without WSM_SECRET_ variables.
+	TrustedRepositories []string      `json:"trusted_repositories,omitempty" yaml:"trusted_repositories,omitempty"`
+	Secrets             SecretsConfig `json:"secrets" yaml:"secrets"`
+}
+
+// SetupEnabled reports whether the setup scripts run automatically after workspace creation
+func (wm *WorkspaceManager) SetupEnabled() bool {
+	return wm.config.Setup.Enabled
+}
+
+// receivesSecrets reports whether a setup script is given the secrets: workspace-level scripts
+// come from the user's templates, repository scripts only when the repository is trusted
+func (c SetupConfig) receivesSecrets(script SetupScript) bool {
+	return script.Repository == "" || slices.Contains(c.TrustedRepositories, script.Repository)
+}
+
+// SecretsConfig lists the secrets exported to setup scripts as WSM_SECRET_<NAME> variables
+type SecretsConfig struct {
+	// SopsFile is a sops-encrypted dotenv, yaml or json file; every key becomes a secret
+	SopsFile string `json:"sops_file,omitempty" yaml:"sops_file,omitempty"`
+	// OnePassword maps secret names to 1Password references (op://vault/item/field)
+	OnePassword map[string]string `json:"onepassword,omitempty" yaml:"onepassword,omitempty"`
+	// Vault maps secret names to "<path>#<field>" read with 'vault kv get'
+	Vault map[string]string `json:"vault,omitempty" yaml:"vault,omitempty"`
+}
+
+// SetupScript is an executable run with the workspace environment
+type SetupScript struct {
+	// Repository is empty for workspace-level scripts
+	Repository string `json:"repository,omitempty"`
+	Path       string `json:"path"`
+	Dir        string `json:"dir"`
+}
+
+// setupTemplateSuffix marks the setup scripts that are rendered as Go templates before they run
+const setupTemplateSuffix = ".tmpl"
+
+// DetectSetupScripts returns the workspace-level scripts (.wsm/setup.sh and .wsm/setup.sh.tmpl,
+// then .wsm/setup.d/* in lexical order) followed by the same scripts of each repository
+func DetectSetupScripts(workspace *Workspace) []SetupScript {
+	scripts := findSetupScripts(workspace.Path, "")
+	for _, repo := range workspace.Repositories {
+		scripts = append(scripts, findSetupScripts(filepath.Join(workspace.Path, repo.Name), repo.Name)...)
+	}
+	return scripts
+}
+
+func findSetupScripts(dir, repository string) []SetupScript {
+	scripts, skipped := listSetupScripts(dir, repository)
+	for _, path := range skipped {
+		output.PrintWarning("Skipping non-executable setup script %s", path)
+	}
+	return scripts
+}
+
+// listSetupScripts returns the setup scripts of a directory, and the non-executable files of its
+// setup.d directory that are not run
+func listSetupScripts(dir, repository string) ([]SetupScript, []string) {
+	var scripts []SetupScript
+	var skipped []string
+
+	for _, name := range []string{"setup.sh", "setup.sh" + setupTemplateSuffix} {
+		setupSh := filepath.Join(dir, ".wsm", name)
+		if fileExists(setupSh) {
+			scripts = append(scripts, SetupScript{Repository: repository, Path: setupSh, Dir: dir})
+		}
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dir, ".wsm", "setup.d"))
+	if err != nil {
+		return scripts, nil
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.Mode()&0111 == 0 {
+			skipped = append(skipped, filepath.Join(dir, ".wsm", "setup.d", entry.Name()))
+			continue
+		}
+		scripts = append(scripts, SetupScript{Repository: repository, Path: filepath.Join(dir, ".wsm", "setup.d", entry.Name()), Dir: dir})
+	}
+
+	return scripts, skipped
+}
+
+// SetupEnvironment returns the variables passed to services, watchers and the trusted setup
+// scripts of the workspace: workspace information, the workspace .wsm/env file and the secrets
+// configured in config.yaml and .wsm/secrets.yaml. Repository .wsm/env files are layered on top
+// when running their scripts.
+func (wm *WorkspaceManager) SetupEnvironment(ctx context.Context, workspace *Workspace) (map[string]string, error) {
+	env, err := setupBaseEnvironment(workspace)
+	if err != nil {
+		return nil, err
+	}
+	secrets, err := wm.secretEnvironment(ctx, workspace)
+	if err != nil {
+		return nil, err
+	}
+	return mergeEnv(env, secrets), nil
+}
+
+// setupBaseEnvironment returns the workspace information and .wsm/env variables, without secrets
+func setupBaseEnvironment(workspace *Workspace) (map[string]string, error) {
+	repoNames := make([]string, len(workspace.Repositories))
+	for i, repo := range workspace.Repositories {
+		repoNames[i] = repo.Name
+	}
+
+	env := map[string]string{
+		"WSM_WORKSPACE":      workspace.Name,
+		"WSM_WORKSPACE_PATH": workspace.Path,
+		"WSM_BRANCH":         workspace.Branch,
+		"WSM_BASE_BRANCH":    workspace.BaseBranch,
+		"WSM_REPOS":          strings.Join(repoNames, " "),
+	}
+
+	workspaceEnv, err := LoadEnvFile(filepath.Join(workspace.Path, ".wsm", "env"))
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range workspaceEnv {
+		env[key] = value
+	}
+	return env, nil
+}
+
+// secretEnvironment resolves the secrets of the workspace into WSM_SECRET_<NAME> variables
+func (wm *WorkspaceManager) secretEnvironment(ctx context.Context, workspace *Workspace) (map[string]string, error) {
+	secrets, err := wm.WorkspaceSecrets(workspace)
+	if err != nil {
+		return nil, err
+	}
+	values, err := ResolveSecrets(ctx, secrets)
+	if err != nil {
+		return nil, err
+	}
+	env := make(map[string]string, len(values))
+	for name, value := range values {
+		env["WSM_SECRET_"+secretVariableName(name)] = value
+	}
+	return env, nil
+}
+
+// WorkspaceSecrets returns the secrets configuration of config.yaml with the workspace
+// .wsm/secrets.yaml layered on top
+func (wm *WorkspaceManager) WorkspaceSecrets(workspace *Workspace) (SecretsConfig, error) {
+	secrets := wm.config.Setup.Secrets
+	secretsPath := filepath.Join(workspace.Path, ".wsm", "secrets.yaml")
+	if !fileExists(secretsPath) {
+		return secrets, nil
+	}
+
+	workspaceSecrets, err := loadSecretsConfig(secretsPath)
+	if err != nil {
+		return secrets, err
+	}
+	return mergeSecretsConfig(secrets, workspaceSecrets), nil
+}
+
+// SecretVariables returns the WSM_SECRET_ variable names of the named secrets, without
+// resolving them. Secrets from the sops file are only known after decryption.
+func (c SecretsConfig) SecretVariables() []string {
+	var names []string
+	for _, name := range sortedKeys(mergeEnv(c.OnePassword, c.Vault)) {
+		names = append(names, "WSM_SECRET_"+secretVariableName(name))
+	}
+	return names
+}
+
+// RunSetupScripts runs the scripts returned by DetectSetupScripts in order and stops at the first
+// failure. Secrets are only resolved when a script that receives them is run, and are never passed
+// to scripts of repositories missing from setup.trusted_repositories.
+func (wm *WorkspaceManager) RunSetupScripts(ctx context.Context, workspace *Workspace, scripts []SetupScript) error {
+	if len(scripts) == 0 {
+		return nil
+	}
+
+	env, err := setupBaseEnvironment(workspace)
+	if err != nil {
+		return errors.Wrap(err, "failed to prepare setup environment")
+	}
+	secretEnv := map[string]string{}
+	if slices.ContainsFunc(scripts, wm.config.Setup.receivesSecrets) {
+		if secretEnv, err = wm.secretEnvironment(ctx, workspace); err != nil {
+			return errors.Wrap(err, "failed to prepare setup environment")
+		}
+	}
+
+	output.PrintInfo("Running setup scripts (%d)...", len(scripts))
+
+	data := NewAgentTemplateData(workspace)
+
+	repoEnvs := make(map[string]map[string]string)
+	untrusted := make(map[string]bool)
+	for i, script := range scripts {
+		scriptEnv := env
+		if wm.config.Setup.receivesSecrets(script) {
+			scriptEnv = mergeEnv(env, secretEnv)
+		} else if len(secretEnv) > 0 && !untrusted[script.Repository] {
+			untrusted[script.Repository] = true
+			output.PrintInfo("Not passing secrets to the scripts of '%s' (not in setup.trusted_repositories)", script.Repository)
+		}
+		if script.Repository != "" {
+			repoEnv, ok := repoEnvs[script.Repository]
+			if !ok {
+				repoEnv, err = LoadEnvFile(filepath.Join(script.Dir, ".wsm", "env"))
+				if err != nil {
+					return err
+				}
+				repoEnv["WSM_REPO"] = script.Repository
+				repoEnv["WSM_REPO_PATH"] = script.Dir
+				repoEnvs[script.Repository] = repoEnv
+			}
+			scriptEnv = mergeEnv(scriptEnv, repoEnv)
+		}
+
+		name, _ := filepath.Rel(workspace.Path, script.Path)
+		output.PrintInfo("[%d/%d] %s", i+1, len(scripts), name)
+
+		if err := runSetupScript(ctx, script, scriptEnv, data.forRepository(script.Repository)); err != nil {
+			return errors.Wrapf(err, "setup script %s failed", name)
+		}
+	}
+
+	return nil
+}
+
+// runSetupScript runs a script, after rendering it as a Go template with the workspace context
+// when its name ends in .tmpl. Other scripts are run in place.
+func runSetupScript(ctx context.Context, script SetupScript, env map[string]string, data *AgentTemplateData) error {
+	info, err := os.Stat(script.Path)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(script.Path)
+	if err != nil {
+		return err
+	}
+
+	path := script.Path
+	if strings.HasSuffix(script.Path, setupTemplateSuffix) {
+		rendered, err := os.CreateTemp("", "wsm-setup-*")
+		if err != nil {
+			return errors.Wrap(err, "failed to create rendered script")
+		}
+		defer func() {
+			_ = os.Remove(rendered.Name())
+		}()
+		if _, err := rendered.Write(RenderAgentTemplate(filepath.Base(script.Path), content, data)); err != nil {
+			_ = rendered.Close()
+			return errors.Wrap(err, "failed to write rendered script")
+		}
+		if err := rendered.Close(); err != nil {
+			return errors.Wrap(err, "failed to write rendered script")
+		}
+		if err := os.Chmod(rendered.Name(), info.Mode().Perm()|0700); err != nil {
+			return errors.Wrap(err, "failed to make rendered script executable")
+		}
+		path = rendered.Name()
+	}
+
+	cmd := exec.CommandContext(ctx, path)
+	if info.Mode()&0111 == 0 {
+		// .wsm/setup.sh does not need to be executable
+		cmd = exec.CommandContext(ctx, "sh", path)
+	}
+	cmd.Dir = script.Dir
+	cmd.Env = environ(env)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// LoadEnvFile parses a dotenv file (KEY=VALUE lines, # comments, optional "export " prefix and
+// quotes). A missing file yields an empty map.
+func LoadEnvFile(path string) (map[string]string, error) {
+	env := make(map[string]string)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return env, nil
+		}
+		return nil, errors.Wrapf(err, "failed to read %s", path)
+	}
+
+	if err := parseEnv(data, env); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", path)
+	}
+	return env, nil
+}
+
+func parseEnv(data []byte, env map[string]string) error {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return errors.Errorf("line %d: expected KEY=VALUE", lineNumber)
+		}
+
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		env[key] = value
+	}
+	return scanner.Err()
+}
+
+// ResolveSecrets fetches the configured secrets with the sops, op and vault CLIs
+func ResolveSecrets(ctx context.Context, config SecretsConfig) (map[string]string, error) {
+	secrets := make(map[string]string)
+
+	if config.SopsFile != "" {
+		path, err := expandHomePath(config.SopsFile)
+		if err != nil {
+			return nil, err
+		}
+		out, err := runSecretCommand(ctx, "sops", "--decrypt", "--output-type", "dotenv", path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to decrypt %s", path)
+		}
+		if err := parseEnv([]byte(out), secrets); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse decrypted %s", path)
+		}
+	}
+
+	for _, name := range sortedKeys(config.OnePassword) {
+		value, err := runSecretCommand(ctx, "op", "read", "--no-newline", config.OnePassword[name])
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read 1Password secret %s", name)
+		}
+		secrets[name] = value
+	}
+
+	for _, name := range sortedKeys(config.Vault) {
+		path, field, ok := strings.Cut(config.Vault[name], "#")
+		if !ok || path == "" || field == "" {
+			return nil, errors.Errorf("vault secret %s: expected '<path>#<field>', got '%s'", name, config.Vault[name])
+		}
+		value, err := runSecretCommand(ctx, "vault", "kv", "get", "-field="+field, path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read vault secret %s", name)
+		}
+		secrets[name] = value
+	}
+
+	return secrets, nil
+}
+
+// runSecretCommand runs a secret manager CLI and returns its output without the trailing newline.
+// The output is never logged.
+func runSecretCommand(ctx context.Context, name string, args ...string) (string, error) {
+	if _, err := exec.LookPath(name); err != nil {
+		return "", errors.Errorf("%s not found in PATH", name)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", errors.Errorf("%s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimRight(stdout.String(), "\r\n"), nil
+}
+
+func loadSecretsConfig(path string) (SecretsConfig, error) {
+	var config SecretsConfig
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, errors.Wrapf(err, "failed to read %s", path)
+	}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return config, errors.Wrapf(err, "failed to parse %s", path)
+	}
+	return config, nil
+}
+
+// mergeSecretsConfig layers the workspace secrets over the global configuration
+func mergeSecretsConfig(base, override SecretsConfig) SecretsConfig {
+	merged := SecretsConfig{
+		SopsFile:    base.SopsFile,
+		OnePassword: mergeEnv(base.OnePassword, override.OnePassword),
+		Vault:       mergeEnv(base.Vault, override.Vault),
+	}
+	if override.SopsFile != "" {
+		merged.SopsFile = override.SopsFile
+	}
+	return merged
+}
+
+// secretVariableName turns a secret name into the suffix of its WSM_SECRET_ variable
+func secretVariableName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+func mergeEnv(base, override map[string]string) map[string]string {
+	merged := make(map[string]string, len(base)+len(override))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range override {
+		merged[key] = value
+	}
+	return merged
+}
+
+// environ returns the process environment with env applied on top
+func environ(env map[string]string) []string {
+	result := os.Environ()
+	for _, key := range sortedKeys(env) {
+		result = append(result, fmt.Sprintf("%s=%s", key, env[key]))
+	}
+	return result
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,93 @@
+package wsm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "plain", input: "A=1\nB=two\n", want: map[string]string{"A": "1", "B": "two"}},
+		{name: "comments and blanks", input: "# comment\n\nA=1\n", want: map[string]string{"A": "1"}},
+		{name: "export prefix", input: "export A=1\n", want: map[string]string{"A": "1"}},
+		{name: "quotes", input: "A=\"x y\"\nB='z'\n", want: map[string]string{"A": "x y", "B": "z"}},
+		{name: "equals in value", input: "A=b=c\n", want: map[string]string{"A": "b=c"}},
+		{name: "missing equals", input: "A\n", wantErr: true},
+		{name: "space in key", input: "A B=1\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := map[string]string{}
+			err := parseEnv([]byte(tt.input), env)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(env, tt.want) {
+				t.Errorf("parseEnv() = %v, want %v", env, tt.want)
+			}
+		})
+	}
+}
+
+func TestSecretVariableName(t *testing.T) {
+	for name, want := range map[string]string{
+		"github_token": "GITHUB_TOKEN",
+		"db-password":  "DB_PASSWORD",
+		"a.b/c":        "A_B_C",
+		"X9":           "X9",
+	} {
+		if got := secretVariableName(name); got != want {
+			t.Errorf("secretVariableName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestSetupScriptsReceiveSecrets(t *testing.T) {
+	config := SetupConfig{TrustedRepositories: []string{"infra"}}
+	tests := []struct {
+		script SetupScript
+		want   bool
+	}{
+		{script: SetupScript{Repository: ""}, want: true},
+		{script: SetupScript{Repository: "infra"}, want: true},
+		{script: SetupScript{Repository: "app"}, want: false},
+	}
+	for _, tt := range tests {
+		if got := config.receivesSecrets(tt.script); got != tt.want {
+			t.Errorf("receivesSecrets(%q) = %v, want %v", tt.script.Repository, got, tt.want)
+		}
+	}
+	if (SetupConfig{}).receivesSecrets(SetupScript{Repository: "app"}) {
+		t.Errorf("repository scripts must not receive secrets by default")
+	}
+}
+
+func TestRunSetupScriptOnlyRendersTemplates(t *testing.T) {
+	dir := t.TempDir()
+	writeGoFiles(t, dir, map[string]string{
+		".wsm/setup.sh":      "echo '{{.Name}}' > plain.out\n",
+		".wsm/setup.sh.tmpl": "echo '{{.Name}}' > rendered.out\n",
+	})
+	scripts, _ := listSetupScripts(dir, "")
+	if len(scripts) != 2 {
+		t.Fatalf("scripts = %+v, want setup.sh and setup.sh.tmpl", scripts)
+	}
+	for _, script := range scripts {
+		if err := runSetupScript(context.Background(), script, nil, &AgentTemplateData{Name: "feat"}); err != nil {
+			t.Fatalf("runSetupScript(%s) failed: %v", script.Path, err)
+		}
+	}
+	for name, want := range map[string]string{"plain.out": "{{.Name}}\n", "rendered.out": "feat\n"} {
+		if got, _ := os.ReadFile(filepath.Join(dir, name)); string(got) != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
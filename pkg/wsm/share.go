@@ -0,0 +1,215 @@
+package wsm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/pkg/errors"
+)
+
+// ShareWorkspace describes the workspace as a manifest that does not depend on local paths:
+// repositories are listed with their remote URL and the branch or commit they are on, so that
+// 'wsm apply --clone-root' recreates an equivalent workspace on another machine. The returned
+// warnings name what a teammate would be missing, such as unpushed branches.
+func ShareWorkspace(ctx context.Context, workspace *Workspace) (*WorkspaceManifest, []string, error) {
+	manifest := &WorkspaceManifest{
+		Name:       workspace.Name,
+		Branch:     workspace.Branch,
+		BaseBranch: workspace.BaseBranch,
+	}
+
+	var warnings []string
+	for _, repo := range workspace.Repositories {
+		worktreePath := filepath.Join(workspace.Path, repo.Name)
+		shared := ManifestRepository{Name: repo.Name}
+
+		remote, err := runGitOutput(ctx, worktreePath, "remote", "get-url", "origin")
+		if err != nil {
+			remote = repo.RemoteURL
+		}
+		switch {
+		case remote == "":
+			warnings = append(warnings, fmt.Sprintf("%s has no origin remote; teammates need it in their registry", repo.Name))
+		case isLocalRemote(remote):
+			warnings = append(warnings, fmt.Sprintf("%s: origin is a local path (%s)", repo.Name, remote))
+		}
+		shared.Remote = remote
+
+		branch, err := runGitOutput(ctx, worktreePath, "branch", "--show-current")
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to get branch of %s", repo.Name)
+		}
+		if branch == "" {
+			head, err := runGitOutput(ctx, worktreePath, "rev-parse", "HEAD")
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "failed to resolve HEAD of %s", repo.Name)
+			}
+			shared.Pin = head
+			if remotes, _ := runGitOutput(ctx, worktreePath, "branch", "--remotes", "--contains", head); remotes == "" {
+				warnings = append(warnings, fmt.Sprintf("%s: pinned commit %s is not on any remote branch", repo.Name, head[:min(len(head), 12)]))
+			}
+		} else {
+			if branch != workspace.Branch {
+				shared.Branch = branch
+			}
+			if warning := branchPushWarning(ctx, worktreePath, repo.Name, branch); warning != "" {
+				warnings = append(warnings, warning)
+			}
+		}
+
+		manifest.Repositories = append(manifest.Repositories, shared)
+	}
+
+	return manifest, warnings, nil
+}
+
+// branchPushWarning describes what of the branch is missing on origin, if anything
+func branchPushWarning(ctx context.Context, worktreePath, repoName, branch string) string {
+	remoteBranch := "refs/remotes/origin/" + branch
+	if _, err := runGitOutput(ctx, worktreePath, "rev-parse", "--verify", "--quiet", remoteBranch); err != nil {
+		return fmt.Sprintf("%s: branch %s is not pushed to origin", repoName, branch)
+	}
+	count, err := runGitOutput(ctx, worktreePath, "rev-list", "--count", remoteBranch+"..HEAD")
+	if err == nil && count != "0" {
+		return fmt.Sprintf("%s: %s unpushed commit(s) on %s", repoName, count, branch)
+	}
+	return ""
+}
+
+// isLocalRemote reports whether a remote URL is a path that only exists on this machine
+func isLocalRemote(url string) bool {
+	if strings.HasPrefix(url, "file://") {
+		return true
+	}
+	if strings.Contains(url, "://") {
+		return false
+	}
+	// scp-like syntax: [user@]host:path
+	if colon := strings.Index(url, ":"); colon > 0 && !strings.Contains(url[:colon], "/") {
+		return false
+	}
+	return true
+}
+
+// NormalizeRemoteURL reduces the ssh, scp-like and https forms of a remote URL to host/path, so
+// that clones of the same repository compare equal
+func NormalizeRemoteURL(url string) string {
+	url = strings.TrimSpace(url)
+	if scheme := strings.Index(url, "://"); scheme >= 0 {
+		url = url[scheme+3:]
+	} else if colon := strings.Index(url, ":"); colon > 0 && !strings.Contains(url[:colon], "/") {
+		url = url[:colon] + "/" + url[colon+1:]
+	}
+	if at := strings.Index(url, "@"); at >= 0 && at < strings.Index(url+"/", "/") {
+		url = url[at+1:]
+	}
+	url = strings.TrimSuffix(strings.TrimSuffix(url, "/"), ".git")
+	host, path, _ := strings.Cut(url, "/")
+	// Drop explicit ports so ssh://host:22/x and host:x compare equal
+	if colon := strings.Index(host, ":"); colon >= 0 {
+		host = host[:colon]
+	}
+	return strings.ToLower(host) + "/" + path
+}
+
+// validateCloneSource rejects repository names from untrusted manifests that would be cloned
+// outside of the clone root, and remotes git would parse as options
+func validateCloneSource(name, remote string) error {
+	if !filepath.IsLocal(name) {
+		return errors.Errorf("invalid repository name '%s': it must be a relative path inside the clone root", name)
+	}
+	if strings.HasPrefix(remote, "-") {
+		return errors.Errorf("invalid remote '%s' of repository '%s'", remote, name)
+	}
+	return nil
+}
+
+// ManifestClone is a repository of a shared manifest that is cloned because it is not in the registry
+type ManifestClone struct {
+	Name   string
+	Remote string
+	Path   string
+}
+
+// ResolveManifestRemotes maps the repositories of a shared manifest onto the local registry.
+// Repositories are matched by remote URL first, so they may have another name locally, and by
+// name otherwise. Repositories found in neither way are cloned into cloneRoot and added to the
+// registry; with dryRun they are only returned.
+func (wm *WorkspaceManager) ResolveManifestRemotes(ctx context.Context, manifest *WorkspaceManifest, cloneRoot string, dryRun bool) ([]ManifestClone, error) {
+	byRemote := map[string]string{}
+	byName := map[string]bool{}
+	for _, repo := range wm.Discoverer.GetRepositories() {
+		byName[repo.Name] = true
+		if repo.RemoteURL != "" {
+			byRemote[NormalizeRemoteURL(repo.RemoteURL)] = repo.Name
+		}
+	}
+
+	var clones []ManifestClone
+	for i, repo := range manifest.Repositories {
+		if repo.Remote == "" {
+			continue
+		}
+		if name, ok := byRemote[NormalizeRemoteURL(repo.Remote)]; ok {
+			manifest.Repositories[i].Name = name
+			continue
+		}
+		if byName[repo.Name] || byName[wm.Discoverer.ResolveAlias(repo.Name)] {
+			continue
+		}
+		if cloneRoot == "" {
+			return nil, errors.Errorf("repository '%s' (%s) is not in the registry; pass --clone-root to clone it", repo.Name, repo.Remote)
+		}
+		if err := validateCloneSource(repo.Name, repo.Remote); err != nil {
+			return nil, err
+		}
+		clones = append(clones, ManifestClone{Name: repo.Name, Remote: repo.Remote})
+	}
+
+	if len(clones) == 0 {
+		return nil, nil
+	}
+	root, err := expandHomePath(cloneRoot)
+	if err == nil {
+		root, err = filepath.Abs(root)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid clone root: %s", cloneRoot)
+	}
+	for i := range clones {
+		clones[i].Path = filepath.Join(root, clones[i].Name)
+	}
+	if dryRun {
+		return clones, nil
+	}
+
+	var paths []string
+	for _, clone := range clones {
+		if _, err := os.Stat(clone.Path); err == nil {
+			if _, err := runGitOutput(ctx, clone.Path, "rev-parse", "--git-dir"); err != nil {
+				return nil, errors.Errorf("cannot clone %s: %s exists and is not a git repository", clone.Name, clone.Path)
+			}
+			output.PrintInfo("Using existing clone %s", clone.Path)
+		} else {
+			output.PrintInfo("Cloning %s into %s", clone.Remote, clone.Path)
+			if err := os.MkdirAll(filepath.Dir(clone.Path), 0755); err != nil {
+				return nil, errors.Wrapf(err, "failed to create %s", filepath.Dir(clone.Path))
+			}
+			cloneCtx, done := withCloneTimeout(ctx, wm.Config().Timeouts, clone.Name, clone.Remote)
+			_, err := runGitOutput(cloneCtx, "", "clone", "--", clone.Remote, clone.Path)
+			if err = done(err); err != nil {
+				return nil, errors.Wrapf(err, "failed to clone %s", clone.Remote)
+			}
+		}
+		paths = append(paths, clone.Path)
+	}
+
+	if err := wm.Discoverer.DiscoverRepositories(ctx, paths, false, 0); err != nil {
+		return nil, errors.Wrap(err, "failed to add clones to the registry")
+	}
+	return clones, nil
+}
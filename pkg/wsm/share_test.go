@@ -0,0 +1,93 @@
+package wsm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestWorkspaceManager(t *testing.T) *WorkspaceManager {
+	t.Helper()
+	dir := t.TempDir()
+	config := &WorkspaceConfig{WorkspaceDir: filepath.Join(dir, "workspaces"), RegistryPath: filepath.Join(dir, "registry.json")}
+	wm, err := NewWorkspaceManagerWithBackends(config, config.RegistryPath, OSFS{}, ExecRunner{})
+	if err != nil {
+		t.Fatalf("failed to create workspace manager: %v", err)
+	}
+	return wm
+}
+
+func TestResolveManifestRemotesRejectsUnsafeClones(t *testing.T) {
+	tests := []struct {
+		name    string
+		repo    ManifestRepository
+		wantErr string
+	}{
+		{name: "parent directory", repo: ManifestRepository{Name: "../x", Remote: "https://example.com/x.git"}, wantErr: "invalid repository name"},
+		{name: "nested escape", repo: ManifestRepository{Name: "a/../../x", Remote: "https://example.com/x.git"}, wantErr: "invalid repository name"},
+		{name: "absolute", repo: ManifestRepository{Name: "/tmp/x", Remote: "https://example.com/x.git"}, wantErr: "invalid repository name"},
+		{name: "option remote", repo: ManifestRepository{Name: "x", Remote: "--upload-pack=touch /tmp/pwned"}, wantErr: "invalid remote"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wm := newTestWorkspaceManager(t)
+			root := t.TempDir()
+			manifest := &WorkspaceManifest{Name: "shared", Repositories: []ManifestRepository{tt.repo}}
+
+			_, err := wm.ResolveManifestRemotes(context.Background(), manifest, filepath.Join(root, "clones"), false)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+			if entries, _ := os.ReadDir(root); len(entries) != 0 {
+				t.Errorf("nothing should be cloned, found %v", entries)
+			}
+		})
+	}
+}
+
+func TestResolveManifestRemotesPlansClonesInsideTheRoot(t *testing.T) {
+	wm := newTestWorkspaceManager(t)
+	root := t.TempDir()
+	manifest := &WorkspaceManifest{Name: "shared", Repositories: []ManifestRepository{
+		{Name: "lib", Remote: "https://example.com/lib.git"},
+		{Name: "tools/cli", Remote: "git@example.com:tools/cli.git"},
+	}}
+
+	clones, err := wm.ResolveManifestRemotes(context.Background(), manifest, root, true)
+	if err != nil {
+		t.Fatalf("ResolveManifestRemotes failed: %v", err)
+	}
+	if len(clones) != 2 || clones[0].Path != filepath.Join(root, "lib") || clones[1].Path != filepath.Join(root, "tools", "cli") {
+		t.Errorf("unexpected clones: %+v", clones)
+	}
+}
+
+func TestNormalizeRemoteURL(t *testing.T) {
+	for url, want := range map[string]string{
+		"https://github.com/Org/Repo.git":     "github.com/Org/Repo",
+		"git@github.com:Org/Repo.git":         "github.com/Org/Repo",
+		"ssh://git@GitHub.com:22/Org/Repo":    "github.com/Org/Repo",
+		"https://user@github.com/Org/Repo/":   "github.com/Org/Repo",
+		"  https://gitlab.com/group/sub/repo": "gitlab.com/group/sub/repo",
+	} {
+		if got := NormalizeRemoteURL(url); got != want {
+			t.Errorf("NormalizeRemoteURL(%q) = %q, want %q", url, got, want)
+		}
+	}
+}
+
+func TestIsLocalRemote(t *testing.T) {
+	for url, want := range map[string]bool{
+		"/srv/git/repo.git":          true,
+		"../repo":                    true,
+		"file:///srv/git/repo.git":   true,
+		"https://github.com/org/rep": false,
+		"git@github.com:org/repo":    false,
+	} {
+		if got := isLocalRemote(url); got != want {
+			t.Errorf("isLocalRemote(%q) = %v, want %v", url, got, want)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package wsm
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Ways the repositories of a workspace are checked out
+const (
+	WorkspaceModeWorktree = "worktree"
+	WorkspaceModeLinked   = "linked"
+	WorkspaceModeClone    = "clone"
+)
+
+// creationOperations are the history operations that create a workspace
+var creationOperations = []string{"create", "fork", "respin", "import-bundle"}
+
+// WorkspaceDefinition is the full definition of a workspace as 'wsm show' prints it: the stored
+// workspace, what each repository checks out, the hooks that run in it and where it came from
+type WorkspaceDefinition struct {
+	*Workspace
+	// Mode is worktree, linked or clone
+	Mode         string                 `json:"mode"`
+	Repositories []RepositoryDefinition `json:"repositories"`
+	// SetupScripts run after creation and with 'wsm setup'
+	SetupScripts []SetupScript `json:"setup_scripts,omitempty"`
+	// HookSuites are the pre-commit and lefthook configurations run by 'wsm precommit'
+	HookSuites []HookSuite `json:"hook_suites,omitempty"`
+	// RespunFrom is the workspace this one was respun from
+	RespunFrom string `json:"respun_from,omitempty"`
+	// ForkedFrom is the workspace this one was forked from
+	ForkedFrom string `json:"forked_from,omitempty"`
+	// Provenance is the history entry of the creation, nil when the journal does not have it
+	Provenance *HistoryEntry `json:"provenance,omitempty"`
+}
+
+// RepositoryDefinition describes a repository of a workspace and what its directory checks out
+type RepositoryDefinition struct {
+	Name string `json:"name"`
+	// Path is the directory of the repository inside the workspace
+	Path string `json:"path"`
+	// Source is the registered checkout the worktree, link or clone was made from
+	Source     string   `json:"source"`
+	RemoteURL  string   `json:"remote_url,omitempty"`
+	Categories []string `json:"categories,omitempty"`
+	// Branch is the branch checked out, read from HEAD
+	Branch string `json:"branch,omitempty"`
+	// Pin is the commit a detached checkout is pinned at
+	Pin string `json:"pin,omitempty"`
+	// BranchPoint is the commit the workspace branch was created from
+	BranchPoint string `json:"branch_point,omitempty"`
+	Frozen      bool   `json:"frozen,omitempty"`
+	// Missing is set when the directory of the repository does not exist
+	Missing bool `json:"missing,omitempty"`
+}
+
+// DescribeWorkspace reads the full definition of a workspace from its configuration, the files of
+// its repositories and the operation journal. Git is not run, so it works on broken or locked
+// workspaces too.
+func DescribeWorkspace(workspace *Workspace) (*WorkspaceDefinition, error) {
+	definition := &WorkspaceDefinition{Workspace: workspace, Mode: WorkspaceModeWorktree}
+	switch {
+	case workspace.Linked:
+		definition.Mode = WorkspaceModeLinked
+	case workspace.Clone != "":
+		definition.Mode = WorkspaceModeClone
+	}
+
+	definition.SetupScripts, _ = listSetupScripts(workspace.Path, "")
+	for _, repo := range workspace.Repositories {
+		path := filepath.Join(workspace.Path, repo.Name)
+		repository := RepositoryDefinition{
+			Name:        repo.Name,
+			Path:        path,
+			Source:      repo.Path,
+			RemoteURL:   repo.RemoteURL,
+			Categories:  repo.Categories,
+			BranchPoint: workspace.BranchPoints[repo.Name],
+			Frozen:      slices.Contains(workspace.Frozen, repo.Name),
+		}
+		if _, err := os.Stat(path); err != nil {
+			repository.Missing = true
+		} else {
+			repository.Branch, repository.Pin = readHead(path)
+		}
+		definition.Repositories = append(definition.Repositories, repository)
+
+		scripts, _ := listSetupScripts(path, repo.Name)
+		definition.SetupScripts = append(definition.SetupScripts, scripts...)
+	}
+	definition.HookSuites = DetectHookSuites(workspace, nil)
+
+	// The creation is recorded once the workspace is saved, shortly after its creation time
+	entries, err := ReadHistory(workspace.Name, workspace.Created.Add(-time.Minute))
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if !slices.Contains(creationOperations, entry.Operation) {
+			continue
+		}
+		definition.Provenance = &entry
+		switch entry.Operation {
+		case "respin":
+			definition.RespunFrom = entry.Parameters["source"]
+		case "fork":
+			definition.ForkedFrom = entry.Parameters["source"]
+		}
+		break
+	}
+	return definition, nil
+}
+
+// readHead returns the branch checked out in a repository directory, or the commit when HEAD is
+// detached, by reading HEAD from its git directory
+func readHead(path string) (string, string) {
+	gitDir := worktreeGitDir(path)
+	if gitDir == "" {
+		return "", ""
+	}
+	data, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return "", ""
+	}
+	head := strings.TrimSpace(string(data))
+	if ref, ok := strings.CutPrefix(head, "ref:"); ok {
+		return strings.TrimPrefix(strings.TrimSpace(ref), "refs/heads/"), ""
+	}
+	return "", head
+}
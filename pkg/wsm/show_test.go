@@ -0,0 +1,97 @@
+package wsm
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReadHead(t *testing.T) {
+	const commit = "0123456789abcdef0123456789abcdef01234567"
+	tests := []struct {
+		name       string
+		files      map[string]string
+		wantBranch string
+		wantPin    string
+	}{
+		{name: "branch", files: map[string]string{".git/HEAD": "ref: refs/heads/feature/x\n"}, wantBranch: "feature/x"},
+		{name: "detached", files: map[string]string{".git/HEAD": commit + "\n"}, wantPin: commit},
+		{
+			name: "worktree",
+			files: map[string]string{
+				".git":                            "gitdir: ../main/.git/worktrees/lib\n",
+				"../main/.git/worktrees/lib/HEAD": "ref: refs/heads/main\n",
+			},
+			wantBranch: "main",
+		},
+		{name: "not a repository", files: map[string]string{"README.md": ""}},
+		{name: "broken git file", files: map[string]string{".git": "not a gitdir line\n"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "lib")
+			writeGoFiles(t, path, tt.files)
+			branch, pin := readHead(path)
+			if branch != tt.wantBranch || pin != tt.wantPin {
+				t.Errorf("readHead = %q, %q, want %q, %q", branch, pin, tt.wantBranch, tt.wantPin)
+			}
+		})
+	}
+}
+
+func TestDescribeWorkspace(t *testing.T) {
+	useTestConfigDir(t)
+	root := t.TempDir()
+	writeGoFiles(t, root, map[string]string{
+		"lib/.git/HEAD": "ref: refs/heads/feature/x\n",
+		"lib/README.md": "",
+	})
+	workspace := &Workspace{
+		Name:         "feat",
+		Path:         root,
+		Branch:       "feature/x",
+		Created:      time.Now(),
+		Repositories: []Repository{{Name: "lib", Path: "/src/lib"}, {Name: "app", Path: "/src/app"}},
+		Frozen:       []string{"app"},
+		BranchPoints: map[string]string{"lib": "abc123"},
+	}
+
+	definition, err := DescribeWorkspace(workspace)
+	if err != nil {
+		t.Fatalf("DescribeWorkspace failed: %v", err)
+	}
+	if definition.Mode != WorkspaceModeWorktree || definition.Provenance != nil {
+		t.Errorf("definition = mode %s, provenance %+v; want a worktree workspace missing from the history", definition.Mode, definition.Provenance)
+	}
+	lib, app := definition.Repositories[0], definition.Repositories[1]
+	if lib.Branch != "feature/x" || lib.BranchPoint != "abc123" || lib.Source != "/src/lib" || lib.Missing {
+		t.Errorf("lib = %+v", lib)
+	}
+	if !app.Missing || !app.Frozen {
+		t.Errorf("app = %+v, want it missing and frozen", app)
+	}
+
+	// Operations after the creation do not hide it
+	RecordOperation("fork", "feat", time.Now(), map[string]string{"source": "main-work"})
+	RecordOperation("sync", "feat", time.Now(), nil)
+	RecordOperation("fork", "other", time.Now(), map[string]string{"source": "unrelated"})
+	workspace.Clone = CloneShared
+	if definition, err = DescribeWorkspace(workspace); err != nil {
+		t.Fatalf("DescribeWorkspace failed: %v", err)
+	}
+	if definition.Mode != WorkspaceModeClone {
+		t.Errorf("mode = %s, want %s", definition.Mode, WorkspaceModeClone)
+	}
+	if definition.Provenance == nil || definition.Provenance.Operation != "fork" || definition.ForkedFrom != "main-work" {
+		t.Errorf("provenance = %+v, forked from %q", definition.Provenance, definition.ForkedFrom)
+	}
+
+	imported := &Workspace{Name: "imported", Path: t.TempDir(), Created: time.Now(), Linked: true}
+	RecordOperation("import-bundle", "imported", time.Now(), map[string]string{"bundle": "imported.wsmpack"})
+	if definition, err = DescribeWorkspace(imported); err != nil {
+		t.Fatalf("DescribeWorkspace failed: %v", err)
+	}
+	if definition.Mode != WorkspaceModeLinked || definition.Provenance == nil || definition.Provenance.Parameters["bundle"] != "imported.wsmpack" {
+		t.Errorf("definition = mode %s, provenance %+v", definition.Mode, definition.Provenance)
+	}
+}
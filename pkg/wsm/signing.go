@@ -0,0 +1,255 @@
+package wsm
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Signing modes of a SigningPolicy
+const (
+	// SigningOff leaves signing to the git configuration of the repository
+	SigningOff = "off"
+	// SigningAuto signs commits when a signing key is available and commits unsigned otherwise
+	SigningAuto = "auto"
+	// SigningRequire refuses to commit without a usable signing key and flags unsigned commits
+	SigningRequire = "require"
+)
+
+// SigningPolicy configures commit signing for a repository
+type SigningPolicy struct {
+	// Mode is off (default), auto or require
+	Mode string `json:"mode,omitempty" yaml:"mode,omitempty"`
+	// Format is the signature format: gpg (openpgp), ssh or x509; defaults to gpg.format
+	Format string `json:"format,omitempty" yaml:"format,omitempty"`
+	// Key is the signing key (GPG key id, or SSH key path / "key::<public key>"); defaults to
+	// user.signingkey
+	Key string `json:"key,omitempty" yaml:"key,omitempty"`
+}
+
+// SigningConfig is the signing policy of 'wsm commit', 'wsm preflight' and 'wsm lint commits':
+//
+//	signing:
+//	  mode: require
+//	  format: ssh
+//	  key: ~/.ssh/id_ed25519.pub
+//	  repositories:
+//	    scratch:
+//	      mode: off
+type SigningConfig struct {
+	SigningPolicy `yaml:",inline"`
+	// Repositories override the policy for single repositories
+	Repositories map[string]SigningPolicy `json:"repositories,omitempty" yaml:"repositories,omitempty"`
+}
+
+// For returns the policy of a repository
+func (c SigningConfig) For(repoName string) SigningPolicy {
+	policy := c.SigningPolicy
+	if override, ok := c.Repositories[repoName]; ok {
+		if override.Mode != "" {
+			policy.Mode = override.Mode
+		}
+		if override.Format != "" {
+			policy.Format = override.Format
+		}
+		if override.Key != "" {
+			policy.Key = override.Key
+		}
+	}
+	if policy.Mode == "" {
+		policy.Mode = SigningOff
+	}
+	return policy
+}
+
+// Validate checks the modes and formats of the configuration
+func (c SigningConfig) Validate() error {
+	policies := map[string]SigningPolicy{"signing": c.SigningPolicy}
+	for repo, policy := range c.Repositories {
+		policies["signing.repositories."+repo] = policy
+	}
+	for name, policy := range policies {
+		switch policy.Mode {
+		case "", SigningOff, SigningAuto, SigningRequire:
+		default:
+			return errors.Errorf("invalid %s mode '%s': expected off, auto or require", name, policy.Mode)
+		}
+		switch policy.Format {
+		case "", "gpg", "openpgp", "ssh", "x509":
+		default:
+			return errors.Errorf("invalid %s format '%s': expected gpg, ssh or x509", name, policy.Format)
+		}
+	}
+	return nil
+}
+
+// SigningCheck is whether a repository can sign commits under its policy
+type SigningCheck struct {
+	Repository string `json:"repository"`
+	Mode       string `json:"mode"`
+	Format     string `json:"format"`
+	Key        string `json:"key,omitempty"`
+	Available  bool   `json:"available"`
+	Error      string `json:"error,omitempty"`
+}
+
+// CheckSigning verifies that the signing key of every repository with a signing policy is usable
+func CheckSigning(ctx context.Context, workspace *Workspace, config SigningConfig) []SigningCheck {
+	var checks []SigningCheck
+	for _, repo := range workspace.ActiveRepositories() {
+		policy := config.For(repo.Name)
+		if policy.Mode == SigningOff {
+			continue
+		}
+		checks = append(checks, CheckSigningKey(ctx, repo.Name, filepath.Join(workspace.Path, repo.Name), policy))
+	}
+	return checks
+}
+
+// CheckSigningKey resolves the format and key git would sign with in repoPath and verifies the key
+// is available: a GPG secret key, an SSH private key file or ssh-agent identity, or gpgsm
+func CheckSigningKey(ctx context.Context, repoName, repoPath string, policy SigningPolicy) SigningCheck {
+	check := SigningCheck{Repository: repoName, Mode: policy.Mode, Format: policy.Format, Key: policy.Key}
+	if check.Format == "" {
+		check.Format, _ = runGitOutput(ctx, repoPath, "config", "--get", "gpg.format")
+	}
+	if check.Format == "" || check.Format == "gpg" {
+		check.Format = "openpgp"
+	}
+	if check.Key == "" {
+		check.Key, _ = runGitOutput(ctx, repoPath, "config", "--get", "user.signingkey")
+	}
+
+	var err error
+	switch check.Format {
+	case "ssh":
+		err = checkSSHSigningKey(ctx, repoPath, check.Key)
+	case "x509":
+		program := gitConfigOr(ctx, repoPath, "gpg.x509.program", "gpgsm")
+		err = checkSecretKey(ctx, program, check.Key, "--list-secret-keys")
+	default:
+		program := gitConfigOr(ctx, repoPath, "gpg.program", "gpg")
+		key := check.Key
+		if key == "" {
+			// Without user.signingkey, git signs with the key of the committer
+			key, _ = runGitOutput(ctx, repoPath, "config", "--get", "user.email")
+		}
+		err = checkSecretKey(ctx, program, key, "--batch", "--list-secret-keys")
+	}
+	if err != nil {
+		check.Error = err.Error()
+	} else {
+		check.Available = true
+	}
+	return check
+}
+
+func checkSSHSigningKey(ctx context.Context, repoPath, key string) error {
+	if key == "" {
+		if command, _ := runGitOutput(ctx, repoPath, "config", "--get", "gpg.ssh.defaultKeyCommand"); command != "" {
+			return nil
+		}
+		return errors.New("no SSH signing key: set user.signingkey or signing.key")
+	}
+
+	publicKey := strings.TrimPrefix(key, "key::")
+	if publicKey == key {
+		path, err := expandHomePath(key)
+		if err != nil {
+			return err
+		}
+		if !strings.HasSuffix(path, ".pub") {
+			if _, err := os.Stat(path); err != nil {
+				return errors.Errorf("SSH signing key %s not found", key)
+			}
+			return nil
+		}
+		// A public key signs with its private key next to it or with the matching ssh-agent identity
+		if _, err := os.Stat(strings.TrimSuffix(path, ".pub")); err == nil {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return errors.Errorf("SSH signing key %s not found", key)
+		}
+		publicKey = string(content)
+	}
+
+	fields := strings.Fields(publicKey)
+	if len(fields) < 2 {
+		return errors.Errorf("invalid SSH public key %s", key)
+	}
+	agentKeys, err := exec.CommandContext(ctx, "ssh-add", "-L").Output()
+	if err != nil || !strings.Contains(string(agentKeys), fields[1]) {
+		return errors.New("the SSH signing key is neither a private key file nor loaded in ssh-agent")
+	}
+	return nil
+}
+
+// checkSecretKey lists the secret keys matching key (any key when empty) with a gpg-compatible program
+func checkSecretKey(ctx context.Context, program, key string, args ...string) error {
+	if _, err := exec.LookPath(program); err != nil {
+		return errors.Errorf("%s is not installed", program)
+	}
+	if key != "" {
+		args = append(args, key)
+	}
+	out, err := exec.CommandContext(ctx, program, args...).CombinedOutput()
+	if err != nil || strings.TrimSpace(string(out)) == "" {
+		if key == "" {
+			return errors.Errorf("no secret key in %s", program)
+		}
+		return errors.Errorf("no secret key for '%s' in %s", key, program)
+	}
+	return nil
+}
+
+func gitConfigOr(ctx context.Context, repoPath, key, fallback string) string {
+	if value, _ := runGitOutput(ctx, repoPath, "config", "--get", key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// signingArgs are the git arguments placed before 'commit' and the commit flags that sign a
+// commit with the policy's format and key
+func signingArgs(policy SigningPolicy) ([]string, []string) {
+	var gitArgs []string
+	format := policy.Format
+	if format == "gpg" {
+		format = "openpgp"
+	}
+	if format != "" {
+		gitArgs = append(gitArgs, "-c", "gpg.format="+format)
+	}
+	if policy.Key != "" {
+		key := policy.Key
+		if !strings.HasPrefix(key, "key::") {
+			if expanded, err := expandHomePath(key); err == nil {
+				key = expanded
+			}
+		}
+		gitArgs = append(gitArgs, "-c", "user.signingkey="+key)
+	}
+	return gitArgs, []string{"-S"}
+}
+
+// commitIsSigned reports whether a commit carries a signature. The commit headers are read instead
+// of verifying the signature, which needs the signer's public key (e.g. gpg.ssh.allowedSignersFile).
+func commitIsSigned(ctx context.Context, dir, commit string) (bool, error) {
+	raw, err := runGitOutput(ctx, dir, "cat-file", "commit", commit)
+	if err != nil {
+		return false, err
+	}
+	headers, _, _ := strings.Cut(raw, "\n\n")
+	for _, line := range strings.Split(headers, "\n") {
+		if strings.HasPrefix(line, "gpgsig ") || strings.HasPrefix(line, "gpgsig-sha256 ") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
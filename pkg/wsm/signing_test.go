@@ -0,0 +1,159 @@
+package wsm
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSigningConfigFor(t *testing.T) {
+	config := SigningConfig{
+		SigningPolicy: SigningPolicy{Mode: SigningRequire, Format: "ssh", Key: "~/.ssh/id_ed25519.pub"},
+		Repositories: map[string]SigningPolicy{
+			"scratch": {Mode: SigningOff},
+			"infra":   {Format: "gpg", Key: "ABCD1234"},
+		},
+	}
+
+	if got := config.For("app"); got != config.SigningPolicy {
+		t.Errorf("For(app) = %+v, want the top-level policy", got)
+	}
+	want := SigningPolicy{Mode: SigningOff, Format: "ssh", Key: "~/.ssh/id_ed25519.pub"}
+	if got := config.For("scratch"); got != want {
+		t.Errorf("For(scratch) = %+v, want %+v", got, want)
+	}
+	want = SigningPolicy{Mode: SigningRequire, Format: "gpg", Key: "ABCD1234"}
+	if got := config.For("infra"); got != want {
+		t.Errorf("For(infra) = %+v, want %+v", got, want)
+	}
+	if got := (SigningConfig{}).For("app"); got.Mode != SigningOff {
+		t.Errorf("empty config mode = %q, want %q", got.Mode, SigningOff)
+	}
+}
+
+func TestSigningConfigValidate(t *testing.T) {
+	valid := SigningConfig{
+		SigningPolicy: SigningPolicy{Mode: SigningAuto, Format: "openpgp"},
+		Repositories:  map[string]SigningPolicy{"scratch": {Mode: SigningOff, Format: "x509"}},
+	}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+
+	tests := []struct {
+		name   string
+		config SigningConfig
+		want   string
+	}{
+		{
+			name:   "mode",
+			config: SigningConfig{SigningPolicy: SigningPolicy{Mode: "always"}},
+			want:   "invalid signing mode 'always'",
+		},
+		{
+			name:   "format",
+			config: SigningConfig{SigningPolicy: SigningPolicy{Format: "pgp"}},
+			want:   "invalid signing format 'pgp'",
+		},
+		{
+			name:   "repository",
+			config: SigningConfig{Repositories: map[string]SigningPolicy{"app": {Mode: "yes"}}},
+			want:   "invalid signing.repositories.app mode 'yes'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSigningArgs(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	tests := []struct {
+		name   string
+		policy SigningPolicy
+		want   []string
+	}{
+		{name: "git configuration", policy: SigningPolicy{Mode: SigningAuto}},
+		{
+			name:   "gpg",
+			policy: SigningPolicy{Format: "gpg", Key: "ABCD1234"},
+			want:   []string{"-c", "gpg.format=openpgp", "-c", "user.signingkey=ABCD1234"},
+		},
+		{
+			name:   "ssh key path",
+			policy: SigningPolicy{Format: "ssh", Key: "~/.ssh/id_ed25519.pub"},
+			want:   []string{"-c", "gpg.format=ssh", "-c", "user.signingkey=" + filepath.Join(home, ".ssh/id_ed25519.pub")},
+		},
+		{
+			name:   "ssh literal key",
+			policy: SigningPolicy{Format: "ssh", Key: "key::ssh-ed25519 AAAA"},
+			want:   []string{"-c", "gpg.format=ssh", "-c", "user.signingkey=key::ssh-ed25519 AAAA"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gitArgs, commitArgs := signingArgs(tt.policy)
+			if !reflect.DeepEqual(gitArgs, tt.want) {
+				t.Errorf("git args = %q, want %q", gitArgs, tt.want)
+			}
+			if !reflect.DeepEqual(commitArgs, []string{"-S"}) {
+				t.Errorf("commit args = %q, want [-S]", commitArgs)
+			}
+		})
+	}
+}
+
+func TestCommitIsSigned(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	testGit(t, dir, "init", "--quiet")
+	testGit(t, dir, "commit", "--quiet", "--allow-empty", "-m", "Unsigned")
+
+	signed, err := commitIsSigned(ctx, dir, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signed {
+		t.Error("unsigned commit reported as signed")
+	}
+
+	// A commit object carrying a signature header, which is all commitIsSigned looks at
+	tree := testGit(t, dir, "rev-parse", "HEAD^{tree}")
+	raw := "tree " + tree + "\n" +
+		"author Test <test@example.com> 1700000000 +0000\n" +
+		"committer Test <test@example.com> 1700000000 +0000\n" +
+		"gpgsig -----BEGIN SSH SIGNATURE-----\n" +
+		" U1NIU0lH\n" +
+		" -----END SSH SIGNATURE-----\n" +
+		"\n" +
+		"Signed\n"
+	cmd := exec.Command("git", "hash-object", "-t", "commit", "-w", "--stdin")
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(raw)
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git hash-object failed: %v", err)
+	}
+
+	signed, err = commitIsSigned(ctx, dir, strings.TrimSpace(string(out)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !signed {
+		t.Error("signed commit reported as unsigned")
+	}
+
+	if _, err := commitIsSigned(ctx, dir, "missing"); err == nil {
+		t.Error("expected an error for an unknown commit")
+	}
+}
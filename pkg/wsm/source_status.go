@@ -0,0 +1,205 @@
+package wsm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+const (
+	// staleFetchAge is how long after the last fetch remote-tracking refs count as outdated
+	staleFetchAge = 7 * 24 * time.Hour
+	// sourceStatusConcurrency is the number of repositories inspected at the same time
+	sourceStatusConcurrency = 8
+)
+
+// SourceStatus is the state of the checkout of a registered repository, the one workspaces
+// create their worktrees from
+type SourceStatus struct {
+	Repository string `json:"repository"`
+	Path       string `json:"path"`
+	Missing    bool   `json:"missing,omitempty"`
+	// Branch is the checked out branch, empty when HEAD is detached
+	Branch   string `json:"branch,omitempty"`
+	Detached bool   `json:"detached,omitempty"`
+	Upstream string `json:"upstream,omitempty"`
+	Ahead    int    `json:"ahead"`
+	Behind   int    `json:"behind"`
+	// Changed counts tracked files with uncommitted changes
+	Changed   int `json:"changed"`
+	Untracked int `json:"untracked"`
+	// Operation is a rebase, merge, cherry-pick or revert in progress
+	Operation string `json:"operation,omitempty"`
+	// Worktrees counts the linked worktrees, e.g. of workspaces
+	Worktrees int `json:"worktrees"`
+	// PrunableWorktrees are linked worktrees whose directory is gone
+	PrunableWorktrees int `json:"prunable_worktrees,omitempty"`
+	// FetchedAt is the last fetch, by git or 'wsm prefetch'
+	FetchedAt time.Time `json:"fetched_at,omitzero"`
+	// UnfetchedRemotes are remotes without any remote-tracking refs
+	UnfetchedRemotes []string `json:"unfetched_remotes,omitempty"`
+	Error            string   `json:"error,omitempty"`
+}
+
+// Problems lists what makes the checkout a poor source for new workspaces
+func (s SourceStatus) Problems() []string {
+	if s.Missing {
+		return []string{"path does not exist"}
+	}
+	if s.Error != "" {
+		return []string{s.Error}
+	}
+	var problems []string
+	if s.Operation != "" {
+		problems = append(problems, s.Operation+" in progress")
+	}
+	if s.Detached {
+		problems = append(problems, "detached HEAD")
+	}
+	if s.Changed > 0 {
+		problems = append(problems, countOf(s.Changed, "uncommitted change"))
+	}
+	if s.Behind > 0 {
+		problems = append(problems, fmt.Sprintf("%d behind %s", s.Behind, s.Upstream))
+	}
+	if s.PrunableWorktrees > 0 {
+		problems = append(problems, countOf(s.PrunableWorktrees, "prunable worktree"))
+	}
+	if len(s.UnfetchedRemotes) > 0 {
+		problems = append(problems, "never fetched: "+strings.Join(s.UnfetchedRemotes, ", "))
+	} else if !s.FetchedAt.IsZero() && time.Since(s.FetchedAt) > staleFetchAge {
+		problems = append(problems, "fetched "+humanize.Time(s.FetchedAt))
+	}
+	return problems
+}
+
+// countOf formats a count with the noun in singular or plural
+func countOf(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// GetSourceStatuses inspects the checkouts of registered repositories without fetching
+func GetSourceStatuses(ctx context.Context, runner CommandRunner, repos []Repository) []SourceStatus {
+	prefetched, _ := LoadPrefetchState()
+
+	statuses := make([]SourceStatus, len(repos))
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, sourceStatusConcurrency)
+	for i, repo := range repos {
+		wg.Add(1)
+		go func(i int, repo Repository) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			statuses[i] = getSourceStatus(ctx, runner, repo, prefetched)
+		}(i, repo)
+	}
+	wg.Wait()
+	return statuses
+}
+
+func getSourceStatus(ctx context.Context, runner CommandRunner, repo Repository, prefetched *PrefetchState) SourceStatus {
+	status := SourceStatus{Repository: repo.Name, Path: repo.Path}
+	if _, err := os.Stat(repo.Path); err != nil {
+		status.Missing = true
+		return status
+	}
+
+	out, err := gitOutput(ctx, runner, repo.Path, "status", "--porcelain=v2", "--branch")
+	if err != nil {
+		status.Error = "not a git repository"
+		return status
+	}
+	porcelain := parsePorcelainStatus(out)
+	status.Branch = porcelain.Branch
+	status.Detached = porcelain.Detached
+	status.Upstream = porcelain.Upstream
+	status.Ahead = porcelain.Ahead
+	status.Behind = porcelain.Behind
+	status.Changed = porcelain.Changed
+	status.Untracked = porcelain.Untracked
+
+	status.Operation = ConflictOperation(ctx, repo.Path)
+
+	if out, err := gitOutput(ctx, runner, repo.Path, "worktree", "list", "--porcelain"); err == nil {
+		for _, line := range strings.Split(out, "\n") {
+			switch {
+			case strings.HasPrefix(line, "worktree "):
+				status.Worktrees++
+			case strings.HasPrefix(line, "prunable"):
+				status.PrunableWorktrees++
+			}
+		}
+		// The first entry is the checkout itself
+		status.Worktrees = max(status.Worktrees-1, 0)
+	}
+
+	status.FetchedAt = prefetched.FetchedAt(repo.Path)
+	if commonDir, err := gitOutput(ctx, runner, repo.Path, "rev-parse", "--path-format=absolute", "--git-common-dir"); err == nil {
+		if info, err := os.Stat(filepath.Join(commonDir, "FETCH_HEAD")); err == nil && info.ModTime().After(status.FetchedAt) {
+			status.FetchedAt = info.ModTime()
+		}
+	}
+
+	remotes, _ := gitOutput(ctx, runner, repo.Path, "remote")
+	refs, _ := gitOutput(ctx, runner, repo.Path, "for-each-ref", "--format=%(refname)", "refs/remotes/")
+	for _, remote := range strings.Fields(remotes) {
+		if !strings.Contains("\n"+refs, "\nrefs/remotes/"+remote+"/") {
+			status.UnfetchedRemotes = append(status.UnfetchedRemotes, remote)
+		}
+	}
+	return status
+}
+
+// porcelainStatus is the output of 'git status --porcelain=v2 --branch'
+type porcelainStatus struct {
+	// Branch is empty when HEAD is detached
+	Branch   string
+	Detached bool
+	Upstream string
+	Ahead    int
+	Behind   int
+	// Changed counts tracked files with changes, including the conflicted ones
+	Changed    int
+	Conflicted int
+	Untracked  int
+}
+
+func parsePorcelainStatus(out string) porcelainStatus {
+	var status porcelainStatus
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+		case fields[0] == "#" && len(fields) >= 3 && fields[1] == "branch.head":
+			if fields[2] == "(detached)" {
+				status.Detached = true
+			} else {
+				status.Branch = fields[2]
+			}
+		case fields[0] == "#" && len(fields) >= 3 && fields[1] == "branch.upstream":
+			status.Upstream = fields[2]
+		case fields[0] == "#" && len(fields) == 4 && fields[1] == "branch.ab":
+			status.Ahead, _ = strconv.Atoi(strings.TrimPrefix(fields[2], "+"))
+			status.Behind, _ = strconv.Atoi(strings.TrimPrefix(fields[3], "-"))
+		case fields[0] == "?":
+			status.Untracked++
+		case fields[0] == "u":
+			status.Changed++
+			status.Conflicted++
+		case fields[0] != "#" && fields[0] != "!":
+			status.Changed++
+		}
+	}
+	return status
+}
@@ -0,0 +1,53 @@
+package wsm
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParsePorcelainStatus(t *testing.T) {
+	out := `# branch.oid 1234567890abcdef
+# branch.head main
+# branch.upstream origin/main
+# branch.ab +2 -5
+1 .M N... 100644 100644 100644 aaa bbb main.go
+2 R. N... 100644 100644 100644 aaa bbb R100 new.go	old.go
+u UU N... 100644 100644 100644 100644 aaa bbb ccc conflict.go
+? notes.txt
+? tmp/
+! build/
+`
+	want := porcelainStatus{Branch: "main", Upstream: "origin/main", Ahead: 2, Behind: 5, Changed: 3, Conflicted: 1, Untracked: 2}
+	if got := parsePorcelainStatus(out); got != want {
+		t.Errorf("parsePorcelainStatus = %+v, want %+v", got, want)
+	}
+
+	detached := parsePorcelainStatus("# branch.oid 1234567\n# branch.head (detached)\n")
+	if detached != (porcelainStatus{Detached: true}) {
+		t.Errorf("parsePorcelainStatus of a detached HEAD = %+v", detached)
+	}
+}
+
+func TestSourceStatusProblems(t *testing.T) {
+	tests := []struct {
+		name   string
+		status SourceStatus
+		want   []string
+	}{
+		{"clean", SourceStatus{FetchedAt: time.Now()}, nil},
+		{"missing", SourceStatus{Missing: true, Changed: 3}, []string{"path does not exist"}},
+		{"error", SourceStatus{Error: "not a git repository"}, []string{"not a git repository"}},
+		{
+			"everything",
+			SourceStatus{Operation: "rebase", Detached: true, Changed: 1, Behind: 4, Upstream: "origin/main", PrunableWorktrees: 2, UnfetchedRemotes: []string{"upstream", "fork"}},
+			[]string{"rebase in progress", "detached HEAD", "1 uncommitted change", "4 behind origin/main", "2 prunable worktrees", "never fetched: upstream, fork"},
+		},
+		{"stale fetch", SourceStatus{Changed: 2, FetchedAt: time.Now().Add(-10 * 24 * time.Hour)}, []string{"2 uncommitted changes", "fetched 1 week ago"}},
+	}
+	for _, tt := range tests {
+		if got := tt.status.Problems(); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: Problems() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
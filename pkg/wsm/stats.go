@@ -0,0 +1,117 @@
+package wsm
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// RepositoryStats aggregates the contributions on the workspace branch of one repository
+type RepositoryStats struct {
+	Repository   string         `json:"repository"`
+	Commits      int            `json:"commits"`
+	Insertions   int            `json:"insertions"`
+	Deletions    int            `json:"deletions"`
+	FilesTouched int            `json:"files_touched"`
+	Authors      map[string]int `json:"authors"`
+}
+
+// WorkspaceStats is the contribution report of a workspace branch across all repositories
+type WorkspaceStats struct {
+	Workspace    string            `json:"workspace"`
+	Base         string            `json:"base"`
+	Since        string            `json:"since,omitempty"`
+	Repositories []RepositoryStats `json:"repositories"`
+	Total        RepositoryStats   `json:"total"`
+}
+
+// AuthorNames returns the authors sorted by commit count, then name
+func (s RepositoryStats) AuthorNames() []string {
+	names := make([]string, 0, len(s.Authors))
+	for name := range s.Authors {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if s.Authors[names[i]] != s.Authors[names[j]] {
+			return s.Authors[names[i]] > s.Authors[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// GetWorkspaceStats collects commit, line, file and author statistics for the non-merge commits
+// in <base>..HEAD of every repository, optionally limited to commits newer than since
+func GetWorkspaceStats(ctx context.Context, workspace *Workspace, base, since string) (*WorkspaceStats, error) {
+	stats := &WorkspaceStats{
+		Workspace: workspace.Name,
+		Base:      WorkspaceBaseRef(workspace, base),
+		Since:     since,
+		Total:     RepositoryStats{Repository: "total", Authors: make(map[string]int)},
+	}
+
+	totalFiles := make(map[string]bool)
+	for _, repo := range workspace.Repositories {
+		repoStats, files, err := getRepositoryStats(ctx, filepath.Join(workspace.Path, repo.Name), stats.Base, since)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to collect stats for %s", repo.Name)
+		}
+		repoStats.Repository = repo.Name
+		stats.Repositories = append(stats.Repositories, *repoStats)
+
+		stats.Total.Commits += repoStats.Commits
+		stats.Total.Insertions += repoStats.Insertions
+		stats.Total.Deletions += repoStats.Deletions
+		for author, count := range repoStats.Authors {
+			stats.Total.Authors[author] += count
+		}
+		for file := range files {
+			totalFiles[repo.Name+"/"+file] = true
+		}
+	}
+	stats.Total.FilesTouched = len(totalFiles)
+
+	return stats, nil
+}
+
+func getRepositoryStats(ctx context.Context, worktreePath, base, since string) (*RepositoryStats, map[string]bool, error) {
+	args := []string{"log", "--no-merges", "--numstat", "--format=%x00%an", base + "..HEAD"}
+	if since != "" {
+		args = append(args, "--since="+since)
+	}
+
+	out, err := runGitOutput(ctx, worktreePath, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stats := &RepositoryStats{Authors: make(map[string]int)}
+	files := make(map[string]bool)
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "\x00") {
+			stats.Commits++
+			stats.Authors[strings.TrimPrefix(line, "\x00")]++
+			continue
+		}
+
+		// numstat lines: <added>\t<deleted>\t<path>, with "-" for binary files
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		if added, err := strconv.Atoi(fields[0]); err == nil {
+			stats.Insertions += added
+		}
+		if deleted, err := strconv.Atoi(fields[1]); err == nil {
+			stats.Deletions += deleted
+		}
+		files[fields[2]] = true
+	}
+	stats.FilesTouched = len(files)
+
+	return stats, files, nil
+}
@@ -0,0 +1,74 @@
+package wsm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGetWorkspaceStats(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	dir := filepath.Join(root, "lib")
+	testGit(t, root, "init", "--quiet", dir)
+	writeGoFiles(t, dir, map[string]string{
+		"a.txt":   "one\ntwo\n",
+		"old.txt": "a file that is only renamed\n",
+	})
+	testGit(t, dir, "add", ".")
+	testGit(t, dir, "commit", "--quiet", "-m", "Initial")
+	testGit(t, dir, "checkout", "--quiet", "-b", "feature/x")
+
+	// Alice changes a text file and adds a binary one, Bob renames a file
+	writeGoFiles(t, dir, map[string]string{"a.txt": "one\nTWO\nthree\n"})
+	if err := os.WriteFile(filepath.Join(dir, "logo.bin"), []byte{0, 1, 2, 0, 255}, 0644); err != nil {
+		t.Fatal(err)
+	}
+	testGit(t, dir, "add", ".")
+	testGit(t, dir, "commit", "--quiet", "--author", "Alice <alice@example.com>", "-m", "Change a")
+	testGit(t, dir, "mv", "old.txt", "new.txt")
+	testGit(t, dir, "commit", "--quiet", "--author", "Bob <bob@example.com>", "-m", "Rename old")
+	writeGoFiles(t, dir, map[string]string{"a.txt": "one\nTWO\nthree\nfour\n"})
+	testGit(t, dir, "commit", "--quiet", "-a", "--author", "Alice <alice@example.com>", "-m", "Extend a")
+
+	// Neither merging the base back nor the commits it brings in are counted
+	testGit(t, dir, "checkout", "--quiet", "main")
+	commitFile(t, dir, "upstream.txt", "upstream\n", "Upstream")
+	testGit(t, dir, "checkout", "--quiet", "feature/x")
+	testGit(t, dir, "merge", "--quiet", "--no-ff", "-m", "Merge main", "main")
+
+	workspace := &Workspace{Name: "feat", Path: root, Repositories: []Repository{{Name: "lib"}}}
+	stats, err := GetWorkspaceStats(ctx, workspace, "main", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lib := stats.Repositories[0]
+	// a.txt +3 -1, logo.bin counted as a file without lines, the rename without changes
+	if lib.Repository != "lib" || lib.Commits != 3 || lib.Insertions != 3 || lib.Deletions != 1 || lib.FilesTouched != 3 {
+		t.Errorf("lib = %+v, want 3 commits, +3 -1 in 3 files", lib)
+	}
+	if want := map[string]int{"Alice": 2, "Bob": 1}; !reflect.DeepEqual(lib.Authors, want) {
+		t.Errorf("authors = %v, want %v", lib.Authors, want)
+	}
+	if names := lib.AuthorNames(); !reflect.DeepEqual(names, []string{"Alice", "Bob"}) {
+		t.Errorf("author names = %v", names)
+	}
+	if stats.Total.Commits != 3 || stats.Total.FilesTouched != 3 || stats.Total.Repository != "total" {
+		t.Errorf("total = %+v", stats.Total)
+	}
+
+	// An empty range has no commits and no authors
+	stats, err = GetWorkspaceStats(ctx, workspace, "feature/x", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if empty := stats.Repositories[0]; empty.Commits != 0 || empty.FilesTouched != 0 || len(empty.Authors) != 0 {
+		t.Errorf("empty range = %+v", empty)
+	}
+
+	if _, err := GetWorkspaceStats(ctx, workspace, "missing", ""); err == nil {
+		t.Error("expected an error for an unknown base")
+	}
+}
@@ -2,25 +2,96 @@ package wsm
 
 import (
 	"context"
-	"os/exec"
+	"maps"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 )
 
 // StatusChecker handles workspace status operations
-type StatusChecker struct{}
+type StatusChecker struct {
+	// Config selects the remotes divergence is reported against
+	Config StatusConfig
+	// Runner runs git
+	Runner CommandRunner
+}
+
+// OldestFetch returns when the least recently fetched repository was fetched, and false if
+// some repository was never fetched by 'wsm prefetch'
+func (s *WorkspaceStatus) OldestFetch() (time.Time, bool) {
+	var oldest time.Time
+	for _, repo := range s.Repositories {
+		if repo.FetchedAt.IsZero() {
+			return time.Time{}, false
+		}
+		if oldest.IsZero() || repo.FetchedAt.Before(oldest) {
+			oldest = repo.FetchedAt
+		}
+	}
+	return oldest, !oldest.IsZero()
+}
+
+// StatusGroup is the status of the repositories sharing a category, with rollup counts
+type StatusGroup struct {
+	Category     string             `json:"category"`
+	Repositories []RepositoryStatus `json:"repositories"`
+	// Dirty and Conflicted count repositories, Ahead and Behind are the sums of their commits
+	Dirty      int `json:"dirty"`
+	Conflicted int `json:"conflicted"`
+	Ahead      int `json:"ahead"`
+	Behind     int `json:"behind"`
+}
+
+// GroupByCategory groups the repositories by the categories discovery tagged them with (go, node,
+// docker, ...), in category order. A repository with several categories is part of each of their
+// groups; one without is in the group "unknown".
+func (s *WorkspaceStatus) GroupByCategory() []StatusGroup {
+	groups := map[string]*StatusGroup{}
+	for _, repo := range s.Repositories {
+		categories := repo.Repository.Categories
+		if len(categories) == 0 {
+			categories = []string{"unknown"}
+		}
+		for _, category := range slices.Compact(slices.Sorted(slices.Values(categories))) {
+			group, ok := groups[category]
+			if !ok {
+				group = &StatusGroup{Category: category}
+				groups[category] = group
+			}
+			group.Repositories = append(group.Repositories, repo)
+			if repo.HasChanges {
+				group.Dirty++
+			}
+			if repo.HasConflicts {
+				group.Conflicted++
+			}
+			group.Ahead += repo.Ahead
+			group.Behind += repo.Behind
+		}
+	}
+
+	var result []StatusGroup
+	for _, category := range slices.Sorted(maps.Keys(groups)) {
+		result = append(result, *groups[category])
+	}
+	return result
+}
 
 // NewStatusChecker creates a new status checker
 func NewStatusChecker() *StatusChecker {
-	return &StatusChecker{}
+	return &StatusChecker{Runner: ExecRunner{}}
 }
 
 // GetWorkspaceStatus gets the status of a workspace
 func (sc *StatusChecker) GetWorkspaceStatus(ctx context.Context, workspace *Workspace) (*WorkspaceStatus, error) {
 	var repoStatuses []RepositoryStatus
+	// Status never fetches; the time of the last prefetch tells how current ahead/behind are
+	prefetched, _ := LoadPrefetchState()
 
 	for _, repo := range workspace.Repositories {
 		repoPath := filepath.Join(workspace.Path, repo.Name)
@@ -28,6 +99,9 @@ func (sc *StatusChecker) GetWorkspaceStatus(ctx context.Context, workspace *Work
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to get status for repository %s", repo.Name)
 		}
+		status.Frozen = workspace.IsFrozen(repo.Name)
+		status.FetchedAt = prefetched.FetchedAt(repo.Path)
+		status.Encryption = DetectEncryption(repoPath)
 		repoStatuses = append(repoStatuses, *status)
 	}
 
@@ -37,6 +111,53 @@ func (sc *StatusChecker) GetWorkspaceStatus(ctx context.Context, workspace *Work
 		Workspace:    *workspace,
 		Repositories: repoStatuses,
 		Overall:      overall,
+		Container:    ContainerState(ctx, sc.Runner, workspace),
+	}, nil
+}
+
+// GetFastWorkspaceStatus gets only the branch, ahead/behind counts and whether there are
+// uncommitted changes of each repository, with a single git invocation per repository run
+// concurrently, for prompts and dashboards. File lists, untracked files, remote divergence,
+// merge and rebase checks and the container state are left out.
+func (sc *StatusChecker) GetFastWorkspaceStatus(ctx context.Context, workspace *Workspace) (*WorkspaceStatus, error) {
+	repoStatuses := make([]RepositoryStatus, len(workspace.Repositories))
+	errs := make([]error, len(workspace.Repositories))
+	var wg sync.WaitGroup
+	for i, repo := range workspace.Repositories {
+		wg.Add(1)
+		go func(i int, repo Repository) {
+			defer wg.Done()
+			repoPath := filepath.Join(workspace.Path, repo.Name)
+			// No optional locks: a prompt must not contend with git commands the user runs
+			out, err := gitOutput(ctx, sc.Runner, repoPath, "--no-optional-locks", "status",
+				"--porcelain=v2", "--branch", "--untracked-files=no", "--ignore-submodules=dirty")
+			if err != nil {
+				errs[i] = errors.Wrapf(err, "failed to get status for repository %s", repo.Name)
+				return
+			}
+			porcelain := parsePorcelainStatus(out)
+			repoStatuses[i] = RepositoryStatus{
+				Repository:    repo,
+				CurrentBranch: porcelain.Branch,
+				HasChanges:    porcelain.Changed > 0,
+				HasConflicts:  porcelain.Conflicted > 0,
+				Ahead:         porcelain.Ahead,
+				Behind:        porcelain.Behind,
+				Frozen:        workspace.IsFrozen(repo.Name),
+			}
+		}(i, repo)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &WorkspaceStatus{
+		Workspace:    *workspace,
+		Repositories: repoStatuses,
+		Overall:      sc.calculateOverallStatus(repoStatuses),
 	}, nil
 }
 
@@ -76,18 +197,23 @@ func (sc *StatusChecker) getRepositoryStatus(ctx context.Context, repo Repositor
 		status.Behind = behind
 	}
 
+	// Get divergence against each comparison remote (e.g. fork and upstream)
+	if remotes, err := getRemoteDivergence(ctx, sc.Runner, repoPath, status.CurrentBranch, sc.Config.RemotesFor(repo.Name)); err == nil {
+		status.Remotes = remotes
+	}
+
 	// Check for conflicts
 	if hasConflicts, err := sc.hasConflicts(ctx, repoPath); err == nil {
 		status.HasConflicts = hasConflicts
 	}
 
 	// Check if branch is merged to origin/main
-	if isMerged, err := CheckBranchMerged(ctx, repoPath); err == nil {
+	if isMerged, err := checkBranchMerged(ctx, sc.Runner, repoPath); err == nil {
 		status.IsMerged = isMerged
 	}
 
 	// Check if branch needs to be rebased on origin/main
-	if needsRebase, err := CheckBranchNeedsRebase(ctx, repoPath); err == nil {
+	if needsRebase, err := checkBranchNeedsRebase(ctx, sc.Runner, repoPath); err == nil {
 		status.NeedsRebase = needsRebase
 	}
 
@@ -96,85 +222,66 @@ func (sc *StatusChecker) getRepositoryStatus(ctx context.Context, repo Repositor
 
 // getCurrentBranch gets the current branch name
 func (sc *StatusChecker) getCurrentBranch(ctx context.Context, repoPath string) (string, error) {
-	cmd := exec.CommandContext(ctx, "git", "branch", "--show-current")
-	cmd.Dir = repoPath
-	output, err := cmd.Output()
-	if err != nil {
-		return "", err
-	}
-	return strings.TrimSpace(string(output)), nil
+	return gitOutput(ctx, sc.Runner, repoPath, "branch", "--show-current")
 }
 
 // getModifiedFiles gets modified files
 func (sc *StatusChecker) getModifiedFiles(ctx context.Context, repoPath string) ([]string, error) {
-	cmd := exec.CommandContext(ctx, "git", "diff", "--name-only")
-	cmd.Dir = repoPath
-	output, err := cmd.Output()
+	output, err := gitOutput(ctx, sc.Runner, repoPath, "diff", "--name-only")
 	if err != nil {
 		return nil, err
 	}
 
-	if len(output) == 0 {
+	if output == "" {
 		return []string{}, nil
 	}
 
-	files := strings.Split(strings.TrimSpace(string(output)), "\n")
-	return files, nil
+	return strings.Split(output, "\n"), nil
 }
 
 // getStagedFiles gets staged files
 func (sc *StatusChecker) getStagedFiles(ctx context.Context, repoPath string) ([]string, error) {
-	cmd := exec.CommandContext(ctx, "git", "diff", "--cached", "--name-only")
-	cmd.Dir = repoPath
-	output, err := cmd.Output()
+	output, err := gitOutput(ctx, sc.Runner, repoPath, "diff", "--cached", "--name-only")
 	if err != nil {
 		return nil, err
 	}
 
-	if len(output) == 0 {
+	if output == "" {
 		return []string{}, nil
 	}
 
-	files := strings.Split(strings.TrimSpace(string(output)), "\n")
-	return files, nil
+	return strings.Split(output, "\n"), nil
 }
 
 // getUntrackedFiles gets untracked files
 func (sc *StatusChecker) getUntrackedFiles(ctx context.Context, repoPath string) ([]string, error) {
-	cmd := exec.CommandContext(ctx, "git", "ls-files", "--others", "--exclude-standard")
-	cmd.Dir = repoPath
-	output, err := cmd.Output()
+	output, err := gitOutput(ctx, sc.Runner, repoPath, "ls-files", "--others", "--exclude-standard")
 	if err != nil {
 		return nil, err
 	}
 
-	if len(output) == 0 {
+	if output == "" {
 		return []string{}, nil
 	}
 
-	files := strings.Split(strings.TrimSpace(string(output)), "\n")
-	return files, nil
+	return strings.Split(output, "\n"), nil
 }
 
 // getAheadBehind gets ahead/behind commit counts
 func (sc *StatusChecker) getAheadBehind(ctx context.Context, repoPath string) (int, int, error) {
 	// First check if we have a remote tracking branch
-	cmd := exec.CommandContext(ctx, "git", "rev-parse", "--abbrev-ref", "@{upstream}")
-	cmd.Dir = repoPath
-	if _, err := cmd.Output(); err != nil {
+	if _, err := gitOutput(ctx, sc.Runner, repoPath, "rev-parse", "--abbrev-ref", "@{upstream}"); err != nil {
 		// No upstream configured
 		return 0, 0, nil
 	}
 
 	// Get ahead/behind counts
-	cmd = exec.CommandContext(ctx, "git", "rev-list", "--left-right", "--count", "HEAD...@{upstream}")
-	cmd.Dir = repoPath
-	output, err := cmd.Output()
+	output, err := gitOutput(ctx, sc.Runner, repoPath, "rev-list", "--left-right", "--count", "HEAD...@{upstream}")
 	if err != nil {
 		return 0, 0, err
 	}
 
-	parts := strings.Fields(strings.TrimSpace(string(output)))
+	parts := strings.Fields(output)
 	if len(parts) != 2 {
 		return 0, 0, errors.New("unexpected git rev-list output")
 	}
@@ -194,14 +301,12 @@ func (sc *StatusChecker) getAheadBehind(ctx context.Context, repoPath string) (i
 
 // hasConflicts checks if there are merge conflicts
 func (sc *StatusChecker) hasConflicts(ctx context.Context, repoPath string) (bool, error) {
-	cmd := exec.CommandContext(ctx, "git", "status", "--porcelain")
-	cmd.Dir = repoPath
-	output, err := cmd.Output()
+	output, err := gitOutput(ctx, sc.Runner, repoPath, "status", "--porcelain")
 	if err != nil {
 		return false, err
 	}
 
-	lines := strings.Split(string(output), "\n")
+	lines := strings.Split(output, "\n")
 	for _, line := range lines {
 		if len(line) >= 2 && (line[0] == 'U' || line[1] == 'U' ||
 			(line[0] == 'A' && line[1] == 'A') ||
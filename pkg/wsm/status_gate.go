@@ -0,0 +1,111 @@
+package wsm
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/pkg/errors"
+)
+
+// Repository conditions 'wsm status --fail-on' can fail on
+const (
+	// StatusConditionDirty is a worktree with uncommitted changes
+	StatusConditionDirty = "dirty"
+	// StatusConditionBehind is a branch behind its upstream
+	StatusConditionBehind = "behind"
+	// StatusConditionConflict is a worktree with merge conflicts or a branch diverged from its upstream
+	StatusConditionConflict = "conflict"
+)
+
+// StatusConditions lists the conditions accepted by --fail-on
+var StatusConditions = []string{StatusConditionDirty, StatusConditionBehind, StatusConditionConflict}
+
+// StatusExitCodes are the exit statuses per condition; unset codes use the defaults
+// (dirty 1, behind 1, conflict 2)
+type StatusExitCodes struct {
+	Dirty    int `json:"dirty,omitempty" yaml:"dirty,omitempty"`
+	Behind   int `json:"behind,omitempty" yaml:"behind,omitempty"`
+	Conflict int `json:"conflict,omitempty" yaml:"conflict,omitempty"`
+}
+
+// Code returns the exit status of a condition
+func (c StatusExitCodes) Code(condition string) int {
+	code, fallback := 0, 1
+	switch condition {
+	case StatusConditionDirty:
+		code = c.Dirty
+	case StatusConditionBehind:
+		code = c.Behind
+	case StatusConditionConflict:
+		code, fallback = c.Conflict, 2
+	}
+	if code == 0 {
+		return fallback
+	}
+	return code
+}
+
+// StatusFailure is a repository matching a condition the status gate fails on
+type StatusFailure struct {
+	Repository string
+	Condition  string
+	Detail     string
+}
+
+// ValidateStatusConditions rejects unknown --fail-on conditions
+func ValidateStatusConditions(conditions []string) error {
+	for _, condition := range conditions {
+		if !slices.Contains(StatusConditions, condition) {
+			return errors.Errorf("unknown status condition '%s' (expected dirty, behind or conflict)", condition)
+		}
+	}
+	return nil
+}
+
+// RepositoryConditions returns the conditions a repository is in, with a short description each
+func RepositoryConditions(status RepositoryStatus) map[string]string {
+	conditions := map[string]string{}
+	switch {
+	case status.HasChanges && len(status.StagedFiles)+len(status.ModifiedFiles) == 0:
+		// The fast status does not list files
+		conditions[StatusConditionDirty] = "uncommitted changes"
+	case status.HasChanges:
+		conditions[StatusConditionDirty] = fmt.Sprintf("%d staged, %d modified", len(status.StagedFiles), len(status.ModifiedFiles))
+	}
+	if status.Behind > 0 {
+		conditions[StatusConditionBehind] = fmt.Sprintf("%d behind", status.Behind)
+	}
+	switch {
+	case status.HasConflicts:
+		conditions[StatusConditionConflict] = "merge conflicts"
+	case status.Ahead > 0 && status.Behind > 0:
+		conditions[StatusConditionConflict] = fmt.Sprintf("diverged, %d ahead and %d behind", status.Ahead, status.Behind)
+	}
+	return conditions
+}
+
+// EvaluateStatusGate checks the workspace status against the conditions to fail on. It returns
+// the highest exit code of the failing conditions, 0 when none matched, and the failures in
+// repository order. Frozen repositories are not checked.
+func EvaluateStatusGate(status *WorkspaceStatus, failOn []string, codes StatusExitCodes) (int, []StatusFailure) {
+	exitCode := 0
+	var failures []StatusFailure
+	for _, repo := range status.Repositories {
+		if repo.Frozen {
+			continue
+		}
+		conditions := RepositoryConditions(repo)
+		for _, condition := range sortedKeys(conditions) {
+			if !slices.Contains(failOn, condition) {
+				continue
+			}
+			failures = append(failures, StatusFailure{
+				Repository: repo.Repository.Name,
+				Condition:  condition,
+				Detail:     conditions[condition],
+			})
+			exitCode = max(exitCode, codes.Code(condition))
+		}
+	}
+	return exitCode, failures
+}
@@ -0,0 +1,82 @@
+package wsm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateStatusConditions(t *testing.T) {
+	if err := ValidateStatusConditions([]string{"dirty", "conflict"}); err != nil {
+		t.Errorf("ValidateStatusConditions failed: %v", err)
+	}
+	if err := ValidateStatusConditions([]string{"dirty", "ahead"}); err == nil {
+		t.Error("expected an error for an unknown condition")
+	}
+}
+
+func TestStatusExitCodes(t *testing.T) {
+	defaults := StatusExitCodes{}
+	custom := StatusExitCodes{Behind: 3, Conflict: 4}
+	tests := []struct {
+		codes     StatusExitCodes
+		condition string
+		want      int
+	}{
+		{defaults, StatusConditionDirty, 1},
+		{defaults, StatusConditionBehind, 1},
+		{defaults, StatusConditionConflict, 2},
+		{custom, StatusConditionDirty, 1},
+		{custom, StatusConditionBehind, 3},
+		{custom, StatusConditionConflict, 4},
+	}
+	for _, tt := range tests {
+		if got := tt.codes.Code(tt.condition); got != tt.want {
+			t.Errorf("%+v.Code(%s) = %d, want %d", tt.codes, tt.condition, got, tt.want)
+		}
+	}
+}
+
+func TestRepositoryConditions(t *testing.T) {
+	tests := []struct {
+		name   string
+		status RepositoryStatus
+		want   map[string]string
+	}{
+		{"clean", RepositoryStatus{Ahead: 2}, map[string]string{}},
+		{"fast status", RepositoryStatus{HasChanges: true}, map[string]string{"dirty": "uncommitted changes"}},
+		{"files", RepositoryStatus{HasChanges: true, StagedFiles: []string{"a"}, ModifiedFiles: []string{"b", "c"}}, map[string]string{"dirty": "1 staged, 2 modified"}},
+		{"behind", RepositoryStatus{Behind: 3}, map[string]string{"behind": "3 behind"}},
+		{"diverged", RepositoryStatus{Ahead: 1, Behind: 2}, map[string]string{"behind": "2 behind", "conflict": "diverged, 1 ahead and 2 behind"}},
+		{"conflicts", RepositoryStatus{HasChanges: true, HasConflicts: true, Ahead: 1, Behind: 1}, map[string]string{"dirty": "uncommitted changes", "behind": "1 behind", "conflict": "merge conflicts"}},
+	}
+	for _, tt := range tests {
+		if got := RepositoryConditions(tt.status); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: RepositoryConditions = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEvaluateStatusGate(t *testing.T) {
+	status := &WorkspaceStatus{Repositories: []RepositoryStatus{
+		{Repository: Repository{Name: "api"}, HasChanges: true},
+		{Repository: Repository{Name: "web"}, Ahead: 1, Behind: 2},
+		{Repository: Repository{Name: "vendored"}, HasConflicts: true, Frozen: true},
+	}}
+
+	code, failures := EvaluateStatusGate(status, []string{"dirty", "behind", "conflict"}, StatusExitCodes{})
+	want := []StatusFailure{
+		{Repository: "api", Condition: "dirty", Detail: "uncommitted changes"},
+		{Repository: "web", Condition: "behind", Detail: "2 behind"},
+		{Repository: "web", Condition: "conflict", Detail: "diverged, 1 ahead and 2 behind"},
+	}
+	if code != 2 || !reflect.DeepEqual(failures, want) {
+		t.Errorf("EvaluateStatusGate = %d, %+v, want 2, %+v", code, failures, want)
+	}
+
+	if code, failures := EvaluateStatusGate(status, []string{"dirty"}, StatusExitCodes{Dirty: 7}); code != 7 || len(failures) != 1 {
+		t.Errorf("EvaluateStatusGate(dirty) = %d, %+v", code, failures)
+	}
+	if code, failures := EvaluateStatusGate(status, nil, StatusExitCodes{}); code != 0 || len(failures) != 0 {
+		t.Errorf("EvaluateStatusGate without conditions = %d, %+v", code, failures)
+	}
+}
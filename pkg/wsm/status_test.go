@@ -0,0 +1,85 @@
+package wsm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGroupByCategory(t *testing.T) {
+	repo := func(name string, categories ...string) Repository {
+		return Repository{Name: name, Categories: categories}
+	}
+	names := func(group StatusGroup) []string {
+		var result []string
+		for _, status := range group.Repositories {
+			result = append(result, status.Repository.Name)
+		}
+		return result
+	}
+
+	tests := []struct {
+		name   string
+		repos  []RepositoryStatus
+		want   []StatusGroup
+		repoIn map[string][]string
+	}{
+		{name: "empty"},
+		{
+			name: "rollup",
+			repos: []RepositoryStatus{
+				{Repository: repo("api", "go"), HasChanges: true, Ahead: 2},
+				{Repository: repo("cli", "go"), HasChanges: true, HasConflicts: true, Ahead: 1, Behind: 3},
+				{Repository: repo("web", "node"), Behind: 1},
+			},
+			want: []StatusGroup{
+				{Category: "go", Dirty: 2, Conflicted: 1, Ahead: 3, Behind: 3},
+				{Category: "node", Behind: 1},
+			},
+			repoIn: map[string][]string{"go": {"api", "cli"}, "node": {"web"}},
+		},
+		{
+			name: "untagged repositories",
+			repos: []RepositoryStatus{
+				{Repository: repo("notes"), HasChanges: true},
+				{Repository: repo("api", "go")},
+			},
+			want: []StatusGroup{
+				{Category: "go"},
+				{Category: "unknown", Dirty: 1},
+			},
+			repoIn: map[string][]string{"go": {"api"}, "unknown": {"notes"}},
+		},
+		{
+			name: "several categories",
+			repos: []RepositoryStatus{
+				// Duplicate categories count once
+				{Repository: repo("app", "node", "docker", "go", "docker"), HasChanges: true, Ahead: 1},
+				{Repository: repo("api", "go")},
+			},
+			want: []StatusGroup{
+				{Category: "docker", Dirty: 1, Ahead: 1},
+				{Category: "go", Dirty: 1, Ahead: 1},
+				{Category: "node", Dirty: 1, Ahead: 1},
+			},
+			repoIn: map[string][]string{"docker": {"app"}, "go": {"app", "api"}, "node": {"app"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := &WorkspaceStatus{Repositories: tt.repos}
+			groups := status.GroupByCategory()
+			if len(groups) != len(tt.want) {
+				t.Fatalf("got %d groups, want %d: %+v", len(groups), len(tt.want), groups)
+			}
+			for i, group := range groups {
+				if want := tt.repoIn[group.Category]; !reflect.DeepEqual(names(group), want) {
+					t.Errorf("group %s holds %v, want %v", group.Category, names(group), want)
+				}
+				group.Repositories = nil
+				if !reflect.DeepEqual(group, tt.want[i]) {
+					t.Errorf("group %d = %+v, want %+v", i, group, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package wsm
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/pkg/errors"
+)
+
+const (
+	// SwitchCheckout checks out a local branch that already exists
+	SwitchCheckout = "checkout"
+	// SwitchTrack creates the branch from origin/<branch>
+	SwitchTrack = "track"
+	// SwitchCreate creates the branch from the base
+	SwitchCreate = "create"
+	// SwitchCurrent leaves a repository that is already on the branch
+	SwitchCurrent = "current"
+)
+
+// SwitchStep is how one repository of a workspace is moved to another branch
+type SwitchStep struct {
+	Repository string `json:"repository"`
+	Action     string `json:"action"`
+	// From is the branch the worktree is on before switching
+	From string `json:"from"`
+	// Base is the starting point of a created branch
+	Base string `json:"base,omitempty"`
+}
+
+// PlanSwitch determines, for every repository of the workspace, how its worktree gets onto branch.
+// Missing branches are created from origin/<branch> when it exists and from base otherwise; base
+// defaults to the workspace base branch, then to the branch checked out in the source repository.
+// Repositories with uncommitted changes make the plan fail unless force is set.
+func (wm *WorkspaceManager) PlanSwitch(ctx context.Context, workspace *Workspace, branch, base string, force bool) ([]SwitchStep, error) {
+	if err := workspace.RequireWorktrees("switching"); err != nil {
+		return nil, err
+	}
+	if base == "" {
+		base = workspace.BaseBranch
+	}
+
+	var steps []SwitchStep
+	var dirty []string
+	for _, repo := range workspace.Repositories {
+		worktreePath := filepath.Join(workspace.Path, repo.Name)
+
+		current, err := getGitCurrentBranch(ctx, wm.runner(), worktreePath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get current branch of '%s'", repo.Name)
+		}
+		status, err := gitOutput(ctx, wm.runner(), worktreePath, "status", "--porcelain", "--untracked-files=no")
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get status of '%s'", repo.Name)
+		}
+		if status != "" {
+			dirty = append(dirty, repo.Name)
+		}
+
+		step := SwitchStep{Repository: repo.Name, From: current}
+		switch {
+		case current == branch:
+			step.Action = SwitchCurrent
+		default:
+			exists, _ := wm.CheckBranchExists(ctx, worktreePath, branch)
+			remoteExists, _ := wm.CheckRemoteBranchExists(ctx, worktreePath, branch)
+			switch {
+			case exists:
+				step.Action = SwitchCheckout
+			case remoteExists:
+				step.Action = SwitchTrack
+				step.Base = "origin/" + branch
+			default:
+				step.Action = SwitchCreate
+				step.Base = base
+				if step.Base == "" {
+					// The source repository usually stays on its default branch
+					if step.Base, err = getGitCurrentBranch(ctx, wm.runner(), repo.Path); err != nil {
+						return nil, errors.Wrapf(err, "failed to get base branch of '%s'", repo.Name)
+					}
+				}
+			}
+		}
+		steps = append(steps, step)
+	}
+
+	if len(dirty) > 0 && !force {
+		return nil, errors.Errorf("repositories have uncommitted changes: %s (commit or stash them, or use --force to carry them over)", strings.Join(dirty, ", "))
+	}
+
+	return steps, nil
+}
+
+// ApplySwitch moves every worktree onto branch following the plan and records the new branch in the
+// workspace configuration. When a repository fails, the repositories already switched are moved
+// back to their previous branch so the workspace stays on a single branch.
+func (wm *WorkspaceManager) ApplySwitch(ctx context.Context, workspace *Workspace, branch string, steps []SwitchStep) error {
+	start := time.Now()
+	var switched []SwitchStep
+	for _, step := range steps {
+		worktreePath := filepath.Join(workspace.Path, step.Repository)
+
+		var args []string
+		switch step.Action {
+		case SwitchCurrent:
+			continue
+		case SwitchCheckout:
+			args = []string{"checkout", branch}
+		case SwitchTrack:
+			args = []string{"checkout", "-b", branch, "--track", step.Base}
+		case SwitchCreate:
+			args = []string{"checkout", "-b", branch, step.Base}
+		}
+
+		if _, err := gitOutput(ctx, wm.runner(), worktreePath, args...); err != nil {
+			wm.rollbackSwitch(ctx, workspace, branch, switched)
+			return errors.Wrapf(err, "failed to switch '%s' to '%s'", step.Repository, branch)
+		}
+		switched = append(switched, step)
+		output.LogInfo(
+			fmt.Sprintf("Switched '%s' from '%s' to '%s'", step.Repository, step.From, branch),
+			"Switched repository branch",
+			"repository", step.Repository,
+			"from", step.From,
+			"branch", branch,
+		)
+	}
+
+	previous := workspace.Branch
+	workspace.Branch = branch
+	// Branch points belong to the previous branch; the new branch has its own
+	workspace.BranchPoints = nil
+	for _, repo := range workspace.Repositories {
+		recordBranchPoint(ctx, workspace, repo.Name)
+	}
+	if err := wm.SaveWorkspace(workspace); err != nil {
+		return errors.Wrap(err, "failed to save workspace configuration")
+	}
+
+	RecordOperation("switch", workspace.Name, start, map[string]string{"from": previous, "branch": branch})
+	return nil
+}
+
+// rollbackSwitch checks the previous branches out again after a failed switch and deletes the
+// branches the switch created, so a retry plans them from their base again
+func (wm *WorkspaceManager) rollbackSwitch(ctx context.Context, workspace *Workspace, branch string, switched []SwitchStep) {
+	for _, step := range switched {
+		worktreePath := filepath.Join(workspace.Path, step.Repository)
+		if _, err := gitOutput(ctx, wm.runner(), worktreePath, "checkout", step.From); err != nil {
+			output.PrintWarning("Failed to switch '%s' back to '%s': %v", step.Repository, step.From, err)
+			continue
+		}
+		if step.Action != SwitchCreate && step.Action != SwitchTrack {
+			continue
+		}
+		if _, err := gitOutput(ctx, wm.runner(), worktreePath, "branch", "-D", branch); err != nil {
+			output.PrintWarning("Failed to delete branch '%s' created in '%s': %v", branch, step.Repository, err)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/go-go-golems/workspace-manager/pkg/output"
 	"github.com/pkg/errors"
@@ -14,6 +15,11 @@ import (
 // SyncOperations handles synchronization operations across repositories
 type SyncOperations struct {
 	workspace *Workspace
+
+	// Progress receives the progress of pulls and pushes, nil to run git silently
+	Progress ProgressReporter
+	// Timeouts bound the pull and the push of each repository
+	Timeouts TimeoutConfig
 }
 
 // NewSyncOperations creates a new sync operations handler
@@ -35,6 +41,14 @@ type SyncResult struct {
 	BehindBefore int    `json:"behind_before"`
 	AheadAfter   int    `json:"ahead_after"`
 	BehindAfter  int    `json:"behind_after"`
+	// Stashed is set when uncommitted changes were stashed for the pull
+	Stashed bool `json:"stashed,omitempty"`
+	// Stash is the stash commit holding changes that could not be restored
+	Stash string `json:"stash,omitempty"`
+	// TimedOut is set when the pull or the push did not complete within its timeout
+	TimedOut bool `json:"timed_out,omitempty"`
+	// Cancelled is set when the sync was interrupted before reaching the repository
+	Cancelled bool `json:"cancelled,omitempty"`
 }
 
 // SyncOptions configures sync operations
@@ -43,10 +57,16 @@ type SyncOptions struct {
 	Push   bool `json:"push"`
 	Rebase bool `json:"rebase"`
 	DryRun bool `json:"dry_run"`
+	// AutoStash stashes uncommitted changes before pulling and restores them afterwards
+	AutoStash bool `json:"autostash"`
 }
 
-// SyncWorkspace synchronizes all repositories in the workspace
+// SyncWorkspace synchronizes all repositories in the workspace that are not frozen
 func (so *SyncOperations) SyncWorkspace(ctx context.Context, options *SyncOptions) ([]SyncResult, error) {
+	start := time.Now()
+	if err := so.workspace.RequireWorktrees("syncing"); err != nil {
+		return nil, err
+	}
 	var results []SyncResult
 
 	output.LogInfo(
@@ -59,12 +79,44 @@ func (so *SyncOperations) SyncWorkspace(ctx context.Context, options *SyncOption
 		"dry_run", options.DryRun,
 	)
 
-	for _, repo := range so.workspace.Repositories {
+	for _, repo := range so.workspace.ActiveRepositories() {
 		repoPath := filepath.Join(so.workspace.Path, repo.Name)
+		// Once interrupted, the remaining repositories are reported as not synced rather than failed
+		if ctx.Err() != nil {
+			results = append(results, SyncResult{Repository: repo.Name, Error: "cancelled", Cancelled: true})
+			reportDone(so.Progress, repo.Name, ctx.Err())
+			continue
+		}
+		if !options.DryRun {
+			reportStart(so.Progress, repo.Name, "Syncing")
+		}
 		result := so.syncRepository(ctx, repo.Name, repoPath, options)
+		if !options.DryRun {
+			var err error
+			if !result.Success {
+				err = errors.New(result.Error)
+			}
+			reportDone(so.Progress, repo.Name, err)
+		}
 		results = append(results, result)
 	}
 
+	if !options.DryRun {
+		failed := 0
+		for _, result := range results {
+			if !result.Success {
+				failed++
+			}
+		}
+		RecordOperation("sync", so.workspace.Name, start, map[string]string{
+			"pull":   fmt.Sprintf("%v", options.Pull),
+			"push":   fmt.Sprintf("%v", options.Push),
+			"rebase": fmt.Sprintf("%v", options.Rebase),
+			"repos":  fmt.Sprintf("%d", len(results)),
+			"failed": fmt.Sprintf("%d", failed),
+		})
+	}
+
 	return results, nil
 }
 
@@ -92,20 +144,47 @@ func (so *SyncOperations) syncRepository(ctx context.Context, repoName, repoPath
 
 	// Pull changes if requested
 	if options.Pull {
-		if err := so.pullRepository(ctx, repoPath, options.Rebase); err != nil {
+		var stash *Stash
+		if options.AutoStash {
+			stash, err = AutoStash(ctx, repoName, repoPath, "sync")
+			if err != nil {
+				result.Success = false
+				result.Error = err.Error()
+				return result
+			}
+			result.Stashed = stash != nil
+		}
+
+		if err := so.pullRepository(ctx, repoName, repoPath, options.Rebase); err != nil {
 			result.Success = false
 			result.Error = fmt.Sprintf("pull failed: %v", err)
+			result.TimedOut = IsTimeout(err)
 			result.Conflicts = so.hasConflicts(ctx, repoPath)
+			if stash != nil {
+				// The stash cannot be applied on top of an unfinished merge or rebase
+				if !result.Conflicts {
+					so.restoreStash(ctx, stash, &result)
+				} else {
+					result.Stash = stash.Commit
+				}
+			} else if !options.AutoStash && HasUncommittedChanges(ctx, repoPath) {
+				result.Error += " (uncommitted changes, retry with --autostash)"
+			}
 			return result
 		}
 		result.Pulled = true
+
+		if stash != nil && !so.restoreStash(ctx, stash, &result) {
+			return result
+		}
 	}
 
 	// Push changes if requested
 	if options.Push {
-		if err := so.pushRepository(ctx, repoPath); err != nil {
+		if err := so.pushRepository(ctx, repoName, repoPath); err != nil {
 			result.Success = false
 			result.Error = fmt.Sprintf("push failed: %v", err)
+			result.TimedOut = IsTimeout(err)
 			return result
 		}
 		result.Pushed = true
@@ -136,31 +215,71 @@ func (so *SyncOperations) syncRepository(ctx context.Context, repoName, repoPath
 	return result
 }
 
-// pullRepository pulls changes from remote
-func (so *SyncOperations) pullRepository(ctx context.Context, repoPath string, rebase bool) error {
-	var cmd *exec.Cmd
-	if rebase {
-		cmd = exec.CommandContext(ctx, "git", "pull", "--rebase")
+// restoreStash restores the autostash after a pull, recording conflicts and failures in the
+// result. It returns false when the changes were not restored cleanly.
+func (so *SyncOperations) restoreStash(ctx context.Context, stash *Stash, result *SyncResult) bool {
+	conflicts, err := stash.Restore(ctx)
+	if err == nil && !conflicts {
+		return true
+	}
+	result.Success = false
+	result.Stash = stash.Commit
+	if conflicts {
+		result.Conflicts = true
+		result.Error = "restoring stashed changes conflicted"
 	} else {
-		cmd = exec.CommandContext(ctx, "git", "pull")
+		result.Error = err.Error()
 	}
-	cmd.Dir = repoPath
+	return false
+}
 
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return errors.Wrapf(err, "git pull failed: %s", string(output))
+// pullRepository pulls changes from remote. Only the fetch is bounded by the fetch timeout: the
+// merge or rebase runs locally, and interrupting it would leave the repository mid-rebase.
+func (so *SyncOperations) pullRepository(ctx context.Context, repoName, repoPath string, rebase bool) error {
+	args := []string{"fetch"}
+	if so.Progress != nil {
+		args = append(args, "--progress")
+	}
+
+	fetchCtx, done := WithNetworkTimeout(ctx, so.Timeouts, repoName, OperationFetch)
+	output, err := runGitProgress(fetchCtx, nil, so.Progress, repoName, repoPath, args...)
+	if err = done(err); err != nil {
+		if IsTimeout(err) {
+			return err
+		}
+		return errors.Wrapf(err, "git fetch failed: %s", string(output))
+	}
+
+	// Like git pull, integrate the upstream of the branch, honouring pull.rebase
+	integrate := []string{"merge", "--no-edit", "@{upstream}"}
+	if setting, _ := runGitOutput(ctx, repoPath, "config", "--get", "pull.rebase"); rebase || (setting != "" && setting != "false") {
+		integrate = []string{"rebase"}
+		if setting == "merges" {
+			integrate = append(integrate, "--rebase-merges")
+		}
+	}
+	cmd := exec.CommandContext(ctx, "git", integrate...)
+	cmd.Dir = repoPath
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "git %s failed: %s", integrate[0], string(output))
 	}
 
 	return nil
 }
 
 // pushRepository pushes changes to remote
-func (so *SyncOperations) pushRepository(ctx context.Context, repoPath string) error {
-	cmd := exec.CommandContext(ctx, "git", "push")
-	cmd.Dir = repoPath
+func (so *SyncOperations) pushRepository(ctx context.Context, repoName, repoPath string) error {
+	args := []string{"push"}
+	if so.Progress != nil {
+		args = append(args, "--progress")
+	}
 
-	output, err := cmd.CombinedOutput()
-	if err != nil {
+	ctx, done := WithNetworkTimeout(ctx, so.Timeouts, repoName, OperationPush)
+	output, err := runGitProgress(ctx, nil, so.Progress, repoName, repoPath, args...)
+	if err = done(err); err != nil {
+		if IsTimeout(err) {
+			return err
+		}
 		return errors.Wrapf(err, "git push failed: %s", string(output))
 	}
 
@@ -216,6 +335,9 @@ func (so *SyncOperations) hasConflicts(ctx context.Context, repoPath string) boo
 
 // CreateBranch creates a branch across all repositories
 func (so *SyncOperations) CreateBranch(ctx context.Context, branchName string, track bool) ([]SyncResult, error) {
+	if err := so.workspace.RequireWorktrees("creating a branch"); err != nil {
+		return nil, err
+	}
 	var results []SyncResult
 
 	output.LogInfo(
@@ -268,6 +390,9 @@ func (so *SyncOperations) createBranchInRepository(ctx context.Context, repoName
 
 // SwitchBranch switches all repositories to a specific branch
 func (so *SyncOperations) SwitchBranch(ctx context.Context, branchName string) ([]SyncResult, error) {
+	if err := so.workspace.RequireWorktrees("switching branches"); err != nil {
+		return nil, err
+	}
 	var results []SyncResult
 
 	output.LogInfo(
@@ -311,48 +436,3 @@ func (so *SyncOperations) switchBranchInRepository(ctx context.Context, repoName
 
 	return result
 }
-
-// GetWorkspaceLog gets commit history across workspace
-func (so *SyncOperations) GetWorkspaceLog(ctx context.Context, since string, oneline bool, limit int) (map[string]string, error) {
-	logs := make(map[string]string)
-
-	for _, repo := range so.workspace.Repositories {
-		repoPath := filepath.Join(so.workspace.Path, repo.Name)
-		log, err := so.getRepositoryLog(ctx, repoPath, since, oneline, limit)
-		if err != nil {
-			return nil, errors.Wrapf(err, "failed to get log for %s", repo.Name)
-		}
-		if log != "" {
-			logs[repo.Name] = log
-		}
-	}
-
-	return logs, nil
-}
-
-// getRepositoryLog gets commit history for a single repository
-func (so *SyncOperations) getRepositoryLog(ctx context.Context, repoPath, since string, oneline bool, limit int) (string, error) {
-	args := []string{"log"}
-
-	if since != "" {
-		args = append(args, "--since", since)
-	}
-
-	if oneline {
-		args = append(args, "--oneline")
-	}
-
-	if limit > 0 {
-		args = append(args, fmt.Sprintf("-%d", limit))
-	}
-
-	cmd := exec.CommandContext(ctx, "git", args...)
-	cmd.Dir = repoPath
-
-	output, err := cmd.Output()
-	if err != nil {
-		return "", err
-	}
-
-	return string(output), nil
-}
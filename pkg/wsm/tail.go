@@ -0,0 +1,274 @@
+package wsm
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultTailGlob matches the files tailed when no glob is given
+	DefaultTailGlob = "*.log"
+	// tailRescanInterval is how often new files matching the globs are first looked for while
+	// following; the interval doubles up to tailMaxRescanInterval while no new file shows up
+	tailRescanInterval    = 2 * time.Second
+	tailMaxRescanInterval = time.Minute
+	// tailMaxBacklog bounds how much of the end of a file is read for its last lines
+	tailMaxBacklog = 1 << 20
+)
+
+// tailSkippedDirs are never searched for files to tail
+var tailSkippedDirs = []string{".git", "node_modules"}
+
+// TailOptions configures TailWorkspace
+type TailOptions struct {
+	// Globs match files relative to each repository worktree (logs/*.log, **/debug.log); patterns
+	// without a slash match file names at any depth
+	Globs []string
+	// Repositories limits tailing to these repositories; all when empty
+	Repositories []string
+	// Services adds the logs of the services started with 'wsm up'
+	Services bool
+	// Lines is how many lines of each file are printed first
+	Lines int
+	// Follow keeps printing lines appended to the files, and files created later
+	Follow bool
+	// Pattern only keeps the lines it matches
+	Pattern *regexp.Regexp
+}
+
+// TailFile is a file tailed by TailWorkspace
+type TailFile struct {
+	// Repository is the repository of the file, or the service for a service log
+	Repository string `json:"repository"`
+	Path       string `json:"path"`
+	// Label identifies the file in the merged stream: the repository, followed by the file path
+	// when several files of the repository are tailed
+	Label   string `json:"label"`
+	Service bool   `json:"service,omitempty"`
+}
+
+// TailLine is a line of a tailed file
+type TailLine struct {
+	File TailFile
+	Line string
+}
+
+// FindTailFiles returns the files of the workspace matching the options, by repository
+func FindTailFiles(workspace *Workspace, opts TailOptions) ([]TailFile, error) {
+	globs := opts.Globs
+	if len(globs) == 0 {
+		globs = []string{DefaultTailGlob}
+	}
+
+	var files []TailFile
+	for _, repo := range workspace.Repositories {
+		if len(opts.Repositories) > 0 && !slices.Contains(opts.Repositories, repo.Name) {
+			continue
+		}
+		root := filepath.Join(workspace.Path, repo.Name)
+		var matched []TailFile
+		err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if entry.IsDir() {
+				if path != root && slices.Contains(tailSkippedDirs, entry.Name()) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return nil
+			}
+			rel = filepath.ToSlash(rel)
+			for _, glob := range globs {
+				if matchPathGlob(glob, rel) {
+					matched = append(matched, TailFile{Repository: repo.Name, Path: path, Label: repo.Name + "/" + rel})
+					break
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to search %s", root)
+		}
+		if len(matched) == 1 {
+			matched[0].Label = repo.Name
+		}
+		files = append(files, matched...)
+	}
+
+	if opts.Services {
+		config, err := LoadServices(workspace)
+		if err != nil {
+			return nil, err
+		}
+		if config != nil {
+			for _, name := range config.Names() {
+				path := ServiceLogPath(workspace, name)
+				if _, err := os.Stat(path); err == nil {
+					files = append(files, TailFile{Repository: name, Path: path, Label: name, Service: true})
+				}
+			}
+		}
+	}
+	return files, nil
+}
+
+// TailWorkspace prints the last lines of the files matching the options, then, when following,
+// the lines appended to them until ctx is done. Files created while following are picked up.
+func TailWorkspace(ctx context.Context, workspace *Workspace, opts TailOptions, emit func(TailLine)) error {
+	files, err := FindTailFiles(workspace, opts)
+	if err != nil {
+		return err
+	}
+
+	keep := func(file TailFile, lines []string) {
+		for _, line := range lines {
+			if file.Service {
+				_, line, _ = strings.Cut(line, " ")
+			}
+			if opts.Pattern == nil || opts.Pattern.MatchString(line) {
+				emit(TailLine{File: file, Line: line})
+			}
+		}
+	}
+
+	followers := map[string]*fileFollower{}
+	for _, file := range files {
+		lines, offset, err := lastLines(file.Path, opts.Lines)
+		if err != nil {
+			return err
+		}
+		keep(file, lines)
+		followers[file.Path] = &fileFollower{path: file.Path, offset: offset}
+	}
+	if !opts.Follow {
+		return nil
+	}
+
+	interval := tailRescanInterval
+	rescan := time.Now().Add(interval)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(250 * time.Millisecond):
+		}
+
+		if time.Now().After(rescan) {
+			foundNew := false
+			if found, err := FindTailFiles(workspace, opts); err == nil {
+				foundNew = slices.ContainsFunc(found, func(file TailFile) bool { return followers[file.Path] == nil })
+				files = found
+			}
+			interval = nextTailRescan(interval, foundNew)
+			rescan = time.Now().Add(interval)
+		}
+		for _, file := range files {
+			follower, ok := followers[file.Path]
+			if !ok {
+				// A file created while following is printed from its start
+				follower = &fileFollower{path: file.Path}
+				followers[file.Path] = follower
+			}
+			keep(file, follower.read())
+		}
+	}
+}
+
+// nextTailRescan doubles the interval between rescans that find no new file, up to
+// tailMaxRescanInterval, and goes back to tailRescanInterval when one does
+func nextTailRescan(interval time.Duration, foundNew bool) time.Duration {
+	if foundNew {
+		return tailRescanInterval
+	}
+	return min(2*interval, tailMaxRescanInterval)
+}
+
+// lastLines returns the last n lines of a file and its size
+func lastLines(path string, n int) ([]string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "failed to open %s", path)
+	}
+	defer func() { _ = file.Close() }()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "failed to read %s", path)
+	}
+	if n <= 0 {
+		return nil, info.Size(), nil
+	}
+	start := max(info.Size()-tailMaxBacklog, 0)
+	if _, err := file.Seek(start, io.SeekStart); err != nil {
+		return nil, 0, errors.Wrapf(err, "failed to read %s", path)
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "failed to read %s", path)
+	}
+
+	text := string(data)
+	// A line cut by the start of the backlog is incomplete; a line still being written is left
+	// to the follower
+	if start > 0 {
+		if i := strings.IndexByte(text, '\n'); i >= 0 {
+			text = text[i+1:]
+		}
+	}
+	complete := strings.LastIndexByte(text, '\n') + 1
+	offset := info.Size() - int64(len(text)-complete)
+	lines := strings.Split(strings.TrimSuffix(text[:complete], "\n"), "\n")
+	if complete == 0 {
+		lines = nil
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines, offset, nil
+}
+
+// fileFollower reads the complete lines appended to a file since its last read; a file that
+// shrank, e.g. truncated or rotated, is read again from its start
+type fileFollower struct {
+	path    string
+	offset  int64
+	pending string
+}
+
+func (f *fileFollower) read() []string {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = file.Close() }()
+
+	if info, err := file.Stat(); err == nil && info.Size() < f.offset {
+		f.offset = 0
+		f.pending = ""
+	}
+	if _, err := file.Seek(f.offset, io.SeekStart); err != nil {
+		return nil
+	}
+	data, _ := io.ReadAll(file)
+	f.offset += int64(len(data))
+	text := f.pending + string(data)
+	complete := strings.LastIndexByte(text, '\n') + 1
+	f.pending = text[complete:]
+	if complete == 0 {
+		return nil
+	}
+	return strings.Split(text[:complete-1], "\n")
+}
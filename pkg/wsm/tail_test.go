@@ -0,0 +1,24 @@
+package wsm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextTailRescan(t *testing.T) {
+	interval := tailRescanInterval
+	var intervals []time.Duration
+	for range 7 {
+		interval = nextTailRescan(interval, false)
+		intervals = append(intervals, interval)
+	}
+	want := []time.Duration{4 * time.Second, 8 * time.Second, 16 * time.Second, 32 * time.Second, time.Minute, time.Minute, time.Minute}
+	for i := range want {
+		if intervals[i] != want[i] {
+			t.Fatalf("intervals without new files = %v, want %v", intervals, want)
+		}
+	}
+	if got := nextTailRescan(time.Minute, true); got != tailRescanInterval {
+		t.Errorf("interval after a new file = %v, want %v", got, tailRescanInterval)
+	}
+}
@@ -0,0 +1,169 @@
+package wsm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// TermConfig configures the terminal integration used by 'wsm term'
+type TermConfig struct {
+	// Backend is one of "auto" (default), "kitty", "wezterm" or "iterm2"
+	Backend string `json:"backend,omitempty" yaml:"backend,omitempty"`
+	// Overview adds a first tab running the overview command (see TmuxProfile)
+	Overview        bool   `json:"overview" yaml:"overview"`
+	OverviewCommand string `json:"overview_command,omitempty" yaml:"overview_command,omitempty"`
+	// ITerm2Profile is the iTerm2 profile used for new tabs
+	ITerm2Profile string `json:"iterm2_profile,omitempty" yaml:"iterm2_profile,omitempty"`
+}
+
+// Terminal backends
+const (
+	TermBackendAuto    = "auto"
+	TermBackendKitty   = "kitty"
+	TermBackendWezTerm = "wezterm"
+	TermBackendITerm2  = "iterm2"
+)
+
+// TerminalBackend opens workspace windows as tabs in a terminal emulator
+type TerminalBackend interface {
+	Name() string
+	OpenTab(ctx context.Context, window SessionWindow) error
+}
+
+// NewTerminalBackend returns the backend for the given name, detecting the running terminal for "auto"
+func NewTerminalBackend(config TermConfig) (TerminalBackend, error) {
+	backend := config.Backend
+	if backend == "" || backend == TermBackendAuto {
+		backend = DetectTerminalBackend()
+		if backend == "" {
+			return nil, errors.New("could not detect a supported terminal (kitty, WezTerm, iTerm2); use --backend or tmux")
+		}
+	}
+
+	switch backend {
+	case TermBackendKitty:
+		return &kittyBackend{}, nil
+	case TermBackendWezTerm:
+		return &wezTermBackend{}, nil
+	case TermBackendITerm2:
+		return &iTerm2Backend{profile: config.ITerm2Profile}, nil
+	default:
+		return nil, errors.Errorf("unknown terminal backend %q (expected kitty, wezterm or iterm2)", backend)
+	}
+}
+
+// DetectTerminalBackend returns the backend matching the current terminal, or "" if none is detected
+func DetectTerminalBackend() string {
+	switch {
+	case os.Getenv("KITTY_WINDOW_ID") != "":
+		return TermBackendKitty
+	case os.Getenv("WEZTERM_PANE") != "" || os.Getenv("TERM_PROGRAM") == "WezTerm":
+		return TermBackendWezTerm
+	case os.Getenv("TERM_PROGRAM") == "iTerm.app":
+		return TermBackendITerm2
+	default:
+		return ""
+	}
+}
+
+// NewTermSession builds the tab layout for a workspace: one tab per repository plus an optional overview
+func NewTermSession(workspace *Workspace, config TermConfig) *TmuxSession {
+	return NewTmuxSession(workspace, TmuxProfile{
+		PerRepoWindows:  true,
+		Overview:        config.Overview,
+		OverviewCommand: config.OverviewCommand,
+	})
+}
+
+// shellCommand wraps a command so the tab keeps an interactive shell after it exits
+func shellCommand(command string) []string {
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+	return []string{shell, "-c", fmt.Sprintf("%s; exec %s", command, shell)}
+}
+
+type kittyBackend struct{}
+
+func (b *kittyBackend) Name() string { return TermBackendKitty }
+
+// OpenTab uses kitty remote control, which requires allow_remote_control in kitty.conf
+func (b *kittyBackend) OpenTab(ctx context.Context, window SessionWindow) error {
+	args := []string{"@", "launch", "--type=tab", "--tab-title", window.Name, "--cwd", window.Dir}
+	if window.Command != "" {
+		args = append(args, shellCommand(window.Command)...)
+	}
+	return runTerminalCommand(ctx, "kitten", args...)
+}
+
+type wezTermBackend struct{}
+
+func (b *wezTermBackend) Name() string { return TermBackendWezTerm }
+
+func (b *wezTermBackend) OpenTab(ctx context.Context, window SessionWindow) error {
+	args := []string{"cli", "spawn", "--cwd", window.Dir}
+	if window.Command != "" {
+		args = append(args, "--")
+		args = append(args, shellCommand(window.Command)...)
+	}
+
+	out, err := exec.CommandContext(ctx, "wezterm", args...).Output()
+	if err != nil {
+		return errors.Wrapf(err, "wezterm %s", strings.Join(args, " "))
+	}
+
+	paneID := strings.TrimSpace(string(out))
+	return runTerminalCommand(ctx, "wezterm", "cli", "set-tab-title", "--pane-id", paneID, window.Name)
+}
+
+type iTerm2Backend struct {
+	profile string
+}
+
+func (b *iTerm2Backend) Name() string { return TermBackendITerm2 }
+
+func (b *iTerm2Backend) OpenTab(ctx context.Context, window SessionWindow) error {
+	createTab := "create tab with default profile"
+	if b.profile != "" {
+		createTab = fmt.Sprintf("create tab with profile %s", appleScriptString(b.profile))
+	}
+
+	text := fmt.Sprintf("cd %s", shellQuote(window.Dir))
+	if window.Command != "" {
+		text += " && " + window.Command
+	}
+
+	script := fmt.Sprintf(`tell application "iTerm2"
+	tell current window
+		%s
+		tell current session
+			set name to %s
+			write text %s
+		end tell
+	end tell
+end tell`, createTab, appleScriptString(window.Name), appleScriptString(text))
+
+	return runTerminalCommand(ctx, "osascript", "-e", script)
+}
+
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func runTerminalCommand(ctx context.Context, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "%s failed: %s", name, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package wsm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetectTerminalBackend(t *testing.T) {
+	tests := []struct {
+		env  map[string]string
+		want string
+	}{
+		{env: map[string]string{}, want: ""},
+		{env: map[string]string{"KITTY_WINDOW_ID": "1", "TERM_PROGRAM": "iTerm.app"}, want: TermBackendKitty},
+		{env: map[string]string{"WEZTERM_PANE": "3"}, want: TermBackendWezTerm},
+		{env: map[string]string{"TERM_PROGRAM": "WezTerm"}, want: TermBackendWezTerm},
+		{env: map[string]string{"TERM_PROGRAM": "iTerm.app"}, want: TermBackendITerm2},
+	}
+	for _, tt := range tests {
+		for _, name := range []string{"KITTY_WINDOW_ID", "WEZTERM_PANE", "TERM_PROGRAM"} {
+			t.Setenv(name, tt.env[name])
+		}
+		if got := DetectTerminalBackend(); got != tt.want {
+			t.Errorf("DetectTerminalBackend() with %v = %q, want %q", tt.env, got, tt.want)
+		}
+	}
+
+	if _, err := NewTerminalBackend(TermConfig{Backend: "konsole"}); err == nil || !strings.Contains(err.Error(), "konsole") {
+		t.Errorf("expected an error for an unknown backend, got %v", err)
+	}
+}
+
+func TestTerminalQuoting(t *testing.T) {
+	if got, want := shellQuote(`/work/it's here`), `'/work/it'\''s here'`; got != want {
+		t.Errorf("shellQuote = %s, want %s", got, want)
+	}
+	if got, want := appleScriptString(`say "hi" \ bye`), `"say \"hi\" \\ bye"`; got != want {
+		t.Errorf("appleScriptString = %s, want %s", got, want)
+	}
+
+	t.Setenv("SHELL", "/bin/zsh")
+	if got := shellCommand("make test"); strings.Join(got, "|") != "/bin/zsh|-c|make test; exec /bin/zsh" {
+		t.Errorf("shellCommand = %q", got)
+	}
+}
@@ -0,0 +1,218 @@
+package wsm
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// TimelineCommit is a commit of one repository in the workspace timeline
+type TimelineCommit struct {
+	Repository string    `json:"repository"`
+	Hash       string    `json:"hash"`
+	ShortHash  string    `json:"short_hash"`
+	Author     string    `json:"author"`
+	Email      string    `json:"email"`
+	Date       time.Time `json:"date"`
+	Subject    string    `json:"subject"`
+}
+
+// TimelineOptions filters the workspace timeline. Since, Until, Authors and Grep take the values
+// of the matching git log options.
+type TimelineOptions struct {
+	Since   string
+	Until   string
+	Authors []string
+	Grep    string
+	// Limit is the maximum number of commits of the whole timeline, 0 for all
+	Limit int
+}
+
+// GetWorkspaceTimeline merges the history of all repositories of the workspace into one
+// timeline, newest commit first
+func GetWorkspaceTimeline(ctx context.Context, workspace *Workspace, options TimelineOptions) ([]TimelineCommit, error) {
+	args := []string{"log", "--format=%H%x00%h%x00%an%x00%ae%x00%cI%x00%s"}
+	if options.Since != "" {
+		args = append(args, "--since", options.Since)
+	}
+	if options.Until != "" {
+		args = append(args, "--until", options.Until)
+	}
+	for _, author := range options.Authors {
+		args = append(args, "--author", author)
+	}
+	if options.Grep != "" {
+		args = append(args, "--grep", options.Grep, "--regexp-ignore-case")
+	}
+	// No repository contributes more than the whole timeline shows
+	if options.Limit > 0 {
+		args = append(args, fmt.Sprintf("-%d", options.Limit))
+	}
+
+	var commits []TimelineCommit
+	for _, repo := range workspace.Repositories {
+		log, err := runGitOutput(ctx, filepath.Join(workspace.Path, repo.Name), args...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get log for %s", repo.Name)
+		}
+		for _, line := range strings.Split(log, "\n") {
+			fields := strings.SplitN(line, "\x00", 6)
+			if len(fields) != 6 {
+				continue
+			}
+			date, err := time.Parse(time.RFC3339, fields[4])
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to parse date of %s in %s", fields[1], repo.Name)
+			}
+			commits = append(commits, TimelineCommit{
+				Repository: repo.Name,
+				Hash:       fields[0],
+				ShortHash:  fields[1],
+				Author:     fields[2],
+				Email:      fields[3],
+				Date:       date,
+				Subject:    fields[5],
+			})
+		}
+	}
+
+	sort.SliceStable(commits, func(i, j int) bool {
+		return commits[i].Date.After(commits[j].Date)
+	})
+	if options.Limit > 0 && len(commits) > options.Limit {
+		commits = commits[:options.Limit]
+	}
+	return commits, nil
+}
+
+// FileCommit is a commit of the history of one file; Path is the path of the file in that commit,
+// which differs from the current path before a rename
+type FileCommit struct {
+	TimelineCommit
+	Path string `json:"path"`
+	// Patch is the change of the commit to the file, when requested
+	Patch string `json:"patch,omitempty"`
+}
+
+// ResolveWorkspaceFile finds the repository of a path in the workspace and the path of the file
+// inside it. Relative paths are taken from cwd when that is inside the workspace and the file
+// exists there, otherwise from the workspace root, e.g. api/internal/server.go. The file itself
+// need not exist anymore.
+func ResolveWorkspaceFile(workspace *Workspace, path, cwd string) (Repository, string, error) {
+	abs := path
+	if !filepath.IsAbs(path) {
+		abs = filepath.Join(workspace.Path, path)
+		if fromCwd := filepath.Join(cwd, path); isWithin(workspace.Path, cwd) {
+			if _, err := os.Lstat(fromCwd); err == nil {
+				abs = fromCwd
+			}
+		}
+	}
+
+	rel, err := filepath.Rel(workspace.Path, abs)
+	if err != nil || !isWithin(workspace.Path, abs) {
+		return Repository{}, "", errors.Errorf("%s is not inside workspace '%s'", path, workspace.Name)
+	}
+	repoName, inRepo, _ := strings.Cut(filepath.ToSlash(rel), "/")
+	repo, ok := FindRepository(workspace, repoName)
+	if !ok {
+		return Repository{}, "", errors.Errorf("%s is not inside a repository of workspace '%s'", path, workspace.Name)
+	}
+	if inRepo == "" {
+		return Repository{}, "", errors.Errorf("%s is a repository, not a file", path)
+	}
+	return repo, inRepo, nil
+}
+
+// isWithin reports whether path is dir or inside it
+func isWithin(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// GetFileHistory returns the commits of a repository of the workspace that changed a file, newest
+// first, following renames. With patch, each commit carries its change to the file.
+func GetFileHistory(ctx context.Context, workspace *Workspace, repo Repository, path string, options TimelineOptions, patch bool) ([]FileCommit, error) {
+	// Records start with \x1e so patches, which span lines, can be split off
+	args := []string{"log", "--follow", "--format=%x1e%H%x00%h%x00%an%x00%ae%x00%cI%x00%s"}
+	if patch {
+		args = append(args, "--patch")
+	} else {
+		args = append(args, "--name-only")
+	}
+	if options.Since != "" {
+		args = append(args, "--since", options.Since)
+	}
+	if options.Until != "" {
+		args = append(args, "--until", options.Until)
+	}
+	for _, author := range options.Authors {
+		args = append(args, "--author", author)
+	}
+	if options.Grep != "" {
+		args = append(args, "--grep", options.Grep, "--regexp-ignore-case")
+	}
+	if options.Limit > 0 {
+		args = append(args, fmt.Sprintf("-%d", options.Limit))
+	}
+	args = append(args, "--", path)
+
+	log, err := runGitOutput(ctx, filepath.Join(workspace.Path, repo.Name), args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get history of %s in %s", path, repo.Name)
+	}
+
+	var commits []FileCommit
+	for _, record := range strings.Split(log, "\x1e") {
+		header, body, _ := strings.Cut(record, "\n")
+		fields := strings.SplitN(header, "\x00", 6)
+		if len(fields) != 6 {
+			continue
+		}
+		date, err := time.Parse(time.RFC3339, fields[4])
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse date of %s in %s", fields[1], repo.Name)
+		}
+		commit := FileCommit{
+			TimelineCommit: TimelineCommit{
+				Repository: repo.Name,
+				Hash:       fields[0],
+				ShortHash:  fields[1],
+				Author:     fields[2],
+				Email:      fields[3],
+				Date:       date,
+				Subject:    fields[5],
+			},
+			Path: path,
+		}
+		body = strings.Trim(body, "\n")
+		if patch {
+			commit.Patch = body
+			commit.Path = cmp.Or(patchPath(body), path)
+		} else if body != "" {
+			commit.Path = strings.SplitN(body, "\n", 2)[0]
+		}
+		commits = append(commits, commit)
+	}
+	return commits, nil
+}
+
+// patchPath returns the path of the file after the change of a single-file patch
+func patchPath(patch string) string {
+	for _, line := range strings.Split(patch, "\n") {
+		if after, ok := strings.CutPrefix(line, "rename to "); ok {
+			return after
+		}
+		if after, ok := strings.CutPrefix(line, "+++ b/"); ok {
+			return after
+		}
+	}
+	return ""
+}
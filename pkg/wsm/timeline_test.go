@@ -0,0 +1,80 @@
+package wsm
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveWorkspaceFile(t *testing.T) {
+	root := t.TempDir()
+	writeGoFiles(t, root, map[string]string{
+		"api/internal/server.go": "package internal\n",
+		"api/main.go":            "package main\n",
+		"web/main.go":            "",
+	})
+	workspace := &Workspace{Name: "feat", Path: root, Repositories: []Repository{{Name: "api"}, {Name: "web"}}}
+	outside := t.TempDir()
+
+	tests := []struct {
+		path, cwd string
+		repo      string
+		file      string
+		wantErr   string
+	}{
+		{path: "api/internal/server.go", cwd: outside, repo: "api", file: "internal/server.go"},
+		{path: filepath.Join(root, "web", "main.go"), cwd: outside, repo: "web", file: "main.go"},
+		// Relative to the working directory when the file exists there
+		{path: "server.go", cwd: filepath.Join(root, "api", "internal"), repo: "api", file: "internal/server.go"},
+		{path: "main.go", cwd: filepath.Join(root, "api"), repo: "api", file: "main.go"},
+		// ... and from the workspace root otherwise, including deleted files
+		{path: "api/deleted.go", cwd: filepath.Join(root, "web"), repo: "api", file: "deleted.go"},
+		{path: "../elsewhere.go", cwd: outside, wantErr: "not inside workspace"},
+		{path: filepath.Join(root+"-other", "api", "main.go"), cwd: outside, wantErr: "not inside workspace"},
+		{path: "docs/readme.md", cwd: outside, wantErr: "not inside a repository"},
+		{path: "api", cwd: outside, wantErr: "is a repository"},
+	}
+	for _, tt := range tests {
+		repo, file, err := ResolveWorkspaceFile(workspace, tt.path, tt.cwd)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ResolveWorkspaceFile(%s) = %v, want an error containing %q", tt.path, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || repo.Name != tt.repo || file != tt.file {
+			t.Errorf("ResolveWorkspaceFile(%s) = %s, %s (%v), want %s, %s", tt.path, repo.Name, file, err, tt.repo, tt.file)
+		}
+	}
+}
+
+func TestIsWithin(t *testing.T) {
+	tests := []struct {
+		dir, path string
+		want      bool
+	}{
+		{"/ws", "/ws", true},
+		{"/ws", "/ws/api/main.go", true},
+		{"/ws", "/ws/../other", false},
+		{"/ws", "/wsx/api", false},
+		{"/ws", "/ws/..hidden", true},
+	}
+	for _, tt := range tests {
+		if got := isWithin(tt.dir, tt.path); got != tt.want {
+			t.Errorf("isWithin(%s, %s) = %v, want %v", tt.dir, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestPatchPath(t *testing.T) {
+	renamed := "diff --git a/old.go b/new.go\nsimilarity index 90%\nrename from old.go\nrename to new.go\n--- a/old.go\n+++ b/new.go\n"
+	if got := patchPath(renamed); got != "new.go" {
+		t.Errorf("patchPath of a rename = %q", got)
+	}
+	if got := patchPath("--- a/main.go\n+++ b/main.go\n@@ -1 +1 @@\n"); got != "main.go" {
+		t.Errorf("patchPath = %q", got)
+	}
+	if got := patchPath("--- a/gone.go\n+++ /dev/null\n"); got != "" {
+		t.Errorf("patchPath of a deletion = %q", got)
+	}
+}
@@ -0,0 +1,188 @@
+package wsm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Network operations bounded by TimeoutConfig
+const (
+	OperationFetch = "fetch"
+	OperationPush  = "push"
+	OperationClone = "clone"
+)
+
+// gitCancelGrace is how long git has to exit after being interrupted, e.g. because its timeout
+// expired, before it is killed. Interrupted, git removes its lock files and partial packs.
+const gitCancelGrace = 5 * time.Second
+
+// TimeoutConfig bounds the git network operations of a single repository in config.yaml, so that
+// one unreachable remote does not stall a command across the whole workspace. Operations have no
+// timeout unless one is set.
+type TimeoutConfig struct {
+	// Fetch bounds fetches, including the fetch of a pull but never its merge or rebase, e.g. 2m
+	Fetch string `json:"fetch,omitempty" yaml:"fetch,omitempty"`
+	// Push bounds pushes; pre-push hooks run within it
+	Push string `json:"push,omitempty" yaml:"push,omitempty"`
+	// Clone bounds clones from a remote; local clones are never bounded
+	Clone string `json:"clone,omitempty" yaml:"clone,omitempty"`
+	// Repositories overrides the timeouts of known-slow repositories, keyed by repository name
+	Repositories map[string]RepositoryTimeouts `json:"repositories,omitempty" yaml:"repositories,omitempty"`
+}
+
+// RepositoryTimeouts are the timeouts of a single repository; those not set fall back to the
+// global ones, and "off" disables a global one
+type RepositoryTimeouts struct {
+	Fetch string `json:"fetch,omitempty" yaml:"fetch,omitempty"`
+	Push  string `json:"push,omitempty" yaml:"push,omitempty"`
+	Clone string `json:"clone,omitempty" yaml:"clone,omitempty"`
+}
+
+func (t RepositoryTimeouts) get(operation string) string {
+	switch operation {
+	case OperationFetch:
+		return t.Fetch
+	case OperationPush:
+		return t.Push
+	case OperationClone:
+		return t.Clone
+	}
+	return ""
+}
+
+// Timeout returns how long operation may take for repo; 0 means no limit
+func (c TimeoutConfig) Timeout(repo, operation string) (time.Duration, error) {
+	key := "timeouts." + operation
+	value := RepositoryTimeouts{Fetch: c.Fetch, Push: c.Push, Clone: c.Clone}.get(operation)
+	if override := c.Repositories[repo].get(operation); override != "" {
+		key = fmt.Sprintf("timeouts.repositories.%s.%s", repo, operation)
+		value = override
+	}
+
+	switch operation {
+	case OperationFetch, OperationPush, OperationClone:
+	default:
+		return 0, errors.Errorf("unknown network operation '%s'", operation)
+	}
+	if value == "" || value == "off" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, errors.Errorf("invalid %s '%s': expected a duration such as 90s or 10m, or off", key, value)
+	}
+	return d, nil
+}
+
+// Validate checks the timeouts and their overrides
+func (c TimeoutConfig) Validate() error {
+	for _, operation := range []string{OperationFetch, OperationPush, OperationClone} {
+		if _, err := c.Timeout("", operation); err != nil {
+			return err
+		}
+		for repo := range c.Repositories {
+			if _, err := c.Timeout(repo, operation); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WithTimeout overrides the global timeout of operation, e.g. with the --timeout of a command.
+// Per-repository overrides still apply.
+func (c TimeoutConfig) WithTimeout(operation string, timeout time.Duration) TimeoutConfig {
+	if timeout <= 0 {
+		return c
+	}
+	switch operation {
+	case OperationFetch:
+		c.Fetch = timeout.String()
+	case OperationPush:
+		c.Push = timeout.String()
+	case OperationClone:
+		c.Clone = timeout.String()
+	}
+	return c
+}
+
+// TimeoutError is returned when a network operation on a repository did not complete in time
+type TimeoutError struct {
+	Repository string
+	Operation  string
+	Timeout    time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("git %s of %s timed out after %s", e.Operation, e.Repository, e.Timeout)
+}
+
+// IsTimeout reports whether err is, or wraps, a TimeoutError
+func IsTimeout(err error) bool {
+	var timeoutErr *TimeoutError
+	return errors.As(err, &timeoutErr)
+}
+
+// WithNetworkTimeout bounds a network operation on repo by its timeout. The returned function
+// releases the context and turns err into a TimeoutError when the timeout expired, while a
+// cancellation of ctx by the caller (e.g. Ctrl+C) is reported as such.
+func WithNetworkTimeout(ctx context.Context, config TimeoutConfig, repo, operation string) (context.Context, func(err error) error) {
+	timeout, err := config.Timeout(repo, operation)
+	if err != nil || timeout == 0 {
+		return ctx, func(err error) error { return err }
+	}
+
+	parent := ctx
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, func(err error) error {
+		defer cancel()
+		if err == nil || parent.Err() != nil {
+			return err
+		}
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return &TimeoutError{Repository: repo, Operation: operation, Timeout: timeout}
+		}
+		return err
+	}
+}
+
+// networkGit runs a git network operation on repo in dir, bounded by the timeout of the operation
+func (wm *WorkspaceManager) networkGit(ctx context.Context, repo, operation, dir string, args ...string) (string, error) {
+	var timeouts TimeoutConfig
+	if wm.config != nil {
+		timeouts = wm.config.Timeouts
+	}
+	ctx, done := WithNetworkTimeout(ctx, timeouts, repo, operation)
+	out, err := gitOutput(ctx, wm.runner(), dir, args...)
+	return out, done(err)
+}
+
+// withCloneTimeout bounds a clone of repo from source by the clone timeout. A clone of a local
+// checkout copies or links objects on disk, which a timeout meant for unreachable remotes must not
+// interrupt, so it is never bounded.
+func withCloneTimeout(ctx context.Context, config TimeoutConfig, repo, source string) (context.Context, func(err error) error) {
+	if isLocalRemote(source) {
+		return ctx, func(err error) error { return err }
+	}
+	return WithNetworkTimeout(ctx, config, repo, OperationClone)
+}
+
+// gitCommand creates a command that is interrupted rather than killed when ctx is done, and only
+// killed when it does not exit within gitCancelGrace, so git can clean up after itself
+func gitCommand(ctx context.Context, dir, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	if runtime.GOOS != "windows" {
+		cmd.Cancel = func() error {
+			return cmd.Process.Signal(os.Interrupt)
+		}
+	}
+	cmd.WaitDelay = gitCancelGrace
+	return cmd
+}
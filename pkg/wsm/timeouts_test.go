@@ -0,0 +1,73 @@
+package wsm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTimeoutConfigTimeout(t *testing.T) {
+	config := TimeoutConfig{
+		Fetch: "2m",
+		Repositories: map[string]RepositoryTimeouts{
+			"monorepo": {Fetch: "off", Push: "10m"},
+		},
+	}
+	tests := []struct {
+		repo      string
+		operation string
+		want      time.Duration
+	}{
+		{repo: "lib", operation: OperationFetch, want: 2 * time.Minute},
+		{repo: "lib", operation: OperationPush, want: 0},
+		{repo: "lib", operation: OperationClone, want: 0},
+		{repo: "monorepo", operation: OperationFetch, want: 0},
+		{repo: "monorepo", operation: OperationPush, want: 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		got, err := config.Timeout(tt.repo, tt.operation)
+		if err != nil || got != tt.want {
+			t.Errorf("Timeout(%s, %s) = %s, %v, want %s", tt.repo, tt.operation, got, err, tt.want)
+		}
+	}
+
+	if _, err := config.Timeout("lib", "rebase"); err == nil {
+		t.Error("expected an error for an unknown operation")
+	}
+	invalid := TimeoutConfig{Repositories: map[string]RepositoryTimeouts{"lib": {Push: "soon"}}}
+	if err := invalid.Validate(); err == nil {
+		t.Error("expected an error for an invalid override")
+	}
+}
+
+func TestWithNetworkTimeout(t *testing.T) {
+	config := TimeoutConfig{Fetch: "10ms"}
+	ctx, done := WithNetworkTimeout(context.Background(), config, "lib", OperationFetch)
+	<-ctx.Done()
+	err := done(ctx.Err())
+	if !IsTimeout(err) {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+	if want := "git fetch of lib timed out after 10ms"; err.Error() != want {
+		t.Errorf("error = %q, want %q", err, want)
+	}
+
+	// Without a timeout the context is left alone and errors are passed through
+	ctx, done = WithNetworkTimeout(context.Background(), config, "lib", OperationPush)
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("push has no timeout but the context has a deadline")
+	}
+	failure := errors.New("rejected")
+	if err := done(failure); err != failure {
+		t.Errorf("done = %v, want the error unchanged", err)
+	}
+
+	// A cancellation by the caller is not a timeout
+	parent, cancel := context.WithCancel(context.Background())
+	ctx, done = WithNetworkTimeout(parent, TimeoutConfig{Fetch: "1h"}, "lib", OperationFetch)
+	cancel()
+	if err := done(ctx.Err()); IsTimeout(err) {
+		t.Errorf("a cancelled context was reported as a timeout: %v", err)
+	}
+}
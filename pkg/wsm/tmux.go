@@ -0,0 +1,152 @@
+package wsm
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/pkg/errors"
+)
+
+// TmuxProfile configures the tmux session generated for a workspace
+type TmuxProfile struct {
+	// PerRepoWindows opens one window per repository with its worktree as working directory
+	PerRepoWindows bool `json:"per_repo_windows" yaml:"per_repo_windows"`
+	// Overview adds a first window running OverviewCommand in the workspace root
+	Overview bool `json:"overview" yaml:"overview"`
+	// OverviewCommand defaults to "wsm status --watch"
+	OverviewCommand string `json:"overview_command,omitempty" yaml:"overview_command,omitempty"`
+}
+
+// TmuxSession describes the tmux session layout for a workspace
+type TmuxSession struct {
+	Name    string          `json:"name"`
+	Windows []SessionWindow `json:"windows"`
+	// Config is the workspace .wsm/tmux.conf, sourced into the tmux server once the session is created
+	Config string `json:"config,omitempty"`
+}
+
+// SessionWindow is a single window (tmux window or terminal tab) of a workspace session
+type SessionWindow struct {
+	Name    string `json:"name"`
+	Dir     string `json:"dir"`
+	Command string `json:"command,omitempty"`
+}
+
+// TmuxSessionName returns a tmux-safe session name for a workspace
+func TmuxSessionName(workspace *Workspace) string {
+	// tmux does not allow '.' or ':' in session names
+	return strings.NewReplacer(".", "_", ":", "_").Replace(workspace.Name)
+}
+
+// NewTmuxSession builds the session layout for a workspace from a profile
+func NewTmuxSession(workspace *Workspace, profile TmuxProfile) *TmuxSession {
+	session := &TmuxSession{Name: TmuxSessionName(workspace)}
+	if config := filepath.Join(workspace.Path, ".wsm", "tmux.conf"); fileExists(config) {
+		session.Config = config
+	}
+
+	if !profile.PerRepoWindows {
+		session.Windows = append(session.Windows, SessionWindow{Name: workspace.Name, Dir: workspace.Path})
+		return session
+	}
+
+	if profile.Overview {
+		command := profile.OverviewCommand
+		if command == "" {
+			command = "wsm status --watch"
+		}
+		session.Windows = append(session.Windows, SessionWindow{Name: "overview", Dir: workspace.Path, Command: command})
+	}
+
+	for _, repo := range workspace.Repositories {
+		session.Windows = append(session.Windows, SessionWindow{
+			Name: repo.Name,
+			Dir:  filepath.Join(workspace.Path, repo.Name),
+		})
+	}
+
+	return session
+}
+
+// TmuxSessionExists reports whether a tmux session with the given name is running
+func TmuxSessionExists(ctx context.Context, name string) bool {
+	return exec.CommandContext(ctx, "tmux", "has-session", "-t", "="+name).Run() == nil
+}
+
+// tmuxWindowFormat prints the target of a window created by new-session or new-window
+const tmuxWindowFormat = "#{session_id}:#{window_index}"
+
+// CreateTmuxSession creates a detached tmux session with the given layout
+func CreateTmuxSession(ctx context.Context, session *TmuxSession) error {
+	if len(session.Windows) == 0 {
+		return errors.New("tmux session has no windows")
+	}
+
+	// Windows are targeted by the session id and window index tmux prints, never by name: tmux
+	// reads "." and ":" in names as target separators
+	var sessionID, firstWindow string
+	for i, window := range session.Windows {
+		var args []string
+		if i == 0 {
+			args = []string{"new-session", "-d", "-P", "-F", tmuxWindowFormat, "-s", session.Name, "-n", window.Name, "-c", window.Dir}
+		} else {
+			args = []string{"new-window", "-P", "-F", tmuxWindowFormat, "-t", sessionID + ":", "-n", window.Name, "-c", window.Dir}
+		}
+		target, err := runTmuxOutput(ctx, args...)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create tmux window '%s'", window.Name)
+		}
+		if i == 0 {
+			sessionID, _, _ = strings.Cut(target, ":")
+			firstWindow = target
+		}
+
+		if window.Command != "" {
+			if err := runTmux(ctx, "send-keys", "-t", target, window.Command, "Enter"); err != nil {
+				return errors.Wrapf(err, "failed to start command in tmux window '%s'", window.Name)
+			}
+		}
+	}
+
+	if session.Config != "" {
+		// The session exists at this point, so a broken config is reported but does not fail
+		if err := runTmux(ctx, "source-file", session.Config); err != nil {
+			output.PrintWarning("Failed to source %s: %v", session.Config, err)
+		}
+	}
+
+	return runTmux(ctx, "select-window", "-t", firstWindow)
+}
+
+// AttachTmuxSession attaches to a session, switching the client when already inside tmux
+func AttachTmuxSession(ctx context.Context, name string) error {
+	args := []string{"attach-session", "-t", name}
+	if os.Getenv("TMUX") != "" {
+		args = []string{"switch-client", "-t", name}
+	}
+
+	cmd := exec.CommandContext(ctx, "tmux", args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+func runTmux(ctx context.Context, args ...string) error {
+	_, err := runTmuxOutput(ctx, args...)
+	return err
+}
+
+// runTmuxOutput runs tmux and returns its trimmed output
+func runTmuxOutput(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "tmux", args...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", errors.Wrapf(err, "tmux %s: %s", strings.Join(args, " "), strings.TrimSpace(string(out)))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
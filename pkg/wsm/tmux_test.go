@@ -0,0 +1,36 @@
+package wsm
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+)
+
+func TestCreateTmuxSessionWithDotsAndColonsInWindowNames(t *testing.T) {
+	if _, err := exec.LookPath("tmux"); err != nil {
+		t.Skip("tmux is not installed")
+	}
+	// A private tmux server, so the test neither sees nor touches the sessions of the user
+	t.Setenv("TMUX_TMPDIR", t.TempDir())
+	t.Setenv("TMUX", "")
+	ctx := context.Background()
+	t.Cleanup(func() { _ = exec.Command("tmux", "kill-server").Run() })
+
+	dir := t.TempDir()
+	session := &TmuxSession{Name: "ws", Windows: []SessionWindow{
+		{Name: "overview", Dir: dir, Command: "echo overview"},
+		{Name: "api.v2", Dir: dir, Command: "echo api"},
+		{Name: "a:b", Dir: dir},
+	}}
+	if err := CreateTmuxSession(ctx, session); err != nil {
+		t.Fatalf("CreateTmuxSession failed: %v", err)
+	}
+
+	out, err := runTmuxOutput(ctx, "list-windows", "-t", "=ws", "-F", "#{window_name} #{window_active}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "overview 1\napi.v2 0\na:b 0"; out != want {
+		t.Errorf("windows:\n%s\nwant:\n%s", out, want)
+	}
+}
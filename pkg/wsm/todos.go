@@ -0,0 +1,179 @@
+package wsm
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultTodoMarkers are the comment markers reported by ScanTodos when none are given
+var DefaultTodoMarkers = []string{"TODO", "FIXME", "HACK"}
+
+// TodoItem is a marker comment added on the workspace branch
+type TodoItem struct {
+	Repository string `json:"repository"`
+	File       string `json:"file"`
+	Line       int    `json:"line"`
+	Marker     string `json:"marker"`
+	Text       string `json:"text"`
+}
+
+// hunkHeader matches the new-file range of a unified diff hunk: @@ -a,b +c,d @@
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// ScanTodos returns the marker comments added in every repository since its branch point, including
+// uncommitted changes and untracked files. Lines that already existed at the branch point are not
+// reported, so only the debt introduced by the workspace shows up.
+func ScanTodos(ctx context.Context, workspace *Workspace, markers []string) ([]TodoItem, error) {
+	if len(markers) == 0 {
+		markers = DefaultTodoMarkers
+	}
+	quoted := make([]string, len(markers))
+	for i, marker := range markers {
+		quoted[i] = regexp.QuoteMeta(marker)
+	}
+	pattern := regexp.MustCompile(`\b(` + strings.Join(quoted, "|") + `)\b(?:\([^)]*\))?:?\s*(.*)`)
+
+	var items []TodoItem
+	for _, repo := range workspace.Repositories {
+		repoItems, err := scanRepositoryTodos(ctx, workspace, repo.Name, pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to scan %s", repo.Name)
+		}
+		items = append(items, repoItems...)
+	}
+	return items, nil
+}
+
+func scanRepositoryTodos(ctx context.Context, workspace *Workspace, repoName string, pattern *regexp.Regexp) ([]TodoItem, error) {
+	worktreePath := filepath.Join(workspace.Path, repoName)
+	branchPoint, _, err := repositoryBranchPoint(ctx, workspace, repoName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Diffing against the working tree covers both the branch commits and uncommitted changes
+	diff, err := runGitOutput(ctx, worktreePath, "diff", "--unified=0", "--no-color", "--no-ext-diff", branchPoint)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []TodoItem
+	var file string
+	line := 0
+	inHeader := false
+	for _, diffLine := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(diffLine, "diff --git "):
+			inHeader = true
+		case inHeader && strings.HasPrefix(diffLine, "+++ "):
+			file = strings.TrimPrefix(strings.TrimPrefix(diffLine, "+++ "), "b/")
+		case strings.HasPrefix(diffLine, "@@"):
+			inHeader = false
+			if m := hunkHeader.FindStringSubmatch(diffLine); m != nil {
+				line, _ = strconv.Atoi(m[1])
+			}
+		case !inHeader && strings.HasPrefix(diffLine, "+"):
+			if item, ok := matchTodo(pattern, diffLine[1:]); ok && file != "/dev/null" {
+				item.Repository, item.File, item.Line = repoName, file, line
+				items = append(items, item)
+			}
+			line++
+		}
+	}
+
+	untracked, err := runGitOutput(ctx, worktreePath, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range strings.Split(untracked, "\n") {
+		if file == "" {
+			continue
+		}
+		fileItems, err := scanFileTodos(filepath.Join(worktreePath, file), pattern)
+		if err != nil {
+			continue
+		}
+		for _, item := range fileItems {
+			item.Repository, item.File = repoName, file
+			items = append(items, item)
+		}
+	}
+
+	return items, nil
+}
+
+func scanFileTodos(path string, pattern *regexp.Regexp) ([]TodoItem, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var items []TodoItem
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if item, ok := matchTodo(pattern, scanner.Text()); ok {
+			item.Line = line
+			items = append(items, item)
+		}
+	}
+	return items, scanner.Err()
+}
+
+// matchTodo reports the marker in the comment of a source line. Markers in code or string
+// literals are not reported.
+func matchTodo(pattern *regexp.Regexp, line string) (TodoItem, bool) {
+	comment, ok := commentText(line)
+	if !ok {
+		return TodoItem{}, false
+	}
+	m := pattern.FindStringSubmatch(comment)
+	if m == nil {
+		return TodoItem{}, false
+	}
+	text := strings.TrimSpace(m[2])
+	text = strings.TrimSuffix(strings.TrimSuffix(text, "*/"), "-->")
+	return TodoItem{Marker: m[1], Text: strings.TrimSpace(text)}, true
+}
+
+// commentText returns the comment of a source line: the text after the first //, /*, #, <!-- or
+// -- outside of a string literal, or the whole line when it continues a block comment with *
+func commentText(line string) (string, bool) {
+	if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "*") {
+		return trimmed[1:], true
+	}
+
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		if quote != 0 {
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		rest := line[i:]
+		switch {
+		case c == '"' || c == '\'' || c == '`':
+			quote = c
+		case strings.HasPrefix(rest, "//"), strings.HasPrefix(rest, "/*"):
+			return rest[2:], true
+		case c == '#':
+			return rest[1:], true
+		case strings.HasPrefix(rest, "<!--"):
+			return rest[4:], true
+		case strings.HasPrefix(rest, "-- ") && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return rest[3:], true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,33 @@
+package wsm
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestMatchTodo(t *testing.T) {
+	pattern := regexp.MustCompile(`\b(TODO|FIXME)\b(?:\([^)]*\))?:?\s*(.*)`)
+	tests := []struct {
+		line string
+		want TodoItem
+		ok   bool
+	}{
+		{line: "\tx := 1 // TODO: handle errors", want: TodoItem{Marker: "TODO", Text: "handle errors"}, ok: true},
+		{line: "/* FIXME(alice) leaks */", want: TodoItem{Marker: "FIXME", Text: "leaks"}, ok: true},
+		{line: " * TODO document the options", want: TodoItem{Marker: "TODO", Text: "document the options"}, ok: true},
+		{line: "# TODO: python or shell", want: TodoItem{Marker: "TODO", Text: "python or shell"}, ok: true},
+		{line: "<!-- TODO: fill in -->", want: TodoItem{Marker: "TODO", Text: "fill in"}, ok: true},
+		{line: "SELECT 1; -- TODO index", want: TodoItem{Marker: "TODO", Text: "index"}, ok: true},
+		{line: `fmt.Println("see http://x # TODO") // TODO: real one`, want: TodoItem{Marker: "TODO", Text: "real one"}, ok: true},
+		{line: `msg := "TODO: not a comment"`},
+		{line: `pattern := "// TODO \" still a string"`},
+		{line: "var TODO = 1"},
+		{line: "i--; TODO()"},
+	}
+	for _, tt := range tests {
+		got, ok := matchTodo(pattern, tt.line)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("matchTodo(%q) = %+v, %v, want %+v, %v", tt.line, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package wsm
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pkg/errors"
+)
+
+const (
+	ToolVersionsFormatASDF = "asdf"
+	ToolVersionsFormatMise = "mise"
+
+	// toolVersionsGeneratedHeader marks files written by 'wsm tools' so they can be regenerated safely
+	toolVersionsGeneratedHeader = "# Generated by workspace-manager (wsm tools)"
+)
+
+// ToolVersion is a tool pinned by a repository in .tool-versions or .mise.toml
+type ToolVersion struct {
+	Tool string `json:"tool"`
+	// Versions lists the pinned versions; the first one is preferred by version managers
+	Versions   []string `json:"versions"`
+	Repository string   `json:"repository"`
+	Source     string   `json:"source"`
+}
+
+// MergedToolVersion is the version of a tool used at the workspace level
+type MergedToolVersion struct {
+	Tool     string        `json:"tool"`
+	Versions []string      `json:"versions"`
+	Pins     []ToolVersion `json:"pins"`
+	// Conflict is set when repositories pin different versions; the highest one is used
+	Conflict bool `json:"conflict,omitempty"`
+}
+
+// ToolVersionsFileName returns the workspace-root file generated for a format
+func ToolVersionsFileName(format string) string {
+	if format == ToolVersionsFormatMise {
+		return ".mise.toml"
+	}
+	return ".tool-versions"
+}
+
+// ReadToolVersions returns the tools pinned by the repositories of a workspace in their
+// .tool-versions, .mise.toml and mise.toml files
+func ReadToolVersions(workspace *Workspace) ([]ToolVersion, error) {
+	var pins []ToolVersion
+	for _, repo := range workspace.Repositories {
+		dir := filepath.Join(workspace.Path, repo.Name)
+
+		if data, err := os.ReadFile(filepath.Join(dir, ".tool-versions")); err == nil {
+			for _, pin := range parseASDFToolVersions(data) {
+				pin.Repository, pin.Source = repo.Name, ".tool-versions"
+				pins = append(pins, pin)
+			}
+		}
+
+		for _, file := range []string{".mise.toml", "mise.toml"} {
+			data, err := os.ReadFile(filepath.Join(dir, file))
+			if err != nil {
+				continue
+			}
+			misePins, err := parseMiseToolVersions(data)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to parse %s", filepath.Join(dir, file))
+			}
+			for _, pin := range misePins {
+				pin.Repository, pin.Source = repo.Name, file
+				pins = append(pins, pin)
+			}
+		}
+	}
+	return pins, nil
+}
+
+func parseASDFToolVersions(data []byte) []ToolVersion {
+	var pins []ToolVersion
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		pins = append(pins, ToolVersion{Tool: fields[0], Versions: fields[1:]})
+	}
+	return pins
+}
+
+// parseMiseToolVersions reads the [tools] table, whose values are a version, a list of versions
+// or a table with a version key
+func parseMiseToolVersions(data []byte) ([]ToolVersion, error) {
+	var config struct {
+		Tools map[string]any `toml:"tools"`
+	}
+	if err := toml.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+
+	tools := make([]string, 0, len(config.Tools))
+	for tool := range config.Tools {
+		tools = append(tools, tool)
+	}
+	sort.Strings(tools)
+
+	var pins []ToolVersion
+	for _, tool := range tools {
+		var versions []string
+		switch value := config.Tools[tool].(type) {
+		case string:
+			versions = []string{value}
+		case []any:
+			for _, item := range value {
+				versions = append(versions, miseVersion(item))
+			}
+		default:
+			versions = []string{miseVersion(value)}
+		}
+		if len(versions) > 0 && versions[0] != "" {
+			pins = append(pins, ToolVersion{Tool: tool, Versions: versions})
+		}
+	}
+	return pins, nil
+}
+
+func miseVersion(value any) string {
+	switch value := value.(type) {
+	case string:
+		return value
+	case map[string]any:
+		if version, ok := value["version"].(string); ok {
+			return version
+		}
+	}
+	return ""
+}
+
+// MergeToolVersions combines the pins of all repositories into one version list per tool. When
+// repositories disagree, the pin with the highest preferred version wins.
+func MergeToolVersions(pins []ToolVersion) []MergedToolVersion {
+	byTool := map[string]*MergedToolVersion{}
+	var tools []string
+	for _, pin := range pins {
+		merged, ok := byTool[pin.Tool]
+		if !ok {
+			merged = &MergedToolVersion{Tool: pin.Tool, Versions: pin.Versions}
+			byTool[pin.Tool] = merged
+			tools = append(tools, pin.Tool)
+		} else {
+			if strings.Join(pin.Versions, " ") != strings.Join(merged.Versions, " ") {
+				merged.Conflict = true
+			}
+			if compareVersions(pin.Versions[0], merged.Versions[0]) > 0 {
+				merged.Versions = pin.Versions
+			}
+		}
+		merged.Pins = append(merged.Pins, pin)
+	}
+	sort.Strings(tools)
+
+	result := make([]MergedToolVersion, 0, len(tools))
+	for _, tool := range tools {
+		result = append(result, *byTool[tool])
+	}
+	return result
+}
+
+// GenerateToolVersions renders the merged versions as a .tool-versions or .mise.toml file
+func GenerateToolVersions(workspace *Workspace, merged []MergedToolVersion, format string) string {
+	var b strings.Builder
+	b.WriteString(toolVersionsGeneratedHeader + "\n")
+	fmt.Fprintf(&b, "# Workspace: %s\n", workspace.Name)
+	for _, tool := range merged {
+		if !tool.Conflict {
+			continue
+		}
+		fmt.Fprintf(&b, "# Conflict for %s, using %s:\n", tool.Tool, strings.Join(tool.Versions, " "))
+		for _, pin := range tool.Pins {
+			fmt.Fprintf(&b, "#   %s: %s (%s)\n", pin.Repository, strings.Join(pin.Versions, " "), pin.Source)
+		}
+	}
+
+	if format == ToolVersionsFormatMise {
+		b.WriteString("\n[tools]\n")
+		for _, tool := range merged {
+			if len(tool.Versions) == 1 {
+				fmt.Fprintf(&b, "%s = %s\n", tomlKey(tool.Tool), strconv.Quote(tool.Versions[0]))
+				continue
+			}
+			quoted := make([]string, len(tool.Versions))
+			for i, version := range tool.Versions {
+				quoted[i] = strconv.Quote(version)
+			}
+			fmt.Fprintf(&b, "%s = [%s]\n", tomlKey(tool.Tool), strings.Join(quoted, ", "))
+		}
+		return b.String()
+	}
+
+	for _, tool := range merged {
+		fmt.Fprintf(&b, "%s %s\n", tool.Tool, strings.Join(tool.Versions, " "))
+	}
+	return b.String()
+}
+
+// tomlKey quotes keys such as "npm:prettier" or "go:github.com/x/y" that are not bare keys
+func tomlKey(key string) string {
+	for _, r := range key {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return strconv.Quote(key)
+		}
+	}
+	return key
+}
+
+// IsGeneratedToolVersionsFile reports whether a file was written by 'wsm tools' and can be overwritten
+func IsGeneratedToolVersionsFile(path string) bool {
+	return hasGeneratedHeader(path, toolVersionsGeneratedHeader)
+}
+
+// hasGeneratedHeader reports whether a file starts with the given generator header
+func hasGeneratedHeader(path, header string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(string(data), header)
+}
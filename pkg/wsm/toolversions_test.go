@@ -0,0 +1,73 @@
+package wsm
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func describePins(pins []ToolVersion) string {
+	var described []string
+	for _, pin := range pins {
+		described = append(described, fmt.Sprintf("%s:%s=%s", pin.Repository, pin.Tool, strings.Join(pin.Versions, "|")))
+	}
+	return strings.Join(described, ", ")
+}
+
+func TestReadToolVersions(t *testing.T) {
+	root := t.TempDir()
+	writeGoFiles(t, root, map[string]string{
+		"api/.tool-versions": "# tools\ngolang 1.23.4 # pinned\nnodejs 20.11.0 18.19.0\n\nbroken\n",
+		"web/.mise.toml":     "[tools]\nnode = \"22\"\npython = [\"3.12\", \"3.11\"]\n\"npm:prettier\" = { version = \"3.3.3\" }\nempty = { os = [\"linux\"] }\n",
+		"etl/mise.toml":      "[env]\nA = \"1\"\n",
+	})
+	workspace := &Workspace{Path: root, Repositories: []Repository{{Name: "api"}, {Name: "web"}, {Name: "etl"}}}
+
+	pins, err := ReadToolVersions(workspace)
+	if err != nil {
+		t.Fatalf("ReadToolVersions failed: %v", err)
+	}
+	want := "api:golang=1.23.4, api:nodejs=20.11.0|18.19.0, web:node=22, web:npm:prettier=3.3.3, web:python=3.12|3.11"
+	if got := describePins(pins); got != want {
+		t.Errorf("pins = %s\nwant   %s", got, want)
+	}
+
+	writeGoFiles(t, root, map[string]string{"etl/mise.toml": "[tools\n"})
+	if _, err := ReadToolVersions(workspace); err == nil || !strings.Contains(err.Error(), filepath.Join("etl", "mise.toml")) {
+		t.Errorf("expected an error naming the invalid file, got %v", err)
+	}
+}
+
+func TestMergeAndGenerateToolVersions(t *testing.T) {
+	merged := MergeToolVersions([]ToolVersion{
+		{Tool: "nodejs", Versions: []string{"20.11.0"}, Repository: "web", Source: ".tool-versions"},
+		{Tool: "golang", Versions: []string{"1.23.4"}, Repository: "api", Source: ".tool-versions"},
+		{Tool: "nodejs", Versions: []string{"22.1.0", "20.11.0"}, Repository: "app", Source: ".mise.toml"},
+		{Tool: "golang", Versions: []string{"1.23.4"}, Repository: "lib", Source: ".tool-versions"},
+	})
+	if len(merged) != 2 || merged[0].Tool != "golang" || merged[0].Conflict || len(merged[0].Pins) != 2 {
+		t.Fatalf("merged = %+v", merged)
+	}
+	if node := merged[1]; !node.Conflict || strings.Join(node.Versions, " ") != "22.1.0 20.11.0" {
+		t.Errorf("nodejs = %+v, want the highest pin and a conflict", node)
+	}
+
+	workspace := &Workspace{Name: "feat"}
+	asdf := GenerateToolVersions(workspace, merged, ToolVersionsFormatASDF)
+	for _, want := range []string{toolVersionsGeneratedHeader, "# Conflict for nodejs, using 22.1.0 20.11.0:\n#   web: 20.11.0 (.tool-versions)\n", "golang 1.23.4\nnodejs 22.1.0 20.11.0\n"} {
+		if !strings.Contains(asdf, want) {
+			t.Errorf(".tool-versions does not contain %q:\n%s", want, asdf)
+		}
+	}
+
+	merged = append(merged, MergedToolVersion{Tool: "npm:prettier", Versions: []string{"3.3.3"}})
+	mise := GenerateToolVersions(workspace, merged, ToolVersionsFormatMise)
+	if !strings.HasSuffix(mise, "[tools]\ngolang = \"1.23.4\"\nnodejs = [\"22.1.0\", \"20.11.0\"]\n\"npm:prettier\" = \"3.3.3\"\n") {
+		t.Errorf(".mise.toml:\n%s", mise)
+	}
+	pins, err := parseMiseToolVersions([]byte(mise))
+	if err != nil || describePins(pins) != ":golang=1.23.4, :nodejs=22.1.0|20.11.0, :npm:prettier=3.3.3" {
+		t.Errorf("the generated file reads back as %s (%v)", describePins(pins), err)
+	}
+}
@@ -0,0 +1,120 @@
+package wsm
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultTicketPattern = `[A-Z][A-Z0-9_]+-[0-9]+`
+	defaultTrailerFormat = "Refs: {ticket}"
+)
+
+// nonTicketPrefixes are encodings and standards whose names look like tickets in branch names
+// (fix/UTF-8-decoding, feat/SHA-256-digests); the default pattern skips them
+var nonTicketPrefixes = []string{"AES", "CVE", "ISO", "RFC", "RSA", "SHA", "UCS", "UTF"}
+
+// trailerLinePattern matches a git trailer line such as "Refs: PROJ-123" or "Signed-off-by: ..."
+var trailerLinePattern = regexp.MustCompile(`^[A-Za-z0-9-]+: `)
+
+// CommitTrailerConfig controls the ticket trailers 'wsm commit' appends to commit messages
+type CommitTrailerConfig struct {
+	// Enabled defaults to true when unset
+	Enabled *bool `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	// Pattern finds ticket IDs in the workspace branch; when it has a capture group, the first
+	// group is the ID (default: Jira keys such as PROJ-123)
+	Pattern string `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	// Format is the trailer for one ticket, {ticket} standing for its ID (default: "Refs: {ticket}")
+	Format string `json:"format,omitempty" yaml:"format,omitempty"`
+}
+
+// IsEnabled reports whether trailers are appended by default
+func (c CommitTrailerConfig) IsEnabled() bool {
+	return c.Enabled == nil || *c.Enabled
+}
+
+// WorkspaceTickets returns the ticket IDs of a workspace: those found in its branch, then its
+// linked issues, without duplicates
+func WorkspaceTickets(workspace *Workspace, config CommitTrailerConfig) ([]string, error) {
+	pattern := config.Pattern
+	if pattern == "" {
+		pattern = defaultTicketPattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid ticket pattern %q", pattern)
+	}
+
+	var tickets []string
+	seen := map[string]bool{}
+	add := func(ticket string) {
+		if ticket != "" && !seen[ticket] {
+			seen[ticket] = true
+			tickets = append(tickets, ticket)
+		}
+	}
+	for _, match := range re.FindAllStringSubmatch(workspace.Branch, -1) {
+		ticket := match[0]
+		if len(match) > 1 {
+			ticket = match[1]
+		}
+		if prefix, _, _ := strings.Cut(ticket, "-"); config.Pattern == "" && slices.Contains(nonTicketPrefixes, prefix) {
+			continue
+		}
+		add(ticket)
+	}
+	for _, ref := range IssueRefs(workspace.Issues) {
+		add(ref)
+	}
+	return tickets, nil
+}
+
+// TicketTrailers formats one trailer per ticket
+func TicketTrailers(tickets []string, config CommitTrailerConfig) []string {
+	format := config.Format
+	if format == "" {
+		format = defaultTrailerFormat
+	}
+	trailers := make([]string, len(tickets))
+	for i, ticket := range tickets {
+		trailers[i] = strings.ReplaceAll(format, "{ticket}", ticket)
+	}
+	return trailers
+}
+
+// AppendTrailers adds the trailers missing from a commit message. They join the trailer block
+// closing the message, if any, and start a new paragraph otherwise.
+func AppendTrailers(message string, trailers []string) string {
+	message = strings.TrimRight(message, "\n")
+	existing := map[string]bool{}
+	for _, line := range strings.Split(message, "\n") {
+		existing[strings.TrimSpace(line)] = true
+	}
+	var missing []string
+	for _, trailer := range trailers {
+		if !existing[trailer] {
+			missing = append(missing, trailer)
+			existing[trailer] = true
+		}
+	}
+	if len(missing) == 0 {
+		return message
+	}
+
+	paragraphs := strings.Split(message, "\n\n")
+	last := paragraphs[len(paragraphs)-1]
+	inTrailerBlock := len(paragraphs) > 1
+	for _, line := range strings.Split(last, "\n") {
+		if !trailerLinePattern.MatchString(line) {
+			inTrailerBlock = false
+			break
+		}
+	}
+	if inTrailerBlock {
+		return message + "\n" + strings.Join(missing, "\n")
+	}
+	return message + "\n\n" + strings.Join(missing, "\n")
+}
@@ -0,0 +1,30 @@
+package wsm
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestWorkspaceTickets(t *testing.T) {
+	tests := []struct {
+		branch  string
+		pattern string
+		want    []string
+	}{
+		{branch: "feature/PROJ-123-login", want: []string{"PROJ-123"}},
+		{branch: "PROJ-1/OPS-22", want: []string{"PROJ-1", "OPS-22"}},
+		{branch: "fix/UTF-8-decoding", want: nil},
+		{branch: "feat/SHA-256-digests-PROJ-9", want: []string{"PROJ-9"}},
+		{branch: "fix/UTF-8", pattern: `UTF-[0-9]+`, want: []string{"UTF-8"}},
+		{branch: "feature/issue-42", pattern: `issue-([0-9]+)`, want: []string{"42"}},
+	}
+	for _, tt := range tests {
+		got, err := WorkspaceTickets(&Workspace{Branch: tt.branch}, CommitTrailerConfig{Pattern: tt.pattern})
+		if err != nil {
+			t.Fatalf("WorkspaceTickets(%q) failed: %v", tt.branch, err)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("WorkspaceTickets(%q) = %v, want %v", tt.branch, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,408 @@
+package wsm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultTrashRetention is how long deleted workspaces can be restored
+	DefaultTrashRetention = 7 * 24 * time.Hour
+	// trashEntryFile describes a deleted workspace in its trash directory
+	trashEntryFile = "trash.json"
+)
+
+// TrashConfig configures the trash 'delete --remove-files' moves workspaces into
+type TrashConfig struct {
+	// Retention is how long deleted workspaces can be restored, e.g. 14d or 72h (default 7d);
+	// "off" deletes workspaces permanently
+	Retention string `json:"retention,omitempty" yaml:"retention,omitempty"`
+}
+
+// Enabled reports whether deleted workspaces go to the trash
+func (c TrashConfig) Enabled() bool {
+	return c.Retention != "off"
+}
+
+// RetentionDuration parses Retention, falling back to DefaultTrashRetention
+func (c TrashConfig) RetentionDuration() (time.Duration, error) {
+	if c.Retention == "" || c.Retention == "off" {
+		return DefaultTrashRetention, nil
+	}
+	d, err := ParseAge(c.Retention)
+	if err != nil {
+		return 0, errors.Errorf("invalid trash.retention '%s': expected a duration such as 14d or 72h, or off", c.Retention)
+	}
+	return d, nil
+}
+
+// Validate checks the retention
+func (c TrashConfig) Validate() error {
+	_, err := c.RetentionDuration()
+	return err
+}
+
+// TrashedWorktree is the git registration of a worktree of a deleted workspace
+type TrashedWorktree struct {
+	Repository string `json:"repository"`
+	// ID is the name of the administrative directory of the worktree, .git/worktrees/<id>
+	ID     string `json:"id"`
+	Branch string `json:"branch,omitempty"`
+	Head   string `json:"head"`
+}
+
+// TrashEntry is a workspace deleted with its files, which UndeleteWorkspace restores until it
+// expires
+type TrashEntry struct {
+	Workspace Workspace         `json:"workspace"`
+	Profile   string            `json:"profile"`
+	Deleted   time.Time         `json:"deleted"`
+	Expires   time.Time         `json:"expires"`
+	Worktrees []TrashedWorktree `json:"worktrees,omitempty"`
+
+	// Dir is the directory of the entry in the trash
+	Dir string `json:"-"`
+}
+
+// TrashDir returns the directory deleted workspaces are moved to: $XDG_DATA_HOME/wsm/trash, by
+// default ~/.local/share/wsm/trash
+func TrashDir() (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", errors.Wrap(err, "failed to get home directory")
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "wsm", "trash"), nil
+}
+
+// trashWorkspace moves the workspace directory into the trash. The administrative directories of
+// its worktrees (.git/worktrees/<id>) are moved along: git forgets the worktrees, so their
+// branches can be checked out elsewhere, while their index, HEAD and lock are kept for
+// UndeleteWorkspace. Like removing them, trashing locked worktrees requires force. It returns nil
+// when the workspace directory does not exist.
+func (wm *WorkspaceManager) trashWorkspace(ctx context.Context, workspace *Workspace, force bool) (*TrashEntry, error) {
+	if _, err := wm.fs().Stat(workspace.Path); os.IsNotExist(err) {
+		return nil, nil
+	}
+	retention, err := wm.config.Trash.RetentionDuration()
+	if err != nil {
+		return nil, err
+	}
+	trashDir, err := TrashDir()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	entry := &TrashEntry{
+		Workspace: *workspace,
+		Profile:   CurrentProfile(),
+		Deleted:   now,
+		Expires:   now.Add(retention),
+		Dir:       filepath.Join(trashDir, fmt.Sprintf("%s-%s", workspace.Name, now.Format("20060102-150405"))),
+	}
+
+	// The administrative directories are found through the worktrees, before they move
+	adminDirs := map[string]string{}
+	var worktrees []TrashedWorktree
+	if !workspace.Linked && workspace.Clone == "" {
+		for _, repo := range workspace.Repositories {
+			worktreePath := filepath.Join(workspace.Path, repo.Name)
+			if _, err := wm.checkWorktreeRemovable(ctx, repo, worktreePath, force); err != nil {
+				return nil, err
+			}
+			gitDir, err := gitOutput(ctx, wm.runner(), worktreePath, "rev-parse", "--path-format=absolute", "--git-dir")
+			if err != nil || filepath.Base(filepath.Dir(gitDir)) != "worktrees" {
+				continue
+			}
+			head, _ := gitOutput(ctx, wm.runner(), worktreePath, "rev-parse", "HEAD")
+			branch, _ := gitOutput(ctx, wm.runner(), worktreePath, "symbolic-ref", "--quiet", "--short", "HEAD")
+			adminDirs[repo.Name] = gitDir
+			worktrees = append(worktrees, TrashedWorktree{Repository: repo.Name, ID: filepath.Base(gitDir), Branch: branch, Head: head})
+		}
+	}
+
+	output.LogInfo(
+		fmt.Sprintf("Moving workspace directory %s to the trash", workspace.Path),
+		"Moving workspace to the trash",
+		"path", workspace.Path,
+		"trash", entry.Dir,
+	)
+	if err := wm.fs().MkdirAll(entry.Dir, 0755); err != nil {
+		return nil, errors.Wrap(err, "failed to create the trash directory")
+	}
+	if err := moveDir(workspace.Path, filepath.Join(entry.Dir, "files")); err != nil {
+		_ = wm.fs().RemoveAll(entry.Dir)
+		return nil, errors.Wrapf(err, "failed to move %s to the trash", workspace.Path)
+	}
+
+	for _, worktree := range worktrees {
+		if err := moveDir(adminDirs[worktree.Repository], filepath.Join(entry.Dir, "worktrees", worktree.Repository)); err != nil {
+			// git prunes the registration of the missing worktree later; only its index is lost
+			output.LogWarn(
+				fmt.Sprintf("Failed to move the worktree registration of '%s' to the trash: %v", worktree.Repository, err),
+				"Failed to move worktree registration to the trash",
+				"repo", worktree.Repository,
+				"error", err,
+			)
+			continue
+		}
+		entry.Worktrees = append(entry.Worktrees, worktree)
+	}
+
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal the trash entry")
+	}
+	if err := wm.fs().WriteFile(filepath.Join(entry.Dir, trashEntryFile), data, 0644); err != nil {
+		return nil, errors.Wrap(err, "failed to write the trash entry")
+	}
+	return entry, nil
+}
+
+// ListTrash returns the workspaces of the current profile in the trash, most recently deleted
+// first
+func ListTrash() ([]TrashEntry, error) {
+	entries, err := loadTrash()
+	if err != nil {
+		return nil, err
+	}
+	profile := CurrentProfile()
+	var result []TrashEntry
+	for _, entry := range entries {
+		if entry.Profile == profile {
+			result = append(result, entry)
+		}
+	}
+	return result, nil
+}
+
+// loadTrash reads the entries of all profiles, most recently deleted first
+func loadTrash() ([]TrashEntry, error) {
+	trashDir, err := TrashDir()
+	if err != nil {
+		return nil, err
+	}
+	dirs, err := os.ReadDir(trashDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", trashDir)
+	}
+
+	var entries []TrashEntry
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		entryDir := filepath.Join(trashDir, dir.Name())
+		data, err := os.ReadFile(filepath.Join(entryDir, trashEntryFile))
+		if err != nil {
+			continue
+		}
+		var entry TrashEntry
+		if json.Unmarshal(data, &entry) != nil {
+			continue
+		}
+		entry.Dir = entryDir
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Deleted.After(entries[j].Deleted)
+	})
+	return entries, nil
+}
+
+// PurgeTrash permanently deletes the workspaces whose retention ended, in all profiles
+func PurgeTrash() ([]TrashEntry, error) {
+	entries, err := loadTrash()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var purged []TrashEntry
+	for _, entry := range entries {
+		if entry.Expires.After(now) {
+			continue
+		}
+		if err := os.RemoveAll(entry.Dir); err != nil {
+			return purged, errors.Wrapf(err, "failed to remove %s", entry.Dir)
+		}
+		purged = append(purged, entry)
+	}
+	return purged, nil
+}
+
+// UndeleteWorkspace restores the most recently deleted workspace named name from the trash: its
+// directory moves back to its path, its worktrees are registered again with the repositories and
+// its configuration is saved. A branch deleted in the meantime is recreated at the commit the
+// worktree was on.
+func (wm *WorkspaceManager) UndeleteWorkspace(ctx context.Context, name string) (*Workspace, error) {
+	start := time.Now()
+	entries, err := ListTrash()
+	if err != nil {
+		return nil, err
+	}
+	var entry *TrashEntry
+	for i := range entries {
+		if entries[i].Workspace.Name == name {
+			entry = &entries[i]
+			break
+		}
+	}
+	if entry == nil {
+		return nil, errors.Errorf("workspace '%s' is not in the trash", name)
+	}
+	if _, err := wm.LoadWorkspace(name); err == nil {
+		return nil, errors.Errorf("a workspace named '%s' exists; delete it before restoring the deleted one", name)
+	}
+	workspace := &entry.Workspace
+	if _, err := wm.fs().Lstat(workspace.Path); err == nil {
+		return nil, errors.Errorf("%s already exists; move it away before restoring workspace '%s'", workspace.Path, name)
+	}
+
+	output.LogInfo(
+		fmt.Sprintf("Restoring workspace directory %s from the trash", workspace.Path),
+		"Restoring workspace from the trash",
+		"path", workspace.Path,
+		"trash", entry.Dir,
+	)
+	if err := wm.fs().MkdirAll(filepath.Dir(workspace.Path), 0755); err != nil {
+		return nil, errors.Wrapf(err, "failed to create %s", filepath.Dir(workspace.Path))
+	}
+	if err := moveDir(filepath.Join(entry.Dir, "files"), workspace.Path); err != nil {
+		return nil, errors.Wrapf(err, "failed to restore %s", workspace.Path)
+	}
+
+	// The links of linked workspaces and the clones of cloned workspaces moved with the files
+	var problems []string
+	for _, worktree := range entry.Worktrees {
+		if err := wm.reregisterWorktree(ctx, workspace, worktree, filepath.Join(entry.Dir, "worktrees", worktree.Repository)); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", worktree.Repository, err))
+		}
+	}
+
+	if err := wm.SaveWorkspace(workspace); err != nil {
+		return nil, errors.Wrap(err, "failed to save workspace configuration")
+	}
+	if err := wm.fs().RemoveAll(entry.Dir); err != nil {
+		output.PrintWarning("Failed to remove %s from the trash: %v", entry.Dir, err)
+	}
+
+	RecordOperation("undelete", workspace.Name, start, map[string]string{
+		"path":    workspace.Path,
+		"deleted": entry.Deleted.Format(time.RFC3339),
+	})
+
+	if len(problems) > 0 {
+		return workspace, errors.Errorf("workspace restored, but some repositories need 'wsm reconcile': %s", strings.Join(problems, "; "))
+	}
+	return workspace, nil
+}
+
+// reregisterWorktree moves the administrative directory of a worktree back into its repository and
+// lets git repair the links between the two. When the directory name was taken in the meantime,
+// the worktree is registered under a new one.
+func (wm *WorkspaceManager) reregisterWorktree(ctx context.Context, workspace *Workspace, worktree TrashedWorktree, adminDir string) error {
+	var repo *Repository
+	for i := range workspace.Repositories {
+		if workspace.Repositories[i].Name == worktree.Repository {
+			repo = &workspace.Repositories[i]
+		}
+	}
+	if repo == nil {
+		return errors.New("not a repository of the workspace")
+	}
+	worktreePath := filepath.Join(workspace.Path, repo.Name)
+
+	commonDir, err := gitOutput(ctx, wm.runner(), repo.Path, "rev-parse", "--path-format=absolute", "--git-common-dir")
+	if err != nil {
+		return errors.Wrapf(err, "failed to find the repository at %s", repo.Path)
+	}
+	id := worktree.ID
+	target := filepath.Join(commonDir, "worktrees", id)
+	for i := 1; ; i++ {
+		if _, err := wm.fs().Lstat(target); os.IsNotExist(err) {
+			break
+		}
+		id = fmt.Sprintf("%s%d", worktree.ID, i)
+		target = filepath.Join(commonDir, "worktrees", id)
+	}
+
+	if err := wm.fs().MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return errors.Wrap(err, "failed to create the worktrees directory")
+	}
+	if err := moveDir(adminDir, target); err != nil {
+		return errors.Wrap(err, "failed to restore the worktree registration")
+	}
+	if id != worktree.ID {
+		if err := wm.fs().WriteFile(filepath.Join(worktreePath, ".git"), []byte("gitdir: "+target+"\n"), 0644); err != nil {
+			return errors.Wrap(err, "failed to point the worktree at its new registration")
+		}
+	}
+	if _, err := gitOutput(ctx, wm.runner(), repo.Path, "worktree", "repair", worktreePath); err != nil {
+		return err
+	}
+
+	if worktree.Branch != "" && worktree.Head != "" {
+		if _, err := gitOutput(ctx, wm.runner(), repo.Path, "rev-parse", "--verify", "--quiet", "refs/heads/"+worktree.Branch); err != nil {
+			output.PrintWarning("Branch '%s' of '%s' was deleted, recreating it at %.7s", worktree.Branch, repo.Name, worktree.Head)
+			if _, err := gitOutput(ctx, wm.runner(), repo.Path, "branch", worktree.Branch, worktree.Head); err != nil {
+				return errors.Wrapf(err, "failed to recreate branch '%s'", worktree.Branch)
+			}
+		}
+	}
+	return nil
+}
+
+// renameDir is os.Rename; tests replace it to move directories as across filesystems
+var renameDir = os.Rename
+
+// moveDir renames src to dst, copying and deleting src when they are on different filesystems
+func moveDir(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	if err := renameDir(src, dst); err == nil {
+		return nil
+	}
+
+	err := filepath.WalkDir(src, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		switch {
+		case entry.IsDir():
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			return os.MkdirAll(target, info.Mode().Perm())
+		default:
+			return copyFile(path, target)
+		}
+	})
+	if err != nil {
+		_ = os.RemoveAll(dst)
+		return err
+	}
+	return os.RemoveAll(src)
+}
@@ -0,0 +1,143 @@
+package wsm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// moveAcrossFilesystems makes moveDir copy directories as if the trash were on another filesystem
+func moveAcrossFilesystems(t *testing.T) {
+	t.Helper()
+	previous := renameDir
+	renameDir = func(src, dst string) error {
+		return &os.LinkError{Op: "rename", Old: src, New: dst, Err: syscall.EXDEV}
+	}
+	t.Cleanup(func() { renameDir = previous })
+}
+
+func TestTrashAndUndeleteWorkspace(t *testing.T) {
+	for _, crossDevice := range []bool{false, true} {
+		name := "same filesystem"
+		if crossDevice {
+			name = "across filesystems"
+		}
+		t.Run(name, func(t *testing.T) {
+			useTestConfigDir(t)
+			t.Setenv("XDG_DATA_HOME", t.TempDir())
+			if crossDevice {
+				moveAcrossFilesystems(t)
+			}
+			ctx := context.Background()
+			wm := newTestWorkspaceManager(t)
+
+			repo := filepath.Join(t.TempDir(), "app")
+			if err := os.MkdirAll(repo, 0755); err != nil {
+				t.Fatal(err)
+			}
+			testGit(t, repo, "init")
+			testGit(t, repo, "commit", "--allow-empty", "-m", "initial")
+
+			workspace := &Workspace{
+				Name:         "feat",
+				Path:         filepath.Join(wm.workspaceDir, "feat"),
+				Branch:       "feature/x",
+				Repositories: []Repository{{Name: "app", Path: repo}},
+			}
+			worktree := filepath.Join(workspace.Path, "app")
+			testGit(t, repo, "worktree", "add", "-b", "feature/x", worktree)
+			writeGoFiles(t, worktree, map[string]string{"wip.go": "package app\n"})
+			testGit(t, worktree, "add", "wip.go")
+			testGit(t, worktree, "commit", "-m", "wip")
+			head := testGit(t, worktree, "rev-parse", "HEAD")
+			writeGoFiles(t, worktree, map[string]string{"staged.go": "package app\n"})
+			testGit(t, worktree, "add", "staged.go")
+
+			entry, err := wm.trashWorkspace(ctx, workspace, false)
+			if err != nil {
+				t.Fatalf("trashWorkspace failed: %v", err)
+			}
+			if _, err := os.Stat(workspace.Path); !os.IsNotExist(err) {
+				t.Fatalf("the workspace directory is still there: %v", err)
+			}
+			if len(entry.Worktrees) != 1 || entry.Worktrees[0].ID != "app" || entry.Worktrees[0].Head != head {
+				t.Fatalf("trashed worktrees = %+v", entry.Worktrees)
+			}
+			if worktrees := testGit(t, repo, "worktree", "list", "--porcelain"); strings.Contains(worktrees, worktree) {
+				t.Fatalf("git still knows the trashed worktree:\n%s", worktrees)
+			}
+
+			// Meanwhile the branch is deleted and another worktree takes the administrative directory
+			testGit(t, repo, "branch", "-D", "feature/x")
+			other := filepath.Join(t.TempDir(), "app")
+			testGit(t, repo, "worktree", "add", "-b", "other", other)
+
+			restored, err := wm.UndeleteWorkspace(ctx, "feat")
+			if err != nil {
+				t.Fatalf("UndeleteWorkspace failed: %v", err)
+			}
+			if restored.Path != workspace.Path {
+				t.Errorf("restored to %s, want %s", restored.Path, workspace.Path)
+			}
+			if _, err := os.Stat(entry.Dir); !os.IsNotExist(err) {
+				t.Errorf("the trash entry was not removed: %v", err)
+			}
+
+			gitFile, err := os.ReadFile(filepath.Join(worktree, ".git"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasSuffix(strings.TrimSpace(string(gitFile)), filepath.Join("worktrees", "app1")) {
+				t.Errorf("the worktree should be registered under a new ID, .git is %q", gitFile)
+			}
+			if got := testGit(t, repo, "rev-parse", "refs/heads/feature/x"); got != head {
+				t.Errorf("feature/x was recreated at %s, want %s", got, head)
+			}
+			if got := testGit(t, worktree, "symbolic-ref", "--short", "HEAD"); got != "feature/x" {
+				t.Errorf("the restored worktree is on %s", got)
+			}
+			// The index moved with the registration, so the staged file is still staged
+			if status := testGit(t, worktree, "status", "--porcelain"); status != "A  staged.go" {
+				t.Errorf("status of the restored worktree = %q", status)
+			}
+			if got := testGit(t, other, "symbolic-ref", "--short", "HEAD"); got != "other" {
+				t.Errorf("the worktree that took the ID is on %s", got)
+			}
+			if _, err := os.Stat(filepath.Join(filepath.Dir(wm.config.RegistryPath), "workspaces", "feat.json")); err != nil {
+				t.Errorf("the restored workspace was not saved: %v", err)
+			}
+		})
+	}
+}
+
+func TestMoveDirAcrossFilesystems(t *testing.T) {
+	moveAcrossFilesystems(t)
+	src := filepath.Join(t.TempDir(), "src")
+	writeGoFiles(t, src, map[string]string{"a/b.txt": "b", "run.sh": "#!/bin/sh\n"})
+	if err := os.Chmod(filepath.Join(src, "run.sh"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("a/b.txt", filepath.Join(src, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(t.TempDir(), "nested", "dst")
+	if err := moveDir(src, dst); err != nil {
+		t.Fatalf("moveDir failed: %v", err)
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Errorf("the source was not removed: %v", err)
+	}
+	if content, err := os.ReadFile(filepath.Join(dst, "a", "b.txt")); err != nil || string(content) != "b" {
+		t.Errorf("a/b.txt = %q, %v", content, err)
+	}
+	if info, err := os.Stat(filepath.Join(dst, "run.sh")); err != nil || info.Mode().Perm() != 0755 {
+		t.Errorf("run.sh lost its mode: %v, %v", info, err)
+	}
+	if link, err := os.Readlink(filepath.Join(dst, "link")); err != nil || link != "a/b.txt" {
+		t.Errorf("link = %q, %v", link, err)
+	}
+}
@@ -15,12 +15,16 @@ type Repository struct {
 	LastCommit    string    `json:"last_commit"`
 	LastUpdated   time.Time `json:"last_updated"`
 	Categories    []string  `json:"categories"`
+	// Description is the first line of prose of the README, taken at discovery
+	Description string `json:"description,omitempty"`
 }
 
 // RepositoryRegistry stores discovered repositories
 type RepositoryRegistry struct {
 	Repositories []Repository `json:"repositories"`
 	LastScan     time.Time    `json:"last_scan"`
+	// Aliases maps short names to repository names
+	Aliases map[string]string `json:"aliases,omitempty"`
 }
 
 // Workspace represents a multi-repository workspace
@@ -33,28 +37,115 @@ type Workspace struct {
 	Created      time.Time    `json:"created"`
 	GoWorkspace  bool         `json:"go_workspace"`
 	AgentMD      string       `json:"agent_md"`
+	AgentAssets  []AgentAsset `json:"agent_assets,omitempty"`
+	// BranchPoints maps repository names to the commit their worktree branched off
+	BranchPoints map[string]string `json:"branch_points,omitempty"`
+	// Children are workspaces included as components; their directories are linked into this one
+	Children []string `json:"children,omitempty"`
+	// Parent is the workspace that owns this one as a child
+	Parent string `json:"parent,omitempty"`
+	// Container is the dev container started by 'wsm container up'
+	Container *WorkspaceContainer `json:"container,omitempty"`
+	// Issues are the tickets linked with 'wsm link issue'
+	Issues []IssueLink `json:"issues,omitempty"`
+	// Frozen are the repositories excluded from commit, sync and push with 'wsm freeze'
+	Frozen []string `json:"frozen,omitempty"`
+	// GitConfig is the git configuration set in every worktree, see 'wsm gitconfig'
+	GitConfig *GitConfigOverrides `json:"git_config,omitempty"`
+	// Labels are key=value pairs set with 'wsm label', used to select workspaces
+	Labels map[string]string `json:"labels,omitempty"`
+	// AppliedPRs are the pull requests of others merged or cherry-picked with 'wsm apply-pr'
+	AppliedPRs []AppliedPR `json:"applied_prs,omitempty"`
+	// BuildManifests are the build manifests other than go.work generated at the workspace root
+	// (pnpm, cargo, maven)
+	BuildManifests []string `json:"build_manifests,omitempty"`
+	// Linked workspaces include their repositories as symbolic links to the registered checkouts
+	// instead of worktrees, see 'wsm create --link'
+	Linked bool `json:"linked,omitempty"`
+	// Clone is "shared" or "full" for workspaces whose repositories are local clones instead of
+	// worktrees, see 'wsm create --clone'
+	Clone string `json:"clone,omitempty"`
+
+	// repositoryBases overrides BaseBranch per repository while the worktrees are created
+	repositoryBases map[string]string
 }
 
 // WorkspaceConfig holds workspace management configuration
 type WorkspaceConfig struct {
-	WorkspaceDir string `json:"workspace_dir"`
-	TemplateDir  string `json:"template_dir"`
-	RegistryPath string `json:"registry_path"`
+	WorkspaceDir string `json:"workspace_dir" yaml:"workspace_dir"`
+	// WorkspaceLayout is "dated" to create workspaces below a directory per day of WorkspaceDir,
+	// or "flat" to create them in WorkspaceDir itself
+	WorkspaceLayout string `json:"workspace_layout,omitempty" yaml:"workspace_layout,omitempty"`
+	TemplateDir     string `json:"template_dir" yaml:"template_dir"`
+	RegistryPath    string `json:"registry_path" yaml:"registry_path"`
+	// DiscoveryPaths are scanned by 'wsm discover' when it is given no paths
+	DiscoveryPaths []string         `json:"discovery_paths,omitempty" yaml:"discovery_paths,omitempty"`
+	Discovery      DiscoveryConfig  `json:"discovery" yaml:"discovery"`
+	AgentAssets    []AgentAsset     `json:"agent_assets" yaml:"agent_assets"`
+	Bootstrap      BootstrapConfig  `json:"bootstrap" yaml:"bootstrap"`
+	Tmux           TmuxProfile      `json:"tmux" yaml:"tmux"`
+	Term           TermConfig       `json:"term" yaml:"term"`
+	Scaffold       ScaffoldConfig   `json:"scaffold" yaml:"scaffold"`
+	CommitLint     CommitLintConfig `json:"commit_lint" yaml:"commit_lint"`
+	Status         StatusConfig     `json:"status" yaml:"status"`
+	Setup          SetupConfig      `json:"setup" yaml:"setup"`
+	Remote         RemoteConfig     `json:"remote" yaml:"remote"`
+	Container      ContainerConfig  `json:"container" yaml:"container"`
+	Issues         IssuesConfig     `json:"issues" yaml:"issues"`
+	Watch          WatchConfig      `json:"watch" yaml:"watch"`
+	Prefetch       PrefetchConfig   `json:"prefetch" yaml:"prefetch"`
+	Daemon         DaemonConfig     `json:"daemon" yaml:"daemon"`
+	// GitConfig is copied into new workspaces and applied to their worktrees
+	GitConfig GitConfigOverrides `json:"git_config" yaml:"git_config"`
+	Signing   SigningConfig      `json:"signing" yaml:"signing"`
+	// GoWork configures the go.work file of Go workspaces
+	GoWork GoWorkConfig `json:"go_work" yaml:"go_work"`
+	// BranchProtection decides what happens when the branch of a new workspace is protected on GitHub
+	BranchProtection BranchProtectionConfig `json:"branch_protection" yaml:"branch_protection"`
+	// Trash keeps the workspaces deleted with their files restorable for a while
+	Trash TrashConfig `json:"trash" yaml:"trash"`
+	// Drift sets when 'wsm drift' flags branches that fell behind their default branch
+	Drift DriftConfig `json:"drift" yaml:"drift"`
+	// Timeouts bound fetches, pushes and clones of a single repository
+	Timeouts TimeoutConfig `json:"timeouts" yaml:"timeouts"`
+	// Quota limits the number of workspaces and the disk space they take
+	Quota QuotaConfig `json:"quota" yaml:"quota"`
+	// Webhooks receive workspace events, e.g. to post them to a Slack channel
+	Webhooks []WebhookConfig `json:"webhooks,omitempty" yaml:"webhooks,omitempty"`
+	// Encryption configures the unlocking of git-crypt and blackbox repositories
+	Encryption EncryptionConfig `json:"encryption" yaml:"encryption"`
+}
+
+// AgentAsset describes a templated file installed into new workspaces for coding assistants
+// (CLAUDE.md, .cursorrules, .aider.conf.yml, ...)
+type AgentAsset struct {
+	Source string `json:"source" yaml:"source"`
+	Target string `json:"target" yaml:"target"`
+	// Scope is one of "workspace" (default), "repos" or "all"
+	Scope string `json:"scope,omitempty" yaml:"scope,omitempty"`
+	// Repos restricts repository-scoped installation to the listed repositories
+	Repos []string `json:"repos,omitempty" yaml:"repos,omitempty"`
 }
 
 // RepositoryStatus represents the git status of a repository
 type RepositoryStatus struct {
-	Repository     Repository `json:"repository"`
-	HasChanges     bool       `json:"has_changes"`
-	StagedFiles    []string   `json:"staged_files"`
-	ModifiedFiles  []string   `json:"modified_files"`
-	UntrackedFiles []string   `json:"untracked_files"`
-	Ahead          int        `json:"ahead"`
-	Behind         int        `json:"behind"`
-	CurrentBranch  string     `json:"current_branch"`
-	HasConflicts   bool       `json:"has_conflicts"`
-	IsMerged       bool       `json:"is_merged"`    // True if branch is merged to origin/main
-	NeedsRebase    bool       `json:"needs_rebase"` // True if branch needs to be rebased on origin/main
+	Repository     Repository         `json:"repository"`
+	HasChanges     bool               `json:"has_changes"`
+	StagedFiles    []string           `json:"staged_files"`
+	ModifiedFiles  []string           `json:"modified_files"`
+	UntrackedFiles []string           `json:"untracked_files"`
+	Ahead          int                `json:"ahead"`
+	Behind         int                `json:"behind"`
+	CurrentBranch  string             `json:"current_branch"`
+	HasConflicts   bool               `json:"has_conflicts"`
+	IsMerged       bool               `json:"is_merged"`    // True if branch is merged to origin/main
+	NeedsRebase    bool               `json:"needs_rebase"` // True if branch needs to be rebased on origin/main
+	Remotes        []RemoteDivergence `json:"remotes,omitempty"`
+	Frozen         bool               `json:"frozen,omitempty"`
+	// FetchedAt is when 'wsm prefetch' last updated the remote-tracking refs ahead/behind are computed from
+	FetchedAt time.Time `json:"fetched_at,omitzero"`
+	// Encryption tells whether the worktree has git-crypt or blackbox files and whether they are still encrypted
+	Encryption EncryptionState `json:"encryption,omitzero"`
 }
 
 // WorkspaceStatus represents the overall status of a workspace
@@ -62,6 +153,8 @@ type WorkspaceStatus struct {
 	Workspace    Workspace          `json:"workspace"`
 	Repositories []RepositoryStatus `json:"repositories"`
 	Overall      string             `json:"overall"`
+	// Container is the state of the workspace dev container (running, stopped, missing), if any
+	Container string `json:"container,omitempty"`
 }
 
 // WorktreeInfo tracks information about a created worktree for rollback purposes
@@ -0,0 +1,121 @@
+package wsm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/pkg/errors"
+)
+
+// UnpushedRepository is a workspace repository with commits that are not on any remote
+type UnpushedRepository struct {
+	Workspace  string `json:"workspace"`
+	Repository string `json:"repository"`
+	Path       string `json:"path"`
+	Branch     string `json:"branch"`
+	Commits    int    `json:"commits"`
+	// Frozen is set for repositories excluded from batch operations with 'wsm freeze'
+	Frozen bool `json:"frozen,omitempty"`
+	// Linked is set for repositories of linked workspaces, whose path is the checkout itself
+	Linked bool `json:"linked,omitempty"`
+}
+
+// FindUnpushedCommits scans the worktrees of all given workspaces for commits not present on any remote.
+// Worktrees that no longer exist or cannot be inspected are skipped with a warning.
+func FindUnpushedCommits(ctx context.Context, workspaces []Workspace) []UnpushedRepository {
+	var result []UnpushedRepository
+
+	for _, workspace := range workspaces {
+		for _, repo := range workspace.Repositories {
+			worktreePath := filepath.Join(workspace.Path, repo.Name)
+			if _, err := os.Stat(worktreePath); err != nil {
+				continue
+			}
+
+			unpushed, err := checkUnpushed(ctx, worktreePath)
+			if err != nil {
+				output.LogWarn(
+					fmt.Sprintf("Could not check %s/%s: %v", workspace.Name, repo.Name, err),
+					"Failed to check unpushed commits",
+					"workspace", workspace.Name,
+					"repo", repo.Name,
+					"error", err,
+				)
+				continue
+			}
+			if unpushed == nil {
+				continue
+			}
+
+			unpushed.Workspace = workspace.Name
+			unpushed.Repository = repo.Name
+			unpushed.Frozen = workspace.IsFrozen(repo.Name)
+			unpushed.Linked = workspace.Linked
+			result = append(result, *unpushed)
+		}
+	}
+
+	return result
+}
+
+func checkUnpushed(ctx context.Context, worktreePath string) (*UnpushedRepository, error) {
+	countStr, err := runGitOutput(ctx, worktreePath, "rev-list", "--count", "HEAD", "--not", "--remotes")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to count unpushed commits")
+	}
+
+	count, err := strconv.Atoi(countStr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unexpected rev-list output: %s", countStr)
+	}
+	if count == 0 {
+		return nil, nil
+	}
+
+	branch, err := runGitOutput(ctx, worktreePath, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get current branch")
+	}
+
+	return &UnpushedRepository{
+		Path:    worktreePath,
+		Branch:  branch,
+		Commits: count,
+	}, nil
+}
+
+// SplitPushable separates the unpushed repositories that --push-all may push from those of frozen
+// repositories and linked workspaces, which are only reported
+func SplitPushable(unpushed []UnpushedRepository) ([]UnpushedRepository, []UnpushedRepository) {
+	var pushable, skipped []UnpushedRepository
+	for _, repo := range unpushed {
+		if repo.Frozen || repo.Linked {
+			skipped = append(skipped, repo)
+			continue
+		}
+		pushable = append(pushable, repo)
+	}
+	return pushable, skipped
+}
+
+// PushUnpushed pushes the branch of an unpushed repository to the given remote and sets upstream
+// tracking. The push is bounded by the push timeout of the repository.
+func PushUnpushed(ctx context.Context, repo UnpushedRepository, remote string, timeouts TimeoutConfig) error {
+	workspace := Workspace{Name: repo.Workspace, Linked: repo.Linked}
+	if err := workspace.RequireWorktrees("pushing"); err != nil {
+		return err
+	}
+	if repo.Frozen {
+		return errors.Errorf("repository '%s' is frozen in workspace '%s'", repo.Repository, repo.Workspace)
+	}
+	if repo.Branch == "HEAD" {
+		return errors.New("detached HEAD cannot be pushed")
+	}
+	ctx, done := WithNetworkTimeout(ctx, timeouts, repo.Repository, OperationPush)
+	_, err := runGitOutput(ctx, repo.Path, "push", "-u", remote, repo.Branch)
+	return done(err)
+}
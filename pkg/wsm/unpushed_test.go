@@ -0,0 +1,91 @@
+package wsm
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newUnpushedRepo creates a clone of a bare remote with one commit that is not pushed
+func newUnpushedRepo(t *testing.T, root, name string) {
+	t.Helper()
+	remote := filepath.Join(t.TempDir(), name+".git")
+	testGit(t, root, "init", "--quiet", "--bare", remote)
+	testGit(t, root, "clone", "--quiet", remote, name)
+	dir := filepath.Join(root, name)
+	testGit(t, dir, "commit", "--quiet", "--allow-empty", "-m", "Initial")
+	testGit(t, dir, "push", "--quiet", "origin", "HEAD:main")
+	testGit(t, dir, "commit", "--quiet", "--allow-empty", "-m", "Local")
+}
+
+func TestFindUnpushedCommits(t *testing.T) {
+	root := t.TempDir()
+	newUnpushedRepo(t, root, "lib")
+	newUnpushedRepo(t, root, "app")
+	testGit(t, filepath.Join(root, "app"), "push", "--quiet", "origin", "HEAD:main")
+	newUnpushedRepo(t, root, "docs")
+
+	// The worktree of gone no longer exists and is skipped
+	workspaces := []Workspace{{
+		Name:         "ws",
+		Path:         root,
+		Repositories: []Repository{{Name: "lib"}, {Name: "app"}, {Name: "docs"}, {Name: "gone"}},
+		Frozen:       []string{"docs"},
+	}}
+	unpushed := FindUnpushedCommits(context.Background(), workspaces)
+	if len(unpushed) != 2 {
+		t.Fatalf("unpushed = %+v, want lib and docs", unpushed)
+	}
+	lib, docs := unpushed[0], unpushed[1]
+	if lib.Workspace != "ws" || lib.Repository != "lib" || lib.Commits != 1 || lib.Frozen || lib.Path != filepath.Join(root, "lib") {
+		t.Errorf("lib = %+v", lib)
+	}
+	if docs.Repository != "docs" || !docs.Frozen {
+		t.Errorf("docs = %+v, want a frozen repository", docs)
+	}
+}
+
+func TestSplitPushableSkipsFrozenAndLinked(t *testing.T) {
+	unpushed := []UnpushedRepository{
+		{Workspace: "ws", Repository: "lib", Branch: "feature"},
+		{Workspace: "ws", Repository: "docs", Branch: "feature", Frozen: true},
+		{Workspace: "look", Repository: "lib", Branch: "main", Linked: true},
+	}
+	pushable, skipped := SplitPushable(unpushed)
+	if len(pushable) != 1 || pushable[0].Repository != "lib" || pushable[0].Workspace != "ws" {
+		t.Errorf("pushable = %+v, want ws/lib", pushable)
+	}
+	if len(skipped) != 2 {
+		t.Errorf("skipped = %+v, want the frozen and the linked repository", skipped)
+	}
+
+	ctx := context.Background()
+	if err := PushUnpushed(ctx, unpushed[1], "origin", TimeoutConfig{}); err == nil || !strings.Contains(err.Error(), "frozen") {
+		t.Errorf("pushing a frozen repository should fail, got %v", err)
+	}
+	if err := PushUnpushed(ctx, unpushed[2], "origin", TimeoutConfig{}); err == nil || !strings.Contains(err.Error(), "links existing checkouts") {
+		t.Errorf("pushing from a linked workspace should fail, got %v", err)
+	}
+}
+
+func TestPushUnpushedSetsUpstream(t *testing.T) {
+	root := t.TempDir()
+	newUnpushedRepo(t, root, "lib")
+	dir := filepath.Join(root, "lib")
+	testGit(t, dir, "checkout", "--quiet", "-b", "feature")
+
+	unpushed := FindUnpushedCommits(context.Background(), []Workspace{{Name: "ws", Path: root, Repositories: []Repository{{Name: "lib"}}}})
+	if len(unpushed) != 1 || unpushed[0].Branch != "feature" {
+		t.Fatalf("unpushed = %+v", unpushed)
+	}
+	if err := PushUnpushed(context.Background(), unpushed[0], "origin", TimeoutConfig{Push: "1m"}); err != nil {
+		t.Fatalf("PushUnpushed failed: %v", err)
+	}
+	if upstream := testGit(t, dir, "rev-parse", "--abbrev-ref", "feature@{upstream}"); upstream != "origin/feature" {
+		t.Errorf("upstream = %q, want origin/feature", upstream)
+	}
+	if left := FindUnpushedCommits(context.Background(), []Workspace{{Name: "ws", Path: root, Repositories: []Repository{{Name: "lib"}}}}); len(left) != 0 {
+		t.Errorf("still unpushed after the push: %+v", left)
+	}
+}
@@ -0,0 +1,81 @@
+package wsm
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// WorkspaceUsage summarizes a workspace for clean-up decisions
+type WorkspaceUsage struct {
+	Workspace Workspace     `json:"workspace"`
+	Age       time.Duration `json:"age"`
+	// DirtyRepositories have uncommitted changes or untracked files
+	DirtyRepositories []string `json:"dirty_repositories,omitempty"`
+	DiskUsage         int64    `json:"disk_usage"`
+	// Missing is set when the workspace directory no longer exists
+	Missing bool `json:"missing,omitempty"`
+}
+
+// IsDirty reports whether any repository has uncommitted changes
+func (u WorkspaceUsage) IsDirty() bool {
+	return len(u.DirtyRepositories) > 0
+}
+
+// GetWorkspaceUsages computes the age, dirty state and disk usage of the workspaces concurrently
+func GetWorkspaceUsages(ctx context.Context, workspaces []Workspace) []WorkspaceUsage {
+	usages := make([]WorkspaceUsage, len(workspaces))
+	var wg sync.WaitGroup
+	for i := range workspaces {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			usages[i] = getWorkspaceUsage(ctx, workspaces[i])
+		}(i)
+	}
+	wg.Wait()
+	return usages
+}
+
+func getWorkspaceUsage(ctx context.Context, workspace Workspace) WorkspaceUsage {
+	usage := WorkspaceUsage{
+		Workspace: workspace,
+		Age:       time.Since(workspace.Created),
+	}
+
+	if _, err := os.Stat(workspace.Path); os.IsNotExist(err) {
+		usage.Missing = true
+		return usage
+	}
+
+	for _, repo := range workspace.Repositories {
+		status, err := runGitOutput(ctx, filepath.Join(workspace.Path, repo.Name), "status", "--porcelain")
+		if err == nil && status != "" {
+			usage.DirtyRepositories = append(usage.DirtyRepositories, repo.Name)
+		}
+	}
+
+	usage.DiskUsage = DirectorySize(workspace.Path)
+	return usage
+}
+
+// DirectorySize returns the total size of the regular files below path. Unreadable entries are
+// skipped, and symlinks are not followed.
+func DirectorySize(path string) int64 {
+	var size int64
+	_ = filepath.WalkDir(path, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if entry.Type().IsRegular() {
+			if info, err := entry.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
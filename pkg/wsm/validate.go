@@ -0,0 +1,423 @@
+package wsm
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Severities of validation issues
+const (
+	ValidationError   = "error"
+	ValidationWarning = "warning"
+)
+
+// ValidationIssue is a problem found in registry.json or a workspace file
+type ValidationIssue struct {
+	File string `json:"file"`
+	// Field is the JSON path of the offending value, e.g. repositories[2].path
+	Field    string `json:"field,omitempty"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// ValidationReport lists the files checked by 'wsm validate' and the issues found
+type ValidationReport struct {
+	Files  []string          `json:"files"`
+	Issues []ValidationIssue `json:"issues"`
+}
+
+// Count returns the number of issues of a severity
+func (r *ValidationReport) Count(severity string) int {
+	count := 0
+	for _, issue := range r.Issues {
+		if issue.Severity == severity {
+			count++
+		}
+	}
+	return count
+}
+
+// ValidateOptions configures 'wsm validate'
+type ValidateOptions struct {
+	// SkipPaths does not check that repository and workspace paths exist, for files validated on
+	// another machine, e.g. in the CI of a dotfiles repository
+	SkipPaths bool
+}
+
+// validator collects the issues of the files it checks
+type validator struct {
+	options ValidateOptions
+	report  *ValidationReport
+	file    string
+}
+
+func (v *validator) add(severity, field, format string, args ...any) {
+	v.report.Issues = append(v.report.Issues, ValidationIssue{
+		File:     v.file,
+		Field:    field,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// ValidateConfigFiles checks registry.json and workspace files without running git or changing
+// anything. Paths are files or configuration directories; a directory contributes its
+// registry.json and the files of its workspaces directory. Without paths, the configuration
+// directory of the current profile is checked. Files named registry.json are registries, other
+// files workspaces.
+func ValidateConfigFiles(paths []string, options ValidateOptions) (*ValidationReport, error) {
+	if len(paths) == 0 {
+		configDir, err := ConfigDir()
+		if err != nil {
+			return nil, err
+		}
+		paths = []string{configDir}
+	}
+
+	var registries, workspaceFiles []string
+	// Parents and children are only checked against whole configuration directories
+	checkReferences := false
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot validate %s", path)
+		}
+		if !info.IsDir() {
+			if filepath.Base(path) == "registry.json" {
+				registries = append(registries, path)
+			} else {
+				workspaceFiles = append(workspaceFiles, path)
+			}
+			continue
+		}
+		checkReferences = true
+		if registry := filepath.Join(path, "registry.json"); fileExists(registry) {
+			registries = append(registries, registry)
+		}
+		matches, err := filepath.Glob(filepath.Join(path, "workspaces", "*.json"))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list the workspaces of %s", path)
+		}
+		workspaceFiles = append(workspaceFiles, matches...)
+	}
+
+	v := &validator{options: options, report: &ValidationReport{Files: []string{}, Issues: []ValidationIssue{}}}
+	var registered map[string]bool
+	for _, path := range registries {
+		v.file = path
+		v.report.Files = append(v.report.Files, path)
+		if registry := v.validateRegistry(path); registry != nil {
+			if registered == nil {
+				registered = map[string]bool{}
+			}
+			for _, repo := range registry.Repositories {
+				registered[repo.Name] = true
+			}
+		}
+	}
+
+	workspaces := map[string]*Workspace{}
+	names, locations := map[string]string{}, map[string]string{}
+	for _, path := range workspaceFiles {
+		v.file = path
+		v.report.Files = append(v.report.Files, path)
+		workspace := v.validateWorkspace(path, registered)
+		if workspace == nil {
+			continue
+		}
+		if other, ok := names[workspace.Name]; ok && workspace.Name != "" {
+			v.add(ValidationError, "name", "workspace '%s' is also defined in %s", workspace.Name, other)
+		} else {
+			names[workspace.Name] = path
+			workspaces[workspace.Name] = workspace
+		}
+		if other, ok := locations[workspace.Path]; ok && workspace.Path != "" {
+			v.add(ValidationError, "path", "%s is also the path of the workspace in %s", workspace.Path, other)
+		} else {
+			locations[workspace.Path] = path
+		}
+	}
+
+	if !checkReferences {
+		return v.report, nil
+	}
+	for _, name := range slices.Sorted(maps.Keys(workspaces)) {
+		workspace := workspaces[name]
+		v.file = names[name]
+		if workspace.Parent != "" && workspaces[workspace.Parent] == nil {
+			v.add(ValidationWarning, "parent", "parent workspace '%s' does not exist", workspace.Parent)
+		}
+		for i, child := range workspace.Children {
+			if workspaces[child] == nil {
+				v.add(ValidationWarning, fmt.Sprintf("children[%d]", i), "child workspace '%s' does not exist", child)
+			}
+		}
+	}
+	return v.report, nil
+}
+
+// decode parses a file into target and reports syntax errors, type errors and unknown fields. It
+// returns false when the file cannot be used at all.
+func (v *validator) decode(path string, target any) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		v.add(ValidationError, "", "cannot read the file: %v", err)
+		return false
+	}
+	var raw any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		v.add(ValidationError, "", "invalid JSON: %v", err)
+		return false
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		v.add(ValidationError, "", "does not match the schema: %v", err)
+		return false
+	}
+	for _, field := range unknownFields(raw, reflect.TypeOf(target), "") {
+		v.add(ValidationError, field, "unknown field")
+	}
+	return true
+}
+
+func (v *validator) validateRegistry(path string) *RepositoryRegistry {
+	var registry RepositoryRegistry
+	if !v.decode(path, &registry) {
+		return nil
+	}
+
+	names, paths := map[string]int{}, map[string]int{}
+	for i, repo := range registry.Repositories {
+		field := fmt.Sprintf("repositories[%d]", i)
+		v.validateRepository(field, repo)
+		if first, ok := names[repo.Name]; ok && repo.Name != "" {
+			v.add(ValidationError, field+".name", "duplicate repository name '%s', first defined in repositories[%d]", repo.Name, first)
+		} else {
+			names[repo.Name] = i
+		}
+		if first, ok := paths[repo.Path]; ok && repo.Path != "" {
+			v.add(ValidationError, field+".path", "%s is also registered as repositories[%d]", repo.Path, first)
+		} else {
+			paths[repo.Path] = i
+		}
+	}
+
+	for _, alias := range slices.Sorted(maps.Keys(registry.Aliases)) {
+		field := "aliases." + alias
+		target := registry.Aliases[alias]
+		if _, ok := names[target]; !ok {
+			v.add(ValidationError, field, "alias of unknown repository '%s'", target)
+		}
+		if _, ok := names[alias]; ok {
+			v.add(ValidationWarning, field, "alias shadows the repository of the same name")
+		}
+	}
+	return &registry
+}
+
+// validateRepository checks a repository entry of the registry or of a workspace
+func (v *validator) validateRepository(field string, repo Repository) {
+	if repo.Name == "" {
+		v.add(ValidationError, field+".name", "repository without name")
+	}
+	v.validatePath(field+".path", repo.Path)
+	if repo.CurrentBranch != "" {
+		if err := checkBranchName(repo.CurrentBranch); err != nil {
+			v.add(ValidationError, field+".current_branch", "invalid branch '%s': %v", repo.CurrentBranch, err)
+		}
+	}
+}
+
+func (v *validator) validatePath(field, path string) {
+	switch {
+	case path == "":
+		v.add(ValidationError, field, "missing path")
+	case !filepath.IsAbs(path):
+		v.add(ValidationError, field, "%s is not an absolute path", path)
+	case !v.options.SkipPaths:
+		if _, err := os.Stat(path); err != nil {
+			v.add(ValidationError, field, "%s does not exist", path)
+		}
+	}
+}
+
+func (v *validator) validateWorkspace(path string, registered map[string]bool) *Workspace {
+	var workspace Workspace
+	if !v.decode(path, &workspace) {
+		return nil
+	}
+
+	if workspace.Name == "" {
+		v.add(ValidationError, "name", "workspace without name")
+	} else if name := strings.TrimSuffix(filepath.Base(path), ".json"); name != workspace.Name {
+		v.add(ValidationError, "name", "workspace '%s' is stored as %s.json and cannot be loaded by name", workspace.Name, name)
+	}
+	v.validatePath("path", workspace.Path)
+	if workspace.Branch != "" {
+		if err := checkBranchName(workspace.Branch); err != nil {
+			v.add(ValidationError, "branch", "invalid branch '%s': %v", workspace.Branch, err)
+		}
+	}
+	if workspace.BaseBranch != "" {
+		if err := checkBranchName(workspace.BaseBranch); err != nil {
+			v.add(ValidationError, "base_branch", "invalid branch '%s': %v", workspace.BaseBranch, err)
+		}
+	}
+	if workspace.Clone != "" && !slices.Contains(CloneModes, workspace.Clone) {
+		v.add(ValidationError, "clone", "unknown clone mode '%s', expected one of %s", workspace.Clone, strings.Join(CloneModes, ", "))
+	}
+
+	names := map[string]int{}
+	for i, repo := range workspace.Repositories {
+		field := fmt.Sprintf("repositories[%d]", i)
+		v.validateRepository(field, repo)
+		if repo.Name == "" {
+			continue
+		}
+		if first, ok := names[repo.Name]; ok {
+			v.add(ValidationError, field+".name", "duplicate repository name '%s', first defined in repositories[%d]", repo.Name, first)
+			continue
+		}
+		names[repo.Name] = i
+		if registered != nil && !registered[repo.Name] {
+			v.add(ValidationWarning, field+".name", "repository '%s' is not in the registry", repo.Name)
+		}
+		if !v.options.SkipPaths && filepath.IsAbs(workspace.Path) && fileExists(workspace.Path) && !fileExists(filepath.Join(workspace.Path, repo.Name)) {
+			v.add(ValidationWarning, field+".name", "%s has no '%s' directory; run 'wsm reconcile %s'", workspace.Path, repo.Name, workspace.Name)
+		}
+	}
+
+	for i, name := range workspace.Frozen {
+		if _, ok := names[name]; !ok {
+			v.add(ValidationWarning, fmt.Sprintf("frozen[%d]", i), "frozen repository '%s' is not part of the workspace", name)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(workspace.BranchPoints)) {
+		if _, ok := names[name]; !ok {
+			v.add(ValidationWarning, "branch_points."+name, "repository '%s' is not part of the workspace", name)
+		}
+	}
+	return &workspace
+}
+
+var jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+
+// unknownFields returns the JSON paths of the object keys in value that typ does not declare.
+// Like encoding/json, keys match field names case-insensitively.
+func unknownFields(value any, typ reflect.Type, path string) []string {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if reflect.PointerTo(typ).Implements(jsonUnmarshalerType) {
+		return nil
+	}
+
+	var unknown []string
+	switch typ.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		fields := map[string]reflect.Type{}
+		collectJSONFields(typ, fields)
+		for _, key := range slices.Sorted(maps.Keys(object)) {
+			field := key
+			if path != "" {
+				field = path + "." + key
+			}
+			fieldType, ok := fields[strings.ToLower(key)]
+			if !ok {
+				unknown = append(unknown, field)
+				continue
+			}
+			unknown = append(unknown, unknownFields(object[key], fieldType, field)...)
+		}
+	case reflect.Slice, reflect.Array:
+		items, ok := value.([]any)
+		if !ok {
+			return nil
+		}
+		for i, item := range items {
+			unknown = append(unknown, unknownFields(item, typ.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	case reflect.Map:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		for _, key := range slices.Sorted(maps.Keys(object)) {
+			unknown = append(unknown, unknownFields(object[key], typ.Elem(), path+"."+key)...)
+		}
+	}
+	return unknown
+}
+
+// collectJSONFields maps the lower-cased JSON names of the fields of a struct, including those
+// promoted from embedded structs, to their types
+func collectJSONFields(typ reflect.Type, fields map[string]reflect.Type) {
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				collectJSONFields(embedded, fields)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[strings.ToLower(name)] = field.Type
+	}
+}
+
+// checkBranchName applies the rules of 'git check-ref-format --branch' without running git, so
+// files can be validated where git is not installed
+func checkBranchName(branch string) error {
+	switch {
+	case branch == "@" || branch == "HEAD":
+		return errors.Errorf("'%s' is reserved", branch)
+	case strings.HasPrefix(branch, "-"):
+		return errors.New("starts with '-'")
+	case strings.HasPrefix(branch, "/") || strings.HasSuffix(branch, "/") || strings.Contains(branch, "//"):
+		return errors.New("has an empty path component")
+	case strings.HasSuffix(branch, "."):
+		return errors.New("ends with '.'")
+	case strings.Contains(branch, ".."):
+		return errors.New("contains '..'")
+	case strings.Contains(branch, "@{"):
+		return errors.New("contains '@{'")
+	}
+	for _, r := range branch {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(" ~^:?*[\\", r) {
+			return errors.Errorf("contains %q", r)
+		}
+	}
+	for _, component := range strings.Split(branch, "/") {
+		if strings.HasPrefix(component, ".") {
+			return errors.Errorf("component '%s' starts with '.'", component)
+		}
+		if strings.HasSuffix(component, ".lock") {
+			return errors.Errorf("component '%s' ends with '.lock'", component)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package wsm
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+type validateInner struct {
+	Value string `json:"value"`
+}
+
+type validateEmbedded struct {
+	Shared string `json:"shared"`
+}
+
+type validatePointerEmbedded struct {
+	Pointed string `json:"pointed"`
+}
+
+type validateSample struct {
+	validateEmbedded
+	*validatePointerEmbedded
+	Named    validateEmbedded         `json:"named"`
+	Items    []validateInner          `json:"items"`
+	ByName   map[string]validateInner `json:"by_name"`
+	Plain    map[string]string        `json:"plain"`
+	When     time.Time                `json:"when"`
+	Untagged string
+	Skipped  string `json:"-"`
+	hidden   string
+}
+
+func TestUnknownFields(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want []string
+	}{
+		{name: "known fields", json: `{"named": {"shared": "x"}, "items": [{"value": "a"}], "untagged": "y", "when": "2026-10-16T00:00:00Z"}`},
+		{name: "top level", json: `{"extra": 1, "named": {}}`, want: []string{"extra"}},
+		{name: "case-insensitive keys", json: `{"NAMED": {"Shared": "x"}, "Items": []}`},
+		{name: "promoted from embedded structs", json: `{"shared": "x", "pointed": "y"}`},
+		{name: "embedded struct name", json: `{"validateEmbedded": {}}`, want: []string{"validateEmbedded"}},
+		{name: "nested struct", json: `{"named": {"shared": "x", "typo": 1}}`, want: []string{"named.typo"}},
+		{name: "slice items", json: `{"items": [{"value": "a"}, {"valu": "b"}]}`, want: []string{"items[1].valu"}},
+		{name: "map values", json: `{"by_name": {"a": {"value": "x"}, "b": {"other": 1}}}`, want: []string{"by_name.b.other"}},
+		{name: "map keys are free", json: `{"plain": {"anything": "x"}}`},
+		{name: "skipped and unexported fields", json: `{"Skipped": "x", "hidden": "y"}`, want: []string{"Skipped", "hidden"}},
+		{name: "json unmarshalers are opaque", json: `{"when": {"anything": 1}}`},
+		{name: "type mismatch is left to the decoder", json: `{"items": {"value": "a"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var raw any
+			if err := json.Unmarshal([]byte(tt.json), &raw); err != nil {
+				t.Fatal(err)
+			}
+			got := unknownFields(raw, reflect.TypeFor[*validateSample](), "")
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("unknownFields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckBranchName(t *testing.T) {
+	tests := []struct {
+		branch  string
+		wantErr string
+	}{
+		{branch: "main"},
+		{branch: "feature/x"},
+		{branch: "release/1.2.3"},
+		{branch: "fix/a.b-c_d"},
+		{branch: "user@host"},
+		{branch: "@", wantErr: "reserved"},
+		{branch: "HEAD", wantErr: "reserved"},
+		{branch: "-feature", wantErr: "starts with '-'"},
+		{branch: "/feature", wantErr: "empty path component"},
+		{branch: "feature/", wantErr: "empty path component"},
+		{branch: "feature//x", wantErr: "empty path component"},
+		{branch: "feature.", wantErr: "ends with '.'"},
+		{branch: "feature..x", wantErr: "contains '..'"},
+		{branch: "feature@{1}", wantErr: "contains '@{'"},
+		{branch: "feature x", wantErr: "contains ' '"},
+		{branch: "feature~1", wantErr: "contains '~'"},
+		{branch: "feature^", wantErr: "contains '^'"},
+		{branch: "a:b", wantErr: "contains ':'"},
+		{branch: "a?b", wantErr: "contains '?'"},
+		{branch: "a*b", wantErr: "contains '*'"},
+		{branch: "a[b", wantErr: "contains '['"},
+		{branch: `a\b`, wantErr: `contains '\\'`},
+		{branch: "a\tb", wantErr: `contains '\t'`},
+		{branch: "feature/.hidden", wantErr: "starts with '.'"},
+		{branch: "feature.lock", wantErr: "ends with '.lock'"},
+		{branch: "feature.lock/x", wantErr: "ends with '.lock'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.branch, func(t *testing.T) {
+			err := checkBranchName(tt.branch)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkBranchName(%q) = %v, want no error", tt.branch, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkBranchName(%q) = %v, want an error containing %q", tt.branch, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateConfigFilesFindsDuplicates(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  []string
+	}{
+		{
+			name: "repository names and paths",
+			files: map[string]string{"registry.json": `{"repositories": [
+				{"name": "lib", "path": "/src/lib"},
+				{"name": "lib", "path": "/src/other"},
+				{"name": "app", "path": "/src/lib"}
+			]}`},
+			want: []string{
+				"registry.json repositories[1].name: duplicate repository name 'lib', first defined in repositories[0]",
+				"registry.json repositories[2].path: /src/lib is also registered as repositories[0]",
+			},
+		},
+		{
+			name: "repositories of a workspace",
+			files: map[string]string{"workspaces/feat.json": `{"name": "feat", "path": "/ws/feat", "repositories": [
+				{"name": "lib", "path": "/src/lib"},
+				{"name": "lib", "path": "/src/lib"}
+			]}`},
+			want: []string{"feat.json repositories[1].name: duplicate repository name 'lib', first defined in repositories[0]"},
+		},
+		{
+			name: "workspace names and paths",
+			files: map[string]string{
+				"workspaces/a.json": `{"name": "a", "path": "/ws/shared"}`,
+				"workspaces/b.json": `{"name": "a", "path": "/ws/b"}`,
+				"workspaces/c.json": `{"name": "c", "path": "/ws/shared"}`,
+			},
+			want: []string{
+				"b.json name: workspace 'a' is stored as b.json and cannot be loaded by name",
+				"b.json name: workspace 'a' is also defined in a.json",
+				"c.json path: /ws/shared is also the path of the workspace in a.json",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeGoFiles(t, dir, tt.files)
+			report, err := ValidateConfigFiles([]string{dir}, ValidateOptions{SkipPaths: true})
+			if err != nil {
+				t.Fatalf("ValidateConfigFiles failed: %v", err)
+			}
+			var got []string
+			for _, issue := range report.Issues {
+				message := strings.ReplaceAll(issue.Message, dir+string(filepath.Separator)+"workspaces"+string(filepath.Separator), "")
+				got = append(got, fmt.Sprintf("%s %s: %s", filepath.Base(issue.File), issue.Field, message))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("issues:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
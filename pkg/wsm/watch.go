@@ -0,0 +1,253 @@
+package wsm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+)
+
+// DefaultWatchDebounce is how long a repository has to be quiet before its command runs
+const DefaultWatchDebounce = 300 * time.Millisecond
+
+// defaultWatchIgnore are directories never watched; they hold dependencies or build output
+var defaultWatchIgnore = []string{".git", "node_modules", "vendor", "dist", "build", "target", "__pycache__"}
+
+// WatchConfig configures 'wsm watch' in config.yaml
+type WatchConfig struct {
+	// Run is the command run in a repository whose files changed
+	Run string `json:"run,omitempty" yaml:"run,omitempty"`
+	// Commands overrides Run per repository name
+	Commands map[string]string `json:"commands,omitempty" yaml:"commands,omitempty"`
+	// Debounce is the quiet period before running, e.g. 500ms
+	Debounce string `json:"debounce,omitempty" yaml:"debounce,omitempty"`
+	// Ignore lists additional directory names that are not watched
+	Ignore []string `json:"ignore,omitempty" yaml:"ignore,omitempty"`
+}
+
+// CommandFor returns the command to run for a repository: the explicit command, the repository
+// command from the configuration, or the configured default
+func (c WatchConfig) CommandFor(repoName, explicit string) string {
+	if explicit != "" {
+		return explicit
+	}
+	if command, ok := c.Commands[repoName]; ok {
+		return command
+	}
+	return c.Run
+}
+
+// DebounceDuration parses Debounce, falling back to DefaultWatchDebounce
+func (c WatchConfig) DebounceDuration() (time.Duration, error) {
+	if c.Debounce == "" {
+		return DefaultWatchDebounce, nil
+	}
+	d, err := time.ParseDuration(c.Debounce)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid watch.debounce '%s'", c.Debounce)
+	}
+	return d, nil
+}
+
+// WatchOptions configures WatchWorkspace
+type WatchOptions struct {
+	Repositories []Repository
+	Debounce     time.Duration
+	// Ignore lists directory names skipped in addition to the defaults
+	Ignore []string
+}
+
+// WatchChange is a batch of changed files in one repository
+type WatchChange struct {
+	Repository Repository
+	// Files are relative to the repository worktree
+	Files []string
+}
+
+// WatchWorkspace watches the worktrees of the given repositories until ctx is done and calls
+// changed with the files that changed in a repository once it has been quiet for the debounce
+// period. Files ignored by git are dropped. Calls never overlap: changes made while changed runs
+// are collected and delivered when it returns.
+func WatchWorkspace(ctx context.Context, workspace *Workspace, opts WatchOptions, changed func(WatchChange)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errors.Wrap(err, "failed to create file watcher")
+	}
+	defer watcher.Close()
+
+	ignore := append(slices.Clone(defaultWatchIgnore), opts.Ignore...)
+	roots := map[string]Repository{}
+	for _, repo := range opts.Repositories {
+		root := filepath.Join(workspace.Path, repo.Name)
+		roots[root] = repo
+		if err := watchTree(watcher, root, ignore); err != nil {
+			return err
+		}
+	}
+
+	debounce := opts.Debounce
+	if debounce <= 0 {
+		debounce = DefaultWatchDebounce
+	}
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+
+	pending := map[string]map[string]bool{}
+	running := false
+	done := make(chan struct{})
+
+	for {
+		select {
+		case <-ctx.Done():
+			if running {
+				<-done
+			}
+			return nil
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			return errors.Wrap(err, "file watcher failed")
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			root, rel := watchedRepository(roots, event.Name)
+			if root == "" || ignoredPath(rel, ignore) || editorTempFile(filepath.Base(rel)) {
+				continue
+			}
+			// New directories have to be watched themselves
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					_ = watchTree(watcher, event.Name, ignore)
+					continue
+				}
+			}
+			if pending[root] == nil {
+				pending[root] = map[string]bool{}
+			}
+			pending[root][rel] = true
+			timer.Reset(debounce)
+
+		case <-timer.C:
+			if running || len(pending) == 0 {
+				continue
+			}
+			batch := pending
+			pending = map[string]map[string]bool{}
+			running = true
+			go func() {
+				defer func() { done <- struct{}{} }()
+				deliverWatchChanges(ctx, roots, batch, changed)
+			}()
+
+		case <-done:
+			running = false
+			if len(pending) > 0 {
+				timer.Reset(debounce)
+			}
+		}
+	}
+}
+
+func deliverWatchChanges(ctx context.Context, roots map[string]Repository, batch map[string]map[string]bool, changed func(WatchChange)) {
+	paths := make([]string, 0, len(batch))
+	for root := range batch {
+		paths = append(paths, root)
+	}
+	sort.Strings(paths)
+
+	for _, root := range paths {
+		if ctx.Err() != nil {
+			return
+		}
+		files := make([]string, 0, len(batch[root]))
+		for file := range batch[root] {
+			files = append(files, file)
+		}
+		sort.Strings(files)
+
+		files = withoutGitIgnored(ctx, root, files)
+		if len(files) == 0 {
+			continue
+		}
+		changed(WatchChange{Repository: roots[root], Files: files})
+	}
+}
+
+// withoutGitIgnored drops the files git ignores, such as build output written by the command itself
+func withoutGitIgnored(ctx context.Context, root string, files []string) []string {
+	args := append([]string{"check-ignore", "--"}, files...)
+	// check-ignore exits with 1 when no file is ignored
+	out, _ := runGitOutput(ctx, root, args...)
+	if out == "" {
+		return files
+	}
+	ignored := map[string]bool{}
+	for _, line := range strings.Split(out, "\n") {
+		ignored[strings.TrimSpace(line)] = true
+	}
+	var kept []string
+	for _, file := range files {
+		if !ignored[file] {
+			kept = append(kept, file)
+		}
+	}
+	return kept
+}
+
+// watchTree adds root and its subdirectories to the watcher, skipping ignored directories
+func watchTree(watcher *fsnotify.Watcher, root string, ignore []string) error {
+	return filepath.WalkDir(root, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			// Directories can disappear while walking
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		if path != root && slices.Contains(ignore, entry.Name()) {
+			return filepath.SkipDir
+		}
+		if err := watcher.Add(path); err != nil {
+			return errors.Wrapf(err, "failed to watch %s", path)
+		}
+		return nil
+	})
+}
+
+// watchedRepository returns the worktree root containing path and path relative to it
+func watchedRepository(roots map[string]Repository, path string) (string, string) {
+	for root := range roots {
+		if rel, err := filepath.Rel(root, path); err == nil && rel != "." && filepath.IsLocal(rel) {
+			return root, rel
+		}
+	}
+	return "", ""
+}
+
+func ignoredPath(rel string, ignore []string) bool {
+	for _, part := range strings.Split(filepath.ToSlash(rel), "/") {
+		if slices.Contains(ignore, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// editorTempFile reports swap and backup files editors write next to the file being saved
+func editorTempFile(name string) bool {
+	return strings.HasSuffix(name, "~") || strings.HasSuffix(name, ".swp") || strings.HasSuffix(name, ".swx") ||
+		strings.HasPrefix(name, ".#") || name == "4913"
+}
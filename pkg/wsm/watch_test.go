@@ -0,0 +1,73 @@
+package wsm
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchConfig(t *testing.T) {
+	config := WatchConfig{Run: "make", Commands: map[string]string{"web": "pnpm test", "docs": ""}}
+	tests := []struct{ repo, explicit, want string }{
+		{"api", "", "make"},
+		{"web", "", "pnpm test"},
+		{"docs", "", ""},
+		{"web", "go vet ./...", "go vet ./..."},
+	}
+	for _, tt := range tests {
+		if got := config.CommandFor(tt.repo, tt.explicit); got != tt.want {
+			t.Errorf("CommandFor(%s, %q) = %q, want %q", tt.repo, tt.explicit, got, tt.want)
+		}
+	}
+
+	if d, err := config.DebounceDuration(); err != nil || d != DefaultWatchDebounce {
+		t.Errorf("default debounce = %s (%v)", d, err)
+	}
+	config.Debounce = "1s"
+	if d, err := config.DebounceDuration(); err != nil || d != time.Second {
+		t.Errorf("debounce = %s (%v), want 1s", d, err)
+	}
+	config.Debounce = "soon"
+	if _, err := config.DebounceDuration(); err == nil {
+		t.Error("expected an error for an invalid debounce")
+	}
+}
+
+func TestWatchedPaths(t *testing.T) {
+	roots := map[string]Repository{"/ws/feat/api": {Name: "api"}, "/ws/feat/api-docs": {Name: "api-docs"}}
+	tests := []struct{ path, root, rel string }{
+		{"/ws/feat/api/cmd/main.go", "/ws/feat/api", filepath.FromSlash("cmd/main.go")},
+		{"/ws/feat/api-docs/index.md", "/ws/feat/api-docs", "index.md"},
+		{"/ws/feat/api", "", ""},
+		{"/ws/feat/go.work", "", ""},
+	}
+	for _, tt := range tests {
+		if root, rel := watchedRepository(roots, tt.path); root != tt.root || rel != tt.rel {
+			t.Errorf("watchedRepository(%s) = %q, %q, want %q, %q", tt.path, root, rel, tt.root, tt.rel)
+		}
+	}
+
+	for rel, want := range map[string]bool{
+		"node_modules/react/index.js": true,
+		"web/dist/app.js":             true,
+		"distribution/notes.md":       false,
+		"main.go":                     false,
+	} {
+		if got := ignoredPath(filepath.FromSlash(rel), defaultWatchIgnore); got != want {
+			t.Errorf("ignoredPath(%s) = %v, want %v", rel, got, want)
+		}
+	}
+
+	for name, want := range map[string]bool{
+		"main.go~":     true,
+		".main.go.swp": true,
+		".#main.go":    true,
+		"4913":         true,
+		"main.go":      false,
+		"swp.go":       false,
+	} {
+		if got := editorTempFile(name); got != want {
+			t.Errorf("editorTempFile(%s) = %v, want %v", name, got, want)
+		}
+	}
+}
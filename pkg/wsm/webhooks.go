@@ -0,0 +1,263 @@
+package wsm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/user"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/pkg/errors"
+)
+
+// Payload formats of webhooks
+const (
+	WebhookSlack = "slack"
+	WebhookJSON  = "json"
+)
+
+// WebhookFormats are the valid values of webhooks[].format
+var WebhookFormats = []string{WebhookSlack, WebhookJSON}
+
+// webhookTimeout bounds the delivery of an event to a single webhook
+const webhookTimeout = 5 * time.Second
+
+// defaultSlackTemplate is the message of Slack webhooks without a template
+const defaultSlackTemplate = "[{{.User}}@{{.Host}}] {{.Message}}"
+
+// WebhookConfig is a URL events are posted to, e.g. a Slack incoming webhook, so that teams
+// sharing dev servers see what happens on them
+type WebhookConfig struct {
+	// Name identifies the webhook in messages and in 'wsm webhook test'
+	Name string `json:"name" yaml:"name"`
+	// URL receives the events; ${VAR} is expanded from the environment, so secrets stay out of
+	// config.yaml
+	URL string `json:"url" yaml:"url"`
+	// Format is slack (default), which posts {"text": <template>}, or json, which posts the
+	// template as is, or the event as JSON without a template
+	Format string `json:"format,omitempty" yaml:"format,omitempty"`
+	// Events are the event types sent to the webhook, see 'wsm events --help'; empty sends
+	// workspace.created, pr.opened and sync.failed
+	Events []string `json:"events,omitempty" yaml:"events,omitempty"`
+	// Template is a Go template of the payload over the fields of the event plus .User and .Host
+	Template string `json:"template,omitempty" yaml:"template,omitempty"`
+	// Headers are added to the requests; ${VAR} is expanded from the environment
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+}
+
+// DefaultWebhookEvents are the events sent to webhooks that do not list theirs
+var DefaultWebhookEvents = []string{EventWorkspaceCreated, EventPROpened, EventSyncFailed}
+
+// Subscribes reports whether the webhook receives events of the given type
+func (c WebhookConfig) Subscribes(eventType string) bool {
+	if len(c.Events) == 0 {
+		return slices.Contains(DefaultWebhookEvents, eventType)
+	}
+	return slices.Contains(c.Events, eventType)
+}
+
+func (c WebhookConfig) format() string {
+	if c.Format == "" {
+		return WebhookSlack
+	}
+	return c.Format
+}
+
+// Validate checks the URL, format, events and template of the webhook
+func (c WebhookConfig) Validate() error {
+	if c.Name == "" {
+		return errors.New("webhooks: every webhook needs a name")
+	}
+	key := fmt.Sprintf("webhooks.%s", c.Name)
+	if c.URL == "" {
+		return errors.Errorf("%s: url is not set", key)
+	}
+	if !slices.Contains(WebhookFormats, c.format()) {
+		return errors.Errorf("invalid %s.format '%s': expected %s", key, c.Format, strings.Join(WebhookFormats, " or "))
+	}
+	for _, event := range c.Events {
+		if !slices.Contains(EventTypes, event) {
+			return errors.Errorf("invalid %s.events '%s': expected one of %s", key, event, strings.Join(EventTypes, ", "))
+		}
+	}
+	if _, err := c.template(); err != nil {
+		return errors.Wrapf(err, "invalid %s.template", key)
+	}
+	return nil
+}
+
+// ValidateWebhooks checks the webhooks of config.yaml and that their names are unique
+func ValidateWebhooks(webhooks []WebhookConfig) error {
+	names := map[string]bool{}
+	for _, webhook := range webhooks {
+		if err := webhook.Validate(); err != nil {
+			return err
+		}
+		if names[webhook.Name] {
+			return errors.Errorf("webhooks: duplicate name '%s'", webhook.Name)
+		}
+		names[webhook.Name] = true
+	}
+	return nil
+}
+
+func (c WebhookConfig) template() (*template.Template, error) {
+	text := c.Template
+	if text == "" {
+		if c.format() != WebhookSlack {
+			return nil, nil
+		}
+		text = defaultSlackTemplate
+	}
+	return template.New(c.Name).Option("missingkey=zero").Parse(text)
+}
+
+// webhookEvent is what webhook templates and JSON payloads see: the event and where it happened
+type webhookEvent struct {
+	Event
+	User string `json:"user"`
+	Host string `json:"host"`
+}
+
+// Payload renders the body posted to the webhook for event
+func (c WebhookConfig) Payload(event Event) ([]byte, error) {
+	data := webhookEvent{Event: event, Host: hostname()}
+	if current, err := user.Current(); err == nil {
+		data.User = current.Username
+	}
+
+	tmpl, err := c.template()
+	if err != nil {
+		return nil, err
+	}
+	if tmpl == nil {
+		return json.Marshal(data)
+	}
+	if c.format() == WebhookJSON {
+		data = data.jsonEscaped()
+	}
+	var text bytes.Buffer
+	if err := tmpl.Execute(&text, data); err != nil {
+		return nil, errors.Wrap(err, "failed to render template")
+	}
+	if c.format() == WebhookSlack {
+		return json.Marshal(map[string]string{"text": text.String()})
+	}
+	if !json.Valid(text.Bytes()) {
+		return nil, errors.New("the template does not render valid JSON")
+	}
+	return text.Bytes(), nil
+}
+
+// jsonEscaped returns the event with its text fields escaped for JSON strings, so that json
+// templates can place them between quotes whatever they contain
+func (e webhookEvent) jsonEscaped() webhookEvent {
+	for _, field := range []*string{&e.Type, &e.Workspace, &e.Repository, &e.Branch, &e.URL, &e.Message, &e.User, &e.Host} {
+		quoted, _ := json.Marshal(*field)
+		*field = string(quoted[1 : len(quoted)-1])
+	}
+	return e
+}
+
+// Send posts event to the webhook
+func (c WebhookConfig) Send(ctx context.Context, client *http.Client, event Event) error {
+	payload, err := c.Payload(event)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, os.ExpandEnv(c.URL), bytes.NewReader(payload))
+	if err != nil {
+		return errors.Wrap(err, "failed to create request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "workspace-manager")
+	for name, value := range c.Headers {
+		req.Header.Set(name, os.ExpandEnv(value))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// The URL may hold a secret, which the error of the client repeats
+		return errors.Errorf("failed to reach the webhook: %v", redactURL(err, os.ExpandEnv(c.URL)))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return errors.Errorf("webhook returned %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// NotifyWebhooks sends event to the webhooks subscribed to its type, concurrently, and returns
+// the failures. It waits for the deliveries, each bounded by a few seconds, so that short-lived
+// commands do not exit before their events are out.
+func NotifyWebhooks(ctx context.Context, webhooks []WebhookConfig, event Event) []error {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	client := &http.Client{}
+
+	var mu sync.Mutex
+	var failures []error
+	var wg sync.WaitGroup
+	for _, webhook := range webhooks {
+		if !webhook.Subscribes(event.Type) {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := webhook.Send(ctx, client, event); err != nil {
+				mu.Lock()
+				failures = append(failures, errors.Wrapf(err, "webhook %s", webhook.Name))
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return failures
+}
+
+// Notify sends an event to the webhooks of config.yaml. Like the operation journal, notifications
+// are best effort: failures are reported as warnings and never fail the operation itself.
+func (wm *WorkspaceManager) Notify(ctx context.Context, event Event) {
+	if wm.config == nil {
+		return
+	}
+	for _, failure := range NotifyWebhooks(ctx, wm.Config().Webhooks, event) {
+		output.LogWarn(
+			fmt.Sprintf("Failed to notify %v", failure),
+			"Failed to notify webhook",
+			"event", event.Type,
+			"error", failure,
+		)
+	}
+}
+
+func hostname() string {
+	host, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+	return host
+}
+
+func redactURL(err error, url string) string {
+	message := err.Error()
+	if url == "" {
+		return message
+	}
+	return strings.ReplaceAll(message, url, "<webhook url>")
+}
@@ -0,0 +1,172 @@
+package wsm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWebhookPayload(t *testing.T) {
+	event := Event{
+		Type:      EventWorkspaceCreated,
+		Time:      time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC),
+		Workspace: "feat",
+		Message:   `workspace "feat" was created\nwith a <note>`,
+	}
+	tests := []struct {
+		name    string
+		webhook WebhookConfig
+		check   func(t *testing.T, payload map[string]any)
+		wantErr string
+	}{
+		{
+			name:    "slack default template",
+			webhook: WebhookConfig{Name: "slack"},
+			check: func(t *testing.T, payload map[string]any) {
+				if text, _ := payload["text"].(string); !strings.HasSuffix(text, "] "+event.Message) {
+					t.Errorf("text = %q", text)
+				}
+			},
+		},
+		{
+			name:    "slack template",
+			webhook: WebhookConfig{Name: "slack", Template: "{{.Workspace}}: {{.Type}}"},
+			check: func(t *testing.T, payload map[string]any) {
+				if text := payload["text"]; text != "feat: workspace.created" {
+					t.Errorf("text = %q", text)
+				}
+			},
+		},
+		{
+			name:    "json event",
+			webhook: WebhookConfig{Name: "audit", Format: WebhookJSON},
+			check: func(t *testing.T, payload map[string]any) {
+				if payload["type"] != EventWorkspaceCreated || payload["workspace"] != "feat" || payload["message"] != event.Message {
+					t.Errorf("payload = %v", payload)
+				}
+				if _, ok := payload["host"]; !ok {
+					t.Errorf("payload has no host: %v", payload)
+				}
+			},
+		},
+		{
+			name:    "json template escapes the fields",
+			webhook: WebhookConfig{Name: "audit", Format: WebhookJSON, Template: `{"what": "{{.Message}}", "where": "{{.Workspace}}"}`},
+			check: func(t *testing.T, payload map[string]any) {
+				if payload["what"] != event.Message || payload["where"] != "feat" {
+					t.Errorf("payload = %v", payload)
+				}
+			},
+		},
+		{
+			name:    "json template rendering invalid JSON",
+			webhook: WebhookConfig{Name: "audit", Format: WebhookJSON, Template: `{"what": {{.Message}}}`},
+			wantErr: "valid JSON",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := tt.webhook.Payload(event)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Payload failed: %v", err)
+			}
+			var payload map[string]any
+			if err := json.Unmarshal(body, &payload); err != nil {
+				t.Fatalf("payload is not JSON: %v\n%s", err, body)
+			}
+			tt.check(t, payload)
+		})
+	}
+}
+
+func TestWebhookSend(t *testing.T) {
+	t.Setenv("WEBHOOK_TOKEN", "secret")
+	var mu sync.Mutex
+	var requests []*http.Request
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, r)
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+		if r.URL.Path == "/fail" {
+			http.Error(w, "nope", http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	webhook := WebhookConfig{
+		Name:    "audit",
+		URL:     server.URL + "/hook",
+		Format:  WebhookJSON,
+		Headers: map[string]string{"Authorization": "Bearer ${WEBHOOK_TOKEN}"},
+	}
+	event := Event{Type: EventPROpened, Workspace: "feat", Message: "opened"}
+	if err := webhook.Send(context.Background(), server.Client(), event); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if len(requests) != 1 {
+		t.Fatalf("expected one request, got %d", len(requests))
+	}
+	request := requests[0]
+	if request.Method != http.MethodPost || request.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("request = %s with Content-Type %q", request.Method, request.Header.Get("Content-Type"))
+	}
+	if got := request.Header.Get("Authorization"); got != "Bearer secret" {
+		t.Errorf("Authorization = %q, want the expanded token", got)
+	}
+	if !strings.Contains(bodies[0], `"type":"pr.opened"`) {
+		t.Errorf("body = %s", bodies[0])
+	}
+
+	failing := webhook
+	failing.URL = server.URL + "/fail"
+	if err := failing.Send(context.Background(), server.Client(), event); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("expected the status in the error, got %v", err)
+	}
+
+	// The URL may hold a secret and is never repeated in errors
+	unreachable := WebhookConfig{Name: "down", URL: "http://127.0.0.1:1/${WEBHOOK_TOKEN}"}
+	err := unreachable.Send(context.Background(), server.Client(), event)
+	if err == nil || strings.Contains(err.Error(), "secret") {
+		t.Errorf("expected a redacted error, got %v", err)
+	}
+}
+
+func TestNotifyWebhooksOnlySendsSubscribedEvents(t *testing.T) {
+	var mu sync.Mutex
+	received := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received[r.URL.Path]++
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	webhooks := []WebhookConfig{
+		{Name: "default", URL: server.URL + "/default"},
+		{Name: "deletions", URL: server.URL + "/deletions", Events: []string{EventWorkspaceDeleted}},
+	}
+	if failures := NotifyWebhooks(context.Background(), webhooks, Event{Type: EventWorkspaceCreated, Message: "created"}); len(failures) > 0 {
+		t.Fatalf("NotifyWebhooks failed: %v", failures)
+	}
+	if failures := NotifyWebhooks(context.Background(), webhooks, Event{Type: EventWorkspaceDeleted, Message: "deleted"}); len(failures) > 0 {
+		t.Fatalf("NotifyWebhooks failed: %v", failures)
+	}
+	if received["/default"] != 1 || received["/deletions"] != 1 {
+		t.Errorf("received = %v, want one event per webhook", received)
+	}
+}
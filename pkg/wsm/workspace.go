@@ -1,18 +1,22 @@
 package wsm
 
 import (
+	"bytes"
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/huh"
 	"github.com/go-go-golems/workspace-manager/pkg/output"
 	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
 )
 
 // WorkspaceManager handles workspace creation and management
@@ -20,14 +24,37 @@ type WorkspaceManager struct {
 	config       *WorkspaceConfig
 	Discoverer   *RepositoryDiscoverer
 	workspaceDir string
+
+	// FS is used for workspace configurations and generated workspace files
+	FS FS
+	// Runner runs git
+	Runner CommandRunner
+	// Progress receives the progress of clones and checkouts, nil to run git silently
+	Progress ProgressReporter
+	// IgnoreQuota creates workspaces even when quota.enforce refuses them
+	IgnoreQuota bool
 }
 
 func getRegistryPath() (string, error) {
-	configDir, err := os.UserConfigDir()
+	return RegistryPath()
+}
+
+// ConfigPath returns the path of config.yaml of the current profile
+func ConfigPath() (string, error) {
+	configDir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "config.yaml"), nil
+}
+
+// RegistryPath returns the path of the repository registry of the current profile
+func RegistryPath() (string, error) {
+	configDir, err := ConfigDir()
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(configDir, "workspace-manager", "registry.json"), nil
+	return filepath.Join(configDir, "registry.json"), nil
 }
 
 // NewWorkspaceManager creates a new workspace manager
@@ -42,7 +69,15 @@ func NewWorkspaceManager() (*WorkspaceManager, error) {
 		return nil, errors.Wrap(err, "failed to get registry path")
 	}
 
+	return NewWorkspaceManagerWithBackends(config, registryPath, OSFS{}, ExecRunner{})
+}
+
+// NewWorkspaceManagerWithBackends creates a workspace manager for an already loaded configuration
+// that accesses files through fsys and runs git through runner
+func NewWorkspaceManagerWithBackends(config *WorkspaceConfig, registryPath string, fsys FS, runner CommandRunner) (*WorkspaceManager, error) {
 	discoverer := NewRepositoryDiscoverer(registryPath)
+	discoverer.FS = fsys
+	discoverer.Runner = runner
 	if err := discoverer.LoadRegistry(); err != nil {
 		return nil, errors.Wrap(err, "failed to load registry")
 	}
@@ -51,11 +86,76 @@ func NewWorkspaceManager() (*WorkspaceManager, error) {
 		config:       config,
 		Discoverer:   discoverer,
 		workspaceDir: config.WorkspaceDir,
+		FS:           fsys,
+		Runner:       runner,
 	}, nil
 }
 
+// fs returns the injected file system, defaulting to the local one
+func (wm *WorkspaceManager) fs() FS {
+	return fsOrDefault(wm.FS)
+}
+
+// runner returns the injected command runner, defaulting to local processes
+func (wm *WorkspaceManager) runner() CommandRunner {
+	return runnerOrDefault(wm.Runner)
+}
+
+// Config returns the loaded workspace manager configuration
+func (wm *WorkspaceManager) Config() *WorkspaceConfig {
+	return wm.config
+}
+
 // CreateWorkspace creates a new multi-repository workspace
 func (wm *WorkspaceManager) CreateWorkspace(ctx context.Context, name string, repoNames []string, branch string, baseBranch string, agentSource string, dryRun bool) (*Workspace, error) {
+	workspace, err := wm.planWorkspace(name, repoNames, branch, baseBranch, agentSource)
+	if err != nil {
+		return nil, err
+	}
+	if dryRun {
+		return workspace, nil
+	}
+
+	if err := wm.establishWorkspace(ctx, workspace, "create", map[string]string{
+		"repos":       strings.Join(repoNames, ","),
+		"branch":      branch,
+		"base_branch": baseBranch,
+	}); err != nil {
+		return nil, err
+	}
+
+	return workspace, nil
+}
+
+// ForkWorkspace creates a workspace with the repositories of source, on a new branch starting from
+// baseBranch. The history records the source workspace, which 'wsm show' prints.
+func (wm *WorkspaceManager) ForkWorkspace(ctx context.Context, source *Workspace, name, branch, baseBranch, agentSource string, dryRun bool) (*Workspace, error) {
+	repoNames := make([]string, 0, len(source.Repositories))
+	for _, repo := range source.Repositories {
+		repoNames = append(repoNames, repo.Name)
+	}
+	workspace, err := wm.planWorkspace(name, repoNames, branch, baseBranch, agentSource)
+	if err != nil {
+		return nil, err
+	}
+	if dryRun {
+		return workspace, nil
+	}
+
+	if err := wm.establishWorkspace(ctx, workspace, "fork", map[string]string{
+		"source":      source.Name,
+		"repos":       strings.Join(repoNames, ","),
+		"branch":      branch,
+		"base_branch": baseBranch,
+	}); err != nil {
+		return nil, err
+	}
+	return workspace, nil
+}
+
+// planWorkspace describes a new workspace of the registered repositories without creating
+// anything; the ways of creating a workspace adjust it before passing it to establishWorkspace
+func (wm *WorkspaceManager) planWorkspace(name string, repoNames []string, branch, baseBranch, agentSource string) (*Workspace, error) {
 	// Validate input
 	if name == "" {
 		return nil, errors.New("workspace name is required")
@@ -79,23 +179,44 @@ func (wm *WorkspaceManager) CreateWorkspace(ctx context.Context, name string, re
 		Created:      time.Now(),
 		GoWorkspace:  wm.shouldCreateGoWorkspace(repos),
 		AgentMD:      agentSource,
+		AgentAssets:  wm.config.AgentAssets,
 	}
-
-	if dryRun {
-		return workspace, nil
+	if !wm.config.GitConfig.IsEmpty() {
+		workspace.GitConfig = wm.config.GitConfig.clone()
 	}
+	return workspace, nil
+}
 
-	// Create workspace
+// establishWorkspace checks the quota, creates the structure of a planned workspace and saves it:
+// the steps shared by the ways of creating a workspace from the registered repositories
+func (wm *WorkspaceManager) establishWorkspace(ctx context.Context, workspace *Workspace, operation string, details map[string]string) error {
+	if err := wm.EnforceQuota(ctx, !workspace.Linked); err != nil {
+		return err
+	}
 	if err := wm.createWorkspaceStructure(ctx, workspace); err != nil {
-		return nil, errors.Wrap(err, "failed to create workspace structure")
+		return errors.Wrap(err, "failed to create workspace structure")
 	}
-
-	// Save workspace configuration
 	if err := wm.SaveWorkspace(workspace); err != nil {
-		return nil, errors.Wrap(err, "failed to save workspace configuration")
+		return errors.Wrap(err, "failed to save workspace configuration")
 	}
 
-	return workspace, nil
+	// The workspace is planned when its creation starts
+	wm.workspaceCreated(ctx, workspace, operation, workspace.Created, details)
+	return nil
+}
+
+// workspaceCreated records the operation that created a workspace, started at start, in the history
+// and sends workspace.created to the webhooks. Every creation path ends with it, so that 'wsm show'
+// and the webhooks see every new workspace.
+func (wm *WorkspaceManager) workspaceCreated(ctx context.Context, workspace *Workspace, operation string, start time.Time, details map[string]string) {
+	details["path"] = workspace.Path
+	RecordOperation(operation, workspace.Name, start, details)
+	wm.Notify(ctx, Event{
+		Type:      EventWorkspaceCreated,
+		Workspace: workspace.Name,
+		Branch:    workspace.Branch,
+		Message:   fmt.Sprintf("workspace %s was created", workspace.Name),
+	})
 }
 
 // findRepositories finds repositories by name
@@ -109,10 +230,16 @@ func (wm *WorkspaceManager) FindRepositories(repoNames []string) ([]Repository,
 
 	var repos []Repository
 	var notFound []string
+	seen := make(map[string]bool)
 
 	for _, name := range repoNames {
-		if repo, exists := repoMap[name]; exists {
-			repos = append(repos, repo)
+		// Aliases resolve to their repository; listing a repository twice adds it once
+		resolved := wm.Discoverer.ResolveAlias(name)
+		if repo, exists := repoMap[resolved]; exists {
+			if !seen[resolved] {
+				repos = append(repos, repo)
+				seen[resolved] = true
+			}
 		} else {
 			notFound = append(notFound, name)
 		}
@@ -137,7 +264,9 @@ func (wm *WorkspaceManager) shouldCreateGoWorkspace(repos []Repository) bool {
 	return false
 }
 
-// createWorkspaceStructure creates the physical workspace structure
+// createWorkspaceStructure creates the physical workspace structure. When some repositories
+// cannot be created, the others are kept and the plan is saved for 'create --resume', see
+// IncompleteCreationError.
 func (wm *WorkspaceManager) createWorkspaceStructure(ctx context.Context, workspace *Workspace) error {
 	output.LogInfo(
 		fmt.Sprintf("Creating workspace structure for '%s'", workspace.Name),
@@ -145,47 +274,101 @@ func (wm *WorkspaceManager) createWorkspaceStructure(ctx context.Context, worksp
 		"workspace", workspace.Name,
 	)
 
+	if err := wm.checkNoIncompleteCreation(workspace.Name); err != nil {
+		return err
+	}
+
 	// Create workspace directory
-	if err := os.MkdirAll(workspace.Path, 0755); err != nil {
+	if err := wm.fs().MkdirAll(workspace.Path, 0755); err != nil {
 		return errors.Wrapf(err, "failed to create workspace directory: %s", workspace.Path)
 	}
 
 	// Track successfully created worktrees for rollback
 	var createdWorktrees []WorktreeInfo
+	var failed []FailedRepository
 
 	// Create worktrees for each repository
 	for _, repo := range workspace.Repositories {
-		worktreeInfo := WorktreeInfo{
-			Repository: repo,
-			TargetPath: filepath.Join(workspace.Path, repo.Name),
-			Branch:     workspace.Branch,
-		}
-
-		if err := wm.createWorktree(ctx, workspace, repo); err != nil {
-			// Rollback any worktrees created so far
+		worktreeInfo, err := wm.createRepository(ctx, workspace, repo)
+		if err != nil {
+			if isCancellation(err) {
+				wm.rollbackWorktrees(ctx, createdWorktrees)
+				wm.cleanupWorkspaceDirectory(workspace.Path)
+				return err
+			}
 			output.LogError(
-				fmt.Sprintf("Failed to create worktree for repository '%s'", repo.Name),
-				"Failed to create worktree, rolling back",
+				fmt.Sprintf("Failed to create worktree for repository '%s': %v", repo.Name, err),
+				"Failed to create worktree, continuing with the other repositories",
 				"repo", repo.Name,
 				"createdWorktrees", len(createdWorktrees),
 				"error", err,
 			)
-
-			wm.rollbackWorktrees(ctx, createdWorktrees)
-			wm.cleanupWorkspaceDirectory(workspace.Path)
-			return errors.Wrapf(err, "failed to create worktree for %s", repo.Name)
+			failed = append(failed, FailedRepository{Name: repo.Name, Error: err.Error()})
+			continue
 		}
 
 		// Track successful creation
 		createdWorktrees = append(createdWorktrees, worktreeInfo)
-		output.LogInfo(
-			fmt.Sprintf("Successfully created worktree for '%s'", repo.Name),
-			"Successfully created worktree",
-			"repo", repo.Name,
-			"path", worktreeInfo.TargetPath,
-		)
 	}
 
+	if len(failed) > 0 {
+		return wm.suspendCreation(ctx, workspace, workspace.Created, createdWorktrees, failed)
+	}
+	return wm.finishWorkspaceStructure(ctx, workspace, createdWorktrees)
+}
+
+// createRepository adds one repository to the workspace: a link, a clone or a worktree depending
+// on the workspace. When it fails, whatever it left in the workspace is removed again, so the
+// repository can be retried.
+func (wm *WorkspaceManager) createRepository(ctx context.Context, workspace *Workspace, repo Repository) (WorktreeInfo, error) {
+	worktreeInfo := WorktreeInfo{
+		Repository: repo,
+		TargetPath: filepath.Join(workspace.Path, repo.Name),
+		Branch:     workspace.Branch,
+	}
+
+	if workspace.Linked {
+		if err := wm.linkRepository(workspace, repo); err != nil {
+			return worktreeInfo, errors.Wrapf(err, "failed to link %s", repo.Name)
+		}
+		return worktreeInfo, nil
+	}
+
+	_, lstatErr := wm.fs().Lstat(worktreeInfo.TargetPath)
+	existed := lstatErr == nil
+	create := wm.createWorktree
+	if workspace.Clone != "" {
+		create = wm.cloneRepository
+	}
+	reportStart(wm.Progress, repo.Name, "Preparing")
+	err := create(ctx, workspace, repo)
+	reportDone(wm.Progress, repo.Name, err)
+	if err != nil {
+		// Only remove what this attempt created, never a directory that was there before
+		if _, statErr := wm.fs().Lstat(worktreeInfo.TargetPath); !existed && statErr == nil {
+			wm.rollbackWorktrees(ctx, []WorktreeInfo{worktreeInfo})
+		}
+		return worktreeInfo, errors.Wrapf(err, "failed to create worktree for %s", repo.Name)
+	}
+
+	recordBranchPoint(ctx, workspace, repo.Name)
+	if workspace.Clone == "" {
+		wm.autoLockWorktree(ctx, workspace, repo)
+	}
+	wm.applyRepositoryGitConfig(ctx, workspace, repo)
+	wm.unlockCreatedRepository(ctx, workspace, repo)
+	output.LogInfo(
+		fmt.Sprintf("Successfully created worktree for '%s'", repo.Name),
+		"Successfully created worktree",
+		"repo", repo.Name,
+		"path", worktreeInfo.TargetPath,
+	)
+	return worktreeInfo, nil
+}
+
+// finishWorkspaceStructure writes the workspace files once all repositories are in place. On
+// failure, the repositories are rolled back and the workspace directory is removed.
+func (wm *WorkspaceManager) finishWorkspaceStructure(ctx context.Context, workspace *Workspace, createdWorktrees []WorktreeInfo) error {
 	// Create go.work file if needed
 	if workspace.GoWorkspace {
 		if err := wm.CreateGoWorkspace(workspace); err != nil {
@@ -198,6 +381,34 @@ func (wm *WorkspaceManager) createWorkspaceStructure(ctx context.Context, worksp
 			wm.cleanupWorkspaceDirectory(workspace.Path)
 			return errors.Wrap(err, "failed to create go.work file")
 		}
+		if err := wm.SyncGoWorkspace(ctx, workspace); err != nil {
+			output.LogWarn(
+				fmt.Sprintf("Failed to sync go.work: %v", err),
+				"Failed to sync go.work, but continuing",
+				"error", err,
+			)
+		}
+	}
+
+	// Write pnpm-workspace.yaml, Cargo.toml and pom.xml for JS, Rust and Java repositories
+	if err := wm.updateBuildManifests(workspace); err != nil {
+		output.LogWarn(
+			fmt.Sprintf("Failed to write build manifests: %v", err),
+			"Failed to write build manifests, but continuing",
+			"error", err,
+		)
+	}
+
+	// Write workspace-root scaffolding (.gitignore, .editorconfig, .wsm templates)
+	if err := wm.scaffoldWorkspaceFiles(workspace); err != nil {
+		output.LogError(
+			"Failed to write workspace scaffolding",
+			"Failed to write workspace scaffolding, rolling back worktrees",
+			"error", err,
+		)
+		wm.rollbackWorktrees(ctx, createdWorktrees)
+		wm.cleanupWorkspaceDirectory(workspace.Path)
+		return errors.Wrap(err, "failed to write workspace scaffolding")
 	}
 
 	// Copy AGENT.md if specified
@@ -214,6 +425,20 @@ func (wm *WorkspaceManager) createWorkspaceStructure(ctx context.Context, worksp
 		}
 	}
 
+	// Install configured agent assets
+	if len(workspace.AgentAssets) > 0 {
+		if err := wm.installAgentAssets(workspace); err != nil {
+			output.LogError(
+				"Failed to install agent assets",
+				"Failed to install agent assets, rolling back worktrees",
+				"error", err,
+			)
+			wm.rollbackWorktrees(ctx, createdWorktrees)
+			wm.cleanupWorkspaceDirectory(workspace.Path)
+			return errors.Wrap(err, "failed to install agent assets")
+		}
+	}
+
 	output.LogInfo(
 		fmt.Sprintf("Successfully created workspace structure for '%s' with %d worktrees", workspace.Name, len(createdWorktrees)),
 		"Successfully created workspace structure",
@@ -227,6 +452,7 @@ func (wm *WorkspaceManager) createWorkspaceStructure(ctx context.Context, worksp
 // createWorktree creates a git worktree for a repository
 func (wm *WorkspaceManager) createWorktree(ctx context.Context, workspace *Workspace, repo Repository) error {
 	targetPath := filepath.Join(workspace.Path, repo.Name)
+	baseBranch := workspace.repositoryBase(repo.Name)
 
 	output.LogInfo(
 		fmt.Sprintf("Creating worktree for '%s' on branch '%s'", repo.Name, workspace.Branch),
@@ -258,39 +484,45 @@ func (wm *WorkspaceManager) createWorktree(ctx context.Context, workspace *Works
 		)
 	}
 
-	fmt.Printf("\nBranch status for %s:\n", repo.Name)
-	fmt.Printf("  Local branch '%s' exists: %v\n", workspace.Branch, branchExists)
-	fmt.Printf("  Remote branch 'origin/%s' exists: %v\n", workspace.Branch, remoteBranchExists)
+	output.Printf("\nBranch status for %s:\n", repo.Name)
+	output.Printf("  Local branch '%s' exists: %v\n", workspace.Branch, branchExists)
+	output.Printf("  Remote branch 'origin/%s' exists: %v\n", workspace.Branch, remoteBranchExists)
 
 	if branchExists {
 		// Branch exists locally - ask user what to do using huh
+		defer output.SuspendProgress()()
 		output.PrintWarning("Branch '%s' already exists in repository '%s'", workspace.Branch, repo.Name)
 
 		var choice string
-		form := huh.NewForm(
-			huh.NewGroup(
-				huh.NewSelect[string]().
-					Title("How would you like to handle the existing branch?").
-					Options(
-						huh.NewOption("Overwrite the existing branch (git worktree add -B)", "overwrite"),
-						huh.NewOption("Use the existing branch as-is (git worktree add)", "use"),
-						huh.NewOption("Cancel workspace creation", "cancel"),
-					).
-					Value(&choice),
-			),
-		)
+		if output.IsInteractive() {
+			form := huh.NewForm(
+				huh.NewGroup(
+					huh.NewSelect[string]().
+						Title("How would you like to handle the existing branch?").
+						Options(
+							huh.NewOption("Overwrite the existing branch (git worktree add -B)", "overwrite"),
+							huh.NewOption("Use the existing branch as-is (git worktree add)", "use"),
+							huh.NewOption("Cancel workspace creation", "cancel"),
+						).
+						Value(&choice),
+				),
+			)
 
-		err := form.Run()
-		if err != nil {
-			// Check if user cancelled/aborted the form
-			errMsg := strings.ToLower(err.Error())
-			if strings.Contains(errMsg, "user aborted") ||
-				strings.Contains(errMsg, "cancelled") ||
-				strings.Contains(errMsg, "aborted") ||
-				strings.Contains(errMsg, "interrupt") {
-				return errors.New("workspace creation cancelled by user")
+			if err := form.Run(); err != nil {
+				// Check if user cancelled/aborted the form
+				errMsg := strings.ToLower(err.Error())
+				if strings.Contains(errMsg, "user aborted") ||
+					strings.Contains(errMsg, "cancelled") ||
+					strings.Contains(errMsg, "aborted") ||
+					strings.Contains(errMsg, "interrupt") {
+					return errCreationCancelled
+				}
+				return errors.Wrap(err, "failed to get user choice")
 			}
-			return errors.Wrap(err, "failed to get user choice")
+		} else {
+			// Without a terminal, keep the existing branch rather than discarding its commits
+			output.PrintInfo("Not running interactively, using the existing branch '%s' as-is", workspace.Branch)
+			choice = "use"
 		}
 
 		switch choice {
@@ -298,9 +530,9 @@ func (wm *WorkspaceManager) createWorktree(ctx context.Context, workspace *Works
 			output.PrintInfo("Overwriting branch '%s'...", workspace.Branch)
 			if remoteBranchExists {
 				return wm.ExecuteWorktreeCommand(ctx, repo.Path, "git", "worktree", "add", "-B", workspace.Branch, targetPath, "origin/"+workspace.Branch)
-			} else if workspace.BaseBranch != "" {
-				output.PrintInfo("Creating new branch '%s' from '%s'...", workspace.Branch, workspace.BaseBranch)
-				return wm.ExecuteWorktreeCommand(ctx, repo.Path, "git", "worktree", "add", "-B", workspace.Branch, targetPath, workspace.BaseBranch)
+			} else if baseBranch != "" {
+				output.PrintInfo("Creating new branch '%s' from '%s'...", workspace.Branch, baseBranch)
+				return wm.ExecuteWorktreeCommand(ctx, repo.Path, "git", "worktree", "add", "--no-track", "-B", workspace.Branch, targetPath, baseBranch)
 			} else {
 				return wm.ExecuteWorktreeCommand(ctx, repo.Path, "git", "worktree", "add", "-B", workspace.Branch, targetPath)
 			}
@@ -308,7 +540,7 @@ func (wm *WorkspaceManager) createWorktree(ctx context.Context, workspace *Works
 			output.PrintInfo("Using existing branch '%s'...", workspace.Branch)
 			return wm.ExecuteWorktreeCommand(ctx, repo.Path, "git", "worktree", "add", targetPath, workspace.Branch)
 		case "cancel":
-			return errors.New("workspace creation cancelled by user")
+			return errCreationCancelled
 		default:
 			return errors.New("invalid choice, workspace creation cancelled")
 		}
@@ -318,9 +550,9 @@ func (wm *WorkspaceManager) createWorktree(ctx context.Context, workspace *Works
 			output.PrintInfo("Creating worktree from remote branch origin/%s...", workspace.Branch)
 			return wm.ExecuteWorktreeCommand(ctx, repo.Path, "git", "worktree", "add", "-b", workspace.Branch, targetPath, "origin/"+workspace.Branch)
 		} else {
-			if workspace.BaseBranch != "" {
-				output.PrintInfo("Creating new branch '%s' from '%s' and worktree...", workspace.Branch, workspace.BaseBranch)
-				return wm.ExecuteWorktreeCommand(ctx, repo.Path, "git", "worktree", "add", "-b", workspace.Branch, targetPath, workspace.BaseBranch)
+			if baseBranch != "" {
+				output.PrintInfo("Creating new branch '%s' from '%s' and worktree...", workspace.Branch, baseBranch)
+				return wm.ExecuteWorktreeCommand(ctx, repo.Path, "git", "worktree", "add", "--no-track", "-b", workspace.Branch, targetPath, baseBranch)
 			} else {
 				output.PrintInfo("Creating new branch '%s' and worktree...", workspace.Branch)
 				return wm.ExecuteWorktreeCommand(ctx, repo.Path, "git", "worktree", "add", "-b", workspace.Branch, targetPath)
@@ -331,27 +563,20 @@ func (wm *WorkspaceManager) createWorktree(ctx context.Context, workspace *Works
 
 // checkBranchExists checks if a local branch exists
 func (wm *WorkspaceManager) CheckBranchExists(ctx context.Context, repoPath, branch string) (bool, error) {
-	cmd := exec.CommandContext(ctx, "git", "show-ref", "--verify", "--quiet", "refs/heads/"+branch)
-	cmd.Dir = repoPath
-	err := cmd.Run()
+	_, err := gitOutput(ctx, wm.Runner, repoPath, "show-ref", "--verify", "--quiet", "refs/heads/"+branch)
 	return err == nil, nil
 }
 
 // checkRemoteBranchExists checks if a remote branch exists
 func (wm *WorkspaceManager) CheckRemoteBranchExists(ctx context.Context, repoPath, branch string) (bool, error) {
-	cmd := exec.CommandContext(ctx, "git", "show-ref", "--verify", "--quiet", "refs/remotes/origin/"+branch)
-	cmd.Dir = repoPath
-	err := cmd.Run()
+	_, err := gitOutput(ctx, wm.Runner, repoPath, "show-ref", "--verify", "--quiet", "refs/remotes/origin/"+branch)
 	return err == nil, nil
 }
 
 // executeWorktreeCommand executes a git worktree command with proper logging and error handling
 func (wm *WorkspaceManager) ExecuteWorktreeCommand(ctx context.Context, repoPath string, args ...string) error {
-	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
-	cmd.Dir = repoPath
-
 	cmdStr := strings.Join(args, " ")
-	fmt.Printf("Executing: %s (in %s)\n", cmdStr, repoPath)
+	output.Printf("Executing: %s (in %s)\n", cmdStr, repoPath)
 
 	output.LogInfo(
 		fmt.Sprintf("Executing git worktree command: %s", cmdStr),
@@ -360,11 +585,17 @@ func (wm *WorkspaceManager) ExecuteWorktreeCommand(ctx context.Context, repoPath
 		"repoPath", repoPath,
 	)
 
-	cmdOutput, err := cmd.CombinedOutput()
+	var cmdOutput []byte
+	var err error
+	if target, ok := worktreeAddTarget(args); ok && wm.Progress != nil {
+		cmdOutput, err = wm.addWorktreeWithProgress(ctx, repoPath, target, args)
+	} else {
+		cmdOutput, err = wm.runner().CombinedOutput(ctx, repoPath, args[0], args[1:]...)
+	}
 	if err != nil {
-		fmt.Printf("❌ Command failed: %s\n", cmdStr)
-		fmt.Printf("   Error: %v\n", err)
-		fmt.Printf("   Output: %s\n", string(cmdOutput))
+		output.Printf("❌ Command failed: %s\n", cmdStr)
+		output.Printf("   Error: %v\n", err)
+		output.Printf("   Output: %s\n", string(cmdOutput))
 
 		output.LogError(
 			fmt.Sprintf("Git worktree command failed: %s", cmdStr),
@@ -377,9 +608,9 @@ func (wm *WorkspaceManager) ExecuteWorktreeCommand(ctx context.Context, repoPath
 		return errors.Wrapf(err, "git command failed: %s", string(cmdOutput))
 	}
 
-	fmt.Printf("✓ Successfully executed: %s\n", cmdStr)
+	output.Printf("✓ Successfully executed: %s\n", cmdStr)
 	if len(cmdOutput) > 0 {
-		fmt.Printf("  Output: %s\n", string(cmdOutput))
+		output.Printf("  Output: %s\n", string(cmdOutput))
 	}
 
 	output.LogInfo(
@@ -402,35 +633,47 @@ func (wm *WorkspaceManager) CreateGoWorkspace(workspace *Workspace) error {
 		"path", goWorkPath,
 	)
 
-	content := "go 1.23\n\nuse (\n"
+	if err := wm.fs().WriteFile(goWorkPath, []byte(wm.goWorkContent(workspace)), 0644); err != nil {
+		return errors.Wrapf(err, "failed to write go.work file")
+	}
+
+	return nil
+}
+
+// goWorkContent returns the go.work file using every repository of the workspace with a go.mod,
+// with the go and toolchain directives its modules require
+func (wm *WorkspaceManager) goWorkContent(workspace *Workspace) string {
+	var modules []string
+	var goMods [][]byte
 
 	for _, repo := range workspace.Repositories {
 		// Check if repo has go.mod
-		goModPath := filepath.Join(workspace.Path, repo.Name, "go.mod")
-		if _, err := os.Stat(goModPath); err == nil {
-			content += fmt.Sprintf("\t./%s\n", repo.Name)
+		data, err := wm.fs().ReadFile(filepath.Join(workspace.Path, repo.Name, "go.mod"))
+		if err != nil {
+			continue
 		}
+		modules = append(modules, repo.Name)
+		goMods = append(goMods, data)
 	}
 
-	content += ")\n"
-
-	if err := os.WriteFile(goWorkPath, []byte(content), 0644); err != nil {
-		return errors.Wrapf(err, "failed to write go.work file")
+	goVersion, toolchain := goWorkVersions(goMods)
+	content := fmt.Sprintf("go %s\n\n", goVersion)
+	if toolchain != "" {
+		content += fmt.Sprintf("toolchain go%s\n\n", toolchain)
+	}
+	content += "use (\n"
+	for _, module := range modules {
+		content += fmt.Sprintf("\t./%s\n", module)
 	}
 
-	return nil
+	return content + ")\n"
 }
 
-// copyAgentMD copies AGENT.md file to workspace
+// copyAgentMD renders the AGENT.md template into the workspace
 func (wm *WorkspaceManager) copyAgentMD(workspace *Workspace) error {
-	// Expand ~ in source path
-	source := workspace.AgentMD
-	if strings.HasPrefix(source, "~") {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return errors.Wrap(err, "failed to get home directory")
-		}
-		source = filepath.Join(home, source[1:])
+	source, err := expandHomePath(workspace.AgentMD)
+	if err != nil {
+		return err
 	}
 
 	target := filepath.Join(workspace.Path, "AGENT.md")
@@ -442,12 +685,14 @@ func (wm *WorkspaceManager) copyAgentMD(workspace *Workspace) error {
 		"target", target,
 	)
 
-	data, err := os.ReadFile(source)
+	data, err := wm.fs().ReadFile(source)
 	if err != nil {
 		return errors.Wrapf(err, "failed to read source file: %s", source)
 	}
 
-	if err := os.WriteFile(target, data, 0644); err != nil {
+	rendered := RenderAgentTemplate(filepath.Base(source), data, NewAgentTemplateData(workspace))
+
+	if err := wm.fs().WriteFile(target, rendered, 0644); err != nil {
 		return errors.Wrapf(err, "failed to write target file: %s", target)
 	}
 
@@ -457,7 +702,7 @@ func (wm *WorkspaceManager) copyAgentMD(workspace *Workspace) error {
 // saveWorkspace saves workspace configuration
 func (wm *WorkspaceManager) SaveWorkspace(workspace *Workspace) error {
 	workspacesDir := filepath.Join(filepath.Dir(wm.config.RegistryPath), "workspaces")
-	if err := os.MkdirAll(workspacesDir, 0755); err != nil {
+	if err := wm.fs().MkdirAll(workspacesDir, 0755); err != nil {
 		return errors.Wrap(err, "failed to create workspaces directory")
 	}
 
@@ -468,13 +713,60 @@ func (wm *WorkspaceManager) SaveWorkspace(workspace *Workspace) error {
 		return errors.Wrap(err, "failed to marshal workspace configuration")
 	}
 
-	if err := os.WriteFile(configPath, data, 0644); err != nil {
+	if err := wm.fs().WriteFile(configPath, data, 0644); err != nil {
 		return errors.Wrap(err, "failed to write workspace configuration")
 	}
 
 	return nil
 }
 
+// LoadConfig loads the workspace manager configuration, including config.yaml overrides
+func LoadConfig() (*WorkspaceConfig, error) {
+	return loadConfig()
+}
+
+// loggingConfigKeys are read from config.yaml by the glazed logging setup, not by wsm
+var loggingConfigKeys = map[string]bool{
+	"log-level":            true,
+	"log-format":           true,
+	"log-file":             true,
+	"with-caller":          true,
+	"logstash-enabled":     true,
+	"logstash-host":        true,
+	"logstash-port":        true,
+	"logstash-protocol":    true,
+	"logstash-app-name":    true,
+	"logstash-environment": true,
+}
+
+// withoutLoggingKeys drops the top-level logging keys from a config file so the
+// strict decoding of the wsm settings does not reject them
+func withoutLoggingKeys(data []byte) ([]byte, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	if len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+		return data, nil
+	}
+
+	mapping := document.Content[0]
+	content := make([]*yaml.Node, 0, len(mapping.Content))
+	removed := false
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if loggingConfigKeys[mapping.Content[i].Value] {
+			removed = true
+			continue
+		}
+		content = append(content, mapping.Content[i], mapping.Content[i+1])
+	}
+	if !removed {
+		return data, nil
+	}
+	mapping.Content = content
+	return yaml.Marshal(&document)
+}
+
 // loadConfig loads workspace manager configuration
 func loadConfig() (*WorkspaceConfig, error) {
 	home, err := os.UserHomeDir()
@@ -482,15 +774,82 @@ func loadConfig() (*WorkspaceConfig, error) {
 		return nil, err
 	}
 
-	configDir, err := os.UserConfigDir()
+	configDir, err := ConfigDir()
 	if err != nil {
 		return nil, err
 	}
 
 	config := &WorkspaceConfig{
-		WorkspaceDir: filepath.Join(home, "workspaces", time.Now().Format("2006-01-02")),
 		TemplateDir:  filepath.Join(home, "templates"),
-		RegistryPath: filepath.Join(configDir, "workspace-manager", "registry.json"),
+		RegistryPath: filepath.Join(configDir, "registry.json"),
+	}
+
+	// Overlay user settings from config.yaml if present
+	configPath := filepath.Join(configDir, "config.yaml")
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			config.WorkspaceDir = filepath.Join(defaultWorkspaceRoot(home, CurrentProfile()), time.Now().Format("2006-01-02"))
+			return config, nil
+		}
+		return nil, errors.Wrapf(err, "failed to read config file: %s", configPath)
+	}
+
+	data, err = withoutLoggingKeys(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse config file: %s", configPath)
+	}
+
+	// Unknown keys are errors: a misspelled setting would otherwise be silently left at its default
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(config); err != nil && err != io.EOF {
+		return nil, errors.Wrapf(err, "failed to parse config file: %s", configPath)
+	}
+
+	// Without a layout, the default root is dated and a configured workspace_dir is used as is
+	switch config.WorkspaceLayout {
+	case "":
+		if config.WorkspaceDir == "" {
+			config.WorkspaceDir = filepath.Join(defaultWorkspaceRoot(home, CurrentProfile()), time.Now().Format("2006-01-02"))
+		}
+	case WorkspaceLayoutDated:
+		config.WorkspaceDir = filepath.Join(cmp.Or(config.WorkspaceDir, defaultWorkspaceRoot(home, CurrentProfile())), time.Now().Format("2006-01-02"))
+	case WorkspaceLayoutFlat:
+		config.WorkspaceDir = cmp.Or(config.WorkspaceDir, defaultWorkspaceRoot(home, CurrentProfile()))
+	default:
+		return nil, errors.Errorf("invalid config file %s: unknown workspace_layout '%s' (expected %s or %s)",
+			configPath, config.WorkspaceLayout, WorkspaceLayoutDated, WorkspaceLayoutFlat)
+	}
+	if config.WorkspaceDir, err = expandHomePath(config.WorkspaceDir); err != nil {
+		return nil, err
+	}
+	if config.TemplateDir, err = expandHomePath(config.TemplateDir); err != nil {
+		return nil, err
+	}
+	if err := config.Signing.Validate(); err != nil {
+		return nil, errors.Wrapf(err, "invalid config file: %s", configPath)
+	}
+	if err := config.BranchProtection.Validate(); err != nil {
+		return nil, errors.Wrapf(err, "invalid config file: %s", configPath)
+	}
+	if err := config.Trash.Validate(); err != nil {
+		return nil, errors.Wrapf(err, "invalid config file: %s", configPath)
+	}
+	if err := config.Drift.Validate(); err != nil {
+		return nil, errors.Wrapf(err, "invalid config file: %s", configPath)
+	}
+	if err := config.Timeouts.Validate(); err != nil {
+		return nil, errors.Wrapf(err, "invalid config file: %s", configPath)
+	}
+	if err := config.Quota.Validate(); err != nil {
+		return nil, errors.Wrapf(err, "invalid config file: %s", configPath)
+	}
+	if err := config.Issues.Validate(); err != nil {
+		return nil, errors.Wrapf(err, "invalid config file: %s", configPath)
+	}
+	if err := ValidateWebhooks(config.Webhooks); err != nil {
+		return nil, errors.Wrapf(err, "invalid config file: %s", configPath)
 	}
 
 	return config, nil
@@ -498,12 +857,12 @@ func loadConfig() (*WorkspaceConfig, error) {
 
 // LoadWorkspaces loads all workspace configurations
 func LoadWorkspaces() ([]Workspace, error) {
-	configDir, err := os.UserConfigDir()
+	configDir, err := ConfigDir()
 	if err != nil {
 		return nil, err
 	}
 
-	workspacesDir := filepath.Join(configDir, "workspace-manager", "workspaces")
+	workspacesDir := filepath.Join(configDir, "workspaces")
 
 	if _, err := os.Stat(workspacesDir); os.IsNotExist(err) {
 		return []Workspace{}, nil
@@ -549,18 +908,18 @@ func LoadWorkspaces() ([]Workspace, error) {
 
 // LoadWorkspace loads a specific workspace by name
 func (wm *WorkspaceManager) LoadWorkspace(name string) (*Workspace, error) {
-	configDir, err := os.UserConfigDir()
+	configDir, err := ConfigDir()
 	if err != nil {
 		return nil, err
 	}
 
-	workspacePath := filepath.Join(configDir, "workspace-manager", "workspaces", name+".json")
+	workspacePath := filepath.Join(configDir, "workspaces", name+".json")
 
-	if _, err := os.Stat(workspacePath); os.IsNotExist(err) {
+	if _, err := wm.fs().Stat(workspacePath); os.IsNotExist(err) {
 		return nil, errors.Errorf("workspace '%s' not found", name)
 	}
 
-	data, err := os.ReadFile(workspacePath)
+	data, err := wm.fs().ReadFile(workspacePath)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to read workspace file: %s", workspacePath)
 	}
@@ -575,6 +934,7 @@ func (wm *WorkspaceManager) LoadWorkspace(name string) (*Workspace, error) {
 
 // DeleteWorkspace deletes a workspace and optionally removes its files
 func (wm *WorkspaceManager) DeleteWorkspace(ctx context.Context, name string, removeFiles bool, forceWorktrees bool) error {
+	start := time.Now()
 	output.LogInfo(
 		fmt.Sprintf("Deleting workspace '%s' (removeFiles: %v, forceWorktrees: %v)", name, removeFiles, forceWorktrees),
 		"Deleting workspace",
@@ -589,14 +949,70 @@ func (wm *WorkspaceManager) DeleteWorkspace(ctx context.Context, name string, re
 		return errors.Wrapf(err, "failed to load workspace '%s'", name)
 	}
 
-	// Remove worktrees first
-	if err := wm.removeWorktrees(ctx, workspace, forceWorktrees); err != nil {
-		return errors.Wrap(err, "failed to remove worktrees")
+	if err := wm.CheckDeletable(workspace); err != nil {
+		return err
+	}
+
+	// Children are workspaces in their own right: detach them instead of deleting their worktrees
+	if len(workspace.Children) > 0 {
+		for _, childName := range slices.Clone(workspace.Children) {
+			if err := wm.detachChild(workspace, childName); err != nil {
+				return err
+			}
+		}
+		if err := wm.SaveWorkspace(workspace); err != nil {
+			return errors.Wrap(err, "failed to save workspace configuration")
+		}
+	}
+
+	// The dev container mounts the workspace directory, remove it first
+	if workspace.Container != nil {
+		if err := wm.StopContainer(ctx, workspace); err != nil {
+			output.LogWarn(
+				fmt.Sprintf("Failed to remove container of workspace '%s': %v", name, err),
+				"Failed to remove workspace container",
+				"workspace", name,
+				"error", err,
+			)
+		}
+	}
+
+	// Services run from the worktrees, stop them before removing them
+	if err := StopServices(ctx, workspace); err != nil {
+		output.LogWarn(
+			fmt.Sprintf("Failed to stop services of workspace '%s': %v", name, err),
+			"Failed to stop workspace services",
+			"workspace", name,
+			"error", err,
+		)
+	}
+
+	// The trash keeps the worktrees as they are, along with their git registrations
+	var trashed *TrashEntry
+	if removeFiles && wm.config.Trash.Enabled() {
+		if trashed, err = wm.trashWorkspace(ctx, workspace, forceWorktrees); err != nil {
+			return err
+		}
+	} else {
+		// Remove generated agent assets so they don't block worktree removal
+		wm.removeRepoAgentAssets(ctx, workspace)
+
+		// Remove worktrees first
+		if err := wm.removeWorktrees(ctx, workspace, forceWorktrees); err != nil {
+			return errors.Wrap(err, "failed to remove worktrees")
+		}
 	}
 
 	// Remove workspace directory and files if requested
-	if removeFiles {
-		if _, err := os.Stat(workspace.Path); err == nil {
+	if trashed != nil {
+		output.LogInfo(
+			fmt.Sprintf("Moved workspace '%s' to the trash; restore it with 'wsm undelete %s' until %s", name, name, trashed.Expires.Format("2006-01-02 15:04")),
+			"Moved workspace to the trash",
+			"workspace", name,
+			"trash", trashed.Dir,
+		)
+	} else if removeFiles {
+		if _, err := wm.fs().Stat(workspace.Path); err == nil {
 			output.LogInfo(
 				fmt.Sprintf("Removing workspace directory and files: %s", workspace.Path),
 				"Removing workspace directory and files",
@@ -612,7 +1028,7 @@ func (wm *WorkspaceManager) DeleteWorkspace(ctx context.Context, name string, re
 				)
 			}
 
-			if err := os.RemoveAll(workspace.Path); err != nil {
+			if err := wm.fs().RemoveAll(workspace.Path); err != nil {
 				return errors.Wrapf(err, "failed to remove workspace directory: %s", workspace.Path)
 			}
 
@@ -625,7 +1041,7 @@ func (wm *WorkspaceManager) DeleteWorkspace(ctx context.Context, name string, re
 	} else {
 		// If not removing files, still clean up go.work and AGENT.md from workspace directory
 		// as these are workspace-specific files that should be removed with workspace deletion
-		if err := wm.cleanupWorkspaceSpecificFiles(workspace.Path); err != nil {
+		if err := wm.cleanupWorkspaceSpecificFiles(workspace); err != nil {
 			output.LogWarn(
 				"Failed to clean up workspace-specific files",
 				"Failed to clean up workspace-specific files",
@@ -635,13 +1051,13 @@ func (wm *WorkspaceManager) DeleteWorkspace(ctx context.Context, name string, re
 	}
 
 	// Remove workspace configuration
-	configDir, err := os.UserConfigDir()
+	configDir, err := ConfigDir()
 	if err != nil {
 		return errors.Wrap(err, "failed to get config directory")
 	}
 
-	configPath := filepath.Join(configDir, "workspace-manager", "workspaces", name+".json")
-	if err := os.Remove(configPath); err != nil && !os.IsNotExist(err) {
+	configPath := filepath.Join(configDir, "workspaces", name+".json")
+	if err := wm.fs().Remove(configPath); err != nil && !os.IsNotExist(err) {
 		return errors.Wrapf(err, "failed to remove workspace configuration: %s", configPath)
 	}
 
@@ -650,6 +1066,29 @@ func (wm *WorkspaceManager) DeleteWorkspace(ctx context.Context, name string, re
 		"Workspace deleted successfully",
 		"workspace", name,
 	)
+	details := map[string]string{
+		"path":         workspace.Path,
+		"remove_files": fmt.Sprintf("%v", removeFiles),
+	}
+	if trashed != nil {
+		details["trash"] = trashed.Dir
+	}
+	RecordOperation("delete", name, start, details)
+	wm.Notify(ctx, Event{Type: EventWorkspaceDeleted, Workspace: name, Branch: workspace.Branch,
+		Message: fmt.Sprintf("workspace %s was deleted", name)})
+
+	if purged, err := PurgeTrash(); err != nil {
+		output.PrintWarning("Failed to empty the trash: %v", err)
+	} else {
+		for _, entry := range purged {
+			output.LogInfo(
+				fmt.Sprintf("Permanently deleted workspace '%s' from the trash (deleted %s)", entry.Workspace.Name, entry.Deleted.Format("2006-01-02 15:04")),
+				"Purged workspace from the trash",
+				"workspace", entry.Workspace.Name,
+				"trash", entry.Dir,
+			)
+		}
+	}
 	return nil
 }
 
@@ -657,15 +1096,37 @@ func (wm *WorkspaceManager) DeleteWorkspace(ctx context.Context, name string, re
 func (wm *WorkspaceManager) removeWorktrees(ctx context.Context, workspace *Workspace, force bool) error {
 	var errs []error
 
+	// Linked workspaces have no worktrees, only links to checkouts that must stay; cloned
+	// workspaces have clones that are deleted unless they hold work that would be lost
+	if workspace.Linked || workspace.Clone != "" {
+		for _, repo := range workspace.Repositories {
+			var err error
+			if workspace.Clone != "" {
+				err = wm.removeClone(ctx, workspace, repo, force)
+			} else {
+				err = wm.unlinkRepository(workspace, repo)
+			}
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if len(errs) > 0 {
+			var errMsgs []string
+			for _, err := range errs {
+				errMsgs = append(errMsgs, err.Error())
+			}
+			return errors.New("failed to remove some repositories: " + strings.Join(errMsgs, "; "))
+		}
+		return nil
+	}
+
 	// First, let's list existing worktrees for debugging
 	output.PrintHeader("Workspace Cleanup Debug Info")
 	for _, repo := range workspace.Repositories {
 		output.PrintInfo("Repository: %s (at %s)", repo.Name, repo.Path)
 
 		// List existing worktrees
-		listCmd := exec.CommandContext(ctx, "git", "worktree", "list")
-		listCmd.Dir = repo.Path
-		if cmdOutput, err := listCmd.CombinedOutput(); err != nil {
+		if cmdOutput, err := wm.runner().CombinedOutput(ctx, repo.Path, "git", "worktree", "list"); err != nil {
 			output.PrintWarning("Failed to list worktrees: %v", err)
 		} else {
 			output.PrintInfo("Current worktrees:\n%s", string(cmdOutput))
@@ -688,7 +1149,7 @@ func (wm *WorkspaceManager) removeWorktrees(ctx context.Context, workspace *Work
 		fmt.Printf("Expected worktree path: %s\n", worktreePath)
 
 		// Check if worktree path exists
-		if stat, err := os.Stat(worktreePath); os.IsNotExist(err) {
+		if stat, err := wm.fs().Stat(worktreePath); os.IsNotExist(err) {
 			fmt.Printf("⚠️  Worktree directory does not exist, skipping\n")
 			continue
 		} else if err != nil {
@@ -735,16 +1196,13 @@ func (wm *WorkspaceManager) removeWorktrees(ctx context.Context, workspace *Work
 		}
 
 		// Remove worktree using git command
-		var cmd *exec.Cmd
-		var cmdStr string
-		if force {
-			cmd = exec.CommandContext(ctx, "git", "worktree", "remove", "--force", worktreePath)
-			cmdStr = fmt.Sprintf("git worktree remove --force %s", worktreePath)
-		} else {
-			cmd = exec.CommandContext(ctx, "git", "worktree", "remove", worktreePath)
-			cmdStr = fmt.Sprintf("git worktree remove %s", worktreePath)
+		args, err := wm.worktreeRemoveArgs(ctx, repo, worktreePath, force)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			errs = append(errs, err)
+			continue
 		}
-		cmd.Dir = repo.Path
+		cmdStr := "git " + strings.Join(args, " ")
 
 		output.LogInfo(
 			fmt.Sprintf("Executing git worktree remove command: %s", cmdStr),
@@ -757,7 +1215,7 @@ func (wm *WorkspaceManager) removeWorktrees(ctx context.Context, workspace *Work
 
 		fmt.Printf("Executing: %s (in %s)\n", cmdStr, repo.Path)
 
-		if cmdOutput, err := cmd.CombinedOutput(); err != nil {
+		if cmdOutput, err := wm.runner().CombinedOutput(ctx, repo.Path, "git", args...); err != nil {
 			output.LogError(
 				fmt.Sprintf("Failed to remove worktree for repository '%s'", repo.Name),
 				"Failed to remove worktree with git command",
@@ -796,9 +1254,7 @@ func (wm *WorkspaceManager) removeWorktrees(ctx context.Context, workspace *Work
 		fmt.Printf("\nRepository: %s\n", repo.Name)
 
 		// List remaining worktrees
-		listCmd := exec.CommandContext(ctx, "git", "worktree", "list")
-		listCmd.Dir = repo.Path
-		if output, err := listCmd.CombinedOutput(); err != nil {
+		if output, err := wm.runner().CombinedOutput(ctx, repo.Path, "git", "worktree", "list"); err != nil {
 			fmt.Printf("  ⚠️  Failed to list worktrees: %v\n", err)
 		} else {
 			fmt.Printf("  Remaining worktrees:\n%s", string(output))
@@ -819,7 +1275,7 @@ func (wm *WorkspaceManager) removeWorktrees(ctx context.Context, workspace *Work
 
 // logWorkspaceFilesToRemove logs the files that will be removed for transparency
 func (wm *WorkspaceManager) logWorkspaceFilesToRemove(workspacePath string) error {
-	entries, err := os.ReadDir(workspacePath)
+	entries, err := wm.fs().ReadDir(workspacePath)
 	if err != nil {
 		return err
 	}
@@ -847,22 +1303,40 @@ func (wm *WorkspaceManager) logWorkspaceFilesToRemove(workspacePath string) erro
 	return nil
 }
 
-// cleanupWorkspaceSpecificFiles removes workspace-specific files (go.work, AGENT.md)
-// even when not doing a full directory removal
-func (wm *WorkspaceManager) cleanupWorkspaceSpecificFiles(workspacePath string) error {
-	workspaceSpecificFiles := []string{"go.work", "go.work.sum", "AGENT.md"}
+// cleanupWorkspaceSpecificFiles removes workspace-specific files (go.work and other build manifests, AGENT.md,
+// scaffolding, agent assets) even when not doing a full directory removal. Build manifests and
+// scaffolding the user edited since they were generated are kept.
+func (wm *WorkspaceManager) cleanupWorkspaceSpecificFiles(workspace *Workspace) error {
+	workspacePath := workspace.Path
+	workspaceSpecificFiles := append([]string{"go.work", "go.work.sum", "AGENT.md"}, AgentAssetWorkspaceTargets(workspace)...)
+	workspaceSpecificFiles = append(workspaceSpecificFiles, wm.unchangedScaffoldFiles(workspace)...)
+	for _, file := range BuildManifestFiles(workspace) {
+		if slices.Contains(workspaceSpecificFiles, file) {
+			continue
+		}
+		path := filepath.Join(workspacePath, file)
+		if _, err := wm.fs().Stat(path); err == nil && !wm.isGeneratedBuildManifest(path) {
+			output.LogInfo(
+				fmt.Sprintf("Keeping %s: it was not generated by workspace-manager", file),
+				"Keeping build manifest that was not generated",
+				"file", file,
+			)
+			continue
+		}
+		workspaceSpecificFiles = append(workspaceSpecificFiles, file)
+	}
 
 	for _, fileName := range workspaceSpecificFiles {
 		filePath := filepath.Join(workspacePath, fileName)
 
-		if _, err := os.Stat(filePath); err == nil {
+		if _, err := wm.fs().Stat(filePath); err == nil {
 			output.LogInfo(
 				fmt.Sprintf("Removing workspace file %s", fileName),
 				"Removing workspace-specific file",
 				"file", filePath,
 			)
 
-			if err := os.Remove(filePath); err != nil {
+			if err := wm.fs().Remove(filePath); err != nil {
 				output.LogWarn(
 					fmt.Sprintf("Failed to remove workspace-specific file: %s", filePath),
 					"Failed to remove workspace-specific file",
@@ -896,7 +1370,6 @@ func (wm *WorkspaceManager) rollbackWorktrees(ctx context.Context, worktrees []W
 		return
 	}
 
-	fmt.Printf("\n🔄 Rolling back %d created worktrees...\n", len(worktrees))
 	output.LogInfo(
 		fmt.Sprintf("Rolling back %d created worktrees", len(worktrees)),
 		"Rolling back created worktrees",
@@ -906,7 +1379,23 @@ func (wm *WorkspaceManager) rollbackWorktrees(ctx context.Context, worktrees []W
 	for i := len(worktrees) - 1; i >= 0; i-- {
 		worktree := worktrees[i]
 
-		fmt.Printf("Rolling back worktree: %s (at %s)\n", worktree.Repository.Name, worktree.TargetPath)
+		// Linked repositories only need their link removed
+		if info, err := wm.fs().Lstat(worktree.TargetPath); err == nil && info.Mode()&os.ModeSymlink != 0 {
+			output.PrintInfo("Removing link: %s (at %s)", worktree.Repository.Name, worktree.TargetPath)
+			if err := wm.fs().Remove(worktree.TargetPath); err != nil {
+				output.PrintWarning("Failed to remove link %s: %v", worktree.TargetPath, err)
+			}
+			continue
+		}
+
+		// Clones have a .git directory of their own and are simply deleted
+		if info, err := wm.fs().Stat(filepath.Join(worktree.TargetPath, ".git")); err == nil && info.IsDir() {
+			output.PrintInfo("Removing clone: %s (at %s)", worktree.Repository.Name, worktree.TargetPath)
+			if err := wm.fs().RemoveAll(worktree.TargetPath); err != nil {
+				output.PrintWarning("Failed to remove clone %s: %v", worktree.TargetPath, err)
+			}
+			continue
+		}
 
 		output.LogInfo(
 			fmt.Sprintf("Rolling back worktree for %s", worktree.Repository.Name),
@@ -916,17 +1405,9 @@ func (wm *WorkspaceManager) rollbackWorktrees(ctx context.Context, worktrees []W
 			"repoPath", worktree.Repository.Path,
 		)
 
-		// Use git worktree remove --force for rollback to ensure it works even with uncommitted changes
-		cmd := exec.CommandContext(ctx, "git", "worktree", "remove", "--force", worktree.TargetPath)
-		cmd.Dir = worktree.Repository.Path
-
-		cmdStr := fmt.Sprintf("git worktree remove --force %s", worktree.TargetPath)
-		fmt.Printf("  Executing: %s (in %s)\n", cmdStr, worktree.Repository.Path)
-
-		if cmdOutput, err := cmd.CombinedOutput(); err != nil {
-			fmt.Printf("  ⚠️  Failed to remove worktree: %v\n", err)
-			fmt.Printf("      Output: %s\n", string(cmdOutput))
-
+		// Use git worktree remove --force for rollback to ensure it works even with uncommitted changes;
+		// the second --force also removes worktrees that were locked automatically
+		if cmdOutput, err := wm.runner().CombinedOutput(ctx, worktree.Repository.Path, "git", "worktree", "remove", "--force", "--force", worktree.TargetPath); err != nil {
 			output.LogWarn(
 				fmt.Sprintf("Failed to remove worktree for '%s' during rollback", worktree.Repository.Name),
 				"Failed to remove worktree during rollback",
@@ -936,8 +1417,6 @@ func (wm *WorkspaceManager) rollbackWorktrees(ctx context.Context, worktrees []W
 				"targetPath", worktree.TargetPath,
 			)
 		} else {
-			fmt.Printf("  ✓ Successfully removed worktree\n")
-
 			output.LogInfo(
 				fmt.Sprintf("Successfully removed worktree for %s", worktree.Repository.Name),
 				"Successfully removed worktree during rollback",
@@ -947,7 +1426,6 @@ func (wm *WorkspaceManager) rollbackWorktrees(ctx context.Context, worktrees []W
 		}
 	}
 
-	fmt.Printf("🔄 Rollback completed\n\n")
 	output.LogInfo("Rollback completed", "Worktree rollback completed")
 }
 
@@ -965,13 +1443,13 @@ func (wm *WorkspaceManager) cleanupWorkspaceDirectory(workspacePath string) {
 	)
 
 	// Check if directory exists
-	if _, err := os.Stat(workspacePath); os.IsNotExist(err) {
+	if _, err := wm.fs().Stat(workspacePath); os.IsNotExist(err) {
 		fmt.Printf("  Directory doesn't exist, nothing to clean up\n")
 		return
 	}
 
 	// Read directory contents
-	entries, err := os.ReadDir(workspacePath)
+	entries, err := wm.fs().ReadDir(workspacePath)
 	if err != nil {
 		fmt.Printf("  ⚠️  Failed to read directory: %v\n", err)
 		output.LogWarn(
@@ -1003,7 +1481,7 @@ func (wm *WorkspaceManager) cleanupWorkspaceDirectory(workspacePath string) {
 
 	if isEmpty || onlyExpectedFiles {
 		fmt.Printf("  Removing workspace directory (empty or only contains expected files)\n")
-		if err := os.RemoveAll(workspacePath); err != nil {
+		if err := wm.fs().RemoveAll(workspacePath); err != nil {
 			fmt.Printf("  ⚠️  Failed to remove workspace directory: %v\n", err)
 			output.LogWarn(
 				fmt.Sprintf("Failed to remove workspace directory during cleanup: %s", workspacePath),
@@ -1038,14 +1516,17 @@ func (wm *WorkspaceManager) cleanupWorkspaceDirectory(workspacePath string) {
 }
 
 // AddRepositoryToWorkspace adds a repository to an existing workspace
-func (wm *WorkspaceManager) AddRepositoryToWorkspace(ctx context.Context, workspaceName, repoName, branchName string, forceOverwrite bool) error {
+func (wm *WorkspaceManager) AddRepositoryToWorkspace(ctx context.Context, workspaceName, repoName, branchName string, existingBranch string) error {
+	start := time.Now()
+	repoName = wm.Discoverer.ResolveAlias(repoName)
+
 	output.LogInfo(
 		fmt.Sprintf("Adding repository %s to workspace %s", repoName, workspaceName),
 		"Adding repository to workspace",
 		"workspace", workspaceName,
 		"repo", repoName,
 		"branch", branchName,
-		"force", forceOverwrite,
+		"existing_branch", existingBranch,
 	)
 
 	// Load existing workspace
@@ -1073,6 +1554,10 @@ func (wm *WorkspaceManager) AddRepositoryToWorkspace(ctx context.Context, worksp
 
 	repo := repos[0]
 
+	if workspace.Linked && branchName != "" {
+		return errors.Errorf("workspace '%s' links existing checkouts, a branch cannot be chosen", workspaceName)
+	}
+
 	// Use the workspace's branch if no specific branch provided
 	targetBranch := branchName
 	if targetBranch == "" {
@@ -1085,26 +1570,45 @@ func (wm *WorkspaceManager) AddRepositoryToWorkspace(ctx context.Context, worksp
 	tempWorkspace.Repositories = []Repository{repo}
 
 	output.PrintInfo("Adding repository '%s' to workspace '%s'", repoName, workspaceName)
-	output.PrintInfo("Target branch: %s", targetBranch)
+	if !workspace.Linked {
+		output.PrintInfo("Target branch: %s", targetBranch)
+	}
 	output.PrintInfo("Workspace path: %s", workspace.Path)
 
-	// Create worktree for the new repository
-	if err := wm.CreateWorktreeForAdd(ctx, workspace, repo, targetBranch, forceOverwrite); err != nil {
-		return errors.Wrapf(err, "failed to create worktree for repository '%s'", repoName)
-	}
+	if workspace.Linked {
+		// Linked workspaces include the checkout as it is, on whatever branch it has
+		if err := wm.linkRepository(workspace, repo); err != nil {
+			return errors.Wrapf(err, "failed to link repository '%s'", repoName)
+		}
+		workspace.Repositories = append(workspace.Repositories, repo)
+	} else if workspace.Clone != "" {
+		// Cloned workspaces clone the new repository as well, onto the target branch
+		if err := wm.cloneRepository(ctx, &tempWorkspace, repo); err != nil {
+			return errors.Wrapf(err, "failed to clone repository '%s'", repoName)
+		}
+		workspace.Repositories = append(workspace.Repositories, repo)
+		recordBranchPoint(ctx, workspace, repo.Name)
+		wm.applyRepositoryGitConfig(ctx, workspace, repo)
+	} else {
+		// Create worktree for the new repository
+		if err := wm.CreateWorktreeForAdd(ctx, workspace, repo, targetBranch, existingBranch); err != nil {
+			return errors.Wrapf(err, "failed to create worktree for repository '%s'", repoName)
+		}
 
-	// Add repository to workspace configuration
-	workspace.Repositories = append(workspace.Repositories, repo)
+		// Add repository to workspace configuration
+		workspace.Repositories = append(workspace.Repositories, repo)
+		recordBranchPoint(ctx, workspace, repo.Name)
+		wm.autoLockWorktree(ctx, workspace, repo)
+		wm.applyRepositoryGitConfig(ctx, workspace, repo)
+	}
 
-	// Update go.work file if this is a Go workspace and the new repo has go.mod
-	if workspace.GoWorkspace {
-		if err := wm.CreateGoWorkspace(workspace); err != nil {
-			output.LogWarn(
-				fmt.Sprintf("Failed to update go.work file: %v", err),
-				"Failed to update go.work file, but continuing",
-				"error", err,
-			)
-		}
+	// Update go.work and the other build manifests, creating them for the first repository of an ecosystem
+	if err := wm.UpdateBuildManifests(ctx, workspace); err != nil {
+		output.LogWarn(
+			fmt.Sprintf("Failed to update build manifests: %v", err),
+			"Failed to update build manifests, but continuing",
+			"error", err,
+		)
 	}
 
 	// Save updated workspace configuration
@@ -1112,12 +1616,28 @@ func (wm *WorkspaceManager) AddRepositoryToWorkspace(ctx context.Context, worksp
 		return errors.Wrap(err, "failed to save updated workspace configuration")
 	}
 
+	RecordOperation("add", workspaceName, start, map[string]string{
+		"repo":   repoName,
+		"branch": branchName,
+	})
+
 	fmt.Printf("✓ Successfully added repository '%s' to workspace '%s'\n", repoName, workspaceName)
 	return nil
 }
 
-// CreateWorktreeForAdd creates a worktree for adding a repository to an existing workspace
-func (wm *WorkspaceManager) CreateWorktreeForAdd(ctx context.Context, workspace *Workspace, repo Repository, branch string, forceOverwrite bool) error {
+// How AddRepositoryToWorkspace handles a branch that already exists in the repository
+const (
+	// ExistingBranchAsk prompts for it, and fails without a terminal
+	ExistingBranchAsk = ""
+	// ExistingBranchOverwrite resets the branch (git worktree add -B)
+	ExistingBranchOverwrite = "overwrite"
+	// ExistingBranchUse checks the branch out as it is
+	ExistingBranchUse = "use"
+)
+
+// CreateWorktreeForAdd creates a worktree for adding a repository to an existing workspace;
+// existingBranch is one of the ExistingBranch* handlings
+func (wm *WorkspaceManager) CreateWorktreeForAdd(ctx context.Context, workspace *Workspace, repo Repository, branch string, existingBranch string) error {
 	targetPath := filepath.Join(workspace.Path, repo.Name)
 
 	output.LogInfo(
@@ -1126,11 +1646,11 @@ func (wm *WorkspaceManager) CreateWorktreeForAdd(ctx context.Context, workspace
 		"repo", repo.Name,
 		"branch", branch,
 		"target", targetPath,
-		"force", forceOverwrite,
+		"existing_branch", existingBranch,
 	)
 
 	// Check if target path already exists
-	if _, err := os.Stat(targetPath); err == nil {
+	if _, err := wm.fs().Stat(targetPath); err == nil {
 		return errors.Errorf("target path '%s' already exists", targetPath)
 	}
 
@@ -1161,15 +1681,13 @@ func (wm *WorkspaceManager) CreateWorktreeForAdd(ctx context.Context, workspace
 	fmt.Printf("  Remote branch 'origin/%s' exists: %v\n", branch, remoteBranchExists)
 
 	if branchExists {
-		if forceOverwrite {
-			fmt.Printf("Force overwriting branch '%s'...\n", branch)
-			if remoteBranchExists {
-				return wm.ExecuteWorktreeCommand(ctx, repo.Path, "git", "worktree", "add", "-B", branch, targetPath, "origin/"+branch)
-			} else {
-				return wm.ExecuteWorktreeCommand(ctx, repo.Path, "git", "worktree", "add", "-B", branch, targetPath)
+		choice := existingBranch
+		if choice == ExistingBranchAsk {
+			if err := output.RequireInteractive(fmt.Sprintf("ask how to handle existing branch '%s' in '%s'", branch, repo.Name), "use --use-existing to keep it or --force to overwrite it"); err != nil {
+				return err
 			}
-		} else {
-			// Branch exists locally - ask user what to do unless force is specified
+
+			// Branch exists locally - ask user what to do
 			fmt.Printf("\n⚠️  Branch '%s' already exists in repository '%s'\n", branch, repo.Name)
 			fmt.Printf("What would you like to do?\n")
 			fmt.Printf("  [o] Overwrite the existing branch (git worktree add -B)\n")
@@ -1177,29 +1695,37 @@ func (wm *WorkspaceManager) CreateWorktreeForAdd(ctx context.Context, workspace
 			fmt.Printf("  [c] Cancel operation\n")
 			fmt.Printf("Choice [o/u/c]: ")
 
-			var choice string
-			if _, err := fmt.Scanln(&choice); err != nil {
+			var answer string
+			if _, err := fmt.Scanln(&answer); err != nil {
 				// If input fails, default to cancel to be safe
-				choice = "c"
+				answer = "c"
 			}
 
-			switch strings.ToLower(choice) {
+			switch strings.ToLower(answer) {
 			case "o", "overwrite":
-				fmt.Printf("Overwriting branch '%s'...\n", branch)
-				if remoteBranchExists {
-					return wm.ExecuteWorktreeCommand(ctx, repo.Path, "git", "worktree", "add", "-B", branch, targetPath, "origin/"+branch)
-				} else {
-					return wm.ExecuteWorktreeCommand(ctx, repo.Path, "git", "worktree", "add", "-B", branch, targetPath)
-				}
+				choice = ExistingBranchOverwrite
 			case "u", "use":
-				fmt.Printf("Using existing branch '%s'...\n", branch)
-				return wm.ExecuteWorktreeCommand(ctx, repo.Path, "git", "worktree", "add", targetPath, branch)
+				choice = ExistingBranchUse
 			case "c", "cancel":
 				return errors.New("operation cancelled by user")
 			default:
 				return errors.New("invalid choice, operation cancelled")
 			}
 		}
+
+		switch choice {
+		case ExistingBranchOverwrite:
+			fmt.Printf("Overwriting branch '%s'...\n", branch)
+			if remoteBranchExists {
+				return wm.ExecuteWorktreeCommand(ctx, repo.Path, "git", "worktree", "add", "-B", branch, targetPath, "origin/"+branch)
+			}
+			return wm.ExecuteWorktreeCommand(ctx, repo.Path, "git", "worktree", "add", "-B", branch, targetPath)
+		case ExistingBranchUse:
+			fmt.Printf("Using existing branch '%s'...\n", branch)
+			return wm.ExecuteWorktreeCommand(ctx, repo.Path, "git", "worktree", "add", targetPath, branch)
+		default:
+			return errors.Errorf("unknown handling '%s' of an existing branch", choice)
+		}
 	} else {
 		// Branch doesn't exist locally
 		if remoteBranchExists {
@@ -1214,6 +1740,9 @@ func (wm *WorkspaceManager) CreateWorktreeForAdd(ctx context.Context, workspace
 
 // RemoveRepositoryFromWorkspace removes a repository from an existing workspace
 func (wm *WorkspaceManager) RemoveRepositoryFromWorkspace(ctx context.Context, workspaceName, repoName string, force, removeFiles bool) error {
+	start := time.Now()
+	repoName = wm.Discoverer.ResolveAlias(repoName)
+
 	output.LogInfo(
 		fmt.Sprintf("Removing repository %s from workspace %s", repoName, workspaceName),
 		"Removing repository from workspace",
@@ -1248,17 +1777,25 @@ func (wm *WorkspaceManager) RemoveRepositoryFromWorkspace(ctx context.Context, w
 	fmt.Printf("Repository path: %s\n", targetRepo.Path)
 	fmt.Printf("Workspace path: %s\n", workspace.Path)
 
-	// Remove the worktree
+	// Remove the worktree, or the link or clone of linked and cloned workspaces
 	worktreePath := filepath.Join(workspace.Path, repoName)
-	if err := wm.removeWorktreeForRepo(ctx, targetRepo, worktreePath, force); err != nil {
+	if workspace.Linked {
+		if err := wm.unlinkRepository(workspace, targetRepo); err != nil {
+			return errors.Wrapf(err, "failed to unlink repository '%s'", repoName)
+		}
+	} else if workspace.Clone != "" {
+		if err := wm.removeClone(ctx, workspace, targetRepo, force); err != nil {
+			return errors.Wrapf(err, "failed to remove clone of repository '%s'", repoName)
+		}
+	} else if err := wm.removeWorktreeForRepo(ctx, targetRepo, worktreePath, force); err != nil {
 		return errors.Wrapf(err, "failed to remove worktree for repository '%s'", repoName)
 	}
 
 	// Remove repository directory if requested
-	if removeFiles {
-		if _, err := os.Stat(worktreePath); err == nil {
+	if removeFiles && !workspace.Linked {
+		if _, err := wm.fs().Stat(worktreePath); err == nil {
 			fmt.Printf("Removing repository directory: %s\n", worktreePath)
-			if err := os.RemoveAll(worktreePath); err != nil {
+			if err := wm.fs().RemoveAll(worktreePath); err != nil {
 				return errors.Wrapf(err, "failed to remove repository directory: %s", worktreePath)
 			}
 			fmt.Printf("✓ Successfully removed repository directory\n")
@@ -1267,16 +1804,18 @@ func (wm *WorkspaceManager) RemoveRepositoryFromWorkspace(ctx context.Context, w
 
 	// Remove repository from workspace configuration
 	workspace.Repositories = append(workspace.Repositories[:repoIndex], workspace.Repositories[repoIndex+1:]...)
+	delete(workspace.BranchPoints, repoName)
+	if i := slices.Index(workspace.Frozen, repoName); i >= 0 {
+		workspace.Frozen = slices.Delete(workspace.Frozen, i, i+1)
+	}
 
-	// Update go.work file if this is a Go workspace
-	if workspace.GoWorkspace {
-		if err := wm.CreateGoWorkspace(workspace); err != nil {
-			output.LogWarn(
-				fmt.Sprintf("Failed to update go.work file: %v", err),
-				"Failed to update go.work file, but continuing",
-				"error", err,
-			)
-		}
+	// Update go.work and the other build manifests, removing those without repositories left
+	if err := wm.UpdateBuildManifests(ctx, workspace); err != nil {
+		output.LogWarn(
+			fmt.Sprintf("Failed to update build manifests: %v", err),
+			"Failed to update build manifests, but continuing",
+			"error", err,
+		)
 	}
 
 	// Save updated workspace configuration
@@ -1284,6 +1823,11 @@ func (wm *WorkspaceManager) RemoveRepositoryFromWorkspace(ctx context.Context, w
 		return errors.Wrap(err, "failed to save updated workspace configuration")
 	}
 
+	RecordOperation("remove", workspaceName, start, map[string]string{
+		"repo":         repoName,
+		"remove_files": fmt.Sprintf("%v", removeFiles),
+	})
+
 	fmt.Printf("✓ Successfully removed repository '%s' from workspace '%s'\n", repoName, workspaceName)
 	return nil
 }
@@ -1302,7 +1846,7 @@ func (wm *WorkspaceManager) removeWorktreeForRepo(ctx context.Context, repo Repo
 	fmt.Printf("Worktree path: %s\n", worktreePath)
 
 	// Check if worktree path exists
-	if stat, err := os.Stat(worktreePath); os.IsNotExist(err) {
+	if stat, err := wm.fs().Stat(worktreePath); os.IsNotExist(err) {
 		fmt.Printf("⚠️  Worktree directory does not exist, skipping worktree removal\n")
 		return nil
 	} else if err != nil {
@@ -1346,25 +1890,18 @@ func (wm *WorkspaceManager) removeWorktreeForRepo(ctx context.Context, repo Repo
 
 	// First, list current worktrees for debugging
 	fmt.Printf("\nCurrent worktrees for %s:\n", repo.Name)
-	listCmd := exec.CommandContext(ctx, "git", "worktree", "list")
-	listCmd.Dir = repo.Path
-	if output, err := listCmd.CombinedOutput(); err != nil {
+	if output, err := wm.runner().CombinedOutput(ctx, repo.Path, "git", "worktree", "list"); err != nil {
 		fmt.Printf("⚠️  Failed to list worktrees: %v\n", err)
 	} else {
 		fmt.Printf("%s", string(output))
 	}
 
 	// Remove worktree using git command
-	var cmd *exec.Cmd
-	var cmdStr string
-	if force {
-		cmd = exec.CommandContext(ctx, "git", "worktree", "remove", "--force", worktreePath)
-		cmdStr = fmt.Sprintf("git worktree remove --force %s", worktreePath)
-	} else {
-		cmd = exec.CommandContext(ctx, "git", "worktree", "remove", worktreePath)
-		cmdStr = fmt.Sprintf("git worktree remove %s", worktreePath)
+	args, err := wm.worktreeRemoveArgs(ctx, repo, worktreePath, force)
+	if err != nil {
+		return err
 	}
-	cmd.Dir = repo.Path
+	cmdStr := "git " + strings.Join(args, " ")
 
 	output.LogInfo(
 		fmt.Sprintf("Executing: %s (in %s)", cmdStr, repo.Path),
@@ -1377,7 +1914,7 @@ func (wm *WorkspaceManager) removeWorktreeForRepo(ctx context.Context, repo Repo
 
 	fmt.Printf("Executing: %s (in %s)\n", cmdStr, repo.Path)
 
-	cmdOutput, err := cmd.CombinedOutput()
+	cmdOutput, err := wm.runner().CombinedOutput(ctx, repo.Path, "git", args...)
 	if err != nil {
 		output.LogError(
 			fmt.Sprintf("Failed to remove worktree for '%s': %v", repo.Name, err),
@@ -1408,9 +1945,7 @@ func (wm *WorkspaceManager) removeWorktreeForRepo(ctx context.Context, repo Repo
 
 	// Verify worktree was removed
 	fmt.Printf("\nVerification: Remaining worktrees for %s:\n", repo.Name)
-	listCmd = exec.CommandContext(ctx, "git", "worktree", "list")
-	listCmd.Dir = repo.Path
-	if output, err := listCmd.CombinedOutput(); err != nil {
+	if output, err := wm.runner().CombinedOutput(ctx, repo.Path, "git", "worktree", "list"); err != nil {
 		fmt.Printf("⚠️  Failed to list worktrees: %v\n", err)
 	} else {
 		fmt.Printf("%s", string(output))
@@ -1421,17 +1956,14 @@ func (wm *WorkspaceManager) removeWorktreeForRepo(ctx context.Context, repo Repo
 
 // getUntrackedFiles gets untracked files in a repository path
 func (wm *WorkspaceManager) getUntrackedFiles(ctx context.Context, repoPath string) ([]string, error) {
-	cmd := exec.CommandContext(ctx, "git", "ls-files", "--others", "--exclude-standard")
-	cmd.Dir = repoPath
-	output, err := cmd.Output()
+	output, err := gitOutput(ctx, wm.Runner, repoPath, "ls-files", "--others", "--exclude-standard")
 	if err != nil {
 		return nil, err
 	}
 
-	if len(output) == 0 {
+	if output == "" {
 		return []string{}, nil
 	}
 
-	files := strings.Split(strings.TrimSpace(string(output)), "\n")
-	return files, nil
+	return strings.Split(output, "\n"), nil
 }
@@ -0,0 +1,242 @@
+package wsm
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/go-go-golems/workspace-manager/pkg/output"
+	"github.com/pkg/errors"
+)
+
+// networkFilesystems are mount types whose worktrees can disappear while the repository stays
+var networkFilesystems = map[string]bool{
+	"nfs": true, "nfs4": true, "cifs": true, "smb3": true, "smbfs": true, "9p": true, "afs": true,
+	"ceph": true, "glusterfs": true, "fuse.sshfs": true, "fuse.rclone": true, "davfs": true, "fuse.davfs2": true,
+}
+
+// WorktreeLock is the git lock state of the worktree of a workspace repository
+type WorktreeLock struct {
+	Repository string `json:"repository"`
+	Path       string `json:"path"`
+	Locked     bool   `json:"locked"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+// worktreeLockState returns whether git has the worktree locked, and why, from 'git worktree list'
+// run in the source repository
+func (wm *WorkspaceManager) worktreeLockState(ctx context.Context, repo Repository, worktreePath string) (bool, string, error) {
+	out, err := gitOutput(ctx, wm.runner(), repo.Path, "worktree", "list", "--porcelain")
+	if err != nil {
+		return false, "", err
+	}
+
+	current := ""
+	for _, line := range strings.Split(out, "\n") {
+		switch {
+		case strings.HasPrefix(line, "worktree "):
+			current = strings.TrimPrefix(line, "worktree ")
+		case line == "locked" || strings.HasPrefix(line, "locked "):
+			if samePath(current, worktreePath) {
+				return true, strings.TrimSpace(strings.TrimPrefix(line, "locked")), nil
+			}
+		}
+	}
+	return false, "", nil
+}
+
+// samePath compares paths after resolving symlinks, since git reports resolved worktree paths
+func samePath(a, b string) bool {
+	if a == b {
+		return true
+	}
+	resolvedA, errA := filepath.EvalSymlinks(a)
+	resolvedB, errB := filepath.EvalSymlinks(b)
+	return errA == nil && errB == nil && resolvedA == resolvedB
+}
+
+// WorktreeLocks returns the lock state of every worktree of the workspace
+func (wm *WorkspaceManager) WorktreeLocks(ctx context.Context, workspace *Workspace) ([]WorktreeLock, error) {
+	locks := make([]WorktreeLock, 0, len(workspace.Repositories))
+	for _, repo := range workspace.Repositories {
+		worktreePath := filepath.Join(workspace.Path, repo.Name)
+		locked, reason, err := wm.worktreeLockState(ctx, repo, worktreePath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get worktree state of '%s'", repo.Name)
+		}
+		locks = append(locks, WorktreeLock{Repository: repo.Name, Path: worktreePath, Locked: locked, Reason: reason})
+	}
+	return locks, nil
+}
+
+// LockWorktree locks the worktree of a workspace repository so git does not prune it, e.g. while
+// the removable drive or network share holding it is not mounted
+func (wm *WorkspaceManager) LockWorktree(ctx context.Context, workspace *Workspace, repoName, reason string) error {
+	start := time.Now()
+	repo, ok := FindRepository(workspace, repoName)
+	if !ok {
+		return errors.Errorf("repository '%s' is not in workspace '%s'", repoName, workspace.Name)
+	}
+	worktreePath := filepath.Join(workspace.Path, repo.Name)
+
+	locked, current, err := wm.worktreeLockState(ctx, repo, worktreePath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get worktree state of '%s'", repo.Name)
+	}
+	if locked {
+		return errors.Errorf("worktree of '%s' is already locked%s", repo.Name, lockReasonSuffix(current))
+	}
+
+	args := []string{"worktree", "lock"}
+	if reason != "" {
+		args = append(args, "--reason", reason)
+	}
+	args = append(args, worktreePath)
+	if _, err := gitOutput(ctx, wm.runner(), repo.Path, args...); err != nil {
+		return errors.Wrapf(err, "failed to lock worktree of '%s'", repo.Name)
+	}
+	RecordOperation("lock", workspace.Name, start, map[string]string{"repo": repo.Name, "reason": reason})
+	return nil
+}
+
+// UnlockWorktree unlocks the worktree of a workspace repository
+func (wm *WorkspaceManager) UnlockWorktree(ctx context.Context, workspace *Workspace, repoName string) error {
+	start := time.Now()
+	repo, ok := FindRepository(workspace, repoName)
+	if !ok {
+		return errors.Errorf("repository '%s' is not in workspace '%s'", repoName, workspace.Name)
+	}
+	worktreePath := filepath.Join(workspace.Path, repo.Name)
+
+	locked, _, err := wm.worktreeLockState(ctx, repo, worktreePath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get worktree state of '%s'", repo.Name)
+	}
+	if !locked {
+		return errors.Errorf("worktree of '%s' is not locked", repo.Name)
+	}
+
+	if _, err := gitOutput(ctx, wm.runner(), repo.Path, "worktree", "unlock", worktreePath); err != nil {
+		return errors.Wrapf(err, "failed to unlock worktree of '%s'", repo.Name)
+	}
+	RecordOperation("unlock", workspace.Name, start, map[string]string{"repo": repo.Name})
+	return nil
+}
+
+// worktreeRemoveArgs returns the git arguments removing a worktree. A locked worktree is only
+// removed with force, which git requires twice for locked worktrees.
+func (wm *WorkspaceManager) worktreeRemoveArgs(ctx context.Context, repo Repository, worktreePath string, force bool) ([]string, error) {
+	locked, err := wm.checkWorktreeRemovable(ctx, repo, worktreePath, force)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case locked:
+		return []string{"worktree", "remove", "--force", "--force", worktreePath}, nil
+	case force:
+		return []string{"worktree", "remove", "--force", worktreePath}, nil
+	default:
+		return []string{"worktree", "remove", worktreePath}, nil
+	}
+}
+
+// checkWorktreeRemovable returns whether the worktree is locked, and an error when it is locked
+// and force is not set
+func (wm *WorkspaceManager) checkWorktreeRemovable(ctx context.Context, repo Repository, worktreePath string, force bool) (bool, error) {
+	locked, reason, err := wm.worktreeLockState(ctx, repo, worktreePath)
+	if err != nil {
+		// Leave it to git to report a broken repository
+		return false, nil
+	}
+	if locked && !force {
+		return true, errors.Errorf("worktree of '%s' is locked%s; unlock it with 'workspace-manager worktree unlock %s' or force the removal",
+			repo.Name, lockReasonSuffix(reason), repo.Name)
+	}
+	return locked, nil
+}
+
+func lockReasonSuffix(reason string) string {
+	if reason == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (%s)", reason)
+}
+
+// autoLockWorktree locks a new worktree that lives on removable media or a network share, so an
+// unmounted drive does not make git prune it
+func (wm *WorkspaceManager) autoLockWorktree(ctx context.Context, workspace *Workspace, repo Repository) {
+	worktreePath := filepath.Join(workspace.Path, repo.Name)
+	kind := RemovableOrNetworkPath(worktreePath)
+	if kind == "" {
+		return
+	}
+	reason := fmt.Sprintf("wsm: worktree on %s", kind)
+	if _, err := gitOutput(ctx, wm.runner(), repo.Path, "worktree", "lock", "--reason", reason, worktreePath); err != nil {
+		output.PrintWarning("Failed to lock worktree of '%s' on %s: %v", repo.Name, kind, err)
+		return
+	}
+	output.PrintInfo("Locked worktree of '%s' because it is on %s", repo.Name, kind)
+}
+
+// RemovableOrNetworkPath describes the filesystem of path when it is a network share or removable
+// media ("network filesystem nfs4", "removable media"), and returns "" otherwise
+func RemovableOrNetworkPath(path string) string {
+	if runtime.GOOS == "darwin" {
+		// Additional volumes, including external drives and mounted shares, live under /Volumes
+		if strings.HasPrefix(path, "/Volumes/") {
+			return "removable media"
+		}
+		return ""
+	}
+
+	file, err := os.Open("/proc/self/mounts")
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	var mounts []mountEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 3 {
+			mounts = append(mounts, mountEntry{Point: unescapeMountPoint(fields[1]), Type: fields[2]})
+		}
+	}
+	return classifyMount(mounts, path)
+}
+
+type mountEntry struct {
+	Point string
+	Type  string
+}
+
+// classifyMount finds the mount holding path and classifies it as a network filesystem or
+// removable media (mounted under /media or /run/media)
+func classifyMount(mounts []mountEntry, path string) string {
+	var best mountEntry
+	for _, mount := range mounts {
+		if (path == mount.Point || strings.HasPrefix(path, strings.TrimSuffix(mount.Point, "/")+"/")) && len(mount.Point) > len(best.Point) {
+			best = mount
+		}
+	}
+	switch {
+	case best.Point == "":
+		return ""
+	case networkFilesystems[best.Type]:
+		return "network filesystem " + best.Type
+	case strings.HasPrefix(best.Point, "/media/") || strings.HasPrefix(best.Point, "/run/media/"):
+		return "removable media"
+	}
+	return ""
+}
+
+// unescapeMountPoint decodes the octal escapes (\040 for spaces) of /proc/self/mounts
+func unescapeMountPoint(point string) string {
+	return strings.NewReplacer(`\040`, " ", `\011`, "\t", `\012`, "\n", `\134`, `\`).Replace(point)
+}
@@ -0,0 +1,44 @@
+package wsm
+
+import "testing"
+
+func TestClassifyMount(t *testing.T) {
+	mounts := []mountEntry{
+		{Point: "/", Type: "ext4"},
+		{Point: "/home", Type: "ext4"},
+		{Point: "/mnt/share", Type: "nfs4"},
+		{Point: "/media/me/USB STICK", Type: "vfat"},
+		{Point: "/run/media/me/disk", Type: "exfat"},
+	}
+	tests := map[string]string{
+		"/home/me/ws/feat":       "",
+		"/mnt/share/ws/feat":     "network filesystem nfs4",
+		"/mnt/shared/ws":         "",
+		"/media/me/USB STICK/ws": "removable media",
+		"/run/media/me/disk":     "removable media",
+		"/srv/code":              "",
+	}
+	for path, want := range tests {
+		if got := classifyMount(mounts, path); got != want {
+			t.Errorf("classifyMount(%s) = %q, want %q", path, got, want)
+		}
+	}
+	if got := classifyMount(nil, "/home"); got != "" {
+		t.Errorf("classifyMount without mounts = %q", got)
+	}
+}
+
+func TestUnescapeMountPoint(t *testing.T) {
+	if got := unescapeMountPoint(`/media/me/USB\040STICK\134x`); got != `/media/me/USB STICK\x` {
+		t.Errorf("unescapeMountPoint = %q", got)
+	}
+}
+
+func TestLockReasonSuffix(t *testing.T) {
+	if got := lockReasonSuffix(""); got != "" {
+		t.Errorf("lockReasonSuffix(\"\") = %q", got)
+	}
+	if got := lockReasonSuffix("on a USB drive"); got != " (on a USB drive)" {
+		t.Errorf("lockReasonSuffix = %q", got)
+	}
+}